@@ -0,0 +1,416 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heartwilltell/scotty"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+	"github.com/plainq/plainq/internal/shared/pqname"
+)
+
+func accountCommand() *scotty.Command {
+	cmd := scotty.Command{
+		Name:  "account",
+		Short: "Manages service accounts of machine producers and consumers",
+	}
+
+	cmd.AddSubcommands(accountSubcommands()...)
+
+	return &cmd
+}
+
+// accountSubcommands returns subcommands of the account command.
+func accountSubcommands() []*scotty.Command {
+	return []*scotty.Command{
+		accountCreateCommand(),
+		accountListCommand(),
+		accountDeleteCommand(),
+	}
+}
+
+func accountCreateCommand() *scotty.Command {
+	var (
+		conn        connFlags
+		jsonOut     bool
+		description string
+		roles       string
+	)
+
+	cmd := scotty.Command{
+		Name:  "create",
+		Short: "Create a service account",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+			flags.StringVar(&description, "description", "",
+				"sets the description of the service account",
+			)
+			flags.StringVar(&roles, "roles", "",
+				"sets comma separated names of roles granted to the service account, e.g. 'producer'",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("service account name should be specified: plainq account create [name]")
+			}
+
+			name := args[0]
+
+			if err := pqname.ValidateServiceAccountName(name); err != nil {
+				return err
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, createErr := cli.CreateServiceAccount(ctx, &v1.CreateServiceAccountRequest{
+				Name:        name,
+				Description: description,
+				Roles:       splitRoles(roles),
+			})
+			if createErr != nil {
+				return fmt.Errorf("create service account %q: %w", name, createErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			fmt.Println(output.GetAccount().GetAccountId())
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func accountListCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "list",
+		Short: "List service accounts",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, _ []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, listErr := cli.ListServiceAccounts(ctx, &v1.ListServiceAccountsRequest{})
+			if listErr != nil {
+				return fmt.Errorf("list service accounts: %w", listErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			return writeServiceAccounts(os.Stdout, output.GetAccounts())
+		},
+	}
+
+	return &cmd
+}
+
+func accountDeleteCommand() *scotty.Command {
+	var conn connFlags
+
+	cmd := scotty.Command{
+		Name:  "delete",
+		Short: "Delete a service account along with its API keys",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("service account id should be specified: plainq account delete [account id]")
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			if _, err := cli.DeleteServiceAccount(ctx, &v1.DeleteServiceAccountRequest{AccountId: args[0]}); err != nil {
+				return fmt.Errorf("delete service account (id: %q): %w", args[0], err)
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func apiKeyCommand() *scotty.Command {
+	cmd := scotty.Command{
+		Name:  "apikey",
+		Short: "Manages API keys of service accounts",
+	}
+
+	cmd.AddSubcommands(apiKeySubcommands()...)
+
+	return &cmd
+}
+
+// apiKeySubcommands returns subcommands of the apikey command.
+func apiKeySubcommands() []*scotty.Command {
+	return []*scotty.Command{
+		apiKeyCreateCommand(),
+		apiKeyListCommand(),
+		apiKeyRevokeCommand(),
+	}
+}
+
+func apiKeyCreateCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+		name    string
+		ttl     time.Duration
+	)
+
+	cmd := scotty.Command{
+		Name:  "create",
+		Short: "Create an API key of a service account, the key is shown only once",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+			flags.StringVar(&name, "name", "",
+				"sets the name of the key, e.g. where it's used",
+			)
+			flags.DurationVar(&ttl, "ttl", 0,
+				"sets the lifetime of the key, zero means the key never expires",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("service account id should be specified: plainq apikey create [account id]")
+			}
+
+			if ttl < 0 {
+				return errors.New("ttl should not be negative")
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, createErr := cli.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+				AccountId:  args[0],
+				Name:       name,
+				TtlSeconds: uint64(ttl.Seconds()),
+			})
+			if createErr != nil {
+				return fmt.Errorf("create API key (account id: %q): %w", args[0], createErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			fmt.Fprintln(os.Stderr, "Store the key now, it can't be shown again")
+			fmt.Println(output.GetSecret())
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func apiKeyListCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "list",
+		Short: "List API keys of a service account",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("service account id should be specified: plainq apikey list [account id]")
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, listErr := cli.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{AccountId: args[0]})
+			if listErr != nil {
+				return fmt.Errorf("list API keys (account id: %q): %w", args[0], listErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			return writeAPIKeys(os.Stdout, output.GetKeys())
+		},
+	}
+
+	return &cmd
+}
+
+func apiKeyRevokeCommand() *scotty.Command {
+	var conn connFlags
+
+	cmd := scotty.Command{
+		Name:  "revoke",
+		Short: "Revoke an API key, which is rejected from then on",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("key id should be specified: plainq apikey revoke [key id]")
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			if _, err := cli.RevokeAPIKey(ctx, &v1.RevokeAPIKeyRequest{KeyId: args[0]}); err != nil {
+				return fmt.Errorf("revoke API key (id: %q): %w", args[0], err)
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+// splitRoles splits comma separated role names, dropping empty ones.
+func splitRoles(roles string) []string {
+	var names []string
+
+	for _, name := range strings.Split(roles, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// writeServiceAccounts writes service accounts to w as an aligned table.
+func writeServiceAccounts(w io.Writer, accounts []*v1.ServiceAccount) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ID\tNAME\tROLES\tDESCRIPTION")
+
+	for _, a := range accounts {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			a.GetAccountId(),
+			a.GetName(),
+			strings.Join(a.GetRoles(), ","),
+			a.GetDescription(),
+		)
+	}
+
+	return tw.Flush()
+}
+
+// writeAPIKeys writes API keys to w as an aligned table.
+func writeAPIKeys(w io.Writer, keys []*v1.APIKey) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ID\tPREFIX\tNAME\tCREATED\tEXPIRES\tSTATUS")
+
+	for _, k := range keys {
+		expires, status := "never", "active"
+
+		if k.GetExpiresAt() != nil {
+			expires = k.GetExpiresAt().AsTime().Local().Format(time.DateTime)
+
+			if k.GetExpiresAt().AsTime().Before(time.Now()) {
+				status = "expired"
+			}
+		}
+
+		if k.GetRevokedAt() != nil {
+			status = "revoked"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			k.GetKeyId(),
+			k.GetPrefix(),
+			k.GetName(),
+			k.GetCreatedAt().AsTime().Local().Format(time.DateTime),
+			expires,
+			status,
+		)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heartwilltell/scotty"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func auditCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		actor   string
+		action  string
+		target  string
+		since   time.Duration
+		cursor  string
+		limit   uint
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "audit",
+		Short: "Show recorded mutations of queues and messages, newest first",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.StringVar(&actor, "actor", "",
+				"shows only events made by the given identity",
+			)
+			flags.StringVar(&action, "action", "",
+				"shows only events of the given action, e.g. queue.delete",
+			)
+			flags.StringVar(&target, "target", "",
+				"shows only events on the given target, e.g. the queue id",
+			)
+			flags.DurationVar(&since, "since", 0,
+				"shows only events recorded within the given duration, e.g. 24h",
+			)
+			flags.StringVar(&cursor, "cursor", "",
+				"sets the cursor of the page to start from",
+			)
+			flags.UintVar(&limit, "limit", 20,
+				"sets the maximum number of returned events",
+			)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, _ []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if limit > math.MaxUint32 {
+				return fmt.Errorf("limit value too large: %d", limit)
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			input := v1.ListAuditEventsRequest{
+				Actor:  actor,
+				Action: action,
+				Target: target,
+				Cursor: cursor,
+				Limit:  uint32(limit),
+			}
+
+			if since > 0 {
+				input.From = timestamppb.New(time.Now().Add(-since))
+			}
+
+			events, listErr := cli.ListAuditEvents(ctx, &input)
+			if listErr != nil {
+				return fmt.Errorf("list audit events: %w", listErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, events); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			if err := writeAuditEvents(os.Stdout, events.GetEvents()); err != nil {
+				return err
+			}
+
+			if next := events.GetNextCursor(); next != "" {
+				fmt.Fprintf(os.Stderr, "More events available, use --cursor=%s to see the next page\n", next)
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+// writeAuditEvents writes audit events to w as an aligned table.
+func writeAuditEvents(w io.Writer, events []*v1.AuditEvent) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "TIME\tACTOR\tACTION\tTARGET\tDETAIL")
+
+	for _, e := range events {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			e.GetCreatedAt().AsTime().Local().Format(time.DateTime),
+			e.GetActor(),
+			e.GetAction(),
+			e.GetTarget(),
+			e.GetDetail(),
+		)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heartwilltell/scotty"
+	"github.com/plainq/plainq/internal/client"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/idkit"
+)
+
+// benchCleanupTimeout limits the time of the benchmark queue deletion.
+const benchCleanupTimeout = 10 * time.Second
+
+func benchCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+
+		size      uint
+		batch     uint
+		producers uint
+		consumers uint
+		duration  time.Duration
+	)
+
+	cmd := scotty.Command{
+		Name:  "bench",
+		Short: "Run send/receive/delete load against the server and report throughput and latency",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+
+			flags.UintVar(&size, "size", 1024,
+				"sets the message body size in bytes",
+			)
+
+			flags.UintVar(&batch, "batch", 10,
+				"sets the number of messages per send and receive request",
+			)
+
+			flags.UintVar(&producers, "producers", 4,
+				"sets the number of concurrent producers",
+			)
+
+			flags.UintVar(&consumers, "consumers", 4,
+				"sets the number of concurrent consumers, which receive and delete messages",
+			)
+
+			flags.DurationVar(&duration, "duration", 30*time.Second,
+				"sets the duration of the benchmark",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			switch {
+			case batch == 0 || batch > math.MaxUint32:
+				return fmt.Errorf("invalid batch size: %d", batch)
+
+			case producers == 0 && consumers == 0:
+				return errors.New("at least one producer or consumer should be specified")
+
+			case duration <= 0:
+				return fmt.Errorf("invalid duration: %s", duration)
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			// The benchmark runs against the given queue,
+			// or against a temporary queue which is deleted afterward.
+			var queueID string
+
+			if len(args) > 0 {
+				queueID = args[0]
+
+				if err := validateQueueID(queueID); err != nil {
+					return err
+				}
+			} else {
+				created, createErr := cli.CreateQueue(ctx, &v1.CreateQueueRequest{
+					QueueName:      "bench-" + idkit.XID(),
+					EvictionPolicy: v1.EvictionPolicy_EVICTION_POLICY_DROP,
+				})
+				if createErr != nil {
+					return fmt.Errorf("create benchmark queue: %w", createErr)
+				}
+
+				queueID = created.GetQueueId()
+
+				defer func() {
+					cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), benchCleanupTimeout)
+					defer cleanupCancel()
+
+					if _, err := cli.DeleteQueue(cleanupCtx, &v1.DeleteQueueRequest{QueueId: queueID, Force: true}); err != nil {
+						fmt.Fprintf(os.Stderr, "Failed to delete benchmark queue (id: %q): %v\n", queueID, err)
+					}
+				}()
+			}
+
+			b := bench{
+				cli:       cli,
+				queueID:   queueID,
+				body:      bytes.Repeat([]byte("x"), int(size)),
+				batch:     uint32(batch),
+				producers: int(producers),
+				consumers: int(consumers),
+			}
+
+			if !jsonOut {
+				fmt.Fprintf(os.Stderr, "Running benchmark against queue %q for %s...\n", queueID, duration)
+			}
+
+			benchCtx, benchCancel := context.WithTimeout(ctx, duration)
+			defer benchCancel()
+
+			report := b.run(benchCtx)
+
+			if jsonOut {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+
+				if err := enc.Encode(report); err != nil {
+					return fmt.Errorf("encode report: %w", err)
+				}
+
+				return nil
+			}
+
+			return report.write(os.Stdout)
+		},
+	}
+
+	return &cmd
+}
+
+// bench drives the load against the queue.
+type bench struct {
+	cli       *client.Client
+	queueID   string
+	body      []byte
+	batch     uint32
+	producers int
+	consumers int
+}
+
+// run runs producers and consumers until the ctx is done.
+func (b *bench) run(ctx context.Context) benchReport {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		start = time.Now()
+
+		send, receive, del benchStats
+	)
+
+	for range b.producers {
+		wg.Go(func() {
+			var s benchStats
+
+			b.produce(ctx, &s)
+
+			mu.Lock()
+			send.merge(&s)
+			mu.Unlock()
+		})
+	}
+
+	for range b.consumers {
+		wg.Go(func() {
+			var r, d benchStats
+
+			b.consume(ctx, &r, &d)
+
+			mu.Lock()
+			receive.merge(&r)
+			del.merge(&d)
+			mu.Unlock()
+		})
+	}
+
+	wg.Wait()
+
+	elapsed := time.Since(start)
+
+	report := benchReport{
+		QueueID:  b.queueID,
+		Duration: elapsed.String(),
+		Operations: []benchOperation{
+			send.operation("send", elapsed),
+			receive.operation("receive", elapsed),
+			del.operation("delete", elapsed),
+		},
+	}
+
+	return report
+}
+
+func (b *bench) produce(ctx context.Context, s *benchStats) {
+	messages := make([]*v1.SendMessage, b.batch)
+
+	for i := range messages {
+		messages[i] = &v1.SendMessage{Body: b.body}
+	}
+
+	for ctx.Err() == nil {
+		start := time.Now()
+
+		_, err := b.cli.Send(ctx, &v1.SendRequest{QueueId: b.queueID, Messages: messages})
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.record(start, len(messages), err)
+	}
+}
+
+func (b *bench) consume(ctx context.Context, r, d *benchStats) {
+	for ctx.Err() == nil {
+		start := time.Now()
+
+		received, receiveErr := b.cli.Receive(ctx, &v1.ReceiveRequest{QueueId: b.queueID, BatchSize: b.batch})
+		if ctx.Err() != nil {
+			return
+		}
+
+		r.record(start, len(received.GetMessages()), receiveErr)
+
+		if len(received.GetMessages()) == 0 {
+			r.empty++
+
+			// Give producers a chance to fill the queue.
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-time.After(10 * time.Millisecond):
+				continue
+			}
+		}
+
+		handles := make([]string, 0, len(received.GetMessages()))
+
+		for _, m := range received.GetMessages() {
+			handles = append(handles, m.GetReceiptHandle())
+		}
+
+		start = time.Now()
+
+		_, deleteErr := b.cli.Delete(ctx, &v1.DeleteRequest{QueueId: b.queueID, ReceiptHandles: handles})
+		if ctx.Err() != nil {
+			return
+		}
+
+		d.record(start, len(handles), deleteErr)
+	}
+}
+
+// benchStats holds measurements of an operation.
+type benchStats struct {
+	requests  uint64
+	messages  uint64
+	errors    uint64
+	empty     uint64
+	latencies []time.Duration
+}
+
+// record records the request which has been started at the start.
+func (s *benchStats) record(start time.Time, messages int, err error) {
+	s.requests++
+
+	if err != nil {
+		s.errors++
+		return
+	}
+
+	s.messages += uint64(messages)
+	s.latencies = append(s.latencies, time.Since(start))
+}
+
+// merge adds measurements of another benchStats.
+func (s *benchStats) merge(other *benchStats) {
+	s.requests += other.requests
+	s.messages += other.messages
+	s.errors += other.errors
+	s.empty += other.empty
+	s.latencies = append(s.latencies, other.latencies...)
+}
+
+// operation summarizes measurements of the operation.
+func (s *benchStats) operation(name string, elapsed time.Duration) benchOperation {
+	latencies := slices.Clone(s.latencies)
+	slices.Sort(latencies)
+
+	op := benchOperation{
+		Name:          name,
+		Requests:      s.requests,
+		Messages:      s.messages,
+		Errors:        s.errors,
+		EmptyReceives: s.empty,
+		P50:           percentile(latencies, 0.5),
+		P90:           percentile(latencies, 0.9),
+		P99:           percentile(latencies, 0.99),
+	}
+
+	if len(latencies) > 0 {
+		op.Max = latencies[len(latencies)-1]
+	}
+
+	if elapsed > 0 {
+		op.MessagesPerSecond = float64(s.messages) / elapsed.Seconds()
+	}
+
+	return op
+}
+
+// percentile returns the nearest-rank p-percentile (0 < p <= 1) of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[min(rank, len(sorted))-1]
+}
+
+// benchReport holds the benchmark results.
+type benchReport struct {
+	QueueID    string           `json:"queueId"`
+	Duration   string           `json:"duration"`
+	Operations []benchOperation `json:"operations"`
+}
+
+// benchOperation holds the results of an operation.
+type benchOperation struct {
+	Name              string        `json:"name"`
+	Requests          uint64        `json:"requests"`
+	Messages          uint64        `json:"messages"`
+	Errors            uint64        `json:"errors"`
+	EmptyReceives     uint64        `json:"emptyReceives,omitempty"`
+	MessagesPerSecond float64       `json:"messagesPerSecond"`
+	P50               time.Duration `json:"p50"`
+	P90               time.Duration `json:"p90"`
+	P99               time.Duration `json:"p99"`
+	Max               time.Duration `json:"max"`
+}
+
+// write writes the report to w as an aligned table.
+func (r *benchReport) write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "OP\tREQUESTS\tMESSAGES\tMSG/S\tP50\tP90\tP99\tMAX\tERRORS")
+
+	for _, op := range r.Operations {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\t%d\n",
+			op.Name,
+			op.Requests,
+			op.Messages,
+			op.MessagesPerSecond,
+			op.P50.Round(time.Microsecond),
+			op.P90.Round(time.Microsecond),
+			op.P99.Round(time.Microsecond),
+			op.Max.Round(time.Microsecond),
+			op.Errors,
+		)
+	}
+
+	for _, op := range r.Operations {
+		if op.EmptyReceives > 0 {
+			fmt.Fprintf(tw, "\nEmpty receives:\t%d\n", op.EmptyReceives)
+		}
+	}
+
+	return tw.Flush()
+}
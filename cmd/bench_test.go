@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 0, 100)
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+
+	tests := map[string]struct {
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		"Empty":  {sorted: nil, p: 0.5, want: 0},
+		"Single": {sorted: []time.Duration{time.Second}, p: 0.99, want: time.Second},
+		"P50":    {sorted: sorted, p: 0.5, want: 50 * time.Millisecond},
+		"P90":    {sorted: sorted, p: 0.9, want: 90 * time.Millisecond},
+		"P99":    {sorted: sorted, p: 0.99, want: 99 * time.Millisecond},
+		"P100":   {sorted: sorted, p: 1, want: 100 * time.Millisecond},
+		"Zero":   {sorted: sorted, p: 0, want: time.Millisecond},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, percentile(tc.sorted, tc.p), tc.want)
+		})
+	}
+}
+
+func TestBenchStats(t *testing.T) {
+	var a, b benchStats
+
+	start := time.Now()
+
+	a.record(start, 10, nil)
+	a.record(start, 10, errors.New("unavailable"))
+	b.record(start, 5, nil)
+	b.empty++
+
+	a.merge(&b)
+
+	op := a.operation("receive", 2*time.Second)
+
+	td.Cmp(t, op.Name, "receive")
+	td.Cmp(t, op.Requests, uint64(3))
+	td.Cmp(t, op.Messages, uint64(15))
+	td.Cmp(t, op.Errors, uint64(1))
+	td.Cmp(t, op.EmptyReceives, uint64(1))
+	td.Cmp(t, op.MessagesPerSecond, 7.5)
+	td.Cmp(t, len(a.latencies), 2)
+	td.Cmp(t, op.Max >= op.P50, true)
+}
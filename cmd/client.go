@@ -1,31 +1,52 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/heartwilltell/scotty"
-	"github.com/plainq/plainq/internal/client"
 	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+	"github.com/plainq/plainq/internal/shared/pqname"
 	"github.com/plainq/servekit/idkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const (
 	// defaultLimit represents the default limit for listing queues.
-	defaultLimit = 500
+	defaultLimit = 20
+
+	// maxLimit represents the maximum limit for listing queues.
+	maxLimit = 100
 )
 
+// validateQueueID validates the queue identifier. The server issues identifiers
+// in upper case, while XIDs are validated in lower case.
+func validateQueueID(id string) error {
+	return idkit.ValidateXID(strings.ToLower(id))
+}
+
 func listQueueCommand() *scotty.Command {
 	var (
-		addr string
+		conn connFlags
 
 		limit   uint
+		cursor  string
+		prefix  string
+		all     bool
 		jsonOut bool
 	)
 
@@ -33,9 +54,7 @@ func listQueueCommand() *scotty.Command {
 		Name:  "list",
 		Short: "List queues",
 		SetFlags: func(flags *scotty.FlagSet) {
-			flags.StringVar(&addr, "grpc.addr", "localhost:8080",
-				"sets PlainQ gRPC address.",
-			)
+			conn.register(flags)
 
 			flags.BoolVar(&jsonOut, "json", false,
 				"enables json output",
@@ -44,88 +63,317 @@ func listQueueCommand() *scotty.Command {
 			flags.UintVar(&limit, "limit", defaultLimit,
 				"sets pages size for pagination",
 			)
+
+			flags.StringVar(&cursor, "cursor", "",
+				"sets the cursor to start listing from",
+			)
+
+			flags.StringVar(&prefix, "prefix", "",
+				"lists only queues which names start with the prefix",
+			)
+
+			flags.BoolVar(&all, "all", false,
+				"lists all pages without asking",
+			)
 		},
 		Run: func(_ *scotty.Command, _ []string) error {
 			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 			defer cancel()
 
-			cli, cliErr := client.New(addr)
+			cli, cliErr := newClient(&conn)
 			if cliErr != nil {
 				return fmt.Errorf("create client: %w", cliErr)
 			}
 
-			if limit > math.MaxInt32 {
-				return fmt.Errorf("limit value too large: %d", limit)
+			if limit == 0 || limit > maxLimit {
+				return fmt.Errorf("limit should be between 1 and %d: %d", maxLimit, limit)
 			}
+
 			in := &v1.ListQueuesRequest{
-				Limit: int32(limit),
+				QueuePrefix:  prefix,
+				Limit:        int32(limit),
+				Cursor:       cursor,
+				IncludeStats: true,
 			}
 
-			list, listErr := cli.ListQueues(ctx, in)
-			if listErr != nil {
-				return fmt.Errorf("list queues: %w", listErr)
-			}
+			interactive := isTerminal(os.Stdin) && isTerminal(os.Stdout)
+			stdin := bufio.NewReader(os.Stdin)
 
-			if jsonOut {
-				if err := json.NewEncoder(os.Stdout).Encode(list); err != nil {
-					return fmt.Errorf("encode queue list: %w", err)
+			for page := 0; ; page++ {
+				list, listErr := cli.ListQueues(ctx, in)
+				if listErr != nil {
+					return fmt.Errorf("list queues: %w", listErr)
 				}
 
-				return nil
-			}
+				if jsonOut {
+					if err := pqjson.Encode(os.Stdout, list); err != nil {
+						return fmt.Errorf("encode queue list: %w", err)
+					}
+				} else if err := writeQueueTable(os.Stdout, list.GetQueues(), page == 0); err != nil {
+					return fmt.Errorf("write queue list: %w", err)
+				}
 
-			for _, q := range list.GetQueues() {
-				fmt.Println(q.GetQueueId(), "|", q.GetQueueName())
-			}
+				if !list.GetHasMore() {
+					return nil
+				}
 
-			// TODO: ask for pagination.
+				in.Cursor = list.GetNextCursor()
 
-			return nil
+				if all {
+					continue
+				}
+
+				if !interactive || jsonOut {
+					fmt.Fprintf(os.Stderr, "More queues available, use --cursor=%s to list the next page.\n", in.Cursor)
+					return nil
+				}
+
+				if !askMore(stdin, os.Stderr) {
+					return nil
+				}
+			}
 		},
 	}
 
 	return &cmd
 }
 
+// writeQueueTable writes queues to w as an aligned table.
+func writeQueueTable(w io.Writer, queues []*v1.DescribeQueueResponse, header bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if header {
+		fmt.Fprintln(tw, "ID\tNAME\tCREATED\tSTATE\tDEPTH\tPOLICY")
+	}
+
+	for _, q := range queues {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			q.GetQueueId(),
+			q.GetQueueName(),
+			q.GetCreatedAt().AsTime().Local().Format(time.DateTime),
+			stateName(q.GetState()),
+			q.GetDepth(),
+			policyName(q.GetEvictionPolicy()),
+		)
+	}
+
+	return tw.Flush()
+}
+
+// policyName returns the eviction policy name in the form accepted by the create command.
+func policyName(p v1.EvictionPolicy) string {
+	switch p {
+	case v1.EvictionPolicy_EVICTION_POLICY_DROP:
+		return "drop"
+
+	case v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER:
+		return "dead-letter"
+
+	case v1.EvictionPolicy_EVICTION_POLICY_ARCHIVE:
+		return "archive"
+
+	default:
+		return "unspecified"
+	}
+}
+
+// askMore asks whether the next page should be listed.
+// Empty answer means yes.
+func askMore(r *bufio.Reader, w io.Writer) bool {
+	fmt.Fprint(w, "-- More? [Y/n] ")
+
+	answer, err := r.ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+		return true
+
+	default:
+		return false
+	}
+}
+
+// isTerminal reports whether the file is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// queueTemplate holds a preset of queue properties which
+// can be used as a base for the queue creation.
+type queueTemplate struct {
+	retentionPeriod    time.Duration
+	visibilityTimeout  time.Duration
+	maxReceiveAttempts uint32
+	dropPolicy         string
+	fifo               bool
+	delay              time.Duration
+	maxSize            uint64
+}
+
+// queueTemplates holds available queue templates by name.
+var queueTemplates = map[string]queueTemplate{
+	"default": {
+		visibilityTimeout:  30 * time.Second,
+		maxReceiveAttempts: 5,
+		dropPolicy:         "drop",
+	},
+	"task": {
+		retentionPeriod:    7 * 24 * time.Hour,
+		visibilityTimeout:  5 * time.Minute,
+		maxReceiveAttempts: 10,
+		dropPolicy:         "dead-letter",
+	},
+	"event": {
+		retentionPeriod:    24 * time.Hour,
+		visibilityTimeout:  30 * time.Second,
+		maxReceiveAttempts: 3,
+		dropPolicy:         "drop",
+		maxSize:            256 << 10,
+	},
+	"fifo": {
+		retentionPeriod:    4 * 24 * time.Hour,
+		visibilityTimeout:  time.Minute,
+		maxReceiveAttempts: 5,
+		dropPolicy:         "dead-letter",
+		fifo:               true,
+	},
+}
+
+// tagsFlag implements flag.Value for repeatable key=value flags of tags and attributes.
+type tagsFlag map[string]string
+
+func (t tagsFlag) String() string {
+	pairs := make([]string, 0, len(t))
+
+	for k, v := range t {
+		pairs = append(pairs, k+"="+v)
+	}
+
+	slices.Sort(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+func (t tagsFlag) Set(value string) error {
+	k, v, ok := strings.Cut(value, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("value should be in key=value format: %q", value)
+	}
+
+	t[k] = v
+
+	return nil
+}
+
 func createQueueCommand() *scotty.Command {
 	var (
-		addr    string
+		conn    connFlags
 		jsonOut bool
-
-		retentionPeriodSeconds   uint
-		visibilityTimeoutSeconds uint
-		maxReceiveAttempts       uint
-		dropPolicy               string
-		deadLetterQueueID        string
+		dryRun  bool
+
+		template           string
+		retentionPeriod    time.Duration
+		visibilityTimeout  time.Duration
+		maxReceiveAttempts uint
+		dropPolicy         string
+		deadLetterQueueID  string
+		description        string
+		tags               = tagsFlag{}
+		fifo               bool
+		delay              time.Duration
+		maxSize            uint64
+		searchIndex        bool
+		rateLimit          uint
+		rateLimitBurst     uint
+		maxMessages        uint64
+		maxBytes           uint64
+		quotaPolicy        string
+		retryBackoff       time.Duration
+		retryMultiplier    float64
+		retryMaxBackoff    time.Duration
 	)
 
 	cmd := scotty.Command{
 		Name:  "create",
 		Short: "Create a queue",
 		SetFlags: func(flags *scotty.FlagSet) {
-			flags.StringVar(&addr, "grpc.addr", "localhost:8080",
-				"sets PlainQ gRPC address.",
-			)
+			conn.register(flags)
 			flags.BoolVar(&jsonOut, "json", false,
 				"enables json output",
 			)
-			flags.UintVar(&retentionPeriodSeconds, "retention-period", 0,
-				"",
+			flags.BoolVar(&dryRun, "dry-run", false,
+				"prints the resolved create request without creating the queue",
+			)
+			flags.StringVar(&template, "template", "default",
+				"sets the preset of queue properties: default, task, event or fifo; explicitly set flags take precedence",
+			)
+			flags.DurationVar(&retentionPeriod, "retention-period", 0,
+				"sets how long messages are kept in the queue, zero keeps them forever",
 			)
-			flags.UintVar(&visibilityTimeoutSeconds, "visibility-timeout", 30,
-				"",
+			flags.DurationVar(&visibilityTimeout, "visibility-timeout", 0,
+				"sets how long a received message stays invisible for other consumers",
 			)
-			flags.UintVar(&maxReceiveAttempts, "max-receive-attempts", 5,
-				"",
+			flags.UintVar(&maxReceiveAttempts, "max-receive-attempts", 0,
+				"sets how many times a message can be received before the drop policy is applied",
 			)
-			flags.StringVar(&dropPolicy, "drop-policy", "drop",
-				"",
+			flags.StringVar(&dropPolicy, "drop-policy", "",
+				`sets what happens with a message after the last receive attempt: "drop", "dead-letter" or "archive"`,
 			)
 			flags.StringVar(&deadLetterQueueID, "dead-letter-queue-id", "",
-				"",
+				`sets the queue to move messages to when drop policy is "dead-letter"`,
+			)
+			flags.StringVar(&description, "description", "",
+				"sets the queue description",
+			)
+			flags.Var(tags, "tag",
+				"adds a key=value tag to the queue, can be repeated",
+			)
+			flags.BoolVar(&fifo, "fifo", false,
+				"enables first-in-first-out delivery: the next message is not delivered until the previous is deleted",
+			)
+			flags.DurationVar(&delay, "delay", 0,
+				"sets how long new messages stay invisible after being sent",
+			)
+			flags.Uint64Var(&maxSize, "max-size", 0,
+				"sets the maximum message size in bytes, zero means no limit",
+			)
+			flags.BoolVar(&searchIndex, "search-index", false,
+				"indexes message bodies for the search, which makes sends and deletes slower",
+			)
+			flags.UintVar(&rateLimit, "rate-limit", 0,
+				"sets the number of requests per second allowed to the queue, zero means no limit",
+			)
+			flags.UintVar(&rateLimitBurst, "rate-limit-burst", 0,
+				"sets the number of requests allowed to the queue in a burst, defaults to the rate limit",
+			)
+			flags.Uint64Var(&maxMessages, "max-messages", 0,
+				"sets the maximum number of messages stored in the queue, zero means no limit",
+			)
+			flags.Uint64Var(&maxBytes, "max-bytes", 0,
+				"sets the maximum total size of message bodies stored in the queue in bytes, zero means no limit",
+			)
+			flags.StringVar(&quotaPolicy, "quota-policy", "reject",
+				`sets what happens when a send exceeds the queue quota: "reject" or "evict" the oldest messages`,
+			)
+			flags.DurationVar(&retryBackoff, "retry-backoff", 0,
+				"sets how much longer than the visibility timeout a message stays invisible after the first receive, zero disables the backoff",
+			)
+			flags.Float64Var(&retryMultiplier, "retry-multiplier", 2,
+				"sets the factor the retry backoff grows by after each receive",
+			)
+			flags.DurationVar(&retryMaxBackoff, "retry-max-backoff", 0,
+				"sets the upper bound of the retry backoff, zero means no bound",
 			)
 		},
-		Run: func(_ *scotty.Command, args []string) error {
+		Run: func(cmd *scotty.Command, args []string) error {
 			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 			defer cancel()
 
@@ -135,9 +383,45 @@ func createQueueCommand() *scotty.Command {
 
 			name := args[0]
 
-			cli, cliErr := client.New(addr)
-			if cliErr != nil {
-				return fmt.Errorf("create client: %w", cliErr)
+			if err := pqname.ValidateQueueName(name); err != nil {
+				return fmt.Errorf("validate queue name: %w", err)
+			}
+
+			preset, ok := queueTemplates[template]
+			if !ok {
+				return fmt.Errorf(`unknown template: %q, should be one of: ["default", "task", "event", "fifo"]`, template)
+			}
+
+			// Apply template values to the flags which haven't been set explicitly.
+			set := make(map[string]bool)
+			cmd.Flags().Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+			if !set["retention-period"] {
+				retentionPeriod = preset.retentionPeriod
+			}
+
+			if !set["visibility-timeout"] {
+				visibilityTimeout = preset.visibilityTimeout
+			}
+
+			if !set["max-receive-attempts"] {
+				maxReceiveAttempts = uint(preset.maxReceiveAttempts)
+			}
+
+			if !set["drop-policy"] {
+				dropPolicy = preset.dropPolicy
+			}
+
+			if !set["fifo"] {
+				fifo = preset.fifo
+			}
+
+			if !set["delay"] {
+				delay = preset.delay
+			}
+
+			if !set["max-size"] {
+				maxSize = preset.maxSize
 			}
 
 			var queueDropPolicy v1.EvictionPolicy
@@ -149,21 +433,86 @@ func createQueueCommand() *scotty.Command {
 			case "drop":
 				queueDropPolicy = v1.EvictionPolicy_EVICTION_POLICY_DROP
 
+			case "archive":
+				queueDropPolicy = v1.EvictionPolicy_EVICTION_POLICY_ARCHIVE
+
 			default:
-				return fmt.Errorf(`unknown drop policy: %q, should be on of: ["dead-letter", "drop"]`, dropPolicy)
+				return fmt.Errorf(`unknown drop policy: %q, should be on of: ["dead-letter", "drop", "archive"]`, dropPolicy)
+			}
+
+			var queueQuotaPolicy v1.QuotaPolicy
+
+			switch strings.ToLower(quotaPolicy) {
+			case "reject":
+				queueQuotaPolicy = v1.QuotaPolicy_QUOTA_POLICY_REJECT
+
+			case "evict":
+				queueQuotaPolicy = v1.QuotaPolicy_QUOTA_POLICY_EVICT
+
+			default:
+				return fmt.Errorf(`unknown quota policy: %q, should be on of: ["reject", "evict"]`, quotaPolicy)
 			}
 
 			if maxReceiveAttempts > math.MaxUint32 {
 				return fmt.Errorf("max receive attempts value too large: %d", maxReceiveAttempts)
 			}
 
+			if rateLimit > math.MaxUint32 || rateLimitBurst > math.MaxUint32 {
+				return fmt.Errorf("rate limit value too large: %d:%d", rateLimit, rateLimitBurst)
+			}
+
+			if retentionPeriod < 0 || visibilityTimeout < 0 || delay < 0 || retryBackoff < 0 || retryMaxBackoff < 0 {
+				return errors.New("durations should not be negative")
+			}
+
 			in := &v1.CreateQueueRequest{
 				QueueName:                name,
-				RetentionPeriodSeconds:   uint64(retentionPeriodSeconds),
-				VisibilityTimeoutSeconds: uint64(visibilityTimeoutSeconds),
+				RetentionPeriodSeconds:   uint64(retentionPeriod.Seconds()),
+				VisibilityTimeoutSeconds: uint64(visibilityTimeout.Seconds()),
 				MaxReceiveAttempts:       uint32(maxReceiveAttempts),
 				EvictionPolicy:           queueDropPolicy,
 				DeadLetterQueueId:        deadLetterQueueID,
+				Description:              description,
+				Tags:                     tags,
+				Fifo:                     fifo,
+				DelaySeconds:             uint64(delay.Seconds()),
+				MaxMessageSizeBytes:      maxSize,
+				SearchIndex:              searchIndex,
+				RateLimit:                uint32(rateLimit),
+				RateLimitBurst:           uint32(rateLimitBurst),
+				MaxMessages:              maxMessages,
+				MaxBytes:                 maxBytes,
+				QuotaPolicy:              queueQuotaPolicy,
+			}
+
+			if retryBackoff > 0 {
+				in.RetryPolicy = &v1.RetryPolicy{
+					InitialBackoffSeconds: uint64(retryBackoff.Seconds()),
+					Multiplier:            retryMultiplier,
+					MaxBackoffSeconds:     uint64(retryMaxBackoff.Seconds()),
+				}
+			}
+
+			if dryRun {
+				data, marshalErr := pqjson.Marshal(in)
+				if marshalErr != nil {
+					return fmt.Errorf("encode request: %w", marshalErr)
+				}
+
+				var out bytes.Buffer
+
+				if err := json.Indent(&out, data, "", "  "); err != nil {
+					return fmt.Errorf("encode request: %w", err)
+				}
+
+				fmt.Println(out.String())
+
+				return nil
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
 			}
 
 			create, createErr := cli.CreateQueue(ctx, in)
@@ -172,7 +521,7 @@ func createQueueCommand() *scotty.Command {
 			}
 
 			if jsonOut {
-				if err := json.NewEncoder(os.Stdout).Encode(create); err != nil {
+				if err := pqjson.Encode(os.Stdout, create); err != nil {
 					return fmt.Errorf("encode response: %w", err)
 				}
 
@@ -190,7 +539,7 @@ func createQueueCommand() *scotty.Command {
 
 func describeQueueCommand() *scotty.Command {
 	var (
-		addr    string
+		conn    connFlags
 		jsonOut bool
 	)
 
@@ -198,9 +547,7 @@ func describeQueueCommand() *scotty.Command {
 		Name:  "describe",
 		Short: "describe a queue",
 		SetFlags: func(flags *scotty.FlagSet) {
-			flags.StringVar(&addr, "grpc.addr", "localhost:8080",
-				"sets PlainQ gRPC address.",
-			)
+			conn.register(flags)
 
 			flags.BoolVar(&jsonOut, "json", false,
 				"enables json output",
@@ -216,11 +563,11 @@ func describeQueueCommand() *scotty.Command {
 
 			id := args[0]
 
-			if err := idkit.ValidateXID(id); err != nil {
+			if err := validateQueueID(id); err != nil {
 				return err
 			}
 
-			cli, cliErr := client.New(addr)
+			cli, cliErr := newClient(&conn)
 			if cliErr != nil {
 				return fmt.Errorf("create client: %w", cliErr)
 			}
@@ -235,7 +582,7 @@ func describeQueueCommand() *scotty.Command {
 			}
 
 			if jsonOut {
-				if err := json.NewEncoder(os.Stdout).Encode(purge); err != nil {
+				if err := pqjson.Encode(os.Stdout, purge); err != nil {
 					return fmt.Errorf("encode response: %w", err)
 				}
 
@@ -251,20 +598,30 @@ func describeQueueCommand() *scotty.Command {
 
 func purgeQueueCommand() *scotty.Command {
 	var (
-		addr    string
-		jsonOut bool
+		conn        connFlags
+		jsonOut     bool
+		olderThan   time.Duration
+		minAttempts uint
+		async       bool
 	)
 
 	cmd := scotty.Command{
 		Name:  "purge",
 		Short: "Purge a queue",
 		SetFlags: func(flags *scotty.FlagSet) {
-			flags.StringVar(&addr, "grpc.addr", "localhost:8080",
-				"sets PlainQ gRPC address.",
-			)
+			conn.register(flags)
 			flags.BoolVar(&jsonOut, "json", false,
 				"enables json output",
 			)
+			flags.DurationVar(&olderThan, "older-than", 0,
+				"purges only messages sent longer ago than the duration",
+			)
+			flags.UintVar(&minAttempts, "min-attempts", 0,
+				"purges only messages received at least the number of times",
+			)
+			flags.BoolVar(&async, "async", false,
+				"purges messages in batches by a background job and prints the job id, see plainq jobs get --watch",
+			)
 		},
 		Run: func(_ *scotty.Command, args []string) error {
 			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
@@ -276,17 +633,23 @@ func purgeQueueCommand() *scotty.Command {
 
 			id := args[0]
 
-			if err := idkit.ValidateXID(id); err != nil {
+			if err := validateQueueID(id); err != nil {
 				return err
 			}
 
-			cli, cliErr := client.New(addr)
+			cli, cliErr := newClient(&conn)
 			if cliErr != nil {
 				return fmt.Errorf("create client: %w", cliErr)
 			}
 
 			in := &v1.PurgeQueueRequest{
-				QueueId: id,
+				QueueId:     id,
+				MinAttempts: uint32(minAttempts),
+				Async:       async,
+			}
+
+			if olderThan > 0 {
+				in.OlderThan = timestamppb.New(time.Now().Add(-olderThan))
 			}
 
 			purge, purgeErr := cli.PurgeQueue(ctx, in)
@@ -295,13 +658,20 @@ func purgeQueueCommand() *scotty.Command {
 			}
 
 			if jsonOut {
-				if err := json.NewEncoder(os.Stdout).Encode(purge); err != nil {
+				if err := pqjson.Encode(os.Stdout, purge); err != nil {
 					return fmt.Errorf("encode response: %w", err)
 				}
 
 				return nil
 			}
 
+			if async {
+				fmt.Println(purge.GetJobId(), "|", "started")
+				return nil
+			}
+
+			fmt.Println(purge.GetMessagesCount(), "|", "purged")
+
 			return nil
 		},
 	}
@@ -311,7 +681,7 @@ func purgeQueueCommand() *scotty.Command {
 
 func deleteQueueCommand() *scotty.Command {
 	var (
-		addr string
+		conn connFlags
 
 		force   bool
 		jsonOut bool
@@ -321,9 +691,7 @@ func deleteQueueCommand() *scotty.Command {
 		Name:  "delete",
 		Short: "Delete a queue",
 		SetFlags: func(flags *scotty.FlagSet) {
-			flags.StringVar(&addr, "grpc.addr", "localhost:8080",
-				"sets PlainQ gRPC address.",
-			)
+			conn.register(flags)
 			flags.BoolVar(&jsonOut, "json", false,
 				"enables json output",
 			)
@@ -341,11 +709,11 @@ func deleteQueueCommand() *scotty.Command {
 
 			id := args[0]
 
-			if err := idkit.ValidateXID(id); err != nil {
+			if err := validateQueueID(id); err != nil {
 				return err
 			}
 
-			cli, cliErr := client.New(addr)
+			cli, cliErr := newClient(&conn)
 			if cliErr != nil {
 				return fmt.Errorf("create client: %w", cliErr)
 			}
@@ -361,7 +729,7 @@ func deleteQueueCommand() *scotty.Command {
 			}
 
 			if jsonOut {
-				if err := json.NewEncoder(os.Stdout).Encode(deleteq); err != nil {
+				if err := pqjson.Encode(os.Stdout, deleteq); err != nil {
 					return fmt.Errorf("encode response: %w", err)
 				}
 
@@ -377,21 +745,23 @@ func deleteQueueCommand() *scotty.Command {
 
 func sendCommand() *scotty.Command {
 	var (
-		addr    string
-		message string
-		jsonOut bool
+		conn       connFlags
+		message    string
+		attributes = tagsFlag{}
+		jsonOut    bool
 	)
 
 	cmd := scotty.Command{
 		Name:  "send",
 		Short: "Sent a message to the queue",
 		SetFlags: func(flags *scotty.FlagSet) {
-			flags.StringVar(&addr, "grpc.addr", "localhost:8080",
-				"sets PlainQ gRPC address.",
-			)
+			conn.register(flags)
 			flags.StringVar(&message, "message", "",
 				"sets message as a string",
 			)
+			flags.Var(attributes, "attribute",
+				"adds a key=value attribute to the message, which receives filter messages by, can be repeated",
+			)
 			flags.BoolVar(&jsonOut, "json", false,
 				"enables json output",
 			)
@@ -406,11 +776,11 @@ func sendCommand() *scotty.Command {
 
 			id := args[0]
 
-			if err := idkit.ValidateXID(id); err != nil {
+			if err := validateQueueID(id); err != nil {
 				return err
 			}
 
-			cli, cliErr := client.New(addr)
+			cli, cliErr := newClient(&conn)
 			if cliErr != nil {
 				return fmt.Errorf("create client: %w", cliErr)
 			}
@@ -418,7 +788,7 @@ func sendCommand() *scotty.Command {
 			in := &v1.SendRequest{
 				QueueId: id,
 				Messages: []*v1.SendMessage{
-					{Body: []byte(message)},
+					{Body: []byte(message), Attributes: attributes},
 				},
 			}
 
@@ -428,7 +798,7 @@ func sendCommand() *scotty.Command {
 			}
 
 			if jsonOut {
-				if err := json.NewEncoder(os.Stdout).Encode(send); err != nil {
+				if err := pqjson.Encode(os.Stdout, send); err != nil {
 					return fmt.Errorf("encode response: %w", err)
 				}
 
@@ -446,8 +816,9 @@ func sendCommand() *scotty.Command {
 
 func receiveCommand() *scotty.Command {
 	var (
-		addr    string
+		conn    connFlags
 		batch   uint
+		filter  string
 		jsonOut bool
 	)
 
@@ -455,12 +826,13 @@ func receiveCommand() *scotty.Command {
 		Name:  "receive",
 		Short: "Receive a messages from the queue",
 		SetFlags: func(flags *scotty.FlagSet) {
-			flags.StringVar(&addr, "grpc.addr", "localhost:8080",
-				"sets PlainQ gRPC address.",
-			)
+			conn.register(flags)
 			flags.UintVar(&batch, "batch", 1,
 				"set receive batch size",
 			)
+			flags.StringVar(&filter, "filter", "",
+				`receives only messages which attributes match the expression, e.g. 'type = "order" AND region IN ("eu", "us")'`,
+			)
 			flags.BoolVar(&jsonOut, "json", false,
 				"enables json output",
 			)
@@ -475,11 +847,11 @@ func receiveCommand() *scotty.Command {
 
 			id := args[0]
 
-			if err := idkit.ValidateXID(id); err != nil {
+			if err := validateQueueID(id); err != nil {
 				return err
 			}
 
-			cli, cliErr := client.New(addr)
+			cli, cliErr := newClient(&conn)
 			if cliErr != nil {
 				return fmt.Errorf("create client: %w", cliErr)
 			}
@@ -490,6 +862,7 @@ func receiveCommand() *scotty.Command {
 			in := &v1.ReceiveRequest{
 				QueueId:   id,
 				BatchSize: uint32(batch),
+				Filter:    filter,
 			}
 
 			receive, receiveErr := cli.Receive(ctx, in)
@@ -498,7 +871,7 @@ func receiveCommand() *scotty.Command {
 			}
 
 			if jsonOut {
-				if err := json.NewEncoder(os.Stdout).Encode(receive); err != nil {
+				if err := pqjson.Encode(os.Stdout, receive); err != nil {
 					return fmt.Errorf("encode response: %w", err)
 				}
 
@@ -513,3 +886,497 @@ func receiveCommand() *scotty.Command {
 
 	return &cmd
 }
+
+func ackCommand() *scotty.Command {
+	var (
+		conn     connFlags
+		batch    uint
+		jsonOut  bool
+		receipts bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "ack",
+		Short: "Acknowledge (delete) received messages",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.UintVar(&batch, "batch", 100,
+				"sets maximum number of message ids sent in a single request",
+			)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+			flags.BoolVar(&receipts, "receipts", false,
+				"treats arguments as receipt handles returned by receive, deleting by message ids must be allowed "+
+					"by the server with --storage.ack-by-message-id and requires the purge permission",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 2 {
+				return errors.New("queue id and message ids should be specified: plainq ack [flags...] [queue id] [message id...|receipt handle...|-]")
+			}
+
+			id := args[0]
+
+			if err := validateQueueID(id); err != nil {
+				return err
+			}
+
+			ids := args[1:]
+
+			// Read message ids from the standard input, one per line.
+			if len(ids) == 1 && ids[0] == "-" {
+				ids = ids[:0]
+
+				scanner := bufio.NewScanner(os.Stdin)
+				for scanner.Scan() {
+					if line := strings.TrimSpace(scanner.Text()); line != "" {
+						ids = append(ids, line)
+					}
+				}
+
+				if err := scanner.Err(); err != nil {
+					return fmt.Errorf("read message ids: %w", err)
+				}
+			}
+
+			if batch == 0 {
+				batch = 1
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output := v1.DeleteResponse{
+				Successful: make([]string, 0, len(ids)),
+			}
+
+			for chunk := range slices.Chunk(ids, int(batch)) {
+				input := v1.DeleteRequest{QueueId: id, MessageIds: chunk}
+				if receipts {
+					input = v1.DeleteRequest{QueueId: id, ReceiptHandles: chunk}
+				}
+
+				res, deleteErr := cli.Delete(ctx, &input)
+				if deleteErr != nil {
+					return fmt.Errorf("delete messages: %w", deleteErr)
+				}
+
+				output.Successful = append(output.Successful, res.GetSuccessful()...)
+				output.Failed = append(output.Failed, res.GetFailed()...)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, &output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+			} else {
+				for _, s := range output.GetSuccessful() {
+					fmt.Println(s, "|", "deleted")
+				}
+
+				for _, f := range output.GetFailed() {
+					fmt.Println(f.GetMessageId(), "|", "failed:", f.GetError())
+				}
+			}
+
+			if len(output.GetFailed()) > 0 {
+				return fmt.Errorf("failed to delete %d of %d messages", len(output.GetFailed()), len(ids))
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func tailCommand() *scotty.Command {
+	var (
+		conn     connFlags
+		batch    uint
+		interval time.Duration
+		follow   bool
+		noAck    bool
+		pretty   bool
+		jsonOut  bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "tail",
+		Short: "Continuously receive and print messages from the queue",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.UintVar(&batch, "batch", 10,
+				"set receive batch size",
+			)
+			flags.DurationVar(&interval, "interval", time.Second,
+				"sets polling interval when the queue is empty",
+			)
+			flags.BoolVar(&follow, "follow", false,
+				"keeps polling the queue when it is empty",
+			)
+			flags.BoolVar(&noAck, "no-ack", false,
+				"disables deletion of received messages",
+			)
+			flags.BoolVar(&pretty, "pretty", false,
+				"pretty-prints message bodies which are valid JSON",
+			)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("queue id should be specified: plainq tail [flags...] [queue id]")
+			}
+
+			id := args[0]
+
+			if err := validateQueueID(id); err != nil {
+				return err
+			}
+
+			if batch > math.MaxUint32 {
+				return fmt.Errorf("batch size value too large: %d", batch)
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			for {
+				receive, receiveErr := cli.Receive(ctx, &v1.ReceiveRequest{
+					QueueId:   id,
+					BatchSize: uint32(batch),
+				})
+				if receiveErr != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+
+					return fmt.Errorf("receive message: %w", receiveErr)
+				}
+
+				messages := receive.GetMessages()
+
+				if len(messages) == 0 {
+					if !follow {
+						return nil
+					}
+
+					select {
+					case <-ctx.Done():
+						return nil
+
+					case <-time.After(interval):
+						continue
+					}
+				}
+
+				handles := make([]string, 0, len(messages))
+
+				for _, m := range messages {
+					handles = append(handles, m.GetReceiptHandle())
+
+					if jsonOut {
+						if err := pqjson.Encode(os.Stdout, m); err != nil {
+							return fmt.Errorf("encode message: %w", err)
+						}
+
+						continue
+					}
+
+					fmt.Println(m.GetId(), "|", formatBody(m.GetBody(), pretty))
+				}
+
+				if noAck {
+					continue
+				}
+
+				if _, err := cli.Delete(ctx, &v1.DeleteRequest{QueueId: id, ReceiptHandles: handles}); err != nil {
+					return fmt.Errorf("delete messages: %w", err)
+				}
+			}
+		},
+	}
+
+	return &cmd
+}
+
+// formatBody returns the message body as a string. When pretty is true
+// and the body is a valid JSON, it returns the indented JSON.
+func formatBody(body []byte, pretty bool) string {
+	if !pretty || !json.Valid(body) {
+		return string(body)
+	}
+
+	var buf bytes.Buffer
+
+	if err := json.Indent(&buf, body, "", "  "); err != nil {
+		return string(body)
+	}
+
+	return buf.String()
+}
+
+func adviseCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "advise",
+		Short: "Suggest queue settings based on telemetry",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("queue id should be specified: plainq advise [queue id]")
+			}
+
+			id := args[0]
+
+			if err := validateQueueID(id); err != nil {
+				return err
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			advice, adviseErr := cli.AdviseQueue(ctx, &v1.AdviseQueueRequest{QueueId: id})
+			if adviseErr != nil {
+				return fmt.Errorf("advise queue (id: %q): %w", id, adviseErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, advice); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			if len(advice.GetSuggestions()) == 0 {
+				fmt.Println("No suggestions: queue settings look fine for the observed load")
+				return nil
+			}
+
+			for _, s := range advice.GetSuggestions() {
+				fmt.Println(s.GetSetting(), "|", s.GetCurrent(), "->", s.GetSuggested(), "|", s.GetReason())
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+// batchMessage represents a single line of the JSON-lines file used by send-batch.
+type batchMessage struct {
+	// Body holds the message body. JSON strings are sent as is,
+	// any other JSON values are sent as their JSON representation.
+	Body json.RawMessage `json:"body"`
+
+	// Attributes holds message attributes.
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// batchResult represents the result of sending a single batch.
+type batchResult struct {
+	Batch      int      `json:"batch"`
+	Lines      []int    `json:"lines"`
+	MessageIDs []string `json:"message_ids,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+func sendBatchCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		file    string
+		batch   uint
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "send-batch",
+		Short: "Send messages from the JSON-lines file to the queue",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.StringVar(&file, "file", "-",
+				`sets path to the JSON-lines file with {"body": ...} objects, "-" reads from standard input`,
+			)
+			flags.UintVar(&batch, "batch", 10,
+				"sets the number of messages sent in a single request",
+			)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("queue id should be specified: plainq send-batch [flags...] [queue id]")
+			}
+
+			id := args[0]
+
+			if err := validateQueueID(id); err != nil {
+				return err
+			}
+
+			if batch == 0 {
+				batch = 1
+			}
+
+			input := os.Stdin
+
+			if file != "-" {
+				f, openErr := os.Open(file)
+				if openErr != nil {
+					return fmt.Errorf("open messages file: %w", openErr)
+				}
+
+				defer func() { _ = f.Close() }()
+
+				input = f
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			var (
+				encoder = json.NewEncoder(os.Stdout)
+				scanner = bufio.NewScanner(input)
+
+				messages = make([]*v1.SendMessage, 0, batch)
+				lines    = make([]int, 0, batch)
+
+				lineNum, batchNum, sent, failed int
+				attrWarned                      bool
+			)
+
+			// Allow lines up to 4MB long.
+			scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+			flush := func() error {
+				if len(messages) == 0 {
+					return nil
+				}
+
+				batchNum++
+
+				result := batchResult{Batch: batchNum, Lines: slices.Clone(lines)}
+
+				output, sendErr := cli.Send(ctx, &v1.SendRequest{QueueId: id, Messages: messages})
+				if sendErr != nil {
+					result.Error = sendErr.Error()
+					failed += len(messages)
+				} else {
+					result.MessageIDs = output.GetMessageIds()
+					sent += len(output.GetMessageIds())
+				}
+
+				messages, lines = messages[:0], lines[:0]
+
+				if jsonOut {
+					return encoder.Encode(&result)
+				}
+
+				if result.Error != "" {
+					fmt.Printf("batch %d (lines %d-%d): failed: %s\n",
+						result.Batch, result.Lines[0], result.Lines[len(result.Lines)-1], result.Error,
+					)
+
+					return nil
+				}
+
+				fmt.Printf("batch %d (lines %d-%d): sent %d messages\n",
+					result.Batch, result.Lines[0], result.Lines[len(result.Lines)-1], len(result.MessageIDs),
+				)
+
+				return nil
+			}
+
+			for scanner.Scan() {
+				lineNum++
+
+				line := bytes.TrimSpace(scanner.Bytes())
+				if len(line) == 0 {
+					continue
+				}
+
+				var m batchMessage
+				if err := json.Unmarshal(line, &m); err != nil || len(m.Body) == 0 {
+					return fmt.Errorf("line %d: expected JSON object with the 'body' field", lineNum)
+				}
+
+				if len(m.Attributes) > 0 && !attrWarned {
+					attrWarned = true
+					fmt.Fprintln(os.Stderr, "warning: message attributes are not supported by the server and will be ignored")
+				}
+
+				body := []byte(m.Body)
+
+				var s string
+				if err := json.Unmarshal(m.Body, &s); err == nil {
+					body = []byte(s)
+				}
+
+				messages = append(messages, &v1.SendMessage{Body: body})
+				lines = append(lines, lineNum)
+
+				if len(messages) == int(batch) {
+					if err := flush(); err != nil {
+						return fmt.Errorf("encode batch result: %w", err)
+					}
+				}
+			}
+
+			if err := scanner.Err(); err != nil {
+				return fmt.Errorf("read messages file: %w", err)
+			}
+
+			if err := flush(); err != nil {
+				return fmt.Errorf("encode batch result: %w", err)
+			}
+
+			if !jsonOut {
+				fmt.Printf("summary: %d batches, %d messages sent, %d failed\n", batchNum, sent, failed)
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("failed to send %d of %d messages", failed, sent+failed)
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_writeQueueTable(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+
+	queues := []*v1.DescribeQueueResponse{
+		{
+			QueueId:        "cq1",
+			QueueName:      "orders",
+			CreatedAt:      timestamppb.New(created),
+			Depth:          42,
+			EvictionPolicy: v1.EvictionPolicy_EVICTION_POLICY_DROP,
+			State:          v1.QueueState_QUEUE_STATE_ACTIVE,
+		},
+		{
+			QueueId:        "cq2",
+			QueueName:      "payments-dlq",
+			CreatedAt:      timestamppb.New(created),
+			EvictionPolicy: v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER,
+			State:          v1.QueueState_QUEUE_STATE_PAUSED,
+		},
+	}
+
+	tests := map[string]struct {
+		header bool
+		want   string
+	}{
+		"WithHeader": {
+			header: true,
+			want: "ID   NAME          CREATED              STATE   DEPTH  POLICY\n" +
+				"cq1  orders        2024-05-01 10:30:00  active  42     drop\n" +
+				"cq2  payments-dlq  2024-05-01 10:30:00  paused  0      dead-letter\n",
+		},
+		"WithoutHeader": {
+			header: false,
+			want: "cq1  orders        2024-05-01 10:30:00  active  42  drop\n" +
+				"cq2  payments-dlq  2024-05-01 10:30:00  paused  0   dead-letter\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			td.CmpNoError(t, writeQueueTable(&buf, queues, tc.header))
+			td.Cmp(t, buf.String(), tc.want)
+		})
+	}
+}
+
+func Test_validateQueueID(t *testing.T) {
+	tests := map[string]struct {
+		id      string
+		wantErr bool
+	}{
+		"Lower":   {id: "db9k7uvh7ojoejd39h3g"},
+		"Upper":   {id: "DB9K7UVH7OJOEJD39H3G"},
+		"Empty":   {id: "", wantErr: true},
+		"Invalid": {id: "not-a-queue-id", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validateQueueID(tc.id)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+		})
+	}
+}
+
+func Test_askMore(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		want  bool
+	}{
+		"Empty":  {input: "\n", want: true},
+		"Yes":    {input: "y\n", want: true},
+		"YesUp":  {input: " YES \n", want: true},
+		"No":     {input: "n\n", want: false},
+		"Quit":   {input: "q\n", want: false},
+		"Closed": {input: "", want: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			got := askMore(bufio.NewReader(strings.NewReader(tc.input)), &out)
+			td.Cmp(t, got, tc.want)
+			td.Cmp(t, out.String(), "-- More? [Y/n] ")
+		})
+	}
+}
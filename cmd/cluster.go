@@ -0,0 +1,110 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heartwilltell/scotty"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+)
+
+func clusterCommand() *scotty.Command {
+	cmd := scotty.Command{
+		Name:  "cluster",
+		Short: "Inspects the cluster of servers which replicate the storage database",
+	}
+
+	cmd.AddSubcommands(clusterStatusCommand())
+
+	return &cmd
+}
+
+func clusterStatusCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "status",
+		Short: "Show the status of the cluster as seen by the server, along with its members",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, _ []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, statusErr := cli.ClusterStatus(ctx, &v1.ClusterStatusRequest{})
+			if statusErr != nil {
+				return fmt.Errorf("get cluster status: %w", statusErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			return writeClusterStatus(os.Stdout, output)
+		},
+	}
+
+	return &cmd
+}
+
+// writeClusterStatus writes the status of the cluster to w as an aligned list followed by the table of servers.
+func writeClusterStatus(w io.Writer, status *v1.ClusterStatusResponse) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Node ID:\t%s\n", status.GetNodeId())
+	fmt.Fprintf(tw, "State:\t%s\n", clusterNodeStateName(status.GetState()))
+	fmt.Fprintf(tw, "Leader:\t%s\n", cmp.Or(status.GetLeaderId(), "none"))
+	fmt.Fprintf(tw, "Term:\t%d\n", status.GetTerm())
+	fmt.Fprintf(tw, "Last log index:\t%d\n", status.GetLastLogIndex())
+	fmt.Fprintf(tw, "Applied index:\t%d\n", status.GetAppliedIndex())
+
+	if status.GetLastContact() != nil {
+		fmt.Fprintf(tw, "Last contact:\t%s\n", status.GetLastContact().AsTime().Local().Format(time.DateTime))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+
+	// Servers are aligned on their own, apart from the list.
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ID\tADDRESS\tVOTER\tLEADER")
+
+	for _, s := range status.GetServers() {
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%t\n", s.GetId(), s.GetAddress(), s.GetVoter(), s.GetLeader())
+	}
+
+	return tw.Flush()
+}
+
+// clusterNodeStateName returns the lowercase name of the state, e.g. "leader".
+func clusterNodeStateName(state v1.ClusterNodeState) string {
+	return strings.ToLower(strings.TrimPrefix(state.String(), "CLUSTER_NODE_STATE_"))
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+func Test_writeClusterStatus(t *testing.T) {
+	status := v1.ClusterStatusResponse{
+		NodeId:       "node1",
+		State:        v1.ClusterNodeState_CLUSTER_NODE_STATE_LEADER,
+		LeaderId:     "node1",
+		Term:         3,
+		LastLogIndex: 42,
+		AppliedIndex: 41,
+		Servers: []*v1.ClusterServer{
+			{Id: "node1", Address: "10.0.0.1:8082", Voter: true, Leader: true},
+			{Id: "node2", Address: "10.0.0.2:8082", Voter: true},
+		},
+	}
+
+	var buf bytes.Buffer
+
+	td.CmpNoError(t, writeClusterStatus(&buf, &status))
+
+	td.Cmp(t, buf.String(), ""+
+		"Node ID:         node1\n"+
+		"State:           leader\n"+
+		"Leader:          node1\n"+
+		"Term:            3\n"+
+		"Last log index:  42\n"+
+		"Applied index:   41\n"+
+		"\n"+
+		"ID     ADDRESS        VOTER  LEADER\n"+
+		"node1  10.0.0.1:8082  true   true\n"+
+		"node2  10.0.0.2:8082  true   false\n",
+	)
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/heartwilltell/scotty"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+// completionTimeout limits the time of fetching dynamic completions from the server.
+const completionTimeout = 2 * time.Second
+
+// completionArgs holds functions which complete positional arguments by command path.
+var completionArgs = map[string]func(ctx context.Context, prefix string) []string{
+	"describe":         completeQueues,
+	"purge":            completeQueues,
+	"delete":           completeQueues,
+	"state":            completeQueues,
+	"rename":           completeQueues,
+	"send":             completeQueues,
+	"send-batch":       completeQueues,
+	"receive":          completeQueues,
+	"ack":              completeQueues,
+	"tail":             completeQueues,
+	"advise":           completeQueues,
+	"stats":            completeQueues,
+	"generate start":   completeQueues,
+	"generate stop":    completeGenerators,
+	"jobs get":         completeJobs,
+	"jobs cancel":      completeJobs,
+	"ctx use":          completeContexts,
+	"ctx remove":       completeContexts,
+	"rbac grant":       completeQueues,
+	"rbac revoke":      completeQueues,
+	"rbac permissions": completeQueues,
+}
+
+// completionScripts holds completion scripts by shell name.
+// The scripts call the "completion complete" command to get candidates.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for plainq
+_plainq() {
+	local IFS=$'\n'
+	COMPREPLY=($(plainq completion complete -- "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null | cut -f1))
+}
+
+complete -o default -F _plainq plainq
+`,
+	"zsh": `#compdef plainq
+
+_plainq() {
+	local -a candidates
+	local line
+
+	for line in "${(@f)$(plainq completion complete -- "${(@)words[2,CURRENT]}" 2>/dev/null)}"; do
+		[[ -n $line ]] && candidates+=("${line/$'\t'/:}")
+	done
+
+	_describe 'plainq' candidates
+}
+
+if [ "$funcstack[1]" = "_plainq" ]; then
+	_plainq "$@"
+else
+	compdef _plainq plainq
+fi
+`,
+	"fish": `# fish completion for plainq
+function __plainq_complete
+	set -l tokens (commandline -opc) (commandline -ct)
+	plainq completion complete -- $tokens[2..-1] 2>/dev/null
+end
+
+complete -c plainq -f -a '(__plainq_complete)'
+`,
+}
+
+func completionCommand() *scotty.Command {
+	cmd := scotty.Command{
+		Name:  "completion",
+		Short: "Generates shell completion scripts",
+	}
+
+	cmd.AddSubcommands(completionSubcommands()...)
+
+	return &cmd
+}
+
+// completionSubcommands returns subcommands of the completion command.
+func completionSubcommands() []*scotty.Command {
+	subcommands := make([]*scotty.Command, 0, len(completionScripts)+1)
+
+	for _, shell := range slices.Sorted(maps.Keys(completionScripts)) {
+		subcommands = append(subcommands, &scotty.Command{
+			Name:  shell,
+			Short: "Prints " + shell + " completion script",
+			Run: func(_ *scotty.Command, _ []string) error {
+				fmt.Print(completionScripts[shell])
+				return nil
+			},
+		})
+	}
+
+	subcommands = append(subcommands, &scotty.Command{
+		Name:  "complete",
+		Short: "Prints completion candidates for given words, used by completion scripts",
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+			defer cancel()
+
+			for _, c := range newCompletionNode("", commands()).complete(ctx, args) {
+				fmt.Println(c)
+			}
+
+			return nil
+		},
+	})
+
+	return subcommands
+}
+
+// completionNode describes a command for the completion.
+type completionNode struct {
+	// path is the space separated chain of command names.
+	path string
+	// flags holds flag names and whether the flag is boolean.
+	flags map[string]bool
+	// subcommands holds subcommands by name.
+	subcommands map[string]*completionNode
+}
+
+// newCompletionNode returns a completion node for the command with given subcommands.
+func newCompletionNode(path string, subcommands []*scotty.Command) *completionNode {
+	n := completionNode{
+		path:        path,
+		flags:       make(map[string]bool),
+		subcommands: make(map[string]*completionNode, len(subcommands)),
+	}
+
+	for _, sub := range subcommands {
+		subPath := strings.TrimSpace(path + " " + sub.Name)
+
+		var nested []*scotty.Command
+
+		switch subPath {
+		case "ctx":
+			nested = contextSubcommands()
+
+		case "generate":
+			nested = generateSubcommands()
+
+		case "completion":
+			nested = completionSubcommands()
+
+		case "migrate-from":
+			nested = migrateSubcommands()
+
+		case "account":
+			nested = accountSubcommands()
+
+		case "apikey":
+			nested = apiKeySubcommands()
+
+		case "rbac":
+			nested = rbacSubcommands()
+
+		case "rbac role":
+			nested = rbacRoleSubcommands()
+		}
+
+		node := newCompletionNode(subPath, nested)
+
+		sub.Flags().VisitAll(func(f *flag.Flag) {
+			b, ok := f.Value.(interface{ IsBoolFlag() bool })
+			node.flags[f.Name] = ok && b.IsBoolFlag()
+		})
+
+		n.subcommands[sub.Name] = node
+	}
+
+	return &n
+}
+
+// complete returns completion candidates for the last of the words,
+// which is the word being completed. Candidates may be followed
+// by a tab separated description.
+func (n *completionNode) complete(ctx context.Context, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+
+	var (
+		node       = n
+		current    = words[len(words)-1]
+		positional = 0
+	)
+
+	for i := 0; i < len(words)-1; i++ {
+		w := words[i]
+
+		if strings.HasPrefix(w, "-") && w != "-" {
+			name := strings.TrimLeft(w, "-")
+			if strings.Contains(name, "=") {
+				continue
+			}
+
+			// Skip the value of the non-boolean flag.
+			if isBool, ok := node.flags[name]; ok && !isBool {
+				i++
+			}
+
+			continue
+		}
+
+		if sub, ok := node.subcommands[w]; ok && positional == 0 {
+			node = sub
+			continue
+		}
+
+		positional++
+	}
+
+	var candidates []string
+
+	switch {
+	case strings.HasPrefix(current, "-"):
+		for name := range node.flags {
+			if flagName := "--" + name; strings.HasPrefix(flagName, current) {
+				candidates = append(candidates, flagName)
+			}
+		}
+
+	case len(node.subcommands) > 0 && positional == 0:
+		for name := range node.subcommands {
+			if strings.HasPrefix(name, current) {
+				candidates = append(candidates, name)
+			}
+		}
+
+	case positional == 0:
+		if args, ok := completionArgs[node.path]; ok {
+			return args(ctx, current)
+		}
+	}
+
+	slices.Sort(candidates)
+
+	return candidates
+}
+
+// completeQueues returns identifiers of queues which identifier or name starts
+// with the prefix, described by queue names. Errors are ignored, since there
+// is no way to report them during the completion.
+func completeQueues(ctx context.Context, prefix string) []string {
+	cli, cliErr := newClient(&connFlags{})
+	if cliErr != nil {
+		return nil
+	}
+
+	list, listErr := cli.ListQueues(ctx, &v1.ListQueuesRequest{Limit: maxLimit})
+	if listErr != nil {
+		return nil
+	}
+
+	var candidates []string
+
+	for _, q := range list.GetQueues() {
+		if strings.HasPrefix(q.GetQueueId(), prefix) || strings.HasPrefix(q.GetQueueName(), prefix) {
+			candidates = append(candidates, q.GetQueueId()+"\t"+q.GetQueueName())
+		}
+	}
+
+	return candidates
+}
+
+// completeGenerators returns identifiers of running generators
+// which start with the prefix, described by queue identifiers.
+func completeGenerators(ctx context.Context, prefix string) []string {
+	cli, cliErr := newClient(&connFlags{})
+	if cliErr != nil {
+		return nil
+	}
+
+	list, listErr := cli.ListGenerators(ctx, &v1.ListGeneratorsRequest{})
+	if listErr != nil {
+		return nil
+	}
+
+	var candidates []string
+
+	for _, g := range list.GetGenerators() {
+		if strings.HasPrefix(g.GetGeneratorId(), prefix) {
+			candidates = append(candidates, g.GetGeneratorId()+"\tqueue "+g.GetQueueId())
+		}
+	}
+
+	return candidates
+}
+
+// completeJobs returns identifiers of running jobs which start with the prefix.
+func completeJobs(ctx context.Context, prefix string) []string {
+	cli, cliErr := newClient(&connFlags{})
+	if cliErr != nil {
+		return nil
+	}
+
+	list, listErr := cli.ListJobs(ctx, &v1.ListJobsRequest{State: v1.JobState_JOB_STATE_RUNNING})
+	if listErr != nil {
+		return nil
+	}
+
+	var candidates []string
+
+	for _, job := range list.GetJobs() {
+		if strings.HasPrefix(job.GetJobId(), prefix) {
+			candidates = append(candidates, job.GetJobId()+"\t"+job.GetKind()+" of queue "+job.GetQueueId())
+		}
+	}
+
+	return candidates
+}
+
+// completeContexts returns names of contexts which start with the prefix.
+func completeContexts(_ context.Context, prefix string) []string {
+	ctxConfig, loadErr := loadContextConfig()
+	if loadErr != nil {
+		return nil
+	}
+
+	var candidates []string
+
+	for _, c := range ctxConfig.Contexts {
+		if strings.HasPrefix(c.Name, prefix) {
+			candidates = append(candidates, c.Name+"\t"+c.Endpoint)
+		}
+	}
+
+	return candidates
+}
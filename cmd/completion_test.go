@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func Test_completionNode_complete(t *testing.T) {
+	root := newCompletionNode("", commands())
+
+	tests := map[string]struct {
+		words []string
+		want  any
+	}{
+		"Commands": {
+			words: []string{"ge"},
+			want:  []string{"generate"},
+		},
+		"AllCommands": {
+			words: []string{""},
+			want:  td.SuperBagOf("ctx", "list", "stats", "completion"),
+		},
+		"Subcommands": {
+			words: []string{"ctx", "u"},
+			want:  []string{"use"},
+		},
+		"Flags": {
+			words: []string{"stats", "--wa"},
+			want:  []string{"--watch"},
+		},
+		"FlagsAfterFlagValue": {
+			words: []string{"list", "--limit", "10", "--js"},
+			want:  []string{"--json"},
+		},
+		"NestedFlags": {
+			words: []string{"generate", "start", "--ra"},
+			want:  []string{"--rate"},
+		},
+		"NoArgsCompletion": {
+			words: []string{"list", ""},
+			want:  td.Nil(),
+		},
+		"SecondPositional": {
+			words: []string{"completion", "bash", ""},
+			want:  td.Nil(),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, root.complete(context.Background(), tc.words), tc.want)
+		})
+	}
+}
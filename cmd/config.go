@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// envPrefix represents the prefix of environment variables which configure the server.
+	envPrefix = "PLAINQ_"
+
+	// configFlag represents the name of the flag which holds the path to the configuration file.
+	configFlag = "config"
+)
+
+// envName returns the name of the environment variable which configures
+// the flag, e.g. PLAINQ_STORAGE_GC_TIMEOUT for the "storage.gc.timeout" flag.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(flagName))
+}
+
+// applyConfigSources sets flags which have not been set on the command line
+// from environment variables, and then from the configuration file.
+// The precedence is: flags > environment variables > configuration file > defaults.
+//
+// The configuration file is a JSON object with flag names as keys, e.g.
+// {"grpc.addr": ":8080", "storage.gc.timeout": "10m", "metrics": true}.
+func applyConfigSources(flags *flag.FlagSet, lookupEnv func(string) (string, bool)) error {
+	set := make(map[string]bool)
+
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	var envErr error
+
+	flags.VisitAll(func(f *flag.Flag) {
+		if set[f.Name] || envErr != nil {
+			return
+		}
+
+		name := envName(f.Name)
+
+		value, ok := lookupEnv(name)
+		if !ok {
+			return
+		}
+
+		if err := flags.Set(f.Name, value); err != nil {
+			envErr = fmt.Errorf("invalid value %q of environment variable %s: %w", value, name, err)
+			return
+		}
+
+		set[f.Name] = true
+	})
+
+	if envErr != nil {
+		return envErr
+	}
+
+	path := flags.Lookup(configFlag)
+	if path == nil || path.Value.String() == "" {
+		return nil
+	}
+
+	values, readErr := readConfigFile(path.Value.String())
+	if readErr != nil {
+		return readErr
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		if flags.Lookup(name) == nil || name == configFlag {
+			return fmt.Errorf("config file %s: unknown option %q", path.Value.String(), name)
+		}
+
+		if set[name] {
+			continue
+		}
+
+		if err := flags.Set(name, values[name]); err != nil {
+			return fmt.Errorf("config file %s: invalid value %q of option %q: %w", path.Value.String(), values[name], name, err)
+		}
+	}
+
+	return nil
+}
+
+// readConfigFile reads the JSON configuration file
+// and returns option values by flag names.
+func readConfigFile(path string) (map[string]string, error) {
+	data, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return nil, fmt.Errorf("read config file: %w", readErr)
+	}
+
+	var raw map[string]any
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+
+	for name, v := range raw {
+		switch v := v.(type) {
+		case string:
+			values[name] = v
+
+		case bool:
+			values[name] = strconv.FormatBool(v)
+
+		case float64:
+			values[name] = strconv.FormatFloat(v, 'f', -1, 64)
+
+		default:
+			return nil, fmt.Errorf("config file %s: option %q should be a string, number or boolean", path, name)
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func Test_envName(t *testing.T) {
+	td.Cmp(t, envName("grpc.addr"), "PLAINQ_GRPC_ADDR")
+	td.Cmp(t, envName("http.read-header-timeout"), "PLAINQ_HTTP_READ_HEADER_TIMEOUT")
+	td.Cmp(t, envName("metrics"), "PLAINQ_METRICS")
+}
+
+func Test_applyConfigSources(t *testing.T) {
+	type tcase struct {
+		args    []string
+		env     map[string]string
+		file    string
+		want    map[string]string
+		wantErr bool
+	}
+
+	tests := map[string]tcase{
+		"Defaults": {
+			want: map[string]string{"addr": ":8080", "timeout": "1s", "enable": "true"},
+		},
+		"Env": {
+			env:  map[string]string{"PLAINQ_ADDR": ":9090", "PLAINQ_ENABLE": "false"},
+			want: map[string]string{"addr": ":9090", "timeout": "1s", "enable": "false"},
+		},
+		"File": {
+			file: `{"addr": ":7070", "timeout": "1m", "enable": false}`,
+			want: map[string]string{"addr": ":7070", "timeout": "1m0s", "enable": "false"},
+		},
+		"Precedence": {
+			args: []string{"--addr", ":6060"},
+			env:  map[string]string{"PLAINQ_ADDR": ":9090", "PLAINQ_TIMEOUT": "2s"},
+			file: `{"addr": ":7070", "timeout": "1m", "enable": false}`,
+			want: map[string]string{"addr": ":6060", "timeout": "2s", "enable": "false"},
+		},
+		"InvalidEnv": {
+			env:     map[string]string{"PLAINQ_TIMEOUT": "soon"},
+			wantErr: true,
+		},
+		"UnknownFileOption": {
+			file:    `{"address": ":7070"}`,
+			wantErr: true,
+		},
+		"InvalidFileValue": {
+			file:    `{"timeout": ["1s"]}`,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.String(configFlag, "", "")
+			flags.String("addr", ":8080", "")
+			flags.Duration("timeout", time.Second, "")
+			flags.Bool("enable", true, "")
+
+			args := tc.args
+
+			if tc.file != "" {
+				path := filepath.Join(t.TempDir(), "plainq.json")
+				td.CmpNoError(t, os.WriteFile(path, []byte(tc.file), 0o600))
+
+				args = append(args, "--config", path)
+			}
+
+			td.CmpNoError(t, flags.Parse(args))
+
+			err := applyConfigSources(flags, func(name string) (string, bool) {
+				v, ok := tc.env[name]
+				return v, ok
+			})
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+
+			for name, want := range tc.want {
+				td.Cmp(t, flags.Lookup(name).Value.String(), want, name)
+			}
+		})
+	}
+}
@@ -1,24 +1,139 @@
 package main
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"os"
-	"runtime"
+	"path/filepath"
+	"slices"
 
 	"github.com/heartwilltell/scotty"
+	"github.com/plainq/plainq/internal/client"
 )
 
+const (
+	// contextDirName represents the name of the directory inside
+	// the user config directory where the context file is stored.
+	contextDirName = "plainq"
+
+	// contextFileName represents the name of the context file.
+	contextFileName = "context.json"
+
+	// defaultContextName represents the name of the context created by 'ctx init'.
+	defaultContextName = "default"
+
+	// defaultEndpoint represents the endpoint of the context created by 'ctx init'.
+	defaultEndpoint = "localhost:8080"
+)
+
+// errNoContextFile indicates that the context file has not been created yet.
+var errNoContextFile = fmt.Errorf("context file doesn't exist: execute %q", "plainq ctx init")
+
 type plainqContextConfig struct {
-	Current  plainqContext   `json:"current"`
+	Current  string          `json:"current"`
 	Contexts []plainqContext `json:"contexts"`
 }
 
+// find returns the context with given name.
+func (c *plainqContextConfig) find(name string) (*plainqContext, bool) {
+	i := slices.IndexFunc(c.Contexts, func(ctx plainqContext) bool { return ctx.Name == name })
+	if i < 0 {
+		return nil, false
+	}
+
+	return &c.Contexts[i], true
+}
+
+// current returns the context which is currently in use.
+func (c *plainqContextConfig) current() (*plainqContext, error) {
+	ctx, ok := c.find(c.Current)
+	if !ok {
+		return nil, fmt.Errorf("current context %q not found: execute %q", c.Current, "plainq ctx use [name]")
+	}
+
+	return ctx, nil
+}
+
 type plainqContext struct {
-	Name     string `json:"name"`
-	Endpoint string `json:"endpoint"`
+	Name     string      `json:"name"`
+	Endpoint string      `json:"endpoint"`
+	TLS      *plainqTLS  `json:"tls,omitempty"`
+	Auth     *plainqAuth `json:"auth,omitempty"`
+}
+
+// clientOptions returns client options according to the context TLS and auth settings.
+func (c *plainqContext) clientOptions() ([]client.Option, error) {
+	options := make([]client.Option, 0, 2)
+
+	if c.TLS != nil {
+		cfg, cfgErr := c.TLS.config()
+		if cfgErr != nil {
+			return nil, cfgErr
+		}
+
+		options = append(options, client.WithTLS(cfg))
+	}
+
+	if c.Auth != nil && c.Auth.Token != "" {
+		options = append(options, client.WithToken(c.Auth.Token))
+	}
+
+	if c.Auth != nil && c.Auth.APIKey != "" {
+		options = append(options, client.WithAPIKey(c.Auth.APIKey))
+	}
+
+	return options, nil
+}
+
+// plainqTLS holds TLS settings of the context.
+type plainqTLS struct {
+	CAFile             string `json:"ca_file,omitempty"`
+	CertFile           string `json:"cert_file,omitempty"`
+	KeyFile            string `json:"key_file,omitempty"`
+	ServerName         string `json:"server_name,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+// config returns TLS configuration according to the settings.
+func (t *plainqTLS) config() (*tls.Config, error) {
+	cfg := tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify, //nolint:gosec // explicitly enabled by the user.
+	}
+
+	if t.CAFile != "" {
+		pem, readErr := os.ReadFile(t.CAFile)
+		if readErr != nil {
+			return nil, fmt.Errorf("read CA certificate: %w", readErr)
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %q", t.CAFile)
+		}
+	}
+
+	if t.CertFile != "" {
+		cert, loadErr := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if loadErr != nil {
+			return nil, fmt.Errorf("load client certificate: %w", loadErr)
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return &cfg, nil
+}
+
+// plainqAuth holds authentication settings of the context.
+type plainqAuth struct {
+	Token  string `json:"token,omitempty"`
+	APIKey string `json:"api_key,omitempty"`
 }
 
 func contextCommand() *scotty.Command {
@@ -27,43 +142,47 @@ func contextCommand() *scotty.Command {
 		Short: "Manages plainq client contexts",
 	}
 
-	cmd.AddSubcommands(
-		contextInitCommand(),
-		contextListCommand(),
-	)
+	cmd.AddSubcommands(contextSubcommands()...)
 
 	return &cmd
 }
 
+// contextSubcommands returns subcommands of the context command.
+func contextSubcommands() []*scotty.Command {
+	return []*scotty.Command{
+		contextInitCommand(),
+		contextListCommand(),
+		contextCurrentCommand(),
+		contextUseCommand(),
+		contextAddCommand(),
+		contextRemoveCommand(),
+	}
+}
+
 func contextInitCommand() *scotty.Command {
 	cmd := scotty.Command{
 		Name:  "init",
 		Short: "Create context configuration file",
 		Run: func(_ *scotty.Command, _ []string) error {
-			// TODO: create context in default location.
-			// TODO: ~/.config/plainq/context.json.
-
-			f, createErr := createContextFile()
-			if createErr != nil {
-				if errors.Is(createErr, os.ErrExist) {
-					fmt.Println("Context file already exists")
-					return nil
-				}
+			_, loadErr := loadContextConfig()
+			if loadErr == nil {
+				fmt.Println("Context file already exists")
+				return nil
+			}
 
-				return createErr
+			if !errors.Is(loadErr, errNoContextFile) {
+				return loadErr
 			}
 
 			ctxConfig := plainqContextConfig{
-				Current: plainqContext{
-					Name: "default", Endpoint: "localhost:8080",
-				},
+				Current: defaultContextName,
 				Contexts: []plainqContext{
-					{Name: "default", Endpoint: "localhost:8080"},
+					{Name: defaultContextName, Endpoint: defaultEndpoint},
 				},
 			}
 
-			if err := json.NewEncoder(f).Encode(&ctxConfig); err != nil {
-				return fmt.Errorf("encode context file content: %w", err)
+			if err := saveContextConfig(&ctxConfig); err != nil {
+				return err
 			}
 
 			fmt.Println("Context file created")
@@ -79,33 +198,81 @@ func contextListCommand() *scotty.Command {
 		Name:  "list",
 		Short: "show list of available contexts",
 		Run: func(_ *scotty.Command, _ []string) error {
-			f, readErr := readContextFile()
-			if readErr != nil {
-				if errors.Is(readErr, os.ErrNotExist) {
-					return fmt.Errorf("context file doesn't exist: execute %q", "plainq ctx init")
+			ctxConfig, loadErr := loadContextConfig()
+			if loadErr != nil {
+				return loadErr
+			}
+
+			fmt.Println("Contexts list:")
+
+			for _, ctx := range ctxConfig.Contexts {
+				marker := " "
+				if ctx.Name == ctxConfig.Current {
+					marker = "*"
 				}
+
+				fmt.Printf("%s Name: %q endpoint: %q tls: %t auth: %t\n",
+					marker, ctx.Name, ctx.Endpoint, ctx.TLS != nil, ctx.Auth != nil,
+				)
 			}
-			defer f.Close()
 
-			var ctxConfig plainqContextConfig
+			return nil
+		},
+	}
 
-			if err := json.NewDecoder(f).Decode(&ctxConfig); err != nil {
-				return fmt.Errorf("decode contex file: %w", err)
+	return &cmd
+}
+
+func contextCurrentCommand() *scotty.Command {
+	cmd := scotty.Command{
+		Name:  "current",
+		Short: "show current context",
+		Run: func(_ *scotty.Command, _ []string) error {
+			ctxConfig, loadErr := loadContextConfig()
+			if loadErr != nil {
+				return loadErr
 			}
 
-			fmt.Printf("Current context: %q endpoint: %q\n",
-				ctxConfig.Current.Name,
-				ctxConfig.Current.Endpoint,
-			)
+			ctx, currentErr := ctxConfig.current()
+			if currentErr != nil {
+				return currentErr
+			}
 
-			fmt.Println("Contexts list:")
+			fmt.Printf("Current context: %q endpoint: %q\n", ctx.Name, ctx.Endpoint)
 
-			for _, ctx := range ctxConfig.Contexts {
-				fmt.Printf("Name: %q endpoint: %q\n",
-					ctx.Name, ctx.Endpoint,
-				)
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func contextUseCommand() *scotty.Command {
+	cmd := scotty.Command{
+		Name:  "use",
+		Short: "switch current context",
+		Run: func(_ *scotty.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("context name should be specified: plainq ctx use [name]")
+			}
+
+			ctxConfig, loadErr := loadContextConfig()
+			if loadErr != nil {
+				return loadErr
+			}
+
+			if _, ok := ctxConfig.find(args[0]); !ok {
+				return fmt.Errorf("context %q not found", args[0])
+			}
+
+			ctxConfig.Current = args[0]
+
+			if err := saveContextConfig(ctxConfig); err != nil {
+				return err
 			}
 
+			fmt.Printf("Switched to context %q\n", args[0])
+
 			return nil
 		},
 	}
@@ -113,47 +280,309 @@ func contextListCommand() *scotty.Command {
 	return &cmd
 }
 
-func readContextFile() (io.ReadCloser, error) {
+func contextAddCommand() *scotty.Command {
 	var (
-		f   io.ReadCloser
-		err error
+		endpoint string
+		use      bool
+		tls      plainqTLS
+		useTLS   bool
+		token    string
+		apiKey   string
+	)
+
+	cmd := scotty.Command{
+		Name:  "add",
+		Short: "add or replace a context",
+		SetFlags: func(flags *scotty.FlagSet) {
+			flags.StringVar(&endpoint, "endpoint", defaultEndpoint,
+				"sets PlainQ gRPC address of the context",
+			)
+			flags.BoolVar(&use, "use", false,
+				"switches to the context after it has been added",
+			)
+			flags.BoolVar(&useTLS, "tls", false,
+				"enables TLS for connections, implied by any other tls flag",
+			)
+			flags.StringVar(&tls.CAFile, "tls-ca", "",
+				"sets the path to the CA certificate to verify the server",
+			)
+			flags.StringVar(&tls.CertFile, "tls-cert", "",
+				"sets the path to the client certificate",
+			)
+			flags.StringVar(&tls.KeyFile, "tls-key", "",
+				"sets the path to the client certificate key",
+			)
+			flags.StringVar(&tls.ServerName, "tls-server-name", "",
+				"overrides the server name used to verify the server certificate",
+			)
+			flags.BoolVar(&tls.InsecureSkipVerify, "insecure-skip-verify", false,
+				"disables server certificate verification",
+			)
+			flags.StringVar(&token, "token", "",
+				"sets the token used to authenticate requests",
+			)
+			flags.StringVar(&apiKey, "api-key", "",
+				"sets the service account API key used to authenticate requests",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("context name should be specified: plainq ctx add [name]")
+			}
+
+			if (tls.CertFile == "") != (tls.KeyFile == "") {
+				return errors.New("both --tls-cert and --tls-key should be specified")
+			}
+
+			ctxConfig, loadErr := loadContextConfig()
+			if loadErr != nil {
+				return loadErr
+			}
+
+			ctx := plainqContext{Name: args[0], Endpoint: endpoint}
+
+			if useTLS || tls != (plainqTLS{}) {
+				ctx.TLS = &tls
+			}
+
+			if token != "" && apiKey != "" {
+				return errors.New("only one of --token and --api-key should be specified")
+			}
+
+			if token != "" || apiKey != "" {
+				ctx.Auth = &plainqAuth{Token: token, APIKey: apiKey}
+			}
+
+			if existing, ok := ctxConfig.find(ctx.Name); ok {
+				*existing = ctx
+			} else {
+				ctxConfig.Contexts = append(ctxConfig.Contexts, ctx)
+			}
+
+			if use {
+				ctxConfig.Current = ctx.Name
+			}
+
+			if err := saveContextConfig(ctxConfig); err != nil {
+				return err
+			}
+
+			fmt.Printf("Context %q saved\n", ctx.Name)
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func contextRemoveCommand() *scotty.Command {
+	cmd := scotty.Command{
+		Name:  "remove",
+		Short: "remove a context",
+		Run: func(_ *scotty.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("context name should be specified: plainq ctx remove [name]")
+			}
+
+			ctxConfig, loadErr := loadContextConfig()
+			if loadErr != nil {
+				return loadErr
+			}
+
+			if args[0] == ctxConfig.Current {
+				return fmt.Errorf("context %q is in use: switch to another context first", args[0])
+			}
+
+			if _, ok := ctxConfig.find(args[0]); !ok {
+				return fmt.Errorf("context %q not found", args[0])
+			}
+
+			ctxConfig.Contexts = slices.DeleteFunc(ctxConfig.Contexts, func(ctx plainqContext) bool {
+				return ctx.Name == args[0]
+			})
+
+			if err := saveContextConfig(ctxConfig); err != nil {
+				return err
+			}
+
+			fmt.Printf("Context %q removed\n", args[0])
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+// connFlags holds connection flags shared by client commands.
+type connFlags struct {
+	addr   string
+	useTLS bool
+	tls    plainqTLS
+}
+
+// register registers connection flags in the flag set.
+func (c *connFlags) register(flags *scotty.FlagSet) {
+	flags.StringVar(&c.addr, "grpc.addr", "",
+		"sets PlainQ gRPC address, overrides the current context endpoint.",
+	)
+	flags.BoolVar(&c.useTLS, "tls", false,
+		"enables TLS, overrides the current context TLS settings; implied by other tls flags",
+	)
+	flags.StringVar(&c.tls.CAFile, "tls-ca", "",
+		"sets the path to the CA certificate to verify the server",
+	)
+	flags.StringVar(&c.tls.CertFile, "tls-cert", "",
+		"sets the path to the client certificate",
 	)
+	flags.StringVar(&c.tls.KeyFile, "tls-key", "",
+		"sets the path to the client certificate key",
+	)
+	flags.BoolVar(&c.tls.InsecureSkipVerify, "insecure-skip-verify", false,
+		"disables server certificate verification",
+	)
+}
 
-	switch runtime.GOOS {
-	case "darwin":
-		f, err = os.Open("~/.config/plainq/context.json")
+// tlsOverride returns TLS settings set by flags or nil when no TLS flags have been set.
+func (c *connFlags) tlsOverride() (*plainqTLS, error) {
+	if (c.tls.CertFile == "") != (c.tls.KeyFile == "") {
+		return nil, errors.New("both --tls-cert and --tls-key should be specified")
+	}
 
-	default:
-		return nil, errors.New("unsupported operating system")
+	if !c.useTLS && c.tls == (plainqTLS{}) {
+		return nil, nil
 	}
 
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil, err
-		}
+	return &c.tls, nil
+}
+
+// newClient returns the client connected according to the context resolved by resolveContext.
+func newClient(conn *connFlags) (*client.Client, error) {
+	ctx, ctxErr := resolveContext(conn)
+	if ctxErr != nil {
+		return nil, ctxErr
+	}
 
-		return nil, fmt.Errorf("open context file: %w", err)
+	options, optionsErr := ctx.clientOptions()
+	if optionsErr != nil {
+		return nil, fmt.Errorf("context %q: %w", ctx.Name, optionsErr)
 	}
 
-	return f, nil
+	options = append(options, client.WithUserAgent(userAgent()))
+
+	return client.New(ctx.Endpoint, options...)
 }
 
-func createContextFile() (f *os.File, err error) {
-	switch runtime.GOOS {
-	case "darwin":
-		f, err = os.Create("~/.config/plainq/context.json")
+// resolveContext returns the context of the address set by flags. When the address
+// is not set the endpoint, TLS and auth settings of the current context are used,
+// and TLS flags override the context TLS settings. Without the context file
+// the default endpoint is used.
+func resolveContext(conn *connFlags) (*plainqContext, error) {
+	override, overrideErr := conn.tlsOverride()
+	if overrideErr != nil {
+		return nil, overrideErr
+	}
+
+	ctx := plainqContext{Name: "flags", Endpoint: conn.addr, TLS: override}
+
+	if conn.addr != "" {
+		return &ctx, nil
+	}
+
+	ctxConfig, loadErr := loadContextConfig()
+
+	switch {
+	case errors.Is(loadErr, errNoContextFile):
+		ctx.Endpoint = defaultEndpoint
+
+	case loadErr != nil:
+		return nil, loadErr
 
 	default:
-		return nil, errors.New("unsupported operating system")
+		current, currentErr := ctxConfig.current()
+		if currentErr != nil {
+			return nil, currentErr
+		}
+
+		ctx = *current
+
+		if override != nil {
+			ctx.TLS = override
+		}
 	}
 
-	if err != nil {
-		if errors.Is(err, os.ErrExist) {
-			return nil, err
+	return &ctx, nil
+}
+
+// userAgent returns the user agent of the CLI with the version it's built from.
+func userAgent() string { return fmt.Sprintf("plainq-cli/%s (%s)", Commit, Branch) }
+
+// contextFilePath returns the path to the context file inside
+// the user config directory of the current operating system.
+func contextFilePath() (string, error) {
+	dir, dirErr := os.UserConfigDir()
+	if dirErr != nil {
+		return "", fmt.Errorf("resolve config directory: %w", dirErr)
+	}
+
+	return filepath.Join(dir, contextDirName, contextFileName), nil
+}
+
+// loadContextConfig reads and decodes the context file.
+// Returns errNoContextFile when the file doesn't exist.
+func loadContextConfig() (*plainqContextConfig, error) {
+	path, pathErr := contextFilePath()
+	if pathErr != nil {
+		return nil, pathErr
+	}
+
+	data, readErr := os.ReadFile(path)
+	if readErr != nil {
+		if errors.Is(readErr, os.ErrNotExist) {
+			return nil, errNoContextFile
 		}
 
-		return nil, fmt.Errorf("create context file: %w", err)
+		return nil, fmt.Errorf("read context file: %w", readErr)
+	}
+
+	var ctxConfig plainqContextConfig
+
+	if err := json.Unmarshal(data, &ctxConfig); err != nil {
+		return nil, fmt.Errorf("decode context file: %w", err)
+	}
+
+	return &ctxConfig, nil
+}
+
+// saveContextConfig encodes and writes the context file, creating the directory
+// if needed. The file is readable only by the owner since it may hold tokens.
+func saveContextConfig(ctxConfig *plainqContextConfig) error {
+	path, pathErr := contextFilePath()
+	if pathErr != nil {
+		return pathErr
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create context directory: %w", err)
+	}
+
+	data, encodeErr := json.MarshalIndent(ctxConfig, "", "  ")
+	if encodeErr != nil {
+		return fmt.Errorf("encode context file content: %w", encodeErr)
+	}
+
+	// Write to a temporary file first, so the existing context file
+	// isn't corrupted if the write fails halfway.
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write context file: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace context file: %w", err)
 	}
 
-	return f, nil
+	return nil
 }
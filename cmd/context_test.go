@@ -0,0 +1,122 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestContextConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+
+	_, loadErr := loadContextConfig()
+	td.CmpErrorIs(t, loadErr, errNoContextFile)
+
+	want := plainqContextConfig{
+		Current: "prod",
+		Contexts: []plainqContext{
+			{Name: defaultContextName, Endpoint: defaultEndpoint},
+			{
+				Name:     "prod",
+				Endpoint: "plainq.example.com:443",
+				TLS:      &plainqTLS{CAFile: "/etc/plainq/ca.pem"},
+				Auth:     &plainqAuth{Token: "secret"},
+			},
+		},
+	}
+
+	td.CmpNoError(t, saveContextConfig(&want))
+
+	got, loadErr := loadContextConfig()
+	td.CmpNoError(t, loadErr)
+	td.Cmp(t, got, &want)
+
+	current, currentErr := got.current()
+	td.CmpNoError(t, currentErr)
+	td.Cmp(t, current.Endpoint, "plainq.example.com:443")
+
+	got.Current = "missing"
+	_, currentErr = got.current()
+	td.CmpError(t, currentErr)
+}
+
+func TestContextClientOptions(t *testing.T) {
+	type tcase struct {
+		ctx     plainqContext
+		want    int
+		wantErr bool
+	}
+
+	tests := map[string]tcase{
+		"Plain": {
+			ctx:  plainqContext{Name: "local", Endpoint: defaultEndpoint},
+			want: 0,
+		},
+
+		"TLSAndToken": {
+			ctx: plainqContext{
+				Name:     "prod",
+				Endpoint: "plainq.example.com:443",
+				TLS:      &plainqTLS{},
+				Auth:     &plainqAuth{Token: "secret"},
+			},
+			want: 2,
+		},
+
+		"MissingCA": {
+			ctx: plainqContext{
+				Name: "prod",
+				TLS:  &plainqTLS{CAFile: filepath.Join(t.TempDir(), "ca.pem")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.ctx.clientOptions()
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.CmpLen(t, got, tc.want)
+		})
+	}
+}
+
+func TestConnFlags_tlsOverride(t *testing.T) {
+	type tcase struct {
+		conn    connFlags
+		want    any
+		wantErr bool
+	}
+
+	tests := map[string]tcase{
+		"NoFlags": {conn: connFlags{addr: "localhost:8080"}, want: td.Nil()},
+		"TLS":     {conn: connFlags{useTLS: true}, want: &plainqTLS{}},
+		"Implied": {
+			conn: connFlags{tls: plainqTLS{InsecureSkipVerify: true}},
+			want: &plainqTLS{InsecureSkipVerify: true},
+		},
+		"CertWithoutKey": {conn: connFlags{tls: plainqTLS{CertFile: "cert.pem"}}, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.conn.tlsOverride()
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
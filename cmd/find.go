@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heartwilltell/scotty"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+)
+
+// entityKinds maps kind names accepted by the find command to entity kinds.
+var entityKinds = map[string]v1.EntityKind{
+	"queue": v1.EntityKind_ENTITY_KIND_QUEUE,
+	"user":  v1.EntityKind_ENTITY_KIND_USER,
+	"role":  v1.EntityKind_ENTITY_KIND_ROLE,
+	"audit": v1.EntityKind_ENTITY_KIND_AUDIT_EVENT,
+}
+
+func findCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		kinds   string
+		limit   uint
+		idsOnly bool
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "find",
+		Short: "Find queues, users, roles and recent audit events matching the query",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.StringVar(&kinds, "kind", "",
+				"limits the search to comma separated kinds: queue, user, role, audit",
+			)
+			flags.UintVar(&limit, "limit", 10,
+				"sets the maximum number of returned results",
+			)
+			flags.BoolVar(&idsOnly, "ids", false,
+				"prints only identifiers of found entities, the best match first",
+			)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) == 0 {
+				return errors.New("query should be specified: plainq find [flags...] [query]")
+			}
+
+			if limit > math.MaxUint32 {
+				return fmt.Errorf("limit value too large: %d", limit)
+			}
+
+			input := v1.SearchRequest{
+				Query: strings.Join(args, " "),
+				Limit: uint32(limit),
+			}
+
+			parsed, parseErr := parseEntityKinds(kinds)
+			if parseErr != nil {
+				return parseErr
+			}
+
+			input.Kinds = parsed
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			found, searchErr := cli.Search(ctx, &input)
+			if searchErr != nil {
+				return fmt.Errorf("search: %w", searchErr)
+			}
+
+			switch {
+			case jsonOut:
+				if err := pqjson.Encode(os.Stdout, found); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+
+			case idsOnly:
+				for _, r := range found.GetResults() {
+					if r.GetId() != "" {
+						fmt.Fprintln(os.Stdout, r.GetId())
+					}
+				}
+
+				return nil
+			}
+
+			return writeFindResults(os.Stdout, found)
+		},
+	}
+
+	return &cmd
+}
+
+// parseEntityKinds parses comma separated kind names.
+func parseEntityKinds(spec string) ([]v1.EntityKind, error) {
+	var kinds []v1.EntityKind
+
+	for name := range strings.SplitSeq(spec, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+
+		kind, ok := entityKinds[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown kind %q, should be one of: queue, user, role, audit", name)
+		}
+
+		kinds = append(kinds, kind)
+	}
+
+	return kinds, nil
+}
+
+// writeFindResults writes found entities to w as an aligned table.
+func writeFindResults(w io.Writer, found *v1.SearchResponse) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "KIND\tID\tNAME\tDETAIL")
+
+	for _, r := range found.GetResults() {
+		id := r.GetId()
+		if r.GetTime() != nil {
+			id = r.GetTime().AsTime().Local().Format(time.DateTime)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entityKindName(r.GetKind()), id, r.GetTitle(), r.GetDetail())
+	}
+
+	return tw.Flush()
+}
+
+// entityKindName returns the entity kind name in the form accepted by the find command.
+func entityKindName(k v1.EntityKind) string {
+	for name, kind := range entityKinds {
+		if kind == k {
+			return name
+		}
+	}
+
+	return "unspecified"
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_parseEntityKinds(t *testing.T) {
+	tests := map[string]struct {
+		spec    string
+		want    []v1.EntityKind
+		wantErr bool
+	}{
+		"Empty":   {spec: "", want: nil},
+		"Single":  {spec: "queue", want: []v1.EntityKind{v1.EntityKind_ENTITY_KIND_QUEUE}},
+		"List":    {spec: "user, audit", want: []v1.EntityKind{v1.EntityKind_ENTITY_KIND_USER, v1.EntityKind_ENTITY_KIND_AUDIT_EVENT}},
+		"Unknown": {spec: "queue,topic", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseEntityKinds(tc.spec)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
+
+func Test_writeFindResults(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+
+	var buf bytes.Buffer
+
+	td.CmpNoError(t, writeFindResults(&buf, &v1.SearchResponse{Results: []*v1.SearchResult{
+		{Kind: v1.EntityKind_ENTITY_KIND_QUEUE, Id: "Q1", Title: "orders", Detail: "team=billing"},
+		{Kind: v1.EntityKind_ENTITY_KIND_AUDIT_EVENT, Title: "Queue state has been changed", Time: timestamppb.New(at)},
+	}}))
+
+	td.Cmp(t, buf.String(), ""+
+		"KIND   ID                   NAME                          DETAIL\n"+
+		"queue  Q1                   orders                        team=billing\n"+
+		"audit  2024-05-01 10:30:00  Queue state has been changed  \n",
+	)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/heartwilltell/scotty"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+)
+
+func generateCommand() *scotty.Command {
+	cmd := scotty.Command{
+		Name:  "generate",
+		Short: "Manages synthetic producers for demos and testing",
+	}
+
+	cmd.AddSubcommands(generateSubcommands()...)
+
+	return &cmd
+}
+
+// generateSubcommands returns subcommands of the generate command.
+func generateSubcommands() []*scotty.Command {
+	return []*scotty.Command{
+		generateStartCommand(),
+		generateStopCommand(),
+		generateListCommand(),
+	}
+}
+
+func generateStartCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+
+		rate     uint
+		size     uint64
+		tmpl     string
+		duration time.Duration
+	)
+
+	cmd := scotty.Command{
+		Name:  "start",
+		Short: "Start sending generated messages to a queue",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+			flags.UintVar(&rate, "rate", 10,
+				"sets how many messages are sent each second",
+			)
+			flags.Uint64Var(&size, "size", 0,
+				"sets the minimal message size in bytes, the payload is padded with spaces",
+			)
+			flags.StringVar(&tmpl, "template", "",
+				"sets the Go text/template of the message body, fields: .Seq, .ID, .QueueID, .Time",
+			)
+			flags.DurationVar(&duration, "duration", 0,
+				"sets how long the producer runs, zero means until stopped",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("queue id should be specified: plainq generate start [queue id]")
+			}
+
+			id := args[0]
+
+			if err := validateQueueID(id); err != nil {
+				return err
+			}
+
+			if rate > math.MaxUint32 {
+				return fmt.Errorf("rate value too large: %d", rate)
+			}
+
+			if duration < 0 {
+				return errors.New("duration should not be negative")
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, startErr := cli.StartGenerator(ctx, &v1.StartGeneratorRequest{
+				QueueId:         id,
+				RatePerSecond:   uint32(rate),
+				SizeBytes:       size,
+				PayloadTemplate: tmpl,
+				DurationSeconds: uint64(duration.Seconds()),
+			})
+			if startErr != nil {
+				return fmt.Errorf("start generator (queue id: %q): %w", id, startErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			fmt.Println(output.GetGenerator().GetGeneratorId())
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func generateStopCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "stop",
+		Short: "Stop a synthetic producer",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("generator id should be specified: plainq generate stop [generator id]")
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, stopErr := cli.StopGenerator(ctx, &v1.StopGeneratorRequest{GeneratorId: args[0]})
+			if stopErr != nil {
+				return fmt.Errorf("stop generator (id: %q): %w", args[0], stopErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			printGenerator(output.GetGenerator())
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func generateListCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "list",
+		Short: "List running synthetic producers",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, _ []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, listErr := cli.ListGenerators(ctx, &v1.ListGeneratorsRequest{})
+			if listErr != nil {
+				return fmt.Errorf("list generators: %w", listErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			for _, g := range output.GetGenerators() {
+				printGenerator(g)
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func printGenerator(g *v1.Generator) {
+	fmt.Println(g.GetGeneratorId(), "|", g.GetQueueId(), "|",
+		g.GetRatePerSecond(), "msg/s", "|",
+		"sent:", g.GetMessagesSent(), "failed:", g.GetMessagesFailed(),
+	)
+
+	if g.GetLastError() != "" {
+		fmt.Println("  last error:", g.GetLastError())
+	}
+}
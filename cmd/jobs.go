@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heartwilltell/scotty"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+)
+
+func jobsCommand() *scotty.Command {
+	cmd := scotty.Command{
+		Name:  "jobs",
+		Short: "Manages background jobs, e.g. asynchronous purges",
+	}
+
+	cmd.AddSubcommands(jobsSubcommands()...)
+
+	return &cmd
+}
+
+// jobsSubcommands returns subcommands of the jobs command.
+func jobsSubcommands() []*scotty.Command {
+	return []*scotty.Command{
+		jobsListCommand(),
+		jobsGetCommand(),
+		jobsCancelCommand(),
+	}
+}
+
+func jobsListCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+
+		queueID string
+		state   string
+		limit   uint
+	)
+
+	cmd := scotty.Command{
+		Name:  "list",
+		Short: "List background jobs, the most recent first",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+			flags.StringVar(&queueID, "queue", "",
+				"lists only jobs of the queue, listing jobs of all queues requires the admin role",
+			)
+			flags.StringVar(&state, "state", "",
+				"lists only jobs in the state: running, succeeded, failed or canceled",
+			)
+			flags.UintVar(&limit, "limit", 0,
+				"sets the maximum number of listed jobs",
+			)
+		},
+		Run: func(_ *scotty.Command, _ []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if queueID != "" {
+				if err := validateQueueID(queueID); err != nil {
+					return err
+				}
+			}
+
+			if limit > math.MaxUint32 {
+				return fmt.Errorf("limit value too large: %d", limit)
+			}
+
+			in := v1.ListJobsRequest{QueueId: queueID, Limit: uint32(limit)}
+
+			if state != "" {
+				s, ok := v1.JobState_value["JOB_STATE_"+strings.ToUpper(state)]
+				if !ok {
+					return fmt.Errorf("unknown job state %q", state)
+				}
+
+				in.State = v1.JobState(s)
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, listErr := cli.ListJobs(ctx, &in)
+			if listErr != nil {
+				return fmt.Errorf("list jobs: %w", listErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			for _, job := range output.GetJobs() {
+				fmt.Println(formatJob(job))
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func jobsGetCommand() *scotty.Command {
+	var (
+		conn     connFlags
+		jsonOut  bool
+		watch    bool
+		interval time.Duration
+	)
+
+	cmd := scotty.Command{
+		Name:  "get",
+		Short: "Show a background job along with its progress",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+			flags.BoolVar(&watch, "watch", false,
+				"keeps refreshing the progress until the job stops",
+			)
+			flags.DurationVar(&interval, "interval", time.Second,
+				"sets the refresh interval of the watch mode",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("job id should be specified: plainq jobs get [job id]")
+			}
+
+			id := args[0]
+
+			if watch && interval < 100*time.Millisecond {
+				return fmt.Errorf("interval should be at least 100ms: %s", interval)
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				output, getErr := cli.GetJob(ctx, &v1.GetJobRequest{JobId: id})
+				if getErr != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+
+					return fmt.Errorf("get job (id: %q): %w", id, getErr)
+				}
+
+				if jsonOut {
+					if err := pqjson.Encode(os.Stdout, output); err != nil {
+						return fmt.Errorf("encode response: %w", err)
+					}
+				} else {
+					fmt.Println(formatJob(output.GetJob()))
+				}
+
+				if !watch || output.GetJob().GetState() != v1.JobState_JOB_STATE_RUNNING {
+					return nil
+				}
+
+				select {
+				case <-ctx.Done():
+					return nil
+
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+
+	return &cmd
+}
+
+func jobsCancelCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "cancel",
+		Short: "Stop a running background job",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("job id should be specified: plainq jobs cancel [job id]")
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, cancelErr := cli.CancelJob(ctx, &v1.CancelJobRequest{JobId: args[0]})
+			if cancelErr != nil {
+				return fmt.Errorf("cancel job (id: %q): %w", args[0], cancelErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			fmt.Println(formatJob(output.GetJob()))
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+// formatJob returns the line describing the job and its progress.
+func formatJob(job *v1.Job) string {
+	state := strings.ToLower(strings.TrimPrefix(job.GetState().String(), "JOB_STATE_"))
+	progress := strconv.FormatUint(job.GetProcessed(), 10) + "/" + strconv.FormatUint(job.GetTotal(), 10)
+
+	line := strings.Join([]string{job.GetJobId(), job.GetKind(), job.GetQueueId(), state, progress}, " | ")
+
+	if job.GetError() != "" {
+		line += " | " + job.GetError()
+	}
+
+	return line
+}
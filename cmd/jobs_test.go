@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+func Test_formatJob(t *testing.T) {
+	tests := map[string]struct {
+		job  *v1.Job
+		want string
+	}{
+		"Running": {
+			job:  &v1.Job{JobId: "job", Kind: "purge", QueueId: "queue", State: v1.JobState_JOB_STATE_RUNNING, Processed: 1000, Total: 2500},
+			want: "job | purge | queue | running | 1000/2500",
+		},
+		"Failed": {
+			job:  &v1.Job{JobId: "job", Kind: "purge", QueueId: "queue", State: v1.JobState_JOB_STATE_FAILED, Total: 10, Error: "interrupted by the server shutdown"},
+			want: "job | purge | queue | failed | 0/10 | interrupted by the server shutdown",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, formatJob(tc.job), tc.want)
+		})
+	}
+}
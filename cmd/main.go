@@ -30,9 +30,21 @@ func main() {
 		Name: "plainq",
 	}
 
-	rootCmd.AddSubcommands(
+	rootCmd.AddSubcommands(commands()...)
+
+	if err := rootCmd.Exec(); err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+}
+
+// commands returns top-level commands of the program.
+func commands() []*scotty.Command {
+	return []*scotty.Command{
 		versionCommand(),
 		contextCommand(),
+		whoamiCommand(),
+		completionCommand(),
 
 		// Serer commands.
 		serverCommand(),
@@ -42,14 +54,29 @@ func main() {
 		createQueueCommand(),
 		describeQueueCommand(),
 		purgeQueueCommand(),
+		jobsCommand(),
+		clusterCommand(),
+		storageCommand(),
 		deleteQueueCommand(),
+		stateCommand(),
+		renameCommand(),
 		sendCommand(),
+		sendBatchCommand(),
 		receiveCommand(),
-	)
-
-	if err := rootCmd.Exec(); err != nil {
-		fmt.Println(err)
-		os.Exit(2)
+		ackCommand(),
+		tailCommand(),
+		adviseCommand(),
+		statsCommand(),
+		benchCommand(),
+		searchCommand(),
+		findCommand(),
+		peekCommand(),
+		auditCommand(),
+		accountCommand(),
+		apiKeyCommand(),
+		rbacCommand(),
+		generateCommand(),
+		migrateCommand(),
 	}
 }
 
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/heartwilltell/scotty"
+	"github.com/plainq/plainq/internal/migrate"
+)
+
+func migrateCommand() *scotty.Command {
+	cmd := scotty.Command{
+		Name:  "migrate-from",
+		Short: "Recreates queues of another message broker in plainq",
+	}
+
+	cmd.AddSubcommands(migrateSubcommands()...)
+
+	return &cmd
+}
+
+// migrateSubcommands returns subcommands of the migrate-from command.
+func migrateSubcommands() []*scotty.Command {
+	return []*scotty.Command{
+		migrateSQSCommand(),
+		migrateRabbitMQCommand(),
+	}
+}
+
+// migrateFlags holds flags shared by migrate-from subcommands.
+type migrateFlags struct {
+	conn connFlags
+
+	drain   bool
+	dryRun  bool
+	prefix  string
+	report  string
+	jsonOut bool
+}
+
+// register registers migration flags in the flag set.
+func (f *migrateFlags) register(flags *scotty.FlagSet) {
+	f.conn.register(flags)
+
+	flags.BoolVar(&f.drain, "drain", false,
+		"moves messages of migrated queues to plainq, removing them from the source",
+	)
+	flags.BoolVar(&f.dryRun, "dry-run", false,
+		"reports planned changes without creating queues",
+	)
+	flags.StringVar(&f.prefix, "prefix", "",
+		"sets the prefix of names of created queues",
+	)
+	flags.StringVar(&f.report, "report", "",
+		"writes the json migration report to the file",
+	)
+	flags.BoolVar(&f.jsonOut, "json", false,
+		"enables json output",
+	)
+}
+
+// run migrates queues with given names, or all queues, from the source.
+func (f *migrateFlags) run(source migrate.Source, names []string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	cli, cliErr := newClient(&f.conn)
+	if cliErr != nil {
+		return fmt.Errorf("create client: %w", cliErr)
+	}
+
+	report, migrateErr := migrate.Migrate(ctx, source, cli,
+		migrate.WithDrain(f.drain),
+		migrate.WithDryRun(f.dryRun),
+		migrate.WithPrefix(f.prefix),
+		migrate.WithQueues(names...),
+	)
+	if migrateErr != nil {
+		return fmt.Errorf("migrate from %s: %w", source.Name(), migrateErr)
+	}
+
+	if f.report != "" {
+		data, marshalErr := json.MarshalIndent(report, "", "  ")
+		if marshalErr != nil {
+			return fmt.Errorf("encode report: %w", marshalErr)
+		}
+
+		if err := os.WriteFile(f.report, data, 0o600); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+	}
+
+	if f.jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("encode report: %w", err)
+		}
+	} else if err := writeMigrationReport(os.Stdout, report); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("%d of %d queues failed to migrate", failed, len(report.Queues))
+	}
+
+	return nil
+}
+
+// writeMigrationReport writes the migration report to w as an aligned table followed by notes.
+func writeMigrationReport(w io.Writer, report *migrate.Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "SOURCE\tNAME\tID\tSTATUS\tMESSAGES")
+
+	for _, q := range report.Queues {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n",
+			q.Source,
+			q.Name,
+			q.QueueID,
+			q.Status,
+			q.Messages,
+		)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, q := range report.Queues {
+		details := q.Notes
+		if q.Error != "" {
+			details = append([]string{"error: " + q.Error}, details...)
+		}
+
+		if len(details) > 0 {
+			fmt.Fprintf(w, "\n%s:\n  %s\n", q.Source, strings.Join(details, "\n  "))
+		}
+	}
+
+	return nil
+}
+
+func migrateSQSCommand() *scotty.Command {
+	var (
+		flags migrateFlags
+
+		region   string
+		endpoint string
+		prefix   string
+	)
+
+	cmd := scotty.Command{
+		Name:  "sqs",
+		Short: "Recreates Amazon SQS queues in plainq, credentials are read from AWS_* environment variables",
+		SetFlags: func(fs *scotty.FlagSet) {
+			flags.register(fs)
+
+			fs.StringVar(&region, "region", cmp.Or(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")),
+				"sets the AWS region of queues",
+			)
+			fs.StringVar(&endpoint, "endpoint", "",
+				"overrides the SQS endpoint, e.g. for LocalStack",
+			)
+			fs.StringVar(&prefix, "name-prefix", "",
+				"migrates only queues which names start with the prefix",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			source, sourceErr := migrate.NewSQS(migrate.SQSConfig{
+				Region:          region,
+				Endpoint:        endpoint,
+				Prefix:          prefix,
+				AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+				SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+				SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+			})
+			if sourceErr != nil {
+				return sourceErr
+			}
+
+			return flags.run(source, args)
+		},
+	}
+
+	return &cmd
+}
+
+func migrateRabbitMQCommand() *scotty.Command {
+	var (
+		flags migrateFlags
+
+		url      string
+		vhost    string
+		username string
+		password string
+	)
+
+	cmd := scotty.Command{
+		Name:  "rabbitmq",
+		Short: "Recreates RabbitMQ queues in plainq using the management API",
+		SetFlags: func(fs *scotty.FlagSet) {
+			flags.register(fs)
+
+			fs.StringVar(&url, "url", "http://localhost:15672",
+				"sets the address of the RabbitMQ management API",
+			)
+			fs.StringVar(&vhost, "vhost", "/",
+				"sets the virtual host of queues",
+			)
+			fs.StringVar(&username, "username", "guest",
+				"sets the management API user",
+			)
+			fs.StringVar(&password, "password", "",
+				"sets the management API password, defaults to RABBITMQ_PASSWORD or guest",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			if password == "" {
+				password = cmp.Or(os.Getenv("RABBITMQ_PASSWORD"), "guest")
+			}
+
+			source, sourceErr := migrate.NewRabbitMQ(migrate.RabbitMQConfig{
+				URL:      url,
+				VHost:    vhost,
+				Username: username,
+				Password: password,
+			})
+			if sourceErr != nil {
+				return sourceErr
+			}
+
+			return flags.run(source, args)
+		},
+	}
+
+	return &cmd
+}
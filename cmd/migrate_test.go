@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/migrate"
+)
+
+func Test_writeMigrationReport(t *testing.T) {
+	report := migrate.Report{
+		Source: "sqs",
+		Queues: []migrate.QueueReport{
+			{Source: "orders", Name: "orders", QueueID: "q1", Status: migrate.StatusCreated, Messages: 10},
+			{
+				Source: "orders dlq", Name: "orders-dlq", Status: migrate.StatusFailed,
+				Notes: []string{"content based deduplication is not supported"},
+				Error: "create queue: unavailable",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+
+	td.CmpNoError(t, writeMigrationReport(&buf, &report))
+	td.Cmp(t, buf.String(), ""+
+		"SOURCE      NAME        ID  STATUS   MESSAGES\n"+
+		"orders      orders      q1  created  10\n"+
+		"orders dlq  orders-dlq      failed   0\n"+
+		"\n"+
+		"orders dlq:\n"+
+		"  error: create queue: unavailable\n"+
+		"  content based deduplication is not supported\n",
+	)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heartwilltell/scotty"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+)
+
+func peekCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		cursor  string
+		limit   uint
+		noBody  bool
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "peek",
+		Short: "Look at messages of the queue without receiving them",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.StringVar(&cursor, "cursor", "",
+				"sets the cursor of the page to start from",
+			)
+			flags.UintVar(&limit, "limit", 20,
+				"sets the maximum number of returned messages",
+			)
+			flags.BoolVar(&noBody, "no-body", false,
+				"lists messages without bodies, which doesn't require the receive permission",
+			)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("queue id should be specified: plainq peek [flags...] [queue id]")
+			}
+
+			id := args[0]
+
+			if err := validateQueueID(id); err != nil {
+				return err
+			}
+
+			if limit > math.MaxUint32 {
+				return fmt.Errorf("limit value too large: %d", limit)
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			peek, peekErr := cli.PeekMessages(ctx, &v1.PeekMessagesRequest{
+				QueueId:     id,
+				Cursor:      cursor,
+				Limit:       uint32(limit),
+				IncludeBody: !noBody,
+			})
+			if peekErr != nil {
+				return fmt.Errorf("peek messages: %w", peekErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, peek); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			if err := writePeekedMessages(os.Stdout, peek.GetMessages()); err != nil {
+				return err
+			}
+
+			if next := peek.GetNextCursor(); next != "" {
+				fmt.Fprintf(os.Stderr, "More messages available, use --cursor=%s to see the next page\n", next)
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+// writePeekedMessages writes messages to w as an aligned table.
+func writePeekedMessages(w io.Writer, messages []*v1.PeekMessage) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ID\tSENT\tVISIBLE\tATTEMPTS\tSIZE\tBODY")
+
+	for _, m := range messages {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\n",
+			m.GetId(),
+			m.GetCreatedAt().AsTime().Local().Format(time.DateTime),
+			m.GetVisibleAt().AsTime().Local().Format(time.DateTime),
+			m.GetAttempts(),
+			m.GetSizeBytes(),
+			bodyPreview(m.GetBody()),
+		)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,459 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heartwilltell/scotty"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+)
+
+func rbacCommand() *scotty.Command {
+	cmd := scotty.Command{
+		Name:  "rbac",
+		Short: "Manages roles, their users and their permissions on queues",
+	}
+
+	cmd.AddSubcommands(rbacSubcommands()...)
+
+	return &cmd
+}
+
+// rbacSubcommands returns subcommands of the rbac command.
+func rbacSubcommands() []*scotty.Command {
+	return []*scotty.Command{
+		rbacRoleCommand(),
+		rbacAssignCommand(),
+		rbacUnassignCommand(),
+		rbacGrantCommand(),
+		rbacRevokeCommand(),
+		rbacPermissionsCommand(),
+	}
+}
+
+func rbacRoleCommand() *scotty.Command {
+	cmd := scotty.Command{
+		Name:  "role",
+		Short: "Manages roles",
+	}
+
+	cmd.AddSubcommands(rbacRoleSubcommands()...)
+
+	return &cmd
+}
+
+// rbacRoleSubcommands returns subcommands of the rbac role command.
+func rbacRoleSubcommands() []*scotty.Command {
+	return []*scotty.Command{
+		rbacRoleListCommand(),
+		rbacRoleCreateCommand(),
+		rbacRoleDeleteCommand(),
+	}
+}
+
+func rbacRoleListCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "list",
+		Short: "List roles with users they are assigned to",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, _ []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, listErr := cli.ListRoles(ctx, &v1.ListRolesRequest{})
+			if listErr != nil {
+				return fmt.Errorf("list roles: %w", listErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			return writeRoles(os.Stdout, output.GetRoles())
+		},
+	}
+
+	return &cmd
+}
+
+func rbacRoleCreateCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "create",
+		Short: "Create a role, which is granted nothing until permissions are granted to it",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("role name should be specified: plainq rbac role create [role]")
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, createErr := cli.CreateRole(ctx, &v1.CreateRoleRequest{Name: args[0]})
+			if createErr != nil {
+				return fmt.Errorf("create role %q: %w", args[0], createErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			fmt.Println(output.GetRole().GetRoleId())
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func rbacRoleDeleteCommand() *scotty.Command {
+	var conn connFlags
+
+	cmd := scotty.Command{
+		Name:  "delete",
+		Short: "Delete a role along with its permissions and assignments",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("role name should be specified: plainq rbac role delete [role]")
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			if _, err := cli.DeleteRole(ctx, &v1.DeleteRoleRequest{Name: args[0]}); err != nil {
+				return fmt.Errorf("delete role %q: %w", args[0], err)
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func rbacAssignCommand() *scotty.Command {
+	var conn connFlags
+
+	cmd := scotty.Command{
+		Name:  "assign",
+		Short: "Assign a role to a user",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 2 {
+				return errors.New("user email and role name should be specified: plainq rbac assign [email] [role]")
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			if _, err := cli.AssignRole(ctx, &v1.AssignRoleRequest{Email: args[0], Role: args[1]}); err != nil {
+				return fmt.Errorf("assign role %q to %q: %w", args[1], args[0], err)
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func rbacUnassignCommand() *scotty.Command {
+	var conn connFlags
+
+	cmd := scotty.Command{
+		Name:  "unassign",
+		Short: "Take a role away from a user",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 2 {
+				return errors.New("user email and role name should be specified: plainq rbac unassign [email] [role]")
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			if _, err := cli.UnassignRole(ctx, &v1.UnassignRoleRequest{Email: args[0], Role: args[1]}); err != nil {
+				return fmt.Errorf("unassign role %q from %q: %w", args[1], args[0], err)
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func rbacGrantCommand() *scotty.Command {
+	var (
+		conn       connFlags
+		jsonOut    bool
+		permission v1.QueuePermission
+	)
+
+	cmd := scotty.Command{
+		Name:  "grant",
+		Short: "Grant a role permissions on a queue, replacing permissions granted before",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+			flags.BoolVar(&permission.Send, "send", false,
+				"allows sending messages to the queue",
+			)
+			flags.BoolVar(&permission.Receive, "receive", false,
+				"allows receiving, deleting and changing visibility of messages of the queue",
+			)
+			flags.BoolVar(&permission.Purge, "purge", false,
+				"allows purging the queue",
+			)
+			flags.BoolVar(&permission.Delete, "delete", false,
+				"allows deleting the queue",
+			)
+			flags.BoolVar(&permission.Update, "update", false,
+				"allows updating properties and the state of the queue, and transferring it",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 2 {
+				return errors.New("queue id and role name should be specified: plainq rbac grant [queue id] [role]")
+			}
+
+			if operations(&permission) == "" {
+				return errors.New("at least one of --send, --receive, --purge, --delete or --update should be set, " +
+					"use 'plainq rbac revoke' to revoke permissions")
+			}
+
+			permission.QueueId, permission.Role = args[0], args[1]
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, setErr := cli.SetQueuePermission(ctx, &v1.SetQueuePermissionRequest{Permission: &permission})
+			if setErr != nil {
+				return fmt.Errorf("grant role %q permissions on queue (id: %q): %w", args[1], args[0], setErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func rbacRevokeCommand() *scotty.Command {
+	var conn connFlags
+
+	cmd := scotty.Command{
+		Name:  "revoke",
+		Short: "Revoke all permissions of a role on a queue",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 2 {
+				return errors.New("queue id and role name should be specified: plainq rbac revoke [queue id] [role]")
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			if _, err := cli.DeleteQueuePermission(ctx, &v1.DeleteQueuePermissionRequest{QueueId: args[0], Role: args[1]}); err != nil {
+				return fmt.Errorf("revoke role %q permissions on queue (id: %q): %w", args[1], args[0], err)
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func rbacPermissionsCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "permissions",
+		Short: "List permissions of roles on a queue",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("queue id should be specified: plainq rbac permissions [queue id]")
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, listErr := cli.ListQueuePermissions(ctx, &v1.ListQueuePermissionsRequest{QueueId: args[0]})
+			if listErr != nil {
+				return fmt.Errorf("list permissions on queue (id: %q): %w", args[0], listErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			return writeQueuePermissions(os.Stdout, output.GetPermissions())
+		},
+	}
+
+	return &cmd
+}
+
+// operations returns comma separated operations allowed by the permission.
+func operations(p *v1.QueuePermission) string {
+	allowed := make([]string, 0, 5)
+
+	for _, op := range []struct {
+		name    string
+		allowed bool
+	}{
+		{name: "send", allowed: p.GetSend()},
+		{name: "receive", allowed: p.GetReceive()},
+		{name: "purge", allowed: p.GetPurge()},
+		{name: "delete", allowed: p.GetDelete()},
+		{name: "update", allowed: p.GetUpdate()},
+	} {
+		if op.allowed {
+			allowed = append(allowed, op.name)
+		}
+	}
+
+	return strings.Join(allowed, ",")
+}
+
+// writeRoles writes roles to w as an aligned table.
+func writeRoles(w io.Writer, roles []*v1.Role) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "NAME\tUSERS\tCREATED")
+
+	for _, r := range roles {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n",
+			r.GetName(),
+			strings.Join(r.GetUsers(), ","),
+			r.GetCreatedAt().AsTime().Local().Format(time.DateTime),
+		)
+	}
+
+	return tw.Flush()
+}
+
+// writeQueuePermissions writes permissions of roles on the queue to w as an aligned table.
+func writeQueuePermissions(w io.Writer, permissions []*v1.QueuePermission) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ROLE\tOPERATIONS\tUPDATED")
+
+	for _, p := range permissions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n",
+			p.GetRole(),
+			operations(p),
+			p.GetUpdatedAt().AsTime().Local().Format(time.DateTime),
+		)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_writeRoles(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+
+	var buf bytes.Buffer
+
+	td.CmpNoError(t, writeRoles(&buf, []*v1.Role{
+		{Name: "admin", Users: []string{"alice@example.com"}, CreatedAt: timestamppb.New(at)},
+		{Name: "payments", Users: []string{"bob@example.com", "carol@example.com"}, CreatedAt: timestamppb.New(at)},
+	}))
+
+	td.Cmp(t, buf.String(), ""+
+		"NAME      USERS                              CREATED\n"+
+		"admin     alice@example.com                  2024-05-01 10:30:00\n"+
+		"payments  bob@example.com,carol@example.com  2024-05-01 10:30:00\n",
+	)
+}
+
+func Test_writeQueuePermissions(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+
+	var buf bytes.Buffer
+
+	td.CmpNoError(t, writeQueuePermissions(&buf, []*v1.QueuePermission{
+		{Role: "payments", Send: true, Receive: true, UpdatedAt: timestamppb.New(at)},
+		{Role: "ops", Purge: true, Delete: true, Update: true, UpdatedAt: timestamppb.New(at)},
+		{Role: "auditors", UpdatedAt: timestamppb.New(at)},
+	}))
+
+	td.Cmp(t, buf.String(), ""+
+		"ROLE      OPERATIONS           UPDATED\n"+
+		"payments  send,receive         2024-05-01 10:30:00\n"+
+		"ops       purge,delete,update  2024-05-01 10:30:00\n"+
+		"auditors                       2024-05-01 10:30:00\n",
+	)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/heartwilltell/scotty"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+	"github.com/plainq/plainq/internal/shared/pqname"
+)
+
+func renameCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "rename",
+		Short: "Rename a queue, names are unique",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 2 {
+				return errors.New("queue id and name should be specified: plainq rename [queue id] [name]")
+			}
+
+			id, name := args[0], args[1]
+
+			if err := validateQueueID(id); err != nil {
+				return err
+			}
+
+			if err := pqname.ValidateQueueName(name); err != nil {
+				return err
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			// The update is applied to the current version of the queue,
+			// so other properties stay as they are.
+			info, describeErr := cli.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: id})
+			if describeErr != nil {
+				return fmt.Errorf("describe queue: %w", describeErr)
+			}
+
+			output, updateErr := cli.UpdateQueue(ctx, &v1.UpdateQueueRequest{
+				QueueId:   id,
+				Version:   info.GetVersion(),
+				QueueName: name,
+			})
+			if updateErr != nil {
+				return fmt.Errorf("rename queue: %w", updateErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			fmt.Fprintf(os.Stdout, "Queue %q is renamed from %q to %q\n", id, info.GetQueueName(), name)
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heartwilltell/scotty"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// searchPreviewLen limits the length of the message body preview in the search results.
+const searchPreviewLen = 80
+
+func searchCommand() *scotty.Command {
+	var (
+		conn        connFlags
+		contains    string
+		jsonPath    string
+		jsonValue   string
+		since       time.Duration
+		minAttempts uint
+		maxAttempts int
+		limit       uint
+		jsonOut     bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "search",
+		Short: "Search messages of the queue by their content, send time and receive attempts",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.StringVar(&contains, "contains", "",
+				"finds only messages whose body contains the given substring",
+			)
+			flags.StringVar(&jsonPath, "json-path", "",
+				"finds only messages with the JSON body which has a value at the given path, e.g. $.order.id",
+			)
+			flags.StringVar(&jsonValue, "json-value", "",
+				"finds only messages whose value at the --json-path equals the given one",
+			)
+			flags.DurationVar(&since, "since", 0,
+				"finds only messages sent within the given duration, e.g. 1h",
+			)
+			flags.UintVar(&minAttempts, "min-attempts", 0,
+				"finds only messages received at least the given number of times",
+			)
+			flags.IntVar(&maxAttempts, "max-attempts", -1,
+				"finds only messages received at most the given number of times, 0 finds never received ones",
+			)
+			flags.UintVar(&limit, "limit", 20,
+				"sets the maximum number of returned messages",
+			)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("queue id should be specified: plainq search [flags...] [queue id] [query]")
+			}
+
+			id := args[0]
+
+			if err := validateQueueID(id); err != nil {
+				return err
+			}
+
+			if limit > math.MaxUint32 {
+				return fmt.Errorf("limit value too large: %d", limit)
+			}
+
+			if minAttempts > math.MaxUint32 || maxAttempts > math.MaxUint32 {
+				return errors.New("attempts value too large")
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			input := v1.SearchMessagesRequest{
+				QueueId:     id,
+				Query:       strings.Join(args[1:], " "),
+				Contains:    contains,
+				JsonPath:    jsonPath,
+				JsonValue:   jsonValue,
+				MinAttempts: uint32(minAttempts),
+				Limit:       uint32(limit),
+			}
+
+			if since > 0 {
+				input.From = timestamppb.New(time.Now().Add(-since))
+			}
+
+			if maxAttempts >= 0 {
+				input.MaxAttempts = proto.Uint32(uint32(maxAttempts))
+			}
+
+			found, searchErr := cli.SearchMessages(ctx, &input)
+			if searchErr != nil {
+				return fmt.Errorf("search messages: %w", searchErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, found); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			return writeSearchResults(os.Stdout, found)
+		},
+	}
+
+	return &cmd
+}
+
+// writeSearchResults writes found messages to w as an aligned table.
+func writeSearchResults(w io.Writer, found *v1.SearchMessagesResponse) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ID\tATTEMPTS\tBODY")
+
+	for _, m := range found.GetMessages() {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", m.GetId(), m.GetAttempts(), bodyPreview(m.GetBody()))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if found.GetTruncated() {
+		fmt.Fprintln(w, "More messages match the query, narrow it down or increase --limit.")
+	}
+
+	return nil
+}
+
+// bodyPreview returns the single-line beginning of the message body.
+func bodyPreview(body []byte) string {
+	preview := strings.Join(strings.Fields(string(body)), " ")
+
+	if r := []rune(preview); len(r) > searchPreviewLen {
+		preview = string(r[:searchPreviewLen-3]) + "..."
+	}
+
+	return preview
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func Test_bodyPreview(t *testing.T) {
+	tests := map[string]struct {
+		body string
+		want string
+	}{
+		"Empty":     {body: "", want: ""},
+		"Short":     {body: `{"order": 42}`, want: `{"order": 42}`},
+		"Multiline": {body: "{\n  \"order\": 42\n}\n", want: `{ "order": 42 }`},
+		"Long":      {body: strings.Repeat("a", 100), want: strings.Repeat("a", 77) + "..."},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, bodyPreview([]byte(tc.body)), tc.want)
+		})
+	}
+}
@@ -2,253 +2,1355 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
+	"maps"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/heartwilltell/hc"
 	"github.com/heartwilltell/scotty"
 	"github.com/plainq/plainq/internal/server"
+	"github.com/plainq/plainq/internal/server/alerting"
+	"github.com/plainq/plainq/internal/server/backup"
+	"github.com/plainq/plainq/internal/server/cluster"
 	"github.com/plainq/plainq/internal/server/config"
+	"github.com/plainq/plainq/internal/server/health"
+	"github.com/plainq/plainq/internal/server/lease"
+	"github.com/plainq/plainq/internal/server/logging"
 	"github.com/plainq/plainq/internal/server/mutations"
+	"github.com/plainq/plainq/internal/server/rbac"
+	"github.com/plainq/plainq/internal/server/reload"
 	"github.com/plainq/plainq/internal/server/storage/litestore"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/plainq/internal/server/tracing"
 	"github.com/plainq/servekit/dbkit/litekit"
 	"github.com/plainq/servekit/logkit"
+	"go.opentelemetry.io/otel/metric"
 )
 
+// otlpShutdownTimeout limits the time of flushing pending spans and metrics on shutdown.
+const otlpShutdownTimeout = 5 * time.Second
+
 func serverCommand() *scotty.Command {
 	var cfg config.Config
 
 	cmd := scotty.Command{
-		Name:  "server",
-		Short: "Runs the PlainQ server",
-		SetFlags: func(f *scotty.FlagSet) {
-			// Storage.
+		Name:     "server",
+		Short:    "Runs the PlainQ server",
+		SetFlags: func(f *scotty.FlagSet) { serverFlags(f, &cfg) },
+		Run: func(c *scotty.Command, _ []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
 
-			f.BoolVar(&cfg.StorageLogEnable, "storage.log.enable", false,
-				"enable logging for storage engine",
-			)
+			// Options given on the command line keep
+			// their values when the configuration is reloaded.
+			cmdline := make(map[string]string)
 
-			f.StringVar(&cfg.StorageDBPath, "storage.path", "",
-				"set path to SQLite database file",
-			)
+			c.Flags().Visit(func(f *flag.Flag) { cmdline[f.Name] = f.Value.String() })
 
-			f.DurationVar(&cfg.StorageGCTimeout, "storage.gc.timeout", 0,
-				"set storage GC timeout",
-			)
+			if err := applyConfigSources(c.Flags().FlagSet, os.LookupEnv); err != nil {
+				return err
+			}
 
-			f.StringVar(&cfg.StorageAccessMode, "storage.access-mode", "",
-				"set the sqlite storage access mode",
-			)
+			loggers, loggersErr := initLoggers(&cfg)
+			if loggersErr != nil {
+				return loggersErr
+			}
 
-			f.StringVar(&cfg.StorageJournalMode, "storage.journal-mode", "",
-				"set the sqlite storage journal mode",
-			)
+			logger := loggers.Logger(logging.Server)
 
-			// Logs.
+			logger.Info("Starting plainq server")
 
-			f.BoolVar(&cfg.LogEnable, "log.enable", true,
-				"enable logging",
-			)
+			// Tracing initialization.
 
-			f.BoolVar(&cfg.LogAccessEnable, "log.access.enable", true,
-				"enable access logging",
-			)
+			if cfg.TelemetryOTLPEnable {
+				shutdown, tracingErr := initTracing(ctx, &cfg)
+				if tracingErr != nil {
+					return tracingErr
+				}
 
-			f.StringVar(&cfg.LogLevel, "log.level", "info",
-				"set logging level: 'debug', 'info', 'warning', 'error'",
-			)
+				defer func() {
+					// Pending spans are flushed after the server has stopped.
+					shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), otlpShutdownTimeout)
+					defer shutdownCancel()
+
+					if err := shutdown(shutdownCtx); err != nil {
+						logger.Error("Failed to shutdown tracing",
+							slog.String("error", err.Error()),
+						)
+					}
+				}()
+			}
 
-			// Telemetry.
+			// Storage initialization.
 
-			f.BoolVar(&cfg.TelemetryEnabled, "telemetry.enable", true,
-				"enable telemetry subsystem",
-			)
+			var meter metric.Meter
 
-			f.StringVar(&cfg.TelemetryProvider, "telemetry.provider", "sqlite",
-				"set telemetry provider",
-			)
+			if cfg.TelemetryOTLPMetricsEnable {
+				provider, shutdown, metricsErr := initOTLPMetrics(ctx, &cfg)
+				if metricsErr != nil {
+					return metricsErr
+				}
 
-			f.BoolVar(&cfg.TelemetryLogEnable, "telemetry.log.enable", false,
-				"enable logging for telemetry subsystem",
-			)
+				defer func() {
+					// The last metrics are exported after the server has stopped.
+					shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), otlpShutdownTimeout)
+					defer shutdownCancel()
 
-			f.DurationVar(&cfg.TelemetryLiteScrapeTimeout, "telemetry.sqlite.collection.timeout", 10*time.Second,
-				"set telemetry collection timeout",
-			)
+					if err := shutdown(shutdownCtx); err != nil {
+						logger.Error("Failed to shutdown OTLP metrics export",
+							slog.String("error", err.Error()),
+						)
+					}
+				}()
 
-			f.DurationVar(&cfg.TelemetryLiteGCTimeout, "telemetry.sqlite.gc.timeout", 10*time.Minute,
-				"set telemetry GC timeout",
-			)
+				meter = provider.Meter("github.com/plainq/plainq")
+			}
 
-			f.DurationVar(&cfg.TelemetryLiteRetentionPeriod, "telemetry.sqlite.retention.period", 14*24*time.Hour,
-				"set telemetry retention period",
-			)
+			observer, observerErr := initObserver(&cfg, meter)
+			if observerErr != nil {
+				return observerErr
+			}
 
-			f.StringVar(&cfg.TelemetryPromBaseURL, "telemetry.prometheus.baseurl", "",
-				"set Prometheus API base URL",
-			)
+			if cfg.TelemetryRemoteWriteURL != "" {
+				writer, writerErr := initRemoteWriter(&cfg, loggers)
+				if writerErr != nil {
+					return writerErr
+				}
 
-			// Listeners & PlainQ.
+				writerCtx, writerCancel := context.WithCancel(ctx)
+				done := make(chan struct{})
 
-			f.StringVar(&cfg.GRPCAddr, "grpc.addr", ":8080",
-				"set gRPC listener address",
-			)
+				go func() {
+					defer close(done)
+					writer.Run(writerCtx)
+				}()
 
-			f.StringVar(&cfg.HTTPAddr, "http.addr", ":8081",
-				"set HTTP listener address",
-			)
+				// The last values are pushed after the server has stopped.
+				defer func() {
+					writerCancel()
+					<-done
+				}()
+			}
 
-			f.DurationVar(&cfg.HTTPReadHeaderTimeout, "http.read-header-timeout", 0,
-				"",
-			)
+			sqliteStorage, leader, storageInitErr := initStorage(&cfg, loggers, observer)
+			if storageInitErr != nil {
+				return storageInitErr
+			}
 
-			f.DurationVar(&cfg.HTTPReadTimeout, "http.read-timeout", 0,
-				"",
-			)
+			defer func() {
+				// Writes are handed over to another server before the storage is closed.
+				if leader != nil {
+					if err := leader.Close(); err != nil {
+						logger.Error("Failed to hand writes over to another server",
+							slog.String("error", err.Error()),
+						)
+					}
+				}
 
-			f.DurationVar(&cfg.HTTPWriteTimeout, "http.write-timeout", 0,
-				"",
-			)
+				if err := sqliteStorage.Close(); err != nil {
+					logger.Error("Failed to close storage database connection",
+						slog.String("error", err.Error()),
+					)
+				}
+			}()
 
-			f.DurationVar(&cfg.HTTPIdleTimeout, "http.idle-timeout", 0,
-				"",
-			)
+			// Roles of the replica are seeded by the primary server, and roles
+			// of servers which elect the leader are seeded by each elected one.
+			switch {
+			case leader != nil:
+				leader.OnLead(func(ctx context.Context) error {
+					if err := sqliteStorage.SeedRoles(ctx, rbac.Templates); err != nil {
+						return fmt.Errorf("seed roles: %w", err)
+					}
+
+					return nil
+				})
+
+			case cfg.ReplicaOf == "":
+				if err := sqliteStorage.SeedRoles(ctx, rbac.Templates); err != nil {
+					return fmt.Errorf("seed roles: %w", err)
+				}
+			}
 
-			// Metrics.
+			history, closeHistory, historyErr := initHistory(ctx, &cfg, loggers)
+			if historyErr != nil {
+				return historyErr
+			}
 
-			f.BoolVar(&cfg.MetricsEnable, "metrics", true,
-				"enable the metrics endpoint",
-			)
+			defer closeHistory()
 
-			f.BoolVar(&cfg.MetricsRouteLogs, "metrics.route.logs", false,
-				"turn on access logs for metrics endpoint",
-			)
+			var alerts *alerting.Engine
 
-			f.BoolVar(&cfg.MetricsRouteMetrics, "metrics.route.metrics", false,
-				"turn on metrics for metrics endpoint",
-			)
+			if cfg.AlertingEnable && cfg.ReplicaOf != "" {
+				logger.Warn("Alerting is disabled on the read-only replica, alerts are evaluated by the primary server")
+			}
 
-			f.StringVar(&cfg.MetricsRoute, "metrics.route", "/metrics",
-				"set given route as metrics endpoint route",
-			)
+			if cfg.AlertingEnable && cfg.ReplicaOf == "" {
+				engine, engineErr := initAlerting(&cfg, loggers, sqliteStorage)
+				if engineErr != nil {
+					return engineErr
+				}
 
-			// Health.
+				// Alerts are evaluated by the elected leader, so notifications are sent once.
+				if leader != nil {
+					leader.OnLead(func(leadCtx context.Context) error {
+						go engine.Run(leadCtx)
+						return nil
+					})
+				} else {
+					go engine.Run(ctx)
+				}
 
-			f.BoolVar(&cfg.HealthEnable, "health", true,
-				"enable the metrics endpoint",
-			)
+				alerts = engine
+			}
 
-			f.BoolVar(&cfg.HealthRouteLogs, "health.route.logs", false,
-				"turn on access logs for metrics endpoint",
-			)
+			if cfg.BackupEnable && cfg.ReplicaOf != "" {
+				logger.Warn("Backups are disabled on the read-only replica, databases are backed up by the primary server")
+			}
 
-			f.BoolVar(&cfg.HealthRouteMetrics, "health.route.metrics", false,
-				"turn on metrics for metrics endpoint",
-			)
+			if cfg.BackupEnable && cfg.ReplicaOf == "" {
+				scheduler, schedulerErr := initBackup(&cfg, loggers, observer, sqliteStorage, history)
+				if schedulerErr != nil {
+					return schedulerErr
+				}
 
-			f.StringVar(&cfg.HealthRoute, "health.route", "/health",
-				"set given route as metrics endpoint route",
-			)
+				// Databases are backed up by the elected leader, so backups are made once.
+				if leader != nil {
+					leader.OnLead(func(leadCtx context.Context) error {
+						go scheduler.Run(leadCtx)
+						return nil
+					})
+				} else {
+					go scheduler.Run(ctx)
+				}
+			}
+
+			checker := initHealth(&cfg, sqliteStorage, history)
 
-			// CORS.
+			if leader != nil {
+				checker.Add(health.Check{Name: "leader", Func: leader.Health})
+			}
 
-			f.BoolVar(&cfg.CORSEnable, "cors", true,
-				"enable CORS configuration for Houston API routes",
+			reloader := reload.New(&cfg, func() (*config.Config, error) { return loadServerConfig(cmdline) }, logger)
+			reloader.Register(
+				reload.Setting{
+					Name:  "log.level",
+					Value: func(cfg *config.Config) string { return cfg.LogLevel },
+					Apply: func(cfg *config.Config) error { return reloadLogLevels(cfg, loggers) },
+				},
+				reload.Setting{
+					Name:  "log.levels",
+					Value: func(cfg *config.Config) string { return cfg.LogLevels },
+					Apply: func(cfg *config.Config) error { return reloadLogLevels(cfg, loggers) },
+				},
+				reload.Setting{
+					Name:  "storage.gc.timeout",
+					Value: func(cfg *config.Config) string { return cfg.StorageGCTimeout.String() },
+					Apply: func(cfg *config.Config) error { return sqliteStorage.SetGCTimeout(cfg.StorageGCTimeout) },
+				},
+				reload.Setting{
+					Name:  "storage.max-message-size",
+					Value: func(cfg *config.Config) string { return strconv.FormatUint(cfg.StorageMaxMessageSize, 10) },
+					Apply: func(cfg *config.Config) error {
+						sqliteStorage.SetMaxMessageSize(cfg.StorageMaxMessageSize)
+						return nil
+					},
+				},
 			)
 
-			// Profiler.
+			plainqServer, serverErr := server.NewServer(&cfg, loggers, sqliteStorage, observer, history, alerts, leader, checker, reloader)
+			if serverErr != nil {
+				return fmt.Errorf("create PlainQ server: %s", serverErr.Error())
+			}
 
-			f.BoolVar(&cfg.ProfilerEnabled, "profiler", false,
-				"enable the profiler endpoint",
+			logger.Info("Houston Web UI",
+				slog.String("address", printAddrHTTP(cfg.HTTPAddr, cfg.HTTPTLSCert != "")),
 			)
+
+			go reloader.Watch(ctx)
+
+			return plainqServer.Serve(ctx)
 		},
+	}
 
-		Run: func(_ *scotty.Command, _ []string) error {
-			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
-			defer cancel()
+	return &cmd
+}
 
-			logger, loggerErr := initLogger(&cfg)
-			if loggerErr != nil {
-				return loggerErr
-			}
+// serverFlags binds the server command flags to the cfg.
+func serverFlags(f *scotty.FlagSet, cfg *config.Config) {
+	// Configuration sources.
 
-			logger.Info("Starting plainq server")
+	f.StringVar(&cfg.ConfigFile, configFlag, "",
+		"set path to JSON configuration file with flag names as keys, "+
+			"every flag can also be set by PLAINQ_* environment variable, e.g. PLAINQ_GRPC_ADDR, "+
+			"precedence is: flags > environment > file > defaults",
+	)
 
-			// Storage initialization.
+	// Storage.
 
-			sqliteStorage, storageInitErr := initStorage(&cfg, logger)
-			if storageInitErr != nil {
-				return storageInitErr
-			}
+	f.BoolVar(&cfg.StorageLogEnable, "storage.log.enable", false,
+		"enable logging for storage engine",
+	)
 
-			defer func() {
-				if err := sqliteStorage.Close(); err != nil {
-					logger.Error("Failed to close storage database connection",
-						slog.String("error", err.Error()),
-					)
-				}
-			}()
+	f.StringVar(&cfg.StorageDBPath, "storage.path", "",
+		"set path to SQLite database file",
+	)
 
-			var checker hc.HealthChecker = hc.NewNopChecker()
+	f.StringVar(&cfg.ReplicaOf, "replica-of", "",
+		"serve reads from the SQLite database file of the primary server at the path, the file is opened read-only and writes are rejected",
+	)
 
-			if cfg.HealthEnable {
-				checker = hc.NewMultiChecker(sqliteStorage)
-			}
+	f.DurationVar(&cfg.StorageGCTimeout, "storage.gc.timeout", 0,
+		"set storage GC timeout",
+	)
 
-			plainqServer, serverErr := server.NewServer(&cfg, logger, sqliteStorage, checker)
-			if serverErr != nil {
-				return fmt.Errorf("create PlainQ server: %s", serverErr.Error())
-			}
+	f.StringVar(&cfg.StorageAccessMode, "storage.access-mode", "",
+		"set the sqlite storage access mode",
+	)
 
-			logger.Info("Houston Web UI",
-				slog.String("address", printAddrHTTP(cfg.HTTPAddr)),
-			)
+	f.StringVar(&cfg.StorageJournalMode, "storage.journal-mode", "",
+		"set the sqlite storage journal mode",
+	)
 
-			return plainqServer.Serve(ctx)
+	f.UintVar(&cfg.StorageSearchMaxIndexedBytes, "storage.search.max-indexed-bytes", 4096,
+		"set the number of leading bytes of message body indexed for queues with the search index",
+	)
+
+	f.Uint64Var(&cfg.StorageMaxMessageSize, "storage.max-message-size", 1<<20,
+		"set the maximum message body size in bytes for all queues, queues may set lower limits, zero means no limit",
+	)
+
+	f.UintVar(&cfg.StorageQueueEvolutionBatchSize, "storage.queue-evolution.batch-size", 100,
+		"set the number of queue tables updated in a batch when their schema changes",
+	)
+
+	f.DurationVar(&cfg.StorageQueueEvolutionPause, "storage.queue-evolution.pause", time.Second,
+		"set the pause between batches of queue table updates, which lets regular operations use the database",
+	)
+
+	f.DurationVar(&cfg.StorageDepthInterval, "storage.depth-interval", time.Minute,
+		"set the interval between recounts of visible and in-flight messages reported by queue depth metrics",
+	)
+
+	f.Uint64Var(&cfg.StorageQueueCacheSize, "storage.queue-cache.size", 1000,
+		"set the number of queues which properties are cached, the least recently used ones are evicted",
+	)
+
+	f.DurationVar(&cfg.StorageQueueCacheTTL, "storage.queue-cache.ttl", 5*time.Minute,
+		"set how long queue properties are cached before they are read from the database again, zero disables expiration",
+	)
+
+	f.Uint64Var(&cfg.StorageMessageEventsSize, "storage.message-events.size", 100_000,
+		"set the number of message lifecycle events kept, the oldest ones are replaced, zero disables recording of events",
+	)
+
+	f.DurationVar(&cfg.StorageMessageEventsRetention, "storage.message-events.retention", 24*time.Hour,
+		"set how long message lifecycle events are kept for, zero keeps them until they are replaced",
+	)
+
+	f.BoolVar(&cfg.StorageAckByMessageID, "storage.ack-by-message-id", false,
+		"allow to delete messages and change their visibility by message ids instead of receipt handles, "+
+			"which requires the purge permission when authentication is enabled",
+	)
+
+	f.StringVar(&cfg.StorageMaintenanceWindow, "storage.maintenance.window", "",
+		"set the daily window in UTC, like 02:00-04:00, in which maintenance operations of the storage database run, empty disables them",
+	)
+
+	f.StringVar(&cfg.StorageMaintenanceOperations, "storage.maintenance.operations", "analyze",
+		"set the comma-separated maintenance operations run in the window one after another: vacuum, analyze or incremental-vacuum",
+	)
+
+	// Cluster.
+
+	f.BoolVar(&cfg.ClusterEnable, "cluster.enable", false,
+		"enable the cluster mode, which replicates the storage database between servers with Raft, "+
+			"writes are served by the elected leader and reads by any server",
+	)
+
+	f.StringVar(&cfg.ClusterNodeID, "cluster.node-id", "",
+		"set identifier of the server in the cluster, which must not change between restarts",
+	)
+
+	f.StringVar(&cfg.ClusterAddr, "cluster.addr", ":8082",
+		"set the address the server replicates the log on",
+	)
+
+	f.StringVar(&cfg.ClusterAdvertise, "cluster.advertise", "",
+		"set the address other servers reach the server on, by default cluster.addr",
+	)
+
+	f.StringVar(&cfg.ClusterDir, "cluster.dir", "",
+		"set the directory which keeps the replicated log and snapshots, by default 'raft' next to the storage database",
+	)
+
+	f.StringVar(&cfg.ClusterPeers, "cluster.peers", "",
+		`set comma separated cluster addresses of all servers by their identifiers, which form the cluster on the first start, `+
+			`e.g. "node1=10.0.0.1:8082,node2=10.0.0.2:8082,node3=10.0.0.3:8082"`,
+	)
+
+	f.DurationVar(&cfg.ClusterApplyTimeout, "cluster.apply-timeout", 10*time.Second,
+		"set how long the leader waits for the transaction to be replicated to the majority of servers",
+	)
+
+	// Failover.
+
+	f.BoolVar(&cfg.FailoverEnable, "failover.enable", false,
+		"enable the active-passive failover of servers sharing the storage database, the server which holds "+
+			"the lease serves writes while others stand by serving reads and take writes over once the lease expires",
+	)
+
+	f.StringVar(&cfg.FailoverHolder, "failover.holder", "",
+		"set identifier of the server competing for the lease, which must be unique, by default the host name",
+	)
+
+	f.DurationVar(&cfg.FailoverLeaseTTL, "failover.lease-ttl", 15*time.Second,
+		"set how long the lease is held without renewals, which is the time a standby waits to take writes over",
+	)
+
+	f.DurationVar(&cfg.FailoverRenewInterval, "failover.renew-interval", 5*time.Second,
+		"set the interval between renewals of the lease and attempts to take it over, less than half of the lease TTL",
+	)
+
+	// Logs.
+
+	f.BoolVar(&cfg.LogEnable, "log.enable", true,
+		"enable logging",
+	)
+
+	f.BoolVar(&cfg.LogAccessEnable, "log.access.enable", true,
+		"enable access logging",
+	)
+
+	f.StringVar(&cfg.LogAccessFormat, "log.access.format", "text",
+		"set the format of the access log: 'text' or 'json'",
+	)
+
+	f.StringVar(&cfg.LogAccessFields, "log.access.fields", "",
+		"set comma separated fields of the access log: method, route, uri, status, bytes, remote, duration, "+
+			"user_agent, identity, headers (credentials are redacted), by default: method,status,uri,remote,duration",
+	)
+
+	f.StringVar(&cfg.LogAccessSampling, "log.access.sampling", "",
+		`set access log sampling rates per route, 1 logs every request, N logs one of N, 0 disables the route, `+
+			`e.g. "GET /api/v1/queue/{id}/messages=100,*=1"`,
+	)
+
+	f.StringVar(&cfg.LogLevel, "log.level", "info",
+		"set logging level: 'debug', 'info', 'warn', 'error'",
+	)
+
+	f.StringVar(&cfg.LogLevels, "log.levels", "",
+		`set logging levels per subsystem (server, storage, gc, http, grpc, auth, telemetry, audit, cluster, backup), e.g. "storage=debug,http=warn"`,
+	)
+
+	// Telemetry.
+
+	f.BoolVar(&cfg.TelemetryEnabled, "telemetry.enable", true,
+		"enable telemetry subsystem",
+	)
+
+	f.StringVar(&cfg.TelemetryProvider, "telemetry.provider", "sqlite",
+		"set the provider of historical metrics: 'sqlite' or 'prometheus'",
+	)
+
+	f.BoolVar(&cfg.TelemetryLogEnable, "telemetry.log.enable", false,
+		"enable logging for telemetry subsystem",
+	)
+
+	f.StringVar(&cfg.TelemetryLiteDBPath, "telemetry.sqlite.path", "",
+		"set path to SQLite database file of historical metrics, by default it's next to the storage database",
+	)
+
+	f.StringVar(&cfg.TelemetryLiteAccessMode, "telemetry.sqlite.access-mode", "",
+		"set the sqlite telemetry access mode",
+	)
+
+	f.StringVar(&cfg.TelemetryLiteJournalMode, "telemetry.sqlite.journal-mode", "",
+		"set the sqlite telemetry journal mode",
+	)
+
+	f.DurationVar(&cfg.TelemetryLiteScrapeTimeout, "telemetry.sqlite.collection.timeout", 10*time.Second,
+		"set telemetry collection timeout",
+	)
+
+	f.DurationVar(&cfg.TelemetryLiteGCTimeout, "telemetry.sqlite.gc.timeout", 10*time.Minute,
+		"set telemetry GC timeout",
+	)
+
+	f.DurationVar(&cfg.TelemetryLiteRetentionPeriod, "telemetry.sqlite.retention.period", 14*24*time.Hour,
+		"set telemetry retention period",
+	)
+
+	f.StringVar(&cfg.TelemetryPromBaseURL, "telemetry.prometheus.baseurl", "",
+		"set Prometheus API base URL",
+	)
+
+	f.BoolVar(&cfg.TelemetryOTLPEnable, "telemetry.otlp.enable", false,
+		"enable OpenTelemetry tracing with the export of spans over OTLP gRPC",
+	)
+
+	f.StringVar(&cfg.TelemetryOTLPEndpoint, "telemetry.otlp.endpoint", "localhost:4317",
+		"set OTLP gRPC collector address",
+	)
+
+	f.BoolVar(&cfg.TelemetryOTLPInsecure, "telemetry.otlp.insecure", false,
+		"disable TLS of the connection to the OTLP collector",
+	)
+
+	f.StringVar(&cfg.TelemetryOTLPHeaders, "telemetry.otlp.headers", "",
+		`set headers sent to the OTLP collector, e.g. "authorization=Bearer token,x-tenant=plainq"`,
+	)
+
+	f.Float64Var(&cfg.TelemetryOTLPSampleRatio, "telemetry.otlp.sample-ratio", 1,
+		"set the fraction of traces started by the server which are sampled, between 0 and 1",
+	)
+
+	f.BoolVar(&cfg.TelemetryOTLPMetricsEnable, "telemetry.otlp.metrics.enable", false,
+		"enable the export of metrics over OTLP gRPC along with the /metrics endpoint",
+	)
+
+	f.DurationVar(&cfg.TelemetryOTLPMetricsInterval, "telemetry.otlp.metrics.interval", 30*time.Second,
+		"set the interval between exports of metrics over OTLP",
+	)
+
+	f.StringVar(&cfg.TelemetryRemoteWriteURL, "telemetry.remote-write.url", "",
+		`set Prometheus remote-write endpoint which metrics are pushed to, e.g. "https://prometheus:9090/api/v1/write"`,
+	)
+
+	f.DurationVar(&cfg.TelemetryRemoteWriteInterval, "telemetry.remote-write.interval", 30*time.Second,
+		"set the interval between pushes of metrics to the remote-write endpoint",
+	)
+
+	f.DurationVar(&cfg.TelemetryRemoteWriteTimeout, "telemetry.remote-write.timeout", 10*time.Second,
+		"set the timeout of each push of metrics to the remote-write endpoint",
+	)
+
+	f.StringVar(&cfg.TelemetryRemoteWriteHeaders, "telemetry.remote-write.headers", "",
+		`set headers sent to the remote-write endpoint, e.g. "authorization=Bearer token"`,
+	)
+
+	f.StringVar(&cfg.TelemetryRemoteWriteLabels, "telemetry.remote-write.labels", "",
+		`set labels attached to pushed series, e.g. "instance=plainq-1,region=eu"`,
+	)
+
+	// Listeners & PlainQ.
+
+	f.StringVar(&cfg.GRPCAddr, "grpc.addr", ":8080",
+		"set gRPC listener address",
+	)
+
+	f.StringVar(&cfg.HTTPAddr, "http.addr", ":8081",
+		"set HTTP listener address",
+	)
+
+	f.StringVar(&cfg.GRPCTLSCert, "grpc.tls.cert", "",
+		"set path to PEM encoded TLS certificate of gRPC listener, enables TLS together with --grpc.tls.key",
+	)
+
+	f.StringVar(&cfg.GRPCTLSKey, "grpc.tls.key", "",
+		"set path to PEM encoded TLS private key of gRPC listener",
+	)
+
+	f.StringVar(&cfg.GRPCTLSClientCA, "grpc.tls.client-ca", "",
+		"set path to PEM encoded CA certificates, requires gRPC clients to present certificates signed by them",
+	)
+
+	f.StringVar(&cfg.GRPCTLSClientIdentities, "grpc.tls.client-identities", "",
+		`set path to JSON file which maps client certificates to identities, e.g. {"cn:orders": "orders-service", `+
+			`"uri:spiffe://acme/billing": "billing"}, by default the identity is the certificate subject common name`,
+	)
+
+	f.StringVar(&cfg.HTTPTLSCert, "http.tls.cert", "",
+		"set path to PEM encoded TLS certificate of HTTP listener, enables TLS together with --http.tls.key",
+	)
+
+	f.StringVar(&cfg.HTTPTLSKey, "http.tls.key", "",
+		"set path to PEM encoded TLS private key of HTTP listener",
+	)
+
+	f.DurationVar(&cfg.TLSReloadInterval, "tls.reload.interval", 0,
+		"set the interval of checking TLS certificate and key files for changes, "+
+			"changed certificates are reloaded without restart, 0 disables the reload",
+	)
+
+	f.DurationVar(&cfg.HTTPReadHeaderTimeout, "http.read-header-timeout", 0,
+		"",
+	)
+
+	f.DurationVar(&cfg.HTTPReadTimeout, "http.read-timeout", 0,
+		"",
+	)
+
+	f.DurationVar(&cfg.HTTPWriteTimeout, "http.write-timeout", 0,
+		"",
+	)
+
+	f.DurationVar(&cfg.HTTPIdleTimeout, "http.idle-timeout", 0,
+		"",
+	)
+
+	f.StringVar(&cfg.HTTPTimeouts, "http.timeouts", "",
+		`comma separated timeouts of HTTP routes which override server timeouts, `+
+			`e.g. "GET /api/v1/queue/{id}/search=1m,*=10s", where "*" applies to other routes`,
+	)
+
+	f.StringVar(&cfg.GRPCTimeouts, "grpc.timeouts", "",
+		`comma separated timeouts of gRPC methods, e.g. "Send=2s,Receive=30s,*=10s", where "*" applies to other methods`,
+	)
+
+	// Metrics.
+
+	f.BoolVar(&cfg.MetricsEnable, "metrics", true,
+		"enable the metrics endpoint",
+	)
+
+	f.BoolVar(&cfg.MetricsRouteLogs, "metrics.route.logs", false,
+		"turn on access logs for metrics endpoint",
+	)
+
+	f.BoolVar(&cfg.MetricsRouteMetrics, "metrics.route.metrics", false,
+		"turn on metrics for metrics endpoint",
+	)
+
+	f.StringVar(&cfg.MetricsRoute, "metrics.route", "/metrics",
+		"set given route as metrics endpoint route",
+	)
+
+	f.StringVar(&cfg.MetricsTagLabels, "metrics.tag-labels", "",
+		"comma separated list of queue tags attached to queue metrics as labels",
+	)
+
+	f.IntVar(&cfg.MetricsTagValues, "metrics.tag-labels.max-values", telemetry.DefaultTagLabelValues,
+		`limit distinct values of each tag label, the rest are reported as "other"`,
+	)
+
+	f.StringVar(&cfg.MetricsInQueueBuckets, "metrics.in-queue-duration.buckets", "",
+		`comma separated histogram buckets of message in queue duration, e.g. "1s,1m,10m,1h,6h,24h"`,
+	)
+
+	f.StringVar(&cfg.MetricsGCBuckets, "metrics.gc-duration.buckets", "",
+		`comma separated histogram buckets of garbage collection duration, e.g. "10ms,100ms,1s,10s"`,
+	)
+
+	f.StringVar(&cfg.MetricsStorageTxBuckets, "metrics.storage-tx-duration.buckets", "",
+		`comma separated histogram buckets of storage transaction duration, e.g. "1ms,10ms,100ms,1s"`,
+	)
+
+	f.BoolVar(&cfg.MetricsDurationSummary, "metrics.duration-summary", false,
+		"expose duration metrics as summaries instead of histograms",
+	)
+
+	// Health.
+
+	f.BoolVar(&cfg.HealthEnable, "health", true,
+		"enable the metrics endpoint",
+	)
+
+	f.BoolVar(&cfg.HealthRouteLogs, "health.route.logs", false,
+		"turn on access logs for metrics endpoint",
+	)
+
+	f.BoolVar(&cfg.HealthRouteMetrics, "health.route.metrics", false,
+		"turn on metrics for metrics endpoint",
+	)
+
+	f.StringVar(&cfg.HealthRoute, "health.route", "/health",
+		"set given route as metrics endpoint route",
+	)
+
+	f.DurationVar(&cfg.HealthTimeout, "health.timeout", health.DefaultTimeout,
+		"set the timeout of each health check of server components",
+	)
+
+	f.Uint64Var(&cfg.HealthWALMaxSize, "health.wal.max-size", 0,
+		"report the storage write-ahead log as unhealthy when it exceeds the given size in bytes, 0 means no limit",
+	)
+
+	// Circuit breaker.
+
+	f.BoolVar(&cfg.BreakerEnable, "breaker.enable", false,
+		"enable the circuit breaker which freezes queues on anomalies",
+	)
+
+	f.StringVar(&cfg.BreakerAction, "breaker.action", "receive",
+		"set the operation paused on frozen queues: 'receive' or 'send'",
+	)
+
+	f.UintVar(&cfg.BreakerMaxDepth, "breaker.max-depth", 0,
+		"freeze queues which hold at least the given number of messages, 0 disables the condition",
+	)
+
+	f.UintVar(&cfg.BreakerMaxDeadLettered, "breaker.max-dead-lettered", 0,
+		"freeze queues which moved at least the given number of messages to the dead letter queue "+
+			"during the breaker interval, 0 disables the condition",
+	)
+
+	f.DurationVar(&cfg.BreakerInterval, "breaker.interval", time.Minute,
+		"set the interval between evaluations of the circuit breaker conditions",
+	)
+
+	// Audit log.
+
+	f.BoolVar(&cfg.AuditDataPlane, "audit.data-plane", false,
+		"record sent and deleted messages to the audit log besides administrative mutations",
+	)
+
+	// Alerting.
+
+	f.BoolVar(&cfg.AlertingEnable, "alerting.enable", false,
+		"enable the evaluation of alert rules and delivery of their notifications",
+	)
+
+	f.DurationVar(&cfg.AlertingInterval, "alerting.interval", 30*time.Second,
+		"set the interval between evaluations of alert rules",
+	)
+
+	f.DurationVar(&cfg.AlertingWebhookTimeout, "alerting.webhook-timeout", 10*time.Second,
+		"set the timeout of the delivery of alert webhooks",
+	)
+
+	f.StringVar(&cfg.AlertingSMTPAddr, "alerting.smtp.addr", "",
+		"set the host:port address of the SMTP server alert emails are sent with, empty disables emails",
+	)
+
+	f.StringVar(&cfg.AlertingSMTPFrom, "alerting.smtp.from", "plainq@localhost",
+		"set the sender address of alert emails",
+	)
+
+	f.StringVar(&cfg.AlertingSMTPUsername, "alerting.smtp.username", "",
+		"set the username of the SMTP server, empty disables authentication",
+	)
+
+	f.StringVar(&cfg.AlertingSMTPPassword, "alerting.smtp.password", "",
+		"set the password of the SMTP server",
+	)
+
+	// Backups.
+
+	f.BoolVar(&cfg.BackupEnable, "backup.enable", false,
+		"enable scheduled backups of the storage and telemetry databases to the S3-compatible bucket",
+	)
+
+	f.StringVar(&cfg.BackupSchedule, "backup.schedule", "0 3 * * *",
+		`set the cron expression backups are made on in UTC, e.g. "0 */6 * * *" or "@daily"`,
+	)
+
+	f.DurationVar(&cfg.BackupRetention, "backup.retention", 7*24*time.Hour,
+		"set how long backups are kept, the latest backup is never pruned, 0 keeps backups forever",
+	)
+
+	f.StringVar(&cfg.BackupDir, "backup.dir", "",
+		"set the directory snapshots are written to before they are uploaded, by default the directory for temporary files",
+	)
+
+	f.StringVar(&cfg.BackupS3Bucket, "backup.s3.bucket", "",
+		"set the name of the bucket backups are uploaded to",
+	)
+
+	f.StringVar(&cfg.BackupS3Prefix, "backup.s3.prefix", "plainq",
+		"set the prefix of object keys of backups",
+	)
+
+	f.StringVar(&cfg.BackupS3Endpoint, "backup.s3.endpoint", "",
+		"set the URL of the S3-compatible service, e.g. http://localhost:9000, empty means AWS S3",
+	)
+
+	f.StringVar(&cfg.BackupS3Region, "backup.s3.region", "us-east-1",
+		"set the region of the bucket",
+	)
+
+	f.StringVar(&cfg.BackupS3AccessKey, "backup.s3.access-key", "",
+		"set the access key ID, empty resolves credentials from the environment",
+	)
+
+	f.StringVar(&cfg.BackupS3SecretKey, "backup.s3.secret-key", "",
+		"set the secret access key",
+	)
+
+	f.BoolVar(&cfg.BackupS3PathStyle, "backup.s3.path-style", false,
+		"address the bucket in the path of URLs, which is required by most S3-compatible services",
+	)
+
+	// Authentication.
+
+	f.BoolVar(&cfg.AuthEnable, "auth.enable", false,
+		"enable authentication of gRPC clients by bearer tokens or API keys and enforcement of queue permissions",
+	)
+
+	f.BoolVar(&cfg.AuthHTTP, "auth.http", true,
+		"authenticate HTTP API requests when authentication is enabled, false serves queue and message routes "+
+			"without authentication while administrative and SCIM routes are still authenticated",
+	)
+
+	f.StringVar(&cfg.AuthJWTSecret, "auth.jwt.secret", "",
+		"set the secret key of HS256 signatures of bearer tokens, empty rejects HS256 tokens",
+	)
+
+	f.StringVar(&cfg.AuthJWTIssuer, "auth.jwt.issuer", "",
+		"set the expected issuer of bearer tokens, empty accepts any issuer",
+	)
+
+	f.StringVar(&cfg.AuthJWTAudience, "auth.jwt.audience", "",
+		"set the expected audience of bearer tokens, empty accepts any audience",
+	)
+
+	f.DurationVar(&cfg.AuthJWTLeeway, "auth.jwt.leeway", 30*time.Second,
+		"set the allowed clock skew of expiration times of bearer tokens",
+	)
+
+	f.StringVar(&cfg.AuthJWKSURL, "auth.jwks.url", "",
+		"set the URL of the JSON Web Key Set of the OAuth provider, which verifies RS256 and ES256 bearer tokens",
+	)
+
+	f.DurationVar(&cfg.AuthJWKSRefreshInterval, "auth.jwks.refresh-interval", 15*time.Minute,
+		"set the interval of the refresh of keys of the JSON Web Key Set",
+	)
+
+	f.DurationVar(&cfg.AuthDenylistCacheTTL, "auth.denylist.cache-ttl", 10*time.Second,
+		"set how long tokens which are not signed out are cached before the denylist is checked again",
+	)
+
+	f.DurationVar(&cfg.AuthGrantsCacheTTL, "auth.grants.cache-ttl", 30*time.Second,
+		"set how long permissions of subjects on queues are cached, zero disables the cache",
+	)
+
+	f.DurationVar(&cfg.AuthDenylistCleanupInterval, "auth.denylist.cleanup-interval", 10*time.Minute,
+		"set the interval of the removal of expired tokens from the denylist",
+	)
+
+	f.DurationVar(&cfg.AuthTokenTTL, "auth.token-ttl", time.Hour,
+		"set the lifetime of access tokens issued on sign-in",
+	)
+
+	f.DurationVar(&cfg.AuthResetCodeTTL, "auth.reset-code-ttl", 15*time.Minute,
+		"set the lifetime of password reset codes, which are emailed by the alerting SMTP server",
+	)
+
+	f.BoolVar(&cfg.AuthRequireVerifiedEmail, "auth.require-verified-email", false,
+		"reject sign-in of local accounts until their emails are verified",
+	)
+
+	f.DurationVar(&cfg.AuthVerifyCodeTTL, "auth.verify-code-ttl", 24*time.Hour,
+		"set the lifetime of email verification codes, which are emailed by the alerting SMTP server",
+	)
+
+	f.DurationVar(&cfg.AuthVerifyResendInterval, "auth.verify-resend-interval", time.Minute,
+		"set the minimal interval between email verification codes sent to the same account",
+	)
+
+	f.StringVar(&cfg.AuthLDAPURL, "auth.ldap.url", "",
+		"set the URL of the LDAP directory which verifies passwords on sign-in, e.g. 'ldaps://ldap.example.com'",
+	)
+
+	f.StringVar(&cfg.AuthLDAPBindDN, "auth.ldap.bind-dn", "",
+		"set the DN which binds to search users, empty searches anonymously",
+	)
+
+	f.StringVar(&cfg.AuthLDAPBindPassword, "auth.ldap.bind-password", "",
+		"set the password of the bind DN",
+	)
+
+	f.StringVar(&cfg.AuthLDAPBaseDN, "auth.ldap.base-dn", "",
+		"set the DN users are searched under, e.g. 'ou=people,dc=example,dc=com'",
+	)
+
+	f.StringVar(&cfg.AuthLDAPUserAttribute, "auth.ldap.user-attribute", "uid",
+		"set the attribute which equals the username, e.g. 'sAMAccountName' for Active Directory",
+	)
+
+	f.StringVar(&cfg.AuthLDAPEmailAttribute, "auth.ldap.email-attribute", "mail",
+		"set the attribute of the email, which is the identity of the user",
+	)
+
+	f.StringVar(&cfg.AuthLDAPGroupAttribute, "auth.ldap.group-attribute", "memberOf",
+		"set the attribute which lists groups of the user",
+	)
+
+	f.StringVar(&cfg.AuthLDAPGroupRoles, "auth.ldap.group-roles", "",
+		"set the path to the JSON file which maps DNs of groups to names of roles",
+	)
+
+	f.DurationVar(&cfg.AuthLDAPTimeout, "auth.ldap.timeout", 5*time.Second,
+		"set the timeout of the sign-in by the LDAP directory",
+	)
+
+	// Rate limiting.
+
+	f.BoolVar(&cfg.RateLimitEnable, "ratelimit.enable", false,
+		"enable rate limiting of API requests",
+	)
+
+	f.StringVar(&cfg.RateLimitGlobal, "ratelimit.global", "",
+		"set the limit of all requests in form rate[:burst], e.g. '1000:2000', empty means no limit",
+	)
+
+	f.StringVar(&cfg.RateLimitClient, "ratelimit.client", "",
+		"set the limit of requests of each client in form rate[:burst], empty means no limit",
+	)
+
+	f.StringVar(&cfg.RateLimitClients, "ratelimit.clients", "",
+		"comma separated limits of specific clients in form client=rate[:burst], e.g. 'orders-service=50:100'",
+	)
+
+	// CORS.
+
+	f.BoolVar(&cfg.CORSEnable, "cors", true,
+		"enable CORS configuration for Houston API routes",
+	)
+
+	f.StringVar(&cfg.CORSOrigins, "cors.origins", "",
+		"comma separated list of origins allowed by CORS, any origin is allowed by default",
+	)
+
+	// API documentation.
+
+	f.BoolVar(&cfg.DocsEnable, "docs", false,
+		"serve the OpenAPI document of the HTTP API and Swagger UI under /api/docs",
+	)
+
+	f.StringVar(&cfg.DocsSwaggerUIURL, "docs.swagger-ui", "https://unpkg.com/swagger-ui-dist@5",
+		"set the base URL of Swagger UI assets, e.g. of a local copy of swagger-ui-dist",
+	)
+
+	// JSON gateway.
+
+	f.BoolVar(&cfg.GatewayEnable, "gateway", true,
+		"serve each gRPC method as JSON under /api/rpc, e.g. POST /api/rpc/v1.PlainQService/Send",
+	)
+
+	// Profiler.
+
+	f.BoolVar(&cfg.ProfilerEnabled, "profiler", false,
+		"enable the profiler endpoint",
+	)
+
+	f.StringVar(&cfg.ProfilerAddr, "profiler.addr", "127.0.0.1:6060",
+		"set the address of the dedicated listener which serves the profiler under /debug/pprof",
+	)
+
+	f.StringVar(&cfg.ProfilerToken, "profiler.token", "",
+		"require the given bearer token for profiler requests, which is mandatory for non-loopback addresses",
+	)
+}
+
+func initLoggers(cfg *config.Config) (*logging.Loggers, error) {
+	base := logkit.NewNop()
+
+	level, levelErr := logkit.ParseLevel(cfg.LogLevel)
+	if levelErr != nil {
+		return nil, levelErr
+	}
+
+	if cfg.LogEnable {
+		// Records are filtered by subsystem loggers, so
+		// the base logger should pass records of any level.
+		base = logkit.New(logkit.WithLevel(slog.LevelDebug))
+	}
+
+	loggers := logging.New(base, level)
+
+	levels, parseErr := logging.ParseLevels(cfg.LogLevels)
+	if parseErr != nil {
+		return nil, fmt.Errorf("log levels: %w", parseErr)
+	}
+
+	if err := loggers.SetLevels(levels); err != nil {
+		return nil, fmt.Errorf("log levels: %w", err)
+	}
+
+	loggers.Logger(logging.Server).Debug("Logger has been initialized",
+		slog.String("level", level.String()),
+	)
+
+	return loggers, nil
+}
+
+// loadServerConfig loads the server configuration from its sources,
+// options given on the command line as cmdline keep their values.
+func loadServerConfig(cmdline map[string]string) (*config.Config, error) {
+	var cfg config.Config
+
+	flags := scotty.FlagSet{FlagSet: flag.NewFlagSet("server", flag.ContinueOnError)}
+	serverFlags(&flags, &cfg)
+
+	for name, value := range cmdline {
+		if err := flags.Set(name, value); err != nil {
+			return nil, fmt.Errorf("set flag %q: %w", name, err)
+		}
+	}
+
+	if err := applyConfigSources(flags.FlagSet, os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// reloadLogLevels sets log levels of all subsystems to the
+// log level from cfg, and then applies per subsystem levels.
+func reloadLogLevels(cfg *config.Config, loggers *logging.Loggers) error {
+	if _, err := logkit.ParseLevel(cfg.LogLevel); err != nil {
+		return err
+	}
+
+	overrides, parseErr := logging.ParseLevels(cfg.LogLevels)
+	if parseErr != nil {
+		return fmt.Errorf("log levels: %w", parseErr)
+	}
+
+	levels := loggers.Levels()
+
+	for s := range levels {
+		levels[s] = cfg.LogLevel
+	}
+
+	maps.Copy(levels, overrides)
+
+	return loggers.SetLevels(levels)
+}
+
+func initTracing(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	headers, headersErr := tracing.ParseHeaders(cfg.TelemetryOTLPHeaders)
+	if headersErr != nil {
+		return nil, fmt.Errorf("tracing headers: %w", headersErr)
+	}
+
+	shutdown, startErr := tracing.Start(ctx, tracing.Config{
+		Endpoint:    cfg.TelemetryOTLPEndpoint,
+		Insecure:    cfg.TelemetryOTLPInsecure,
+		Headers:     headers,
+		SampleRatio: cfg.TelemetryOTLPSampleRatio,
+	})
+	if startErr != nil {
+		return nil, fmt.Errorf("start tracing: %w", startErr)
+	}
+
+	return shutdown, nil
+}
+
+func initOTLPMetrics(ctx context.Context, cfg *config.Config) (metric.MeterProvider, func(context.Context) error, error) {
+	headers, headersErr := tracing.ParseHeaders(cfg.TelemetryOTLPHeaders)
+	if headersErr != nil {
+		return nil, nil, fmt.Errorf("OTLP headers: %w", headersErr)
+	}
+
+	provider, shutdown, startErr := telemetry.StartOTLP(ctx, telemetry.OTLPConfig{
+		Endpoint:    cfg.TelemetryOTLPEndpoint,
+		Insecure:    cfg.TelemetryOTLPInsecure,
+		Headers:     headers,
+		Interval:    cfg.TelemetryOTLPMetricsInterval,
+		ServiceName: tracing.ServiceName,
+	})
+	if startErr != nil {
+		return nil, nil, fmt.Errorf("start OTLP metrics export: %w", startErr)
+	}
+
+	return provider, shutdown, nil
+}
+
+func initRemoteWriter(cfg *config.Config, loggers *logging.Loggers) (*telemetry.RemoteWriter, error) {
+	if cfg.TelemetryRemoteWriteInterval <= 0 {
+		return nil, fmt.Errorf("remote-write interval should be positive, got %s", cfg.TelemetryRemoteWriteInterval)
+	}
+
+	headers, headersErr := tracing.ParseHeaders(cfg.TelemetryRemoteWriteHeaders)
+	if headersErr != nil {
+		return nil, fmt.Errorf("remote-write headers: %w", headersErr)
+	}
+
+	labels, labelsErr := telemetry.LabelsFromString(cfg.TelemetryRemoteWriteLabels)
+	if labelsErr != nil {
+		return nil, fmt.Errorf("remote-write labels: %w", labelsErr)
+	}
+
+	writer := telemetry.NewRemoteWriter(telemetry.RemoteWriteConfig{
+		URL:      cfg.TelemetryRemoteWriteURL,
+		Interval: cfg.TelemetryRemoteWriteInterval,
+		Timeout:  cfg.TelemetryRemoteWriteTimeout,
+		Headers:  headers,
+		Labels:   labels.Map(),
+	}, loggers.Logger(logging.Telemetry))
+
+	return writer, nil
+}
+
+// initHealth returns the checker of server components. The gRPC listener is checked
+// once it has been created by the server.
+func initHealth(cfg *config.Config, storage *litestore.Storage, history telemetry.Querier) *health.Checker {
+	started := time.Now()
+
+	checker := health.New(cfg.HealthTimeout,
+		health.Check{Name: "storage", Critical: true, Func: health.Ping(storage)},
+		health.Check{Name: "storage_wal", Func: health.Size(storage.WALSize, cfg.HealthWALMaxSize)},
+		health.Check{Name: "storage_gc", Func: health.Recency(storage.GCLastRun, started, func() time.Duration {
+			// The run may be delayed by other maintenance, e.g. snapshots.
+			return 3 * storage.GCTimeout()
+		})},
+	)
+
+	if store, ok := history.(hc.HealthChecker); ok {
+		checker.Add(health.Check{Name: "telemetry", Func: health.Ping(store)})
+	}
+
+	return checker
+}
+
+func initAlerting(cfg *config.Config, loggers *logging.Loggers, rules alerting.Rules) (*alerting.Engine, error) {
+	engine, engineErr := alerting.New(alerting.Config{
+		Interval:       cfg.AlertingInterval,
+		WebhookTimeout: cfg.AlertingWebhookTimeout,
+		SMTP: alerting.SMTPConfig{
+			Addr:     cfg.AlertingSMTPAddr,
+			From:     cfg.AlertingSMTPFrom,
+			Username: cfg.AlertingSMTPUsername,
+			Password: cfg.AlertingSMTPPassword,
 		},
+	}, rules, loggers.Logger(logging.Telemetry))
+	if engineErr != nil {
+		return nil, fmt.Errorf("create alerting engine: %w", engineErr)
 	}
 
-	return &cmd
+	return engine, nil
 }
 
-func initLogger(cfg *config.Config) (*slog.Logger, error) {
-	logger := logkit.NewNop()
+// initBackup returns the scheduler which backs the storage database up, along
+// with the telemetry database when metrics are stored in SQLite.
+func initBackup(cfg *config.Config, loggers *logging.Loggers, observer telemetry.Observer, storage *litestore.Storage, history telemetry.Querier) (*backup.Scheduler, error) {
+	if cfg.BackupS3Bucket == "" {
+		return nil, errors.New("backup.enable requires --backup.s3.bucket")
+	}
+
+	bucket, bucketErr := backup.NewS3Bucket(backup.S3Config{
+		Bucket:          cfg.BackupS3Bucket,
+		Endpoint:        cfg.BackupS3Endpoint,
+		Region:          cfg.BackupS3Region,
+		AccessKeyID:     cfg.BackupS3AccessKey,
+		SecretAccessKey: cfg.BackupS3SecretKey,
+		PathStyle:       cfg.BackupS3PathStyle,
+	})
+	if bucketErr != nil {
+		return nil, fmt.Errorf("create backup bucket: %w", bucketErr)
+	}
+
+	databases := []backup.Database{{Name: "storage", Snapshot: storage.Snapshot}}
 
-	if cfg.LogEnable {
-		level, levelErr := logkit.ParseLevel(cfg.LogLevel)
-		if levelErr != nil {
-			return nil, levelErr
+	if store, ok := history.(*telemetry.SQLiteStore); ok {
+		databases = append(databases, backup.Database{Name: "telemetry", Snapshot: store.Snapshot})
+	}
+
+	scheduler, schedulerErr := backup.New(backup.Config{
+		Schedule:  cfg.BackupSchedule,
+		Prefix:    cfg.BackupS3Prefix,
+		Retention: cfg.BackupRetention,
+		Dir:       cfg.BackupDir,
+		Logger:    loggers.Logger(logging.Backup),
+	}, bucket, observer, databases...)
+	if schedulerErr != nil {
+		return nil, fmt.Errorf("create backup scheduler: %w", schedulerErr)
+	}
+
+	return scheduler, nil
+}
+
+// initHistory returns the querier of historical metrics of the telemetry provider, or nil when
+// telemetry is disabled. The returned function stops the collection and releases resources.
+func initHistory(ctx context.Context, cfg *config.Config, loggers *logging.Loggers) (telemetry.Querier, func(), error) {
+	if !cfg.TelemetryEnabled {
+		return nil, func() {}, nil
+	}
+
+	switch cfg.TelemetryProvider {
+	case "sqlite":
+		return initSQLiteHistory(ctx, cfg, loggers)
+
+	case "prometheus":
+		if cfg.TelemetryPromBaseURL == "" {
+			return nil, nil, errors.New("telemetry provider prometheus requires --telemetry.prometheus.baseurl")
+		}
+
+		return telemetry.NewPrometheusQuerier(cfg.TelemetryPromBaseURL, &http.Client{}), func() {}, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown telemetry provider %q", cfg.TelemetryProvider)
+	}
+}
+
+func initSQLiteHistory(ctx context.Context, cfg *config.Config, loggers *logging.Loggers) (telemetry.Querier, func(), error) {
+	logger := loggers.Logger(logging.Telemetry)
+
+	if cfg.TelemetryLiteScrapeTimeout <= 0 || cfg.TelemetryLiteGCTimeout <= 0 {
+		return nil, nil, errors.New("telemetry collection and GC timeouts should be positive")
+	}
+
+	if cfg.TelemetryLiteDBPath == "" {
+		cfg.TelemetryLiteDBPath = filepath.Join(filepath.Dir(cfg.StorageDBPath), "plainq-telemetry.db")
+	}
+
+	connOption := make([]litekit.Option, 0, 2)
+	if cfg.TelemetryLiteAccessMode != "" {
+		mode, err := litekit.AccessModeFromString(cfg.TelemetryLiteAccessMode)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		options := []logkit.Option{
-			logkit.WithLevel(level),
+		connOption = append(connOption, litekit.WithAccessMode(mode))
+	}
+
+	if cfg.TelemetryLiteJournalMode != "" {
+		mode, err := litekit.JournalModeFromString(cfg.TelemetryLiteJournalMode)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		logger = logkit.New(options...)
+		connOption = append(connOption, litekit.WithJournalMode(mode))
+	}
 
-		logger.Debug("Logger has been initialized",
-			slog.String("level", level.String()),
-		)
+	conn, conErr := litekit.New(cfg.TelemetryLiteDBPath, connOption...)
+	if conErr != nil {
+		return nil, nil, fmt.Errorf("connect to telemetry database: %w", conErr)
 	}
 
-	return logger, nil
+	evolver, evolverErr := litekit.NewEvolver(conn, mutations.TelemetryMutation())
+	if evolverErr != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("create telemetry schema evolver: %w", evolverErr)
+	}
+
+	if err := evolver.MutateSchema(); err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("telemetry schema mutation: %w", err)
+	}
+
+	logger.Info("Telemetry storage has been initialized",
+		slog.String("path", cfg.TelemetryLiteDBPath),
+	)
+
+	store := telemetry.NewSQLiteStore(conn.DB, telemetry.SQLiteConfig{
+		Interval:   cfg.TelemetryLiteScrapeTimeout,
+		GCInterval: cfg.TelemetryLiteGCTimeout,
+		Retention:  cfg.TelemetryLiteRetentionPeriod,
+	}, logger)
+
+	storeCtx, storeCancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		store.Run(storeCtx)
+	}()
+
+	closer := func() {
+		storeCancel()
+		<-done
+
+		if err := conn.Close(); err != nil {
+			logger.Error("Failed to close telemetry database connection",
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	return store, closer, nil
 }
 
-func initStorage(cfg *config.Config, logger *slog.Logger) (*litestore.Storage, error) {
+func initObserver(cfg *config.Config, meter metric.Meter) (*telemetry.MetricsObserver, error) {
+	var keys []string
+
+	for k := range strings.SplitSeq(cfg.MetricsTagLabels, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+
+	labels, labelsErr := telemetry.NewQueueLabels(keys, cfg.MetricsTagValues)
+	if labelsErr != nil {
+		return nil, fmt.Errorf("metrics tag labels: %w", labelsErr)
+	}
+
+	options := []telemetry.ObserverOption{telemetry.WithQueueLabels(labels)}
+
+	buckets := map[string]string{
+		telemetry.MetricTimeInQueue:       cfg.MetricsInQueueBuckets,
+		telemetry.MetricGCDuration:        cfg.MetricsGCBuckets,
+		telemetry.MetricStorageTxDuration: cfg.MetricsStorageTxBuckets,
+	}
+
+	for metric, spec := range buckets {
+		bounds, parseErr := telemetry.ParseDurationBuckets(spec)
+		if parseErr != nil {
+			return nil, fmt.Errorf("metrics %s buckets: %w", metric, parseErr)
+		}
+
+		if len(bounds) == 0 {
+			continue
+		}
+
+		if cfg.MetricsDurationSummary {
+			return nil, fmt.Errorf("metrics %s buckets can't be used with duration summaries", metric)
+		}
+
+		options = append(options, telemetry.WithDurationBuckets(metric, bounds))
+	}
+
+	if cfg.MetricsDurationSummary {
+		options = append(options, telemetry.WithDurationSummary())
+	}
+
+	if meter != nil {
+		options = append(options, telemetry.WithMeter(meter))
+	}
+
+	return telemetry.NewObserver(options...), nil
+}
+
+// leadership elects the server which changes the storage database
+// among servers of the cluster or servers sharing the database.
+type leadership interface {
+	IsLeader() bool
+	Leader() string
+	OnLead(fn func(ctx context.Context) error)
+	Health(ctx context.Context) (map[string]any, error)
+	Close() error
+}
+
+// initStorage returns the storage along with the election of the server which changes
+// the database, which is nil unless the cluster mode or the failover is enabled.
+func initStorage(cfg *config.Config, loggers *logging.Loggers, observer telemetry.Observer) (*litestore.Storage, leadership, error) {
+	logger := loggers.Logger(logging.Storage)
+
+	if cfg.ClusterEnable && cfg.FailoverEnable {
+		return nil, nil, errors.New("cluster.enable and failover.enable can't be set together: servers of the cluster don't share the database")
+	}
+
+	if cfg.ReplicaOf != "" {
+		if cfg.ClusterEnable || cfg.FailoverEnable {
+			return nil, nil, errors.New("replica-of can't be set along with cluster.enable or failover.enable: the replica never writes the database")
+		}
+
+		sqliteStorage, storageErr := initReplicaStorage(cfg, loggers, observer)
+
+		return sqliteStorage, nil, storageErr
+	}
+
 	if cfg.StorageDBPath == "" {
 		pwd, pwdErr := os.Getwd()
 		if pwdErr != nil {
-			return nil, fmt.Errorf("get current working derrectory: %w", pwdErr)
+			return nil, nil, fmt.Errorf("get current working derrectory: %w", pwdErr)
 		}
 
 		dbPath, err := filepath.Abs(filepath.Join(pwd, "plainq.db"))
 		if err != nil {
-			return nil, fmt.Errorf("create storage file: %w", err)
+			return nil, nil, fmt.Errorf("create storage file: %w", err)
 		}
 
 		logger.Info("Storage has been initialized",
@@ -262,7 +1364,7 @@ func initStorage(cfg *config.Config, logger *slog.Logger) (*litestore.Storage, e
 	if cfg.StorageAccessMode != "" {
 		mode, err := litekit.AccessModeFromString(cfg.StorageAccessMode)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		connOption = append(connOption, litekit.WithAccessMode(mode))
@@ -271,7 +1373,7 @@ func initStorage(cfg *config.Config, logger *slog.Logger) (*litestore.Storage, e
 	if cfg.StorageJournalMode != "" {
 		mode, err := litekit.JournalModeFromString(cfg.StorageJournalMode)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		connOption = append(connOption, litekit.WithJournalMode(mode))
@@ -279,43 +1381,245 @@ func initStorage(cfg *config.Config, logger *slog.Logger) (*litestore.Storage, e
 
 	conn, conErr := litekit.New(cfg.StorageDBPath, connOption...)
 	if conErr != nil {
-		return nil, fmt.Errorf("connect to database: %w", conErr)
+		return nil, nil, fmt.Errorf("connect to database: %w", conErr)
 	}
 
 	evolver, evolverErr := litekit.NewEvolver(conn, mutations.StorageMutations())
 	if evolverErr != nil {
-		return nil, fmt.Errorf("create schema evolver: %w", evolverErr)
+		return nil, nil, fmt.Errorf("create schema evolver: %w", evolverErr)
 	}
 
 	if err := evolver.MutateSchema(); err != nil {
-		return nil, fmt.Errorf("schema mutation: %w", err)
+		return nil, nil, fmt.Errorf("schema mutation: %w", err)
 	}
 
-	storageOptions := make([]litestore.Option, 0, 2)
+	if cfg.ClusterEnable {
+		return initClusterStorage(cfg, loggers, observer, conn)
+	}
 
-	if cfg.StorageLogEnable {
-		storageOptions = append(storageOptions, litestore.WithLogger(logger))
+	if cfg.FailoverEnable {
+		return initFailoverStorage(cfg, loggers, observer, conn)
 	}
 
-	if cfg.StorageGCTimeout != 0 {
-		storageOptions = append(storageOptions, litestore.WithGCTimeout(cfg.StorageGCTimeout))
+	options, optionsErr := storageOptions(cfg, loggers, observer)
+	if optionsErr != nil {
+		return nil, nil, errors.Join(optionsErr, conn.Close())
 	}
 
-	sqliteStorage, storageInitErr := litestore.New(conn, storageOptions...)
+	sqliteStorage, storageInitErr := litestore.New(conn, options...)
+	if storageInitErr != nil {
+		return nil, nil, fmt.Errorf("create storage: %w", storageInitErr)
+	}
+
+	return sqliteStorage, nil, nil
+}
+
+// initClusterStorage starts the node of the cluster which replicates changes of the database of conn.
+// The schema is evolved by each server on its own, so all servers of the cluster must run the same version.
+func initClusterStorage(cfg *config.Config, loggers *logging.Loggers, observer telemetry.Observer, conn *litekit.Conn) (*litestore.Storage, leadership, error) {
+	logger := loggers.Logger(logging.Cluster)
+
+	if cfg.ClusterNodeID == "" {
+		return nil, nil, errors.Join(errors.New("cluster.node-id is required in the cluster mode"), conn.Close())
+	}
+
+	peers, peersErr := cluster.ParsePeers(cfg.ClusterPeers)
+	if peersErr != nil {
+		return nil, nil, errors.Join(fmt.Errorf("cluster peers: %w", peersErr), conn.Close())
+	}
+
+	options, optionsErr := storageOptions(cfg, loggers, observer)
+	if optionsErr != nil {
+		return nil, nil, errors.Join(optionsErr, conn.Close())
+	}
+
+	dir := cfg.ClusterDir
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(cfg.StorageDBPath), "raft")
+	}
+
+	node, nodeErr := cluster.New(cluster.Config{
+		NodeID:       cfg.ClusterNodeID,
+		Addr:         cfg.ClusterAddr,
+		Advertise:    cfg.ClusterAdvertise,
+		Dir:          dir,
+		Peers:        peers,
+		ApplyTimeout: cfg.ClusterApplyTimeout,
+		Logger:       logger,
+	}, conn.DB)
+	if nodeErr != nil {
+		return nil, nil, errors.Join(fmt.Errorf("start cluster node: %w", nodeErr), conn.Close())
+	}
+
+	// The storage changes the database through the node, and the node closes the database of conn.
+	options = append(options, litestore.WithLeader(node.IsLeader))
+
+	sqliteStorage, storageInitErr := litestore.New(&litekit.Conn{DB: node.DB()}, options...)
+	if storageInitErr != nil {
+		return nil, nil, errors.Join(fmt.Errorf("create storage: %w", storageInitErr), node.Close())
+	}
+
+	node.OnLead(sqliteStorage.Lead)
+
+	logger.Info("Serving as a server of the cluster",
+		slog.String("node_id", cfg.ClusterNodeID),
+		slog.String("address", cfg.ClusterAddr),
+		slog.String("dir", dir),
+	)
+
+	return sqliteStorage, node, nil
+}
+
+// initFailoverStorage competes for the lease of the active server among servers sharing the database of conn.
+// The standby serves reads and takes writes over once the lease of the active server expires.
+func initFailoverStorage(cfg *config.Config, loggers *logging.Loggers, observer telemetry.Observer, conn *litekit.Conn) (*litestore.Storage, leadership, error) {
+	logger := loggers.Logger(logging.Cluster)
+
+	holder := cfg.FailoverHolder
+	if holder == "" {
+		hostname, hostnameErr := os.Hostname()
+		if hostnameErr != nil {
+			return nil, nil, errors.Join(fmt.Errorf("get host name of the lease holder: %w", hostnameErr), conn.Close())
+		}
+
+		holder = hostname
+	}
+
+	options, optionsErr := storageOptions(cfg, loggers, observer)
+	if optionsErr != nil {
+		return nil, nil, errors.Join(optionsErr, conn.Close())
+	}
+
+	l, leaseErr := lease.New(lease.Config{
+		Holder:        holder,
+		TTL:           cfg.FailoverLeaseTTL,
+		RenewInterval: cfg.FailoverRenewInterval,
+		Logger:        logger,
+	}, conn.DB)
+	if leaseErr != nil {
+		return nil, nil, errors.Join(fmt.Errorf("start lease: %w", leaseErr), conn.Close())
+	}
+
+	options = append(options, litestore.WithLeader(l.IsLeader))
+
+	sqliteStorage, storageInitErr := litestore.New(conn, options...)
+	if storageInitErr != nil {
+		return nil, nil, errors.Join(fmt.Errorf("create storage: %w", storageInitErr), l.Close(), conn.Close())
+	}
+
+	l.OnLead(sqliteStorage.Lead)
+
+	logger.Info("Competing for the lease of the active server",
+		slog.String("holder", holder),
+		slog.Duration("ttl", cfg.FailoverLeaseTTL),
+	)
+
+	return sqliteStorage, l, nil
+}
+
+// initReplicaStorage opens the database of the primary server read-only.
+// The schema is evolved by the primary server, so the replica must run the same version.
+func initReplicaStorage(cfg *config.Config, loggers *logging.Loggers, observer telemetry.Observer) (*litestore.Storage, error) {
+	logger := loggers.Logger(logging.Storage)
+
+	if cfg.StorageDBPath != "" {
+		return nil, errors.New("storage.path and replica-of can't be set together: the replica serves the database of the primary server")
+	}
+
+	if _, err := os.Stat(cfg.ReplicaOf); err != nil {
+		return nil, fmt.Errorf("open database of the primary server: %w", err)
+	}
+
+	if cfg.StorageQueueCacheTTL == 0 {
+		logger.Warn("Queue cache entries of the replica never expire, set storage.queue-cache.ttl to see changes of queues made by the primary server")
+	}
+
+	if cfg.StorageMaintenanceWindow != "" {
+		logger.Warn("Maintenance window is ignored by the replica, the database is maintained by the primary server")
+	}
+
+	connOption := []litekit.Option{litekit.WithAccessMode(litekit.ReadOnly)}
+
+	if cfg.StorageJournalMode != "" {
+		mode, err := litekit.JournalModeFromString(cfg.StorageJournalMode)
+		if err != nil {
+			return nil, err
+		}
+
+		connOption = append(connOption, litekit.WithJournalMode(mode))
+	}
+
+	conn, conErr := litekit.New(cfg.ReplicaOf, connOption...)
+	if conErr != nil {
+		return nil, fmt.Errorf("connect to database: %w", conErr)
+	}
+
+	options, optionsErr := storageOptions(cfg, loggers, observer)
+	if optionsErr != nil {
+		return nil, errors.Join(optionsErr, conn.Close())
+	}
+
+	sqliteStorage, storageInitErr := litestore.New(conn, append(options, litestore.WithReadOnly())...)
 	if storageInitErr != nil {
 		return nil, fmt.Errorf("create storage: %w", storageInitErr)
 	}
 
+	logger.Info("Serving as a read-only replica",
+		slog.String("path", cfg.ReplicaOf),
+	)
+
 	return sqliteStorage, nil
 }
 
-func printAddrHTTP(addr string) string {
+// storageOptions returns options of the storage set by the configuration.
+func storageOptions(cfg *config.Config, loggers *logging.Loggers, observer telemetry.Observer) ([]litestore.Option, error) {
+	options := make([]litestore.Option, 0, 10)
+	options = append(options,
+		litestore.WithObserver(observer),
+		litestore.WithSearchMaxIndexedBytes(cfg.StorageSearchMaxIndexedBytes),
+		litestore.WithMaxMessageSize(cfg.StorageMaxMessageSize),
+		litestore.WithQueueEvolution(cfg.StorageQueueEvolutionBatchSize, cfg.StorageQueueEvolutionPause),
+		litestore.WithDepthInterval(cfg.StorageDepthInterval),
+		litestore.WithQueuePropsCache(cfg.StorageQueueCacheSize, cfg.StorageQueueCacheTTL),
+		litestore.WithMessageEvents(cfg.StorageMessageEventsSize, cfg.StorageMessageEventsRetention),
+		litestore.WithGrantsCacheTTL(cfg.AuthGrantsCacheTTL),
+	)
+
+	if cfg.StorageLogEnable {
+		options = append(options,
+			litestore.WithLogger(loggers.Logger(logging.Storage)),
+			litestore.WithGCLogger(loggers.Logger(logging.GC)),
+		)
+	}
+
+	if cfg.StorageGCTimeout != 0 {
+		options = append(options, litestore.WithGCTimeout(cfg.StorageGCTimeout))
+	}
+
+	if cfg.StorageMaintenanceWindow != "" {
+		window, err := litestore.ParseMaintenanceWindow(cfg.StorageMaintenanceWindow, cfg.StorageMaintenanceOperations)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, litestore.WithMaintenanceWindow(window))
+	}
+
+	return options, nil
+}
+
+func printAddrHTTP(addr string, tls bool) string {
 	if strings.HasPrefix(addr, "http") {
 		return addr
 	}
 
+	scheme := "http"
+	if tls {
+		scheme = "https"
+	}
+
 	if strings.HasPrefix(addr, ":") {
-		return fmt.Sprintf("http://localhost%s", addr)
+		return fmt.Sprintf("%s://localhost%s", scheme, addr)
 	}
 
 	return addr
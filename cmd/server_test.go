@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/config"
+	"github.com/plainq/plainq/internal/server/logging"
+	"github.com/plainq/servekit/logkit"
+)
+
+func Test_loadServerConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plainq.json")
+	td.CmpNoError(t, os.WriteFile(path, []byte(`{"log.level": "debug", "storage.gc.timeout": "5m"}`), 0o600))
+
+	cfg, err := loadServerConfig(map[string]string{configFlag: path, "log.level": "warn"})
+	td.CmpNoError(t, err)
+
+	td.Cmp(t, cfg.LogLevel, "warn")
+	td.Cmp(t, cfg.StorageGCTimeout, 5*time.Minute)
+	td.Cmp(t, cfg.GRPCAddr, ":8080")
+
+	_, err = loadServerConfig(map[string]string{"storage.gc.timeout": "soon"})
+	td.CmpError(t, err)
+}
+
+func Test_reloadLogLevels(t *testing.T) {
+	loggers := logging.New(logkit.NewNop(), 0)
+
+	td.CmpNoError(t, loggers.SetLevels(map[string]string{logging.GC: "debug"}))
+
+	td.CmpNoError(t, reloadLogLevels(&config.Config{LogLevel: "warn", LogLevels: "http=error"}, loggers))
+	td.Cmp(t, loggers.Levels(), td.SuperMapOf(map[string]string{
+		logging.Server: "warn",
+		logging.GC:     "warn",
+		logging.HTTP:   "error",
+	}, nil))
+
+	td.CmpError(t, reloadLogLevels(&config.Config{LogLevel: "loud"}, loggers))
+	td.CmpError(t, reloadLogLevels(&config.Config{LogLevel: "info", LogLevels: "nope=debug"}, loggers))
+	td.Cmp(t, loggers.Levels()[logging.HTTP], "error")
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/heartwilltell/scotty"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+)
+
+// queueStates maps state names accepted by the state command to queue states.
+var queueStates = map[string]v1.QueueState{
+	"creating": v1.QueueState_QUEUE_STATE_CREATING,
+	"active":   v1.QueueState_QUEUE_STATE_ACTIVE,
+	"paused":   v1.QueueState_QUEUE_STATE_PAUSED,
+	"draining": v1.QueueState_QUEUE_STATE_DRAINING,
+	"deleting": v1.QueueState_QUEUE_STATE_DELETING,
+	"archived": v1.QueueState_QUEUE_STATE_ARCHIVED,
+}
+
+func stateCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "state",
+		Short: "Move a queue to another lifecycle state: active, paused, draining or archived",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 2 {
+				return errors.New("queue id and state should be specified: plainq state [queue id] [state]")
+			}
+
+			id := args[0]
+
+			if err := validateQueueID(id); err != nil {
+				return err
+			}
+
+			state, ok := queueStates[args[1]]
+			if !ok {
+				return fmt.Errorf("unknown queue state: %q", args[1])
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, setErr := cli.SetQueueState(ctx, &v1.SetQueueStateRequest{QueueId: id, State: state})
+			if setErr != nil {
+				return fmt.Errorf("set queue state: %w", setErr)
+			}
+
+			if jsonOut {
+				if err := pqjson.Encode(os.Stdout, output); err != nil {
+					return fmt.Errorf("encode response: %w", err)
+				}
+
+				return nil
+			}
+
+			fmt.Fprintf(os.Stdout, "Queue %q is %s\n", id, stateName(output.GetState()))
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+// stateName returns the queue state name in the form accepted by the state command.
+func stateName(s v1.QueueState) string {
+	for name, state := range queueStates {
+		if state == s {
+			return name
+		}
+	}
+
+	return "unspecified"
+}
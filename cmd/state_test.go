@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+func Test_stateName(t *testing.T) {
+	tests := map[string]struct {
+		state v1.QueueState
+		want  string
+	}{
+		"Unspecified": {state: v1.QueueState_QUEUE_STATE_UNSPECIFIED, want: "unspecified"},
+		"Active":      {state: v1.QueueState_QUEUE_STATE_ACTIVE, want: "active"},
+		"Draining":    {state: v1.QueueState_QUEUE_STATE_DRAINING, want: "draining"},
+		"Archived":    {state: v1.QueueState_QUEUE_STATE_ARCHIVED, want: "archived"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, stateName(tc.state), tc.want)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heartwilltell/scotty"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+)
+
+// clearScreen moves the cursor home and clears the terminal screen.
+const clearScreen = "\033[H\033[2J"
+
+func statsCommand() *scotty.Command {
+	var (
+		conn     connFlags
+		watch    bool
+		interval time.Duration
+		jsonOut  bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "stats",
+		Short: "Show queue statistics",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+
+			flags.BoolVar(&watch, "watch", false,
+				"keeps refreshing the statistics until interrupted",
+			)
+
+			flags.DurationVar(&interval, "interval", 2*time.Second,
+				"sets the refresh interval of the watch mode",
+			)
+
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if len(args) < 1 {
+				return errors.New("queue id should be specified: plainq stats [queue id]")
+			}
+
+			id := args[0]
+
+			if err := validateQueueID(id); err != nil {
+				return err
+			}
+
+			if watch && interval < 100*time.Millisecond {
+				return fmt.Errorf("interval should be at least 100ms: %s", interval)
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			redraw := watch && !jsonOut && isTerminal(os.Stdout)
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			var prev *v1.QueueStatsResponse
+
+			for {
+				stats, statsErr := cli.QueueStats(ctx, &v1.QueueStatsRequest{QueueId: id})
+				if statsErr != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+
+					return fmt.Errorf("get queue stats (id: %q): %w", id, statsErr)
+				}
+
+				if jsonOut {
+					if err := pqjson.Encode(os.Stdout, stats); err != nil {
+						return fmt.Errorf("encode response: %w", err)
+					}
+				} else {
+					if redraw {
+						fmt.Print(clearScreen)
+					}
+
+					if err := writeStats(os.Stdout, stats, prev); err != nil {
+						return fmt.Errorf("write queue stats: %w", err)
+					}
+				}
+
+				if !watch {
+					return nil
+				}
+
+				prev = stats
+
+				select {
+				case <-ctx.Done():
+					return nil
+
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+
+	return &cmd
+}
+
+// statsRates holds the rates of queue operations per second.
+type statsRates struct {
+	send    float64
+	receive float64
+	// window is the period the rates are measured over.
+	window time.Duration
+	// average tells that the rates are averaged since the server start.
+	average bool
+}
+
+// queueStatsRates calculates the rates between the previous and the current
+// statistics. When there is no previous statistics, or the server has been
+// restarted since, the rates are averaged since the server start.
+func queueStatsRates(prev, cur *v1.QueueStatsResponse) statsRates {
+	collected := cur.GetCollectedAt().AsTime()
+
+	if prev == nil ||
+		!prev.GetCountersSince().AsTime().Equal(cur.GetCountersSince().AsTime()) ||
+		cur.GetMessagesSentTotal() < prev.GetMessagesSentTotal() ||
+		cur.GetMessagesReceivedTotal() < prev.GetMessagesReceivedTotal() {
+		window := collected.Sub(cur.GetCountersSince().AsTime())
+
+		return statsRates{
+			send:    perSecond(cur.GetMessagesSentTotal(), window),
+			receive: perSecond(cur.GetMessagesReceivedTotal(), window),
+			window:  window,
+			average: true,
+		}
+	}
+
+	window := collected.Sub(prev.GetCollectedAt().AsTime())
+
+	return statsRates{
+		send:    perSecond(cur.GetMessagesSentTotal()-prev.GetMessagesSentTotal(), window),
+		receive: perSecond(cur.GetMessagesReceivedTotal()-prev.GetMessagesReceivedTotal(), window),
+		window:  window,
+	}
+}
+
+func perSecond(n uint64, window time.Duration) float64 {
+	if window <= 0 {
+		return 0
+	}
+
+	return float64(n) / window.Seconds()
+}
+
+// writeStats writes the queue statistics to w in a human-readable form.
+func writeStats(w io.Writer, cur, prev *v1.QueueStatsResponse) error {
+	rates := queueStatsRates(prev, cur)
+
+	period := "last " + rates.window.Round(time.Millisecond).String()
+	if rates.average {
+		period = "average since " + cur.GetCountersSince().AsTime().Local().Format(time.DateTime)
+	}
+
+	var emptyShare float64
+	if r := cur.GetReceiveRequestsTotal(); r > 0 {
+		emptyShare = float64(cur.GetEmptyReceivesTotal()) / float64(r) * 100
+	}
+
+	oldest := time.Duration(cur.GetOldestMessageAgeSeconds()) * time.Second
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Queue:\t%s\n", cur.GetQueueId())
+	fmt.Fprintf(tw, "Collected at:\t%s\n", cur.GetCollectedAt().AsTime().Local().Format(time.DateTime))
+	fmt.Fprintf(tw, "Depth:\t%d\n", cur.GetDepth())
+	fmt.Fprintf(tw, "Size:\t%d bytes\n", cur.GetBytes())
+	fmt.Fprintf(tw, "In flight:\t%d\n", cur.GetInFlight())
+	fmt.Fprintf(tw, "Oldest message age:\t%s\n", oldest)
+	fmt.Fprintf(tw, "Send rate:\t%.2f msg/s (%s)\n", rates.send, period)
+	fmt.Fprintf(tw, "Receive rate:\t%.2f msg/s (%s)\n", rates.receive, period)
+	fmt.Fprintf(tw, "Empty receives:\t%d of %d requests (%.1f%%)\n",
+		cur.GetEmptyReceivesTotal(), cur.GetReceiveRequestsTotal(), emptyShare,
+	)
+
+	return tw.Flush()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_queueStatsRates(t *testing.T) {
+	since := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	stats := func(since time.Time, at time.Duration, sent, received uint64) *v1.QueueStatsResponse {
+		return &v1.QueueStatsResponse{
+			MessagesSentTotal:     sent,
+			MessagesReceivedTotal: received,
+			CountersSince:         timestamppb.New(since),
+			CollectedAt:           timestamppb.New(since.Add(at)),
+		}
+	}
+
+	tests := map[string]struct {
+		prev *v1.QueueStatsResponse
+		cur  *v1.QueueStatsResponse
+		want statsRates
+	}{
+		"First": {
+			cur:  stats(since, 100*time.Second, 1000, 500),
+			want: statsRates{send: 10, receive: 5, window: 100 * time.Second, average: true},
+		},
+		"Delta": {
+			prev: stats(since, 100*time.Second, 1000, 500),
+			cur:  stats(since, 102*time.Second, 1040, 502),
+			want: statsRates{send: 20, receive: 1, window: 2 * time.Second},
+		},
+		"ServerRestarted": {
+			prev: stats(since, 100*time.Second, 1000, 500),
+			cur:  stats(since.Add(time.Minute), 10*time.Second, 20, 10),
+			want: statsRates{send: 2, receive: 1, window: 10 * time.Second, average: true},
+		},
+		"ZeroWindow": {
+			cur:  stats(since, 0, 10, 10),
+			want: statsRates{average: true},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, queueStatsRates(tc.prev, tc.cur), tc.want)
+		})
+	}
+}
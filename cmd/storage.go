@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/heartwilltell/scotty"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+)
+
+func storageCommand() *scotty.Command {
+	cmd := scotty.Command{
+		Name:  "storage",
+		Short: "Maintains the storage database of the server",
+	}
+
+	cmd.AddSubcommands(
+		storageVacuumCommand(),
+		storageAnalyzeCommand(),
+	)
+
+	return &cmd
+}
+
+func storageVacuumCommand() *scotty.Command {
+	var (
+		conn        connFlags
+		jsonOut     bool
+		incremental bool
+		pages       uint64
+	)
+
+	cmd := scotty.Command{
+		Name:  "vacuum",
+		Short: "Rebuild the storage database to reclaim the space of deleted data",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+			flags.BoolVar(&incremental, "incremental", false,
+				"reclaims free pages without rebuilding the database, which requires a full vacuum to be run once",
+			)
+			flags.Uint64Var(&pages, "pages", 0,
+				"sets the maximum number of pages reclaimed by the incremental vacuum, all of them when zero",
+			)
+		},
+		Run: func(_ *scotty.Command, _ []string) error {
+			if pages > 0 && !incremental {
+				return errors.New("--pages requires --incremental")
+			}
+
+			input := v1.RunMaintenanceRequest{Operation: v1.MaintenanceOperation_MAINTENANCE_OPERATION_VACUUM}
+
+			if incremental {
+				input.Operation = v1.MaintenanceOperation_MAINTENANCE_OPERATION_INCREMENTAL_VACUUM
+				input.Pages = pages
+			}
+
+			return runMaintenance(&conn, &input, jsonOut)
+		},
+	}
+
+	return &cmd
+}
+
+func storageAnalyzeCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "analyze",
+		Short: "Gather statistics of the storage database which help to plan queries",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, _ []string) error {
+			input := v1.RunMaintenanceRequest{Operation: v1.MaintenanceOperation_MAINTENANCE_OPERATION_ANALYZE}
+
+			return runMaintenance(&conn, &input, jsonOut)
+		},
+	}
+
+	return &cmd
+}
+
+// runMaintenance runs the maintenance operation on the server and writes the result to stdout.
+func runMaintenance(conn *connFlags, input *v1.RunMaintenanceRequest, jsonOut bool) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	cli, cliErr := newClient(conn)
+	if cliErr != nil {
+		return fmt.Errorf("create client: %w", cliErr)
+	}
+
+	output, runErr := cli.RunMaintenance(ctx, input)
+	if runErr != nil {
+		return fmt.Errorf("run %s: %w", maintenanceOperationName(input.GetOperation()), runErr)
+	}
+
+	if jsonOut {
+		if err := pqjson.Encode(os.Stdout, output); err != nil {
+			return fmt.Errorf("encode response: %w", err)
+		}
+
+		return nil
+	}
+
+	return writeMaintenance(os.Stdout, output)
+}
+
+// writeMaintenance writes the operation and its duration followed by the table of sizes in bytes before and after it.
+func writeMaintenance(w io.Writer, result *v1.RunMaintenanceResponse) error {
+	duration := result.GetFinishedAt().AsTime().Sub(result.GetStartedAt().AsTime())
+
+	fmt.Fprintf(w, "Completed %s in %s\n\n", maintenanceOperationName(result.GetOperation()), duration)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "\tBEFORE\tAFTER")
+	fmt.Fprintf(tw, "Size\t%d\t%d\n", result.GetBefore().GetSizeBytes(), result.GetAfter().GetSizeBytes())
+	fmt.Fprintf(tw, "Free\t%d\t%d\n", result.GetBefore().GetFreeBytes(), result.GetAfter().GetFreeBytes())
+	fmt.Fprintf(tw, "WAL\t%d\t%d\n", result.GetBefore().GetWalBytes(), result.GetAfter().GetWalBytes())
+
+	return tw.Flush()
+}
+
+// maintenanceOperationName returns the lowercase name of the operation, e.g. "incremental vacuum".
+func maintenanceOperationName(op v1.MaintenanceOperation) string {
+	name := strings.TrimPrefix(op.String(), "MAINTENANCE_OPERATION_")
+
+	return strings.ToLower(strings.ReplaceAll(name, "_", " "))
+}
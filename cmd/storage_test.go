@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_writeMaintenance(t *testing.T) {
+	startedAt := time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC)
+
+	result := v1.RunMaintenanceResponse{
+		Operation:  v1.MaintenanceOperation_MAINTENANCE_OPERATION_INCREMENTAL_VACUUM,
+		Before:     &v1.StorageSize{SizeBytes: 1048576, FreeBytes: 524288, WalBytes: 4096},
+		After:      &v1.StorageSize{SizeBytes: 524288},
+		StartedAt:  timestamppb.New(startedAt),
+		FinishedAt: timestamppb.New(startedAt.Add(1500 * time.Millisecond)),
+	}
+
+	var buf bytes.Buffer
+
+	td.CmpNoError(t, writeMaintenance(&buf, &result))
+
+	td.Cmp(t, buf.String(), ""+
+		"Completed incremental vacuum in 1.5s\n"+
+		"\n"+
+		"      BEFORE   AFTER\n"+
+		"Size  1048576  524288\n"+
+		"Free  524288   0\n"+
+		"WAL   4096     0\n",
+	)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heartwilltell/scotty"
+	"github.com/plainq/plainq/internal/server/auth"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+)
+
+func whoamiCommand() *scotty.Command {
+	var (
+		conn    connFlags
+		jsonOut bool
+	)
+
+	cmd := scotty.Command{
+		Name:  "whoami",
+		Short: "Show the user of the current context and the endpoint it's connected to",
+		SetFlags: func(flags *scotty.FlagSet) {
+			conn.register(flags)
+			flags.BoolVar(&jsonOut, "json", false,
+				"enables json output",
+			)
+		},
+		Run: func(_ *scotty.Command, _ []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			current, currentErr := resolveContext(&conn)
+			if currentErr != nil {
+				return currentErr
+			}
+
+			// The token is decoded only to show when it expires,
+			// the server verifies it and tells who the user is.
+			var claims *auth.Claims
+
+			if current.Auth != nil && current.Auth.Token != "" {
+				parsed, parseErr := auth.ParseJWT(current.Auth.Token)
+				if parseErr != nil {
+					return fmt.Errorf("context %q: decode token: %w", current.Name, parseErr)
+				}
+
+				claims = parsed
+			}
+
+			cli, cliErr := newClient(&conn)
+			if cliErr != nil {
+				return fmt.Errorf("create client: %w", cliErr)
+			}
+
+			output, accountErr := cli.GetAccount(ctx, &v1.GetAccountRequest{})
+			if accountErr != nil {
+				return fmt.Errorf("get account (context: %q, endpoint: %q): %w", current.Name, current.Endpoint, accountErr)
+			}
+
+			if jsonOut {
+				return encodeWhoami(os.Stdout, current, claims, output.GetAccount())
+			}
+
+			return writeWhoami(os.Stdout, current, claims, output.GetAccount())
+		},
+	}
+
+	return &cmd
+}
+
+// writeWhoami writes the user of the context to w as an aligned list.
+func writeWhoami(w io.Writer, ctx *plainqContext, claims *auth.Claims, account *v1.AccountProfile) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Context:\t%s\n", ctx.Name)
+	fmt.Fprintf(tw, "Endpoint:\t%s\n", ctx.Endpoint)
+	fmt.Fprintf(tw, "User ID:\t%s\n", account.GetUserId())
+	fmt.Fprintf(tw, "Email:\t%s\n", account.GetEmail())
+	fmt.Fprintf(tw, "Roles:\t%s\n", strings.Join(account.GetRoles(), ","))
+
+	if claims != nil && claims.ExpiresAt > 0 {
+		fmt.Fprintf(tw, "Token expires:\t%s\n", time.Unix(claims.ExpiresAt, 0).Local().Format(time.DateTime))
+	}
+
+	return tw.Flush()
+}
+
+// encodeWhoami writes the user of the context to w as JSON.
+func encodeWhoami(w io.Writer, ctx *plainqContext, claims *auth.Claims, account *v1.AccountProfile) error {
+	data, marshalErr := pqjson.Marshal(account)
+	if marshalErr != nil {
+		return fmt.Errorf("encode account: %w", marshalErr)
+	}
+
+	output := struct {
+		Context        string          `json:"context"`
+		Endpoint       string          `json:"endpoint"`
+		Account        json.RawMessage `json:"account"`
+		TokenExpiresAt *time.Time      `json:"token_expires_at,omitempty"`
+	}{
+		Context:  ctx.Name,
+		Endpoint: ctx.Endpoint,
+		Account:  data,
+	}
+
+	if claims != nil && claims.ExpiresAt > 0 {
+		expiresAt := time.Unix(claims.ExpiresAt, 0).UTC()
+		output.TokenExpiresAt = &expiresAt
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(output); err != nil {
+		return fmt.Errorf("encode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/auth"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+func Test_writeWhoami(t *testing.T) {
+	expiresAt := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+
+	ctx := plainqContext{Name: "prod", Endpoint: "plainq.example.com:8080"}
+	account := v1.AccountProfile{UserId: "U1", Email: "ada@example.com", Roles: []string{"admin", "payments"}}
+
+	var buf bytes.Buffer
+
+	td.CmpNoError(t, writeWhoami(&buf, &ctx, &auth.Claims{Subject: "ada@example.com", ExpiresAt: expiresAt.Unix()}, &account))
+
+	td.Cmp(t, buf.String(), ""+
+		"Context:        prod\n"+
+		"Endpoint:       plainq.example.com:8080\n"+
+		"User ID:        U1\n"+
+		"Email:          ada@example.com\n"+
+		"Roles:          admin,payments\n"+
+		"Token expires:  2024-05-01 10:30:00\n",
+	)
+
+	buf.Reset()
+
+	td.CmpNoError(t, encodeWhoami(&buf, &ctx, nil, &account))
+	td.Cmp(t, json.RawMessage(buf.Bytes()), td.JSON(`{
+		"context": "prod",
+		"endpoint": "plainq.example.com:8080",
+		"account": {"userId": "U1", "email": "ada@example.com", "roles": ["admin", "payments"]}
+	}`))
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tokenRefreshLeeway represents how long before the expiry the token is refreshed,
+// so the token doesn't expire while the request is on its way to the server.
+const tokenRefreshLeeway = 30 * time.Second
+
+// Token represents the access token issued to the client.
+type Token struct {
+	// AccessToken is the token which is sent to the server.
+	AccessToken string
+
+	// Expiry is the time the token expires at. Zero value means that it never expires.
+	Expiry time.Time
+}
+
+// TokenSource returns tokens to authenticate calls with, e.g. by signing in or refreshing the previous token.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc is an adapter to use ordinary functions as TokenSource.
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) { return f(ctx) }
+
+// WithToken is an Option function that sets the token which
+// is sent in the authorization metadata of each request.
+func WithToken(token string) Option {
+	return func(o *Options) {
+		o.interceptors = append(o.interceptors, tokenInterceptor(token))
+	}
+}
+
+// WithAPIKey is an Option function that sets the API key which
+// is sent in the authorization metadata of each request.
+func WithAPIKey(key string) Option {
+	return func(o *Options) {
+		o.interceptors = append(o.interceptors, tokenInterceptor(key))
+	}
+}
+
+// WithTokenSource is an Option function that authenticates requests with tokens of the source.
+// The token is reused until it's about to expire, and a request rejected as unauthenticated
+// is sent once again with a new token, e.g. when the token has been revoked.
+func WithTokenSource(src TokenSource) Option {
+	return func(o *Options) {
+		o.interceptors = append(o.interceptors, tokenSourceInterceptor(&cachedTokenSource{src: src}))
+	}
+}
+
+// tokenInterceptor returns grpc.UnaryClientInterceptor
+// which adds the bearer token to the outgoing metadata.
+func tokenInterceptor(token string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// tokenSourceInterceptor returns grpc.UnaryClientInterceptor
+// which adds the bearer token of the source to the outgoing metadata.
+func tokenSourceInterceptor(src *cachedTokenSource) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		token, tokenErr := src.token(ctx)
+		if tokenErr != nil {
+			return status.Error(codes.Unauthenticated, fmt.Sprintf("get token: %s", tokenErr))
+		}
+
+		err := invoker(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token), method, req, reply, cc, opts...)
+		if status.Code(err) != codes.Unauthenticated {
+			return err
+		}
+
+		src.invalidate(token)
+
+		token, tokenErr = src.token(ctx)
+		if tokenErr != nil {
+			return status.Error(codes.Unauthenticated, fmt.Sprintf("refresh token: %s", tokenErr))
+		}
+
+		return invoker(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token), method, req, reply, cc, opts...)
+	}
+}
+
+// cachedTokenSource reuses the token of the source until it's about to expire.
+type cachedTokenSource struct {
+	src TokenSource
+	now func() time.Time
+
+	mu      sync.Mutex
+	current *Token
+}
+
+// token returns the current token, getting the new one from the source when there is none
+// or it's about to expire. Concurrent requests wait for the single refresh.
+func (s *cachedTokenSource) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+
+	if s.current != nil && (s.current.Expiry.IsZero() || now().Add(tokenRefreshLeeway).Before(s.current.Expiry)) {
+		return s.current.AccessToken, nil
+	}
+
+	token, err := s.src.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if token == nil || token.AccessToken == "" {
+		return "", errors.New("token source has returned an empty token")
+	}
+
+	s.current = token
+
+	return token.AccessToken, nil
+}
+
+// invalidate drops the token rejected by the server, unless it has been refreshed already.
+func (s *cachedTokenSource) invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current != nil && s.current.AccessToken == token {
+		s.current = nil
+	}
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func Test_cachedTokenSource(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var issued int
+
+	src := cachedTokenSource{
+		now: func() time.Time { return now },
+		src: TokenSourceFunc(func(context.Context) (*Token, error) {
+			issued++
+			return &Token{AccessToken: "token-" + strconv.Itoa(issued), Expiry: now.Add(time.Hour)}, nil
+		}),
+	}
+
+	ctx := context.Background()
+
+	token, err := src.token(ctx)
+	td.CmpNoError(t, err)
+	td.Cmp(t, token, "token-1")
+
+	// The token is reused until it's about to expire.
+	now = now.Add(time.Hour - 2*tokenRefreshLeeway)
+	token, err = src.token(ctx)
+	td.CmpNoError(t, err)
+	td.Cmp(t, token, "token-1")
+
+	now = now.Add(tokenRefreshLeeway)
+	token, err = src.token(ctx)
+	td.CmpNoError(t, err)
+	td.Cmp(t, token, "token-2")
+
+	// Stale tokens don't drop the refreshed one.
+	src.invalidate("token-1")
+	token, err = src.token(ctx)
+	td.CmpNoError(t, err)
+	td.Cmp(t, token, "token-2")
+
+	src.invalidate("token-2")
+	token, err = src.token(ctx)
+	td.CmpNoError(t, err)
+	td.Cmp(t, token, "token-3")
+}
+
+func Test_cachedTokenSource_error(t *testing.T) {
+	src := cachedTokenSource{src: TokenSourceFunc(func(context.Context) (*Token, error) { return nil, errors.New("fail") })}
+	_, err := src.token(context.Background())
+	td.CmpError(t, err)
+
+	src = cachedTokenSource{src: TokenSourceFunc(func(context.Context) (*Token, error) { return &Token{}, nil })}
+	_, err = src.token(context.Background())
+	td.CmpError(t, err)
+}
+
+func Test_tokenSourceInterceptor(t *testing.T) {
+	var issued int
+
+	src := cachedTokenSource{
+		src: TokenSourceFunc(func(context.Context) (*Token, error) {
+			issued++
+			return &Token{AccessToken: "token-" + strconv.Itoa(issued)}, nil
+		}),
+	}
+
+	var sent []string
+
+	// The server rejects the first token as revoked.
+	invoker := func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		sent = append(sent, md.Get("authorization")...)
+
+		if md.Get("authorization")[0] == "Bearer token-1" {
+			return status.Error(codes.Unauthenticated, "revoked token")
+		}
+
+		return nil
+	}
+
+	interceptor := tokenSourceInterceptor(&src)
+
+	td.CmpNoError(t, interceptor(context.Background(), "/v1.PlainQService/ListQueues", nil, nil, nil, invoker))
+	td.CmpNoError(t, interceptor(context.Background(), "/v1.PlainQService/ListQueues", nil, nil, nil, invoker))
+	td.Cmp(t, sent, []string{"Bearer token-1", "Bearer token-2", "Bearer token-2"})
+}
@@ -2,57 +2,113 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"time"
 
 	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
 const (
 	dialTimeout = 10 * time.Second
+
+	// defaultUserAgent identifies the client to the server unless WithUserAgent is set.
+	defaultUserAgent = "plainq-go"
 )
 
 // Option configures the Client structs with Options properties.
 type Option func(*Options)
 
-// WithDialTimeout is an Option function that sets the dial timeout duration for the Client.
+// WithDialTimeout is an Option function that sets the timeout of each attempt to connect to the server,
+// which also bounds how long calls wait for the connection to be re-established before they fail.
 func WithDialTimeout(t time.Duration) Option {
 	return func(o *Options) { o.dialTimeout = t }
 }
 
+// WithTLS is an Option function that enables TLS with given configuration for the Client.
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *Options) { o.tls = cfg }
+}
+
+// WithUserAgent is an Option function that sets the user agent which identifies the Client
+// to the server, e.g. "orders-service/1.2.0". The gRPC library appends its own version to it.
+func WithUserAgent(ua string) Option {
+	return func(o *Options) { o.userAgent = ua }
+}
+
+// WithUnaryInterceptor is an Option function that adds interceptors of unary calls, e.g. for logging.
+// Interceptors are called in the order they are added, after the retries of the Client,
+// so each attempt of the call passes through them.
+func WithUnaryInterceptor(interceptors ...grpc.UnaryClientInterceptor) Option {
+	return func(o *Options) { o.interceptors = append(o.interceptors, interceptors...) }
+}
+
 // Options holds a set of properties to configure Client.
 type Options struct {
 	dialTimeout  time.Duration
 	interceptors []grpc.UnaryClientInterceptor
 	userAgent    string
+	tls          *tls.Config
+	retry        RetryPolicy
+
+	reconnect      backoff.Config
+	stateCallbacks []func(state connectivity.State)
+	metrics        []func(info CallInfo)
 }
 
 // Client represents a gRPC client for plainq server.
 type Client struct {
 	conn   *grpc.ClientConn
 	client v1.PlainQServiceClient
+	health grpc_health_v1.HealthClient
 }
 
-// New returns a pointer to a new instance of Client.
+// New returns a pointer to a new instance of Client. The client connects to the server
+// in the background and reconnects with a backoff whenever the connection is lost.
 func New(addr string, options ...Option) (*Client, error) {
 	opts := Options{
 		dialTimeout:  dialTimeout,
+		userAgent:    defaultUserAgent,
 		interceptors: make([]grpc.UnaryClientInterceptor, 0, 10),
+		retry:        DefaultRetryPolicy(),
+		reconnect:    defaultReconnectBackoff(),
 	}
 
 	for _, option := range options {
 		option(&opts)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), opts.dialTimeout)
-	defer cancel()
+	creds := insecure.NewCredentials()
+	if opts.tls != nil {
+		creds = credentials.NewTLS(opts.tls)
+	}
 
-	conn, dialErr := grpc.DialContext(ctx, addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	// Retries go first, so each attempt passes through the rest of interceptors
+	// and waits for the connection to be re-established.
+	interceptors := append([]grpc.UnaryClientInterceptor{
+		retryInterceptor(opts.retry),
+		readyInterceptor(opts.dialTimeout),
+	}, opts.interceptors...)
+
+	// Metrics wrap retries, so they describe calls as the application sees them.
+	if len(opts.metrics) > 0 {
+		interceptors = append([]grpc.UnaryClientInterceptor{metricsInterceptor(opts.metrics)}, interceptors...)
+	}
+
+	conn, dialErr := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: opts.reconnect, MinConnectTimeout: opts.dialTimeout}),
 		grpc.WithUserAgent(opts.userAgent),
-		grpc.WithChainUnaryInterceptor(opts.interceptors...),
+		grpc.WithChainUnaryInterceptor(interceptors...),
+		// Propagates the trace context of the caller to the server.
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 	)
 	if dialErr != nil {
 		return nil, fmt.Errorf("connect to server: %w", dialErr)
@@ -61,6 +117,14 @@ func New(addr string, options ...Option) (*Client, error) {
 	c := Client{
 		conn:   conn,
 		client: v1.NewPlainQServiceClient(conn),
+		health: grpc_health_v1.NewHealthClient(conn),
+	}
+
+	// Start connecting right away instead of on the first call.
+	conn.Connect()
+
+	if len(opts.stateCallbacks) > 0 {
+		go c.watchState(opts.stateCallbacks)
 	}
 
 	return &c, nil
@@ -86,6 +150,10 @@ func (c *Client) CreateQueue(ctx context.Context, in *v1.CreateQueueRequest, opt
 	return c.client.CreateQueue(ctx, in, opts...)
 }
 
+func (c *Client) UpdateQueue(ctx context.Context, in *v1.UpdateQueueRequest, opts ...grpc.CallOption) (*v1.UpdateQueueResponse, error) {
+	return c.client.UpdateQueue(ctx, in, opts...)
+}
+
 func (c *Client) DeleteQueue(ctx context.Context, in *v1.DeleteQueueRequest, opts ...grpc.CallOption) (*v1.DeleteQueueResponse, error) {
 	return c.client.DeleteQueue(ctx, in, opts...)
 }
@@ -105,3 +173,239 @@ func (c *Client) Receive(ctx context.Context, in *v1.ReceiveRequest, opts ...grp
 func (c *Client) Delete(ctx context.Context, in *v1.DeleteRequest, opts ...grpc.CallOption) (*v1.DeleteResponse, error) {
 	return c.client.Delete(ctx, in, opts...)
 }
+
+func (c *Client) ChangeVisibility(
+	ctx context.Context,
+	in *v1.ChangeVisibilityRequest,
+	opts ...grpc.CallOption,
+) (*v1.ChangeVisibilityResponse, error) {
+	return c.client.ChangeVisibility(ctx, in, opts...)
+}
+
+func (c *Client) AdviseQueue(ctx context.Context, in *v1.AdviseQueueRequest, opts ...grpc.CallOption) (*v1.AdviseQueueResponse, error) {
+	return c.client.AdviseQueue(ctx, in, opts...)
+}
+
+func (c *Client) QueueStats(ctx context.Context, in *v1.QueueStatsRequest, opts ...grpc.CallOption) (*v1.QueueStatsResponse, error) {
+	return c.client.QueueStats(ctx, in, opts...)
+}
+
+func (c *Client) GetLogLevels(ctx context.Context, in *v1.GetLogLevelsRequest, opts ...grpc.CallOption) (*v1.GetLogLevelsResponse, error) {
+	return c.client.GetLogLevels(ctx, in, opts...)
+}
+
+func (c *Client) SetLogLevels(ctx context.Context, in *v1.SetLogLevelsRequest, opts ...grpc.CallOption) (*v1.SetLogLevelsResponse, error) {
+	return c.client.SetLogLevels(ctx, in, opts...)
+}
+
+func (c *Client) ReloadConfig(ctx context.Context, in *v1.ReloadConfigRequest, opts ...grpc.CallOption) (*v1.ReloadConfigResponse, error) {
+	return c.client.ReloadConfig(ctx, in, opts...)
+}
+
+func (c *Client) ClusterStatus(ctx context.Context, in *v1.ClusterStatusRequest, opts ...grpc.CallOption) (*v1.ClusterStatusResponse, error) {
+	return c.client.ClusterStatus(ctx, in, opts...)
+}
+
+func (c *Client) RunMaintenance(ctx context.Context, in *v1.RunMaintenanceRequest, opts ...grpc.CallOption) (*v1.RunMaintenanceResponse, error) {
+	return c.client.RunMaintenance(ctx, in, opts...)
+}
+
+func (c *Client) ListBreakers(ctx context.Context, in *v1.ListBreakersRequest, opts ...grpc.CallOption) (*v1.ListBreakersResponse, error) {
+	return c.client.ListBreakers(ctx, in, opts...)
+}
+
+func (c *Client) ResetBreaker(ctx context.Context, in *v1.ResetBreakerRequest, opts ...grpc.CallOption) (*v1.ResetBreakerResponse, error) {
+	return c.client.ResetBreaker(ctx, in, opts...)
+}
+
+func (c *Client) SetQueueState(ctx context.Context, in *v1.SetQueueStateRequest, opts ...grpc.CallOption) (*v1.SetQueueStateResponse, error) {
+	return c.client.SetQueueState(ctx, in, opts...)
+}
+
+func (c *Client) PeekMessages(ctx context.Context, in *v1.PeekMessagesRequest, opts ...grpc.CallOption) (*v1.PeekMessagesResponse, error) {
+	return c.client.PeekMessages(ctx, in, opts...)
+}
+
+func (c *Client) GetMessage(ctx context.Context, in *v1.GetMessageRequest, opts ...grpc.CallOption) (*v1.GetMessageResponse, error) {
+	return c.client.GetMessage(ctx, in, opts...)
+}
+
+func (c *Client) ExtendVisibilityBatch(ctx context.Context, in *v1.ExtendVisibilityBatchRequest, opts ...grpc.CallOption) (*v1.ExtendVisibilityBatchResponse, error) {
+	return c.client.ExtendVisibilityBatch(ctx, in, opts...)
+}
+
+func (c *Client) ListMessageEvents(ctx context.Context, in *v1.ListMessageEventsRequest, opts ...grpc.CallOption) (*v1.ListMessageEventsResponse, error) {
+	return c.client.ListMessageEvents(ctx, in, opts...)
+}
+
+func (c *Client) ListArchivedMessages(ctx context.Context, in *v1.ListArchivedMessagesRequest, opts ...grpc.CallOption) (*v1.ListArchivedMessagesResponse, error) {
+	return c.client.ListArchivedMessages(ctx, in, opts...)
+}
+
+func (c *Client) RestoreArchivedMessages(ctx context.Context, in *v1.RestoreArchivedMessagesRequest, opts ...grpc.CallOption) (*v1.RestoreArchivedMessagesResponse, error) {
+	return c.client.RestoreArchivedMessages(ctx, in, opts...)
+}
+
+func (c *Client) GetJob(ctx context.Context, in *v1.GetJobRequest, opts ...grpc.CallOption) (*v1.GetJobResponse, error) {
+	return c.client.GetJob(ctx, in, opts...)
+}
+
+func (c *Client) ListJobs(ctx context.Context, in *v1.ListJobsRequest, opts ...grpc.CallOption) (*v1.ListJobsResponse, error) {
+	return c.client.ListJobs(ctx, in, opts...)
+}
+
+func (c *Client) CancelJob(ctx context.Context, in *v1.CancelJobRequest, opts ...grpc.CallOption) (*v1.CancelJobResponse, error) {
+	return c.client.CancelJob(ctx, in, opts...)
+}
+
+func (c *Client) Transact(ctx context.Context, in *v1.TransactRequest, opts ...grpc.CallOption) (*v1.TransactResponse, error) {
+	return c.client.Transact(ctx, in, opts...)
+}
+
+func (c *Client) SearchMessages(ctx context.Context, in *v1.SearchMessagesRequest, opts ...grpc.CallOption) (*v1.SearchMessagesResponse, error) {
+	return c.client.SearchMessages(ctx, in, opts...)
+}
+
+func (c *Client) Search(ctx context.Context, in *v1.SearchRequest, opts ...grpc.CallOption) (*v1.SearchResponse, error) {
+	return c.client.Search(ctx, in, opts...)
+}
+
+func (c *Client) TransferQueue(ctx context.Context, in *v1.TransferQueueRequest, opts ...grpc.CallOption) (*v1.TransferQueueResponse, error) {
+	return c.client.TransferQueue(ctx, in, opts...)
+}
+
+func (c *Client) AcceptQueueTransfer(
+	ctx context.Context,
+	in *v1.AcceptQueueTransferRequest,
+	opts ...grpc.CallOption,
+) (*v1.AcceptQueueTransferResponse, error) {
+	return c.client.AcceptQueueTransfer(ctx, in, opts...)
+}
+
+func (c *Client) CancelQueueTransfer(
+	ctx context.Context,
+	in *v1.CancelQueueTransferRequest,
+	opts ...grpc.CallOption,
+) (*v1.CancelQueueTransferResponse, error) {
+	return c.client.CancelQueueTransfer(ctx, in, opts...)
+}
+
+func (c *Client) StartGenerator(ctx context.Context, in *v1.StartGeneratorRequest, opts ...grpc.CallOption) (*v1.StartGeneratorResponse, error) {
+	return c.client.StartGenerator(ctx, in, opts...)
+}
+
+func (c *Client) StopGenerator(ctx context.Context, in *v1.StopGeneratorRequest, opts ...grpc.CallOption) (*v1.StopGeneratorResponse, error) {
+	return c.client.StopGenerator(ctx, in, opts...)
+}
+
+func (c *Client) ListGenerators(ctx context.Context, in *v1.ListGeneratorsRequest, opts ...grpc.CallOption) (*v1.ListGeneratorsResponse, error) {
+	return c.client.ListGenerators(ctx, in, opts...)
+}
+
+func (c *Client) ListAuditEvents(ctx context.Context, in *v1.ListAuditEventsRequest, opts ...grpc.CallOption) (*v1.ListAuditEventsResponse, error) {
+	return c.client.ListAuditEvents(ctx, in, opts...)
+}
+
+func (c *Client) CreateAlertRule(ctx context.Context, in *v1.CreateAlertRuleRequest, opts ...grpc.CallOption) (*v1.CreateAlertRuleResponse, error) {
+	return c.client.CreateAlertRule(ctx, in, opts...)
+}
+
+func (c *Client) ListAlertRules(ctx context.Context, in *v1.ListAlertRulesRequest, opts ...grpc.CallOption) (*v1.ListAlertRulesResponse, error) {
+	return c.client.ListAlertRules(ctx, in, opts...)
+}
+
+func (c *Client) UpdateAlertRule(ctx context.Context, in *v1.UpdateAlertRuleRequest, opts ...grpc.CallOption) (*v1.UpdateAlertRuleResponse, error) {
+	return c.client.UpdateAlertRule(ctx, in, opts...)
+}
+
+func (c *Client) DeleteAlertRule(ctx context.Context, in *v1.DeleteAlertRuleRequest, opts ...grpc.CallOption) (*v1.DeleteAlertRuleResponse, error) {
+	return c.client.DeleteAlertRule(ctx, in, opts...)
+}
+
+func (c *Client) ListAlerts(ctx context.Context, in *v1.ListAlertsRequest, opts ...grpc.CallOption) (*v1.ListAlertsResponse, error) {
+	return c.client.ListAlerts(ctx, in, opts...)
+}
+
+func (c *Client) CreateServiceAccount(
+	ctx context.Context,
+	in *v1.CreateServiceAccountRequest,
+	opts ...grpc.CallOption,
+) (*v1.CreateServiceAccountResponse, error) {
+	return c.client.CreateServiceAccount(ctx, in, opts...)
+}
+
+func (c *Client) ListServiceAccounts(
+	ctx context.Context,
+	in *v1.ListServiceAccountsRequest,
+	opts ...grpc.CallOption,
+) (*v1.ListServiceAccountsResponse, error) {
+	return c.client.ListServiceAccounts(ctx, in, opts...)
+}
+
+func (c *Client) DeleteServiceAccount(
+	ctx context.Context,
+	in *v1.DeleteServiceAccountRequest,
+	opts ...grpc.CallOption,
+) (*v1.DeleteServiceAccountResponse, error) {
+	return c.client.DeleteServiceAccount(ctx, in, opts...)
+}
+
+func (c *Client) CreateAPIKey(ctx context.Context, in *v1.CreateAPIKeyRequest, opts ...grpc.CallOption) (*v1.CreateAPIKeyResponse, error) {
+	return c.client.CreateAPIKey(ctx, in, opts...)
+}
+
+func (c *Client) ListAPIKeys(ctx context.Context, in *v1.ListAPIKeysRequest, opts ...grpc.CallOption) (*v1.ListAPIKeysResponse, error) {
+	return c.client.ListAPIKeys(ctx, in, opts...)
+}
+
+func (c *Client) RevokeAPIKey(ctx context.Context, in *v1.RevokeAPIKeyRequest, opts ...grpc.CallOption) (*v1.RevokeAPIKeyResponse, error) {
+	return c.client.RevokeAPIKey(ctx, in, opts...)
+}
+
+func (c *Client) ListQueuePermissions(
+	ctx context.Context,
+	in *v1.ListQueuePermissionsRequest,
+	opts ...grpc.CallOption,
+) (*v1.ListQueuePermissionsResponse, error) {
+	return c.client.ListQueuePermissions(ctx, in, opts...)
+}
+
+func (c *Client) SetQueuePermission(
+	ctx context.Context,
+	in *v1.SetQueuePermissionRequest,
+	opts ...grpc.CallOption,
+) (*v1.SetQueuePermissionResponse, error) {
+	return c.client.SetQueuePermission(ctx, in, opts...)
+}
+
+func (c *Client) DeleteQueuePermission(
+	ctx context.Context,
+	in *v1.DeleteQueuePermissionRequest,
+	opts ...grpc.CallOption,
+) (*v1.DeleteQueuePermissionResponse, error) {
+	return c.client.DeleteQueuePermission(ctx, in, opts...)
+}
+
+func (c *Client) ListRoles(ctx context.Context, in *v1.ListRolesRequest, opts ...grpc.CallOption) (*v1.ListRolesResponse, error) {
+	return c.client.ListRoles(ctx, in, opts...)
+}
+
+func (c *Client) CreateRole(ctx context.Context, in *v1.CreateRoleRequest, opts ...grpc.CallOption) (*v1.CreateRoleResponse, error) {
+	return c.client.CreateRole(ctx, in, opts...)
+}
+
+func (c *Client) DeleteRole(ctx context.Context, in *v1.DeleteRoleRequest, opts ...grpc.CallOption) (*v1.DeleteRoleResponse, error) {
+	return c.client.DeleteRole(ctx, in, opts...)
+}
+
+func (c *Client) AssignRole(ctx context.Context, in *v1.AssignRoleRequest, opts ...grpc.CallOption) (*v1.AssignRoleResponse, error) {
+	return c.client.AssignRole(ctx, in, opts...)
+}
+
+func (c *Client) UnassignRole(ctx context.Context, in *v1.UnassignRoleRequest, opts ...grpc.CallOption) (*v1.UnassignRoleResponse, error) {
+	return c.client.UnassignRole(ctx, in, opts...)
+}
+
+func (c *Client) GetAccount(ctx context.Context, in *v1.GetAccountRequest, opts ...grpc.CallOption) (*v1.GetAccountResponse, error) {
+	return c.client.GetAccount(ctx, in, opts...)
+}
@@ -1 +1,138 @@
 package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// serve starts the gRPC server with the health service on the address.
+func serve(t *testing.T, addr string, options ...grpc.ServerOption) (*grpc.Server, *health.Server, string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", addr)
+	td.Require(t).CmpNoError(err)
+
+	hs := health.NewServer()
+
+	server := grpc.NewServer(options...)
+	grpc_health_v1.RegisterHealthServer(server, hs)
+
+	go func() { _ = server.Serve(ln) }()
+
+	t.Cleanup(server.Stop)
+
+	return server, hs, ln.Addr().String()
+}
+
+func TestClient_Ping(t *testing.T) {
+	_, hs, addr := serve(t, "127.0.0.1:0")
+
+	c, err := New(addr)
+	td.Require(t).CmpNoError(err)
+
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := context.Background()
+
+	td.CmpNoError(t, c.Ping(ctx))
+	td.Cmp(t, c.State(), connectivity.Ready)
+
+	hs.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	td.Cmp(t, status.Code(c.Ping(ctx)), codes.Unavailable)
+}
+
+func TestClient_reconnect(t *testing.T) {
+	server, _, addr := serve(t, "127.0.0.1:0")
+
+	states := make(chan connectivity.State, 100)
+
+	c, err := New(addr,
+		WithDialTimeout(5*time.Second),
+		WithReconnectBackoff(backoff.Config{BaseDelay: 10 * time.Millisecond, Multiplier: 1, MaxDelay: 10 * time.Millisecond}),
+		WithStateCallback(func(state connectivity.State) { states <- state }),
+	)
+	td.Require(t).CmpNoError(err)
+
+	ctx := context.Background()
+
+	td.Require(t).CmpNoError(c.Ping(ctx))
+
+	// The connection is lost when the server stops.
+	server.Stop()
+
+	for state := range states {
+		if state != connectivity.Ready && state != connectivity.Connecting {
+			break
+		}
+	}
+
+	// Calls wait for the client to reconnect to the restarted server.
+	serve(t, addr)
+	td.CmpNoError(t, c.Ping(ctx))
+
+	td.CmpNoError(t, c.Close())
+
+	for state := range states {
+		if state == connectivity.Shutdown {
+			break
+		}
+	}
+}
+
+func TestClient_waitReady(t *testing.T) {
+	// Nothing listens on the address, so calls fail once the timeout passes.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	td.Require(t).CmpNoError(err)
+	td.Require(t).CmpNoError(ln.Close())
+
+	c, err := New(ln.Addr().String(), WithDialTimeout(100*time.Millisecond), WithRetryPolicy(RetryPolicy{}))
+	td.Require(t).CmpNoError(err)
+
+	t.Cleanup(func() { _ = c.Close() })
+
+	start := time.Now()
+
+	td.Cmp(t, status.Code(c.Ping(context.Background())), codes.Unavailable)
+	td.Cmp(t, time.Since(start), td.Between(100*time.Millisecond, 5*time.Second))
+}
+
+func TestClient_interceptors(t *testing.T) {
+	var agents []string
+
+	agent := func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		agents = append(agents, md.Get("user-agent")...)
+
+		return handler(ctx, req)
+	}
+
+	_, _, addr := serve(t, "127.0.0.1:0", grpc.UnaryInterceptor(agent))
+
+	var methods []string
+
+	interceptor := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		methods = append(methods, method)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	c, err := New(addr, WithUserAgent("orders-service/1.2.0"), WithUnaryInterceptor(interceptor))
+	td.Require(t).CmpNoError(err)
+
+	t.Cleanup(func() { _ = c.Close() })
+
+	td.CmpNoError(t, c.Ping(context.Background()))
+	td.Cmp(t, methods, []string{grpc_health_v1.Health_Check_FullMethodName})
+	td.Cmp(t, agents, td.Bag(td.HasPrefix("orders-service/1.2.0 grpc-go/")))
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// defaultReconnectBackoff returns the backoff of reconnects, which is the gRPC default
+// with a shorter maximum pause, so the client doesn't wait long after the server is back.
+func defaultReconnectBackoff() backoff.Config {
+	cfg := backoff.DefaultConfig
+	cfg.MaxDelay = 30 * time.Second
+
+	return cfg
+}
+
+// WithReconnectBackoff is an Option function that sets the backoff of reconnects
+// to the server after the connection has been lost or has failed to connect.
+func WithReconnectBackoff(cfg backoff.Config) Option {
+	return func(o *Options) { o.reconnect = cfg }
+}
+
+// WithStateCallback is an Option function that adds the callback which is called with
+// the new state of the connection each time it changes, e.g. to connectivity.TransientFailure
+// when the connection is lost and to connectivity.Ready when it has been re-established.
+// The callback is called sequentially from a single goroutine and shouldn't block.
+func WithStateCallback(fn func(state connectivity.State)) Option {
+	return func(o *Options) { o.stateCallbacks = append(o.stateCallbacks, fn) }
+}
+
+// State returns the current state of the connection to the server.
+func (c *Client) State() connectivity.State { return c.conn.GetState() }
+
+// Ping checks that the server is ready to serve requests with the gRPC health service.
+// It fails with codes.Unavailable when the server is not ready.
+func (c *Client) Ping(ctx context.Context, opts ...grpc.CallOption) error {
+	response, err := c.health.Check(ctx, &grpc_health_v1.HealthCheckRequest{}, opts...)
+	if err != nil {
+		return err
+	}
+
+	if response.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		return status.Errorf(codes.Unavailable, "server is not ready: %s", response.GetStatus())
+	}
+
+	return nil
+}
+
+// Close closes the connection to the server.
+func (c *Client) Close() error { return c.conn.Close() }
+
+// watchState calls callbacks on each change of the connection state until the connection is closed.
+func (c *Client) watchState(callbacks []func(state connectivity.State)) {
+	state := c.conn.GetState()
+
+	for state != connectivity.Shutdown && c.conn.WaitForStateChange(context.Background(), state) {
+		state = c.conn.GetState()
+
+		for _, fn := range callbacks {
+			fn(state)
+		}
+	}
+}
+
+// readyInterceptor returns grpc.UnaryClientInterceptor which waits up to the timeout
+// for the connection to be re-established before the call, so calls made while the
+// client reconnects don't fail right away. When the connection isn't ready in time,
+// the call is made anyway and fails with codes.Unavailable.
+func readyInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if cc != nil {
+			waitReady(ctx, cc, timeout)
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// waitReady waits until the connection is ready, the timeout passes or the ctx is done.
+func waitReady(ctx context.Context, cc *grpc.ClientConn, timeout time.Duration) {
+	state := cc.GetState()
+	if state == connectivity.Ready || state == connectivity.Shutdown {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Idle connections don't connect until asked to.
+	cc.Connect()
+
+	for state != connectivity.Ready && state != connectivity.Shutdown && cc.WaitForStateChange(ctx, state) {
+		state = cc.GetState()
+	}
+}
@@ -0,0 +1,392 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/grpc"
+)
+
+const (
+	// consumerPollInterval represents default duration to wait
+	// before the next receive when the queue is empty.
+	consumerPollInterval = time.Second
+
+	// consumerMaxBatchSize represents the maximum number of
+	// messages which server returns for a single receive.
+	consumerMaxBatchSize = 10
+
+	// consumerRequestTimeout represents the timeout for delete and visibility
+	// requests which are sent on behalf of already received messages.
+	consumerRequestTimeout = 10 * time.Second
+)
+
+// ErrAttemptsExhausted indicates that the handler has failed to process the message
+// on its last receive attempt, so the queue eviction policy will be applied to it.
+var ErrAttemptsExhausted = errors.New("receive attempts exhausted")
+
+// Handler processes a single message. Returning nil means that
+// the message has been processed and can be deleted from the queue.
+type Handler func(ctx context.Context, msg *Message) error
+
+// ErrorHandler is called for each error which has occurred during consuming.
+// The msg is nil when the error is not related to particular message.
+type ErrorHandler func(ctx context.Context, msg *Message, err error)
+
+// Message represents a message which is passed to the Handler.
+type Message struct {
+	// ID represents unique message identifier.
+	ID string
+
+	// QueueID represents an identifier of the queue message has been received from.
+	QueueID string
+
+	// Body represents the message content.
+	Body []byte
+
+	// Attempts represents how many times the message has been received.
+	Attempts uint32
+
+	// ReceiptHandle represents the handle of this receive of the message,
+	// which acknowledges the message while it stays invisible.
+	ReceiptHandle string
+
+	// SentAt represents the time the message has been sent to the queue.
+	SentAt time.Time
+
+	// FirstReceivedAt represents the time the message has been received for
+	// the first time. It's zero if the server hasn't recorded the first receive.
+	FirstReceivedAt time.Time
+
+	// Attributes represents string attributes the message has been sent with.
+	Attributes map[string]string
+}
+
+// EvictionError is passed to the ErrorHandler when the message has failed on the
+// last receive attempt. It wraps the handler error and ErrAttemptsExhausted.
+type EvictionError struct {
+	// Policy is the eviction policy the queue will apply to the message.
+	Policy v1.EvictionPolicy
+
+	// DeadLetterQueueID holds the queue the message will be moved to
+	// when the Policy is v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER.
+	DeadLetterQueueID string
+
+	// Err holds the error returned by the handler.
+	Err error
+}
+
+func (e *EvictionError) Error() string {
+	if e.Policy == v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER {
+		return fmt.Sprintf("%s: message will be moved to dead letter queue %q: %s",
+			ErrAttemptsExhausted, e.DeadLetterQueueID, e.Err,
+		)
+	}
+
+	return fmt.Sprintf("%s: message will be dropped: %s", ErrAttemptsExhausted, e.Err)
+}
+
+func (e *EvictionError) Unwrap() []error { return []error{ErrAttemptsExhausted, e.Err} }
+
+// ConsumerOption configures the Consumer with ConsumerOptions properties.
+type ConsumerOption func(*ConsumerOptions)
+
+// WithConcurrency sets the maximum number of messages processed simultaneously.
+func WithConcurrency(n int) ConsumerOption {
+	return func(o *ConsumerOptions) { o.concurrency = n }
+}
+
+// WithBatchSize sets the maximum number of messages requested per single receive.
+// The value is limited to 10 by the server.
+func WithBatchSize(n uint32) ConsumerOption {
+	return func(o *ConsumerOptions) { o.batchSize = n }
+}
+
+// WithSuggestedBatchSize makes the Consumer follow the batch size suggested
+// by the server in receive responses instead of the configured one. The
+// configured batch size is used for the first receive.
+func WithSuggestedBatchSize() ConsumerOption {
+	return func(o *ConsumerOptions) { o.followSuggested = true }
+}
+
+// WithPollInterval sets how long the Consumer waits before the next
+// receive when the queue has no visible messages.
+func WithPollInterval(d time.Duration) ConsumerOption {
+	return func(o *ConsumerOptions) { o.pollInterval = d }
+}
+
+// WithVisibilityExtension sets how often the visibility timeout of the message which
+// is being processed is extended. By default, it is extended each half of the queue
+// visibility timeout. Zero value disables the extension.
+func WithVisibilityExtension(every time.Duration) ConsumerOption {
+	return func(o *ConsumerOptions) { o.extendEvery = &every }
+}
+
+// WithRetryDelay makes a failed message visible again after the given delay instead
+// of waiting for the queue visibility timeout. Zero value makes it visible immediately.
+func WithRetryDelay(d time.Duration) ConsumerOption {
+	return func(o *ConsumerOptions) { o.retryDelay = &d }
+}
+
+// WithFilter makes the Consumer receive only messages which attributes match the filter
+// expression, e.g. `type = "order" AND region IN ("eu", "us")`. Other messages are left
+// in the queue for other consumers.
+func WithFilter(filter string) ConsumerOption {
+	return func(o *ConsumerOptions) { o.filter = filter }
+}
+
+// WithErrorHandler sets the ErrorHandler.
+func WithErrorHandler(h ErrorHandler) ConsumerOption {
+	return func(o *ConsumerOptions) { o.onError = h }
+}
+
+// ConsumerOptions holds a set of properties to configure Consumer.
+type ConsumerOptions struct {
+	concurrency     int
+	batchSize       uint32
+	followSuggested bool
+	pollInterval    time.Duration
+	extendEvery     *time.Duration
+	retryDelay      *time.Duration
+	filter          string
+	onError         ErrorHandler
+}
+
+// consumerClient describes the subset of Client methods used by the Consumer.
+type consumerClient interface {
+	DescribeQueue(ctx context.Context, in *v1.DescribeQueueRequest, opts ...grpc.CallOption) (*v1.DescribeQueueResponse, error)
+	Receive(ctx context.Context, in *v1.ReceiveRequest, opts ...grpc.CallOption) (*v1.ReceiveResponse, error)
+	Delete(ctx context.Context, in *v1.DeleteRequest, opts ...grpc.CallOption) (*v1.DeleteResponse, error)
+	ChangeVisibility(ctx context.Context, in *v1.ChangeVisibilityRequest, opts ...grpc.CallOption) (*v1.ChangeVisibilityResponse, error)
+}
+
+// Consumer runs the receive, process and delete loop for the queue.
+type Consumer struct {
+	client  consumerClient
+	queueID string
+	handler Handler
+	opts    ConsumerOptions
+
+	// props holds queue properties fetched on start.
+	props *v1.DescribeQueueResponse
+}
+
+// NewConsumer returns a pointer to a new instance of Consumer
+// which consumes messages from the queue with given queueID.
+func (c *Client) NewConsumer(queueID string, handler Handler, options ...ConsumerOption) *Consumer {
+	return newConsumer(c, queueID, handler, options...)
+}
+
+func newConsumer(client consumerClient, queueID string, handler Handler, options ...ConsumerOption) *Consumer {
+	opts := ConsumerOptions{
+		concurrency:  1,
+		pollInterval: consumerPollInterval,
+		onError:      func(context.Context, *Message, error) {},
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if opts.concurrency < 1 {
+		opts.concurrency = 1
+	}
+
+	if opts.batchSize == 0 || opts.batchSize > consumerMaxBatchSize {
+		opts.batchSize = uint32(min(opts.concurrency, consumerMaxBatchSize))
+	}
+
+	consumer := Consumer{
+		client:  client,
+		queueID: queueID,
+		handler: handler,
+		opts:    opts,
+	}
+
+	return &consumer
+}
+
+// Run consumes messages until the ctx is canceled. Messages which are being
+// processed at the moment of cancellation are waited for. Run returns nil when
+// the ctx is canceled or an error when the queue can't be described.
+func (c *Consumer) Run(ctx context.Context) error {
+	props, describeErr := c.client.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: c.queueID})
+	if describeErr != nil {
+		return fmt.Errorf("describe queue (id: %q): %w", c.queueID, describeErr)
+	}
+
+	c.props = props
+
+	if c.opts.extendEvery == nil {
+		every := time.Duration(props.GetVisibilityTimeoutSeconds()) * time.Second / 2
+		c.opts.extendEvery = &every
+	}
+
+	var (
+		wg        sync.WaitGroup
+		slots     = make(chan struct{}, c.opts.concurrency)
+		batchSize = c.opts.batchSize
+	)
+
+	defer wg.Wait()
+
+	for {
+		// Wait for at least one free slot before receiving.
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case slots <- struct{}{}:
+			<-slots
+		}
+
+		batch := min(batchSize, uint32(cap(slots)-len(slots)))
+
+		output, receiveErr := c.client.Receive(ctx, &v1.ReceiveRequest{
+			QueueId:   c.queueID,
+			BatchSize: batch,
+			Filter:    c.opts.filter,
+		})
+		if receiveErr != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			c.opts.onError(ctx, nil, fmt.Errorf("receive messages: %w", receiveErr))
+		}
+
+		if suggested := output.GetSuggestedBatchSize(); c.opts.followSuggested && suggested > 0 {
+			batchSize = min(suggested, consumerMaxBatchSize)
+		}
+
+		if len(output.GetMessages()) == 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+
+			case <-time.After(c.opts.pollInterval):
+				continue
+			}
+		}
+
+		for _, m := range output.GetMessages() {
+			slots <- struct{}{}
+
+			wg.Add(1)
+
+			go func() {
+				defer func() { <-slots; wg.Done() }()
+
+				msg := &Message{
+					ID:       m.GetId(),
+					QueueID:  c.queueID,
+					Body:     m.GetBody(),
+					Attempts: m.GetAttempts(),
+					SentAt:   m.GetSentAt().AsTime(),
+
+					ReceiptHandle: m.GetReceiptHandle(),
+					Attributes:    m.GetAttributes(),
+				}
+
+				if m.GetFirstReceivedAt() != nil {
+					msg.FirstReceivedAt = m.GetFirstReceivedAt().AsTime()
+				}
+
+				c.process(ctx, msg)
+			}()
+		}
+	}
+}
+
+// process runs the handler for the message while keeping
+// the message invisible and then acknowledges the result.
+func (c *Consumer) process(ctx context.Context, msg *Message) {
+	handlerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Requests related to the received message have to reach the
+	// server even if the consumer is stopping at the moment.
+	ackCtx := context.WithoutCancel(ctx)
+
+	if every := *c.opts.extendEvery; every > 0 {
+		go c.extendVisibility(handlerCtx, ackCtx, msg, every)
+	}
+
+	handleErr := c.handler(handlerCtx, msg)
+
+	cancel()
+
+	if handleErr == nil {
+		c.delete(ackCtx, msg)
+		return
+	}
+
+	if msg.Attempts >= c.props.GetMaxReceiveAttempts() {
+		c.opts.onError(ctx, msg, &EvictionError{
+			Policy:            c.props.GetEvictionPolicy(),
+			DeadLetterQueueID: c.props.GetDeadLetterQueueId(),
+			Err:               handleErr,
+		})
+
+		return
+	}
+
+	c.opts.onError(ctx, msg, handleErr)
+
+	if c.opts.retryDelay != nil {
+		c.changeVisibility(ackCtx, msg, *c.opts.retryDelay)
+	}
+}
+
+// extendVisibility periodically extends the message visibility timeout until the ctx is canceled.
+func (c *Consumer) extendVisibility(ctx, ackCtx context.Context, msg *Message, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	timeout := time.Duration(c.props.GetVisibilityTimeoutSeconds()) * time.Second
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			c.changeVisibility(ackCtx, msg, timeout)
+		}
+	}
+}
+
+func (c *Consumer) changeVisibility(ctx context.Context, msg *Message, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, consumerRequestTimeout)
+	defer cancel()
+
+	if _, err := c.client.ChangeVisibility(ctx, &v1.ChangeVisibilityRequest{
+		QueueId:                  c.queueID,
+		ReceiptHandle:            msg.ReceiptHandle,
+		VisibilityTimeoutSeconds: uint64(timeout.Seconds()),
+	}); err != nil {
+		c.opts.onError(ctx, msg, fmt.Errorf("change message visibility: %w", err))
+	}
+}
+
+func (c *Consumer) delete(ctx context.Context, msg *Message) {
+	ctx, cancel := context.WithTimeout(ctx, consumerRequestTimeout)
+	defer cancel()
+
+	output, deleteErr := c.client.Delete(ctx, &v1.DeleteRequest{
+		QueueId:        c.queueID,
+		ReceiptHandles: []string{msg.ReceiptHandle},
+	})
+	if deleteErr != nil {
+		c.opts.onError(ctx, msg, fmt.Errorf("delete message: %w", deleteErr))
+		return
+	}
+
+	for _, f := range output.GetFailed() {
+		c.opts.onError(ctx, msg, fmt.Errorf("delete message: %s", f.GetError()))
+	}
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/grpc"
+)
+
+type fakeConsumerClient struct {
+	mu       sync.Mutex
+	props    *v1.DescribeQueueResponse
+	messages []*v1.ReceiveMessage
+	deleted  []string
+	changed  []string
+}
+
+func (f *fakeConsumerClient) DescribeQueue(context.Context, *v1.DescribeQueueRequest, ...grpc.CallOption) (*v1.DescribeQueueResponse, error) {
+	return f.props, nil
+}
+
+func (f *fakeConsumerClient) Receive(_ context.Context, in *v1.ReceiveRequest, _ ...grpc.CallOption) (*v1.ReceiveResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := min(int(in.GetBatchSize()), len(f.messages))
+	output := v1.ReceiveResponse{Messages: f.messages[:n]}
+	f.messages = f.messages[n:]
+
+	return &output, nil
+}
+
+func (f *fakeConsumerClient) Delete(_ context.Context, in *v1.DeleteRequest, _ ...grpc.CallOption) (*v1.DeleteResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.deleted = append(f.deleted, in.GetReceiptHandles()...)
+
+	return &v1.DeleteResponse{Successful: in.GetReceiptHandles()}, nil
+}
+
+func (f *fakeConsumerClient) ChangeVisibility(_ context.Context, in *v1.ChangeVisibilityRequest, _ ...grpc.CallOption) (*v1.ChangeVisibilityResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.changed = append(f.changed, in.GetReceiptHandle())
+
+	return &v1.ChangeVisibilityResponse{}, nil
+}
+
+func TestConsumer_Run(t *testing.T) {
+	errHandler := errors.New("handler error")
+
+	fake := fakeConsumerClient{
+		props: &v1.DescribeQueueResponse{
+			QueueId:                  "q",
+			VisibilityTimeoutSeconds: 30,
+			MaxReceiveAttempts:       3,
+			EvictionPolicy:           v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER,
+			DeadLetterQueueId:        "dlq",
+		},
+		messages: []*v1.ReceiveMessage{
+			{Id: "ok", Attempts: 1, ReceiptHandle: "ok-1"},
+			{Id: "retry", Attempts: 1, ReceiptHandle: "retry-1"},
+			{Id: "evict", Attempts: 3, ReceiptHandle: "evict-3"},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu     sync.Mutex
+		errs   = make(map[string]error)
+		remain = 3
+	)
+
+	consumer := newConsumer(&fake, "q",
+		func(_ context.Context, msg *Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if remain--; remain == 0 {
+				cancel()
+			}
+
+			if msg.ID == "ok" {
+				return nil
+			}
+
+			return errHandler
+		},
+		WithConcurrency(2),
+		WithPollInterval(time.Millisecond),
+		WithRetryDelay(0),
+		WithErrorHandler(func(_ context.Context, msg *Message, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			errs[msg.ID] = err
+		}),
+	)
+
+	td.CmpNoError(t, consumer.Run(ctx))
+
+	td.Cmp(t, fake.deleted, []string{"ok-1"})
+	td.Cmp(t, fake.changed, []string{"retry-1"})
+	td.CmpErrorIs(t, errs["retry"], errHandler)
+	td.CmpErrorIs(t, errs["evict"], ErrAttemptsExhausted)
+	td.CmpErrorIs(t, errs["evict"], errHandler)
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// CallInfo describes a completed unary call, including all its retries.
+type CallInfo struct {
+	// Method is the full name of the called method, e.g. "/v1.PlainQService/Send".
+	Method string
+
+	// Code is the status code of the call, which is codes.OK for successful calls.
+	Code codes.Code
+
+	// Duration is the time the call took.
+	Duration time.Duration
+
+	// RequestSize and ResponseSize are sizes in bytes of the encoded messages.
+	// The ResponseSize is zero for failed calls.
+	RequestSize  int
+	ResponseSize int
+}
+
+// WithMetricsCallback is an Option function that adds the callback which is called
+// with the CallInfo of each completed call, e.g. to record metrics of the application.
+// The callback is called synchronously on the calling goroutine and shouldn't block.
+func WithMetricsCallback(fn func(info CallInfo)) Option {
+	return func(o *Options) { o.metrics = append(o.metrics, fn) }
+}
+
+// WithMetricsSet is an Option function that records metrics of calls to the set,
+// which can be written in the Prometheus format with metrics.Set.WritePrometheus
+// or exposed along with the default metrics with metrics.RegisterSet.
+//
+// Recorded metrics are plainq_client_requests_total by method and status code,
+// plainq_client_request_duration_seconds, plainq_client_request_size_bytes
+// and plainq_client_response_size_bytes by method.
+func WithMetricsSet(set *metrics.Set) Option {
+	return WithMetricsCallback(func(info CallInfo) {
+		code := strconv.Itoa(int(info.Code))
+
+		set.GetOrCreateCounter(`plainq_client_requests_total{method="` + info.Method + `", code="` + code + `"}`).
+			Inc()
+
+		set.GetOrCreateSummaryExt(`plainq_client_request_duration_seconds{method="`+info.Method+`"}`, 5*time.Minute, []float64{0.5, 0.95, 0.99}).
+			Update(info.Duration.Seconds())
+
+		set.GetOrCreateHistogram(`plainq_client_request_size_bytes{method="` + info.Method + `"}`).
+			Update(float64(info.RequestSize))
+
+		if info.Code == codes.OK {
+			set.GetOrCreateHistogram(`plainq_client_response_size_bytes{method="` + info.Method + `"}`).
+				Update(float64(info.ResponseSize))
+		}
+	})
+}
+
+// metricsInterceptor returns grpc.UnaryClientInterceptor which
+// reports the CallInfo of each completed call to callbacks.
+func metricsInterceptor(callbacks []func(info CallInfo)) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		info := CallInfo{
+			Method:      method,
+			Code:        status.Code(err),
+			Duration:    time.Since(start),
+			RequestSize: messageSize(req),
+		}
+
+		if err == nil {
+			info.ResponseSize = messageSize(reply)
+		}
+
+		for _, fn := range callbacks {
+			fn(info)
+		}
+
+		return err
+	}
+}
+
+// messageSize returns the size in bytes of the encoded message.
+func messageSize(m any) int {
+	switch m := m.(type) {
+	case interface{ SizeVT() int }:
+		return m.SizeVT()
+
+	case proto.Message:
+		return proto.Size(m)
+
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_metricsInterceptor(t *testing.T) {
+	type tcase struct {
+		err  error
+		want CallInfo
+	}
+
+	req := v1.DescribeQueueRequest{QueueId: "queue"}
+	reply := v1.DescribeQueueResponse{QueueName: "orders"}
+
+	tests := map[string]tcase{
+		"OK": {
+			want: CallInfo{
+				Method:       v1.PlainQService_DescribeQueue_FullMethodName,
+				Code:         codes.OK,
+				RequestSize:  req.SizeVT(),
+				ResponseSize: reply.SizeVT(),
+			},
+		},
+		"Failed": {
+			err: status.Error(codes.NotFound, "not found"),
+			want: CallInfo{
+				Method:      v1.PlainQService_DescribeQueue_FullMethodName,
+				Code:        codes.NotFound,
+				RequestSize: req.SizeVT(),
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []CallInfo
+
+			interceptor := metricsInterceptor([]func(CallInfo){func(info CallInfo) { got = append(got, info) }})
+
+			invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error { return tc.err }
+
+			err := interceptor(context.Background(), v1.PlainQService_DescribeQueue_FullMethodName, &req, &reply, nil, invoker)
+			td.Cmp(t, err, tc.err)
+			td.Cmp(t, got, td.Bag(td.SStruct(tc.want, td.StructFields{"Duration": td.Gte(time.Duration(0))})))
+		})
+	}
+}
+
+func TestWithMetricsSet(t *testing.T) {
+	set := metrics.NewSet()
+
+	var opts Options
+	WithMetricsSet(set)(&opts)
+
+	interceptor := metricsInterceptor(opts.metrics)
+
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error { return nil }
+
+	req := v1.DescribeQueueRequest{QueueId: "queue"}
+	reply := v1.DescribeQueueResponse{}
+
+	td.CmpNoError(t, interceptor(context.Background(), v1.PlainQService_DescribeQueue_FullMethodName, &req, &reply, nil, invoker))
+
+	var buf bytes.Buffer
+	set.WritePrometheus(&buf)
+
+	td.Cmp(t, buf.String(), td.All(
+		td.Contains(`plainq_client_requests_total{method="/v1.PlainQService/DescribeQueue", code="0"} 1`),
+		td.Contains(`plainq_client_request_duration_seconds_count{method="/v1.PlainQService/DescribeQueue"} 1`),
+		td.Contains(`plainq_client_request_size_bytes_count{method="/v1.PlainQService/DescribeQueue"} 1`),
+		td.Contains(`plainq_client_response_size_bytes_count{method="/v1.PlainQService/DescribeQueue"} 1`),
+	))
+}
@@ -0,0 +1,225 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// idempotentMethods holds methods which are safe to retry, since calling them
+// again has the same effect as calling them once. Receives and sends are not
+// retried by default, since a lost response of the first attempt would make
+// messages invisible or sent twice.
+var idempotentMethods = map[string]struct{}{
+	v1.PlainQService_ListQueues_FullMethodName:            {},
+	v1.PlainQService_DescribeQueue_FullMethodName:         {},
+	v1.PlainQService_AdviseQueue_FullMethodName:           {},
+	v1.PlainQService_QueueStats_FullMethodName:            {},
+	v1.PlainQService_GetMessage_FullMethodName:            {},
+	v1.PlainQService_PeekMessages_FullMethodName:          {},
+	v1.PlainQService_SearchMessages_FullMethodName:        {},
+	v1.PlainQService_Search_FullMethodName:                {},
+	v1.PlainQService_ListMessageEvents_FullMethodName:     {},
+	v1.PlainQService_ListArchivedMessages_FullMethodName:  {},
+	v1.PlainQService_ListGenerators_FullMethodName:        {},
+	v1.PlainQService_ListJobs_FullMethodName:              {},
+	v1.PlainQService_GetJob_FullMethodName:                {},
+	v1.PlainQService_ListAlerts_FullMethodName:            {},
+	v1.PlainQService_ListAlertRules_FullMethodName:        {},
+	v1.PlainQService_ListAuditEvents_FullMethodName:       {},
+	v1.PlainQService_ListBreakers_FullMethodName:          {},
+	v1.PlainQService_ListServiceAccounts_FullMethodName:   {},
+	v1.PlainQService_ListAPIKeys_FullMethodName:           {},
+	v1.PlainQService_GetLogLevels_FullMethodName:          {},
+	v1.PlainQService_ClusterStatus_FullMethodName:         {},
+	v1.PlainQService_ChangeVisibility_FullMethodName:      {},
+	v1.PlainQService_ExtendVisibilityBatch_FullMethodName: {},
+
+	grpc_health_v1.Health_Check_FullMethodName: {},
+}
+
+// RetryPolicy configures retries of failed unary calls.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of the call, including
+	// the first one. Values less than 2 disable retries.
+	MaxAttempts int
+
+	// InitialBackoff is the pause before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff bounds the pause between retries. Zero leaves it unbounded.
+	MaxBackoff time.Duration
+
+	// Multiplier grows the pause with every retry. Values less than 1 keep it the same.
+	Multiplier float64
+
+	// Codes holds status codes of failures which are retried.
+	Codes []codes.Code
+
+	// Methods holds full names of methods which are retried in addition to the idempotent
+	// ones, e.g. v1.PlainQService_Send_FullMethodName when duplicate messages are tolerated.
+	Methods []string
+
+	// BudgetRatio is the number of retries each call earns for later calls, e.g. 0.1 allows
+	// one retry per ten calls, so retries don't multiply the load of an overloaded server.
+	// Zero disables the budget.
+	BudgetRatio float64
+
+	// BudgetMax is the maximum number of retries saved up by the budget, which is also
+	// the number of retries allowed before any call has been made.
+	BudgetMax float64
+}
+
+// DefaultRetryPolicy returns the RetryPolicy the Client uses unless WithRetryPolicy is set.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Codes:          []codes.Code{codes.Unavailable, codes.Aborted},
+		BudgetRatio:    0.1,
+		BudgetMax:      10,
+	}
+}
+
+// WithRetryPolicy is an Option function that sets the RetryPolicy of unary calls.
+// By default, the DefaultRetryPolicy is used, and a policy with MaxAttempts
+// less than 2 disables retries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *Options) { o.retry = policy }
+}
+
+// retryCallOption overrides the retry policy of the Client for a single call.
+type retryCallOption struct {
+	grpc.EmptyCallOption
+
+	policy RetryPolicy
+}
+
+// WithCallRetryPolicy returns grpc.CallOption which overrides the retry policy of the Client
+// for a single call. The call is retried even if its method isn't idempotent.
+func WithCallRetryPolicy(policy RetryPolicy) grpc.CallOption {
+	return retryCallOption{policy: policy}
+}
+
+// WithoutRetry returns grpc.CallOption which disables retries of a single call.
+func WithoutRetry() grpc.CallOption {
+	return retryCallOption{policy: RetryPolicy{MaxAttempts: 1}}
+}
+
+// retryBudget limits the number of retries to the share of calls.
+type retryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	max    float64
+	tokens float64
+}
+
+func newRetryBudget(ratio, maxTokens float64) *retryBudget {
+	return &retryBudget{ratio: ratio, max: maxTokens, tokens: maxTokens}
+}
+
+// deposit earns the share of the retry for the call.
+func (b *retryBudget) deposit() {
+	if b.ratio <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.tokens+b.ratio, b.max)
+}
+
+// withdraw reports whether the budget allows the retry, spending it if so.
+func (b *retryBudget) withdraw() bool {
+	if b.ratio <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+// backoff returns the pause before the given retry, starting at 1,
+// with the jitter of up to a half of it.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	backoff := float64(p.InitialBackoff) * math.Pow(max(p.Multiplier, 1), float64(retry-1))
+
+	bound := float64(math.MaxInt64)
+	if p.MaxBackoff > 0 {
+		bound = float64(p.MaxBackoff)
+	}
+
+	backoff = min(backoff, bound)
+
+	return time.Duration(backoff/2 + rand.Float64()*backoff/2)
+}
+
+// retryable reports whether the call which has failed with the err can be retried.
+func (p RetryPolicy) retryable(err error) bool {
+	return slices.Contains(p.Codes, status.Code(err))
+}
+
+// retryInterceptor returns grpc.UnaryClientInterceptor which retries failed calls
+// of idempotent methods according to the policy.
+func retryInterceptor(policy RetryPolicy) grpc.UnaryClientInterceptor {
+	budget := newRetryBudget(policy.BudgetRatio, policy.BudgetMax)
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		budget.deposit()
+
+		p := policy
+		_, retried := idempotentMethods[method]
+		retried = retried || slices.Contains(policy.Methods, method)
+
+		for _, opt := range opts {
+			if o, ok := opt.(retryCallOption); ok {
+				p, retried = o.policy, true
+			}
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if !retried {
+			return err
+		}
+
+		for attempt := 2; attempt <= p.MaxAttempts && err != nil && p.retryable(err); attempt++ {
+			if !budget.withdraw() {
+				return err
+			}
+
+			timer := time.NewTimer(p.backoff(attempt - 1))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+
+			case <-timer.C:
+			}
+
+			err = invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_retryInterceptor(t *testing.T) {
+	type tcase struct {
+		policy    RetryPolicy
+		method    string
+		opts      []grpc.CallOption
+		failures  []codes.Code
+		wantCalls int
+		wantCode  codes.Code
+	}
+
+	policy := RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Multiplier:     2,
+		Codes:          []codes.Code{codes.Unavailable},
+	}
+
+	tests := map[string]tcase{
+		"OK": {
+			policy: policy, method: v1.PlainQService_DescribeQueue_FullMethodName,
+			wantCalls: 1,
+		},
+		"RetriedIdempotent": {
+			policy: policy, method: v1.PlainQService_DescribeQueue_FullMethodName,
+			failures:  []codes.Code{codes.Unavailable, codes.Unavailable},
+			wantCalls: 3,
+		},
+		"AttemptsExhausted": {
+			policy: policy, method: v1.PlainQService_DescribeQueue_FullMethodName,
+			failures:  []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable},
+			wantCalls: 3, wantCode: codes.Unavailable,
+		},
+		"NotRetryableCode": {
+			policy: policy, method: v1.PlainQService_DescribeQueue_FullMethodName,
+			failures:  []codes.Code{codes.NotFound},
+			wantCalls: 1, wantCode: codes.NotFound,
+		},
+		"NotIdempotent": {
+			policy: policy, method: v1.PlainQService_Send_FullMethodName,
+			failures:  []codes.Code{codes.Unavailable},
+			wantCalls: 1, wantCode: codes.Unavailable,
+		},
+		"PolicyMethod": {
+			policy: func() RetryPolicy {
+				p := policy
+				p.Methods = []string{v1.PlainQService_Send_FullMethodName}
+				return p
+			}(),
+			method:    v1.PlainQService_Send_FullMethodName,
+			failures:  []codes.Code{codes.Unavailable},
+			wantCalls: 2,
+		},
+		"CallPolicy": {
+			policy: policy, method: v1.PlainQService_Send_FullMethodName,
+			opts:      []grpc.CallOption{WithCallRetryPolicy(policy)},
+			failures:  []codes.Code{codes.Unavailable},
+			wantCalls: 2,
+		},
+		"CallWithoutRetry": {
+			policy: policy, method: v1.PlainQService_DescribeQueue_FullMethodName,
+			opts:      []grpc.CallOption{WithoutRetry()},
+			failures:  []codes.Code{codes.Unavailable},
+			wantCalls: 1, wantCode: codes.Unavailable,
+		},
+		"BudgetExhausted": {
+			policy: func() RetryPolicy {
+				p := policy
+				p.BudgetRatio, p.BudgetMax = 0.1, 1
+				return p
+			}(),
+			method:    v1.PlainQService_DescribeQueue_FullMethodName,
+			failures:  []codes.Code{codes.Unavailable, codes.Unavailable},
+			wantCalls: 2, wantCode: codes.Unavailable,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls int
+
+			invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+				calls++
+
+				if calls <= len(tc.failures) {
+					return status.Error(tc.failures[calls-1], "failure")
+				}
+
+				return nil
+			}
+
+			err := retryInterceptor(tc.policy)(context.Background(), tc.method, nil, nil, nil, invoker, tc.opts...)
+			td.Cmp(t, calls, tc.wantCalls)
+			td.Cmp(t, status.Code(err), tc.wantCode)
+		})
+	}
+}
+
+func Test_retryInterceptor_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var calls int
+
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		calls++
+		cancel()
+
+		return status.Error(codes.Unavailable, "failure")
+	}
+
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour, Codes: []codes.Code{codes.Unavailable}}
+
+	err := retryInterceptor(policy)(ctx, v1.PlainQService_DescribeQueue_FullMethodName, nil, nil, nil, invoker)
+	td.Cmp(t, calls, 1)
+	td.Cmp(t, status.Code(err), codes.Unavailable)
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+
+	td.Cmp(t, p.backoff(1), td.Between(50*time.Millisecond, 100*time.Millisecond))
+	td.Cmp(t, p.backoff(3), td.Between(200*time.Millisecond, 400*time.Millisecond))
+	td.Cmp(t, p.backoff(10), td.Between(500*time.Millisecond, time.Second))
+}
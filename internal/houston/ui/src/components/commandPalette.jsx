@@ -0,0 +1,112 @@
+import { useEffect, useState } from "react";
+import { Dialog, DialogContent } from "@/components/ui/dialog";
+import {
+  Command,
+  CommandEmpty,
+  CommandGroup,
+  CommandInput,
+  CommandItem,
+  CommandList,
+} from "@/components/ui/command";
+
+const groups = [
+  { kind: "ENTITY_KIND_QUEUE", heading: "Queues" },
+  { kind: "ENTITY_KIND_USER", heading: "Users" },
+  { kind: "ENTITY_KIND_ROLE", heading: "Roles" },
+  { kind: "ENTITY_KIND_AUDIT_EVENT", heading: "Recent audit events" },
+];
+
+export default function CommandPalette() {
+  const [open, setOpen] = useState(false);
+  const [query, setQuery] = useState("");
+  const [results, setResults] = useState([]);
+
+  useEffect(() => {
+    const onKeyDown = (e) => {
+      if (e.key === "k" && (e.metaKey || e.ctrlKey)) {
+        e.preventDefault();
+        setOpen((open) => !open);
+      }
+    };
+
+    document.addEventListener("keydown", onKeyDown);
+    return () => document.removeEventListener("keydown", onKeyDown);
+  }, []);
+
+  useEffect(() => {
+    if (query.trim() === "") {
+      setResults([]);
+      return;
+    }
+
+    // Requests are debounced and the outdated ones are aborted,
+    // so results always match the latest query.
+    const controller = new AbortController();
+    const timer = setTimeout(async () => {
+      try {
+        const response = await fetch(
+          `http://localhost:8081/api/v1/search?q=${encodeURIComponent(query)}&limit=20`,
+          { signal: controller.signal }
+        );
+        if (!response.ok) {
+          throw new Error("Failed to search");
+        }
+
+        const data = await response.json();
+        setResults(data.results || []);
+      } catch (error) {
+        if (error.name !== "AbortError") {
+          setResults([]);
+        }
+      }
+    }, 150);
+
+    return () => {
+      clearTimeout(timer);
+      controller.abort();
+    };
+  }, [query]);
+
+  const onSelect = (result) => {
+    if (result.kind === "ENTITY_KIND_QUEUE") {
+      window.location.href = `/queue/${result.id}`;
+    }
+
+    setOpen(false);
+  };
+
+  return (
+    <Dialog open={open} onOpenChange={setOpen}>
+      <DialogContent className="overflow-hidden p-0">
+        {/* Results are ordered by the server, so the built-in filtering is disabled. */}
+        <Command shouldFilter={false}>
+          <CommandInput
+            placeholder="Search queues, users, roles and audit events..."
+            value={query}
+            onValueChange={setQuery}
+          />
+          <CommandList>
+            <CommandEmpty>{query.trim() === "" ? "Type to search." : "Nothing found."}</CommandEmpty>
+            {groups.map(({ kind, heading }) => {
+              const items = results.filter((r) => r.kind === kind);
+              if (items.length === 0) {
+                return null;
+              }
+
+              return (
+                <CommandGroup key={kind} heading={heading}>
+                  {items.map((r, i) => (
+                    <CommandItem key={`${kind}-${r.id || i}`} value={`${kind}-${r.id || i}`} onSelect={() => onSelect(r)}>
+                      <span>{r.title}</span>
+                      {r.detail && <span className="ml-2 text-xs text-gray-500 truncate">{r.detail}</span>}
+                    </CommandItem>
+                  ))}
+                </CommandGroup>
+              );
+            })}
+          </CommandList>
+        </Command>
+      </DialogContent>
+    </Dialog>
+  );
+}
@@ -1,6 +1,7 @@
 import {Tabs, TabsContent, TabsList, TabsTrigger} from "@/components/ui/tabs"
 import {Avatar, AvatarFallback, AvatarImage} from "@/components/ui/avatar"
 import Queues from "@/components/queues.jsx";
+import CommandPalette from "@/components/commandPalette.jsx";
 
 export default function Navigation() {
   return (
@@ -10,6 +11,8 @@ export default function Navigation() {
           <a href="/" className="text-lg text-gray-500 hover:text-gray-900">PlainQ</a>
         </div>
         <div className="flex flex-row items-center gap-2">
+          <CommandPalette/>
+          <kbd className="text-xs text-gray-500 border rounded px-1.5 py-0.5">Ctrl K</kbd>
           <div>
             <a href="https://docs.plainq.com">Docs</a>
           </div>
@@ -0,0 +1,74 @@
+import { useEffect, useState } from "react";
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from "@/components/ui/table";
+
+export default function QueueAdvice({ queueId }) {
+  const [suggestions, setSuggestions] = useState([]);
+  const [error, setError] = useState(null);
+
+  useEffect(() => {
+    if (!queueId) {
+      return;
+    }
+
+    const fetchAdvice = async () => {
+      try {
+        const response = await fetch(
+          `http://localhost:8081/api/v1/queue/${queueId}/advice`
+        );
+        if (!response.ok) {
+          throw new Error("Failed to fetch queue advice");
+        }
+
+        const data = await response.json();
+        setSuggestions(data.suggestions || []);
+      } catch (err) {
+        console.error("Error fetching queue advice:", err);
+        setError(err.message);
+      }
+    };
+
+    fetchAdvice();
+  }, [queueId]);
+
+  if (error) {
+    return <p className="text-sm text-red-500">{error}</p>;
+  }
+
+  if (suggestions.length === 0) {
+    return (
+      <p className="text-sm text-gray-500">
+        No suggestions: queue settings look fine for the observed load.
+      </p>
+    );
+  }
+
+  return (
+    <Table>
+      <TableHeader>
+        <TableRow>
+          <TableHead>Setting</TableHead>
+          <TableHead>Current</TableHead>
+          <TableHead>Suggested</TableHead>
+          <TableHead>Reason</TableHead>
+        </TableRow>
+      </TableHeader>
+      <TableBody>
+        {suggestions.map((s) => (
+          <TableRow key={s.setting}>
+            <TableCell className="font-medium">{s.setting}</TableCell>
+            <TableCell>{s.current}</TableCell>
+            <TableCell>{s.suggested}</TableCell>
+            <TableCell>{s.reason}</TableCell>
+          </TableRow>
+        ))}
+      </TableBody>
+    </Table>
+  );
+}
@@ -1,4 +1,6 @@
 import { Tabs, TabsContent } from "@/components/ui/tabs";
+import QueueAdvice from "@/components/queueAdvice.jsx";
+import QueueJobs from "@/components/queueJobs.jsx";
 
 export default function QueueDetails({ queueDetails, error }) {
   return (
@@ -8,8 +10,19 @@ export default function QueueDetails({ queueDetails, error }) {
           <div className="flex flex-row justify-between pt-4 pb-4">
             <div>
               <p className="text-2xl font-bold">Queue: {queueDetails.name}</p>
+              <p className="text-sm text-muted-foreground">
+                State: {queueDetails.state?.replace("QUEUE_STATE_", "").toLowerCase() || "unspecified"}
+              </p>
             </div>
           </div>
+          <div className="pb-4">
+            <p className="text-lg font-semibold pb-2">Suggestions</p>
+            <QueueAdvice queueId={queueDetails.queue_id} />
+          </div>
+          <div className="pb-4">
+            <p className="text-lg font-semibold pb-2">Background jobs</p>
+            <QueueJobs queueId={queueDetails.queue_id} />
+          </div>
         </TabsContent>
       </Tabs>
     </div>
@@ -0,0 +1,116 @@
+import { useCallback, useEffect, useState } from "react";
+import { Button } from "@/components/ui/button";
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from "@/components/ui/table";
+
+const refreshInterval = 2000;
+
+export default function QueueJobs({ queueId }) {
+  const [jobs, setJobs] = useState([]);
+  const [error, setError] = useState(null);
+
+  const fetchJobs = useCallback(async () => {
+    try {
+      const response = await fetch(
+        `http://localhost:8081/api/v1/jobs?queue_id=${queueId}`
+      );
+      if (!response.ok) {
+        throw new Error("Failed to fetch queue jobs");
+      }
+
+      const data = await response.json();
+      setJobs(data.jobs || []);
+    } catch (err) {
+      console.error("Error fetching queue jobs:", err);
+      setError(err.message);
+    }
+  }, [queueId]);
+
+  const cancelJob = async (jobId) => {
+    try {
+      const response = await fetch(
+        `http://localhost:8081/api/v1/jobs/${jobId}`,
+        { method: "DELETE" }
+      );
+      if (!response.ok) {
+        throw new Error("Failed to cancel the job");
+      }
+
+      await fetchJobs();
+    } catch (err) {
+      console.error("Error canceling the job:", err);
+      setError(err.message);
+    }
+  };
+
+  useEffect(() => {
+    if (!queueId) {
+      return;
+    }
+
+    fetchJobs();
+  }, [queueId, fetchJobs]);
+
+  // Progress is refreshed while any of jobs is running.
+  const running = jobs.some((job) => job.state === "JOB_STATE_RUNNING");
+
+  useEffect(() => {
+    if (!running) {
+      return;
+    }
+
+    const timer = setInterval(fetchJobs, refreshInterval);
+    return () => clearInterval(timer);
+  }, [running, fetchJobs]);
+
+  if (error) {
+    return <p className="text-sm text-red-500">{error}</p>;
+  }
+
+  if (jobs.length === 0) {
+    return <p className="text-sm text-gray-500">No background jobs.</p>;
+  }
+
+  return (
+    <Table>
+      <TableHeader>
+        <TableRow>
+          <TableHead>Job</TableHead>
+          <TableHead>Kind</TableHead>
+          <TableHead>State</TableHead>
+          <TableHead>Progress</TableHead>
+          <TableHead>Error</TableHead>
+          <TableHead></TableHead>
+        </TableRow>
+      </TableHeader>
+      <TableBody>
+        {jobs.map((job) => (
+          <TableRow key={job.job_id}>
+            <TableCell className="font-medium">{job.job_id}</TableCell>
+            <TableCell>{job.kind}</TableCell>
+            <TableCell>
+              {job.state?.replace("JOB_STATE_", "").toLowerCase()}
+            </TableCell>
+            <TableCell>
+              {job.processed || 0} / {job.total || 0}
+            </TableCell>
+            <TableCell>{job.error}</TableCell>
+            <TableCell>
+              {job.state === "JOB_STATE_RUNNING" && (
+                <Button variant="outline" size="sm" onClick={() => cancelJob(job.job_id)}>
+                  Cancel
+                </Button>
+              )}
+            </TableCell>
+          </TableRow>
+        ))}
+      </TableBody>
+    </Table>
+  );
+}
@@ -74,7 +74,7 @@ export default function Queues() {
     try {
       setLoading(true);
       const response = await fetch(
-        `http://localhost:8081/api/v1/queue?limit=${limit}&cursor=${currentCursor}`
+        `http://localhost:8081/api/v1/queue?limit=${limit}&cursor=${currentCursor}&stats=true`
       );
       if (!response.ok) {
         throw new Error("Failed to fetch queues");
@@ -185,6 +185,9 @@ export default function Queues() {
                 <TableHead>Name</TableHead>
                 <TableHead>ID</TableHead>
                 <TableHead>Created At</TableHead>
+                <TableHead>State</TableHead>
+                <TableHead>Depth</TableHead>
+                <TableHead>In Flight</TableHead>
                 <TableHead>Attempts</TableHead>
                 <TableHead>Retention Period</TableHead>
                 <TableHead>Visibility Timeout</TableHead>
@@ -212,6 +215,22 @@ export default function Queues() {
                         hour12: false,
                       })}
                     </TableCell>
+                    <TableCell>
+                      {
+                        {
+                          undefined: "Unspecified",
+                          QUEUE_STATE_UNSPECIFIED: "Unspecified",
+                          QUEUE_STATE_CREATING: "Creating",
+                          QUEUE_STATE_ACTIVE: "Active",
+                          QUEUE_STATE_PAUSED: "Paused",
+                          QUEUE_STATE_DRAINING: "Draining",
+                          QUEUE_STATE_DELETING: "Deleting",
+                          QUEUE_STATE_ARCHIVED: "Archived",
+                        }[queue.state]
+                      }
+                    </TableCell>
+                    <TableCell>{queue.stats?.depth || 0}</TableCell>
+                    <TableCell>{queue.stats?.inFlight || 0}</TableCell>
                     <TableCell>{queue.maxReceiveAttempts}</TableCell>
                     <TableCell>{queue.retentionPeriodSeconds}</TableCell>
                     <TableCell>{queue.visibilityTimeoutSeconds}</TableCell>
@@ -0,0 +1,153 @@
+package migrate
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// awsAlgorithm represents the algorithm of AWS Signature Version 4.
+	awsAlgorithm = "AWS4-HMAC-SHA256"
+
+	// awsTimeFormat represents the format of the X-Amz-Date header.
+	awsTimeFormat = "20060102T150405Z"
+)
+
+// awsSigner signs requests to AWS with Signature Version 4.
+type awsSigner struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	region          string
+	service         string
+	now             func() time.Time
+}
+
+// sign adds the authorization header to the request with given body.
+func (s *awsSigner) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", now.Format(awsTimeFormat))
+
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers, signedHeaders := canonicalHeaders(req)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL),
+		canonicalQuery(req.URL),
+		headers,
+		signedHeaders,
+		hashHex(body),
+	}, "\n")
+
+	scope := date + "/" + s.region + "/" + s.service + "/aws4_request"
+
+	stringToSign := strings.Join([]string{
+		awsAlgorithm,
+		now.Format(awsTimeFormat),
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(s.signingKey(date), stringToSign))
+
+	req.Header.Set("Authorization", awsAlgorithm+
+		" Credential="+s.accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature,
+	)
+}
+
+// signingKey derives the key which signs requests of the date.
+func (s *awsSigner) signingKey(date string) []byte {
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+
+	return hmacSHA256(key, "aws4_request")
+}
+
+// canonicalHeaders returns the host header and headers of the request
+// in the canonical form, along with the list of their names.
+func canonicalHeaders(req *http.Request) (headers, names string) {
+	values := map[string]string{"host": req.Host}
+
+	if values["host"] == "" {
+		values["host"] = req.URL.Host
+	}
+
+	for name, v := range req.Header {
+		values[strings.ToLower(name)] = strings.Join(v, ",")
+	}
+
+	keys := make([]string, 0, len(values))
+
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	var b strings.Builder
+
+	for _, k := range keys {
+		b.WriteString(k + ":" + strings.TrimSpace(values[k]) + "\n")
+	}
+
+	return b.String(), strings.Join(keys, ";")
+}
+
+// canonicalPath returns the URI encoded path of the request.
+func canonicalPath(u *url.URL) string {
+	p := u.EscapedPath()
+	if p == "" {
+		return "/"
+	}
+
+	return p
+}
+
+// canonicalQuery returns the query of the request sorted by parameter names.
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	pairs := make([]string, 0, len(query))
+
+	for k, values := range query {
+		for _, v := range values {
+			pairs = append(pairs, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+
+	slices.Sort(pairs)
+
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape URI encodes the string as AWS requires, which differs
+// from url.QueryEscape in escaping spaces as %20 and keeping tildes.
+func awsEscape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(url.QueryEscape(s), "+", "%20"), "%7E", "~")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,45 @@
+package migrate
+
+import (
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+// Test_awsSigner_sign checks the signature against the example of the AWS documentation.
+func Test_awsSigner_sign(t *testing.T) {
+	req, reqErr := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Version=2010-05-08&Action=ListUsers", http.NoBody)
+	td.CmpNoError(t, reqErr)
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	signer := awsSigner{
+		accessKeyID:     "AKIDEXAMPLE",
+		secretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		region:          "us-east-1",
+		service:         "iam",
+		now:             func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) },
+	}
+
+	signer.sign(req, nil)
+
+	td.Cmp(t, req.Header.Get("X-Amz-Date"), "20150830T123600Z")
+	td.Cmp(t, req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 "+
+		"Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, "+
+		"SignedHeaders=content-type;host;x-amz-date, "+
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+	)
+}
+
+func Test_awsSigner_signingKey(t *testing.T) {
+	signer := awsSigner{
+		secretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		region:          "us-east-1",
+		service:         "iam",
+	}
+
+	td.Cmp(t, hex.EncodeToString(signer.signingKey("20150830")), "c4afb1cc5771d871763a393e44b703571b55cc28424d1a5e86da6ed3c154a4b9")
+}
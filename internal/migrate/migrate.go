@@ -0,0 +1,468 @@
+// Package migrate moves queue definitions, and optionally messages,
+// from other message brokers, e.g. Amazon SQS or RabbitMQ, to PlainQ.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqname"
+	"google.golang.org/grpc"
+)
+
+// Statuses of migrated queues.
+const (
+	StatusPlanned = "planned"
+	StatusCreated = "created"
+	StatusExists  = "exists"
+	StatusSkipped = "skipped"
+	StatusFailed  = "failed"
+)
+
+const (
+	// listPageSize represents the number of PlainQ queues listed per page.
+	listPageSize int32 = 100
+
+	// unlimitedReceiveAttempts emulates brokers which redeliver messages until they expire.
+	unlimitedReceiveAttempts = math.MaxUint32
+)
+
+// Queue represents the queue definition of the source broker.
+type Queue struct {
+	// Name is the name of the queue in the source broker.
+	Name string
+
+	// Ref is the reference of the queue in the source broker, e.g. the queue ARN.
+	Ref string
+
+	// VisibilityTimeout is the zero when the source has no such setting.
+	VisibilityTimeout time.Duration
+
+	// RetentionPeriod is the zero when messages don't expire.
+	RetentionPeriod time.Duration
+
+	Delay          time.Duration
+	MaxMessageSize uint64
+
+	// MaxReceiveAttempts is the zero when messages are redelivered until they expire.
+	MaxReceiveAttempts uint32
+
+	// DeadLetterQueue is the name of the dead letter queue in the source broker.
+	DeadLetterQueue string
+
+	FIFO bool
+
+	// Skip holds the reason why the queue can't be migrated, if any.
+	Skip string
+
+	// Notes holds settings which have no equivalent in PlainQ.
+	Notes []string
+}
+
+// Source represents the broker queues are migrated from.
+type Source interface {
+	// Name returns the name of the broker, e.g. "sqs".
+	Name() string
+
+	// Queues returns definitions of all queues.
+	Queues(ctx context.Context) ([]Queue, error)
+
+	// Drain removes messages from the queue and passes them to fn by batches, until the queue
+	// is empty. Messages are removed from the source only after fn succeeds, or are returned
+	// back to the source otherwise.
+	Drain(ctx context.Context, queue Queue, fn func(bodies [][]byte) error) error
+}
+
+// Target represents the PlainQ server queues are migrated to.
+type Target interface {
+	ListQueues(ctx context.Context, in *v1.ListQueuesRequest, opts ...grpc.CallOption) (*v1.ListQueuesResponse, error)
+	CreateQueue(ctx context.Context, in *v1.CreateQueueRequest, opts ...grpc.CallOption) (*v1.CreateQueueResponse, error)
+	Send(ctx context.Context, in *v1.SendRequest, opts ...grpc.CallOption) (*v1.SendResponse, error)
+}
+
+// Report represents the result of the migration.
+type Report struct {
+	Source string        `json:"source"`
+	DryRun bool          `json:"dry_run"`
+	Drain  bool          `json:"drain"`
+	Queues []QueueReport `json:"queues"`
+}
+
+// Failed returns the number of queues which failed to migrate.
+func (r *Report) Failed() int {
+	var failed int
+
+	for _, q := range r.Queues {
+		if q.Status == StatusFailed {
+			failed++
+		}
+	}
+
+	return failed
+}
+
+// QueueReport represents the result of the queue migration.
+type QueueReport struct {
+	Source            string   `json:"source"`
+	Name              string   `json:"name"`
+	QueueID           string   `json:"queue_id,omitempty"`
+	DeadLetterQueueID string   `json:"dead_letter_queue_id,omitempty"`
+	Status            string   `json:"status"`
+	Messages          uint64   `json:"messages"`
+	Notes             []string `json:"notes,omitempty"`
+	Error             string   `json:"error,omitempty"`
+}
+
+// Option configures the migration.
+type Option func(*Options)
+
+// WithDrain is an Option function that enables moving messages of migrated queues.
+func WithDrain(drain bool) Option {
+	return func(o *Options) { o.drain = drain }
+}
+
+// WithDryRun is an Option function that makes the migration only report the planned changes.
+func WithDryRun(dryRun bool) Option {
+	return func(o *Options) { o.dryRun = dryRun }
+}
+
+// WithQueues is an Option function that limits the migration to given source queues
+// and their dead letter queues.
+func WithQueues(names ...string) Option {
+	return func(o *Options) { o.queues = names }
+}
+
+// WithPrefix is an Option function that sets the prefix of names of created queues.
+func WithPrefix(prefix string) Option {
+	return func(o *Options) { o.prefix = prefix }
+}
+
+// Options holds a set of properties to configure the migration.
+type Options struct {
+	drain  bool
+	dryRun bool
+	queues []string
+	prefix string
+}
+
+// Migrate recreates queues of the source in the target and returns the report. Queues which
+// already exist in the target are kept as they are. Failure of a queue doesn't stop the
+// migration of other queues, and is recorded in the report.
+func Migrate(ctx context.Context, source Source, target Target, options ...Option) (*Report, error) {
+	var opts Options
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	queues, queuesErr := source.Queues(ctx)
+	if queuesErr != nil {
+		return nil, fmt.Errorf("list %s queues: %w", source.Name(), queuesErr)
+	}
+
+	selected, selectErr := selectQueues(queues, opts.queues)
+	if selectErr != nil {
+		return nil, selectErr
+	}
+
+	existing, existingErr := existingQueues(ctx, target)
+	if existingErr != nil {
+		return nil, existingErr
+	}
+
+	m := migration{
+		source:   source,
+		target:   target,
+		opts:     opts,
+		byName:   make(map[string]Queue, len(queues)),
+		reports:  make(map[string]*QueueReport, len(selected)),
+		existing: existing,
+		names:    make(map[string]string, len(selected)),
+	}
+
+	for _, q := range queues {
+		m.byName[q.Name] = q
+	}
+
+	report := Report{
+		Source: source.Name(),
+		DryRun: opts.dryRun,
+		Drain:  opts.drain,
+		Queues: make([]QueueReport, 0, len(selected)),
+	}
+
+	// Dead letter queues are created first, since
+	// queues refer to them by PlainQ identifiers.
+	for _, q := range selected {
+		m.migrate(ctx, q, nil)
+	}
+
+	if opts.drain && !opts.dryRun {
+		for _, q := range selected {
+			m.drain(ctx, q)
+		}
+	}
+
+	for _, q := range selected {
+		report.Queues = append(report.Queues, *m.reports[q.Name])
+	}
+
+	return &report, nil
+}
+
+// selectQueues returns queues with given names and their dead letter queues,
+// or all queues when no names are given.
+func selectQueues(queues []Queue, names []string) ([]Queue, error) {
+	if len(names) == 0 {
+		return queues, nil
+	}
+
+	wanted := make(map[string]bool, len(names))
+
+	for _, name := range names {
+		if !slices.ContainsFunc(queues, func(q Queue) bool { return q.Name == name }) {
+			return nil, fmt.Errorf("queue %q not found in the source", name)
+		}
+
+		wanted[name] = true
+	}
+
+	// Dead letter queues may have own dead letter queues.
+	for added := true; added; {
+		added = false
+
+		for _, q := range queues {
+			if wanted[q.Name] && q.DeadLetterQueue != "" && !wanted[q.DeadLetterQueue] {
+				wanted[q.DeadLetterQueue] = true
+				added = true
+			}
+		}
+	}
+
+	selected := make([]Queue, 0, len(wanted))
+
+	for _, q := range queues {
+		if wanted[q.Name] {
+			selected = append(selected, q)
+		}
+	}
+
+	return selected, nil
+}
+
+// existingQueues returns identifiers of queues of the target by name.
+func existingQueues(ctx context.Context, target Target) (map[string]string, error) {
+	existing := make(map[string]string)
+
+	in := v1.ListQueuesRequest{Limit: listPageSize}
+
+	for {
+		list, listErr := target.ListQueues(ctx, &in)
+		if listErr != nil {
+			return nil, fmt.Errorf("list plainq queues: %w", listErr)
+		}
+
+		for _, q := range list.GetQueues() {
+			existing[q.GetQueueName()] = q.GetQueueId()
+		}
+
+		if !list.GetHasMore() {
+			return existing, nil
+		}
+
+		in.Cursor = list.GetNextCursor()
+	}
+}
+
+// migration holds the state of the running migration.
+type migration struct {
+	source Source
+	target Target
+	opts   Options
+
+	// byName holds all source queues by name.
+	byName map[string]Queue
+
+	// reports holds reports of processed queues by source name.
+	reports map[string]*QueueReport
+
+	// existing holds identifiers of target queues by name.
+	existing map[string]string
+
+	// names holds source names of queues by target name.
+	names map[string]string
+}
+
+// migrate creates the queue after its dead letter queue and returns its report.
+// The chain holds source names of queues which wait for the queue as a dead letter one.
+func (m *migration) migrate(ctx context.Context, q Queue, chain []string) *QueueReport {
+	if r, ok := m.reports[q.Name]; ok {
+		return r
+	}
+
+	r := QueueReport{
+		Source: q.Name,
+		Name:   TargetName(m.opts.prefix + q.Name),
+		Notes:  slices.Clone(q.Notes),
+	}
+
+	m.reports[q.Name] = &r
+
+	if q.Skip != "" {
+		r.Status = StatusSkipped
+		r.Notes = append(r.Notes, q.Skip)
+
+		return &r
+	}
+
+	if err := pqname.ValidateQueueName(r.Name); err != nil {
+		return r.fail(err)
+	}
+
+	if other, ok := m.names[r.Name]; ok {
+		return r.fail(fmt.Errorf("queue %q has the same name in plainq", other))
+	}
+
+	m.names[r.Name] = q.Name
+
+	in := createRequest(m.source.Name(), q, r.Name)
+
+	if q.DeadLetterQueue != "" {
+		dlq, ok := m.byName[q.DeadLetterQueue]
+
+		switch {
+		case !ok:
+			r.Notes = append(r.Notes, fmt.Sprintf("dead letter queue %q not found in the source, messages are dropped instead", q.DeadLetterQueue))
+			in.EvictionPolicy = v1.EvictionPolicy_EVICTION_POLICY_DROP
+
+		case slices.Contains(chain, dlq.Name) || dlq.Name == q.Name:
+			return r.fail(fmt.Errorf("dead letter queues of %q form a cycle", q.Name))
+
+		default:
+			dlqReport := m.migrate(ctx, dlq, append(chain, q.Name))
+			if dlqReport.Status == StatusFailed || dlqReport.Status == StatusSkipped {
+				return r.fail(fmt.Errorf("dead letter queue %q has %s", dlq.Name, dlqReport.Status))
+			}
+
+			in.DeadLetterQueueId = dlqReport.QueueID
+			r.DeadLetterQueueID = dlqReport.QueueID
+		}
+	}
+
+	if id, ok := m.existing[r.Name]; ok {
+		r.Status = StatusExists
+		r.QueueID = id
+		r.Notes = append(r.Notes, "queue already exists in plainq, its properties are kept")
+
+		return &r
+	}
+
+	if m.opts.dryRun {
+		r.Status = StatusPlanned
+		return &r
+	}
+
+	create, createErr := m.target.CreateQueue(ctx, in)
+	if createErr != nil {
+		return r.fail(fmt.Errorf("create queue: %w", createErr))
+	}
+
+	r.Status = StatusCreated
+	r.QueueID = create.GetQueueId()
+
+	return &r
+}
+
+// drain moves messages of the migrated queue to its PlainQ counterpart.
+func (m *migration) drain(ctx context.Context, q Queue) {
+	r := m.reports[q.Name]
+
+	if r.QueueID == "" {
+		return
+	}
+
+	drainErr := m.source.Drain(ctx, q, func(bodies [][]byte) error {
+		in := v1.SendRequest{
+			QueueId:  r.QueueID,
+			Messages: make([]*v1.SendMessage, 0, len(bodies)),
+		}
+
+		for _, body := range bodies {
+			in.Messages = append(in.Messages, &v1.SendMessage{Body: body})
+		}
+
+		if _, err := m.target.Send(ctx, &in); err != nil {
+			return fmt.Errorf("send messages: %w", err)
+		}
+
+		r.Messages += uint64(len(bodies))
+
+		return nil
+	})
+	if drainErr != nil {
+		r.fail(fmt.Errorf("drain messages: %w", drainErr))
+	}
+}
+
+// fail marks the queue as failed with the err and returns the report.
+func (r *QueueReport) fail(err error) *QueueReport {
+	r.Status = StatusFailed
+	r.Error = err.Error()
+
+	return r
+}
+
+// createRequest returns the request which creates the PlainQ counterpart of the queue.
+func createRequest(source string, q Queue, name string) *v1.CreateQueueRequest {
+	in := v1.CreateQueueRequest{
+		QueueName:                name,
+		RetentionPeriodSeconds:   uint64(q.RetentionPeriod.Seconds()),
+		VisibilityTimeoutSeconds: uint64(q.VisibilityTimeout.Seconds()),
+		MaxReceiveAttempts:       q.MaxReceiveAttempts,
+		EvictionPolicy:           v1.EvictionPolicy_EVICTION_POLICY_DROP,
+		Description:              fmt.Sprintf("Migrated from %s queue %q", source, q.Name),
+		Tags:                     map[string]string{"migrated-from": source},
+		Fifo:                     q.FIFO,
+		DelaySeconds:             uint64(q.Delay.Seconds()),
+		MaxMessageSizeBytes:      q.MaxMessageSize,
+	}
+
+	if q.Ref != "" {
+		in.Tags["source"] = q.Ref
+	}
+
+	if q.MaxReceiveAttempts == 0 {
+		in.MaxReceiveAttempts = unlimitedReceiveAttempts
+	}
+
+	if q.DeadLetterQueue != "" {
+		in.EvictionPolicy = v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER
+	}
+
+	return &in
+}
+
+// TargetName returns the PlainQ queue name for the source queue name,
+// replacing characters PlainQ doesn't allow with hyphens.
+func TargetName(name string) string {
+	mapped := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+
+		default:
+			return '-'
+		}
+	}, name)
+
+	if len(mapped) > pqname.MaxQueueNameLen {
+		mapped = mapped[:pqname.MaxQueueNameLen]
+	}
+
+	return mapped
+}
@@ -0,0 +1,289 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/grpc"
+)
+
+// fakeSource is the Source which holds queues and their messages in memory.
+type fakeSource struct {
+	queues   []Queue
+	messages map[string][][]byte
+}
+
+func (*fakeSource) Name() string { return "fake" }
+
+func (s *fakeSource) Queues(context.Context) ([]Queue, error) { return s.queues, nil }
+
+func (s *fakeSource) Drain(_ context.Context, queue Queue, fn func(bodies [][]byte) error) error {
+	if bodies := s.messages[queue.Name]; len(bodies) > 0 {
+		if err := fn(bodies); err != nil {
+			return err
+		}
+	}
+
+	delete(s.messages, queue.Name)
+
+	return nil
+}
+
+// fakeTarget is the Target which records created queues and sent messages.
+type fakeTarget struct {
+	existing []*v1.DescribeQueueResponse
+	created  []*v1.CreateQueueRequest
+	sent     map[string]int
+	sendErr  error
+}
+
+func (t *fakeTarget) ListQueues(context.Context, *v1.ListQueuesRequest, ...grpc.CallOption) (*v1.ListQueuesResponse, error) {
+	return &v1.ListQueuesResponse{Queues: t.existing}, nil
+}
+
+func (t *fakeTarget) CreateQueue(_ context.Context, in *v1.CreateQueueRequest, _ ...grpc.CallOption) (*v1.CreateQueueResponse, error) {
+	t.created = append(t.created, in)
+	return &v1.CreateQueueResponse{QueueId: "id-" + in.GetQueueName()}, nil
+}
+
+func (t *fakeTarget) Send(_ context.Context, in *v1.SendRequest, _ ...grpc.CallOption) (*v1.SendResponse, error) {
+	if t.sendErr != nil {
+		return nil, t.sendErr
+	}
+
+	if t.sent == nil {
+		t.sent = make(map[string]int)
+	}
+
+	t.sent[in.GetQueueId()] += len(in.GetMessages())
+
+	return &v1.SendResponse{}, nil
+}
+
+func TestMigrate(t *testing.T) {
+	orders := Queue{
+		Name:               "orders",
+		VisibilityTimeout:  time.Minute,
+		RetentionPeriod:    time.Hour,
+		MaxReceiveAttempts: 3,
+		DeadLetterQueue:    "orders-dlq",
+	}
+
+	dlq := Queue{Name: "orders-dlq"}
+
+	type tcase struct {
+		queues   []Queue
+		existing []*v1.DescribeQueueResponse
+		options  []Option
+		sendErr  error
+
+		wantCreated []string
+		wantReports []QueueReport
+		wantSent    map[string]int
+		wantErr     bool
+	}
+
+	tests := map[string]tcase{
+		"DeadLetterQueueFirst": {
+			queues:      []Queue{orders, dlq},
+			wantCreated: []string{"orders-dlq", "orders"},
+			wantReports: []QueueReport{
+				{Source: "orders", Name: "orders", QueueID: "id-orders", DeadLetterQueueID: "id-orders-dlq", Status: StatusCreated},
+				{Source: "orders-dlq", Name: "orders-dlq", QueueID: "id-orders-dlq", Status: StatusCreated},
+			},
+		},
+		"Exists": {
+			queues:      []Queue{orders, dlq},
+			existing:    []*v1.DescribeQueueResponse{{QueueId: "old", QueueName: "orders-dlq"}},
+			wantCreated: []string{"orders"},
+			wantReports: []QueueReport{
+				{Source: "orders", Name: "orders", QueueID: "id-orders", DeadLetterQueueID: "old", Status: StatusCreated},
+				{
+					Source: "orders-dlq", Name: "orders-dlq", QueueID: "old", Status: StatusExists,
+					Notes: []string{"queue already exists in plainq, its properties are kept"},
+				},
+			},
+		},
+		"DryRun": {
+			queues:  []Queue{dlq},
+			options: []Option{WithDryRun(true), WithDrain(true)},
+			wantReports: []QueueReport{
+				{Source: "orders-dlq", Name: "orders-dlq", Status: StatusPlanned},
+			},
+		},
+		"Selected": {
+			queues:      []Queue{{Name: "other"}, orders, dlq},
+			options:     []Option{WithQueues("orders"), WithPrefix("aws.")},
+			wantCreated: []string{"aws.orders-dlq", "aws.orders"},
+			wantReports: []QueueReport{
+				{Source: "orders", Name: "aws.orders", QueueID: "id-aws.orders", DeadLetterQueueID: "id-aws.orders-dlq", Status: StatusCreated},
+				{Source: "orders-dlq", Name: "aws.orders-dlq", QueueID: "id-aws.orders-dlq", Status: StatusCreated},
+			},
+		},
+		"SelectedNotFound": {
+			queues:  []Queue{orders, dlq},
+			options: []Option{WithQueues("missing")},
+			wantErr: true,
+		},
+		"MissingDeadLetterQueue": {
+			queues:      []Queue{orders},
+			wantCreated: []string{"orders"},
+			wantReports: []QueueReport{
+				{
+					Source: "orders", Name: "orders", QueueID: "id-orders", Status: StatusCreated,
+					Notes: []string{`dead letter queue "orders-dlq" not found in the source, messages are dropped instead`},
+				},
+			},
+		},
+		"Cycle": {
+			queues: []Queue{
+				{Name: "a", DeadLetterQueue: "b"},
+				{Name: "b", DeadLetterQueue: "a"},
+			},
+			wantReports: []QueueReport{
+				{Source: "a", Name: "a", Status: StatusFailed, Error: `dead letter queue "b" has failed`},
+				{Source: "b", Name: "b", Status: StatusFailed, Error: `dead letter queues of "b" form a cycle`},
+			},
+		},
+		"Skipped": {
+			queues: []Queue{{Name: "amq.gen-1", Skip: "exclusive queues belong to a single connection"}},
+			wantReports: []QueueReport{
+				{
+					Source: "amq.gen-1", Name: "amq.gen-1", Status: StatusSkipped,
+					Notes: []string{"exclusive queues belong to a single connection"},
+				},
+			},
+		},
+		"SameName": {
+			queues:      []Queue{{Name: "a b"}, {Name: "a:b"}},
+			wantCreated: []string{"a-b"},
+			wantReports: []QueueReport{
+				{Source: "a b", Name: "a-b", QueueID: "id-a-b", Status: StatusCreated},
+				{Source: "a:b", Name: "a-b", Status: StatusFailed, Error: `queue "a b" has the same name in plainq`},
+			},
+		},
+		"Drain": {
+			queues:      []Queue{dlq},
+			options:     []Option{WithDrain(true)},
+			wantCreated: []string{"orders-dlq"},
+			wantReports: []QueueReport{
+				{Source: "orders-dlq", Name: "orders-dlq", QueueID: "id-orders-dlq", Status: StatusCreated, Messages: 2},
+			},
+			wantSent: map[string]int{"id-orders-dlq": 2},
+		},
+		"DrainFailed": {
+			queues:      []Queue{dlq},
+			options:     []Option{WithDrain(true)},
+			sendErr:     errors.New("unavailable"),
+			wantCreated: []string{"orders-dlq"},
+			wantReports: []QueueReport{
+				{
+					Source: "orders-dlq", Name: "orders-dlq", QueueID: "id-orders-dlq", Status: StatusFailed,
+					Error: "drain messages: send messages: unavailable",
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			source := fakeSource{
+				queues:   tc.queues,
+				messages: map[string][][]byte{"orders-dlq": {[]byte("1"), []byte("2")}},
+			}
+
+			target := fakeTarget{existing: tc.existing, sendErr: tc.sendErr}
+
+			report, err := Migrate(context.Background(), &source, &target, tc.options...)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+
+			var created []string
+
+			for _, in := range target.created {
+				created = append(created, in.GetQueueName())
+			}
+
+			td.Cmp(t, created, tc.wantCreated)
+			td.Cmp(t, report.Queues, tc.wantReports)
+			td.Cmp(t, target.sent, tc.wantSent)
+		})
+	}
+}
+
+func Test_createRequest(t *testing.T) {
+	type tcase struct {
+		queue Queue
+		want  *v1.CreateQueueRequest
+	}
+
+	tests := map[string]tcase{
+		"DeadLetter": {
+			queue: Queue{
+				Name:               "orders.fifo",
+				Ref:                "arn:aws:sqs:eu-west-1:1:orders.fifo",
+				VisibilityTimeout:  45 * time.Second,
+				RetentionPeriod:    4 * 24 * time.Hour,
+				Delay:              5 * time.Second,
+				MaxMessageSize:     256 << 10,
+				MaxReceiveAttempts: 3,
+				DeadLetterQueue:    "orders-dlq.fifo",
+				FIFO:               true,
+			},
+			want: &v1.CreateQueueRequest{
+				QueueName:                "orders.fifo",
+				RetentionPeriodSeconds:   4 * 24 * 3600,
+				VisibilityTimeoutSeconds: 45,
+				MaxReceiveAttempts:       3,
+				EvictionPolicy:           v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER,
+				Description:              `Migrated from sqs queue "orders.fifo"`,
+				Tags:                     map[string]string{"migrated-from": "sqs", "source": "arn:aws:sqs:eu-west-1:1:orders.fifo"},
+				Fifo:                     true,
+				DelaySeconds:             5,
+				MaxMessageSizeBytes:      256 << 10,
+			},
+		},
+		"UnlimitedAttempts": {
+			queue: Queue{Name: "events"},
+			want: &v1.CreateQueueRequest{
+				QueueName:          "events",
+				MaxReceiveAttempts: unlimitedReceiveAttempts,
+				EvictionPolicy:     v1.EvictionPolicy_EVICTION_POLICY_DROP,
+				Description:        `Migrated from sqs queue "events"`,
+				Tags:               map[string]string{"migrated-from": "sqs"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, createRequest("sqs", tc.queue, tc.queue.Name), tc.want)
+		})
+	}
+}
+
+func TestTargetName(t *testing.T) {
+	tests := map[string]struct {
+		name string
+		want string
+	}{
+		"Valid":   {name: "orders.eu-west_1", want: "orders.eu-west_1"},
+		"Invalid": {name: "orders/eu west:1", want: "orders-eu-west-1"},
+		"TooLong": {name: strings.Repeat("a", 100), want: strings.Repeat("a", 80)},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, TargetName(tc.name), tc.want)
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// rabbitBatchSize represents the number of messages fetched from RabbitMQ at once.
+const rabbitBatchSize = 100
+
+// RabbitMQConfig holds the configuration of the RabbitMQ source.
+type RabbitMQConfig struct {
+	// URL is the address of the management API, e.g. "http://localhost:15672".
+	URL string
+
+	// VHost is the virtual host of queues.
+	VHost string
+
+	Username string
+	Password string
+}
+
+// RabbitMQ is the Source which reads queues from RabbitMQ with its management API.
+type RabbitMQ struct {
+	cfg    RabbitMQConfig
+	client *http.Client
+}
+
+// NewRabbitMQ returns a pointer to a new instance of RabbitMQ.
+func NewRabbitMQ(cfg RabbitMQConfig) (*RabbitMQ, error) {
+	if _, err := url.Parse(cfg.URL); err != nil || cfg.URL == "" {
+		return nil, fmt.Errorf("rabbitmq: invalid management API URL %q", cfg.URL)
+	}
+
+	if cfg.VHost == "" {
+		cfg.VHost = "/"
+	}
+
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+
+	r := RabbitMQ{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	return &r, nil
+}
+
+func (*RabbitMQ) Name() string { return "rabbitmq" }
+
+// rabbitQueue represents the queue returned by the management API.
+type rabbitQueue struct {
+	Name       string         `json:"name"`
+	VHost      string         `json:"vhost"`
+	Type       string         `json:"type"`
+	Exclusive  bool           `json:"exclusive"`
+	AutoDelete bool           `json:"auto_delete"`
+	Arguments  map[string]any `json:"arguments"`
+	Policy     map[string]any `json:"effective_policy_definition"`
+}
+
+// setting returns the queue argument with given name, e.g. "message-ttl",
+// which is set either by the x- prefixed argument or by the policy.
+func (q *rabbitQueue) setting(name string) (any, bool) {
+	if v, ok := q.Arguments["x-"+name]; ok {
+		return v, true
+	}
+
+	v, ok := q.Policy[name]
+
+	return v, ok
+}
+
+// rabbitBinding represents the binding returned by the management API.
+type rabbitBinding struct {
+	Destination     string `json:"destination"`
+	DestinationType string `json:"destination_type"`
+	RoutingKey      string `json:"routing_key"`
+}
+
+func (r *RabbitMQ) Queues(ctx context.Context) ([]Queue, error) {
+	var list []rabbitQueue
+
+	if err := r.call(ctx, http.MethodGet, "/api/queues/"+url.PathEscape(r.cfg.VHost), nil, &list); err != nil {
+		return nil, err
+	}
+
+	queues := make([]Queue, 0, len(list))
+
+	for _, rq := range list {
+		q, queueErr := r.queue(ctx, &rq)
+		if queueErr != nil {
+			return nil, fmt.Errorf("queue %q: %w", rq.Name, queueErr)
+		}
+
+		queues = append(queues, q)
+	}
+
+	return queues, nil
+}
+
+// queue maps the RabbitMQ queue to the Queue.
+func (r *RabbitMQ) queue(ctx context.Context, rq *rabbitQueue) (Queue, error) {
+	q := Queue{
+		Name: rq.Name,
+		Ref:  strings.TrimSuffix(rq.VHost, "/") + "/" + rq.Name,
+	}
+
+	switch {
+	case rq.Exclusive:
+		q.Skip = "exclusive queues belong to a single connection"
+
+	case rq.Type == "stream":
+		q.Skip = "streams are not supported"
+	}
+
+	if rq.AutoDelete {
+		q.Notes = append(q.Notes, "auto-delete is not supported")
+	}
+
+	// RabbitMQ has no visibility timeout, messages are
+	// redelivered only when the consumer goes away.
+	q.Notes = append(q.Notes, "visibility timeout is set to the plainq default")
+
+	if v, ok := rq.setting("message-ttl"); ok {
+		ms, msErr := number(v)
+		if msErr != nil {
+			return Queue{}, fmt.Errorf("message-ttl: %w", msErr)
+		}
+
+		q.RetentionPeriod = time.Duration(ms) * time.Millisecond
+	}
+
+	if v, ok := rq.setting("delivery-limit"); ok {
+		limit, limitErr := number(v)
+		if limitErr != nil {
+			return Queue{}, fmt.Errorf("delivery-limit: %w", limitErr)
+		}
+
+		// The limit counts redeliveries, while PlainQ counts all receives.
+		q.MaxReceiveAttempts = uint32(limit) + 1
+	}
+
+	for _, name := range []string{"max-length", "max-length-bytes", "overflow"} {
+		if _, ok := rq.setting(name); ok {
+			q.Notes = append(q.Notes, name+" is not supported")
+		}
+	}
+
+	if v, ok := rq.setting("dead-letter-exchange"); ok {
+		exchange, _ := v.(string)
+
+		routingKey := rq.Name
+		if v, ok := rq.setting("dead-letter-routing-key"); ok {
+			routingKey, _ = v.(string)
+		}
+
+		dlq, dlqErr := r.deadLetterQueue(ctx, exchange, routingKey)
+		if dlqErr != nil {
+			return Queue{}, dlqErr
+		}
+
+		if dlq == "" {
+			q.Notes = append(q.Notes, fmt.Sprintf("dead letter exchange %q routes to no single queue, messages are dropped instead", exchange))
+		}
+
+		q.DeadLetterQueue = dlq
+	}
+
+	return q, nil
+}
+
+// deadLetterQueue returns the queue the dead letter exchange routes messages with
+// the routing key to, or the empty string if it routes them to no single queue.
+func (r *RabbitMQ) deadLetterQueue(ctx context.Context, exchange, routingKey string) (string, error) {
+	// The default exchange routes messages to the queue named by the routing key.
+	if exchange == "" {
+		return routingKey, nil
+	}
+
+	var bindings []rabbitBinding
+
+	p := "/api/exchanges/" + url.PathEscape(r.cfg.VHost) + "/" + url.PathEscape(exchange) + "/bindings/source"
+
+	if err := r.call(ctx, http.MethodGet, p, nil, &bindings); err != nil {
+		return "", fmt.Errorf("dead letter exchange %q bindings: %w", exchange, err)
+	}
+
+	var bound, matched []string
+
+	for _, b := range bindings {
+		if b.DestinationType != "queue" {
+			continue
+		}
+
+		bound = append(bound, b.Destination)
+
+		if b.RoutingKey == routingKey {
+			matched = append(matched, b.Destination)
+		}
+	}
+
+	switch {
+	case len(matched) == 1:
+		return matched[0], nil
+
+	// Exchanges of other types than direct, e.g. fanout, may ignore routing keys.
+	case len(matched) == 0 && len(bound) == 1:
+		return bound[0], nil
+
+	default:
+		return "", nil
+	}
+}
+
+// rabbitMessage represents the message returned by the management API.
+type rabbitMessage struct {
+	Payload         string `json:"payload"`
+	PayloadEncoding string `json:"payload_encoding"`
+}
+
+func (r *RabbitMQ) Drain(ctx context.Context, queue Queue, fn func(bodies [][]byte) error) error {
+	vhost := url.PathEscape(r.cfg.VHost)
+
+	for {
+		var messages []rabbitMessage
+
+		// The management API can't acknowledge messages after they are fetched,
+		// so they are removed at once and published back if PlainQ rejects them.
+		in := map[string]any{
+			"count":    rabbitBatchSize,
+			"ackmode":  "ack_requeue_false",
+			"encoding": "base64",
+		}
+
+		if err := r.call(ctx, http.MethodPost, "/api/queues/"+vhost+"/"+url.PathEscape(queue.Name)+"/get", in, &messages); err != nil {
+			return err
+		}
+
+		if len(messages) == 0 {
+			return nil
+		}
+
+		bodies := make([][]byte, 0, len(messages))
+
+		for _, m := range messages {
+			body, decodeErr := base64.StdEncoding.DecodeString(m.Payload)
+			if decodeErr != nil {
+				return errors.Join(fmt.Errorf("decode message payload: %w", decodeErr), r.requeue(ctx, queue.Name, messages))
+			}
+
+			bodies = append(bodies, body)
+		}
+
+		if err := fn(bodies); err != nil {
+			return errors.Join(err, r.requeue(ctx, queue.Name, messages))
+		}
+	}
+}
+
+// requeue publishes messages back to the queue through the default exchange.
+func (r *RabbitMQ) requeue(ctx context.Context, queue string, messages []rabbitMessage) error {
+	p := "/api/exchanges/" + url.PathEscape(r.cfg.VHost) + "/amq.default/publish"
+
+	for i, m := range messages {
+		in := map[string]any{
+			"properties":       map[string]any{"delivery_mode": 2},
+			"routing_key":      queue,
+			"payload":          m.Payload,
+			"payload_encoding": "base64",
+		}
+
+		var out struct {
+			Routed bool `json:"routed"`
+		}
+
+		if err := r.call(ctx, http.MethodPost, p, in, &out); err != nil {
+			return fmt.Errorf("%d messages are lost: return message to the source: %w", len(messages)-i, err)
+		}
+
+		if !out.Routed {
+			return fmt.Errorf("%d messages are lost: return message to the source: not routed", len(messages)-i)
+		}
+	}
+
+	return nil
+}
+
+// call calls the management API and decodes the result to out.
+func (r *RabbitMQ) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader = http.NoBody
+
+	if in != nil {
+		data, marshalErr := json.Marshal(in)
+		if marshalErr != nil {
+			return fmt.Errorf("rabbitmq: encode request: %w", marshalErr)
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	req, reqErr := http.NewRequestWithContext(ctx, method, r.cfg.URL+path, body)
+	if reqErr != nil {
+		return fmt.Errorf("rabbitmq: create request: %w", reqErr)
+	}
+
+	req.SetBasicAuth(r.cfg.Username, r.cfg.Password)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, doErr := r.client.Do(req)
+	if doErr != nil {
+		return fmt.Errorf("rabbitmq: %w", doErr)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	data, readErr := io.ReadAll(resp.Body)
+	if readErr != nil {
+		return fmt.Errorf("rabbitmq: read response: %w", readErr)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Reason string `json:"reason"`
+		}
+
+		if err := json.Unmarshal(data, &apiErr); err != nil || apiErr.Reason == "" {
+			return fmt.Errorf("rabbitmq: %s %s: unexpected status %s", method, path, resp.Status)
+		}
+
+		return fmt.Errorf("rabbitmq: %s %s: %s", method, path, apiErr.Reason)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("rabbitmq: decode response: %w", err)
+	}
+
+	return nil
+}
+
+// number converts the JSON number to the non-negative integer.
+func number(v any) (uint64, error) {
+	n, ok := v.(float64)
+	if !ok || n < 0 || n != float64(uint64(n)) {
+		return 0, fmt.Errorf("should be a non-negative integer: %v", v)
+	}
+
+	return uint64(n), nil
+}
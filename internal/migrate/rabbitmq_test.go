@@ -0,0 +1,194 @@
+package migrate
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+// fakeRabbitMQ emulates the RabbitMQ management API of the default virtual host.
+type fakeRabbitMQ struct {
+	t         *testing.T
+	queues    []map[string]any
+	bindings  []rabbitBinding
+	messages  []string
+	published []string
+}
+
+func (f *fakeRabbitMQ) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/queues/%2F", func(w http.ResponseWriter, _ *http.Request) {
+		td.CmpNoError(f.t, json.NewEncoder(w).Encode(f.queues))
+	})
+
+	mux.HandleFunc("GET /api/exchanges/%2F/dlx/bindings/source", func(w http.ResponseWriter, _ *http.Request) {
+		td.CmpNoError(f.t, json.NewEncoder(w).Encode(f.bindings))
+	})
+
+	mux.HandleFunc("POST /api/queues/%2F/orders/get", func(w http.ResponseWriter, _ *http.Request) {
+		n := min(len(f.messages), rabbitBatchSize)
+		messages := make([]rabbitMessage, 0, n)
+
+		for _, body := range f.messages[:n] {
+			messages = append(messages, rabbitMessage{Payload: base64.StdEncoding.EncodeToString([]byte(body)), PayloadEncoding: "base64"})
+		}
+
+		f.messages = f.messages[n:]
+
+		td.CmpNoError(f.t, json.NewEncoder(w).Encode(messages))
+	})
+
+	mux.HandleFunc("POST /api/exchanges/%2F/amq.default/publish", func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			RoutingKey string `json:"routing_key"`
+			Payload    string `json:"payload"`
+		}
+
+		td.CmpNoError(f.t, json.NewDecoder(r.Body).Decode(&in))
+		td.Cmp(f.t, in.RoutingKey, "orders")
+
+		body, _ := base64.StdEncoding.DecodeString(in.Payload)
+		f.published = append(f.published, string(body))
+
+		_, _ = w.Write([]byte(`{"routed":true}`))
+	})
+
+	return withBasicAuth(mux)
+}
+
+// withBasicAuth rejects requests without the guest credentials.
+func withBasicAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "guest" || pass != "guest" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"not_authorised","reason":"Login failed"}`))
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newTestRabbitMQ(t *testing.T, f *fakeRabbitMQ, password string) *RabbitMQ {
+	srv := httptest.NewServer(f.handler())
+	t.Cleanup(srv.Close)
+
+	r, err := NewRabbitMQ(RabbitMQConfig{URL: srv.URL, Username: "guest", Password: password})
+	td.CmpNoError(t, err)
+
+	return r
+}
+
+func TestRabbitMQ_Queues(t *testing.T) {
+	f := fakeRabbitMQ{
+		t: t,
+		queues: []map[string]any{
+			{
+				"name":  "orders",
+				"vhost": "/",
+				"type":  "quorum",
+				"arguments": map[string]any{
+					"x-message-ttl":          3600000,
+					"x-delivery-limit":       4,
+					"x-dead-letter-exchange": "dlx",
+				},
+				"effective_policy_definition": map[string]any{"max-length": 1000},
+			},
+			{
+				"name":  "orders-dlq",
+				"vhost": "/",
+				"type":  "classic",
+				"effective_policy_definition": map[string]any{
+					"dead-letter-exchange":    "",
+					"dead-letter-routing-key": "parking",
+				},
+			},
+			{"name": "amq.gen-1", "vhost": "/", "type": "classic", "exclusive": true},
+		},
+		bindings: []rabbitBinding{
+			{Destination: "audit", DestinationType: "exchange", RoutingKey: "orders"},
+			{Destination: "orders-dlq", DestinationType: "queue", RoutingKey: "orders"},
+			{Destination: "other-dlq", DestinationType: "queue", RoutingKey: "other"},
+		},
+	}
+
+	visibility := "visibility timeout is set to the plainq default"
+
+	queues, err := newTestRabbitMQ(t, &f, "guest").Queues(context.Background())
+	td.CmpNoError(t, err)
+	td.Cmp(t, queues, []Queue{
+		{
+			Name:               "orders",
+			Ref:                "/orders",
+			RetentionPeriod:    time.Hour,
+			MaxReceiveAttempts: 5,
+			DeadLetterQueue:    "orders-dlq",
+			Notes:              []string{visibility, "max-length is not supported"},
+		},
+		{
+			Name:            "orders-dlq",
+			Ref:             "/orders-dlq",
+			DeadLetterQueue: "parking",
+			Notes:           []string{visibility},
+		},
+		{
+			Name:  "amq.gen-1",
+			Ref:   "/amq.gen-1",
+			Skip:  "exclusive queues belong to a single connection",
+			Notes: []string{visibility},
+		},
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		_, err := newTestRabbitMQ(t, &f, "wrong").Queues(context.Background())
+		td.Cmp(t, err, td.Smuggle(func(err error) string { return err.Error() }, td.HasSuffix("Login failed")))
+	})
+}
+
+func TestRabbitMQ_Drain(t *testing.T) {
+	bodies := make([]string, 0, 150)
+
+	for i := range 150 {
+		bodies = append(bodies, string(rune('a'+i%26)))
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		f := fakeRabbitMQ{t: t, messages: bodies}
+
+		var drained []string
+
+		err := newTestRabbitMQ(t, &f, "guest").Drain(context.Background(), Queue{Name: "orders"}, func(batch [][]byte) error {
+			for _, b := range batch {
+				drained = append(drained, string(b))
+			}
+
+			return nil
+		})
+
+		td.CmpNoError(t, err)
+		td.Cmp(t, drained, bodies)
+		td.CmpEmpty(t, f.published)
+	})
+
+	t.Run("RequeuedOnFailure", func(t *testing.T) {
+		f := fakeRabbitMQ{t: t, messages: bodies}
+
+		errSend := errors.New("send failed")
+
+		err := newTestRabbitMQ(t, &f, "guest").Drain(context.Background(), Queue{Name: "orders"}, func([][]byte) error {
+			return errSend
+		})
+
+		td.CmpErrorIs(t, err, errSend)
+		td.Cmp(t, f.published, bodies[:rabbitBatchSize])
+	})
+}
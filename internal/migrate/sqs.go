@@ -0,0 +1,341 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// sqsBatchSize represents the maximum number of messages SQS returns at once.
+	sqsBatchSize = 10
+
+	// sqsWaitTime represents how long SQS waits for messages, which makes it
+	// check all its servers, so the empty response means the queue is empty.
+	sqsWaitTime = 2 * time.Second
+
+	// sqsDrainVisibilityTimeout represents how long drained messages are hidden
+	// from other consumers of the source while they are sent to PlainQ.
+	sqsDrainVisibilityTimeout = time.Minute
+)
+
+// SQSConfig holds the configuration of the SQS source.
+type SQSConfig struct {
+	// Region is the AWS region of queues, e.g. "eu-west-1".
+	Region string
+
+	// Endpoint overrides the regional SQS endpoint, e.g. for LocalStack.
+	Endpoint string
+
+	// Prefix limits queues to ones which names start with it.
+	Prefix string
+
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// SQS is the Source which reads queues from Amazon SQS with its JSON API.
+type SQS struct {
+	cfg    SQSConfig
+	client *http.Client
+	signer awsSigner
+}
+
+// NewSQS returns a pointer to a new instance of SQS.
+func NewSQS(cfg SQSConfig) (*SQS, error) {
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("sqs: region is not set")
+	}
+
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("sqs: credentials are not set")
+	}
+
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://sqs." + cfg.Region + ".amazonaws.com"
+	}
+
+	s := SQS{
+		cfg:    cfg,
+		client: &http.Client{Timeout: sqsWaitTime + 30*time.Second},
+		signer: awsSigner{
+			accessKeyID:     cfg.AccessKeyID,
+			secretAccessKey: cfg.SecretAccessKey,
+			sessionToken:    cfg.SessionToken,
+			region:          cfg.Region,
+			service:         "sqs",
+			now:             time.Now,
+		},
+	}
+
+	return &s, nil
+}
+
+func (*SQS) Name() string { return "sqs" }
+
+func (s *SQS) Queues(ctx context.Context) ([]Queue, error) {
+	var (
+		urls  []string
+		token string
+	)
+
+	for {
+		var out struct {
+			QueueUrls []string `json:"QueueUrls"`
+			NextToken string   `json:"NextToken"`
+		}
+
+		in := map[string]any{"MaxResults": 1000}
+
+		if s.cfg.Prefix != "" {
+			in["QueueNamePrefix"] = s.cfg.Prefix
+		}
+
+		if token != "" {
+			in["NextToken"] = token
+		}
+
+		if err := s.call(ctx, "ListQueues", in, &out); err != nil {
+			return nil, err
+		}
+
+		urls = append(urls, out.QueueUrls...)
+
+		if token = out.NextToken; token == "" {
+			break
+		}
+	}
+
+	queues := make([]Queue, 0, len(urls))
+
+	for _, url := range urls {
+		var out struct {
+			Attributes map[string]string `json:"Attributes"`
+		}
+
+		in := map[string]any{
+			"QueueUrl":       url,
+			"AttributeNames": []string{"All"},
+		}
+
+		if err := s.call(ctx, "GetQueueAttributes", in, &out); err != nil {
+			return nil, fmt.Errorf("queue %q: %w", url, err)
+		}
+
+		q, queueErr := sqsQueue(url, out.Attributes)
+		if queueErr != nil {
+			return nil, fmt.Errorf("queue %q: %w", url, queueErr)
+		}
+
+		queues = append(queues, q)
+	}
+
+	return queues, nil
+}
+
+// sqsQueue maps attributes of the SQS queue to the Queue.
+func sqsQueue(url string, attrs map[string]string) (Queue, error) {
+	q := Queue{
+		Name: path.Base(url),
+		Ref:  attrs["QueueArn"],
+		FIFO: attrs["FifoQueue"] == "true",
+	}
+
+	seconds := map[string]*time.Duration{
+		"VisibilityTimeout":      &q.VisibilityTimeout,
+		"MessageRetentionPeriod": &q.RetentionPeriod,
+		"DelaySeconds":           &q.Delay,
+	}
+
+	for name, d := range seconds {
+		v, ok := attrs[name]
+		if !ok {
+			continue
+		}
+
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return Queue{}, fmt.Errorf("parse %s attribute: %w", name, err)
+		}
+
+		*d = time.Duration(n) * time.Second
+	}
+
+	if v, ok := attrs["MaximumMessageSize"]; ok {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return Queue{}, fmt.Errorf("parse MaximumMessageSize attribute: %w", err)
+		}
+
+		q.MaxMessageSize = n
+	}
+
+	if v, ok := attrs["RedrivePolicy"]; ok && v != "" {
+		var policy struct {
+			DeadLetterTargetArn string      `json:"deadLetterTargetArn"`
+			MaxReceiveCount     json.Number `json:"maxReceiveCount"`
+		}
+
+		if err := json.Unmarshal([]byte(v), &policy); err != nil {
+			return Queue{}, fmt.Errorf("parse RedrivePolicy attribute: %w", err)
+		}
+
+		count, countErr := strconv.ParseUint(policy.MaxReceiveCount.String(), 10, 32)
+		if countErr != nil {
+			return Queue{}, fmt.Errorf("parse RedrivePolicy attribute: maxReceiveCount: %w", countErr)
+		}
+
+		// ARN has the form: arn:aws:sqs:region:account:name.
+		q.DeadLetterQueue = policy.DeadLetterTargetArn[strings.LastIndex(policy.DeadLetterTargetArn, ":")+1:]
+		q.MaxReceiveAttempts = uint32(count)
+	}
+
+	if attrs["ContentBasedDeduplication"] == "true" {
+		q.Notes = append(q.Notes, "content based deduplication is not supported")
+	}
+
+	if v := attrs["KmsMasterKeyId"]; v != "" {
+		q.Notes = append(q.Notes, "KMS encryption is not supported")
+	}
+
+	return q, nil
+}
+
+func (s *SQS) Drain(ctx context.Context, queue Queue, fn func(bodies [][]byte) error) error {
+	url, urlErr := s.queueURL(ctx, queue.Name)
+	if urlErr != nil {
+		return urlErr
+	}
+
+	for {
+		var out struct {
+			Messages []struct {
+				MessageID     string `json:"MessageId"`
+				ReceiptHandle string `json:"ReceiptHandle"`
+				Body          string `json:"Body"`
+			} `json:"Messages"`
+		}
+
+		in := map[string]any{
+			"QueueUrl":            url,
+			"MaxNumberOfMessages": sqsBatchSize,
+			"WaitTimeSeconds":     int(sqsWaitTime.Seconds()),
+			"VisibilityTimeout":   int(sqsDrainVisibilityTimeout.Seconds()),
+		}
+
+		if err := s.call(ctx, "ReceiveMessage", in, &out); err != nil {
+			return err
+		}
+
+		if len(out.Messages) == 0 {
+			return nil
+		}
+
+		bodies := make([][]byte, 0, len(out.Messages))
+		entries := make([]map[string]string, 0, len(out.Messages))
+
+		for i, m := range out.Messages {
+			bodies = append(bodies, []byte(m.Body))
+			entries = append(entries, map[string]string{
+				"Id":            strconv.Itoa(i),
+				"ReceiptHandle": m.ReceiptHandle,
+			})
+		}
+
+		// Messages which are not deleted become visible again
+		// in the source once their visibility timeout expires.
+		if err := fn(bodies); err != nil {
+			return err
+		}
+
+		var deleted struct {
+			Failed []struct {
+				ID      string `json:"Id"`
+				Message string `json:"Message"`
+			} `json:"Failed"`
+		}
+
+		if err := s.call(ctx, "DeleteMessageBatch", map[string]any{"QueueUrl": url, "Entries": entries}, &deleted); err != nil {
+			return fmt.Errorf("messages have been copied but not deleted from the source: %w", err)
+		}
+
+		if len(deleted.Failed) > 0 {
+			return fmt.Errorf("%d messages have been copied but not deleted from the source: %s",
+				len(deleted.Failed), deleted.Failed[0].Message,
+			)
+		}
+	}
+}
+
+// queueURL returns the URL of the queue with given name.
+func (s *SQS) queueURL(ctx context.Context, name string) (string, error) {
+	var out struct {
+		QueueURL string `json:"QueueUrl"`
+	}
+
+	if err := s.call(ctx, "GetQueueUrl", map[string]any{"QueueName": name}, &out); err != nil {
+		return "", err
+	}
+
+	return out.QueueURL, nil
+}
+
+// sqsError represents the error response of SQS.
+type sqsError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// call calls the SQS action with the input and decodes the result to out.
+func (s *SQS) call(ctx context.Context, action string, in, out any) error {
+	body, marshalErr := json.Marshal(in)
+	if marshalErr != nil {
+		return fmt.Errorf("sqs %s: encode request: %w", action, marshalErr)
+	}
+
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.Endpoint, bytes.NewReader(body))
+	if reqErr != nil {
+		return fmt.Errorf("sqs %s: create request: %w", action, reqErr)
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", "AmazonSQS."+action)
+
+	s.signer.sign(req, body)
+
+	resp, doErr := s.client.Do(req)
+	if doErr != nil {
+		return fmt.Errorf("sqs %s: %w", action, doErr)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	data, readErr := io.ReadAll(resp.Body)
+	if readErr != nil {
+		return fmt.Errorf("sqs %s: read response: %w", action, readErr)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr sqsError
+		if err := json.Unmarshal(data, &apiErr); err != nil || apiErr.Type == "" {
+			return fmt.Errorf("sqs %s: unexpected status %s", action, resp.Status)
+		}
+
+		// Type has the form: com.amazonaws.sqs#QueueDoesNotExist.
+		return fmt.Errorf("sqs %s: %s: %s", action, apiErr.Type[strings.LastIndex(apiErr.Type, "#")+1:], apiErr.Message)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("sqs %s: decode response: %w", action, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+// fakeSQS emulates the SQS JSON API with a single page of queues.
+type fakeSQS struct {
+	t          *testing.T
+	attributes map[string]map[string]string
+	messages   []string
+	deleted    []string
+}
+
+func (f *fakeSQS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), awsAlgorithm+" Credential=AKID/") {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"__type":"com.amazon.coral.service#MissingAuthenticationTokenException","message":"no auth"}`))
+
+		return
+	}
+
+	var in map[string]any
+	td.CmpNoError(f.t, json.NewDecoder(r.Body).Decode(&in))
+
+	var out any
+
+	switch action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "AmazonSQS."); action {
+	case "ListQueues":
+		urls := make([]string, 0, len(f.attributes))
+
+		for name := range f.attributes {
+			urls = append(urls, "http://sqs/000000000000/"+name)
+		}
+
+		out = map[string]any{"QueueUrls": urls}
+
+	case "GetQueueAttributes":
+		url, _ := in["QueueUrl"].(string)
+		out = map[string]any{"Attributes": f.attributes[url[strings.LastIndex(url, "/")+1:]]}
+
+	case "GetQueueUrl":
+		name, _ := in["QueueName"].(string)
+		if _, ok := f.attributes[name]; !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"com.amazonaws.sqs#QueueDoesNotExist","message":"The specified queue does not exist."}`))
+
+			return
+		}
+
+		out = map[string]any{"QueueUrl": "http://sqs/000000000000/" + name}
+
+	case "ReceiveMessage":
+		n := min(len(f.messages), sqsBatchSize)
+		messages := make([]map[string]string, 0, n)
+
+		for _, body := range f.messages[:n] {
+			messages = append(messages, map[string]string{"MessageId": body, "ReceiptHandle": "rh-" + body, "Body": body})
+		}
+
+		f.messages = f.messages[n:]
+		out = map[string]any{"Messages": messages}
+
+	case "DeleteMessageBatch":
+		entries, _ := in["Entries"].([]any)
+
+		for _, e := range entries {
+			entry, _ := e.(map[string]any)
+			handle, _ := entry["ReceiptHandle"].(string)
+			f.deleted = append(f.deleted, handle)
+		}
+
+		out = map[string]any{}
+
+	default:
+		f.t.Errorf("unexpected action: %q", action)
+	}
+
+	td.CmpNoError(f.t, json.NewEncoder(w).Encode(out))
+}
+
+func newTestSQS(t *testing.T, f *fakeSQS) *SQS {
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	s, err := NewSQS(SQSConfig{
+		Region:          "eu-west-1",
+		Endpoint:        srv.URL,
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+	})
+	td.CmpNoError(t, err)
+
+	return s
+}
+
+func TestSQS_Queues(t *testing.T) {
+	f := fakeSQS{
+		t: t,
+		attributes: map[string]map[string]string{
+			"orders.fifo": {
+				"QueueArn":                  "arn:aws:sqs:eu-west-1:000000000000:orders.fifo",
+				"VisibilityTimeout":         "45",
+				"MessageRetentionPeriod":    "345600",
+				"DelaySeconds":              "5",
+				"MaximumMessageSize":        "262144",
+				"FifoQueue":                 "true",
+				"ContentBasedDeduplication": "true",
+				"RedrivePolicy":             `{"deadLetterTargetArn":"arn:aws:sqs:eu-west-1:000000000000:orders-dlq.fifo","maxReceiveCount":3}`,
+			},
+		},
+	}
+
+	queues, err := newTestSQS(t, &f).Queues(context.Background())
+	td.CmpNoError(t, err)
+	td.Cmp(t, queues, []Queue{
+		{
+			Name:               "orders.fifo",
+			Ref:                "arn:aws:sqs:eu-west-1:000000000000:orders.fifo",
+			VisibilityTimeout:  45 * time.Second,
+			RetentionPeriod:    4 * 24 * time.Hour,
+			Delay:              5 * time.Second,
+			MaxMessageSize:     262144,
+			MaxReceiveAttempts: 3,
+			DeadLetterQueue:    "orders-dlq.fifo",
+			FIFO:               true,
+			Notes:              []string{"content based deduplication is not supported"},
+		},
+	})
+}
+
+func TestSQS_Drain(t *testing.T) {
+	bodies := make([]string, 0, 15)
+
+	for i := range 15 {
+		bodies = append(bodies, string(rune('a'+i)))
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		f := fakeSQS{
+			t:          t,
+			attributes: map[string]map[string]string{"orders": {}},
+			messages:   bodies,
+		}
+
+		var drained []string
+
+		err := newTestSQS(t, &f).Drain(context.Background(), Queue{Name: "orders"}, func(batch [][]byte) error {
+			for _, b := range batch {
+				drained = append(drained, string(b))
+			}
+
+			return nil
+		})
+
+		td.CmpNoError(t, err)
+		td.Cmp(t, drained, bodies)
+		td.Cmp(t, f.deleted, td.Len(len(bodies)))
+	})
+
+	t.Run("NotDeletedOnFailure", func(t *testing.T) {
+		f := fakeSQS{
+			t:          t,
+			attributes: map[string]map[string]string{"orders": {}},
+			messages:   bodies,
+		}
+
+		err := newTestSQS(t, &f).Drain(context.Background(), Queue{Name: "orders"}, func([][]byte) error {
+			return context.Canceled
+		})
+
+		td.CmpErrorIs(t, err, context.Canceled)
+		td.CmpEmpty(t, f.deleted)
+	})
+
+	t.Run("QueueDoesNotExist", func(t *testing.T) {
+		f := fakeSQS{t: t}
+
+		err := newTestSQS(t, &f).Drain(context.Background(), Queue{Name: "orders"}, func([][]byte) error { return nil })
+		td.Cmp(t, err, td.Smuggle(func(err error) string { return err.Error() }, td.Contains("QueueDoesNotExist")))
+	})
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/plainq/plainq/internal/server/auth"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/rbac"
+	"github.com/plainq/servekit/errkit"
+)
+
+// httpOperations maps HTTP API routes to operations on the queue of the request
+// which they perform, like gRPC methods. Routes which are not listed,
+// e.g. administrative ones, require the rbac.AdminRole.
+var httpOperations = map[string]rbac.Operation{
+	http.MethodGet + " /api/v1/queue/{id}":            rbac.OpDescribe,
+	http.MethodGet + " /api/v1/queue/{id}/advice":     rbac.OpDescribe,
+	http.MethodGet + " /api/v1/queue/{id}/stats":      rbac.OpDescribe,
+	http.MethodGet + " /api/v1/queue/{id}/search":     rbac.OpDescribe,
+	http.MethodGet + " /api/v1/queue/{id}/messages":   rbac.OpDescribe,
+	http.MethodPost + " /api/v1/queue/{id}/messages":  rbac.OpSend,
+	http.MethodGet + " /api/v1/queue/{id}/archive":    rbac.OpDescribe,
+	http.MethodPatch + " /api/v1/queue/{id}":          rbac.OpUpdate,
+	http.MethodPut + " /api/v1/queue/{id}/state":      rbac.OpUpdate,
+	http.MethodPost + " /api/v1/queue/{id}/generator": rbac.OpUpdate,
+	http.MethodPost + " /api/v1/queue/{id}/transfer":  rbac.OpUpdate,
+	http.MethodPost + " /api/v1/queue/{id}/purge":     rbac.OpPurge,
+	http.MethodDelete + " /api/v1/queue/{id}":         rbac.OpDelete,
+
+	http.MethodGet + " /api/v1/queue/{id}/messages/{message}":        rbac.OpReceive,
+	http.MethodGet + " /api/v1/queue/{id}/messages/{message}/events": rbac.OpDescribe,
+	http.MethodPost + " /api/v1/queue/{id}/archive/restore":          rbac.OpSend,
+
+	http.MethodGet + " /api/v1/queue":                 auth.OpAuthenticated,
+	http.MethodGet + " /api/v1/queue/":                auth.OpAuthenticated,
+	http.MethodGet + " /api/v1/search":                auth.OpAuthenticated,
+	http.MethodGet + " /api/v1/alerts":                auth.OpAuthenticated,
+	http.MethodGet + " /api/v1/alerts/":               auth.OpAuthenticated,
+	http.MethodPost + " /api/v1/transfer/{id}/accept": auth.OpAuthenticated,
+	http.MethodDelete + " /api/v1/transfer/{id}":      auth.OpAuthenticated,
+	http.MethodGet + " /api/v1/jobs":                  auth.OpAuthenticated,
+	http.MethodGet + " /api/v1/jobs/{id}":             auth.OpAuthenticated,
+	http.MethodDelete + " /api/v1/jobs/{id}":          auth.OpAuthenticated,
+	http.MethodPost + " /api/v1/auth/sign-out":        auth.OpAuthenticated,
+	http.MethodGet + " /api/v1/auth/sessions":         auth.OpAuthenticated,
+	http.MethodDelete + " /api/v1/auth/sessions":      auth.OpAuthenticated,
+	http.MethodDelete + " /api/v1/auth/sessions/{id}": auth.OpAuthenticated,
+	http.MethodGet + " /api/v1/account/me":            auth.OpAuthenticated,
+	http.MethodPatch + " /api/v1/account/me":          auth.OpAuthenticated,
+	http.MethodPost + " /api/v1/account/password":     auth.OpAuthenticated,
+	http.MethodGet + " /api/v1/account/permissions":   auth.OpAuthenticated,
+	http.MethodPost + " /api/v1/auth/sign-in":         auth.OpPublic,
+
+	http.MethodPost + " /api/v1/auth/password-reset":         auth.OpPublic,
+	http.MethodPost + " /api/v1/auth/password-reset/verify":  auth.OpPublic,
+	http.MethodPost + " /api/v1/auth/password-reset/confirm": auth.OpPublic,
+	http.MethodPost + " /api/v1/auth/verify-email/send":      auth.OpPublic,
+	http.MethodPost + " /api/v1/auth/verify-email":           auth.OpPublic,
+
+	http.MethodGet + " /api/docs":              auth.OpPublic,
+	http.MethodGet + " /api/docs/openapi.json": auth.OpPublic,
+}
+
+// creator returns the name of the authenticated client of the ctx, which is recorded
+// as the creator of queues. It is empty when clients are not authenticated.
+func creator(ctx context.Context) string {
+	if id, ok := identity.FromContext(ctx); ok {
+		return id.Name
+	}
+
+	return ""
+}
+
+// authorizeQueue checks that the authenticated client of the ctx is granted the operation
+// on the queue, which some calls require in addition to the operation of their route or method.
+// Clients are not authenticated only when authentication is disabled, so they're allowed.
+func (s *PlainQ) authorizeQueue(ctx context.Context, queueID string, op rbac.Operation) error {
+	id, ok := identity.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	if err := auth.Authorize(ctx, s.storage, id.Name, queueID, op); err != nil {
+		if errors.Is(err, auth.ErrPermissionDenied) {
+			s.observer.AuthDenials(queueID, string(op)).Inc()
+			return fmt.Errorf("%w: %w", errkit.ErrUnauthorized, err)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// isAdmin reports whether the authenticated client of the ctx has the rbac.AdminRole.
+// Clients are not authenticated only when authentication is disabled, so they're administrators.
+func (s *PlainQ) isAdmin(ctx context.Context) (bool, error) {
+	id, ok := identity.FromContext(ctx)
+	if !ok {
+		return true, nil
+	}
+
+	err := auth.Authorize(ctx, s.storage, id.Name, "", auth.OpAdmin)
+
+	switch {
+	case err == nil:
+		return true, nil
+
+	case errors.Is(err, auth.ErrPermissionDenied):
+		return false, nil
+
+	default:
+		return false, err
+	}
+}
@@ -0,0 +1,162 @@
+// Package accesslog provides the configuration of the HTTP access log:
+// fields of records, sampling of high-volume routes and redaction
+// of request headers which carry credentials.
+package accesslog
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Fields of access log records.
+const (
+	FieldMethod    = "method"
+	FieldRoute     = "route"
+	FieldURI       = "uri"
+	FieldStatus    = "status"
+	FieldBytes     = "bytes"
+	FieldRemote    = "remote"
+	FieldDuration  = "duration"
+	FieldUserAgent = "user_agent"
+	FieldIdentity  = "identity"
+	FieldHeaders   = "headers"
+)
+
+// Any is the key of the sampling rate applied to routes which have no own rate.
+const Any = "*"
+
+// Redacted replaces values of headers which carry credentials.
+const Redacted = "REDACTED"
+
+// DefaultFields holds fields of records when no fields are configured.
+var DefaultFields = []string{FieldMethod, FieldStatus, FieldURI, FieldRemote, FieldDuration}
+
+// fields holds all known fields.
+var fields = []string{
+	FieldMethod, FieldRoute, FieldURI, FieldStatus, FieldBytes,
+	FieldRemote, FieldDuration, FieldUserAgent, FieldIdentity, FieldHeaders,
+}
+
+// sensitiveHeaders holds canonical names of headers which values are redacted.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// ParseFields parses comma separated fields of records, e.g. "method,route,status,duration".
+// The empty spec results in DefaultFields.
+func ParseFields(spec string) ([]string, error) {
+	var parsed []string
+
+	for f := range strings.SplitSeq(spec, ",") {
+		if f = strings.ToLower(strings.TrimSpace(f)); f == "" {
+			continue
+		}
+
+		if !slices.Contains(fields, f) {
+			return nil, fmt.Errorf("unknown access log field %q, should be one of: %s", f, strings.Join(fields, ", "))
+		}
+
+		if !slices.Contains(parsed, f) {
+			parsed = append(parsed, f)
+		}
+	}
+
+	if len(parsed) == 0 {
+		return slices.Clone(DefaultFields), nil
+	}
+
+	return parsed, nil
+}
+
+// Sampling holds sampling rates by route. The route is logged once per rate
+// requests: 1 logs every request, 100 logs one of a hundred, and 0 disables
+// the access log of the route.
+type Sampling map[string]uint64
+
+// ParseSampling parses comma separated sampling rates in form: route=rate,
+// e.g. "GET /api/v1/queue/{id}/messages=100,GET /api/v1/search=0,*=1".
+func ParseSampling(spec string) (Sampling, error) {
+	sampling := make(Sampling)
+
+	for pair := range strings.SplitSeq(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		i := strings.LastIndex(pair, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid route sampling %q, should be in form: route=rate", pair)
+		}
+
+		route := strings.TrimSpace(pair[:i])
+
+		rate, parseErr := strconv.ParseUint(strings.TrimSpace(pair[i+1:]), 10, 64)
+		if parseErr != nil {
+			return nil, fmt.Errorf("route %q sampling rate: %w", route, parseErr)
+		}
+
+		sampling[route] = rate
+	}
+
+	return sampling, nil
+}
+
+// Lookup returns the sampling rate of the route,
+// or the Any rate when the route has none, or 1.
+func (s Sampling) Lookup(route string) uint64 {
+	if rate, ok := s[route]; ok {
+		return rate
+	}
+
+	if rate, ok := s[Any]; ok {
+		return rate
+	}
+
+	return 1
+}
+
+// Sampler decides which requests of routes are logged according to the Sampling.
+// Sampling is deterministic: the first request of each route is logged,
+// then each rate-th one.
+type Sampler struct {
+	sampling Sampling
+	counters sync.Map // route -> *atomic.Uint64
+}
+
+// NewSampler returns a pointer to a new instance of Sampler.
+func NewSampler(sampling Sampling) *Sampler {
+	return &Sampler{sampling: sampling}
+}
+
+// Sample reports whether the request of the route should be logged.
+func (s *Sampler) Sample(route string) bool {
+	rate := s.sampling.Lookup(route)
+
+	switch rate {
+	case 0:
+		return false
+
+	case 1:
+		return true
+	}
+
+	c, _ := s.counters.LoadOrStore(route, new(atomic.Uint64))
+
+	return (c.(*atomic.Uint64).Add(1)-1)%rate == 0
+}
+
+// Headers returns the copy of headers with values of headers which carry credentials redacted.
+func Headers(h http.Header) http.Header {
+	redacted := h.Clone()
+
+	for _, name := range sensitiveHeaders {
+		if _, ok := redacted[name]; ok {
+			redacted[name] = []string{Redacted}
+		}
+	}
+
+	return redacted
+}
@@ -0,0 +1,124 @@
+package accesslog
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestParseFields(t *testing.T) {
+	type tcase struct {
+		spec    string
+		want    []string
+		wantErr bool
+	}
+
+	tests := map[string]tcase{
+		"Empty": {
+			spec: "",
+			want: DefaultFields,
+		},
+		"Fields": {
+			spec: "Method, route,status,route",
+			want: []string{FieldMethod, FieldRoute, FieldStatus},
+		},
+		"Unknown": {
+			spec:    "method,password",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseFields(tc.spec)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
+
+func TestParseSampling(t *testing.T) {
+	type tcase struct {
+		spec    string
+		want    Sampling
+		wantErr bool
+	}
+
+	tests := map[string]tcase{
+		"Empty": {
+			spec: "",
+			want: Sampling{},
+		},
+		"Routes": {
+			spec: "GET /api/v1/queue/{id}/messages=100, GET /api/v1/search=0,*=2",
+			want: Sampling{"GET /api/v1/queue/{id}/messages": 100, "GET /api/v1/search": 0, "*": 2},
+		},
+		"NoRoute": {
+			spec:    "=10",
+			wantErr: true,
+		},
+		"InvalidRate": {
+			spec:    "GET /api/v1/search=-1",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseSampling(tc.spec)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
+
+func TestSampler_Sample(t *testing.T) {
+	s := NewSampler(Sampling{"GET /a": 3, "GET /off": 0})
+
+	var a, off, other int
+
+	for range 9 {
+		if s.Sample("GET /a") {
+			a++
+		}
+
+		if s.Sample("GET /off") {
+			off++
+		}
+
+		if s.Sample("GET /other") {
+			other++
+		}
+	}
+
+	td.Cmp(t, a, 3)
+	td.Cmp(t, off, 0)
+	td.Cmp(t, other, 9)
+}
+
+func TestHeaders(t *testing.T) {
+	h := http.Header{
+		"Authorization": {"Bearer secret"},
+		"Cookie":        {"session=secret"},
+		"Accept":        {"application/json"},
+	}
+
+	td.Cmp(t, Headers(h), http.Header{
+		"Authorization": {Redacted},
+		"Cookie":        {Redacted},
+		"Accept":        {"application/json"},
+	})
+
+	td.Cmp(t, h.Get("Authorization"), "Bearer secret", "original headers are kept")
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/plainq/plainq/internal/server/auth"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqname"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+)
+
+// createServiceAccount validates and stores the service account.
+func (s *PlainQ) createServiceAccount(ctx context.Context, input *v1.CreateServiceAccountRequest) (*v1.CreateServiceAccountResponse, error) {
+	if err := pqname.ValidateServiceAccountName(input.GetName()); err != nil {
+		return nil, fmt.Errorf("%w: %w", errkit.ErrInvalidArgument, err)
+	}
+
+	output, createErr := s.storage.CreateServiceAccount(ctx, input)
+	if createErr != nil {
+		return nil, fmt.Errorf("create service account: %w", createErr)
+	}
+
+	return output, nil
+}
+
+// deleteServiceAccount deletes the service account along with its API keys.
+func (s *PlainQ) deleteServiceAccount(ctx context.Context, input *v1.DeleteServiceAccountRequest) (*v1.DeleteServiceAccountResponse, error) {
+	if err := validateXID("account", input.GetAccountId()); err != nil {
+		return nil, err
+	}
+
+	output, deleteErr := s.storage.DeleteServiceAccount(ctx, input)
+	if deleteErr != nil {
+		return nil, fmt.Errorf("delete service account: %w", deleteErr)
+	}
+
+	return output, nil
+}
+
+// createAPIKey generates the API key of the service account and stores its hash.
+// The key itself is returned only once.
+func (s *PlainQ) createAPIKey(ctx context.Context, input *v1.CreateAPIKeyRequest) (*v1.CreateAPIKeyResponse, error) {
+	if err := validateXID("account", input.GetAccountId()); err != nil {
+		return nil, err
+	}
+
+	secret, prefix, hash, generateErr := auth.GenerateAPIKey()
+	if generateErr != nil {
+		return nil, generateErr
+	}
+
+	key, createErr := s.storage.CreateAPIKey(ctx, input, prefix, hash)
+	if createErr != nil {
+		return nil, fmt.Errorf("create API key: %w", createErr)
+	}
+
+	return &v1.CreateAPIKeyResponse{Key: key, Secret: secret}, nil
+}
+
+// listAPIKeys returns API keys of the service account.
+func (s *PlainQ) listAPIKeys(ctx context.Context, input *v1.ListAPIKeysRequest) (*v1.ListAPIKeysResponse, error) {
+	if err := validateXID("account", input.GetAccountId()); err != nil {
+		return nil, err
+	}
+
+	output, listErr := s.storage.ListAPIKeys(ctx, input)
+	if listErr != nil {
+		return nil, fmt.Errorf("list API keys: %w", listErr)
+	}
+
+	return output, nil
+}
+
+// revokeAPIKey revokes the API key, which is rejected from then on.
+func (s *PlainQ) revokeAPIKey(ctx context.Context, input *v1.RevokeAPIKeyRequest) (*v1.RevokeAPIKeyResponse, error) {
+	if err := validateXID("key", input.GetKeyId()); err != nil {
+		return nil, err
+	}
+
+	output, revokeErr := s.storage.RevokeAPIKey(ctx, input)
+	if revokeErr != nil {
+		return nil, fmt.Errorf("revoke API key: %w", revokeErr)
+	}
+
+	return output, nil
+}
+
+// validateXID validates given identifier of the entity of the kind.
+func validateXID(kind, id string) error {
+	if err := idkit.ValidateXID(strings.ToLower(id)); err != nil {
+		return fmt.Errorf("%w: invalid %s id %q", errkit.ErrInvalidArgument, kind, id)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/advisor"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+// adviseQueue collects the queue telemetry and returns suggested queue settings.
+func (s *PlainQ) adviseQueue(ctx context.Context, queueID string) (*v1.AdviseQueueResponse, error) {
+	props, describeErr := s.storage.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID})
+	if describeErr != nil {
+		return nil, fmt.Errorf("describe queue: %w", describeErr)
+	}
+
+	retries, retriesErr := s.storage.RetriesDistribution(ctx, queueID)
+	if retriesErr != nil {
+		return nil, fmt.Errorf("get retries distribution: %w", retriesErr)
+	}
+
+	in := advisor.Input{
+		Props:            props,
+		ReceiveRequests:  s.observer.ReceiveRequests(queueID).Get(),
+		EmptyReceives:    s.observer.EmptyReceives(queueID).Get(),
+		MessagesReceived: s.observer.MessagesReceived(queueID).Get(),
+		MessagesDropped: s.observer.MessageDropped(queueID, v1.EvictionPolicy_EVICTION_POLICY_DROP).Get() +
+			s.observer.MessageDropped(queueID, v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER).Get(),
+		Retries:     retries,
+		TimeInQueue: time.Duration(s.observer.TimeInQueue(queueID).Quantile(0.9) * float64(time.Second)),
+	}
+
+	output := v1.AdviseQueueResponse{
+		Suggestions: advisor.Advise(in),
+	}
+
+	return &output, nil
+}
@@ -0,0 +1,214 @@
+// Package advisor analyzes queue telemetry and suggests queue settings.
+package advisor
+
+import (
+	"strconv"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+const (
+	// minReceiveSamples represents the minimal amount of receive requests
+	// required to make suggestions based on receive statistics.
+	minReceiveSamples = 100
+
+	// minMessageSamples represents the minimal amount of messages
+	// required to make suggestions based on messages statistics.
+	minMessageSamples = 20
+
+	// maxBatchSize represents the maximum batch size supported by the server.
+	maxBatchSize = 10
+
+	// redeliveryThreshold represents the share of messages received more than once,
+	// after which the visibility timeout considered too short.
+	redeliveryThreshold = 0.1
+
+	// dropThreshold represents the share of received messages which have
+	// been dropped, after which the max receive attempts considered too low.
+	dropThreshold = 0.05
+
+	// emptyReceivesHigh and emptyReceivesLow represent the shares of empty receives
+	// which indicate that consumers poll too often or can't keep up with producers.
+	emptyReceivesHigh = 0.8
+	emptyReceivesLow  = 0.1
+
+	// retentionThreshold represents the part of retention period which, when reached
+	// by the time messages spend in the queue, means that messages risk to expire.
+	retentionThreshold = 0.5
+)
+
+// Settings names.
+const (
+	SettingVisibilityTimeout  = "visibility_timeout_seconds"
+	SettingMaxReceiveAttempts = "max_receive_attempts"
+	SettingRetentionPeriod    = "retention_period_seconds"
+	SettingEvictionPolicy     = "eviction_policy"
+	SettingBatchSize          = "batch_size"
+	SettingPollInterval       = "poll_interval"
+)
+
+// Input holds the telemetry of a single queue.
+type Input struct {
+	// Props holds current queue properties.
+	Props *v1.DescribeQueueResponse
+
+	// ReceiveRequests is the amount of receive requests including empty ones.
+	ReceiveRequests uint64
+
+	// EmptyReceives is the amount of receive requests which returned no messages.
+	EmptyReceives uint64
+
+	// MessagesReceived is the amount of received messages.
+	MessagesReceived uint64
+
+	// MessagesDropped is the amount of messages evicted by the queue.
+	MessagesDropped uint64
+
+	// Retries holds the number of stored messages grouped by receive attempts.
+	Retries map[uint32]uint64
+
+	// TimeInQueue is the 90th percentile of time messages spend in the queue.
+	TimeInQueue time.Duration
+}
+
+// Advise returns the list of suggested queue settings changes based on the input.
+func Advise(in Input) []*v1.QueueSuggestion {
+	suggestions := make([]*v1.QueueSuggestion, 0, 4)
+
+	if s := adviseVisibilityTimeout(in); s != nil {
+		suggestions = append(suggestions, s)
+	}
+
+	suggestions = append(suggestions, adviseMaxReceiveAttempts(in)...)
+
+	if s := adviseBatchSize(in); s != nil {
+		suggestions = append(suggestions, s)
+	}
+
+	if s := adviseRetentionPeriod(in); s != nil {
+		suggestions = append(suggestions, s)
+	}
+
+	return suggestions
+}
+
+// adviseVisibilityTimeout suggests to increase the visibility timeout when many
+// stored messages have been received more than once, which usually means
+// that processing takes longer than the message stays invisible.
+func adviseVisibilityTimeout(in Input) *v1.QueueSuggestion {
+	var total, redelivered uint64
+
+	for retries, count := range in.Retries {
+		total += count
+
+		if retries > 1 {
+			redelivered += count
+		}
+	}
+
+	if total < minMessageSamples || float64(redelivered)/float64(total) < redeliveryThreshold {
+		return nil
+	}
+
+	current := in.Props.GetVisibilityTimeoutSeconds()
+
+	return &v1.QueueSuggestion{
+		Setting:   SettingVisibilityTimeout,
+		Current:   strconv.FormatUint(current, 10),
+		Suggested: strconv.FormatUint(max(current*2, 1), 10),
+		Reason: strconv.FormatUint(redelivered, 10) + " of " + strconv.FormatUint(total, 10) +
+			" stored messages have been received more than once; processing likely exceeds the visibility timeout",
+	}
+}
+
+// adviseMaxReceiveAttempts suggests to increase max receive attempts or to
+// configure dead letter queue when a noticeable share of messages is dropped.
+func adviseMaxReceiveAttempts(in Input) []*v1.QueueSuggestion {
+	if in.MessagesReceived < minReceiveSamples {
+		return nil
+	}
+
+	share := float64(in.MessagesDropped) / float64(in.MessagesReceived)
+	if share < dropThreshold {
+		return nil
+	}
+
+	reason := strconv.FormatFloat(share*100, 'f', 1, 64) + "% of received messages have been evicted"
+	current := in.Props.GetMaxReceiveAttempts()
+
+	suggestions := []*v1.QueueSuggestion{{
+		Setting:   SettingMaxReceiveAttempts,
+		Current:   strconv.FormatUint(uint64(current), 10),
+		Suggested: strconv.FormatUint(uint64(current)+2, 10),
+		Reason:    reason + "; transient failures may need more attempts",
+	}}
+
+	if in.Props.GetEvictionPolicy() != v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER {
+		suggestions = append(suggestions, &v1.QueueSuggestion{
+			Setting:   SettingEvictionPolicy,
+			Current:   in.Props.GetEvictionPolicy().String(),
+			Suggested: v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER.String(),
+			Reason:    reason + "; a dead letter queue keeps them for inspection",
+		})
+	}
+
+	return suggestions
+}
+
+// adviseBatchSize suggests consumers behaviour based on the ratio of empty
+// receives and the average number of messages per non-empty receive.
+func adviseBatchSize(in Input) *v1.QueueSuggestion {
+	if in.ReceiveRequests < minReceiveSamples || in.EmptyReceives > in.ReceiveRequests {
+		return nil
+	}
+
+	emptyRatio := float64(in.EmptyReceives) / float64(in.ReceiveRequests)
+	percent := strconv.FormatFloat(emptyRatio*100, 'f', 1, 64) + "%"
+
+	if emptyRatio >= emptyReceivesHigh {
+		return &v1.QueueSuggestion{
+			Setting:   SettingPollInterval,
+			Current:   "",
+			Suggested: "increase",
+			Reason:    percent + " of receives are empty; consumers poll more often than messages arrive",
+		}
+	}
+
+	nonEmpty := in.ReceiveRequests - in.EmptyReceives
+	if emptyRatio > emptyReceivesLow || nonEmpty == 0 {
+		return nil
+	}
+
+	avg := float64(in.MessagesReceived) / float64(nonEmpty)
+	if avg >= maxBatchSize/2 {
+		return nil
+	}
+
+	return &v1.QueueSuggestion{
+		Setting:   SettingBatchSize,
+		Current:   strconv.FormatFloat(avg, 'f', 1, 64),
+		Suggested: strconv.Itoa(maxBatchSize),
+		Reason:    "only " + percent + " of receives are empty, but consumers receive few messages per request",
+	}
+}
+
+// adviseRetentionPeriod suggests to increase the retention period when
+// messages spend in the queue a significant part of it.
+func adviseRetentionPeriod(in Input) *v1.QueueSuggestion {
+	current := in.Props.GetRetentionPeriodSeconds()
+	if current == 0 || in.TimeInQueue <= 0 {
+		return nil
+	}
+
+	if in.TimeInQueue.Seconds() < float64(current)*retentionThreshold {
+		return nil
+	}
+
+	return &v1.QueueSuggestion{
+		Setting:   SettingRetentionPeriod,
+		Current:   strconv.FormatUint(current, 10),
+		Suggested: strconv.FormatUint(current*2, 10),
+		Reason:    "90% of messages spend up to " + in.TimeInQueue.Round(time.Second).String() + " in the queue; messages risk to expire",
+	}
+}
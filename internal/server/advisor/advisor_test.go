@@ -0,0 +1,63 @@
+package advisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+func TestAdvise(t *testing.T) {
+	props := v1.DescribeQueueResponse{
+		RetentionPeriodSeconds:   3600,
+		VisibilityTimeoutSeconds: 30,
+		MaxReceiveAttempts:       5,
+		EvictionPolicy:           v1.EvictionPolicy_EVICTION_POLICY_DROP,
+	}
+
+	type tcase struct {
+		in   Input
+		want []string
+	}
+
+	tests := map[string]tcase{
+		"NoData": {
+			in:   Input{Props: &props},
+			want: []string{},
+		},
+		"Redeliveries": {
+			in:   Input{Props: &props, Retries: map[uint32]uint64{0: 10, 1: 10, 2: 5}},
+			want: []string{SettingVisibilityTimeout},
+		},
+		"Drops": {
+			in:   Input{Props: &props, MessagesReceived: 1000, MessagesDropped: 100},
+			want: []string{SettingMaxReceiveAttempts, SettingEvictionPolicy},
+		},
+		"EmptyReceives": {
+			in:   Input{Props: &props, ReceiveRequests: 1000, EmptyReceives: 900},
+			want: []string{SettingPollInterval},
+		},
+		"SmallBatches": {
+			in:   Input{Props: &props, ReceiveRequests: 1000, EmptyReceives: 10, MessagesReceived: 990},
+			want: []string{SettingBatchSize},
+		},
+		"LongTimeInQueue": {
+			in:   Input{Props: &props, TimeInQueue: 45 * time.Minute},
+			want: []string{SettingRetentionPeriod},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := Advise(tc.in)
+
+			settings := make([]string, 0, len(got))
+			for _, s := range got {
+				settings = append(settings, s.GetSetting())
+			}
+
+			td.Cmp(t, settings, tc.want)
+		})
+	}
+}
@@ -0,0 +1,327 @@
+// Package alerting implements the engine which evaluates threshold rules over
+// observed metrics, e.g. the depth of the queue above N for 5 minutes, and
+// delivers notifications of firing and resolved alerts by webhook or email.
+package alerting
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/servekit/errkit"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Counters the EmptyReceiveRatio is derived from.
+const (
+	emptyReceives   = "empty_receives_total"
+	receiveRequests = "receive_requests_total"
+)
+
+// Config holds the configuration of the Engine.
+type Config struct {
+	// Interval is the time between evaluations of rules.
+	Interval time.Duration
+
+	// WebhookTimeout limits the time of delivery of a webhook notification.
+	WebhookTimeout time.Duration
+
+	// SMTP is the mail server email notifications are sent with.
+	SMTP SMTPConfig
+}
+
+// Rules lists alert rules.
+type Rules interface {
+	ListAlertRules(ctx context.Context, input *v1.ListAlertRulesRequest) (*v1.ListAlertRulesResponse, error)
+}
+
+// Engine evaluates alert rules on the interval and keeps states of their alerts.
+type Engine struct {
+	cfg    Config
+	rules  Rules
+	logger *slog.Logger
+
+	client   *http.Client
+	sendMail sendMailFunc
+
+	// snapshot returns current values of observed metrics.
+	snapshot func() ([]telemetry.Sample, error)
+
+	mu     sync.Mutex
+	alerts map[string]*v1.Alert
+
+	// versions holds update times of rules the alerts have been evaluated for,
+	// so the state of the alert starts over when its rule is updated.
+	versions map[string]time.Time
+
+	// prev holds values of the previous evaluation, which rates of counters are calculated from.
+	prev   map[seriesKey]float64
+	prevAt time.Time
+}
+
+// New returns a pointer to a new instance of Engine.
+func New(cfg Config, rules Rules, logger *slog.Logger) (*Engine, error) {
+	if cfg.Interval <= 0 {
+		return nil, fmt.Errorf("%w: alerting interval should be positive: %s", errkit.ErrInvalidArgument, cfg.Interval)
+	}
+
+	e := Engine{
+		cfg:      cfg,
+		rules:    rules,
+		logger:   logger,
+		client:   &http.Client{Timeout: cfg.WebhookTimeout},
+		sendMail: smtpSendMail,
+		snapshot: telemetry.Snapshot,
+		alerts:   make(map[string]*v1.Alert),
+		versions: make(map[string]time.Time),
+	}
+
+	return &e, nil
+}
+
+// Run evaluates rules on the interval until the context is canceled.
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if err := e.evaluate(ctx, time.Now().UTC()); err != nil {
+				e.logger.Error("Failed to evaluate alert rules",
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
+
+// Alerts returns current states of alert rules ordered by rule name.
+// Rules which have not been evaluated yet are not listed.
+func (e *Engine) Alerts() *v1.ListAlertsResponse {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	output := v1.ListAlertsResponse{Alerts: make([]*v1.Alert, 0, len(e.alerts))}
+
+	for _, a := range e.alerts {
+		output.Alerts = append(output.Alerts, proto.Clone(a).(*v1.Alert))
+	}
+
+	slices.SortFunc(output.Alerts, func(a, b *v1.Alert) int {
+		return cmp.Or(cmp.Compare(a.GetRuleName(), b.GetRuleName()), cmp.Compare(a.GetRuleId(), b.GetRuleId()))
+	})
+
+	return &output
+}
+
+// evaluate evaluates all rules against current values of metrics
+// and notifies about alerts which have fired or resolved.
+func (e *Engine) evaluate(ctx context.Context, now time.Time) error {
+	list, listErr := e.rules.ListAlertRules(ctx, &v1.ListAlertRulesRequest{})
+	if listErr != nil {
+		return listErr
+	}
+
+	samples, snapshotErr := e.snapshot()
+	if snapshotErr != nil {
+		return snapshotErr
+	}
+
+	e.mu.Lock()
+
+	cur := aggregate(samples)
+	values := derive(e.prev, cur, now.Sub(e.prevAt))
+	e.prev, e.prevAt = cur, now
+
+	var notifications []notification
+
+	seen := make(map[string]struct{}, len(list.GetRules()))
+
+	for _, rule := range list.GetRules() {
+		seen[rule.GetRuleId()] = struct{}{}
+
+		value, ok := ruleValue(values, rule)
+		if !ok {
+			continue
+		}
+
+		alert, exists := e.alerts[rule.GetRuleId()]
+		if !exists || !e.versions[rule.GetRuleId()].Equal(rule.GetUpdatedAt().AsTime()) {
+			alert = &v1.Alert{
+				RuleId: rule.GetRuleId(),
+				State:  v1.AlertState_ALERT_STATE_OK,
+				Since:  timestamppb.New(now),
+			}
+
+			e.alerts[rule.GetRuleId()] = alert
+			e.versions[rule.GetRuleId()] = rule.GetUpdatedAt().AsTime()
+		}
+
+		alert.RuleName = rule.GetName()
+		alert.Value = value
+		alert.EvaluatedAt = timestamppb.New(now)
+
+		if transition(alert, rule, breached(rule, value), now) {
+			notifications = append(notifications, notification{
+				rule:  rule,
+				alert: proto.Clone(alert).(*v1.Alert),
+			})
+		}
+	}
+
+	// States of deleted rules are forgotten.
+	for id := range e.alerts {
+		if _, ok := seen[id]; !ok {
+			delete(e.alerts, id)
+			delete(e.versions, id)
+		}
+	}
+
+	e.mu.Unlock()
+
+	for _, n := range notifications {
+		e.notify(ctx, n)
+	}
+
+	return nil
+}
+
+// transition moves the alert to the next state according to whether the condition
+// of the rule is met, and reports whether the alert has fired or resolved.
+func transition(alert *v1.Alert, rule *v1.AlertRule, breach bool, now time.Time) bool {
+	hold := time.Duration(rule.GetForSeconds()) * time.Second
+
+	set := func(state v1.AlertState) {
+		alert.State = state
+		alert.Since = timestamppb.New(now)
+	}
+
+	switch {
+	case breach && alert.GetState() == v1.AlertState_ALERT_STATE_OK:
+		if hold > 0 {
+			set(v1.AlertState_ALERT_STATE_PENDING)
+			return false
+		}
+
+		set(v1.AlertState_ALERT_STATE_FIRING)
+
+		return true
+
+	case breach && alert.GetState() == v1.AlertState_ALERT_STATE_PENDING:
+		if now.Sub(alert.GetSince().AsTime()) < hold {
+			return false
+		}
+
+		set(v1.AlertState_ALERT_STATE_FIRING)
+
+		return true
+
+	case !breach && alert.GetState() == v1.AlertState_ALERT_STATE_PENDING:
+		set(v1.AlertState_ALERT_STATE_OK)
+
+	case !breach && alert.GetState() == v1.AlertState_ALERT_STATE_FIRING:
+		set(v1.AlertState_ALERT_STATE_OK)
+
+		return true
+	}
+
+	return false
+}
+
+// seriesKey identifies the aggregated value of the metric of the queue,
+// or over all series of the metric when the queue is empty.
+type seriesKey struct{ metric, queueID string }
+
+// aggregate sums values of samples per metric of each queue and per metric over all series.
+func aggregate(samples []telemetry.Sample) map[seriesKey]float64 {
+	values := make(map[seriesKey]float64, len(samples))
+
+	for _, s := range samples {
+		if s.QueueID != "" {
+			values[seriesKey{metric: s.Name, queueID: s.QueueID}] += s.Value
+		}
+
+		values[seriesKey{metric: s.Name}] += s.Value
+	}
+
+	return values
+}
+
+// derive returns values rules are evaluated against. Gauges keep their values,
+// counters are converted to per-second rates since the previous evaluation, and
+// the EmptyReceiveRatio is derived from increases of receive counters. Counters
+// have no values until there is the previous evaluation.
+func derive(prev, cur map[seriesKey]float64, elapsed time.Duration) map[seriesKey]float64 {
+	values := make(map[seriesKey]float64, len(cur))
+
+	for k, v := range cur {
+		if !isCounter(k.metric) {
+			values[k] = v
+			continue
+		}
+
+		if prev == nil || elapsed <= 0 {
+			continue
+		}
+
+		values[k] = increase(prev[k], v) / elapsed.Seconds()
+
+		if k.metric == receiveRequests {
+			ratio := seriesKey{metric: EmptyReceiveRatio, queueID: k.queueID}
+			empty := seriesKey{metric: emptyReceives, queueID: k.queueID}
+
+			if requests := increase(prev[k], v); requests > 0 {
+				values[ratio] = increase(prev[empty], cur[empty]) / requests
+			} else {
+				values[ratio] = 0
+			}
+		}
+	}
+
+	return values
+}
+
+// increase returns the increase of the counter, which starts
+// over from zero when the counter has been reset.
+func increase(prev, cur float64) float64 {
+	if cur < prev {
+		return cur
+	}
+
+	return cur - prev
+}
+
+// ruleValue returns the value of the metric of the rule. Series which are not
+// observed yet, e.g. of the queue which has not been used, have zero values.
+// Counters and derived metrics have no values at the first evaluation.
+func ruleValue(values map[seriesKey]float64, rule *v1.AlertRule) (float64, bool) {
+	if v, ok := values[seriesKey{metric: rule.GetMetric(), queueID: rule.GetQueueId()}]; ok {
+		return v, true
+	}
+
+	if isCounter(rule.GetMetric()) || rule.GetMetric() == EmptyReceiveRatio {
+		// Counters have values once there is the previous evaluation,
+		// which is told by the rate of any observed counter.
+		for k := range values {
+			if isCounter(k.metric) {
+				return 0, true
+			}
+		}
+
+		return 0, false
+	}
+
+	return 0, true
+}
@@ -0,0 +1,195 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/logkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type fakeRules struct {
+	rules []*v1.AlertRule
+}
+
+func (f *fakeRules) ListAlertRules(context.Context, *v1.ListAlertRulesRequest) (*v1.ListAlertRulesResponse, error) {
+	return &v1.ListAlertRulesResponse{Rules: f.rules}, nil
+}
+
+func TestDerive(t *testing.T) {
+	prev := map[seriesKey]float64{
+		{metric: "receive_requests_total", queueID: "q1"}: 100,
+		{metric: "empty_receives_total", queueID: "q1"}:   40,
+		{metric: "messages_sent_total", queueID: "q1"}:    50,
+	}
+
+	cur := map[seriesKey]float64{
+		{metric: "receive_requests_total", queueID: "q1"}: 120,
+		{metric: "empty_receives_total", queueID: "q1"}:   55,
+		{metric: "messages_sent_total", queueID: "q1"}:    10,
+		{metric: "messages_visible", queueID: "q1"}:       7,
+	}
+
+	td.Cmp(t, derive(nil, cur, 0), map[seriesKey]float64{
+		{metric: "messages_visible", queueID: "q1"}: 7,
+	})
+
+	td.Cmp(t, derive(prev, cur, 10*time.Second), map[seriesKey]float64{
+		{metric: "receive_requests_total", queueID: "q1"}: 2,
+		{metric: "empty_receives_total", queueID: "q1"}:   1.5,
+		{metric: "messages_sent_total", queueID: "q1"}:    1, // Reset counter.
+		{metric: "messages_visible", queueID: "q1"}:       7,
+		{metric: EmptyReceiveRatio, queueID: "q1"}:        0.75,
+	})
+}
+
+func TestAggregate(t *testing.T) {
+	samples := []telemetry.Sample{
+		{Name: "messages_visible", QueueID: "q1", Value: 3},
+		{Name: "messages_visible", QueueID: "q2", Value: 4},
+		{Name: "rate_limited_total", Labels: telemetry.Labels{{Key: "scope", Value: "client"}}, Value: 5},
+		{Name: "rate_limited_total", Labels: telemetry.Labels{{Key: "scope", Value: "queue"}}, Value: 6},
+	}
+
+	td.Cmp(t, aggregate(samples), map[seriesKey]float64{
+		{metric: "messages_visible", queueID: "q1"}: 3,
+		{metric: "messages_visible", queueID: "q2"}: 4,
+		{metric: "messages_visible"}:                7,
+		{metric: "rate_limited_total"}:              11,
+	})
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{}, &fakeRules{}, logkit.NewNop())
+	td.Cmp(t, err, td.ErrorIs(errkit.ErrInvalidArgument))
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	var (
+		depth    float64
+		webhooks []string
+		mails    []string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Status string `json:"status"`
+		}
+
+		td.CmpNoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		webhooks = append(webhooks, payload.Status)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	rule := v1.AlertRule{
+		RuleId:     "r1",
+		Name:       "orders backlog",
+		Metric:     "messages_visible",
+		QueueId:    "q1",
+		Operator:   v1.AlertOperator_ALERT_OPERATOR_GT,
+		Threshold:  100,
+		ForSeconds: 300,
+		WebhookUrl: srv.URL,
+		Email:      "ops@example.com",
+		UpdatedAt:  timestamppb.New(time.Unix(1, 0)),
+	}
+
+	rules := fakeRules{rules: []*v1.AlertRule{&rule}}
+
+	engine, newErr := New(Config{Interval: time.Minute, WebhookTimeout: time.Second, SMTP: SMTPConfig{Addr: "smtp.example.com:25", From: "plainq@example.com"}}, &rules, logkit.NewNop())
+	td.CmpNoError(t, newErr)
+
+	engine.snapshot = func() ([]telemetry.Sample, error) {
+		return []telemetry.Sample{{Name: "messages_visible", QueueID: "q1", Value: depth}}, nil
+	}
+	engine.sendMail = func(_ string, _ smtp.Auth, _ string, to []string, _ []byte) error {
+		mails = append(mails, to...)
+		return nil
+	}
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	step := func(at time.Duration, value float64, want v1.AlertState) {
+		t.Helper()
+
+		depth = value
+		td.CmpNoError(t, engine.evaluate(context.Background(), start.Add(at)))
+
+		alerts := engine.Alerts().GetAlerts()
+		td.Cmp(t, len(alerts), 1)
+		td.Cmp(t, alerts[0].GetState(), want)
+		td.Cmp(t, alerts[0].GetValue(), value)
+	}
+
+	step(0, 10, v1.AlertState_ALERT_STATE_OK)
+	step(time.Minute, 150, v1.AlertState_ALERT_STATE_PENDING)
+	step(2*time.Minute, 50, v1.AlertState_ALERT_STATE_OK)
+	step(3*time.Minute, 150, v1.AlertState_ALERT_STATE_PENDING)
+	step(7*time.Minute, 150, v1.AlertState_ALERT_STATE_PENDING)
+	td.Cmp(t, webhooks, []string(nil))
+
+	step(8*time.Minute, 200, v1.AlertState_ALERT_STATE_FIRING)
+	step(9*time.Minute, 200, v1.AlertState_ALERT_STATE_FIRING)
+	td.Cmp(t, webhooks, []string{statusFiring})
+
+	step(10*time.Minute, 20, v1.AlertState_ALERT_STATE_OK)
+	td.Cmp(t, webhooks, []string{statusFiring, statusResolved})
+	td.Cmp(t, mails, []string{"ops@example.com", "ops@example.com"})
+
+	// Updated rule starts over.
+	step(11*time.Minute, 200, v1.AlertState_ALERT_STATE_PENDING)
+	rule.ForSeconds = 0
+	rule.UpdatedAt = timestamppb.New(time.Unix(2, 0))
+	step(12*time.Minute, 200, v1.AlertState_ALERT_STATE_FIRING)
+
+	// Deleted rule is forgotten.
+	rules.rules = nil
+	td.CmpNoError(t, engine.evaluate(context.Background(), start.Add(13*time.Minute)))
+	td.CmpEmpty(t, engine.Alerts().GetAlerts())
+}
+
+func TestEngine_EvaluateCounter(t *testing.T) {
+	var requests, empty float64
+
+	rules := fakeRules{rules: []*v1.AlertRule{{
+		RuleId:    "r1",
+		Name:      "idle consumers",
+		Metric:    EmptyReceiveRatio,
+		Operator:  v1.AlertOperator_ALERT_OPERATOR_GT,
+		Threshold: 0.5,
+	}}}
+
+	engine, newErr := New(Config{Interval: time.Minute}, &rules, logkit.NewNop())
+	td.CmpNoError(t, newErr)
+
+	engine.snapshot = func() ([]telemetry.Sample, error) {
+		return []telemetry.Sample{
+			{Name: "receive_requests_total", QueueID: "q1", Value: requests},
+			{Name: "empty_receives_total", QueueID: "q1", Value: empty},
+		}, nil
+	}
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	requests, empty = 10, 0
+	td.CmpNoError(t, engine.evaluate(context.Background(), start))
+	td.CmpEmpty(t, engine.Alerts().GetAlerts())
+
+	requests, empty = 20, 8
+	td.CmpNoError(t, engine.evaluate(context.Background(), start.Add(time.Minute)))
+
+	alerts := engine.Alerts().GetAlerts()
+	td.Cmp(t, len(alerts), 1)
+	td.Cmp(t, alerts[0].GetState(), v1.AlertState_ALERT_STATE_FIRING)
+	td.Cmp(t, alerts[0].GetValue(), 0.8)
+}
@@ -0,0 +1,202 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+)
+
+// Statuses of notifications.
+const (
+	statusFiring   = "firing"
+	statusResolved = "resolved"
+)
+
+// SMTPConfig holds the configuration of the mail server.
+type SMTPConfig struct {
+	// Addr is the host:port address of the mail server.
+	// Email notifications are not sent when it's empty.
+	Addr string
+
+	// From is the sender address.
+	From string
+
+	// Username and Password authenticate the sender when the username is set.
+	Username string
+	Password string
+}
+
+// sendMailFunc sends the message, see smtp.SendMail.
+type sendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+var smtpSendMail sendMailFunc = smtp.SendMail
+
+// notification of the alert which has fired or resolved.
+type notification struct {
+	rule  *v1.AlertRule
+	alert *v1.Alert
+}
+
+// status returns the status of the notification.
+func (n notification) status() string {
+	if n.alert.GetState() == v1.AlertState_ALERT_STATE_FIRING {
+		return statusFiring
+	}
+
+	return statusResolved
+}
+
+// webhookPayload is the body of webhook requests.
+type webhookPayload struct {
+	Status string          `json:"status"`
+	Rule   json.RawMessage `json:"rule"`
+	Alert  json.RawMessage `json:"alert"`
+}
+
+// notify delivers the notification to receivers of the rule. Failures are logged.
+func (e *Engine) notify(ctx context.Context, n notification) {
+	logger := e.logger.With(
+		slog.String("rule_id", n.rule.GetRuleId()),
+		slog.String("status", n.status()),
+	)
+
+	logger.Info("Alert " + n.status())
+
+	if n.rule.GetWebhookUrl() != "" {
+		if err := e.sendWebhook(ctx, n); err != nil {
+			logger.Error("Failed to deliver alert webhook",
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	if n.rule.GetEmail() != "" {
+		if err := e.sendEmail(n); err != nil {
+			logger.Error("Failed to deliver alert email",
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// sendWebhook posts the notification as JSON to the webhook of the rule.
+func (e *Engine) sendWebhook(ctx context.Context, n notification) error {
+	rule, ruleErr := pqjson.Marshal(n.rule)
+	if ruleErr != nil {
+		return fmt.Errorf("marshal rule: %w", ruleErr)
+	}
+
+	alert, alertErr := pqjson.Marshal(n.alert)
+	if alertErr != nil {
+		return fmt.Errorf("marshal alert: %w", alertErr)
+	}
+
+	body, marshalErr := json.Marshal(webhookPayload{Status: n.status(), Rule: rule, Alert: alert})
+	if marshalErr != nil {
+		return fmt.Errorf("marshal payload: %w", marshalErr)
+	}
+
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodPost, n.rule.GetWebhookUrl(), bytes.NewReader(body))
+	if reqErr != nil {
+		return fmt.Errorf("create request: %w", reqErr)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, doErr := e.client.Do(req)
+	if doErr != nil {
+		return fmt.Errorf("send request: %w", doErr)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// sendEmail sends the notification by email to the address of the rule.
+func (e *Engine) sendEmail(n notification) error {
+	smtpCfg := e.cfg.SMTP
+
+	if smtpCfg.Addr == "" {
+		return errors.New("smtp server is not configured")
+	}
+
+	var auth smtp.Auth
+
+	if smtpCfg.Username != "" {
+		host, _, splitErr := net.SplitHostPort(smtpCfg.Addr)
+		if splitErr != nil {
+			return fmt.Errorf("parse smtp address: %w", splitErr)
+		}
+
+		auth = smtp.PlainAuth("", smtpCfg.Username, smtpCfg.Password, host)
+	}
+
+	if err := e.sendMail(smtpCfg.Addr, auth, smtpCfg.From, []string{n.rule.GetEmail()}, emailMessage(smtpCfg.From, n)); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+
+	return nil
+}
+
+// emailMessage returns the RFC 5322 message of the notification.
+func emailMessage(from string, n notification) []byte {
+	subject := fmt.Sprintf("[PlainQ] Alert %s: %s", n.status(), n.rule.GetName())
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", n.rule.GetEmail())
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+
+	fmt.Fprintf(&b, "Rule: %s (%s)\r\n", n.rule.GetName(), n.rule.GetRuleId())
+	fmt.Fprintf(&b, "Condition: %s %s %g\r\n", n.rule.GetMetric(), operatorSign(n.rule.GetOperator()), n.rule.GetThreshold())
+
+	if n.rule.GetQueueId() != "" {
+		fmt.Fprintf(&b, "Queue: %s\r\n", n.rule.GetQueueId())
+	}
+
+	fmt.Fprintf(&b, "Value: %g\r\n", n.alert.GetValue())
+	fmt.Fprintf(&b, "Since: %s\r\n", n.alert.GetSince().AsTime().Format(time.RFC3339))
+
+	return []byte(b.String())
+}
+
+// operatorSign returns the sign of the operator.
+func operatorSign(op v1.AlertOperator) string {
+	switch op {
+	case v1.AlertOperator_ALERT_OPERATOR_GT:
+		return ">"
+
+	case v1.AlertOperator_ALERT_OPERATOR_GTE:
+		return ">="
+
+	case v1.AlertOperator_ALERT_OPERATOR_LT:
+		return "<"
+
+	case v1.AlertOperator_ALERT_OPERATOR_LTE:
+		return "<="
+
+	default:
+		return "?"
+	}
+}
@@ -0,0 +1,112 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+)
+
+// EmptyReceiveRatio is the metric derived from observed ones, which is the ratio
+// of empty receives to receive requests over the evaluation interval.
+const EmptyReceiveRatio = "empty_receive_ratio"
+
+// maxForSeconds limits the duration the condition of the rule should be met for.
+const maxForSeconds = 7 * 24 * 60 * 60
+
+// ValidateRule validates the rule created or updated by the client.
+func ValidateRule(rule *v1.AlertRule) error {
+	if rule == nil {
+		return fmt.Errorf("%w: rule should be specified", errkit.ErrInvalidArgument)
+	}
+
+	if strings.TrimSpace(rule.GetName()) == "" {
+		return fmt.Errorf("%w: rule name should be specified", errkit.ErrInvalidArgument)
+	}
+
+	if err := validateMetric(rule.GetMetric()); err != nil {
+		return fmt.Errorf("%w: %w", errkit.ErrInvalidArgument, err)
+	}
+
+	if rule.GetQueueId() != "" {
+		if err := idkit.ValidateXID(strings.ToLower(rule.GetQueueId())); err != nil {
+			return fmt.Errorf("%w: invalid queue id %q", errkit.ErrInvalidArgument, rule.GetQueueId())
+		}
+	}
+
+	if _, ok := v1.AlertOperator_name[int32(rule.GetOperator())]; !ok || rule.GetOperator() == v1.AlertOperator_ALERT_OPERATOR_UNSPECIFIED {
+		return fmt.Errorf("%w: rule operator should be specified", errkit.ErrInvalidArgument)
+	}
+
+	if rule.GetForSeconds() > maxForSeconds {
+		return fmt.Errorf("%w: rule duration should not exceed %d seconds", errkit.ErrInvalidArgument, maxForSeconds)
+	}
+
+	if u := rule.GetWebhookUrl(); u != "" {
+		parsed, parseErr := url.Parse(u)
+		if parseErr != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%w: invalid webhook url %q", errkit.ErrInvalidArgument, u)
+		}
+	}
+
+	if e := rule.GetEmail(); e != "" {
+		if _, err := mail.ParseAddress(e); err != nil {
+			return fmt.Errorf("%w: invalid email %q", errkit.ErrInvalidArgument, e)
+		}
+	}
+
+	return nil
+}
+
+// validateMetric checks whether the metric can be evaluated. Histograms
+// can't, since only counters and gauges have a single value per series.
+func validateMetric(metric string) error {
+	if metric == EmptyReceiveRatio {
+		return nil
+	}
+
+	observable, _ := telemetry.Observable(context.Background(), metric)
+
+	switch {
+	case metric == "":
+		return errors.New("rule metric should be specified")
+
+	case !observable:
+		return fmt.Errorf("unknown metric %q", metric)
+
+	case strings.HasSuffix(metric, "_duration"):
+		return fmt.Errorf("histogram metric %q is not supported", metric)
+	}
+
+	return nil
+}
+
+// isCounter reports whether the metric is a counter, which is evaluated as a per-second rate.
+func isCounter(metric string) bool { return strings.HasSuffix(metric, "_total") }
+
+// breached reports whether the value meets the condition of the rule.
+func breached(rule *v1.AlertRule, value float64) bool {
+	switch rule.GetOperator() {
+	case v1.AlertOperator_ALERT_OPERATOR_GT:
+		return value > rule.GetThreshold()
+
+	case v1.AlertOperator_ALERT_OPERATOR_GTE:
+		return value >= rule.GetThreshold()
+
+	case v1.AlertOperator_ALERT_OPERATOR_LT:
+		return value < rule.GetThreshold()
+
+	case v1.AlertOperator_ALERT_OPERATOR_LTE:
+		return value <= rule.GetThreshold()
+
+	default:
+		return false
+	}
+}
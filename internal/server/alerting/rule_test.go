@@ -0,0 +1,91 @@
+package alerting
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+func TestValidateRule(t *testing.T) {
+	type tcase struct {
+		rule    *v1.AlertRule
+		wantErr bool
+	}
+
+	valid := func(modify func(r *v1.AlertRule)) *v1.AlertRule {
+		r := v1.AlertRule{
+			Name:       "orders backlog",
+			Metric:     "messages_visible",
+			QueueId:    "cn3qa1m2fq1s70e6e0d0",
+			Operator:   v1.AlertOperator_ALERT_OPERATOR_GT,
+			Threshold:  1000,
+			ForSeconds: 300,
+			WebhookUrl: "https://hooks.example.com/plainq",
+			Email:      "ops@example.com",
+		}
+
+		if modify != nil {
+			modify(&r)
+		}
+
+		return &r
+	}
+
+	tests := map[string]tcase{
+		"Valid":             {rule: valid(nil)},
+		"EmptyReceiveRatio": {rule: valid(func(r *v1.AlertRule) { r.Metric = EmptyReceiveRatio })},
+		"Counter":           {rule: valid(func(r *v1.AlertRule) { r.Metric = "messages_sent_total" })},
+		"AllQueues":         {rule: valid(func(r *v1.AlertRule) { r.QueueId = "" })},
+		"NoReceivers":       {rule: valid(func(r *v1.AlertRule) { r.WebhookUrl, r.Email = "", "" })},
+		"Nil":               {rule: nil, wantErr: true},
+		"NoName":            {rule: valid(func(r *v1.AlertRule) { r.Name = " " }), wantErr: true},
+		"NoMetric":          {rule: valid(func(r *v1.AlertRule) { r.Metric = "" }), wantErr: true},
+		"UnknownMetric":     {rule: valid(func(r *v1.AlertRule) { r.Metric = "messages_lost" }), wantErr: true},
+		"Histogram":         {rule: valid(func(r *v1.AlertRule) { r.Metric = "gc_duration" }), wantErr: true},
+		"InvalidQueue":      {rule: valid(func(r *v1.AlertRule) { r.QueueId = "orders" }), wantErr: true},
+		"NoOperator":        {rule: valid(func(r *v1.AlertRule) { r.Operator = v1.AlertOperator_ALERT_OPERATOR_UNSPECIFIED }), wantErr: true},
+		"UnknownOperator":   {rule: valid(func(r *v1.AlertRule) { r.Operator = 42 }), wantErr: true},
+		"LongDuration":      {rule: valid(func(r *v1.AlertRule) { r.ForSeconds = maxForSeconds + 1 }), wantErr: true},
+		"WebhookScheme":     {rule: valid(func(r *v1.AlertRule) { r.WebhookUrl = "ftp://hooks.example.com" }), wantErr: true},
+		"WebhookNoHost":     {rule: valid(func(r *v1.AlertRule) { r.WebhookUrl = "https://" }), wantErr: true},
+		"InvalidEmail":      {rule: valid(func(r *v1.AlertRule) { r.Email = "ops" }), wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateRule(tc.rule)
+			if tc.wantErr {
+				td.Cmp(t, err, td.ErrorIs(errkit.ErrInvalidArgument))
+				return
+			}
+
+			td.CmpNoError(t, err)
+		})
+	}
+}
+
+func TestBreached(t *testing.T) {
+	type tcase struct {
+		operator v1.AlertOperator
+		value    float64
+		want     bool
+	}
+
+	tests := map[string]tcase{
+		"GT":          {operator: v1.AlertOperator_ALERT_OPERATOR_GT, value: 11, want: true},
+		"GTEqual":     {operator: v1.AlertOperator_ALERT_OPERATOR_GT, value: 10, want: false},
+		"GTE":         {operator: v1.AlertOperator_ALERT_OPERATOR_GTE, value: 10, want: true},
+		"LT":          {operator: v1.AlertOperator_ALERT_OPERATOR_LT, value: 9, want: true},
+		"LTEqual":     {operator: v1.AlertOperator_ALERT_OPERATOR_LT, value: 10, want: false},
+		"LTE":         {operator: v1.AlertOperator_ALERT_OPERATOR_LTE, value: 10, want: true},
+		"Unspecified": {operator: v1.AlertOperator_ALERT_OPERATOR_UNSPECIFIED, value: 10, want: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, breached(&v1.AlertRule{Operator: tc.operator, Threshold: 10}, tc.value), tc.want)
+		})
+	}
+}
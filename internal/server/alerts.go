@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/plainq/plainq/internal/server/alerting"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+)
+
+// createAlertRule validates and stores the alert rule. Rules can be managed
+// when alerting is not enabled, they are evaluated once it's enabled.
+func (s *PlainQ) createAlertRule(ctx context.Context, input *v1.CreateAlertRuleRequest) (*v1.CreateAlertRuleResponse, error) {
+	if err := alerting.ValidateRule(input.GetRule()); err != nil {
+		return nil, err
+	}
+
+	output, createErr := s.storage.CreateAlertRule(ctx, input)
+	if createErr != nil {
+		return nil, fmt.Errorf("create alert rule: %w", createErr)
+	}
+
+	return output, nil
+}
+
+// updateAlertRule validates and replaces the alert rule.
+// The state of the alert of the rule starts over.
+func (s *PlainQ) updateAlertRule(ctx context.Context, input *v1.UpdateAlertRuleRequest) (*v1.UpdateAlertRuleResponse, error) {
+	if err := validateRuleID(input.GetRule().GetRuleId()); err != nil {
+		return nil, err
+	}
+
+	if err := alerting.ValidateRule(input.GetRule()); err != nil {
+		return nil, err
+	}
+
+	output, updateErr := s.storage.UpdateAlertRule(ctx, input)
+	if updateErr != nil {
+		return nil, fmt.Errorf("update alert rule: %w", updateErr)
+	}
+
+	return output, nil
+}
+
+// deleteAlertRule deletes the alert rule.
+func (s *PlainQ) deleteAlertRule(ctx context.Context, input *v1.DeleteAlertRuleRequest) (*v1.DeleteAlertRuleResponse, error) {
+	if err := validateRuleID(input.GetRuleId()); err != nil {
+		return nil, err
+	}
+
+	output, deleteErr := s.storage.DeleteAlertRule(ctx, input)
+	if deleteErr != nil {
+		return nil, fmt.Errorf("delete alert rule: %w", deleteErr)
+	}
+
+	return output, nil
+}
+
+// listAlerts returns current states of alerts, which Houston shows on the alerts page.
+func (s *PlainQ) listAlerts() (*v1.ListAlertsResponse, error) {
+	if s.alerts == nil {
+		return nil, fmt.Errorf("%w: alerting is not enabled", errkit.ErrUnavailable)
+	}
+
+	return s.alerts.Alerts(), nil
+}
+
+// validateRuleID validates given alert rule identifier.
+func validateRuleID(ruleID string) error {
+	if err := idkit.ValidateXID(strings.ToLower(ruleID)); err != nil {
+		return fmt.Errorf("%w: invalid rule id %q", errkit.ErrInvalidArgument, ruleID)
+	}
+
+	return nil
+}
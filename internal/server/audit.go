@@ -0,0 +1,23 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+// listAuditEvents returns recorded mutations which match the filter, newest first.
+func (s *PlainQ) listAuditEvents(ctx context.Context, input *v1.ListAuditEventsRequest) (*v1.ListAuditEventsResponse, error) {
+	if input.GetFrom() != nil && input.GetTo() != nil && !input.GetFrom().AsTime().Before(input.GetTo().AsTime()) {
+		return nil, fmt.Errorf("%w: from should be before to", errkit.ErrInvalidArgument)
+	}
+
+	output, listErr := s.storage.ListAuditEvents(ctx, input)
+	if listErr != nil {
+		return nil, fmt.Errorf("list audit events: %w", listErr)
+	}
+
+	return output, nil
+}
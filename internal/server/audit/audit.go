@@ -0,0 +1,611 @@
+// Package audit records administrative and data-plane mutations
+// to the append-only audit log of the storage, which tells who
+// has changed what and when.
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/auth"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/rbac"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/scim"
+	"github.com/plainq/plainq/internal/server/storage"
+)
+
+// Actions of recorded events.
+const (
+	ActionQueueCreate          = "queue.create"
+	ActionQueueUpdate          = "queue.update"
+	ActionQueueRename          = "queue.rename"
+	ActionQueuePurge           = "queue.purge"
+	ActionQueueDelete          = "queue.delete"
+	ActionQueueState           = "queue.state"
+	ActionQueueTransfer        = "queue.transfer"
+	ActionQueueTransferAccept  = "queue.transfer.accept"
+	ActionQueueTransferCancel  = "queue.transfer.cancel"
+	ActionJobCancel            = "job.cancel"
+	ActionStorageMaintenance   = "storage.maintenance"
+	ActionAlertRuleCreate      = "alert_rule.create"
+	ActionAlertRuleUpdate      = "alert_rule.update"
+	ActionAlertRuleDelete      = "alert_rule.delete"
+	ActionServiceAccountCreate = "service_account.create"
+	ActionServiceAccountDelete = "service_account.delete"
+	ActionAPIKeyCreate         = "api_key.create"
+	ActionAPIKeyRevoke         = "api_key.revoke"
+	ActionUserCreate           = "user.create"
+	ActionUserUpdate           = "user.update"
+	ActionUserDelete           = "user.delete"
+	ActionUserPasswordReset    = "user.password_reset"
+	ActionUserPasswordChange   = "user.password_change"
+	ActionUserVerify           = "user.verify"
+	ActionSessionRevoke        = "session.revoke"
+	ActionRoleCreate           = "role.create"
+	ActionRoleUpdate           = "role.update"
+	ActionRoleDelete           = "role.delete"
+	ActionRoleChange           = "role.change"
+	ActionPermissionChange     = "permission.change"
+	ActionMessageSend          = "message.send"
+	ActionMessageDelete        = "message.delete"
+	ActionMessageRestore       = "message.restore"
+)
+
+// anonymous is the actor of events made by unauthenticated clients.
+const anonymous = "anonymous"
+
+// Config holds the configuration of the Recorder.
+type Config struct {
+	// DataPlane enables recording of sent and deleted messages,
+	// which produces an event per each send and delete call.
+	DataPlane bool
+}
+
+// Log appends events to the audit log.
+type Log interface {
+	AppendAuditEvent(ctx context.Context, event *v1.AuditEvent) error
+}
+
+// Recorder records mutations made by actors of requests.
+type Recorder struct {
+	log    Log
+	cfg    Config
+	logger *slog.Logger
+}
+
+// New returns a pointer to a new instance of Recorder.
+func New(log Log, cfg Config, logger *slog.Logger) *Recorder {
+	r := Recorder{
+		log:    log,
+		cfg:    cfg,
+		logger: logger,
+	}
+
+	return &r
+}
+
+// Record appends the event of the action on the target to the audit log.
+// The actor is the identity of the ctx. Failures to record are logged
+// and don't fail the mutation, which has already been made.
+func (r *Recorder) Record(ctx context.Context, action, target, detail string) {
+	event := v1.AuditEvent{
+		Actor:  Actor(ctx),
+		Action: action,
+		Target: target,
+		Detail: detail,
+	}
+
+	// The mutation is done even when the request is canceled right after it.
+	if err := r.log.AppendAuditEvent(context.WithoutCancel(ctx), &event); err != nil {
+		r.logger.Error("Failed to record audit event",
+			slog.String("action", action),
+			slog.String("target", target),
+			slog.String("actor", event.Actor),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// Actor returns the name of the identity of the ctx, or "anonymous"
+// when the request has not been authenticated.
+func Actor(ctx context.Context) string {
+	if id, ok := identity.FromContext(ctx); ok && id.Name != "" {
+		return id.Name
+	}
+
+	return anonymous
+}
+
+// Storage returns the storage which records successful mutations of the s.
+// Role and permission changes are recorded by their handlers with Record.
+func (r *Recorder) Storage(s storage.Storage) storage.Storage {
+	return &recorded{Storage: s, recorder: r}
+}
+
+// recorded records mutations of queues and, optionally, messages.
+type recorded struct {
+	storage.Storage
+
+	recorder *Recorder
+}
+
+func (r *recorded) CreateQueue(ctx context.Context, input *v1.CreateQueueRequest) (*v1.CreateQueueResponse, error) {
+	output, err := r.Storage.CreateQueue(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionQueueCreate, output.GetQueueId(), "name="+input.GetQueueName())
+
+	return output, nil
+}
+
+func (r *recorded) UpdateQueue(ctx context.Context, input *v1.UpdateQueueRequest) (*v1.UpdateQueueResponse, error) {
+	var name string
+
+	// The current name is read before the update to record the rename.
+	if input.GetQueueName() != "" {
+		if info, err := r.Storage.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: input.GetQueueId()}); err == nil {
+			name = info.GetQueueName()
+		}
+	}
+
+	output, err := r.Storage.UpdateQueue(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionQueueUpdate, input.GetQueueId(), "")
+
+	if name != "" && name != input.GetQueueName() {
+		r.recorder.Record(ctx, ActionQueueRename, input.GetQueueId(), "from="+name+" to="+input.GetQueueName())
+	}
+
+	return output, nil
+}
+
+func (r *recorded) PurgeQueue(ctx context.Context, input *v1.PurgeQueueRequest) (*v1.PurgeQueueResponse, error) {
+	output, err := r.Storage.PurgeQueue(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionQueuePurge, input.GetQueueId(), countDetail("messages", output.GetMessagesCount()))
+
+	return output, nil
+}
+
+func (r *recorded) DeleteQueue(ctx context.Context, input *v1.DeleteQueueRequest) (*v1.DeleteQueueResponse, error) {
+	output, err := r.Storage.DeleteQueue(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionQueueDelete, input.GetQueueId(), "force="+strconv.FormatBool(input.GetForce()))
+
+	return output, nil
+}
+
+func (r *recorded) SetQueueState(ctx context.Context, input *v1.SetQueueStateRequest) (*v1.SetQueueStateResponse, error) {
+	output, err := r.Storage.SetQueueState(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionQueueState, input.GetQueueId(), "state="+output.GetState().String())
+
+	return output, nil
+}
+
+func (r *recorded) TransferQueue(ctx context.Context, input *v1.TransferQueueRequest) (*v1.TransferQueueResponse, error) {
+	output, err := r.Storage.TransferQueue(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionQueueTransfer, input.GetQueueId(), "to="+input.GetToOwner())
+
+	return output, nil
+}
+
+func (r *recorded) AcceptQueueTransfer(ctx context.Context, input *v1.AcceptQueueTransferRequest) (*v1.AcceptQueueTransferResponse, error) {
+	output, err := r.Storage.AcceptQueueTransfer(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionQueueTransferAccept, output.GetQueueId(), "transfer="+input.GetTransferId())
+
+	return output, nil
+}
+
+func (r *recorded) CancelQueueTransfer(ctx context.Context, input *v1.CancelQueueTransferRequest) (*v1.QueueTransfer, error) {
+	output, err := r.Storage.CancelQueueTransfer(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionQueueTransferCancel, output.GetQueueId(), "transfer="+input.GetTransferId())
+
+	return output, nil
+}
+
+func (r *recorded) CancelJob(ctx context.Context, input *v1.CancelJobRequest) (*v1.CancelJobResponse, error) {
+	output, err := r.Storage.CancelJob(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionJobCancel, output.GetJob().GetQueueId(), "job="+input.GetJobId())
+
+	return output, nil
+}
+
+func (r *recorded) RunMaintenance(ctx context.Context, input *v1.RunMaintenanceRequest) (*v1.RunMaintenanceResponse, error) {
+	output, err := r.Storage.RunMaintenance(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionStorageMaintenance, "", "operation="+input.GetOperation().String())
+
+	return output, nil
+}
+
+func (r *recorded) CreateAlertRule(ctx context.Context, input *v1.CreateAlertRuleRequest) (*v1.CreateAlertRuleResponse, error) {
+	output, err := r.Storage.CreateAlertRule(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionAlertRuleCreate, output.GetRule().GetRuleId(), "name="+input.GetRule().GetName())
+
+	return output, nil
+}
+
+func (r *recorded) UpdateAlertRule(ctx context.Context, input *v1.UpdateAlertRuleRequest) (*v1.UpdateAlertRuleResponse, error) {
+	output, err := r.Storage.UpdateAlertRule(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionAlertRuleUpdate, input.GetRule().GetRuleId(), "")
+
+	return output, nil
+}
+
+func (r *recorded) DeleteAlertRule(ctx context.Context, input *v1.DeleteAlertRuleRequest) (*v1.DeleteAlertRuleResponse, error) {
+	output, err := r.Storage.DeleteAlertRule(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionAlertRuleDelete, input.GetRuleId(), "")
+
+	return output, nil
+}
+
+func (r *recorded) CreateServiceAccount(ctx context.Context, input *v1.CreateServiceAccountRequest) (*v1.CreateServiceAccountResponse, error) {
+	output, err := r.Storage.CreateServiceAccount(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionServiceAccountCreate, output.GetAccount().GetAccountId(), "name="+input.GetName())
+
+	return output, nil
+}
+
+func (r *recorded) DeleteServiceAccount(ctx context.Context, input *v1.DeleteServiceAccountRequest) (*v1.DeleteServiceAccountResponse, error) {
+	output, err := r.Storage.DeleteServiceAccount(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionServiceAccountDelete, input.GetAccountId(), "")
+
+	return output, nil
+}
+
+func (r *recorded) CreateAPIKey(ctx context.Context, input *v1.CreateAPIKeyRequest, prefix, hash string) (*v1.APIKey, error) {
+	output, err := r.Storage.CreateAPIKey(ctx, input, prefix, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionAPIKeyCreate, output.GetKeyId(), "account="+input.GetAccountId()+" prefix="+prefix)
+
+	return output, nil
+}
+
+func (r *recorded) RevokeAPIKey(ctx context.Context, input *v1.RevokeAPIKeyRequest) (*v1.RevokeAPIKeyResponse, error) {
+	output, err := r.Storage.RevokeAPIKey(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionAPIKeyRevoke, input.GetKeyId(), "prefix="+output.GetKey().GetPrefix())
+
+	return output, nil
+}
+
+func (r *recorded) CreateSCIMUser(ctx context.Context, user scim.User) (*scim.User, error) {
+	output, err := r.Storage.CreateSCIMUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionUserCreate, output.ID, "email="+output.UserName+" active="+strconv.FormatBool(output.Active))
+
+	return output, nil
+}
+
+func (r *recorded) UpdateSCIMUser(ctx context.Context, user scim.User) (*scim.User, error) {
+	output, err := r.Storage.UpdateSCIMUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionUserUpdate, output.ID, "email="+output.UserName+" active="+strconv.FormatBool(output.Active))
+
+	return output, nil
+}
+
+func (r *recorded) DeleteSCIMUser(ctx context.Context, id string) error {
+	if err := r.Storage.DeleteSCIMUser(ctx, id); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, ActionUserDelete, id, "")
+
+	return nil
+}
+
+func (r *recorded) ResetPassword(ctx context.Context, email, hash, passwordHash string, now time.Time) error {
+	if err := r.Storage.ResetPassword(ctx, email, hash, passwordHash, now); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, ActionUserPasswordReset, email, "")
+
+	return nil
+}
+
+func (r *recorded) UpdateAccount(ctx context.Context, email string, input *v1.UpdateAccountRequest) (*v1.AccountProfile, error) {
+	output, err := r.Storage.UpdateAccount(ctx, email, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionUserUpdate, output.GetUserId(), "email="+output.GetEmail())
+
+	return output, nil
+}
+
+func (r *recorded) SetQueuePermission(ctx context.Context, permission *v1.QueuePermission) (*v1.QueuePermission, error) {
+	output, err := r.Storage.SetQueuePermission(ctx, permission)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionPermissionChange, output.GetQueueId(), permissionDetail(output))
+
+	return output, nil
+}
+
+func (r *recorded) DeleteQueuePermission(ctx context.Context, queueID, role string) error {
+	if err := r.Storage.DeleteQueuePermission(ctx, queueID, role); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, ActionPermissionChange, queueID, "role="+role+" operations=")
+
+	return nil
+}
+
+func (r *recorded) ApplyTemplate(ctx context.Context, role string, template *rbac.Template) error {
+	if err := r.Storage.ApplyTemplate(ctx, role, template); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, ActionRoleChange, role, "template="+template.Name)
+
+	return nil
+}
+
+func (r *recorded) CreateRole(ctx context.Context, name string) (*v1.Role, error) {
+	output, err := r.Storage.CreateRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionRoleCreate, output.GetRoleId(), "name="+output.GetName()+" "+countDetail("users", len(output.GetUsers())))
+
+	return output, nil
+}
+
+func (r *recorded) DeleteRole(ctx context.Context, name string) error {
+	if err := r.Storage.DeleteRole(ctx, name); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, ActionRoleDelete, name, "")
+
+	return nil
+}
+
+func (r *recorded) AssignRole(ctx context.Context, email, role string) error {
+	if err := r.Storage.AssignRole(ctx, email, role); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, ActionRoleUpdate, role, "assigned="+email)
+
+	return nil
+}
+
+func (r *recorded) UnassignRole(ctx context.Context, email, role string) error {
+	if err := r.Storage.UnassignRole(ctx, email, role); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, ActionRoleUpdate, role, "unassigned="+email)
+
+	return nil
+}
+
+func (r *recorded) UpdateUserPassword(ctx context.Context, email, passwordHash string, now time.Time) error {
+	if err := r.Storage.UpdateUserPassword(ctx, email, passwordHash, now); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, ActionUserPasswordChange, email, "")
+
+	return nil
+}
+
+func (r *recorded) VerifyEmail(ctx context.Context, email, hash string, now time.Time) error {
+	if err := r.Storage.VerifyEmail(ctx, email, hash, now); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, ActionUserVerify, email, "")
+
+	return nil
+}
+
+func (r *recorded) RevokeSession(ctx context.Context, subject, id string, now time.Time) (*auth.Session, error) {
+	session, err := r.Storage.RevokeSession(ctx, subject, id, now)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionSessionRevoke, subject, "session="+id)
+
+	return session, nil
+}
+
+func (r *recorded) RevokeSessions(ctx context.Context, subject string, now time.Time) (int64, error) {
+	revoked, err := r.Storage.RevokeSessions(ctx, subject, now)
+	if err != nil {
+		return 0, err
+	}
+
+	r.recorder.Record(ctx, ActionSessionRevoke, subject, countDetail("sessions", int(revoked)))
+
+	return revoked, nil
+}
+
+func (r *recorded) CreateSCIMGroup(ctx context.Context, group scim.Group) (*scim.Group, error) {
+	output, err := r.Storage.CreateSCIMGroup(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionRoleCreate, output.ID, "name="+output.DisplayName+" "+countDetail("users", len(output.Members)))
+
+	return output, nil
+}
+
+func (r *recorded) UpdateSCIMGroup(ctx context.Context, group scim.Group) (*scim.Group, error) {
+	output, err := r.Storage.UpdateSCIMGroup(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionRoleUpdate, output.ID, "name="+output.DisplayName+" "+countDetail("users", len(output.Members)))
+
+	return output, nil
+}
+
+func (r *recorded) DeleteSCIMGroup(ctx context.Context, id string) error {
+	if err := r.Storage.DeleteSCIMGroup(ctx, id); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, ActionRoleDelete, id, "")
+
+	return nil
+}
+
+func (r *recorded) RestoreArchivedMessages(ctx context.Context, input *v1.RestoreArchivedMessagesRequest) (*v1.RestoreArchivedMessagesResponse, error) {
+	output, err := r.Storage.RestoreArchivedMessages(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorder.Record(ctx, ActionMessageRestore, input.GetQueueId(), countDetail("messages", len(output.GetRestored())))
+
+	return output, nil
+}
+
+func (r *recorded) Send(ctx context.Context, input *v1.SendRequest) (*v1.SendResponse, error) {
+	output, err := r.Storage.Send(ctx, input)
+	if err != nil || !r.recorder.cfg.DataPlane {
+		return output, err
+	}
+
+	r.recorder.Record(ctx, ActionMessageSend, input.GetQueueId(), countDetail("messages", len(output.GetMessageIds())))
+
+	return output, nil
+}
+
+func (r *recorded) Delete(ctx context.Context, input *v1.DeleteRequest) (*v1.DeleteResponse, error) {
+	output, err := r.Storage.Delete(ctx, input)
+	if err != nil || !r.recorder.cfg.DataPlane {
+		return output, err
+	}
+
+	r.recorder.Record(ctx, ActionMessageDelete, input.GetQueueId(), countDetail("messages", len(input.GetMessageIds())+len(input.GetReceiptHandles())))
+
+	return output, nil
+}
+
+func (r *recorded) Transact(ctx context.Context, input *v1.TransactRequest) (*v1.TransactResponse, error) {
+	output, err := r.Storage.Transact(ctx, input)
+	if err != nil || !r.recorder.cfg.DataPlane {
+		return output, err
+	}
+
+	for i, send := range input.GetSends() {
+		r.recorder.Record(ctx, ActionMessageSend, send.GetQueueId(), countDetail("messages", len(output.GetSends()[i].GetMessageIds())))
+	}
+
+	for _, del := range input.GetDeletes() {
+		r.recorder.Record(ctx, ActionMessageDelete, del.GetQueueId(), countDetail("messages", len(del.GetMessageIds())+len(del.GetReceiptHandles())))
+	}
+
+	return output, nil
+}
+
+// countDetail returns the detail of the event which tells the number of affected entities.
+// permissionDetail returns the detail of permissions granted to the role,
+// e.g. "role=payments operations=send,receive".
+func permissionDetail(p *v1.QueuePermission) string {
+	var ops []string
+
+	for _, g := range []struct {
+		op      string
+		granted bool
+	}{
+		{op: "send", granted: p.GetSend()},
+		{op: "receive", granted: p.GetReceive()},
+		{op: "purge", granted: p.GetPurge()},
+		{op: "delete", granted: p.GetDelete()},
+		{op: "update", granted: p.GetUpdate()},
+	} {
+		if g.granted {
+			ops = append(ops, g.op)
+		}
+	}
+
+	return "role=" + p.GetRole() + " operations=" + strings.Join(ops, ",")
+}
+
+func countDetail[N int | uint64](key string, n N) string {
+	return key + "=" + strconv.FormatUint(uint64(n), 10)
+}
@@ -0,0 +1,162 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/identity"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/storage"
+	"github.com/plainq/servekit/logkit"
+)
+
+type fakeStorage struct {
+	storage.Storage
+
+	events  []*v1.AuditEvent
+	sendErr error
+}
+
+func (f *fakeStorage) AppendAuditEvent(_ context.Context, event *v1.AuditEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeStorage) Send(_ context.Context, input *v1.SendRequest) (*v1.SendResponse, error) {
+	if f.sendErr != nil {
+		return nil, f.sendErr
+	}
+
+	return &v1.SendResponse{MessageIds: make([]string, len(input.GetMessages()))}, nil
+}
+
+func (*fakeStorage) PurgeQueue(context.Context, *v1.PurgeQueueRequest) (*v1.PurgeQueueResponse, error) {
+	return &v1.PurgeQueueResponse{MessagesCount: 3}, nil
+}
+
+func TestActor(t *testing.T) {
+	type tcase struct {
+		ctx  context.Context
+		want string
+	}
+
+	tests := map[string]tcase{
+		"Identity": {
+			ctx:  identity.WithIdentity(context.Background(), identity.Identity{Name: "orders-service", Method: identity.MethodMTLS}),
+			want: "orders-service",
+		},
+		"Anonymous": {
+			ctx:  context.Background(),
+			want: anonymous,
+		},
+		"EmptyName": {
+			ctx:  identity.WithIdentity(context.Background(), identity.Identity{Method: identity.MethodJWT}),
+			want: anonymous,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, Actor(tc.ctx), tc.want)
+		})
+	}
+}
+
+func (*fakeStorage) DescribeQueue(context.Context, *v1.DescribeQueueRequest) (*v1.DescribeQueueResponse, error) {
+	return &v1.DescribeQueueResponse{QueueId: "q1", QueueName: "orders"}, nil
+}
+
+func (*fakeStorage) UpdateQueue(context.Context, *v1.UpdateQueueRequest) (*v1.UpdateQueueResponse, error) {
+	return &v1.UpdateQueueResponse{Version: 2}, nil
+}
+
+func TestRecorder_Storage(t *testing.T) {
+	type tcase struct {
+		cfg     Config
+		sendErr error
+		want    []*v1.AuditEvent
+	}
+
+	ctx := identity.WithIdentity(context.Background(), identity.Identity{Name: "ops", Method: identity.MethodJWT})
+
+	tests := map[string]tcase{
+		"AdministrativeOnly": {
+			want: []*v1.AuditEvent{
+				{Actor: "ops", Action: ActionQueuePurge, Target: "q1", Detail: "messages=3"},
+			},
+		},
+		"DataPlane": {
+			cfg: Config{DataPlane: true},
+			want: []*v1.AuditEvent{
+				{Actor: "ops", Action: ActionMessageSend, Target: "q1", Detail: "messages=2"},
+				{Actor: "ops", Action: ActionQueuePurge, Target: "q1", Detail: "messages=3"},
+			},
+		},
+		"FailedSend": {
+			cfg:     Config{DataPlane: true},
+			sendErr: errors.New("boom"),
+			want: []*v1.AuditEvent{
+				{Actor: "ops", Action: ActionQueuePurge, Target: "q1", Detail: "messages=3"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			fake := fakeStorage{sendErr: tc.sendErr}
+			s := New(&fake, tc.cfg, logkit.NewNop()).Storage(&fake)
+
+			_, sendErr := s.Send(ctx, &v1.SendRequest{QueueId: "q1", Messages: []*v1.SendMessage{{}, {}}})
+			td.Cmp(t, sendErr, tc.sendErr)
+
+			_, purgeErr := s.PurgeQueue(ctx, &v1.PurgeQueueRequest{QueueId: "q1"})
+			td.CmpNoError(t, purgeErr)
+
+			td.Cmp(t, fake.events, tc.want)
+		})
+	}
+}
+
+func TestRecorder_UpdateQueue(t *testing.T) {
+	type tcase struct {
+		name string
+		want []*v1.AuditEvent
+	}
+
+	ctx := identity.WithIdentity(context.Background(), identity.Identity{Name: "ops", Method: identity.MethodJWT})
+
+	tests := map[string]tcase{
+		"Update": {
+			want: []*v1.AuditEvent{
+				{Actor: "ops", Action: ActionQueueUpdate, Target: "q1"},
+			},
+		},
+		"SameName": {
+			name: "orders",
+			want: []*v1.AuditEvent{
+				{Actor: "ops", Action: ActionQueueUpdate, Target: "q1"},
+			},
+		},
+		"Rename": {
+			name: "orders-v2",
+			want: []*v1.AuditEvent{
+				{Actor: "ops", Action: ActionQueueUpdate, Target: "q1"},
+				{Actor: "ops", Action: ActionQueueRename, Target: "q1", Detail: "from=orders to=orders-v2"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var fake fakeStorage
+			s := New(&fake, Config{}, logkit.NewNop()).Storage(&fake)
+
+			_, err := s.UpdateQueue(ctx, &v1.UpdateQueueRequest{QueueId: "q1", QueueName: tc.name})
+			td.CmpNoError(t, err)
+
+			td.Cmp(t, fake.events, tc.want)
+		})
+	}
+}
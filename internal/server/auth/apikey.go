@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/servekit/errkit"
+)
+
+// APIKeyPrefix starts every API key, which tells keys from tokens.
+const APIKeyPrefix = "pq_"
+
+// ErrInvalidAPIKey indicates that the API key is unknown or doesn't match the stored one.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// apiKeyEncoding encodes the public part of keys, which is short and case-insensitive.
+var apiKeyEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// StoredAPIKey represents the API key as it's stored, which is looked up by its prefix.
+type StoredAPIKey struct {
+	// KeyID is the identifier of the key.
+	KeyID string
+
+	// Account is the name of the service account the key belongs to.
+	Account string
+
+	// Hash is the hex encoded SHA-256 hash of the full key.
+	Hash string
+
+	// ExpiresAt is the time the key expires, zero means the key never expires.
+	ExpiresAt time.Time
+
+	// Revoked reports whether the key has been revoked.
+	Revoked bool
+}
+
+// APIKeyStore looks up stored API keys.
+type APIKeyStore interface {
+	// APIKey returns the key with the prefix. Unknown keys are rejected with errkit.ErrNotFound.
+	APIKey(ctx context.Context, prefix string) (*StoredAPIKey, error)
+}
+
+// GenerateAPIKey returns the new API key in form "pq_<id>_<secret>",
+// its prefix "pq_<id>", which identifies the key, and the hash of the key to store.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	id := make([]byte, 5)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", "", fmt.Errorf("generate key id: %w", err)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", fmt.Errorf("generate key secret: %w", err)
+	}
+
+	prefix = APIKeyPrefix + apiKeyEncoding.EncodeToString(id)
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash of the key. Keys hold 256 random
+// bits, so a fast hash is enough, unlike for passwords chosen by humans.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// authenticateAPIKey authenticates the service account by the API key.
+func (a *Authenticator) authenticateAPIKey(ctx context.Context, key string) (identity.Identity, error) {
+	if a.cfg.APIKeys == nil {
+		return identity.Identity{}, fmt.Errorf("%w: API keys are not accepted", ErrInvalidAPIKey)
+	}
+
+	// The id never holds underscores, unlike the secret.
+	id, _, ok := strings.Cut(strings.TrimPrefix(key, APIKeyPrefix), "_")
+	if !ok || id == "" {
+		return identity.Identity{}, fmt.Errorf("%w: key should be in form %s<id>_<secret>", ErrMalformedToken, APIKeyPrefix)
+	}
+
+	stored, lookupErr := a.cfg.APIKeys.APIKey(ctx, APIKeyPrefix+id)
+	if lookupErr != nil {
+		if errors.Is(lookupErr, errkit.ErrNotFound) {
+			return identity.Identity{}, ErrInvalidAPIKey
+		}
+
+		return identity.Identity{}, fmt.Errorf("look up API key: %w", lookupErr)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(stored.Hash)) != 1 {
+		return identity.Identity{}, ErrInvalidAPIKey
+	}
+
+	if stored.Revoked {
+		return identity.Identity{}, fmt.Errorf("%w: API key %s", ErrRevokedToken, stored.KeyID)
+	}
+
+	if !stored.ExpiresAt.IsZero() && a.now().After(stored.ExpiresAt) {
+		return identity.Identity{}, fmt.Errorf("%w: API key %s", ErrExpiredToken, stored.KeyID)
+	}
+
+	return identity.Identity{Name: stored.Account, Method: identity.MethodAPIKey}, nil
+}
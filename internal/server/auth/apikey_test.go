@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	td.Require(t).CmpNoError(err)
+
+	td.Cmp(t, prefix, td.Re(`^pq_[a-z2-7]{8}$`))
+	td.CmpTrue(t, strings.HasPrefix(key, prefix+"_"))
+	td.Cmp(t, hash, HashAPIKey(key))
+	td.CmpNot(t, hash, td.Contains(key))
+
+	other, otherPrefix, _, otherErr := GenerateAPIKey()
+	td.Require(t).CmpNoError(otherErr)
+
+	td.CmpNot(t, other, key)
+	td.CmpNot(t, otherPrefix, prefix)
+}
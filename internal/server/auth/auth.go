@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/servekit/errkit"
+)
+
+// Errors of authentication, which tell why the client has been rejected.
+var (
+	ErrMissingCredentials = errors.New("credentials are required")
+	ErrRevokedToken       = errors.New("token is revoked")
+)
+
+// Config holds the configuration of the Authenticator.
+type Config struct {
+	// JWT configures the validation of bearer tokens.
+	// Tokens are not accepted when neither the secret nor keys are set.
+	JWT JWTConfig
+
+	// APIKeys looks up API keys of service accounts.
+	// API keys are not accepted when it's nil.
+	APIKeys APIKeyStore
+
+	// Denylist holds tokens revoked before they expire.
+	// Tokens are not checked against the denylist when it's nil.
+	Denylist *Denylist
+
+	// Verifiers verify passwords of users on sign-in, in order.
+	Verifiers []PasswordVerifier
+
+	// Users syncs roles of users managed by the directory on sign-in.
+	Users UserSyncer
+
+	// TokenTTL is the lifetime of tokens issued on sign-in.
+	TokenTTL time.Duration
+
+	// Passwords replaces passwords of local accounts changed by their users.
+	Passwords PasswordChangeStore
+
+	// Resets persists password reset codes, which are emailed by the Mailer.
+	// Passwords can't be reset when either of them is nil.
+	Resets PasswordResetStore
+	Mailer Mailer
+
+	// ResetCodeTTL is the lifetime of password reset codes.
+	ResetCodeTTL time.Duration
+
+	// Sessions records tokens issued on sign-in, so users can list and revoke them.
+	// Sessions are not recorded when it's nil.
+	Sessions SessionStore
+
+	// Verifications stores email verification codes, which are sent by the Mailer.
+	Verifications EmailVerificationStore
+
+	// VerificationCodeTTL is the lifetime of email verification codes.
+	VerificationCodeTTL time.Duration
+
+	// VerificationResendInterval is the minimal interval between
+	// email verification codes sent to the same account.
+	VerificationResendInterval time.Duration
+}
+
+// Authenticator authenticates clients by credentials they present.
+type Authenticator struct {
+	cfg Config
+	now func() time.Time
+}
+
+// New returns a pointer to a new instance of Authenticator.
+func New(cfg Config) (*Authenticator, error) {
+	if len(cfg.JWT.Secret) == 0 && cfg.JWT.Keys == nil && cfg.APIKeys == nil {
+		return nil, errors.New("either JWT secret, JWT keys or API keys store should be specified")
+	}
+
+	a := Authenticator{
+		cfg: cfg,
+		now: time.Now,
+	}
+
+	return &a, nil
+}
+
+// Authenticate authenticates the client by the value of the authorization
+// header or metadata, which should be in form "Bearer <token>", where the token
+// is either the JSON Web Token or the API key of the service account.
+func (a *Authenticator) Authenticate(ctx context.Context, authorization string) (identity.Identity, error) {
+	credentials, credentialsErr := bearerCredentials(authorization)
+	if credentialsErr != nil {
+		return identity.Identity{}, credentialsErr
+	}
+
+	if strings.HasPrefix(credentials, APIKeyPrefix) {
+		return a.authenticateAPIKey(ctx, credentials)
+	}
+
+	claims, claimsErr := a.authenticateJWT(ctx, credentials)
+	if claimsErr != nil {
+		return identity.Identity{}, claimsErr
+	}
+
+	return identity.Identity{Name: claims.Subject, Method: identity.MethodJWT}, nil
+}
+
+// authenticateJWT verifies the token and checks that it's not revoked.
+func (a *Authenticator) authenticateJWT(ctx context.Context, token string) (*Claims, error) {
+	claims, verifyErr := VerifyJWT(ctx, token, a.cfg.JWT, a.now())
+	if verifyErr != nil {
+		return nil, verifyErr
+	}
+
+	if a.cfg.Denylist != nil {
+		denied, deniedErr := a.cfg.Denylist.Denied(ctx, token)
+		if deniedErr != nil {
+			return nil, deniedErr
+		}
+
+		if denied {
+			return nil, ErrRevokedToken
+		}
+
+		revoked, revokedErr := a.cfg.Denylist.SubjectRevoked(ctx, claims.Subject, time.Unix(claims.IssuedAt, 0))
+		if revokedErr != nil {
+			return nil, revokedErr
+		}
+
+		if revoked {
+			return nil, ErrRevokedToken
+		}
+	}
+
+	return claims, nil
+}
+
+// SignOut revokes the token of the authorization until it expires.
+// API keys are long-lived and are revoked by administrators instead.
+func (a *Authenticator) SignOut(ctx context.Context, authorization string) error {
+	if a.cfg.Denylist == nil {
+		return fmt.Errorf("%w: token denylist is not enabled", errkit.ErrUnavailable)
+	}
+
+	credentials, credentialsErr := bearerCredentials(authorization)
+	if credentialsErr != nil {
+		return credentialsErr
+	}
+
+	if strings.HasPrefix(credentials, APIKeyPrefix) {
+		return fmt.Errorf("%w: API keys can't sign out, revoke the key instead", errkit.ErrInvalidArgument)
+	}
+
+	claims, verifyErr := VerifyJWT(ctx, credentials, a.cfg.JWT, a.now())
+	if verifyErr != nil {
+		return verifyErr
+	}
+
+	// The token is accepted within the leeway after it expires.
+	expiresAt := time.Unix(claims.ExpiresAt, 0).Add(a.cfg.JWT.Leeway)
+
+	if err := a.cfg.Denylist.Deny(ctx, credentials, expiresAt); err != nil {
+		return err
+	}
+
+	return a.endSession(ctx, claims)
+}
+
+// RevokeTokens revokes tokens of the subject issued so far, e.g. when its password is reset,
+// and ends its sessions. Tokens issued by plainq expire within the token TTL, so the revocation
+// is kept for as long.
+func (a *Authenticator) RevokeTokens(ctx context.Context, subject string) error {
+	if a.cfg.Denylist == nil {
+		return fmt.Errorf("%w: token denylist is not enabled", errkit.ErrUnavailable)
+	}
+
+	// Tokens tell the time they were issued in seconds.
+	now := a.now().Truncate(time.Second)
+
+	if err := a.cfg.Denylist.RevokeSubject(ctx, subject, now, now.Add(a.cfg.TokenTTL+a.cfg.JWT.Leeway)); err != nil {
+		return err
+	}
+
+	if a.cfg.Sessions == nil {
+		return nil
+	}
+
+	if _, err := a.cfg.Sessions.RevokeSessions(ctx, subject, a.now()); err != nil {
+		return fmt.Errorf("revoke sessions of %q: %w", subject, err)
+	}
+
+	return nil
+}
+
+// bearerCredentials returns credentials of the authorization in form "Bearer <credentials>".
+func bearerCredentials(authorization string) (string, error) {
+	if authorization == "" {
+		return "", ErrMissingCredentials
+	}
+
+	scheme, credentials, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("%w: authorization should be in form: Bearer <token>", ErrMalformedToken)
+	}
+
+	return strings.TrimSpace(credentials), nil
+}
+
+// Unauthenticated reports whether the error tells why credentials have been rejected,
+// unlike errors of lookups of credentials, e.g. of the storage.
+func Unauthenticated(err error) bool {
+	for _, target := range []error{
+		ErrMissingCredentials,
+		ErrMalformedToken,
+		ErrInvalidSignature,
+		ErrExpiredToken,
+		ErrInvalidClaims,
+		ErrRevokedToken,
+		ErrInvalidAPIKey,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reason returns the reason of the authentication failure, which is used as the metric label.
+func Reason(err error) string {
+	switch {
+	case errors.Is(err, ErrMissingCredentials):
+		return telemetry.ReasonMissingCredentials
+
+	case errors.Is(err, ErrMalformedToken):
+		return telemetry.ReasonMalformedToken
+
+	case errors.Is(err, ErrExpiredToken):
+		return telemetry.ReasonExpiredToken
+
+	case errors.Is(err, ErrInvalidSignature):
+		return telemetry.ReasonInvalidSignature
+
+	case errors.Is(err, ErrRevokedToken):
+		return telemetry.ReasonRevokedToken
+
+	default:
+		return telemetry.ReasonInvalidCredentials
+	}
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/plainq/plainq/internal/server/rbac"
+)
+
+// ErrPermissionDenied indicates that the client is not granted the operation.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// Operations which are not performed on queues.
+const (
+	// OpAuthenticated is allowed to any authenticated client.
+	OpAuthenticated rbac.Operation = ""
+
+	// OpAdmin requires the rbac.AdminRole.
+	OpAdmin = rbac.OpAll
+
+	// OpPublic is allowed without authentication, e.g. the sign-in.
+	OpPublic rbac.Operation = "public"
+)
+
+// Granter resolves permissions of the subject on the queue.
+type Granter interface {
+	QueueGrants(ctx context.Context, subject, queueID string) (*rbac.Grants, error)
+}
+
+// Authorize checks that the subject is granted the operation on the queue.
+// Denied operations are rejected with ErrPermissionDenied, and operations
+// on unknown queues are rejected with errkit.ErrNotFound.
+func Authorize(ctx context.Context, granter Granter, subject, queueID string, op rbac.Operation) error {
+	if op == OpAuthenticated || op == OpPublic {
+		return nil
+	}
+
+	// Only roles matter to operations which require the admin role.
+	if op == OpAdmin {
+		queueID = ""
+	}
+
+	grants, err := granter.QueueGrants(ctx, subject, queueID)
+	if err != nil {
+		return fmt.Errorf("resolve grants of %q: %w", subject, err)
+	}
+
+	switch {
+	case op == OpAdmin && !grants.Admin:
+		return fmt.Errorf("%w: %s is not allowed to call the method, it requires the %s role", ErrPermissionDenied, subject, rbac.AdminRole)
+
+	case op != OpAdmin && !grants.Allows(op):
+		return fmt.Errorf("%w: %s is not allowed to %s the queue", ErrPermissionDenied, subject, op)
+
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DenylistStore persists denied access tokens, which are identified by their hashes.
+type DenylistStore interface {
+	// DenyAccessToken adds the token to the denylist until the time the token expires.
+	DenyAccessToken(ctx context.Context, hash string, expiresAt time.Time) error
+
+	// AccessTokenDenied reports whether the token is on the denylist.
+	AccessTokenDenied(ctx context.Context, hash string) (bool, error)
+
+	// DeleteExpiredAccessTokens removes tokens and revocations of tokens of subjects which have
+	// expired before the time from the denylist, since expired tokens are rejected anyway.
+	DeleteExpiredAccessTokens(ctx context.Context, before time.Time) (int64, error)
+
+	// RevokeSubjectTokens revokes tokens of the subject issued at or before the time,
+	// until the time those tokens expire. Later revocations replace earlier ones.
+	RevokeSubjectTokens(ctx context.Context, subject string, issuedBefore, expiresAt time.Time) error
+
+	// SubjectTokensRevokedAt returns the time tokens of the subject issued at or before
+	// are revoked, which is zero when tokens of the subject are not revoked.
+	SubjectTokensRevokedAt(ctx context.Context, subject string) (time.Time, error)
+}
+
+// DenylistConfig holds the configuration of the Denylist.
+type DenylistConfig struct {
+	// CacheTTL is how long the token which is not denied is cached.
+	// Tokens denied by other servers are accepted by this server for as long.
+	CacheTTL time.Duration
+
+	// CleanupInterval is the interval of the removal of expired entries.
+	CleanupInterval time.Duration
+}
+
+// Denylist holds access tokens revoked before they expire, e.g. on sign-out.
+// Lookups are served by the in-memory cache in front of the store.
+type Denylist struct {
+	cfg    DenylistConfig
+	store  DenylistStore
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu       sync.Mutex
+	entries  map[string]denylistEntry
+	subjects map[string]subjectEntry
+}
+
+// denylistEntry represents the cached state of the token.
+type denylistEntry struct {
+	denied bool
+
+	// until is the time the entry is dropped from the cache,
+	// which is the expiration of the token for denied tokens.
+	until time.Time
+}
+
+// subjectEntry represents the cached revocation of tokens of the subject.
+type subjectEntry struct {
+	// revokedAt is the time tokens issued at or before are revoked, zero when they're not.
+	revokedAt time.Time
+
+	// until is the time the entry is dropped from the cache.
+	until time.Time
+}
+
+// NewDenylist returns a pointer to a new instance of Denylist.
+func NewDenylist(store DenylistStore, cfg DenylistConfig, logger *slog.Logger) (*Denylist, error) {
+	if cfg.CacheTTL < 0 {
+		return nil, fmt.Errorf("cache TTL should not be negative: %s", cfg.CacheTTL)
+	}
+
+	if cfg.CleanupInterval <= 0 {
+		return nil, fmt.Errorf("cleanup interval should be positive: %s", cfg.CleanupInterval)
+	}
+
+	d := Denylist{
+		cfg:      cfg,
+		store:    store,
+		logger:   logger,
+		now:      time.Now,
+		entries:  make(map[string]denylistEntry),
+		subjects: make(map[string]subjectEntry),
+	}
+
+	return &d, nil
+}
+
+// Deny adds the token to the denylist until the time it expires.
+func (d *Denylist) Deny(ctx context.Context, token string, expiresAt time.Time) error {
+	return d.denyHash(ctx, hashToken(token), expiresAt)
+}
+
+// denyHash puts the token with the hash on the denylist until the time it expires.
+func (d *Denylist) denyHash(ctx context.Context, hash string, expiresAt time.Time) error {
+	if err := d.store.DenyAccessToken(ctx, hash, expiresAt); err != nil {
+		return fmt.Errorf("deny access token: %w", err)
+	}
+
+	d.mu.Lock()
+	d.entries[hash] = denylistEntry{denied: true, until: expiresAt}
+	d.mu.Unlock()
+
+	return nil
+}
+
+// Denied reports whether the token is on the denylist.
+func (d *Denylist) Denied(ctx context.Context, token string) (bool, error) {
+	hash := hashToken(token)
+	now := d.now()
+
+	d.mu.Lock()
+	entry, ok := d.entries[hash]
+	d.mu.Unlock()
+
+	if ok && now.Before(entry.until) {
+		return entry.denied, nil
+	}
+
+	denied, err := d.store.AccessTokenDenied(ctx, hash)
+	if err != nil {
+		return false, fmt.Errorf("check access token denylist: %w", err)
+	}
+
+	// Denied tokens are never allowed again, so only the answer
+	// for tokens which are not denied goes stale.
+	if !denied && d.cfg.CacheTTL > 0 {
+		d.mu.Lock()
+		d.entries[hash] = denylistEntry{until: now.Add(d.cfg.CacheTTL)}
+		d.mu.Unlock()
+	}
+
+	return denied, nil
+}
+
+// RevokeSubject revokes tokens of the subject issued at or before the time until they expire.
+func (d *Denylist) RevokeSubject(ctx context.Context, subject string, issuedBefore, expiresAt time.Time) error {
+	if err := d.store.RevokeSubjectTokens(ctx, subject, issuedBefore, expiresAt); err != nil {
+		return fmt.Errorf("revoke tokens of %q: %w", subject, err)
+	}
+
+	d.mu.Lock()
+	d.subjects[subject] = subjectEntry{revokedAt: issuedBefore, until: d.now().Add(d.cfg.CacheTTL)}
+	d.mu.Unlock()
+
+	return nil
+}
+
+// SubjectRevoked reports whether the token of the subject issued at the time has been revoked.
+// Revocations made by other servers are seen by this server within the cache TTL.
+func (d *Denylist) SubjectRevoked(ctx context.Context, subject string, issuedAt time.Time) (bool, error) {
+	now := d.now()
+
+	d.mu.Lock()
+	entry, ok := d.subjects[subject]
+	d.mu.Unlock()
+
+	if !ok || !now.Before(entry.until) {
+		revokedAt, err := d.store.SubjectTokensRevokedAt(ctx, subject)
+		if err != nil {
+			return false, fmt.Errorf("check revoked tokens of %q: %w", subject, err)
+		}
+
+		entry = subjectEntry{revokedAt: revokedAt, until: now.Add(d.cfg.CacheTTL)}
+
+		if d.cfg.CacheTTL > 0 {
+			d.mu.Lock()
+			d.subjects[subject] = entry
+			d.mu.Unlock()
+		}
+	}
+
+	return !entry.revokedAt.IsZero() && !issuedAt.After(entry.revokedAt), nil
+}
+
+// Run removes expired entries from the cache and the store
+// every cleanup interval until the context is canceled.
+func (d *Denylist) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if err := d.cleanup(ctx); err != nil && ctx.Err() == nil {
+				d.logger.Error("Failed to clean up access token denylist",
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
+
+// cleanup removes expired entries from the cache and the store.
+func (d *Denylist) cleanup(ctx context.Context) error {
+	now := d.now()
+
+	d.mu.Lock()
+
+	for hash, entry := range d.entries {
+		if !now.Before(entry.until) {
+			delete(d.entries, hash)
+		}
+	}
+
+	for subject, entry := range d.subjects {
+		if !now.Before(entry.until) {
+			delete(d.subjects, subject)
+		}
+	}
+
+	d.mu.Unlock()
+
+	deleted, err := d.store.DeleteExpiredAccessTokens(ctx, now)
+	if err != nil {
+		return fmt.Errorf("delete expired access tokens: %w", err)
+	}
+
+	if deleted > 0 {
+		d.logger.Debug("Expired access tokens removed from denylist", slog.Int64("count", deleted))
+	}
+
+	return nil
+}
+
+// hashToken returns the hex encoded SHA-256 hash of the token,
+// so the denylist doesn't hold tokens which are valid until they expire.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/errkit"
+)
+
+type mockDenylistStore struct {
+	denied  map[string]time.Time
+	revoked map[string]time.Time
+	lookups int
+}
+
+func (m *mockDenylistStore) DenyAccessToken(_ context.Context, hash string, expiresAt time.Time) error {
+	m.denied[hash] = expiresAt
+	return nil
+}
+
+func (m *mockDenylistStore) AccessTokenDenied(_ context.Context, hash string) (bool, error) {
+	m.lookups++
+
+	_, ok := m.denied[hash]
+
+	return ok, nil
+}
+
+func (m *mockDenylistStore) DeleteExpiredAccessTokens(_ context.Context, before time.Time) (int64, error) {
+	var deleted int64
+
+	for hash, expiresAt := range m.denied {
+		if expiresAt.Before(before) {
+			delete(m.denied, hash)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (m *mockDenylistStore) RevokeSubjectTokens(_ context.Context, subject string, issuedBefore, _ time.Time) error {
+	if m.revoked == nil {
+		m.revoked = make(map[string]time.Time)
+	}
+
+	m.revoked[subject] = issuedBefore
+
+	return nil
+}
+
+func (m *mockDenylistStore) SubjectTokensRevokedAt(_ context.Context, subject string) (time.Time, error) {
+	return m.revoked[subject], nil
+}
+
+func TestAuthenticator_SignOut(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("secret")
+	now := time.Now()
+
+	store := mockDenylistStore{denied: make(map[string]time.Time)}
+
+	denylist, denylistErr := NewDenylist(&store, DenylistConfig{CacheTTL: time.Minute, CleanupInterval: time.Minute}, slog.Default())
+	td.Require(t).CmpNoError(denylistErr)
+
+	authn, authnErr := New(Config{JWT: JWTConfig{Secret: secret}, Denylist: denylist})
+	td.Require(t).CmpNoError(authnErr)
+
+	token := func(subject string) string {
+		signed, err := SignJWT(Claims{Subject: subject, ExpiresAt: now.Add(time.Hour).Unix()}, secret)
+		td.Require(t).CmpNoError(err)
+
+		return "Bearer " + signed
+	}
+
+	signedOut, other := token("orders-service"), token("billing-service")
+
+	_, err := authn.Authenticate(ctx, signedOut)
+	td.CmpNoError(t, err)
+
+	// Tokens which are not denied are cached, so the second lookup doesn't hit the store.
+	_, err = authn.Authenticate(ctx, signedOut)
+	td.CmpNoError(t, err)
+	td.Cmp(t, store.lookups, 1)
+
+	td.CmpNoError(t, authn.SignOut(ctx, signedOut))
+	td.Cmp(t, store.denied, td.Len(1))
+
+	_, err = authn.Authenticate(ctx, signedOut)
+	td.CmpErrorIs(t, err, ErrRevokedToken)
+
+	_, err = authn.Authenticate(ctx, other)
+	td.CmpNoError(t, err)
+
+	// Denied tokens are rejected by servers which haven't cached them yet.
+	restarted, restartedErr := NewDenylist(&store, DenylistConfig{CleanupInterval: time.Minute}, slog.Default())
+	td.Require(t).CmpNoError(restartedErr)
+
+	denied, deniedErr := restarted.Denied(ctx, signedOut[len("Bearer "):])
+	td.CmpNoError(t, deniedErr)
+	td.CmpTrue(t, denied)
+
+	td.CmpErrorIs(t, authn.SignOut(ctx, "Bearer pq_abcdefgh_secret"), errkit.ErrInvalidArgument)
+	td.CmpErrorIs(t, authn.SignOut(ctx, ""), ErrMissingCredentials)
+}
+
+func TestDenylist_cleanup(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	store := mockDenylistStore{denied: make(map[string]time.Time)}
+
+	denylist, denylistErr := NewDenylist(&store, DenylistConfig{CacheTTL: time.Minute, CleanupInterval: time.Minute}, slog.Default())
+	td.Require(t).CmpNoError(denylistErr)
+
+	td.CmpNoError(t, denylist.Deny(ctx, "expired", now.Add(time.Minute)))
+	td.CmpNoError(t, denylist.Deny(ctx, "valid", now.Add(time.Hour)))
+
+	denylist.now = func() time.Time { return now.Add(2 * time.Minute) }
+
+	td.CmpNoError(t, denylist.cleanup(ctx))
+
+	td.Cmp(t, denylist.entries, td.Len(1))
+	td.Cmp(t, store.denied, td.Len(1))
+
+	denied, err := denylist.Denied(ctx, "valid")
+	td.CmpNoError(t, err)
+	td.CmpTrue(t, denied)
+}
@@ -0,0 +1,326 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxJWKSSize limits the size of the JWKS response, which holds a handful of keys.
+const maxJWKSSize = 1 << 20
+
+// JWKSConfig holds the configuration of the JWKS.
+type JWKSConfig struct {
+	// URL is the address of the JSON Web Key Set, e.g. "https://idp.example.com/.well-known/jwks.json".
+	URL string
+
+	// RefreshInterval is the interval of the background refresh of keys.
+	RefreshInterval time.Duration
+
+	// MinRefreshInterval limits refreshes of keys caused by tokens with unknown key ids,
+	// which happen when the provider rotates keys, so forged tokens can't flood the provider.
+	MinRefreshInterval time.Duration
+
+	// Timeout is the timeout of the request of keys.
+	Timeout time.Duration
+}
+
+// JWKS resolves public keys of tokens from the JSON Web Key Set of the OAuth provider.
+// Keys are cached and refreshed in the background, and on tokens with unknown key ids,
+// which makes the rotation of keys seamless.
+type JWKS struct {
+	cfg    JWKSConfig
+	client *http.Client
+	logger *slog.Logger
+	now    func() time.Time
+
+	// refresh serializes refreshes, so concurrent
+	// misses don't request keys more than once.
+	refresh sync.Mutex
+
+	mu      sync.RWMutex
+	keys    map[string]crypto.PublicKey
+	etag    string
+	fetched time.Time
+}
+
+// NewJWKS returns a pointer to a new instance of JWKS. Keys are not requested
+// until the first token or refresh, so the provider being down doesn't block the start.
+func NewJWKS(cfg JWKSConfig, logger *slog.Logger) (*JWKS, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("JWKS URL should be specified")
+	}
+
+	if cfg.RefreshInterval <= 0 {
+		return nil, fmt.Errorf("JWKS refresh interval should be positive: %s", cfg.RefreshInterval)
+	}
+
+	j := JWKS{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		logger: logger,
+		now:    time.Now,
+		keys:   make(map[string]crypto.PublicKey),
+	}
+
+	return &j, nil
+}
+
+// PublicKey returns the key with the id. Keys are requested again when the id
+// is unknown, e.g. after the rotation, at most once per the minimal refresh interval.
+func (j *JWKS) PublicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if key, ok := j.lookup(kid); ok {
+		return key, nil
+	}
+
+	j.refresh.Lock()
+	defer j.refresh.Unlock()
+
+	// Keys could have been refreshed while waiting for the lock.
+	if key, ok := j.lookup(kid); ok {
+		return key, nil
+	}
+
+	j.mu.RLock()
+	fetched := j.fetched
+	j.mu.RUnlock()
+
+	if !fetched.IsZero() && j.now().Sub(fetched) < j.cfg.MinRefreshInterval {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidSignature, kid)
+	}
+
+	if err := j.fetch(ctx); err != nil {
+		return nil, err
+	}
+
+	if key, ok := j.lookup(kid); ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidSignature, kid)
+}
+
+// Refresh requests keys from the provider.
+func (j *JWKS) Refresh(ctx context.Context) error {
+	j.refresh.Lock()
+	defer j.refresh.Unlock()
+
+	return j.fetch(ctx)
+}
+
+// Run refreshes keys every refresh interval until the context is canceled.
+func (j *JWKS) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if err := j.Refresh(ctx); err != nil && ctx.Err() == nil {
+				j.logger.Error("Failed to refresh JWKS",
+					slog.String("url", j.cfg.URL),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
+
+// lookup returns the cached key with the id. Tokens without the key id
+// are verified by the only key of the set, if there is just one.
+func (j *JWKS) lookup(kid string) (crypto.PublicKey, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+
+	key, ok := j.keys[kid]
+
+	return key, ok
+}
+
+// fetch requests keys and replaces cached ones, unless the set hasn't changed since
+// the last request. The caller should hold the refresh lock.
+func (j *JWKS) fetch(ctx context.Context) error {
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, j.cfg.URL, nil)
+	if reqErr != nil {
+		return fmt.Errorf("create JWKS request: %w", reqErr)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	j.mu.RLock()
+	etag := j.etag
+	j.mu.RUnlock()
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	res, resErr := j.client.Do(req)
+	if resErr != nil {
+		return fmt.Errorf("request JWKS: %w", resErr)
+	}
+
+	defer func() { _ = res.Body.Close() }()
+
+	now := j.now()
+
+	if res.StatusCode == http.StatusNotModified {
+		j.mu.Lock()
+		j.fetched = now
+		j.mu.Unlock()
+
+		return nil
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("request JWKS: unexpected status %s", res.Status)
+	}
+
+	body, readErr := io.ReadAll(io.LimitReader(res.Body, maxJWKSSize))
+	if readErr != nil {
+		return fmt.Errorf("read JWKS: %w", readErr)
+	}
+
+	keys, parseErr := ParseJWKS(body)
+	if parseErr != nil {
+		return parseErr
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.etag = res.Header.Get("ETag")
+	j.fetched = now
+	j.mu.Unlock()
+
+	return nil
+}
+
+// jwk represents the JSON Web Key of RSA or EC type.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// N and E are the modulus and exponent of RSA keys.
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// Crv, X and Y are the curve and coordinates of EC keys.
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS returns public keys of the JSON Web Key Set by their ids. Keys which
+// can't verify RS256 or ES256 signatures, e.g. encryption keys, are skipped.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		var (
+			key crypto.PublicKey
+			err error
+		)
+
+		switch {
+		case k.Kty == "RSA" && (k.Alg == "" || k.Alg == "RS256"):
+			key, err = k.rsaKey()
+
+		case k.Kty == "EC" && k.Crv == "P-256" && (k.Alg == "" || k.Alg == "ES256"):
+			key, err = k.ecKey()
+
+		default:
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("parse JWKS key %q: %w", k.Kid, err)
+		}
+
+		keys[k.Kid] = key
+	}
+
+	return keys, nil
+}
+
+// rsaKey returns the RSA public key.
+func (k *jwk) rsaKey() (*rsa.PublicKey, error) {
+	n, nErr := base64.RawURLEncoding.DecodeString(k.N)
+	if nErr != nil {
+		return nil, fmt.Errorf("modulus: %w", nErr)
+	}
+
+	e, eErr := base64.RawURLEncoding.DecodeString(k.E)
+	if eErr != nil {
+		return nil, fmt.Errorf("exponent: %w", eErr)
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("exponent is out of range")
+	}
+
+	key := rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+
+	if key.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("modulus of %d bits is too short", key.N.BitLen())
+	}
+
+	return &key, nil
+}
+
+// ecKey returns the P-256 public key.
+func (k *jwk) ecKey() (*ecdsa.PublicKey, error) {
+	x, xErr := base64.RawURLEncoding.DecodeString(k.X)
+	if xErr != nil {
+		return nil, fmt.Errorf("x coordinate: %w", xErr)
+	}
+
+	y, yErr := base64.RawURLEncoding.DecodeString(k.Y)
+	if yErr != nil {
+		return nil, fmt.Errorf("y coordinate: %w", yErr)
+	}
+
+	if len(x) != 32 || len(y) != 32 {
+		return nil, errors.New("coordinates should be 32 bytes")
+	}
+
+	// The uncompressed point is validated to be on the curve.
+	point := append(append([]byte{4}, x...), y...)
+
+	return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+}
@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+// signAsymmetric returns the token with the claims signed with RS256 or ES256 by the key.
+func signAsymmetric(t *testing.T, claims Claims, alg, kid string, key crypto.Signer) string {
+	t.Helper()
+
+	header, headerErr := json.Marshal(jwtHeader{Alg: alg, Typ: "JWT", Kid: kid})
+	td.Require(t).CmpNoError(headerErr)
+
+	payload, payloadErr := json.Marshal(claims)
+	td.Require(t).CmpNoError(payloadErr)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		sig, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		td.Require(t).CmpNoError(err)
+
+		signature = sig
+
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		td.Require(t).CmpNoError(err)
+
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// jwkOf returns the JSON Web Key of the public key.
+func jwkOf(kid string, key crypto.PublicKey) map[string]string {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}
+
+	case *ecdsa.PublicKey:
+		point, _ := k.Bytes()
+
+		return map[string]string{
+			"kty": "EC",
+			"kid": kid,
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(point[1:33]),
+			"y":   base64.RawURLEncoding.EncodeToString(point[33:]),
+		}
+	}
+
+	return nil
+}
+
+func TestJWKS(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	rsaKey, rsaErr := rsa.GenerateKey(rand.Reader, 2048)
+	td.Require(t).CmpNoError(rsaErr)
+
+	ecKey, ecErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	td.Require(t).CmpNoError(ecErr)
+
+	rotated, rotatedErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	td.Require(t).CmpNoError(rotatedErr)
+
+	var (
+		keys     atomic.Value
+		requests atomic.Int32
+	)
+
+	keys.Store([]map[string]string{jwkOf("rsa-1", &rsaKey.PublicKey), jwkOf("ec-1", &ecKey.PublicKey)})
+
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": keys.Load()})
+	}))
+	defer provider.Close()
+
+	jwks, jwksErr := NewJWKS(JWKSConfig{URL: provider.URL, RefreshInterval: time.Hour, MinRefreshInterval: time.Minute}, slog.Default())
+	td.Require(t).CmpNoError(jwksErr)
+
+	cfg := JWTConfig{Keys: jwks}
+	claims := Claims{Subject: "orders-service", ExpiresAt: now.Add(time.Hour).Unix()}
+
+	type tcase struct {
+		token   string
+		wantErr error
+	}
+
+	tests := map[string]tcase{
+		"RS256": {token: signAsymmetric(t, claims, "RS256", "rsa-1", rsaKey)},
+		"ES256": {token: signAsymmetric(t, claims, "ES256", "ec-1", ecKey)},
+		"WrongKey": {
+			token:   signAsymmetric(t, claims, "ES256", "ec-1", rotated),
+			wantErr: ErrInvalidSignature,
+		},
+		"AlgKeyMismatch": {
+			token:   signAsymmetric(t, claims, "RS256", "ec-1", rsaKey),
+			wantErr: ErrInvalidSignature,
+		},
+		"HS256WithoutSecret": {
+			token: func() string {
+				signed, err := SignJWT(claims, []byte("secret"))
+				td.Require(t).CmpNoError(err)
+
+				return signed
+			}(),
+			wantErr: ErrInvalidSignature,
+		},
+		"Expired": {
+			token:   signAsymmetric(t, Claims{Subject: "orders-service", ExpiresAt: now.Add(-time.Hour).Unix()}, "RS256", "rsa-1", rsaKey),
+			wantErr: ErrExpiredToken,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := VerifyJWT(ctx, tc.token, cfg, now)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, got.Subject, "orders-service")
+		})
+	}
+
+	td.Cmp(t, requests.Load(), int32(1))
+
+	// Unknown key ids are refreshed at most once per the minimal refresh interval.
+	keys.Store([]map[string]string{jwkOf("ec-2", &rotated.PublicKey)})
+
+	_, err := VerifyJWT(ctx, signAsymmetric(t, claims, "ES256", "ec-2", rotated), cfg, now)
+	td.CmpErrorIs(t, err, ErrInvalidSignature)
+	td.Cmp(t, requests.Load(), int32(1))
+
+	// Keys are rotated once the interval has passed.
+	jwks.now = func() time.Time { return now.Add(2 * time.Minute) }
+
+	_, err = VerifyJWT(ctx, signAsymmetric(t, claims, "ES256", "ec-2", rotated), cfg, now)
+	td.CmpNoError(t, err)
+	td.Cmp(t, requests.Load(), int32(2))
+
+	_, err = VerifyJWT(ctx, signAsymmetric(t, claims, "ES256", "ec-1", ecKey), cfg, now)
+	td.CmpErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestParseJWKS(t *testing.T) {
+	ecKey, ecErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	td.Require(t).CmpNoError(ecErr)
+
+	valid, _ := json.Marshal(map[string]any{"keys": []any{
+		jwkOf("ec-1", &ecKey.PublicKey),
+		map[string]string{"kty": "RSA", "kid": "enc-1", "use": "enc"},
+		map[string]string{"kty": "oct", "kid": "oct-1", "k": "c2VjcmV0"},
+	}})
+
+	type tcase struct {
+		data     []byte
+		wantKeys []string
+		wantErr  bool
+	}
+
+	tests := map[string]tcase{
+		"SkipsUnsupported": {data: valid, wantKeys: []string{"ec-1"}},
+		"Malformed":        {data: []byte(`{"keys":`), wantErr: true},
+		"OffCurve": {
+			data:    []byte(`{"keys":[{"kty":"EC","kid":"bad","crv":"P-256","x":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA","y":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE"}]}`),
+			wantErr: true,
+		},
+		"ShortRSA": {
+			data:    []byte(`{"keys":[{"kty":"RSA","kid":"short","n":"AQAB","e":"AQAB"}]}`),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			keys, err := ParseJWKS(tc.data)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, keys, td.Len(len(tc.wantKeys)))
+
+			for _, kid := range tc.wantKeys {
+				td.Cmp(t, keys, td.ContainsKey(kid))
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+// Package auth authenticates API clients by credentials they present,
+// e.g. JSON Web Tokens, and maps them to identities.
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Errors of token validation, which tell why the token has been rejected.
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrExpiredToken     = errors.New("token is expired or not valid yet")
+	ErrInvalidClaims    = errors.New("invalid token claims")
+)
+
+// KeyResolver resolves public keys which verify signatures of tokens, e.g. from the JWKS.
+type KeyResolver interface {
+	// PublicKey returns the key with the id of the "kid" header of the token.
+	PublicKey(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// JWTConfig holds the configuration of the validation of JSON Web Tokens.
+type JWTConfig struct {
+	// Secret is the key of HS256 signatures of tokens.
+	// HS256 tokens are not accepted when it's empty.
+	Secret []byte
+
+	// Keys resolves public keys of RS256 and ES256 signatures of tokens,
+	// e.g. issued by an OAuth provider. Such tokens are not accepted when it's nil.
+	Keys KeyResolver
+
+	// Issuer is the expected "iss" claim, any issuer is accepted when it's empty.
+	Issuer string
+
+	// Audience is the expected "aud" claim, any audience is accepted when it's empty.
+	Audience string
+
+	// Leeway is the allowed clock skew of the expiration and not before times.
+	Leeway time.Duration
+}
+
+// Claims represents registered claims of the token which identify the client.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ID        string   `json:"jti,omitempty"`
+}
+
+// Audience represents the "aud" claim, which is either a string or an array of strings.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+
+	*a = multiple
+
+	return nil
+}
+
+// jwtHeader represents the header of the token.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// VerifyJWT verifies the signature and validates claims of the compact serialized token
+// at the time now. Tokens should be signed with HS256 by the secret, or with RS256 or ES256
+// by one of the keys, and have the subject and expiration.
+func VerifyJWT(ctx context.Context, token string, cfg JWTConfig, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: token should have 3 parts", ErrMalformedToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %w", ErrMalformedToken, err)
+	}
+
+	signature, sigErr := base64.RawURLEncoding.DecodeString(parts[2])
+	if sigErr != nil {
+		return nil, fmt.Errorf("%w: signature: %w", ErrMalformedToken, sigErr)
+	}
+
+	if err := verifySignature(ctx, header, parts[0]+"."+parts[1], signature, cfg); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %w", ErrMalformedToken, err)
+	}
+
+	if err := claims.validate(cfg, now); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// ParseJWT decodes claims of the compact serialized token without verifying it,
+// so clients can inspect tokens they have been issued. The signature and claims
+// are verified by the server, claims returned here should not be trusted.
+func ParseJWT(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: token should have 3 parts", ErrMalformedToken)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %w", ErrMalformedToken, err)
+	}
+
+	return &claims, nil
+}
+
+// SignJWT returns the compact serialized token with the claims signed with HS256.
+func SignJWT(claims Claims, secret []byte) (string, error) {
+	header, headerErr := json.Marshal(jwtHeader{Alg: "HS256", Typ: "JWT"})
+	if headerErr != nil {
+		return "", fmt.Errorf("marshal header: %w", headerErr)
+	}
+
+	payload, payloadErr := json.Marshal(claims)
+	if payloadErr != nil {
+		return "", fmt.Errorf("marshal claims: %w", payloadErr)
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(signed, secret)), nil
+}
+
+// validate checks time constraints and the audience and issuer of the claims.
+func (c *Claims) validate(cfg JWTConfig, now time.Time) error {
+	if c.Subject == "" {
+		return fmt.Errorf("%w: subject is empty", ErrInvalidClaims)
+	}
+
+	if c.ExpiresAt == 0 {
+		return fmt.Errorf("%w: expiration is not set", ErrInvalidClaims)
+	}
+
+	if now.After(time.Unix(c.ExpiresAt, 0).Add(cfg.Leeway)) {
+		return ErrExpiredToken
+	}
+
+	if c.NotBefore != 0 && now.Before(time.Unix(c.NotBefore, 0).Add(-cfg.Leeway)) {
+		return ErrExpiredToken
+	}
+
+	if cfg.Issuer != "" && c.Issuer != cfg.Issuer {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidClaims, c.Issuer)
+	}
+
+	if cfg.Audience != "" && !slices.Contains(c.Audience, cfg.Audience) {
+		return fmt.Errorf("%w: token is not issued for audience %q", ErrInvalidClaims, cfg.Audience)
+	}
+
+	return nil
+}
+
+// verifySignature verifies the signature of the signing input with the algorithm
+// of the header. Tokens which choose the algorithm, e.g. "none", are never trusted,
+// and the key should be of the algorithm, so public keys are never used as secrets.
+func verifySignature(ctx context.Context, header jwtHeader, input string, signature []byte, cfg JWTConfig) error {
+	switch header.Alg {
+	case "HS256":
+		if len(cfg.Secret) == 0 {
+			return fmt.Errorf("%w: HS256 tokens are not accepted", ErrInvalidSignature)
+		}
+
+		if !hmac.Equal(signature, sign(input, cfg.Secret)) {
+			return ErrInvalidSignature
+		}
+
+		return nil
+
+	case "RS256", "ES256":
+		if cfg.Keys == nil {
+			return fmt.Errorf("%w: %s tokens are not accepted", ErrInvalidSignature, header.Alg)
+		}
+
+		key, keyErr := cfg.Keys.PublicKey(ctx, header.Kid)
+		if keyErr != nil {
+			return keyErr
+		}
+
+		digest := sha256.Sum256([]byte(input))
+
+		if header.Alg == "RS256" {
+			rsaKey, ok := key.(*rsa.PublicKey)
+			if !ok {
+				return fmt.Errorf("%w: key %q is not an RSA key", ErrInvalidSignature, header.Kid)
+			}
+
+			if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature); err != nil {
+				return ErrInvalidSignature
+			}
+
+			return nil
+		}
+
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve != elliptic.P256() {
+			return fmt.Errorf("%w: key %q is not a P-256 key", ErrInvalidSignature, header.Kid)
+		}
+
+		// ES256 signatures are the concatenation of R and S, not the ASN.1 structure.
+		if len(signature) != 64 {
+			return fmt.Errorf("%w: ES256 signature should be 64 bytes", ErrMalformedToken)
+		}
+
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+
+		return nil
+
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, header.Alg)
+	}
+}
+
+// sign returns the HS256 signature of the signing input.
+func sign(input string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+
+	return mac.Sum(nil)
+}
+
+// decodeSegment decodes the base64url encoded JSON segment of the token.
+func decodeSegment(segment string, v any) error {
+	data, decodeErr := base64.RawURLEncoding.DecodeString(segment)
+	if decodeErr != nil {
+		return decodeErr
+	}
+
+	return json.Unmarshal(data, v)
+}
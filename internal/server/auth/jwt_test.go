@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestVerifyJWT(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1_700_000_000, 0)
+
+	token := func(claims Claims) string {
+		signed, err := SignJWT(claims, secret)
+		td.Require(t).CmpNoError(err)
+
+		return signed
+	}
+
+	valid := Claims{
+		Subject:   "orders-service",
+		Issuer:    "plainq",
+		Audience:  Audience{"queues"},
+		ExpiresAt: now.Add(time.Hour).Unix(),
+	}
+
+	noneHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	parts := strings.Split(token(valid), ".")
+
+	type tcase struct {
+		token   string
+		cfg     JWTConfig
+		want    string
+		wantErr error
+	}
+
+	tests := map[string]tcase{
+		"Valid": {
+			token: token(valid),
+			cfg:   JWTConfig{Secret: secret, Issuer: "plainq", Audience: "queues"},
+			want:  "orders-service",
+		},
+		"Expired": {
+			token:   token(Claims{Subject: "orders-service", ExpiresAt: now.Add(-time.Minute).Unix()}),
+			cfg:     JWTConfig{Secret: secret},
+			wantErr: ErrExpiredToken,
+		},
+		"ExpiredWithinLeeway": {
+			token: token(Claims{Subject: "orders-service", ExpiresAt: now.Add(-time.Minute).Unix()}),
+			cfg:   JWTConfig{Secret: secret, Leeway: 2 * time.Minute},
+			want:  "orders-service",
+		},
+		"NotYetValid": {
+			token:   token(Claims{Subject: "orders-service", ExpiresAt: now.Add(time.Hour).Unix(), NotBefore: now.Add(time.Minute).Unix()}),
+			cfg:     JWTConfig{Secret: secret},
+			wantErr: ErrExpiredToken,
+		},
+		"BadSignature": {
+			token:   token(valid),
+			cfg:     JWTConfig{Secret: []byte("other")},
+			wantErr: ErrInvalidSignature,
+		},
+		"AlgNone": {
+			token:   noneHeader + "." + parts[1] + ".",
+			cfg:     JWTConfig{Secret: secret},
+			wantErr: ErrInvalidSignature,
+		},
+		"Malformed": {
+			token:   "not-a-token",
+			cfg:     JWTConfig{Secret: secret},
+			wantErr: ErrMalformedToken,
+		},
+		"WrongIssuer": {
+			token:   token(valid),
+			cfg:     JWTConfig{Secret: secret, Issuer: "other"},
+			wantErr: ErrInvalidClaims,
+		},
+		"WrongAudience": {
+			token:   token(valid),
+			cfg:     JWTConfig{Secret: secret, Audience: "other"},
+			wantErr: ErrInvalidClaims,
+		},
+		"NoExpiration": {
+			token:   token(Claims{Subject: "orders-service"}),
+			cfg:     JWTConfig{Secret: secret},
+			wantErr: ErrInvalidClaims,
+		},
+		"NoSubject": {
+			token:   token(Claims{ExpiresAt: now.Add(time.Hour).Unix()}),
+			cfg:     JWTConfig{Secret: secret},
+			wantErr: ErrInvalidClaims,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims, err := VerifyJWT(context.Background(), tc.token, tc.cfg, now)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, claims.Subject, tc.want)
+		})
+	}
+}
+
+func TestParseJWT(t *testing.T) {
+	claims := Claims{Subject: "ada@example.com", Issuer: "plainq", ExpiresAt: 1_700_000_000}
+
+	signed, signErr := SignJWT(claims, []byte("secret"))
+	td.Require(t).CmpNoError(signErr)
+
+	parsed, parseErr := ParseJWT(signed)
+	td.CmpNoError(t, parseErr)
+	td.Cmp(t, parsed, &claims)
+
+	_, parseErr = ParseJWT("pq_key")
+	td.CmpErrorIs(t, parseErr, ErrMalformedToken)
+
+	_, parseErr = ParseJWT("a.b.c")
+	td.CmpErrorIs(t, parseErr, ErrMalformedToken)
+}
+
+func TestAudience_UnmarshalJSON(t *testing.T) {
+	var single, multiple Audience
+
+	td.CmpNoError(t, single.UnmarshalJSON([]byte(`"queues"`)))
+	td.Cmp(t, single, Audience{"queues"})
+
+	td.CmpNoError(t, multiple.UnmarshalJSON([]byte(`["queues","houston"]`)))
+	td.Cmp(t, multiple, Audience{"queues", "houston"})
+}
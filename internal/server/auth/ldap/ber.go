@@ -0,0 +1,181 @@
+package ldap
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// maxElementSize limits the size of elements read from the directory.
+const maxElementSize = 1 << 20
+
+// Tags of BER elements used by LDAP messages, see RFC 4511.
+const (
+	tagBoolean     byte = 0x01
+	tagInteger     byte = 0x02
+	tagOctetString byte = 0x04
+	tagEnumerated  byte = 0x0a
+	tagSequence    byte = 0x30
+	tagSet         byte = 0x31
+
+	tagBindRequest       byte = 0x60
+	tagBindResponse      byte = 0x61
+	tagUnbindRequest     byte = 0x42
+	tagSearchRequest     byte = 0x63
+	tagSearchResultEntry byte = 0x64
+	tagSearchResultDone  byte = 0x65
+	tagSearchResultRef   byte = 0x73
+
+	tagSimpleAuth    byte = 0x80
+	tagEqualityMatch byte = 0xa3
+)
+
+// element represents the decoded BER element.
+type element struct {
+	tag     byte
+	content []byte
+}
+
+// encode returns the element with the tag and the content.
+func encode(tag byte, content []byte) []byte {
+	n := len(content)
+
+	var length []byte
+
+	switch {
+	case n < 0x80:
+		length = []byte{byte(n)}
+
+	case n <= 0xff:
+		length = []byte{0x81, byte(n)}
+
+	case n <= 0xffff:
+		length = []byte{0x82, byte(n >> 8), byte(n)}
+
+	default:
+		length = []byte{0x84, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+	}
+
+	out := make([]byte, 0, 1+len(length)+n)
+	out = append(out, tag)
+	out = append(out, length...)
+
+	return append(out, content...)
+}
+
+// encodeConstructed returns the element which holds the elements.
+func encodeConstructed(tag byte, elements ...[]byte) []byte {
+	var content []byte
+
+	for _, e := range elements {
+		content = append(content, e...)
+	}
+
+	return encode(tag, content)
+}
+
+// encodeInt returns the integer element in the minimal two's complement form.
+func encodeInt(tag byte, v int64) []byte {
+	content := []byte{byte(v)}
+
+	for v >>= 8; v != 0 && v != -1; v >>= 8 {
+		content = append([]byte{byte(v)}, content...)
+	}
+
+	// The sign bit of the first byte should match the sign of the value.
+	if v == 0 && content[0]&0x80 != 0 {
+		content = append([]byte{0}, content...)
+	}
+
+	if v == -1 && content[0]&0x80 == 0 {
+		content = append([]byte{0xff}, content...)
+	}
+
+	return encode(tag, content)
+}
+
+// encodeString returns the string element, e.g. the octet string.
+func encodeString(tag byte, s string) []byte { return encode(tag, []byte(s)) }
+
+// byteReader is the reader of elements, e.g. bufio.Reader of the connection.
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// readElement reads the element from the reader.
+func readElement(r byteReader) (element, error) {
+	tag, tagErr := r.ReadByte()
+	if tagErr != nil {
+		return element{}, tagErr
+	}
+
+	first, lengthErr := r.ReadByte()
+	if lengthErr != nil {
+		return element{}, lengthErr
+	}
+
+	n := int(first)
+
+	if first&0x80 != 0 {
+		size := int(first & 0x7f)
+		if size == 0 || size > 4 {
+			return element{}, fmt.Errorf("unsupported length of %d bytes", size)
+		}
+
+		n = 0
+
+		for range size {
+			b, err := r.ReadByte()
+			if err != nil {
+				return element{}, err
+			}
+
+			n = n<<8 | int(b)
+		}
+	}
+
+	if n > maxElementSize {
+		return element{}, fmt.Errorf("element of %d bytes exceeds the limit of %d bytes", n, maxElementSize)
+	}
+
+	content := make([]byte, n)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return element{}, err
+	}
+
+	return element{tag: tag, content: content}, nil
+}
+
+// children decodes elements held by the constructed element.
+func (e element) children() ([]element, error) {
+	r := bytes.NewReader(e.content)
+
+	var elements []element
+
+	for r.Len() > 0 {
+		child, err := readElement(r)
+		if err != nil {
+			return nil, fmt.Errorf("decode element: %w", err)
+		}
+
+		elements = append(elements, child)
+	}
+
+	return elements, nil
+}
+
+// int returns the value of the integer or enumerated element.
+func (e element) int() (int64, error) {
+	if len(e.content) == 0 || len(e.content) > 8 {
+		return 0, fmt.Errorf("invalid integer of %d bytes", len(e.content))
+	}
+
+	v := int64(int8(e.content[0]))
+
+	for _, b := range e.content[1:] {
+		v = v<<8 | int64(b)
+	}
+
+	return v, nil
+}
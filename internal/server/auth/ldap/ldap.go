@@ -0,0 +1,464 @@
+// Package ldap verifies passwords of users by the LDAP directory, e.g. Active Directory,
+// and maps groups of users to plainq roles. It speaks the subset of LDAPv3 which
+// the sign-in needs: simple binds and equality searches.
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/auth"
+)
+
+// Result codes of LDAP operations, see RFC 4511.
+const (
+	resultSuccess            = 0
+	resultSizeLimitExceeded  = 4
+	resultInvalidCredentials = 49
+)
+
+// Config holds the configuration of the Directory.
+type Config struct {
+	// URL is the address of the directory, e.g. "ldaps://ldap.example.com:636".
+	// The "ldap" scheme connects without TLS.
+	URL string
+
+	// BindDN and BindPassword authenticate the search of users.
+	// The search is anonymous when the bind DN is empty.
+	BindDN       string
+	BindPassword string
+
+	// BaseDN is the entry users are searched under, e.g. "ou=people,dc=example,dc=com".
+	BaseDN string
+
+	// UserAttribute is the attribute which equals the username,
+	// e.g. "uid", or "sAMAccountName" for Active Directory.
+	UserAttribute string
+
+	// EmailAttribute is the attribute of the email, which is the identity of the user.
+	// The username is the identity when the user has no email.
+	EmailAttribute string
+
+	// GroupAttribute is the attribute which lists groups of the user, e.g. "memberOf".
+	GroupAttribute string
+
+	// GroupRoles maps DNs of groups to names of roles. Roles of users are
+	// not managed by the directory when it's empty.
+	GroupRoles map[string]string
+
+	// Timeout is the timeout of the sign-in conversation with the directory.
+	Timeout time.Duration
+
+	// TLS configures the connection of the "ldaps" scheme.
+	TLS *tls.Config
+}
+
+// Directory verifies passwords of users by the LDAP directory.
+type Directory struct {
+	cfg        Config
+	addr       string
+	useTLS     bool
+	groupRoles map[string]string
+}
+
+// New returns a pointer to a new instance of Directory.
+func New(cfg Config) (*Directory, error) {
+	u, parseErr := url.Parse(cfg.URL)
+	if parseErr != nil {
+		return nil, fmt.Errorf("parse LDAP URL: %w", parseErr)
+	}
+
+	d := Directory{cfg: cfg, addr: u.Host}
+
+	switch u.Scheme {
+	case "ldap":
+		if u.Port() == "" {
+			d.addr = net.JoinHostPort(u.Hostname(), "389")
+		}
+
+	case "ldaps":
+		d.useTLS = true
+
+		if u.Port() == "" {
+			d.addr = net.JoinHostPort(u.Hostname(), "636")
+		}
+
+	default:
+		return nil, fmt.Errorf("LDAP URL should have ldap or ldaps scheme: %q", cfg.URL)
+	}
+
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("LDAP URL should have the host: %q", cfg.URL)
+	}
+
+	if cfg.BaseDN == "" || cfg.UserAttribute == "" {
+		return nil, errors.New("LDAP base DN and user attribute should be specified")
+	}
+
+	// DNs are matched case-insensitively, as directories do.
+	if len(cfg.GroupRoles) > 0 {
+		d.groupRoles = make(map[string]string, len(cfg.GroupRoles))
+
+		for group, role := range cfg.GroupRoles {
+			d.groupRoles[normalizeDN(group)] = role
+		}
+	}
+
+	return &d, nil
+}
+
+// VerifyPassword finds the user by the username and binds as the user with the password.
+// Roles of the principal are mapped from groups of the user, when groups are mapped.
+func (d *Directory) VerifyPassword(ctx context.Context, username, password string) (*auth.Principal, error) {
+	// The simple bind with the empty password is the unauthenticated
+	// bind, which succeeds without checking anything.
+	if password == "" {
+		return nil, auth.ErrInvalidPassword
+	}
+
+	c, dialErr := d.dial(ctx)
+	if dialErr != nil {
+		return nil, fmt.Errorf("connect to LDAP directory: %w", dialErr)
+	}
+
+	defer c.close()
+
+	if d.cfg.BindDN != "" {
+		if err := c.bind(d.cfg.BindDN, d.cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("bind as %q: %w", d.cfg.BindDN, err)
+		}
+	}
+
+	var attributes []string
+
+	for _, attr := range []string{d.cfg.EmailAttribute, d.cfg.GroupAttribute} {
+		if attr != "" {
+			attributes = append(attributes, attr)
+		}
+	}
+
+	user, searchErr := c.searchUser(d.cfg.BaseDN, d.cfg.UserAttribute, username, attributes)
+	if searchErr != nil {
+		return nil, searchErr
+	}
+
+	if err := c.bind(user.dn, password); err != nil {
+		var resErr *resultError
+		if errors.As(err, &resErr) && resErr.code == resultInvalidCredentials {
+			return nil, auth.ErrInvalidPassword
+		}
+
+		return nil, fmt.Errorf("bind as %q: %w", user.dn, err)
+	}
+
+	principal := auth.Principal{Subject: username}
+
+	if emails := user.attribute(d.cfg.EmailAttribute); len(emails) > 0 && emails[0] != "" {
+		principal.Subject = emails[0]
+	}
+
+	if d.groupRoles != nil {
+		principal.Roles = []string{}
+
+		for _, group := range user.attribute(d.cfg.GroupAttribute) {
+			if role, ok := d.groupRoles[normalizeDN(group)]; ok && !slices.Contains(principal.Roles, role) {
+				principal.Roles = append(principal.Roles, role)
+			}
+		}
+
+		slices.Sort(principal.Roles)
+	}
+
+	return &principal, nil
+}
+
+// dial connects to the directory, the connection expires with the context or the timeout.
+func (d *Directory) dial(ctx context.Context) (*conn, error) {
+	if d.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, d.cfg.Timeout)
+		defer cancel()
+	}
+
+	var (
+		nc  net.Conn
+		err error
+	)
+
+	if d.useTLS {
+		dialer := tls.Dialer{Config: d.cfg.TLS}
+		nc, err = dialer.DialContext(ctx, "tcp", d.addr)
+	} else {
+		var dialer net.Dialer
+		nc, err = dialer.DialContext(ctx, "tcp", d.addr)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The conversation is bound by the deadline of the dial, which includes the timeout.
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = nc.SetDeadline(deadline)
+	}
+
+	return &conn{nc: nc, r: bufio.NewReader(nc)}, nil
+}
+
+// normalizeDN returns the DN in the form which is compared, e.g. "cn=admins,dc=example,dc=com".
+func normalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+
+	for i, p := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(p))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// conn represents the connection to the directory.
+type conn struct {
+	nc    net.Conn
+	r     *bufio.Reader
+	msgID int64
+}
+
+// entry represents the entry found by the search.
+type entry struct {
+	dn         string
+	attributes map[string][]string
+}
+
+// attribute returns values of the attribute, names of attributes are case-insensitive.
+func (e *entry) attribute(name string) []string {
+	return e.attributes[strings.ToLower(name)]
+}
+
+// resultError represents the LDAP result which is not successful.
+type resultError struct {
+	code    int64
+	message string
+}
+
+func (e *resultError) Error() string {
+	if e.message == "" {
+		return fmt.Sprintf("LDAP result code %d", e.code)
+	}
+
+	return fmt.Sprintf("LDAP result code %d: %s", e.code, e.message)
+}
+
+// bind authenticates the connection with the DN and the password.
+func (c *conn) bind(dn, password string) error {
+	op := encodeConstructed(tagBindRequest,
+		encodeInt(tagInteger, 3),
+		encodeString(tagOctetString, dn),
+		encodeString(tagSimpleAuth, password),
+	)
+
+	if err := c.send(op); err != nil {
+		return err
+	}
+
+	res, readErr := c.receive()
+	if readErr != nil {
+		return readErr
+	}
+
+	if res.tag != tagBindResponse {
+		return fmt.Errorf("unexpected response 0x%02x to the bind request", res.tag)
+	}
+
+	return result(res)
+}
+
+// searchUser returns the only entry under the base whose attribute equals the value.
+func (c *conn) searchUser(base, attribute, value string, attributes []string) (*entry, error) {
+	requested := make([][]byte, 0, len(attributes))
+	for _, attr := range attributes {
+		requested = append(requested, encodeString(tagOctetString, attr))
+	}
+
+	// The value is sent as is, since the filter is encoded rather than parsed from
+	// the string, which leaves no room for the injection of filters by usernames.
+	op := encodeConstructed(tagSearchRequest,
+		encodeString(tagOctetString, base),
+		encodeInt(tagEnumerated, 2), // Whole subtree.
+		encodeInt(tagEnumerated, 0), // Never dereference aliases.
+		encodeInt(tagInteger, 2),    // Two entries tell the ambiguous username.
+		encodeInt(tagInteger, 0),
+		encode(tagBoolean, []byte{0}),
+		encodeConstructed(tagEqualityMatch,
+			encodeString(tagOctetString, attribute),
+			encodeString(tagOctetString, value),
+		),
+		encodeConstructed(tagSequence, requested...),
+	)
+
+	if err := c.send(op); err != nil {
+		return nil, err
+	}
+
+	var entries []*entry
+
+	for {
+		res, readErr := c.receive()
+		if readErr != nil {
+			return nil, readErr
+		}
+
+		switch res.tag {
+		case tagSearchResultEntry:
+			e, parseErr := parseEntry(res)
+			if parseErr != nil {
+				return nil, parseErr
+			}
+
+			entries = append(entries, e)
+
+		case tagSearchResultRef:
+			// Referrals to other directories are not followed.
+
+		case tagSearchResultDone:
+			err := result(res)
+
+			var resErr *resultError
+			if errors.As(err, &resErr) && resErr.code == resultSizeLimitExceeded || len(entries) > 1 {
+				return nil, fmt.Errorf("username %q matches more than one LDAP entry", value)
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("search user %q: %w", value, err)
+			}
+
+			if len(entries) == 0 {
+				return nil, auth.ErrUnknownUser
+			}
+
+			return entries[0], nil
+
+		default:
+			return nil, fmt.Errorf("unexpected response 0x%02x to the search request", res.tag)
+		}
+	}
+}
+
+// close unbinds and closes the connection.
+func (c *conn) close() {
+	_ = c.send(encode(tagUnbindRequest, nil))
+	_ = c.nc.Close()
+}
+
+// send writes the message with the operation.
+func (c *conn) send(op []byte) error {
+	c.msgID++
+
+	if _, err := c.nc.Write(encodeConstructed(tagSequence, encodeInt(tagInteger, c.msgID), op)); err != nil {
+		return fmt.Errorf("write LDAP message: %w", err)
+	}
+
+	return nil
+}
+
+// receive reads the message and returns its operation.
+func (c *conn) receive() (element, error) {
+	msg, readErr := readElement(c.r)
+	if readErr != nil {
+		return element{}, fmt.Errorf("read LDAP message: %w", readErr)
+	}
+
+	parts, partsErr := msg.children()
+	if partsErr != nil {
+		return element{}, fmt.Errorf("read LDAP message: %w", partsErr)
+	}
+
+	if msg.tag != tagSequence || len(parts) < 2 {
+		return element{}, errors.New("read LDAP message: malformed message")
+	}
+
+	id, idErr := parts[0].int()
+	if idErr != nil {
+		return element{}, fmt.Errorf("read LDAP message id: %w", idErr)
+	}
+
+	if id != c.msgID {
+		return element{}, fmt.Errorf("unexpected LDAP message id %d, want %d", id, c.msgID)
+	}
+
+	return parts[1], nil
+}
+
+// result returns the error of the LDAP result which is not successful.
+func result(res element) error {
+	parts, err := res.children()
+	if err != nil {
+		return err
+	}
+
+	if len(parts) < 3 {
+		return errors.New("malformed LDAP result")
+	}
+
+	code, codeErr := parts[0].int()
+	if codeErr != nil {
+		return fmt.Errorf("LDAP result code: %w", codeErr)
+	}
+
+	if code == resultSuccess {
+		return nil
+	}
+
+	return &resultError{code: code, message: string(parts[2].content)}
+}
+
+// parseEntry parses the search result entry.
+func parseEntry(res element) (*entry, error) {
+	parts, err := res.children()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(parts) < 2 {
+		return nil, errors.New("malformed LDAP entry")
+	}
+
+	e := entry{dn: string(parts[0].content), attributes: make(map[string][]string)}
+
+	attributes, attrsErr := parts[1].children()
+	if attrsErr != nil {
+		return nil, attrsErr
+	}
+
+	for _, attr := range attributes {
+		fields, fieldsErr := attr.children()
+		if fieldsErr != nil {
+			return nil, fieldsErr
+		}
+
+		if len(fields) < 2 {
+			return nil, errors.New("malformed LDAP attribute")
+		}
+
+		values, valuesErr := fields[1].children()
+		if valuesErr != nil {
+			return nil, valuesErr
+		}
+
+		name := strings.ToLower(string(fields[0].content))
+
+		for _, v := range values {
+			e.attributes[name] = append(e.attributes[name], string(v.content))
+		}
+	}
+
+	return &e, nil
+}
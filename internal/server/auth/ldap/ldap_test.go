@@ -0,0 +1,226 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/auth"
+)
+
+// fakeUser represents the entry of the fake directory.
+type fakeUser struct {
+	dn       string
+	uid      string
+	password string
+	mail     string
+	groups   []string
+}
+
+// serveFake serves the fake directory with the users on the listener,
+// which accepts binds of the service account "cn=svc" with the password "svc".
+func serveFake(t *testing.T, ln net.Listener, users []fakeUser) {
+	t.Helper()
+
+	for {
+		nc, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer func() { _ = nc.Close() }()
+
+			r := bufio.NewReader(nc)
+
+			for {
+				msg, readErr := readElement(r)
+				if readErr != nil {
+					return
+				}
+
+				parts, _ := msg.children()
+				id, _ := parts[0].int()
+
+				reply := func(op []byte) {
+					_, _ = nc.Write(encodeConstructed(tagSequence, encodeInt(tagInteger, id), op))
+				}
+
+				ldapResult := func(tag byte, code int64) []byte {
+					return encodeConstructed(tag,
+						encodeInt(tagEnumerated, code),
+						encodeString(tagOctetString, ""),
+						encodeString(tagOctetString, ""),
+					)
+				}
+
+				op := parts[1]
+				fields, _ := op.children()
+
+				switch op.tag {
+				case tagBindRequest:
+					dn, password := string(fields[1].content), string(fields[2].content)
+
+					code := int64(resultInvalidCredentials)
+					if dn == "cn=svc" && password == "svc" {
+						code = resultSuccess
+					}
+
+					for _, u := range users {
+						if u.dn == dn && u.password == password {
+							code = resultSuccess
+						}
+					}
+
+					reply(ldapResult(tagBindResponse, code))
+
+				case tagSearchRequest:
+					assertion, _ := fields[6].children()
+					attr, value := string(assertion[0].content), string(assertion[1].content)
+
+					for _, u := range users {
+						if attr != "uid" || u.uid != value {
+							continue
+						}
+
+						groups := make([][]byte, 0, len(u.groups))
+						for _, g := range u.groups {
+							groups = append(groups, encodeString(tagOctetString, g))
+						}
+
+						reply(encodeConstructed(tagSearchResultEntry,
+							encodeString(tagOctetString, u.dn),
+							encodeConstructed(tagSequence,
+								encodeConstructed(tagSequence,
+									encodeString(tagOctetString, "mail"),
+									encodeConstructed(tagSet, encodeString(tagOctetString, u.mail)),
+								),
+								encodeConstructed(tagSequence,
+									encodeString(tagOctetString, "memberOf"),
+									encodeConstructed(tagSet, groups...),
+								),
+							),
+						))
+					}
+
+					reply(ldapResult(tagSearchResultDone, resultSuccess))
+
+				default:
+					return
+				}
+			}
+		}()
+	}
+}
+
+func TestDirectory_VerifyPassword(t *testing.T) {
+	ln, lnErr := net.Listen("tcp", "127.0.0.1:0")
+	td.Require(t).CmpNoError(lnErr)
+
+	defer func() { _ = ln.Close() }()
+
+	go serveFake(t, ln, []fakeUser{
+		{
+			dn:       "uid=alice,ou=people,dc=example,dc=com",
+			uid:      "alice",
+			password: "wonderland",
+			mail:     "alice@example.com",
+			groups:   []string{"CN=Admins, ou=groups,dc=example,dc=com", "cn=staff,ou=groups,dc=example,dc=com"},
+		},
+		{
+			dn:       "uid=bob,ou=people,dc=example,dc=com",
+			uid:      "bob",
+			password: "builder",
+		},
+		{dn: "uid=twin,ou=a,dc=example,dc=com", uid: "twin", password: "twin"},
+		{dn: "uid=twin,ou=b,dc=example,dc=com", uid: "twin", password: "twin"},
+	})
+
+	cfg := Config{
+		URL:            "ldap://" + ln.Addr().String(),
+		BindDN:         "cn=svc",
+		BindPassword:   "svc",
+		BaseDN:         "dc=example,dc=com",
+		UserAttribute:  "uid",
+		EmailAttribute: "mail",
+		GroupAttribute: "memberOf",
+		GroupRoles:     map[string]string{"cn=admins,ou=groups,dc=example,dc=com": "admin"},
+		Timeout:        5 * time.Second,
+	}
+
+	type tcase struct {
+		cfg      func(cfg Config) Config
+		username string
+		password string
+		want     *auth.Principal
+		wantErr  error
+		anyErr   bool
+	}
+
+	tests := map[string]tcase{
+		"MappedGroups": {
+			username: "alice",
+			password: "wonderland",
+			want:     &auth.Principal{Subject: "alice@example.com", Roles: []string{"admin"}},
+		},
+		"WithoutEmailAndGroups": {
+			username: "bob",
+			password: "builder",
+			want:     &auth.Principal{Subject: "bob", Roles: []string{}},
+		},
+		"UnmappedGroups": {
+			cfg:      func(cfg Config) Config { cfg.GroupRoles = nil; return cfg },
+			username: "alice",
+			password: "wonderland",
+			want:     &auth.Principal{Subject: "alice@example.com"},
+		},
+		"WrongPassword":  {username: "alice", password: "nope", wantErr: auth.ErrInvalidPassword},
+		"EmptyPassword":  {username: "alice", password: "", wantErr: auth.ErrInvalidPassword},
+		"UnknownUser":    {username: "carol", password: "secret", wantErr: auth.ErrUnknownUser},
+		"AmbiguousUser":  {username: "twin", password: "twin", anyErr: true},
+		"FilterInjected": {username: "*)(uid=*", password: "secret", wantErr: auth.ErrUnknownUser},
+		"WrongBindPassword": {
+			cfg:      func(cfg Config) Config { cfg.BindPassword = "nope"; return cfg },
+			username: "alice",
+			password: "wonderland",
+			anyErr:   true,
+		},
+		"Unreachable": {
+			cfg:      func(cfg Config) Config { cfg.URL = "ldap://127.0.0.1:1"; return cfg },
+			username: "alice",
+			password: "wonderland",
+			anyErr:   true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := cfg
+			if tc.cfg != nil {
+				c = tc.cfg(c)
+			}
+
+			d, newErr := New(c)
+			td.Require(t).CmpNoError(newErr)
+
+			got, err := d.VerifyPassword(context.Background(), tc.username, tc.password)
+
+			switch {
+			case tc.wantErr != nil:
+				td.CmpErrorIs(t, err, tc.wantErr)
+
+			case tc.anyErr:
+				td.CmpError(t, err)
+				td.CmpFalse(t, errors.Is(err, auth.ErrInvalidPassword) || errors.Is(err, auth.ErrUnknownUser))
+
+			default:
+				td.CmpNoError(t, err)
+				td.Cmp(t, got, tc.want)
+			}
+		})
+	}
+}
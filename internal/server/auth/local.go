@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/plainq/servekit/errkit"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyPasswordHash is compared for unknown users, so they take as long
+// to reject as wrong passwords and can't be told apart by timing.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("plainq"), bcrypt.DefaultCost)
+	return hash
+})
+
+// PasswordStore looks up password hashes of local accounts.
+type PasswordStore interface {
+	// UserPasswordHash returns the bcrypt hash of the password of the user with the email.
+	// Unknown users are rejected with errkit.ErrNotFound, and users without
+	// the password, e.g. managed by the directory, have the empty hash.
+	UserPasswordHash(ctx context.Context, email string) (string, error)
+}
+
+// VerifiedEmailStore looks up whether emails of local accounts are verified.
+type VerifiedEmailStore interface {
+	// UserEmailVerified reports whether the email of the user has been verified.
+	UserEmailVerified(ctx context.Context, email string) (bool, error)
+}
+
+// LocalAccounts verifies passwords of users stored by plainq.
+type LocalAccounts struct {
+	store    PasswordStore
+	verified VerifiedEmailStore
+}
+
+// LocalOption configures LocalAccounts.
+type LocalOption func(l *LocalAccounts)
+
+// WithVerifiedEmail rejects users whose emails are not verified with ErrEmailNotVerified.
+func WithVerifiedEmail(store VerifiedEmailStore) LocalOption {
+	return func(l *LocalAccounts) { l.verified = store }
+}
+
+// NewLocalAccounts returns a pointer to a new instance of LocalAccounts.
+func NewLocalAccounts(store PasswordStore, options ...LocalOption) *LocalAccounts {
+	l := LocalAccounts{store: store}
+
+	for _, option := range options {
+		option(&l)
+	}
+
+	return &l
+}
+
+// VerifyPassword verifies the password of the user with the email,
+// which is the subject of the principal.
+func (l *LocalAccounts) VerifyPassword(ctx context.Context, email, password string) (*Principal, error) {
+	hash, hashErr := l.store.UserPasswordHash(ctx, email)
+	if hashErr != nil {
+		if errors.Is(hashErr, errkit.ErrNotFound) {
+			_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+			return nil, ErrUnknownUser
+		}
+
+		return nil, fmt.Errorf("select password of %q: %w", email, hashErr)
+	}
+
+	if hash == "" {
+		return nil, ErrUnknownUser
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	// The password is checked first, so only the owner finds out the email is not verified.
+	if l.verified != nil {
+		verified, verifiedErr := l.verified.UserEmailVerified(ctx, email)
+		if verifiedErr != nil {
+			return nil, fmt.Errorf("select verification of %q: %w", email, verifiedErr)
+		}
+
+		if !verified {
+			return nil, ErrEmailNotVerified
+		}
+	}
+
+	return &Principal{Subject: email}, nil
+}
+
+// HashPassword returns the bcrypt hash of the password to store.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("hash password: %w", err)
+	}
+
+	return string(hash), nil
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPConfig holds the configuration of the SMTPMailer.
+type SMTPConfig struct {
+	// Addr is the address of the mail server in form "host:port".
+	Addr string
+
+	// From is the sender address.
+	From string
+
+	// Username and Password authenticate with the mail server when the username is set.
+	Username string
+	Password string
+}
+
+// SMTPMailer sends emails by the SMTP server.
+type SMTPMailer struct {
+	cfg      SMTPConfig
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPMailer returns a pointer to a new instance of SMTPMailer.
+func NewSMTPMailer(cfg SMTPConfig) (*SMTPMailer, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("smtp server is not configured")
+	}
+
+	m := SMTPMailer{
+		cfg:      cfg,
+		sendMail: smtp.SendMail,
+	}
+
+	return &m, nil
+}
+
+// SendMail sends the plain text email to the address.
+func (m *SMTPMailer) SendMail(_ context.Context, to, subject, body string) error {
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid recipient address %q", to)
+	}
+
+	var a smtp.Auth
+
+	if m.cfg.Username != "" {
+		host, _, splitErr := net.SplitHostPort(m.cfg.Addr)
+		if splitErr != nil {
+			return fmt.Errorf("parse smtp address: %w", splitErr)
+		}
+
+		a = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, host)
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\r\n", m.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(body)
+
+	if err := m.sendMail(m.cfg.Addr, a, m.cfg.From, []string{to}, []byte(b.String())); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/plainq/servekit/errkit"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordChangeStore looks up and replaces password hashes of local accounts.
+type PasswordChangeStore interface {
+	PasswordStore
+
+	// UpdateUserPassword replaces the password hash of the active user with the email.
+	// Unknown users are rejected with errkit.ErrNotFound.
+	UpdateUserPassword(ctx context.Context, email, passwordHash string, now time.Time) error
+}
+
+// ChangePassword replaces the password of the local account of the token with the claims,
+// once the current password is verified, and revokes other sessions of the account.
+// It returns the number of revoked sessions.
+func (a *Authenticator) ChangePassword(ctx context.Context, claims *Claims, current, password string) (int64, error) {
+	if a.cfg.Passwords == nil {
+		return 0, fmt.Errorf("%w: passwords can't be changed", errkit.ErrUnavailable)
+	}
+
+	hash, hashErr := a.cfg.Passwords.UserPasswordHash(ctx, claims.Subject)
+	if hashErr != nil {
+		return 0, fmt.Errorf("select password of %q: %w", claims.Subject, hashErr)
+	}
+
+	if hash == "" {
+		return 0, fmt.Errorf("%w: password of the user managed by the directory can't be changed", errkit.ErrInvalidArgument)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(current)); err != nil {
+		return 0, ErrInvalidPassword
+	}
+
+	if err := ValidatePassword(password); err != nil {
+		return 0, err
+	}
+
+	passwordHash, passwordErr := HashPassword(password)
+	if passwordErr != nil {
+		return 0, passwordErr
+	}
+
+	if err := a.cfg.Passwords.UpdateUserPassword(ctx, claims.Subject, passwordHash, a.now()); err != nil {
+		return 0, fmt.Errorf("update password of %q: %w", claims.Subject, err)
+	}
+
+	if a.cfg.Sessions == nil || a.cfg.Denylist == nil {
+		return 0, nil
+	}
+
+	sessions, sessionsErr := a.cfg.Sessions.Sessions(ctx, claims.Subject, a.now())
+	if sessionsErr != nil {
+		return 0, fmt.Errorf("password has been changed: select sessions of %q: %w", claims.Subject, sessionsErr)
+	}
+
+	var revoked int64
+
+	for _, session := range sessions {
+		if session.ID == claims.ID {
+			continue
+		}
+
+		if err := a.RevokeSession(ctx, claims.Subject, session.ID); err != nil {
+			// The session may have ended in the meantime.
+			if errors.Is(err, errkit.ErrNotFound) {
+				continue
+			}
+
+			return revoked, fmt.Errorf("password has been changed: %w", err)
+		}
+
+		revoked++
+	}
+
+	return revoked, nil
+}
+
+// ReverifyEmail ends the use of the previous email of the account, which has been changed:
+// tokens issued to it are revoked, since they no longer identify the user, and the verification
+// code is sent to the new email when the mail server is configured.
+func (a *Authenticator) ReverifyEmail(ctx context.Context, previous, email string) error {
+	if a.cfg.Denylist != nil {
+		if err := a.RevokeTokens(ctx, previous); err != nil {
+			return fmt.Errorf("revoke tokens of %q: %w", previous, err)
+		}
+	}
+
+	if a.cfg.Verifications == nil || a.cfg.Mailer == nil {
+		return nil
+	}
+
+	return a.SendEmailVerification(ctx, email)
+}
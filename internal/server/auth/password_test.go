@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/errkit"
+)
+
+type mockPasswordChanges struct {
+	mockPasswords
+}
+
+func (m mockPasswordChanges) UpdateUserPassword(_ context.Context, email, passwordHash string, _ time.Time) error {
+	if m.mockPasswords[email] == "" {
+		return fmt.Errorf("%w: user %q", errkit.ErrNotFound, email)
+	}
+
+	m.mockPasswords[email] = passwordHash
+
+	return nil
+}
+
+func TestAuthenticator_ChangePassword(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	hash, hashErr := HashPassword("old-password")
+	td.Require(t).CmpNoError(hashErr)
+
+	passwords := mockPasswordChanges{mockPasswords{"local@example.com": hash, "directory@example.com": ""}}
+
+	denylist, denylistErr := NewDenylist(&mockDenylistStore{denied: make(map[string]time.Time)},
+		DenylistConfig{CacheTTL: time.Minute, CleanupInterval: time.Minute},
+		slog.Default(),
+	)
+	td.Require(t).CmpNoError(denylistErr)
+
+	authn, authnErr := New(Config{
+		JWT:       JWTConfig{Secret: []byte("secret")},
+		Verifiers: []PasswordVerifier{NewLocalAccounts(passwords)},
+		Denylist:  denylist,
+		Sessions:  &mockSessions{},
+		Passwords: passwords,
+		TokenTTL:  time.Hour,
+	})
+	td.Require(t).CmpNoError(authnErr)
+
+	authn.now = func() time.Time { return now }
+
+	current, _, signInErr := authn.SignIn(ctx, "local@example.com", "old-password", Device{UserAgent: "laptop"})
+	td.Require(t).CmpNoError(signInErr)
+
+	other, _, signInErr := authn.SignIn(ctx, "local@example.com", "old-password", Device{UserAgent: "phone"})
+	td.Require(t).CmpNoError(signInErr)
+
+	claims, claimsErr := authn.SessionClaims(ctx, "Bearer "+current)
+	td.Require(t).CmpNoError(claimsErr)
+
+	_, err := authn.ChangePassword(ctx, claims, "wrong-password", "new-password")
+	td.CmpErrorIs(t, err, ErrInvalidPassword)
+
+	_, err = authn.ChangePassword(ctx, claims, "old-password", "short")
+	td.CmpErrorIs(t, err, errkit.ErrInvalidArgument)
+
+	_, err = authn.ChangePassword(ctx, &Claims{Subject: "directory@example.com"}, "", "new-password")
+	td.CmpErrorIs(t, err, errkit.ErrInvalidArgument)
+
+	revoked, changeErr := authn.ChangePassword(ctx, claims, "old-password", "new-password")
+	td.CmpNoError(t, changeErr)
+	td.Cmp(t, revoked, int64(1))
+
+	// The session which has changed the password goes on, others end.
+	_, err = authn.Authenticate(ctx, "Bearer "+current)
+	td.CmpNoError(t, err)
+
+	_, err = authn.Authenticate(ctx, "Bearer "+other)
+	td.CmpErrorIs(t, err, ErrRevokedToken)
+
+	_, _, err = authn.SignIn(ctx, "local@example.com", "old-password", Device{})
+	td.CmpErrorIs(t, err, ErrInvalidPassword)
+
+	_, _, err = authn.SignIn(ctx, "local@example.com", "new-password", Device{})
+	td.CmpNoError(t, err)
+}
+
+func TestAuthenticator_ReverifyEmail(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	secret := []byte("secret")
+
+	denylist, denylistErr := NewDenylist(&mockDenylistStore{denied: make(map[string]time.Time)},
+		DenylistConfig{CacheTTL: time.Minute, CleanupInterval: time.Minute},
+		slog.Default(),
+	)
+	td.Require(t).CmpNoError(denylistErr)
+
+	store := mockVerifications{
+		passwords: mockPasswords{"new@example.com": "hash"},
+		verified:  make(map[string]bool),
+		sentAt:    make(map[string]time.Time),
+		codes:     make(map[string]*mockVerifyCode),
+	}
+
+	mailer := make(mockMailer)
+
+	authn, authnErr := New(Config{
+		JWT:                 JWTConfig{Secret: secret},
+		Denylist:            denylist,
+		Verifications:       &store,
+		Mailer:              mailer,
+		TokenTTL:            time.Hour,
+		VerificationCodeTTL: time.Hour,
+	})
+	td.Require(t).CmpNoError(authnErr)
+
+	authn.now = func() time.Time { return now }
+
+	signed, signErr := SignJWT(Claims{
+		Subject:   "old@example.com",
+		IssuedAt:  now.Add(-time.Minute).Unix(),
+		ExpiresAt: now.Add(time.Hour).Unix(),
+	}, secret)
+	td.Require(t).CmpNoError(signErr)
+
+	td.CmpNoError(t, authn.ReverifyEmail(ctx, "old@example.com", "new@example.com"))
+
+	_, err := authn.Authenticate(ctx, "Bearer "+signed)
+	td.CmpErrorIs(t, err, ErrRevokedToken)
+
+	td.Cmp(t, mailer.code("new@example.com"), td.Len(codeLength))
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/plainq/servekit/errkit"
+)
+
+const (
+	// codeLength is the length of emailed codes, 60 bits of
+	// the base32 alphabet, which can't be guessed within their TTL.
+	codeLength = 12
+
+	// MinPasswordLength is the minimal length of passwords set by users.
+	MinPasswordLength = 8
+
+	// maxPasswordLength is the maximal length of passwords, bcrypt ignores bytes past 72.
+	maxPasswordLength = 72
+)
+
+// ErrInvalidResetCode tells that the password reset code is unknown, used or expired.
+var ErrInvalidResetCode = errors.New("invalid or expired password reset code")
+
+// PasswordResetStore persists password reset codes, which are identified by their hashes.
+type PasswordResetStore interface {
+	// CreatePasswordResetCode stores the code of the user with the email until it expires,
+	// replacing unused codes of the user. Unknown and deactivated users, and users without
+	// the password, e.g. managed by the directory, are rejected with errkit.ErrNotFound.
+	CreatePasswordResetCode(ctx context.Context, email, hash string, expiresAt time.Time) error
+
+	// PasswordResetCodeExpiresAt returns the time the unused code of the user with the email expires.
+	// Unknown, used and expired codes are rejected with errkit.ErrNotFound.
+	PasswordResetCodeExpiresAt(ctx context.Context, email, hash string, now time.Time) (time.Time, error)
+
+	// ResetPassword uses the code of the user with the email and replaces the password hash of the user.
+	// Unknown, used and expired codes are rejected with errkit.ErrNotFound.
+	ResetPassword(ctx context.Context, email, hash, passwordHash string, now time.Time) error
+}
+
+// Mailer sends emails to users.
+type Mailer interface {
+	// SendMail sends the plain text email to the address.
+	SendMail(ctx context.Context, to, subject, body string) error
+}
+
+// RequestPasswordReset emails the password reset code to the local account with the email. Unknown
+// accounts are not told apart from known ones, so the reset can't be used to find out who has one.
+func (a *Authenticator) RequestPasswordReset(ctx context.Context, email string) error {
+	if a.cfg.Resets == nil || a.cfg.Mailer == nil {
+		return fmt.Errorf("%w: password reset requires the mail server", errkit.ErrUnavailable)
+	}
+
+	if email == "" {
+		return fmt.Errorf("%w: email is required", errkit.ErrInvalidArgument)
+	}
+
+	code := generateCode()
+	expiresAt := a.now().Add(a.cfg.ResetCodeTTL)
+
+	if err := a.cfg.Resets.CreatePasswordResetCode(ctx, email, hashToken(code), expiresAt); err != nil {
+		if errors.Is(err, errkit.ErrNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("create password reset code: %w", err)
+	}
+
+	body := fmt.Sprintf("Use the code below to reset the password of your PlainQ account %s.\r\n\r\n"+
+		"    %s\r\n\r\n"+
+		"The code can be used once and expires at %s.\r\n"+
+		"If you didn't request the reset, ignore this email, your password stays the same.\r\n",
+		email, code, expiresAt.UTC().Format(time.RFC1123),
+	)
+
+	if err := a.cfg.Mailer.SendMail(ctx, email, "[PlainQ] Password reset code", body); err != nil {
+		return fmt.Errorf("send password reset code: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyPasswordResetCode checks the password reset code of the email without using it,
+// so clients can ask for the new password only once the code is known to be valid.
+func (a *Authenticator) VerifyPasswordResetCode(ctx context.Context, email, code string) (time.Time, error) {
+	if a.cfg.Resets == nil {
+		return time.Time{}, fmt.Errorf("%w: password reset requires the mail server", errkit.ErrUnavailable)
+	}
+
+	expiresAt, err := a.cfg.Resets.PasswordResetCodeExpiresAt(ctx, email, hashToken(normalizeCode(code)), a.now())
+	if err != nil {
+		if errors.Is(err, errkit.ErrNotFound) {
+			return time.Time{}, ErrInvalidResetCode
+		}
+
+		return time.Time{}, fmt.Errorf("select password reset code: %w", err)
+	}
+
+	return expiresAt, nil
+}
+
+// ResetPassword sets the new password of the account by the password reset code, which is used once,
+// and revokes tokens issued to the account before, so sessions of whoever knew the old password end.
+func (a *Authenticator) ResetPassword(ctx context.Context, email, code, password string) error {
+	if a.cfg.Resets == nil {
+		return fmt.Errorf("%w: password reset requires the mail server", errkit.ErrUnavailable)
+	}
+
+	if err := ValidatePassword(password); err != nil {
+		return err
+	}
+
+	passwordHash, hashErr := HashPassword(password)
+	if hashErr != nil {
+		return hashErr
+	}
+
+	if err := a.cfg.Resets.ResetPassword(ctx, email, hashToken(normalizeCode(code)), passwordHash, a.now()); err != nil {
+		if errors.Is(err, errkit.ErrNotFound) {
+			return ErrInvalidResetCode
+		}
+
+		return fmt.Errorf("reset password: %w", err)
+	}
+
+	if a.cfg.Denylist == nil {
+		return nil
+	}
+
+	if err := a.RevokeTokens(ctx, email); err != nil {
+		return fmt.Errorf("password has been reset: %w", err)
+	}
+
+	return nil
+}
+
+// ValidatePassword validates the length of the password set by the user.
+func ValidatePassword(password string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return fmt.Errorf("%w: password should be at least %d characters long", errkit.ErrInvalidArgument, MinPasswordLength)
+	}
+
+	if len(password) > maxPasswordLength {
+		return fmt.Errorf("%w: password should be at most %d bytes long", errkit.ErrInvalidArgument, maxPasswordLength)
+	}
+
+	return nil
+}
+
+// generateCode returns the random code to email.
+func generateCode() string {
+	return rand.Text()[:codeLength]
+}
+
+// normalizeCode returns the code as it's generated, codes are typed by users,
+// who may change the case or add spaces.
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(code), ""))
+}
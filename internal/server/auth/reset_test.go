@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/errkit"
+)
+
+type mockResetCode struct {
+	email     string
+	expiresAt time.Time
+	used      bool
+}
+
+type mockResets struct {
+	passwords mockPasswords
+	codes     map[string]*mockResetCode
+}
+
+func (m *mockResets) CreatePasswordResetCode(_ context.Context, email, hash string, expiresAt time.Time) error {
+	if m.passwords[email] == "" {
+		return fmt.Errorf("%w: user %q", errkit.ErrNotFound, email)
+	}
+
+	for h, c := range m.codes {
+		if c.email == email && !c.used {
+			delete(m.codes, h)
+		}
+	}
+
+	m.codes[hash] = &mockResetCode{email: email, expiresAt: expiresAt}
+
+	return nil
+}
+
+func (m *mockResets) PasswordResetCodeExpiresAt(_ context.Context, email, hash string, now time.Time) (time.Time, error) {
+	c, ok := m.codes[hash]
+	if !ok || c.email != email || c.used || !c.expiresAt.After(now) {
+		return time.Time{}, errkit.ErrNotFound
+	}
+
+	return c.expiresAt, nil
+}
+
+func (m *mockResets) ResetPassword(ctx context.Context, email, hash, passwordHash string, now time.Time) error {
+	if _, err := m.PasswordResetCodeExpiresAt(ctx, email, hash, now); err != nil {
+		return err
+	}
+
+	m.codes[hash].used = true
+	m.passwords[email] = passwordHash
+
+	return nil
+}
+
+type mockMailer map[string]string
+
+func (m mockMailer) SendMail(_ context.Context, to, _, body string) error {
+	m[to] = body
+	return nil
+}
+
+// code returns the code from the last email sent to the address.
+func (m mockMailer) code(to string) string {
+	for _, line := range strings.Split(m[to], "\r\n") {
+		if line = strings.TrimSpace(line); len(line) == codeLength {
+			return line
+		}
+	}
+
+	return ""
+}
+
+func TestAuthenticator_ResetPassword(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("secret")
+	now := time.Now()
+
+	hash, hashErr := HashPassword("old-password")
+	td.Require(t).CmpNoError(hashErr)
+
+	resets := mockResets{
+		passwords: mockPasswords{"local@example.com": hash, "directory@example.com": ""},
+		codes:     make(map[string]*mockResetCode),
+	}
+
+	mailer := make(mockMailer)
+
+	denylist, denylistErr := NewDenylist(&mockDenylistStore{denied: make(map[string]time.Time)},
+		DenylistConfig{CacheTTL: time.Minute, CleanupInterval: time.Minute},
+		slog.Default(),
+	)
+	td.Require(t).CmpNoError(denylistErr)
+
+	authn, authnErr := New(Config{
+		JWT:          JWTConfig{Secret: secret},
+		Verifiers:    []PasswordVerifier{NewLocalAccounts(resets.passwords)},
+		Denylist:     denylist,
+		Resets:       &resets,
+		Mailer:       mailer,
+		TokenTTL:     time.Hour,
+		ResetCodeTTL: 15 * time.Minute,
+	})
+	td.Require(t).CmpNoError(authnErr)
+
+	authn.now = func() time.Time { return now }
+
+	signed, signErr := SignJWT(Claims{
+		Subject:   "local@example.com",
+		IssuedAt:  now.Add(-time.Minute).Unix(),
+		ExpiresAt: now.Add(time.Hour).Unix(),
+	}, secret)
+	td.Require(t).CmpNoError(signErr)
+
+	// Unknown accounts and accounts managed by the directory get no email, and no error either.
+	td.CmpNoError(t, authn.RequestPasswordReset(ctx, "unknown@example.com"))
+	td.CmpNoError(t, authn.RequestPasswordReset(ctx, "directory@example.com"))
+	td.Cmp(t, mailer, td.Len(0))
+
+	td.CmpNoError(t, authn.RequestPasswordReset(ctx, "local@example.com"))
+	td.Cmp(t, mailer, td.Len(1))
+
+	code := mailer.code("local@example.com")
+	td.Require(t).Cmp(code, td.Len(codeLength))
+
+	// Only the hash of the code is stored.
+	td.Cmp(t, resets.codes, td.ContainsKey(hashToken(code)))
+
+	expiresAt, verifyErr := authn.VerifyPasswordResetCode(ctx, "local@example.com", strings.ToLower(code[:6])+" "+code[6:])
+	td.CmpNoError(t, verifyErr)
+	td.Cmp(t, expiresAt, now.Add(15*time.Minute))
+
+	_, verifyErr = authn.VerifyPasswordResetCode(ctx, "other@example.com", code)
+	td.CmpErrorIs(t, verifyErr, ErrInvalidResetCode)
+
+	td.CmpErrorIs(t, authn.ResetPassword(ctx, "local@example.com", code, "short"), errkit.ErrInvalidArgument)
+	td.CmpErrorIs(t, authn.ResetPassword(ctx, "local@example.com", "WRONGCODE123", "new-password"), ErrInvalidResetCode)
+	td.CmpNoError(t, authn.ResetPassword(ctx, "local@example.com", code, "new-password"))
+
+	// Codes are used once.
+	td.CmpErrorIs(t, authn.ResetPassword(ctx, "local@example.com", code, "other-password"), ErrInvalidResetCode)
+
+	// Tokens issued before the reset are revoked.
+	_, err := authn.Authenticate(ctx, "Bearer "+signed)
+	td.CmpErrorIs(t, err, ErrRevokedToken)
+
+	_, _, err = authn.SignIn(ctx, "local@example.com", "old-password", Device{})
+	td.CmpErrorIs(t, err, ErrInvalidPassword)
+
+	authn.now = func() time.Time { return now.Add(time.Second) }
+
+	token, _, signInErr := authn.SignIn(ctx, "local@example.com", "new-password", Device{})
+	td.Require(t).CmpNoError(signInErr)
+
+	_, err = authn.Authenticate(ctx, "Bearer "+token)
+	td.CmpNoError(t, err)
+
+	// Codes expire.
+	td.CmpNoError(t, authn.RequestPasswordReset(ctx, "local@example.com"))
+
+	authn.now = func() time.Time { return now.Add(time.Hour) }
+
+	_, verifyErr = authn.VerifyPasswordResetCode(ctx, "local@example.com", mailer.code("local@example.com"))
+	td.CmpErrorIs(t, verifyErr, ErrInvalidResetCode)
+}
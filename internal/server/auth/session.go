@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/plainq/servekit/errkit"
+)
+
+// maxDeviceLength limits the length of the user agent and the address of sessions.
+const maxDeviceLength = 256
+
+// Device describes the client which signs in.
+type Device struct {
+	// UserAgent is the user agent of the client.
+	UserAgent string
+
+	// RemoteAddr is the address of the client.
+	RemoteAddr string
+}
+
+// Session represents the token issued on sign-in.
+type Session struct {
+	// ID is the ID of the token.
+	ID string
+
+	// Subject is the user the token has been issued to.
+	Subject string
+
+	// TokenHash is the SHA-256 hash of the token, which puts it on the denylist on revocation.
+	TokenHash string
+
+	// Device is the client which has signed in.
+	Device Device
+
+	// CreatedAt is the time of the sign-in.
+	CreatedAt time.Time
+
+	// ExpiresAt is the time the token expires.
+	ExpiresAt time.Time
+}
+
+// SessionStore records sessions.
+type SessionStore interface {
+	// CreateSession records the session.
+	CreateSession(ctx context.Context, session Session) error
+
+	// Sessions returns sessions of the subject which are neither expired nor revoked at the time, newest first.
+	Sessions(ctx context.Context, subject string, now time.Time) ([]Session, error)
+
+	// RevokeSession revokes the active session of the subject and returns it.
+	// Unknown, expired and revoked sessions are rejected with errkit.ErrNotFound.
+	RevokeSession(ctx context.Context, subject, id string, now time.Time) (*Session, error)
+
+	// RevokeSessions revokes active sessions of the subject and returns the number of revoked sessions.
+	RevokeSessions(ctx context.Context, subject string, now time.Time) (int64, error)
+}
+
+// SessionClaims authenticates the token of the authorization and returns its claims,
+// which tell the subject and the session of the token. API keys have no sessions.
+func (a *Authenticator) SessionClaims(ctx context.Context, authorization string) (*Claims, error) {
+	credentials, credentialsErr := bearerCredentials(authorization)
+	if credentialsErr != nil {
+		return nil, credentialsErr
+	}
+
+	if strings.HasPrefix(credentials, APIKeyPrefix) {
+		return nil, fmt.Errorf("%w: API keys have no sessions", errkit.ErrInvalidArgument)
+	}
+
+	return a.authenticateJWT(ctx, credentials)
+}
+
+// Sessions returns active sessions of the subject, newest first.
+func (a *Authenticator) Sessions(ctx context.Context, subject string) ([]Session, error) {
+	if a.cfg.Sessions == nil {
+		return nil, fmt.Errorf("%w: sessions are not recorded", errkit.ErrUnavailable)
+	}
+
+	sessions, err := a.cfg.Sessions.Sessions(ctx, subject, a.now())
+	if err != nil {
+		return nil, fmt.Errorf("select sessions of %q: %w", subject, err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession revokes the session of the subject, whose token is put on the denylist until it expires.
+func (a *Authenticator) RevokeSession(ctx context.Context, subject, id string) error {
+	if a.cfg.Sessions == nil || a.cfg.Denylist == nil {
+		return fmt.Errorf("%w: sessions are revoked by the token denylist", errkit.ErrUnavailable)
+	}
+
+	session, revokeErr := a.cfg.Sessions.RevokeSession(ctx, subject, id, a.now())
+	if revokeErr != nil {
+		return fmt.Errorf("revoke session %q of %q: %w", id, subject, revokeErr)
+	}
+
+	// The token is accepted within the leeway after it expires.
+	return a.cfg.Denylist.denyHash(ctx, session.TokenHash, session.ExpiresAt.Add(a.cfg.JWT.Leeway))
+}
+
+// RevokeSessions revokes all sessions of the subject, including the ones of tokens issued
+// before sessions have been recorded, and returns the number of revoked sessions.
+func (a *Authenticator) RevokeSessions(ctx context.Context, subject string) (int64, error) {
+	if a.cfg.Sessions == nil || a.cfg.Denylist == nil {
+		return 0, fmt.Errorf("%w: sessions are revoked by the token denylist", errkit.ErrUnavailable)
+	}
+
+	sessions, sessionsErr := a.cfg.Sessions.Sessions(ctx, subject, a.now())
+	if sessionsErr != nil {
+		return 0, fmt.Errorf("select sessions of %q: %w", subject, sessionsErr)
+	}
+
+	if err := a.RevokeTokens(ctx, subject); err != nil {
+		return 0, err
+	}
+
+	return int64(len(sessions)), nil
+}
+
+// startSession records the session of the token issued on sign-in.
+func (a *Authenticator) startSession(ctx context.Context, token string, claims *Claims, device Device) error {
+	if a.cfg.Sessions == nil {
+		return nil
+	}
+
+	session := Session{
+		ID:        claims.ID,
+		Subject:   claims.Subject,
+		TokenHash: hashToken(token),
+		Device: Device{
+			UserAgent:  truncate(device.UserAgent, maxDeviceLength),
+			RemoteAddr: truncate(device.RemoteAddr, maxDeviceLength),
+		},
+		CreatedAt: time.Unix(claims.IssuedAt, 0),
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}
+
+	if err := a.cfg.Sessions.CreateSession(ctx, session); err != nil {
+		return fmt.Errorf("create session of %q: %w", claims.Subject, err)
+	}
+
+	return nil
+}
+
+// endSession marks the session of the signed-out token revoked.
+func (a *Authenticator) endSession(ctx context.Context, claims *Claims) error {
+	if a.cfg.Sessions == nil || claims.ID == "" {
+		return nil
+	}
+
+	if _, err := a.cfg.Sessions.RevokeSession(ctx, claims.Subject, claims.ID, a.now()); err != nil {
+		// Tokens issued by other servers, or before sessions have been recorded, have no sessions.
+		if errors.Is(err, errkit.ErrNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("revoke session of %q: %w", claims.Subject, err)
+	}
+
+	return nil
+}
+
+// truncate returns the string cut to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return strings.ToValidUTF8(s[:n], "")
+}
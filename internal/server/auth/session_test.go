@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/errkit"
+)
+
+type mockSession struct {
+	Session
+	revoked bool
+}
+
+type mockSessions struct {
+	sessions []*mockSession
+}
+
+func (m *mockSessions) CreateSession(_ context.Context, session Session) error {
+	m.sessions = append(m.sessions, &mockSession{Session: session})
+	return nil
+}
+
+func (m *mockSessions) Sessions(_ context.Context, subject string, now time.Time) ([]Session, error) {
+	var sessions []Session
+
+	for _, s := range slices.Backward(m.sessions) {
+		if s.Subject == subject && !s.revoked && s.ExpiresAt.After(now) {
+			sessions = append(sessions, s.Session)
+		}
+	}
+
+	return sessions, nil
+}
+
+func (m *mockSessions) RevokeSession(_ context.Context, subject, id string, now time.Time) (*Session, error) {
+	for _, s := range m.sessions {
+		if s.Subject == subject && s.ID == id && !s.revoked && s.ExpiresAt.After(now) {
+			s.revoked = true
+			return &s.Session, nil
+		}
+	}
+
+	return nil, errkit.ErrNotFound
+}
+
+func (m *mockSessions) RevokeSessions(_ context.Context, subject string, now time.Time) (int64, error) {
+	var revoked int64
+
+	for _, s := range m.sessions {
+		if s.Subject == subject && !s.revoked && s.ExpiresAt.After(now) {
+			s.revoked = true
+			revoked++
+		}
+	}
+
+	return revoked, nil
+}
+
+func TestAuthenticator_Sessions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	hash, hashErr := HashPassword("local-password")
+	td.Require(t).CmpNoError(hashErr)
+
+	denylist, denylistErr := NewDenylist(&mockDenylistStore{denied: make(map[string]time.Time)},
+		DenylistConfig{CacheTTL: time.Minute, CleanupInterval: time.Minute},
+		slog.Default(),
+	)
+	td.Require(t).CmpNoError(denylistErr)
+
+	store := mockSessions{}
+
+	authn, authnErr := New(Config{
+		JWT:       JWTConfig{Secret: []byte("secret")},
+		Verifiers: []PasswordVerifier{NewLocalAccounts(mockPasswords{"local@example.com": hash, "other@example.com": hash})},
+		Denylist:  denylist,
+		Sessions:  &store,
+		TokenTTL:  time.Hour,
+	})
+	td.Require(t).CmpNoError(authnErr)
+
+	authn.now = func() time.Time { return now }
+
+	signIn := func(username, userAgent string) (string, *Claims) {
+		token, claims, err := authn.SignIn(ctx, username, "local-password", Device{UserAgent: userAgent, RemoteAddr: "10.0.0.1:52100"})
+		td.Require(t).CmpNoError(err)
+
+		return "Bearer " + token, claims
+	}
+
+	laptop, laptopClaims := signIn("local@example.com", "laptop")
+	phone, phoneClaims := signIn("local@example.com", strings.Repeat("phone", 100))
+	tablet, _ := signIn("local@example.com", "tablet")
+	other, _ := signIn("other@example.com", "laptop")
+
+	claims, claimsErr := authn.SessionClaims(ctx, laptop)
+	td.Require(t).CmpNoError(claimsErr)
+	td.Cmp(t, claims.ID, laptopClaims.ID)
+
+	_, claimsErr = authn.SessionClaims(ctx, "Bearer pq_abcdefgh_secret")
+	td.CmpErrorIs(t, claimsErr, errkit.ErrInvalidArgument)
+
+	sessions, sessionsErr := authn.Sessions(ctx, "local@example.com")
+	td.CmpNoError(t, sessionsErr)
+	td.Cmp(t, sessions, td.Len(3))
+	td.Cmp(t, sessions[1], td.SStruct(Session{
+		ID:      phoneClaims.ID,
+		Subject: "local@example.com",
+		Device:  Device{UserAgent: strings.Repeat("phone", 100)[:maxDeviceLength], RemoteAddr: "10.0.0.1:52100"},
+	}, td.StructFields{
+		"TokenHash": hashToken(phone[len("Bearer "):]),
+		"CreatedAt": td.Between(now.Add(-time.Second), now),
+		"ExpiresAt": td.Between(now.Add(time.Hour-time.Second), now.Add(time.Hour)),
+	}))
+
+	// Revoked sessions end, their tokens are rejected.
+	td.CmpErrorIs(t, authn.RevokeSession(ctx, "other@example.com", phoneClaims.ID), errkit.ErrNotFound)
+	td.CmpNoError(t, authn.RevokeSession(ctx, "local@example.com", phoneClaims.ID))
+	td.CmpErrorIs(t, authn.RevokeSession(ctx, "local@example.com", phoneClaims.ID), errkit.ErrNotFound)
+
+	_, err := authn.Authenticate(ctx, phone)
+	td.CmpErrorIs(t, err, ErrRevokedToken)
+
+	// Signed-out sessions end too.
+	td.CmpNoError(t, authn.SignOut(ctx, tablet))
+
+	sessions, sessionsErr = authn.Sessions(ctx, "local@example.com")
+	td.CmpNoError(t, sessionsErr)
+	td.Cmp(t, sessions, td.Len(1))
+
+	revoked, revokeErr := authn.RevokeSessions(ctx, "local@example.com")
+	td.CmpNoError(t, revokeErr)
+	td.Cmp(t, revoked, int64(1))
+
+	_, err = authn.Authenticate(ctx, laptop)
+	td.CmpErrorIs(t, err, ErrRevokedToken)
+
+	// Sessions of other users go on.
+	_, err = authn.Authenticate(ctx, other)
+	td.CmpNoError(t, err)
+
+	sessions, sessionsErr = authn.Sessions(ctx, "other@example.com")
+	td.CmpNoError(t, sessionsErr)
+	td.Cmp(t, sessions, td.Len(1))
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/plainq/servekit/errkit"
+)
+
+// Errors of password verification, which tell why the sign-in has been rejected.
+var (
+	ErrUnknownUser      = errors.New("unknown user")
+	ErrInvalidPassword  = errors.New("invalid username or password")
+	ErrEmailNotVerified = errors.New("email is not verified")
+)
+
+// Principal represents the user whose password has been verified.
+type Principal struct {
+	// Subject is the identity of the user, which is the subject of issued tokens.
+	Subject string
+
+	// Roles are names of roles of the user managed by the directory,
+	// which replace roles granted to the user on sign-in. Roles are
+	// managed by plainq when it's nil, e.g. for local accounts.
+	Roles []string
+}
+
+// PasswordVerifier verifies passwords of users, e.g. by the directory or local accounts.
+type PasswordVerifier interface {
+	// VerifyPassword returns the principal of the user. Users which the verifier doesn't know
+	// are rejected with ErrUnknownUser, wrong passwords are rejected with ErrInvalidPassword.
+	VerifyPassword(ctx context.Context, username, password string) (*Principal, error)
+}
+
+// UserSyncer syncs users managed by the directory.
+type UserSyncer interface {
+	// SyncUserRoles creates the user with the subject, unless it exists, and replaces its roles.
+	SyncUserRoles(ctx context.Context, subject string, roles []string) error
+}
+
+// SignIn verifies the password by the first verifier which knows the user and issues the
+// token of the user. Unavailable verifiers, e.g. the directory which is down, fall back
+// to the next one, so local accounts can sign in when the directory can't. The token
+// is recorded as the session of the device when sessions are recorded.
+func (a *Authenticator) SignIn(ctx context.Context, username, password string, device Device) (string, *Claims, error) {
+	if len(a.cfg.JWT.Secret) == 0 {
+		return "", nil, fmt.Errorf("%w: sign-in requires the JWT secret", errkit.ErrUnavailable)
+	}
+
+	if username == "" || password == "" {
+		return "", nil, ErrInvalidPassword
+	}
+
+	var (
+		principal *Principal
+		errs      []error
+	)
+
+	for _, v := range a.cfg.Verifiers {
+		p, err := v.VerifyPassword(ctx, username, password)
+		if err == nil {
+			principal = p
+			break
+		}
+
+		if errors.Is(err, ErrInvalidPassword) {
+			return "", nil, ErrInvalidPassword
+		}
+
+		if errors.Is(err, ErrEmailNotVerified) {
+			return "", nil, ErrEmailNotVerified
+		}
+
+		if !errors.Is(err, ErrUnknownUser) {
+			errs = append(errs, err)
+		}
+	}
+
+	if principal == nil {
+		if len(errs) > 0 {
+			return "", nil, fmt.Errorf("%w: verify password: %w", errkit.ErrUnavailable, errors.Join(errs...))
+		}
+
+		// Unknown users are not told from wrong passwords.
+		return "", nil, ErrInvalidPassword
+	}
+
+	if principal.Roles != nil && a.cfg.Users != nil {
+		if err := a.cfg.Users.SyncUserRoles(ctx, principal.Subject, principal.Roles); err != nil {
+			return "", nil, fmt.Errorf("sync roles of %q: %w", principal.Subject, err)
+		}
+	}
+
+	now := a.now()
+
+	claims := Claims{
+		Subject:   principal.Subject,
+		Issuer:    a.cfg.JWT.Issuer,
+		ExpiresAt: now.Add(a.cfg.TokenTTL).Unix(),
+		IssuedAt:  now.Unix(),
+		ID:        rand.Text(),
+	}
+
+	if a.cfg.JWT.Audience != "" {
+		claims.Audience = Audience{a.cfg.JWT.Audience}
+	}
+
+	token, signErr := SignJWT(claims, a.cfg.JWT.Secret)
+	if signErr != nil {
+		return "", nil, fmt.Errorf("sign token: %w", signErr)
+	}
+
+	if err := a.startSession(ctx, token, &claims, device); err != nil {
+		return "", nil, err
+	}
+
+	return token, &claims, nil
+}
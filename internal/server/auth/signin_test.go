@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/errkit"
+)
+
+type mockVerifier func(ctx context.Context, username, password string) (*Principal, error)
+
+func (m mockVerifier) VerifyPassword(ctx context.Context, username, password string) (*Principal, error) {
+	return m(ctx, username, password)
+}
+
+type mockPasswords map[string]string
+
+func (m mockPasswords) UserPasswordHash(_ context.Context, email string) (string, error) {
+	hash, ok := m[email]
+	if !ok {
+		return "", fmt.Errorf("%w: user %q", errkit.ErrNotFound, email)
+	}
+
+	return hash, nil
+}
+
+type mockUsers map[string][]string
+
+func (m mockUsers) SyncUserRoles(_ context.Context, subject string, roles []string) error {
+	m[subject] = roles
+	return nil
+}
+
+func TestAuthenticator_SignIn(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("secret")
+
+	hash, hashErr := HashPassword("local-password")
+	td.Require(t).CmpNoError(hashErr)
+
+	local := NewLocalAccounts(mockPasswords{"local@example.com": hash, "directory@example.com": ""})
+
+	directory := func(err error) PasswordVerifier {
+		return mockVerifier(func(_ context.Context, username, password string) (*Principal, error) {
+			if err != nil {
+				return nil, err
+			}
+
+			if username != "directory" {
+				return nil, ErrUnknownUser
+			}
+
+			if password != "directory-password" {
+				return nil, ErrInvalidPassword
+			}
+
+			return &Principal{Subject: "directory@example.com", Roles: []string{"producer"}}, nil
+		})
+	}
+
+	type tcase struct {
+		verifiers   []PasswordVerifier
+		username    string
+		password    string
+		wantSubject string
+		wantRoles   []string
+		wantErr     error
+	}
+
+	tests := map[string]tcase{
+		"Directory": {
+			verifiers:   []PasswordVerifier{directory(nil), local},
+			username:    "directory",
+			password:    "directory-password",
+			wantSubject: "directory@example.com",
+			wantRoles:   []string{"producer"},
+		},
+		"DirectoryWrongPassword": {
+			verifiers: []PasswordVerifier{directory(nil), local},
+			username:  "directory",
+			password:  "local-password",
+			wantErr:   ErrInvalidPassword,
+		},
+		"FallbackToLocal": {
+			verifiers:   []PasswordVerifier{directory(nil), local},
+			username:    "local@example.com",
+			password:    "local-password",
+			wantSubject: "local@example.com",
+		},
+		"DirectoryUnavailable": {
+			verifiers:   []PasswordVerifier{directory(errors.New("connection refused")), local},
+			username:    "local@example.com",
+			password:    "local-password",
+			wantSubject: "local@example.com",
+		},
+		"DirectoryUnavailableUnknownLocal": {
+			verifiers: []PasswordVerifier{directory(errors.New("connection refused")), local},
+			username:  "directory",
+			password:  "directory-password",
+			wantErr:   errkit.ErrUnavailable,
+		},
+		"LocalWrongPassword": {
+			verifiers: []PasswordVerifier{local},
+			username:  "local@example.com",
+			password:  "nope",
+			wantErr:   ErrInvalidPassword,
+		},
+		"LocalWithoutPassword": {
+			verifiers: []PasswordVerifier{local},
+			username:  "directory@example.com",
+			password:  "anything",
+			wantErr:   ErrInvalidPassword,
+		},
+		"Unknown": {
+			verifiers: []PasswordVerifier{directory(nil), local},
+			username:  "carol@example.com",
+			password:  "secret",
+			wantErr:   ErrInvalidPassword,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			users := mockUsers{}
+
+			authn, newErr := New(Config{
+				JWT:       JWTConfig{Secret: secret, Issuer: "plainq"},
+				Verifiers: tc.verifiers,
+				Users:     users,
+				TokenTTL:  time.Hour,
+			})
+			td.Require(t).CmpNoError(newErr)
+
+			token, claims, err := authn.SignIn(ctx, tc.username, tc.password, Device{})
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.Require(t).CmpNoError(err)
+			td.Cmp(t, claims.Subject, tc.wantSubject)
+
+			id, authErr := authn.Authenticate(ctx, "Bearer "+token)
+			td.CmpNoError(t, authErr)
+			td.Cmp(t, id.Name, tc.wantSubject)
+
+			if tc.wantRoles != nil {
+				td.Cmp(t, users, mockUsers{tc.wantSubject: tc.wantRoles})
+			} else {
+				td.Cmp(t, users, td.Len(0))
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/plainq/servekit/errkit"
+)
+
+// ErrInvalidVerificationCode tells that the email verification code is unknown, used or expired.
+var ErrInvalidVerificationCode = errors.New("invalid or expired email verification code")
+
+// ResendTooSoonError tells that the verification code has been sent to the account recently.
+type ResendTooSoonError struct {
+	// RetryAfter is the time after which the code can be sent again.
+	RetryAfter time.Duration
+}
+
+func (e *ResendTooSoonError) Error() string {
+	return fmt.Sprintf("verification code has been sent recently, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// RetryAfterSeconds returns the time to wait in whole seconds, which is at least one second.
+func (e *ResendTooSoonError) RetryAfterSeconds() int64 {
+	return max(int64((e.RetryAfter+time.Second-1)/time.Second), 1)
+}
+
+// EmailVerificationStore persists email verification codes, which are identified by their hashes.
+type EmailVerificationStore interface {
+	// EmailVerificationCodeSentAt returns the time the last code of the user with the email
+	// has been created, which is zero when there is no such code or user.
+	EmailVerificationCodeSentAt(ctx context.Context, email string) (time.Time, error)
+
+	// CreateEmailVerificationCode stores the code of the unverified user with the email until it expires,
+	// replacing unused codes of the user. Unknown, deactivated and verified users are rejected with
+	// errkit.ErrNotFound.
+	CreateEmailVerificationCode(ctx context.Context, email, hash string, now, expiresAt time.Time) error
+
+	// VerifyEmail uses the code of the user with the email and marks the email of the user verified.
+	// Unknown, used and expired codes are rejected with errkit.ErrNotFound.
+	VerifyEmail(ctx context.Context, email, hash string, now time.Time) error
+}
+
+// SendEmailVerification emails the verification code to the unverified local account with the email.
+// Unknown and verified accounts are not told apart from unverified ones. Codes are resent to the same
+// account at most once per the resend interval, earlier requests are rejected with *ResendTooSoonError.
+func (a *Authenticator) SendEmailVerification(ctx context.Context, email string) error {
+	if a.cfg.Verifications == nil || a.cfg.Mailer == nil {
+		return fmt.Errorf("%w: email verification requires the mail server", errkit.ErrUnavailable)
+	}
+
+	if email == "" {
+		return fmt.Errorf("%w: email is required", errkit.ErrInvalidArgument)
+	}
+
+	now := a.now()
+
+	sentAt, sentErr := a.cfg.Verifications.EmailVerificationCodeSentAt(ctx, email)
+	if sentErr != nil {
+		return fmt.Errorf("select email verification code: %w", sentErr)
+	}
+
+	if resendAt := sentAt.Add(a.cfg.VerificationResendInterval); !sentAt.IsZero() && now.Before(resendAt) {
+		return &ResendTooSoonError{RetryAfter: resendAt.Sub(now)}
+	}
+
+	code := generateCode()
+	expiresAt := now.Add(a.cfg.VerificationCodeTTL)
+
+	if err := a.cfg.Verifications.CreateEmailVerificationCode(ctx, email, hashToken(code), now, expiresAt); err != nil {
+		if errors.Is(err, errkit.ErrNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("create email verification code: %w", err)
+	}
+
+	body := fmt.Sprintf("Use the code below to verify the email of your PlainQ account %s.\r\n\r\n"+
+		"    %s\r\n\r\n"+
+		"The code can be used once and expires at %s.\r\n"+
+		"If you didn't create the account, ignore this email.\r\n",
+		email, code, expiresAt.UTC().Format(time.RFC1123),
+	)
+
+	if err := a.cfg.Mailer.SendMail(ctx, email, "[PlainQ] Email verification code", body); err != nil {
+		return fmt.Errorf("send email verification code: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the email of the account verified by the code sent to it, which is used once.
+func (a *Authenticator) VerifyEmail(ctx context.Context, email, code string) error {
+	if a.cfg.Verifications == nil {
+		return fmt.Errorf("%w: email verification requires the mail server", errkit.ErrUnavailable)
+	}
+
+	if err := a.cfg.Verifications.VerifyEmail(ctx, email, hashToken(normalizeCode(code)), a.now()); err != nil {
+		if errors.Is(err, errkit.ErrNotFound) {
+			return ErrInvalidVerificationCode
+		}
+
+		return fmt.Errorf("verify email: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/errkit"
+)
+
+type mockVerifyCode struct {
+	email     string
+	expiresAt time.Time
+	used      bool
+}
+
+type mockVerifications struct {
+	passwords mockPasswords
+	verified  map[string]bool
+	sentAt    map[string]time.Time
+	codes     map[string]*mockVerifyCode
+}
+
+func (m *mockVerifications) EmailVerificationCodeSentAt(_ context.Context, email string) (time.Time, error) {
+	return m.sentAt[email], nil
+}
+
+func (m *mockVerifications) CreateEmailVerificationCode(_ context.Context, email, hash string, now, expiresAt time.Time) error {
+	if _, ok := m.passwords[email]; !ok || m.verified[email] {
+		return fmt.Errorf("%w: unverified user %q", errkit.ErrNotFound, email)
+	}
+
+	m.sentAt[email] = now
+	m.codes[hash] = &mockVerifyCode{email: email, expiresAt: expiresAt}
+
+	return nil
+}
+
+func (m *mockVerifications) VerifyEmail(_ context.Context, email, hash string, now time.Time) error {
+	c, ok := m.codes[hash]
+	if !ok || c.email != email || c.used || !c.expiresAt.After(now) {
+		return errkit.ErrNotFound
+	}
+
+	c.used = true
+	m.verified[email] = true
+
+	return nil
+}
+
+func (m *mockVerifications) UserEmailVerified(_ context.Context, email string) (bool, error) {
+	return m.verified[email], nil
+}
+
+func TestAuthenticator_VerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	hash, hashErr := HashPassword("local-password")
+	td.Require(t).CmpNoError(hashErr)
+
+	store := mockVerifications{
+		passwords: mockPasswords{"local@example.com": hash, "verified@example.com": hash},
+		verified:  map[string]bool{"verified@example.com": true},
+		sentAt:    make(map[string]time.Time),
+		codes:     make(map[string]*mockVerifyCode),
+	}
+
+	mailer := make(mockMailer)
+
+	authn, authnErr := New(Config{
+		JWT:                        JWTConfig{Secret: []byte("secret")},
+		Verifiers:                  []PasswordVerifier{NewLocalAccounts(store.passwords, WithVerifiedEmail(&store))},
+		Verifications:              &store,
+		Mailer:                     mailer,
+		TokenTTL:                   time.Hour,
+		VerificationCodeTTL:        time.Hour,
+		VerificationResendInterval: time.Minute,
+	})
+	td.Require(t).CmpNoError(authnErr)
+
+	authn.now = func() time.Time { return now }
+
+	// Unverified accounts can't sign in, wrong passwords are still told as such.
+	_, _, err := authn.SignIn(ctx, "local@example.com", "local-password", Device{})
+	td.CmpErrorIs(t, err, ErrEmailNotVerified)
+
+	_, _, err = authn.SignIn(ctx, "local@example.com", "wrong-password", Device{})
+	td.CmpErrorIs(t, err, ErrInvalidPassword)
+
+	_, _, err = authn.SignIn(ctx, "verified@example.com", "local-password", Device{})
+	td.CmpNoError(t, err)
+
+	// Unknown and verified accounts get no email, and no error either.
+	td.CmpNoError(t, authn.SendEmailVerification(ctx, "unknown@example.com"))
+	td.CmpNoError(t, authn.SendEmailVerification(ctx, "verified@example.com"))
+	td.Cmp(t, mailer, td.Len(0))
+
+	td.CmpNoError(t, authn.SendEmailVerification(ctx, "local@example.com"))
+	first := mailer.code("local@example.com")
+	td.Require(t).Cmp(first, td.Len(codeLength))
+
+	// Codes are resent once per the resend interval.
+	authn.now = func() time.Time { return now.Add(20 * time.Second) }
+
+	err = authn.SendEmailVerification(ctx, "local@example.com")
+	td.Cmp(t, err, td.Isa(&ResendTooSoonError{}))
+	td.Cmp(t, err.(*ResendTooSoonError).RetryAfterSeconds(), int64(40))
+
+	authn.now = func() time.Time { return now.Add(time.Minute) }
+
+	td.CmpNoError(t, authn.SendEmailVerification(ctx, "local@example.com"))
+	second := mailer.code("local@example.com")
+	td.Cmp(t, second, td.Not(first))
+
+	td.CmpErrorIs(t, authn.VerifyEmail(ctx, "other@example.com", second), ErrInvalidVerificationCode)
+	td.CmpNoError(t, authn.VerifyEmail(ctx, "local@example.com", second))
+
+	// Codes are used once.
+	td.CmpErrorIs(t, authn.VerifyEmail(ctx, "local@example.com", second), ErrInvalidVerificationCode)
+
+	_, _, err = authn.SignIn(ctx, "local@example.com", "local-password", Device{})
+	td.CmpNoError(t, err)
+}
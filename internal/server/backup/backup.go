@@ -0,0 +1,295 @@
+// Package backup uploads snapshots of databases to the S3-compatible bucket on the schedule
+// and prunes backups which are older than the retention period.
+//
+// Backups are stored under the key prefix, in the directory of the database, and named
+// after the time the backup has started, e.g. "plainq/storage/20261017T030000Z.db.gz".
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// keyTimeLayout is the layout of the time in object keys.
+	keyTimeLayout = "20060102T150405Z"
+
+	// keySuffix is the suffix of object keys of backups.
+	keySuffix = ".db.gz"
+)
+
+// Database is the database which is backed up.
+type Database struct {
+	// Name identifies the database in object keys and metric labels.
+	Name string
+
+	// Snapshot writes a consistent copy of the database to a new file at the path.
+	Snapshot func(ctx context.Context, path string) error
+}
+
+// Object is the object stored in the bucket.
+type Object struct {
+	Key          string
+	LastModified time.Time
+}
+
+// Bucket stores backups.
+type Bucket interface {
+	// Put uploads the body to the object with the key.
+	Put(ctx context.Context, key string, body io.Reader) error
+
+	// List lists objects which keys start with the prefix.
+	List(ctx context.Context, prefix string) ([]Object, error)
+
+	// Delete deletes objects with the keys.
+	Delete(ctx context.Context, keys []string) error
+}
+
+// Config is the configuration of the Scheduler.
+type Config struct {
+	// Schedule is the cron expression backups are made on, e.g. "0 3 * * *",
+	// which is evaluated in UTC unless it's prefixed with CRON_TZ.
+	Schedule string
+
+	// Prefix is the prefix of object keys of backups.
+	Prefix string
+
+	// Retention is the period backups are kept for, zero keeps them forever.
+	// The latest backup of each database is never pruned.
+	Retention time.Duration
+
+	// Dir is the directory snapshots are written to before they are
+	// uploaded, the default directory for temporary files by default.
+	Dir string
+
+	// Logger is the logger of the Scheduler.
+	Logger *slog.Logger
+}
+
+// Scheduler backs databases up to the bucket on the schedule.
+type Scheduler struct {
+	cfg       Config
+	schedule  cron.Schedule
+	bucket    Bucket
+	observer  telemetry.Observer
+	databases []Database
+	logger    *slog.Logger
+
+	// now returns the current time, which tests move forward.
+	now func() time.Time
+}
+
+// New returns a pointer to a new instance of Scheduler which backs the databases up.
+func New(cfg Config, bucket Bucket, observer telemetry.Observer, databases ...Database) (*Scheduler, error) {
+	schedule, parseErr := cron.ParseStandard(cfg.Schedule)
+	if parseErr != nil {
+		return nil, fmt.Errorf("parse backup schedule %q: %w", cfg.Schedule, parseErr)
+	}
+
+	if cfg.Retention < 0 {
+		return nil, fmt.Errorf("backup retention should not be negative: %s", cfg.Retention)
+	}
+
+	if len(databases) == 0 {
+		return nil, errors.New("no databases to back up")
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	s := Scheduler{
+		cfg:       cfg,
+		schedule:  schedule,
+		bucket:    bucket,
+		observer:  observer,
+		databases: databases,
+		logger:    logger,
+		now:       func() time.Time { return time.Now().UTC() },
+	}
+
+	return &s, nil
+}
+
+// Run backs databases up on the schedule until the context is canceled.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		next := s.schedule.Next(s.now())
+
+		s.logger.Debug("Next backup has been scheduled",
+			slog.Time("at", next),
+		)
+
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+
+		case <-timer.C:
+			// Errors are logged and observed by Backup.
+			_ = s.Backup(ctx)
+		}
+	}
+}
+
+// Backup uploads snapshots of all databases and prunes their expired backups.
+// A database which fails to be backed up doesn't prevent others from being backed up.
+func (s *Scheduler) Backup(ctx context.Context) error {
+	var errs []error
+
+	for _, db := range s.databases {
+		start := s.now()
+
+		if err := s.backup(ctx, db, start); err != nil {
+			s.observer.BackupFailures(db.Name).Inc()
+			s.observer.BackupsFailing(db.Name).Set(1)
+
+			s.logger.Error("Failed to back up database",
+				slog.String("database", db.Name),
+				slog.String("error", err.Error()),
+			)
+
+			errs = append(errs, fmt.Errorf("back up %s database: %w", db.Name, err))
+
+			continue
+		}
+
+		s.observer.Backups(db.Name).Inc()
+		s.observer.BackupsFailing(db.Name).Set(0)
+
+		s.logger.Info("Database has been backed up",
+			slog.String("database", db.Name),
+			slog.String("duration", s.now().Sub(start).String()),
+		)
+
+		if err := s.prune(ctx, db, start); err != nil {
+			s.logger.Error("Failed to prune expired backups",
+				slog.String("database", db.Name),
+				slog.String("error", err.Error()),
+			)
+
+			errs = append(errs, fmt.Errorf("prune %s database backups: %w", db.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// backup uploads the compressed snapshot of the database.
+func (s *Scheduler) backup(ctx context.Context, db Database, start time.Time) error {
+	dir, dirErr := os.MkdirTemp(s.cfg.Dir, "plainq-backup-")
+	if dirErr != nil {
+		return fmt.Errorf("create snapshot directory: %w", dirErr)
+	}
+
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	// The snapshot is written to the file which doesn't exist yet.
+	snapshotPath := filepath.Join(dir, db.Name+".db")
+
+	if err := db.Snapshot(ctx, snapshotPath); err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+
+	snapshot, openErr := os.Open(snapshotPath)
+	if openErr != nil {
+		return fmt.Errorf("open snapshot: %w", openErr)
+	}
+
+	defer func() { _ = snapshot.Close() }()
+
+	// The snapshot is compressed while it's uploaded,
+	// so it never takes the disk space twice.
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		zw := gzip.NewWriter(pw)
+
+		_, copyErr := io.Copy(zw, snapshot)
+		_ = pw.CloseWithError(errors.Join(copyErr, zw.Close()))
+	}()
+
+	key := s.key(db, start)
+	putErr := s.bucket.Put(ctx, key, pr)
+
+	// Closing the reader stops the compression when the upload has stopped early.
+	_ = pr.CloseWithError(putErr)
+	<-done
+
+	if putErr != nil {
+		return fmt.Errorf("upload %q: %w", key, putErr)
+	}
+
+	return nil
+}
+
+// prune deletes backups of the database which are older than the retention period.
+func (s *Scheduler) prune(ctx context.Context, db Database, now time.Time) error {
+	if s.cfg.Retention == 0 {
+		return nil
+	}
+
+	objects, listErr := s.bucket.List(ctx, s.dir(db))
+	if listErr != nil {
+		return fmt.Errorf("list backups: %w", listErr)
+	}
+
+	var latest Object
+
+	for _, o := range objects {
+		if o.LastModified.After(latest.LastModified) {
+			latest = o
+		}
+	}
+
+	expired := make([]string, 0, len(objects))
+	before := now.Add(-s.cfg.Retention)
+
+	for _, o := range objects {
+		if o.Key != latest.Key && o.LastModified.Before(before) {
+			expired = append(expired, o.Key)
+		}
+	}
+
+	if len(expired) == 0 {
+		return nil
+	}
+
+	if err := s.bucket.Delete(ctx, expired); err != nil {
+		return fmt.Errorf("delete backups: %w", err)
+	}
+
+	s.logger.Info("Expired backups have been pruned",
+		slog.String("database", db.Name),
+		slog.Int("count", len(expired)),
+	)
+
+	return nil
+}
+
+// dir returns the prefix of object keys of backups of the database.
+func (s *Scheduler) dir(db Database) string {
+	return path.Join(s.cfg.Prefix, db.Name) + "/"
+}
+
+// key returns the object key of the backup of the database started at the time.
+func (s *Scheduler) key(db Database, start time.Time) string {
+	return s.dir(db) + start.UTC().Format(keyTimeLayout) + keySuffix
+}
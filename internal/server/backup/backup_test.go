@@ -0,0 +1,254 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/telemetry"
+)
+
+// memBucket is the Bucket which keeps objects in memory.
+type memBucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	times   map[string]time.Time
+	now     func() time.Time
+	putErr  error
+}
+
+func newMemBucket(now func() time.Time) *memBucket {
+	return &memBucket{objects: make(map[string][]byte), times: make(map[string]time.Time), now: now}
+}
+
+func (b *memBucket) Put(_ context.Context, key string, body io.Reader) error {
+	if b.putErr != nil {
+		return b.putErr
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.objects[key] = data
+	b.times[key] = b.now()
+
+	return nil
+}
+
+func (b *memBucket) List(_ context.Context, prefix string) ([]Object, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var objects []Object
+
+	for key, t := range b.times {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, Object{Key: key, LastModified: t})
+		}
+	}
+
+	return objects, nil
+}
+
+func (b *memBucket) Delete(_ context.Context, keys []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, key := range keys {
+		delete(b.objects, key)
+		delete(b.times, key)
+	}
+
+	return nil
+}
+
+func (b *memBucket) keys() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	keys := make([]string, 0, len(b.objects))
+	for key := range b.objects {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// writeSnapshot returns the snapshot function which writes the content to the file.
+func writeSnapshot(content string) func(ctx context.Context, path string) error {
+	return func(_ context.Context, path string) error {
+		return os.WriteFile(path, []byte(content), 0o600)
+	}
+}
+
+func gunzip(t *testing.T, data []byte) string {
+	t.Helper()
+
+	zr, zrErr := gzip.NewReader(bytes.NewReader(data))
+	td.Require(t).CmpNoError(zrErr)
+
+	out, readErr := io.ReadAll(zr)
+	td.Require(t).CmpNoError(readErr)
+
+	return string(out)
+}
+
+func TestScheduler_Backup(t *testing.T) {
+	now := time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	bucket := newMemBucket(clock)
+	observer := telemetry.NewObserver()
+
+	s, err := New(Config{Schedule: "0 3 * * *", Prefix: "plainq", Dir: t.TempDir()}, bucket, observer,
+		Database{Name: "backup_test_storage", Snapshot: writeSnapshot("storage")},
+		Database{Name: "backup_test_telemetry", Snapshot: func(context.Context, string) error {
+			return errors.New("disk is full")
+		}},
+	)
+	td.Require(t).CmpNoError(err)
+
+	s.now = clock
+
+	// The database which fails to be backed up doesn't prevent others from being backed up.
+	td.CmpString(t, s.Backup(context.Background()),
+		"back up backup_test_telemetry database: snapshot: disk is full")
+
+	td.Cmp(t, bucket.keys(), []string{"plainq/backup_test_storage/20261017T030000Z.db.gz"})
+	td.Cmp(t, gunzip(t, bucket.objects["plainq/backup_test_storage/20261017T030000Z.db.gz"]), "storage")
+
+	td.Cmp(t, observer.Backups("backup_test_storage").Get(), uint64(1))
+	td.Cmp(t, observer.BackupsFailing("backup_test_storage").Get(), uint64(0))
+	td.Cmp(t, observer.BackupFailures("backup_test_telemetry").Get(), uint64(1))
+	td.Cmp(t, observer.BackupsFailing("backup_test_telemetry").Get(), uint64(1))
+
+	bucket.putErr = errors.New("access denied")
+	td.CmpContains(t, s.Backup(context.Background()), `upload "plainq/backup_test_storage/20261017T030000Z.db.gz": access denied`)
+	td.Cmp(t, observer.BackupsFailing("backup_test_storage").Get(), uint64(1))
+
+	// The failing gauge is reset once the backup succeeds.
+	bucket.putErr = nil
+	td.CmpError(t, s.Backup(context.Background()))
+	td.Cmp(t, observer.Backups("backup_test_storage").Get(), uint64(2))
+	td.Cmp(t, observer.BackupsFailing("backup_test_storage").Get(), uint64(0))
+}
+
+func TestScheduler_prune(t *testing.T) {
+	now := time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	tests := map[string]struct {
+		retention time.Duration
+		ages      map[string]time.Duration
+		want      []string
+	}{
+		"Expired": {
+			retention: 48 * time.Hour,
+			ages: map[string]time.Duration{
+				"storage/20261017T030000Z.db.gz":   0,
+				"storage/20261016T030000Z.db.gz":   24 * time.Hour,
+				"storage/20261014T030000Z.db.gz":   72 * time.Hour,
+				"telemetry/20261014T030000Z.db.gz": 72 * time.Hour,
+			},
+			want: []string{
+				"storage/20261016T030000Z.db.gz",
+				"storage/20261017T030000Z.db.gz",
+				"telemetry/20261014T030000Z.db.gz",
+			},
+		},
+		"LatestIsKept": {
+			retention: time.Hour,
+			ages: map[string]time.Duration{
+				"storage/20261014T030000Z.db.gz": 72 * time.Hour,
+				"storage/20261013T030000Z.db.gz": 96 * time.Hour,
+			},
+			want: []string{"storage/20261014T030000Z.db.gz"},
+		},
+		"KeptForever": {
+			ages: map[string]time.Duration{
+				"storage/20261014T030000Z.db.gz": 72 * time.Hour,
+				"storage/20261013T030000Z.db.gz": 96 * time.Hour,
+			},
+			want: []string{
+				"storage/20261013T030000Z.db.gz",
+				"storage/20261014T030000Z.db.gz",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			bucket := newMemBucket(clock)
+			for key, age := range tt.ages {
+				bucket.objects[key] = nil
+				bucket.times[key] = now.Add(-age)
+			}
+
+			db := Database{Name: "storage", Snapshot: writeSnapshot("storage")}
+
+			s, err := New(Config{Schedule: "@daily", Retention: tt.retention}, bucket, telemetry.NewObserver(), db)
+			td.Require(t).CmpNoError(err)
+
+			td.CmpNoError(t, s.prune(context.Background(), db, now))
+			td.Cmp(t, bucket.keys(), tt.want)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	db := Database{Name: "storage", Snapshot: writeSnapshot("storage")}
+	observer := telemetry.NewObserver()
+
+	tests := map[string]struct {
+		cfg       Config
+		databases []Database
+		wantErr   string
+	}{
+		"Valid": {
+			cfg:       Config{Schedule: "0 3 * * *", Retention: 7 * 24 * time.Hour},
+			databases: []Database{db},
+		},
+		"InvalidSchedule": {
+			cfg:       Config{Schedule: "every day"},
+			databases: []Database{db},
+			wantErr:   `parse backup schedule "every day": expected exactly 5 fields, found 2: [every day]`,
+		},
+		"NegativeRetention": {
+			cfg:       Config{Schedule: "@daily", Retention: -time.Hour},
+			databases: []Database{db},
+			wantErr:   "backup retention should not be negative: -1h0m0s",
+		},
+		"NoDatabases": {
+			cfg:     Config{Schedule: "@daily"},
+			wantErr: "no databases to back up",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(tt.cfg, newMemBucket(time.Now), observer, tt.databases...)
+			if tt.wantErr == "" {
+				td.CmpNoError(t, err)
+				return
+			}
+
+			td.CmpString(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// maxDeleteKeys is the maximum number of objects deleted by a single request.
+const maxDeleteKeys = 1000
+
+// S3Config is the configuration of the S3-compatible bucket.
+type S3Config struct {
+	// Bucket is the name of the bucket.
+	Bucket string
+
+	// Endpoint is the URL of the S3-compatible service, e.g. "http://localhost:9000".
+	// Empty means AWS S3.
+	Endpoint string
+
+	// Region is the region of the bucket.
+	Region string
+
+	// AccessKeyID and SecretAccessKey authenticate requests when the access key is set,
+	// otherwise credentials are resolved from the environment.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// PathStyle addresses the bucket in the path of the URL instead of the host name,
+	// which is required by most S3-compatible services.
+	PathStyle bool
+}
+
+// S3Bucket implements the Bucket backed by the S3-compatible bucket.
+type S3Bucket struct {
+	bucket   string
+	client   *s3.S3
+	uploader *s3manager.Uploader
+}
+
+// NewS3Bucket returns a pointer to a new instance of S3Bucket.
+func NewS3Bucket(cfg S3Config) (*S3Bucket, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("backup bucket is required")
+	}
+
+	awsCfg := aws.NewConfig().
+		WithRegion(cfg.Region).
+		WithS3ForcePathStyle(cfg.PathStyle)
+
+	if cfg.Endpoint != "" {
+		awsCfg = awsCfg.WithEndpoint(cfg.Endpoint)
+	}
+
+	if cfg.AccessKeyID != "" {
+		awsCfg = awsCfg.WithCredentials(credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.SecretAccessKey, ""))
+	}
+
+	sess, sessErr := session.NewSession(awsCfg)
+	if sessErr != nil {
+		return nil, fmt.Errorf("create S3 session: %w", sessErr)
+	}
+
+	client := s3.New(sess)
+
+	b := S3Bucket{
+		bucket:   cfg.Bucket,
+		client:   client,
+		uploader: s3manager.NewUploaderWithClient(client),
+	}
+
+	return &b, nil
+}
+
+func (b *S3Bucket) Put(ctx context.Context, key string, body io.Reader) error {
+	if _, err := b.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	}); err != nil {
+		return fmt.Errorf("upload object: %w", err)
+	}
+
+	return nil
+}
+
+func (b *S3Bucket) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+
+	input := s3.ListObjectsV2Input{
+		Bucket: aws.String(b.bucket),
+		Prefix: aws.String(prefix),
+	}
+
+	if err := b.client.ListObjectsV2PagesWithContext(ctx, &input, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, o := range page.Contents {
+			objects = append(objects, Object{
+				Key:          aws.StringValue(o.Key),
+				LastModified: aws.TimeValue(o.LastModified),
+			})
+		}
+
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("list objects: %w", err)
+	}
+
+	return objects, nil
+}
+
+func (b *S3Bucket) Delete(ctx context.Context, keys []string) error {
+	for start := 0; start < len(keys); start += maxDeleteKeys {
+		batch := keys[start:min(start+maxDeleteKeys, len(keys))]
+
+		ids := make([]*s3.ObjectIdentifier, 0, len(batch))
+		for _, key := range batch {
+			ids = append(ids, &s3.ObjectIdentifier{Key: aws.String(key)})
+		}
+
+		out, deleteErr := b.client.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(b.bucket),
+			Delete: &s3.Delete{Objects: ids, Quiet: aws.Bool(true)},
+		})
+		if deleteErr != nil {
+			return fmt.Errorf("delete objects: %w", deleteErr)
+		}
+
+		if len(out.Errors) > 0 {
+			e := out.Errors[0]
+
+			return fmt.Errorf("delete object %q: %s: %s (%d objects failed)",
+				aws.StringValue(e.Key), aws.StringValue(e.Code), aws.StringValue(e.Message), len(out.Errors),
+			)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package backup
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+// fakeS3 serves a single bucket addressed in the path of URLs.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/backups/")
+
+	switch {
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = string(body)
+
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		prefix := r.URL.Query().Get("prefix")
+
+		var b strings.Builder
+		b.WriteString(`<ListBucketResult><Name>backups</Name><IsTruncated>false</IsTruncated>`)
+
+		for k := range f.objects {
+			if strings.HasPrefix(k, prefix) {
+				fmt.Fprintf(&b, `<Contents><Key>%s</Key><LastModified>2026-10-17T03:00:00.000Z</LastModified></Contents>`, k)
+			}
+		}
+
+		b.WriteString(`</ListBucketResult>`)
+		_, _ = io.WriteString(w, b.String())
+
+	case r.Method == http.MethodPost && r.URL.Query().Has("delete"):
+		var input struct {
+			Objects []struct {
+				Key string `xml:"Key"`
+			} `xml:"Object"`
+		}
+
+		if err := xml.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		for _, o := range input.Objects {
+			delete(f.objects, o.Key)
+		}
+
+		_, _ = io.WriteString(w, `<DeleteResult></DeleteResult>`)
+
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func TestS3Bucket(t *testing.T) {
+	ctx := context.Background()
+
+	fake := fakeS3{objects: make(map[string]string)}
+	srv := httptest.NewServer(&fake)
+	t.Cleanup(srv.Close)
+
+	b, err := NewS3Bucket(S3Config{
+		Bucket:          "backups",
+		Endpoint:        srv.URL,
+		Region:          "us-east-1",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		PathStyle:       true,
+	})
+	td.Require(t).CmpNoError(err)
+
+	td.CmpNoError(t, b.Put(ctx, "plainq/storage/20261017T030000Z.db.gz", strings.NewReader("storage")))
+	td.CmpNoError(t, b.Put(ctx, "plainq/telemetry/20261017T030000Z.db.gz", strings.NewReader("telemetry")))
+	td.Cmp(t, fake.objects["plainq/storage/20261017T030000Z.db.gz"], "storage")
+
+	objects, listErr := b.List(ctx, "plainq/storage/")
+	td.CmpNoError(t, listErr)
+	td.Cmp(t, objects, []Object{{
+		Key:          "plainq/storage/20261017T030000Z.db.gz",
+		LastModified: time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC),
+	}})
+
+	td.CmpNoError(t, b.Delete(ctx, []string{"plainq/storage/20261017T030000Z.db.gz"}))
+	td.Cmp(t, fake.objects, map[string]string{"plainq/telemetry/20261017T030000Z.db.gz": "telemetry"})
+}
+
+func TestNewS3Bucket(t *testing.T) {
+	_, err := NewS3Bucket(S3Config{})
+	td.CmpString(t, err, "backup bucket is required")
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+// listBreakers returns tripped circuit breakers.
+func (s *PlainQ) listBreakers() *v1.ListBreakersResponse {
+	if s.breaker == nil {
+		return &v1.ListBreakersResponse{}
+	}
+
+	return &v1.ListBreakersResponse{Breakers: s.breaker.List()}
+}
+
+// resetBreaker unfreezes the queue and records it to the audit log.
+func (s *PlainQ) resetBreaker(input *v1.ResetBreakerRequest) (*v1.ResetBreakerResponse, error) {
+	if err := validateQueueIDFromRequest(input); err != nil {
+		return nil, err
+	}
+
+	if s.breaker == nil {
+		return nil, fmt.Errorf("%w: circuit breaker is not enabled", errkit.ErrUnavailable)
+	}
+
+	if err := s.breaker.Reset(input.GetQueueId()); err != nil {
+		return nil, fmt.Errorf("reset breaker: %w", err)
+	}
+
+	s.audit.Info("Circuit breaker has been reset",
+		slog.String("queue_id", input.GetQueueId()),
+	)
+
+	return &v1.ResetBreakerResponse{}, nil
+}
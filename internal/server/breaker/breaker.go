@@ -0,0 +1,305 @@
+// Package breaker implements the circuit breaker which freezes queues
+// on anomalies, e.g. a spike of dead-lettered messages after a bad deploy,
+// to stop feedback loops until an operator resets the breaker.
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/storage"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/servekit/errkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// listPageSize represents the number of queues evaluated per page.
+const listPageSize int32 = 100
+
+// Config holds anomaly conditions which trip the breaker.
+type Config struct {
+	// Action is the operation paused on the frozen queue.
+	Action v1.BreakerAction
+
+	// MaxDepth trips the breaker when the queue holds
+	// at least MaxDepth messages. Zero disables the condition.
+	MaxDepth uint64
+
+	// MaxDeadLettered trips the breaker when at least MaxDeadLettered messages
+	// have been moved to the dead letter queue since the previous evaluation.
+	// Zero disables the condition.
+	MaxDeadLettered uint64
+
+	// Interval is the time between evaluations of anomaly conditions.
+	Interval time.Duration
+}
+
+// ParseAction parses the action name: "receive" or "send".
+func ParseAction(name string) (v1.BreakerAction, error) {
+	switch strings.ToLower(name) {
+	case "receive":
+		return v1.BreakerAction_BREAKER_ACTION_PAUSE_RECEIVE, nil
+
+	case "send":
+		return v1.BreakerAction_BREAKER_ACTION_PAUSE_SEND, nil
+
+	default:
+		return v1.BreakerAction_BREAKER_ACTION_UNSPECIFIED,
+			fmt.Errorf("%w: unknown breaker action %q, should be one of: receive, send", errkit.ErrInvalidArgument, name)
+	}
+}
+
+// Queues lists queues with their depth.
+type Queues interface {
+	ListQueues(ctx context.Context, input *v1.ListQueuesRequest) (*v1.ListQueuesResponse, error)
+}
+
+// Breaker evaluates anomaly conditions of queues and freezes queues
+// which met any of them. Frozen queues stay frozen until they are reset.
+type Breaker struct {
+	cfg      Config
+	queues   Queues
+	observer telemetry.Observer
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	tripped map[string]*v1.Breaker
+
+	// deadLettered holds values of dead-lettered messages
+	// counters of queues at the previous evaluation.
+	deadLettered map[string]uint64
+}
+
+// New returns a pointer to a new instance of Breaker.
+func New(cfg Config, queues Queues, observer telemetry.Observer, logger *slog.Logger) (*Breaker, error) {
+	if cfg.Action == v1.BreakerAction_BREAKER_ACTION_UNSPECIFIED {
+		return nil, fmt.Errorf("%w: breaker action should be specified", errkit.ErrInvalidArgument)
+	}
+
+	if cfg.Interval <= 0 {
+		return nil, fmt.Errorf("%w: breaker interval should be positive: %s", errkit.ErrInvalidArgument, cfg.Interval)
+	}
+
+	if cfg.MaxDepth == 0 && cfg.MaxDeadLettered == 0 {
+		return nil, fmt.Errorf("%w: at least one breaker condition should be specified", errkit.ErrInvalidArgument)
+	}
+
+	b := Breaker{
+		cfg:          cfg,
+		queues:       queues,
+		observer:     observer,
+		logger:       logger,
+		tripped:      make(map[string]*v1.Breaker),
+		deadLettered: make(map[string]uint64),
+	}
+
+	return &b, nil
+}
+
+// Run evaluates anomaly conditions every interval until the ctx is done.
+func (b *Breaker) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if err := b.evaluate(ctx); err != nil && ctx.Err() == nil {
+				b.logger.Error("Failed to evaluate circuit breaker conditions",
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
+
+// Allow returns an error when the action is paused on the queue.
+func (b *Breaker) Allow(queueID string, action v1.BreakerAction) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if t, ok := b.tripped[queueID]; ok && t.GetAction() == action {
+		return fmt.Errorf("%w: queue %q is frozen by the circuit breaker: %s",
+			errkit.ErrUnavailable, queueID, t.GetReason(),
+		)
+	}
+
+	return nil
+}
+
+// List returns tripped breakers ordered by trip time.
+func (b *Breaker) List() []*v1.Breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	list := make([]*v1.Breaker, 0, len(b.tripped))
+
+	for _, t := range b.tripped {
+		list = append(list, t)
+	}
+
+	slices.SortFunc(list, func(a, b *v1.Breaker) int {
+		return a.GetTrippedAt().AsTime().Compare(b.GetTrippedAt().AsTime())
+	})
+
+	return list
+}
+
+// Reset unfreezes the queue. The breaker trips again
+// if the anomaly persists at the next evaluation.
+func (b *Breaker) Reset(queueID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.tripped[queueID]; !ok {
+		return fmt.Errorf("breaker of queue %q: %w", queueID, errkit.ErrNotFound)
+	}
+
+	delete(b.tripped, queueID)
+
+	b.logger.Info("Circuit breaker has been reset",
+		slog.String("queue_id", queueID),
+	)
+
+	return nil
+}
+
+// Storage wraps the storage to pause operations on frozen queues.
+func (b *Breaker) Storage(s storage.Storage) storage.Storage {
+	return &guarded{Storage: s, breaker: b}
+}
+
+// evaluate evaluates anomaly conditions of all queues.
+func (b *Breaker) evaluate(ctx context.Context) error {
+	seen := make(map[string]struct{})
+
+	var cursor string
+
+	for {
+		page, listErr := b.queues.ListQueues(ctx, &v1.ListQueuesRequest{
+			Limit:        listPageSize,
+			Cursor:       cursor,
+			IncludeStats: b.cfg.MaxDepth > 0,
+		})
+		if listErr != nil {
+			return fmt.Errorf("list queues: %w", listErr)
+		}
+
+		for _, q := range page.GetQueues() {
+			seen[q.GetQueueId()] = struct{}{}
+
+			if reason := b.anomaly(q); reason != "" {
+				b.trip(q.GetQueueId(), reason)
+			}
+		}
+
+		if !page.GetHasMore() {
+			break
+		}
+
+		cursor = page.GetNextCursor()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Forget deleted queues.
+	for id := range b.deadLettered {
+		if _, ok := seen[id]; !ok {
+			delete(b.deadLettered, id)
+			delete(b.tripped, id)
+		}
+	}
+
+	return nil
+}
+
+// anomaly returns the description of the anomaly of the queue, or an empty string.
+func (b *Breaker) anomaly(q *v1.DescribeQueueResponse) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	current := b.observer.MessageDropped(q.GetQueueId(), v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER).Get()
+	previous, known := b.deadLettered[q.GetQueueId()]
+	b.deadLettered[q.GetQueueId()] = current
+
+	if b.cfg.MaxDepth > 0 && q.GetDepth() >= b.cfg.MaxDepth {
+		return fmt.Sprintf("depth %d reached the limit of %d messages", q.GetDepth(), b.cfg.MaxDepth)
+	}
+
+	// The counter has no baseline at the first evaluation of the queue.
+	if b.cfg.MaxDeadLettered > 0 && known && current-previous >= b.cfg.MaxDeadLettered {
+		return fmt.Sprintf("%d messages have been dead-lettered in %s, the limit is %d",
+			current-previous, b.cfg.Interval, b.cfg.MaxDeadLettered,
+		)
+	}
+
+	return ""
+}
+
+// trip freezes the queue unless it is already frozen.
+func (b *Breaker) trip(queueID, reason string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.tripped[queueID]; ok {
+		return
+	}
+
+	b.tripped[queueID] = &v1.Breaker{
+		QueueId:   queueID,
+		Action:    b.cfg.Action,
+		Reason:    reason,
+		TrippedAt: timestamppb.Now(),
+	}
+
+	b.observer.BreakerTrips(queueID).Inc()
+
+	b.logger.Warn("Circuit breaker has been tripped",
+		slog.String("queue_id", queueID),
+		slog.String("action", b.cfg.Action.String()),
+		slog.String("reason", reason),
+	)
+}
+
+// guarded pauses operations on frozen queues.
+type guarded struct {
+	storage.Storage
+
+	breaker *Breaker
+}
+
+func (g *guarded) Send(ctx context.Context, input *v1.SendRequest) (*v1.SendResponse, error) {
+	if err := g.breaker.Allow(input.GetQueueId(), v1.BreakerAction_BREAKER_ACTION_PAUSE_SEND); err != nil {
+		return nil, err
+	}
+
+	return g.Storage.Send(ctx, input)
+}
+
+func (g *guarded) Transact(ctx context.Context, input *v1.TransactRequest) (*v1.TransactResponse, error) {
+	for _, send := range input.GetSends() {
+		if err := g.breaker.Allow(send.GetQueueId(), v1.BreakerAction_BREAKER_ACTION_PAUSE_SEND); err != nil {
+			return nil, err
+		}
+	}
+
+	return g.Storage.Transact(ctx, input)
+}
+
+func (g *guarded) Receive(ctx context.Context, input *v1.ReceiveRequest) (*v1.ReceiveResponse, error) {
+	if err := g.breaker.Allow(input.GetQueueId(), v1.BreakerAction_BREAKER_ACTION_PAUSE_RECEIVE); err != nil {
+		return nil, err
+	}
+
+	return g.Storage.Receive(ctx, input)
+}
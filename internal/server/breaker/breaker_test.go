@@ -0,0 +1,146 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/storage"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+	"github.com/plainq/servekit/logkit"
+)
+
+type fakeQueues struct {
+	storage.Storage
+
+	queues []*v1.DescribeQueueResponse
+}
+
+func (f *fakeQueues) ListQueues(_ context.Context, input *v1.ListQueuesRequest) (*v1.ListQueuesResponse, error) {
+	start := 0
+
+	for i, q := range f.queues {
+		if q.GetQueueId() == input.GetCursor() {
+			start = i + 1
+		}
+	}
+
+	end := min(start+int(input.GetLimit()), len(f.queues))
+	output := v1.ListQueuesResponse{Queues: f.queues[start:end], HasMore: end < len(f.queues)}
+
+	if output.HasMore {
+		output.NextCursor = f.queues[end-1].GetQueueId()
+	}
+
+	return &output, nil
+}
+
+func (*fakeQueues) Send(context.Context, *v1.SendRequest) (*v1.SendResponse, error) {
+	return &v1.SendResponse{}, nil
+}
+
+func (*fakeQueues) Receive(context.Context, *v1.ReceiveRequest) (*v1.ReceiveResponse, error) {
+	return &v1.ReceiveResponse{}, nil
+}
+
+func TestNew_validation(t *testing.T) {
+	tests := map[string]Config{
+		"NoAction":     {Interval: time.Minute, MaxDepth: 1},
+		"NoInterval":   {Action: v1.BreakerAction_BREAKER_ACTION_PAUSE_SEND, MaxDepth: 1},
+		"NoConditions": {Action: v1.BreakerAction_BREAKER_ACTION_PAUSE_SEND, Interval: time.Minute},
+	}
+
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(cfg, &fakeQueues{}, telemetry.NewObserver(), logkit.NewNop())
+			td.Cmp(t, errors.Is(err, errkit.ErrInvalidArgument), true)
+		})
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	action, err := ParseAction("Send")
+	td.CmpNoError(t, err)
+	td.Cmp(t, action, v1.BreakerAction_BREAKER_ACTION_PAUSE_SEND)
+
+	_, err = ParseAction("pause")
+	td.CmpError(t, err)
+}
+
+func TestBreaker(t *testing.T) {
+	observer := telemetry.NewObserver()
+
+	deep := &v1.DescribeQueueResponse{QueueId: idkit.XID(), Depth: 100}
+	failing := &v1.DescribeQueueResponse{QueueId: idkit.XID(), Depth: 1}
+	healthy := &v1.DescribeQueueResponse{QueueId: idkit.XID(), Depth: 1}
+
+	queues := fakeQueues{queues: []*v1.DescribeQueueResponse{deep, failing, healthy}}
+
+	b, newErr := New(Config{
+		Action:          v1.BreakerAction_BREAKER_ACTION_PAUSE_RECEIVE,
+		MaxDepth:        100,
+		MaxDeadLettered: 5,
+		Interval:        time.Minute,
+	}, &queues, observer, logkit.NewNop())
+	td.CmpNoError(t, newErr)
+
+	deadLettered := observer.MessageDropped(failing.GetQueueId(), v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER)
+	deadLettered.Add(10)
+
+	// The first evaluation sets the baseline of dead-lettered messages.
+	td.CmpNoError(t, b.evaluate(context.Background()))
+	td.Cmp(t, len(b.List()), 1)
+	td.Cmp(t, b.List()[0].GetQueueId(), deep.GetQueueId())
+
+	deadLettered.Add(5)
+
+	td.CmpNoError(t, b.evaluate(context.Background()))
+	td.Cmp(t, len(b.List()), 2)
+	td.Cmp(t, observer.BreakerTrips(failing.GetQueueId()).Get(), uint64(1))
+
+	guarded := b.Storage(&queues)
+
+	_, err := guarded.Receive(context.Background(), &v1.ReceiveRequest{QueueId: failing.GetQueueId()})
+	td.Cmp(t, errors.Is(err, errkit.ErrUnavailable), true)
+
+	_, err = guarded.Send(context.Background(), &v1.SendRequest{QueueId: failing.GetQueueId()})
+	td.CmpNoError(t, err)
+
+	_, err = guarded.Receive(context.Background(), &v1.ReceiveRequest{QueueId: healthy.GetQueueId()})
+	td.CmpNoError(t, err)
+
+	td.CmpNoError(t, b.Reset(failing.GetQueueId()))
+	td.Cmp(t, errors.Is(b.Reset(failing.GetQueueId()), errkit.ErrNotFound), true)
+
+	_, err = guarded.Receive(context.Background(), &v1.ReceiveRequest{QueueId: failing.GetQueueId()})
+	td.CmpNoError(t, err)
+
+	// Deleted queues are forgotten.
+	queues.queues = []*v1.DescribeQueueResponse{failing, healthy}
+
+	td.CmpNoError(t, b.evaluate(context.Background()))
+	td.Cmp(t, len(b.List()), 0)
+}
+
+func TestBreaker_evaluate_pages(t *testing.T) {
+	queues := fakeQueues{}
+
+	for range int(listPageSize) + 5 {
+		queues.queues = append(queues.queues, &v1.DescribeQueueResponse{QueueId: idkit.XID(), Depth: 1})
+	}
+
+	b, newErr := New(Config{
+		Action:   v1.BreakerAction_BREAKER_ACTION_PAUSE_SEND,
+		MaxDepth: 1,
+		Interval: time.Minute,
+	}, &queues, telemetry.NewObserver(), logkit.NewNop())
+	td.CmpNoError(t, newErr)
+
+	td.CmpNoError(t, b.evaluate(context.Background()))
+	td.Cmp(t, len(b.List()), len(queues.queues))
+}
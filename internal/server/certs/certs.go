@@ -0,0 +1,138 @@
+// Package certs provides TLS certificates which are reloaded
+// when the certificate or key files change on disk.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reloader holds the TLS certificate loaded from the certificate and key files.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	logger   *slog.Logger
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewReloader returns a pointer to a new instance of Reloader
+// with the certificate loaded from given files.
+func NewReloader(certFile, keyFile string, logger *slog.Logger) (*Reloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("both TLS certificate and key files should be specified")
+	}
+
+	r := Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logger,
+	}
+
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// TLSConfig returns the TLS configuration which serves the current certificate.
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+}
+
+// GetCertificate returns the current certificate, see tls.Config GetCertificate.
+func (r *Reloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// Watch checks certificate and key files every interval and reloads
+// the certificate when any of them has been changed, until the ctx is done.
+// The current certificate is kept when the changed files can't be loaded.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			reloaded, err := r.reload()
+			if err != nil {
+				r.logger.Error("Failed to reload TLS certificate",
+					slog.String("cert", r.certFile),
+					slog.String("error", err.Error()),
+				)
+
+				continue
+			}
+
+			if reloaded {
+				r.logger.Info("TLS certificate has been reloaded",
+					slog.String("cert", r.certFile),
+				)
+			}
+		}
+	}
+}
+
+// reload loads the certificate when files have been modified since the last load.
+func (r *Reloader) reload() (bool, error) {
+	modTime, statErr := r.lastModified()
+	if statErr != nil {
+		return false, statErr
+	}
+
+	r.mu.RLock()
+	unchanged := r.cert != nil && modTime.Equal(r.modTime)
+	r.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	cert, loadErr := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if loadErr != nil {
+		return false, fmt.Errorf("load TLS certificate: %w", loadErr)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.mu.Unlock()
+
+	return true, nil
+}
+
+// lastModified returns the latest modification time of certificate and key files.
+func (r *Reloader) lastModified() (time.Time, error) {
+	var latest time.Time
+
+	for _, name := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("stat TLS file: %w", err)
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
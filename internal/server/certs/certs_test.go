@@ -0,0 +1,95 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/logkit"
+)
+
+// writeCert writes a self-signed certificate with given common name and its key to files.
+func writeCert(t *testing.T, certFile, keyFile, name string) {
+	t.Helper()
+
+	key, keyErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	td.CmpNoError(t, keyErr)
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, certErr := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	td.CmpNoError(t, certErr)
+
+	keyDER, marshalErr := x509.MarshalECPrivateKey(key)
+	td.CmpNoError(t, marshalErr)
+
+	td.CmpNoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	td.CmpNoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+}
+
+func commonName(t *testing.T, r *Reloader) string {
+	t.Helper()
+
+	cert, err := r.GetCertificate(nil)
+	td.CmpNoError(t, err)
+
+	leaf, parseErr := x509.ParseCertificate(cert.Certificate[0])
+	td.CmpNoError(t, parseErr)
+
+	return leaf.Subject.CommonName
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	_, err := NewReloader(certFile, "", logkit.NewNop())
+	td.CmpError(t, err)
+
+	_, err = NewReloader(certFile, keyFile, logkit.NewNop())
+	td.CmpError(t, err)
+
+	writeCert(t, certFile, keyFile, "first")
+
+	r, newErr := NewReloader(certFile, keyFile, logkit.NewNop())
+	td.CmpNoError(t, newErr)
+	td.Cmp(t, commonName(t, r), "first")
+
+	reloaded, reloadErr := r.reload()
+	td.CmpNoError(t, reloadErr)
+	td.Cmp(t, reloaded, false)
+
+	writeCert(t, certFile, keyFile, "second")
+
+	// Make sure the modification time differs on file systems with coarse timestamps.
+	later := time.Now().Add(time.Minute)
+	td.CmpNoError(t, os.Chtimes(certFile, later, later))
+
+	reloaded, reloadErr = r.reload()
+	td.CmpNoError(t, reloadErr)
+	td.Cmp(t, reloaded, true)
+	td.Cmp(t, commonName(t, r), "second")
+
+	// Broken files keep the current certificate.
+	td.CmpNoError(t, os.WriteFile(keyFile, []byte("broken"), 0o600))
+	td.CmpNoError(t, os.Chtimes(keyFile, later.Add(time.Minute), later.Add(time.Minute)))
+
+	_, reloadErr = r.reload()
+	td.CmpError(t, reloadErr)
+	td.Cmp(t, commonName(t, r), "second")
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/plainq/plainq/internal/server/cluster"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// clusterStatus returns the status of the cluster as seen by the server.
+func (s *PlainQ) clusterStatus() (*v1.ClusterStatusResponse, error) {
+	if s.cluster == nil {
+		return nil, fmt.Errorf("%w: cluster mode is not enabled", errkit.ErrUnavailable)
+	}
+
+	status, statusErr := s.cluster.Status()
+	if statusErr != nil {
+		return nil, fmt.Errorf("cluster status: %w", statusErr)
+	}
+
+	output := v1.ClusterStatusResponse{
+		NodeId:        status.NodeID,
+		State:         clusterNodeStateToProto(status.State),
+		LeaderId:      status.LeaderID,
+		LeaderAddress: status.LeaderAddress,
+		Term:          status.Term,
+		LastLogIndex:  status.LastLogIndex,
+		AppliedIndex:  status.AppliedIndex,
+		Servers:       make([]*v1.ClusterServer, 0, len(status.Servers)),
+	}
+
+	if !status.LastContact.IsZero() {
+		output.LastContact = timestamppb.New(status.LastContact)
+	}
+
+	for _, server := range status.Servers {
+		output.Servers = append(output.Servers, &v1.ClusterServer{
+			Id:      server.ID,
+			Address: server.Address,
+			Voter:   server.Voter,
+			Leader:  server.Leader,
+		})
+	}
+
+	return &output, nil
+}
+
+func clusterNodeStateToProto(state cluster.State) v1.ClusterNodeState {
+	switch state {
+	case cluster.Follower:
+		return v1.ClusterNodeState_CLUSTER_NODE_STATE_FOLLOWER
+
+	case cluster.Candidate:
+		return v1.ClusterNodeState_CLUSTER_NODE_STATE_CANDIDATE
+
+	case cluster.Leader:
+		return v1.ClusterNodeState_CLUSTER_NODE_STATE_LEADER
+
+	case cluster.Shutdown:
+		return v1.ClusterNodeState_CLUSTER_NODE_STATE_SHUTDOWN
+
+	default:
+		return v1.ClusterNodeState_CLUSTER_NODE_STATE_UNSPECIFIED
+	}
+}
@@ -0,0 +1,626 @@
+// Package cluster replicates the storage database between servers with Raft.
+//
+// Servers of the cluster elect the leader, which is the only one to change the database.
+// Transactions of the leader are executed as usual, and statements which change the
+// database are replicated before the commit, so followers apply the same statements in
+// the same order, while serving reads from their own copy of the database. When the
+// leader fails, followers elect another one, which continues from the replicated log.
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/mattn/go-sqlite3"
+	"github.com/plainq/servekit/errkit"
+)
+
+// ErrNotLeader is returned on attempts to change the database of the follower.
+var ErrNotLeader = fmt.Errorf("%w: the server is not the leader of the cluster", errkit.ErrUnavailable)
+
+const (
+	// applyTimeout is the default time the leader waits for the transaction to be replicated.
+	applyTimeout = 10 * time.Second
+
+	// barrierTimeout is the time the new leader waits to apply entries of the previous one.
+	barrierTimeout = time.Minute
+
+	// transportPool is the number of connections kept to each server.
+	transportPool = 3
+
+	// transportTimeout is the timeout of network operations of the transport.
+	transportTimeout = 10 * time.Second
+
+	// snapshotsRetain is the number of snapshots kept on disk.
+	snapshotsRetain = 2
+)
+
+// raftConfig returns the configuration of raft timeouts and limits.
+var raftConfig = raft.DefaultConfig
+
+// State is the role of the server in the cluster.
+type State uint8
+
+// States of the server.
+const (
+	Follower State = iota + 1
+	Candidate
+	Leader
+	Shutdown
+)
+
+func (s State) String() string {
+	switch s {
+	case Follower:
+		return "follower"
+
+	case Candidate:
+		return "candidate"
+
+	case Leader:
+		return "leader"
+
+	case Shutdown:
+		return "shutdown"
+
+	default:
+		return "unknown"
+	}
+}
+
+func stateOf(s raft.RaftState) State {
+	switch s {
+	case raft.Follower:
+		return Follower
+
+	case raft.Candidate:
+		return Candidate
+
+	case raft.Leader:
+		return Leader
+
+	default:
+		return Shutdown
+	}
+}
+
+// Server is the member of the cluster.
+type Server struct {
+	ID      string
+	Address string
+	Voter   bool
+	Leader  bool
+}
+
+// Status is the status of the cluster as seen by the server.
+type Status struct {
+	NodeID        string
+	State         State
+	LeaderID      string
+	LeaderAddress string
+	Term          uint64
+	LastLogIndex  uint64
+	AppliedIndex  uint64
+	LastContact   time.Time
+	Servers       []Server
+}
+
+// Config is the configuration of the server in the cluster.
+type Config struct {
+	// NodeID identifies the server in the cluster, which must not change between restarts.
+	NodeID string
+
+	// Addr is the address the server replicates the log on.
+	Addr string
+
+	// Advertise is the address other servers reach the server on, Addr by default.
+	Advertise string
+
+	// Dir is the directory which keeps the replicated log and snapshots of the database.
+	Dir string
+
+	// Peers holds addresses of servers by their identifiers, which form the cluster
+	// on the first start. The cluster which has been formed ignores them.
+	Peers map[string]string
+
+	// ApplyTimeout is the time the leader waits for the transaction to be replicated.
+	ApplyTimeout time.Duration
+
+	// Logger is the logger of the node.
+	Logger *slog.Logger
+}
+
+// ParsePeers parses comma separated addresses of servers by their
+// identifiers, e.g. "node1=10.0.0.1:7000,node2=10.0.0.2:7000".
+func ParsePeers(spec string) (map[string]string, error) {
+	peers := make(map[string]string)
+
+	for pair := range strings.SplitSeq(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		id, addr, ok := strings.Cut(pair, "=")
+		if id, addr = strings.TrimSpace(id), strings.TrimSpace(addr); !ok || id == "" || addr == "" {
+			return nil, fmt.Errorf("invalid peer %q, should be in form: id=host:port", pair)
+		}
+
+		peers[id] = addr
+	}
+
+	return peers, nil
+}
+
+// Node is the server in the cluster.
+type Node struct {
+	id      string
+	path    string
+	timeout time.Duration
+	logger  *slog.Logger
+
+	raft *raft.Raft
+	fsm  *fsm
+
+	// store and db are closed along with the node, unless they are nil.
+	store *sql.DB
+	db    *sql.DB
+
+	// seq numbers transactions replicated by the node.
+	seq atomic.Uint64
+
+	// leader is true once the node has become the leader and has applied the log.
+	leader atomic.Bool
+
+	// mu orders changes of the leadership.
+	mu     sync.Mutex
+	onLead []func(ctx context.Context) error
+
+	// leadCtx is canceled once the node loses the leadership.
+	leadCtx context.Context
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// New starts the node which applies the replicated log to the database of db, which is
+// closed along with the node. The database is changed through the DB of the node,
+// and db must only be used to read it.
+func New(cfg Config, db *sql.DB) (*Node, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create cluster directory: %w", err)
+	}
+
+	logDB, openErr := sql.Open("sqlite3", "file:"+filepath.Join(cfg.Dir, "raft.db")+"?_journal=WAL")
+	if openErr != nil {
+		return nil, fmt.Errorf("open raft log: %w", openErr)
+	}
+
+	logs, logsErr := newLogStore(logDB)
+	if logsErr != nil {
+		return nil, errors.Join(logsErr, logDB.Close())
+	}
+
+	snaps, snapsErr := raft.NewFileSnapshotStore(cfg.Dir, snapshotsRetain, logWriter(cfg.Logger))
+	if snapsErr != nil {
+		return nil, errors.Join(fmt.Errorf("create snapshot store: %w", snapsErr), logDB.Close())
+	}
+
+	advertise := cfg.Advertise
+	if advertise == "" {
+		advertise = cfg.Addr
+	}
+
+	advertiseAddr, resolveErr := net.ResolveTCPAddr("tcp", advertise)
+	if resolveErr != nil {
+		return nil, errors.Join(fmt.Errorf("resolve advertised address: %w", resolveErr), logDB.Close())
+	}
+
+	transport, transportErr := raft.NewTCPTransport(cfg.Addr, advertiseAddr, transportPool, transportTimeout,
+		logWriter(cfg.Logger),
+	)
+	if transportErr != nil {
+		return nil, errors.Join(fmt.Errorf("listen on %q: %w", cfg.Addr, transportErr), logDB.Close())
+	}
+
+	node, nodeErr := newNode(cfg, db, logs, logs, snaps, transport)
+	if nodeErr != nil {
+		return nil, errors.Join(nodeErr, transport.Close(), logDB.Close())
+	}
+
+	node.store = logDB
+	node.db = db
+
+	return node, nil
+}
+
+// newNode starts the node with the given stores and transport.
+func newNode(cfg Config, db *sql.DB, logs raft.LogStore, stable raft.StableStore, snaps raft.SnapshotStore,
+	transport raft.Transport,
+) (*Node, error) {
+	if cfg.NodeID == "" {
+		return nil, errors.New("node id is required")
+	}
+
+	if _, err := db.Exec(queryCreateStateTable); err != nil {
+		return nil, fmt.Errorf("create cluster state table: %w", err)
+	}
+
+	path, pathErr := databasePath(db)
+	if pathErr != nil {
+		return nil, pathErr
+	}
+
+	origin := make([]byte, 8)
+	if _, err := rand.Read(origin); err != nil {
+		return nil, fmt.Errorf("generate origin: %w", err)
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	timeout := cfg.ApplyTimeout
+	if timeout <= 0 {
+		timeout = applyTimeout
+	}
+
+	notify := make(chan bool, 1)
+
+	conf := raftConfig()
+	conf.LocalID = raft.ServerID(cfg.NodeID)
+	conf.NotifyCh = notify
+	conf.Logger = hclog.FromStandardLogger(slog.NewLogLogger(logger.Handler(), slog.LevelInfo), &hclog.LoggerOptions{
+		Name:  "raft",
+		Level: hclog.Info,
+	})
+
+	f := fsm{
+		db:      db,
+		dir:     cfg.Dir,
+		origin:  hex.EncodeToString(origin),
+		logger:  logger,
+		pending: make(map[uint64]*pendingBatch),
+	}
+
+	if f.dir == "" {
+		f.dir = os.TempDir()
+	}
+
+	exists, existsErr := raft.HasExistingState(logs, stable, snaps)
+	if existsErr != nil {
+		return nil, fmt.Errorf("check existing state: %w", existsErr)
+	}
+
+	if !exists && len(cfg.Peers) > 0 {
+		servers := make([]raft.Server, 0, len(cfg.Peers))
+
+		for id, addr := range cfg.Peers {
+			servers = append(servers, raft.Server{
+				Suffrage: raft.Voter,
+				ID:       raft.ServerID(id),
+				Address:  raft.ServerAddress(addr),
+			})
+		}
+
+		if err := raft.BootstrapCluster(conf, logs, stable, snaps, transport, raft.Configuration{Servers: servers}); err != nil {
+			return nil, fmt.Errorf("bootstrap cluster: %w", err)
+		}
+
+		logger.Info("Cluster has been bootstrapped",
+			slog.Int("servers", len(servers)),
+		)
+	}
+
+	r, raftErr := raft.NewRaft(conf, &f, logs, stable, snaps, transport)
+	if raftErr != nil {
+		return nil, fmt.Errorf("start raft: %w", raftErr)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+
+	n := Node{
+		id:      cfg.NodeID,
+		path:    path,
+		timeout: timeout,
+		logger:  logger,
+		raft:    r,
+		fsm:     &f,
+		stop:    stop,
+	}
+
+	n.wg.Add(1)
+	go n.observe(ctx, notify)
+
+	return &n, nil
+}
+
+// DB returns the database which changes are replicated by the node.
+// Changes are rejected with ErrNotLeader unless the node is the leader.
+func (n *Node) DB() *sql.DB {
+	c := connector{
+		node:   n,
+		dsn:    "file:" + n.path + "?_journal=WAL",
+		driver: &sqlite3.SQLiteDriver{},
+	}
+
+	return sql.OpenDB(&c)
+}
+
+// IsLeader reports whether the node is the leader, which is ready to change the database.
+func (n *Node) IsLeader() bool { return n.leader.Load() }
+
+// Leader returns the identifier of the leader, or an empty string when there is no leader.
+func (n *Node) Leader() string {
+	_, id := n.raft.LeaderWithID()
+	return string(id)
+}
+
+// OnLead registers the function which is called each time the node becomes the leader,
+// and right away when it's the leader already. Functions are called in the order they are
+// registered once the log has been applied, with the context which is canceled once the
+// node loses the leadership.
+func (n *Node) OnLead(fn func(ctx context.Context) error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.onLead = append(n.onLead, fn)
+
+	if n.leader.Load() {
+		ctx := n.leadCtx
+
+		n.wg.Add(1)
+
+		go func() {
+			defer n.wg.Done()
+			n.prepare(ctx, fn)
+		}()
+	}
+}
+
+// Status returns the status of the cluster as seen by the node.
+func (n *Node) Status() (*Status, error) {
+	future := n.raft.GetConfiguration()
+	if err := future.Error(); err != nil {
+		return nil, fmt.Errorf("get cluster configuration: %w", err)
+	}
+
+	leaderAddr, leaderID := n.raft.LeaderWithID()
+
+	status := Status{
+		NodeID:        n.id,
+		State:         stateOf(n.raft.State()),
+		LeaderID:      string(leaderID),
+		LeaderAddress: string(leaderAddr),
+		Term:          n.raft.CurrentTerm(),
+		LastLogIndex:  n.raft.LastIndex(),
+		AppliedIndex:  n.raft.AppliedIndex(),
+		Servers:       make([]Server, 0, len(future.Configuration().Servers)),
+	}
+
+	if status.State != Leader {
+		status.LastContact = n.raft.LastContact()
+	}
+
+	for _, s := range future.Configuration().Servers {
+		status.Servers = append(status.Servers, Server{
+			ID:      string(s.ID),
+			Address: string(s.Address),
+			Voter:   s.Suffrage == raft.Voter,
+			Leader:  s.ID == leaderID,
+		})
+	}
+
+	return &status, nil
+}
+
+// Health reports the role of the server and the leader of the cluster, it implements the health.CheckFunc.
+func (n *Node) Health(context.Context) (map[string]any, error) {
+	return map[string]any{"role": stateOf(n.raft.State()).String(), "leader": n.Leader()}, nil
+}
+
+// Close leaves the cluster, leadership is transferred to another server.
+func (n *Node) Close() error {
+	if n.raft.State() == raft.Leader {
+		if err := n.raft.LeadershipTransfer().Error(); err != nil && !errors.Is(err, raft.ErrNotLeader) {
+			n.logger.Warn("Failed to transfer leadership",
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	n.stop()
+	n.wg.Wait()
+
+	closeErr := n.raft.Shutdown().Error()
+
+	if n.store != nil {
+		closeErr = errors.Join(closeErr, n.store.Close())
+	}
+
+	if n.db != nil {
+		closeErr = errors.Join(closeErr, n.db.Close())
+	}
+
+	return closeErr
+}
+
+// observe follows changes of leadership.
+func (n *Node) observe(ctx context.Context, notify <-chan bool) {
+	defer n.wg.Done()
+
+	leadCancel := context.CancelFunc(func() {})
+	defer func() { leadCancel() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			n.follow()
+			return
+
+		case leader := <-notify:
+			leadCancel()
+			n.follow()
+
+			if !leader {
+				n.logger.Info("Server is following the leader of the cluster")
+				continue
+			}
+
+			// Raft waits for notifications to be received, so the leader is prepared in background.
+			leadCtx, cancel := context.WithCancel(ctx)
+			leadCancel = cancel
+
+			n.wg.Add(1)
+			go n.lead(leadCtx)
+		}
+	}
+}
+
+// follow stops changes of the database by the node.
+func (n *Node) follow() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.leader.Store(false)
+}
+
+// lead makes the node ready to change the database once it has applied entries of the previous
+// leader, and calls functions registered by OnLead, unless ctx is canceled by the loss of leadership.
+func (n *Node) lead(ctx context.Context) {
+	defer n.wg.Done()
+
+	if err := n.raft.Barrier(barrierTimeout).Error(); err != nil {
+		n.logger.Error("Failed to apply the log as the leader, waiting for the next election",
+			slog.String("error", err.Error()),
+		)
+
+		return
+	}
+
+	n.mu.Lock()
+
+	if ctx.Err() != nil {
+		n.mu.Unlock()
+		return
+	}
+
+	n.leader.Store(true)
+	n.leadCtx = ctx
+	onLead := slices.Clone(n.onLead)
+
+	n.mu.Unlock()
+
+	n.logger.Info("Server has become the leader of the cluster",
+		slog.Uint64("term", n.raft.CurrentTerm()),
+	)
+
+	for _, fn := range onLead {
+		n.prepare(ctx, fn)
+	}
+}
+
+// prepare calls the function registered by OnLead.
+func (n *Node) prepare(ctx context.Context, fn func(ctx context.Context) error) {
+	if err := fn(ctx); err != nil && ctx.Err() == nil {
+		n.logger.Error("Failed to prepare the leader",
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// commit replicates statements of the transaction of the leader and commits it.
+func (n *Node) commit(c driver.ExecerContext, tx driver.Tx, statements []statement) error {
+	b := batch{
+		Origin:     n.fsm.origin,
+		Seq:        n.seq.Add(1),
+		Statements: statements,
+	}
+
+	data, encodeErr := encodeBatch(&b)
+	if encodeErr != nil {
+		return errors.Join(encodeErr, tx.Rollback())
+	}
+
+	n.fsm.expect(b.Seq)
+
+	applyErr := n.raft.Apply(data, n.timeout).Error()
+
+	// The entry might be committed even though the leader has failed to wait for it,
+	// so it's committed by the leader whenever the fsm has taken it.
+	index, taken := n.fsm.settle(b.Seq)
+	if !taken {
+		if errors.Is(applyErr, raft.ErrNotLeader) || errors.Is(applyErr, raft.ErrLeadershipLost) ||
+			errors.Is(applyErr, raft.ErrLeadershipTransferInProgress) {
+			applyErr = errors.Join(ErrNotLeader, applyErr)
+		}
+
+		return errors.Join(fmt.Errorf("replicate transaction: %w", applyErr), tx.Rollback())
+	}
+
+	_, execErr := c.ExecContext(context.Background(), queryUpsertAppliedIndex, []driver.NamedValue{
+		{Ordinal: 1, Value: int64(index)},
+	})
+
+	commitErr := errors.Join(execErr, tx.Commit())
+	if commitErr != nil {
+		n.logger.Error("Failed to commit the replicated transaction, the server should be restarted to apply it",
+			slog.Uint64("index", index),
+			slog.String("error", commitErr.Error()),
+		)
+	}
+
+	n.fsm.committed(commitErr)
+
+	return commitErr
+}
+
+// databasePath returns the path to the main database file.
+func databasePath(db *sql.DB) (string, error) {
+	var path string
+
+	if err := db.QueryRow(`select file from pragma_database_list where name = 'main';`).Scan(&path); err != nil {
+		return "", fmt.Errorf("select database file: %w", err)
+	}
+
+	if path == "" {
+		return "", errors.New("the database must be stored in the file")
+	}
+
+	return path, nil
+}
+
+// logWriter returns the writer of logs of raft components which don't take the logger.
+func logWriter(logger *slog.Logger) *logBridge {
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	return &logBridge{logger: logger}
+}
+
+// logBridge writes lines to the logger.
+type logBridge struct {
+	logger *slog.Logger
+}
+
+func (b *logBridge) Write(p []byte) (int, error) {
+	b.logger.Info(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
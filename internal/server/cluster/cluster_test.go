@@ -0,0 +1,304 @@
+package cluster
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func init() {
+	// Elections take milliseconds instead of seconds in tests.
+	raftConfig = func() *raft.Config {
+		conf := raft.DefaultConfig()
+		conf.HeartbeatTimeout = 50 * time.Millisecond
+		conf.ElectionTimeout = 50 * time.Millisecond
+		conf.LeaderLeaseTimeout = 50 * time.Millisecond
+		conf.CommitTimeout = 5 * time.Millisecond
+
+		return conf
+	}
+}
+
+// testNode is the node along with the database it applies the log to.
+type testNode struct {
+	*Node
+	raw *sql.DB
+	db  *sql.DB
+}
+
+// newTestCluster starts the cluster of n nodes connected in memory.
+func newTestCluster(t *testing.T, n int) []*testNode {
+	t.Helper()
+
+	transports := make([]*raft.InmemTransport, n)
+	peers := make(map[string]string, n)
+
+	for i := range n {
+		addr, transport := raft.NewInmemTransport("")
+		transports[i] = transport
+		peers[fmt.Sprintf("node%d", i)] = string(addr)
+	}
+
+	for _, a := range transports {
+		for _, b := range transports {
+			a.Connect(b.LocalAddr(), b)
+		}
+	}
+
+	nodes := make([]*testNode, n)
+
+	for i := range n {
+		raw := openTestDB(t)
+		store := raft.NewInmemStore()
+
+		node, err := newNode(Config{NodeID: fmt.Sprintf("node%d", i), Dir: t.TempDir(), Peers: peers},
+			raw, store, store, raft.NewInmemSnapshotStore(), transports[i],
+		)
+		td.Require(t).CmpNoError(err)
+
+		db := node.DB()
+
+		t.Cleanup(func() {
+			_ = node.Close()
+			_ = db.Close()
+		})
+
+		nodes[i] = &testNode{Node: node, raw: raw, db: db}
+	}
+
+	return nodes
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "plainq.db")+"?_journal=WAL")
+	td.Require(t).CmpNoError(err)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+// waitLeader returns the node which is ready to change the database.
+func waitLeader(t *testing.T, nodes []*testNode) *testNode {
+	t.Helper()
+
+	for range 200 {
+		for _, n := range nodes {
+			if n.IsLeader() {
+				return n
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("no leader has been elected")
+
+	return nil
+}
+
+// waitNames waits for names of items to be replicated to all nodes.
+func waitNames(t *testing.T, nodes []*testNode, want []string) {
+	t.Helper()
+
+	for _, n := range nodes {
+		var got []string
+
+		for range 200 {
+			got = selectNames(t, n.raw)
+			if slices.Equal(got, want) {
+				break
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		td.Cmp(t, got, want, n.id)
+	}
+}
+
+func selectNames(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+
+	rows, queryErr := db.Query(`select name from items order by id;`)
+	if queryErr != nil {
+		return nil
+	}
+
+	defer rows.Close()
+
+	var names []string
+
+	for rows.Next() {
+		var name string
+		td.Require(t).CmpNoError(rows.Scan(&name))
+		names = append(names, name)
+	}
+
+	td.Require(t).CmpNoError(rows.Err())
+
+	return names
+}
+
+func TestNode(t *testing.T) {
+	ctx := context.Background()
+	nodes := newTestCluster(t, 3)
+
+	leader := waitLeader(t, nodes)
+
+	_, createErr := leader.db.ExecContext(ctx, `create table items
+		(
+			id         integer primary key,
+			name       text not null,
+			created_at timestamp default current_timestamp not null
+		);`)
+	td.Require(t).CmpNoError(createErr)
+
+	_, insertErr := leader.db.ExecContext(ctx, `insert into items (name) values (?), (?);`, "a", "b")
+	td.Require(t).CmpNoError(insertErr)
+
+	tx, txErr := leader.db.BeginTx(ctx, nil)
+	td.Require(t).CmpNoError(txErr)
+
+	var id int64
+
+	td.Require(t).CmpNoError(tx.QueryRowContext(ctx, `insert into items (name) values (?) returning id;`, "c").Scan(&id))
+
+	_, deleteErr := tx.ExecContext(ctx, `delete from items where name = ? and created_at <= current_timestamp;`, "a")
+	td.Require(t).CmpNoError(deleteErr)
+
+	var count int
+
+	td.Require(t).CmpNoError(tx.QueryRowContext(ctx, `select count(*) from items;`).Scan(&count))
+	td.Cmp(t, count, 2)
+	td.Require(t).CmpNoError(tx.Commit())
+
+	// Rolled back transactions are not replicated.
+	rollback, rollbackErr := leader.db.BeginTx(ctx, nil)
+	td.Require(t).CmpNoError(rollbackErr)
+
+	_, insertErr = rollback.ExecContext(ctx, `insert into items (name) values (?);`, "d")
+	td.Require(t).CmpNoError(insertErr)
+	td.Require(t).CmpNoError(rollback.Rollback())
+
+	waitNames(t, nodes, []string{"b", "c"})
+
+	var follower *testNode
+
+	for _, n := range nodes {
+		if n != leader {
+			follower = n
+			break
+		}
+	}
+
+	// Followers serve reads and reject writes.
+	td.CmpNoError(t, follower.db.QueryRowContext(ctx, `select count(*) from items;`).Scan(&count))
+	td.Cmp(t, count, 2)
+
+	_, insertErr = follower.db.ExecContext(ctx, `insert into items (name) values (?);`, "e")
+	td.CmpErrorIs(t, insertErr, ErrNotLeader)
+
+	status, statusErr := follower.Status()
+	td.Require(t).CmpNoError(statusErr)
+	td.Cmp(t, status.State, Follower)
+	td.Cmp(t, status.LeaderID, leader.id)
+	td.Cmp(t, status.Servers, td.Len(3))
+	td.Cmp(t, status.Servers, td.Contains(td.SStruct(Server{ID: leader.id, Voter: true, Leader: true}, td.StructFields{
+		"Address": td.NotEmpty(),
+	})))
+
+	// Statements which maintain the database file are executed by each server on its own.
+	for _, n := range []*testNode{leader, follower} {
+		_, vacuumErr := n.db.ExecContext(ctx, `vacuum;`)
+		td.CmpNoError(t, vacuumErr, n.id)
+
+		rows, pragmaErr := n.db.QueryContext(ctx, `pragma incremental_vacuum;`)
+		td.Require(t).CmpNoError(pragmaErr, n.id)
+		td.CmpNoError(t, rows.Close(), n.id)
+	}
+
+	health, healthErr := follower.Health(ctx)
+	td.CmpNoError(t, healthErr)
+	td.Cmp(t, health, map[string]any{"role": "follower", "leader": leader.id})
+
+	// The new leader continues from the replicated log.
+	td.Require(t).CmpNoError(leader.Close())
+
+	rest := make([]*testNode, 0, len(nodes)-1)
+
+	for _, n := range nodes {
+		if n != leader {
+			rest = append(rest, n)
+		}
+	}
+
+	next := waitLeader(t, rest)
+
+	_, insertErr = next.db.ExecContext(ctx, `insert into items (name) values (?);`, "f")
+	td.Require(t).CmpNoError(insertErr)
+
+	waitNames(t, rest, []string{"b", "c", "f"})
+}
+
+func TestNode_OnLead(t *testing.T) {
+	nodes := newTestCluster(t, 1)
+	called := make(chan string, 1)
+
+	nodes[0].OnLead(func(context.Context) error {
+		called <- nodes[0].Leader()
+		return nil
+	})
+
+	select {
+	case id := <-called:
+		td.Cmp(t, id, "node0")
+		td.CmpTrue(t, nodes[0].IsLeader())
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("the node has not become the leader")
+	}
+}
+
+func TestParsePeers(t *testing.T) {
+	tests := map[string]struct {
+		spec    string
+		want    map[string]string
+		wantErr bool
+	}{
+		"Valid": {
+			spec: "node0=10.0.0.1:7000, node1 = 10.0.0.2:7000,",
+			want: map[string]string{"node0": "10.0.0.1:7000", "node1": "10.0.0.2:7000"},
+		},
+		"Empty": {
+			spec: "",
+			want: map[string]string{},
+		},
+		"NoAddress": {
+			spec:    "node0=10.0.0.1:7000,node1",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			peers, err := ParsePeers(tc.spec)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, peers, tc.want)
+		})
+	}
+}
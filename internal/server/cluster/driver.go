@@ -0,0 +1,295 @@
+package cluster
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Compilation time check that connections of the cluster implement database/sql/driver interfaces.
+var (
+	_ driver.Connector          = (*connector)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.StmtExecContext    = (*stmt)(nil)
+	_ driver.StmtQueryContext   = (*stmt)(nil)
+)
+
+// connector opens connections to the database which pass statements which
+// change the database through the replicated log.
+type connector struct {
+	node   *Node
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+func (c *connector) Connect(context.Context) (driver.Conn, error) {
+	inner, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{inner: inner.(*sqlite3.SQLiteConn), node: c.node}, nil
+}
+
+func (c *connector) Driver() driver.Driver { return c.driver }
+
+// conn executes statements which only read the database right away.
+// Statements which change it are executed only by the leader, and
+// the transaction they belong to is committed after it's replicated.
+type conn struct {
+	inner *sqlite3.SQLiteConn
+	node  *Node
+
+	// tx holds the transaction in progress.
+	tx *tx
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	s, err := c.inner.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt{inner: s.(*sqlite3.SQLiteStmt), conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error { return c.inner.Close() }
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	inner, err := c.inner.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c.tx = &tx{conn: c, inner: inner}
+
+	return c.tx, nil
+}
+
+func (c *conn) Ping(ctx context.Context) error { return c.inner.Ping(ctx) }
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	readOnly, readOnlyErr := c.readOnly(ctx, query)
+	if readOnlyErr != nil {
+		return nil, readOnlyErr
+	}
+
+	if readOnly {
+		return c.inner.ExecContext(ctx, query, args)
+	}
+
+	done, writeErr := c.write(ctx, query, args)
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	// The query might hold several statements, which are executed by the inner connection.
+	res, execErr := c.inner.ExecContext(ctx, query, args)
+	if err := done(execErr); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	s, err := c.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	r, queryErr := s.(*stmt).QueryContext(ctx, args)
+	if queryErr != nil {
+		return nil, errors.Join(queryErr, s.Close())
+	}
+
+	return &rows{Rows: r, done: s.Close}, nil
+}
+
+// readOnly reports whether the first statement of the query doesn't change the database.
+// Statements are checked one by one, since SQLite doesn't have read-only transactions.
+// Statements which maintain the database file are executed by each server on its own.
+func (c *conn) readOnly(ctx context.Context, query string) (bool, error) {
+	if local(query) {
+		return true, nil
+	}
+
+	s, err := c.inner.PrepareContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	readOnly := s.(*sqlite3.SQLiteStmt).Readonly()
+
+	return readOnly, s.Close()
+}
+
+// write prepares the execution of the statement which changes the database and returns
+// the function which is called with the result of the execution. The statement is
+// replicated along with its transaction unless it has failed. Outside of transactions
+// the statement gets its own one, which is committed by the function.
+func (c *conn) write(ctx context.Context, query string, args []driver.NamedValue) (func(error) error, error) {
+	if !c.node.IsLeader() {
+		return nil, ErrNotLeader
+	}
+
+	s, newErr := newStatement(query, args)
+	if newErr != nil {
+		return nil, newErr
+	}
+
+	if c.tx != nil {
+		t := c.tx
+
+		done := func(err error) error {
+			if err == nil {
+				t.statements = append(t.statements, s)
+			}
+
+			return err
+		}
+
+		return done, nil
+	}
+
+	t, beginErr := c.BeginTx(ctx, driver.TxOptions{})
+	if beginErr != nil {
+		return nil, beginErr
+	}
+
+	done := func(err error) error {
+		if err != nil {
+			return errors.Join(err, t.Rollback())
+		}
+
+		c.tx.statements = append(c.tx.statements, s)
+
+		return t.Commit()
+	}
+
+	return done, nil
+}
+
+// tx is the transaction, statements of which are replicated on the commit.
+type tx struct {
+	conn  *conn
+	inner driver.Tx
+
+	statements []statement
+}
+
+func (t *tx) Commit() error {
+	t.conn.tx = nil
+
+	if len(t.statements) == 0 {
+		return t.inner.Commit()
+	}
+
+	return t.conn.node.commit(t.conn.inner, t.inner, t.statements)
+}
+
+func (t *tx) Rollback() error {
+	t.conn.tx = nil
+
+	return t.inner.Rollback()
+}
+
+// stmt is the prepared statement, which is replicated when it changes the database.
+type stmt struct {
+	inner *sqlite3.SQLiteStmt
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error  { return s.inner.Close() }
+func (s *stmt) NumInput() int { return s.inner.NumInput() }
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if s.inner.Readonly() || local(s.query) {
+		return s.inner.ExecContext(ctx, args)
+	}
+
+	done, writeErr := s.conn.write(ctx, s.query, args)
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	res, execErr := s.inner.ExecContext(ctx, args)
+	if err := done(execErr); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if s.inner.Readonly() || local(s.query) {
+		return s.inner.QueryContext(ctx, args)
+	}
+
+	done, writeErr := s.conn.write(ctx, s.query, args)
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	r, queryErr := s.inner.QueryContext(ctx, args)
+	if queryErr != nil {
+		return nil, done(queryErr)
+	}
+
+	// Rows are changed while they are read, so the transaction of the statement
+	// is committed once they are closed.
+	return &rows{Rows: r, done: func() error { return done(nil) }}, nil
+}
+
+// rows calls done once they are closed.
+type rows struct {
+	driver.Rows
+	done func() error
+}
+
+func (r *rows) Close() error {
+	return errors.Join(r.Rows.Close(), r.done())
+}
+
+// local reports whether the query maintains the database file of the server rather than
+// changing the data, e.g. copies the database by VACUUM INTO, reclaims free pages or
+// gathers statistics for the query planner, so it's not replicated.
+func local(query string) bool {
+	q := strings.ToLower(strings.TrimSpace(query))
+
+	return strings.HasPrefix(q, "vacuum") || strings.HasPrefix(q, "analyze") || strings.HasPrefix(q, "pragma")
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+
+	return named
+}
@@ -0,0 +1,487 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	queryCreateStateTable = `create table if not exists cluster_state
+		(
+			id            integer not null primary key,
+			applied_index integer not null
+		);`
+
+	querySelectAppliedIndex = `select coalesce((select applied_index from cluster_state where id = 0), 0);`
+
+	queryUpsertAppliedIndex = `insert into cluster_state (id, applied_index) values (0, ?)
+		on conflict (id) do update set applied_index = excluded.applied_index;`
+
+	// applyRetryPause is the pause between attempts to apply the entry to the busy database.
+	applyRetryPause = 50 * time.Millisecond
+)
+
+// batch is the entry of the replicated log, which holds statements
+// of the transaction committed by the leader in the order they've been executed.
+type batch struct {
+	// Origin identifies the process of the leader which has committed the transaction.
+	Origin string
+
+	// Seq identifies the transaction among others committed by the process.
+	Seq uint64
+
+	Statements []statement
+}
+
+// statement is the statement which changes the database.
+type statement struct {
+	Query string
+	Args  []value
+
+	// Time is the clock of the leader when the statement has been executed.
+	Time time.Time
+}
+
+// valueKind is the type of the value of the statement argument.
+type valueKind uint8
+
+const (
+	kindNull valueKind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindText
+	kindBlob
+	kindTime
+)
+
+// value is the argument of the statement, which holds one of driver.Value types.
+type value struct {
+	Name  string
+	Kind  valueKind
+	Int   int64
+	Float float64
+	Bool  bool
+	Text  string
+	Blob  []byte
+	Time  time.Time
+}
+
+func encodeBatch(b *batch) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := gob.NewEncoder(&buf).Encode(b); err != nil {
+		return nil, fmt.Errorf("encode batch: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decodeBatch(data []byte) (*batch, error) {
+	var b batch
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&b); err != nil {
+		return nil, fmt.Errorf("decode batch: %w", err)
+	}
+
+	return &b, nil
+}
+
+// newStatement returns the statement of the query with its arguments.
+func newStatement(query string, args []driver.NamedValue) (statement, error) {
+	s := statement{
+		Query: query,
+		Args:  make([]value, 0, len(args)),
+		Time:  time.Now().UTC(),
+	}
+
+	for _, arg := range args {
+		v := value{Name: arg.Name}
+
+		switch a := arg.Value.(type) {
+		case nil:
+			v.Kind = kindNull
+
+		case int64:
+			v.Kind, v.Int = kindInt, a
+
+		case float64:
+			v.Kind, v.Float = kindFloat, a
+
+		case bool:
+			v.Kind, v.Bool = kindBool, a
+
+		case string:
+			v.Kind, v.Text = kindText, a
+
+		case []byte:
+			v.Kind, v.Blob = kindBlob, a
+
+		case time.Time:
+			v.Kind, v.Time = kindTime, a
+
+		default:
+			return statement{}, fmt.Errorf("unsupported argument %d of type %T", arg.Ordinal, arg.Value)
+		}
+
+		s.Args = append(s.Args, v)
+	}
+
+	return s, nil
+}
+
+// args returns arguments of the statement as they are passed to the database/sql.
+func (s *statement) args() []any {
+	args := make([]any, 0, len(s.Args))
+
+	for _, v := range s.Args {
+		var a any
+
+		switch v.Kind {
+		case kindInt:
+			a = v.Int
+
+		case kindFloat:
+			a = v.Float
+
+		case kindBool:
+			a = v.Bool
+
+		case kindText:
+			a = v.Text
+
+		case kindBlob:
+			a = v.Blob
+
+		case kindTime:
+			a = v.Time
+
+		case kindNull:
+		}
+
+		if v.Name != "" {
+			a = sql.Named(v.Name, a)
+		}
+
+		args = append(args, a)
+	}
+
+	return args
+}
+
+// pinnedQuery returns the query where the current time is replaced by the time of the leader,
+// so statements depending on it, e.g. deletes of messages which are still invisible, change
+// the database of each server in the same way no matter when they are applied.
+// Definitions of tables and triggers are left as they are.
+func (s *statement) pinnedQuery() string {
+	keyword, _, _ := strings.Cut(strings.TrimSpace(s.Query), " ")
+
+	switch strings.ToLower(keyword) {
+	case "create", "alter", "drop":
+		return s.Query
+	}
+
+	now := "'" + s.Time.UTC().Format(time.DateTime) + "'"
+
+	return strings.NewReplacer(
+		"current_timestamp", now,
+		"CURRENT_TIMESTAMP", now,
+		"'now'", now,
+	).Replace(s.Query)
+}
+
+// pendingBatch is the transaction of this process which waits for the replication.
+type pendingBatch struct {
+	index uint64
+	taken bool
+}
+
+// fsm applies the replicated log to the database.
+//
+// Transactions of the leader are executed by the leader before they are replicated,
+// and committed after they are, so the fsm skips batches of its own process which
+// are expected by the leader and applies the rest. The index of the last applied
+// entry is stored along with changes, so entries are not applied twice after restart.
+type fsm struct {
+	db     *sql.DB
+	dir    string
+	origin string
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	pending map[uint64]*pendingBatch
+
+	// inflight counts batches which have been replicated, but not yet committed by the leader.
+	inflight sync.WaitGroup
+
+	// broken holds the error of the leader which has failed to commit the replicated transaction.
+	broken error
+}
+
+// expect registers the transaction of the leader which is about to be replicated.
+func (f *fsm) expect(seq uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pending[seq] = &pendingBatch{}
+}
+
+// settle unregisters the transaction of the leader once its replication has completed
+// and reports whether the batch has been taken by the fsm, in which case the leader
+// must commit it and call the committed after.
+func (f *fsm) settle(seq uint64) (uint64, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p := f.pending[seq]
+	delete(f.pending, seq)
+
+	return p.index, p.taken
+}
+
+// committed marks the replicated transaction of the leader as committed, err is its commit error.
+func (f *fsm) committed(err error) {
+	if err != nil {
+		f.mu.Lock()
+		f.broken = errors.Join(f.broken, err)
+		f.mu.Unlock()
+	}
+
+	f.inflight.Done()
+}
+
+// take reports whether the batch is the transaction of the leader,
+// which is executed by the leader itself.
+func (f *fsm) take(b *batch, index uint64) bool {
+	if b.Origin != f.origin {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, ok := f.pending[b.Seq]
+	if !ok {
+		return false
+	}
+
+	p.index, p.taken = index, true
+	f.inflight.Add(1)
+
+	return true
+}
+
+func (f *fsm) Apply(entry *raft.Log) any {
+	b, decodeErr := decodeBatch(entry.Data)
+	if decodeErr != nil {
+		f.logger.Error("Failed to decode the replicated transaction",
+			slog.Uint64("index", entry.Index),
+			slog.String("error", decodeErr.Error()),
+		)
+
+		return decodeErr
+	}
+
+	if f.take(b, entry.Index) {
+		return nil
+	}
+
+	for {
+		err := f.apply(entry.Index, b)
+		if err == nil {
+			return nil
+		}
+
+		// The database is busy while the leader holds the transaction which waits for this entry to be applied.
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked) {
+			time.Sleep(applyRetryPause)
+			continue
+		}
+
+		f.logger.Error("Failed to apply the replicated transaction, the database of the server differs from the leader",
+			slog.Uint64("index", entry.Index),
+			slog.String("error", err.Error()),
+		)
+
+		return err
+	}
+}
+
+// apply executes statements of the batch in a transaction unless the entry has been applied.
+func (f *fsm) apply(index uint64, b *batch) (aErr error) {
+	ctx := context.Background()
+
+	tx, txErr := f.db.BeginTx(ctx, nil)
+	if txErr != nil {
+		return fmt.Errorf("begin transaction: %w", txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			aErr = errors.Join(aErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	var applied uint64
+
+	if err := tx.QueryRowContext(ctx, querySelectAppliedIndex).Scan(&applied); err != nil {
+		return fmt.Errorf("select applied index: %w", err)
+	}
+
+	if applied >= index {
+		return nil
+	}
+
+	for i, s := range b.Statements {
+		if _, err := tx.ExecContext(ctx, s.pinnedQuery(), s.args()...); err != nil {
+			return fmt.Errorf("execute statement %d: %w", i, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, queryUpsertAppliedIndex, index); err != nil {
+		return fmt.Errorf("update applied index: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Snapshot copies the database into a file, which is sent to followers
+// which are too far behind, after all replicated transactions of the leader are committed.
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	f.inflight.Wait()
+
+	f.mu.Lock()
+	broken := f.broken
+	f.mu.Unlock()
+
+	if broken != nil {
+		return nil, fmt.Errorf("the database misses replicated transactions: %w", broken)
+	}
+
+	file, createErr := os.CreateTemp(f.dir, "snapshot-*.db")
+	if createErr != nil {
+		return nil, fmt.Errorf("create snapshot file: %w", createErr)
+	}
+
+	path := file.Name()
+
+	// VACUUM INTO writes the database to the file which doesn't exist.
+	if err := errors.Join(file.Close(), os.Remove(path)); err != nil {
+		return nil, fmt.Errorf("prepare snapshot file: %w", err)
+	}
+
+	if _, err := f.db.Exec(`vacuum into ?;`, path); err != nil {
+		return nil, fmt.Errorf("copy database: %w", err)
+	}
+
+	return &snapshot{path: path}, nil
+}
+
+// Restore replaces the database with the snapshot of the leader.
+func (f *fsm) Restore(rc io.ReadCloser) (rErr error) {
+	defer func() {
+		if err := rc.Close(); err != nil {
+			rErr = errors.Join(rErr, fmt.Errorf("close snapshot: %w", err))
+		}
+	}()
+
+	file, createErr := os.CreateTemp(f.dir, "restore-*.db")
+	if createErr != nil {
+		return fmt.Errorf("create snapshot file: %w", createErr)
+	}
+
+	defer func() {
+		if err := os.Remove(file.Name()); err != nil {
+			rErr = errors.Join(rErr, fmt.Errorf("remove snapshot file: %w", err))
+		}
+	}()
+
+	if _, err := io.Copy(file, rc); err != nil {
+		return errors.Join(fmt.Errorf("write snapshot file: %w", err), file.Close())
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close snapshot file: %w", err)
+	}
+
+	src, openErr := (&sqlite3.SQLiteDriver{}).Open("file:" + filepath.ToSlash(file.Name()) + "?mode=ro")
+	if openErr != nil {
+		return fmt.Errorf("open snapshot: %w", openErr)
+	}
+
+	defer func() {
+		if err := src.Close(); err != nil {
+			rErr = errors.Join(rErr, fmt.Errorf("close snapshot database: %w", err))
+		}
+	}()
+
+	conn, connErr := f.db.Conn(context.Background())
+	if connErr != nil {
+		return fmt.Errorf("get database connection: %w", connErr)
+	}
+
+	defer func() {
+		if err := conn.Close(); err != nil {
+			rErr = errors.Join(rErr, fmt.Errorf("close database connection: %w", err))
+		}
+	}()
+
+	return conn.Raw(func(dc any) error {
+		backup, backupErr := dc.(*sqlite3.SQLiteConn).Backup("main", src.(*sqlite3.SQLiteConn), "main")
+		if backupErr != nil {
+			return fmt.Errorf("start restore: %w", backupErr)
+		}
+
+		if _, err := backup.Step(-1); err != nil {
+			return errors.Join(fmt.Errorf("restore: %w", err), backup.Finish())
+		}
+
+		if err := backup.Finish(); err != nil {
+			return fmt.Errorf("finish restore: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// snapshot is the copy of the database in the file.
+type snapshot struct {
+	path string
+}
+
+func (s *snapshot) Persist(sink raft.SnapshotSink) error {
+	file, openErr := os.Open(s.path)
+	if openErr != nil {
+		return errors.Join(fmt.Errorf("open snapshot file: %w", openErr), sink.Cancel())
+	}
+
+	defer file.Close() //nolint:errcheck // the file is only read.
+
+	if _, err := io.Copy(sink, file); err != nil {
+		return errors.Join(fmt.Errorf("write snapshot: %w", err), sink.Cancel())
+	}
+
+	return sink.Close()
+}
+
+func (s *snapshot) Release() { _ = os.Remove(s.path) }
@@ -0,0 +1,151 @@
+package cluster
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func Test_statement_pinnedQuery(t *testing.T) {
+	at := time.Date(2026, 10, 17, 7, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		query string
+		want  string
+	}{
+		"Timestamp": {
+			query: `delete from q where msg_id = ? and visible_at > current_timestamp;`,
+			want:  `delete from q where msg_id = ? and visible_at > '2026-10-17 07:00:00';`,
+		},
+		"Now": {
+			query: `update q set visible_at = datetime('now', '+30 seconds') where msg_id = ?;`,
+			want:  `update q set visible_at = datetime('2026-10-17 07:00:00', '+30 seconds') where msg_id = ?;`,
+		},
+		"Definition": {
+			query: `
+				create table q (created_at int default current_timestamp not null);`,
+			want: `
+				create table q (created_at int default current_timestamp not null);`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := statement{Query: tc.query, Time: at}
+			td.Cmp(t, s.pinnedQuery(), tc.want)
+		})
+	}
+}
+
+func Test_encodeBatch(t *testing.T) {
+	at := time.Date(2026, 10, 17, 7, 0, 0, 0, time.UTC)
+
+	s, newErr := newStatement(`insert into q values (?, ?, ?, ?, ?, ?, :name);`, []driver.NamedValue{
+		{Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Value: 1.5},
+		{Ordinal: 3, Value: true},
+		{Ordinal: 4, Value: []byte("body")},
+		{Ordinal: 5, Value: at},
+		{Ordinal: 6, Value: nil},
+		{Ordinal: 7, Name: "name", Value: "text"},
+	})
+	td.Require(t).CmpNoError(newErr)
+
+	data, encodeErr := encodeBatch(&batch{Origin: "origin", Seq: 1, Statements: []statement{s}})
+	td.Require(t).CmpNoError(encodeErr)
+
+	b, decodeErr := decodeBatch(data)
+	td.Require(t).CmpNoError(decodeErr)
+	td.Cmp(t, b.Origin, "origin")
+	td.Cmp(t, b.Statements, td.Len(1))
+	td.Cmp(t, b.Statements[0].args(), []any{
+		int64(1), 1.5, true, []byte("body"), at, nil, sql.Named("name", "text"),
+	})
+
+	_, newErr = newStatement(`insert into q values (?);`, []driver.NamedValue{{Ordinal: 1, Value: uint8(1)}})
+	td.CmpString(t, newErr, "unsupported argument 1 of type uint8")
+}
+
+func Test_fsm(t *testing.T) {
+	src := openTestDB(t)
+	dst := openTestDB(t)
+
+	f := fsm{db: src, dir: t.TempDir(), origin: "leader", pending: make(map[uint64]*pendingBatch)}
+
+	_, execErr := src.Exec(queryCreateStateTable + `create table items (name text not null);`)
+	td.Require(t).CmpNoError(execErr)
+
+	apply := func(f *fsm, index uint64, names ...string) any {
+		statements := make([]statement, 0, len(names))
+
+		for _, name := range names {
+			s, err := newStatement(`insert into items (name) values (?);`, []driver.NamedValue{{Ordinal: 1, Value: name}})
+			td.Require(t).CmpNoError(err)
+
+			statements = append(statements, s)
+		}
+
+		data, err := encodeBatch(&batch{Origin: "follower", Statements: statements})
+		td.Require(t).CmpNoError(err)
+
+		return f.Apply(&raft.Log{Index: index, Data: data})
+	}
+
+	td.CmpNil(t, apply(&f, 1, "a", "b"))
+
+	// Entries are applied once.
+	td.CmpNil(t, apply(&f, 1, "a", "b"))
+	td.CmpNil(t, apply(&f, 2, "c"))
+
+	snap, snapErr := f.Snapshot()
+	td.Require(t).CmpNoError(snapErr)
+
+	defer snap.Release()
+
+	sink := snapshotSink{}
+	td.Require(t).CmpNoError(snap.Persist(&sink))
+
+	restored := fsm{db: dst, dir: t.TempDir(), pending: make(map[uint64]*pendingBatch)}
+	td.Require(t).CmpNoError(restored.Restore(io.NopCloser(&sink.Buffer)))
+
+	var applied uint64
+
+	td.Require(t).CmpNoError(dst.QueryRow(querySelectAppliedIndex).Scan(&applied))
+	td.Cmp(t, applied, uint64(2))
+
+	td.CmpNil(t, apply(&restored, 2, "c"))
+	td.CmpNil(t, apply(&restored, 3, "d"))
+
+	var count int
+
+	td.Require(t).CmpNoError(dst.QueryRow(`select count(*) from items;`).Scan(&count))
+	td.Cmp(t, count, 4)
+
+	// Batches of the leader are taken by the leader.
+	f.expect(1)
+
+	data, encodeErr := encodeBatch(&batch{Origin: "leader", Seq: 1})
+	td.Require(t).CmpNoError(encodeErr)
+	td.CmpNil(t, f.Apply(&raft.Log{Index: 3, Data: data}))
+
+	index, taken := f.settle(1)
+	td.Cmp(t, index, uint64(3))
+	td.CmpTrue(t, taken)
+
+	f.committed(nil)
+}
+
+// snapshotSink collects the snapshot in memory.
+type snapshotSink struct {
+	bytes.Buffer
+}
+
+func (s *snapshotSink) ID() string    { return "snapshot" }
+func (s *snapshotSink) Cancel() error { return nil }
+func (s *snapshotSink) Close() error  { return nil }
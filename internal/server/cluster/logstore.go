@@ -0,0 +1,186 @@
+package cluster
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// errKeyNotFound is returned by the stable store for unknown keys.
+// Raft compares the message to tell a new server from a broken store.
+var errKeyNotFound = errors.New("not found")
+
+const (
+	queryCreateLogTables = `
+		create table if not exists raft_log
+		(
+			idx         integer not null primary key,
+			term        integer not null,
+			type        integer not null,
+			data        blob,
+			extensions  blob,
+			appended_at integer not null
+		);
+
+		create table if not exists raft_stable
+		(
+			key   blob not null primary key,
+			value blob not null
+		);`
+
+	querySelectFirstIndex = `select coalesce(min(idx), 0) from raft_log;`
+	querySelectLastIndex  = `select coalesce(max(idx), 0) from raft_log;`
+
+	querySelectLog = `select term, type, data, extensions, appended_at from raft_log where idx = ?;`
+
+	queryInsertLog = `insert or replace into raft_log (idx, term, type, data, extensions, appended_at)
+		values (?, ?, ?, ?, ?, ?);`
+
+	queryDeleteLogs = `delete from raft_log where idx >= ? and idx <= ?;`
+
+	querySelectStable = `select value from raft_stable where key = ?;`
+	queryUpsertStable = `insert into raft_stable (key, value) values (?, ?)
+		on conflict (key) do update set value = excluded.value;`
+)
+
+// Compilation time check that logStore implements raft stores.
+var (
+	_ raft.LogStore    = (*logStore)(nil)
+	_ raft.StableStore = (*logStore)(nil)
+)
+
+// logStore keeps the replicated log and the state of elections in SQLite,
+// which is a separate database from the one the log is applied to.
+type logStore struct {
+	db *sql.DB
+}
+
+// newLogStore creates tables of the store in the database unless they exist.
+func newLogStore(db *sql.DB) (*logStore, error) {
+	if _, err := db.Exec(queryCreateLogTables); err != nil {
+		return nil, fmt.Errorf("create raft log tables: %w", err)
+	}
+
+	return &logStore{db: db}, nil
+}
+
+func (s *logStore) FirstIndex() (uint64, error) {
+	var index uint64
+
+	if err := s.db.QueryRow(querySelectFirstIndex).Scan(&index); err != nil {
+		return 0, fmt.Errorf("select first log index: %w", err)
+	}
+
+	return index, nil
+}
+
+func (s *logStore) LastIndex() (uint64, error) {
+	var index uint64
+
+	if err := s.db.QueryRow(querySelectLastIndex).Scan(&index); err != nil {
+		return 0, fmt.Errorf("select last log index: %w", err)
+	}
+
+	return index, nil
+}
+
+func (s *logStore) GetLog(index uint64, log *raft.Log) error {
+	var appendedAt int64
+
+	row := s.db.QueryRow(querySelectLog, index)
+	if err := row.Scan(&log.Term, &log.Type, &log.Data, &log.Extensions, &appendedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return raft.ErrLogNotFound
+		}
+
+		return fmt.Errorf("select log %d: %w", index, err)
+	}
+
+	log.Index = index
+	log.AppendedAt = time.Unix(0, appendedAt)
+
+	return nil
+}
+
+func (s *logStore) StoreLog(log *raft.Log) error {
+	return s.StoreLogs([]*raft.Log{log})
+}
+
+func (s *logStore) StoreLogs(logs []*raft.Log) (sErr error) {
+	tx, txErr := s.db.BeginTx(context.Background(), nil)
+	if txErr != nil {
+		return fmt.Errorf("begin transaction: %w", txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	for _, log := range logs {
+		if _, err := tx.Exec(queryInsertLog,
+			log.Index, log.Term, log.Type, log.Data, log.Extensions, log.AppendedAt.UnixNano(),
+		); err != nil {
+			return fmt.Errorf("insert log %d: %w", log.Index, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *logStore) DeleteRange(minIndex, maxIndex uint64) error {
+	if _, err := s.db.Exec(queryDeleteLogs, minIndex, maxIndex); err != nil {
+		return fmt.Errorf("delete logs from %d to %d: %w", minIndex, maxIndex, err)
+	}
+
+	return nil
+}
+
+func (s *logStore) Set(key, value []byte) error {
+	if _, err := s.db.Exec(queryUpsertStable, key, value); err != nil {
+		return fmt.Errorf("set %q: %w", key, err)
+	}
+
+	return nil
+}
+
+func (s *logStore) Get(key []byte) ([]byte, error) {
+	var value []byte
+
+	if err := s.db.QueryRow(querySelectStable, key).Scan(&value); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errKeyNotFound
+		}
+
+		return nil, fmt.Errorf("get %q: %w", key, err)
+	}
+
+	return value, nil
+}
+
+func (s *logStore) SetUint64(key []byte, value uint64) error {
+	return s.Set(key, binary.BigEndian.AppendUint64(nil, value))
+}
+
+func (s *logStore) GetUint64(key []byte) (uint64, error) {
+	value, err := s.Get(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(value) != 8 {
+		return 0, fmt.Errorf("get %q: malformed value of %d bytes", key, len(value))
+	}
+
+	return binary.BigEndian.Uint64(value), nil
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"fmt"
+
+	vtgrpc "github.com/planetscale/vtprotobuf/codec/grpc"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func init() { encoding.RegisterCodec(codec{}) }
+
+// vtprotoMessage is implemented by messages with vtprotobuf helpers.
+type vtprotoMessage interface {
+	MarshalVT() ([]byte, error)
+	UnmarshalVT([]byte) error
+}
+
+// codec marshals messages with vtprotobuf helpers, which are generated for plainq
+// messages, and falls back to the reflection for others, e.g. of the gRPC health service.
+type codec struct{ vtgrpc.Codec }
+
+func (c codec) Marshal(v any) ([]byte, error) {
+	if _, ok := v.(vtprotoMessage); ok {
+		return c.Codec.Marshal(v)
+	}
+
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("failed to marshal, message is %T, want proto.Message", v)
+	}
+
+	return proto.Marshal(m)
+}
+
+func (c codec) Unmarshal(data []byte, v any) error {
+	if _, ok := v.(vtprotoMessage); ok {
+		return c.Codec.Unmarshal(data, v)
+	}
+
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal, message is %T, want proto.Message", v)
+	}
+
+	return proto.Unmarshal(data, m)
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_codec(t *testing.T) {
+	type tcase struct {
+		in   any
+		out  any
+		fail bool
+	}
+
+	tests := map[string]tcase{
+		"VTProto": {
+			in:  &v1.DescribeQueueRequest{QueueId: "queue"},
+			out: &v1.DescribeQueueRequest{},
+		},
+		"Reflection": {
+			in:  &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING},
+			out: &grpc_health_v1.HealthCheckResponse{},
+		},
+		"NotProto": {
+			in:   "queue",
+			fail: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := codec{}.Marshal(tc.in)
+			if tc.fail {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.Require(t).CmpNoError(err)
+			td.CmpNoError(t, codec{}.Unmarshal(data, tc.out))
+			td.CmpTrue(t, proto.Equal(tc.out.(proto.Message), tc.in.(proto.Message)))
+		})
+	}
+}
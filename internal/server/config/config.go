@@ -8,25 +8,79 @@ import (
 // See the cmd/server.go to understand the meanings of each field
 // and default values.
 type Config struct {
+	ConfigFile string
+
 	LogEnable          bool
 	LogAccessEnable    bool
 	LogAccessEnableAll bool
+	LogAccessFormat    string
+	LogAccessFields    string
+	LogAccessSampling  string
 	LogLevel           string
+	LogLevels          string
 
 	GRPCAddr string
 	HTTPAddr string
 
+	GRPCTLSCert string
+	GRPCTLSKey  string
+
+	GRPCTLSClientCA         string
+	GRPCTLSClientIdentities string
+
+	HTTPTLSCert string
+	HTTPTLSKey  string
+
+	TLSReloadInterval time.Duration
+
 	HTTPReadTimeout       time.Duration
 	HTTPReadHeaderTimeout time.Duration
 	HTTPWriteTimeout      time.Duration
 	HTTPIdleTimeout       time.Duration
 
+	GRPCTimeouts string
+	HTTPTimeouts string
+
 	StorageLogEnable   bool
 	StorageDBPath      string
 	StorageGCTimeout   time.Duration
 	StorageAccessMode  string
 	StorageJournalMode string
 
+	StorageSearchMaxIndexedBytes uint
+	StorageMaxMessageSize        uint64
+
+	StorageQueueEvolutionBatchSize uint
+	StorageQueueEvolutionPause     time.Duration
+
+	StorageDepthInterval time.Duration
+
+	StorageQueueCacheSize uint64
+	StorageQueueCacheTTL  time.Duration
+
+	StorageMessageEventsSize      uint64
+	StorageMessageEventsRetention time.Duration
+
+	StorageMaintenanceWindow     string
+	StorageMaintenanceOperations string
+
+	StorageAckByMessageID bool
+
+	ReplicaOf string
+
+	ClusterEnable       bool
+	ClusterNodeID       string
+	ClusterAddr         string
+	ClusterAdvertise    string
+	ClusterDir          string
+	ClusterPeers        string
+	ClusterApplyTimeout time.Duration
+
+	FailoverEnable        bool
+	FailoverHolder        string
+	FailoverLeaseTTL      time.Duration
+	FailoverRenewInterval time.Duration
+
 	TelemetryEnabled   bool
 	TelemetryLogEnable bool
 	TelemetryProvider  string
@@ -40,17 +94,108 @@ type Config struct {
 	TelemetryLiteScrapeTimeout   time.Duration
 	TelemetryLiteRetentionPeriod time.Duration
 
-	CORSEnable bool
+	TelemetryOTLPEnable      bool
+	TelemetryOTLPEndpoint    string
+	TelemetryOTLPInsecure    bool
+	TelemetryOTLPHeaders     string
+	TelemetryOTLPSampleRatio float64
+
+	TelemetryOTLPMetricsEnable   bool
+	TelemetryOTLPMetricsInterval time.Duration
+
+	TelemetryRemoteWriteURL      string
+	TelemetryRemoteWriteInterval time.Duration
+	TelemetryRemoteWriteTimeout  time.Duration
+	TelemetryRemoteWriteHeaders  string
+	TelemetryRemoteWriteLabels   string
+
+	BreakerEnable          bool
+	BreakerAction          string
+	BreakerMaxDepth        uint
+	BreakerMaxDeadLettered uint
+	BreakerInterval        time.Duration
+
+	AuditDataPlane bool
+
+	AlertingEnable         bool
+	AlertingInterval       time.Duration
+	AlertingWebhookTimeout time.Duration
+	AlertingSMTPAddr       string
+	AlertingSMTPFrom       string
+	AlertingSMTPUsername   string
+	AlertingSMTPPassword   string
+
+	BackupEnable      bool
+	BackupSchedule    string
+	BackupRetention   time.Duration
+	BackupDir         string
+	BackupS3Bucket    string
+	BackupS3Prefix    string
+	BackupS3Endpoint  string
+	BackupS3Region    string
+	BackupS3AccessKey string
+	BackupS3SecretKey string
+	BackupS3PathStyle bool
+
+	AuthEnable                  bool
+	AuthHTTP                    bool
+	AuthJWTSecret               string
+	AuthJWTIssuer               string
+	AuthJWTAudience             string
+	AuthJWTLeeway               time.Duration
+	AuthJWKSURL                 string
+	AuthJWKSRefreshInterval     time.Duration
+	AuthDenylistCacheTTL        time.Duration
+	AuthGrantsCacheTTL          time.Duration
+	AuthDenylistCleanupInterval time.Duration
+	AuthTokenTTL                time.Duration
+	AuthResetCodeTTL            time.Duration
+	AuthRequireVerifiedEmail    bool
+	AuthVerifyCodeTTL           time.Duration
+	AuthVerifyResendInterval    time.Duration
+	AuthLDAPURL                 string
+	AuthLDAPBindDN              string
+	AuthLDAPBindPassword        string
+	AuthLDAPBaseDN              string
+	AuthLDAPUserAttribute       string
+	AuthLDAPEmailAttribute      string
+	AuthLDAPGroupAttribute      string
+	AuthLDAPGroupRoles          string
+	AuthLDAPTimeout             time.Duration
+
+	RateLimitEnable  bool
+	RateLimitGlobal  string
+	RateLimitClient  string
+	RateLimitClients string
+
+	CORSEnable  bool
+	CORSOrigins string
+
+	DocsEnable       bool
+	DocsSwaggerUIURL string
+
+	GatewayEnable bool
 
 	HealthEnable       bool
 	HealthRouteLogs    bool
 	HealthRouteMetrics bool
 	HealthRoute        string
+	HealthTimeout      time.Duration
+	HealthWALMaxSize   uint64
 
 	MetricsEnable       bool
 	MetricsRouteLogs    bool
 	MetricsRouteMetrics bool
 	MetricsRoute        string
+	MetricsTagLabels    string
+	MetricsTagValues    int
+
+	MetricsInQueueBuckets   string
+	MetricsGCBuckets        string
+	MetricsStorageTxBuckets string
+	MetricsDurationSummary  bool
 
 	ProfilerEnabled bool
+	ProfilerAddr    string
+	ProfilerToken   string
 }
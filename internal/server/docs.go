@@ -0,0 +1,504 @@
+package server
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/plainq/plainq/internal/server/openapi"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/respond"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Tags which group routes of the OpenAPI document.
+const (
+	tagQueues      = "Queues"
+	tagMessages    = "Messages"
+	tagAuth        = "Auth"
+	tagAccount     = "Account"
+	tagPermissions = "Permissions"
+	tagAdmin       = "Admin"
+	tagAlerts      = "Alerts"
+	tagJobs        = "Jobs"
+	tagTransfers   = "Transfers"
+	tagGenerators  = "Generators"
+	tagTelemetry   = "Telemetry"
+	tagRPC         = "RPC"
+)
+
+// Query parameters which are shared by routes.
+var (
+	queryCursor      = openapi.Query("cursor", "string", "cursor of the next page returned by the previous request")
+	queryLimit       = openapi.Query("limit", "integer", "maximum number of returned items")
+	queryFrom        = openapi.Query("from", "string", "start of the time range in RFC 3339 format")
+	queryTo          = openapi.Query("to", "string", "end of the time range in RFC 3339 format")
+	queryIncludeBody = openapi.Query("include_body", "boolean", "include bodies of messages")
+	queryQueueID     = openapi.Query("queue_id", "string", "narrows permissions to the queue")
+)
+
+// telemetryQuerySchema describes the telemetryQueryResponse.
+var telemetryQuerySchema = openapi.Schema{
+	Type: "object",
+	Properties: map[string]*openapi.Schema{
+		"metrics": {Type: "array", Items: &openapi.Schema{
+			Type: "object",
+			Properties: map[string]*openapi.Schema{
+				"name":   {Type: "string"},
+				"labels": {Type: "object", AdditionalProperties: &openapi.Schema{Type: "string"}},
+				"values": {Type: "array", Items: &openapi.Schema{
+					Type: "object",
+					Properties: map[string]*openapi.Schema{
+						"timestamp": {Type: "string", Format: "date-time"},
+						"value":     {Type: "number", Format: "double"},
+					},
+				}},
+			},
+		}},
+	},
+}
+
+// apiRoutes describes routes registered by routesV1 for the OpenAPI document.
+var apiRoutes = []openapi.Route{
+	// Queues.
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/queue", Tag: tagQueues, Summary: "Create a queue",
+		Request: &v1.CreateQueueRequest{}, Response: &v1.CreateQueueResponse{}, Status: http.StatusCreated,
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/queue", Tag: tagQueues, Summary: "List queues",
+		Query: []openapi.Parameter{
+			openapi.Query("prefix", "string", "lists only queues with names starting with the prefix"),
+			queryCursor,
+			queryLimit,
+			openapi.Query("depth", "boolean", "include the number of messages in each queue"),
+			openapi.Query("stats", "boolean", "include statistics of each queue"),
+		},
+		Response: &v1.ListQueuesResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/queue/{id}", Tag: tagQueues, Summary: "Describe the queue",
+		Response: &v1.DescribeQueueResponse{},
+	},
+	{
+		Method: http.MethodPatch, Pattern: "/api/v1/queue/{id}", Tag: tagQueues, Summary: "Update properties of the queue",
+		Request: &v1.UpdateQueueRequest{}, Response: &v1.UpdateQueueResponse{},
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/queue/{id}", Tag: tagQueues, Summary: "Delete the queue",
+		Query:    []openapi.Parameter{openapi.Query("force", "boolean", "delete the queue even if it has messages")},
+		Response: &v1.DeleteQueueResponse{},
+	},
+	{
+		Method: http.MethodPut, Pattern: "/api/v1/queue/{id}/state", Tag: tagQueues, Summary: "Pause or resume the queue",
+		Request: &v1.SetQueueStateRequest{}, Response: &v1.SetQueueStateResponse{},
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/queue/{id}/purge", Tag: tagQueues, Summary: "Purge messages of the queue",
+		Query: []openapi.Parameter{
+			openapi.Query("async", "boolean", "purge in a background job"),
+			openapi.Query("older_than", "string", "purge only messages sent before the time in RFC 3339 format"),
+			openapi.Query("min_attempts", "integer", "purge only messages received at least this many times"),
+		},
+		Response: &v1.PurgeQueueResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/queue/{id}/advice", Tag: tagQueues, Summary: "Advise settings of the queue",
+		Response: &v1.AdviseQueueResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/queue/{id}/stats", Tag: tagQueues, Summary: "Statistics of the queue",
+		Response: &v1.QueueStatsResponse{},
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/queue/{id}/breaker", Tag: tagQueues, Summary: "Reset the circuit breaker of the queue",
+		Response: &v1.ResetBreakerResponse{},
+	},
+
+	// Messages.
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/queue/{id}/messages", Tag: tagMessages, Summary: "Send messages to the queue",
+		Request: &v1.SendRequest{}, Response: &v1.SendResponse{}, Status: http.StatusCreated,
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/queue/{id}/messages", Tag: tagMessages, Summary: "Peek messages without receiving them",
+		Query:    []openapi.Parameter{queryCursor, queryLimit, queryIncludeBody},
+		Response: &v1.PeekMessagesResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/queue/{id}/messages/{message}", Tag: tagMessages, Summary: "Get the message",
+		Response: &v1.GetMessageResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/queue/{id}/messages/{message}/events", Tag: tagMessages, Summary: "List lifecycle events of the message",
+		Response: &v1.ListMessageEventsResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/queue/{id}/search", Tag: tagMessages, Summary: "Search messages of the queue",
+		Query: []openapi.Parameter{
+			openapi.Query("q", "string", "full-text query of message bodies"),
+			openapi.Query("contains", "string", "substring of message bodies"),
+			openapi.Query("json_path", "string", "JSON path of the value in message bodies"),
+			openapi.Query("json_value", "string", "value at the JSON path"),
+			queryFrom,
+			queryTo,
+			openapi.Query("min_attempts", "integer", "minimum number of receive attempts"),
+			openapi.Query("max_attempts", "integer", "maximum number of receive attempts"),
+			queryLimit,
+		},
+		Response: &v1.SearchMessagesResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/queue/{id}/archive", Tag: tagMessages, Summary: "List archived messages of the queue",
+		Query:    []openapi.Parameter{queryCursor, queryLimit, queryIncludeBody},
+		Response: &v1.ListArchivedMessagesResponse{},
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/queue/{id}/archive/restore", Tag: tagMessages, Summary: "Restore archived messages to the queue",
+		Request: &v1.RestoreArchivedMessagesRequest{}, Response: &v1.RestoreArchivedMessagesResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/search", Tag: tagMessages, Summary: "Search queues and messages",
+		Query:    []openapi.Parameter{openapi.Query("q", "string", "search query"), queryLimit},
+		Response: &v1.SearchResponse{},
+	},
+
+	// Queue permissions.
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/queue/{id}/permissions", Tag: tagPermissions, Summary: "List permissions of roles on the queue",
+		Response: &v1.ListQueuePermissionsResponse{},
+	},
+	{
+		Method: http.MethodPut, Pattern: "/api/v1/queue/{id}/permissions/{role}", Tag: tagPermissions, Summary: "Grant the role permissions on the queue",
+		Request: &v1.QueuePermission{}, Response: &v1.QueuePermission{},
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/queue/{id}/permissions/{role}", Tag: tagPermissions, Summary: "Revoke permissions of the role on the queue",
+		Status: http.StatusNoContent,
+	},
+
+	// Transfers.
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/queue/{id}/transfer", Tag: tagTransfers, Summary: "Offer the queue to a new owner",
+		Request: &v1.TransferQueueRequest{}, Response: &v1.TransferQueueResponse{}, Status: http.StatusCreated,
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/transfer/{id}/accept", Tag: tagTransfers, Summary: "Accept the queue transfer",
+		Request: &v1.AcceptQueueTransferRequest{}, Response: &v1.AcceptQueueTransferResponse{},
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/transfer/{id}", Tag: tagTransfers, Summary: "Cancel the queue transfer",
+		Query:    []openapi.Parameter{openapi.Query("canceled_by", "string", "who cancels the transfer")},
+		Response: &v1.CancelQueueTransferResponse{},
+	},
+
+	// Generators.
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/queue/{id}/generator", Tag: tagGenerators, Summary: "Start the synthetic producer of the queue",
+		Request: &v1.StartGeneratorRequest{}, Response: &v1.StartGeneratorResponse{}, Status: http.StatusCreated,
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/generator", Tag: tagGenerators, Summary: "List synthetic producers",
+		Response: &v1.ListGeneratorsResponse{},
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/generator/{id}", Tag: tagGenerators, Summary: "Stop the synthetic producer",
+		Response: &v1.StopGeneratorResponse{},
+	},
+
+	// Auth.
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/auth/sign-in", Tag: tagAuth, Summary: "Sign in with the email and password",
+		Request: &v1.SignInRequest{}, Response: &v1.SignInResponse{}, Public: true,
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/auth/sign-out", Tag: tagAuth, Summary: "Sign out, revoking the token",
+		Status: http.StatusNoContent,
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/auth/password-reset", Tag: tagAuth, Summary: "Request the password reset code",
+		Request: &v1.RequestPasswordResetRequest{}, Response: &v1.RequestPasswordResetResponse{}, Status: http.StatusAccepted, Public: true,
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/auth/password-reset/verify", Tag: tagAuth, Summary: "Verify the password reset code",
+		Request: &v1.VerifyPasswordResetCodeRequest{}, Response: &v1.VerifyPasswordResetCodeResponse{}, Public: true,
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/auth/password-reset/confirm", Tag: tagAuth, Summary: "Reset the password with the code",
+		Request: &v1.ResetPasswordRequest{}, Response: &v1.ResetPasswordResponse{}, Public: true,
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/auth/verify-email/send", Tag: tagAuth, Summary: "Send the email verification code",
+		Request: &v1.SendEmailVerificationRequest{}, Response: &v1.SendEmailVerificationResponse{}, Status: http.StatusAccepted, Public: true,
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/auth/verify-email", Tag: tagAuth, Summary: "Verify the email with the code",
+		Request: &v1.VerifyEmailRequest{}, Response: &v1.VerifyEmailResponse{}, Public: true,
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/auth/sessions", Tag: tagAuth, Summary: "List sessions of the account",
+		Response: &v1.ListSessionsResponse{},
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/auth/sessions", Tag: tagAuth, Summary: "Revoke all sessions of the account",
+		Response: &v1.RevokeSessionsResponse{},
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/auth/sessions/{id}", Tag: tagAuth, Summary: "Revoke the session of the account",
+		Status: http.StatusNoContent,
+	},
+
+	// Account.
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/account/me", Tag: tagAccount, Summary: "Get the profile of the account",
+		Response: &v1.AccountProfile{},
+	},
+	{
+		Method: http.MethodPatch, Pattern: "/api/v1/account/me", Tag: tagAccount, Summary: "Update the profile of the account",
+		Request: &v1.UpdateAccountRequest{}, Response: &v1.AccountProfile{},
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/account/password", Tag: tagAccount, Summary: "Change the password of the account",
+		Request: &v1.ChangePasswordRequest{}, Response: &v1.ChangePasswordResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/account/permissions", Tag: tagAccount, Summary: "Effective permissions of the account",
+		Query:    []openapi.Parameter{queryQueueID},
+		Response: &v1.EffectivePermissions{},
+	},
+
+	// Telemetry.
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/telemetry/query", Tag: tagTelemetry, Summary: "Query historical metrics",
+		Query: []openapi.Parameter{
+			openapi.Query("metric", "string", "name of the metric"),
+			openapi.Query("queue", "string", "id of the queue of queue metrics"),
+			queryFrom,
+			queryTo,
+			openapi.Query("step", "string", "interval between datapoints, e.g. 1m"),
+		},
+		ResponseSchema: &telemetryQuerySchema,
+	},
+
+	// Alerts.
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/alerts", Tag: tagAlerts, Summary: "List states of alerts",
+		Response: &v1.ListAlertsResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/alerts/rules", Tag: tagAlerts, Summary: "List alert rules",
+		Response: &v1.ListAlertRulesResponse{},
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/alerts/rules", Tag: tagAlerts, Summary: "Create the alert rule",
+		Request: &v1.CreateAlertRuleRequest{}, Response: &v1.CreateAlertRuleResponse{}, Status: http.StatusCreated,
+	},
+	{
+		Method: http.MethodPut, Pattern: "/api/v1/alerts/rules/{id}", Tag: tagAlerts, Summary: "Update the alert rule",
+		Request: &v1.UpdateAlertRuleRequest{}, Response: &v1.UpdateAlertRuleResponse{},
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/alerts/rules/{id}", Tag: tagAlerts, Summary: "Delete the alert rule",
+		Response: &v1.DeleteAlertRuleResponse{},
+	},
+
+	// Administration.
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/admin/log-levels", Tag: tagAdmin, Summary: "Get levels of loggers",
+		Response: &v1.GetLogLevelsResponse{},
+	},
+	{
+		Method: http.MethodPut, Pattern: "/api/v1/admin/log-levels", Tag: tagAdmin, Summary: "Set levels of loggers",
+		Request: &v1.SetLogLevelsRequest{}, Response: &v1.SetLogLevelsResponse{},
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/admin/reload", Tag: tagAdmin, Summary: "Reload the configuration",
+		Response: &v1.ReloadConfigResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/admin/cluster", Tag: tagAdmin, Summary: "Get the status of the cluster",
+		Response: &v1.ClusterStatusResponse{},
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/admin/storage/maintenance", Tag: tagAdmin, Summary: "Run the maintenance operation of the storage",
+		Request: &v1.RunMaintenanceRequest{}, Response: &v1.RunMaintenanceResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/admin/breakers", Tag: tagAdmin, Summary: "List circuit breakers of queues",
+		Response: &v1.ListBreakersResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/admin/audit", Tag: tagAdmin, Summary: "List audit events",
+		Query: []openapi.Parameter{
+			openapi.Query("actor", "string", "who performed the action"),
+			openapi.Query("action", "string", "performed action"),
+			openapi.Query("target", "string", "target of the action"),
+			queryCursor,
+			queryFrom,
+			queryTo,
+			queryLimit,
+		},
+		Response: &v1.ListAuditEventsResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/admin/service-accounts", Tag: tagAdmin, Summary: "List service accounts",
+		Response: &v1.ListServiceAccountsResponse{},
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/admin/service-accounts", Tag: tagAdmin, Summary: "Create the service account",
+		Request: &v1.CreateServiceAccountRequest{}, Response: &v1.CreateServiceAccountResponse{}, Status: http.StatusCreated,
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/admin/service-accounts/{id}", Tag: tagAdmin, Summary: "Delete the service account",
+		Response: &v1.DeleteServiceAccountResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/admin/service-accounts/{id}/keys", Tag: tagAdmin, Summary: "List API keys of the service account",
+		Response: &v1.ListAPIKeysResponse{},
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/admin/service-accounts/{id}/keys", Tag: tagAdmin, Summary: "Create the API key of the service account",
+		Request: &v1.CreateAPIKeyRequest{}, Response: &v1.CreateAPIKeyResponse{}, Status: http.StatusCreated,
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/admin/keys/{id}", Tag: tagAdmin, Summary: "Revoke the API key",
+		Response: &v1.RevokeAPIKeyResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/admin/users/{subject}/sessions", Tag: tagAdmin, Summary: "List sessions of the user",
+		Response: &v1.ListSessionsResponse{},
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/admin/users/{subject}/sessions", Tag: tagAdmin, Summary: "Revoke all sessions of the user",
+		Response: &v1.RevokeSessionsResponse{},
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/admin/users/{subject}/sessions/{id}", Tag: tagAdmin, Summary: "Revoke the session of the user",
+		Status: http.StatusNoContent,
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/admin/users/{subject}/permissions", Tag: tagPermissions, Summary: "Effective permissions of the user",
+		Query:    []openapi.Parameter{queryQueueID},
+		Response: &v1.EffectivePermissions{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/admin/templates", Tag: tagPermissions, Summary: "List permission templates",
+		Response: &v1.ListPermissionTemplatesResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/admin/roles", Tag: tagPermissions, Summary: "List roles with their users",
+		Response: &v1.ListRolesResponse{},
+	},
+	{
+		Method: http.MethodPost, Pattern: "/api/v1/admin/roles", Tag: tagPermissions, Summary: "Create the role",
+		Request: &v1.CreateRoleRequest{}, Response: &v1.CreateRoleResponse{}, Status: http.StatusCreated,
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/admin/roles/{role}", Tag: tagPermissions, Summary: "Delete the role",
+		Status: http.StatusNoContent,
+	},
+	{
+		Method: http.MethodPut, Pattern: "/api/v1/admin/roles/{role}/users/{email}", Tag: tagPermissions, Summary: "Assign the role to the user",
+		Status: http.StatusNoContent,
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/admin/roles/{role}/users/{email}", Tag: tagPermissions, Summary: "Unassign the role from the user",
+		Status: http.StatusNoContent,
+	},
+	{
+		Method: http.MethodPut, Pattern: "/api/v1/admin/roles/{role}/templates/{template}", Tag: tagPermissions, Summary: "Grant the role permissions of the template",
+		Status: http.StatusNoContent,
+	},
+
+	// Jobs.
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/jobs", Tag: tagJobs, Summary: "List background jobs",
+		Query: []openapi.Parameter{
+			openapi.Query("queue_id", "string", "lists only jobs of the queue"),
+			openapi.Query("state", "string", "lists only jobs in the state"),
+			queryLimit,
+		},
+		Response: &v1.ListJobsResponse{},
+	},
+	{
+		Method: http.MethodGet, Pattern: "/api/v1/jobs/{id}", Tag: tagJobs, Summary: "Get the background job",
+		Response: &v1.GetJobResponse{},
+	},
+	{
+		Method: http.MethodDelete, Pattern: "/api/v1/jobs/{id}", Tag: tagJobs, Summary: "Cancel the background job",
+		Response: &v1.CancelJobResponse{},
+	},
+}
+
+// swaggerUI renders the Swagger UI page, which loads its assets from the given base URL.
+var swaggerUI = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>PlainQ API</title>
+  <link rel="stylesheet" href="{{.}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="{{.}}/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "/api/docs/openapi.json", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`))
+
+// rpcRoutes describes routes of the JSON gateway, one per method of the gRPC API.
+func rpcRoutes() []openapi.Route {
+	methods := v1.File_v1_schema_proto.Services().ByName("PlainQService").Methods()
+	routes := make([]openapi.Route, 0, methods.Len())
+
+	for i := range methods.Len() {
+		md := methods.Get(i)
+
+		routes = append(routes, openapi.Route{
+			Method:   http.MethodPost,
+			Pattern:  "/api/rpc/" + string(md.Parent().FullName()) + "/" + string(md.Name()),
+			Tag:      tagRPC,
+			Summary:  "Call " + string(md.Name()) + " of the gRPC API",
+			Request:  dynamicpb.NewMessage(md.Input()),
+			Response: dynamicpb.NewMessage(md.Output()),
+		})
+	}
+
+	return routes
+}
+
+// openAPIDocument returns the OpenAPI document of the HTTP API, which is built once.
+var openAPIDocument = sync.OnceValues(func() ([]byte, error) {
+	doc := openapi.Build(openapi.Info{
+		Title:       "PlainQ HTTP API",
+		Description: "Queues, messages, accounts and permissions of PlainQ. Bodies follow the protobuf JSON mapping.",
+		Version:     "v1",
+	}, append(slices.Clone(apiRoutes), rpcRoutes()...))
+
+	return json.Marshal(doc)
+})
+
+func (*PlainQ) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	data, marshalErr := openAPIDocument()
+	if marshalErr != nil {
+		respond.ErrorHTTP(w, r, marshalErr)
+		return
+	}
+
+	respond.JSON(w, r, json.RawMessage(data), respond.WithStatus(http.StatusOK))
+}
+
+// docsHandler returns the handler of the Swagger UI page, which loads assets from the assetsURL.
+func docsHandler(assetsURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+		if err := swaggerUI.Execute(w, assetsURL); err != nil {
+			respond.ErrorHTTP(w, r, err)
+		}
+	}
+}
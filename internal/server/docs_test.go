@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+// Every route of the HTTP API v1 is described in the OpenAPI document.
+func Test_apiRoutes(t *testing.T) {
+	var pq PlainQ
+
+	router := chi.NewRouter()
+	router.Route("/api/v1", pq.routesV1)
+
+	var registered []string
+
+	walkErr := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		registered = append(registered, method+" "+strings.TrimSuffix(route, "/"))
+		return nil
+	})
+	td.Require(t).CmpNoError(walkErr)
+
+	documented := make([]string, 0, len(apiRoutes))
+
+	for _, route := range apiRoutes {
+		documented = append(documented, route.Method+" "+route.Pattern)
+	}
+
+	sort.Strings(registered)
+	sort.Strings(documented)
+
+	td.Cmp(t, documented, registered)
+}
+
+func TestPlainQ_openAPIHandler(t *testing.T) {
+	var pq PlainQ
+
+	w := httptest.NewRecorder()
+	pq.openAPIHandler(w, httptest.NewRequest(http.MethodGet, "/api/docs/openapi.json", nil))
+
+	td.Cmp(t, w.Code, http.StatusOK)
+
+	var doc struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+
+	td.Require(t).CmpNoError(json.Unmarshal(w.Body.Bytes(), &doc))
+	td.Cmp(t, doc.OpenAPI, "3.0.3")
+	td.Cmp(t, doc.Paths, td.ContainsKey("/api/v1/queue/{id}/messages"))
+	td.Cmp(t, doc.Paths, td.ContainsKey("/api/rpc/v1.PlainQService/Send"))
+}
+
+func Test_docsHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	docsHandler("/static/swagger-ui")(w, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+
+	td.Cmp(t, w.Code, http.StatusOK)
+	td.Cmp(t, w.Header().Get("Content-Type"), "text/html; charset=utf-8")
+	td.Cmp(t, w.Body.String(), td.All(
+		td.Contains(`href="/static/swagger-ui/swagger-ui.css"`),
+		td.Contains(`url: "/api/docs/openapi.json"`),
+	))
+}
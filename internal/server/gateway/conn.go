@@ -0,0 +1,146 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Compilation time check that localConn implements the grpc.ClientConnInterface.
+var _ grpc.ClientConnInterface = (*localConn)(nil)
+
+// localConn calls unary methods of the service in-process, as the gRPC server
+// would call them: outgoing metadata of calls is passed as the incoming one,
+// requests go through the interceptors, and headers and trailers set by
+// the server are returned to grpc.Header and grpc.Trailer call options.
+type localConn struct {
+	server      any
+	methods     map[string]grpc.MethodDesc
+	interceptor grpc.UnaryServerInterceptor
+}
+
+func newLocalConn(desc *grpc.ServiceDesc, server any, interceptors []grpc.UnaryServerInterceptor) *localConn {
+	conn := localConn{
+		server:      server,
+		methods:     make(map[string]grpc.MethodDesc, len(desc.Methods)),
+		interceptor: chainInterceptors(interceptors),
+	}
+
+	for _, method := range desc.Methods {
+		conn.methods["/"+desc.ServiceName+"/"+method.MethodName] = method
+	}
+
+	return &conn
+}
+
+func (c *localConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	desc, ok := c.methods[method]
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+
+	stream := transportStream{method: method}
+
+	ctx = metadata.NewIncomingContext(ctx, md)
+	ctx = grpc.NewContextWithServerTransportStream(ctx, &stream)
+
+	decode := func(in any) error {
+		proto.Merge(in.(proto.Message), args.(proto.Message))
+		return nil
+	}
+
+	out, err := desc.Handler(c.server, ctx, decode, c.interceptor)
+
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case grpc.HeaderCallOption:
+			*o.HeaderAddr = stream.headerMD()
+
+		case grpc.TrailerCallOption:
+			*o.TrailerAddr = stream.trailerMD()
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	proto.Merge(reply.(proto.Message), out.(proto.Message))
+
+	return nil
+}
+
+func (*localConn) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Error(codes.Unimplemented, "streaming calls are not supported")
+}
+
+// chainInterceptors returns the interceptor which calls the interceptors in order,
+// or nil when there are none.
+func chainInterceptors(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	if len(interceptors) == 0 {
+		return nil
+	}
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		next := handler
+
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, h := interceptors[i], next
+			next = func(ctx context.Context, req any) (any, error) { return interceptor(ctx, req, info, h) }
+		}
+
+		return next(ctx, req)
+	}
+}
+
+// transportStream collects headers and trailers which the server sets on the call.
+type transportStream struct {
+	method string
+
+	mu      sync.Mutex
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (s *transportStream) Method() string { return s.method }
+
+func (s *transportStream) SetHeader(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.header = metadata.Join(s.header, md)
+
+	return nil
+}
+
+func (s *transportStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *transportStream) SetTrailer(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.trailer = metadata.Join(s.trailer, md)
+
+	return nil
+}
+
+func (s *transportStream) headerMD() metadata.MD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.header
+}
+
+func (s *transportStream) trailerMD() metadata.MD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.trailer
+}
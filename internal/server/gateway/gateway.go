@@ -0,0 +1,70 @@
+// Package gateway serves the gRPC API as JSON over HTTP. Each method of the
+// PlainQService is transcoded to the POST /v1.PlainQService/<Method> route,
+// which takes the request message and responds with the response message,
+// both encoded by the protobuf JSON mapping.
+//
+// Calls are made in-process through unary interceptors of the gRPC server,
+// so they are authenticated, authorized, rate limited and time out the same
+// way as gRPC calls, and errors are responded with statuses of gRPC codes.
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqjson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// New returns the handler which transcodes JSON requests to calls of the server,
+// passing them through the interceptors in order.
+func New(server v1.PlainQServiceServer, interceptors ...grpc.UnaryServerInterceptor) (http.Handler, error) {
+	marshalOptions, unmarshalOptions := pqjson.Options()
+
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   marshalOptions,
+			UnmarshalOptions: unmarshalOptions,
+		}),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeader),
+	)
+
+	conn := newLocalConn(&v1.PlainQService_ServiceDesc, server, interceptors)
+
+	if err := v1.RegisterPlainQServiceHandlerClient(context.Background(), mux, v1.NewPlainQServiceClient(conn)); err != nil {
+		return nil, fmt.Errorf("register service handlers: %w", err)
+	}
+
+	return withPeer(mux), nil
+}
+
+// outgoingHeader maps header metadata of calls to HTTP headers. The retry-after
+// set by the rate limiter is the standard header, others are prefixed.
+func outgoingHeader(key string) (string, bool) {
+	if key == "retry-after" {
+		return "Retry-After", true
+	}
+
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+// withPeer adds the remote address of the request to its context as the peer,
+// so clients without identities are rate limited by their addresses.
+func withPeer(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := peer.NewContext(r.Context(), &peer.Peer{Addr: remoteAddr(r.RemoteAddr)})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// remoteAddr implements the net.Addr of the remote address of the request.
+type remoteAddr string
+
+func (remoteAddr) Network() string  { return "tcp" }
+func (a remoteAddr) String() string { return string(a) }
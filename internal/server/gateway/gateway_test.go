@@ -0,0 +1,170 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type server struct {
+	v1.UnimplementedPlainQServiceServer
+}
+
+func (server) Send(_ context.Context, r *v1.SendRequest) (*v1.SendResponse, error) {
+	if r.GetQueueId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "queue id is required")
+	}
+
+	ids := make([]string, 0, len(r.GetMessages()))
+
+	for _, msg := range r.GetMessages() {
+		ids = append(ids, "id-"+string(msg.GetBody()))
+	}
+
+	return &v1.SendResponse{MessageIds: ids}, nil
+}
+
+func TestNew(t *testing.T) {
+	type tcase struct {
+		path       string
+		body       string
+		authorized bool
+		wantMethod string
+		wantCode   int
+		wantBody   td.TestDeep
+		wantHeader http.Header
+	}
+
+	// The interceptor rejects calls without the token,
+	// and records the method and the peer of the call.
+	var (
+		method string
+		client string
+	)
+
+	auth := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		method = info.FullMethod
+
+		if p, ok := peer.FromContext(ctx); ok {
+			client = p.Addr.String()
+		}
+
+		if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) == 0 || values[0] != "Bearer token" {
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", "1"))
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		return handler(ctx, req)
+	}
+
+	h, newErr := New(server{}, auth)
+	td.Require(t).CmpNoError(newErr)
+
+	tests := map[string]tcase{
+		"OK": {
+			path:       "/v1.PlainQService/Send",
+			body:       `{"queueId": "q", "messages": [{"body": "YQ=="}, {"body": "Yg=="}]}`,
+			authorized: true,
+			wantMethod: v1.PlainQService_Send_FullMethodName,
+			wantCode:   http.StatusOK,
+			wantBody:   td.JSON(`{"messageIds": ["id-a", "id-b"]}`),
+		},
+		"InvalidArgument": {
+			path:       "/v1.PlainQService/Send",
+			body:       `{}`,
+			authorized: true,
+			wantMethod: v1.PlainQService_Send_FullMethodName,
+			wantCode:   http.StatusBadRequest,
+			wantBody:   td.JSON(`{"code": 3, "message": "queue id is required"}`),
+		},
+		"Unauthenticated": {
+			path:       "/v1.PlainQService/Send",
+			body:       `{"queueId": "q"}`,
+			wantMethod: v1.PlainQService_Send_FullMethodName,
+			wantCode:   http.StatusUnauthorized,
+			wantBody:   td.JSON(`{"code": 16, "message": "invalid token"}`),
+			wantHeader: http.Header{"Retry-After": {"1"}},
+		},
+		"Unimplemented": {
+			path:       "/v1.PlainQService/PurgeQueue",
+			body:       `{"queueId": "q"}`,
+			authorized: true,
+			wantMethod: v1.PlainQService_PurgeQueue_FullMethodName,
+			wantCode:   http.StatusNotImplemented,
+			wantBody:   td.JSON(`{"code": 12, "message": "method PurgeQueue not implemented"}`),
+		},
+		"UnknownField": {
+			path:       "/v1.PlainQService/Send",
+			body:       `{"queue": "q"}`,
+			authorized: true,
+			wantCode:   http.StatusBadRequest,
+			wantBody:   td.JSON(`{"code": 3, "message": $1}`, td.Contains("unknown field")),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			method, client = "", ""
+
+			r := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", "application/json")
+			r.RemoteAddr = "192.0.2.1:1234"
+
+			r.Header.Set("Authorization", "Bearer wrong")
+			if tc.authorized {
+				r.Header.Set("Authorization", "Bearer token")
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			td.Cmp(t, w.Code, tc.wantCode)
+			td.Cmp(t, json.RawMessage(w.Body.Bytes()), tc.wantBody)
+
+			for key := range tc.wantHeader {
+				td.Cmp(t, w.Header().Get(key), tc.wantHeader.Get(key), key)
+			}
+
+			// Requests which can't be decoded are not passed to the interceptors.
+			td.Cmp(t, method, tc.wantMethod)
+
+			if tc.wantMethod != "" {
+				td.Cmp(t, client, "192.0.2.1:1234")
+			}
+		})
+	}
+}
+
+func Test_chainInterceptors(t *testing.T) {
+	var calls []string
+
+	interceptor := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			calls = append(calls, name)
+			return handler(ctx, req)
+		}
+	}
+
+	td.CmpNil(t, chainInterceptors(nil))
+
+	chain := chainInterceptors([]grpc.UnaryServerInterceptor{interceptor("a"), interceptor("b")})
+
+	out, err := chain(context.Background(), "req", &grpc.UnaryServerInfo{}, func(_ context.Context, req any) (any, error) {
+		calls = append(calls, "handler")
+		return req, nil
+	})
+	td.CmpNoError(t, err)
+	td.Cmp(t, out, "req")
+	td.Cmp(t, calls, []string{"a", "b", "handler"})
+}
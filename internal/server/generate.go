@@ -0,0 +1,22 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+// startGenerator makes sure the queue exists and starts a synthetic producer for it.
+func (s *PlainQ) startGenerator(ctx context.Context, input *v1.StartGeneratorRequest) (*v1.StartGeneratorResponse, error) {
+	if _, err := s.storage.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: input.GetQueueId()}); err != nil {
+		return nil, fmt.Errorf("describe queue: %w", err)
+	}
+
+	gen, startErr := s.generator.Start(input)
+	if startErr != nil {
+		return nil, fmt.Errorf("start generator: %w", startErr)
+	}
+
+	return &v1.StartGeneratorResponse{Generator: gen}, nil
+}
@@ -0,0 +1,350 @@
+// Package generator implements synthetic producers which send generated
+// messages to queues. It is useful for demos, load testing and
+// validating alerting thresholds end to end.
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// MaxRate represents the maximum number of messages per second of a single producer.
+	MaxRate = 1000
+
+	// MaxSize represents the maximum size of the generated message body.
+	MaxSize = 256 << 10
+
+	// MaxGenerators represents the maximum number of simultaneously running producers.
+	MaxGenerators = 10
+
+	// maxConsecutiveFailures represents the number of consecutive send
+	// failures after which the producer stops, e.g. when the queue is deleted.
+	maxConsecutiveFailures = 10
+
+	// sendTimeout represents the timeout of a single send.
+	sendTimeout = 5 * time.Second
+
+	// defaultTemplate is used when the payload template is not specified.
+	defaultTemplate = `{"seq":{{.Seq}},"id":"{{.ID}}","time":"{{.Time.Format "2006-01-02T15:04:05.000Z07:00"}}"}`
+)
+
+// Sender sends messages to the queue.
+type Sender interface {
+	Send(ctx context.Context, input *v1.SendRequest) (*v1.SendResponse, error)
+}
+
+// Payload holds the data available in the payload template.
+type Payload struct {
+	// Seq is the sequence number of the message starting from 1.
+	Seq uint64
+
+	// ID is the unique random identifier of the message payload.
+	ID string
+
+	// QueueID is the identifier of the queue the message is sent to.
+	QueueID string
+
+	// Time is the time the message is generated.
+	Time time.Time
+}
+
+// Generator manages running synthetic producers.
+type Generator struct {
+	sender Sender
+	logger *slog.Logger
+
+	mu        sync.Mutex
+	producers map[string]*producer
+}
+
+// New returns a pointer to a new instance of Generator.
+func New(sender Sender, logger *slog.Logger) *Generator {
+	g := Generator{
+		sender:    sender,
+		logger:    logger,
+		producers: make(map[string]*producer),
+	}
+
+	return &g
+}
+
+// Start validates the input and starts a new producer.
+func (g *Generator) Start(input *v1.StartGeneratorRequest) (*v1.Generator, error) {
+	if input.GetRatePerSecond() == 0 || input.GetRatePerSecond() > MaxRate {
+		return nil, fmt.Errorf("%w: rate should be between 1 and %d messages per second", errkit.ErrInvalidArgument, MaxRate)
+	}
+
+	if input.GetSizeBytes() > MaxSize {
+		return nil, fmt.Errorf("%w: size should not exceed %d bytes", errkit.ErrInvalidArgument, MaxSize)
+	}
+
+	text := input.GetPayloadTemplate()
+	if text == "" {
+		text = defaultTemplate
+	}
+
+	tmpl, parseErr := template.New("payload").Option("missingkey=error").Parse(text)
+	if parseErr != nil {
+		return nil, fmt.Errorf("%w: parse payload template: %w", errkit.ErrInvalidArgument, parseErr)
+	}
+
+	// Render the template once to catch errors before the producer starts.
+	if _, err := render(tmpl, Payload{QueueID: input.GetQueueId(), Time: time.Now()}, 0); err != nil {
+		return nil, fmt.Errorf("%w: execute payload template: %w", errkit.ErrInvalidArgument, err)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.producers) >= MaxGenerators {
+		return nil, fmt.Errorf("%w: maximum %d generators are allowed to run simultaneously",
+			errkit.ErrUnavailable, MaxGenerators,
+		)
+	}
+
+	p := producer{
+		id:      idkit.XID(),
+		queueID: input.GetQueueId(),
+		rate:    input.GetRatePerSecond(),
+		size:    input.GetSizeBytes(),
+		tmpl:    tmpl,
+		started: time.Now().UTC(),
+		done:    make(chan struct{}),
+		sender:  g.sender,
+		logger:  g.logger,
+	}
+
+	var ctx context.Context
+
+	if d := input.GetDurationSeconds(); d > 0 {
+		p.stops = p.started.Add(time.Duration(d) * time.Second)
+		ctx, p.cancel = context.WithDeadline(context.Background(), p.stops)
+	} else {
+		ctx, p.cancel = context.WithCancel(context.Background())
+	}
+
+	g.producers[p.id] = &p
+
+	go func() {
+		defer g.remove(p.id)
+		p.run(ctx)
+	}()
+
+	return p.info(), nil
+}
+
+// Stop stops the producer with given id and waits for it to finish.
+func (g *Generator) Stop(id string) (*v1.Generator, error) {
+	g.mu.Lock()
+	p, ok := g.producers[id]
+	g.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("generator %q: %w", id, errkit.ErrNotFound)
+	}
+
+	p.cancel()
+	<-p.done
+
+	return p.info(), nil
+}
+
+// List returns running producers ordered by start time.
+func (g *Generator) List() []*v1.Generator {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	list := make([]*v1.Generator, 0, len(g.producers))
+
+	for _, p := range g.producers {
+		list = append(list, p.info())
+	}
+
+	slices.SortFunc(list, func(a, b *v1.Generator) int {
+		return a.GetStartedAt().AsTime().Compare(b.GetStartedAt().AsTime())
+	})
+
+	return list
+}
+
+// Close stops all running producers.
+func (g *Generator) Close() {
+	g.mu.Lock()
+	producers := make([]*producer, 0, len(g.producers))
+
+	for _, p := range g.producers {
+		producers = append(producers, p)
+	}
+	g.mu.Unlock()
+
+	for _, p := range producers {
+		p.cancel()
+		<-p.done
+	}
+}
+
+func (g *Generator) remove(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.producers, id)
+}
+
+// producer sends generated messages to the queue at the given rate.
+type producer struct {
+	id      string
+	queueID string
+	rate    uint32
+	size    uint64
+	tmpl    *template.Template
+	started time.Time
+	stops   time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	sender Sender
+	logger *slog.Logger
+
+	sent    atomic.Uint64
+	failed  atomic.Uint64
+	lastErr atomic.Pointer[string]
+}
+
+func (p *producer) run(ctx context.Context) {
+	defer close(p.done)
+	defer p.cancel()
+
+	p.logger.Info("Generator started",
+		slog.String("generator_id", p.id),
+		slog.String("queue_id", p.queueID),
+		slog.Uint64("rate", uint64(p.rate)),
+	)
+
+	ticker := time.NewTicker(time.Second / time.Duration(p.rate))
+	defer ticker.Stop()
+
+	var seq, failures uint64
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Info("Generator stopped",
+				slog.String("generator_id", p.id),
+				slog.Uint64("messages_sent", p.sent.Load()),
+				slog.Uint64("messages_failed", p.failed.Load()),
+			)
+
+			return
+
+		case now := <-ticker.C:
+			seq++
+
+			if err := p.send(ctx, seq, now); err != nil {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				failures++
+
+				p.failed.Add(1)
+				p.setLastErr(err)
+
+				if failures >= maxConsecutiveFailures {
+					p.logger.Error("Generator stopped after consecutive failures",
+						slog.String("generator_id", p.id),
+						slog.String("error", err.Error()),
+					)
+
+					return
+				}
+
+				continue
+			}
+
+			failures = 0
+
+			p.sent.Add(1)
+		}
+	}
+}
+
+func (p *producer) send(ctx context.Context, seq uint64, now time.Time) error {
+	body, renderErr := render(p.tmpl, Payload{
+		Seq:     seq,
+		ID:      idkit.XID(),
+		QueueID: p.queueID,
+		Time:    now.UTC(),
+	}, p.size)
+	if renderErr != nil {
+		return fmt.Errorf("render payload: %w", renderErr)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	if _, err := p.sender.Send(ctx, &v1.SendRequest{
+		QueueId:  p.queueID,
+		Messages: []*v1.SendMessage{{Body: body}},
+	}); err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+
+	return nil
+}
+
+func (p *producer) setLastErr(err error) {
+	msg := err.Error()
+	p.lastErr.Store(&msg)
+}
+
+func (p *producer) info() *v1.Generator {
+	info := v1.Generator{
+		GeneratorId:    p.id,
+		QueueId:        p.queueID,
+		RatePerSecond:  p.rate,
+		SizeBytes:      p.size,
+		StartedAt:      timestamppb.New(p.started),
+		MessagesSent:   p.sent.Load(),
+		MessagesFailed: p.failed.Load(),
+	}
+
+	if !p.stops.IsZero() {
+		info.StopsAt = timestamppb.New(p.stops)
+	}
+
+	if lastErr := p.lastErr.Load(); lastErr != nil {
+		info.LastError = *lastErr
+	}
+
+	return &info
+}
+
+// render executes the template and pads the result with spaces up to the size.
+func render(tmpl *template.Template, payload Payload, size uint64) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := tmpl.Execute(&buf, payload); err != nil {
+		return nil, err
+	}
+
+	if n := uint64(buf.Len()); n < size {
+		buf.WriteString(strings.Repeat(" ", int(size-n)))
+	}
+
+	return buf.Bytes(), nil
+}
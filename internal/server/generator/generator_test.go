@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/logkit"
+)
+
+type fakeSender struct {
+	mu     sync.Mutex
+	bodies []string
+	err    error
+}
+
+func (f *fakeSender) Send(_ context.Context, input *v1.SendRequest) (*v1.SendResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	for _, m := range input.GetMessages() {
+		f.bodies = append(f.bodies, string(m.GetBody()))
+	}
+
+	return &v1.SendResponse{}, nil
+}
+
+func (f *fakeSender) sent() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.bodies...)
+}
+
+func TestGenerator_Start_validation(t *testing.T) {
+	type tcase struct {
+		input *v1.StartGeneratorRequest
+	}
+
+	tests := map[string]tcase{
+		"ZeroRate":    {input: &v1.StartGeneratorRequest{QueueId: "q"}},
+		"HighRate":    {input: &v1.StartGeneratorRequest{QueueId: "q", RatePerSecond: MaxRate + 1}},
+		"LargeSize":   {input: &v1.StartGeneratorRequest{QueueId: "q", RatePerSecond: 1, SizeBytes: MaxSize + 1}},
+		"BadTemplate": {input: &v1.StartGeneratorRequest{QueueId: "q", RatePerSecond: 1, PayloadTemplate: "{{.Seq"}},
+		"BadField":    {input: &v1.StartGeneratorRequest{QueueId: "q", RatePerSecond: 1, PayloadTemplate: "{{.Unknown}}"}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := New(&fakeSender{}, logkit.NewNop())
+
+			_, err := g.Start(tc.input)
+			td.CmpErrorIs(t, err, errkit.ErrInvalidArgument)
+			td.CmpEmpty(t, g.List())
+		})
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	sender := fakeSender{}
+	g := New(&sender, logkit.NewNop())
+
+	gen, startErr := g.Start(&v1.StartGeneratorRequest{
+		QueueId:         "q",
+		RatePerSecond:   MaxRate,
+		SizeBytes:       8,
+		PayloadTemplate: "{{.Seq}}",
+	})
+	td.Require(t).CmpNoError(startErr)
+	td.CmpLen(t, g.List(), 1)
+
+	td.CmpTrue(t, eventually(func() bool { return len(sender.sent()) >= 3 }))
+
+	stopped, stopErr := g.Stop(gen.GetGeneratorId())
+	td.CmpNoError(t, stopErr)
+	td.CmpGte(t, stopped.GetMessagesSent(), uint64(3))
+	td.Cmp(t, sender.sent()[:3], []string{"1       ", "2       ", "3       "})
+	td.CmpEmpty(t, g.List())
+
+	_, stopErr = g.Stop(gen.GetGeneratorId())
+	td.CmpErrorIs(t, stopErr, errkit.ErrNotFound)
+}
+
+func TestGenerator_failures(t *testing.T) {
+	g := New(&fakeSender{err: errors.New("queue not found")}, logkit.NewNop())
+
+	_, startErr := g.Start(&v1.StartGeneratorRequest{QueueId: "q", RatePerSecond: MaxRate})
+	td.Require(t).CmpNoError(startErr)
+
+	// The producer stops itself after consecutive failures.
+	td.CmpTrue(t, eventually(func() bool { return len(g.List()) == 0 }))
+}
+
+func eventually(cond func() bool) bool {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	return false
+}
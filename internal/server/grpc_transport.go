@@ -2,9 +2,13 @@ package server
 
 import (
 	"context"
+	"errors"
 
 	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqerr"
 	"github.com/plainq/servekit/respond"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func (s *PlainQ) ListQueues(
@@ -36,6 +40,15 @@ func (s *PlainQ) DescribeQueue(
 }
 
 func (s *PlainQ) CreateQueue(ctx context.Context, r *v1.CreateQueueRequest) (*v1.CreateQueueResponse, error) {
+	if err := validateQueueName(r.GetQueueName()); err != nil {
+		return respond.ErrorGRPC[*v1.CreateQueueResponse](ctx, err)
+	}
+
+	// The creator owns the queue unless the owner is given.
+	if r.GetOwner() == "" {
+		r.Owner = creator(ctx)
+	}
+
 	output, createErr := s.storage.CreateQueue(ctx, r)
 	if createErr != nil {
 		return respond.ErrorGRPC[*v1.CreateQueueResponse](ctx, createErr)
@@ -44,6 +57,31 @@ func (s *PlainQ) CreateQueue(ctx context.Context, r *v1.CreateQueueRequest) (*v1
 	return output, nil
 }
 
+func (s *PlainQ) UpdateQueue(ctx context.Context, r *v1.UpdateQueueRequest) (*v1.UpdateQueueResponse, error) {
+	if err := validateQueueIDFromRequest(r); err != nil {
+		return respond.ErrorGRPC[*v1.UpdateQueueResponse](ctx, err)
+	}
+
+	if r.GetQueueName() != "" {
+		if err := validateQueueName(r.GetQueueName()); err != nil {
+			return respond.ErrorGRPC[*v1.UpdateQueueResponse](ctx, err)
+		}
+	}
+
+	output, updateErr := s.storage.UpdateQueue(ctx, r)
+	if updateErr != nil {
+		// Conflicts are not known to the respond package,
+		// but the caller has to distinguish them to retry.
+		if errors.Is(updateErr, pqerr.ErrConflict) {
+			return nil, status.Error(codes.Aborted, updateErr.Error())
+		}
+
+		return respond.ErrorGRPC[*v1.UpdateQueueResponse](ctx, updateErr)
+	}
+
+	return output, nil
+}
+
 func (s *PlainQ) DeleteQueue(ctx context.Context, r *v1.DeleteQueueRequest) (*v1.DeleteQueueResponse, error) {
 	if err := validateQueueIDFromRequest(r); err != nil {
 		return respond.ErrorGRPC[*v1.DeleteQueueResponse](ctx, err)
@@ -76,6 +114,14 @@ func (s *PlainQ) Send(ctx context.Context, r *v1.SendRequest) (*v1.SendResponse,
 
 	output, sendErr := s.storage.Send(ctx, r)
 	if sendErr != nil {
+		if errors.Is(sendErr, pqerr.ErrInterrupted) {
+			return nil, interruptedStatus(sendErr)
+		}
+
+		if errors.Is(sendErr, pqerr.ErrQuotaExceeded) {
+			return nil, status.Error(codes.ResourceExhausted, sendErr.Error())
+		}
+
 		return respond.ErrorGRPC[*v1.SendResponse](ctx, sendErr)
 	}
 
@@ -96,13 +142,494 @@ func (s *PlainQ) Receive(ctx context.Context, r *v1.ReceiveRequest) (*v1.Receive
 }
 
 func (s *PlainQ) Delete(ctx context.Context, r *v1.DeleteRequest) (*v1.DeleteResponse, error) {
+	output, deleteErr := s.deleteMessages(ctx, r)
+	if deleteErr != nil {
+		if errors.Is(deleteErr, pqerr.ErrInterrupted) {
+			return nil, interruptedStatus(deleteErr)
+		}
+
+		return respond.ErrorGRPC[*v1.DeleteResponse](ctx, deleteErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) ChangeVisibility(ctx context.Context, r *v1.ChangeVisibilityRequest) (*v1.ChangeVisibilityResponse, error) {
+	output, changeErr := s.changeVisibility(ctx, r)
+	if changeErr != nil {
+		return respond.ErrorGRPC[*v1.ChangeVisibilityResponse](ctx, changeErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) ExtendVisibilityBatch(ctx context.Context, r *v1.ExtendVisibilityBatchRequest) (*v1.ExtendVisibilityBatchResponse, error) {
+	output, extendErr := s.extendVisibilityBatch(ctx, r)
+	if extendErr != nil {
+		return respond.ErrorGRPC[*v1.ExtendVisibilityBatchResponse](ctx, extendErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) ListArchivedMessages(ctx context.Context, r *v1.ListArchivedMessagesRequest) (*v1.ListArchivedMessagesResponse, error) {
+	output, listErr := s.listArchivedMessages(ctx, r)
+	if listErr != nil {
+		return respond.ErrorGRPC[*v1.ListArchivedMessagesResponse](ctx, listErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) RestoreArchivedMessages(ctx context.Context, r *v1.RestoreArchivedMessagesRequest) (*v1.RestoreArchivedMessagesResponse, error) {
+	output, restoreErr := s.restoreArchivedMessages(ctx, r)
+	if restoreErr != nil {
+		if errors.Is(restoreErr, pqerr.ErrQuotaExceeded) {
+			return nil, status.Error(codes.ResourceExhausted, restoreErr.Error())
+		}
+
+		return respond.ErrorGRPC[*v1.RestoreArchivedMessagesResponse](ctx, restoreErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) GetJob(ctx context.Context, r *v1.GetJobRequest) (*v1.GetJobResponse, error) {
+	output, getErr := s.getJob(ctx, r)
+	if getErr != nil {
+		return respond.ErrorGRPC[*v1.GetJobResponse](ctx, getErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) ListJobs(ctx context.Context, r *v1.ListJobsRequest) (*v1.ListJobsResponse, error) {
+	output, listErr := s.listJobs(ctx, r)
+	if listErr != nil {
+		return respond.ErrorGRPC[*v1.ListJobsResponse](ctx, listErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) CancelJob(ctx context.Context, r *v1.CancelJobRequest) (*v1.CancelJobResponse, error) {
+	output, cancelErr := s.cancelJob(ctx, r)
+	if cancelErr != nil {
+		switch {
+		case errors.Is(cancelErr, pqerr.ErrConflict):
+			return nil, status.Error(codes.Aborted, cancelErr.Error())
+
+		case errors.Is(cancelErr, pqerr.ErrInterrupted):
+			return nil, interruptedStatus(cancelErr)
+		}
+
+		return respond.ErrorGRPC[*v1.CancelJobResponse](ctx, cancelErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) Transact(ctx context.Context, r *v1.TransactRequest) (*v1.TransactResponse, error) {
+	output, transactErr := s.transact(ctx, r)
+	if transactErr != nil {
+		if errors.Is(transactErr, pqerr.ErrInterrupted) {
+			return nil, interruptedStatus(transactErr)
+		}
+
+		if errors.Is(transactErr, pqerr.ErrQuotaExceeded) {
+			return nil, status.Error(codes.ResourceExhausted, transactErr.Error())
+		}
+
+		return respond.ErrorGRPC[*v1.TransactResponse](ctx, transactErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) AdviseQueue(ctx context.Context, r *v1.AdviseQueueRequest) (*v1.AdviseQueueResponse, error) {
 	if err := validateQueueIDFromRequest(r); err != nil {
-		return respond.ErrorGRPC[*v1.DeleteResponse](ctx, err)
+		return respond.ErrorGRPC[*v1.AdviseQueueResponse](ctx, err)
+	}
+
+	output, adviseErr := s.adviseQueue(ctx, r.GetQueueId())
+	if adviseErr != nil {
+		return respond.ErrorGRPC[*v1.AdviseQueueResponse](ctx, adviseErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) StartGenerator(ctx context.Context, r *v1.StartGeneratorRequest) (*v1.StartGeneratorResponse, error) {
+	if err := validateQueueIDFromRequest(r); err != nil {
+		return respond.ErrorGRPC[*v1.StartGeneratorResponse](ctx, err)
+	}
+
+	output, startErr := s.startGenerator(ctx, r)
+	if startErr != nil {
+		return respond.ErrorGRPC[*v1.StartGeneratorResponse](ctx, startErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) StopGenerator(ctx context.Context, r *v1.StopGeneratorRequest) (*v1.StopGeneratorResponse, error) {
+	gen, stopErr := s.generator.Stop(r.GetGeneratorId())
+	if stopErr != nil {
+		return respond.ErrorGRPC[*v1.StopGeneratorResponse](ctx, stopErr)
+	}
+
+	return &v1.StopGeneratorResponse{Generator: gen}, nil
+}
+
+func (s *PlainQ) ListGenerators(_ context.Context, _ *v1.ListGeneratorsRequest) (*v1.ListGeneratorsResponse, error) {
+	return &v1.ListGeneratorsResponse{Generators: s.generator.List()}, nil
+}
+
+func (s *PlainQ) QueueStats(ctx context.Context, r *v1.QueueStatsRequest) (*v1.QueueStatsResponse, error) {
+	if err := validateQueueIDFromRequest(r); err != nil {
+		return respond.ErrorGRPC[*v1.QueueStatsResponse](ctx, err)
+	}
+
+	output, statsErr := s.queueStats(ctx, r.GetQueueId())
+	if statsErr != nil {
+		return respond.ErrorGRPC[*v1.QueueStatsResponse](ctx, statsErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) GetLogLevels(_ context.Context, _ *v1.GetLogLevelsRequest) (*v1.GetLogLevelsResponse, error) {
+	return &v1.GetLogLevelsResponse{Levels: s.loggers.Levels()}, nil
+}
+
+func (s *PlainQ) ClusterStatus(ctx context.Context, _ *v1.ClusterStatusRequest) (*v1.ClusterStatusResponse, error) {
+	output, statusErr := s.clusterStatus()
+	if statusErr != nil {
+		return respond.ErrorGRPC[*v1.ClusterStatusResponse](ctx, statusErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) RunMaintenance(ctx context.Context, r *v1.RunMaintenanceRequest) (*v1.RunMaintenanceResponse, error) {
+	output, runErr := s.runMaintenance(ctx, r)
+	if runErr != nil {
+		return respond.ErrorGRPC[*v1.RunMaintenanceResponse](ctx, runErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) SetLogLevels(ctx context.Context, r *v1.SetLogLevelsRequest) (*v1.SetLogLevelsResponse, error) {
+	output, setErr := s.setLogLevels(r)
+	if setErr != nil {
+		return respond.ErrorGRPC[*v1.SetLogLevelsResponse](ctx, setErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) ReloadConfig(ctx context.Context, _ *v1.ReloadConfigRequest) (*v1.ReloadConfigResponse, error) {
+	output, reloadErr := s.reloadConfig()
+	if reloadErr != nil {
+		return respond.ErrorGRPC[*v1.ReloadConfigResponse](ctx, reloadErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) ListBreakers(_ context.Context, _ *v1.ListBreakersRequest) (*v1.ListBreakersResponse, error) {
+	return s.listBreakers(), nil
+}
+
+func (s *PlainQ) ResetBreaker(ctx context.Context, r *v1.ResetBreakerRequest) (*v1.ResetBreakerResponse, error) {
+	output, resetErr := s.resetBreaker(r)
+	if resetErr != nil {
+		return respond.ErrorGRPC[*v1.ResetBreakerResponse](ctx, resetErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) ListAuditEvents(ctx context.Context, r *v1.ListAuditEventsRequest) (*v1.ListAuditEventsResponse, error) {
+	output, listErr := s.listAuditEvents(ctx, r)
+	if listErr != nil {
+		return respond.ErrorGRPC[*v1.ListAuditEventsResponse](ctx, listErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) CreateAlertRule(ctx context.Context, r *v1.CreateAlertRuleRequest) (*v1.CreateAlertRuleResponse, error) {
+	output, createErr := s.createAlertRule(ctx, r)
+	if createErr != nil {
+		return respond.ErrorGRPC[*v1.CreateAlertRuleResponse](ctx, createErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) ListAlertRules(ctx context.Context, r *v1.ListAlertRulesRequest) (*v1.ListAlertRulesResponse, error) {
+	output, listErr := s.storage.ListAlertRules(ctx, r)
+	if listErr != nil {
+		return respond.ErrorGRPC[*v1.ListAlertRulesResponse](ctx, listErr)
 	}
 
-	output, deleteErr := s.storage.Delete(ctx, r)
+	return output, nil
+}
+
+func (s *PlainQ) UpdateAlertRule(ctx context.Context, r *v1.UpdateAlertRuleRequest) (*v1.UpdateAlertRuleResponse, error) {
+	output, updateErr := s.updateAlertRule(ctx, r)
+	if updateErr != nil {
+		return respond.ErrorGRPC[*v1.UpdateAlertRuleResponse](ctx, updateErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) DeleteAlertRule(ctx context.Context, r *v1.DeleteAlertRuleRequest) (*v1.DeleteAlertRuleResponse, error) {
+	output, deleteErr := s.deleteAlertRule(ctx, r)
 	if deleteErr != nil {
-		return respond.ErrorGRPC[*v1.DeleteResponse](ctx, deleteErr)
+		return respond.ErrorGRPC[*v1.DeleteAlertRuleResponse](ctx, deleteErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) ListAlerts(ctx context.Context, _ *v1.ListAlertsRequest) (*v1.ListAlertsResponse, error) {
+	output, listErr := s.listAlerts()
+	if listErr != nil {
+		return respond.ErrorGRPC[*v1.ListAlertsResponse](ctx, listErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) CreateServiceAccount(ctx context.Context, r *v1.CreateServiceAccountRequest) (*v1.CreateServiceAccountResponse, error) {
+	output, createErr := s.createServiceAccount(ctx, r)
+	if createErr != nil {
+		return respond.ErrorGRPC[*v1.CreateServiceAccountResponse](ctx, createErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) ListServiceAccounts(ctx context.Context, r *v1.ListServiceAccountsRequest) (*v1.ListServiceAccountsResponse, error) {
+	output, listErr := s.storage.ListServiceAccounts(ctx, r)
+	if listErr != nil {
+		return respond.ErrorGRPC[*v1.ListServiceAccountsResponse](ctx, listErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) DeleteServiceAccount(ctx context.Context, r *v1.DeleteServiceAccountRequest) (*v1.DeleteServiceAccountResponse, error) {
+	output, deleteErr := s.deleteServiceAccount(ctx, r)
+	if deleteErr != nil {
+		return respond.ErrorGRPC[*v1.DeleteServiceAccountResponse](ctx, deleteErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) CreateAPIKey(ctx context.Context, r *v1.CreateAPIKeyRequest) (*v1.CreateAPIKeyResponse, error) {
+	output, createErr := s.createAPIKey(ctx, r)
+	if createErr != nil {
+		return respond.ErrorGRPC[*v1.CreateAPIKeyResponse](ctx, createErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) ListAPIKeys(ctx context.Context, r *v1.ListAPIKeysRequest) (*v1.ListAPIKeysResponse, error) {
+	output, listErr := s.listAPIKeys(ctx, r)
+	if listErr != nil {
+		return respond.ErrorGRPC[*v1.ListAPIKeysResponse](ctx, listErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) RevokeAPIKey(ctx context.Context, r *v1.RevokeAPIKeyRequest) (*v1.RevokeAPIKeyResponse, error) {
+	output, revokeErr := s.revokeAPIKey(ctx, r)
+	if revokeErr != nil {
+		return respond.ErrorGRPC[*v1.RevokeAPIKeyResponse](ctx, revokeErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) ListQueuePermissions(ctx context.Context, r *v1.ListQueuePermissionsRequest) (*v1.ListQueuePermissionsResponse, error) {
+	output, listErr := s.listQueuePermissions(ctx, r.GetQueueId())
+	if listErr != nil {
+		return respond.ErrorGRPC[*v1.ListQueuePermissionsResponse](ctx, listErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) SetQueuePermission(ctx context.Context, r *v1.SetQueuePermissionRequest) (*v1.SetQueuePermissionResponse, error) {
+	permission, setErr := s.setQueuePermission(ctx, r.GetPermission())
+	if setErr != nil {
+		return respond.ErrorGRPC[*v1.SetQueuePermissionResponse](ctx, setErr)
+	}
+
+	return &v1.SetQueuePermissionResponse{Permission: permission}, nil
+}
+
+func (s *PlainQ) DeleteQueuePermission(ctx context.Context, r *v1.DeleteQueuePermissionRequest) (*v1.DeleteQueuePermissionResponse, error) {
+	if err := s.deleteQueuePermission(ctx, r.GetQueueId(), r.GetRole()); err != nil {
+		return respond.ErrorGRPC[*v1.DeleteQueuePermissionResponse](ctx, err)
+	}
+
+	return &v1.DeleteQueuePermissionResponse{}, nil
+}
+
+func (s *PlainQ) ListRoles(ctx context.Context, _ *v1.ListRolesRequest) (*v1.ListRolesResponse, error) {
+	roles, listErr := s.storage.Roles(ctx)
+	if listErr != nil {
+		return respond.ErrorGRPC[*v1.ListRolesResponse](ctx, listErr)
+	}
+
+	return &v1.ListRolesResponse{Roles: roles}, nil
+}
+
+func (s *PlainQ) CreateRole(ctx context.Context, r *v1.CreateRoleRequest) (*v1.CreateRoleResponse, error) {
+	role, createErr := s.createRole(ctx, r.GetName())
+	if createErr != nil {
+		return respond.ErrorGRPC[*v1.CreateRoleResponse](ctx, createErr)
+	}
+
+	return &v1.CreateRoleResponse{Role: role}, nil
+}
+
+func (s *PlainQ) DeleteRole(ctx context.Context, r *v1.DeleteRoleRequest) (*v1.DeleteRoleResponse, error) {
+	if err := s.deleteRole(ctx, r.GetName()); err != nil {
+		return respond.ErrorGRPC[*v1.DeleteRoleResponse](ctx, err)
+	}
+
+	return &v1.DeleteRoleResponse{}, nil
+}
+
+func (s *PlainQ) AssignRole(ctx context.Context, r *v1.AssignRoleRequest) (*v1.AssignRoleResponse, error) {
+	if err := s.assignRole(ctx, r.GetEmail(), r.GetRole()); err != nil {
+		return respond.ErrorGRPC[*v1.AssignRoleResponse](ctx, err)
+	}
+
+	return &v1.AssignRoleResponse{}, nil
+}
+
+func (s *PlainQ) UnassignRole(ctx context.Context, r *v1.UnassignRoleRequest) (*v1.UnassignRoleResponse, error) {
+	if err := s.unassignRole(ctx, r.GetEmail(), r.GetRole()); err != nil {
+		return respond.ErrorGRPC[*v1.UnassignRoleResponse](ctx, err)
+	}
+
+	return &v1.UnassignRoleResponse{}, nil
+}
+
+func (s *PlainQ) GetAccount(ctx context.Context, _ *v1.GetAccountRequest) (*v1.GetAccountResponse, error) {
+	account, accountErr := s.account(ctx)
+	if accountErr != nil {
+		return respond.ErrorGRPC[*v1.GetAccountResponse](ctx, accountErr)
+	}
+
+	return &v1.GetAccountResponse{Account: account}, nil
+}
+
+func (s *PlainQ) SetQueueState(ctx context.Context, r *v1.SetQueueStateRequest) (*v1.SetQueueStateResponse, error) {
+	output, setErr := s.setQueueState(ctx, r)
+	if setErr != nil {
+		if errors.Is(setErr, pqerr.ErrConflict) {
+			return nil, status.Error(codes.Aborted, setErr.Error())
+		}
+
+		return respond.ErrorGRPC[*v1.SetQueueStateResponse](ctx, setErr)
+	}
+
+	return output, nil
+}
+
+// interruptedStatus returns the gRPC status for the interrupted operation
+// according to the context error which caused the interruption.
+func interruptedStatus(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	return status.Error(codes.Canceled, err.Error())
+}
+
+func (s *PlainQ) TransferQueue(ctx context.Context, r *v1.TransferQueueRequest) (*v1.TransferQueueResponse, error) {
+	output, transferErr := s.transferQueue(ctx, r)
+	if transferErr != nil {
+		return respond.ErrorGRPC[*v1.TransferQueueResponse](ctx, transferErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) AcceptQueueTransfer(ctx context.Context, r *v1.AcceptQueueTransferRequest) (*v1.AcceptQueueTransferResponse, error) {
+	output, acceptErr := s.acceptQueueTransfer(ctx, r)
+	if acceptErr != nil {
+		if errors.Is(acceptErr, pqerr.ErrConflict) {
+			return nil, status.Error(codes.Aborted, acceptErr.Error())
+		}
+
+		return respond.ErrorGRPC[*v1.AcceptQueueTransferResponse](ctx, acceptErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) CancelQueueTransfer(ctx context.Context, r *v1.CancelQueueTransferRequest) (*v1.CancelQueueTransferResponse, error) {
+	output, cancelErr := s.cancelQueueTransfer(ctx, r)
+	if cancelErr != nil {
+		return respond.ErrorGRPC[*v1.CancelQueueTransferResponse](ctx, cancelErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) SearchMessages(ctx context.Context, r *v1.SearchMessagesRequest) (*v1.SearchMessagesResponse, error) {
+	output, searchErr := s.searchMessages(ctx, r)
+	if searchErr != nil {
+		return respond.ErrorGRPC[*v1.SearchMessagesResponse](ctx, searchErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) PeekMessages(ctx context.Context, r *v1.PeekMessagesRequest) (*v1.PeekMessagesResponse, error) {
+	output, peekErr := s.peekMessages(ctx, r)
+	if peekErr != nil {
+		return respond.ErrorGRPC[*v1.PeekMessagesResponse](ctx, peekErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) GetMessage(ctx context.Context, r *v1.GetMessageRequest) (*v1.GetMessageResponse, error) {
+	output, getErr := s.getMessage(ctx, r)
+	if getErr != nil {
+		return respond.ErrorGRPC[*v1.GetMessageResponse](ctx, getErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) ListMessageEvents(ctx context.Context, r *v1.ListMessageEventsRequest) (*v1.ListMessageEventsResponse, error) {
+	output, listErr := s.listMessageEvents(ctx, r)
+	if listErr != nil {
+		return respond.ErrorGRPC[*v1.ListMessageEventsResponse](ctx, listErr)
+	}
+
+	return output, nil
+}
+
+func (s *PlainQ) Search(ctx context.Context, r *v1.SearchRequest) (*v1.SearchResponse, error) {
+	output, searchErr := s.search(ctx, r)
+	if searchErr != nil {
+		return respond.ErrorGRPC[*v1.SearchResponse](ctx, searchErr)
 	}
 
 	return output, nil
@@ -4,14 +4,24 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/rbac"
 	v1 "github.com/plainq/plainq/internal/server/schema/v1"
 	"github.com/plainq/plainq/internal/server/storage"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+	"github.com/plainq/plainq/internal/shared/pqmatch"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+	"github.com/plainq/servekit/logkit"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -109,3 +119,547 @@ func TestServer_ListQueues(t *testing.T) {
 	}
 
 }
+
+func TestServer_CreateQueue(t *testing.T) {
+	type tcase struct {
+		ctx context.Context
+		req *v1.CreateQueueRequest
+
+		wantOwner string
+	}
+
+	tests := map[string]tcase{
+		"Authenticated": {
+			ctx:       identity.WithIdentity(context.Background(), identity.Identity{Name: "alice@example.com"}),
+			req:       &v1.CreateQueueRequest{QueueName: "orders"},
+			wantOwner: "alice@example.com",
+		},
+		"GivenOwner": {
+			ctx:       identity.WithIdentity(context.Background(), identity.Identity{Name: "alice@example.com"}),
+			req:       &v1.CreateQueueRequest{QueueName: "orders", Owner: "payments"},
+			wantOwner: "payments",
+		},
+		"Unauthenticated": {
+			ctx: context.Background(),
+			req: &v1.CreateQueueRequest{QueueName: "orders"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var owner string
+
+			server := PlainQ{
+				storage: &mockStorage{
+					createQueueFunc: func(ctx context.Context, input *v1.CreateQueueRequest) (*v1.CreateQueueResponse, error) {
+						owner = input.GetOwner()
+						return &v1.CreateQueueResponse{QueueId: idkit.XID()}, nil
+					},
+				},
+			}
+
+			_, err := server.CreateQueue(tc.ctx, tc.req)
+			td.CmpNoError(t, err)
+			td.Cmp(t, owner, tc.wantOwner)
+		})
+	}
+}
+
+func TestServer_UpdateQueue(t *testing.T) {
+	type tcase struct {
+		storage storage.Storage
+		req     *v1.UpdateQueueRequest
+
+		want     *v1.UpdateQueueResponse
+		wantCode codes.Code
+	}
+
+	queueID := idkit.XID()
+
+	tests := map[string]tcase{
+		"OK": {
+			storage: &mockStorage{
+				updateQueueFunc: func(ctx context.Context, input *v1.UpdateQueueRequest) (*v1.UpdateQueueResponse, error) {
+					return &v1.UpdateQueueResponse{Version: input.Version + 1}, nil
+				},
+			},
+			req:      &v1.UpdateQueueRequest{QueueId: queueID, Version: 1},
+			want:     &v1.UpdateQueueResponse{Version: 2},
+			wantCode: codes.OK,
+		},
+		"Conflict": {
+			storage: &mockStorage{
+				updateQueueFunc: func(ctx context.Context, input *v1.UpdateQueueRequest) (*v1.UpdateQueueResponse, error) {
+					return nil, &pqerr.ConflictError{Resource: "queue", Expected: input.Version, Actual: 2}
+				},
+			},
+			req:      &v1.UpdateQueueRequest{QueueId: queueID, Version: 1},
+			wantCode: codes.Aborted,
+		},
+		"InvalidID": {
+			storage:  &mockStorage{},
+			req:      &v1.UpdateQueueRequest{QueueId: "invalid"},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := PlainQ{
+				storage: tc.storage,
+			}
+
+			res, err := server.UpdateQueue(context.Background(), tc.req)
+			td.Cmp(t, status.Code(err), tc.wantCode)
+			if tc.wantCode == codes.OK {
+				td.Cmp(t, res, tc.want)
+			}
+		})
+	}
+}
+
+func TestServer_Send_errors(t *testing.T) {
+	type tcase struct {
+		err      error
+		wantCode codes.Code
+	}
+
+	tests := map[string]tcase{
+		"Canceled": {
+			err:      &pqerr.InterruptedError{Operation: "send", Processed: 1, Total: 2, Err: context.Canceled},
+			wantCode: codes.Canceled,
+		},
+		"DeadlineExceeded": {
+			err:      &pqerr.InterruptedError{Operation: "send", Processed: 1, Total: 2, Err: context.DeadlineExceeded},
+			wantCode: codes.DeadlineExceeded,
+		},
+		"QuotaExceeded": {
+			err:      &pqerr.QuotaExceededError{Quota: "messages", Usage: 10, Requested: 1, Limit: 10},
+			wantCode: codes.ResourceExhausted,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := PlainQ{
+				storage: &mockStorage{
+					sendFunc: func(context.Context, *v1.SendRequest) (*v1.SendResponse, error) {
+						return nil, tc.err
+					},
+				},
+			}
+
+			_, err := server.Send(context.Background(), &v1.SendRequest{QueueId: idkit.XID()})
+			td.Cmp(t, status.Code(err), tc.wantCode)
+		})
+	}
+}
+
+func TestServer_QueueStats(t *testing.T) {
+	queueID := idkit.XID()
+
+	observer := telemetry.NewObserver()
+	observer.MessagesSent(queueID).Add(7)
+	observer.MessagesReceived(queueID).Add(5)
+	observer.EmptyReceives(queueID).Add(2)
+
+	started := time.Now().Add(-time.Minute).UTC()
+
+	server := PlainQ{
+		observer: observer,
+		started:  started,
+		storage: &mockStorage{
+			queueStatsFunc: func(_ context.Context, id string) (*v1.QueueStatsResponse, error) {
+				return &v1.QueueStatsResponse{QueueId: id, Depth: 3, InFlight: 1, OldestMessageAgeSeconds: 42}, nil
+			},
+		},
+	}
+
+	res, err := server.QueueStats(context.Background(), &v1.QueueStatsRequest{QueueId: queueID})
+	td.CmpNoError(t, err)
+
+	td.Cmp(t, res.GetDepth(), uint64(3))
+	td.Cmp(t, res.GetInFlight(), uint64(1))
+	td.Cmp(t, res.GetOldestMessageAgeSeconds(), uint64(42))
+	td.Cmp(t, res.GetMessagesSentTotal(), uint64(7))
+	td.Cmp(t, res.GetMessagesReceivedTotal(), uint64(5))
+	td.Cmp(t, res.GetEmptyReceivesTotal(), uint64(2))
+	td.Cmp(t, res.GetCountersSince().AsTime(), started)
+
+	_, invalidErr := server.QueueStats(context.Background(), &v1.QueueStatsRequest{QueueId: "invalid"})
+	td.CmpError(t, invalidErr)
+}
+
+func TestServer_AcceptQueueTransfer(t *testing.T) {
+	type tcase struct {
+		req      *v1.AcceptQueueTransferRequest
+		err      error
+		wantCode codes.Code
+	}
+
+	transferID := idkit.XID()
+
+	tests := map[string]tcase{
+		"OK": {
+			req:      &v1.AcceptQueueTransferRequest{TransferId: transferID, ToOwner: "payments", AcceptedBy: "bob"},
+			wantCode: codes.OK,
+		},
+		"InvalidTransferID": {
+			req:      &v1.AcceptQueueTransferRequest{TransferId: "invalid", ToOwner: "payments", AcceptedBy: "bob"},
+			wantCode: codes.InvalidArgument,
+		},
+		"NotFound": {
+			req:      &v1.AcceptQueueTransferRequest{TransferId: transferID, ToOwner: "payments", AcceptedBy: "bob"},
+			err:      errkit.ErrNotFound,
+			wantCode: codes.NotFound,
+		},
+		"Conflict": {
+			req:      &v1.AcceptQueueTransferRequest{TransferId: transferID, ToOwner: "payments", AcceptedBy: "bob"},
+			err:      pqerr.ErrConflict,
+			wantCode: codes.Aborted,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := PlainQ{
+				audit: logkit.NewNop(),
+				storage: &mockStorage{
+					acceptTransferFunc: func(context.Context, *v1.AcceptQueueTransferRequest) (*v1.AcceptQueueTransferResponse, error) {
+						if tc.err != nil {
+							return nil, tc.err
+						}
+
+						return &v1.AcceptQueueTransferResponse{QueueId: idkit.XID(), Version: 2}, nil
+					},
+				},
+			}
+
+			res, err := server.AcceptQueueTransfer(context.Background(), tc.req)
+			td.Cmp(t, status.Code(err), tc.wantCode)
+			if tc.wantCode == codes.OK {
+				td.Cmp(t, res.GetVersion(), uint64(2))
+			}
+		})
+	}
+}
+
+func TestServer_SetQueueState(t *testing.T) {
+	type tcase struct {
+		req      *v1.SetQueueStateRequest
+		err      error
+		wantCode codes.Code
+	}
+
+	queueID := idkit.XID()
+
+	tests := map[string]tcase{
+		"OK": {
+			req:      &v1.SetQueueStateRequest{QueueId: queueID, State: v1.QueueState_QUEUE_STATE_PAUSED},
+			wantCode: codes.OK,
+		},
+		"InvalidID": {
+			req:      &v1.SetQueueStateRequest{QueueId: "invalid", State: v1.QueueState_QUEUE_STATE_PAUSED},
+			wantCode: codes.Internal,
+		},
+		"NotFound": {
+			req:      &v1.SetQueueStateRequest{QueueId: queueID, State: v1.QueueState_QUEUE_STATE_PAUSED},
+			err:      errkit.ErrNotFound,
+			wantCode: codes.NotFound,
+		},
+		"InvalidTransition": {
+			req:      &v1.SetQueueStateRequest{QueueId: queueID, State: v1.QueueState_QUEUE_STATE_CREATING},
+			err:      pqerr.ErrConflict,
+			wantCode: codes.Aborted,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := PlainQ{
+				audit: logkit.NewNop(),
+				storage: &mockStorage{
+					setQueueStateFunc: func(_ context.Context, input *v1.SetQueueStateRequest) (*v1.SetQueueStateResponse, error) {
+						if tc.err != nil {
+							return nil, tc.err
+						}
+
+						return &v1.SetQueueStateResponse{State: input.GetState(), Version: 2}, nil
+					},
+				},
+			}
+
+			res, err := server.SetQueueState(context.Background(), tc.req)
+			td.Cmp(t, status.Code(err), tc.wantCode)
+			if tc.wantCode == codes.OK {
+				td.Cmp(t, res.GetState(), tc.req.GetState())
+				td.Cmp(t, res.GetVersion(), uint64(2))
+			}
+		})
+	}
+}
+
+func TestServer_ListAuditEvents(t *testing.T) {
+	type tcase struct {
+		req      *v1.ListAuditEventsRequest
+		err      error
+		wantCode codes.Code
+	}
+
+	now := time.Now()
+
+	tests := map[string]tcase{
+		"OK": {
+			req:      &v1.ListAuditEventsRequest{Actor: "orders-service", From: timestamppb.New(now.Add(-time.Hour)), To: timestamppb.New(now)},
+			wantCode: codes.OK,
+		},
+		"InvalidRange": {
+			req:      &v1.ListAuditEventsRequest{From: timestamppb.New(now), To: timestamppb.New(now.Add(-time.Hour))},
+			wantCode: codes.InvalidArgument,
+		},
+		"InvalidLimit": {
+			req:      &v1.ListAuditEventsRequest{Limit: 5000},
+			err:      errkit.ErrInvalidArgument,
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := PlainQ{
+				storage: &mockStorage{
+					listAuditFunc: func(_ context.Context, input *v1.ListAuditEventsRequest) (*v1.ListAuditEventsResponse, error) {
+						if tc.err != nil {
+							return nil, tc.err
+						}
+
+						return &v1.ListAuditEventsResponse{Events: []*v1.AuditEvent{
+							{EventId: idkit.ULID(), Actor: input.GetActor(), Action: "queue.create"},
+						}}, nil
+					},
+				},
+			}
+
+			res, err := server.ListAuditEvents(context.Background(), tc.req)
+			td.Cmp(t, status.Code(err), tc.wantCode)
+			if tc.wantCode == codes.OK {
+				td.Cmp(t, res.GetEvents(), td.Len(1))
+				td.Cmp(t, res.GetEvents()[0].GetActor(), tc.req.GetActor())
+			}
+		})
+	}
+}
+
+func TestServer_CreateAlertRule(t *testing.T) {
+	type tcase struct {
+		req      *v1.CreateAlertRuleRequest
+		wantCode codes.Code
+	}
+
+	tests := map[string]tcase{
+		"OK": {
+			req: &v1.CreateAlertRuleRequest{Rule: &v1.AlertRule{
+				Name:      "orders backlog",
+				Metric:    "messages_visible",
+				Operator:  v1.AlertOperator_ALERT_OPERATOR_GT,
+				Threshold: 1000,
+			}},
+			wantCode: codes.OK,
+		},
+		"NoRule": {
+			req:      &v1.CreateAlertRuleRequest{},
+			wantCode: codes.InvalidArgument,
+		},
+		"UnknownMetric": {
+			req: &v1.CreateAlertRuleRequest{Rule: &v1.AlertRule{
+				Name:     "orders backlog",
+				Metric:   "messages_lost",
+				Operator: v1.AlertOperator_ALERT_OPERATOR_GT,
+			}},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := PlainQ{
+				storage: &mockStorage{
+					createAlertRuleFunc: func(_ context.Context, input *v1.CreateAlertRuleRequest) (*v1.CreateAlertRuleResponse, error) {
+						rule := proto.Clone(input.GetRule()).(*v1.AlertRule)
+						rule.RuleId = idkit.XID()
+
+						return &v1.CreateAlertRuleResponse{Rule: rule}, nil
+					},
+				},
+			}
+
+			res, err := server.CreateAlertRule(context.Background(), tc.req)
+			td.Cmp(t, status.Code(err), tc.wantCode)
+			if tc.wantCode == codes.OK {
+				td.CmpNotEmpty(t, res.GetRule().GetRuleId())
+			}
+		})
+	}
+}
+
+func TestServer_UpdateAlertRule(t *testing.T) {
+	type tcase struct {
+		req      *v1.UpdateAlertRuleRequest
+		err      error
+		wantCode codes.Code
+	}
+
+	rule := func(id string) *v1.AlertRule {
+		return &v1.AlertRule{
+			RuleId:    id,
+			Name:      "idle consumers",
+			Metric:    "empty_receive_ratio",
+			Operator:  v1.AlertOperator_ALERT_OPERATOR_GTE,
+			Threshold: 0.9,
+		}
+	}
+
+	tests := map[string]tcase{
+		"OK": {
+			req:      &v1.UpdateAlertRuleRequest{Rule: rule(idkit.XID())},
+			wantCode: codes.OK,
+		},
+		"InvalidID": {
+			req:      &v1.UpdateAlertRuleRequest{Rule: rule("r1")},
+			wantCode: codes.InvalidArgument,
+		},
+		"NotFound": {
+			req:      &v1.UpdateAlertRuleRequest{Rule: rule(idkit.XID())},
+			err:      errkit.ErrNotFound,
+			wantCode: codes.NotFound,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := PlainQ{
+				storage: &mockStorage{
+					updateAlertRuleFunc: func(_ context.Context, input *v1.UpdateAlertRuleRequest) (*v1.UpdateAlertRuleResponse, error) {
+						if tc.err != nil {
+							return nil, tc.err
+						}
+
+						return &v1.UpdateAlertRuleResponse{Rule: input.GetRule()}, nil
+					},
+				},
+			}
+
+			_, err := server.UpdateAlertRule(context.Background(), tc.req)
+			td.Cmp(t, status.Code(err), tc.wantCode)
+		})
+	}
+}
+
+func TestServer_ListAlerts(t *testing.T) {
+	var server PlainQ
+
+	_, err := server.ListAlerts(context.Background(), &v1.ListAlertsRequest{})
+	td.Cmp(t, status.Code(err), codes.Unavailable)
+}
+
+func TestServer_Search(t *testing.T) {
+	type tcase struct {
+		subject  string
+		req      *v1.SearchRequest
+		wantCode codes.Code
+		want     []string
+	}
+
+	tests := map[string]tcase{
+		"All": {
+			req:      &v1.SearchRequest{Query: "orders"},
+			wantCode: codes.OK,
+			want:     []string{"orders", "orders@example.com", "orders-admin", "queue.purge"},
+		},
+		"Admin": {
+			subject:  "admin",
+			req:      &v1.SearchRequest{Query: "orders"},
+			wantCode: codes.OK,
+			want:     []string{"orders", "orders@example.com", "orders-admin", "queue.purge"},
+		},
+		"NotAdmin": {
+			subject:  "consumer",
+			req:      &v1.SearchRequest{Query: "orders"},
+			wantCode: codes.OK,
+			want:     []string{"orders"},
+		},
+		"NotAdminAuditEvents": {
+			subject:  "consumer",
+			req:      &v1.SearchRequest{Query: "orders", Kinds: []v1.EntityKind{v1.EntityKind_ENTITY_KIND_AUDIT_EVENT}},
+			wantCode: codes.OK,
+		},
+		"Limit": {
+			req:      &v1.SearchRequest{Query: "orders", Limit: 1},
+			wantCode: codes.OK,
+			want:     []string{"orders"},
+		},
+		"AuditEventsOnly": {
+			req:      &v1.SearchRequest{Query: "orders", Kinds: []v1.EntityKind{v1.EntityKind_ENTITY_KIND_AUDIT_EVENT}},
+			wantCode: codes.OK,
+			want:     []string{"queue.purge"},
+		},
+		"EmptyQuery": {
+			req:      &v1.SearchRequest{Query: " "},
+			wantCode: codes.InvalidArgument,
+		},
+		"LimitTooLarge": {
+			req:      &v1.SearchRequest{Query: "orders", Limit: maxEntitySearchLimit + 1},
+			wantCode: codes.InvalidArgument,
+		},
+		"UnknownKind": {
+			req:      &v1.SearchRequest{Query: "orders", Kinds: []v1.EntityKind{42}},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	entities := []*v1.SearchResult{
+		{Kind: v1.EntityKind_ENTITY_KIND_QUEUE, Title: "orders", Score: pqmatch.ScoreExact},
+		{Kind: v1.EntityKind_ENTITY_KIND_USER, Title: "orders@example.com", Score: pqmatch.ScorePrefix},
+		{Kind: v1.EntityKind_ENTITY_KIND_ROLE, Title: "orders-admin", Score: pqmatch.ScorePrefix},
+		{Kind: v1.EntityKind_ENTITY_KIND_AUDIT_EVENT, Title: "queue.purge", Score: pqmatch.ScoreWordPrefix},
+	}
+
+	server := PlainQ{
+		storage: &mockStorage{
+			searchEntitiesFunc: func(_ context.Context, input *v1.SearchRequest) (*v1.SearchResponse, error) {
+				var results []*v1.SearchResult
+
+				for _, e := range entities {
+					if slices.Contains(input.GetKinds(), e.GetKind()) && len(results) < int(input.GetLimit()) {
+						results = append(results, e)
+					}
+				}
+
+				return &v1.SearchResponse{Results: results}, nil
+			},
+			queueGrantsFunc: func(_ context.Context, subject, _ string) (*rbac.Grants, error) {
+				return &rbac.Grants{Admin: subject == "admin"}, nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.subject != "" {
+				ctx = identity.WithIdentity(ctx, identity.Identity{Name: tc.subject})
+			}
+
+			res, err := server.Search(ctx, tc.req)
+			td.Cmp(t, status.Code(err), tc.wantCode)
+
+			var titles []string
+
+			for _, r := range res.GetResults() {
+				titles = append(titles, r.GetTitle())
+			}
+
+			td.Cmp(t, titles, tc.want)
+		})
+	}
+}
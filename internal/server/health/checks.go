@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/heartwilltell/hc"
+)
+
+// Ping returns the check of the component which checks its own health, e.g. the database connection.
+func Ping(checker hc.HealthChecker) CheckFunc {
+	return func(ctx context.Context) (map[string]any, error) {
+		return nil, checker.Health(ctx)
+	}
+}
+
+// Listener returns the check of the listener, which accepts connections on the address.
+func Listener(addr net.Addr) CheckFunc {
+	return func(ctx context.Context) (map[string]any, error) {
+		var d net.Dialer
+
+		conn, dialErr := d.DialContext(ctx, addr.Network(), addr.String())
+		if dialErr != nil {
+			return nil, fmt.Errorf("listener doesn't accept connections: %w", dialErr)
+		}
+
+		_ = conn.Close()
+
+		return map[string]any{"address": addr.String()}, nil
+	}
+}
+
+// Size returns the check of the size in bytes, e.g. of the write-ahead log,
+// which fails when the size exceeds the limit. Zero limit means no limit.
+func Size(size func(ctx context.Context) (int64, error), limit uint64) CheckFunc {
+	return func(ctx context.Context) (map[string]any, error) {
+		n, sizeErr := size(ctx)
+		if sizeErr != nil {
+			return nil, sizeErr
+		}
+
+		details := map[string]any{"bytes": n}
+
+		if limit > 0 {
+			details["limit_bytes"] = limit
+
+			if uint64(max(n, 0)) > limit {
+				return details, fmt.Errorf("size %d exceeds the limit of %d bytes", n, limit)
+			}
+		}
+
+		return details, nil
+	}
+}
+
+// Recency returns the check of the routine which runs periodically, e.g. the garbage
+// collection, which fails when the routine hasn't run for longer than maxAge.
+// The last returns the time of the last run, which is zero when it hasn't run yet.
+func Recency(last func() time.Time, since time.Time, maxAge func() time.Duration) CheckFunc {
+	return func(context.Context) (map[string]any, error) {
+		details := make(map[string]any, 2)
+
+		ran := last()
+		if ran.IsZero() {
+			// The routine has time to run for the first time.
+			ran = since
+		} else {
+			details["last_run"] = ran.UTC().Format(time.RFC3339)
+		}
+
+		age := time.Since(ran).Truncate(time.Second)
+		details["age"] = age.String()
+
+		if limit := maxAge(); age > limit {
+			return details, fmt.Errorf("has not run for %s", age)
+		}
+
+		return details, nil
+	}
+}
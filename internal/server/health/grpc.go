@@ -0,0 +1,39 @@
+package health
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Compilation time check that grpcHealth implements the grpc_health_v1.HealthServer.
+var _ grpc_health_v1.HealthServer = (*grpcHealth)(nil)
+
+// GRPC returns the standard gRPC health service, which reports the server
+// as serving while it's ready. Only the overall health of the server is
+// reported, which is requested with the empty service name.
+func GRPC(checker *Checker) grpc_health_v1.HealthServer {
+	return &grpcHealth{checker: checker}
+}
+
+type grpcHealth struct {
+	grpc_health_v1.UnimplementedHealthServer
+
+	checker *Checker
+}
+
+func (h *grpcHealth) Check(ctx context.Context, r *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if r.GetService() != "" {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", r.GetService())
+	}
+
+	response := grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}
+
+	if !h.checker.Report(ctx).Ready() {
+		response.Status = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,192 @@
+// Package health checks components of the server, e.g. the storage or the gRPC
+// listener, and reports their statuses, which are served by health routes.
+//
+// The server is live as long as it responds, since restarting it doesn't fix
+// its dependencies. The server is ready when all its critical components are up.
+// Failures of non-critical components degrade the server, but keep it ready.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/heartwilltell/hc"
+)
+
+// Compilation time check that Checker implements the hc.HealthChecker.
+var _ hc.HealthChecker = (*Checker)(nil)
+
+// DefaultTimeout limits the time of each check when the timeout is not set.
+const DefaultTimeout = 5 * time.Second
+
+// Status represents the status of the component or the server.
+type Status string
+
+// Statuses of components and the server.
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// CheckFunc checks the component and returns details of its state,
+// e.g. the size of the file. An error means the component is down.
+type CheckFunc func(ctx context.Context) (map[string]any, error)
+
+// Check represents a checked component.
+type Check struct {
+	// Name is the component name, e.g. "storage".
+	Name string
+
+	// Critical components make the server not ready when they are down.
+	Critical bool
+
+	// Func checks the component.
+	Func CheckFunc
+}
+
+// Component represents the status of the checked component.
+type Component struct {
+	Name     string         `json:"name"`
+	Status   Status         `json:"status"`
+	Critical bool           `json:"critical"`
+	Error    string         `json:"error,omitempty"`
+	Details  map[string]any `json:"details,omitempty"`
+	Duration string         `json:"duration"`
+}
+
+// Report represents the status of the server and its components.
+type Report struct {
+	Status     Status      `json:"status"`
+	Components []Component `json:"components,omitempty"`
+}
+
+// Ready reports whether the server is ready to serve requests.
+func (r Report) Ready() bool { return r.Status != StatusDown }
+
+// Checker checks components of the server.
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []Check
+}
+
+// New returns a pointer to a new instance of Checker. Each check is limited
+// by the timeout, or by the DefaultTimeout when the timeout is not positive.
+func New(timeout time.Duration, checks ...Check) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	c := Checker{
+		timeout: timeout,
+		checks:  append(make([]Check, 0, len(checks)), checks...),
+	}
+
+	return &c
+}
+
+// Add adds checks of components, which can be done once the component is created.
+func (c *Checker) Add(checks ...Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, checks...)
+}
+
+// Report checks all components concurrently and reports their statuses
+// in the order the checks have been added.
+func (c *Checker) Report(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	report := Report{
+		Status:     StatusUp,
+		Components: make([]Component, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+
+	for i, check := range checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			report.Components[i] = c.check(ctx, check)
+		}()
+	}
+
+	wg.Wait()
+
+	for _, component := range report.Components {
+		if component.Status != StatusDown {
+			continue
+		}
+
+		if component.Critical {
+			report.Status = StatusDown
+			break
+		}
+
+		report.Status = StatusDegraded
+	}
+
+	return report
+}
+
+// Health implements the hc.HealthChecker interface.
+// Returns an error when the server is not ready.
+func (c *Checker) Health(ctx context.Context) error {
+	report := c.Report(ctx)
+	if report.Ready() {
+		return nil
+	}
+
+	var errs []error
+
+	for _, component := range report.Components {
+		if component.Critical && component.Status == StatusDown {
+			errs = append(errs, fmt.Errorf("%s: %s", component.Name, component.Error))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// check runs the check of the component with the timeout.
+func (c *Checker) check(ctx context.Context, check Check) (component Component) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	component = Component{
+		Name:     check.Name,
+		Status:   StatusUp,
+		Critical: check.Critical,
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			component.Status = StatusDown
+			component.Error = fmt.Sprintf("check panicked: %v", r)
+		}
+
+		component.Duration = time.Since(start).String()
+	}()
+
+	details, err := check.Func(ctx)
+	if err != nil {
+		component.Status = StatusDown
+		component.Error = err.Error()
+	}
+
+	component.Details = details
+
+	return component
+}
@@ -0,0 +1,159 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func check(name string, critical bool, err error) Check {
+	return Check{Name: name, Critical: critical, Func: func(context.Context) (map[string]any, error) { return nil, err }}
+}
+
+func TestChecker_Report(t *testing.T) {
+	type tcase struct {
+		checks     []Check
+		wantStatus Status
+		wantErr    bool
+	}
+
+	boom := errors.New("boom")
+
+	tests := map[string]tcase{
+		"NoChecks": {
+			wantStatus: StatusUp,
+		},
+		"Up": {
+			checks:     []Check{check("storage", true, nil), check("telemetry", false, nil)},
+			wantStatus: StatusUp,
+		},
+		"Degraded": {
+			checks:     []Check{check("storage", true, nil), check("telemetry", false, boom)},
+			wantStatus: StatusDegraded,
+		},
+		"Down": {
+			checks:     []Check{check("storage", true, boom), check("telemetry", false, boom)},
+			wantStatus: StatusDown,
+			wantErr:    true,
+		},
+		"Panic": {
+			checks: []Check{{Name: "storage", Critical: true, Func: func(context.Context) (map[string]any, error) {
+				panic("unexpected")
+			}}},
+			wantStatus: StatusDown,
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			checker := New(time.Second, tc.checks...)
+
+			report := checker.Report(context.Background())
+			td.Cmp(t, report.Status, tc.wantStatus)
+			td.Cmp(t, report.Components, td.Len(len(tc.checks)))
+
+			if tc.wantErr {
+				td.CmpError(t, checker.Health(context.Background()))
+				return
+			}
+
+			td.CmpNoError(t, checker.Health(context.Background()))
+		})
+	}
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	checker := New(10*time.Millisecond, Check{Name: "storage", Critical: true, Func: func(ctx context.Context) (map[string]any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}})
+
+	report := checker.Report(context.Background())
+	td.Cmp(t, report.Status, StatusDown)
+	td.Cmp(t, report.Components[0].Error, context.DeadlineExceeded.Error())
+}
+
+func TestSize(t *testing.T) {
+	size := func(context.Context) (int64, error) { return 2048, nil }
+
+	details, err := Size(size, 0)(context.Background())
+	td.CmpNoError(t, err)
+	td.Cmp(t, details, map[string]any{"bytes": int64(2048)})
+
+	details, err = Size(size, 1024)(context.Background())
+	td.CmpError(t, err)
+	td.Cmp(t, details, map[string]any{"bytes": int64(2048), "limit_bytes": uint64(1024)})
+}
+
+func TestRecency(t *testing.T) {
+	maxAge := func() time.Duration { return time.Minute }
+
+	_, err := Recency(func() time.Time { return time.Time{} }, time.Now(), maxAge)(context.Background())
+	td.CmpNoError(t, err)
+
+	_, err = Recency(func() time.Time { return time.Time{} }, time.Now().Add(-time.Hour), maxAge)(context.Background())
+	td.CmpError(t, err)
+
+	details, err := Recency(func() time.Time { return time.Now().Add(-time.Hour) }, time.Now(), maxAge)(context.Background())
+	td.CmpError(t, err)
+	td.Cmp(t, details, td.ContainsKey("last_run"))
+}
+
+func TestListener(t *testing.T) {
+	ln, listenErr := net.Listen("tcp", "127.0.0.1:0")
+	td.CmpNoError(t, listenErr)
+
+	addr := ln.Addr()
+
+	_, err := Listener(addr)(context.Background())
+	td.CmpNoError(t, err)
+
+	td.CmpNoError(t, ln.Close())
+
+	_, err = Listener(addr)(context.Background())
+	td.CmpError(t, err)
+}
+
+func TestGRPC_Check(t *testing.T) {
+	type tcase struct {
+		checks     []Check
+		service    string
+		wantStatus grpc_health_v1.HealthCheckResponse_ServingStatus
+		wantCode   codes.Code
+	}
+
+	boom := errors.New("boom")
+
+	tests := map[string]tcase{
+		"Serving": {
+			checks:     []Check{check("storage", true, nil), check("telemetry", false, boom)},
+			wantStatus: grpc_health_v1.HealthCheckResponse_SERVING,
+		},
+		"NotServing": {
+			checks:     []Check{check("storage", true, boom)},
+			wantStatus: grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+		},
+		"UnknownService": {
+			service:  "v1.PlainQService",
+			wantCode: codes.NotFound,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := GRPC(New(time.Second, tc.checks...))
+
+			response, err := server.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: tc.service})
+			td.Cmp(t, status.Code(err), tc.wantCode)
+			td.Cmp(t, response.GetStatus(), tc.wantStatus)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/servekit/errkit"
+)
+
+// historyQueryTimeout limits the time of a query of historical metrics.
+const historyQueryTimeout = 10 * time.Second
+
+// telemetryQueryResponse represents the response of the historical metrics query.
+type telemetryQueryResponse struct {
+	Metrics []telemetry.Metric `json:"metrics"`
+}
+
+// queryTelemetry returns datapoints of the metric over the time range, which
+// are the data source of Houston charts. Queue metrics are selected by the queue.
+func (s *PlainQ) queryTelemetry(ctx context.Context, q telemetry.Query) (*telemetryQueryResponse, error) {
+	if s.history == nil {
+		return nil, fmt.Errorf("%w: %w", errkit.ErrUnavailable, telemetry.ErrHistoryUnavailable)
+	}
+
+	if q.QueueID != "" {
+		if err := validateQueueID(q.QueueID); err != nil {
+			return nil, fmt.Errorf("%w: %w", errkit.ErrInvalidArgument, err)
+		}
+	}
+
+	if err := q.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errkit.ErrInvalidArgument, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, historyQueryTimeout)
+	defer cancel()
+
+	series, queryErr := s.history.Query(ctx, q)
+	if queryErr != nil {
+		if errors.Is(queryErr, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: query metrics: %w", errkit.ErrUnavailable, queryErr)
+		}
+
+		return nil, fmt.Errorf("query metrics: %w", queryErr)
+	}
+
+	if series == nil {
+		series = make([]telemetry.Metric, 0)
+	}
+
+	return &telemetryQueryResponse{Metrics: series}, nil
+}
@@ -2,24 +2,33 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/plainq/plainq/internal/houston"
+	"github.com/plainq/plainq/internal/server/auth"
+	"github.com/plainq/plainq/internal/server/identity"
 	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+	"github.com/plainq/plainq/internal/shared/pqjson"
 	"github.com/plainq/servekit/errkit"
 	"github.com/plainq/servekit/respond"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func (s *PlainQ) createQueueHandler(w http.ResponseWriter, r *http.Request) {
 	var input v1.CreateQueueRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	if err := decodeRequest(r, &input); err != nil {
 		respond.ErrorHTTP(w, r, err)
 		return
 	}
@@ -32,13 +41,23 @@ func (s *PlainQ) createQueueHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
+	if err := validateQueueName(input.GetQueueName()); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	// The creator owns the queue unless the owner is given.
+	if input.GetOwner() == "" {
+		input.Owner = creator(r.Context())
+	}
+
 	output, createErr := s.storage.CreateQueue(r.Context(), &input)
 	if createErr != nil {
 		respond.ErrorHTTP(w, r, createErr)
 		return
 	}
 
-	respond.JSON(w, r, output, respond.WithStatus(http.StatusCreated))
+	respondProto(w, r, output, respond.WithStatus(http.StatusCreated))
 }
 
 func (s *PlainQ) listQueuesHandler(w http.ResponseWriter, r *http.Request) {
@@ -66,13 +85,53 @@ func (s *PlainQ) listQueuesHandler(w http.ResponseWriter, r *http.Request) {
 		input.Limit = int32(limit)
 	}
 
+	// The deprecated depth parameter lists the stats as well.
+	if d := r.URL.Query().Get("depth"); d != "" {
+		depth, parseErr := strconv.ParseBool(d)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid depth", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.IncludeStats = depth
+	}
+
+	if st := r.URL.Query().Get("stats"); st != "" {
+		stats, parseErr := strconv.ParseBool(st)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid stats", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.IncludeStats = input.IncludeStats || stats
+	}
+
+	// The queue depth changes with every message, so the
+	// response can't be revalidated by the queue props ETag.
+	if input.IncludeStats {
+		output, listErr := s.storage.ListQueues(r.Context(), &input)
+		if listErr != nil {
+			respond.ErrorHTTP(w, r, listErr)
+			return
+		}
+
+		respondProto(w, r, output)
+		return
+	}
+
+	etag := s.queuePropsETag()
+	if etagMatch(r, etag) {
+		respond.Status(w, r, http.StatusNotModified, cacheHeaders(etag)...)
+		return
+	}
+
 	output, listErr := s.storage.ListQueues(r.Context(), &input)
 	if listErr != nil {
 		respond.ErrorHTTP(w, r, listErr)
 		return
 	}
 
-	respond.JSON(w, r, output)
+	respondProto(w, r, output, cacheHeaders(etag)...)
 }
 
 func (s *PlainQ) describeQueueHandler(w http.ResponseWriter, r *http.Request) {
@@ -83,6 +142,12 @@ func (s *PlainQ) describeQueueHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	etag := s.queuePropsETag()
+	if etagMatch(r, etag) {
+		respond.Status(w, r, http.StatusNotModified, cacheHeaders(etag)...)
+		return
+	}
+
 	input := v1.DescribeQueueRequest{QueueId: id}
 
 	output, describeErr := s.storage.DescribeQueue(r.Context(), &input)
@@ -91,7 +156,53 @@ func (s *PlainQ) describeQueueHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respond.JSON(w, r, output, respond.WithStatus(http.StatusOK))
+	respondProto(w, r, output, append(cacheHeaders(etag), respond.WithStatus(http.StatusOK))...)
+}
+
+func (s *PlainQ) updateQueueHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := validateQueueID(id); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	var input v1.UpdateQueueRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			s.logger.Error("update queue: close request body",
+				slog.String("error", err.Error()),
+			)
+		}
+	}()
+
+	input.QueueId = id
+
+	if input.GetQueueName() != "" {
+		if err := validateQueueName(input.GetQueueName()); err != nil {
+			respond.ErrorHTTP(w, r, err)
+			return
+		}
+	}
+
+	output, updateErr := s.storage.UpdateQueue(r.Context(), &input)
+	if updateErr != nil {
+		if errors.Is(updateErr, pqerr.ErrConflict) {
+			http.Error(w, updateErr.Error(), http.StatusConflict)
+			return
+		}
+
+		respond.ErrorHTTP(w, r, updateErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
 }
 
 func (s *PlainQ) deleteQueueHandler(w http.ResponseWriter, r *http.Request) {
@@ -118,7 +229,7 @@ func (s *PlainQ) deleteQueueHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respond.JSON(w, r, output, respond.WithStatus(http.StatusOK))
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
 }
 
 func (s *PlainQ) purgeQueueHandler(w http.ResponseWriter, r *http.Request) {
@@ -129,31 +240,1446 @@ func (s *PlainQ) purgeQueueHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	output, purgeErr := s.storage.PurgeQueue(r.Context(), &v1.PurgeQueueRequest{
+	input := v1.PurgeQueueRequest{
 		QueueId: id,
-	})
+		Async:   r.URL.Query().Get("async") == "true",
+	}
+
+	if olderThan := r.URL.Query().Get("older_than"); olderThan != "" {
+		t, parseErr := time.Parse(time.RFC3339, olderThan)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid older_than", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.OlderThan = timestamppb.New(t)
+	}
+
+	if m := r.URL.Query().Get("min_attempts"); m != "" {
+		attempts, parseErr := strconv.ParseUint(m, 10, 32)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid min_attempts", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.MinAttempts = uint32(attempts)
+	}
+
+	output, purgeErr := s.storage.PurgeQueue(r.Context(), &input)
 	if purgeErr != nil {
 		respond.ErrorHTTP(w, r, purgeErr)
 		return
 	}
 
-	respond.JSON(w, r, output, respond.WithStatus(http.StatusOK))
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
 }
 
-func (*PlainQ) houstonStaticHandler(w http.ResponseWriter, r *http.Request) {
-	routeCtx := chi.RouteContext(r.Context())
-	pathPrefix := strings.TrimSuffix(routeCtx.RoutePattern(), "/*")
+func (s *PlainQ) adviseQueueHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
 
-	http.StripPrefix(pathPrefix, http.FileServerFS(houston.Bundle())).ServeHTTP(w, r)
+	if err := validateQueueID(id); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	output, adviseErr := s.adviseQueue(r.Context(), id)
+	if adviseErr != nil {
+		respond.ErrorHTTP(w, r, adviseErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
 }
 
-func dropPolicyToString(policy v1.EvictionPolicy) string {
-	switch policy {
-	case v1.EvictionPolicy_EVICTION_POLICY_DROP:
-		return "Drop Message"
+func (s *PlainQ) queueStatsHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
 
-	case v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER:
-		return "Dead Letter Queue"
+	if err := validateQueueID(id); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	output, statsErr := s.queueStats(r.Context(), id)
+	if statsErr != nil {
+		respond.ErrorHTTP(w, r, statsErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) peekMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	input := v1.PeekMessagesRequest{
+		QueueId: chi.URLParam(r, "id"),
+		Cursor:  r.URL.Query().Get("cursor"),
+	}
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, parseErr := strconv.ParseUint(l, 10, 32)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid limit", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.Limit = uint32(limit)
+	}
+
+	if b := r.URL.Query().Get("include_body"); b != "" {
+		includeBody, parseErr := strconv.ParseBool(b)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid include_body", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.IncludeBody = includeBody
+	}
+
+	output, peekErr := s.peekMessages(r.Context(), &input)
+	if peekErr != nil {
+		respond.ErrorHTTP(w, r, peekErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) listArchivedMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	input := v1.ListArchivedMessagesRequest{
+		QueueId: chi.URLParam(r, "id"),
+		Cursor:  r.URL.Query().Get("cursor"),
+	}
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, parseErr := strconv.ParseUint(l, 10, 32)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid limit", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.Limit = uint32(limit)
+	}
+
+	if b := r.URL.Query().Get("include_body"); b != "" {
+		includeBody, parseErr := strconv.ParseBool(b)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid include_body", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.IncludeBody = includeBody
+	}
+
+	output, listErr := s.listArchivedMessages(r.Context(), &input)
+	if listErr != nil {
+		respond.ErrorHTTP(w, r, listErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) restoreArchivedMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	var input v1.RestoreArchivedMessagesRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	input.QueueId = chi.URLParam(r, "id")
+
+	output, restoreErr := s.restoreArchivedMessages(r.Context(), &input)
+	if restoreErr != nil {
+		if errors.Is(restoreErr, pqerr.ErrQuotaExceeded) {
+			http.Error(w, restoreErr.Error(), http.StatusTooManyRequests)
+			return
+		}
+
+		respond.ErrorHTTP(w, r, restoreErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) getMessageHandler(w http.ResponseWriter, r *http.Request) {
+	input := v1.GetMessageRequest{
+		QueueId:   chi.URLParam(r, "id"),
+		MessageId: chi.URLParam(r, "message"),
+	}
+
+	output, getErr := s.getMessage(r.Context(), &input)
+	if getErr != nil {
+		respond.ErrorHTTP(w, r, getErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) listMessageEventsHandler(w http.ResponseWriter, r *http.Request) {
+	input := v1.ListMessageEventsRequest{
+		QueueId:   chi.URLParam(r, "id"),
+		MessageId: chi.URLParam(r, "message"),
+	}
+
+	output, listErr := s.listMessageEvents(r.Context(), &input)
+	if listErr != nil {
+		respond.ErrorHTTP(w, r, listErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) sendHandler(w http.ResponseWriter, r *http.Request) {
+	var input v1.SendRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	input.QueueId = chi.URLParam(r, "id")
+
+	if err := validateQueueID(input.GetQueueId()); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	output, sendErr := s.storage.Send(r.Context(), &input)
+	if sendErr != nil {
+		if errors.Is(sendErr, pqerr.ErrMessageTooLarge) {
+			http.Error(w, sendErr.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if errors.Is(sendErr, pqerr.ErrQuotaExceeded) {
+			http.Error(w, sendErr.Error(), http.StatusTooManyRequests)
+			return
+		}
+
+		respond.ErrorHTTP(w, r, sendErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusCreated))
+}
+
+func (s *PlainQ) searchMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	input := v1.SearchMessagesRequest{
+		QueueId:   chi.URLParam(r, "id"),
+		Query:     r.URL.Query().Get("q"),
+		Contains:  r.URL.Query().Get("contains"),
+		JsonPath:  r.URL.Query().Get("json_path"),
+		JsonValue: r.URL.Query().Get("json_value"),
+	}
+
+	if from := r.URL.Query().Get("from"); from != "" {
+		t, parseErr := time.Parse(time.RFC3339, from)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid from", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.From = timestamppb.New(t)
+	}
+
+	if to := r.URL.Query().Get("to"); to != "" {
+		t, parseErr := time.Parse(time.RFC3339, to)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid to", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.To = timestamppb.New(t)
+	}
+
+	if m := r.URL.Query().Get("min_attempts"); m != "" {
+		attempts, parseErr := strconv.ParseUint(m, 10, 32)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid min_attempts", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.MinAttempts = uint32(attempts)
+	}
+
+	if m := r.URL.Query().Get("max_attempts"); m != "" {
+		attempts, parseErr := strconv.ParseUint(m, 10, 32)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid max_attempts", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.MaxAttempts = proto.Uint32(uint32(attempts))
+	}
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, parseErr := strconv.ParseUint(l, 10, 32)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid limit", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.Limit = uint32(limit)
+	}
+
+	output, searchErr := s.searchMessages(r.Context(), &input)
+	if searchErr != nil {
+		respond.ErrorHTTP(w, r, searchErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) searchHandler(w http.ResponseWriter, r *http.Request) {
+	input := v1.SearchRequest{
+		Query: r.URL.Query().Get("q"),
+	}
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, parseErr := strconv.ParseUint(l, 10, 32)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid limit", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.Limit = uint32(limit)
+	}
+
+	for _, kinds := range r.URL.Query()["kind"] {
+		for name := range strings.SplitSeq(kinds, ",") {
+			kind, ok := v1.EntityKind_value["ENTITY_KIND_"+strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(name), "-", "_"))]
+			if !ok {
+				respond.ErrorHTTP(w, r, fmt.Errorf("%w: unknown entity kind %q", errkit.ErrInvalidArgument, name))
+				return
+			}
+
+			input.Kinds = append(input.Kinds, v1.EntityKind(kind))
+		}
+	}
+
+	output, searchErr := s.search(r.Context(), &input)
+	if searchErr != nil {
+		respond.ErrorHTTP(w, r, searchErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) queryTelemetryHandler(w http.ResponseWriter, r *http.Request) {
+	query := telemetry.Query{
+		Metric:  r.URL.Query().Get("metric"),
+		QueueID: r.URL.Query().Get("queue"),
+		To:      time.Now().UTC(),
+	}
+
+	if to := r.URL.Query().Get("to"); to != "" {
+		t, parseErr := time.Parse(time.RFC3339, to)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid to", errkit.ErrInvalidArgument))
+			return
+		}
+
+		query.To = t
+	}
+
+	query.From = query.To.Add(-telemetry.DefaultHistoryRange)
+
+	if from := r.URL.Query().Get("from"); from != "" {
+		t, parseErr := time.Parse(time.RFC3339, from)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid from", errkit.ErrInvalidArgument))
+			return
+		}
+
+		query.From = t
+	}
+
+	if step := r.URL.Query().Get("step"); step != "" {
+		d, parseErr := time.ParseDuration(step)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid step", errkit.ErrInvalidArgument))
+			return
+		}
+
+		query.Step = d
+	}
+
+	output, queryErr := s.queryTelemetry(r.Context(), query)
+	if queryErr != nil {
+		respond.ErrorHTTP(w, r, queryErr)
+		return
+	}
+
+	respond.JSON(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) transferQueueHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := validateQueueID(id); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	var input v1.TransferQueueRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	input.QueueId = id
+
+	output, transferErr := s.transferQueue(r.Context(), &input)
+	if transferErr != nil {
+		respond.ErrorHTTP(w, r, transferErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusCreated))
+}
+
+func (s *PlainQ) acceptQueueTransferHandler(w http.ResponseWriter, r *http.Request) {
+	var input v1.AcceptQueueTransferRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	input.TransferId = chi.URLParam(r, "id")
+
+	output, acceptErr := s.acceptQueueTransfer(r.Context(), &input)
+	if acceptErr != nil {
+		if errors.Is(acceptErr, pqerr.ErrConflict) {
+			http.Error(w, acceptErr.Error(), http.StatusConflict)
+			return
+		}
+
+		respond.ErrorHTTP(w, r, acceptErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) getJobHandler(w http.ResponseWriter, r *http.Request) {
+	output, getErr := s.getJob(r.Context(), &v1.GetJobRequest{JobId: chi.URLParam(r, "id")})
+	if getErr != nil {
+		respond.ErrorHTTP(w, r, getErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) listJobsHandler(w http.ResponseWriter, r *http.Request) {
+	input := v1.ListJobsRequest{
+		QueueId: r.URL.Query().Get("queue_id"),
+	}
+
+	if name := r.URL.Query().Get("state"); name != "" {
+		state, ok := v1.JobState_value["JOB_STATE_"+strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: unknown job state %q", errkit.ErrInvalidArgument, name))
+			return
+		}
+
+		input.State = v1.JobState(state)
+	}
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, parseErr := strconv.ParseUint(l, 10, 32)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid limit", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.Limit = uint32(limit)
+	}
+
+	output, listErr := s.listJobs(r.Context(), &input)
+	if listErr != nil {
+		respond.ErrorHTTP(w, r, listErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) cancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	output, cancelErr := s.cancelJob(r.Context(), &v1.CancelJobRequest{JobId: chi.URLParam(r, "id")})
+	if cancelErr != nil {
+		if errors.Is(cancelErr, pqerr.ErrConflict) {
+			http.Error(w, cancelErr.Error(), http.StatusConflict)
+			return
+		}
+
+		respond.ErrorHTTP(w, r, cancelErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) cancelQueueTransferHandler(w http.ResponseWriter, r *http.Request) {
+	input := v1.CancelQueueTransferRequest{
+		TransferId: chi.URLParam(r, "id"),
+		CanceledBy: r.URL.Query().Get("canceled_by"),
+	}
+
+	output, cancelErr := s.cancelQueueTransfer(r.Context(), &input)
+	if cancelErr != nil {
+		respond.ErrorHTTP(w, r, cancelErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) getLogLevelsHandler(w http.ResponseWriter, r *http.Request) {
+	respondProto(w, r, &v1.GetLogLevelsResponse{Levels: s.loggers.Levels()}, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) setLogLevelsHandler(w http.ResponseWriter, r *http.Request) {
+	var input v1.SetLogLevelsRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	output, setErr := s.setLogLevels(&input)
+	if setErr != nil {
+		respond.ErrorHTTP(w, r, setErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) clusterStatusHandler(w http.ResponseWriter, r *http.Request) {
+	output, statusErr := s.clusterStatus()
+	if statusErr != nil {
+		respond.ErrorHTTP(w, r, statusErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) runMaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	var input v1.RunMaintenanceRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	output, runErr := s.runMaintenance(r.Context(), &input)
+	if runErr != nil {
+		respond.ErrorHTTP(w, r, runErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) reloadConfigHandler(w http.ResponseWriter, r *http.Request) {
+	output, reloadErr := s.reloadConfig()
+	if reloadErr != nil {
+		respond.ErrorHTTP(w, r, reloadErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) listBreakersHandler(w http.ResponseWriter, r *http.Request) {
+	respondProto(w, r, s.listBreakers(), respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) listAuditEventsHandler(w http.ResponseWriter, r *http.Request) {
+	input := v1.ListAuditEventsRequest{
+		Actor:  r.URL.Query().Get("actor"),
+		Action: r.URL.Query().Get("action"),
+		Target: r.URL.Query().Get("target"),
+		Cursor: r.URL.Query().Get("cursor"),
+	}
+
+	if from := r.URL.Query().Get("from"); from != "" {
+		t, parseErr := time.Parse(time.RFC3339, from)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid from", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.From = timestamppb.New(t)
+	}
+
+	if to := r.URL.Query().Get("to"); to != "" {
+		t, parseErr := time.Parse(time.RFC3339, to)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid to", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.To = timestamppb.New(t)
+	}
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, parseErr := strconv.ParseUint(l, 10, 32)
+		if parseErr != nil {
+			respond.ErrorHTTP(w, r, fmt.Errorf("%w: invalid limit", errkit.ErrInvalidArgument))
+			return
+		}
+
+		input.Limit = uint32(limit)
+	}
+
+	output, listErr := s.listAuditEvents(r.Context(), &input)
+	if listErr != nil {
+		respond.ErrorHTTP(w, r, listErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) listAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	output, listErr := s.listAlerts()
+	if listErr != nil {
+		respond.ErrorHTTP(w, r, listErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) listAlertRulesHandler(w http.ResponseWriter, r *http.Request) {
+	output, listErr := s.storage.ListAlertRules(r.Context(), &v1.ListAlertRulesRequest{})
+	if listErr != nil {
+		respond.ErrorHTTP(w, r, listErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) createAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var input v1.CreateAlertRuleRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	output, createErr := s.createAlertRule(r.Context(), &input)
+	if createErr != nil {
+		respond.ErrorHTTP(w, r, createErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusCreated))
+}
+
+func (s *PlainQ) updateAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var input v1.UpdateAlertRuleRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	if input.Rule == nil {
+		input.Rule = &v1.AlertRule{}
+	}
+
+	input.Rule.RuleId = chi.URLParam(r, "id")
+
+	output, updateErr := s.updateAlertRule(r.Context(), &input)
+	if updateErr != nil {
+		respond.ErrorHTTP(w, r, updateErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) deleteAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	output, deleteErr := s.deleteAlertRule(r.Context(), &v1.DeleteAlertRuleRequest{RuleId: chi.URLParam(r, "id")})
+	if deleteErr != nil {
+		respond.ErrorHTTP(w, r, deleteErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) listServiceAccountsHandler(w http.ResponseWriter, r *http.Request) {
+	output, listErr := s.storage.ListServiceAccounts(r.Context(), &v1.ListServiceAccountsRequest{})
+	if listErr != nil {
+		respond.ErrorHTTP(w, r, listErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) createServiceAccountHandler(w http.ResponseWriter, r *http.Request) {
+	var input v1.CreateServiceAccountRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	output, createErr := s.createServiceAccount(r.Context(), &input)
+	if createErr != nil {
+		respond.ErrorHTTP(w, r, createErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusCreated))
+}
+
+func (s *PlainQ) deleteServiceAccountHandler(w http.ResponseWriter, r *http.Request) {
+	output, deleteErr := s.deleteServiceAccount(r.Context(), &v1.DeleteServiceAccountRequest{AccountId: chi.URLParam(r, "id")})
+	if deleteErr != nil {
+		respond.ErrorHTTP(w, r, deleteErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) listAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	output, listErr := s.listAPIKeys(r.Context(), &v1.ListAPIKeysRequest{AccountId: chi.URLParam(r, "id")})
+	if listErr != nil {
+		respond.ErrorHTTP(w, r, listErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) createAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var input v1.CreateAPIKeyRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	input.AccountId = chi.URLParam(r, "id")
+
+	output, createErr := s.createAPIKey(r.Context(), &input)
+	if createErr != nil {
+		respond.ErrorHTTP(w, r, createErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusCreated))
+}
+
+func (s *PlainQ) revokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	output, revokeErr := s.revokeAPIKey(r.Context(), &v1.RevokeAPIKeyRequest{KeyId: chi.URLParam(r, "id")})
+	if revokeErr != nil {
+		respond.ErrorHTTP(w, r, revokeErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) signInHandler(w http.ResponseWriter, r *http.Request) {
+	if s.authn == nil {
+		respond.ErrorHTTP(w, r, fmt.Errorf("%w: authentication is not enabled", errkit.ErrUnavailable))
+		return
+	}
+
+	var input v1.SignInRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	device := auth.Device{
+		UserAgent:  r.UserAgent(),
+		RemoteAddr: r.RemoteAddr,
+	}
+
+	token, claims, signInErr := s.authn.SignIn(r.Context(), input.GetUsername(), input.GetPassword(), device)
+	if signInErr != nil {
+		if errors.Is(signInErr, auth.ErrInvalidPassword) {
+			s.observer.AuthFailures(auth.Reason(signInErr)).Inc()
+			http.Error(w, signInErr.Error(), http.StatusUnauthorized)
+
+			return
+		}
+
+		if errors.Is(signInErr, auth.ErrEmailNotVerified) {
+			http.Error(w, signInErr.Error(), http.StatusForbidden)
+			return
+		}
+
+		respond.ErrorHTTP(w, r, signInErr)
+
+		return
+	}
+
+	output := v1.SignInResponse{
+		Token:     token,
+		ExpiresAt: timestamppb.New(time.Unix(claims.ExpiresAt, 0)),
+		Subject:   claims.Subject,
+	}
+
+	respondProto(w, r, &output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) signOutHandler(w http.ResponseWriter, r *http.Request) {
+	if s.authn == nil {
+		respond.ErrorHTTP(w, r, fmt.Errorf("%w: authentication is not enabled", errkit.ErrUnavailable))
+		return
+	}
+
+	if err := s.authn.SignOut(r.Context(), r.Header.Get("Authorization")); err != nil {
+		if auth.Unauthenticated(err) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+
+			return
+		}
+
+		respond.ErrorHTTP(w, r, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *PlainQ) listSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := s.tokenClaims(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, sessionsErr := s.authn.Sessions(r.Context(), claims.Subject)
+	if sessionsErr != nil {
+		respond.ErrorHTTP(w, r, sessionsErr)
+		return
+	}
+
+	respondProto(w, r, sessionsOutput(sessions, claims.ID), respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) revokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := s.tokenClaims(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.authn.RevokeSession(r.Context(), claims.Subject, chi.URLParam(r, "id")); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *PlainQ) revokeSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := s.tokenClaims(w, r)
+	if !ok {
+		return
+	}
+
+	revoked, revokeErr := s.authn.RevokeSessions(r.Context(), claims.Subject)
+	if revokeErr != nil {
+		respond.ErrorHTTP(w, r, revokeErr)
+		return
+	}
+
+	respondProto(w, r, &v1.RevokeSessionsResponse{Revoked: revoked}, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) getAccountHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := s.tokenClaims(w, r)
+	if !ok {
+		return
+	}
+
+	output, accountErr := s.storage.Account(r.Context(), claims.Subject)
+	if accountErr != nil {
+		respond.ErrorHTTP(w, r, accountErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) updateAccountHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := s.tokenClaims(w, r)
+	if !ok {
+		return
+	}
+
+	var input v1.UpdateAccountRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	output, updateErr := s.updateAccount(r.Context(), claims, &input)
+	if updateErr != nil {
+		respond.ErrorHTTP(w, r, updateErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) changePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := s.tokenClaims(w, r)
+	if !ok {
+		return
+	}
+
+	var input v1.ChangePasswordRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	revoked, changeErr := s.authn.ChangePassword(r.Context(), claims, input.GetCurrentPassword(), input.GetNewPassword())
+	if changeErr != nil {
+		if errors.Is(changeErr, auth.ErrInvalidPassword) {
+			http.Error(w, "invalid current password", http.StatusForbidden)
+			return
+		}
+
+		respond.ErrorHTTP(w, r, changeErr)
+
+		return
+	}
+
+	respondProto(w, r, &v1.ChangePasswordResponse{RevokedSessions: revoked}, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) listUserSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	if s.authn == nil {
+		respond.ErrorHTTP(w, r, fmt.Errorf("%w: authentication is not enabled", errkit.ErrUnavailable))
+		return
+	}
+
+	sessions, sessionsErr := s.authn.Sessions(r.Context(), chi.URLParam(r, "subject"))
+	if sessionsErr != nil {
+		respond.ErrorHTTP(w, r, sessionsErr)
+		return
+	}
+
+	respondProto(w, r, sessionsOutput(sessions, ""), respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) revokeUserSessionHandler(w http.ResponseWriter, r *http.Request) {
+	if s.authn == nil {
+		respond.ErrorHTTP(w, r, fmt.Errorf("%w: authentication is not enabled", errkit.ErrUnavailable))
+		return
+	}
+
+	if err := s.authn.RevokeSession(r.Context(), chi.URLParam(r, "subject"), chi.URLParam(r, "id")); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *PlainQ) revokeUserSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	if s.authn == nil {
+		respond.ErrorHTTP(w, r, fmt.Errorf("%w: authentication is not enabled", errkit.ErrUnavailable))
+		return
+	}
+
+	revoked, revokeErr := s.authn.RevokeSessions(r.Context(), chi.URLParam(r, "subject"))
+	if revokeErr != nil {
+		respond.ErrorHTTP(w, r, revokeErr)
+		return
+	}
+
+	respondProto(w, r, &v1.RevokeSessionsResponse{Revoked: revoked}, respond.WithStatus(http.StatusOK))
+}
+
+// tokenClaims authenticates the token of the request, whose subject is the signed-in user.
+// It responds with the error and returns false when the token is rejected.
+func (s *PlainQ) tokenClaims(w http.ResponseWriter, r *http.Request) (*auth.Claims, bool) {
+	if s.authn == nil {
+		respond.ErrorHTTP(w, r, fmt.Errorf("%w: authentication is not enabled", errkit.ErrUnavailable))
+		return nil, false
+	}
+
+	claims, err := s.authn.SessionClaims(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		if auth.Unauthenticated(err) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+
+			return nil, false
+		}
+
+		respond.ErrorHTTP(w, r, err)
+
+		return nil, false
+	}
+
+	return claims, true
+}
+
+// sessionsOutput returns the response with sessions, marking the session with the current id.
+func sessionsOutput(sessions []auth.Session, currentID string) *v1.ListSessionsResponse {
+	output := v1.ListSessionsResponse{
+		Sessions: make([]*v1.Session, 0, len(sessions)),
+	}
+
+	for _, session := range sessions {
+		output.Sessions = append(output.Sessions, &v1.Session{
+			SessionId:  session.ID,
+			Subject:    session.Subject,
+			UserAgent:  session.Device.UserAgent,
+			RemoteAddr: session.Device.RemoteAddr,
+			CreatedAt:  timestamppb.New(session.CreatedAt),
+			ExpiresAt:  timestamppb.New(session.ExpiresAt),
+			Current:    currentID != "" && session.ID == currentID,
+		})
+	}
+
+	return &output
+}
+
+func (s *PlainQ) requestPasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	if s.authn == nil {
+		respond.ErrorHTTP(w, r, fmt.Errorf("%w: authentication is not enabled", errkit.ErrUnavailable))
+		return
+	}
+
+	var input v1.RequestPasswordResetRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	if err := s.authn.RequestPasswordReset(r.Context(), input.GetEmail()); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	respondProto(w, r, &v1.RequestPasswordResetResponse{}, respond.WithStatus(http.StatusAccepted))
+}
+
+func (s *PlainQ) verifyPasswordResetCodeHandler(w http.ResponseWriter, r *http.Request) {
+	if s.authn == nil {
+		respond.ErrorHTTP(w, r, fmt.Errorf("%w: authentication is not enabled", errkit.ErrUnavailable))
+		return
+	}
+
+	var input v1.VerifyPasswordResetCodeRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	expiresAt, verifyErr := s.authn.VerifyPasswordResetCode(r.Context(), input.GetEmail(), input.GetCode())
+	if verifyErr != nil {
+		if errors.Is(verifyErr, auth.ErrInvalidResetCode) {
+			http.Error(w, verifyErr.Error(), http.StatusBadRequest)
+			return
+		}
+
+		respond.ErrorHTTP(w, r, verifyErr)
+
+		return
+	}
+
+	output := v1.VerifyPasswordResetCodeResponse{
+		ExpiresAt: timestamppb.New(expiresAt),
+	}
+
+	respondProto(w, r, &output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) resetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if s.authn == nil {
+		respond.ErrorHTTP(w, r, fmt.Errorf("%w: authentication is not enabled", errkit.ErrUnavailable))
+		return
+	}
+
+	var input v1.ResetPasswordRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	if err := s.authn.ResetPassword(r.Context(), input.GetEmail(), input.GetCode(), input.GetNewPassword()); err != nil {
+		if errors.Is(err, auth.ErrInvalidResetCode) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		respond.ErrorHTTP(w, r, err)
+
+		return
+	}
+
+	respondProto(w, r, &v1.ResetPasswordResponse{}, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) sendEmailVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	if s.authn == nil {
+		respond.ErrorHTTP(w, r, fmt.Errorf("%w: authentication is not enabled", errkit.ErrUnavailable))
+		return
+	}
+
+	var input v1.SendEmailVerificationRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	if err := s.authn.SendEmailVerification(r.Context(), input.GetEmail()); err != nil {
+		var tooSoon *auth.ResendTooSoonError
+		if errors.As(err, &tooSoon) {
+			w.Header().Set("Retry-After", strconv.FormatInt(tooSoon.RetryAfterSeconds(), 10))
+			http.Error(w, tooSoon.Error(), http.StatusTooManyRequests)
+
+			return
+		}
+
+		respond.ErrorHTTP(w, r, err)
+
+		return
+	}
+
+	respondProto(w, r, &v1.SendEmailVerificationResponse{}, respond.WithStatus(http.StatusAccepted))
+}
+
+func (s *PlainQ) verifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	if s.authn == nil {
+		respond.ErrorHTTP(w, r, fmt.Errorf("%w: authentication is not enabled", errkit.ErrUnavailable))
+		return
+	}
+
+	var input v1.VerifyEmailRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	if err := s.authn.VerifyEmail(r.Context(), input.GetEmail(), input.GetCode()); err != nil {
+		if errors.Is(err, auth.ErrInvalidVerificationCode) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		respond.ErrorHTTP(w, r, err)
+
+		return
+	}
+
+	respondProto(w, r, &v1.VerifyEmailResponse{}, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) listQueuePermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	output, listErr := s.listQueuePermissions(r.Context(), chi.URLParam(r, "id"))
+	if listErr != nil {
+		respond.ErrorHTTP(w, r, listErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) setQueuePermissionHandler(w http.ResponseWriter, r *http.Request) {
+	var input v1.QueuePermission
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	input.QueueId = chi.URLParam(r, "id")
+	input.Role = chi.URLParam(r, "role")
+
+	output, setErr := s.setQueuePermission(r.Context(), &input)
+	if setErr != nil {
+		respond.ErrorHTTP(w, r, setErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) deleteQueuePermissionHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.deleteQueuePermission(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "role")); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *PlainQ) userPermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	output, permissionsErr := s.effectivePermissions(r.Context(), chi.URLParam(r, "subject"), r.URL.Query().Get("queue_id"))
+	if permissionsErr != nil {
+		respond.ErrorHTTP(w, r, permissionsErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) accountPermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := identity.FromContext(r.Context())
+	if !ok {
+		respond.ErrorHTTP(w, r, fmt.Errorf("%w: authentication is not enabled", errkit.ErrUnavailable))
+		return
+	}
+
+	output, permissionsErr := s.effectivePermissions(r.Context(), id.Name, r.URL.Query().Get("queue_id"))
+	if permissionsErr != nil {
+		respond.ErrorHTTP(w, r, permissionsErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (*PlainQ) listPermissionTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	respondProto(w, r, permissionTemplates(), respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) applyTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.applyTemplate(r.Context(), chi.URLParam(r, "role"), chi.URLParam(r, "template")); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *PlainQ) listRolesHandler(w http.ResponseWriter, r *http.Request) {
+	roles, listErr := s.storage.Roles(r.Context())
+	if listErr != nil {
+		respond.ErrorHTTP(w, r, listErr)
+		return
+	}
+
+	respondProto(w, r, &v1.ListRolesResponse{Roles: roles}, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) createRoleHandler(w http.ResponseWriter, r *http.Request) {
+	var input v1.CreateRoleRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	role, createErr := s.createRole(r.Context(), input.GetName())
+	if createErr != nil {
+		respond.ErrorHTTP(w, r, createErr)
+		return
+	}
+
+	respondProto(w, r, &v1.CreateRoleResponse{Role: role}, respond.WithStatus(http.StatusCreated))
+}
+
+func (s *PlainQ) deleteRoleHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.deleteRole(r.Context(), chi.URLParam(r, "role")); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *PlainQ) assignRoleHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.assignRole(r.Context(), chi.URLParam(r, "email"), chi.URLParam(r, "role")); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *PlainQ) unassignRoleHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.unassignRole(r.Context(), chi.URLParam(r, "email"), chi.URLParam(r, "role")); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *PlainQ) resetBreakerHandler(w http.ResponseWriter, r *http.Request) {
+	output, resetErr := s.resetBreaker(&v1.ResetBreakerRequest{QueueId: chi.URLParam(r, "id")})
+	if resetErr != nil {
+		respond.ErrorHTTP(w, r, resetErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) setQueueStateHandler(w http.ResponseWriter, r *http.Request) {
+	var input v1.SetQueueStateRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	input.QueueId = chi.URLParam(r, "id")
+
+	output, setErr := s.setQueueState(r.Context(), &input)
+	if setErr != nil {
+		if errors.Is(setErr, pqerr.ErrConflict) {
+			http.Error(w, setErr.Error(), http.StatusConflict)
+			return
+		}
+
+		respond.ErrorHTTP(w, r, setErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) startGeneratorHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := validateQueueID(id); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	var input v1.StartGeneratorRequest
+
+	if err := decodeRequest(r, &input); err != nil {
+		respond.ErrorHTTP(w, r, err)
+		return
+	}
+
+	input.QueueId = id
+
+	output, startErr := s.startGenerator(r.Context(), &input)
+	if startErr != nil {
+		respond.ErrorHTTP(w, r, startErr)
+		return
+	}
+
+	respondProto(w, r, output, respond.WithStatus(http.StatusCreated))
+}
+
+func (s *PlainQ) listGeneratorsHandler(w http.ResponseWriter, r *http.Request) {
+	output := v1.ListGeneratorsResponse{Generators: s.generator.List()}
+
+	respondProto(w, r, &output, respond.WithStatus(http.StatusOK))
+}
+
+func (s *PlainQ) stopGeneratorHandler(w http.ResponseWriter, r *http.Request) {
+	gen, stopErr := s.generator.Stop(chi.URLParam(r, "id"))
+	if stopErr != nil {
+		respond.ErrorHTTP(w, r, stopErr)
+		return
+	}
+
+	respondProto(w, r, &v1.StopGeneratorResponse{Generator: gen}, respond.WithStatus(http.StatusOK))
+}
+
+func (*PlainQ) houstonStaticHandler(w http.ResponseWriter, r *http.Request) {
+	routeCtx := chi.RouteContext(r.Context())
+	pathPrefix := strings.TrimSuffix(routeCtx.RoutePattern(), "/*")
+
+	http.StripPrefix(pathPrefix, http.FileServerFS(houston.Bundle())).ServeHTTP(w, r)
+}
+
+// queuePropsETag returns a weak entity tag derived from the version of queue properties.
+// The version is read before the data, so a concurrent modification can only make
+// the tag older than the response, which leads to revalidation rather than to stale data.
+func (s *PlainQ) queuePropsETag() string {
+	return `W/"` + s.epoch + "-" + strconv.FormatUint(s.storage.QueuePropsVersion(), 36) + `"`
+}
+
+// etagMatch reports whether the If-None-Match request header matches the given etag.
+// Comparison is weak as described in RFC 9110, section 8.8.3.2.
+func etagMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cacheHeaders returns response options which allow clients to cache
+// the response, but force them to revalidate it on each request.
+func cacheHeaders(etag string) []respond.Option {
+	return []respond.Option{
+		respond.WithHeader("ETag", etag),
+		respond.WithHeader("Cache-Control", "no-cache"),
+	}
+}
+
+// decodeRequest decodes the request body into the message using protobuf JSON mapping.
+func decodeRequest(r *http.Request, msg proto.Message) error {
+	if err := pqjson.Decode(r.Body, msg); err != nil {
+		return fmt.Errorf("%w: %w", errkit.ErrInvalidArgument, err)
+	}
+
+	return nil
+}
+
+// respondProto writes the message to the response using protobuf JSON mapping.
+func respondProto(w http.ResponseWriter, r *http.Request, msg proto.Message, options ...respond.Option) {
+	data, marshalErr := pqjson.Marshal(msg)
+	if marshalErr != nil {
+		respond.ErrorHTTP(w, r, fmt.Errorf("marshal response: %w", marshalErr))
+		return
+	}
+
+	respond.JSON(w, r, json.RawMessage(data), options...)
+}
+
+func dropPolicyToString(policy v1.EvictionPolicy) string {
+	switch policy {
+	case v1.EvictionPolicy_EVICTION_POLICY_DROP:
+		return "Drop Message"
+
+	case v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER:
+		return "Dead Letter Queue"
+
+	case v1.EvictionPolicy_EVICTION_POLICY_ARCHIVE:
+		return "Archive"
 
 	default:
 		return ""
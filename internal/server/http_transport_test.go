@@ -1 +1,224 @@
 package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+	"github.com/plainq/servekit/errkit"
+)
+
+func Test_etagMatch(t *testing.T) {
+	type tcase struct {
+		header string
+		etag   string
+		want   bool
+	}
+
+	tests := map[string]tcase{
+		"Empty":    {header: "", etag: `W/"a-1"`, want: false},
+		"Exact":    {header: `W/"a-1"`, etag: `W/"a-1"`, want: true},
+		"Strong":   {header: `"a-1"`, etag: `W/"a-1"`, want: true},
+		"Wildcard": {header: "*", etag: `W/"a-1"`, want: true},
+		"List":     {header: `W/"a-0", W/"a-1"`, etag: `W/"a-1"`, want: true},
+		"Mismatch": {header: `W/"a-0"`, etag: `W/"a-1"`, want: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/queue", nil)
+			if tc.header != "" {
+				r.Header.Set("If-None-Match", tc.header)
+			}
+
+			td.Cmp(t, etagMatch(r, tc.etag), tc.want)
+		})
+	}
+}
+
+func TestPlainQ_listQueuesHandler_NotModified(t *testing.T) {
+	pq := PlainQ{
+		storage: &mockStorage{propsVersion: 42},
+		epoch:   "a",
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/queue", nil)
+	r.Header.Set("If-None-Match", pq.queuePropsETag())
+
+	w := httptest.NewRecorder()
+	pq.listQueuesHandler(w, r)
+
+	td.Cmp(t, w.Code, http.StatusNotModified)
+	td.Cmp(t, w.Header().Get("ETag"), `W/"a-16"`)
+	td.Cmp(t, w.Body.Len(), 0)
+}
+
+func TestPlainQ_listQueuesHandler_stats(t *testing.T) {
+	type tcase struct {
+		query    string
+		want     bool
+		wantETag bool
+	}
+
+	tests := map[string]tcase{
+		"None":          {query: "", want: false, wantETag: true},
+		"Stats":         {query: "stats=true", want: true},
+		"Depth":         {query: "depth=true", want: true},
+		"DepthNotStats": {query: "depth=true&stats=false", want: true},
+		"StatsNotDepth": {query: "depth=false&stats=true", want: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got *v1.ListQueuesRequest
+
+			pq := PlainQ{
+				storage: &mockStorage{
+					listQueuesFunc: func(_ context.Context, input *v1.ListQueuesRequest) (*v1.ListQueuesResponse, error) {
+						got = input
+						return &v1.ListQueuesResponse{}, nil
+					},
+				},
+				epoch: "a",
+			}
+
+			w := httptest.NewRecorder()
+			pq.listQueuesHandler(w, httptest.NewRequest(http.MethodGet, "/api/v1/queue?"+tc.query, nil))
+
+			td.Cmp(t, w.Code, http.StatusOK)
+			td.Cmp(t, got.GetIncludeStats(), tc.want)
+
+			// Listings with stats change with every message, so they aren't revalidated.
+			td.Cmp(t, w.Header().Get("ETag") != "", tc.wantETag)
+		})
+	}
+}
+
+func TestPlainQ_sendHandler(t *testing.T) {
+	type tcase struct {
+		sendErr  error
+		wantCode int
+	}
+
+	tests := map[string]tcase{
+		"OK": {wantCode: http.StatusCreated},
+		"TooLarge": {
+			sendErr: fmt.Errorf("%w: %w", errkit.ErrInvalidArgument,
+				&pqerr.MessageTooLargeError{Size: 11, Limit: 10, Scope: "queue"},
+			),
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+		"QuotaExceeded": {
+			sendErr:  &pqerr.QuotaExceededError{Quota: "bytes", Usage: 100, Requested: 5, Limit: 100},
+			wantCode: http.StatusTooManyRequests,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got *v1.SendRequest
+
+			pq := PlainQ{storage: &mockStorage{
+				sendFunc: func(_ context.Context, input *v1.SendRequest) (*v1.SendResponse, error) {
+					got = input
+					return &v1.SendResponse{MessageIds: []string{"m1"}}, tc.sendErr
+				},
+			}}
+
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("id", "CSGE6N05SHOB6TB8V5FG")
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/queue/CSGE6N05SHOB6TB8V5FG/messages",
+				strings.NewReader(`{"messages":[{"body":"aGVsbG8="}]}`),
+			)
+			r.Header.Set("Content-Type", "application/json")
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+
+			w := httptest.NewRecorder()
+			pq.sendHandler(w, r)
+
+			td.Cmp(t, w.Code, tc.wantCode)
+			td.Cmp(t, got.GetQueueId(), "CSGE6N05SHOB6TB8V5FG")
+			td.Cmp(t, got.GetMessages(), td.Len(1))
+		})
+	}
+}
+
+func TestPlainQ_queryTelemetryHandler(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type tcase struct {
+		url      string
+		history  bool
+		want     telemetry.Query
+		wantCode int
+		wantBody string
+	}
+
+	tests := map[string]tcase{
+		"OK": {
+			url:     "/api/v1/telemetry/query?metric=messages_sent_total&queue=CSGE6N05SHOB6TB8V5FG&from=2024-01-01T00:00:00Z&to=2024-01-01T01:00:00Z&step=1m",
+			history: true,
+			want: telemetry.Query{
+				Metric: "messages_sent_total", QueueID: "CSGE6N05SHOB6TB8V5FG",
+				From: from, To: from.Add(time.Hour), Step: time.Minute,
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"metrics":[{"name":"messages_sent_total","labels":{"queue":"CSGE6N05SHOB6TB8V5FG"},` +
+				`"values":[{"timestamp":"2024-01-01T00:00:00Z","value":5}]}]}`,
+		},
+		"DefaultRange": {
+			url:     "/api/v1/telemetry/query?metric=queues_exist&to=2024-01-01T01:00:00Z",
+			history: true,
+			want: telemetry.Query{
+				Metric: "queues_exist", From: from, To: from.Add(time.Hour), Step: time.Hour / 500,
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"metrics":[{"name":"messages_sent_total","labels":{"queue":"CSGE6N05SHOB6TB8V5FG"},` +
+				`"values":[{"timestamp":"2024-01-01T00:00:00Z","value":5}]}]}`,
+		},
+		"NotObserved":  {url: "/api/v1/telemetry/query?metric=go_goroutines", history: true, wantCode: http.StatusBadRequest},
+		"InvalidQueue": {url: "/api/v1/telemetry/query?metric=queues_exist&queue=nope", history: true, wantCode: http.StatusBadRequest},
+		"InvalidFrom":  {url: "/api/v1/telemetry/query?metric=queues_exist&from=yesterday", history: true, wantCode: http.StatusBadRequest},
+		"InvalidStep":  {url: "/api/v1/telemetry/query?metric=queues_exist&step=often", history: true, wantCode: http.StatusBadRequest},
+		"Unavailable":  {url: "/api/v1/telemetry/query?metric=queues_exist", wantCode: http.StatusServiceUnavailable},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var pq PlainQ
+
+			if tc.history {
+				pq.history = &mockQuerier{
+					queryFunc: func(_ context.Context, q telemetry.Query) ([]telemetry.Metric, error) {
+						td.Cmp(t, q, tc.want)
+
+						return []telemetry.Metric{{
+							Name:   "messages_sent_total",
+							Labels: telemetry.Labels{{Key: "queue", Value: "CSGE6N05SHOB6TB8V5FG"}},
+							Values: []telemetry.Datapoint{{Timestamp: from, Value: 5}},
+						}}, nil
+					},
+				}
+			}
+
+			w := httptest.NewRecorder()
+			pq.queryTelemetryHandler(w, httptest.NewRequest(http.MethodGet, tc.url, nil))
+
+			td.Cmp(t, w.Code, tc.wantCode)
+
+			if tc.wantBody != "" {
+				td.Cmp(t, strings.TrimSpace(w.Body.String()), tc.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Package identity provides identities of authenticated clients,
+// which permissions of roles are applied to, and maps
+// client certificates of mutual TLS to identities.
+package identity
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/plainq/servekit/errkit"
+)
+
+// Authentication methods of identities.
+const (
+	MethodMTLS   = "mtls"
+	MethodJWT    = "jwt"
+	MethodAPIKey = "api_key"
+)
+
+// Identity represents an authenticated client.
+type Identity struct {
+	// Name is the plainq identity name, e.g. "orders-service".
+	Name string
+
+	// Method is the authentication method, e.g. MethodMTLS.
+	Method string
+}
+
+type ctxKey struct{}
+
+// WithIdentity returns a copy of the ctx which holds the identity.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the identity held by the ctx.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(ctxKey{}).(Identity)
+	return id, ok
+}
+
+// Kinds of certificate attributes matched by the CertMapping.
+const (
+	matchCN    = "cn"
+	matchDNS   = "dns"
+	matchURI   = "uri"
+	matchEmail = "email"
+)
+
+// CertMapping maps client certificates to identities by the subject
+// common name or subject alternative names. The zero CertMapping maps
+// certificates to identities named after the subject common name.
+type CertMapping struct {
+	names map[string]string
+}
+
+// ParseCertMapping parses and validates the JSON mapping document, which is
+// an object with certificate attributes as keys and identity names as values, e.g.
+// {"cn:orders": "orders-service", "uri:spiffe://acme/billing": "billing", "dns:worker.acme.io": "worker"}.
+// Supported attributes are: cn, dns, uri and email.
+func ParseCertMapping(document []byte) (*CertMapping, error) {
+	var raw map[string]string
+
+	if err := json.Unmarshal(document, &raw); err != nil {
+		return nil, fmt.Errorf("%w: parse certificate mapping: %w", errkit.ErrInvalidArgument, err)
+	}
+
+	m := CertMapping{names: make(map[string]string, len(raw))}
+
+	for key, name := range raw {
+		kind, value, ok := strings.Cut(key, ":")
+		kind = strings.ToLower(kind)
+
+		switch {
+		case !ok || value == "":
+			return nil, fmt.Errorf("%w: certificate mapping key %q should be in form: kind:value",
+				errkit.ErrInvalidArgument, key,
+			)
+
+		case kind != matchCN && kind != matchDNS && kind != matchURI && kind != matchEmail:
+			return nil, fmt.Errorf("%w: certificate mapping key %q: unknown kind %q, should be one of: cn, dns, uri, email",
+				errkit.ErrInvalidArgument, key, kind,
+			)
+
+		case name == "":
+			return nil, fmt.Errorf("%w: certificate mapping key %q: empty identity", errkit.ErrInvalidArgument, key)
+		}
+
+		m.names[kind+":"+value] = name
+	}
+
+	return &m, nil
+}
+
+// Identity returns the identity name of the client certificate. Subject alternative
+// names are matched before the subject common name, in order of appearance.
+func (m *CertMapping) Identity(cert *x509.Certificate) (string, bool) {
+	if m == nil || m.names == nil {
+		return cert.Subject.CommonName, cert.Subject.CommonName != ""
+	}
+
+	keys := make([]string, 0, len(cert.URIs)+len(cert.DNSNames)+len(cert.EmailAddresses)+1)
+
+	for _, u := range cert.URIs {
+		keys = append(keys, matchURI+":"+u.String())
+	}
+
+	for _, n := range cert.DNSNames {
+		keys = append(keys, matchDNS+":"+n)
+	}
+
+	for _, e := range cert.EmailAddresses {
+		keys = append(keys, matchEmail+":"+e)
+	}
+
+	keys = append(keys, matchCN+":"+cert.Subject.CommonName)
+
+	for _, k := range keys {
+		if name, ok := m.names[k]; ok {
+			return name, true
+		}
+	}
+
+	return "", false
+}
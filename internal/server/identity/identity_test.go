@@ -0,0 +1,108 @@
+package identity
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/errkit"
+)
+
+func TestParseCertMapping(t *testing.T) {
+	tests := map[string]string{
+		"NotJSON":       `[`,
+		"NoKind":        `{"orders": "orders-service"}`,
+		"NoValue":       `{"cn:": "orders-service"}`,
+		"UnknownKind":   `{"ip:10.0.0.1": "orders-service"}`,
+		"EmptyIdentity": `{"cn:orders": ""}`,
+	}
+
+	for name, document := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseCertMapping([]byte(document))
+			td.Cmp(t, errors.Is(err, errkit.ErrInvalidArgument), true)
+		})
+	}
+}
+
+func TestCertMapping_Identity(t *testing.T) {
+	spiffe, parseErr := url.Parse("spiffe://acme/billing")
+	td.CmpNoError(t, parseErr)
+
+	mapping, mappingErr := ParseCertMapping([]byte(`{
+		"CN:orders": "orders-service",
+		"uri:spiffe://acme/billing": "billing",
+		"dns:worker.acme.io": "worker",
+		"email:ops@acme.io": "ops"
+	}`))
+	td.CmpNoError(t, mappingErr)
+
+	type tcase struct {
+		mapping *CertMapping
+		cert    x509.Certificate
+		want    string
+		wantOK  bool
+	}
+
+	tests := map[string]tcase{
+		"CommonName": {
+			mapping: mapping,
+			cert:    x509.Certificate{Subject: pkix.Name{CommonName: "orders"}},
+			want:    "orders-service",
+			wantOK:  true,
+		},
+		"URIBeforeCommonName": {
+			mapping: mapping,
+			cert:    x509.Certificate{Subject: pkix.Name{CommonName: "orders"}, URIs: []*url.URL{spiffe}},
+			want:    "billing",
+			wantOK:  true,
+		},
+		"DNS": {
+			mapping: mapping,
+			cert:    x509.Certificate{DNSNames: []string{"other.acme.io", "worker.acme.io"}},
+			want:    "worker",
+			wantOK:  true,
+		},
+		"Email": {
+			mapping: mapping,
+			cert:    x509.Certificate{EmailAddresses: []string{"ops@acme.io"}},
+			want:    "ops",
+			wantOK:  true,
+		},
+		"NotMapped": {
+			mapping: mapping,
+			cert:    x509.Certificate{Subject: pkix.Name{CommonName: "intruder"}},
+		},
+		"DefaultCommonName": {
+			cert:   x509.Certificate{Subject: pkix.Name{CommonName: "intruder"}},
+			want:   "intruder",
+			wantOK: true,
+		},
+		"DefaultNoCommonName": {
+			cert: x509.Certificate{DNSNames: []string{"worker.acme.io"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := tc.mapping.Identity(&tc.cert)
+			td.Cmp(t, ok, tc.wantOK)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	td.Cmp(t, ok, false)
+
+	id := Identity{Name: "orders-service", Method: MethodMTLS}
+
+	got, ok := FromContext(WithIdentity(context.Background(), id))
+	td.Cmp(t, ok, true)
+	td.Cmp(t, got, id)
+}
@@ -0,0 +1,151 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/plainq/plainq/internal/server/auth"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/rbac"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/servekit/errkit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodOperations maps methods to operations on the queue of the request which
+// they perform. Methods which are not listed require the rbac.AdminRole.
+var methodOperations = map[string]rbac.Operation{
+	v1.PlainQService_Send_FullMethodName:             rbac.OpSend,
+	v1.PlainQService_Receive_FullMethodName:          rbac.OpReceive,
+	v1.PlainQService_Delete_FullMethodName:           rbac.OpReceive,
+	v1.PlainQService_ChangeVisibility_FullMethodName: rbac.OpReceive,
+	v1.PlainQService_GetMessage_FullMethodName:       rbac.OpReceive,
+	v1.PlainQService_PurgeQueue_FullMethodName:       rbac.OpPurge,
+	v1.PlainQService_DeleteQueue_FullMethodName:      rbac.OpDelete,
+	v1.PlainQService_DescribeQueue_FullMethodName:    rbac.OpDescribe,
+	v1.PlainQService_AdviseQueue_FullMethodName:      rbac.OpDescribe,
+	v1.PlainQService_QueueStats_FullMethodName:       rbac.OpDescribe,
+	v1.PlainQService_SearchMessages_FullMethodName:   rbac.OpDescribe,
+	v1.PlainQService_PeekMessages_FullMethodName:     rbac.OpDescribe,
+	v1.PlainQService_UpdateQueue_FullMethodName:      rbac.OpUpdate,
+	v1.PlainQService_SetQueueState_FullMethodName:    rbac.OpUpdate,
+	v1.PlainQService_StartGenerator_FullMethodName:   rbac.OpUpdate,
+	v1.PlainQService_TransferQueue_FullMethodName:    rbac.OpUpdate,
+
+	v1.PlainQService_ListArchivedMessages_FullMethodName:    rbac.OpDescribe,
+	v1.PlainQService_RestoreArchivedMessages_FullMethodName: rbac.OpSend,
+	v1.PlainQService_ListMessageEvents_FullMethodName:       rbac.OpDescribe,
+	v1.PlainQService_ExtendVisibilityBatch_FullMethodName:   rbac.OpReceive,
+
+	v1.PlainQService_ListQueues_FullMethodName:          auth.OpAuthenticated,
+	v1.PlainQService_Search_FullMethodName:              auth.OpAuthenticated,
+	v1.PlainQService_ListAlerts_FullMethodName:          auth.OpAuthenticated,
+	v1.PlainQService_AcceptQueueTransfer_FullMethodName: auth.OpAuthenticated,
+	v1.PlainQService_CancelQueueTransfer_FullMethodName: auth.OpAuthenticated,
+	v1.PlainQService_GetJob_FullMethodName:              auth.OpAuthenticated,
+	v1.PlainQService_ListJobs_FullMethodName:            auth.OpAuthenticated,
+	v1.PlainQService_CancelJob_FullMethodName:           auth.OpAuthenticated,
+	v1.PlainQService_GetAccount_FullMethodName:          auth.OpAuthenticated,
+
+	// Transactions span queues, so the server authorizes each send and delete of the transaction.
+	v1.PlainQService_Transact_FullMethodName: auth.OpAuthenticated,
+
+	// Clients ping the server with the health check.
+	grpc_health_v1.Health_Check_FullMethodName: auth.OpAuthenticated,
+}
+
+// Auth authenticates clients by bearer tokens or API keys of the "authorization" metadata,
+// unless they have been authenticated by client certificates, and checks that
+// roles of the client are granted the operation on the queue of the request.
+func Auth(authn *auth.Authenticator, granter auth.Granter, observer telemetry.Observer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		authCtx, id, err := authenticateToken(ctx, authn, observer)
+		if err != nil {
+			return nil, err
+		}
+
+		var queueID string
+		if r, ok := req.(interface{ GetQueueId() string }); ok {
+			queueID = r.GetQueueId()
+		}
+
+		op, ok := methodOperations[info.FullMethod]
+		if !ok {
+			op = auth.OpAdmin
+		}
+
+		if err := authorize(ctx, granter, observer, id, queueID, op); err != nil {
+			return nil, err
+		}
+
+		return handler(authCtx, req)
+	}
+}
+
+// AuthStream is the Auth for streaming RPCs, which require the rbac.AdminRole.
+func AuthStream(authn *auth.Authenticator, granter auth.Granter, observer telemetry.Observer) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		authCtx, id, err := authenticateToken(ss.Context(), authn, observer)
+		if err != nil {
+			return err
+		}
+
+		if err := authorize(authCtx, granter, observer, id, "", auth.OpAdmin); err != nil {
+			return err
+		}
+
+		return handler(srv, &identityStream{ServerStream: ss, ctx: authCtx})
+	}
+}
+
+// authenticateToken returns the context with the identity of the client.
+func authenticateToken(ctx context.Context, authn *auth.Authenticator, observer telemetry.Observer) (context.Context, identity.Identity, error) {
+	if id, ok := identity.FromContext(ctx); ok {
+		return ctx, id, nil
+	}
+
+	var authorization string
+	if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) > 0 {
+		authorization = values[0]
+	}
+
+	id, err := authn.Authenticate(ctx, authorization)
+	if err != nil {
+		reason := auth.Reason(err)
+
+		observer.AuthFailures(reason).Inc()
+
+		if !errors.Is(err, auth.ErrMissingCredentials) {
+			observer.TokenErrors(reason).Inc()
+		}
+
+		return nil, identity.Identity{}, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return identity.WithIdentity(ctx, id), id, nil
+}
+
+// authorize checks that the client is granted the operation on the queue.
+func authorize(ctx context.Context, granter auth.Granter, observer telemetry.Observer, id identity.Identity, queueID string, op rbac.Operation) error {
+	err := auth.Authorize(ctx, granter, id.Name, queueID, op)
+
+	switch {
+	case err == nil:
+		return nil
+
+	case errors.Is(err, auth.ErrPermissionDenied):
+		observer.AuthDenials(queueID, string(op)).Inc()
+		return status.Error(codes.PermissionDenied, err.Error())
+
+	case errors.Is(err, errkit.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
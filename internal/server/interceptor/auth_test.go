@@ -0,0 +1,142 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/auth"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/rbac"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/servekit/errkit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type mockGranter map[string]*rbac.Grants
+
+func (m mockGranter) QueueGrants(_ context.Context, subject, queueID string) (*rbac.Grants, error) {
+	if queueID == "missing" {
+		return nil, fmt.Errorf("%w: queue not found", errkit.ErrNotFound)
+	}
+
+	if grants, ok := m[subject]; ok {
+		return grants, nil
+	}
+
+	return &rbac.Grants{}, nil
+}
+
+func TestAuth(t *testing.T) {
+	secret := []byte("secret")
+
+	authn, authnErr := auth.New(auth.Config{JWT: auth.JWTConfig{Secret: secret}})
+	td.Require(t).CmpNoError(authnErr)
+
+	withToken := func(subject string, key []byte) context.Context {
+		token, err := auth.SignJWT(auth.Claims{Subject: subject, ExpiresAt: time.Now().Add(time.Hour).Unix()}, key)
+		td.Require(t).CmpNoError(err)
+
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	}
+
+	granter := mockGranter{
+		"admin":    {Admin: true},
+		"producer": {Operations: []rbac.Operation{rbac.OpSend}},
+	}
+
+	type tcase struct {
+		ctx      context.Context
+		method   string
+		req      any
+		want     string
+		wantCode codes.Code
+	}
+
+	tests := map[string]tcase{
+		"Granted": {
+			ctx:    withToken("producer", secret),
+			method: v1.PlainQService_Send_FullMethodName,
+			req:    &v1.SendRequest{QueueId: "orders"},
+			want:   "producer",
+		},
+		"Denied": {
+			ctx:      withToken("producer", secret),
+			method:   v1.PlainQService_PurgeQueue_FullMethodName,
+			req:      &v1.PurgeQueueRequest{QueueId: "orders"},
+			wantCode: codes.PermissionDenied,
+		},
+		"DescribeByGrant": {
+			ctx:    withToken("producer", secret),
+			method: v1.PlainQService_DescribeQueue_FullMethodName,
+			req:    &v1.DescribeQueueRequest{QueueId: "orders"},
+			want:   "producer",
+		},
+		"AdminOnly": {
+			ctx:      withToken("producer", secret),
+			method:   v1.PlainQService_SetLogLevels_FullMethodName,
+			req:      &v1.SetLogLevelsRequest{},
+			wantCode: codes.PermissionDenied,
+		},
+		"Admin": {
+			ctx:    withToken("admin", secret),
+			method: v1.PlainQService_SetLogLevels_FullMethodName,
+			req:    &v1.SetLogLevelsRequest{},
+			want:   "admin",
+		},
+		"Authenticated": {
+			ctx:    withToken("nobody", secret),
+			method: v1.PlainQService_ListQueues_FullMethodName,
+			req:    &v1.ListQueuesRequest{},
+			want:   "nobody",
+		},
+		"UnknownQueue": {
+			ctx:      withToken("producer", secret),
+			method:   v1.PlainQService_Send_FullMethodName,
+			req:      &v1.SendRequest{QueueId: "missing"},
+			wantCode: codes.NotFound,
+		},
+		"NoToken": {
+			ctx:      context.Background(),
+			method:   v1.PlainQService_ListQueues_FullMethodName,
+			req:      &v1.ListQueuesRequest{},
+			wantCode: codes.Unauthenticated,
+		},
+		"InvalidToken": {
+			ctx:      withToken("admin", []byte("other")),
+			method:   v1.PlainQService_ListQueues_FullMethodName,
+			req:      &v1.ListQueuesRequest{},
+			wantCode: codes.Unauthenticated,
+		},
+		"ClientCertificate": {
+			ctx:    identity.WithIdentity(context.Background(), identity.Identity{Name: "producer", Method: identity.MethodMTLS}),
+			method: v1.PlainQService_Send_FullMethodName,
+			req:    &v1.SendRequest{QueueId: "orders"},
+			want:   "producer",
+		},
+	}
+
+	intercept := Auth(authn, granter, telemetry.NewObserver())
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+
+			_, err := intercept(tc.ctx, tc.req, &grpc.UnaryServerInfo{FullMethod: tc.method}, func(ctx context.Context, _ any) (any, error) {
+				id, _ := identity.FromContext(ctx)
+				got = id.Name
+
+				return nil, nil
+			})
+
+			td.Cmp(t, status.Code(err), tc.wantCode)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package interceptor
+
+import (
+	"context"
+
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ClientCert authenticates clients by verified TLS client certificates,
+// and puts identities mapped from certificates to the request context.
+func ClientCert(mapping *identity.CertMapping, observer telemetry.Observer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		authCtx, err := authenticateCert(ctx, mapping, observer)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(authCtx, req)
+	}
+}
+
+// ClientCertStream is the ClientCert for streaming RPCs.
+func ClientCertStream(mapping *identity.CertMapping, observer telemetry.Observer) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		authCtx, err := authenticateCert(ss.Context(), mapping, observer)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &identityStream{ServerStream: ss, ctx: authCtx})
+	}
+}
+
+func authenticateCert(ctx context.Context, mapping *identity.CertMapping, observer telemetry.Observer) (context.Context, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		observer.AuthFailures(telemetry.ReasonMissingCredentials).Inc()
+		return nil, status.Error(codes.Unauthenticated, "client certificate is required")
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		observer.AuthFailures(telemetry.ReasonMissingCredentials).Inc()
+		return nil, status.Error(codes.Unauthenticated, "client certificate is required")
+	}
+
+	name, ok := mapping.Identity(info.State.VerifiedChains[0][0])
+	if !ok {
+		observer.AuthFailures(telemetry.ReasonInvalidCredentials).Inc()
+		return nil, status.Error(codes.Unauthenticated, "client certificate is not mapped to an identity")
+	}
+
+	return identity.WithIdentity(ctx, identity.Identity{Name: name, Method: identity.MethodMTLS}), nil
+}
+
+// identityStream overrides the context of the stream with the authenticated one.
+type identityStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *identityStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,76 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientCert(t *testing.T) {
+	mapping, mappingErr := identity.ParseCertMapping([]byte(`{"cn:orders": "orders-service"}`))
+	td.CmpNoError(t, mappingErr)
+
+	withCert := func(cn string) context.Context {
+		cert := x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+
+		return peer.NewContext(context.Background(), &peer.Peer{
+			AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{&cert}},
+			}},
+		})
+	}
+
+	type tcase struct {
+		ctx      context.Context
+		want     string
+		wantCode codes.Code
+	}
+
+	tests := map[string]tcase{
+		"Mapped": {
+			ctx:  withCert("orders"),
+			want: "orders-service",
+		},
+		"NotMapped": {
+			ctx:      withCert("intruder"),
+			wantCode: codes.Unauthenticated,
+		},
+		"NoPeer": {
+			ctx:      context.Background(),
+			wantCode: codes.Unauthenticated,
+		},
+		"NoCertificate": {
+			ctx:      peer.NewContext(context.Background(), &peer.Peer{}),
+			wantCode: codes.Unauthenticated,
+		},
+	}
+
+	intercept := ClientCert(mapping, telemetry.NewObserver())
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+
+			_, err := intercept(tc.ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+				id, _ := identity.FromContext(ctx)
+				got = id.Name
+
+				return nil, nil
+			})
+
+			td.Cmp(t, status.Code(err), tc.wantCode)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Leadership tells whether the server is the leader of the cluster,
+// which is the only server to change the database.
+type Leadership interface {
+	IsLeader() bool
+	Leader() string
+}
+
+// Leader rejects calls of methods which change the database with codes.Unavailable
+// while the server follows another one, so clients retry them on the leader of the cluster.
+// Methods of other services, e.g. the health check, are passed through.
+func Leader(l Leadership) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		service := "/" + v1.PlainQService_ServiceDesc.ServiceName + "/"
+
+		if strings.HasPrefix(info.FullMethod, service) && !readMethods[info.FullMethod] && !l.IsLeader() {
+			if leader := l.Leader(); leader != "" {
+				return nil, status.Errorf(codes.Unavailable,
+					"%s is served by the leader of the cluster, call the server %q", info.FullMethod, leader,
+				)
+			}
+
+			return nil, status.Errorf(codes.Unavailable,
+				"%s is served by the leader of the cluster, which is being elected", info.FullMethod,
+			)
+		}
+
+		return handler(ctx, req)
+	}
+}
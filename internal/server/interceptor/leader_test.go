@@ -0,0 +1,71 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type leadership struct {
+	leader bool
+	id     string
+}
+
+func (l leadership) IsLeader() bool { return l.leader }
+func (l leadership) Leader() string { return l.id }
+
+func TestLeader(t *testing.T) {
+	type tcase struct {
+		leadership leadership
+		method     string
+		wantCode   codes.Code
+	}
+
+	tests := map[string]tcase{
+		"LeaderWrite": {
+			leadership: leadership{leader: true, id: "node0"},
+			method:     v1.PlainQService_Send_FullMethodName,
+			wantCode:   codes.OK,
+		},
+		"FollowerRead": {
+			leadership: leadership{id: "node0"},
+			method:     v1.PlainQService_ClusterStatus_FullMethodName,
+			wantCode:   codes.OK,
+		},
+		"FollowerWrite": {
+			leadership: leadership{id: "node0"},
+			method:     v1.PlainQService_Receive_FullMethodName,
+			wantCode:   codes.Unavailable,
+		},
+		"NoLeader": {
+			method:   v1.PlainQService_Send_FullMethodName,
+			wantCode: codes.Unavailable,
+		},
+		"HealthCheck": {
+			method:   grpc_health_v1.Health_Check_FullMethodName,
+			wantCode: codes.OK,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var called bool
+
+			_, err := Leader(tc.leadership)(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: tc.method},
+				func(context.Context, any) (any, error) {
+					called = true
+					return nil, nil
+				},
+			)
+
+			td.Cmp(t, status.Code(err), tc.wantCode)
+			td.Cmp(t, called, tc.wantCode == codes.OK)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/ratelimit"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RateLimit rejects calls which exceed rate limits with RESOURCE_EXHAUSTED and the retry-after header.
+// Clients are identified by the authenticated identity, or by the peer address when there is none.
+// Calls of methods which requests have a queue id are subject to limits of the queue,
+// and transactions are subject to limits of each queue they send to or delete from.
+func RateLimit(limiter *ratelimit.Limiter, observer telemetry.Observer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		err := limiter.Allow(ctx, callClient(ctx), requestQueues(req)...)
+		if err == nil {
+			return handler(ctx, req)
+		}
+
+		var limited *ratelimit.LimitedError
+		if !errors.As(err, &limited) {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		observer.RateLimited(limited.Scope).Inc()
+
+		// The header is best effort, the status is what tells the caller to back off.
+		_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.FormatInt(limited.RetryAfterSeconds(), 10)))
+
+		return nil, status.Error(codes.ResourceExhausted, limited.Error())
+	}
+}
+
+// requestQueues returns identifiers of queues the request operates on.
+func requestQueues(req any) []string {
+	switch r := req.(type) {
+	case *v1.TransactRequest:
+		queueIDs := make([]string, 0, len(r.GetSends())+len(r.GetDeletes()))
+
+		for _, send := range r.GetSends() {
+			queueIDs = append(queueIDs, send.GetQueueId())
+		}
+
+		for _, del := range r.GetDeletes() {
+			queueIDs = append(queueIDs, del.GetQueueId())
+		}
+
+		return queueIDs
+
+	case interface{ GetQueueId() string }:
+		return []string{r.GetQueueId()}
+
+	default:
+		return nil
+	}
+}
+
+// callClient returns the name of the client which made the call.
+func callClient(ctx context.Context) string {
+	if id, ok := identity.FromContext(ctx); ok {
+		return id.Name
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
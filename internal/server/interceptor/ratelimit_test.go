@@ -0,0 +1,62 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/ratelimit"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimit(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Config{
+		Clients: map[string]ratelimit.Limit{"orders": {Rate: 1, Burst: 1}},
+		Queue: func(_ context.Context, queueID string) ratelimit.Limit {
+			if queueID == "q1" {
+				return ratelimit.Limit{Rate: 1, Burst: 1}
+			}
+
+			return ratelimit.Limit{}
+		},
+	})
+
+	intercept := RateLimit(limiter, telemetry.NewObserver())
+	info := &grpc.UnaryServerInfo{FullMethod: "/plainq.v1.PlainQService/Send"}
+	handler := func(context.Context, any) (any, error) { return nil, nil }
+
+	ordersCtx := identity.WithIdentity(context.Background(), identity.Identity{Name: "orders"})
+
+	// Cases run in order, since each of them takes tokens.
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		req      any
+		wantCode codes.Code
+	}{
+		{name: "QueueAllowed", ctx: context.Background(), req: &v1.SendRequest{QueueId: "q1"}, wantCode: codes.OK},
+		{name: "QueueLimited", ctx: context.Background(), req: &v1.SendRequest{QueueId: "q1"}, wantCode: codes.ResourceExhausted},
+		{name: "ClientAllowed", ctx: ordersCtx, req: &v1.ListQueuesRequest{}, wantCode: codes.OK},
+		{name: "ClientLimited", ctx: ordersCtx, req: &v1.ListQueuesRequest{}, wantCode: codes.ResourceExhausted},
+		{name: "Anonymous", ctx: context.Background(), req: &v1.ListQueuesRequest{}, wantCode: codes.OK},
+		{name: "TransactAllowed", ctx: context.Background(), req: &v1.TransactRequest{
+			Sends: []*v1.SendRequest{{QueueId: "q2"}},
+		}, wantCode: codes.OK},
+		{name: "TransactLimited", ctx: context.Background(), req: &v1.TransactRequest{
+			Sends:   []*v1.SendRequest{{QueueId: "q2"}},
+			Deletes: []*v1.DeleteRequest{{QueueId: "q1"}},
+		}, wantCode: codes.ResourceExhausted},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := intercept(tc.ctx, tc.req, info, handler)
+			td.Cmp(t, status.Code(err), tc.wantCode)
+		})
+	}
+}
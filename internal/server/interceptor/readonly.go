@@ -0,0 +1,57 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// readMethods holds methods which don't change the database,
+// so they are served by read-only replicas and followers of the cluster.
+var readMethods = map[string]bool{
+	v1.PlainQService_ListQueues_FullMethodName:           true,
+	v1.PlainQService_DescribeQueue_FullMethodName:        true,
+	v1.PlainQService_AdviseQueue_FullMethodName:          true,
+	v1.PlainQService_QueueStats_FullMethodName:           true,
+	v1.PlainQService_SearchMessages_FullMethodName:       true,
+	v1.PlainQService_PeekMessages_FullMethodName:         true,
+	v1.PlainQService_GetMessage_FullMethodName:           true,
+	v1.PlainQService_ListArchivedMessages_FullMethodName: true,
+	v1.PlainQService_ListMessageEvents_FullMethodName:    true,
+	v1.PlainQService_Search_FullMethodName:               true,
+	v1.PlainQService_ListAuditEvents_FullMethodName:      true,
+	v1.PlainQService_ListAlertRules_FullMethodName:       true,
+	v1.PlainQService_ListAlerts_FullMethodName:           true,
+	v1.PlainQService_ListServiceAccounts_FullMethodName:  true,
+	v1.PlainQService_ListAPIKeys_FullMethodName:          true,
+	v1.PlainQService_ListQueuePermissions_FullMethodName: true,
+	v1.PlainQService_ListRoles_FullMethodName:            true,
+	v1.PlainQService_GetAccount_FullMethodName:           true,
+	v1.PlainQService_GetJob_FullMethodName:               true,
+	v1.PlainQService_ListJobs_FullMethodName:             true,
+	v1.PlainQService_ListBreakers_FullMethodName:         true,
+	v1.PlainQService_ListGenerators_FullMethodName:       true,
+	v1.PlainQService_GetLogLevels_FullMethodName:         true,
+	v1.PlainQService_ClusterStatus_FullMethodName:        true,
+}
+
+// ReadOnly rejects calls of methods which change the database with codes.FailedPrecondition,
+// since the read-only replica can't serve them and they should be sent to the primary server.
+// Methods of other services, e.g. the health check, are passed through.
+func ReadOnly() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		service := "/" + v1.PlainQService_ServiceDesc.ServiceName + "/"
+
+		if strings.HasPrefix(info.FullMethod, service) && !readMethods[info.FullMethod] {
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"%s is not served by the read-only replica, call the primary server", info.FullMethod,
+			)
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,45 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestReadOnly(t *testing.T) {
+	intercept := ReadOnly()
+
+	type tcase struct {
+		method   string
+		wantCode codes.Code
+	}
+
+	tests := map[string]tcase{
+		"Read":        {method: v1.PlainQService_PeekMessages_FullMethodName, wantCode: codes.OK},
+		"Write":       {method: v1.PlainQService_Send_FullMethodName, wantCode: codes.FailedPrecondition},
+		"Receive":     {method: v1.PlainQService_Receive_FullMethodName, wantCode: codes.FailedPrecondition},
+		"HealthCheck": {method: grpc_health_v1.Health_Check_FullMethodName, wantCode: codes.OK},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var called bool
+
+			_, err := intercept(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: tc.method},
+				func(context.Context, any) (any, error) {
+					called = true
+					return nil, nil
+				},
+			)
+
+			td.Cmp(t, status.Code(err), tc.wantCode)
+			td.Cmp(t, called, tc.wantCode == codes.OK)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package interceptor
+
+import (
+	"context"
+	"path"
+
+	"github.com/plainq/plainq/internal/server/timeout"
+	"google.golang.org/grpc"
+)
+
+// Timeout limits the time of calls of methods which have timeouts. Methods
+// are matched by full names, e.g. "/plainq.v1.PlainQService/Send", or short names, e.g. "Send".
+func Timeout(timeouts timeout.Timeouts) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		d, ok := timeouts.Lookup(info.FullMethod, path.Base(info.FullMethod))
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+
+		return handler(ctx, req)
+	}
+}
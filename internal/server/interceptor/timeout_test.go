@@ -0,0 +1,41 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/timeout"
+	"google.golang.org/grpc"
+)
+
+func TestTimeout(t *testing.T) {
+	intercept := Timeout(timeout.Timeouts{"Send": time.Second})
+
+	type tcase struct {
+		method       string
+		wantDeadline bool
+	}
+
+	tests := map[string]tcase{
+		"WithTimeout":    {method: "/plainq.v1.PlainQService/Send", wantDeadline: true},
+		"WithoutTimeout": {method: "/plainq.v1.PlainQService/Receive"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var hasDeadline bool
+
+			_, err := intercept(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: tc.method},
+				func(ctx context.Context, _ any) (any, error) {
+					_, hasDeadline = ctx.Deadline()
+					return nil, nil
+				},
+			)
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, hasDeadline, tc.wantDeadline)
+		})
+	}
+}
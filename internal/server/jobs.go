@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/plainq/plainq/internal/server/auth"
+	"github.com/plainq/plainq/internal/server/rbac"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+)
+
+// getJob returns the background job, which requires the describe permission on the queue of the job.
+func (s *PlainQ) getJob(ctx context.Context, input *v1.GetJobRequest) (*v1.GetJobResponse, error) {
+	if err := validateJobID(input.GetJobId()); err != nil {
+		return nil, err
+	}
+
+	output, getErr := s.storage.GetJob(ctx, input)
+	if getErr != nil {
+		return nil, fmt.Errorf("get job (id: %q): %w", input.GetJobId(), getErr)
+	}
+
+	if err := s.authorizeQueue(ctx, output.GetJob().GetQueueId(), rbac.OpDescribe); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// jobOperations maps kinds of background jobs to operations on the queue which
+// are required to cancel them. Jobs of unknown kinds are canceled by admins only.
+var jobOperations = map[string]rbac.Operation{
+	"purge": rbac.OpPurge,
+}
+
+// listJobs returns background jobs of the queue, which requires the describe permission on the queue.
+// Listing jobs of all queues requires the admin role.
+func (s *PlainQ) listJobs(ctx context.Context, input *v1.ListJobsRequest) (*v1.ListJobsResponse, error) {
+	op := rbac.OpDescribe
+
+	if input.GetQueueId() == "" {
+		op = auth.OpAdmin
+	} else if err := validateQueueIDFromRequest(input); err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeQueue(ctx, input.GetQueueId(), op); err != nil {
+		return nil, err
+	}
+
+	output, listErr := s.storage.ListJobs(ctx, input)
+	if listErr != nil {
+		return nil, fmt.Errorf("list jobs: %w", listErr)
+	}
+
+	return output, nil
+}
+
+// cancelJob stops the running background job, which requires
+// the permission of the operation the job performs on its queue.
+func (s *PlainQ) cancelJob(ctx context.Context, input *v1.CancelJobRequest) (*v1.CancelJobResponse, error) {
+	job, getErr := s.getJob(ctx, &v1.GetJobRequest{JobId: input.GetJobId()})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	op, ok := jobOperations[job.GetJob().GetKind()]
+	if !ok {
+		op = auth.OpAdmin
+	}
+
+	if err := s.authorizeQueue(ctx, job.GetJob().GetQueueId(), op); err != nil {
+		return nil, err
+	}
+
+	output, cancelErr := s.storage.CancelJob(ctx, input)
+	if cancelErr != nil {
+		return nil, fmt.Errorf("cancel job (id: %q): %w", input.GetJobId(), cancelErr)
+	}
+
+	return output, nil
+}
+
+// validateJobID checks that the job identifier is a valid XID.
+func validateJobID(jobID string) error {
+	if err := idkit.ValidateXID(strings.ToLower(jobID)); err != nil {
+		return fmt.Errorf("%w: invalid job id %q", errkit.ErrInvalidArgument, jobID)
+	}
+
+	return nil
+}
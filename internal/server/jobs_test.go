@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/rbac"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+)
+
+func TestPlainQ_getJob(t *testing.T) {
+	type tcase struct {
+		subject string
+		jobID   string
+		wantErr error
+	}
+
+	jobID, queueID := idkit.XID(), idkit.XID()
+
+	tests := map[string]tcase{
+		"OK":           {subject: "bob@example.com", jobID: jobID},
+		"NoPermission": {subject: "alice@example.com", jobID: jobID, wantErr: errkit.ErrUnauthorized},
+		"UnknownJob":   {subject: "bob@example.com", jobID: idkit.XID(), wantErr: errkit.ErrNotFound},
+		"InvalidJobID": {subject: "bob@example.com", jobID: "invalid", wantErr: errkit.ErrInvalidArgument},
+	}
+
+	server := PlainQ{
+		observer: telemetry.NewObserver(),
+		storage: &mockStorage{
+			getJobFunc: func(_ context.Context, input *v1.GetJobRequest) (*v1.GetJobResponse, error) {
+				if input.GetJobId() != jobID {
+					return nil, errkit.ErrNotFound
+				}
+
+				return &v1.GetJobResponse{Job: &v1.Job{JobId: jobID, QueueId: queueID}}, nil
+			},
+			queueGrantsFunc: func(_ context.Context, subject, _ string) (*rbac.Grants, error) {
+				if subject == "alice@example.com" {
+					return &rbac.Grants{}, nil
+				}
+
+				return &rbac.Grants{Operations: []rbac.Operation{rbac.OpDescribe}}, nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := identity.WithIdentity(context.Background(), identity.Identity{Name: tc.subject})
+
+			output, err := server.getJob(ctx, &v1.GetJobRequest{JobId: tc.jobID})
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output.GetJob().GetJobId(), tc.jobID)
+		})
+	}
+}
+
+func TestPlainQ_listJobs(t *testing.T) {
+	type tcase struct {
+		subject string
+		queueID string
+		wantErr error
+	}
+
+	queueID := idkit.XID()
+
+	tests := map[string]tcase{
+		"QueueJobs":        {subject: "bob@example.com", queueID: queueID},
+		"QueueJobsDenied":  {subject: "alice@example.com", queueID: queueID, wantErr: errkit.ErrUnauthorized},
+		"AllJobsOfAdmin":   {subject: "admin@example.com"},
+		"AllJobsOfViewer":  {subject: "bob@example.com", wantErr: errkit.ErrUnauthorized},
+		"InvalidQueueID":   {subject: "bob@example.com", queueID: "invalid", wantErr: pqerr.ErrInvalidID},
+		"AllJobsNoAuthn":   {},
+		"QueueJobsNoAuthn": {queueID: queueID},
+	}
+
+	server := PlainQ{
+		observer: telemetry.NewObserver(),
+		storage: &mockStorage{
+			listJobsFunc: func(_ context.Context, input *v1.ListJobsRequest) (*v1.ListJobsResponse, error) {
+				return &v1.ListJobsResponse{Jobs: []*v1.Job{{JobId: idkit.XID(), QueueId: input.GetQueueId()}}}, nil
+			},
+			queueGrantsFunc: func(_ context.Context, subject, _ string) (*rbac.Grants, error) {
+				switch subject {
+				case "admin@example.com":
+					return &rbac.Grants{Admin: true}, nil
+
+				case "bob@example.com":
+					return &rbac.Grants{Operations: []rbac.Operation{rbac.OpDescribe}}, nil
+
+				default:
+					return &rbac.Grants{}, nil
+				}
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.subject != "" {
+				ctx = identity.WithIdentity(ctx, identity.Identity{Name: tc.subject})
+			}
+
+			output, err := server.listJobs(ctx, &v1.ListJobsRequest{QueueId: tc.queueID})
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output.GetJobs(), td.Len(1))
+		})
+	}
+}
+
+func TestPlainQ_cancelJob(t *testing.T) {
+	type tcase struct {
+		subject string
+		jobID   string
+		wantErr error
+	}
+
+	purgeJobID, otherJobID, queueID := idkit.XID(), idkit.XID(), idkit.XID()
+
+	tests := map[string]tcase{
+		"PurgeOfOperator":  {subject: "operator@example.com", jobID: purgeJobID},
+		"PurgeOfViewer":    {subject: "viewer@example.com", jobID: purgeJobID, wantErr: errkit.ErrUnauthorized},
+		"UnknownKind":      {subject: "operator@example.com", jobID: otherJobID, wantErr: errkit.ErrUnauthorized},
+		"UnknownKindAdmin": {subject: "admin@example.com", jobID: otherJobID},
+		"UnknownJob":       {subject: "operator@example.com", jobID: idkit.XID(), wantErr: errkit.ErrNotFound},
+		"InvalidJobID":     {subject: "operator@example.com", jobID: "invalid", wantErr: errkit.ErrInvalidArgument},
+	}
+
+	jobs := map[string]*v1.Job{
+		purgeJobID: {JobId: purgeJobID, Kind: "purge", QueueId: queueID},
+		otherJobID: {JobId: otherJobID, Kind: "export", QueueId: queueID},
+	}
+
+	server := PlainQ{
+		observer: telemetry.NewObserver(),
+		storage: &mockStorage{
+			getJobFunc: func(_ context.Context, input *v1.GetJobRequest) (*v1.GetJobResponse, error) {
+				job, ok := jobs[input.GetJobId()]
+				if !ok {
+					return nil, errkit.ErrNotFound
+				}
+
+				return &v1.GetJobResponse{Job: job}, nil
+			},
+			cancelJobFunc: func(_ context.Context, input *v1.CancelJobRequest) (*v1.CancelJobResponse, error) {
+				return &v1.CancelJobResponse{Job: &v1.Job{JobId: input.GetJobId(), State: v1.JobState_JOB_STATE_CANCELED}}, nil
+			},
+			queueGrantsFunc: func(_ context.Context, subject, _ string) (*rbac.Grants, error) {
+				switch subject {
+				case "admin@example.com":
+					return &rbac.Grants{Admin: true}, nil
+
+				case "operator@example.com":
+					return &rbac.Grants{Operations: []rbac.Operation{rbac.OpDescribe, rbac.OpPurge}}, nil
+
+				default:
+					return &rbac.Grants{Operations: []rbac.Operation{rbac.OpDescribe}}, nil
+				}
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := identity.WithIdentity(context.Background(), identity.Identity{Name: tc.subject})
+
+			output, err := server.cancelJob(ctx, &v1.CancelJobRequest{JobId: tc.jobID})
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output.GetJob().GetState(), v1.JobState_JOB_STATE_CANCELED)
+		})
+	}
+}
@@ -0,0 +1,362 @@
+// Package lease elects the active server among servers sharing the storage database.
+//
+// Servers compete for the lease, which is the row of the database table holding the name
+// of the holder and the time the lease expires. The holder renews the lease and serves
+// writes, while others stand by, serving reads, and take the lease over once it expires.
+// The holder stops serving writes well before the lease expires unless it has renewed it,
+// so servers never write at the same time as long as their clocks are synchronized.
+package lease
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultTTL is the default time the lease is held for without renewals.
+	defaultTTL = 15 * time.Second
+
+	// leaseName is the name of the lease of the active server.
+	leaseName = "active"
+)
+
+const (
+	// queryAcquire takes the lease unless it's held by another server until now.
+	queryAcquire = `insert into leases (name, holder, expires_at) values (?, ?, ?)
+		on conflict (name) do update set holder = excluded.holder, expires_at = excluded.expires_at
+		where leases.holder = excluded.holder or leases.expires_at <= ?;`
+
+	// querySelectHolder selects the holder of the lease, which might have expired.
+	querySelectHolder = `select holder, expires_at from leases where name = ?;`
+
+	// queryRelease expires the lease of the holder, so others take it over right away.
+	queryRelease = `update leases set expires_at = 0 where name = ? and holder = ?;`
+)
+
+// Config is the configuration of the lease.
+type Config struct {
+	// Holder identifies the server among servers sharing the database.
+	Holder string
+
+	// TTL is the time the lease is held for without renewals.
+	TTL time.Duration
+
+	// RenewInterval is the interval between renewals of the lease,
+	// as well as attempts to take it over, a third of the TTL by default.
+	RenewInterval time.Duration
+
+	// Logger is the logger of the lease.
+	Logger *slog.Logger
+}
+
+// Lease competes for the lease of the active server.
+type Lease struct {
+	db     *sql.DB
+	holder string
+	ttl    time.Duration
+	renew  time.Duration
+	logger *slog.Logger
+
+	// now returns the current time, which tests move forward.
+	now func() time.Time
+
+	// mu guards the state of the lease, and orders changes of the leadership.
+	mu sync.Mutex
+
+	// until is the time the server stops serving writes unless it renews the lease,
+	// which is zero unless the server holds the lease.
+	until time.Time
+
+	// leader is the holder of the lease as seen by the last attempt to take it.
+	leader string
+
+	onLead []func(ctx context.Context) error
+
+	// leadCtx is canceled once the server loses the lease.
+	leadCtx    context.Context
+	leadCancel context.CancelFunc
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// New starts competing for the lease in the database of db, which must have the leases table.
+func New(cfg Config, db *sql.DB) (*Lease, error) {
+	l, err := newLease(cfg, db, time.Now)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	l.stop = stop
+
+	// The first attempt is made right away, so the server which starts
+	// first doesn't wait for the interval to become the active one.
+	l.acquire(ctx)
+
+	l.wg.Add(1)
+	go l.run(ctx)
+
+	return l, nil
+}
+
+func newLease(cfg Config, db *sql.DB, now func() time.Time) (*Lease, error) {
+	if cfg.Holder == "" {
+		return nil, errors.New("lease holder is required")
+	}
+
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+
+	renew := cfg.RenewInterval
+	if renew <= 0 {
+		renew = ttl / 3
+	}
+
+	if renew >= ttl/2 {
+		return nil, fmt.Errorf("lease renew interval %s should be less than half of the TTL %s", renew, ttl)
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	l := Lease{
+		db:         db,
+		holder:     cfg.Holder,
+		ttl:        ttl,
+		renew:      renew,
+		logger:     logger,
+		now:        now,
+		leadCancel: func() {},
+		stop:       func() {},
+	}
+
+	return &l, nil
+}
+
+// IsLeader reports whether the server holds the lease, so it's the active one.
+func (l *Lease) IsLeader() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.held(l.now())
+}
+
+// Leader returns the holder of the lease, or an empty string when the lease is not held.
+func (l *Lease) Leader() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.leader
+}
+
+// OnLead registers the function which is called each time the server takes the lease,
+// and right away when it holds the lease already. Functions are called in the order they
+// are registered, with the context which is canceled once the server loses the lease.
+func (l *Lease) OnLead(fn func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.onLead = append(l.onLead, fn)
+
+	if l.held(l.now()) {
+		ctx := l.leadCtx
+
+		l.wg.Add(1)
+
+		go func() {
+			defer l.wg.Done()
+			l.prepare(ctx, fn)
+		}()
+	}
+}
+
+// Health reports the role of the server and the holder of the lease, it implements the health.CheckFunc.
+func (l *Lease) Health(context.Context) (map[string]any, error) {
+	role := "standby"
+	if l.IsLeader() {
+		role = "active"
+	}
+
+	return map[string]any{"role": role, "leader": l.Leader()}, nil
+}
+
+// Close stops competing for the lease and releases it, so another server takes it over right away.
+func (l *Lease) Close() error {
+	l.stop()
+
+	l.mu.Lock()
+	held := !l.until.IsZero()
+	l.follow()
+	l.mu.Unlock()
+
+	l.wg.Wait()
+
+	if !held {
+		return nil
+	}
+
+	if _, err := l.db.Exec(queryRelease, leaseName, l.holder); err != nil {
+		return fmt.Errorf("release lease: %w", err)
+	}
+
+	return nil
+}
+
+// run renews the lease or attempts to take it over every renew interval.
+func (l *Lease) run(ctx context.Context) {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(l.renew)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			l.acquire(ctx)
+		}
+	}
+}
+
+// acquire renews the lease or takes it over once it has expired.
+func (l *Lease) acquire(ctx context.Context) {
+	// The lease is held locally for the TTL from the moment before the attempt,
+	// less the renew interval, which covers clock differences and stalls.
+	start := l.now()
+
+	res, execErr := l.db.ExecContext(ctx, queryAcquire, leaseName, l.holder, start.Add(l.ttl).UnixMilli(), start.UnixMilli())
+
+	var taken bool
+
+	if execErr == nil {
+		n, affectedErr := res.RowsAffected()
+		execErr = affectedErr
+		taken = n > 0
+	}
+
+	leader := l.holder
+
+	if execErr == nil && !taken {
+		leader, execErr = l.selectHolder(ctx, start)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	if execErr != nil {
+		l.logger.Error("Failed to acquire the lease",
+			slog.String("error", execErr.Error()),
+		)
+
+		// The lease is kept until it runs out, since the database might be busy for a while.
+		if !l.until.IsZero() && !l.held(l.now()) {
+			l.logger.Warn("Lease has run out, standing by")
+			l.follow()
+		}
+
+		return
+	}
+
+	l.leader = leader
+
+	if !taken {
+		if !l.until.IsZero() {
+			l.logger.Warn("Lease has been taken over by another server, standing by",
+				slog.String("holder", leader),
+			)
+
+			l.follow()
+		}
+
+		return
+	}
+
+	renewed := !l.until.IsZero()
+	l.until = start.Add(l.ttl - l.renew)
+
+	if !renewed {
+		l.lead(ctx)
+	}
+}
+
+// selectHolder returns the holder of the lease, or an empty string when it has expired.
+func (l *Lease) selectHolder(ctx context.Context, now time.Time) (string, error) {
+	var (
+		holder    string
+		expiresAt int64
+	)
+
+	if err := l.db.QueryRowContext(ctx, querySelectHolder, leaseName).Scan(&holder, &expiresAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("select lease holder: %w", err)
+	}
+
+	if expiresAt <= now.UnixMilli() {
+		return "", nil
+	}
+
+	return holder, nil
+}
+
+// held reports whether the server holds the lease at the time.
+// The caller must hold the lock.
+func (l *Lease) held(now time.Time) bool {
+	return !l.until.IsZero() && now.Before(l.until)
+}
+
+// lead calls functions registered by OnLead once the server has taken the lease.
+// The caller must hold the lock.
+func (l *Lease) lead(ctx context.Context) {
+	l.leadCtx, l.leadCancel = context.WithCancel(ctx)
+
+	l.logger.Info("Lease has been taken, serving as the active server")
+
+	ctx = l.leadCtx
+	onLead := slices.Clone(l.onLead)
+
+	l.wg.Add(1)
+
+	go func() {
+		defer l.wg.Done()
+
+		for _, fn := range onLead {
+			l.prepare(ctx, fn)
+		}
+	}()
+}
+
+// follow stops serving writes by the server.
+// The caller must hold the lock.
+func (l *Lease) follow() {
+	l.until = time.Time{}
+	l.leadCancel()
+}
+
+// prepare calls the function registered by OnLead.
+func (l *Lease) prepare(ctx context.Context, fn func(ctx context.Context) error) {
+	if err := fn(ctx); err != nil && ctx.Err() == nil {
+		l.logger.Error("Failed to prepare the active server",
+			slog.String("error", err.Error()),
+		)
+	}
+}
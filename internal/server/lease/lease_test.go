@@ -0,0 +1,161 @@
+package lease
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+// clock is the time which is moved forward by tests.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "plainq.db")+"?_journal=WAL")
+	td.Require(t).CmpNoError(err)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, execErr := db.Exec(`create table leases
+		(
+			name       text    not null primary key,
+			holder     text    not null,
+			expires_at integer not null
+		);`)
+	td.Require(t).CmpNoError(execErr)
+
+	return db
+}
+
+func TestLease(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	c := clock{now: time.Date(2026, 10, 17, 7, 0, 0, 0, time.UTC)}
+
+	cfg := Config{TTL: 15 * time.Second, RenewInterval: 5 * time.Second}
+
+	cfg.Holder = "a"
+	a, aErr := newLease(cfg, db, c.Now)
+	td.Require(t).CmpNoError(aErr)
+
+	cfg.Holder = "b"
+	b, bErr := newLease(cfg, db, c.Now)
+	td.Require(t).CmpNoError(bErr)
+
+	led := make(chan string, 2)
+
+	for _, l := range []*Lease{a, b} {
+		l.OnLead(func(context.Context) error {
+			led <- l.holder
+			return nil
+		})
+	}
+
+	// The first server takes the lease, and the second one stands by.
+	a.acquire(ctx)
+	b.acquire(ctx)
+
+	td.Cmp(t, <-led, "a")
+	td.CmpTrue(t, a.IsLeader())
+	td.CmpFalse(t, b.IsLeader())
+	td.Cmp(t, b.Leader(), "a")
+
+	health, healthErr := b.Health(ctx)
+	td.CmpNoError(t, healthErr)
+	td.Cmp(t, health, map[string]any{"role": "standby", "leader": "a"})
+
+	// The holder keeps the lease as long as it renews it.
+	c.Add(5 * time.Second)
+	a.acquire(ctx)
+	b.acquire(ctx)
+
+	td.CmpTrue(t, a.IsLeader())
+	td.CmpFalse(t, b.IsLeader())
+
+	// The holder which has failed to renew the lease stops serving writes before it expires.
+	c.Add(10 * time.Second)
+	td.CmpFalse(t, a.IsLeader())
+
+	b.acquire(ctx)
+	td.CmpFalse(t, b.IsLeader())
+
+	// The lease which has expired is taken over.
+	c.Add(5 * time.Second)
+	b.acquire(ctx)
+
+	td.Cmp(t, <-led, "b")
+	td.CmpTrue(t, b.IsLeader())
+
+	a.acquire(ctx)
+	td.CmpFalse(t, a.IsLeader())
+	td.Cmp(t, a.Leader(), "b")
+
+	// The released lease is taken over right away.
+	td.CmpNoError(t, b.Close())
+	td.CmpFalse(t, b.IsLeader())
+
+	a.acquire(ctx)
+
+	td.Cmp(t, <-led, "a")
+	td.CmpTrue(t, a.IsLeader())
+	td.CmpNoError(t, a.Close())
+}
+
+func TestLease_OnLead(t *testing.T) {
+	db := openTestDB(t)
+
+	l, err := New(Config{Holder: "a"}, db)
+	td.Require(t).CmpNoError(err)
+
+	t.Cleanup(func() { _ = l.Close() })
+
+	td.CmpTrue(t, l.IsLeader())
+
+	called := make(chan struct{})
+
+	// Functions registered after the lease has been taken are called right away.
+	l.OnLead(func(ctx context.Context) error {
+		close(called)
+		return nil
+	})
+
+	select {
+	case <-called:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the function has not been called")
+	}
+}
+
+func TestNew(t *testing.T) {
+	db := openTestDB(t)
+
+	_, holderErr := New(Config{}, db)
+	td.CmpString(t, holderErr, "lease holder is required")
+
+	_, renewErr := New(Config{Holder: "a", TTL: 10 * time.Second, RenewInterval: 5 * time.Second}, db)
+	td.CmpString(t, renewErr, "lease renew interval 5s should be less than half of the TTL 10s")
+}
@@ -0,0 +1,429 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/go-chi/chi/v5"
+	"github.com/plainq/plainq/internal/server/accesslog"
+	"github.com/plainq/plainq/internal/server/config"
+	"github.com/plainq/plainq/internal/server/health"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/interceptor"
+	"github.com/plainq/plainq/internal/server/logging"
+	"github.com/plainq/plainq/internal/server/middleware"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/plainq/internal/server/timeout"
+	"github.com/plainq/servekit"
+	"github.com/plainq/servekit/httpkit"
+	"github.com/plainq/servekit/logkit"
+	"github.com/plainq/servekit/respond"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// shutdownTimeout limits the graceful shutdown of listeners.
+const shutdownTimeout = 5 * time.Second
+
+// listener serves requests of the HTTP or gRPC server,
+// over TLS when the TLS configuration is given.
+type listener struct {
+	name   string
+	logger *slog.Logger
+	ln     net.Listener
+
+	// serve serves requests from the listener until shutdown is called.
+	serve    func(l net.Listener) error
+	shutdown func(ctx context.Context) error
+}
+
+// Serve serves requests until the ctx is done, then shuts down gracefully.
+func (l *listener) Serve(ctx context.Context) error {
+	g, _ := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		<-ctx.Done()
+
+		l.logger.Info("Shutting down the listener",
+			slog.String("address", l.ln.Addr().String()),
+		)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := l.shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("%w: %s", servekit.ErrGracefullyShutdown, err.Error())
+		}
+
+		return nil
+	})
+
+	g.Go(func() error {
+		l.logger.Info(l.name+" listener started to listen",
+			slog.String("address", l.ln.Addr().String()),
+		)
+
+		if err := l.serve(l.ln); err != nil {
+			return fmt.Errorf("%s listener failed: %w", l.name, err)
+		}
+
+		return nil
+	})
+
+	return g.Wait()
+}
+
+// httpListener holds the router of the HTTP listener.
+type httpListener struct {
+	listener
+
+	router chi.Router
+}
+
+// MountGroup mounts routes created by fn under the route.
+func (l *httpListener) MountGroup(route string, fn func(r chi.Router)) { l.router.Route(route, fn) }
+
+// newAccessLog returns the access log middleware configured by the cfg.
+// Records are written by the HTTP logger, in JSON when it's configured.
+func newAccessLog(cfg *config.Config, loggers *logging.Loggers) (func(next http.Handler) http.Handler, error) {
+	fields, fieldsErr := accesslog.ParseFields(cfg.LogAccessFields)
+	if fieldsErr != nil {
+		return nil, fmt.Errorf("access log: %w", fieldsErr)
+	}
+
+	sampling, samplingErr := accesslog.ParseSampling(cfg.LogAccessSampling)
+	if samplingErr != nil {
+		return nil, fmt.Errorf("access log: %w", samplingErr)
+	}
+
+	logger := loggers.Logger(logging.HTTP)
+
+	switch cfg.LogAccessFormat {
+	case "", "text":
+
+	case "json":
+		if cfg.LogEnable {
+			// Records are filtered by the HTTP logger level.
+			logger = loggers.LoggerTo(logging.HTTP, logkit.New(logkit.WithLevel(slog.LevelDebug), logkit.WithJSON()))
+		}
+
+	default:
+		return nil, fmt.Errorf("access log: unknown format %q, should be one of: text, json", cfg.LogAccessFormat)
+	}
+
+	return middleware.AccessLog(logger, middleware.AccessLogConfig{Fields: fields, Sampling: sampling}), nil
+}
+
+// listenerHTTP creates the HTTP listener. Requests of health and metrics
+// routes are logged by the accessLog when their logs are enabled.
+func listenerHTTP(cfg *config.Config, logger *slog.Logger, accessLog func(next http.Handler) http.Handler, checker *health.Checker, tlsConfig *tls.Config) (*httpListener, error) {
+	router := chi.NewRouter()
+
+	timeouts, timeoutsErr := timeout.Parse(cfg.HTTPTimeouts)
+	if timeoutsErr != nil {
+		return nil, fmt.Errorf("HTTP timeouts: %w", timeoutsErr)
+	}
+
+	if len(timeouts) > 0 {
+		router.Use(middleware.Timeout(router, timeouts))
+	}
+
+	if cfg.HealthEnable {
+		if !strings.HasPrefix(cfg.HealthRoute, "/") {
+			return nil, fmt.Errorf("invalid health route: %q (route should start with '/' slash)", cfg.HealthRoute)
+		}
+
+		router.Route(cfg.HealthRoute, func(route chi.Router) {
+			if cfg.HealthRouteLogs {
+				route.Use(accessLog)
+			}
+
+			if cfg.HealthRouteMetrics {
+				route.Use(httpkit.MetricsMiddleware())
+			}
+
+			// The root route reports the readiness, as it did before
+			// the liveness and readiness have been distinguished.
+			route.Get("/", healthHandler(checker, true))
+			route.Head("/", healthHandler(checker, true))
+			route.Get("/ready", healthHandler(checker, true))
+			route.Head("/ready", healthHandler(checker, true))
+			route.Get("/live", healthHandler(checker, false))
+			route.Head("/live", healthHandler(checker, false))
+		})
+	}
+
+	if cfg.MetricsEnable {
+		if !strings.HasPrefix(cfg.MetricsRoute, "/") {
+			return nil, fmt.Errorf("invalid metrics route: %q (route should start with '/' slash)", cfg.MetricsRoute)
+		}
+
+		router.Route(cfg.MetricsRoute, func(m chi.Router) {
+			if cfg.MetricsRouteLogs {
+				m.Use(accessLog)
+			}
+
+			if cfg.MetricsRouteMetrics {
+				m.Use(httpkit.MetricsMiddleware())
+			}
+
+			m.Get("/", func(w http.ResponseWriter, _ *http.Request) { metrics.WritePrometheus(w, true) })
+		})
+	}
+
+	ln, listenErr := net.Listen("tcp", cfg.HTTPAddr)
+	if listenErr != nil {
+		return nil, fmt.Errorf("create HTTP listener: %w", listenErr)
+	}
+
+	server := http.Server{
+		Handler:           router,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		TLSConfig:         tlsConfig,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+
+	l := httpListener{
+		listener: listener{
+			name:   "HTTP",
+			logger: logger,
+			ln:     ln,
+			serve: func(ln net.Listener) error {
+				var serveErr error
+
+				if tlsConfig != nil {
+					// The certificate is provided by the TLS configuration.
+					serveErr = server.ServeTLS(ln, "", "")
+				} else {
+					serveErr = server.Serve(ln)
+				}
+
+				if errors.Is(serveErr, http.ErrServerClosed) {
+					return nil
+				}
+
+				return serveErr
+			},
+			shutdown: server.Shutdown,
+		},
+		router: router,
+	}
+
+	if tlsConfig != nil {
+		l.name = "HTTPS"
+	}
+
+	return &l, nil
+}
+
+// healthHandler responds with the readiness of the server checked by the checker,
+// or with its liveness, which needs no checks. With the verbose query parameter
+// the response has the JSON body with statuses of checked components.
+func healthHandler(checker *health.Checker, readiness bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		verbose, _ := strconv.ParseBool(r.URL.Query().Get("verbose"))
+
+		report := health.Report{Status: health.StatusUp}
+
+		if readiness {
+			report = checker.Report(r.Context())
+		} else if verbose {
+			report.Components = checker.Report(r.Context()).Components
+		}
+
+		code := http.StatusOK
+		if !report.Ready() {
+			code = http.StatusServiceUnavailable
+		}
+
+		if !verbose {
+			if code != http.StatusOK {
+				http.Error(w, http.StatusText(code), code)
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(code)
+
+			return
+		}
+
+		respond.JSON(w, r, report, respond.WithStatus(code))
+	}
+}
+
+// listenerProfiler creates the dedicated listener which serves the profiler under /debug/pprof,
+// so profiles are never exposed by the public HTTP listener. Requests are guarded by the
+// bearer token, which is required unless the listener is bound to the loopback address.
+func listenerProfiler(cfg *config.Config, logger *slog.Logger) (*listener, error) {
+	if cfg.ProfilerToken == "" && !isLoopback(cfg.ProfilerAddr) {
+		return nil, fmt.Errorf("profiler token should be set to listen on non-loopback address %q", cfg.ProfilerAddr)
+	}
+
+	router := chi.NewRouter()
+
+	if cfg.ProfilerToken != "" {
+		router.Use(middleware.BearerToken(cfg.ProfilerToken))
+	}
+
+	router.Mount("/debug", middleware.Profiler())
+
+	ln, listenErr := net.Listen("tcp", cfg.ProfilerAddr)
+	if listenErr != nil {
+		return nil, fmt.Errorf("create profiler listener: %w", listenErr)
+	}
+
+	server := http.Server{
+		Handler:           router,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+
+	l := listener{
+		name:   "Profiler",
+		logger: logger,
+		ln:     ln,
+		serve: func(ln net.Listener) error {
+			// Profiles and traces take longer than API requests,
+			// so the write timeout of the HTTP server isn't applied.
+			if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+
+			return nil
+		},
+		shutdown: func(ctx context.Context) error {
+			// Profiles which are still being captured, or connections which have not sent
+			// a request yet, are closed instead of holding the shutdown up.
+			if err := server.Shutdown(ctx); err != nil {
+				return errors.Join(err, server.Close())
+			}
+
+			return nil
+		},
+	}
+
+	return &l, nil
+}
+
+// isLoopback reports whether the host of the address is the loopback one.
+func isLoopback(addr string) bool {
+	host, _, splitErr := net.SplitHostPort(addr)
+	if splitErr != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+func listenerGRPC(cfg *config.Config, logger *slog.Logger, tlsConfig *tls.Config, options ...grpc.ServerOption) (*listener, *grpc.Server, error) {
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	ln, listenErr := net.Listen("tcp", cfg.GRPCAddr)
+	if listenErr != nil {
+		return nil, nil, fmt.Errorf("create gRPC listener: %w", listenErr)
+	}
+
+	server := grpc.NewServer(options...)
+
+	l := listener{
+		name:   "gRPC",
+		logger: logger,
+		ln:     ln,
+		serve: func(ln net.Listener) error {
+			if err := server.Serve(ln); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+				return err
+			}
+
+			return nil
+		},
+		shutdown: func(ctx context.Context) error {
+			done := make(chan struct{})
+
+			go func() {
+				server.GracefulStop()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				return nil
+
+			case <-ctx.Done():
+				server.Stop()
+				return fmt.Errorf("shutdown gRPC listener: %w", ctx.Err())
+			}
+		},
+	}
+
+	return &l, server, nil
+}
+
+// clientCertAuth makes the TLS configuration require and verify client certificates
+// signed by the client CA, and returns server options which authenticate clients
+// by identities mapped from their certificates.
+func clientCertAuth(cfg *config.Config, tlsConfig *tls.Config, observer telemetry.Observer) ([]grpc.ServerOption, error) {
+	if tlsConfig == nil {
+		return nil, errors.New("client certificates verification requires TLS certificate and key of the listener")
+	}
+
+	caPEM, readErr := os.ReadFile(cfg.GRPCTLSClientCA)
+	if readErr != nil {
+		return nil, fmt.Errorf("read client CA: %w", readErr)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("client CA %s holds no PEM encoded certificates", cfg.GRPCTLSClientCA)
+	}
+
+	var mapping *identity.CertMapping
+
+	if cfg.GRPCTLSClientIdentities != "" {
+		document, mappingReadErr := os.ReadFile(cfg.GRPCTLSClientIdentities)
+		if mappingReadErr != nil {
+			return nil, fmt.Errorf("read client identities: %w", mappingReadErr)
+		}
+
+		m, parseErr := identity.ParseCertMapping(document)
+		if parseErr != nil {
+			return nil, fmt.Errorf("client identities %s: %w", cfg.GRPCTLSClientIdentities, parseErr)
+		}
+
+		mapping = m
+	}
+
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	tlsConfig.ClientCAs = pool
+
+	options := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(interceptor.ClientCert(mapping, observer)),
+		grpc.ChainStreamInterceptor(interceptor.ClientCertStream(mapping, observer)),
+	}
+
+	return options, nil
+}
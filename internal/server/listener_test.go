@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/config"
+	"github.com/plainq/plainq/internal/server/health"
+	"github.com/plainq/plainq/internal/server/middleware"
+	"github.com/plainq/servekit/logkit"
+)
+
+func TestListenerHTTP_TLS(t *testing.T) {
+	// The test server provides the certificate and the client which trusts it.
+	certServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer certServer.Close()
+
+	cfg := config.Config{
+		HTTPAddr:     "127.0.0.1:0",
+		HealthEnable: true,
+		HealthRoute:  "/health",
+	}
+
+	l, err := listenerHTTP(&cfg, logkit.NewNop(), middleware.AccessLog(logkit.NewNop(), middleware.AccessLogConfig{}), health.New(0), &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: certServer.TLS.Certificates,
+	})
+	td.CmpNoError(t, err)
+	td.Cmp(t, l.name, "HTTPS")
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- l.Serve(ctx) }()
+
+	resp, getErr := certServer.Client().Get("https://" + l.ln.Addr().String() + "/health")
+	td.CmpNoError(t, getErr)
+	td.CmpNoError(t, resp.Body.Close())
+	td.Cmp(t, resp.StatusCode, http.StatusOK)
+
+	cancel()
+	td.CmpNoError(t, <-done)
+}
+
+func TestHealthHandler(t *testing.T) {
+	type tcase struct {
+		readiness  bool
+		target     string
+		storageErr error
+		wantCode   int
+		wantBody   any
+	}
+
+	tests := map[string]tcase{
+		"Ready": {
+			readiness: true,
+			target:    "/health",
+			wantCode:  http.StatusOK,
+			wantBody:  "",
+		},
+		"NotReady": {
+			readiness:  true,
+			target:     "/health",
+			storageErr: errors.New("disk I/O error"),
+			wantCode:   http.StatusServiceUnavailable,
+			wantBody:   td.HasPrefix(http.StatusText(http.StatusServiceUnavailable)),
+		},
+		"NotReadyVerbose": {
+			readiness:  true,
+			target:     "/health?verbose=1",
+			storageErr: errors.New("disk I/O error"),
+			wantCode:   http.StatusServiceUnavailable,
+			wantBody: td.JSON(`{
+				"status": "down",
+				"components": [
+					{"name": "storage", "status": "down", "critical": true, "error": "disk I/O error", "duration": $1},
+					{"name": "storage_wal", "status": "up", "critical": false, "details": {"bytes": 42}, "duration": $1}
+				]
+			}`, td.Re(`^[0-9.]+[nµm]?s$`)),
+		},
+		"LiveVerbose": {
+			target:     "/health/live?verbose=true",
+			storageErr: errors.New("disk I/O error"),
+			wantCode:   http.StatusOK,
+			wantBody: td.SuperMapOf(map[string]any{
+				"status":     "up",
+				"components": td.Len(2),
+			}, nil),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			checker := health.New(time.Second,
+				health.Check{Name: "storage", Critical: true, Func: func(context.Context) (map[string]any, error) {
+					return nil, tc.storageErr
+				}},
+				health.Check{Name: "storage_wal", Func: health.Size(func(context.Context) (int64, error) { return 42, nil }, 0)},
+			)
+
+			w := httptest.NewRecorder()
+			healthHandler(checker, tc.readiness)(w, httptest.NewRequest(http.MethodGet, tc.target, nil))
+
+			td.Cmp(t, w.Code, tc.wantCode)
+
+			if !strings.Contains(tc.target, "verbose") {
+				td.Cmp(t, w.Body.String(), tc.wantBody)
+				return
+			}
+
+			var body any
+
+			td.CmpNoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			td.Cmp(t, body, tc.wantBody)
+		})
+	}
+}
+
+func TestListenerProfiler(t *testing.T) {
+	_, err := listenerProfiler(&config.Config{ProfilerAddr: "0.0.0.0:0"}, logkit.NewNop())
+	td.CmpError(t, err)
+
+	l, err := listenerProfiler(&config.Config{ProfilerAddr: "127.0.0.1:0", ProfilerToken: "s3cret"}, logkit.NewNop())
+	td.CmpNoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- l.Serve(ctx) }()
+
+	url := "http://" + l.ln.Addr().String() + "/debug/pprof/"
+
+	// The dedicated client closes its keep-alive connections before the shutdown.
+	client := http.Client{Transport: &http.Transport{}}
+	t.Cleanup(client.CloseIdleConnections)
+
+	get := func(token string) int {
+		t.Helper()
+
+		req, reqErr := http.NewRequest(http.MethodGet, url, nil)
+		td.Require(t).CmpNoError(reqErr)
+
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, getErr := client.Do(req)
+		td.Require(t).CmpNoError(getErr)
+
+		_, copyErr := io.Copy(io.Discard, resp.Body)
+		td.CmpNoError(t, copyErr)
+		td.CmpNoError(t, resp.Body.Close())
+
+		return resp.StatusCode
+	}
+
+	td.Cmp(t, get(""), http.StatusUnauthorized)
+	td.Cmp(t, get("s3cret"), http.StatusOK)
+
+	client.CloseIdleConnections()
+
+	cancel()
+	td.CmpNoError(t, <-done)
+}
+
+func TestIsLoopback(t *testing.T) {
+	type tcase struct {
+		addr string
+		want bool
+	}
+
+	tests := map[string]tcase{
+		"IPv4":      {addr: "127.0.0.1:6060", want: true},
+		"IPv6":      {addr: "[::1]:6060", want: true},
+		"Localhost": {addr: "localhost:6060", want: true},
+		"Any":       {addr: ":6060", want: false},
+		"Public":    {addr: "10.0.0.5:6060", want: false},
+		"Invalid":   {addr: "6060", want: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, isLoopback(tc.addr), tc.want)
+		})
+	}
+}
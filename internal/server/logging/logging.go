@@ -0,0 +1,163 @@
+// Package logging provides loggers of server subsystems with
+// distinct log levels which can be changed at runtime.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/logkit"
+)
+
+// Subsystems of the server which have their own log levels.
+const (
+	Server    = "server"
+	Storage   = "storage"
+	GC        = "gc"
+	HTTP      = "http"
+	GRPC      = "grpc"
+	Auth      = "auth"
+	Telemetry = "telemetry"
+	Audit     = "audit"
+	Cluster   = "cluster"
+	Backup    = "backup"
+)
+
+// subsystems holds all known subsystems.
+var subsystems = []string{Server, Storage, GC, HTTP, GRPC, Auth, Telemetry, Audit, Cluster, Backup}
+
+// Loggers holds loggers of subsystems. Each subsystem logger writes to
+// the same base logger, but filters records by the subsystem log level.
+type Loggers struct {
+	base   *slog.Logger
+	levels map[string]*slog.LevelVar
+}
+
+// New returns a pointer to a new instance of Loggers. The base logger should
+// not filter records itself, since filtering is done by subsystem loggers.
+// All subsystems start with the given level.
+func New(base *slog.Logger, level slog.Level) *Loggers {
+	l := Loggers{
+		base:   base,
+		levels: make(map[string]*slog.LevelVar, len(subsystems)),
+	}
+
+	for _, s := range subsystems {
+		v := slog.LevelVar{}
+		v.Set(level)
+		l.levels[s] = &v
+	}
+
+	return &l
+}
+
+// Logger returns the logger of the subsystem.
+// Unknown subsystems get the Server level.
+func (l *Loggers) Logger(subsystem string) *slog.Logger {
+	return l.LoggerTo(subsystem, l.base)
+}
+
+// LoggerTo returns the logger of the subsystem which writes to the base
+// instead of the common one, e.g. to write records in another format.
+// The base logger should not filter records itself, as in New.
+func (l *Loggers) LoggerTo(subsystem string, base *slog.Logger) *slog.Logger {
+	level, ok := l.levels[subsystem]
+	if !ok {
+		level = l.levels[Server]
+	}
+
+	h := levelHandler{
+		handler: base.Handler(),
+		level:   level,
+	}
+
+	return slog.New(&h).With(slog.String("subsystem", subsystem))
+}
+
+// Levels returns log level names of all subsystems.
+func (l *Loggers) Levels() map[string]string {
+	levels := make(map[string]string, len(l.levels))
+
+	for s, v := range l.levels {
+		levels[s] = LevelName(v.Level())
+	}
+
+	return levels
+}
+
+// SetLevels validates and changes the log levels of subsystems
+// given as level names by subsystem. Either all levels are changed or none.
+func (l *Loggers) SetLevels(levels map[string]string) error {
+	parsed := make(map[string]slog.Level, len(levels))
+
+	for _, s := range slices.Sorted(maps.Keys(levels)) {
+		if _, ok := l.levels[s]; !ok {
+			return fmt.Errorf("%w: unknown subsystem %q, should be one of: %s",
+				errkit.ErrInvalidArgument, s, strings.Join(subsystems, ", "),
+			)
+		}
+
+		level, parseErr := logkit.ParseLevel(levels[s])
+		if parseErr != nil {
+			return fmt.Errorf("%w: subsystem %q: %w", errkit.ErrInvalidArgument, s, parseErr)
+		}
+
+		parsed[s] = level
+	}
+
+	for s, level := range parsed {
+		l.levels[s].Set(level)
+	}
+
+	return nil
+}
+
+// ParseLevels parses comma separated subsystem levels, e.g. "storage=debug,http=warn".
+func ParseLevels(spec string) (map[string]string, error) {
+	levels := make(map[string]string)
+
+	for pair := range strings.SplitSeq(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		subsystem, level, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid subsystem level %q, should be in form: subsystem=level", pair)
+		}
+
+		levels[strings.TrimSpace(subsystem)] = strings.TrimSpace(level)
+	}
+
+	return levels, nil
+}
+
+// LevelName returns the lowercase name of the level.
+func LevelName(level slog.Level) string { return strings.ToLower(level.String()) }
+
+// levelHandler filters records of the wrapped handler by the level.
+type levelHandler struct {
+	handler slog.Handler
+	level   slog.Leveler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.handler.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{handler: h.handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{handler: h.handler.WithGroup(name), level: h.level}
+}
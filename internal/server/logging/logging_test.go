@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/errkit"
+)
+
+func TestLoggers_Logger(t *testing.T) {
+	var buf bytes.Buffer
+
+	base := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	loggers := New(base, slog.LevelInfo)
+
+	td.CmpNoError(t, loggers.SetLevels(map[string]string{Storage: "debug", HTTP: "error"}))
+
+	loggers.Logger(Storage).Debug("storage debug")
+	loggers.Logger(HTTP).Info("http info")
+	loggers.Logger(HTTP).Error("http error")
+	loggers.Logger(Server).Debug("server debug")
+	loggers.Logger(Server).Info("server info")
+
+	out := buf.String()
+
+	td.Cmp(t, out, td.Contains(`msg="storage debug" subsystem=storage`))
+	td.Cmp(t, out, td.Contains(`msg="http error" subsystem=http`))
+	td.Cmp(t, out, td.Contains(`msg="server info" subsystem=server`))
+	td.Cmp(t, out, td.Not(td.Contains("http info")))
+	td.Cmp(t, out, td.Not(td.Contains("server debug")))
+}
+
+func TestLoggers_SetLevels(t *testing.T) {
+	tests := map[string]struct {
+		levels  map[string]string
+		want    map[string]string
+		wantErr bool
+	}{
+		"Valid": {
+			levels: map[string]string{GC: "debug", Auth: "WARN"},
+			want: map[string]string{
+				Server: "info", Storage: "info", GC: "debug", HTTP: "info",
+				GRPC: "info", Auth: "warn", Telemetry: "info", Audit: "info",
+				Cluster: "info", Backup: "info",
+			},
+		},
+		"UnknownSubsystem": {
+			levels:  map[string]string{GC: "debug", "queue": "debug"},
+			wantErr: true,
+		},
+		"InvalidLevel": {
+			levels:  map[string]string{GC: "debug", HTTP: "loud"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			loggers := New(slog.Default(), slog.LevelInfo)
+
+			err := loggers.SetLevels(tc.levels)
+			if tc.wantErr {
+				td.CmpErrorIs(t, err, errkit.ErrInvalidArgument)
+				// Nothing is changed when any of levels is invalid.
+				td.Cmp(t, loggers.Levels()[GC], "info")
+
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, loggers.Levels(), tc.want)
+		})
+	}
+}
+
+func TestParseLevels(t *testing.T) {
+	tests := map[string]struct {
+		spec    string
+		want    map[string]string
+		wantErr bool
+	}{
+		"Empty":    {spec: "", want: map[string]string{}},
+		"Multiple": {spec: "storage=debug, http = warn,", want: map[string]string{"storage": "debug", "http": "warn"}},
+		"Invalid":  {spec: "storage", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseLevels(tc.spec)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
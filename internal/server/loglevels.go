@@ -0,0 +1,23 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+// setLogLevels changes log levels of server subsystems and returns resulting levels.
+func (s *PlainQ) setLogLevels(input *v1.SetLogLevelsRequest) (*v1.SetLogLevelsResponse, error) {
+	if err := s.loggers.SetLevels(input.GetLevels()); err != nil {
+		return nil, fmt.Errorf("set log levels: %w", err)
+	}
+
+	levels := s.loggers.Levels()
+
+	s.logger.Info("Log levels have been changed",
+		slog.Any("levels", levels),
+	)
+
+	return &v1.SetLogLevelsResponse{Levels: levels}, nil
+}
@@ -0,0 +1,18 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+// runMaintenance runs the maintenance operation of the storage database.
+func (s *PlainQ) runMaintenance(ctx context.Context, input *v1.RunMaintenanceRequest) (*v1.RunMaintenanceResponse, error) {
+	output, runErr := s.storage.RunMaintenance(ctx, input)
+	if runErr != nil {
+		return nil, fmt.Errorf("run maintenance: %w", runErr)
+	}
+
+	return output, nil
+}
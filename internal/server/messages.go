@@ -0,0 +1,234 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/plainq/plainq/internal/server/rbac"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+// maxExtendVisibilityEntries represents the maximum number of messages
+// whose visibility timeouts are changed by a single batch.
+const maxExtendVisibilityEntries = 1000
+
+// peekMessages returns messages of the queue without changing their visibility, so Houston
+// can show contents of the queue. Listing messages requires the describe permission,
+// while their bodies require the receive permission, as if they were received.
+func (s *PlainQ) peekMessages(ctx context.Context, input *v1.PeekMessagesRequest) (*v1.PeekMessagesResponse, error) {
+	if err := validateQueueIDFromRequest(input); err != nil {
+		return nil, err
+	}
+
+	if input.GetIncludeBody() {
+		if err := s.authorizeQueue(ctx, input.GetQueueId(), rbac.OpReceive); err != nil {
+			return nil, err
+		}
+	}
+
+	output, peekErr := s.storage.PeekMessages(ctx, input)
+	if peekErr != nil {
+		return nil, fmt.Errorf("peek messages (queue id: %q): %w", input.GetQueueId(), peekErr)
+	}
+
+	return output, nil
+}
+
+// listArchivedMessages returns messages of the queue archive. Bodies of messages
+// require the receive permission on the queue in addition to the describe one.
+func (s *PlainQ) listArchivedMessages(ctx context.Context, input *v1.ListArchivedMessagesRequest) (*v1.ListArchivedMessagesResponse, error) {
+	if err := validateQueueIDFromRequest(input); err != nil {
+		return nil, err
+	}
+
+	if input.GetIncludeBody() {
+		if err := s.authorizeQueue(ctx, input.GetQueueId(), rbac.OpReceive); err != nil {
+			return nil, err
+		}
+	}
+
+	output, listErr := s.storage.ListArchivedMessages(ctx, input)
+	if listErr != nil {
+		return nil, fmt.Errorf("list archived messages (queue id: %q): %w", input.GetQueueId(), listErr)
+	}
+
+	return output, nil
+}
+
+// restoreArchivedMessages moves archived messages back to the queue.
+func (s *PlainQ) restoreArchivedMessages(ctx context.Context, input *v1.RestoreArchivedMessagesRequest) (*v1.RestoreArchivedMessagesResponse, error) {
+	if err := validateQueueIDFromRequest(input); err != nil {
+		return nil, err
+	}
+
+	if len(input.GetMessageIds()) == 0 {
+		return nil, fmt.Errorf("%w: message ids are empty", errkit.ErrInvalidArgument)
+	}
+
+	output, restoreErr := s.storage.RestoreArchivedMessages(ctx, input)
+	if restoreErr != nil {
+		return nil, fmt.Errorf("restore archived messages (queue id: %q): %w", input.GetQueueId(), restoreErr)
+	}
+
+	return output, nil
+}
+
+// getMessage returns the message of the queue by its identifier without changing its visibility,
+// so operators can look into messages reported by consumers.
+func (s *PlainQ) getMessage(ctx context.Context, input *v1.GetMessageRequest) (*v1.GetMessageResponse, error) {
+	if err := validateQueueIDFromRequest(input); err != nil {
+		return nil, err
+	}
+
+	if input.GetMessageId() == "" {
+		return nil, fmt.Errorf("%w: message id is empty", errkit.ErrInvalidArgument)
+	}
+
+	output, getErr := s.storage.GetMessage(ctx, input)
+	if getErr != nil {
+		return nil, fmt.Errorf("get message %q (queue id: %q): %w", input.GetMessageId(), input.GetQueueId(), getErr)
+	}
+
+	return output, nil
+}
+
+// listMessageEvents returns lifecycle events of the message, so operators can
+// find out why it has been redelivered or hasn't been delivered at all.
+func (s *PlainQ) listMessageEvents(ctx context.Context, input *v1.ListMessageEventsRequest) (*v1.ListMessageEventsResponse, error) {
+	if err := validateQueueIDFromRequest(input); err != nil {
+		return nil, err
+	}
+
+	if input.GetMessageId() == "" {
+		return nil, fmt.Errorf("%w: message id is empty", errkit.ErrInvalidArgument)
+	}
+
+	output, listErr := s.storage.ListMessageEvents(ctx, input)
+	if listErr != nil {
+		return nil, fmt.Errorf("list message %q events (queue id: %q): %w", input.GetMessageId(), input.GetQueueId(), listErr)
+	}
+
+	return output, nil
+}
+
+// deleteMessages acknowledges received messages by their receipt handles. Deleting messages
+// by their identifiers bypasses visibility leases of consumers, so it's left to operators:
+// it must be allowed by the server and requires the purge permission in addition to the receive permission.
+func (s *PlainQ) deleteMessages(ctx context.Context, input *v1.DeleteRequest) (*v1.DeleteResponse, error) {
+	if err := validateQueueIDFromRequest(input); err != nil {
+		return nil, err
+	}
+
+	if len(input.GetMessageIds()) > 0 {
+		if err := s.authorizeAckByMessageID(ctx, input.GetQueueId()); err != nil {
+			return nil, err
+		}
+	}
+
+	output, deleteErr := s.storage.Delete(ctx, input)
+	if deleteErr != nil {
+		return nil, fmt.Errorf("delete messages (queue id: %q): %w", input.GetQueueId(), deleteErr)
+	}
+
+	return output, nil
+}
+
+// changeVisibility changes visibility of the received message by its receipt handle.
+// As with deleteMessages, changing visibility by the message identifier must be allowed
+// by the server and requires the purge permission.
+func (s *PlainQ) changeVisibility(ctx context.Context, input *v1.ChangeVisibilityRequest) (*v1.ChangeVisibilityResponse, error) {
+	if err := validateQueueIDFromRequest(input); err != nil {
+		return nil, err
+	}
+
+	if input.GetReceiptHandle() == "" {
+		if err := s.authorizeAckByMessageID(ctx, input.GetQueueId()); err != nil {
+			return nil, err
+		}
+	}
+
+	output, changeErr := s.storage.ChangeVisibility(ctx, input)
+	if changeErr != nil {
+		return nil, fmt.Errorf("change message visibility (queue id: %q): %w", input.GetQueueId(), changeErr)
+	}
+
+	return output, nil
+}
+
+// authorizeAckByMessageID checks that messages of the queue may be deleted or have their
+// visibility changed by identifiers instead of receipt handles of their receives.
+func (s *PlainQ) authorizeAckByMessageID(ctx context.Context, queueID string) error {
+	if !s.ackByMessageID {
+		return fmt.Errorf("%w: receipt handles are required, acknowledging messages by ids is not allowed by the server",
+			errkit.ErrInvalidArgument,
+		)
+	}
+
+	return s.authorizeQueue(ctx, queueID, rbac.OpPurge)
+}
+
+// extendVisibilityBatch changes visibility timeouts of received messages by their receipt handles,
+// so long-running workers heartbeat all messages they process with a single call.
+func (s *PlainQ) extendVisibilityBatch(ctx context.Context, input *v1.ExtendVisibilityBatchRequest) (*v1.ExtendVisibilityBatchResponse, error) {
+	if err := validateQueueIDFromRequest(input); err != nil {
+		return nil, err
+	}
+
+	if len(input.GetEntries()) == 0 {
+		return nil, fmt.Errorf("%w: entries are empty", errkit.ErrInvalidArgument)
+	}
+
+	if len(input.GetEntries()) > maxExtendVisibilityEntries {
+		return nil, fmt.Errorf("%w: more than %d entries", errkit.ErrInvalidArgument, maxExtendVisibilityEntries)
+	}
+
+	output, extendErr := s.storage.ExtendVisibilityBatch(ctx, input)
+	if extendErr != nil {
+		return nil, fmt.Errorf("extend message visibility (queue id: %q): %w", input.GetQueueId(), extendErr)
+	}
+
+	return output, nil
+}
+
+// transact performs sends and deletes across queues atomically. Since the request spans
+// queues, each send requires the send permission on its queue and each delete requires
+// the same permissions as the deleteMessages.
+func (s *PlainQ) transact(ctx context.Context, input *v1.TransactRequest) (*v1.TransactResponse, error) {
+	if len(input.GetSends()) == 0 && len(input.GetDeletes()) == 0 {
+		return nil, fmt.Errorf("%w: transaction has neither sends nor deletes", errkit.ErrInvalidArgument)
+	}
+
+	for _, send := range input.GetSends() {
+		if err := validateQueueIDFromRequest(send); err != nil {
+			return nil, err
+		}
+
+		if err := s.authorizeQueue(ctx, send.GetQueueId(), rbac.OpSend); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, del := range input.GetDeletes() {
+		if err := validateQueueIDFromRequest(del); err != nil {
+			return nil, err
+		}
+
+		if err := s.authorizeQueue(ctx, del.GetQueueId(), rbac.OpReceive); err != nil {
+			return nil, err
+		}
+
+		if len(del.GetMessageIds()) > 0 {
+			if err := s.authorizeAckByMessageID(ctx, del.GetQueueId()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	output, transactErr := s.storage.Transact(ctx, input)
+	if transactErr != nil {
+		return nil, fmt.Errorf("transact: %w", transactErr)
+	}
+
+	return output, nil
+}
@@ -0,0 +1,492 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/rbac"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+)
+
+func TestPlainQ_peekMessages(t *testing.T) {
+	type tcase struct {
+		subject     string
+		queueID     string
+		includeBody bool
+		wantErr     error
+	}
+
+	queueID := idkit.XID()
+
+	tests := map[string]tcase{
+		"Metadata":             {subject: "viewer", queueID: queueID},
+		"BodyOfConsumer":       {subject: "consumer", queueID: queueID, includeBody: true},
+		"BodyOfViewer":         {subject: "viewer", queueID: queueID, includeBody: true, wantErr: errkit.ErrUnauthorized},
+		"BodyWithoutAuthn":     {queueID: queueID, includeBody: true},
+		"InvalidQueueID":       {subject: "consumer", queueID: "invalid", wantErr: pqerr.ErrInvalidID},
+		"InvalidQueueWithBody": {subject: "viewer", queueID: "invalid", includeBody: true, wantErr: pqerr.ErrInvalidID},
+	}
+
+	server := PlainQ{
+		observer: telemetry.NewObserver(),
+		storage: &mockStorage{
+			peekMessagesFunc: func(_ context.Context, input *v1.PeekMessagesRequest) (*v1.PeekMessagesResponse, error) {
+				return &v1.PeekMessagesResponse{Messages: []*v1.PeekMessage{{Id: input.GetQueueId()}}}, nil
+			},
+			queueGrantsFunc: func(_ context.Context, subject, _ string) (*rbac.Grants, error) {
+				grants := rbac.Grants{Operations: []rbac.Operation{rbac.OpDescribe}}
+
+				if subject == "consumer" {
+					grants.Operations = append(grants.Operations, rbac.OpReceive)
+				}
+
+				return &grants, nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.subject != "" {
+				ctx = identity.WithIdentity(ctx, identity.Identity{Name: tc.subject})
+			}
+
+			input := v1.PeekMessagesRequest{QueueId: tc.queueID, IncludeBody: tc.includeBody}
+
+			output, err := server.peekMessages(ctx, &input)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output.GetMessages(), td.Len(1))
+		})
+	}
+}
+
+func TestPlainQ_getMessage(t *testing.T) {
+	type tcase struct {
+		queueID   string
+		messageID string
+		wantErr   error
+	}
+
+	queueID := idkit.XID()
+
+	tests := map[string]tcase{
+		"OK":             {queueID: queueID, messageID: "01HQ5RJNXS6TPXK89PQWY4N8JD"},
+		"UnknownMessage": {queueID: queueID, messageID: "01HQ5RJNXS6TPXK89PQWY4N8JE", wantErr: errkit.ErrNotFound},
+		"EmptyMessageID": {queueID: queueID, wantErr: errkit.ErrInvalidArgument},
+		"InvalidQueueID": {queueID: "invalid", messageID: "01HQ5RJNXS6TPXK89PQWY4N8JD", wantErr: pqerr.ErrInvalidID},
+	}
+
+	server := PlainQ{
+		storage: &mockStorage{
+			getMessageFunc: func(_ context.Context, input *v1.GetMessageRequest) (*v1.GetMessageResponse, error) {
+				if input.GetMessageId() != "01HQ5RJNXS6TPXK89PQWY4N8JD" {
+					return nil, errkit.ErrNotFound
+				}
+
+				return &v1.GetMessageResponse{Message: &v1.PeekMessage{Id: input.GetMessageId()}}, nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := server.getMessage(context.Background(), &v1.GetMessageRequest{QueueId: tc.queueID, MessageId: tc.messageID})
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output.GetMessage().GetId(), tc.messageID)
+		})
+	}
+}
+
+func TestPlainQ_listMessageEvents(t *testing.T) {
+	type tcase struct {
+		queueID   string
+		messageID string
+		wantErr   error
+	}
+
+	queueID := idkit.XID()
+
+	tests := map[string]tcase{
+		"OK":             {queueID: queueID, messageID: "01HQ5RJNXS6TPXK89PQWY4N8JD"},
+		"UnknownQueue":   {queueID: idkit.XID(), messageID: "01HQ5RJNXS6TPXK89PQWY4N8JD", wantErr: errkit.ErrNotFound},
+		"EmptyMessageID": {queueID: queueID, wantErr: errkit.ErrInvalidArgument},
+		"InvalidQueueID": {queueID: "invalid", messageID: "01HQ5RJNXS6TPXK89PQWY4N8JD", wantErr: pqerr.ErrInvalidID},
+	}
+
+	server := PlainQ{
+		storage: &mockStorage{
+			messageEventsFunc: func(_ context.Context, input *v1.ListMessageEventsRequest) (*v1.ListMessageEventsResponse, error) {
+				if input.GetQueueId() != queueID {
+					return nil, errkit.ErrNotFound
+				}
+
+				return &v1.ListMessageEventsResponse{Events: []*v1.MessageEvent{
+					{Kind: v1.MessageEventKind_MESSAGE_EVENT_KIND_SENT},
+					{Kind: v1.MessageEventKind_MESSAGE_EVENT_KIND_RECEIVED, Attempts: 1},
+				}}, nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := server.listMessageEvents(context.Background(), &v1.ListMessageEventsRequest{QueueId: tc.queueID, MessageId: tc.messageID})
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output.GetEvents(), td.Len(2))
+		})
+	}
+}
+
+func TestPlainQ_deleteMessages(t *testing.T) {
+	type tcase struct {
+		subject        string
+		ackByMessageID bool
+		input          *v1.DeleteRequest
+		wantErr        error
+	}
+
+	queueID := idkit.XID()
+
+	tests := map[string]tcase{
+		"ReceiptHandlesOfConsumer": {
+			subject: "consumer",
+			input:   &v1.DeleteRequest{QueueId: queueID, ReceiptHandles: []string{"handle"}},
+		},
+
+		"ReceiptHandlesWithoutAuthn": {
+			input: &v1.DeleteRequest{QueueId: queueID, ReceiptHandles: []string{"handle"}},
+		},
+
+		"MessageIDsOfConsumer": {
+			subject:        "consumer",
+			ackByMessageID: true,
+			input:          &v1.DeleteRequest{QueueId: queueID, MessageIds: []string{"01HQ5RJNXS6TPXK89PQWY4N8JD"}},
+			wantErr:        errkit.ErrUnauthorized,
+		},
+
+		"MessageIDsOfOperator": {
+			subject:        "operator",
+			ackByMessageID: true,
+			input:          &v1.DeleteRequest{QueueId: queueID, MessageIds: []string{"01HQ5RJNXS6TPXK89PQWY4N8JD"}},
+		},
+
+		"MessageIDsOfOperatorNotAllowed": {
+			subject: "operator",
+			input:   &v1.DeleteRequest{QueueId: queueID, MessageIds: []string{"01HQ5RJNXS6TPXK89PQWY4N8JD"}},
+			wantErr: errkit.ErrInvalidArgument,
+		},
+
+		"MessageIDsWithoutAuthn": {
+			ackByMessageID: true,
+			input:          &v1.DeleteRequest{QueueId: queueID, MessageIds: []string{"01HQ5RJNXS6TPXK89PQWY4N8JD"}},
+		},
+
+		"MessageIDsWithoutAuthnNotAllowed": {
+			input:   &v1.DeleteRequest{QueueId: queueID, MessageIds: []string{"01HQ5RJNXS6TPXK89PQWY4N8JD"}},
+			wantErr: errkit.ErrInvalidArgument,
+		},
+
+		"InvalidQueueID": {
+			subject: "consumer",
+			input:   &v1.DeleteRequest{QueueId: "invalid", ReceiptHandles: []string{"handle"}},
+			wantErr: pqerr.ErrInvalidID,
+		},
+	}
+
+	storage := mockStorage{
+		deleteFunc: func(_ context.Context, input *v1.DeleteRequest) (*v1.DeleteResponse, error) {
+			return &v1.DeleteResponse{Successful: append(input.GetMessageIds(), input.GetReceiptHandles()...)}, nil
+		},
+		queueGrantsFunc: func(_ context.Context, subject, _ string) (*rbac.Grants, error) {
+			grants := rbac.Grants{Operations: []rbac.Operation{rbac.OpReceive}}
+
+			if subject == "operator" {
+				grants.Operations = append(grants.Operations, rbac.OpPurge)
+			}
+
+			return &grants, nil
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := PlainQ{
+				observer:       telemetry.NewObserver(),
+				storage:        &storage,
+				ackByMessageID: tc.ackByMessageID,
+			}
+
+			ctx := context.Background()
+			if tc.subject != "" {
+				ctx = identity.WithIdentity(ctx, identity.Identity{Name: tc.subject})
+			}
+
+			output, err := server.deleteMessages(ctx, tc.input)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output.GetSuccessful(), td.Len(1))
+		})
+	}
+}
+
+func TestPlainQ_extendVisibilityBatch(t *testing.T) {
+	type tcase struct {
+		input   *v1.ExtendVisibilityBatchRequest
+		wantErr error
+	}
+
+	queueID := idkit.XID()
+
+	entries := func(n int) []*v1.ExtendVisibilityEntry {
+		out := make([]*v1.ExtendVisibilityEntry, n)
+		for i := range out {
+			out[i] = &v1.ExtendVisibilityEntry{ReceiptHandle: "handle", VisibilityTimeoutSeconds: 30}
+		}
+
+		return out
+	}
+
+	tests := map[string]tcase{
+		"OK":             {input: &v1.ExtendVisibilityBatchRequest{QueueId: queueID, Entries: entries(2)}},
+		"MaxEntries":     {input: &v1.ExtendVisibilityBatchRequest{QueueId: queueID, Entries: entries(maxExtendVisibilityEntries)}},
+		"NoEntries":      {input: &v1.ExtendVisibilityBatchRequest{QueueId: queueID}, wantErr: errkit.ErrInvalidArgument},
+		"TooManyEntries": {input: &v1.ExtendVisibilityBatchRequest{QueueId: queueID, Entries: entries(maxExtendVisibilityEntries + 1)}, wantErr: errkit.ErrInvalidArgument},
+		"InvalidQueueID": {input: &v1.ExtendVisibilityBatchRequest{QueueId: "invalid", Entries: entries(1)}, wantErr: pqerr.ErrInvalidID},
+	}
+
+	server := PlainQ{
+		storage: &mockStorage{
+			extendVisibilityFunc: func(_ context.Context, input *v1.ExtendVisibilityBatchRequest) (*v1.ExtendVisibilityBatchResponse, error) {
+				output := v1.ExtendVisibilityBatchResponse{}
+				for _, e := range input.GetEntries() {
+					output.Results = append(output.Results, &v1.ExtendVisibilityResult{ReceiptHandle: e.GetReceiptHandle(), Success: true})
+				}
+
+				return &output, nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := server.extendVisibilityBatch(context.Background(), tc.input)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output.GetResults(), td.Len(len(tc.input.GetEntries())))
+		})
+	}
+}
+
+func TestPlainQ_transact(t *testing.T) {
+	type tcase struct {
+		subject        string
+		ackByMessageID bool
+		input          *v1.TransactRequest
+		wantErr        error
+	}
+
+	inbox, outbox := idkit.XID(), idkit.XID()
+
+	tests := map[string]tcase{
+		"OK": {
+			subject: "pipeline",
+			input: &v1.TransactRequest{
+				Sends:   []*v1.SendRequest{{QueueId: outbox, Messages: []*v1.SendMessage{{Body: []byte("result")}}}},
+				Deletes: []*v1.DeleteRequest{{QueueId: inbox, ReceiptHandles: []string{"handle"}}},
+			},
+		},
+
+		"SendToInbox": {
+			subject: "pipeline",
+			input:   &v1.TransactRequest{Sends: []*v1.SendRequest{{QueueId: inbox}}},
+			wantErr: errkit.ErrUnauthorized,
+		},
+
+		"DeleteFromOutbox": {
+			subject: "pipeline",
+			input:   &v1.TransactRequest{Deletes: []*v1.DeleteRequest{{QueueId: outbox, ReceiptHandles: []string{"handle"}}}},
+			wantErr: errkit.ErrUnauthorized,
+		},
+
+		"DeleteByMessageIDs": {
+			subject:        "pipeline",
+			ackByMessageID: true,
+			input:          &v1.TransactRequest{Deletes: []*v1.DeleteRequest{{QueueId: inbox, MessageIds: []string{"01HQ5RJNXS6TPXK89PQWY4N8JD"}}}},
+			wantErr:        errkit.ErrUnauthorized,
+		},
+
+		"DeleteByMessageIDsNotAllowed": {
+			subject: "pipeline",
+			input:   &v1.TransactRequest{Deletes: []*v1.DeleteRequest{{QueueId: inbox, MessageIds: []string{"01HQ5RJNXS6TPXK89PQWY4N8JD"}}}},
+			wantErr: errkit.ErrInvalidArgument,
+		},
+
+		"Empty": {
+			subject: "pipeline",
+			input:   &v1.TransactRequest{},
+			wantErr: errkit.ErrInvalidArgument,
+		},
+
+		"InvalidQueueID": {
+			subject: "pipeline",
+			input:   &v1.TransactRequest{Sends: []*v1.SendRequest{{QueueId: "invalid"}}},
+			wantErr: pqerr.ErrInvalidID,
+		},
+	}
+
+	storage := mockStorage{
+		transactFunc: func(_ context.Context, input *v1.TransactRequest) (*v1.TransactResponse, error) {
+			return &v1.TransactResponse{Sends: make([]*v1.SendResponse, len(input.GetSends()))}, nil
+		},
+		queueGrantsFunc: func(_ context.Context, _, queueID string) (*rbac.Grants, error) {
+			if queueID == inbox {
+				return &rbac.Grants{Operations: []rbac.Operation{rbac.OpReceive}}, nil
+			}
+
+			return &rbac.Grants{Operations: []rbac.Operation{rbac.OpSend}}, nil
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := PlainQ{
+				observer:       telemetry.NewObserver(),
+				storage:        &storage,
+				ackByMessageID: tc.ackByMessageID,
+			}
+
+			ctx := identity.WithIdentity(context.Background(), identity.Identity{Name: tc.subject})
+
+			output, err := server.transact(ctx, tc.input)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output.GetSends(), td.Len(len(tc.input.GetSends())))
+		})
+	}
+}
+
+func TestPlainQ_listArchivedMessages(t *testing.T) {
+	type tcase struct {
+		subject     string
+		queueID     string
+		includeBody bool
+		wantErr     error
+	}
+
+	queueID := idkit.XID()
+
+	tests := map[string]tcase{
+		"Metadata":       {subject: "viewer", queueID: queueID},
+		"BodyOfConsumer": {subject: "consumer", queueID: queueID, includeBody: true},
+		"BodyOfViewer":   {subject: "viewer", queueID: queueID, includeBody: true, wantErr: errkit.ErrUnauthorized},
+		"InvalidQueueID": {subject: "viewer", queueID: "invalid", wantErr: pqerr.ErrInvalidID},
+	}
+
+	server := PlainQ{
+		observer: telemetry.NewObserver(),
+		storage: &mockStorage{
+			listArchivedFunc: func(_ context.Context, _ *v1.ListArchivedMessagesRequest) (*v1.ListArchivedMessagesResponse, error) {
+				return &v1.ListArchivedMessagesResponse{Messages: []*v1.ArchivedMessage{{Id: "01HQ5RJNXS6TPXK89PQWY4N8JD"}}}, nil
+			},
+			queueGrantsFunc: func(_ context.Context, subject, _ string) (*rbac.Grants, error) {
+				grants := rbac.Grants{Operations: []rbac.Operation{rbac.OpDescribe}}
+
+				if subject == "consumer" {
+					grants.Operations = append(grants.Operations, rbac.OpReceive)
+				}
+
+				return &grants, nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := identity.WithIdentity(context.Background(), identity.Identity{Name: tc.subject})
+
+			input := v1.ListArchivedMessagesRequest{QueueId: tc.queueID, IncludeBody: tc.includeBody}
+
+			output, err := server.listArchivedMessages(ctx, &input)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output.GetMessages(), td.Len(1))
+		})
+	}
+}
+
+func TestPlainQ_restoreArchivedMessages(t *testing.T) {
+	type tcase struct {
+		queueID    string
+		messageIDs []string
+		wantErr    error
+	}
+
+	queueID := idkit.XID()
+
+	tests := map[string]tcase{
+		"OK":             {queueID: queueID, messageIDs: []string{"01HQ5RJNXS6TPXK89PQWY4N8JD"}},
+		"NoMessageIDs":   {queueID: queueID, wantErr: errkit.ErrInvalidArgument},
+		"InvalidQueueID": {queueID: "invalid", messageIDs: []string{"01HQ5RJNXS6TPXK89PQWY4N8JD"}, wantErr: pqerr.ErrInvalidID},
+	}
+
+	server := PlainQ{
+		storage: &mockStorage{
+			restoreArchivedFunc: func(_ context.Context, input *v1.RestoreArchivedMessagesRequest) (*v1.RestoreArchivedMessagesResponse, error) {
+				return &v1.RestoreArchivedMessagesResponse{Restored: input.GetMessageIds()}, nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			input := v1.RestoreArchivedMessagesRequest{QueueId: tc.queueID, MessageIds: tc.messageIDs}
+
+			output, err := server.restoreArchivedMessages(context.Background(), &input)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output.GetRestored(), tc.messageIDs)
+		})
+	}
+}
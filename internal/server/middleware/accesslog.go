@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/plainq/plainq/internal/server/accesslog"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/servekit/ctxkit"
+)
+
+// AccessLogConfig holds the configuration of the access log.
+type AccessLogConfig struct {
+	// Fields are fields of records, accesslog.DefaultFields when empty.
+	Fields []string
+
+	// Sampling holds sampling rates of routes, e.g. "GET /api/v1/queue/{id}/messages".
+	// Failed requests are logged regardless of sampling.
+	Sampling accesslog.Sampling
+}
+
+// AccessLog logs requests with configured fields. Requests of routes with sampling rates
+// are logged once per rate requests, unless they failed with a server error, and values
+// of headers which carry credentials are redacted.
+func AccessLog(logger *slog.Logger, cfg AccessLogConfig) func(next http.Handler) http.Handler {
+	fields := cfg.Fields
+	if len(fields) == 0 {
+		fields = accesslog.DefaultFields
+	}
+
+	sampler := accesslog.NewSampler(cfg.Sampling)
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now().UTC()
+
+			var reqErr error
+
+			ctx := ctxkit.SetLogErrHook(r.Context(), func(err error) { reqErr = err })
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			// Handlers which write nothing respond with 200 OK.
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			// The route pattern is known once the request has been routed.
+			route := routePattern(r)
+
+			if status < http.StatusInternalServerError && !sampler.Sample(r.Method+" "+route) {
+				return
+			}
+
+			attrs := make([]slog.Attr, 0, len(fields)+1)
+
+			for _, f := range fields {
+				switch f {
+				case accesslog.FieldMethod:
+					attrs = append(attrs, slog.String(f, r.Method))
+				case accesslog.FieldRoute:
+					attrs = append(attrs, slog.String(f, route))
+				case accesslog.FieldURI:
+					attrs = append(attrs, slog.String(f, r.RequestURI))
+				case accesslog.FieldStatus:
+					attrs = append(attrs, slog.Int(f, status))
+				case accesslog.FieldBytes:
+					attrs = append(attrs, slog.Int(f, ww.BytesWritten()))
+				case accesslog.FieldRemote:
+					attrs = append(attrs, slog.String(f, r.RemoteAddr))
+				case accesslog.FieldDuration:
+					attrs = append(attrs, slog.String(f, time.Since(start).String()))
+				case accesslog.FieldUserAgent:
+					attrs = append(attrs, slog.String(f, r.UserAgent()))
+				case accesslog.FieldIdentity:
+					if id, ok := identity.FromContext(r.Context()); ok {
+						attrs = append(attrs, slog.String(f, id.Name))
+					}
+				case accesslog.FieldHeaders:
+					attrs = append(attrs, headersAttr(accesslog.Headers(r.Header)))
+				}
+			}
+
+			level := slog.LevelInfo
+
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+
+				if reqErr != nil {
+					attrs = append(attrs, slog.String("error", reqErr.Error()))
+				}
+			}
+
+			logger.LogAttrs(r.Context(), level, "HTTP", attrs...)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// routePattern returns the route pattern of the routed request, or the empty
+// string when the request hasn't been routed by chi. The path is not used
+// instead, since sampling keeps a counter per route.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+
+	return ""
+}
+
+// headersAttr returns the group of headers with comma joined values.
+func headersAttr(h http.Header) slog.Attr {
+	attrs := make([]any, 0, len(h))
+
+	for _, name := range slices.Sorted(maps.Keys(h)) {
+		attrs = append(attrs, slog.String(name, strings.Join(h[name], ",")))
+	}
+
+	return slog.Group(accesslog.FieldHeaders, attrs...)
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/accesslog"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	router := chi.NewRouter()
+	router.Use(AccessLog(logger, AccessLogConfig{
+		Fields:   []string{accesslog.FieldMethod, accesslog.FieldRoute, accesslog.FieldStatus, accesslog.FieldHeaders},
+		Sampling: accesslog.Sampling{"GET /api/v1/queue/{id}/messages": 0},
+	}))
+	router.Get("/api/v1/queue/{id}/messages", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("fail") {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	router.Get("/api/v1/queue/{id}", func(http.ResponseWriter, *http.Request) {})
+
+	type tcase struct {
+		path    string
+		want    []string
+		notWant []string
+	}
+
+	tests := map[string]tcase{
+		"Logged": {
+			path:    "/api/v1/queue/abc",
+			want:    []string{`"route":"/api/v1/queue/{id}"`, `"status":200`, `"Authorization":"REDACTED"`},
+			notWant: []string{"secret", `"uri"`},
+		},
+		"Disabled": {
+			path: "/api/v1/queue/abc/messages",
+		},
+		"DisabledFailed": {
+			path: "/api/v1/queue/abc/messages?fail",
+			want: []string{`"level":"ERROR"`, `"status":500`},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf.Reset()
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer secret")
+
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if len(tc.want) == 0 {
+				td.Cmp(t, buf.String(), "")
+				return
+			}
+
+			for _, s := range tc.want {
+				td.CmpTrue(t, strings.Contains(buf.String(), s), s)
+			}
+
+			for _, s := range tc.notWant {
+				td.CmpFalse(t, strings.Contains(buf.String(), s), s)
+			}
+		})
+	}
+}
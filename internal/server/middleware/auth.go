@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/plainq/plainq/internal/server/auth"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/rbac"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/servekit/errkit"
+)
+
+// Auth authenticates requests by bearer tokens or API keys of the Authorization header,
+// and checks that roles of the client are granted the operation on the queue of the request.
+// Operations are looked up by the method and the route pattern, e.g. "POST /api/v1/queue/{id}/purge",
+// routes which are not listed require the rbac.AdminRole, and public routes, e.g. the sign-in,
+// are served without authentication.
+func Auth(routes chi.Routes, authn *auth.Authenticator, granter auth.Granter, observer telemetry.Observer, operations map[string]rbac.Operation) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			var queueID string
+
+			rctx := chi.NewRouteContext()
+			pattern := routes.Find(rctx, r.Method, r.URL.Path)
+
+			if strings.Contains(pattern, queueRoute) {
+				queueID = rctx.URLParam("id")
+			}
+
+			op, ok := operations[r.Method+" "+pattern]
+			if !ok {
+				op = auth.OpAdmin
+			}
+
+			if op == auth.OpPublic {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id, authErr := authn.Authenticate(r.Context(), r.Header.Get("Authorization"))
+			if authErr != nil {
+				reason := auth.Reason(authErr)
+
+				observer.AuthFailures(reason).Inc()
+
+				if !errors.Is(authErr, auth.ErrMissingCredentials) {
+					observer.TokenErrors(reason).Inc()
+				}
+
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, authErr.Error(), http.StatusUnauthorized)
+
+				return
+			}
+
+			err := auth.Authorize(r.Context(), granter, id.Name, queueID, op)
+
+			switch {
+			case err == nil:
+				next.ServeHTTP(w, r.WithContext(identity.WithIdentity(r.Context(), id)))
+
+			case errors.Is(err, auth.ErrPermissionDenied):
+				observer.AuthDenials(queueID, string(op)).Inc()
+				http.Error(w, err.Error(), http.StatusForbidden)
+
+			case errors.Is(err, errkit.ErrNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Prefixes applies the middleware only to requests which paths start with one of prefixes,
+// e.g. to authenticate administrative routes while other routes are served without authentication.
+func Prefixes(mw func(next http.Handler) http.Handler, prefixes ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range prefixes {
+				if r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, strings.TrimSuffix(prefix, "/")+"/") {
+					wrapped.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/auth"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/rbac"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/servekit/errkit"
+)
+
+type mockAPIKeys map[string]*auth.StoredAPIKey
+
+func (m mockAPIKeys) APIKey(_ context.Context, prefix string) (*auth.StoredAPIKey, error) {
+	if key, ok := m[prefix]; ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w: API key %q", errkit.ErrNotFound, prefix)
+}
+
+type mockGranter map[string]*rbac.Grants
+
+func (m mockGranter) QueueGrants(_ context.Context, subject, _ string) (*rbac.Grants, error) {
+	if grants, ok := m[subject]; ok {
+		return grants, nil
+	}
+
+	return &rbac.Grants{}, nil
+}
+
+func TestAuth(t *testing.T) {
+	key, prefix, hash, keyErr := auth.GenerateAPIKey()
+	td.Require(t).CmpNoError(keyErr)
+
+	revoked, revokedPrefix, revokedHash, revokedErr := auth.GenerateAPIKey()
+	td.Require(t).CmpNoError(revokedErr)
+
+	authn, authnErr := auth.New(auth.Config{APIKeys: mockAPIKeys{
+		prefix:        {KeyID: "k1", Account: "orders-producer", Hash: hash},
+		revokedPrefix: {KeyID: "k2", Account: "orders-producer", Hash: revokedHash, Revoked: true},
+	}})
+	td.Require(t).CmpNoError(authnErr)
+
+	granter := mockGranter{"orders-producer": {Operations: []rbac.Operation{rbac.OpSend}}}
+
+	var got string
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		id, _ := identity.FromContext(r.Context())
+		got = id.Name
+
+		w.WriteHeader(http.StatusOK)
+	}
+
+	router := chi.NewRouter()
+	router.Use(Auth(router, authn, granter, telemetry.NewObserver(), map[string]rbac.Operation{
+		"POST /api/v1/queue/{id}/messages": rbac.OpSend,
+		"POST /api/v1/queue/{id}/purge":    rbac.OpPurge,
+		"GET /api/v1/queue/":               auth.OpAuthenticated,
+		"POST /api/v1/auth/sign-in":        auth.OpPublic,
+	}))
+	router.Post("/api/v1/queue/{id}/messages", handler)
+	router.Post("/api/v1/queue/{id}/purge", handler)
+	router.Get("/api/v1/queue/", handler)
+	router.Get("/api/v1/admin/audit", handler)
+	router.Post("/api/v1/auth/sign-in", handler)
+
+	type tcase struct {
+		method   string
+		path     string
+		token    string
+		wantCode int
+		want     string
+	}
+
+	tests := map[string]tcase{
+		"Granted":       {method: http.MethodPost, path: "/api/v1/queue/q1/messages", token: key, wantCode: http.StatusOK, want: "orders-producer"},
+		"Authenticated": {method: http.MethodGet, path: "/api/v1/queue/", token: key, wantCode: http.StatusOK, want: "orders-producer"},
+		"Denied":        {method: http.MethodPost, path: "/api/v1/queue/q1/purge", token: key, wantCode: http.StatusForbidden},
+		"AdminOnly":     {method: http.MethodGet, path: "/api/v1/admin/audit", token: key, wantCode: http.StatusForbidden},
+		"Revoked":       {method: http.MethodGet, path: "/api/v1/queue/", token: revoked, wantCode: http.StatusUnauthorized},
+		"WrongSecret":   {method: http.MethodGet, path: "/api/v1/queue/", token: prefix + "_wrong", wantCode: http.StatusUnauthorized},
+		"NoToken":       {method: http.MethodGet, path: "/api/v1/queue/", wantCode: http.StatusUnauthorized},
+		"JWTDisabled":   {method: http.MethodGet, path: "/api/v1/queue/", token: "a.b.c", wantCode: http.StatusUnauthorized},
+		"Public":        {method: http.MethodPost, path: "/api/v1/auth/sign-in", wantCode: http.StatusOK},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got = ""
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			td.Cmp(t, rec.Code, tc.wantCode)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
+
+func TestAuth_ExpiredKey(t *testing.T) {
+	key, prefix, hash, keyErr := auth.GenerateAPIKey()
+	td.Require(t).CmpNoError(keyErr)
+
+	authn, authnErr := auth.New(auth.Config{APIKeys: mockAPIKeys{
+		prefix: {KeyID: "k1", Account: "orders-producer", Hash: hash, ExpiresAt: time.Now().Add(-time.Minute)},
+	}})
+	td.Require(t).CmpNoError(authnErr)
+
+	_, err := authn.Authenticate(context.Background(), "Bearer "+key)
+	td.CmpErrorIs(t, err, auth.ErrExpiredToken)
+}
+
+func TestPrefixes(t *testing.T) {
+	deny := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusUnauthorized) })
+	}
+
+	h := Prefixes(deny, "/api/v1/admin", "/api/scim/v2")(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }),
+	)
+
+	tests := map[string]struct {
+		path     string
+		wantCode int
+	}{
+		"Admin":        {path: "/api/v1/admin", wantCode: http.StatusUnauthorized},
+		"AdminRoute":   {path: "/api/v1/admin/service-accounts", wantCode: http.StatusUnauthorized},
+		"SCIM":         {path: "/api/scim/v2/Users", wantCode: http.StatusUnauthorized},
+		"Queue":        {path: "/api/v1/queue/abc", wantCode: http.StatusOK},
+		"SimilarRoute": {path: "/api/v1/administrators", wantCode: http.StatusOK},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			td.Cmp(t, w.Code, tt.wantCode)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-chi/cors"
+)
+
+// CORS represents the CORS middleware with allowed origins
+// which can be changed at runtime.
+type CORS struct {
+	// origins holds allowed origins, nil allows any origin.
+	origins atomic.Pointer[[]string]
+	cors    *cors.Cors
+}
+
+// NewCORS returns a pointer to a new instance of CORS
+// which allows given origins. Empty origins allow any origin.
+func NewCORS(origins []string) *CORS {
+	c := CORS{}
+
+	c.SetOrigins(origins)
+
+	c.cors = cors.New(cors.Options{
+		AllowOriginFunc: func(_ *http.Request, origin string) bool { return c.allowed(origin) },
+		AllowedMethods: []string{
+			http.MethodHead,
+			http.MethodGet,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+		},
+		AllowedHeaders:   []string{"*"},
+		AllowCredentials: false,
+	})
+
+	return &c
+}
+
+// Handler returns the middleware handler.
+func (c *CORS) Handler(next http.Handler) http.Handler { return c.cors.Handler(next) }
+
+// Origins returns allowed origins, empty origins mean that any origin is allowed.
+func (c *CORS) Origins() []string {
+	if origins := c.origins.Load(); origins != nil {
+		return slices.Clone(*origins)
+	}
+
+	return nil
+}
+
+// SetOrigins changes allowed origins. Empty origins or "*" allow any origin.
+func (c *CORS) SetOrigins(origins []string) {
+	if len(origins) == 0 || slices.Contains(origins, "*") {
+		c.origins.Store(nil)
+		return
+	}
+
+	lowered := make([]string, 0, len(origins))
+
+	for _, o := range origins {
+		lowered = append(lowered, strings.ToLower(o))
+	}
+
+	c.origins.Store(&lowered)
+}
+
+func (c *CORS) allowed(origin string) bool {
+	origins := c.origins.Load()
+	if origins == nil {
+		return true
+	}
+
+	return slices.Contains(*origins, strings.ToLower(origin))
+}
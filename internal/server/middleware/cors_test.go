@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestCORS(t *testing.T) {
+	type tcase struct {
+		origins []string
+		origin  string
+		want    string
+	}
+
+	tests := map[string]tcase{
+		"AnyOrigin": {
+			origin: "https://example.com",
+			want:   "https://example.com",
+		},
+		"Wildcard": {
+			origins: []string{"*"},
+			origin:  "https://example.com",
+			want:    "https://example.com",
+		},
+		"Allowed": {
+			origins: []string{"https://Example.com"},
+			origin:  "https://example.com",
+			want:    "https://example.com",
+		},
+		"NotAllowed": {
+			origins: []string{"https://example.com"},
+			origin:  "https://example.org",
+			want:    "",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := NewCORS(nil)
+			c.SetOrigins(tc.origins)
+
+			h := c.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Origin", tc.origin)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			td.Cmp(t, w.Header().Get("Access-Control-Allow-Origin"), tc.want)
+		})
+	}
+}
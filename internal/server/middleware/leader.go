@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Leadership tells whether the server is the leader of the cluster,
+// which is the only server to change the database.
+type Leadership interface {
+	IsLeader() bool
+	Leader() string
+}
+
+// Leader rejects requests which may change the database with 503 Service Unavailable
+// while the server follows another one, so clients retry them on the leader of the cluster.
+// Requests of routes which only read, e.g. "GET /api/v1/queue/{id}/messages", are passed through.
+func Leader(l Leadership) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if l.IsLeader() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			msg := r.Method + " requests are served by the leader of the cluster, which is being elected"
+			if leader := l.Leader(); leader != "" {
+				msg = fmt.Sprintf("%s requests are served by the leader of the cluster, send them to the server %q",
+					r.Method, leader,
+				)
+			}
+
+			http.Error(w, msg, http.StatusServiceUnavailable)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+type leadership struct {
+	leader bool
+	id     string
+}
+
+func (l leadership) IsLeader() bool { return l.leader }
+func (l leadership) Leader() string { return l.id }
+
+func TestLeader(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	type tcase struct {
+		leadership leadership
+		method     string
+		wantCode   int
+		wantBody   string
+	}
+
+	tests := map[string]tcase{
+		"LeaderPost": {
+			leadership: leadership{leader: true, id: "node0"},
+			method:     http.MethodPost,
+			wantCode:   http.StatusOK,
+		},
+		"FollowerGet": {
+			leadership: leadership{id: "node0"},
+			method:     http.MethodGet,
+			wantCode:   http.StatusOK,
+		},
+		"FollowerDelete": {
+			leadership: leadership{id: "node0"},
+			method:     http.MethodDelete,
+			wantCode:   http.StatusServiceUnavailable,
+			wantBody:   "DELETE requests are served by the leader of the cluster, send them to the server \"node0\"\n",
+		},
+		"NoLeader": {
+			method:   http.MethodPost,
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: "POST requests are served by the leader of the cluster, which is being elected\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Leader(tc.leadership)(ok).ServeHTTP(w, httptest.NewRequest(tc.method, "/api/v1/queue/abc", nil))
+
+			td.Cmp(t, w.Code, tc.wantCode)
+			td.Cmp(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/ratelimit"
+	"github.com/plainq/plainq/internal/server/telemetry"
+)
+
+// queueRoute is the part of route patterns of queue routes, which holds the queue id.
+const queueRoute = "/queue/{id}"
+
+// RateLimit rejects requests which exceed rate limits with 429 Too Many Requests and the Retry-After header.
+// Clients are identified by the authenticated identity, or by the remote address when there is none.
+// The routes are used to find queues of requests to queue routes, e.g. "/api/v1/queue/{id}/stats".
+func RateLimit(routes chi.Routes, limiter *ratelimit.Limiter, observer telemetry.Observer) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			var queueID string
+
+			rctx := chi.NewRouteContext()
+			if pattern := routes.Find(rctx, r.Method, r.URL.Path); strings.Contains(pattern, queueRoute) {
+				queueID = rctx.URLParam("id")
+			}
+
+			err := limiter.Allow(r.Context(), requestClient(r), queueID)
+			if err == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var limited *ratelimit.LimitedError
+			if !errors.As(err, &limited) {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			observer.RateLimited(limited.Scope).Inc()
+
+			w.Header().Set("Retry-After", strconv.FormatInt(limited.RetryAfterSeconds(), 10))
+			http.Error(w, limited.Error(), http.StatusTooManyRequests)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// requestClient returns the name of the client which made the request.
+func requestClient(r *http.Request) string {
+	if id, ok := identity.FromContext(r.Context()); ok {
+		return id.Name
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
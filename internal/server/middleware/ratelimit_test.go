@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/ratelimit"
+	"github.com/plainq/plainq/internal/server/telemetry"
+)
+
+func TestRateLimit(t *testing.T) {
+	var queues []string
+
+	limiter := ratelimit.New(ratelimit.Config{
+		Queue: func(_ context.Context, queueID string) ratelimit.Limit {
+			queues = append(queues, queueID)
+			return ratelimit.Limit{Rate: 1, Burst: 1}
+		},
+	})
+
+	handler := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	router := chi.NewRouter()
+	router.Use(RateLimit(router, limiter, telemetry.NewObserver()))
+	router.Get("/api/v1/queue/{id}/stats", handler)
+	router.Get("/api/v1/generator/{id}", handler)
+
+	// Cases run in order, since each of them takes tokens.
+	tests := []struct {
+		name           string
+		path           string
+		wantCode       int
+		wantRetryAfter string
+		wantQueues     []string
+	}{
+		{name: "Allowed", path: "/api/v1/queue/q1/stats", wantCode: http.StatusOK, wantQueues: []string{"q1"}},
+		{name: "Limited", path: "/api/v1/queue/q1/stats", wantCode: http.StatusTooManyRequests, wantRetryAfter: "1", wantQueues: []string{"q1"}},
+		{name: "OtherQueue", path: "/api/v1/queue/q2/stats", wantCode: http.StatusOK, wantQueues: []string{"q2"}},
+		{name: "NotQueue", path: "/api/v1/generator/g1", wantCode: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			queues = nil
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			td.Cmp(t, rec.Code, tc.wantCode)
+			td.Cmp(t, rec.Header().Get("Retry-After"), tc.wantRetryAfter)
+			td.Cmp(t, queues, tc.wantQueues)
+		})
+	}
+}
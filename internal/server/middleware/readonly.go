@@ -0,0 +1,23 @@
+package middleware
+
+import "net/http"
+
+// ReadOnly rejects requests which may change the database with 405 Method Not Allowed,
+// since the read-only replica can't serve them and they should be sent to the primary server.
+// Requests of routes which only read, e.g. "GET /api/v1/queue/{id}/messages", are passed through.
+func ReadOnly(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+
+		default:
+			w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+			http.Error(w, r.Method+" requests are not served by the read-only replica, send them to the primary server",
+				http.StatusMethodNotAllowed,
+			)
+		}
+	}
+
+	return http.HandlerFunc(fn)
+}
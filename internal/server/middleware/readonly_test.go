@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestReadOnly(t *testing.T) {
+	h := ReadOnly(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+	type tcase struct {
+		method    string
+		wantCode  int
+		wantAllow string
+	}
+
+	tests := map[string]tcase{
+		"Get":    {method: http.MethodGet, wantCode: http.StatusOK},
+		"Head":   {method: http.MethodHead, wantCode: http.StatusOK},
+		"Post":   {method: http.MethodPost, wantCode: http.StatusMethodNotAllowed, wantAllow: "GET, HEAD, OPTIONS"},
+		"Delete": {method: http.MethodDelete, wantCode: http.StatusMethodNotAllowed, wantAllow: "GET, HEAD, OPTIONS"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tc.method, "/api/v1/queue/abc", nil))
+
+			td.Cmp(t, w.Code, tc.wantCode)
+			td.Cmp(t, w.Header().Get("Allow"), tc.wantAllow)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/plainq/plainq/internal/server/timeout"
+)
+
+// Timeout limits the time of requests of routes which have timeouts, e.g. "GET /api/v1/queue/{id}/search".
+// Route timeouts override the read and write timeouts of the HTTP server, so a route is allowed to take
+// longer than the server timeouts permit. The routes are used to find route patterns of requests.
+func Timeout(routes chi.Routes, timeouts timeout.Timeouts) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			pattern := routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+
+			d, ok := timeouts.Lookup(r.Method + " " + pattern)
+			if !ok || pattern == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			deadline := time.Now().Add(d)
+
+			// Writers which don't support deadlines keep the server timeouts.
+			rc := http.NewResponseController(w)
+			_ = rc.SetReadDeadline(deadline)
+			_ = rc.SetWriteDeadline(deadline)
+
+			ctx, cancel := context.WithDeadline(r.Context(), deadline)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/timeout"
+)
+
+func TestTimeout(t *testing.T) {
+	var (
+		deadline    time.Time
+		hasDeadline bool
+	)
+
+	handler := func(_ http.ResponseWriter, r *http.Request) { deadline, hasDeadline = r.Context().Deadline() }
+
+	router := chi.NewRouter()
+	router.Use(Timeout(router, timeout.Timeouts{"GET /api/v1/queue/{id}/search": time.Minute}))
+	router.Get("/api/v1/queue/{id}/search", handler)
+	router.Get("/api/v1/queue/{id}", handler)
+
+	type tcase struct {
+		path         string
+		wantDeadline bool
+	}
+
+	tests := map[string]tcase{
+		"WithTimeout":    {path: "/api/v1/queue/abc/search", wantDeadline: true},
+		"WithoutTimeout": {path: "/api/v1/queue/abc"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			hasDeadline = false
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			td.Cmp(t, hasDeadline, tc.wantDeadline)
+
+			if tc.wantDeadline {
+				td.Cmp(t, time.Until(deadline), td.Between(50*time.Second, time.Minute))
+			}
+		})
+	}
+}
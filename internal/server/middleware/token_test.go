@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestBearerToken(t *testing.T) {
+	type tcase struct {
+		header   string
+		wantCode int
+	}
+
+	tests := map[string]tcase{
+		"Valid":           {header: "Bearer s3cret", wantCode: http.StatusOK},
+		"CaseInsensitive": {header: "bearer s3cret", wantCode: http.StatusOK},
+		"Missing":         {header: "", wantCode: http.StatusUnauthorized},
+		"Wrong":           {header: "Bearer guess", wantCode: http.StatusUnauthorized},
+		"Prefix":          {header: "Bearer s3cre", wantCode: http.StatusUnauthorized},
+		"Basic":           {header: "Basic s3cret", wantCode: http.StatusUnauthorized},
+	}
+
+	handler := BearerToken("s3cret")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+			if tc.header != "" {
+				r.Header.Set("Authorization", tc.header)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			td.Cmp(t, w.Code, tc.wantCode)
+		})
+	}
+}
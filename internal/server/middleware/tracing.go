@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing represents the middleware which traces HTTP requests with spans of the provider,
+// which continue traces of callers propagated in request headers. Spans are named
+// by the method and the route pattern, since request paths hold identifiers.
+func Tracing(provider trace.TracerProvider) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		// The route is only known once the router has matched the request.
+		named := func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+
+			if ctx := chi.RouteContext(r.Context()); ctx != nil {
+				if route := ctx.RoutePattern(); route != "" {
+					span := trace.SpanFromContext(r.Context())
+					span.SetName(r.Method + " " + route)
+					span.SetAttributes(semconv.HTTPRoute(route))
+				}
+			}
+		}
+
+		return otelhttp.NewHandler(http.HandlerFunc(named), "HTTP",
+			otelhttp.WithTracerProvider(provider),
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string { return "HTTP " + r.Method }),
+		)
+	}
+}
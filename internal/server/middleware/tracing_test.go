@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/maxatome/go-testdeep/td"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	router := chi.NewRouter()
+	router.Use(Tracing(provider))
+	router.Get("/api/v1/queue/{id}", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNotFound) })
+
+	type tcase struct {
+		path     string
+		wantName string
+	}
+
+	tests := map[string]tcase{
+		"Route":    {path: "/api/v1/queue/abc", wantName: "GET /api/v1/queue/{id}"},
+		"NotFound": {path: "/api/v1/unknown", wantName: "HTTP GET"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			spans := recorder.Ended()
+			td.Cmp(t, spans[len(spans)-1].Name(), tc.wantName)
+		})
+	}
+}
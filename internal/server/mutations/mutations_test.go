@@ -0,0 +1,115 @@
+package mutations
+
+import (
+	"database/sql"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/dbkit/litekit"
+)
+
+// releasedStorageMutations holds names of storage mutations as they were released
+// before names got zero-padded to keep their order past the ninth mutation.
+var releasedStorageMutations = []string{
+	"1_schema.sql",
+	"2_user.sql",
+	"3_queue_version.sql",
+	"4_policies.sql",
+	"5_queue_options.sql",
+	"6_queue_owner.sql",
+	"7_search_index.sql",
+	"8_queue_state.sql",
+	"9_queue_rate_limit.sql",
+}
+
+func TestStorageMutations_upgrade(t *testing.T) {
+	entries, readErr := fs.ReadDir(StorageMutations(), ".")
+	td.Require(t).CmpNoError(readErr)
+
+	current := make([]string, 0, len(entries))
+	for _, e := range entries {
+		current = append(current, e.Name())
+	}
+
+	// The evolver numbers mutations by the order of their names, and the schema version
+	// of the database is the number of the last applied one, so released mutations
+	// should keep their numbers.
+	for i, name := range releasedStorageMutations {
+		td.Cmp(t, mutationStem(current[i]), mutationStem(name), name)
+	}
+
+	conn := &litekit.Conn{DB: openDB(t)}
+
+	// Mutations are stubbed with tables named after them, so a mutation
+	// which is applied again fails with the existing table.
+	released := make(fstest.MapFS, len(releasedStorageMutations))
+	for _, name := range releasedStorageMutations {
+		released[name] = &fstest.MapFile{Data: []byte(mutationStub(name))}
+	}
+
+	upgraded := make(fstest.MapFS, len(current))
+	for _, name := range current {
+		upgraded[name] = &fstest.MapFile{Data: []byte(mutationStub(name))}
+	}
+
+	for _, mutations := range []fs.FS{released, upgraded} {
+		evolver, evolverErr := litekit.NewEvolver(conn, mutations)
+		td.Require(t).CmpNoError(evolverErr)
+		td.Require(t).CmpNoError(evolver.MutateSchema())
+	}
+
+	var version int
+
+	td.Require(t).CmpNoError(conn.QueryRow(`select version from schema_version where id = 0;`).Scan(&version))
+	td.Cmp(t, version, len(current))
+
+	rows, queryErr := conn.Query(`select name from sqlite_master where type = 'table' and name like 'mutation_%';`)
+	td.Require(t).CmpNoError(queryErr)
+
+	defer func() { _ = rows.Close() }()
+
+	var tables []string
+
+	for rows.Next() {
+		var table string
+
+		td.Require(t).CmpNoError(rows.Scan(&table))
+
+		tables = append(tables, table)
+	}
+
+	td.Require(t).CmpNoError(rows.Err())
+
+	want := make([]any, 0, len(current))
+	for _, name := range current {
+		want = append(want, "mutation_"+mutationStem(name))
+	}
+
+	td.Cmp(t, tables, td.Bag(want...))
+}
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, openErr := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "plainq.db")+"?_journal=WAL")
+	td.Require(t).CmpNoError(openErr)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+// mutationStem returns the name of the mutation without its number and extension.
+func mutationStem(name string) string {
+	_, stem, _ := strings.Cut(strings.TrimSuffix(name, ".sql"), "_")
+	return stem
+}
+
+func mutationStub(name string) string {
+	return `create table mutation_` + mutationStem(name) + ` (id int);`
+}
@@ -0,0 +1,2 @@
+alter table queue_properties
+    add column version int default 1 not null;
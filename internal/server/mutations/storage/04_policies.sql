@@ -0,0 +1,32 @@
+-- Named permission policies
+create table if not exists "policies"
+(
+    policy_id   varchar(26)                         not null,
+    policy_name text                                not null,
+    document    json      default '[]'              not null,
+    created_at  timestamp default current_timestamp not null,
+    updated_at  timestamp default current_timestamp not null,
+
+    constraint policies_pk
+        primary key (policy_id)
+);
+
+create unique index if not exists policies_name_uindex
+    on policies (policy_name);
+
+-- Role policies mapping
+create table if not exists "role_policies"
+(
+    role_id    varchar(26)                         not null,
+    policy_id  varchar(26)                         not null,
+    created_at timestamp default current_timestamp not null,
+
+    constraint role_policies_pk
+        primary key (role_id, policy_id),
+    constraint role_policies_role_fk
+        foreign key (role_id) references roles (role_id)
+            on delete cascade,
+    constraint role_policies_policy_fk
+        foreign key (policy_id) references policies (policy_id)
+            on delete cascade
+);
@@ -0,0 +1,14 @@
+alter table queue_properties
+    add column description text default '' not null;
+
+alter table queue_properties
+    add column tags json default '{}' not null;
+
+alter table queue_properties
+    add column fifo boolean default false not null;
+
+alter table queue_properties
+    add column delay_seconds int default 0 not null;
+
+alter table queue_properties
+    add column max_message_size_bytes int default 0 not null;
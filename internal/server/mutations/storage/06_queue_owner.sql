@@ -0,0 +1,23 @@
+alter table queue_properties
+    add column owner text default '' not null;
+
+-- Pending transfers of queue ownership
+create table if not exists "queue_transfers"
+(
+    transfer_id  varchar(26)                         not null,
+    queue_id     varchar(26)                         not null,
+    from_owner   text                                not null,
+    to_owner     text                                not null,
+    requested_by text                                not null,
+    created_at   timestamp default current_timestamp not null,
+    expires_at   timestamp                           not null,
+
+    constraint queue_transfers_pk
+        primary key (transfer_id),
+    constraint queue_transfers_queue_fk
+        foreign key (queue_id) references queue_properties (queue_id)
+            on delete cascade
+);
+
+create unique index if not exists queue_transfers_queue_id_uindex
+    on queue_transfers (queue_id);
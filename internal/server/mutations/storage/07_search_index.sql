@@ -0,0 +1,2 @@
+alter table queue_properties
+    add column search_index boolean default false not null;
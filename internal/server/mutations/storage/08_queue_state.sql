@@ -0,0 +1,4 @@
+-- Lifecycle state of the queue, see the v1.QueueState for values.
+-- Existing queues are active.
+alter table queue_properties
+    add column state integer default 2 not null;
@@ -0,0 +1,5 @@
+alter table queue_properties
+    add column rate_limit integer default 0 not null;
+
+alter table queue_properties
+    add column rate_limit_burst integer default 0 not null;
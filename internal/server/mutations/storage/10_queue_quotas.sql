@@ -0,0 +1,20 @@
+alter table queue_properties
+    add column max_messages integer default 0 not null;
+
+alter table queue_properties
+    add column max_bytes integer default 0 not null;
+
+alter table queue_properties
+    add column quota_policy integer default 0 not null;
+
+-- Number of messages and total size of message bodies stored in each queue,
+-- kept up to date by triggers of queue tables, so quotas are checked without counting.
+create table if not exists "queue_usage"
+(
+    queue_id varchar(26)       not null,
+    messages integer default 0 not null,
+    bytes    integer default 0 not null,
+
+    constraint queue_usage_pk
+        primary key (queue_id)
+);
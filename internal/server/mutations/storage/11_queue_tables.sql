@@ -0,0 +1,11 @@
+-- Schema version of each queue table, which is the number of queue table mutations
+-- applied to it. Queues without the record have their tables at the initial version.
+create table if not exists "queue_tables"
+(
+    queue_id   varchar(26)                         not null,
+    version    integer   default 0                 not null,
+    updated_at timestamp default current_timestamp not null,
+
+    constraint queue_tables_pk
+        primary key (queue_id)
+);
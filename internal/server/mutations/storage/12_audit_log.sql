@@ -0,0 +1,35 @@
+-- Append-only log of mutations of queues and their messages
+create table if not exists "audit_log"
+(
+    event_id   varchar(26)                         not null,
+    actor      text                                not null,
+    action     text                                not null,
+    target     text      default ''                not null,
+    detail     text      default ''                not null,
+    created_at timestamp default current_timestamp not null,
+
+    constraint audit_log_pk
+        primary key (event_id)
+);
+
+create index if not exists audit_log_actor_index
+    on audit_log (actor, event_id);
+
+create index if not exists audit_log_target_index
+    on audit_log (target, event_id);
+
+create index if not exists audit_log_created_at_index
+    on audit_log (created_at);
+
+-- Recorded events can't be changed or removed
+create trigger if not exists audit_log_no_update
+    before update on audit_log
+begin
+    select raise(abort, 'audit log is append-only');
+end;
+
+create trigger if not exists audit_log_no_delete
+    before delete on audit_log
+begin
+    select raise(abort, 'audit log is append-only');
+end;
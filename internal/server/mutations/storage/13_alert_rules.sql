@@ -0,0 +1,18 @@
+-- Threshold rules over observed metrics evaluated by the alerting engine
+create table if not exists "alert_rules"
+(
+    rule_id     varchar(26)                         not null,
+    name        text                                not null,
+    metric      text                                not null,
+    queue_id    varchar(26) default ''              not null,
+    operator    integer                             not null,
+    threshold   real                                not null,
+    for_seconds integer   default 0                 not null,
+    webhook_url text      default ''                not null,
+    email       text      default ''                not null,
+    created_at  timestamp default current_timestamp not null,
+    updated_at  timestamp default current_timestamp not null,
+
+    constraint alert_rules_pk
+        primary key (rule_id)
+);
@@ -0,0 +1,56 @@
+-- Service accounts of non-human clients which authenticate by API keys
+create table if not exists "service_accounts"
+(
+    account_id  varchar(26)                         not null,
+    name        text                                not null,
+    description text      default ''                not null,
+    created_at  timestamp default current_timestamp not null,
+
+    constraint service_accounts_pk
+        primary key (account_id)
+);
+
+create unique index if not exists service_accounts_name_uindex
+    on service_accounts (name);
+
+-- Service account roles mapping
+create table if not exists "service_account_roles"
+(
+    account_id varchar(26)                         not null,
+    role_id    varchar(26)                         not null,
+    created_at timestamp default current_timestamp not null,
+
+    constraint service_account_roles_pk
+        primary key (account_id, role_id),
+    constraint service_account_roles_account_fk
+        foreign key (account_id) references service_accounts (account_id)
+            on delete cascade,
+    constraint service_account_roles_role_fk
+        foreign key (role_id) references roles (role_id)
+            on delete cascade
+);
+
+-- API keys of service accounts, only SHA-256 hashes of keys are stored
+create table if not exists "api_keys"
+(
+    key_id     varchar(26)                         not null,
+    account_id varchar(26)                         not null,
+    name       text      default ''                not null,
+    prefix     text                                not null,
+    hash       text                                not null,
+    created_at timestamp default current_timestamp not null,
+    expires_at timestamp,
+    revoked_at timestamp,
+
+    constraint api_keys_pk
+        primary key (key_id),
+    constraint api_keys_account_fk
+        foreign key (account_id) references service_accounts (account_id)
+            on delete cascade
+);
+
+create unique index if not exists api_keys_prefix_uindex
+    on api_keys (prefix);
+
+create index if not exists api_keys_account_id_index
+    on api_keys (account_id);
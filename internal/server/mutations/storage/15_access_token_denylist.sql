@@ -0,0 +1,13 @@
+-- Access tokens revoked before they expire, e.g. on sign-out, only SHA-256 hashes of tokens are stored
+create table if not exists "access_token_denylist"
+(
+    token_hash varchar(64)                         not null,
+    expires_at timestamp                           not null,
+    created_at timestamp default current_timestamp not null,
+
+    constraint access_token_denylist_pk
+        primary key (token_hash)
+);
+
+create index if not exists access_token_denylist_expires_at_index
+    on access_token_denylist (expires_at);
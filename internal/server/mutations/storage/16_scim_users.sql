@@ -0,0 +1,6 @@
+-- Users provisioned by identity providers over SCIM, deactivated users can't sign in and have no roles
+alter table users
+    add column external_id text default '' not null;
+
+alter table users
+    add column active boolean default true not null;
@@ -0,0 +1,32 @@
+-- Password reset codes of local accounts, only SHA-256 hashes of codes are stored
+create table if not exists "password_reset_codes"
+(
+    code_hash  varchar(64)                         not null,
+    user_id    varchar(26)                         not null,
+    expires_at timestamp                           not null,
+    used_at    timestamp,
+    created_at timestamp default current_timestamp not null,
+
+    constraint password_reset_codes_pk
+        primary key (code_hash),
+    constraint password_reset_codes_user_fk
+        foreign key (user_id) references users (user_id)
+            on delete cascade
+);
+
+create index if not exists password_reset_codes_user_id_index
+    on password_reset_codes (user_id);
+
+-- Tokens of subjects issued at or before the time they were revoked, e.g. on password reset
+create table if not exists "subject_token_revocations"
+(
+    subject    text      not null,
+    revoked_at timestamp not null,
+    expires_at timestamp not null,
+
+    constraint subject_token_revocations_pk
+        primary key (subject)
+);
+
+create index if not exists subject_token_revocations_expires_at_index
+    on subject_token_revocations (expires_at);
@@ -0,0 +1,18 @@
+-- Email verification codes of local accounts, only SHA-256 hashes of codes are stored
+create table if not exists "email_verification_codes"
+(
+    code_hash  varchar(64)                         not null,
+    user_id    varchar(26)                         not null,
+    expires_at timestamp                           not null,
+    used_at    timestamp,
+    created_at timestamp default current_timestamp not null,
+
+    constraint email_verification_codes_pk
+        primary key (code_hash),
+    constraint email_verification_codes_user_fk
+        foreign key (user_id) references users (user_id)
+            on delete cascade
+);
+
+create index if not exists email_verification_codes_user_id_index
+    on email_verification_codes (user_id, created_at);
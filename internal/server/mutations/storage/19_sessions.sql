@@ -0,0 +1,21 @@
+-- Sessions of users, which are tokens issued on sign-in, only SHA-256 hashes of tokens are stored
+create table if not exists "sessions"
+(
+    session_id  text                                not null,
+    subject     text                                not null,
+    token_hash  varchar(64)                         not null,
+    user_agent  text      default ''                not null,
+    remote_addr text      default ''                not null,
+    created_at  timestamp default current_timestamp not null,
+    expires_at  timestamp                           not null,
+    revoked_at  timestamp,
+
+    constraint sessions_pk
+        primary key (session_id)
+);
+
+create index if not exists sessions_subject_index
+    on sessions (subject, created_at);
+
+create index if not exists sessions_expires_at_index
+    on sessions (expires_at);
@@ -0,0 +1,3 @@
+-- Display names of users, which users set in their profiles
+alter table users
+    add column name text default '' not null;
@@ -0,0 +1,7 @@
+-- Clients which have created queues
+alter table queue_properties
+    add column created_by text default '' not null;
+
+-- Permission to update queues, which owners of queues are granted
+alter table queue_permissions
+    add column can_update boolean default false not null;
@@ -0,0 +1,19 @@
+-- Long-running operations performed in background, e.g. asynchronous purges
+create table if not exists "jobs"
+(
+    job_id     varchar(26)                         not null,
+    kind       text                                not null,
+    queue_id   varchar(26) default ''              not null,
+    state      integer                             not null,
+    processed  integer   default 0                 not null,
+    total      integer   default 0                 not null,
+    error      text      default ''                not null,
+    created_at timestamp default current_timestamp not null,
+    updated_at timestamp default current_timestamp not null,
+
+    constraint jobs_pk
+        primary key (job_id)
+);
+
+create index if not exists jobs_state_index
+    on jobs (state);
@@ -0,0 +1,14 @@
+-- Messages evicted from queues with the archive eviction policy
+create table if not exists "archive"
+(
+    queue_id    varchar(26)                         not null,
+    msg_id      text                                not null,
+    msg_body    blob                                not null,
+    attempts    integer   default 0                 not null,
+    reason      text                                not null,
+    created_at  timestamp                           not null,
+    archived_at timestamp default current_timestamp not null,
+
+    constraint archive_pk
+        primary key (queue_id, msg_id)
+);
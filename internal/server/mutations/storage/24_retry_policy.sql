@@ -0,0 +1,8 @@
+alter table queue_properties
+    add column retry_initial_backoff_seconds integer default 0 not null;
+
+alter table queue_properties
+    add column retry_multiplier real default 0 not null;
+
+alter table queue_properties
+    add column retry_max_backoff_seconds integer default 0 not null;
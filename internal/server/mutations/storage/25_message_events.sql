@@ -0,0 +1,23 @@
+-- Lifecycle events of messages kept in a ring buffer: each event replaces the oldest one
+-- in the slot its sequence number maps to, so the table never outgrows the number of slots.
+create table if not exists "message_events"
+(
+    slot       integer                             not null,
+    seq        integer                             not null,
+    queue_id   varchar(26)                         not null,
+    msg_id     text                                not null,
+    kind       integer                             not null,
+    attempts   integer   default 0                 not null,
+    visible_at timestamp,
+    detail     text      default ''                not null,
+    created_at timestamp default current_timestamp not null,
+
+    constraint message_events_pk
+        primary key (slot)
+);
+
+create unique index if not exists message_events_seq_index
+    on message_events (seq);
+
+create index if not exists message_events_msg_index
+    on message_events (queue_id, msg_id, seq);
@@ -0,0 +1,11 @@
+-- Leases of servers sharing the database: the server which holds
+-- the lease is the active one, and others stand by until it expires.
+create table if not exists "leases"
+(
+    name       text    not null,
+    holder     text    not null,
+    expires_at integer not null,
+
+    constraint leases_pk
+        primary key (name)
+);
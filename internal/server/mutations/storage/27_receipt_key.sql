@@ -0,0 +1,12 @@
+-- The key of receipt handle signatures, which is shared by servers of the database,
+-- so handles issued by one server are accepted by others and survive restarts.
+create table if not exists "receipt_key"
+(
+    id  int  default 0 not null,
+    key blob           not null,
+
+    constraint receipt_key_pk
+        primary key (id)
+);
+
+insert into receipt_key (id, key) values (0, randomblob(32));
@@ -0,0 +1,4 @@
+-- Creators own queues unless owners are given, so owners are the only clients
+-- granted permissions on queues by their creation, which transfers move.
+alter table queue_properties
+    drop column created_by;
@@ -12,7 +12,7 @@ create table if not exists "schema_version"
 create unique index if not exists id_uindex
     on schema_version (id);
 
-insert into schema_version default
+insert or ignore into schema_version default
 values;
 
 ---
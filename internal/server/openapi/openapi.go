@@ -0,0 +1,239 @@
+// Package openapi builds the OpenAPI 3 document of the HTTP API from descriptions
+// of its routes. Schemas of requests and responses are generated from protobuf
+// messages the routes exchange, following the protobuf JSON mapping, so the
+// document doesn't drift from messages as they change.
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Version is the version of the OpenAPI specification the document follows.
+const Version = "3.0.3"
+
+// bearerAuth is the name of the security scheme of bearer tokens and API keys.
+const bearerAuth = "bearerAuth"
+
+// pathParam matches parameters of chi route patterns, e.g. {id}.
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// Document represents the OpenAPI document.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+}
+
+// Info represents the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem holds operations of the path by lowercase HTTP methods.
+type PathItem map[string]*Operation
+
+// Operation represents the single API operation on the path.
+type Operation struct {
+	Tags        []string               `json:"tags,omitempty"`
+	Summary     string                 `json:"summary,omitempty"`
+	OperationID string                 `json:"operationId,omitempty"`
+	Parameters  []Parameter            `json:"parameters,omitempty"`
+	RequestBody *RequestBody           `json:"requestBody,omitempty"`
+	Responses   map[string]Response    `json:"responses"`
+	Security    *[]map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter represents the path or query parameter of the operation.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody represents the request body of the operation.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response represents the response of the operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType represents the content of the request or response.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds reusable schemas and security schemes of the document.
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme represents the authentication scheme of the API.
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Route describes the route of the HTTP API.
+type Route struct {
+	// Method is the HTTP method of the route, e.g. http.MethodPost.
+	Method string
+
+	// Pattern is the chi pattern of the route, e.g. "/api/v1/queue/{id}".
+	// Its parameters are documented as required string path parameters.
+	Pattern string
+
+	// Tag groups routes in the document, e.g. "Queues".
+	Tag string
+
+	// Summary is the short description of the route.
+	Summary string
+
+	// Query holds query parameters of the route.
+	Query []Parameter
+
+	// Request is the message of the request body, nil when the route has no body.
+	Request proto.Message
+
+	// Response is the message of the response body, nil when the route responds without one.
+	Response proto.Message
+
+	// ResponseSchema is the schema of the response body which isn't a message, used when Response is nil.
+	ResponseSchema *Schema
+
+	// Status is the status code of the successful response, http.StatusOK by default.
+	Status int
+
+	// Public routes don't require authentication.
+	Public bool
+}
+
+// Query returns the query parameter of the given type, e.g. "string", "integer" or "boolean".
+func Query(name, typ, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: typ}}
+}
+
+// Build returns the document which describes the routes.
+func Build(info Info, routes []Route) *Document {
+	doc := Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuth: {
+					Type:        "http",
+					Scheme:      "bearer",
+					Description: "Token issued on sign-in or API key of a service account (pq_...).",
+				},
+			},
+		},
+		Security: []map[string][]string{{bearerAuth: {}}},
+	}
+
+	schemas := schemaSet{schemas: doc.Components.Schemas}
+
+	for _, route := range routes {
+		item, ok := doc.Paths[route.Pattern]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[route.Pattern] = item
+		}
+
+		item[strings.ToLower(route.Method)] = schemas.operation(route)
+	}
+
+	return &doc
+}
+
+// operation returns the operation of the route, adding schemas of its messages to the set.
+func (s schemaSet) operation(route Route) *Operation {
+	op := Operation{
+		Tags:        []string{route.Tag},
+		Summary:     route.Summary,
+		OperationID: operationID(route),
+		Responses:   make(map[string]Response),
+	}
+
+	if route.Tag == "" {
+		op.Tags = nil
+	}
+
+	if route.Public {
+		op.Security = &[]map[string][]string{}
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(route.Pattern, -1) {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	op.Parameters = append(op.Parameters, route.Query...)
+
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(s.message(route.Request.ProtoReflect().Descriptor())),
+		}
+	}
+
+	statusCode := route.Status
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	success := Response{Description: http.StatusText(statusCode)}
+
+	switch {
+	case route.Response != nil:
+		success.Content = jsonContent(s.message(route.Response.ProtoReflect().Descriptor()))
+
+	case route.ResponseSchema != nil:
+		success.Content = jsonContent(route.ResponseSchema)
+	}
+
+	op.Responses[strconv.Itoa(statusCode)] = success
+
+	// Errors are responded with the status text.
+	op.Responses["default"] = Response{
+		Description: "Error",
+		Content:     map[string]MediaType{"text/plain": {Schema: &Schema{Type: "string"}}},
+	}
+
+	return &op
+}
+
+// operationID returns the unique id of the route operation, e.g. "get_api_v1_queue_id".
+func operationID(route Route) string {
+	parts := strings.FieldsFunc(pathParam.ReplaceAllString(route.Pattern, "$1"), func(r rune) bool {
+		return r == '/' || r == '-' || r == '.'
+	})
+
+	return strings.ToLower(route.Method) + "_" + strings.Join(parts, "_")
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
@@ -0,0 +1,91 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+func TestBuild(t *testing.T) {
+	routes := []Route{
+		{
+			Method: http.MethodPost, Pattern: "/api/v1/queue", Tag: "Queues", Summary: "Create a queue",
+			Request: &v1.CreateQueueRequest{}, Response: &v1.CreateQueueResponse{}, Status: http.StatusCreated,
+		},
+		{
+			Method: http.MethodGet, Pattern: "/api/v1/queue/{id}/messages/{message}", Tag: "Messages",
+			Query:    []Parameter{Query("include_body", "boolean", "include the body")},
+			Response: &v1.GetMessageResponse{},
+		},
+		{
+			Method: http.MethodPost, Pattern: "/api/v1/auth/sign-in", Public: true,
+			Request: &v1.SignInRequest{}, Response: &v1.SignInResponse{},
+		},
+		{
+			Method: http.MethodDelete, Pattern: "/api/v1/queue/{id}/permissions/{role}", Status: http.StatusNoContent,
+		},
+	}
+
+	doc := Build(Info{Title: "PlainQ", Version: "v1"}, routes)
+
+	td.Cmp(t, doc.OpenAPI, Version)
+	td.Cmp(t, doc.Paths, td.Len(4))
+
+	create := doc.Paths["/api/v1/queue"]["post"]
+	td.Cmp(t, create.OperationID, "post_api_v1_queue")
+	td.Cmp(t, create.RequestBody.Content["application/json"].Schema, &Schema{Ref: "#/components/schemas/v1.CreateQueueRequest"})
+	td.Cmp(t, create.Responses, td.ContainsKey("201"))
+	td.Cmp(t, create.Security, td.Nil())
+
+	get := doc.Paths["/api/v1/queue/{id}/messages/{message}"]["get"]
+	td.Cmp(t, get.OperationID, "get_api_v1_queue_id_messages_message")
+	td.Cmp(t, get.Parameters, []Parameter{
+		{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+		{Name: "message", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+		{Name: "include_body", In: "query", Description: "include the body", Schema: &Schema{Type: "boolean"}},
+	})
+
+	signIn := doc.Paths["/api/v1/auth/sign-in"]["post"]
+	td.Cmp(t, *signIn.Security, td.Empty())
+
+	deletePermission := doc.Paths["/api/v1/queue/{id}/permissions/{role}"]["delete"]
+	td.Cmp(t, deletePermission.RequestBody, td.Nil())
+	td.Cmp(t, deletePermission.Responses["204"], Response{Description: "No Content"})
+
+	createSchema := doc.Components.Schemas["v1.CreateQueueRequest"]
+	td.Cmp(t, createSchema.Properties["queueName"], &Schema{Type: "string"})
+	td.Cmp(t, createSchema.Properties["retentionPeriodSeconds"], &Schema{Type: "string", Format: "uint64"})
+	td.Cmp(t, createSchema.Properties["maxReceiveAttempts"], &Schema{Type: "integer", Format: "int64"})
+	td.Cmp(t, createSchema.Properties["tags"], &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}})
+	td.Cmp(t, createSchema.Properties["evictionPolicy"].Enum, td.Contains("EVICTION_POLICY_DROP"))
+
+	// Every referenced schema is defined.
+	data, err := json.Marshal(doc)
+	td.Require(t).CmpNoError(err)
+
+	for _, ref := range strings.Split(string(data), `"$ref":"#/components/schemas/`)[1:] {
+		name := ref[:strings.IndexByte(ref, '"')]
+		td.Cmp(t, doc.Components.Schemas, td.ContainsKey(name), name)
+	}
+}
+
+func Test_wellKnown(t *testing.T) {
+	doc := Build(Info{}, []Route{{Method: http.MethodGet, Pattern: "/messages", Response: &v1.GetMessageResponse{}}})
+
+	var timestamps int
+
+	for _, schema := range doc.Components.Schemas {
+		for _, property := range schema.Properties {
+			if property.Format == "date-time" {
+				timestamps++
+			}
+		}
+	}
+
+	td.Cmp(t, doc.Components.Schemas, td.Not(td.ContainsKey("google.protobuf.Timestamp")))
+	td.Cmp(t, timestamps, td.Gt(0))
+}
@@ -0,0 +1,167 @@
+package openapi
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Schema represents the schema of JSON values.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// schemaSet generates schemas of messages, which are stored
+// as components and referenced by their full names.
+type schemaSet struct {
+	schemas map[string]*Schema
+}
+
+// message returns the reference to the schema of the message, generating it when needed.
+func (s schemaSet) message(md protoreflect.MessageDescriptor) *Schema {
+	if schema, ok := wellKnown(md); ok {
+		return schema
+	}
+
+	name := string(md.FullName())
+	ref := Schema{Ref: "#/components/schemas/" + name}
+
+	if _, ok := s.schemas[name]; ok {
+		return &ref
+	}
+
+	schema := Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	// The schema is stored before fields, so recursive messages refer to it.
+	s.schemas[name] = &schema
+
+	fields := md.Fields()
+
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		schema.Properties[fd.JSONName()] = s.field(fd)
+	}
+
+	return &ref
+}
+
+// field returns the schema of the field value.
+func (s schemaSet) field(fd protoreflect.FieldDescriptor) *Schema {
+	switch {
+	case fd.IsMap():
+		return &Schema{Type: "object", AdditionalProperties: s.value(fd.MapValue())}
+
+	case fd.IsList():
+		return &Schema{Type: "array", Items: s.value(fd)}
+
+	default:
+		return s.value(fd)
+	}
+}
+
+// value returns the schema of the single value of the field, as encoded by the protobuf JSON mapping.
+func (s schemaSet) value(fd protoreflect.FieldDescriptor) *Schema {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "int64"}
+
+	// 64-bit integers are encoded as strings to keep their precision in JavaScript.
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return &Schema{Type: "string", Format: "int64"}
+
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Type: "string", Format: "uint64"}
+
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte"}
+
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		schema := Schema{Type: "string", Enum: make([]string, 0, values.Len())}
+
+		for i := range values.Len() {
+			schema.Enum = append(schema.Enum, string(values.Get(i).Name()))
+		}
+
+		return &schema
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return s.message(fd.Message())
+
+	default:
+		return &Schema{}
+	}
+}
+
+// wellKnown returns the schema of well-known messages, which have special JSON encoding.
+func wellKnown(md protoreflect.MessageDescriptor) (*Schema, bool) {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return &Schema{Type: "string", Format: "date-time"}, true
+
+	case "google.protobuf.Duration":
+		return &Schema{Type: "string", Description: "Duration in seconds with the 's' suffix, e.g. '1.5s'."}, true
+
+	case "google.protobuf.FieldMask":
+		return &Schema{Type: "string", Description: "Comma separated lowerCamelCase field paths."}, true
+
+	case "google.protobuf.Struct", "google.protobuf.Empty":
+		return &Schema{Type: "object"}, true
+
+	case "google.protobuf.Value":
+		return &Schema{}, true
+
+	case "google.protobuf.ListValue":
+		return &Schema{Type: "array", Items: &Schema{}}, true
+
+	case "google.protobuf.StringValue":
+		return &Schema{Type: "string"}, true
+
+	case "google.protobuf.BoolValue":
+		return &Schema{Type: "boolean"}, true
+
+	case "google.protobuf.Int32Value":
+		return &Schema{Type: "integer", Format: "int32"}, true
+
+	case "google.protobuf.UInt32Value":
+		return &Schema{Type: "integer", Format: "int64"}, true
+
+	case "google.protobuf.Int64Value":
+		return &Schema{Type: "string", Format: "int64"}, true
+
+	case "google.protobuf.UInt64Value":
+		return &Schema{Type: "string", Format: "uint64"}, true
+
+	case "google.protobuf.FloatValue":
+		return &Schema{Type: "number", Format: "float"}, true
+
+	case "google.protobuf.DoubleValue":
+		return &Schema{Type: "number", Format: "double"}, true
+
+	case "google.protobuf.BytesValue":
+		return &Schema{Type: "string", Format: "byte"}, true
+
+	default:
+		return nil, false
+	}
+}
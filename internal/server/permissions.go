@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/plainq/plainq/internal/server/rbac"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+// setQueuePermission validates and grants permissions on the queue to the role,
+// which may be the role of the team provisioned as the group of the directory.
+func (s *PlainQ) setQueuePermission(ctx context.Context, input *v1.QueuePermission) (*v1.QueuePermission, error) {
+	if err := validateQueueIDFromRequest(input); err != nil {
+		return nil, err
+	}
+
+	if input.GetRole() == "" {
+		return nil, fmt.Errorf("%w: role is empty", errkit.ErrInvalidArgument)
+	}
+
+	output, setErr := s.storage.SetQueuePermission(ctx, input)
+	if setErr != nil {
+		return nil, fmt.Errorf("grant queue permissions (id: %q): %w", input.GetQueueId(), setErr)
+	}
+
+	return output, nil
+}
+
+// listQueuePermissions returns permissions of roles on the queue.
+func (s *PlainQ) listQueuePermissions(ctx context.Context, queueID string) (*v1.ListQueuePermissionsResponse, error) {
+	if err := validateQueueID(queueID); err != nil {
+		return nil, err
+	}
+
+	permissions, listErr := s.storage.QueuePermissions(ctx, queueID)
+	if listErr != nil {
+		return nil, fmt.Errorf("list queue permissions (id: %q): %w", queueID, listErr)
+	}
+
+	return &v1.ListQueuePermissionsResponse{Permissions: permissions}, nil
+}
+
+// deleteQueuePermission revokes permissions of the role on the queue.
+func (s *PlainQ) deleteQueuePermission(ctx context.Context, queueID, role string) error {
+	if err := validateQueueID(queueID); err != nil {
+		return err
+	}
+
+	if role == "" {
+		return fmt.Errorf("%w: role is empty", errkit.ErrInvalidArgument)
+	}
+
+	if err := s.storage.DeleteQueuePermission(ctx, queueID, role); err != nil {
+		return fmt.Errorf("revoke queue permissions (id: %q): %w", queueID, err)
+	}
+
+	return nil
+}
+
+// effectivePermissions returns permissions of the subject combined from all its roles,
+// on the queue when the queue id is given, or on all queues the subject is allowed to use.
+func (s *PlainQ) effectivePermissions(ctx context.Context, subject, queueID string) (*v1.EffectivePermissions, error) {
+	if subject == "" {
+		return nil, fmt.Errorf("%w: subject is empty", errkit.ErrInvalidArgument)
+	}
+
+	if queueID != "" {
+		if err := validateQueueID(queueID); err != nil {
+			return nil, err
+		}
+	}
+
+	output, permissionsErr := s.storage.EffectivePermissions(ctx, subject, queueID)
+	if permissionsErr != nil {
+		return nil, fmt.Errorf("resolve permissions of %q: %w", subject, permissionsErr)
+	}
+
+	return output, nil
+}
+
+// applyTemplate applies the standard template of permissions to the role.
+func (s *PlainQ) applyTemplate(ctx context.Context, role, name string) error {
+	if role == "" {
+		return fmt.Errorf("%w: role is empty", errkit.ErrInvalidArgument)
+	}
+
+	template, findErr := rbac.FindTemplate(name)
+	if findErr != nil {
+		return findErr
+	}
+
+	if err := s.storage.ApplyTemplate(ctx, role, template); err != nil {
+		return fmt.Errorf("apply template %q to role %q: %w", name, role, err)
+	}
+
+	return nil
+}
+
+// permissionTemplates returns standard templates of permissions.
+func permissionTemplates() *v1.ListPermissionTemplatesResponse {
+	output := v1.ListPermissionTemplatesResponse{
+		Templates: make([]*v1.PermissionTemplate, 0, len(rbac.Templates)),
+	}
+
+	for _, t := range rbac.Templates {
+		template := v1.PermissionTemplate{Name: t.Name}
+
+		for _, op := range t.Operations {
+			template.Operations = append(template.Operations, string(op))
+		}
+
+		output.Templates = append(output.Templates, &template)
+	}
+
+	return &output
+}
+
+// createRole creates the role, which is granted nothing until permissions
+// on queues are set for it or a template is applied to it.
+func (s *PlainQ) createRole(ctx context.Context, name string) (*v1.Role, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: role is empty", errkit.ErrInvalidArgument)
+	}
+
+	role, createErr := s.storage.CreateRole(ctx, name)
+	if createErr != nil {
+		return nil, fmt.Errorf("create role %q: %w", name, createErr)
+	}
+
+	return role, nil
+}
+
+// deleteRole deletes the role with its permissions and assignments.
+// The rbac.AdminRole can't be deleted, administrators would be locked out.
+func (s *PlainQ) deleteRole(ctx context.Context, name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: role is empty", errkit.ErrInvalidArgument)
+	}
+
+	if name == rbac.AdminRole {
+		return fmt.Errorf("%w: role %q can't be deleted", errkit.ErrInvalidArgument, name)
+	}
+
+	if err := s.storage.DeleteRole(ctx, name); err != nil {
+		return fmt.Errorf("delete role %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// assignRole assigns the role to the user.
+func (s *PlainQ) assignRole(ctx context.Context, email, role string) error {
+	if err := validateRoleAssignment(email, role); err != nil {
+		return err
+	}
+
+	if err := s.storage.AssignRole(ctx, email, role); err != nil {
+		return fmt.Errorf("assign role %q to %q: %w", role, email, err)
+	}
+
+	return nil
+}
+
+// unassignRole takes the role away from the user.
+func (s *PlainQ) unassignRole(ctx context.Context, email, role string) error {
+	if err := validateRoleAssignment(email, role); err != nil {
+		return err
+	}
+
+	if err := s.storage.UnassignRole(ctx, email, role); err != nil {
+		return fmt.Errorf("unassign role %q from %q: %w", role, email, err)
+	}
+
+	return nil
+}
+
+func validateRoleAssignment(email, role string) error {
+	if email == "" {
+		return fmt.Errorf("%w: email is empty", errkit.ErrInvalidArgument)
+	}
+
+	if role == "" {
+		return fmt.Errorf("%w: role is empty", errkit.ErrInvalidArgument)
+	}
+
+	return nil
+}
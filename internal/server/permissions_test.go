@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/rbac"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+)
+
+func TestPlainQ_setQueuePermission(t *testing.T) {
+	type tcase struct {
+		input   *v1.QueuePermission
+		wantErr error
+	}
+
+	queueID := idkit.XID()
+
+	tests := map[string]tcase{
+		"OK": {
+			input:   &v1.QueuePermission{QueueId: queueID, Role: "payments", Send: true},
+			wantErr: nil,
+		},
+
+		"InvalidQueueID": {
+			input:   &v1.QueuePermission{QueueId: "invalid", Role: "payments"},
+			wantErr: pqerr.ErrInvalidID,
+		},
+
+		"EmptyRole": {
+			input:   &v1.QueuePermission{QueueId: queueID},
+			wantErr: errkit.ErrInvalidArgument,
+		},
+
+		"UnknownRole": {
+			input:   &v1.QueuePermission{QueueId: queueID, Role: "unknown"},
+			wantErr: errkit.ErrNotFound,
+		},
+	}
+
+	server := PlainQ{
+		storage: &mockStorage{
+			setPermissionFunc: func(_ context.Context, permission *v1.QueuePermission) (*v1.QueuePermission, error) {
+				if permission.GetRole() != "payments" {
+					return nil, errkit.ErrNotFound
+				}
+
+				return permission, nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := server.setQueuePermission(context.Background(), tc.input)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output, tc.input)
+		})
+	}
+}
+
+func TestPlainQ_effectivePermissions(t *testing.T) {
+	type tcase struct {
+		subject string
+		queueID string
+		wantErr error
+	}
+
+	tests := map[string]tcase{
+		"AllQueues":      {subject: "bob@example.com"},
+		"Queue":          {subject: "bob@example.com", queueID: idkit.XID()},
+		"EmptySubject":   {queueID: idkit.XID(), wantErr: errkit.ErrInvalidArgument},
+		"InvalidQueueID": {subject: "bob@example.com", queueID: "invalid", wantErr: pqerr.ErrInvalidID},
+	}
+
+	server := PlainQ{
+		storage: &mockStorage{
+			effectivePermsFunc: func(_ context.Context, subject, _ string) (*v1.EffectivePermissions, error) {
+				return &v1.EffectivePermissions{Subject: subject}, nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := server.effectivePermissions(context.Background(), tc.subject, tc.queueID)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output.GetSubject(), tc.subject)
+		})
+	}
+}
+
+func TestPlainQ_applyTemplate(t *testing.T) {
+	type tcase struct {
+		role     string
+		template string
+		wantErr  error
+	}
+
+	tests := map[string]tcase{
+		"OK":              {role: "payments", template: "producer"},
+		"EmptyRole":       {template: "producer", wantErr: errkit.ErrInvalidArgument},
+		"UnknownTemplate": {role: "payments", template: "superuser", wantErr: errkit.ErrNotFound},
+		"UnknownRole":     {role: "unknown", template: "consumer", wantErr: errkit.ErrNotFound},
+	}
+
+	var applied []string
+
+	server := PlainQ{
+		storage: &mockStorage{
+			applyTemplateFunc: func(_ context.Context, role string, template *rbac.Template) error {
+				if role != "payments" {
+					return errkit.ErrNotFound
+				}
+
+				applied = append(applied, template.Name)
+
+				return nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.CmpErrorIs(t, server.applyTemplate(context.Background(), tc.role, tc.template), tc.wantErr)
+		})
+	}
+
+	td.Cmp(t, applied, []string{"producer"})
+}
+
+func TestPlainQ_deleteRole(t *testing.T) {
+	type tcase struct {
+		name    string
+		wantErr error
+	}
+
+	tests := map[string]tcase{
+		"OK":        {name: "payments"},
+		"EmptyName": {name: "", wantErr: errkit.ErrInvalidArgument},
+		"Admin":     {name: rbac.AdminRole, wantErr: errkit.ErrInvalidArgument},
+		"Unknown":   {name: "unknown", wantErr: errkit.ErrNotFound},
+	}
+
+	server := PlainQ{
+		storage: &mockStorage{
+			deleteRoleFunc: func(_ context.Context, name string) error {
+				if name != "payments" {
+					return errkit.ErrNotFound
+				}
+
+				return nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := server.deleteRole(context.Background(), tc.name)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+		})
+	}
+}
+
+func TestPlainQ_assignRole(t *testing.T) {
+	type tcase struct {
+		email   string
+		role    string
+		wantErr error
+	}
+
+	tests := map[string]tcase{
+		"OK":          {email: "bob@example.com", role: "payments"},
+		"EmptyEmail":  {role: "payments", wantErr: errkit.ErrInvalidArgument},
+		"EmptyRole":   {email: "bob@example.com", wantErr: errkit.ErrInvalidArgument},
+		"UnknownUser": {email: "eve@example.com", role: "payments", wantErr: errkit.ErrNotFound},
+	}
+
+	var assigned []string
+
+	server := PlainQ{
+		storage: &mockStorage{
+			assignRoleFunc: func(_ context.Context, email, role string) error {
+				if email != "bob@example.com" {
+					return errkit.ErrNotFound
+				}
+
+				assigned = append(assigned, role+"/"+email)
+
+				return nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := server.assignRole(context.Background(), tc.email, tc.role)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+		})
+	}
+
+	td.Cmp(t, assigned, []string{"payments/bob@example.com"})
+}
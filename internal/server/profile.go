@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"unicode/utf8"
+
+	"github.com/plainq/plainq/internal/server/auth"
+	"github.com/plainq/plainq/internal/server/identity"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+// maxAccountNameLength limits the length of display names of users.
+const maxAccountNameLength = 256
+
+// account returns the profile of the user the call is authenticated as.
+// Service accounts and clients with certificates are not users and have no profiles.
+func (s *PlainQ) account(ctx context.Context) (*v1.AccountProfile, error) {
+	id, ok := identity.FromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%w: authentication is not enabled", errkit.ErrUnavailable)
+	}
+
+	if id.Method != identity.MethodJWT {
+		return nil, fmt.Errorf("%w: %q is authenticated by %s, only users have profiles",
+			errkit.ErrInvalidArgument, id.Name, id.Method,
+		)
+	}
+
+	output, accountErr := s.storage.Account(ctx, id.Name)
+	if accountErr != nil {
+		return nil, fmt.Errorf("get account: %w", accountErr)
+	}
+
+	return output, nil
+}
+
+// updateAccount validates and updates the profile of the user of the token with the claims.
+// The changed email ends the use of the previous one: its tokens are revoked and the new email
+// should be verified.
+func (s *PlainQ) updateAccount(ctx context.Context, claims *auth.Claims, input *v1.UpdateAccountRequest) (*v1.AccountProfile, error) {
+	if err := validateAccountUpdate(input); err != nil {
+		return nil, err
+	}
+
+	output, updateErr := s.storage.UpdateAccount(ctx, claims.Subject, input)
+	if updateErr != nil {
+		return nil, fmt.Errorf("update account: %w", updateErr)
+	}
+
+	if output.GetEmail() == claims.Subject {
+		return output, nil
+	}
+
+	if err := s.authn.ReverifyEmail(ctx, claims.Subject, output.GetEmail()); err != nil {
+		return nil, fmt.Errorf("email has been changed: %w", err)
+	}
+
+	return output, nil
+}
+
+// validateAccountUpdate validates fields of the account update which are set.
+func validateAccountUpdate(input *v1.UpdateAccountRequest) error {
+	if input.Name != nil && utf8.RuneCountInString(input.GetName()) > maxAccountNameLength {
+		return fmt.Errorf("%w: name should be at most %d characters long", errkit.ErrInvalidArgument, maxAccountNameLength)
+	}
+
+	if input.Email != nil {
+		addr, err := mail.ParseAddress(input.GetEmail())
+		if err != nil || addr.Address != input.GetEmail() {
+			return fmt.Errorf("%w: invalid email %q", errkit.ErrInvalidArgument, input.GetEmail())
+		}
+	}
+
+	return nil
+}
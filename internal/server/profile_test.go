@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/identity"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_validateAccountUpdate(t *testing.T) {
+	type tcase struct {
+		input   *v1.UpdateAccountRequest
+		wantErr error
+	}
+
+	tests := map[string]tcase{
+		"Empty": {
+			input:   &v1.UpdateAccountRequest{},
+			wantErr: nil,
+		},
+
+		"Name": {
+			input:   &v1.UpdateAccountRequest{Name: proto.String("Ada Lovelace")},
+			wantErr: nil,
+		},
+
+		"EmptyName": {
+			input:   &v1.UpdateAccountRequest{Name: proto.String("")},
+			wantErr: nil,
+		},
+
+		"LongName": {
+			input:   &v1.UpdateAccountRequest{Name: proto.String(strings.Repeat("a", maxAccountNameLength+1))},
+			wantErr: errkit.ErrInvalidArgument,
+		},
+
+		"Email": {
+			input:   &v1.UpdateAccountRequest{Email: proto.String("ada@example.com")},
+			wantErr: nil,
+		},
+
+		"EmptyEmail": {
+			input:   &v1.UpdateAccountRequest{Email: proto.String("")},
+			wantErr: errkit.ErrInvalidArgument,
+		},
+
+		"InvalidEmail": {
+			input:   &v1.UpdateAccountRequest{Email: proto.String("ada")},
+			wantErr: errkit.ErrInvalidArgument,
+		},
+
+		"EmailWithName": {
+			input:   &v1.UpdateAccountRequest{Email: proto.String("Ada <ada@example.com>")},
+			wantErr: errkit.ErrInvalidArgument,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validateAccountUpdate(tc.input)
+			td.CmpErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestPlainQ_account(t *testing.T) {
+	type tcase struct {
+		ctx     context.Context
+		want    *v1.AccountProfile
+		wantErr error
+	}
+
+	profile := v1.AccountProfile{UserId: "u1", Email: "ada@example.com", Roles: []string{"payments"}}
+
+	tests := map[string]tcase{
+		"OK": {
+			ctx:  identity.WithIdentity(context.Background(), identity.Identity{Name: "ada@example.com", Method: identity.MethodJWT}),
+			want: &profile,
+		},
+
+		"UnknownUser": {
+			ctx:     identity.WithIdentity(context.Background(), identity.Identity{Name: "eve@example.com", Method: identity.MethodJWT}),
+			wantErr: errkit.ErrNotFound,
+		},
+
+		"ServiceAccount": {
+			ctx:     identity.WithIdentity(context.Background(), identity.Identity{Name: "orders", Method: identity.MethodAPIKey}),
+			wantErr: errkit.ErrInvalidArgument,
+		},
+
+		"Unauthenticated": {
+			ctx:     context.Background(),
+			wantErr: errkit.ErrUnavailable,
+		},
+	}
+
+	server := PlainQ{
+		storage: &mockStorage{
+			accountFunc: func(_ context.Context, email string) (*v1.AccountProfile, error) {
+				if email != profile.GetEmail() {
+					return nil, errkit.ErrNotFound
+				}
+
+				return &profile, nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := server.account(tc.ctx)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output, tc.want)
+		})
+	}
+}
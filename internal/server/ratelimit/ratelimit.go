@@ -0,0 +1,291 @@
+// Package ratelimit provides token bucket rate limits of requests, applied
+// to all requests, to requests of each client, and to requests of each queue.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scopes of rate limits. Scopes are used as metric labels.
+const (
+	ScopeGlobal = "global"
+	ScopeClient = "client"
+	ScopeQueue  = "queue"
+)
+
+// sweepInterval represents how often idle buckets are dropped.
+const sweepInterval = time.Minute
+
+// Limit represents the rate of requests per second and the number of requests
+// allowed in a burst. The zero Limit means no limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited reports whether the limit doesn't limit requests.
+func (l Limit) Unlimited() bool { return l.Rate <= 0 }
+
+// burst returns the bucket capacity, which is at least one request.
+func (l Limit) burst() float64 { return math.Max(float64(l.Burst), 1) }
+
+// ParseLimit parses the limit in form: rate[:burst], e.g. "100" or "100:200".
+// The burst defaults to the rate.
+func ParseLimit(spec string) (Limit, error) {
+	if spec = strings.TrimSpace(spec); spec == "" {
+		return Limit{}, nil
+	}
+
+	rateStr, burstStr, hasBurst := strings.Cut(spec, ":")
+
+	rate, rateErr := strconv.ParseFloat(strings.TrimSpace(rateStr), 64)
+	if rateErr != nil || rate < 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, should be in form: rate[:burst]", spec)
+	}
+
+	limit := Limit{Rate: rate, Burst: int(math.Ceil(rate))}
+
+	if hasBurst {
+		burst, burstErr := strconv.Atoi(strings.TrimSpace(burstStr))
+		if burstErr != nil || burst < 1 {
+			return Limit{}, fmt.Errorf("invalid rate limit %q, burst should be a positive integer", spec)
+		}
+
+		limit.Burst = burst
+	}
+
+	return limit, nil
+}
+
+// ParseLimits parses comma separated limits by key in form: key=rate[:burst],
+// e.g. "orders-service=50:100,billing=10".
+func ParseLimits(spec string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+
+	for pair := range strings.SplitSeq(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		key, limitStr, ok := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return nil, fmt.Errorf("invalid rate limit %q, should be in form: key=rate[:burst]", pair)
+		}
+
+		limit, parseErr := ParseLimit(limitStr)
+		if parseErr != nil {
+			return nil, fmt.Errorf("%q: %w", key, parseErr)
+		}
+
+		limits[key] = limit
+	}
+
+	return limits, nil
+}
+
+// LimitedError is returned when the request exceeds the rate limit.
+type LimitedError struct {
+	// Scope is the scope of the exceeded limit, e.g. ScopeQueue.
+	Scope string
+
+	// RetryAfter is the time after which the request is allowed.
+	RetryAfter time.Duration
+}
+
+func (e *LimitedError) Error() string {
+	return fmt.Sprintf("%s rate limit exceeded, retry after %s", e.Scope, e.RetryAfter)
+}
+
+// RetryAfterSeconds returns the RetryAfter rounded up to whole seconds, as the Retry-After header requires.
+func (e *LimitedError) RetryAfterSeconds() int64 {
+	return int64(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// QueueLimitFunc returns the rate limit of the queue.
+type QueueLimitFunc func(ctx context.Context, queueID string) Limit
+
+// Config holds the configuration of the Limiter.
+type Config struct {
+	// Global limits all requests.
+	Global Limit
+
+	// Client limits requests of each client which has no own limit in Clients.
+	Client Limit
+
+	// Clients holds limits of specific clients by client name.
+	Clients map[string]Limit
+
+	// Queue returns limits of queues. Nil means queues are not limited.
+	Queue QueueLimitFunc
+}
+
+// Limiter limits requests with token buckets.
+type Limiter struct {
+	cfg Config
+	now func() time.Time
+
+	mu        sync.Mutex
+	global    bucket
+	clients   map[string]*bucket
+	queues    map[string]*bucket
+	lastSweep time.Time
+}
+
+// New returns a pointer to a new instance of Limiter.
+func New(cfg Config) *Limiter {
+	l := Limiter{
+		cfg:     cfg,
+		now:     time.Now,
+		clients: make(map[string]*bucket),
+		queues:  make(map[string]*bucket),
+	}
+
+	return &l
+}
+
+// Allow takes a token of each limit the request is subject to, the global one, the client one
+// and the one of each queue, and returns the *LimitedError if any of them is exceeded. Empty client
+// means the request is not subject to the client limit, and requests which span several queues,
+// e.g. transactions, take a token of each of them.
+func (l *Limiter) Allow(ctx context.Context, client string, queueIDs ...string) error {
+	// Queue limits are looked up outside the lock,
+	// since the lookup may read the storage.
+	queueLimits := make([]queueLimit, 0, len(queueIDs))
+
+	for _, queueID := range queueIDs {
+		if queueID == "" || l.cfg.Queue == nil || slices.ContainsFunc(queueLimits, func(q queueLimit) bool { return q.id == queueID }) {
+			continue
+		}
+
+		queueLimits = append(queueLimits, queueLimit{id: queueID, limit: l.cfg.Queue(ctx, queueID)})
+	}
+
+	clientLimit, ok := l.cfg.Clients[client]
+	if !ok {
+		clientLimit = l.cfg.Client
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	l.sweep(now)
+
+	checks := make([]check, 0, 2+len(queueLimits))
+
+	if !l.cfg.Global.Unlimited() {
+		checks = append(checks, check{scope: ScopeGlobal, bucket: &l.global, limit: l.cfg.Global})
+	}
+
+	if client != "" && !clientLimit.Unlimited() {
+		checks = append(checks, check{scope: ScopeClient, bucket: bucketOf(l.clients, client), limit: clientLimit})
+	}
+
+	for _, q := range queueLimits {
+		if !q.limit.Unlimited() {
+			checks = append(checks, check{scope: ScopeQueue, bucket: bucketOf(l.queues, q.id), limit: q.limit})
+		}
+	}
+
+	// Tokens are taken only when all limits allow the request,
+	// so the rejected request doesn't consume any of them.
+	for _, c := range checks {
+		if wait := c.bucket.wait(now, c.limit); wait > 0 {
+			return &LimitedError{Scope: c.scope, RetryAfter: wait}
+		}
+	}
+
+	for _, c := range checks {
+		c.bucket.take()
+	}
+
+	return nil
+}
+
+// sweep drops buckets which have been refilled, since they are equal to new ones.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+
+	l.lastSweep = now
+
+	for _, buckets := range []map[string]*bucket{l.clients, l.queues} {
+		for key, b := range buckets {
+			if b.full(now) {
+				delete(buckets, key)
+			}
+		}
+	}
+}
+
+// queueLimit represents the limit of the queue the request operates on.
+type queueLimit struct {
+	id    string
+	limit Limit
+}
+
+// check represents a limit the request is subject to.
+type check struct {
+	scope  string
+	bucket *bucket
+	limit  Limit
+}
+
+func bucketOf(buckets map[string]*bucket, key string) *bucket {
+	b, ok := buckets[key]
+	if !ok {
+		b = &bucket{}
+		buckets[key] = b
+	}
+
+	return b
+}
+
+// bucket represents a token bucket. The zero bucket is full.
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+// wait refills the bucket and returns the time until a token is available.
+func (b *bucket) wait(now time.Time, limit Limit) time.Duration {
+	// New buckets and buckets which limit has been changed start full.
+	if b.last.IsZero() || b.limit != limit {
+		b.limit = limit
+		b.tokens = limit.burst()
+		b.last = now
+	}
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.tokens+elapsed.Seconds()*limit.Rate, limit.burst())
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+}
+
+// take takes a token from the bucket refilled by the wait.
+func (b *bucket) take() { b.tokens-- }
+
+// full reports whether the bucket would be full at the now.
+func (b *bucket) full(now time.Time) bool {
+	if b.last.IsZero() || b.limit.Unlimited() {
+		return true
+	}
+
+	return b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate >= b.limit.burst()
+}
@@ -0,0 +1,197 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestParseLimit(t *testing.T) {
+	type tcase struct {
+		spec    string
+		want    Limit
+		wantErr bool
+	}
+
+	tests := map[string]tcase{
+		"Empty":         {spec: "", want: Limit{}},
+		"Rate":          {spec: "100", want: Limit{Rate: 100, Burst: 100}},
+		"FractionRate":  {spec: "0.5", want: Limit{Rate: 0.5, Burst: 1}},
+		"RateAndBurst":  {spec: " 10:50 ", want: Limit{Rate: 10, Burst: 50}},
+		"NegativeRate":  {spec: "-1", wantErr: true},
+		"InvalidRate":   {spec: "fast", wantErr: true},
+		"ZeroBurst":     {spec: "10:0", wantErr: true},
+		"InvalidBurst":  {spec: "10:many", wantErr: true},
+		"InfiniteRate":  {spec: "+Inf", wantErr: true},
+		"NotANumber":    {spec: "NaN", wantErr: true},
+		"EmptyRateOnly": {spec: ":10", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseLimit(tc.spec)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	type tcase struct {
+		spec    string
+		want    map[string]Limit
+		wantErr bool
+	}
+
+	tests := map[string]tcase{
+		"Empty": {spec: "", want: map[string]Limit{}},
+		"Many": {
+			spec: "orders-service=50:100, billing=10",
+			want: map[string]Limit{
+				"orders-service": {Rate: 50, Burst: 100},
+				"billing":        {Rate: 10, Burst: 10},
+			},
+		},
+		"MissingLimit": {spec: "billing", wantErr: true},
+		"MissingKey":   {spec: "=10", wantErr: true},
+		"InvalidLimit": {spec: "billing=ten", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseLimits(tc.spec)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	queueLimits := map[string]Limit{"q1": {Rate: 1, Burst: 1}}
+
+	newLimiter := func(cfg Config) *Limiter {
+		cfg.Queue = func(_ context.Context, queueID string) Limit { return queueLimits[queueID] }
+
+		l := New(cfg)
+		l.now = func() time.Time { return now }
+
+		return l
+	}
+
+	t.Run("Global", func(t *testing.T) {
+		l := newLimiter(Config{Global: Limit{Rate: 2, Burst: 2}})
+
+		td.CmpNoError(t, l.Allow(context.Background(), "a", ""))
+		td.CmpNoError(t, l.Allow(context.Background(), "b", ""))
+
+		err := l.Allow(context.Background(), "c", "")
+
+		var limited *LimitedError
+		td.Cmp(t, errors.As(err, &limited), true)
+		td.Cmp(t, limited.Scope, ScopeGlobal)
+		td.Cmp(t, limited.RetryAfter, 500*time.Millisecond)
+		td.Cmp(t, limited.RetryAfterSeconds(), int64(1))
+	})
+
+	t.Run("Client", func(t *testing.T) {
+		l := newLimiter(Config{
+			Client:  Limit{Rate: 1, Burst: 1},
+			Clients: map[string]Limit{"vip": {Rate: 10, Burst: 2}},
+		})
+
+		td.CmpNoError(t, l.Allow(context.Background(), "a", ""))
+		td.CmpError(t, l.Allow(context.Background(), "a", ""))
+
+		// Clients don't share buckets.
+		td.CmpNoError(t, l.Allow(context.Background(), "b", ""))
+
+		// Clients with own limits are not limited by the default one.
+		td.CmpNoError(t, l.Allow(context.Background(), "vip", ""))
+		td.CmpNoError(t, l.Allow(context.Background(), "vip", ""))
+		td.CmpError(t, l.Allow(context.Background(), "vip", ""))
+
+		// Anonymous requests are not subject to client limits.
+		td.CmpNoError(t, l.Allow(context.Background(), "", ""))
+		td.CmpNoError(t, l.Allow(context.Background(), "", ""))
+	})
+
+	t.Run("Queue", func(t *testing.T) {
+		l := newLimiter(Config{})
+
+		td.CmpNoError(t, l.Allow(context.Background(), "a", "q1"))
+
+		err := l.Allow(context.Background(), "b", "q1")
+
+		var limited *LimitedError
+		td.Cmp(t, errors.As(err, &limited), true)
+		td.Cmp(t, limited.Scope, ScopeQueue)
+
+		// Queues without limits are not limited.
+		td.CmpNoError(t, l.Allow(context.Background(), "a", "q2"))
+		td.CmpNoError(t, l.Allow(context.Background(), "a", "q2"))
+	})
+
+	t.Run("Queues", func(t *testing.T) {
+		queueLimits["q3"] = Limit{Rate: 1, Burst: 2}
+
+		l := newLimiter(Config{})
+
+		// Each queue of the request takes a token, even when it's repeated.
+		td.CmpNoError(t, l.Allow(context.Background(), "", "q3", "q3", "q2"))
+		td.CmpNoError(t, l.Allow(context.Background(), "", "q3"))
+
+		// The queue limit rejects the request, so tokens of other queues are not taken either.
+		td.CmpError(t, l.Allow(context.Background(), "", "q1", "q3"))
+		td.CmpNoError(t, l.Allow(context.Background(), "", "q1"))
+	})
+
+	t.Run("Refill", func(t *testing.T) {
+		l := newLimiter(Config{Global: Limit{Rate: 1, Burst: 1}})
+
+		td.CmpNoError(t, l.Allow(context.Background(), "", ""))
+		td.CmpError(t, l.Allow(context.Background(), "", ""))
+
+		now = now.Add(time.Second)
+
+		td.CmpNoError(t, l.Allow(context.Background(), "", ""))
+	})
+
+	t.Run("RejectedDoesNotConsume", func(t *testing.T) {
+		l := newLimiter(Config{Global: Limit{Rate: 1, Burst: 2}})
+
+		td.CmpNoError(t, l.Allow(context.Background(), "", "q1"))
+
+		// The queue limit rejects the request,
+		// so the global token is not taken.
+		td.CmpError(t, l.Allow(context.Background(), "", "q1"))
+		td.CmpNoError(t, l.Allow(context.Background(), "", ""))
+	})
+
+	t.Run("Sweep", func(t *testing.T) {
+		l := newLimiter(Config{Client: Limit{Rate: 1, Burst: 1}})
+
+		td.CmpNoError(t, l.Allow(context.Background(), "a", ""))
+		td.Cmp(t, len(l.clients), 1)
+
+		now = now.Add(sweepInterval)
+
+		td.CmpNoError(t, l.Allow(context.Background(), "b", ""))
+		td.Cmp(t, len(l.clients), 1)
+		td.Cmp(t, l.clients, td.ContainsKey("b"))
+	})
+}
@@ -0,0 +1,53 @@
+package rbac
+
+import "slices"
+
+// AdminRole is the name of the role which is allowed any operation.
+const AdminRole = "admin"
+
+// Grants represents permissions of the subject on the queue,
+// which are granted to roles of the subject.
+type Grants struct {
+	// Admin reports whether the subject has the AdminRole.
+	Admin bool
+
+	// QueueName is the name of the queue, which patterns of policies are matched against.
+	QueueName string
+
+	// Operations are granted by queue permissions of roles of the subject.
+	Operations []Operation
+
+	// Policies are attached to roles of the subject.
+	Policies PolicySet
+}
+
+// Allows reports whether the operation on the queue is granted.
+// Any granted operation allows to describe the queue.
+func (g *Grants) Allows(op Operation) bool {
+	switch {
+	case g.Admin:
+		return true
+
+	case slices.Contains(g.Operations, op), slices.Contains(g.Operations, OpAll):
+		return true
+
+	case op == OpDescribe && len(g.Operations) > 0:
+		return true
+
+	default:
+		return g.Policies.Allows(g.QueueName, op)
+	}
+}
+
+// Allowed returns operations on the queue which are allowed, ordered as known operations.
+func (g *Grants) Allowed() []Operation {
+	var allowed []Operation
+
+	for _, op := range operations[1:] {
+		if g.Allows(op) {
+			allowed = append(allowed, op)
+		}
+	}
+
+	return allowed
+}
@@ -0,0 +1,71 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestGrants_Allows(t *testing.T) {
+	producer := Policy{Name: "producer", Rules: []Rule{
+		{Queues: "orders-*", Operations: []Operation{OpSend}},
+	}}
+
+	type tcase struct {
+		grants Grants
+		op     Operation
+		want   bool
+	}
+
+	tests := map[string]tcase{
+		"Admin":              {grants: Grants{Admin: true}, op: OpDelete, want: true},
+		"Granted":            {grants: Grants{Operations: []Operation{OpSend}}, op: OpSend, want: true},
+		"NotGranted":         {grants: Grants{Operations: []Operation{OpSend}}, op: OpPurge, want: false},
+		"DescribeByAnyGrant": {grants: Grants{Operations: []Operation{OpReceive}}, op: OpDescribe, want: true},
+		"DescribeNoGrants":   {grants: Grants{}, op: OpDescribe, want: false},
+		"All":                {grants: Grants{Operations: []Operation{OpAll}}, op: OpUpdate, want: true},
+		"Policy": {
+			grants: Grants{QueueName: "orders-eu", Policies: PolicySet{&producer}},
+			op:     OpSend,
+			want:   true,
+		},
+		"PolicyOtherQueue": {
+			grants: Grants{QueueName: "billing", Policies: PolicySet{&producer}},
+			op:     OpSend,
+			want:   false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, tc.grants.Allows(tc.op), tc.want)
+		})
+	}
+}
+
+func TestGrants_Allowed(t *testing.T) {
+	consumer := Policy{Name: "consumer", Rules: []Rule{
+		{Queues: "orders-*", Operations: []Operation{OpReceive}},
+	}}
+
+	type tcase struct {
+		grants Grants
+		want   []Operation
+	}
+
+	tests := map[string]tcase{
+		"Admin":    {grants: Grants{Admin: true}, want: []Operation{OpDescribe, OpUpdate, OpSend, OpReceive, OpPurge, OpDelete}},
+		"Granted":  {grants: Grants{Operations: []Operation{OpSend}}, want: []Operation{OpDescribe, OpSend}},
+		"NoGrants": {grants: Grants{QueueName: "orders-eu"}, want: nil},
+		"Combined": {
+			grants: Grants{QueueName: "orders-eu", Operations: []Operation{OpSend}, Policies: PolicySet{&consumer}},
+			want:   []Operation{OpDescribe, OpSend, OpReceive},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, tc.grants.Allowed(), tc.want)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package rbac
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+
+	"github.com/plainq/servekit/errkit"
+)
+
+// Operation represents an operation which can be performed on a queue.
+type Operation string
+
+const (
+	// OpAll matches any operation.
+	OpAll Operation = "*"
+
+	OpDescribe Operation = "describe"
+	OpUpdate   Operation = "update"
+	OpSend     Operation = "send"
+	OpReceive  Operation = "receive"
+	OpPurge    Operation = "purge"
+	OpDelete   Operation = "delete"
+)
+
+// operations holds all known operations.
+var operations = []Operation{OpAll, OpDescribe, OpUpdate, OpSend, OpReceive, OpPurge, OpDelete}
+
+// Rule grants the operations on the queues names of which match the queue pattern.
+// The pattern syntax is the same as for path.Match, e.g. "orders-*".
+type Rule struct {
+	Queues     string      `json:"queues"`
+	Operations []Operation `json:"operations"`
+}
+
+// Policy represents a named bundle of permissions which can be attached to roles.
+// Policy allows granting access to a group of queues with a single attachment
+// instead of per-queue permission records.
+type Policy struct {
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+// ParsePolicy parses and validates the JSON policy document.
+func ParsePolicy(name string, document []byte) (*Policy, error) {
+	p := Policy{Name: name}
+
+	if err := json.Unmarshal(document, &p.Rules); err != nil {
+		return nil, fmt.Errorf("%w: parse policy %q: %w", errkit.ErrInvalidArgument, name, err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// Validate checks that policy has a name and all rules have valid patterns and operations.
+func (p *Policy) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("%w: policy name is empty", errkit.ErrInvalidArgument)
+	}
+
+	for i, r := range p.Rules {
+		if _, err := path.Match(r.Queues, ""); err != nil || r.Queues == "" {
+			return fmt.Errorf("%w: policy %q rule %d: invalid queues pattern %q",
+				errkit.ErrInvalidArgument, p.Name, i, r.Queues,
+			)
+		}
+
+		if len(r.Operations) == 0 {
+			return fmt.Errorf("%w: policy %q rule %d: no operations", errkit.ErrInvalidArgument, p.Name, i)
+		}
+
+		for _, op := range r.Operations {
+			if !slices.Contains(operations, op) {
+				return fmt.Errorf("%w: policy %q rule %d: unknown operation %q",
+					errkit.ErrInvalidArgument, p.Name, i, op,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Allows reports whether the policy grants the operation on the queue with given name.
+func (p *Policy) Allows(queueName string, op Operation) bool {
+	for _, r := range p.Rules {
+		if matched, _ := path.Match(r.Queues, queueName); !matched {
+			continue
+		}
+
+		if slices.Contains(r.Operations, op) || slices.Contains(r.Operations, OpAll) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PolicySet represents all policies attached to a subject.
+type PolicySet []*Policy
+
+// Allows reports whether any policy in the set grants the operation on the queue.
+func (s PolicySet) Allows(queueName string, op Operation) bool {
+	return slices.ContainsFunc(s, func(p *Policy) bool { return p.Allows(queueName, op) })
+}
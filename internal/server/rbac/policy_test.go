@@ -0,0 +1,62 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/errkit"
+)
+
+func TestParsePolicy(t *testing.T) {
+	type tcase struct {
+		document string
+		wantErr  error
+	}
+
+	tests := map[string]tcase{
+		"OK":               {document: `[{"queues":"orders-*","operations":["send","receive"]}]`},
+		"Empty":            {document: `[]`},
+		"InvalidJSON":      {document: `{`, wantErr: errkit.ErrInvalidArgument},
+		"InvalidPattern":   {document: `[{"queues":"[","operations":["send"]}]`, wantErr: errkit.ErrInvalidArgument},
+		"NoOperations":     {document: `[{"queues":"*","operations":[]}]`, wantErr: errkit.ErrInvalidArgument},
+		"UnknownOperation": {document: `[{"queues":"*","operations":["fly"]}]`, wantErr: errkit.ErrInvalidArgument},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParsePolicy("test", []byte(tc.document))
+			td.CmpErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestPolicySet_Allows(t *testing.T) {
+	producer := Policy{Name: "producer", Rules: []Rule{
+		{Queues: "orders-*", Operations: []Operation{OpSend, OpDescribe}},
+	}}
+
+	admin := Policy{Name: "admin", Rules: []Rule{
+		{Queues: "billing", Operations: []Operation{OpAll}},
+	}}
+
+	set := PolicySet{&producer, &admin}
+
+	type tcase struct {
+		queue string
+		op    Operation
+		want  bool
+	}
+
+	tests := map[string]tcase{
+		"PatternMatch":     {queue: "orders-eu", op: OpSend, want: true},
+		"PatternNoMatch":   {queue: "payments", op: OpSend, want: false},
+		"OperationNoMatch": {queue: "orders-eu", op: OpPurge, want: false},
+		"Wildcard":         {queue: "billing", op: OpPurge, want: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, set.Allows(tc.queue, tc.op), tc.want)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package rbac
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/plainq/servekit/errkit"
+)
+
+// templatePolicyPrefix prefixes names of policies of templates.
+const templatePolicyPrefix = "template:"
+
+// Template represents the standard set of operations on queues, which the role named
+// after the template is seeded with, and which can be applied to any other role.
+type Template struct {
+	Name       string      `json:"name"`
+	Operations []Operation `json:"operations"`
+}
+
+// Templates holds standard templates of permissions. Together with the AdminRole
+// they make roles which are created on startup.
+var Templates = []Template{
+	{Name: "operator", Operations: []Operation{OpDescribe, OpUpdate, OpPurge}},
+	{Name: "producer", Operations: []Operation{OpDescribe, OpSend}},
+	{Name: "consumer", Operations: []Operation{OpDescribe, OpReceive}},
+	{Name: "read-only", Operations: []Operation{OpDescribe}},
+}
+
+// FindTemplate returns the standard template with the name.
+func FindTemplate(name string) (*Template, error) {
+	i := slices.IndexFunc(Templates, func(t Template) bool { return t.Name == name })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: permission template %q", errkit.ErrNotFound, name)
+	}
+
+	return &Templates[i], nil
+}
+
+// Policy returns the policy which grants operations of the template on all queues.
+// Roles get permissions of the template when the policy is attached to them.
+func (t *Template) Policy() *Policy {
+	return &Policy{
+		Name:  templatePolicyPrefix + t.Name,
+		Rules: []Rule{{Queues: "*", Operations: t.Operations}},
+	}
+}
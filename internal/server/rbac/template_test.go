@@ -0,0 +1,44 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/errkit"
+)
+
+func TestTemplate_Policy(t *testing.T) {
+	type tcase struct {
+		template string
+		queue    string
+		op       Operation
+		want     bool
+	}
+
+	tests := map[string]tcase{
+		"ProducerSends":     {template: "producer", queue: "orders", op: OpSend, want: true},
+		"ProducerDescribes": {template: "producer", queue: "orders", op: OpDescribe, want: true},
+		"ProducerReceives":  {template: "producer", queue: "orders", op: OpReceive, want: false},
+		"ConsumerReceives":  {template: "consumer", queue: "payments-eu", op: OpReceive, want: true},
+		"OperatorPurges":    {template: "operator", queue: "orders", op: OpPurge, want: true},
+		"OperatorDeletes":   {template: "operator", queue: "orders", op: OpDelete, want: false},
+		"ReadOnlyDescribes": {template: "read-only", queue: "orders", op: OpDescribe, want: true},
+		"ReadOnlySends":     {template: "read-only", queue: "orders", op: OpSend, want: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			template, err := FindTemplate(tc.template)
+			td.Require(t).CmpNoError(err)
+
+			policy := template.Policy()
+			td.CmpNoError(t, policy.Validate())
+			td.Cmp(t, policy.Allows(tc.queue, tc.op), tc.want)
+		})
+	}
+}
+
+func TestFindTemplate_unknown(t *testing.T) {
+	_, err := FindTemplate("admin")
+	td.CmpErrorIs(t, err, errkit.ErrNotFound)
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+// reloadConfig reloads the configuration and applies changed settings.
+func (s *PlainQ) reloadConfig() (*v1.ReloadConfigResponse, error) {
+	if s.reloader == nil {
+		return nil, fmt.Errorf("%w: configuration reload is not enabled", errkit.ErrUnavailable)
+	}
+
+	applied, reloadErr := s.reloader.Reload()
+	if reloadErr != nil {
+		return nil, fmt.Errorf("reload configuration: %w", reloadErr)
+	}
+
+	s.logger.Info("Configuration has been reloaded",
+		slog.Any("applied", applied),
+	)
+
+	return &v1.ReloadConfigResponse{Applied: applied}, nil
+}
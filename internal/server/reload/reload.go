@@ -0,0 +1,120 @@
+// Package reload re-reads the server configuration and applies settings
+// which can be changed without restarting listeners, e.g. log levels.
+package reload
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/plainq/plainq/internal/server/config"
+)
+
+// Setting represents a setting which can be changed at runtime.
+type Setting struct {
+	// Name is the name of the option which holds the setting, e.g. "log.level".
+	Name string
+
+	// Value returns the setting value from the configuration.
+	// The setting is applied only when the value has been changed.
+	Value func(cfg *config.Config) string
+
+	// Apply applies the setting from the configuration.
+	Apply func(cfg *config.Config) error
+}
+
+// LoadFunc loads the configuration from its sources.
+type LoadFunc func() (*config.Config, error)
+
+// Reloader reloads the configuration and applies changed settings.
+type Reloader struct {
+	mu       sync.Mutex
+	logger   *slog.Logger
+	load     LoadFunc
+	current  *config.Config
+	settings []Setting
+}
+
+// New returns a pointer to a new instance of Reloader
+// which reloads the current configuration by the load function.
+func New(current *config.Config, load LoadFunc, logger *slog.Logger) *Reloader {
+	r := Reloader{
+		logger:  logger,
+		load:    load,
+		current: current,
+	}
+
+	return &r
+}
+
+// Register registers settings which can be changed at runtime.
+func (r *Reloader) Register(settings ...Setting) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.settings = append(r.settings, settings...)
+}
+
+// Reload loads the configuration and applies changed settings.
+// Returns names of applied settings. Options which are not registered
+// as settings are ignored, since they require the server restart.
+func (r *Reloader) Reload() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, loadErr := r.load()
+	if loadErr != nil {
+		return nil, fmt.Errorf("load configuration: %w", loadErr)
+	}
+
+	applied := make([]string, 0, len(r.settings))
+
+	for _, s := range r.settings {
+		if s.Value(cfg) == s.Value(r.current) {
+			continue
+		}
+
+		if err := s.Apply(cfg); err != nil {
+			return applied, fmt.Errorf("apply %s: %w", s.Name, err)
+		}
+
+		applied = append(applied, s.Name)
+
+		r.logger.Info("Setting has been reloaded",
+			slog.String("setting", s.Name),
+			slog.String("value", s.Value(cfg)),
+		)
+	}
+
+	r.current = cfg
+
+	return applied, nil
+}
+
+// Watch reloads the configuration on SIGHUP until the ctx is done.
+func (r *Reloader) Watch(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-signals:
+			r.logger.Info("Reloading configuration on SIGHUP")
+
+			if _, err := r.Reload(); err != nil {
+				r.logger.Error("Failed to reload configuration",
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package reload
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/config"
+	"github.com/plainq/servekit/logkit"
+)
+
+func TestReloader_Reload(t *testing.T) {
+	type tcase struct {
+		loaded      config.Config
+		loadErr     error
+		applyErr    error
+		wantApplied []string
+		wantLevel   string
+		wantErr     bool
+	}
+
+	tests := map[string]tcase{
+		"Unchanged": {
+			loaded:      config.Config{LogLevel: "info", HTTPAddr: ":8081"},
+			wantApplied: []string{},
+			wantLevel:   "info",
+		},
+		"Changed": {
+			loaded:      config.Config{LogLevel: "debug"},
+			wantApplied: []string{"log.level"},
+			wantLevel:   "debug",
+		},
+		"LoadError": {
+			loadErr:   errors.New("broken"),
+			wantLevel: "info",
+			wantErr:   true,
+		},
+		"ApplyError": {
+			loaded:    config.Config{LogLevel: "debug"},
+			applyErr:  errors.New("broken"),
+			wantLevel: "info",
+			wantErr:   true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			level := "info"
+
+			r := New(&config.Config{LogLevel: "info"}, func() (*config.Config, error) {
+				if tc.loadErr != nil {
+					return nil, tc.loadErr
+				}
+
+				return &tc.loaded, nil
+			}, logkit.NewNop())
+
+			r.Register(Setting{
+				Name:  "log.level",
+				Value: func(cfg *config.Config) string { return cfg.LogLevel },
+				Apply: func(cfg *config.Config) error {
+					if tc.applyErr != nil {
+						return tc.applyErr
+					}
+
+					level = cfg.LogLevel
+
+					return nil
+				},
+			})
+
+			applied, err := r.Reload()
+			if tc.wantErr {
+				td.CmpError(t, err)
+			} else {
+				td.CmpNoError(t, err)
+				td.Cmp(t, applied, tc.wantApplied)
+			}
+
+			td.Cmp(t, level, tc.wantLevel)
+		})
+	}
+}
@@ -19,14 +19,65 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	PlainQService_ListQueues_FullMethodName    = "/v1.PlainQService/ListQueues"
-	PlainQService_DescribeQueue_FullMethodName = "/v1.PlainQService/DescribeQueue"
-	PlainQService_CreateQueue_FullMethodName   = "/v1.PlainQService/CreateQueue"
-	PlainQService_PurgeQueue_FullMethodName    = "/v1.PlainQService/PurgeQueue"
-	PlainQService_DeleteQueue_FullMethodName   = "/v1.PlainQService/DeleteQueue"
-	PlainQService_Send_FullMethodName          = "/v1.PlainQService/Send"
-	PlainQService_Receive_FullMethodName       = "/v1.PlainQService/Receive"
-	PlainQService_Delete_FullMethodName        = "/v1.PlainQService/Delete"
+	PlainQService_ListQueues_FullMethodName              = "/v1.PlainQService/ListQueues"
+	PlainQService_DescribeQueue_FullMethodName           = "/v1.PlainQService/DescribeQueue"
+	PlainQService_CreateQueue_FullMethodName             = "/v1.PlainQService/CreateQueue"
+	PlainQService_PurgeQueue_FullMethodName              = "/v1.PlainQService/PurgeQueue"
+	PlainQService_DeleteQueue_FullMethodName             = "/v1.PlainQService/DeleteQueue"
+	PlainQService_Send_FullMethodName                    = "/v1.PlainQService/Send"
+	PlainQService_Receive_FullMethodName                 = "/v1.PlainQService/Receive"
+	PlainQService_Delete_FullMethodName                  = "/v1.PlainQService/Delete"
+	PlainQService_ChangeVisibility_FullMethodName        = "/v1.PlainQService/ChangeVisibility"
+	PlainQService_UpdateQueue_FullMethodName             = "/v1.PlainQService/UpdateQueue"
+	PlainQService_AdviseQueue_FullMethodName             = "/v1.PlainQService/AdviseQueue"
+	PlainQService_StartGenerator_FullMethodName          = "/v1.PlainQService/StartGenerator"
+	PlainQService_StopGenerator_FullMethodName           = "/v1.PlainQService/StopGenerator"
+	PlainQService_ListGenerators_FullMethodName          = "/v1.PlainQService/ListGenerators"
+	PlainQService_QueueStats_FullMethodName              = "/v1.PlainQService/QueueStats"
+	PlainQService_GetLogLevels_FullMethodName            = "/v1.PlainQService/GetLogLevels"
+	PlainQService_SetLogLevels_FullMethodName            = "/v1.PlainQService/SetLogLevels"
+	PlainQService_TransferQueue_FullMethodName           = "/v1.PlainQService/TransferQueue"
+	PlainQService_AcceptQueueTransfer_FullMethodName     = "/v1.PlainQService/AcceptQueueTransfer"
+	PlainQService_CancelQueueTransfer_FullMethodName     = "/v1.PlainQService/CancelQueueTransfer"
+	PlainQService_SearchMessages_FullMethodName          = "/v1.PlainQService/SearchMessages"
+	PlainQService_PeekMessages_FullMethodName            = "/v1.PlainQService/PeekMessages"
+	PlainQService_ReloadConfig_FullMethodName            = "/v1.PlainQService/ReloadConfig"
+	PlainQService_ListBreakers_FullMethodName            = "/v1.PlainQService/ListBreakers"
+	PlainQService_ResetBreaker_FullMethodName            = "/v1.PlainQService/ResetBreaker"
+	PlainQService_SetQueueState_FullMethodName           = "/v1.PlainQService/SetQueueState"
+	PlainQService_Search_FullMethodName                  = "/v1.PlainQService/Search"
+	PlainQService_ListAuditEvents_FullMethodName         = "/v1.PlainQService/ListAuditEvents"
+	PlainQService_CreateAlertRule_FullMethodName         = "/v1.PlainQService/CreateAlertRule"
+	PlainQService_ListAlertRules_FullMethodName          = "/v1.PlainQService/ListAlertRules"
+	PlainQService_UpdateAlertRule_FullMethodName         = "/v1.PlainQService/UpdateAlertRule"
+	PlainQService_DeleteAlertRule_FullMethodName         = "/v1.PlainQService/DeleteAlertRule"
+	PlainQService_ListAlerts_FullMethodName              = "/v1.PlainQService/ListAlerts"
+	PlainQService_CreateServiceAccount_FullMethodName    = "/v1.PlainQService/CreateServiceAccount"
+	PlainQService_ListServiceAccounts_FullMethodName     = "/v1.PlainQService/ListServiceAccounts"
+	PlainQService_DeleteServiceAccount_FullMethodName    = "/v1.PlainQService/DeleteServiceAccount"
+	PlainQService_CreateAPIKey_FullMethodName            = "/v1.PlainQService/CreateAPIKey"
+	PlainQService_ListAPIKeys_FullMethodName             = "/v1.PlainQService/ListAPIKeys"
+	PlainQService_RevokeAPIKey_FullMethodName            = "/v1.PlainQService/RevokeAPIKey"
+	PlainQService_GetMessage_FullMethodName              = "/v1.PlainQService/GetMessage"
+	PlainQService_Transact_FullMethodName                = "/v1.PlainQService/Transact"
+	PlainQService_GetJob_FullMethodName                  = "/v1.PlainQService/GetJob"
+	PlainQService_ListJobs_FullMethodName                = "/v1.PlainQService/ListJobs"
+	PlainQService_CancelJob_FullMethodName               = "/v1.PlainQService/CancelJob"
+	PlainQService_ListArchivedMessages_FullMethodName    = "/v1.PlainQService/ListArchivedMessages"
+	PlainQService_RestoreArchivedMessages_FullMethodName = "/v1.PlainQService/RestoreArchivedMessages"
+	PlainQService_ListMessageEvents_FullMethodName       = "/v1.PlainQService/ListMessageEvents"
+	PlainQService_ExtendVisibilityBatch_FullMethodName   = "/v1.PlainQService/ExtendVisibilityBatch"
+	PlainQService_ListQueuePermissions_FullMethodName    = "/v1.PlainQService/ListQueuePermissions"
+	PlainQService_SetQueuePermission_FullMethodName      = "/v1.PlainQService/SetQueuePermission"
+	PlainQService_DeleteQueuePermission_FullMethodName   = "/v1.PlainQService/DeleteQueuePermission"
+	PlainQService_ListRoles_FullMethodName               = "/v1.PlainQService/ListRoles"
+	PlainQService_CreateRole_FullMethodName              = "/v1.PlainQService/CreateRole"
+	PlainQService_DeleteRole_FullMethodName              = "/v1.PlainQService/DeleteRole"
+	PlainQService_AssignRole_FullMethodName              = "/v1.PlainQService/AssignRole"
+	PlainQService_UnassignRole_FullMethodName            = "/v1.PlainQService/UnassignRole"
+	PlainQService_GetAccount_FullMethodName              = "/v1.PlainQService/GetAccount"
+	PlainQService_ClusterStatus_FullMethodName           = "/v1.PlainQService/ClusterStatus"
+	PlainQService_RunMaintenance_FullMethodName          = "/v1.PlainQService/RunMaintenance"
 )
 
 // PlainQServiceClient is the client API for PlainQService service.
@@ -51,6 +102,116 @@ type PlainQServiceClient interface {
 	Receive(ctx context.Context, in *ReceiveRequest, opts ...grpc.CallOption) (*ReceiveResponse, error)
 	// Delete deletes message from the queue.
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// ChangeVisibility changes the visibility timeout of a received message.
+	ChangeVisibility(ctx context.Context, in *ChangeVisibilityRequest, opts ...grpc.CallOption) (*ChangeVisibilityResponse, error)
+	// UpdateQueue updates properties of the queue.
+	UpdateQueue(ctx context.Context, in *UpdateQueueRequest, opts ...grpc.CallOption) (*UpdateQueueResponse, error)
+	// AdviseQueue analyzes the queue telemetry and suggests queue settings.
+	AdviseQueue(ctx context.Context, in *AdviseQueueRequest, opts ...grpc.CallOption) (*AdviseQueueResponse, error)
+	// StartGenerator starts a synthetic producer which sends generated messages to the queue.
+	StartGenerator(ctx context.Context, in *StartGeneratorRequest, opts ...grpc.CallOption) (*StartGeneratorResponse, error)
+	// StopGenerator stops the synthetic producer.
+	StopGenerator(ctx context.Context, in *StopGeneratorRequest, opts ...grpc.CallOption) (*StopGeneratorResponse, error)
+	// ListGenerators returns the list of running synthetic producers.
+	ListGenerators(ctx context.Context, in *ListGeneratorsRequest, opts ...grpc.CallOption) (*ListGeneratorsResponse, error)
+	// QueueStats returns the current statistics of the queue.
+	QueueStats(ctx context.Context, in *QueueStatsRequest, opts ...grpc.CallOption) (*QueueStatsResponse, error)
+	// GetLogLevels returns log levels of server subsystems.
+	GetLogLevels(ctx context.Context, in *GetLogLevelsRequest, opts ...grpc.CallOption) (*GetLogLevelsResponse, error)
+	// SetLogLevels changes log levels of server subsystems at runtime.
+	SetLogLevels(ctx context.Context, in *SetLogLevelsRequest, opts ...grpc.CallOption) (*SetLogLevelsResponse, error)
+	// TransferQueue requests the transfer of the queue ownership to another team.
+	TransferQueue(ctx context.Context, in *TransferQueueRequest, opts ...grpc.CallOption) (*TransferQueueResponse, error)
+	// AcceptQueueTransfer confirms the pending transfer of the queue ownership.
+	AcceptQueueTransfer(ctx context.Context, in *AcceptQueueTransferRequest, opts ...grpc.CallOption) (*AcceptQueueTransferResponse, error)
+	// CancelQueueTransfer cancels the pending transfer of the queue ownership.
+	CancelQueueTransfer(ctx context.Context, in *CancelQueueTransferRequest, opts ...grpc.CallOption) (*CancelQueueTransferResponse, error)
+	// SearchMessages searches messages of the queue with the search index by their content.
+	SearchMessages(ctx context.Context, in *SearchMessagesRequest, opts ...grpc.CallOption) (*SearchMessagesResponse, error)
+	// PeekMessages returns messages of the queue in the order they were sent
+	// without receiving them. Pages are iterated with an opaque cursor.
+	PeekMessages(ctx context.Context, in *PeekMessagesRequest, opts ...grpc.CallOption) (*PeekMessagesResponse, error)
+	// ReloadConfig re-reads the configuration and applies settings
+	// which can be changed without restarting the server.
+	ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*ReloadConfigResponse, error)
+	// ListBreakers returns queues frozen by the circuit breaker.
+	ListBreakers(ctx context.Context, in *ListBreakersRequest, opts ...grpc.CallOption) (*ListBreakersResponse, error)
+	// ResetBreaker unfreezes the queue frozen by the circuit breaker.
+	ResetBreaker(ctx context.Context, in *ResetBreakerRequest, opts ...grpc.CallOption) (*ResetBreakerResponse, error)
+	// SetQueueState moves the queue to another lifecycle state.
+	// Transitions which are not allowed from the current state are rejected.
+	SetQueueState(ctx context.Context, in *SetQueueStateRequest, opts ...grpc.CallOption) (*SetQueueStateResponse, error)
+	// Search matches queues, users, roles and recent audit events against the query.
+	// Results are ordered by relevance, so the best match comes first.
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// ListAuditEvents returns recorded mutations which match the filter, newest first.
+	ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error)
+	// CreateAlertRule creates the threshold rule over an observed metric.
+	CreateAlertRule(ctx context.Context, in *CreateAlertRuleRequest, opts ...grpc.CallOption) (*CreateAlertRuleResponse, error)
+	// ListAlertRules returns all alert rules.
+	ListAlertRules(ctx context.Context, in *ListAlertRulesRequest, opts ...grpc.CallOption) (*ListAlertRulesResponse, error)
+	// UpdateAlertRule replaces the alert rule.
+	UpdateAlertRule(ctx context.Context, in *UpdateAlertRuleRequest, opts ...grpc.CallOption) (*UpdateAlertRuleResponse, error)
+	// DeleteAlertRule deletes the alert rule.
+	DeleteAlertRule(ctx context.Context, in *DeleteAlertRuleRequest, opts ...grpc.CallOption) (*DeleteAlertRuleResponse, error)
+	// ListAlerts returns current states of alert rules.
+	ListAlerts(ctx context.Context, in *ListAlertsRequest, opts ...grpc.CallOption) (*ListAlertsResponse, error)
+	// CreateServiceAccount creates the service account.
+	CreateServiceAccount(ctx context.Context, in *CreateServiceAccountRequest, opts ...grpc.CallOption) (*CreateServiceAccountResponse, error)
+	// ListServiceAccounts returns all service accounts.
+	ListServiceAccounts(ctx context.Context, in *ListServiceAccountsRequest, opts ...grpc.CallOption) (*ListServiceAccountsResponse, error)
+	// DeleteServiceAccount deletes the service account along with its keys.
+	DeleteServiceAccount(ctx context.Context, in *DeleteServiceAccountRequest, opts ...grpc.CallOption) (*DeleteServiceAccountResponse, error)
+	// CreateAPIKey creates the API key of the service account.
+	CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyResponse, error)
+	// ListAPIKeys returns API keys of the service account.
+	ListAPIKeys(ctx context.Context, in *ListAPIKeysRequest, opts ...grpc.CallOption) (*ListAPIKeysResponse, error)
+	// RevokeAPIKey revokes the API key.
+	RevokeAPIKey(ctx context.Context, in *RevokeAPIKeyRequest, opts ...grpc.CallOption) (*RevokeAPIKeyResponse, error)
+	// GetMessage returns the message of the queue by its identifier without changing its visibility.
+	GetMessage(ctx context.Context, in *GetMessageRequest, opts ...grpc.CallOption) (*GetMessageResponse, error)
+	// Transact performs sends and deletes across queues in a single transaction, so either all of them
+	// take effect or none, e.g. a message is deleted from one queue and its result is sent to another.
+	Transact(ctx context.Context, in *TransactRequest, opts ...grpc.CallOption) (*TransactResponse, error)
+	// GetJob returns the background job along with its progress.
+	GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*GetJobResponse, error)
+	// ListJobs returns background jobs along with their progress.
+	ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error)
+	// CancelJob stops the running background job. Work the job has done so far stays done.
+	CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error)
+	// ListArchivedMessages returns messages evicted to the archive of the queue.
+	ListArchivedMessages(ctx context.Context, in *ListArchivedMessagesRequest, opts ...grpc.CallOption) (*ListArchivedMessagesResponse, error)
+	// RestoreArchivedMessages moves archived messages back to the queue as visible messages
+	// received zero times.
+	RestoreArchivedMessages(ctx context.Context, in *RestoreArchivedMessagesRequest, opts ...grpc.CallOption) (*RestoreArchivedMessagesResponse, error)
+	// ListMessageEvents returns retained lifecycle events of the message, e.g. receives and
+	// visibility changes, for debugging of its delivery.
+	ListMessageEvents(ctx context.Context, in *ListMessageEventsRequest, opts ...grpc.CallOption) (*ListMessageEventsResponse, error)
+	// ExtendVisibilityBatch changes visibility timeouts of many received messages by their
+	// receipt handles at once, reporting the result of each of them.
+	ExtendVisibilityBatch(ctx context.Context, in *ExtendVisibilityBatchRequest, opts ...grpc.CallOption) (*ExtendVisibilityBatchResponse, error)
+	// ListQueuePermissions returns permissions granted to roles on the queue.
+	ListQueuePermissions(ctx context.Context, in *ListQueuePermissionsRequest, opts ...grpc.CallOption) (*ListQueuePermissionsResponse, error)
+	// SetQueuePermission grants permissions on the queue to the role.
+	SetQueuePermission(ctx context.Context, in *SetQueuePermissionRequest, opts ...grpc.CallOption) (*SetQueuePermissionResponse, error)
+	// DeleteQueuePermission revokes permissions on the queue from the role.
+	DeleteQueuePermission(ctx context.Context, in *DeleteQueuePermissionRequest, opts ...grpc.CallOption) (*DeleteQueuePermissionResponse, error)
+	// ListRoles returns roles along with users they are assigned to.
+	ListRoles(ctx context.Context, in *ListRolesRequest, opts ...grpc.CallOption) (*ListRolesResponse, error)
+	// CreateRole creates the role.
+	CreateRole(ctx context.Context, in *CreateRoleRequest, opts ...grpc.CallOption) (*CreateRoleResponse, error)
+	// DeleteRole deletes the role along with its permissions and assignments.
+	DeleteRole(ctx context.Context, in *DeleteRoleRequest, opts ...grpc.CallOption) (*DeleteRoleResponse, error)
+	// AssignRole assigns the role to the user.
+	AssignRole(ctx context.Context, in *AssignRoleRequest, opts ...grpc.CallOption) (*AssignRoleResponse, error)
+	// UnassignRole takes the role away from the user.
+	UnassignRole(ctx context.Context, in *UnassignRoleRequest, opts ...grpc.CallOption) (*UnassignRoleResponse, error)
+	// GetAccount returns the profile of the signed-in user.
+	GetAccount(ctx context.Context, in *GetAccountRequest, opts ...grpc.CallOption) (*GetAccountResponse, error)
+	// ClusterStatus returns the status of the cluster the server belongs to.
+	ClusterStatus(ctx context.Context, in *ClusterStatusRequest, opts ...grpc.CallOption) (*ClusterStatusResponse, error)
+	// RunMaintenance runs the maintenance operation of the storage database, e.g. the vacuum.
+	RunMaintenance(ctx context.Context, in *RunMaintenanceRequest, opts ...grpc.CallOption) (*RunMaintenanceResponse, error)
 }
 
 type plainQServiceClient struct {
@@ -141,6 +302,516 @@ func (c *plainQServiceClient) Delete(ctx context.Context, in *DeleteRequest, opt
 	return out, nil
 }
 
+func (c *plainQServiceClient) ChangeVisibility(ctx context.Context, in *ChangeVisibilityRequest, opts ...grpc.CallOption) (*ChangeVisibilityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChangeVisibilityResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ChangeVisibility_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) UpdateQueue(ctx context.Context, in *UpdateQueueRequest, opts ...grpc.CallOption) (*UpdateQueueResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateQueueResponse)
+	err := c.cc.Invoke(ctx, PlainQService_UpdateQueue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) AdviseQueue(ctx context.Context, in *AdviseQueueRequest, opts ...grpc.CallOption) (*AdviseQueueResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdviseQueueResponse)
+	err := c.cc.Invoke(ctx, PlainQService_AdviseQueue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) StartGenerator(ctx context.Context, in *StartGeneratorRequest, opts ...grpc.CallOption) (*StartGeneratorResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StartGeneratorResponse)
+	err := c.cc.Invoke(ctx, PlainQService_StartGenerator_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) StopGenerator(ctx context.Context, in *StopGeneratorRequest, opts ...grpc.CallOption) (*StopGeneratorResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StopGeneratorResponse)
+	err := c.cc.Invoke(ctx, PlainQService_StopGenerator_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ListGenerators(ctx context.Context, in *ListGeneratorsRequest, opts ...grpc.CallOption) (*ListGeneratorsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListGeneratorsResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ListGenerators_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) QueueStats(ctx context.Context, in *QueueStatsRequest, opts ...grpc.CallOption) (*QueueStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueueStatsResponse)
+	err := c.cc.Invoke(ctx, PlainQService_QueueStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) GetLogLevels(ctx context.Context, in *GetLogLevelsRequest, opts ...grpc.CallOption) (*GetLogLevelsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetLogLevelsResponse)
+	err := c.cc.Invoke(ctx, PlainQService_GetLogLevels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) SetLogLevels(ctx context.Context, in *SetLogLevelsRequest, opts ...grpc.CallOption) (*SetLogLevelsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetLogLevelsResponse)
+	err := c.cc.Invoke(ctx, PlainQService_SetLogLevels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) TransferQueue(ctx context.Context, in *TransferQueueRequest, opts ...grpc.CallOption) (*TransferQueueResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransferQueueResponse)
+	err := c.cc.Invoke(ctx, PlainQService_TransferQueue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) AcceptQueueTransfer(ctx context.Context, in *AcceptQueueTransferRequest, opts ...grpc.CallOption) (*AcceptQueueTransferResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AcceptQueueTransferResponse)
+	err := c.cc.Invoke(ctx, PlainQService_AcceptQueueTransfer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) CancelQueueTransfer(ctx context.Context, in *CancelQueueTransferRequest, opts ...grpc.CallOption) (*CancelQueueTransferResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelQueueTransferResponse)
+	err := c.cc.Invoke(ctx, PlainQService_CancelQueueTransfer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) SearchMessages(ctx context.Context, in *SearchMessagesRequest, opts ...grpc.CallOption) (*SearchMessagesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchMessagesResponse)
+	err := c.cc.Invoke(ctx, PlainQService_SearchMessages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) PeekMessages(ctx context.Context, in *PeekMessagesRequest, opts ...grpc.CallOption) (*PeekMessagesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PeekMessagesResponse)
+	err := c.cc.Invoke(ctx, PlainQService_PeekMessages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*ReloadConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReloadConfigResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ReloadConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ListBreakers(ctx context.Context, in *ListBreakersRequest, opts ...grpc.CallOption) (*ListBreakersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListBreakersResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ListBreakers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ResetBreaker(ctx context.Context, in *ResetBreakerRequest, opts ...grpc.CallOption) (*ResetBreakerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResetBreakerResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ResetBreaker_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) SetQueueState(ctx context.Context, in *SetQueueStateRequest, opts ...grpc.CallOption) (*SetQueueStateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetQueueStateResponse)
+	err := c.cc.Invoke(ctx, PlainQService_SetQueueState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, PlainQService_Search_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAuditEventsResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ListAuditEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) CreateAlertRule(ctx context.Context, in *CreateAlertRuleRequest, opts ...grpc.CallOption) (*CreateAlertRuleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateAlertRuleResponse)
+	err := c.cc.Invoke(ctx, PlainQService_CreateAlertRule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ListAlertRules(ctx context.Context, in *ListAlertRulesRequest, opts ...grpc.CallOption) (*ListAlertRulesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAlertRulesResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ListAlertRules_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) UpdateAlertRule(ctx context.Context, in *UpdateAlertRuleRequest, opts ...grpc.CallOption) (*UpdateAlertRuleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateAlertRuleResponse)
+	err := c.cc.Invoke(ctx, PlainQService_UpdateAlertRule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) DeleteAlertRule(ctx context.Context, in *DeleteAlertRuleRequest, opts ...grpc.CallOption) (*DeleteAlertRuleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteAlertRuleResponse)
+	err := c.cc.Invoke(ctx, PlainQService_DeleteAlertRule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ListAlerts(ctx context.Context, in *ListAlertsRequest, opts ...grpc.CallOption) (*ListAlertsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAlertsResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ListAlerts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) CreateServiceAccount(ctx context.Context, in *CreateServiceAccountRequest, opts ...grpc.CallOption) (*CreateServiceAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateServiceAccountResponse)
+	err := c.cc.Invoke(ctx, PlainQService_CreateServiceAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ListServiceAccounts(ctx context.Context, in *ListServiceAccountsRequest, opts ...grpc.CallOption) (*ListServiceAccountsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListServiceAccountsResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ListServiceAccounts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) DeleteServiceAccount(ctx context.Context, in *DeleteServiceAccountRequest, opts ...grpc.CallOption) (*DeleteServiceAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteServiceAccountResponse)
+	err := c.cc.Invoke(ctx, PlainQService_DeleteServiceAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateAPIKeyResponse)
+	err := c.cc.Invoke(ctx, PlainQService_CreateAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ListAPIKeys(ctx context.Context, in *ListAPIKeysRequest, opts ...grpc.CallOption) (*ListAPIKeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAPIKeysResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ListAPIKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) RevokeAPIKey(ctx context.Context, in *RevokeAPIKeyRequest, opts ...grpc.CallOption) (*RevokeAPIKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeAPIKeyResponse)
+	err := c.cc.Invoke(ctx, PlainQService_RevokeAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) GetMessage(ctx context.Context, in *GetMessageRequest, opts ...grpc.CallOption) (*GetMessageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetMessageResponse)
+	err := c.cc.Invoke(ctx, PlainQService_GetMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) Transact(ctx context.Context, in *TransactRequest, opts ...grpc.CallOption) (*TransactResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransactResponse)
+	err := c.cc.Invoke(ctx, PlainQService_Transact_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*GetJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetJobResponse)
+	err := c.cc.Invoke(ctx, PlainQService_GetJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListJobsResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ListJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelJobResponse)
+	err := c.cc.Invoke(ctx, PlainQService_CancelJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ListArchivedMessages(ctx context.Context, in *ListArchivedMessagesRequest, opts ...grpc.CallOption) (*ListArchivedMessagesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListArchivedMessagesResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ListArchivedMessages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) RestoreArchivedMessages(ctx context.Context, in *RestoreArchivedMessagesRequest, opts ...grpc.CallOption) (*RestoreArchivedMessagesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreArchivedMessagesResponse)
+	err := c.cc.Invoke(ctx, PlainQService_RestoreArchivedMessages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ListMessageEvents(ctx context.Context, in *ListMessageEventsRequest, opts ...grpc.CallOption) (*ListMessageEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMessageEventsResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ListMessageEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ExtendVisibilityBatch(ctx context.Context, in *ExtendVisibilityBatchRequest, opts ...grpc.CallOption) (*ExtendVisibilityBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExtendVisibilityBatchResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ExtendVisibilityBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ListQueuePermissions(ctx context.Context, in *ListQueuePermissionsRequest, opts ...grpc.CallOption) (*ListQueuePermissionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListQueuePermissionsResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ListQueuePermissions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) SetQueuePermission(ctx context.Context, in *SetQueuePermissionRequest, opts ...grpc.CallOption) (*SetQueuePermissionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetQueuePermissionResponse)
+	err := c.cc.Invoke(ctx, PlainQService_SetQueuePermission_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) DeleteQueuePermission(ctx context.Context, in *DeleteQueuePermissionRequest, opts ...grpc.CallOption) (*DeleteQueuePermissionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteQueuePermissionResponse)
+	err := c.cc.Invoke(ctx, PlainQService_DeleteQueuePermission_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ListRoles(ctx context.Context, in *ListRolesRequest, opts ...grpc.CallOption) (*ListRolesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRolesResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ListRoles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) CreateRole(ctx context.Context, in *CreateRoleRequest, opts ...grpc.CallOption) (*CreateRoleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateRoleResponse)
+	err := c.cc.Invoke(ctx, PlainQService_CreateRole_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) DeleteRole(ctx context.Context, in *DeleteRoleRequest, opts ...grpc.CallOption) (*DeleteRoleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteRoleResponse)
+	err := c.cc.Invoke(ctx, PlainQService_DeleteRole_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) AssignRole(ctx context.Context, in *AssignRoleRequest, opts ...grpc.CallOption) (*AssignRoleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AssignRoleResponse)
+	err := c.cc.Invoke(ctx, PlainQService_AssignRole_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) UnassignRole(ctx context.Context, in *UnassignRoleRequest, opts ...grpc.CallOption) (*UnassignRoleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnassignRoleResponse)
+	err := c.cc.Invoke(ctx, PlainQService_UnassignRole_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) GetAccount(ctx context.Context, in *GetAccountRequest, opts ...grpc.CallOption) (*GetAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetAccountResponse)
+	err := c.cc.Invoke(ctx, PlainQService_GetAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) ClusterStatus(ctx context.Context, in *ClusterStatusRequest, opts ...grpc.CallOption) (*ClusterStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClusterStatusResponse)
+	err := c.cc.Invoke(ctx, PlainQService_ClusterStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plainQServiceClient) RunMaintenance(ctx context.Context, in *RunMaintenanceRequest, opts ...grpc.CallOption) (*RunMaintenanceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RunMaintenanceResponse)
+	err := c.cc.Invoke(ctx, PlainQService_RunMaintenance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PlainQServiceServer is the server API for PlainQService service.
 // All implementations must embed UnimplementedPlainQServiceServer
 // for forward compatibility.
@@ -163,201 +834,1382 @@ type PlainQServiceServer interface {
 	Receive(context.Context, *ReceiveRequest) (*ReceiveResponse, error)
 	// Delete deletes message from the queue.
 	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	// ChangeVisibility changes the visibility timeout of a received message.
+	ChangeVisibility(context.Context, *ChangeVisibilityRequest) (*ChangeVisibilityResponse, error)
+	// UpdateQueue updates properties of the queue.
+	UpdateQueue(context.Context, *UpdateQueueRequest) (*UpdateQueueResponse, error)
+	// AdviseQueue analyzes the queue telemetry and suggests queue settings.
+	AdviseQueue(context.Context, *AdviseQueueRequest) (*AdviseQueueResponse, error)
+	// StartGenerator starts a synthetic producer which sends generated messages to the queue.
+	StartGenerator(context.Context, *StartGeneratorRequest) (*StartGeneratorResponse, error)
+	// StopGenerator stops the synthetic producer.
+	StopGenerator(context.Context, *StopGeneratorRequest) (*StopGeneratorResponse, error)
+	// ListGenerators returns the list of running synthetic producers.
+	ListGenerators(context.Context, *ListGeneratorsRequest) (*ListGeneratorsResponse, error)
+	// QueueStats returns the current statistics of the queue.
+	QueueStats(context.Context, *QueueStatsRequest) (*QueueStatsResponse, error)
+	// GetLogLevels returns log levels of server subsystems.
+	GetLogLevels(context.Context, *GetLogLevelsRequest) (*GetLogLevelsResponse, error)
+	// SetLogLevels changes log levels of server subsystems at runtime.
+	SetLogLevels(context.Context, *SetLogLevelsRequest) (*SetLogLevelsResponse, error)
+	// TransferQueue requests the transfer of the queue ownership to another team.
+	TransferQueue(context.Context, *TransferQueueRequest) (*TransferQueueResponse, error)
+	// AcceptQueueTransfer confirms the pending transfer of the queue ownership.
+	AcceptQueueTransfer(context.Context, *AcceptQueueTransferRequest) (*AcceptQueueTransferResponse, error)
+	// CancelQueueTransfer cancels the pending transfer of the queue ownership.
+	CancelQueueTransfer(context.Context, *CancelQueueTransferRequest) (*CancelQueueTransferResponse, error)
+	// SearchMessages searches messages of the queue with the search index by their content.
+	SearchMessages(context.Context, *SearchMessagesRequest) (*SearchMessagesResponse, error)
+	// PeekMessages returns messages of the queue in the order they were sent
+	// without receiving them. Pages are iterated with an opaque cursor.
+	PeekMessages(context.Context, *PeekMessagesRequest) (*PeekMessagesResponse, error)
+	// ReloadConfig re-reads the configuration and applies settings
+	// which can be changed without restarting the server.
+	ReloadConfig(context.Context, *ReloadConfigRequest) (*ReloadConfigResponse, error)
+	// ListBreakers returns queues frozen by the circuit breaker.
+	ListBreakers(context.Context, *ListBreakersRequest) (*ListBreakersResponse, error)
+	// ResetBreaker unfreezes the queue frozen by the circuit breaker.
+	ResetBreaker(context.Context, *ResetBreakerRequest) (*ResetBreakerResponse, error)
+	// SetQueueState moves the queue to another lifecycle state.
+	// Transitions which are not allowed from the current state are rejected.
+	SetQueueState(context.Context, *SetQueueStateRequest) (*SetQueueStateResponse, error)
+	// Search matches queues, users, roles and recent audit events against the query.
+	// Results are ordered by relevance, so the best match comes first.
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	// ListAuditEvents returns recorded mutations which match the filter, newest first.
+	ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error)
+	// CreateAlertRule creates the threshold rule over an observed metric.
+	CreateAlertRule(context.Context, *CreateAlertRuleRequest) (*CreateAlertRuleResponse, error)
+	// ListAlertRules returns all alert rules.
+	ListAlertRules(context.Context, *ListAlertRulesRequest) (*ListAlertRulesResponse, error)
+	// UpdateAlertRule replaces the alert rule.
+	UpdateAlertRule(context.Context, *UpdateAlertRuleRequest) (*UpdateAlertRuleResponse, error)
+	// DeleteAlertRule deletes the alert rule.
+	DeleteAlertRule(context.Context, *DeleteAlertRuleRequest) (*DeleteAlertRuleResponse, error)
+	// ListAlerts returns current states of alert rules.
+	ListAlerts(context.Context, *ListAlertsRequest) (*ListAlertsResponse, error)
+	// CreateServiceAccount creates the service account.
+	CreateServiceAccount(context.Context, *CreateServiceAccountRequest) (*CreateServiceAccountResponse, error)
+	// ListServiceAccounts returns all service accounts.
+	ListServiceAccounts(context.Context, *ListServiceAccountsRequest) (*ListServiceAccountsResponse, error)
+	// DeleteServiceAccount deletes the service account along with its keys.
+	DeleteServiceAccount(context.Context, *DeleteServiceAccountRequest) (*DeleteServiceAccountResponse, error)
+	// CreateAPIKey creates the API key of the service account.
+	CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error)
+	// ListAPIKeys returns API keys of the service account.
+	ListAPIKeys(context.Context, *ListAPIKeysRequest) (*ListAPIKeysResponse, error)
+	// RevokeAPIKey revokes the API key.
+	RevokeAPIKey(context.Context, *RevokeAPIKeyRequest) (*RevokeAPIKeyResponse, error)
+	// GetMessage returns the message of the queue by its identifier without changing its visibility.
+	GetMessage(context.Context, *GetMessageRequest) (*GetMessageResponse, error)
+	// Transact performs sends and deletes across queues in a single transaction, so either all of them
+	// take effect or none, e.g. a message is deleted from one queue and its result is sent to another.
+	Transact(context.Context, *TransactRequest) (*TransactResponse, error)
+	// GetJob returns the background job along with its progress.
+	GetJob(context.Context, *GetJobRequest) (*GetJobResponse, error)
+	// ListJobs returns background jobs along with their progress.
+	ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error)
+	// CancelJob stops the running background job. Work the job has done so far stays done.
+	CancelJob(context.Context, *CancelJobRequest) (*CancelJobResponse, error)
+	// ListArchivedMessages returns messages evicted to the archive of the queue.
+	ListArchivedMessages(context.Context, *ListArchivedMessagesRequest) (*ListArchivedMessagesResponse, error)
+	// RestoreArchivedMessages moves archived messages back to the queue as visible messages
+	// received zero times.
+	RestoreArchivedMessages(context.Context, *RestoreArchivedMessagesRequest) (*RestoreArchivedMessagesResponse, error)
+	// ListMessageEvents returns retained lifecycle events of the message, e.g. receives and
+	// visibility changes, for debugging of its delivery.
+	ListMessageEvents(context.Context, *ListMessageEventsRequest) (*ListMessageEventsResponse, error)
+	// ExtendVisibilityBatch changes visibility timeouts of many received messages by their
+	// receipt handles at once, reporting the result of each of them.
+	ExtendVisibilityBatch(context.Context, *ExtendVisibilityBatchRequest) (*ExtendVisibilityBatchResponse, error)
+	// ListQueuePermissions returns permissions granted to roles on the queue.
+	ListQueuePermissions(context.Context, *ListQueuePermissionsRequest) (*ListQueuePermissionsResponse, error)
+	// SetQueuePermission grants permissions on the queue to the role.
+	SetQueuePermission(context.Context, *SetQueuePermissionRequest) (*SetQueuePermissionResponse, error)
+	// DeleteQueuePermission revokes permissions on the queue from the role.
+	DeleteQueuePermission(context.Context, *DeleteQueuePermissionRequest) (*DeleteQueuePermissionResponse, error)
+	// ListRoles returns roles along with users they are assigned to.
+	ListRoles(context.Context, *ListRolesRequest) (*ListRolesResponse, error)
+	// CreateRole creates the role.
+	CreateRole(context.Context, *CreateRoleRequest) (*CreateRoleResponse, error)
+	// DeleteRole deletes the role along with its permissions and assignments.
+	DeleteRole(context.Context, *DeleteRoleRequest) (*DeleteRoleResponse, error)
+	// AssignRole assigns the role to the user.
+	AssignRole(context.Context, *AssignRoleRequest) (*AssignRoleResponse, error)
+	// UnassignRole takes the role away from the user.
+	UnassignRole(context.Context, *UnassignRoleRequest) (*UnassignRoleResponse, error)
+	// GetAccount returns the profile of the signed-in user.
+	GetAccount(context.Context, *GetAccountRequest) (*GetAccountResponse, error)
+	// ClusterStatus returns the status of the cluster the server belongs to.
+	ClusterStatus(context.Context, *ClusterStatusRequest) (*ClusterStatusResponse, error)
+	// RunMaintenance runs the maintenance operation of the storage database, e.g. the vacuum.
+	RunMaintenance(context.Context, *RunMaintenanceRequest) (*RunMaintenanceResponse, error)
+	mustEmbedUnimplementedPlainQServiceServer()
+}
+
+// UnimplementedPlainQServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPlainQServiceServer struct{}
+
+func (UnimplementedPlainQServiceServer) ListQueues(context.Context, *ListQueuesRequest) (*ListQueuesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListQueues not implemented")
+}
+func (UnimplementedPlainQServiceServer) DescribeQueue(context.Context, *DescribeQueueRequest) (*DescribeQueueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DescribeQueue not implemented")
+}
+func (UnimplementedPlainQServiceServer) CreateQueue(context.Context, *CreateQueueRequest) (*CreateQueueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateQueue not implemented")
+}
+func (UnimplementedPlainQServiceServer) PurgeQueue(context.Context, *PurgeQueueRequest) (*PurgeQueueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgeQueue not implemented")
+}
+func (UnimplementedPlainQServiceServer) DeleteQueue(context.Context, *DeleteQueueRequest) (*DeleteQueueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteQueue not implemented")
+}
+func (UnimplementedPlainQServiceServer) Send(context.Context, *SendRequest) (*SendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Send not implemented")
+}
+func (UnimplementedPlainQServiceServer) Receive(context.Context, *ReceiveRequest) (*ReceiveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Receive not implemented")
+}
+func (UnimplementedPlainQServiceServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedPlainQServiceServer) ChangeVisibility(context.Context, *ChangeVisibilityRequest) (*ChangeVisibilityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangeVisibility not implemented")
+}
+func (UnimplementedPlainQServiceServer) UpdateQueue(context.Context, *UpdateQueueRequest) (*UpdateQueueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateQueue not implemented")
+}
+func (UnimplementedPlainQServiceServer) AdviseQueue(context.Context, *AdviseQueueRequest) (*AdviseQueueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdviseQueue not implemented")
+}
+func (UnimplementedPlainQServiceServer) StartGenerator(context.Context, *StartGeneratorRequest) (*StartGeneratorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartGenerator not implemented")
+}
+func (UnimplementedPlainQServiceServer) StopGenerator(context.Context, *StopGeneratorRequest) (*StopGeneratorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopGenerator not implemented")
+}
+func (UnimplementedPlainQServiceServer) ListGenerators(context.Context, *ListGeneratorsRequest) (*ListGeneratorsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGenerators not implemented")
+}
+func (UnimplementedPlainQServiceServer) QueueStats(context.Context, *QueueStatsRequest) (*QueueStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueueStats not implemented")
+}
+func (UnimplementedPlainQServiceServer) GetLogLevels(context.Context, *GetLogLevelsRequest) (*GetLogLevelsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLogLevels not implemented")
+}
+func (UnimplementedPlainQServiceServer) SetLogLevels(context.Context, *SetLogLevelsRequest) (*SetLogLevelsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevels not implemented")
+}
+func (UnimplementedPlainQServiceServer) TransferQueue(context.Context, *TransferQueueRequest) (*TransferQueueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransferQueue not implemented")
+}
+func (UnimplementedPlainQServiceServer) AcceptQueueTransfer(context.Context, *AcceptQueueTransferRequest) (*AcceptQueueTransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptQueueTransfer not implemented")
+}
+func (UnimplementedPlainQServiceServer) CancelQueueTransfer(context.Context, *CancelQueueTransferRequest) (*CancelQueueTransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelQueueTransfer not implemented")
+}
+func (UnimplementedPlainQServiceServer) SearchMessages(context.Context, *SearchMessagesRequest) (*SearchMessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchMessages not implemented")
+}
+func (UnimplementedPlainQServiceServer) PeekMessages(context.Context, *PeekMessagesRequest) (*PeekMessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PeekMessages not implemented")
+}
+func (UnimplementedPlainQServiceServer) ReloadConfig(context.Context, *ReloadConfigRequest) (*ReloadConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReloadConfig not implemented")
+}
+func (UnimplementedPlainQServiceServer) ListBreakers(context.Context, *ListBreakersRequest) (*ListBreakersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBreakers not implemented")
+}
+func (UnimplementedPlainQServiceServer) ResetBreaker(context.Context, *ResetBreakerRequest) (*ResetBreakerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetBreaker not implemented")
+}
+func (UnimplementedPlainQServiceServer) SetQueueState(context.Context, *SetQueueStateRequest) (*SetQueueStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetQueueState not implemented")
+}
+func (UnimplementedPlainQServiceServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedPlainQServiceServer) ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEvents not implemented")
+}
+func (UnimplementedPlainQServiceServer) CreateAlertRule(context.Context, *CreateAlertRuleRequest) (*CreateAlertRuleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAlertRule not implemented")
+}
+func (UnimplementedPlainQServiceServer) ListAlertRules(context.Context, *ListAlertRulesRequest) (*ListAlertRulesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAlertRules not implemented")
+}
+func (UnimplementedPlainQServiceServer) UpdateAlertRule(context.Context, *UpdateAlertRuleRequest) (*UpdateAlertRuleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateAlertRule not implemented")
+}
+func (UnimplementedPlainQServiceServer) DeleteAlertRule(context.Context, *DeleteAlertRuleRequest) (*DeleteAlertRuleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAlertRule not implemented")
+}
+func (UnimplementedPlainQServiceServer) ListAlerts(context.Context, *ListAlertsRequest) (*ListAlertsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAlerts not implemented")
+}
+func (UnimplementedPlainQServiceServer) CreateServiceAccount(context.Context, *CreateServiceAccountRequest) (*CreateServiceAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateServiceAccount not implemented")
+}
+func (UnimplementedPlainQServiceServer) ListServiceAccounts(context.Context, *ListServiceAccountsRequest) (*ListServiceAccountsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListServiceAccounts not implemented")
+}
+func (UnimplementedPlainQServiceServer) DeleteServiceAccount(context.Context, *DeleteServiceAccountRequest) (*DeleteServiceAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteServiceAccount not implemented")
+}
+func (UnimplementedPlainQServiceServer) CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAPIKey not implemented")
+}
+func (UnimplementedPlainQServiceServer) ListAPIKeys(context.Context, *ListAPIKeysRequest) (*ListAPIKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAPIKeys not implemented")
+}
+func (UnimplementedPlainQServiceServer) RevokeAPIKey(context.Context, *RevokeAPIKeyRequest) (*RevokeAPIKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeAPIKey not implemented")
+}
+func (UnimplementedPlainQServiceServer) GetMessage(context.Context, *GetMessageRequest) (*GetMessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMessage not implemented")
+}
+func (UnimplementedPlainQServiceServer) Transact(context.Context, *TransactRequest) (*TransactResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Transact not implemented")
+}
+func (UnimplementedPlainQServiceServer) GetJob(context.Context, *GetJobRequest) (*GetJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJob not implemented")
+}
+func (UnimplementedPlainQServiceServer) ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJobs not implemented")
+}
+func (UnimplementedPlainQServiceServer) CancelJob(context.Context, *CancelJobRequest) (*CancelJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelJob not implemented")
+}
+func (UnimplementedPlainQServiceServer) ListArchivedMessages(context.Context, *ListArchivedMessagesRequest) (*ListArchivedMessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListArchivedMessages not implemented")
+}
+func (UnimplementedPlainQServiceServer) RestoreArchivedMessages(context.Context, *RestoreArchivedMessagesRequest) (*RestoreArchivedMessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreArchivedMessages not implemented")
+}
+func (UnimplementedPlainQServiceServer) ListMessageEvents(context.Context, *ListMessageEventsRequest) (*ListMessageEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMessageEvents not implemented")
+}
+func (UnimplementedPlainQServiceServer) ExtendVisibilityBatch(context.Context, *ExtendVisibilityBatchRequest) (*ExtendVisibilityBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExtendVisibilityBatch not implemented")
+}
+func (UnimplementedPlainQServiceServer) ListQueuePermissions(context.Context, *ListQueuePermissionsRequest) (*ListQueuePermissionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListQueuePermissions not implemented")
+}
+func (UnimplementedPlainQServiceServer) SetQueuePermission(context.Context, *SetQueuePermissionRequest) (*SetQueuePermissionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetQueuePermission not implemented")
+}
+func (UnimplementedPlainQServiceServer) DeleteQueuePermission(context.Context, *DeleteQueuePermissionRequest) (*DeleteQueuePermissionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteQueuePermission not implemented")
+}
+func (UnimplementedPlainQServiceServer) ListRoles(context.Context, *ListRolesRequest) (*ListRolesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRoles not implemented")
+}
+func (UnimplementedPlainQServiceServer) CreateRole(context.Context, *CreateRoleRequest) (*CreateRoleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateRole not implemented")
+}
+func (UnimplementedPlainQServiceServer) DeleteRole(context.Context, *DeleteRoleRequest) (*DeleteRoleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRole not implemented")
+}
+func (UnimplementedPlainQServiceServer) AssignRole(context.Context, *AssignRoleRequest) (*AssignRoleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AssignRole not implemented")
+}
+func (UnimplementedPlainQServiceServer) UnassignRole(context.Context, *UnassignRoleRequest) (*UnassignRoleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnassignRole not implemented")
+}
+func (UnimplementedPlainQServiceServer) GetAccount(context.Context, *GetAccountRequest) (*GetAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccount not implemented")
+}
+func (UnimplementedPlainQServiceServer) ClusterStatus(context.Context, *ClusterStatusRequest) (*ClusterStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClusterStatus not implemented")
+}
+func (UnimplementedPlainQServiceServer) RunMaintenance(context.Context, *RunMaintenanceRequest) (*RunMaintenanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunMaintenance not implemented")
+}
+func (UnimplementedPlainQServiceServer) mustEmbedUnimplementedPlainQServiceServer() {}
+func (UnimplementedPlainQServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafePlainQServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PlainQServiceServer will
+// result in compilation errors.
+type UnsafePlainQServiceServer interface {
 	mustEmbedUnimplementedPlainQServiceServer()
 }
 
-// UnimplementedPlainQServiceServer must be embedded to have
-// forward compatible implementations.
-//
-// NOTE: this should be embedded by value instead of pointer to avoid a nil
-// pointer dereference when methods are called.
-type UnimplementedPlainQServiceServer struct{}
+func RegisterPlainQServiceServer(s grpc.ServiceRegistrar, srv PlainQServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPlainQServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PlainQService_ServiceDesc, srv)
+}
+
+func _PlainQService_ListQueues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListQueuesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ListQueues(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ListQueues_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ListQueues(ctx, req.(*ListQueuesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_DescribeQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribeQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).DescribeQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_DescribeQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).DescribeQueue(ctx, req.(*DescribeQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_CreateQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).CreateQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_CreateQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).CreateQueue(ctx, req.(*CreateQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_PurgeQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).PurgeQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_PurgeQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).PurgeQueue(ctx, req.(*PurgeQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_DeleteQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).DeleteQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_DeleteQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).DeleteQueue(ctx, req.(*DeleteQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_Send_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).Send(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_Send_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).Send(ctx, req.(*SendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_Receive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReceiveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).Receive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_Receive_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).Receive(ctx, req.(*ReceiveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_ChangeVisibility_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangeVisibilityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ChangeVisibility(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ChangeVisibility_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ChangeVisibility(ctx, req.(*ChangeVisibilityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_UpdateQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).UpdateQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_UpdateQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).UpdateQueue(ctx, req.(*UpdateQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_AdviseQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdviseQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).AdviseQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_AdviseQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).AdviseQueue(ctx, req.(*AdviseQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_StartGenerator_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartGeneratorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).StartGenerator(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_StartGenerator_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).StartGenerator(ctx, req.(*StartGeneratorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_StopGenerator_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopGeneratorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).StopGenerator(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_StopGenerator_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).StopGenerator(ctx, req.(*StopGeneratorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_ListGenerators_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGeneratorsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ListGenerators(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ListGenerators_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ListGenerators(ctx, req.(*ListGeneratorsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_QueueStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueueStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).QueueStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_QueueStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).QueueStats(ctx, req.(*QueueStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_GetLogLevels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLogLevelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).GetLogLevels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_GetLogLevels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).GetLogLevels(ctx, req.(*GetLogLevelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_SetLogLevels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLogLevelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).SetLogLevels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_SetLogLevels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).SetLogLevels(ctx, req.(*SetLogLevelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_TransferQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).TransferQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_TransferQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).TransferQueue(ctx, req.(*TransferQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_AcceptQueueTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcceptQueueTransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).AcceptQueueTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_AcceptQueueTransfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).AcceptQueueTransfer(ctx, req.(*AcceptQueueTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_CancelQueueTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelQueueTransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).CancelQueueTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_CancelQueueTransfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).CancelQueueTransfer(ctx, req.(*CancelQueueTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_SearchMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).SearchMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_SearchMessages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).SearchMessages(ctx, req.(*SearchMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_PeekMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeekMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).PeekMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_PeekMessages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).PeekMessages(ctx, req.(*PeekMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_ReloadConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ReloadConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ReloadConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ReloadConfig(ctx, req.(*ReloadConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_ListBreakers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBreakersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ListBreakers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ListBreakers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ListBreakers(ctx, req.(*ListBreakersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_ResetBreaker_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetBreakerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ResetBreaker(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ResetBreaker_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ResetBreaker(ctx, req.(*ResetBreakerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_SetQueueState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetQueueStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).SetQueueState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_SetQueueState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).SetQueueState(ctx, req.(*SetQueueStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 
-func (UnimplementedPlainQServiceServer) ListQueues(context.Context, *ListQueuesRequest) (*ListQueuesResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ListQueues not implemented")
+func _PlainQService_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedPlainQServiceServer) DescribeQueue(context.Context, *DescribeQueueRequest) (*DescribeQueueResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method DescribeQueue not implemented")
+
+func _PlainQService_ListAuditEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ListAuditEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ListAuditEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ListAuditEvents(ctx, req.(*ListAuditEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedPlainQServiceServer) CreateQueue(context.Context, *CreateQueueRequest) (*CreateQueueResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method CreateQueue not implemented")
+
+func _PlainQService_CreateAlertRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAlertRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).CreateAlertRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_CreateAlertRule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).CreateAlertRule(ctx, req.(*CreateAlertRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_ListAlertRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAlertRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ListAlertRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ListAlertRules_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ListAlertRules(ctx, req.(*ListAlertRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_UpdateAlertRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateAlertRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).UpdateAlertRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_UpdateAlertRule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).UpdateAlertRule(ctx, req.(*UpdateAlertRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_DeleteAlertRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAlertRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).DeleteAlertRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_DeleteAlertRule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).DeleteAlertRule(ctx, req.(*DeleteAlertRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_ListAlerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAlertsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ListAlerts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ListAlerts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ListAlerts(ctx, req.(*ListAlertsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_CreateServiceAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateServiceAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).CreateServiceAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_CreateServiceAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).CreateServiceAccount(ctx, req.(*CreateServiceAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_ListServiceAccounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListServiceAccountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ListServiceAccounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ListServiceAccounts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ListServiceAccounts(ctx, req.(*ListServiceAccountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_DeleteServiceAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteServiceAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).DeleteServiceAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_DeleteServiceAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).DeleteServiceAccount(ctx, req.(*DeleteServiceAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_CreateAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).CreateAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_CreateAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).CreateAPIKey(ctx, req.(*CreateAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_ListAPIKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAPIKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ListAPIKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ListAPIKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ListAPIKeys(ctx, req.(*ListAPIKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_RevokeAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).RevokeAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_RevokeAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).RevokeAPIKey(ctx, req.(*RevokeAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_GetMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).GetMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_GetMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).GetMessage(ctx, req.(*GetMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_Transact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).Transact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_Transact_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).Transact(ctx, req.(*TransactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_GetJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).GetJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_GetJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).GetJob(ctx, req.(*GetJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ListJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ListJobs(ctx, req.(*ListJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlainQService_CancelJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).CancelJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_CancelJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).CancelJob(ctx, req.(*CancelJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedPlainQServiceServer) PurgeQueue(context.Context, *PurgeQueueRequest) (*PurgeQueueResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method PurgeQueue not implemented")
+
+func _PlainQService_ListArchivedMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListArchivedMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ListArchivedMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ListArchivedMessages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ListArchivedMessages(ctx, req.(*ListArchivedMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedPlainQServiceServer) DeleteQueue(context.Context, *DeleteQueueRequest) (*DeleteQueueResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method DeleteQueue not implemented")
+
+func _PlainQService_RestoreArchivedMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreArchivedMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).RestoreArchivedMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_RestoreArchivedMessages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).RestoreArchivedMessages(ctx, req.(*RestoreArchivedMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedPlainQServiceServer) Send(context.Context, *SendRequest) (*SendResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Send not implemented")
+
+func _PlainQService_ListMessageEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMessageEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ListMessageEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ListMessageEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ListMessageEvents(ctx, req.(*ListMessageEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedPlainQServiceServer) Receive(context.Context, *ReceiveRequest) (*ReceiveResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Receive not implemented")
+
+func _PlainQService_ExtendVisibilityBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExtendVisibilityBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ExtendVisibilityBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ExtendVisibilityBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ExtendVisibilityBatch(ctx, req.(*ExtendVisibilityBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedPlainQServiceServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+
+func _PlainQService_ListQueuePermissions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListQueuePermissionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).ListQueuePermissions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_ListQueuePermissions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).ListQueuePermissions(ctx, req.(*ListQueuePermissionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedPlainQServiceServer) mustEmbedUnimplementedPlainQServiceServer() {}
-func (UnimplementedPlainQServiceServer) testEmbeddedByValue()                       {}
 
-// UnsafePlainQServiceServer may be embedded to opt out of forward compatibility for this service.
-// Use of this interface is not recommended, as added methods to PlainQServiceServer will
-// result in compilation errors.
-type UnsafePlainQServiceServer interface {
-	mustEmbedUnimplementedPlainQServiceServer()
+func _PlainQService_SetQueuePermission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetQueuePermissionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).SetQueuePermission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_SetQueuePermission_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).SetQueuePermission(ctx, req.(*SetQueuePermissionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func RegisterPlainQServiceServer(s grpc.ServiceRegistrar, srv PlainQServiceServer) {
-	// If the following call pancis, it indicates UnimplementedPlainQServiceServer was
-	// embedded by pointer and is nil.  This will cause panics if an
-	// unimplemented method is ever invoked, so we test this at initialization
-	// time to prevent it from happening at runtime later due to I/O.
-	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
-		t.testEmbeddedByValue()
+func _PlainQService_DeleteQueuePermission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteQueuePermissionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	s.RegisterService(&PlainQService_ServiceDesc, srv)
+	if interceptor == nil {
+		return srv.(PlainQServiceServer).DeleteQueuePermission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlainQService_DeleteQueuePermission_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlainQServiceServer).DeleteQueuePermission(ctx, req.(*DeleteQueuePermissionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _PlainQService_ListQueues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ListQueuesRequest)
+func _PlainQService_ListRoles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRolesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(PlainQServiceServer).ListQueues(ctx, in)
+		return srv.(PlainQServiceServer).ListRoles(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: PlainQService_ListQueues_FullMethodName,
+		FullMethod: PlainQService_ListRoles_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PlainQServiceServer).ListQueues(ctx, req.(*ListQueuesRequest))
+		return srv.(PlainQServiceServer).ListRoles(ctx, req.(*ListRolesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _PlainQService_DescribeQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DescribeQueueRequest)
+func _PlainQService_CreateRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRoleRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(PlainQServiceServer).DescribeQueue(ctx, in)
+		return srv.(PlainQServiceServer).CreateRole(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: PlainQService_DescribeQueue_FullMethodName,
+		FullMethod: PlainQService_CreateRole_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PlainQServiceServer).DescribeQueue(ctx, req.(*DescribeQueueRequest))
+		return srv.(PlainQServiceServer).CreateRole(ctx, req.(*CreateRoleRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _PlainQService_CreateQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CreateQueueRequest)
+func _PlainQService_DeleteRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRoleRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(PlainQServiceServer).CreateQueue(ctx, in)
+		return srv.(PlainQServiceServer).DeleteRole(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: PlainQService_CreateQueue_FullMethodName,
+		FullMethod: PlainQService_DeleteRole_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PlainQServiceServer).CreateQueue(ctx, req.(*CreateQueueRequest))
+		return srv.(PlainQServiceServer).DeleteRole(ctx, req.(*DeleteRoleRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _PlainQService_PurgeQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(PurgeQueueRequest)
+func _PlainQService_AssignRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssignRoleRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(PlainQServiceServer).PurgeQueue(ctx, in)
+		return srv.(PlainQServiceServer).AssignRole(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: PlainQService_PurgeQueue_FullMethodName,
+		FullMethod: PlainQService_AssignRole_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PlainQServiceServer).PurgeQueue(ctx, req.(*PurgeQueueRequest))
+		return srv.(PlainQServiceServer).AssignRole(ctx, req.(*AssignRoleRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _PlainQService_DeleteQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DeleteQueueRequest)
+func _PlainQService_UnassignRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnassignRoleRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(PlainQServiceServer).DeleteQueue(ctx, in)
+		return srv.(PlainQServiceServer).UnassignRole(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: PlainQService_DeleteQueue_FullMethodName,
+		FullMethod: PlainQService_UnassignRole_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PlainQServiceServer).DeleteQueue(ctx, req.(*DeleteQueueRequest))
+		return srv.(PlainQServiceServer).UnassignRole(ctx, req.(*UnassignRoleRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _PlainQService_Send_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SendRequest)
+func _PlainQService_GetAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccountRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(PlainQServiceServer).Send(ctx, in)
+		return srv.(PlainQServiceServer).GetAccount(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: PlainQService_Send_FullMethodName,
+		FullMethod: PlainQService_GetAccount_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PlainQServiceServer).Send(ctx, req.(*SendRequest))
+		return srv.(PlainQServiceServer).GetAccount(ctx, req.(*GetAccountRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _PlainQService_Receive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ReceiveRequest)
+func _PlainQService_ClusterStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClusterStatusRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(PlainQServiceServer).Receive(ctx, in)
+		return srv.(PlainQServiceServer).ClusterStatus(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: PlainQService_Receive_FullMethodName,
+		FullMethod: PlainQService_ClusterStatus_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PlainQServiceServer).Receive(ctx, req.(*ReceiveRequest))
+		return srv.(PlainQServiceServer).ClusterStatus(ctx, req.(*ClusterStatusRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _PlainQService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DeleteRequest)
+func _PlainQService_RunMaintenance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunMaintenanceRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(PlainQServiceServer).Delete(ctx, in)
+		return srv.(PlainQServiceServer).RunMaintenance(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: PlainQService_Delete_FullMethodName,
+		FullMethod: PlainQService_RunMaintenance_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PlainQServiceServer).Delete(ctx, req.(*DeleteRequest))
+		return srv.(PlainQServiceServer).RunMaintenance(ctx, req.(*RunMaintenanceRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -401,6 +2253,210 @@ var PlainQService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Delete",
 			Handler:    _PlainQService_Delete_Handler,
 		},
+		{
+			MethodName: "ChangeVisibility",
+			Handler:    _PlainQService_ChangeVisibility_Handler,
+		},
+		{
+			MethodName: "UpdateQueue",
+			Handler:    _PlainQService_UpdateQueue_Handler,
+		},
+		{
+			MethodName: "AdviseQueue",
+			Handler:    _PlainQService_AdviseQueue_Handler,
+		},
+		{
+			MethodName: "StartGenerator",
+			Handler:    _PlainQService_StartGenerator_Handler,
+		},
+		{
+			MethodName: "StopGenerator",
+			Handler:    _PlainQService_StopGenerator_Handler,
+		},
+		{
+			MethodName: "ListGenerators",
+			Handler:    _PlainQService_ListGenerators_Handler,
+		},
+		{
+			MethodName: "QueueStats",
+			Handler:    _PlainQService_QueueStats_Handler,
+		},
+		{
+			MethodName: "GetLogLevels",
+			Handler:    _PlainQService_GetLogLevels_Handler,
+		},
+		{
+			MethodName: "SetLogLevels",
+			Handler:    _PlainQService_SetLogLevels_Handler,
+		},
+		{
+			MethodName: "TransferQueue",
+			Handler:    _PlainQService_TransferQueue_Handler,
+		},
+		{
+			MethodName: "AcceptQueueTransfer",
+			Handler:    _PlainQService_AcceptQueueTransfer_Handler,
+		},
+		{
+			MethodName: "CancelQueueTransfer",
+			Handler:    _PlainQService_CancelQueueTransfer_Handler,
+		},
+		{
+			MethodName: "SearchMessages",
+			Handler:    _PlainQService_SearchMessages_Handler,
+		},
+		{
+			MethodName: "PeekMessages",
+			Handler:    _PlainQService_PeekMessages_Handler,
+		},
+		{
+			MethodName: "ReloadConfig",
+			Handler:    _PlainQService_ReloadConfig_Handler,
+		},
+		{
+			MethodName: "ListBreakers",
+			Handler:    _PlainQService_ListBreakers_Handler,
+		},
+		{
+			MethodName: "ResetBreaker",
+			Handler:    _PlainQService_ResetBreaker_Handler,
+		},
+		{
+			MethodName: "SetQueueState",
+			Handler:    _PlainQService_SetQueueState_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _PlainQService_Search_Handler,
+		},
+		{
+			MethodName: "ListAuditEvents",
+			Handler:    _PlainQService_ListAuditEvents_Handler,
+		},
+		{
+			MethodName: "CreateAlertRule",
+			Handler:    _PlainQService_CreateAlertRule_Handler,
+		},
+		{
+			MethodName: "ListAlertRules",
+			Handler:    _PlainQService_ListAlertRules_Handler,
+		},
+		{
+			MethodName: "UpdateAlertRule",
+			Handler:    _PlainQService_UpdateAlertRule_Handler,
+		},
+		{
+			MethodName: "DeleteAlertRule",
+			Handler:    _PlainQService_DeleteAlertRule_Handler,
+		},
+		{
+			MethodName: "ListAlerts",
+			Handler:    _PlainQService_ListAlerts_Handler,
+		},
+		{
+			MethodName: "CreateServiceAccount",
+			Handler:    _PlainQService_CreateServiceAccount_Handler,
+		},
+		{
+			MethodName: "ListServiceAccounts",
+			Handler:    _PlainQService_ListServiceAccounts_Handler,
+		},
+		{
+			MethodName: "DeleteServiceAccount",
+			Handler:    _PlainQService_DeleteServiceAccount_Handler,
+		},
+		{
+			MethodName: "CreateAPIKey",
+			Handler:    _PlainQService_CreateAPIKey_Handler,
+		},
+		{
+			MethodName: "ListAPIKeys",
+			Handler:    _PlainQService_ListAPIKeys_Handler,
+		},
+		{
+			MethodName: "RevokeAPIKey",
+			Handler:    _PlainQService_RevokeAPIKey_Handler,
+		},
+		{
+			MethodName: "GetMessage",
+			Handler:    _PlainQService_GetMessage_Handler,
+		},
+		{
+			MethodName: "Transact",
+			Handler:    _PlainQService_Transact_Handler,
+		},
+		{
+			MethodName: "GetJob",
+			Handler:    _PlainQService_GetJob_Handler,
+		},
+		{
+			MethodName: "ListJobs",
+			Handler:    _PlainQService_ListJobs_Handler,
+		},
+		{
+			MethodName: "CancelJob",
+			Handler:    _PlainQService_CancelJob_Handler,
+		},
+		{
+			MethodName: "ListArchivedMessages",
+			Handler:    _PlainQService_ListArchivedMessages_Handler,
+		},
+		{
+			MethodName: "RestoreArchivedMessages",
+			Handler:    _PlainQService_RestoreArchivedMessages_Handler,
+		},
+		{
+			MethodName: "ListMessageEvents",
+			Handler:    _PlainQService_ListMessageEvents_Handler,
+		},
+		{
+			MethodName: "ExtendVisibilityBatch",
+			Handler:    _PlainQService_ExtendVisibilityBatch_Handler,
+		},
+		{
+			MethodName: "ListQueuePermissions",
+			Handler:    _PlainQService_ListQueuePermissions_Handler,
+		},
+		{
+			MethodName: "SetQueuePermission",
+			Handler:    _PlainQService_SetQueuePermission_Handler,
+		},
+		{
+			MethodName: "DeleteQueuePermission",
+			Handler:    _PlainQService_DeleteQueuePermission_Handler,
+		},
+		{
+			MethodName: "ListRoles",
+			Handler:    _PlainQService_ListRoles_Handler,
+		},
+		{
+			MethodName: "CreateRole",
+			Handler:    _PlainQService_CreateRole_Handler,
+		},
+		{
+			MethodName: "DeleteRole",
+			Handler:    _PlainQService_DeleteRole_Handler,
+		},
+		{
+			MethodName: "AssignRole",
+			Handler:    _PlainQService_AssignRole_Handler,
+		},
+		{
+			MethodName: "UnassignRole",
+			Handler:    _PlainQService_UnassignRole_Handler,
+		},
+		{
+			MethodName: "GetAccount",
+			Handler:    _PlainQService_GetAccount_Handler,
+		},
+		{
+			MethodName: "ClusterStatus",
+			Handler:    _PlainQService_ClusterStatus_Handler,
+		},
+		{
+			MethodName: "RunMaintenance",
+			Handler:    _PlainQService_RunMaintenance_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "v1/schema.proto",
@@ -0,0 +1,491 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/plainq/plainq/internal/server/rbac"
+	"github.com/plainq/servekit/errkit"
+)
+
+const (
+	// contentType is the media type of SCIM requests and responses.
+	contentType = "application/scim+json"
+
+	// maxResults limits the number of resources of the page.
+	maxResults = 1000
+
+	// maxBodySize limits the size of request bodies.
+	maxBodySize = 1 << 20
+)
+
+// handler serves SCIM requests by the store.
+type handler struct {
+	store  Store
+	logger *slog.Logger
+}
+
+// NewHandler returns the handler of the SCIM endpoint, which is mounted by the base URL
+// of the endpoint, e.g. "/api/scim/v2". Requests are expected to be authenticated, only
+// clients with the admin role are supposed to provision users.
+func NewHandler(store Store, logger *slog.Logger) http.Handler {
+	h := handler{store: store, logger: logger}
+
+	r := chi.NewRouter()
+
+	r.Get("/ServiceProviderConfig", h.serviceProviderConfig)
+
+	r.Route("/Users", func(users chi.Router) {
+		users.Get("/", h.listUsers)
+		users.Post("/", h.createUser)
+		users.Get("/{id}", h.getUser)
+		users.Put("/{id}", h.replaceUser)
+		users.Patch("/{id}", h.patchUser)
+		users.Delete("/{id}", h.deleteUser)
+	})
+
+	r.Route("/Groups", func(groups chi.Router) {
+		groups.Get("/", h.listGroups)
+		groups.Post("/", h.createGroup)
+		groups.Get("/{id}", h.getGroup)
+		groups.Put("/{id}", h.replaceGroup)
+		groups.Patch("/{id}", h.patchGroup)
+		groups.Delete("/{id}", h.deleteGroup)
+	})
+
+	return r
+}
+
+func (h *handler) serviceProviderConfig(w http.ResponseWriter, _ *http.Request) {
+	type supported struct {
+		Supported  bool `json:"supported"`
+		MaxResults int  `json:"maxResults,omitempty"`
+	}
+
+	type authenticationScheme struct {
+		Type        string `json:"type"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	h.respond(w, http.StatusOK, struct {
+		Schemas               []string               `json:"schemas"`
+		Patch                 supported              `json:"patch"`
+		Bulk                  supported              `json:"bulk"`
+		Filter                supported              `json:"filter"`
+		ChangePassword        supported              `json:"changePassword"`
+		Sort                  supported              `json:"sort"`
+		ETag                  supported              `json:"etag"`
+		AuthenticationSchemes []authenticationScheme `json:"authenticationSchemes"`
+	}{
+		Schemas: []string{SchemaServiceProviderConfig},
+		Patch:   supported{Supported: true},
+		Filter:  supported{Supported: true, MaxResults: maxResults},
+		AuthenticationSchemes: []authenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "Bearer token",
+			Description: "Access token or API key of the client with the admin role",
+		}},
+	})
+}
+
+func (h *handler) listUsers(w http.ResponseWriter, r *http.Request) {
+	f, filterErr := parseFilter(r.URL.Query().Get("filter"))
+	if filterErr != nil {
+		h.respondError(w, filterErr)
+		return
+	}
+
+	users, listErr := h.store.SCIMUsers(r.Context())
+	if listErr != nil {
+		h.respondError(w, fmt.Errorf("list users: %w", listErr))
+		return
+	}
+
+	resources := make([]any, 0, len(users))
+
+	for i := range users {
+		ok, matchErr := f.matchUser(&users[i])
+		if matchErr != nil {
+			h.respondError(w, matchErr)
+			return
+		}
+
+		if ok {
+			resources = append(resources, newUserResource(&users[i]))
+		}
+	}
+
+	h.respondList(w, r, resources)
+}
+
+func (h *handler) createUser(w http.ResponseWriter, r *http.Request) {
+	var res userResource
+
+	if err := decode(r, &res); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	user := res.user("")
+
+	if user.UserName == "" {
+		h.respondError(w, fmt.Errorf("%w: userName is required", ErrInvalidValue))
+		return
+	}
+
+	created, createErr := h.store.CreateSCIMUser(r.Context(), user)
+	if createErr != nil {
+		h.respondError(w, fmt.Errorf("create user: %w", createErr))
+		return
+	}
+
+	h.respond(w, http.StatusCreated, newUserResource(created))
+}
+
+func (h *handler) getUser(w http.ResponseWriter, r *http.Request) {
+	user, getErr := h.store.SCIMUser(r.Context(), chi.URLParam(r, "id"))
+	if getErr != nil {
+		h.respondError(w, getErr)
+		return
+	}
+
+	h.respond(w, http.StatusOK, newUserResource(user))
+}
+
+func (h *handler) replaceUser(w http.ResponseWriter, r *http.Request) {
+	var res userResource
+
+	if err := decode(r, &res); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	user := res.user(chi.URLParam(r, "id"))
+
+	if user.UserName == "" {
+		h.respondError(w, fmt.Errorf("%w: userName is required", ErrInvalidValue))
+		return
+	}
+
+	h.updateUser(w, r, user)
+}
+
+func (h *handler) patchUser(w http.ResponseWriter, r *http.Request) {
+	var req patchRequest
+
+	if err := decode(r, &req); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	user, getErr := h.store.SCIMUser(r.Context(), chi.URLParam(r, "id"))
+	if getErr != nil {
+		h.respondError(w, getErr)
+		return
+	}
+
+	if err := patchUser(user, req.Operations); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	h.updateUser(w, r, *user)
+}
+
+// updateUser stores the user and responds with it.
+func (h *handler) updateUser(w http.ResponseWriter, r *http.Request, user User) {
+	updated, updateErr := h.store.UpdateSCIMUser(r.Context(), user)
+	if updateErr != nil {
+		h.respondError(w, fmt.Errorf("update user: %w", updateErr))
+		return
+	}
+
+	h.respond(w, http.StatusOK, newUserResource(updated))
+}
+
+func (h *handler) deleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.DeleteSCIMUser(r.Context(), chi.URLParam(r, "id")); err != nil {
+		h.respondError(w, fmt.Errorf("delete user: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) listGroups(w http.ResponseWriter, r *http.Request) {
+	f, filterErr := parseFilter(r.URL.Query().Get("filter"))
+	if filterErr != nil {
+		h.respondError(w, filterErr)
+		return
+	}
+
+	groups, listErr := h.store.SCIMGroups(r.Context())
+	if listErr != nil {
+		h.respondError(w, fmt.Errorf("list groups: %w", listErr))
+		return
+	}
+
+	withMembers := !excluded(r, attrMembers)
+	resources := make([]any, 0, len(groups))
+
+	for i := range groups {
+		ok, matchErr := f.matchGroup(&groups[i])
+		if matchErr != nil {
+			h.respondError(w, matchErr)
+			return
+		}
+
+		if ok {
+			resources = append(resources, newGroupResource(&groups[i], withMembers))
+		}
+	}
+
+	h.respondList(w, r, resources)
+}
+
+func (h *handler) createGroup(w http.ResponseWriter, r *http.Request) {
+	var res groupResource
+
+	if err := decode(r, &res); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	group := res.group("")
+
+	if err := validateGroup(&group); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	created, createErr := h.store.CreateSCIMGroup(r.Context(), group)
+	if createErr != nil {
+		h.respondError(w, fmt.Errorf("create group: %w", createErr))
+		return
+	}
+
+	h.respond(w, http.StatusCreated, newGroupResource(created, true))
+}
+
+func (h *handler) getGroup(w http.ResponseWriter, r *http.Request) {
+	group, getErr := h.store.SCIMGroup(r.Context(), chi.URLParam(r, "id"))
+	if getErr != nil {
+		h.respondError(w, getErr)
+		return
+	}
+
+	h.respond(w, http.StatusOK, newGroupResource(group, !excluded(r, attrMembers)))
+}
+
+func (h *handler) replaceGroup(w http.ResponseWriter, r *http.Request) {
+	var res groupResource
+
+	if err := decode(r, &res); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	current, getErr := h.store.SCIMGroup(r.Context(), chi.URLParam(r, "id"))
+	if getErr != nil {
+		h.respondError(w, getErr)
+		return
+	}
+
+	h.updateGroup(w, r, current.DisplayName, res.group(current.ID))
+}
+
+func (h *handler) patchGroup(w http.ResponseWriter, r *http.Request) {
+	var req patchRequest
+
+	if err := decode(r, &req); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	group, getErr := h.store.SCIMGroup(r.Context(), chi.URLParam(r, "id"))
+	if getErr != nil {
+		h.respondError(w, getErr)
+		return
+	}
+
+	name := group.DisplayName
+
+	if err := patchGroup(group, req.Operations); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	h.updateGroup(w, r, name, *group)
+}
+
+// updateGroup stores the group currently named so and responds with it.
+// The admin role can't be renamed, since its name is what grants any operation.
+func (h *handler) updateGroup(w http.ResponseWriter, r *http.Request, name string, group Group) {
+	if err := validateGroup(&group); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	if name == rbac.AdminRole && group.DisplayName != rbac.AdminRole {
+		h.respondError(w, fmt.Errorf("%w: the %q role can't be renamed", ErrMutability, rbac.AdminRole))
+		return
+	}
+
+	updated, updateErr := h.store.UpdateSCIMGroup(r.Context(), group)
+	if updateErr != nil {
+		h.respondError(w, fmt.Errorf("update group: %w", updateErr))
+		return
+	}
+
+	h.respond(w, http.StatusOK, newGroupResource(updated, true))
+}
+
+func (h *handler) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	group, getErr := h.store.SCIMGroup(r.Context(), id)
+	if getErr != nil {
+		h.respondError(w, getErr)
+		return
+	}
+
+	if group.DisplayName == rbac.AdminRole {
+		h.respondError(w, fmt.Errorf("%w: the %q role can't be deleted", ErrMutability, rbac.AdminRole))
+		return
+	}
+
+	if err := h.store.DeleteSCIMGroup(r.Context(), id); err != nil {
+		h.respondError(w, fmt.Errorf("delete group: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateGroup validates the display name and members of the group.
+func validateGroup(group *Group) error {
+	if group.DisplayName == "" {
+		return fmt.Errorf("%w: displayName is required", ErrInvalidValue)
+	}
+
+	for _, m := range group.Members {
+		if m.ID == "" {
+			return fmt.Errorf("%w: members must have the value", ErrInvalidValue)
+		}
+	}
+
+	return nil
+}
+
+// respondList responds with the page of resources selected by
+// the 1-based startIndex and the count of the request.
+func (h *handler) respondList(w http.ResponseWriter, r *http.Request, resources []any) {
+	start, count := 1, maxResults
+
+	if v := r.URL.Query().Get("startIndex"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			h.respondError(w, fmt.Errorf("%w: startIndex must be an integer", ErrInvalidValue))
+			return
+		}
+
+		start = max(n, 1)
+	}
+
+	if v := r.URL.Query().Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			h.respondError(w, fmt.Errorf("%w: count must be an integer", ErrInvalidValue))
+			return
+		}
+
+		count = min(max(n, 0), maxResults)
+	}
+
+	total := len(resources)
+	from := min(start-1, total)
+	page := resources[from:min(from+count, total)]
+
+	h.respond(w, http.StatusOK, listResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   start,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	})
+}
+
+// respond writes the body with the status.
+func (h *handler) respond(w http.ResponseWriter, status int, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		h.respondError(w, fmt.Errorf("marshal response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+
+	if _, err := w.Write(data); err != nil {
+		h.logger.Error("Failed to write SCIM response", slog.String("error", err.Error()))
+	}
+}
+
+// respondError writes the error response. Details of internal errors are logged, not sent.
+func (h *handler) respondError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+
+	switch {
+	case errors.Is(err, errkit.ErrInvalidArgument):
+		status = http.StatusBadRequest
+
+	case errors.Is(err, errkit.ErrNotFound):
+		status = http.StatusNotFound
+
+	case errors.Is(err, errkit.ErrAlreadyExists):
+		status = http.StatusConflict
+	}
+
+	detail := err.Error()
+
+	if status == http.StatusInternalServerError {
+		h.logger.Error("Failed to serve SCIM request", slog.String("error", detail))
+		detail = http.StatusText(status)
+	}
+
+	h.respond(w, status, errorResponse{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType(err),
+		Detail:   detail,
+	})
+}
+
+// decode decodes the JSON body of the request.
+func decode(r *http.Request, v any) error {
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBodySize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSyntax, err)
+	}
+
+	return nil
+}
+
+// excluded reports whether the attribute is excluded from the response by the request.
+func excluded(r *http.Request, attr string) bool {
+	for _, v := range r.URL.Query()["excludedAttributes"] {
+		for name := range strings.SplitSeq(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(name), attr) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
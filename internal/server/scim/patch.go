@@ -0,0 +1,391 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Operations of PATCH requests.
+const (
+	opAdd     = "add"
+	opReplace = "replace"
+	opRemove  = "remove"
+)
+
+// Attributes which are stored by plainq, lowercased since names of attributes are case-insensitive.
+// Other attributes, e.g. names and phone numbers, are accepted and ignored.
+const (
+	attrID          = "id"
+	attrUserName    = "username"
+	attrExternalID  = "externalid"
+	attrActive      = "active"
+	attrDisplayName = "displayname"
+	attrMembers     = "members"
+	attrValue       = "value"
+)
+
+// filterPattern matches equality filters, the only ones supported, e.g. `userName eq "alice@example.com"`.
+var filterPattern = regexp.MustCompile(`^\s*([A-Za-z][\w.]*)\s+(?i:eq)\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+// memberPathPattern matches paths of particular members, e.g. `members[value eq "id"]`.
+var memberPathPattern = regexp.MustCompile(`^(?i:members)\[(.+)\]$`)
+
+// filter represents the equality filter of resources, the zero filter matches any resource.
+type filter struct {
+	attr  string
+	value string
+}
+
+// parseFilter parses the equality filter. Names of attributes are lowercased.
+func parseFilter(s string) (filter, error) {
+	if s == "" {
+		return filter{}, nil
+	}
+
+	match := filterPattern.FindStringSubmatch(s)
+	if match == nil {
+		return filter{}, fmt.Errorf("%w: only equality filters like 'userName eq \"value\"' are supported", ErrInvalidFilter)
+	}
+
+	value, unquoteErr := strconv.Unquote(`"` + match[2] + `"`)
+	if unquoteErr != nil {
+		return filter{}, fmt.Errorf("%w: value %s: %w", ErrInvalidFilter, match[2], unquoteErr)
+	}
+
+	return filter{attr: strings.ToLower(match[1]), value: value}, nil
+}
+
+// matchUser reports whether the user matches the filter.
+func (f filter) matchUser(user *User) (bool, error) {
+	switch f.attr {
+	case "":
+		return true, nil
+
+	case attrID:
+		return user.ID == f.value, nil
+
+	case attrUserName:
+		return strings.EqualFold(user.UserName, f.value), nil
+
+	case attrExternalID:
+		return user.ExternalID == f.value, nil
+
+	default:
+		return false, fmt.Errorf("%w: unsupported attribute %q", ErrInvalidFilter, f.attr)
+	}
+}
+
+// matchGroup reports whether the group matches the filter.
+func (f filter) matchGroup(group *Group) (bool, error) {
+	switch f.attr {
+	case "":
+		return true, nil
+
+	case attrID:
+		return group.ID == f.value, nil
+
+	case attrDisplayName:
+		return strings.EqualFold(group.DisplayName, f.value), nil
+
+	default:
+		return false, fmt.Errorf("%w: unsupported attribute %q", ErrInvalidFilter, f.attr)
+	}
+}
+
+// patchRequest is the body of PATCH requests.
+type patchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []patchOperation `json:"Operations"`
+}
+
+// patchOperation is the operation of the PATCH request.
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// op returns the lowercased operation, some identity providers capitalize them.
+func (o *patchOperation) op() (string, error) {
+	switch op := strings.ToLower(o.Op); op {
+	case opAdd, opReplace, opRemove:
+		return op, nil
+
+	default:
+		return "", fmt.Errorf("%w: unsupported operation %q", ErrInvalidValue, o.Op)
+	}
+}
+
+// attributes returns attributes of the value of the operation without the path
+// by their lowercased names.
+func (o *patchOperation) attributes() (map[string]json.RawMessage, error) {
+	var raw map[string]json.RawMessage
+
+	if err := json.Unmarshal(o.Value, &raw); err != nil {
+		return nil, fmt.Errorf("%w: value of the operation without the path must be an object", ErrInvalidValue)
+	}
+
+	attrs := make(map[string]json.RawMessage, len(raw))
+	for name, value := range raw {
+		attrs[strings.ToLower(name)] = value
+	}
+
+	return attrs, nil
+}
+
+// patchUser applies operations to the user.
+func patchUser(user *User, ops []patchOperation) error {
+	for _, o := range ops {
+		op, opErr := o.op()
+		if opErr != nil {
+			return opErr
+		}
+
+		if o.Path == "" {
+			if op == opRemove {
+				return fmt.Errorf("%w: remove requires the path", ErrInvalidPath)
+			}
+
+			attrs, attrsErr := o.attributes()
+			if attrsErr != nil {
+				return attrsErr
+			}
+
+			for name, value := range attrs {
+				if err := setUserAttribute(user, name, value); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		path := strings.ToLower(o.Path)
+
+		if op != opRemove {
+			if err := setUserAttribute(user, path, o.Value); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		switch path {
+		case attrExternalID:
+			user.ExternalID = ""
+
+		case attrUserName, attrActive:
+			return fmt.Errorf("%w: %s is required", ErrMutability, o.Path)
+		}
+	}
+
+	return nil
+}
+
+// setUserAttribute sets the attribute of the user with the name to the value.
+func setUserAttribute(user *User, name string, value json.RawMessage) error {
+	switch name {
+	case attrUserName:
+		userName, err := decodeString(name, value)
+		if err != nil {
+			return err
+		}
+
+		if userName == "" {
+			return fmt.Errorf("%w: userName is required", ErrInvalidValue)
+		}
+
+		user.UserName = userName
+
+	case attrExternalID:
+		externalID, err := decodeString(name, value)
+		if err != nil {
+			return err
+		}
+
+		user.ExternalID = externalID
+
+	case attrActive:
+		active, err := decodeBool(name, value)
+		if err != nil {
+			return err
+		}
+
+		user.Active = active
+	}
+
+	return nil
+}
+
+// patchGroup applies operations to the group.
+func patchGroup(group *Group, ops []patchOperation) error {
+	for _, o := range ops {
+		op, opErr := o.op()
+		if opErr != nil {
+			return opErr
+		}
+
+		if o.Path == "" {
+			if op == opRemove {
+				return fmt.Errorf("%w: remove requires the path", ErrInvalidPath)
+			}
+
+			attrs, attrsErr := o.attributes()
+			if attrsErr != nil {
+				return attrsErr
+			}
+
+			for name, value := range attrs {
+				if err := setGroupAttribute(group, op, name, value); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if match := memberPathPattern.FindStringSubmatch(o.Path); match != nil {
+			if op != opRemove {
+				return fmt.Errorf("%w: %s of the filtered member", ErrInvalidPath, op)
+			}
+
+			f, filterErr := parseFilter(match[1])
+			if filterErr != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidPath, filterErr)
+			}
+
+			if f.attr != attrValue {
+				return fmt.Errorf("%w: members are filtered by value", ErrInvalidPath)
+			}
+
+			group.Members = removeMembers(group.Members, []Member{{ID: f.value}})
+
+			continue
+		}
+
+		path := strings.ToLower(o.Path)
+
+		if op != opRemove {
+			if err := setGroupAttribute(group, op, path, o.Value); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		switch path {
+		case attrMembers:
+			if len(o.Value) == 0 || string(o.Value) == "null" {
+				group.Members = nil
+				continue
+			}
+
+			members, err := decodeMembers(o.Value)
+			if err != nil {
+				return err
+			}
+
+			group.Members = removeMembers(group.Members, members)
+
+		case attrDisplayName:
+			return fmt.Errorf("%w: displayName is required", ErrMutability)
+		}
+	}
+
+	return nil
+}
+
+// setGroupAttribute adds or replaces the attribute of the group with the name.
+// Added members are appended to current ones, replaced ones replace them.
+func setGroupAttribute(group *Group, op, name string, value json.RawMessage) error {
+	switch name {
+	case attrDisplayName:
+		displayName, err := decodeString(name, value)
+		if err != nil {
+			return err
+		}
+
+		if displayName == "" {
+			return fmt.Errorf("%w: displayName is required", ErrInvalidValue)
+		}
+
+		group.DisplayName = displayName
+
+	case attrMembers:
+		members, err := decodeMembers(value)
+		if err != nil {
+			return err
+		}
+
+		if op == opReplace {
+			group.Members = nil
+		}
+
+		for _, m := range members {
+			if !slices.ContainsFunc(group.Members, func(g Member) bool { return g.ID == m.ID }) {
+				group.Members = append(group.Members, m)
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeMembers returns members without the removed ones.
+func removeMembers(members, removed []Member) []Member {
+	return slices.DeleteFunc(members, func(m Member) bool {
+		return slices.ContainsFunc(removed, func(r Member) bool { return r.ID == m.ID })
+	})
+}
+
+// decodeString decodes the string value of the attribute.
+func decodeString(name string, value json.RawMessage) (string, error) {
+	var s string
+
+	if err := json.Unmarshal(value, &s); err != nil {
+		return "", fmt.Errorf("%w: %s must be a string", ErrInvalidValue, name)
+	}
+
+	return s, nil
+}
+
+// decodeBool decodes the boolean value of the attribute. Booleans in strings,
+// e.g. "False", are accepted, some identity providers send them so.
+func decodeBool(name string, value json.RawMessage) (bool, error) {
+	var v any
+
+	if err := json.Unmarshal(value, &v); err == nil {
+		switch b := v.(type) {
+		case bool:
+			return b, nil
+
+		case string:
+			if parsed, parseErr := strconv.ParseBool(strings.ToLower(b)); parseErr == nil {
+				return parsed, nil
+			}
+		}
+	}
+
+	return false, fmt.Errorf("%w: %s must be a boolean", ErrInvalidValue, name)
+}
+
+// decodeMembers decodes references to members, members without the value are rejected.
+func decodeMembers(value json.RawMessage) ([]Member, error) {
+	var refs []reference
+
+	if err := json.Unmarshal(value, &refs); err != nil {
+		return nil, fmt.Errorf("%w: members must be an array of references", ErrInvalidValue)
+	}
+
+	for _, ref := range refs {
+		if ref.Value == "" {
+			return nil, fmt.Errorf("%w: members must have the value", ErrInvalidValue)
+		}
+	}
+
+	return members(refs), nil
+}
@@ -0,0 +1,175 @@
+package scim
+
+import (
+	"strings"
+	"time"
+)
+
+// Resource types of meta attributes.
+const (
+	resourceUser  = "User"
+	resourceGroup = "Group"
+)
+
+// userResource is the JSON representation of the User.
+type userResource struct {
+	Schemas    []string    `json:"schemas"`
+	ID         string      `json:"id,omitempty"`
+	ExternalID string      `json:"externalId,omitempty"`
+	UserName   string      `json:"userName"`
+	Active     *bool       `json:"active,omitempty"`
+	Emails     []email     `json:"emails,omitempty"`
+	Groups     []reference `json:"groups,omitempty"`
+	Meta       *meta       `json:"meta,omitempty"`
+}
+
+// groupResource is the JSON representation of the Group.
+type groupResource struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	DisplayName string      `json:"displayName"`
+	Members     []reference `json:"members,omitempty"`
+	Meta        *meta       `json:"meta,omitempty"`
+}
+
+// email is the email address of the user.
+type email struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// reference is the JSON representation of the Member.
+type reference struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+// meta holds attributes of resources which are managed by the service provider.
+type meta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// listResponse is the page of resources which match the query.
+type listResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+// errorResponse is the body of responses to rejected requests.
+type errorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// newUserResource returns the JSON representation of the user. User names
+// which look like email addresses are reported as the primary email.
+func newUserResource(user *User) userResource {
+	active := user.Active
+
+	res := userResource{
+		Schemas:    []string{SchemaUser},
+		ID:         user.ID,
+		ExternalID: user.ExternalID,
+		UserName:   user.UserName,
+		Active:     &active,
+		Groups:     newReferences(user.Groups),
+		Meta: &meta{
+			ResourceType: resourceUser,
+			Created:      formatTime(user.CreatedAt),
+			LastModified: formatTime(user.UpdatedAt),
+		},
+	}
+
+	if strings.Contains(user.UserName, "@") {
+		res.Emails = []email{{Value: user.UserName, Primary: true}}
+	}
+
+	return res
+}
+
+// user returns the User of the resource with the id.
+// Users are active unless the resource tells otherwise.
+func (res *userResource) user(id string) User {
+	user := User{
+		ID:         id,
+		UserName:   res.UserName,
+		ExternalID: res.ExternalID,
+		Active:     true,
+	}
+
+	if res.Active != nil {
+		user.Active = *res.Active
+	}
+
+	return user
+}
+
+// newGroupResource returns the JSON representation of the group,
+// members are omitted when they are excluded by the request.
+func newGroupResource(group *Group, withMembers bool) groupResource {
+	res := groupResource{
+		Schemas:     []string{SchemaGroup},
+		ID:          group.ID,
+		DisplayName: group.DisplayName,
+		Meta: &meta{
+			ResourceType: resourceGroup,
+			Created:      formatTime(group.CreatedAt),
+			LastModified: formatTime(group.CreatedAt),
+		},
+	}
+
+	if withMembers {
+		res.Members = newReferences(group.Members)
+	}
+
+	return res
+}
+
+// group returns the Group of the resource with the id.
+func (res *groupResource) group(id string) Group {
+	return Group{
+		ID:          id,
+		DisplayName: res.DisplayName,
+		Members:     members(res.Members),
+	}
+}
+
+// newReferences returns JSON representations of members.
+func newReferences(members []Member) []reference {
+	if len(members) == 0 {
+		return nil
+	}
+
+	refs := make([]reference, 0, len(members))
+	for _, m := range members {
+		refs = append(refs, reference{Value: m.ID, Display: m.Display})
+	}
+
+	return refs
+}
+
+// members returns members of references.
+func members(refs []reference) []Member {
+	out := make([]Member, 0, len(refs))
+	for _, ref := range refs {
+		out = append(out, Member{ID: ref.Value, Display: ref.Display})
+	}
+
+	return out
+}
+
+// formatTime formats the time as the SCIM DateTime, zero times are omitted.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,136 @@
+// Package scim implements the SCIM 2.0 (RFC 7643, RFC 7644) provisioning endpoint,
+// which lets identity providers create, update, deactivate and delete users,
+// and manage groups, which are roles of plainq, along with their members.
+package scim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/plainq/servekit/errkit"
+)
+
+// URNs of schemas of resources and messages.
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// Errors which are reported by the scimType of error responses.
+var (
+	ErrInvalidSyntax = fmt.Errorf("%w: invalid syntax", errkit.ErrInvalidArgument)
+	ErrInvalidFilter = fmt.Errorf("%w: invalid filter", errkit.ErrInvalidArgument)
+	ErrInvalidPath   = fmt.Errorf("%w: invalid path", errkit.ErrInvalidArgument)
+	ErrInvalidValue  = fmt.Errorf("%w: invalid value", errkit.ErrInvalidArgument)
+	ErrMutability    = fmt.Errorf("%w: attribute can't be modified", errkit.ErrInvalidArgument)
+)
+
+// User represents the provisioned user of plainq,
+// whose user name is the email, the subject of its tokens.
+type User struct {
+	ID         string
+	UserName   string
+	ExternalID string
+
+	// Active is false for users deactivated by the identity provider,
+	// which can't sign in and are not granted any role.
+	Active bool
+
+	// Groups are roles of the user.
+	Groups []Member
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Group represents the role of plainq, whose members are users granted the role.
+type Group struct {
+	ID          string
+	DisplayName string
+	Members     []Member
+	CreatedAt   time.Time
+}
+
+// Member references the user, the member of the group,
+// or the group, which the user is the member of.
+type Member struct {
+	// ID is the identifier of the user or the group.
+	ID string
+
+	// Display is the user name of the user or the display name of the group.
+	Display string
+}
+
+// Store stores users and groups.
+type Store interface {
+	// SCIMUsers returns all users ordered by the time they were created.
+	SCIMUsers(ctx context.Context) ([]User, error)
+
+	// SCIMUser returns the user. Unknown users are rejected with errkit.ErrNotFound.
+	SCIMUser(ctx context.Context, id string) (*User, error)
+
+	// CreateSCIMUser creates the user without the password. Taken
+	// user names are rejected with errkit.ErrAlreadyExists.
+	CreateSCIMUser(ctx context.Context, user User) (*User, error)
+
+	// UpdateSCIMUser replaces the user name, the external id and the state of the user.
+	// Unknown users are rejected with errkit.ErrNotFound, user names taken by
+	// other users are rejected with errkit.ErrAlreadyExists.
+	UpdateSCIMUser(ctx context.Context, user User) (*User, error)
+
+	// DeleteSCIMUser deletes the user along with its roles.
+	// Unknown users are rejected with errkit.ErrNotFound.
+	DeleteSCIMUser(ctx context.Context, id string) error
+
+	// SCIMGroups returns all groups ordered by the display name.
+	SCIMGroups(ctx context.Context) ([]Group, error)
+
+	// SCIMGroup returns the group. Unknown groups are rejected with errkit.ErrNotFound.
+	SCIMGroup(ctx context.Context, id string) (*Group, error)
+
+	// CreateSCIMGroup creates the role along with its members. Taken names are rejected
+	// with errkit.ErrAlreadyExists, unknown members with errkit.ErrInvalidArgument.
+	CreateSCIMGroup(ctx context.Context, group Group) (*Group, error)
+
+	// UpdateSCIMGroup replaces the name and members of the role. Unknown groups are
+	// rejected with errkit.ErrNotFound, names taken by other roles are rejected
+	// with errkit.ErrAlreadyExists, unknown members with errkit.ErrInvalidArgument.
+	UpdateSCIMGroup(ctx context.Context, group Group) (*Group, error)
+
+	// DeleteSCIMGroup deletes the role along with its permissions.
+	// Unknown groups are rejected with errkit.ErrNotFound.
+	DeleteSCIMGroup(ctx context.Context, id string) error
+}
+
+// scimType returns the SCIM error type of the err, which tells
+// the identity provider why the request has been rejected.
+func scimType(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidSyntax):
+		return "invalidSyntax"
+
+	case errors.Is(err, ErrInvalidFilter):
+		return "invalidFilter"
+
+	case errors.Is(err, ErrInvalidPath):
+		return "invalidPath"
+
+	case errors.Is(err, ErrMutability):
+		return "mutability"
+
+	case errors.Is(err, errkit.ErrAlreadyExists):
+		return "uniqueness"
+
+	case errors.Is(err, errkit.ErrInvalidArgument):
+		return "invalidValue"
+
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,512 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/logkit"
+)
+
+// fakeStore stores users and groups in memory. Members of groups are stored
+// by their ids, users are looked up for their names when groups are returned.
+type fakeStore struct {
+	users  []User
+	groups []Group
+}
+
+func (f *fakeStore) SCIMUsers(context.Context) ([]User, error) {
+	return slices.Clone(f.users), nil
+}
+
+func (f *fakeStore) SCIMUser(_ context.Context, id string) (*User, error) {
+	i := slices.IndexFunc(f.users, func(u User) bool { return u.ID == id })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: user %q", errkit.ErrNotFound, id)
+	}
+
+	user := f.users[i]
+
+	return &user, nil
+}
+
+func (f *fakeStore) CreateSCIMUser(_ context.Context, user User) (*User, error) {
+	if slices.ContainsFunc(f.users, func(u User) bool { return u.UserName == user.UserName }) {
+		return nil, fmt.Errorf("%w: user %q", errkit.ErrAlreadyExists, user.UserName)
+	}
+
+	user.ID = fmt.Sprintf("u%d", len(f.users)+1)
+	f.users = append(f.users, user)
+
+	return &user, nil
+}
+
+func (f *fakeStore) UpdateSCIMUser(ctx context.Context, user User) (*User, error) {
+	i := slices.IndexFunc(f.users, func(u User) bool { return u.ID == user.ID })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: user %q", errkit.ErrNotFound, user.ID)
+	}
+
+	f.users[i].UserName = user.UserName
+	f.users[i].ExternalID = user.ExternalID
+	f.users[i].Active = user.Active
+
+	return f.SCIMUser(ctx, user.ID)
+}
+
+func (f *fakeStore) DeleteSCIMUser(_ context.Context, id string) error {
+	n := len(f.users)
+
+	if f.users = slices.DeleteFunc(f.users, func(u User) bool { return u.ID == id }); len(f.users) == n {
+		return fmt.Errorf("%w: user %q", errkit.ErrNotFound, id)
+	}
+
+	return nil
+}
+
+func (f *fakeStore) SCIMGroups(context.Context) ([]Group, error) {
+	return slices.Clone(f.groups), nil
+}
+
+func (f *fakeStore) SCIMGroup(_ context.Context, id string) (*Group, error) {
+	i := slices.IndexFunc(f.groups, func(g Group) bool { return g.ID == id })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: role %q", errkit.ErrNotFound, id)
+	}
+
+	group := f.groups[i]
+	group.Members = slices.Clone(group.Members)
+
+	return &group, nil
+}
+
+func (f *fakeStore) CreateSCIMGroup(ctx context.Context, group Group) (*Group, error) {
+	if slices.ContainsFunc(f.groups, func(g Group) bool { return g.DisplayName == group.DisplayName }) {
+		return nil, fmt.Errorf("%w: role %q", errkit.ErrAlreadyExists, group.DisplayName)
+	}
+
+	group.ID = fmt.Sprintf("g%d", len(f.groups)+1)
+	f.groups = append(f.groups, Group{ID: group.ID})
+
+	return f.UpdateSCIMGroup(ctx, group)
+}
+
+func (f *fakeStore) UpdateSCIMGroup(ctx context.Context, group Group) (*Group, error) {
+	i := slices.IndexFunc(f.groups, func(g Group) bool { return g.ID == group.ID })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: role %q", errkit.ErrNotFound, group.ID)
+	}
+
+	members := make([]Member, 0, len(group.Members))
+
+	for _, m := range group.Members {
+		user, err := f.SCIMUser(ctx, m.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown user %q", errkit.ErrInvalidArgument, m.ID)
+		}
+
+		members = append(members, Member{ID: user.ID, Display: user.UserName})
+	}
+
+	f.groups[i].DisplayName = group.DisplayName
+	f.groups[i].Members = members
+
+	return f.SCIMGroup(ctx, group.ID)
+}
+
+func (f *fakeStore) DeleteSCIMGroup(_ context.Context, id string) error {
+	n := len(f.groups)
+
+	if f.groups = slices.DeleteFunc(f.groups, func(g Group) bool { return g.ID == id }); len(f.groups) == n {
+		return fmt.Errorf("%w: role %q", errkit.ErrNotFound, id)
+	}
+
+	return nil
+}
+
+func TestParseFilter(t *testing.T) {
+	type tcase struct {
+		filter  string
+		want    filter
+		wantErr error
+	}
+
+	tests := map[string]tcase{
+		"Empty":         {filter: "", want: filter{}},
+		"UserName":      {filter: `userName eq "alice@example.com"`, want: filter{attr: attrUserName, value: "alice@example.com"}},
+		"CaseOfEq":      {filter: `displayName EQ "ops"`, want: filter{attr: attrDisplayName, value: "ops"}},
+		"EscapedQuote":  {filter: `externalId eq "a\"b"`, want: filter{attr: attrExternalID, value: `a"b`}},
+		"Unsupported":   {filter: `userName co "alice"`, wantErr: ErrInvalidFilter},
+		"Conjunction":   {filter: `userName eq "a" and active eq true`, wantErr: ErrInvalidFilter},
+		"UnquotedValue": {filter: `active eq true`, wantErr: ErrInvalidFilter},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseFilter(tc.filter)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
+
+func TestPatchUser(t *testing.T) {
+	type tcase struct {
+		ops     string
+		want    User
+		wantErr error
+	}
+
+	user := User{ID: "u1", UserName: "alice@example.com", ExternalID: "ext-1", Active: true}
+
+	tests := map[string]tcase{
+		"DeactivateByPath": {
+			ops:  `[{"op": "replace", "path": "active", "value": false}]`,
+			want: User{ID: "u1", UserName: "alice@example.com", ExternalID: "ext-1"},
+		},
+		"DeactivateByValue": {
+			ops:  `[{"op": "Replace", "value": {"active": "False"}}]`,
+			want: User{ID: "u1", UserName: "alice@example.com", ExternalID: "ext-1"},
+		},
+		"Rename": {
+			ops:  `[{"op": "replace", "path": "userName", "value": "alice@example.org"}]`,
+			want: User{ID: "u1", UserName: "alice@example.org", ExternalID: "ext-1", Active: true},
+		},
+		"RemoveExternalID": {
+			ops:  `[{"op": "remove", "path": "externalId"}]`,
+			want: User{ID: "u1", UserName: "alice@example.com", Active: true},
+		},
+		"IgnoredAttributes": {
+			ops:  `[{"op": "add", "path": "name.givenName", "value": "Alice"}, {"op": "replace", "value": {"displayName": "Alice"}}]`,
+			want: user,
+		},
+		"RemoveUserName":    {ops: `[{"op": "remove", "path": "userName"}]`, wantErr: ErrMutability},
+		"RemoveWithoutPath": {ops: `[{"op": "remove"}]`, wantErr: ErrInvalidPath},
+		"EmptyUserName":     {ops: `[{"op": "replace", "path": "userName", "value": ""}]`, wantErr: ErrInvalidValue},
+		"InvalidActive":     {ops: `[{"op": "replace", "path": "active", "value": "maybe"}]`, wantErr: ErrInvalidValue},
+		"UnknownOperation":  {ops: `[{"op": "move", "path": "active", "value": false}]`, wantErr: ErrInvalidValue},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var ops []patchOperation
+
+			td.Require(t).CmpNoError(json.Unmarshal([]byte(tc.ops), &ops))
+
+			got := user
+
+			err := patchUser(&got, ops)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
+
+func TestPatchGroup(t *testing.T) {
+	type tcase struct {
+		ops         string
+		wantName    string
+		wantMembers []string
+		wantErr     error
+	}
+
+	tests := map[string]tcase{
+		"AddMembers": {
+			ops:         `[{"op": "add", "path": "members", "value": [{"value": "u2"}, {"value": "u3"}]}]`,
+			wantName:    "ops",
+			wantMembers: []string{"u1", "u2", "u3"},
+		},
+		"RemoveFilteredMember": {
+			ops:         `[{"op": "remove", "path": "members[value eq \"u1\"]"}]`,
+			wantName:    "ops",
+			wantMembers: []string{"u2"},
+		},
+		"RemoveListedMembers": {
+			ops:         `[{"op": "remove", "path": "members", "value": [{"value": "u2"}]}]`,
+			wantName:    "ops",
+			wantMembers: []string{"u1"},
+		},
+		"RemoveAllMembers": {
+			ops:         `[{"op": "remove", "path": "members"}]`,
+			wantName:    "ops",
+			wantMembers: []string{},
+		},
+		"ReplaceByValue": {
+			ops:         `[{"op": "replace", "value": {"id": "g1", "displayName": "sre", "members": [{"value": "u3"}]}}]`,
+			wantName:    "sre",
+			wantMembers: []string{"u3"},
+		},
+		"AddFilteredMember":     {ops: `[{"op": "add", "path": "members[value eq \"u3\"]"}]`, wantErr: ErrInvalidPath},
+		"FilteredByDisplay":     {ops: `[{"op": "remove", "path": "members[display eq \"bob\"]"}]`, wantErr: ErrInvalidPath},
+		"RemoveDisplayName":     {ops: `[{"op": "remove", "path": "displayName"}]`, wantErr: ErrMutability},
+		"MemberWithoutValue":    {ops: `[{"op": "add", "path": "members", "value": [{"display": "bob"}]}]`, wantErr: ErrInvalidValue},
+		"MembersAreNotAnObject": {ops: `[{"op": "add", "path": "members", "value": {"value": "u3"}}]`, wantErr: ErrInvalidValue},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var ops []patchOperation
+
+			td.Require(t).CmpNoError(json.Unmarshal([]byte(tc.ops), &ops))
+
+			group := Group{ID: "g1", DisplayName: "ops", Members: []Member{{ID: "u1"}, {ID: "u2"}}}
+
+			err := patchGroup(&group, ops)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, group.DisplayName, tc.wantName)
+
+			ids := make([]string, 0, len(group.Members))
+			for _, m := range group.Members {
+				ids = append(ids, m.ID)
+			}
+
+			td.Cmp(t, ids, tc.wantMembers)
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	type tcase struct {
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   any
+		wantStore  func(t *testing.T, store *fakeStore)
+	}
+
+	tests := map[string]tcase{
+		"ListUsersByUserName": {
+			method:     http.MethodGet,
+			target:     `/Users?filter=` + url.QueryEscape(`userName eq "BOB@example.com"`),
+			wantStatus: http.StatusOK,
+			wantBody: td.SuperMapOf(map[string]any{
+				"schemas":      []any{SchemaListResponse},
+				"totalResults": 1.0,
+				"itemsPerPage": 1.0,
+				"Resources": []any{td.SuperMapOf(map[string]any{
+					"id":       "u2",
+					"userName": "bob@example.com",
+					"active":   true,
+					"emails":   []any{map[string]any{"value": "bob@example.com", "primary": true}},
+				}, nil)},
+			}, nil),
+		},
+		"ListUsersPage": {
+			method:     http.MethodGet,
+			target:     "/Users?startIndex=2&count=5",
+			wantStatus: http.StatusOK,
+			wantBody: td.SuperMapOf(map[string]any{
+				"totalResults": 2.0,
+				"startIndex":   2.0,
+				"itemsPerPage": 1.0,
+				"Resources":    td.Len(1),
+			}, nil),
+		},
+		"ListUsersUnsupportedFilter": {
+			method:     http.MethodGet,
+			target:     `/Users?filter=` + url.QueryEscape(`emails eq "bob@example.com"`),
+			wantStatus: http.StatusBadRequest,
+			wantBody:   td.SuperMapOf(map[string]any{"status": "400", "scimType": "invalidFilter"}, nil),
+		},
+		"CreateUser": {
+			method:     http.MethodPost,
+			target:     "/Users",
+			body:       `{"schemas": ["` + SchemaUser + `"], "userName": "carol@example.com", "externalId": "ext-3", "name": {"givenName": "Carol"}}`,
+			wantStatus: http.StatusCreated,
+			wantBody: td.SuperMapOf(map[string]any{
+				"id":         "u3",
+				"userName":   "carol@example.com",
+				"externalId": "ext-3",
+				"active":     true,
+			}, nil),
+		},
+		"CreateTakenUser": {
+			method:     http.MethodPost,
+			target:     "/Users",
+			body:       `{"userName": "alice@example.com"}`,
+			wantStatus: http.StatusConflict,
+			wantBody:   td.SuperMapOf(map[string]any{"status": "409", "scimType": "uniqueness"}, nil),
+		},
+		"CreateUserWithoutUserName": {
+			method:     http.MethodPost,
+			target:     "/Users",
+			body:       `{"externalId": "ext-3"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		"CreateUserInvalidJSON": {
+			method:     http.MethodPost,
+			target:     "/Users",
+			body:       `{"userName":`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   td.SuperMapOf(map[string]any{"scimType": "invalidSyntax"}, nil),
+		},
+		"DeactivateUser": {
+			method:     http.MethodPatch,
+			target:     "/Users/u1",
+			body:       `{"schemas": ["` + SchemaPatchOp + `"], "Operations": [{"op": "replace", "path": "active", "value": false}]}`,
+			wantStatus: http.StatusOK,
+			wantBody:   td.SuperMapOf(map[string]any{"id": "u1", "active": false}, nil),
+			wantStore: func(t *testing.T, store *fakeStore) {
+				td.CmpFalse(t, store.users[0].Active)
+			},
+		},
+		"ReplaceUser": {
+			method:     http.MethodPut,
+			target:     "/Users/u1",
+			body:       `{"userName": "alice@example.org", "active": false}`,
+			wantStatus: http.StatusOK,
+			wantBody:   td.SuperMapOf(map[string]any{"userName": "alice@example.org", "active": false}, nil),
+		},
+		"GetUnknownUser": {
+			method:     http.MethodGet,
+			target:     "/Users/u9",
+			wantStatus: http.StatusNotFound,
+			wantBody:   td.SuperMapOf(map[string]any{"schemas": []any{SchemaError}, "status": "404"}, nil),
+		},
+		"DeleteUser": {
+			method:     http.MethodDelete,
+			target:     "/Users/u2",
+			wantStatus: http.StatusNoContent,
+			wantStore: func(t *testing.T, store *fakeStore) {
+				td.Cmp(t, store.users, td.Len(1))
+			},
+		},
+		"ListGroupsWithoutMembers": {
+			method:     http.MethodGet,
+			target:     `/Groups?excludedAttributes=members&filter=` + url.QueryEscape(`displayName eq "producer"`),
+			wantStatus: http.StatusOK,
+			wantBody: td.SuperMapOf(map[string]any{
+				"totalResults": 1.0,
+				"Resources": []any{map[string]any{
+					"schemas":     []any{SchemaGroup},
+					"id":          "g2",
+					"displayName": "producer",
+					"meta":        map[string]any{"resourceType": resourceGroup},
+				}},
+			}, nil),
+		},
+		"CreateGroup": {
+			method:     http.MethodPost,
+			target:     "/Groups",
+			body:       `{"displayName": "sre", "members": [{"value": "u1"}, {"value": "u2"}]}`,
+			wantStatus: http.StatusCreated,
+			wantBody: td.SuperMapOf(map[string]any{
+				"id":          "g3",
+				"displayName": "sre",
+				"members": []any{
+					map[string]any{"value": "u1", "display": "alice@example.com"},
+					map[string]any{"value": "u2", "display": "bob@example.com"},
+				},
+			}, nil),
+		},
+		"CreateGroupUnknownMember": {
+			method:     http.MethodPost,
+			target:     "/Groups",
+			body:       `{"displayName": "sre", "members": [{"value": "u9"}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		"AddGroupMember": {
+			method:     http.MethodPatch,
+			target:     "/Groups/g2",
+			body:       `{"Operations": [{"op": "add", "path": "members", "value": [{"value": "u2"}]}]}`,
+			wantStatus: http.StatusOK,
+			wantStore: func(t *testing.T, store *fakeStore) {
+				td.Cmp(t, store.groups[1].Members, []Member{
+					{ID: "u1", Display: "alice@example.com"},
+					{ID: "u2", Display: "bob@example.com"},
+				})
+			},
+		},
+		"RenameAdmin": {
+			method:     http.MethodPatch,
+			target:     "/Groups/g1",
+			body:       `{"Operations": [{"op": "replace", "path": "displayName", "value": "root"}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   td.SuperMapOf(map[string]any{"scimType": "mutability"}, nil),
+		},
+		"DeleteAdmin": {
+			method:     http.MethodDelete,
+			target:     "/Groups/g1",
+			wantStatus: http.StatusBadRequest,
+			wantStore: func(t *testing.T, store *fakeStore) {
+				td.Cmp(t, store.groups, td.Len(2))
+			},
+		},
+		"DeleteGroup": {
+			method:     http.MethodDelete,
+			target:     "/Groups/g2",
+			wantStatus: http.StatusNoContent,
+			wantStore: func(t *testing.T, store *fakeStore) {
+				td.Cmp(t, store.groups, td.Len(1))
+			},
+		},
+		"ServiceProviderConfig": {
+			method:     http.MethodGet,
+			target:     "/ServiceProviderConfig",
+			wantStatus: http.StatusOK,
+			wantBody: td.SuperMapOf(map[string]any{
+				"patch": map[string]any{"supported": true},
+				"bulk":  map[string]any{"supported": false},
+			}, nil),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := fakeStore{
+				users: []User{
+					{ID: "u1", UserName: "alice@example.com", Active: true},
+					{ID: "u2", UserName: "bob@example.com", Active: true},
+				},
+				groups: []Group{
+					{ID: "g1", DisplayName: "admin"},
+					{ID: "g2", DisplayName: "producer", Members: []Member{{ID: "u1", Display: "alice@example.com"}}},
+				},
+			}
+
+			h := NewHandler(&store, logkit.NewNop())
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+
+			td.Cmp(t, rec.Code, tc.wantStatus)
+
+			if tc.wantBody != nil {
+				td.Cmp(t, rec.Header().Get("Content-Type"), contentType)
+
+				var body any
+
+				td.Require(t).CmpNoError(json.Unmarshal(rec.Body.Bytes(), &body))
+				td.Cmp(t, body, tc.wantBody)
+			}
+
+			if tc.wantStore != nil {
+				tc.wantStore(t, &store)
+			}
+		})
+	}
+}
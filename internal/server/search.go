@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/rbac"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+const (
+	// searchTimeout limits the time of a single search, so an expensive
+	// query can't hold the database for too long.
+	searchTimeout = 5 * time.Second
+
+	// entitySearchTimeout limits the time of the entity search, which
+	// runs on each keystroke in the command palette.
+	entitySearchTimeout = time.Second
+
+	// defaultEntitySearchLimit represents the default number of results of the entity search.
+	defaultEntitySearchLimit = 10
+
+	// maxEntitySearchLimit represents the maximum number of results of the entity search.
+	maxEntitySearchLimit = 50
+)
+
+// searchMessages searches messages of the queue by their content. Matched messages
+// are returned with their bodies, so the search requires the receive permission
+// in addition to the describe one, as if they were received.
+func (s *PlainQ) searchMessages(ctx context.Context, input *v1.SearchMessagesRequest) (*v1.SearchMessagesResponse, error) {
+	if err := validateQueueIDFromRequest(input); err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeQueue(ctx, input.GetQueueId(), rbac.OpReceive); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+
+	output, searchErr := s.storage.SearchMessages(ctx, input)
+	if searchErr != nil {
+		return nil, fmt.Errorf("search messages (queue id: %q): %w", input.GetQueueId(), searchErr)
+	}
+
+	return output, nil
+}
+
+// search matches entities against the query, which powers the command palette
+// of Houston and the find command. Queues are matched for any authenticated
+// client, like they're listed, while users, roles and audit events are matched
+// for administrators only, like their listings require.
+func (s *PlainQ) search(ctx context.Context, input *v1.SearchRequest) (*v1.SearchResponse, error) {
+	query := strings.TrimSpace(input.GetQuery())
+	if query == "" {
+		return nil, fmt.Errorf("%w: search query is empty", errkit.ErrInvalidArgument)
+	}
+
+	limit := input.GetLimit()
+
+	switch {
+	case limit == 0:
+		limit = defaultEntitySearchLimit
+
+	case limit > maxEntitySearchLimit:
+		return nil, fmt.Errorf("%w: search limit should not exceed %d", errkit.ErrInvalidArgument, maxEntitySearchLimit)
+	}
+
+	for _, k := range input.GetKinds() {
+		if _, ok := v1.EntityKind_name[int32(k)]; !ok || k == v1.EntityKind_ENTITY_KIND_UNSPECIFIED {
+			return nil, fmt.Errorf("%w: unknown entity kind %d", errkit.ErrInvalidArgument, k)
+		}
+	}
+
+	kinds, kindsErr := s.searchKinds(ctx, input.GetKinds())
+	if kindsErr != nil {
+		return nil, kindsErr
+	}
+
+	if len(kinds) == 0 {
+		return &v1.SearchResponse{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, entitySearchTimeout)
+	defer cancel()
+
+	output, searchErr := s.storage.SearchEntities(ctx, &v1.SearchRequest{Query: query, Kinds: kinds, Limit: limit})
+	if searchErr != nil {
+		return nil, fmt.Errorf("search entities: %w", searchErr)
+	}
+
+	return output, nil
+}
+
+// searchKinds returns kinds of entities among the requested ones, or among all
+// of them when none are requested, which the client of the ctx may search.
+func (s *PlainQ) searchKinds(ctx context.Context, requested []v1.EntityKind) ([]v1.EntityKind, error) {
+	kinds := slices.Clone(requested)
+	if len(kinds) == 0 {
+		kinds = []v1.EntityKind{
+			v1.EntityKind_ENTITY_KIND_QUEUE,
+			v1.EntityKind_ENTITY_KIND_USER,
+			v1.EntityKind_ENTITY_KIND_ROLE,
+			v1.EntityKind_ENTITY_KIND_AUDIT_EVENT,
+		}
+	}
+
+	admin, adminErr := s.isAdmin(ctx)
+	if adminErr != nil {
+		return nil, adminErr
+	}
+
+	if admin {
+		return kinds, nil
+	}
+
+	return slices.DeleteFunc(kinds, func(k v1.EntityKind) bool { return k != v1.EntityKind_ENTITY_KIND_QUEUE }), nil
+}
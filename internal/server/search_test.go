@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/identity"
+	"github.com/plainq/plainq/internal/server/rbac"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+)
+
+func TestPlainQ_searchMessages(t *testing.T) {
+	type tcase struct {
+		subject string
+		queueID string
+		wantErr error
+	}
+
+	queueID := idkit.XID()
+
+	tests := map[string]tcase{
+		"Consumer":       {subject: "consumer", queueID: queueID},
+		"Viewer":         {subject: "viewer", queueID: queueID, wantErr: errkit.ErrUnauthorized},
+		"WithoutAuthn":   {queueID: queueID},
+		"InvalidQueueID": {subject: "consumer", queueID: "invalid", wantErr: pqerr.ErrInvalidID},
+	}
+
+	server := PlainQ{
+		observer: telemetry.NewObserver(),
+		storage: &mockStorage{
+			searchMessagesFunc: func(_ context.Context, input *v1.SearchMessagesRequest) (*v1.SearchMessagesResponse, error) {
+				return &v1.SearchMessagesResponse{Messages: []*v1.ReceiveMessage{{Id: input.GetQueueId()}}}, nil
+			},
+			queueGrantsFunc: func(_ context.Context, subject, _ string) (*rbac.Grants, error) {
+				grants := rbac.Grants{Operations: []rbac.Operation{rbac.OpDescribe}}
+
+				if subject == "consumer" {
+					grants.Operations = append(grants.Operations, rbac.OpReceive)
+				}
+
+				return &grants, nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.subject != "" {
+				ctx = identity.WithIdentity(ctx, identity.Identity{Name: tc.subject})
+			}
+
+			input := v1.SearchMessagesRequest{QueueId: tc.queueID, Query: "order"}
+
+			output, err := server.searchMessages(ctx, &input)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, output.GetMessages(), td.Len(1))
+		})
+	}
+}
@@ -1,68 +1,420 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/cors"
-	"github.com/heartwilltell/hc"
+	"github.com/plainq/plainq/internal/server/alerting"
+	"github.com/plainq/plainq/internal/server/audit"
+	"github.com/plainq/plainq/internal/server/auth"
+	"github.com/plainq/plainq/internal/server/auth/ldap"
+	"github.com/plainq/plainq/internal/server/breaker"
+	"github.com/plainq/plainq/internal/server/certs"
+	"github.com/plainq/plainq/internal/server/cluster"
 	"github.com/plainq/plainq/internal/server/config"
+	"github.com/plainq/plainq/internal/server/gateway"
+	"github.com/plainq/plainq/internal/server/generator"
+	"github.com/plainq/plainq/internal/server/health"
+	"github.com/plainq/plainq/internal/server/interceptor"
+	"github.com/plainq/plainq/internal/server/logging"
 	"github.com/plainq/plainq/internal/server/middleware"
+	"github.com/plainq/plainq/internal/server/ratelimit"
+	"github.com/plainq/plainq/internal/server/reload"
 	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/scim"
 	"github.com/plainq/plainq/internal/server/storage"
 	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/plainq/internal/server/timeout"
 	"github.com/plainq/servekit"
-	"github.com/plainq/servekit/grpckit"
-	"github.com/plainq/servekit/httpkit"
-	vtgrpc "github.com/planetscale/vtprotobuf/codec/grpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/encoding"
-	_ "google.golang.org/grpc/encoding/proto"
+	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
+// Keys of the OAuth provider are requested on unknown key ids at most once
+// per jwksMinRefreshInterval, and each request times out after jwksTimeout.
+const (
+	jwksMinRefreshInterval = 30 * time.Second
+	jwksTimeout            = 10 * time.Second
+)
+
+// Leader tells whether the server is elected to change the database
+// among servers which replicate or share it.
+type Leader interface {
+	IsLeader() bool
+	Leader() string
+}
+
 // PlainQ represents plainq logic.
 type PlainQ struct {
 	v1.UnimplementedPlainQServiceServer
 
 	logger   *slog.Logger
+	loggers  *logging.Loggers
+	audit    *slog.Logger
 	storage  storage.Storage
 	observer telemetry.Observer
+
+	// history serves historical metrics,
+	// nil when historical metrics are not stored.
+	history telemetry.Querier
+
+	// alerts evaluates alert rules,
+	// nil when alerting is not enabled.
+	alerts *alerting.Engine
+
+	// reloader reloads the configuration on demand,
+	// nil when the configuration reload is not enabled.
+	reloader *reload.Reloader
+
+	// breaker freezes queues on anomalies,
+	// nil when the circuit breaker is not enabled.
+	breaker *breaker.Breaker
+
+	// generator runs synthetic producers.
+	generator *generator.Generator
+
+	// cluster replicates the database between servers,
+	// nil when the cluster mode is not enabled.
+	cluster *cluster.Node
+
+	// authn authenticates clients and signs them out,
+	// nil when the authentication is not enabled.
+	authn *auth.Authenticator
+
+	// ackByMessageID allows to delete messages and change their visibility
+	// by identifiers instead of receipt handles of their receives.
+	ackByMessageID bool
+
+	// epoch distinguishes ETags issued by different server runs,
+	// since the queue properties version starts over on each start.
+	epoch string
+
+	// started is the time the server has been started,
+	// which is the time the telemetry counters count from.
+	started time.Time
 }
 
 func (s *PlainQ) Mount(server *grpc.Server) { v1.RegisterPlainQServiceServer(server, s) }
 
 // NewServer returns a pointer to a new instance of the PlainQ.
-func NewServer(cfg *config.Config, logger *slog.Logger, storage storage.Storage, checker hc.HealthChecker) (*servekit.Server, error) {
+// The server registers its own settings which can be changed at runtime with the reloader.
+// Historical metrics are served by the history, which is nil when they are not stored.
+// States of alerts are served by the alerts engine, which is nil when alerting is not enabled.
+// Writes are served only while the server is elected by the leader, e.g. the node of the cluster,
+// which is nil when servers don't share the database.
+func NewServer(cfg *config.Config, loggers *logging.Loggers, storage storage.Storage, observer telemetry.Observer, history telemetry.Querier, alerts *alerting.Engine, leader Leader, checker *health.Checker, reloader *reload.Reloader) (*servekit.Server, error) {
+	logger := loggers.Logger(logging.Server)
+
 	// Create a server which holds and serve all listeners.
 	server := servekit.NewServer(logger)
 
+	started := time.Now().UTC()
+
+	var circuitBreaker *breaker.Breaker
+
+	if cfg.BreakerEnable {
+		b, breakerErr := newBreaker(cfg, storage, observer, logger)
+		if breakerErr != nil {
+			return nil, breakerErr
+		}
+
+		// Operations on frozen queues are paused
+		// for any producer, including synthetic ones.
+		storage = b.Storage(storage)
+		circuitBreaker = b
+	}
+
+	// Mutations are recorded to the audit log once they pass the breaker.
+	storage = audit.New(storage, audit.Config{DataPlane: cfg.AuditDataPlane}, loggers.Logger(logging.Audit)).Storage(storage)
+
 	pq := PlainQ{
-		logger:   logger,
-		storage:  storage,
-		observer: telemetry.NewObserver(),
+		logger:         logger,
+		loggers:        loggers,
+		audit:          loggers.Logger(logging.Audit),
+		storage:        storage,
+		observer:       observer,
+		history:        history,
+		alerts:         alerts,
+		reloader:       reloader,
+		breaker:        circuitBreaker,
+		generator:      generator.New(storage, logger),
+		ackByMessageID: cfg.StorageAckByMessageID,
+		epoch:          strconv.FormatInt(started.UnixNano(), 36),
+		started:        started,
+	}
+
+	// The status of the cluster is served by its nodes.
+	if node, ok := leader.(*cluster.Node); ok {
+		pq.cluster = node
+	}
+
+	corsMiddleware := middleware.NewCORS(splitList(cfg.CORSOrigins))
+
+	if reloader != nil {
+		reloader.Register(reload.Setting{
+			Name:  "cors.origins",
+			Value: func(cfg *config.Config) string { return cfg.CORSOrigins },
+			Apply: func(cfg *config.Config) error {
+				corsMiddleware.SetOrigins(splitList(cfg.CORSOrigins))
+				return nil
+			},
+		})
+	}
+
+	httpTLS, httpTLSErr := listenerTLS(cfg, cfg.HTTPTLSCert, cfg.HTTPTLSKey, logger)
+	if httpTLSErr != nil {
+		return nil, fmt.Errorf("HTTP listener: %w", httpTLSErr)
+	}
+
+	grpcTLS, grpcTLSErr := listenerTLS(cfg, cfg.GRPCTLSCert, cfg.GRPCTLSKey, logger)
+	if grpcTLSErr != nil {
+		return nil, fmt.Errorf("gRPC listener: %w", grpcTLSErr)
+	}
+
+	grpcTimeouts, grpcTimeoutsErr := timeout.Parse(cfg.GRPCTimeouts)
+	if grpcTimeoutsErr != nil {
+		return nil, fmt.Errorf("gRPC timeouts: %w", grpcTimeoutsErr)
+	}
+
+	grpcOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(interceptor.Timeout(grpcTimeouts)),
+	}
+
+	// Calls of the JSON gateway go through the same interceptors as gRPC calls,
+	// except the client certificates one, since HTTP clients present none.
+	gatewayInterceptors := []grpc.UnaryServerInterceptor{interceptor.Timeout(grpcTimeouts)}
+
+	// Writes are rejected before anything else, the replica can't serve them anyway.
+	if cfg.ReplicaOf != "" {
+		grpcOptions = append(grpcOptions, grpc.ChainUnaryInterceptor(interceptor.ReadOnly()))
+		gatewayInterceptors = append(gatewayInterceptors, interceptor.ReadOnly())
+	}
+
+	// Followers of the cluster and standby servers can't serve writes either, until they are elected.
+	if leader != nil {
+		grpcOptions = append(grpcOptions, grpc.ChainUnaryInterceptor(interceptor.Leader(leader)))
+		gatewayInterceptors = append(gatewayInterceptors, interceptor.Leader(leader))
+	}
+
+	if cfg.TelemetryOTLPEnable {
+		grpcOptions = append(grpcOptions, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	}
+
+	if cfg.GRPCTLSClientCA != "" {
+		options, authErr := clientCertAuth(cfg, grpcTLS, observer)
+		if authErr != nil {
+			return nil, fmt.Errorf("gRPC listener: %w", authErr)
+		}
+
+		grpcOptions = append(grpcOptions, options...)
+	}
+
+	var authn *auth.Authenticator
+
+	if cfg.AuthEnable {
+		denylist, denylistErr := auth.NewDenylist(storage, auth.DenylistConfig{
+			CacheTTL:        cfg.AuthDenylistCacheTTL,
+			CleanupInterval: cfg.AuthDenylistCleanupInterval,
+		}, logger)
+		if denylistErr != nil {
+			return nil, fmt.Errorf("access token denylist: %w", denylistErr)
+		}
+
+		go denylist.Run(context.Background())
+
+		jwtCfg := auth.JWTConfig{
+			Secret:   []byte(cfg.AuthJWTSecret),
+			Issuer:   cfg.AuthJWTIssuer,
+			Audience: cfg.AuthJWTAudience,
+			Leeway:   cfg.AuthJWTLeeway,
+		}
+
+		if cfg.AuthJWKSURL != "" {
+			jwks, jwksErr := auth.NewJWKS(auth.JWKSConfig{
+				URL:                cfg.AuthJWKSURL,
+				RefreshInterval:    cfg.AuthJWKSRefreshInterval,
+				MinRefreshInterval: jwksMinRefreshInterval,
+				Timeout:            jwksTimeout,
+			}, logger)
+			if jwksErr != nil {
+				return nil, fmt.Errorf("authentication: %w", jwksErr)
+			}
+
+			go jwks.Run(context.Background())
+
+			jwtCfg.Keys = jwks
+		}
+
+		// The directory comes first, so local accounts
+		// are the fallback of users the directory doesn't know.
+		var verifiers []auth.PasswordVerifier
+
+		if cfg.AuthLDAPURL != "" {
+			directory, directoryErr := newDirectory(cfg)
+			if directoryErr != nil {
+				return nil, fmt.Errorf("authentication: %w", directoryErr)
+			}
+
+			verifiers = append(verifiers, directory)
+		}
+
+		var localOptions []auth.LocalOption
+
+		if cfg.AuthRequireVerifiedEmail {
+			localOptions = append(localOptions, auth.WithVerifiedEmail(storage))
+		}
+
+		verifiers = append(verifiers, auth.NewLocalAccounts(storage, localOptions...))
+
+		authCfg := auth.Config{
+			JWT:                        jwtCfg,
+			Verifiers:                  verifiers,
+			Users:                      storage,
+			TokenTTL:                   cfg.AuthTokenTTL,
+			APIKeys:                    storage,
+			Denylist:                   denylist,
+			Sessions:                   storage,
+			Passwords:                  storage,
+			Resets:                     storage,
+			ResetCodeTTL:               cfg.AuthResetCodeTTL,
+			Verifications:              storage,
+			VerificationCodeTTL:        cfg.AuthVerifyCodeTTL,
+			VerificationResendInterval: cfg.AuthVerifyResendInterval,
+		}
+
+		// Password reset and email verification codes are emailed by the mail server of alerts.
+		if cfg.AlertingSMTPAddr != "" {
+			mailer, mailerErr := auth.NewSMTPMailer(auth.SMTPConfig{
+				Addr:     cfg.AlertingSMTPAddr,
+				From:     cfg.AlertingSMTPFrom,
+				Username: cfg.AlertingSMTPUsername,
+				Password: cfg.AlertingSMTPPassword,
+			})
+			if mailerErr != nil {
+				return nil, fmt.Errorf("authentication: %w", mailerErr)
+			}
+
+			authCfg.Mailer = mailer
+		}
+
+		a, authErr := auth.New(authCfg)
+		if authErr != nil {
+			return nil, fmt.Errorf("authentication: %w", authErr)
+		}
+
+		// Clients authenticated by certificates are not required to present tokens.
+		grpcOptions = append(grpcOptions,
+			grpc.ChainUnaryInterceptor(interceptor.Auth(a, storage, observer)),
+			grpc.ChainStreamInterceptor(interceptor.AuthStream(a, storage, observer)),
+		)
+		gatewayInterceptors = append(gatewayInterceptors, interceptor.Auth(a, storage, observer))
+		authn = a
+		pq.authn = a
+	}
+
+	var limiter *ratelimit.Limiter
+
+	if cfg.RateLimitEnable {
+		l, limiterErr := newRateLimiter(cfg, storage)
+		if limiterErr != nil {
+			return nil, limiterErr
+		}
+
+		// Clients are limited after they have been authenticated,
+		// so the limiter sees identities instead of addresses.
+		grpcOptions = append(grpcOptions, grpc.ChainUnaryInterceptor(interceptor.RateLimit(l, observer)))
+		gatewayInterceptors = append(gatewayInterceptors, interceptor.RateLimit(l, observer))
+		limiter = l
+	}
+
+	var jsonGateway http.Handler
+
+	if cfg.GatewayEnable {
+		g, gatewayErr := gateway.New(&pq, gatewayInterceptors...)
+		if gatewayErr != nil {
+			return nil, fmt.Errorf("JSON gateway: %w", gatewayErr)
+		}
+
+		jsonGateway = g
 	}
 
 	// Create the HTTP listener.
-	httpListener, httpListenerErr := listenerHTTP(cfg, logger, checker)
+	accessLog, accessLogErr := newAccessLog(cfg, loggers)
+	if accessLogErr != nil {
+		return nil, accessLogErr
+	}
+
+	httpListener, httpListenerErr := listenerHTTP(cfg, loggers.Logger(logging.HTTP), accessLog, checker, httpTLS)
 	if httpListenerErr != nil {
 		return nil, httpListenerErr
 	}
 
 	// Initialize and mount the HTTP API routes.
 	httpListener.MountGroup("/api", func(api chi.Router) {
-		api.Use(middleware.Logging(logger))
-		api.Use(cors.AllowAll().Handler)
-
-		api.Route("/v1", func(v1 chi.Router) {
-			// Queue related routes.
-			v1.Route("/queue", func(queue chi.Router) {
-				queue.Post("/", pq.createQueueHandler)
-				queue.Get("/", pq.listQueuesHandler)
-				queue.Get("/{id}", pq.describeQueueHandler)
-				queue.Post("/{id}/purge", pq.purgeQueueHandler)
-				queue.Delete("/{id}", pq.deleteQueueHandler)
-			})
+		if cfg.TelemetryOTLPEnable {
+			api.Use(middleware.Tracing(otel.GetTracerProvider()))
+		}
+
+		if cfg.LogAccessEnable {
+			api.Use(accessLog)
+		}
+
+		if cfg.CORSEnable {
+			api.Use(corsMiddleware.Handler)
+		}
+
+		// JSON transcoding of the gRPC API, which calls are authenticated
+		// and rate limited by interceptors instead of HTTP middlewares.
+		if jsonGateway != nil {
+			api.Mount("/rpc", http.StripPrefix("/api/rpc", jsonGateway))
+		}
+
+		api.Group(func(api chi.Router) {
+			if cfg.ReplicaOf != "" {
+				api.Use(middleware.ReadOnly)
+			}
+
+			if leader != nil {
+				api.Use(middleware.Leader(leader))
+			}
+
+			if authn != nil {
+				authenticate := middleware.Auth(httpListener.router, authn, storage, observer, httpOperations)
+
+				// Administrative routes and SCIM provisioning manage credentials,
+				// so they are authenticated even when the rest of the HTTP API is not.
+				if !cfg.AuthHTTP {
+					authenticate = middleware.Prefixes(authenticate, "/api/v1/admin", "/api/scim/v2")
+				}
+
+				api.Use(authenticate)
+			}
+
+			if limiter != nil {
+				api.Use(middleware.RateLimit(httpListener.router, limiter, observer))
+			}
+
+			// SCIM provisioning by identity providers, which authenticate as admins.
+			api.Mount("/scim/v2", scim.NewHandler(storage, logger))
+
+			// OpenAPI document of the HTTP API and Swagger UI which renders it.
+			if cfg.DocsEnable {
+				api.Get("/docs", docsHandler(cfg.DocsSwaggerUIURL))
+				api.Get("/docs/openapi.json", pq.openAPIHandler)
+			}
+
+			api.Route("/v1", pq.routesV1)
 		})
 	})
 
@@ -77,52 +429,275 @@ func NewServer(cfg *config.Config, logger *slog.Logger, storage storage.Storage,
 	// Register the HTTP listener with a server.
 	server.RegisterListener("HTTP", httpListener)
 
-	grpcListener, grpcListenerErr := grpckit.NewListenerGRPC(cfg.GRPCAddr)
+	grpcListener, grpcServer, grpcListenerErr := listenerGRPC(cfg, loggers.Logger(logging.GRPC), grpcTLS, grpcOptions...)
 	if grpcListenerErr != nil {
-		return nil, fmt.Errorf("create gRPC listener: %w", grpcListenerErr)
+		_ = httpListener.ln.Close()
+		return nil, grpcListenerErr
 	}
 
-	// Mount the plainq gRPC routes to the gRPC listener.
-	grpcListener.Mount(&pq)
+	// Mount the plainq gRPC routes to the gRPC server.
+	pq.Mount(grpcServer)
+
+	// Serve the standard gRPC health service, e.g. for clients to ping the server.
+	grpc_health_v1.RegisterHealthServer(grpcServer, health.GRPC(checker))
+
+	checker.Add(health.Check{Name: "grpc", Critical: true, Func: health.Listener(grpcListener.ln.Addr())})
 
 	// Register the gRPC listener with a server.
 	server.RegisterListener("GRPC", grpcListener)
 
+	if cfg.ProfilerEnabled {
+		profilerListener, profilerListenerErr := listenerProfiler(cfg, loggers.Logger(logging.HTTP))
+		if profilerListenerErr != nil {
+			_ = httpListener.ln.Close()
+			_ = grpcListener.ln.Close()
+
+			return nil, profilerListenerErr
+		}
+
+		server.RegisterListener("Profiler", profilerListener)
+	}
+
 	return server, nil
 }
 
-func listenerHTTP(cfg *config.Config, logger *slog.Logger, checker hc.HealthChecker) (*httpkit.ListenerHTTP, error) {
-	httpListenerOpts := httpkit.NewListenerOption[httpkit.ListenerConfig](
-		httpkit.WithLogger(logger),
-		httpkit.WithHTTPServerTimeouts(
-			httpkit.HTTPServerReadHeaderTimeout(cfg.HTTPReadHeaderTimeout),
-			httpkit.HTTPServerReadTimeout(cfg.HTTPReadTimeout),
-			httpkit.HTTPServerWriteTimeout(cfg.HTTPWriteTimeout),
-			httpkit.HTTPServerIdleTimeout(cfg.HTTPIdleTimeout),
-		),
-	)
+// routesV1 registers routes of the HTTP API v1 on the router.
+func (s *PlainQ) routesV1(v1 chi.Router) {
+	// Queue related routes.
+	v1.Route("/queue", func(queue chi.Router) {
+		queue.Post("/", s.createQueueHandler)
+		queue.Get("/", s.listQueuesHandler)
+		queue.Get("/{id}", s.describeQueueHandler)
+		queue.Patch("/{id}", s.updateQueueHandler)
+		queue.Put("/{id}/state", s.setQueueStateHandler)
+		queue.Post("/{id}/purge", s.purgeQueueHandler)
+		queue.Get("/{id}/advice", s.adviseQueueHandler)
+		queue.Get("/{id}/stats", s.queueStatsHandler)
+		queue.Get("/{id}/search", s.searchMessagesHandler)
+		queue.Get("/{id}/messages", s.peekMessagesHandler)
+		queue.Get("/{id}/messages/{message}", s.getMessageHandler)
+		queue.Get("/{id}/messages/{message}/events", s.listMessageEventsHandler)
+		queue.Post("/{id}/messages", s.sendHandler)
+		queue.Get("/{id}/archive", s.listArchivedMessagesHandler)
+		queue.Post("/{id}/archive/restore", s.restoreArchivedMessagesHandler)
+		queue.Post("/{id}/generator", s.startGeneratorHandler)
+		queue.Post("/{id}/transfer", s.transferQueueHandler)
+		queue.Get("/{id}/permissions", s.listQueuePermissionsHandler)
+		queue.Put("/{id}/permissions/{role}", s.setQueuePermissionHandler)
+		queue.Delete("/{id}/permissions/{role}", s.deleteQueuePermissionHandler)
+		queue.Delete("/{id}/breaker", s.resetBreakerHandler)
+		queue.Delete("/{id}", s.deleteQueueHandler)
+	})
+
+	// Authentication related routes.
+	v1.Post("/auth/sign-in", s.signInHandler)
+	v1.Post("/auth/sign-out", s.signOutHandler)
+	v1.Post("/auth/password-reset", s.requestPasswordResetHandler)
+	v1.Post("/auth/password-reset/verify", s.verifyPasswordResetCodeHandler)
+	v1.Post("/auth/password-reset/confirm", s.resetPasswordHandler)
+	v1.Post("/auth/verify-email/send", s.sendEmailVerificationHandler)
+	v1.Post("/auth/verify-email", s.verifyEmailHandler)
+	v1.Get("/auth/sessions", s.listSessionsHandler)
+	v1.Delete("/auth/sessions", s.revokeSessionsHandler)
+	v1.Delete("/auth/sessions/{id}", s.revokeSessionHandler)
+
+	v1.Get("/account/me", s.getAccountHandler)
+	v1.Patch("/account/me", s.updateAccountHandler)
+	v1.Post("/account/password", s.changePasswordHandler)
+	v1.Get("/account/permissions", s.accountPermissionsHandler)
+
+	// Search across entities, which powers the command palette.
+	v1.Get("/search", s.searchHandler)
+
+	// Historical metrics, which are the data source of Houston charts.
+	v1.Get("/telemetry/query", s.queryTelemetryHandler)
+
+	// Alerting related routes.
+	v1.Route("/alerts", func(alerts chi.Router) {
+		alerts.Get("/", s.listAlertsHandler)
+		alerts.Get("/rules", s.listAlertRulesHandler)
+		alerts.Post("/rules", s.createAlertRuleHandler)
+		alerts.Put("/rules/{id}", s.updateAlertRuleHandler)
+		alerts.Delete("/rules/{id}", s.deleteAlertRuleHandler)
+	})
+
+	// Administrative routes.
+	v1.Route("/admin", func(admin chi.Router) {
+		admin.Get("/log-levels", s.getLogLevelsHandler)
+		admin.Put("/log-levels", s.setLogLevelsHandler)
+		admin.Post("/reload", s.reloadConfigHandler)
+		admin.Get("/cluster", s.clusterStatusHandler)
+		admin.Post("/storage/maintenance", s.runMaintenanceHandler)
+		admin.Get("/breakers", s.listBreakersHandler)
+		admin.Get("/audit", s.listAuditEventsHandler)
 
-	if cfg.HealthEnable {
-		httpListenerOpts = append(httpListenerOpts, httpkit.WithHealthCheck(
-			httpkit.HealthCheckRoute(cfg.HealthRoute),
-			httpkit.HealthChecker(checker),
-		))
+		admin.Get("/service-accounts", s.listServiceAccountsHandler)
+		admin.Post("/service-accounts", s.createServiceAccountHandler)
+		admin.Delete("/service-accounts/{id}", s.deleteServiceAccountHandler)
+		admin.Get("/service-accounts/{id}/keys", s.listAPIKeysHandler)
+		admin.Post("/service-accounts/{id}/keys", s.createAPIKeyHandler)
+		admin.Delete("/keys/{id}", s.revokeAPIKeyHandler)
+
+		admin.Get("/users/{subject}/sessions", s.listUserSessionsHandler)
+		admin.Delete("/users/{subject}/sessions", s.revokeUserSessionsHandler)
+		admin.Delete("/users/{subject}/sessions/{id}", s.revokeUserSessionHandler)
+		admin.Get("/users/{subject}/permissions", s.userPermissionsHandler)
+
+		admin.Get("/templates", s.listPermissionTemplatesHandler)
+		admin.Get("/roles", s.listRolesHandler)
+		admin.Post("/roles", s.createRoleHandler)
+		admin.Delete("/roles/{role}", s.deleteRoleHandler)
+		admin.Put("/roles/{role}/users/{email}", s.assignRoleHandler)
+		admin.Delete("/roles/{role}/users/{email}", s.unassignRoleHandler)
+		admin.Put("/roles/{role}/templates/{template}", s.applyTemplateHandler)
+	})
+
+	// Queue ownership transfer related routes.
+	v1.Route("/transfer", func(transfer chi.Router) {
+		transfer.Post("/{id}/accept", s.acceptQueueTransferHandler)
+		transfer.Delete("/{id}", s.cancelQueueTransferHandler)
+	})
+
+	// Background jobs related routes.
+	v1.Get("/jobs", s.listJobsHandler)
+	v1.Get("/jobs/{id}", s.getJobHandler)
+	v1.Delete("/jobs/{id}", s.cancelJobHandler)
+
+	// Synthetic producers related routes.
+	v1.Route("/generator", func(gen chi.Router) {
+		gen.Get("/", s.listGeneratorsHandler)
+		gen.Delete("/{id}", s.stopGeneratorHandler)
+	})
+}
+
+// newBreaker creates the circuit breaker and starts the evaluation of its conditions.
+func newBreaker(cfg *config.Config, queues breaker.Queues, observer telemetry.Observer, logger *slog.Logger) (*breaker.Breaker, error) {
+	action, actionErr := breaker.ParseAction(cfg.BreakerAction)
+	if actionErr != nil {
+		return nil, fmt.Errorf("create circuit breaker: %w", actionErr)
+	}
+
+	b, breakerErr := breaker.New(breaker.Config{
+		Action:          action,
+		MaxDepth:        uint64(cfg.BreakerMaxDepth),
+		MaxDeadLettered: uint64(cfg.BreakerMaxDeadLettered),
+		Interval:        cfg.BreakerInterval,
+	}, queues, observer, logger)
+	if breakerErr != nil {
+		return nil, fmt.Errorf("create circuit breaker: %w", breakerErr)
+	}
+
+	go b.Run(context.Background())
+
+	return b, nil
+}
+
+// newDirectory creates the LDAP directory which verifies passwords on sign-in.
+func newDirectory(cfg *config.Config) (*ldap.Directory, error) {
+	var groupRoles map[string]string
+
+	if cfg.AuthLDAPGroupRoles != "" {
+		document, readErr := os.ReadFile(cfg.AuthLDAPGroupRoles)
+		if readErr != nil {
+			return nil, fmt.Errorf("read LDAP group roles: %w", readErr)
+		}
+
+		if err := json.Unmarshal(document, &groupRoles); err != nil {
+			return nil, fmt.Errorf("LDAP group roles %s: %w", cfg.AuthLDAPGroupRoles, err)
+		}
+	}
+
+	directory, directoryErr := ldap.New(ldap.Config{
+		URL:            cfg.AuthLDAPURL,
+		BindDN:         cfg.AuthLDAPBindDN,
+		BindPassword:   cfg.AuthLDAPBindPassword,
+		BaseDN:         cfg.AuthLDAPBaseDN,
+		UserAttribute:  cfg.AuthLDAPUserAttribute,
+		EmailAttribute: cfg.AuthLDAPEmailAttribute,
+		GroupAttribute: cfg.AuthLDAPGroupAttribute,
+		GroupRoles:     groupRoles,
+		Timeout:        cfg.AuthLDAPTimeout,
+	})
+	if directoryErr != nil {
+		return nil, fmt.Errorf("create LDAP directory: %w", directoryErr)
+	}
+
+	return directory, nil
+}
+
+// newRateLimiter creates the rate limiter. Limits of queues are read from queue properties.
+func newRateLimiter(cfg *config.Config, s storage.Storage) (*ratelimit.Limiter, error) {
+	global, globalErr := ratelimit.ParseLimit(cfg.RateLimitGlobal)
+	if globalErr != nil {
+		return nil, fmt.Errorf("global rate limit: %w", globalErr)
+	}
+
+	client, clientErr := ratelimit.ParseLimit(cfg.RateLimitClient)
+	if clientErr != nil {
+		return nil, fmt.Errorf("client rate limit: %w", clientErr)
+	}
+
+	clients, clientsErr := ratelimit.ParseLimits(cfg.RateLimitClients)
+	if clientsErr != nil {
+		return nil, fmt.Errorf("client rate limits: %w", clientsErr)
+	}
+
+	limiter := ratelimit.New(ratelimit.Config{
+		Global:  global,
+		Client:  client,
+		Clients: clients,
+		Queue:   queueRateLimit(s),
+	})
+
+	return limiter, nil
+}
+
+// queueRateLimit returns the rate limit of the queue from its properties. Queues which
+// can't be described are not limited, the request fails later for the same reason.
+func queueRateLimit(s storage.Storage) ratelimit.QueueLimitFunc {
+	return func(ctx context.Context, queueID string) ratelimit.Limit {
+		props, err := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID})
+		if err != nil {
+			return ratelimit.Limit{}
+		}
+
+		burst := props.GetRateLimitBurst()
+		if burst == 0 {
+			burst = props.GetRateLimit()
+		}
+
+		return ratelimit.Limit{Rate: float64(props.GetRateLimit()), Burst: int(burst)}
+	}
+}
+
+// listenerTLS returns the TLS configuration of the listener, or nil when the certificate
+// is not specified. The certificate is reloaded when files change, if it's enabled.
+func listenerTLS(cfg *config.Config, certFile, keyFile string, logger *slog.Logger) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
 	}
 
-	if cfg.MetricsEnable {
-		httpListenerOpts = append(httpListenerOpts, httpkit.WithMetrics(
-			httpkit.MetricsRoute(cfg.MetricsRoute),
-			httpkit.MetricsAccessLog(cfg.MetricsRouteLogs),
-			httpkit.MetricsMetricsForEndpoint(cfg.MetricsRouteMetrics),
-		))
+	reloader, reloaderErr := certs.NewReloader(certFile, keyFile, logger)
+	if reloaderErr != nil {
+		return nil, reloaderErr
 	}
 
-	httpListener, err := httpkit.NewListenerHTTP(cfg.HTTPAddr, httpListenerOpts...)
-	if err != nil {
-		return nil, fmt.Errorf("create HTTP listener: %w", err)
+	if cfg.TLSReloadInterval > 0 {
+		go reloader.Watch(context.Background(), cfg.TLSReloadInterval)
 	}
 
-	return httpListener, nil
+	return reloader.TLSConfig(), nil
 }
 
-func init() { encoding.RegisterCodec(vtgrpc.Codec{}) }
+// splitList splits comma separated list and drops empty elements.
+func splitList(list string) []string {
+	var elements []string
+
+	for e := range strings.SplitSeq(list, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			elements = append(elements, e)
+		}
+	}
+
+	return elements
+}
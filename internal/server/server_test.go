@@ -2,19 +2,101 @@ package server
 
 import (
 	"context"
+	"time"
 
+	"github.com/plainq/plainq/internal/server/auth"
+	"github.com/plainq/plainq/internal/server/rbac"
 	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/scim"
+	"github.com/plainq/plainq/internal/server/telemetry"
 )
 
 type mockStorage struct {
-	createQueueFunc   func(ctx context.Context, input *v1.CreateQueueRequest) (*v1.CreateQueueResponse, error)
-	describeQueueFunc func(ctx context.Context, input *v1.DescribeQueueRequest) (*v1.DescribeQueueResponse, error)
-	listQueuesFunc    func(ctx context.Context, input *v1.ListQueuesRequest) (*v1.ListQueuesResponse, error)
-	purgeQueueFunc    func(ctx context.Context, input *v1.PurgeQueueRequest) (*v1.PurgeQueueResponse, error)
-	deleteQueueFunc   func(ctx context.Context, input *v1.DeleteQueueRequest) (*v1.DeleteQueueResponse, error)
-	sendFunc          func(ctx context.Context, input *v1.SendRequest) (*v1.SendResponse, error)
-	receiveFunc       func(ctx context.Context, input *v1.ReceiveRequest) (*v1.ReceiveResponse, error)
-	deleteFunc        func(ctx context.Context, input *v1.DeleteRequest) (*v1.DeleteResponse, error)
+	createQueueFunc      func(ctx context.Context, input *v1.CreateQueueRequest) (*v1.CreateQueueResponse, error)
+	describeQueueFunc    func(ctx context.Context, input *v1.DescribeQueueRequest) (*v1.DescribeQueueResponse, error)
+	updateQueueFunc      func(ctx context.Context, input *v1.UpdateQueueRequest) (*v1.UpdateQueueResponse, error)
+	listQueuesFunc       func(ctx context.Context, input *v1.ListQueuesRequest) (*v1.ListQueuesResponse, error)
+	purgeQueueFunc       func(ctx context.Context, input *v1.PurgeQueueRequest) (*v1.PurgeQueueResponse, error)
+	deleteQueueFunc      func(ctx context.Context, input *v1.DeleteQueueRequest) (*v1.DeleteQueueResponse, error)
+	sendFunc             func(ctx context.Context, input *v1.SendRequest) (*v1.SendResponse, error)
+	receiveFunc          func(ctx context.Context, input *v1.ReceiveRequest) (*v1.ReceiveResponse, error)
+	deleteFunc           func(ctx context.Context, input *v1.DeleteRequest) (*v1.DeleteResponse, error)
+	changeVisibilityFunc func(ctx context.Context, input *v1.ChangeVisibilityRequest) (*v1.ChangeVisibilityResponse, error)
+	retriesFunc          func(ctx context.Context, queueID string) (map[uint32]uint64, error)
+	queueStatsFunc       func(ctx context.Context, queueID string) (*v1.QueueStatsResponse, error)
+	transferQueueFunc    func(ctx context.Context, input *v1.TransferQueueRequest) (*v1.TransferQueueResponse, error)
+	acceptTransferFunc   func(ctx context.Context, input *v1.AcceptQueueTransferRequest) (*v1.AcceptQueueTransferResponse, error)
+	cancelTransferFunc   func(ctx context.Context, input *v1.CancelQueueTransferRequest) (*v1.QueueTransfer, error)
+	searchMessagesFunc   func(ctx context.Context, input *v1.SearchMessagesRequest) (*v1.SearchMessagesResponse, error)
+	peekMessagesFunc     func(ctx context.Context, input *v1.PeekMessagesRequest) (*v1.PeekMessagesResponse, error)
+	getMessageFunc       func(ctx context.Context, input *v1.GetMessageRequest) (*v1.GetMessageResponse, error)
+	messageEventsFunc    func(ctx context.Context, input *v1.ListMessageEventsRequest) (*v1.ListMessageEventsResponse, error)
+	extendVisibilityFunc func(ctx context.Context, input *v1.ExtendVisibilityBatchRequest) (*v1.ExtendVisibilityBatchResponse, error)
+	transactFunc         func(ctx context.Context, input *v1.TransactRequest) (*v1.TransactResponse, error)
+	listArchivedFunc     func(ctx context.Context, input *v1.ListArchivedMessagesRequest) (*v1.ListArchivedMessagesResponse, error)
+	restoreArchivedFunc  func(ctx context.Context, input *v1.RestoreArchivedMessagesRequest) (*v1.RestoreArchivedMessagesResponse, error)
+	getJobFunc           func(ctx context.Context, input *v1.GetJobRequest) (*v1.GetJobResponse, error)
+	listJobsFunc         func(ctx context.Context, input *v1.ListJobsRequest) (*v1.ListJobsResponse, error)
+	cancelJobFunc        func(ctx context.Context, input *v1.CancelJobRequest) (*v1.CancelJobResponse, error)
+	runMaintenanceFunc   func(ctx context.Context, input *v1.RunMaintenanceRequest) (*v1.RunMaintenanceResponse, error)
+	setQueueStateFunc    func(ctx context.Context, input *v1.SetQueueStateRequest) (*v1.SetQueueStateResponse, error)
+	searchEntitiesFunc   func(ctx context.Context, input *v1.SearchRequest) (*v1.SearchResponse, error)
+	appendAuditFunc      func(ctx context.Context, event *v1.AuditEvent) error
+	listAuditFunc        func(ctx context.Context, input *v1.ListAuditEventsRequest) (*v1.ListAuditEventsResponse, error)
+	createAlertRuleFunc  func(ctx context.Context, input *v1.CreateAlertRuleRequest) (*v1.CreateAlertRuleResponse, error)
+	listAlertRulesFunc   func(ctx context.Context, input *v1.ListAlertRulesRequest) (*v1.ListAlertRulesResponse, error)
+	updateAlertRuleFunc  func(ctx context.Context, input *v1.UpdateAlertRuleRequest) (*v1.UpdateAlertRuleResponse, error)
+	deleteAlertRuleFunc  func(ctx context.Context, input *v1.DeleteAlertRuleRequest) (*v1.DeleteAlertRuleResponse, error)
+	createAccountFunc    func(ctx context.Context, input *v1.CreateServiceAccountRequest) (*v1.CreateServiceAccountResponse, error)
+	listAccountsFunc     func(ctx context.Context, input *v1.ListServiceAccountsRequest) (*v1.ListServiceAccountsResponse, error)
+	deleteAccountFunc    func(ctx context.Context, input *v1.DeleteServiceAccountRequest) (*v1.DeleteServiceAccountResponse, error)
+	createAPIKeyFunc     func(ctx context.Context, input *v1.CreateAPIKeyRequest, prefix, hash string) (*v1.APIKey, error)
+	listAPIKeysFunc      func(ctx context.Context, input *v1.ListAPIKeysRequest) (*v1.ListAPIKeysResponse, error)
+	revokeAPIKeyFunc     func(ctx context.Context, input *v1.RevokeAPIKeyRequest) (*v1.RevokeAPIKeyResponse, error)
+	apiKeyFunc           func(ctx context.Context, prefix string) (*auth.StoredAPIKey, error)
+	queueGrantsFunc      func(ctx context.Context, subject, queueID string) (*rbac.Grants, error)
+	userPasswordFunc     func(ctx context.Context, email string) (string, error)
+	syncUserRolesFunc    func(ctx context.Context, email string, roles []string) error
+	scimUsersFunc        func(ctx context.Context) ([]scim.User, error)
+	scimUserFunc         func(ctx context.Context, id string) (*scim.User, error)
+	createSCIMUserFunc   func(ctx context.Context, user scim.User) (*scim.User, error)
+	updateSCIMUserFunc   func(ctx context.Context, user scim.User) (*scim.User, error)
+	deleteSCIMUserFunc   func(ctx context.Context, id string) error
+	scimGroupsFunc       func(ctx context.Context) ([]scim.Group, error)
+	scimGroupFunc        func(ctx context.Context, id string) (*scim.Group, error)
+	createSCIMGroupFunc  func(ctx context.Context, group scim.Group) (*scim.Group, error)
+	updateSCIMGroupFunc  func(ctx context.Context, group scim.Group) (*scim.Group, error)
+	deleteSCIMGroupFunc  func(ctx context.Context, id string) error
+	denyTokenFunc        func(ctx context.Context, hash string, expiresAt time.Time) error
+	tokenDeniedFunc      func(ctx context.Context, hash string) (bool, error)
+	deleteExpiredFunc    func(ctx context.Context, before time.Time) (int64, error)
+	revokeSubjectFunc    func(ctx context.Context, subject string, issuedBefore, expiresAt time.Time) error
+	subjectRevokedFunc   func(ctx context.Context, subject string) (time.Time, error)
+	createResetCodeFunc  func(ctx context.Context, email, hash string, expiresAt time.Time) error
+	resetCodeFunc        func(ctx context.Context, email, hash string, now time.Time) (time.Time, error)
+	resetPasswordFunc    func(ctx context.Context, email, hash, passwordHash string, now time.Time) error
+	verifyCodeSentFunc   func(ctx context.Context, email string) (time.Time, error)
+	createVerifyCodeFunc func(ctx context.Context, email, hash string, now, expiresAt time.Time) error
+	verifyEmailFunc      func(ctx context.Context, email, hash string, now time.Time) error
+	emailVerifiedFunc    func(ctx context.Context, email string) (bool, error)
+	createSessionFunc    func(ctx context.Context, session auth.Session) error
+	sessionsFunc         func(ctx context.Context, subject string, now time.Time) ([]auth.Session, error)
+	revokeSessionFunc    func(ctx context.Context, subject, id string, now time.Time) (*auth.Session, error)
+	revokeSessionsFunc   func(ctx context.Context, subject string, now time.Time) (int64, error)
+	accountFunc          func(ctx context.Context, email string) (*v1.AccountProfile, error)
+	updateAccountFunc    func(ctx context.Context, email string, input *v1.UpdateAccountRequest) (*v1.AccountProfile, error)
+	updatePasswordFunc   func(ctx context.Context, email, passwordHash string, now time.Time) error
+	queuePermissionsFunc func(ctx context.Context, queueID string) ([]*v1.QueuePermission, error)
+	setPermissionFunc    func(ctx context.Context, permission *v1.QueuePermission) (*v1.QueuePermission, error)
+	deletePermissionFunc func(ctx context.Context, queueID, role string) error
+	effectivePermsFunc   func(ctx context.Context, subject, queueID string) (*v1.EffectivePermissions, error)
+	rolesFunc            func(ctx context.Context) ([]*v1.Role, error)
+	createRoleFunc       func(ctx context.Context, name string) (*v1.Role, error)
+	deleteRoleFunc       func(ctx context.Context, name string) error
+	assignRoleFunc       func(ctx context.Context, email, role string) error
+	unassignRoleFunc     func(ctx context.Context, email, role string) error
+	applyTemplateFunc    func(ctx context.Context, role string, template *rbac.Template) error
+	propsVersion         uint64
 }
 
 func (m *mockStorage) CreateQueue(ctx context.Context, input *v1.CreateQueueRequest) (*v1.CreateQueueResponse, error) {
@@ -25,6 +107,10 @@ func (m *mockStorage) DescribeQueue(ctx context.Context, input *v1.DescribeQueue
 	return m.describeQueueFunc(ctx, input)
 }
 
+func (m *mockStorage) UpdateQueue(ctx context.Context, input *v1.UpdateQueueRequest) (*v1.UpdateQueueResponse, error) {
+	return m.updateQueueFunc(ctx, input)
+}
+
 func (m *mockStorage) ListQueues(ctx context.Context, input *v1.ListQueuesRequest) (*v1.ListQueuesResponse, error) {
 	return m.listQueuesFunc(ctx, input)
 }
@@ -48,3 +134,313 @@ func (m *mockStorage) Receive(ctx context.Context, input *v1.ReceiveRequest) (*v
 func (m *mockStorage) Delete(ctx context.Context, input *v1.DeleteRequest) (*v1.DeleteResponse, error) {
 	return m.deleteFunc(ctx, input)
 }
+
+func (m *mockStorage) ChangeVisibility(ctx context.Context, input *v1.ChangeVisibilityRequest) (*v1.ChangeVisibilityResponse, error) {
+	return m.changeVisibilityFunc(ctx, input)
+}
+
+func (m *mockStorage) RetriesDistribution(ctx context.Context, queueID string) (map[uint32]uint64, error) {
+	return m.retriesFunc(ctx, queueID)
+}
+
+func (m *mockStorage) QueueStats(ctx context.Context, queueID string) (*v1.QueueStatsResponse, error) {
+	return m.queueStatsFunc(ctx, queueID)
+}
+
+func (m *mockStorage) TransferQueue(ctx context.Context, input *v1.TransferQueueRequest) (*v1.TransferQueueResponse, error) {
+	return m.transferQueueFunc(ctx, input)
+}
+
+func (m *mockStorage) AcceptQueueTransfer(ctx context.Context, input *v1.AcceptQueueTransferRequest) (*v1.AcceptQueueTransferResponse, error) {
+	return m.acceptTransferFunc(ctx, input)
+}
+
+func (m *mockStorage) CancelQueueTransfer(ctx context.Context, input *v1.CancelQueueTransferRequest) (*v1.QueueTransfer, error) {
+	return m.cancelTransferFunc(ctx, input)
+}
+
+func (m *mockStorage) SearchMessages(ctx context.Context, input *v1.SearchMessagesRequest) (*v1.SearchMessagesResponse, error) {
+	return m.searchMessagesFunc(ctx, input)
+}
+
+func (m *mockStorage) PeekMessages(ctx context.Context, input *v1.PeekMessagesRequest) (*v1.PeekMessagesResponse, error) {
+	return m.peekMessagesFunc(ctx, input)
+}
+
+func (m *mockStorage) GetMessage(ctx context.Context, input *v1.GetMessageRequest) (*v1.GetMessageResponse, error) {
+	return m.getMessageFunc(ctx, input)
+}
+
+func (m *mockStorage) ListMessageEvents(ctx context.Context, input *v1.ListMessageEventsRequest) (*v1.ListMessageEventsResponse, error) {
+	return m.messageEventsFunc(ctx, input)
+}
+
+func (m *mockStorage) ExtendVisibilityBatch(ctx context.Context, input *v1.ExtendVisibilityBatchRequest) (*v1.ExtendVisibilityBatchResponse, error) {
+	return m.extendVisibilityFunc(ctx, input)
+}
+
+func (m *mockStorage) Transact(ctx context.Context, input *v1.TransactRequest) (*v1.TransactResponse, error) {
+	return m.transactFunc(ctx, input)
+}
+
+func (m *mockStorage) ListArchivedMessages(ctx context.Context, input *v1.ListArchivedMessagesRequest) (*v1.ListArchivedMessagesResponse, error) {
+	return m.listArchivedFunc(ctx, input)
+}
+
+func (m *mockStorage) RestoreArchivedMessages(ctx context.Context, input *v1.RestoreArchivedMessagesRequest) (*v1.RestoreArchivedMessagesResponse, error) {
+	return m.restoreArchivedFunc(ctx, input)
+}
+
+func (m *mockStorage) GetJob(ctx context.Context, input *v1.GetJobRequest) (*v1.GetJobResponse, error) {
+	return m.getJobFunc(ctx, input)
+}
+
+func (m *mockStorage) ListJobs(ctx context.Context, input *v1.ListJobsRequest) (*v1.ListJobsResponse, error) {
+	return m.listJobsFunc(ctx, input)
+}
+
+func (m *mockStorage) CancelJob(ctx context.Context, input *v1.CancelJobRequest) (*v1.CancelJobResponse, error) {
+	return m.cancelJobFunc(ctx, input)
+}
+
+func (m *mockStorage) RunMaintenance(ctx context.Context, input *v1.RunMaintenanceRequest) (*v1.RunMaintenanceResponse, error) {
+	return m.runMaintenanceFunc(ctx, input)
+}
+
+func (m *mockStorage) SetQueueState(ctx context.Context, input *v1.SetQueueStateRequest) (*v1.SetQueueStateResponse, error) {
+	return m.setQueueStateFunc(ctx, input)
+}
+
+func (m *mockStorage) SearchEntities(ctx context.Context, input *v1.SearchRequest) (*v1.SearchResponse, error) {
+	return m.searchEntitiesFunc(ctx, input)
+}
+
+func (m *mockStorage) AppendAuditEvent(ctx context.Context, event *v1.AuditEvent) error {
+	return m.appendAuditFunc(ctx, event)
+}
+
+func (m *mockStorage) ListAuditEvents(ctx context.Context, input *v1.ListAuditEventsRequest) (*v1.ListAuditEventsResponse, error) {
+	return m.listAuditFunc(ctx, input)
+}
+
+func (m *mockStorage) CreateAlertRule(ctx context.Context, input *v1.CreateAlertRuleRequest) (*v1.CreateAlertRuleResponse, error) {
+	return m.createAlertRuleFunc(ctx, input)
+}
+
+func (m *mockStorage) ListAlertRules(ctx context.Context, input *v1.ListAlertRulesRequest) (*v1.ListAlertRulesResponse, error) {
+	return m.listAlertRulesFunc(ctx, input)
+}
+
+func (m *mockStorage) UpdateAlertRule(ctx context.Context, input *v1.UpdateAlertRuleRequest) (*v1.UpdateAlertRuleResponse, error) {
+	return m.updateAlertRuleFunc(ctx, input)
+}
+
+func (m *mockStorage) DeleteAlertRule(ctx context.Context, input *v1.DeleteAlertRuleRequest) (*v1.DeleteAlertRuleResponse, error) {
+	return m.deleteAlertRuleFunc(ctx, input)
+}
+
+func (m *mockStorage) CreateServiceAccount(ctx context.Context, input *v1.CreateServiceAccountRequest) (*v1.CreateServiceAccountResponse, error) {
+	return m.createAccountFunc(ctx, input)
+}
+
+func (m *mockStorage) ListServiceAccounts(ctx context.Context, input *v1.ListServiceAccountsRequest) (*v1.ListServiceAccountsResponse, error) {
+	return m.listAccountsFunc(ctx, input)
+}
+
+func (m *mockStorage) DeleteServiceAccount(ctx context.Context, input *v1.DeleteServiceAccountRequest) (*v1.DeleteServiceAccountResponse, error) {
+	return m.deleteAccountFunc(ctx, input)
+}
+
+func (m *mockStorage) CreateAPIKey(ctx context.Context, input *v1.CreateAPIKeyRequest, prefix, hash string) (*v1.APIKey, error) {
+	return m.createAPIKeyFunc(ctx, input, prefix, hash)
+}
+
+func (m *mockStorage) ListAPIKeys(ctx context.Context, input *v1.ListAPIKeysRequest) (*v1.ListAPIKeysResponse, error) {
+	return m.listAPIKeysFunc(ctx, input)
+}
+
+func (m *mockStorage) RevokeAPIKey(ctx context.Context, input *v1.RevokeAPIKeyRequest) (*v1.RevokeAPIKeyResponse, error) {
+	return m.revokeAPIKeyFunc(ctx, input)
+}
+
+func (m *mockStorage) APIKey(ctx context.Context, prefix string) (*auth.StoredAPIKey, error) {
+	return m.apiKeyFunc(ctx, prefix)
+}
+
+func (m *mockStorage) UserPasswordHash(ctx context.Context, email string) (string, error) {
+	return m.userPasswordFunc(ctx, email)
+}
+
+func (m *mockStorage) SyncUserRoles(ctx context.Context, email string, roles []string) error {
+	return m.syncUserRolesFunc(ctx, email, roles)
+}
+
+func (m *mockStorage) SCIMUsers(ctx context.Context) ([]scim.User, error) {
+	return m.scimUsersFunc(ctx)
+}
+
+func (m *mockStorage) SCIMUser(ctx context.Context, id string) (*scim.User, error) {
+	return m.scimUserFunc(ctx, id)
+}
+
+func (m *mockStorage) CreateSCIMUser(ctx context.Context, user scim.User) (*scim.User, error) {
+	return m.createSCIMUserFunc(ctx, user)
+}
+
+func (m *mockStorage) UpdateSCIMUser(ctx context.Context, user scim.User) (*scim.User, error) {
+	return m.updateSCIMUserFunc(ctx, user)
+}
+
+func (m *mockStorage) DeleteSCIMUser(ctx context.Context, id string) error {
+	return m.deleteSCIMUserFunc(ctx, id)
+}
+
+func (m *mockStorage) SCIMGroups(ctx context.Context) ([]scim.Group, error) {
+	return m.scimGroupsFunc(ctx)
+}
+
+func (m *mockStorage) SCIMGroup(ctx context.Context, id string) (*scim.Group, error) {
+	return m.scimGroupFunc(ctx, id)
+}
+
+func (m *mockStorage) CreateSCIMGroup(ctx context.Context, group scim.Group) (*scim.Group, error) {
+	return m.createSCIMGroupFunc(ctx, group)
+}
+
+func (m *mockStorage) UpdateSCIMGroup(ctx context.Context, group scim.Group) (*scim.Group, error) {
+	return m.updateSCIMGroupFunc(ctx, group)
+}
+
+func (m *mockStorage) DeleteSCIMGroup(ctx context.Context, id string) error {
+	return m.deleteSCIMGroupFunc(ctx, id)
+}
+
+func (m *mockStorage) DenyAccessToken(ctx context.Context, hash string, expiresAt time.Time) error {
+	return m.denyTokenFunc(ctx, hash, expiresAt)
+}
+
+func (m *mockStorage) AccessTokenDenied(ctx context.Context, hash string) (bool, error) {
+	return m.tokenDeniedFunc(ctx, hash)
+}
+
+func (m *mockStorage) DeleteExpiredAccessTokens(ctx context.Context, before time.Time) (int64, error) {
+	return m.deleteExpiredFunc(ctx, before)
+}
+
+func (m *mockStorage) RevokeSubjectTokens(ctx context.Context, subject string, issuedBefore, expiresAt time.Time) error {
+	return m.revokeSubjectFunc(ctx, subject, issuedBefore, expiresAt)
+}
+
+func (m *mockStorage) SubjectTokensRevokedAt(ctx context.Context, subject string) (time.Time, error) {
+	return m.subjectRevokedFunc(ctx, subject)
+}
+
+func (m *mockStorage) CreatePasswordResetCode(ctx context.Context, email, hash string, expiresAt time.Time) error {
+	return m.createResetCodeFunc(ctx, email, hash, expiresAt)
+}
+
+func (m *mockStorage) PasswordResetCodeExpiresAt(ctx context.Context, email, hash string, now time.Time) (time.Time, error) {
+	return m.resetCodeFunc(ctx, email, hash, now)
+}
+
+func (m *mockStorage) ResetPassword(ctx context.Context, email, hash, passwordHash string, now time.Time) error {
+	return m.resetPasswordFunc(ctx, email, hash, passwordHash, now)
+}
+
+func (m *mockStorage) EmailVerificationCodeSentAt(ctx context.Context, email string) (time.Time, error) {
+	return m.verifyCodeSentFunc(ctx, email)
+}
+
+func (m *mockStorage) CreateEmailVerificationCode(ctx context.Context, email, hash string, now, expiresAt time.Time) error {
+	return m.createVerifyCodeFunc(ctx, email, hash, now, expiresAt)
+}
+
+func (m *mockStorage) VerifyEmail(ctx context.Context, email, hash string, now time.Time) error {
+	return m.verifyEmailFunc(ctx, email, hash, now)
+}
+
+func (m *mockStorage) CreateSession(ctx context.Context, session auth.Session) error {
+	return m.createSessionFunc(ctx, session)
+}
+
+func (m *mockStorage) Sessions(ctx context.Context, subject string, now time.Time) ([]auth.Session, error) {
+	return m.sessionsFunc(ctx, subject, now)
+}
+
+func (m *mockStorage) RevokeSession(ctx context.Context, subject, id string, now time.Time) (*auth.Session, error) {
+	return m.revokeSessionFunc(ctx, subject, id, now)
+}
+
+func (m *mockStorage) RevokeSessions(ctx context.Context, subject string, now time.Time) (int64, error) {
+	return m.revokeSessionsFunc(ctx, subject, now)
+}
+
+func (m *mockStorage) Account(ctx context.Context, email string) (*v1.AccountProfile, error) {
+	return m.accountFunc(ctx, email)
+}
+
+func (m *mockStorage) UpdateAccount(ctx context.Context, email string, input *v1.UpdateAccountRequest) (*v1.AccountProfile, error) {
+	return m.updateAccountFunc(ctx, email, input)
+}
+
+func (m *mockStorage) UpdateUserPassword(ctx context.Context, email, passwordHash string, now time.Time) error {
+	return m.updatePasswordFunc(ctx, email, passwordHash, now)
+}
+
+func (m *mockStorage) UserEmailVerified(ctx context.Context, email string) (bool, error) {
+	return m.emailVerifiedFunc(ctx, email)
+}
+
+func (m *mockStorage) QueuePermissions(ctx context.Context, queueID string) ([]*v1.QueuePermission, error) {
+	return m.queuePermissionsFunc(ctx, queueID)
+}
+
+func (m *mockStorage) SetQueuePermission(ctx context.Context, permission *v1.QueuePermission) (*v1.QueuePermission, error) {
+	return m.setPermissionFunc(ctx, permission)
+}
+
+func (m *mockStorage) DeleteQueuePermission(ctx context.Context, queueID, role string) error {
+	return m.deletePermissionFunc(ctx, queueID, role)
+}
+
+func (m *mockStorage) EffectivePermissions(ctx context.Context, subject, queueID string) (*v1.EffectivePermissions, error) {
+	return m.effectivePermsFunc(ctx, subject, queueID)
+}
+
+func (m *mockStorage) Roles(ctx context.Context) ([]*v1.Role, error) {
+	return m.rolesFunc(ctx)
+}
+
+func (m *mockStorage) CreateRole(ctx context.Context, name string) (*v1.Role, error) {
+	return m.createRoleFunc(ctx, name)
+}
+
+func (m *mockStorage) DeleteRole(ctx context.Context, name string) error {
+	return m.deleteRoleFunc(ctx, name)
+}
+
+func (m *mockStorage) AssignRole(ctx context.Context, email, role string) error {
+	return m.assignRoleFunc(ctx, email, role)
+}
+
+func (m *mockStorage) UnassignRole(ctx context.Context, email, role string) error {
+	return m.unassignRoleFunc(ctx, email, role)
+}
+
+func (m *mockStorage) ApplyTemplate(ctx context.Context, role string, template *rbac.Template) error {
+	return m.applyTemplateFunc(ctx, role, template)
+}
+
+func (m *mockStorage) QueueGrants(ctx context.Context, subject, queueID string) (*rbac.Grants, error) {
+	return m.queueGrantsFunc(ctx, subject, queueID)
+}
+
+func (m *mockStorage) QueuePropsVersion() uint64 { return m.propsVersion }
+
+type mockQuerier struct {
+	queryFunc func(ctx context.Context, q telemetry.Query) ([]telemetry.Metric, error)
+}
+
+func (m *mockQuerier) Query(ctx context.Context, q telemetry.Query) ([]telemetry.Metric, error) {
+	return m.queryFunc(ctx, q)
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+// setQueueState moves the queue to another lifecycle state and records it to the audit log.
+func (s *PlainQ) setQueueState(ctx context.Context, input *v1.SetQueueStateRequest) (*v1.SetQueueStateResponse, error) {
+	if err := validateQueueIDFromRequest(input); err != nil {
+		return nil, err
+	}
+
+	output, setErr := s.storage.SetQueueState(ctx, input)
+	if setErr != nil {
+		return nil, fmt.Errorf("set queue state (id: %q): %w", input.GetQueueId(), setErr)
+	}
+
+	s.audit.Info("Queue state has been changed",
+		slog.String("queue_id", input.GetQueueId()),
+		slog.String("state", output.GetState().String()),
+		slog.Uint64("version", output.GetVersion()),
+	)
+
+	return output, nil
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// queueStats collects the queue statistics from the storage and the telemetry counters.
+func (s *PlainQ) queueStats(ctx context.Context, queueID string) (*v1.QueueStatsResponse, error) {
+	output, statsErr := s.storage.QueueStats(ctx, queueID)
+	if statsErr != nil {
+		return nil, fmt.Errorf("get queue stats: %w", statsErr)
+	}
+
+	output.MessagesSentTotal = s.observer.MessagesSent(queueID).Get()
+	output.MessagesReceivedTotal = s.observer.MessagesReceived(queueID).Get()
+	output.MessagesDeletedTotal = s.observer.MessagesDeleted(queueID).Get()
+	output.ReceiveRequestsTotal = s.observer.ReceiveRequests(queueID).Get()
+	output.EmptyReceivesTotal = s.observer.EmptyReceives(queueID).Get()
+	output.CountersSince = timestamppb.New(s.started)
+	output.CollectedAt = timestamppb.Now()
+
+	return output, nil
+}
@@ -0,0 +1,163 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Storage) Account(ctx context.Context, email string) (*v1.AccountProfile, error) {
+	account, accountErr := selectAccount(ctx, s.db, email)
+	if accountErr != nil {
+		return nil, accountErr
+	}
+
+	roles, rolesErr := s.subjectRoles(ctx, account.GetEmail())
+	if rolesErr != nil {
+		return nil, rolesErr
+	}
+
+	account.Roles = roles
+
+	return account, nil
+}
+
+func (s *Storage) UpdateAccount(ctx context.Context, email string, input *v1.UpdateAccountRequest) (_ *v1.AccountProfile, sErr error) {
+	tx, txErr := s.beginTx(ctx, opUpdateAccount, false)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	var (
+		userID string
+		local  bool
+		now    = time.Now().UTC()
+	)
+
+	if err := tx.QueryRowContext(ctx, querySelectAccountLocal, email).Scan(&userID, &local); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: user %q", errkit.ErrNotFound, email)
+		}
+
+		return nil, fmt.Errorf("select user %q: %w", email, err)
+	}
+
+	if input.Name != nil {
+		if _, err := tx.ExecContext(ctx, queryUpdateUserName, input.GetName(), now, userID); err != nil {
+			return nil, fmt.Errorf("update name of user %q: %w", email, err)
+		}
+	}
+
+	if input.Email != nil && input.GetEmail() != email {
+		if !local {
+			return nil, fmt.Errorf("%w: email of the user managed by the directory can't be changed", errkit.ErrInvalidArgument)
+		}
+
+		var taken bool
+
+		if err := tx.QueryRowContext(ctx, querySelectUserEmailTaken, input.GetEmail(), userID).Scan(&taken); err != nil {
+			return nil, fmt.Errorf("check user %q: %w", input.GetEmail(), err)
+		}
+
+		if taken {
+			return nil, fmt.Errorf("%w: user %q", errkit.ErrAlreadyExists, input.GetEmail())
+		}
+
+		if _, err := tx.ExecContext(ctx, queryUpdateUserEmail, input.GetEmail(), now, userID); err != nil {
+			return nil, fmt.Errorf("update email of user %q: %w", email, err)
+		}
+
+		// Codes sent to the previous email are no longer valid.
+		if _, err := tx.ExecContext(ctx, queryDeleteEmailVerificationCodes, userID); err != nil {
+			return nil, fmt.Errorf("delete email verification codes of user %q: %w", email, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, queryDeletePasswordResetCodes, userID); err != nil {
+			return nil, fmt.Errorf("delete password reset codes of user %q: %w", email, err)
+		}
+
+		email = input.GetEmail()
+	}
+
+	account, accountErr := selectAccount(ctx, tx, email)
+	if accountErr != nil {
+		return nil, accountErr
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	s.grants.invalidate()
+
+	roles, rolesErr := s.subjectRoles(ctx, account.GetEmail())
+	if rolesErr != nil {
+		return nil, rolesErr
+	}
+
+	account.Roles = roles
+
+	return account, nil
+}
+
+func (s *Storage) UpdateUserPassword(ctx context.Context, email, passwordHash string, now time.Time) error {
+	res, execErr := s.db.ExecContext(ctx, queryUpdateUserPasswordByEmail, passwordHash, now.UTC(), email)
+	if execErr != nil {
+		return fmt.Errorf("update password of user %q: %w", email, execErr)
+	}
+
+	rows, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return fmt.Errorf("update password of user %q: %w", email, rowsErr)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%w: user %q", errkit.ErrNotFound, email)
+	}
+
+	return nil
+}
+
+// selectAccount selects the profile of the active user with the email.
+func selectAccount(ctx context.Context, db interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}, email string,
+) (*v1.AccountProfile, error) {
+	var (
+		account   v1.AccountProfile
+		createdAt time.Time
+		updatedAt time.Time
+	)
+
+	if err := db.QueryRowContext(ctx, querySelectAccount, email).Scan(
+		&account.UserId,
+		&account.Email,
+		&account.Name,
+		&account.Verified,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: user %q", errkit.ErrNotFound, email)
+		}
+
+		return nil, fmt.Errorf("select user %q: %w", email, err)
+	}
+
+	account.CreatedAt = timestamppb.New(createdAt)
+	account.UpdatedAt = timestamppb.New(updatedAt)
+
+	return &account, nil
+}
@@ -0,0 +1,332 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/auth"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Storage) CreateServiceAccount(ctx context.Context, input *v1.CreateServiceAccountRequest) (_ *v1.CreateServiceAccountResponse, sErr error) {
+	now := time.Now().UTC()
+
+	account := v1.ServiceAccount{
+		AccountId:   idkit.XID(),
+		Name:        input.GetName(),
+		Description: input.GetDescription(),
+		Roles:       input.GetRoles(),
+		CreatedAt:   timestamppb.New(now),
+	}
+
+	tx, txErr := s.beginTx(ctx, opCreateServiceAccount, false)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	var exists bool
+
+	if err := tx.QueryRowContext(ctx, querySelectServiceAccountExists, account.Name).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("check service account %q: %w", account.Name, err)
+	}
+
+	if exists {
+		return nil, fmt.Errorf("%w: service account %q", errkit.ErrAlreadyExists, account.Name)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryInsertServiceAccount,
+		account.AccountId,
+		account.Name,
+		account.Description,
+		now,
+	); err != nil {
+		return nil, fmt.Errorf("insert service account %q: %w", account.Name, err)
+	}
+
+	for _, role := range account.Roles {
+		res, execErr := tx.ExecContext(ctx, queryInsertServiceAccountRole, account.AccountId, role)
+		if execErr != nil {
+			return nil, fmt.Errorf("grant role %q: %w", role, execErr)
+		}
+
+		rows, rowsErr := res.RowsAffected()
+		if rowsErr != nil {
+			return nil, fmt.Errorf("grant role %q: %w", role, rowsErr)
+		}
+
+		if rows == 0 {
+			return nil, fmt.Errorf("%w: unknown role %q", errkit.ErrInvalidArgument, role)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	s.grants.invalidate()
+
+	return &v1.CreateServiceAccountResponse{Account: &account}, nil
+}
+
+func (s *Storage) ListServiceAccounts(ctx context.Context, _ *v1.ListServiceAccountsRequest) (_ *v1.ListServiceAccountsResponse, sErr error) {
+	rows, queryErr := s.db.QueryContext(ctx, querySelectServiceAccounts)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select service accounts: %w", queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	var output v1.ListServiceAccountsResponse
+
+	for rows.Next() {
+		var (
+			account   v1.ServiceAccount
+			createdAt time.Time
+			roles     string
+		)
+
+		if err := rows.Scan(
+			&account.AccountId,
+			&account.Name,
+			&account.Description,
+			&createdAt,
+			&roles,
+		); err != nil {
+			return nil, fmt.Errorf("scan service account: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(roles), &account.Roles); err != nil {
+			return nil, fmt.Errorf("unmarshal roles of service account %q: %w", account.Name, err)
+		}
+
+		account.CreatedAt = timestamppb.New(createdAt)
+
+		output.Accounts = append(output.Accounts, &account)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate service accounts: %w", err)
+	}
+
+	return &output, nil
+}
+
+func (s *Storage) DeleteServiceAccount(ctx context.Context, input *v1.DeleteServiceAccountRequest) (_ *v1.DeleteServiceAccountResponse, sErr error) {
+	accountID := input.GetAccountId()
+
+	tx, txErr := s.beginTx(ctx, opDeleteServiceAccount, false)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, queryDeleteServiceAccountKeys, accountID); err != nil {
+		return nil, fmt.Errorf("delete API keys of service account %q: %w", accountID, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeleteServiceAccountRoles, accountID); err != nil {
+		return nil, fmt.Errorf("delete roles of service account %q: %w", accountID, err)
+	}
+
+	res, execErr := tx.ExecContext(ctx, queryDeleteServiceAccount, accountID)
+	if execErr != nil {
+		return nil, fmt.Errorf("delete service account %q: %w", accountID, execErr)
+	}
+
+	rows, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return nil, fmt.Errorf("delete service account %q: %w", accountID, rowsErr)
+	}
+
+	if rows == 0 {
+		return nil, fmt.Errorf("%w: service account %q", errkit.ErrNotFound, accountID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	s.grants.invalidate()
+
+	return &v1.DeleteServiceAccountResponse{}, nil
+}
+
+func (s *Storage) CreateAPIKey(ctx context.Context, input *v1.CreateAPIKeyRequest, prefix, hash string) (*v1.APIKey, error) {
+	now := time.Now().UTC()
+
+	key := v1.APIKey{
+		KeyId:     idkit.XID(),
+		AccountId: input.GetAccountId(),
+		Name:      input.GetName(),
+		Prefix:    prefix,
+		CreatedAt: timestamppb.New(now),
+	}
+
+	var expiresAt *time.Time
+
+	if ttl := input.GetTtlSeconds(); ttl > 0 {
+		t := now.Add(time.Duration(ttl) * time.Second)
+		expiresAt = &t
+		key.ExpiresAt = timestamppb.New(t)
+	}
+
+	res, execErr := s.db.ExecContext(ctx, queryInsertAPIKey,
+		key.KeyId,
+		key.AccountId,
+		key.Name,
+		key.Prefix,
+		hash,
+		now,
+		expiresAt,
+	)
+	if execErr != nil {
+		return nil, fmt.Errorf("insert API key: %w", execErr)
+	}
+
+	rows, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return nil, fmt.Errorf("insert API key: %w", rowsErr)
+	}
+
+	if rows == 0 {
+		return nil, fmt.Errorf("%w: service account %q", errkit.ErrNotFound, key.AccountId)
+	}
+
+	return &key, nil
+}
+
+func (s *Storage) ListAPIKeys(ctx context.Context, input *v1.ListAPIKeysRequest) (_ *v1.ListAPIKeysResponse, sErr error) {
+	rows, queryErr := s.db.QueryContext(ctx, querySelectAPIKeys, input.GetAccountId())
+	if queryErr != nil {
+		return nil, fmt.Errorf("select API keys: %w", queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	var output v1.ListAPIKeysResponse
+
+	for rows.Next() {
+		var (
+			key       v1.APIKey
+			createdAt time.Time
+			expiresAt sql.NullTime
+			revokedAt sql.NullTime
+		)
+
+		if err := rows.Scan(
+			&key.KeyId,
+			&key.AccountId,
+			&key.Name,
+			&key.Prefix,
+			&createdAt,
+			&expiresAt,
+			&revokedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan API key: %w", err)
+		}
+
+		key.CreatedAt = timestamppb.New(createdAt)
+		key.ExpiresAt = nullTimestamp(expiresAt)
+		key.RevokedAt = nullTimestamp(revokedAt)
+
+		output.Keys = append(output.Keys, &key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate API keys: %w", err)
+	}
+
+	return &output, nil
+}
+
+func (s *Storage) RevokeAPIKey(ctx context.Context, input *v1.RevokeAPIKeyRequest) (*v1.RevokeAPIKeyResponse, error) {
+	var (
+		key       = v1.APIKey{KeyId: input.GetKeyId()}
+		createdAt time.Time
+		expiresAt sql.NullTime
+		revokedAt sql.NullTime
+	)
+
+	if err := s.db.QueryRowContext(ctx, queryRevokeAPIKey, time.Now().UTC(), key.KeyId).Scan(
+		&key.AccountId,
+		&key.Name,
+		&key.Prefix,
+		&createdAt,
+		&expiresAt,
+		&revokedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: API key %q", errkit.ErrNotFound, key.KeyId)
+		}
+
+		return nil, fmt.Errorf("revoke API key %q: %w", key.KeyId, err)
+	}
+
+	key.CreatedAt = timestamppb.New(createdAt)
+	key.ExpiresAt = nullTimestamp(expiresAt)
+	key.RevokedAt = nullTimestamp(revokedAt)
+
+	return &v1.RevokeAPIKeyResponse{Key: &key}, nil
+}
+
+func (s *Storage) APIKey(ctx context.Context, prefix string) (*auth.StoredAPIKey, error) {
+	var (
+		key       auth.StoredAPIKey
+		expiresAt sql.NullTime
+		revokedAt sql.NullTime
+	)
+
+	if err := s.db.QueryRowContext(ctx, querySelectAPIKeyByPrefix, prefix).Scan(
+		&key.KeyID,
+		&key.Account,
+		&key.Hash,
+		&expiresAt,
+		&revokedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: API key %q", errkit.ErrNotFound, prefix)
+		}
+
+		return nil, fmt.Errorf("select API key %q: %w", prefix, err)
+	}
+
+	key.ExpiresAt = expiresAt.Time
+	key.Revoked = revokedAt.Valid
+
+	return &key, nil
+}
+
+// nullTimestamp returns the timestamp of the valid time, or nil.
+func nullTimestamp(t sql.NullTime) *timestamppb.Timestamp {
+	if !t.Valid {
+		return nil
+	}
+
+	return timestamppb.New(t.Time)
+}
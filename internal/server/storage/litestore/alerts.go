@@ -0,0 +1,160 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Storage) CreateAlertRule(ctx context.Context, input *v1.CreateAlertRuleRequest) (*v1.CreateAlertRuleResponse, error) {
+	now := time.Now().UTC()
+
+	rule := v1.AlertRule{
+		RuleId:     idkit.XID(),
+		Name:       input.GetRule().GetName(),
+		Metric:     input.GetRule().GetMetric(),
+		QueueId:    input.GetRule().GetQueueId(),
+		Operator:   input.GetRule().GetOperator(),
+		Threshold:  input.GetRule().GetThreshold(),
+		ForSeconds: input.GetRule().GetForSeconds(),
+		WebhookUrl: input.GetRule().GetWebhookUrl(),
+		Email:      input.GetRule().GetEmail(),
+		CreatedAt:  timestamppb.New(now),
+		UpdatedAt:  timestamppb.New(now),
+	}
+
+	if _, err := s.db.ExecContext(ctx, queryInsertAlertRule,
+		rule.RuleId,
+		rule.Name,
+		rule.Metric,
+		rule.QueueId,
+		rule.Operator,
+		rule.Threshold,
+		rule.ForSeconds,
+		rule.WebhookUrl,
+		rule.Email,
+		now,
+		now,
+	); err != nil {
+		return nil, fmt.Errorf("insert alert rule %q: %w", rule.Name, err)
+	}
+
+	return &v1.CreateAlertRuleResponse{Rule: &rule}, nil
+}
+
+func (s *Storage) ListAlertRules(ctx context.Context, _ *v1.ListAlertRulesRequest) (_ *v1.ListAlertRulesResponse, sErr error) {
+	rows, queryErr := s.db.QueryContext(ctx, querySelectAlertRules)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select alert rules: %w", queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	var output v1.ListAlertRulesResponse
+
+	for rows.Next() {
+		var (
+			rule      v1.AlertRule
+			createdAt time.Time
+			updatedAt time.Time
+		)
+
+		if err := rows.Scan(
+			&rule.RuleId,
+			&rule.Name,
+			&rule.Metric,
+			&rule.QueueId,
+			&rule.Operator,
+			&rule.Threshold,
+			&rule.ForSeconds,
+			&rule.WebhookUrl,
+			&rule.Email,
+			&createdAt,
+			&updatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan alert rule: %w", err)
+		}
+
+		rule.CreatedAt = timestamppb.New(createdAt)
+		rule.UpdatedAt = timestamppb.New(updatedAt)
+
+		output.Rules = append(output.Rules, &rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate alert rules: %w", err)
+	}
+
+	return &output, nil
+}
+
+func (s *Storage) UpdateAlertRule(ctx context.Context, input *v1.UpdateAlertRuleRequest) (*v1.UpdateAlertRuleResponse, error) {
+	rule := input.GetRule()
+	now := time.Now().UTC()
+
+	var createdAt time.Time
+
+	if err := s.db.QueryRowContext(ctx, queryUpdateAlertRule,
+		rule.GetName(),
+		rule.GetMetric(),
+		rule.GetQueueId(),
+		rule.GetOperator(),
+		rule.GetThreshold(),
+		rule.GetForSeconds(),
+		rule.GetWebhookUrl(),
+		rule.GetEmail(),
+		now,
+		rule.GetRuleId(),
+	).Scan(&createdAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: alert rule %q", errkit.ErrNotFound, rule.GetRuleId())
+		}
+
+		return nil, fmt.Errorf("update alert rule %q: %w", rule.GetRuleId(), err)
+	}
+
+	updated := v1.AlertRule{
+		RuleId:     rule.GetRuleId(),
+		Name:       rule.GetName(),
+		Metric:     rule.GetMetric(),
+		QueueId:    rule.GetQueueId(),
+		Operator:   rule.GetOperator(),
+		Threshold:  rule.GetThreshold(),
+		ForSeconds: rule.GetForSeconds(),
+		WebhookUrl: rule.GetWebhookUrl(),
+		Email:      rule.GetEmail(),
+		CreatedAt:  timestamppb.New(createdAt),
+		UpdatedAt:  timestamppb.New(now),
+	}
+
+	return &v1.UpdateAlertRuleResponse{Rule: &updated}, nil
+}
+
+func (s *Storage) DeleteAlertRule(ctx context.Context, input *v1.DeleteAlertRuleRequest) (*v1.DeleteAlertRuleResponse, error) {
+	res, execErr := s.db.ExecContext(ctx, queryDeleteAlertRule, input.GetRuleId())
+	if execErr != nil {
+		return nil, fmt.Errorf("delete alert rule %q: %w", input.GetRuleId(), execErr)
+	}
+
+	rows, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return nil, fmt.Errorf("delete alert rule %q: %w", input.GetRuleId(), rowsErr)
+	}
+
+	if rows == 0 {
+		return nil, fmt.Errorf("%w: alert rule %q", errkit.ErrNotFound, input.GetRuleId())
+	}
+
+	return &v1.DeleteAlertRuleResponse{}, nil
+}
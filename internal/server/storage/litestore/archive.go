@@ -0,0 +1,213 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Reasons of the eviction of archived messages.
+const (
+	archiveReasonRetention = "retention"
+	archiveReasonAttempts  = "max_receive_attempts"
+	archiveReasonQuota     = "quota"
+)
+
+const (
+	// defaultArchiveLimit represents the default number of archived messages returned by the listing.
+	defaultArchiveLimit = 20
+
+	// maxArchiveLimit represents the maximum number of archived messages returned by the listing.
+	maxArchiveLimit = 100
+)
+
+func (s *Storage) ListArchivedMessages(ctx context.Context, input *v1.ListArchivedMessagesRequest) (_ *v1.ListArchivedMessagesResponse, sErr error) {
+	queueID := input.GetQueueId()
+
+	if _, ok := s.cache.getByID(queueID); !ok {
+		if _, err := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID}); err != nil {
+			return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, err)
+		}
+	}
+
+	limit := input.GetLimit()
+
+	switch {
+	case limit == 0:
+		limit = defaultArchiveLimit
+
+	case limit > maxArchiveLimit:
+		return nil, fmt.Errorf("%w: archive limit should not exceed %d", errkit.ErrInvalidArgument, maxArchiveLimit)
+	}
+
+	// Message identifiers are ULIDs, so the cursor is the identifier of the last
+	// returned message, and the extra message tells whether there is the next page.
+	rows, queryErr := s.db.QueryContext(ctx, querySelectArchivedMessages(input.GetIncludeBody()), queueID, input.GetCursor(), limit+1)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select queue %q archived messages: %w", queueID, queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	output := v1.ListArchivedMessagesResponse{Messages: make([]*v1.ArchivedMessage, 0, limit)}
+
+	for rows.Next() {
+		var (
+			m          v1.ArchivedMessage
+			createdAt  string
+			archivedAt string
+		)
+
+		if err := rows.Scan(&m.Id, &m.Body, &m.Attempts, &m.Reason, &createdAt, &archivedAt); err != nil {
+			return nil, fmt.Errorf("scan archived message record: %w", err)
+		}
+
+		if len(output.Messages) == int(limit) {
+			output.NextCursor = output.Messages[len(output.Messages)-1].GetId()
+			break
+		}
+
+		m.SentAt = parseTimestamp(createdAt)
+		m.ArchivedAt = parseTimestamp(archivedAt)
+
+		output.Messages = append(output.Messages, &m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate archived message records: %w", err)
+	}
+
+	return &output, nil
+}
+
+func (s *Storage) RestoreArchivedMessages(ctx context.Context, input *v1.RestoreArchivedMessagesRequest) (*v1.RestoreArchivedMessagesResponse, error) {
+	return retryBusy(ctx, s.observer, opRestoreArchived, func() (*v1.RestoreArchivedMessagesResponse, error) {
+		return s.restoreArchivedMessages(ctx, input)
+	})
+}
+
+func (s *Storage) restoreArchivedMessages(ctx context.Context, input *v1.RestoreArchivedMessagesRequest) (_ *v1.RestoreArchivedMessagesResponse, sErr error) {
+	queueID := input.GetQueueId()
+
+	info, describeErr := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID})
+	if describeErr != nil {
+		return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, describeErr)
+	}
+
+	if err := checkQueueState(queueID, info.GetState(), operationSend); err != nil {
+		return nil, err
+	}
+
+	tx, txErr := s.beginTx(ctx, opRestoreArchived, false)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	tx.span.SetAttributes(attribute.String("queue_id", queueID))
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	var (
+		ids      = make([]string, 0, len(input.GetMessageIds()))
+		messages = make([]*v1.SendMessage, 0, len(input.GetMessageIds()))
+	)
+
+	for _, id := range input.GetMessageIds() {
+		var body []byte
+
+		if err := tx.QueryRowContext(ctx, querySelectArchivedMessageBody, queueID, id).Scan(&body); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+
+			return nil, fmt.Errorf("select archived message %q of queue %q: %w", id, queueID, err)
+		}
+
+		ids = append(ids, id)
+		messages = append(messages, &v1.SendMessage{Body: body})
+	}
+
+	// Restored messages take space of the queue as sent ones do.
+	if hasQuota(info) && len(messages) > 0 {
+		if _, err := s.applyQuota(ctx, tx.Tx, info, messages); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+
+	for i, id := range ids {
+		if _, err := tx.ExecContext(ctx, queryInsertMessages(queueID), id, messages[i].GetBody(), now); err != nil {
+			return nil, fmt.Errorf("restore message %q to queue %q: %w", id, queueID, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, queryDeleteArchivedMessage, queueID, id); err != nil {
+			return nil, fmt.Errorf("delete archived message %q of queue %q: %w", id, queueID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	s.observer.MessagesVisible(queueID).Add(uint64(len(ids)))
+
+	return &v1.RestoreArchivedMessagesResponse{Restored: ids}, nil
+}
+
+// archiveMessages moves messages of the queue which have been received the maximum
+// number of times or outlived the retention period to the archive.
+// Returns the number of archived messages.
+func archiveMessages(ctx context.Context, tx *sql.Tx, props QueueProps) (uint64, error) {
+	now := time.Now().UTC()
+	expiredAt := now.Add(-time.Duration(props.RetentionPeriodSeconds) * time.Second).Format(time.DateTime)
+
+	if _, err := tx.ExecContext(ctx, queryArchiveEvictedMessages(props.ID),
+		props.ID,
+		props.MaxReceiveAttempts,
+		expiredAt,
+		now.Format(time.DateTime),
+	); err != nil {
+		return 0, fmt.Errorf("archive messages: %w", err)
+	}
+
+	r, execErr := tx.ExecContext(ctx, queryDeleteEvictedMessages(props.ID), props.MaxReceiveAttempts, expiredAt)
+	if execErr != nil {
+		return 0, fmt.Errorf("delete archived messages: %w", execErr)
+	}
+
+	rows, rowsErr := r.RowsAffected()
+	if rowsErr != nil {
+		return 0, fmt.Errorf("get affected rows: %w", rowsErr)
+	}
+
+	return uint64(rows), nil
+}
+
+// archiveMessage copies the message of the queue to the archive with the reason of its eviction.
+func archiveMessage(ctx context.Context, tx *sql.Tx, queueID, msgID, reason string) error {
+	if _, err := tx.ExecContext(ctx, queryArchiveMessage(queueID),
+		queueID,
+		reason,
+		time.Now().UTC().Format(time.DateTime),
+		msgID,
+	); err != nil {
+		return fmt.Errorf("archive message %q: %w", msgID, err)
+	}
+
+	return nil
+}
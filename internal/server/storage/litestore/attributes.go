@@ -0,0 +1,411 @@
+package litestore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+const (
+	// maxMessageAttributes represents the maximum number of attributes of the message.
+	maxMessageAttributes = 16
+
+	// maxAttributeNameLength represents the maximum length of the attribute name.
+	maxAttributeNameLength = 128
+
+	// maxAttributeFilterLength represents the maximum length of the filter expression,
+	// which bounds the number of its conditions.
+	maxAttributeFilterLength = 1024
+)
+
+// validateAttributes returns an error when attributes of any message
+// exceed their number or have names the filter can't refer to.
+func validateAttributes(messages []*v1.SendMessage) error {
+	for i, m := range messages {
+		if len(m.GetAttributes()) > maxMessageAttributes {
+			return fmt.Errorf("%w: message %d has %d attributes, at most %d are allowed",
+				errkit.ErrInvalidArgument, i, len(m.GetAttributes()), maxMessageAttributes,
+			)
+		}
+
+		for name := range m.GetAttributes() {
+			if !isAttributeName(name) {
+				return fmt.Errorf("%w: message %d has invalid attribute name %q", errkit.ErrInvalidArgument, i, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasAttributes reports whether any of messages has attributes.
+func hasAttributes(messages []*v1.SendMessage) bool {
+	for _, m := range messages {
+		if len(m.GetAttributes()) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// marshalAttributes returns attributes as the JSON object,
+// or nil for messages without attributes, which are stored as null.
+func marshalAttributes(attributes map[string]string) (any, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, fmt.Errorf("marshal message attributes: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// isAttributeName reports whether the name consists of letters, digits, '_', '-' and '.'.
+func isAttributeName(name string) bool {
+	if name == "" || len(name) > maxAttributeNameLength {
+		return false
+	}
+
+	for i := 0; i < len(name); i++ {
+		if !isAttributeNameByte(name[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isAttributeNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.'
+}
+
+// attributeFilter holds the condition of the filter expression
+// over the column of message attributes, along with its arguments in order.
+type attributeFilter struct {
+	where string
+	args  []any
+}
+
+// parseAttributeFilter parses the filter expression into the condition over the column,
+// which holds attributes of messages as JSON objects. The grammar is:
+//
+//	or         = and { "OR" and }
+//	and        = primary { "AND" primary }
+//	primary    = "(" or ")" | name ( "=" | "!=" ) string | name "IN" "(" string { "," string } ")"
+//
+// Keywords are case-insensitive and strings are double-quoted with '\' escaping '"' and '\'.
+func parseAttributeFilter(expr, column string) (*attributeFilter, error) {
+	if len(expr) > maxAttributeFilterLength {
+		return nil, fmt.Errorf("%w: filter should not exceed %d bytes", errkit.ErrInvalidArgument, maxAttributeFilterLength)
+	}
+
+	p := filterParser{src: expr, column: column}
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	where, parseErr := p.parseOr()
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	if p.tok.kind != filterTokenEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+
+	return &attributeFilter{where: where, args: p.args}, nil
+}
+
+type filterTokenKind int
+
+const (
+	filterTokenEOF filterTokenKind = iota
+	filterTokenName
+	filterTokenString
+	filterTokenLParen
+	filterTokenRParen
+	filterTokenComma
+	filterTokenEq
+	filterTokenNotEq
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+func (t filterToken) String() string {
+	switch t.kind {
+	case filterTokenEOF:
+		return "end of filter"
+
+	case filterTokenString:
+		return fmt.Sprintf("string %q", t.text)
+
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// is reports whether the token is the keyword.
+func (t filterToken) is(keyword string) bool {
+	return t.kind == filterTokenName && strings.EqualFold(t.text, keyword)
+}
+
+// filterParser is the recursive descent parser of filter expressions,
+// which builds the condition while parsing.
+type filterParser struct {
+	src    string
+	pos    int
+	tok    filterToken
+	column string
+	args   []any
+}
+
+func (p *filterParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: filter: %s at %d", errkit.ErrInvalidArgument, fmt.Sprintf(format, args...), p.tok.pos)
+}
+
+func (p *filterParser) parseOr() (string, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return "", err
+	}
+
+	for p.tok.is("or") {
+		if err := p.next(); err != nil {
+			return "", err
+		}
+
+		right, rightErr := p.parseAnd()
+		if rightErr != nil {
+			return "", rightErr
+		}
+
+		left = left + ` or ` + right
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (string, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return "", err
+	}
+
+	for p.tok.is("and") {
+		if err := p.next(); err != nil {
+			return "", err
+		}
+
+		right, rightErr := p.parsePrimary()
+		if rightErr != nil {
+			return "", rightErr
+		}
+
+		left = left + ` and ` + right
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parsePrimary() (string, error) {
+	if p.tok.kind == filterTokenLParen {
+		if err := p.next(); err != nil {
+			return "", err
+		}
+
+		inner, err := p.parseOr()
+		if err != nil {
+			return "", err
+		}
+
+		if p.tok.kind != filterTokenRParen {
+			return "", p.errorf(`expected ")", got %s`, p.tok)
+		}
+
+		if err := p.next(); err != nil {
+			return "", err
+		}
+
+		return `(` + inner + `)`, nil
+	}
+
+	if p.tok.kind != filterTokenName {
+		return "", p.errorf("expected attribute name, got %s", p.tok)
+	}
+
+	// Names are limited to characters which don't need escaping in JSON paths.
+	p.args = append(p.args, `$."`+p.tok.text+`"`)
+	attribute := `json_extract(` + p.column + `, ?)`
+
+	if err := p.next(); err != nil {
+		return "", err
+	}
+
+	switch {
+	case p.tok.kind == filterTokenEq, p.tok.kind == filterTokenNotEq:
+		// Messages without the attribute are null, which "is not" tells apart from any value.
+		op := ` = ?`
+		if p.tok.kind == filterTokenNotEq {
+			op = ` is not ?`
+		}
+
+		if err := p.next(); err != nil {
+			return "", err
+		}
+
+		value, err := p.parseString()
+		if err != nil {
+			return "", err
+		}
+
+		p.args = append(p.args, value)
+
+		return attribute + op, nil
+
+	case p.tok.is("in"):
+		if err := p.next(); err != nil {
+			return "", err
+		}
+
+		if p.tok.kind != filterTokenLParen {
+			return "", p.errorf(`expected "(", got %s`, p.tok)
+		}
+
+		placeholders := make([]string, 0, 1)
+
+		for {
+			if err := p.next(); err != nil {
+				return "", err
+			}
+
+			value, err := p.parseString()
+			if err != nil {
+				return "", err
+			}
+
+			p.args = append(p.args, value)
+			placeholders = append(placeholders, `?`)
+
+			if p.tok.kind != filterTokenComma {
+				break
+			}
+		}
+
+		if p.tok.kind != filterTokenRParen {
+			return "", p.errorf(`expected ")", got %s`, p.tok)
+		}
+
+		if err := p.next(); err != nil {
+			return "", err
+		}
+
+		return attribute + ` in (` + strings.Join(placeholders, `, `) + `)`, nil
+
+	default:
+		return "", p.errorf(`expected "=", "!=" or IN, got %s`, p.tok)
+	}
+}
+
+// parseString returns the value of the string token and advances to the next token.
+func (p *filterParser) parseString() (string, error) {
+	if p.tok.kind != filterTokenString {
+		return "", p.errorf("expected string, got %s", p.tok)
+	}
+
+	value := p.tok.text
+
+	if err := p.next(); err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
+// next scans the next token of the expression.
+func (p *filterParser) next() error {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\n' || p.src[p.pos] == '\r') {
+		p.pos++
+	}
+
+	start := p.pos
+	p.tok = filterToken{pos: start}
+
+	if p.pos == len(p.src) {
+		return nil
+	}
+
+	switch c := p.src[p.pos]; {
+	case c == '(':
+		p.tok.kind, p.tok.text = filterTokenLParen, "("
+		p.pos++
+
+	case c == ')':
+		p.tok.kind, p.tok.text = filterTokenRParen, ")"
+		p.pos++
+
+	case c == ',':
+		p.tok.kind, p.tok.text = filterTokenComma, ","
+		p.pos++
+
+	case c == '=':
+		p.tok.kind, p.tok.text = filterTokenEq, "="
+		p.pos++
+
+	case c == '!' && strings.HasPrefix(p.src[p.pos:], "!="):
+		p.tok.kind, p.tok.text = filterTokenNotEq, "!="
+		p.pos += 2
+
+	case c == '"':
+		var b strings.Builder
+
+		for p.pos++; ; p.pos++ {
+			if p.pos == len(p.src) {
+				return p.errorf("unterminated string")
+			}
+
+			c := p.src[p.pos]
+
+			if c == '"' {
+				p.pos++
+				break
+			}
+
+			if c == '\\' && p.pos+1 < len(p.src) && (p.src[p.pos+1] == '"' || p.src[p.pos+1] == '\\') {
+				p.pos++
+				c = p.src[p.pos]
+			}
+
+			b.WriteByte(c)
+		}
+
+		p.tok.kind, p.tok.text = filterTokenString, b.String()
+
+	case isAttributeNameByte(c):
+		for p.pos < len(p.src) && isAttributeNameByte(p.src[p.pos]) {
+			p.pos++
+		}
+
+		p.tok.kind, p.tok.text = filterTokenName, p.src[start:p.pos]
+
+		if len(p.tok.text) > maxAttributeNameLength {
+			return p.errorf("attribute name exceeds %d bytes", maxAttributeNameLength)
+		}
+
+	default:
+		return p.errorf("unexpected character %q", c)
+	}
+
+	return nil
+}
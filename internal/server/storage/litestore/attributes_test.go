@@ -0,0 +1,192 @@
+package litestore
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+func Test_parseAttributeFilter(t *testing.T) {
+	type tcase struct {
+		expr      string
+		wantWhere string
+		wantArgs  []any
+		wantErr   error
+	}
+
+	tests := map[string]tcase{
+		"Equal": {
+			expr:      `type = "order"`,
+			wantWhere: `json_extract(c, ?) = ?`,
+			wantArgs:  []any{`$."type"`, "order"},
+		},
+		"NotEqual": {
+			expr:      `type != "order"`,
+			wantWhere: `json_extract(c, ?) is not ?`,
+			wantArgs:  []any{`$."type"`, "order"},
+		},
+		"In": {
+			expr:      `region in ("eu","us")`,
+			wantWhere: `json_extract(c, ?) in (?, ?)`,
+			wantArgs:  []any{`$."region"`, "eu", "us"},
+		},
+		"AndIn": {
+			expr:      `type = "order" AND region IN ("eu", "us")`,
+			wantWhere: `json_extract(c, ?) = ? and json_extract(c, ?) in (?, ?)`,
+			wantArgs:  []any{`$."type"`, "order", `$."region"`, "eu", "us"},
+		},
+		"AndBindsTighter": {
+			expr:      `a = "1" or b = "2" and c = "3"`,
+			wantWhere: `json_extract(c, ?) = ? or json_extract(c, ?) = ? and json_extract(c, ?) = ?`,
+			wantArgs:  []any{`$."a"`, "1", `$."b"`, "2", `$."c"`, "3"},
+		},
+		"Parentheses": {
+			expr:      `(a = "1" or b = "2") and c = "3"`,
+			wantWhere: `(json_extract(c, ?) = ? or json_extract(c, ?) = ?) and json_extract(c, ?) = ?`,
+			wantArgs:  []any{`$."a"`, "1", `$."b"`, "2", `$."c"`, "3"},
+		},
+		"Escapes": {
+			expr:      `note = "say \"hi\" \\ bye"`,
+			wantWhere: `json_extract(c, ?) = ?`,
+			wantArgs:  []any{`$."note"`, `say "hi" \ bye`},
+		},
+		"DottedName": {
+			expr:      `order.kind-v2 = ""`,
+			wantWhere: `json_extract(c, ?) = ?`,
+			wantArgs:  []any{`$."order.kind-v2"`, ""},
+		},
+
+		"Empty":           {expr: ``, wantErr: errkit.ErrInvalidArgument},
+		"NoValue":         {expr: `type =`, wantErr: errkit.ErrInvalidArgument},
+		"UnquotedValue":   {expr: `type = order`, wantErr: errkit.ErrInvalidArgument},
+		"Unterminated":    {expr: `type = "order`, wantErr: errkit.ErrInvalidArgument},
+		"UnknownOperator": {expr: `type > "order"`, wantErr: errkit.ErrInvalidArgument},
+		"EmptyIn":         {expr: `type in ()`, wantErr: errkit.ErrInvalidArgument},
+		"UnclosedIn":      {expr: `type in ("a"`, wantErr: errkit.ErrInvalidArgument},
+		"UnclosedParen":   {expr: `(type = "a"`, wantErr: errkit.ErrInvalidArgument},
+		"Trailing":        {expr: `type = "a" "b"`, wantErr: errkit.ErrInvalidArgument},
+		"Injection":       {expr: `type = "a"; drop table q`, wantErr: errkit.ErrInvalidArgument},
+		"TooLong":         {expr: strings.Repeat(`a = "1" and `, 100) + `a = "1"`, wantErr: errkit.ErrInvalidArgument},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			filter, err := parseAttributeFilter(tc.expr, "c")
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.Require(t).CmpNoError(err)
+			td.Cmp(t, filter.where, tc.wantWhere)
+			td.Cmp(t, filter.args, tc.wantArgs)
+		})
+	}
+}
+
+func Test_validateAttributes(t *testing.T) {
+	type tcase struct {
+		attributes map[string]string
+		wantErr    error
+	}
+
+	tooMany := make(map[string]string, maxMessageAttributes+1)
+	for i := range maxMessageAttributes + 1 {
+		tooMany[strings.Repeat("a", i+1)] = "1"
+	}
+
+	tests := map[string]tcase{
+		"None":        {},
+		"Valid":       {attributes: map[string]string{"type": "order", "order.kind-v2": "", "region_1": "eu"}},
+		"EmptyName":   {attributes: map[string]string{"": "order"}, wantErr: errkit.ErrInvalidArgument},
+		"InvalidName": {attributes: map[string]string{"type\"": "order"}, wantErr: errkit.ErrInvalidArgument},
+		"LongName":    {attributes: map[string]string{strings.Repeat("a", maxAttributeNameLength+1): ""}, wantErr: errkit.ErrInvalidArgument},
+		"TooMany":     {attributes: tooMany, wantErr: errkit.ErrInvalidArgument},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validateAttributes([]*v1.SendMessage{{Body: []byte("m")}, {Attributes: tc.attributes}})
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+		})
+	}
+}
+
+func Test_attributeFilterQuery(t *testing.T) {
+	ctx := context.Background()
+	s := newEvolutionStorage(t, 1)
+
+	evolved := createQueueAtVersion(t, s, queueTablesVersion())
+	unevolved := createQueueAtVersion(t, s, attributesVersion-1)
+
+	messages := map[string]map[string]string{
+		"m1": {"type": "order", "region": "eu"},
+		"m2": {"type": "order", "region": "asia"},
+		"m3": {"type": "refund", "region": "us"},
+		"m4": nil,
+	}
+
+	for id, attributes := range messages {
+		attrs, marshalErr := marshalAttributes(attributes)
+		td.Require(t).CmpNoError(marshalErr)
+
+		_, insertErr := s.db.ExecContext(ctx, queryInsertMessagesWithAttributes(evolved), id, []byte(id), "1970-01-01 00:00:00", attrs)
+		td.Require(t).CmpNoError(insertErr)
+
+		_, insertErr = s.db.ExecContext(ctx, queryInsertMessages(unevolved), id, []byte(id), "1970-01-01 00:00:00")
+		td.Require(t).CmpNoError(insertErr)
+	}
+
+	// received returns identifiers of messages of the queue which match the filter.
+	received := func(queueID, expr string, attributes bool) []string {
+		t.Helper()
+
+		filter, parseErr := parseAttributeFilter(expr, attributesColumn(attributes))
+		td.Require(t).CmpNoError(parseErr)
+
+		args := append(append([]any{maxReceiveAttempts}, filter.args...), 10)
+
+		rows, queryErr := s.db.QueryContext(ctx, querySelectMessages(queueID, true, attributes, filter.where), args...)
+		td.Require(t).CmpNoError(queryErr)
+
+		defer func() { _ = rows.Close() }()
+
+		var ids []string
+
+		for rows.Next() {
+			var (
+				id, body, sentAt     string
+				retries              uint32
+				firstRecvAt, attrsOf *string
+			)
+
+			td.Require(t).CmpNoError(rows.Scan(&id, &body, &retries, &sentAt, &firstRecvAt, &attrsOf))
+
+			ids = append(ids, id)
+		}
+
+		td.Require(t).CmpNoError(rows.Err())
+
+		return ids
+	}
+
+	td.Cmp(t, received(evolved, `type = "order"`, true), td.Bag("m1", "m2"))
+	td.Cmp(t, received(evolved, `type = "order" and region in ("eu", "us")`, true), td.Bag("m1"))
+	td.Cmp(t, received(evolved, `type = "refund" or region = "asia"`, true), td.Bag("m2", "m3"))
+
+	// Messages without the attribute match only !=.
+	td.Cmp(t, received(evolved, `type != "order"`, true), td.Bag("m3", "m4"))
+
+	// Messages of tables without attributes have none.
+	td.Cmp(t, received(unevolved, `type = "order"`, false), td.Empty())
+	td.Cmp(t, received(unevolved, `type != "order"`, false), td.Bag("m1", "m2", "m3", "m4"))
+}
@@ -0,0 +1,121 @@
+package litestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// defaultAuditLimit represents the default number of audit events returned by the listing.
+	defaultAuditLimit = 100
+
+	// maxAuditLimit represents the maximum number of audit events returned by the listing.
+	maxAuditLimit = 1000
+)
+
+func (s *Storage) AppendAuditEvent(ctx context.Context, event *v1.AuditEvent) error {
+	if event.GetEventId() == "" {
+		event.EventId = idkit.ULID()
+	}
+
+	if event.GetCreatedAt() == nil {
+		event.CreatedAt = timestamppb.New(time.Now().UTC())
+	}
+
+	if _, err := s.db.ExecContext(ctx, queryInsertAuditEvent,
+		event.GetEventId(),
+		event.GetActor(),
+		event.GetAction(),
+		event.GetTarget(),
+		event.GetDetail(),
+		event.GetCreatedAt().AsTime(),
+	); err != nil {
+		return fmt.Errorf("insert audit event %q: %w", event.GetAction(), err)
+	}
+
+	return nil
+}
+
+func (s *Storage) ListAuditEvents(ctx context.Context, input *v1.ListAuditEventsRequest) (_ *v1.ListAuditEventsResponse, sErr error) {
+	limit := input.GetLimit()
+
+	switch {
+	case limit == 0:
+		limit = defaultAuditLimit
+
+	case limit > maxAuditLimit:
+		return nil, fmt.Errorf("%w: audit limit should not exceed %d", errkit.ErrInvalidArgument, maxAuditLimit)
+	}
+
+	// Event identifiers are ULIDs, so the cursor is the identifier of the last returned event.
+	if c := input.GetCursor(); c != "" {
+		if _, err := ulid.ParseStrict(c); err != nil {
+			return nil, fmt.Errorf("%w: invalid cursor %q", errkit.ErrInvalidArgument, c)
+		}
+	}
+
+	// The extra event tells whether there is the next page.
+	rows, queryErr := s.db.QueryContext(ctx, querySelectAuditEvents,
+		input.GetActor(),
+		input.GetAction(),
+		input.GetTarget(),
+		auditBound(input.GetFrom()),
+		auditBound(input.GetTo()),
+		input.GetCursor(),
+		limit+1,
+	)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select audit events: %w", queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	output := v1.ListAuditEventsResponse{Events: make([]*v1.AuditEvent, 0, limit)}
+
+	for rows.Next() {
+		var (
+			e         v1.AuditEvent
+			createdAt string
+		)
+
+		if err := rows.Scan(&e.EventId, &e.Actor, &e.Action, &e.Target, &e.Detail, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan audit event: %w", err)
+		}
+
+		if len(output.Events) == int(limit) {
+			output.NextCursor = output.Events[len(output.Events)-1].GetEventId()
+			break
+		}
+
+		e.CreatedAt = parseTimestamp(createdAt)
+		output.Events = append(output.Events, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate audit events: %w", err)
+	}
+
+	return &output, nil
+}
+
+// auditBound returns the query argument of the time bound of the audit filter,
+// which is nil when the bound is not set.
+func auditBound(ts *timestamppb.Timestamp) any {
+	if ts == nil {
+		return nil
+	}
+
+	return ts.AsTime().UTC()
+}
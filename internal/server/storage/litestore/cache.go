@@ -3,12 +3,16 @@ package litestore
 import (
 	"container/list"
 	"fmt"
+	"maps"
 	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cockroachdb/swiss"
 	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
 	"github.com/plainq/servekit/tern"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -23,22 +27,96 @@ type QueueProps struct {
 	MaxReceiveAttempts       uint32
 	EvictionPolicy           uint32
 	DeadLetterQueueID        string
+	Version                  uint64
+	Description              string
+	Tags                     map[string]string
+	FIFO                     bool
+	DelaySeconds             uint64
+	MaxMessageSizeBytes      uint64
+	Owner                    string
+	SearchIndex              bool
+	State                    uint32
+	RateLimit                uint32
+	RateLimitBurst           uint32
+	MaxMessages              uint64
+	MaxBytes                 uint64
+	QuotaPolicy              uint32
+	RetryInitialBackoff      uint64
+	RetryMultiplier          float64
+	RetryMaxBackoff          uint64
 }
 
-// QueuePropsCache represents in in-memory cache
-// of QueueProps for each existing queue.
-type QueuePropsCache struct {
-	mu   sync.RWMutex
-	size uint64
+// equal reports whether p and o hold the same properties.
+func (p QueueProps) equal(o QueueProps) bool {
+	return p.ID == o.ID &&
+		p.Name == o.Name &&
+		p.CreatedAt.Equal(o.CreatedAt) &&
+		p.RetentionPeriodSeconds == o.RetentionPeriodSeconds &&
+		p.VisibilityTimeoutSeconds == o.VisibilityTimeoutSeconds &&
+		p.MaxReceiveAttempts == o.MaxReceiveAttempts &&
+		p.EvictionPolicy == o.EvictionPolicy &&
+		p.DeadLetterQueueID == o.DeadLetterQueueID &&
+		p.Version == o.Version &&
+		p.Description == o.Description &&
+		maps.Equal(p.Tags, o.Tags) &&
+		p.FIFO == o.FIFO &&
+		p.DelaySeconds == o.DelaySeconds &&
+		p.MaxMessageSizeBytes == o.MaxMessageSizeBytes &&
+		p.Owner == o.Owner &&
+		p.SearchIndex == o.SearchIndex &&
+		p.State == o.State &&
+		p.RateLimit == o.RateLimit &&
+		p.RateLimitBurst == o.RateLimitBurst &&
+		p.MaxMessages == o.MaxMessages &&
+		p.MaxBytes == o.MaxBytes &&
+		p.QuotaPolicy == o.QuotaPolicy &&
+		p.RetryInitialBackoff == o.RetryInitialBackoff &&
+		p.RetryMultiplier == o.RetryMultiplier &&
+		p.RetryMaxBackoff == o.RetryMaxBackoff
+}
 
+// QueuePropsCache represents an in-memory LRU cache of QueueProps of queues.
+//
+// Entries are replaced by the put on any change of the queue made by the Storage,
+// and removed by the delete and the invalidate. The TTL bounds how long changes
+// made bypassing the Storage, e.g. by another instance sharing the database,
+// are not seen.
+type QueuePropsCache struct {
+	size     uint64
+	ttl      time.Duration
+	now      func() time.Time
+	observer telemetry.Observer
+
+	// mu guards the recency list as well as indexes,
+	// since reads move entries to the front of the list.
+	mu     sync.Mutex
 	byID   *swiss.Map[string, *list.Element]
 	byName *swiss.Map[string, *list.Element]
-	props  *list.List
+
+	// props holds entries from the most to the least recently used.
+	props *list.List
+
+	// ver is a monotonic counter which is incremented
+	// each time the set of cached properties changes.
+	ver atomic.Uint64
+
+	// evicted tells whether properties of any existing queue
+	// have been evicted or invalidated since the cache was filled.
+	evicted atomic.Bool
+}
+
+// propsEntry represents cached properties of the queue.
+type propsEntry struct {
+	props QueueProps
+
+	// until is the time the entry expires, zero when the cache has no TTL.
+	until time.Time
 }
 
 type QueuePropsListOptions struct {
 	orderBy v1.ListQueuesRequest_OrderBy
 	sortBy  v1.ListQueuesRequest_SortBy
+	prefix  string
 }
 
 type QueuePropsListOption func(options *QueuePropsListOptions)
@@ -51,63 +129,76 @@ func listCacheSortBy(by v1.ListQueuesRequest_SortBy) QueuePropsListOption {
 	return func(o *QueuePropsListOptions) { o.sortBy = by }
 }
 
-// NewQueuePropsCache returns a pointer to a new instance of QueuePropsCache.
-func NewQueuePropsCache(size uint64) *QueuePropsCache {
+// listCachePrefix limits the listing to queues which names start with the prefix.
+func listCachePrefix(prefix string) QueuePropsListOption {
+	return func(o *QueuePropsListOptions) { o.prefix = prefix }
+}
+
+// NewQueuePropsCache returns a pointer to a new instance of QueuePropsCache, which holds
+// properties of up to size queues for the TTL. Zero TTL means entries don't expire.
+func NewQueuePropsCache(size uint64, ttl time.Duration, observer telemetry.Observer) *QueuePropsCache {
 	if size == 0 {
 		size = queuePropsCacheSize
 	}
 
 	cache := QueuePropsCache{
-		size:   size,
-		byID:   swiss.New[string, *list.Element](int(size)),
-		byName: swiss.New[string, *list.Element](int(size)),
-		props:  list.New(),
+		size:     size,
+		ttl:      ttl,
+		now:      time.Now,
+		observer: observer,
+		byID:     swiss.New[string, *list.Element](int(size)),
+		byName:   swiss.New[string, *list.Element](int(size)),
+		props:    list.New(),
 	}
 
 	return &cache
 }
 
 func (c *QueuePropsCache) getByID(id string) (QueueProps, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	v, cached := c.byID.Get(id)
-	if cached {
-		c.props.MoveToFront(v)
+	e, _ := c.byID.Get(id)
 
-		props, ok := v.Value.(QueueProps)
-		if !ok {
-			panic(fmt.Errorf("invalid type in cache: %#v", v.Value))
-		}
+	return c.get(e)
+}
 
-		return props, true
-	}
+func (c *QueuePropsCache) getByName(name string) (QueueProps, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, _ := c.byName.Get(name)
 
-	return QueueProps{}, false
+	return c.get(e)
 }
 
-func (c *QueuePropsCache) getByName(name string) (QueueProps, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+// get returns properties of the entry unless it's nil or expired,
+// and marks the entry as the most recently used one.
+// The caller must hold the lock.
+func (c *QueuePropsCache) get(e *list.Element) (QueueProps, bool) {
+	if e == nil {
+		c.observer.QueueCacheMisses().Inc()
+		return QueueProps{}, false
+	}
 
-	v, cached := c.byName.Get(name)
-	if cached {
-		c.props.MoveToFront(v)
+	entry := entryOf(e)
 
-		props, ok := v.Value.(QueueProps)
-		if !ok {
-			panic(fmt.Errorf("invalid type in cache: %#v", v.Value))
-		}
+	if c.expired(entry) {
+		c.remove(e)
+		c.evicted.Store(true)
+		c.observer.QueueCacheEvictions("ttl").Inc()
+		c.observer.QueueCacheMisses().Inc()
 
-		return props, true
+		return QueueProps{}, false
 	}
 
-	return QueueProps{}, false
+	c.props.MoveToFront(e)
+	c.observer.QueueCacheHits().Inc()
+
+	return entry.props, true
 }
 
 func (c *QueuePropsCache) list(options ...QueuePropsListOption) []QueueProps {
-	props := make([]QueueProps, c.byID.Len())
-
 	listOptions := QueuePropsListOptions{
 		orderBy: v1.ListQueuesRequest_ORDER_BY_ID,
 		sortBy:  v1.ListQueuesRequest_SORT_BY_ASC,
@@ -117,31 +208,21 @@ func (c *QueuePropsCache) list(options ...QueuePropsListOption) []QueueProps {
 		option(&listOptions)
 	}
 
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	i := 0
+	props := make([]QueueProps, 0, c.props.Len())
 
-	iter := func(_ string, e *list.Element) bool {
-		v, ok := e.Value.(QueueProps)
-		if !ok {
-			panic(fmt.Errorf("invalid type in queue props cache: %#v", e.Value))
-		}
+	for e := c.props.Front(); e != nil; e = e.Next() {
+		entry := entryOf(e)
 
-		props[i].ID = v.ID
-		props[i].Name = v.Name
-		props[i].VisibilityTimeoutSeconds = v.VisibilityTimeoutSeconds
-		props[i].RetentionPeriodSeconds = v.RetentionPeriodSeconds
-		props[i].MaxReceiveAttempts = v.MaxReceiveAttempts
-		props[i].EvictionPolicy = v.EvictionPolicy
-		props[i].DeadLetterQueueID = v.DeadLetterQueueID
-		i++
+		if c.expired(entry) || !strings.HasPrefix(entry.props.Name, listOptions.prefix) {
+			continue
+		}
 
-		return true
+		props = append(props, entry.props)
 	}
 
-	c.byID.All(iter)
-
 	sortProps(props, listOptions)
 
 	return props
@@ -151,16 +232,49 @@ func (c *QueuePropsCache) put(props QueueProps) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if c.props.Len() == int(c.size) {
-		c.props.Remove(c.props.Back())
+	until := c.until()
+
+	if e, ok := c.byID.Get(props.ID); ok {
+		if entryOf(e).props.equal(props) {
+			e.Value = propsEntry{props: props, until: until}
+			c.props.MoveToFront(e)
+
+			return
+		}
+
+		// The name might have been changed, so both indexes are updated.
+		c.remove(e)
+	}
+
+	if c.props.Len() >= int(c.size) {
+		c.remove(c.props.Back())
+		c.evicted.Store(true)
+		c.observer.QueueCacheEvictions("size").Inc()
+	}
+
+	e := c.props.PushFront(propsEntry{props: props, until: until})
+	c.byID.Put(props.ID, e)
+	c.byName.Put(props.Name, e)
+	c.ver.Add(1)
+}
+
+// delete removes properties of the deleted queue.
+func (c *QueuePropsCache) delete(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.byID.Get(id)
+	if !ok {
+		return
 	}
 
-	entry := c.props.PushBack(props)
-	c.byID.Put(props.ID, entry)
-	c.byName.Put(props.Name, entry)
+	c.remove(e)
+	c.ver.Add(1)
 }
 
-func (c *QueuePropsCache) delete(id, name string) {
+// invalidate removes properties of the existing queue, which are
+// likely outdated, so they are read from the database next time.
+func (c *QueuePropsCache) invalidate(id string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -169,9 +283,83 @@ func (c *QueuePropsCache) delete(id, name string) {
 		return
 	}
 
+	c.remove(e)
+	c.evicted.Store(true)
+	c.ver.Add(1)
+}
+
+// purge removes properties of all queues, e.g. when the database
+// has been changed by another server, so the cache is filled again.
+func (c *QueuePropsCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.props.Init()
+	c.byID.Clear()
+	c.byName.Clear()
+	c.evicted.Store(false)
+	c.ver.Add(1)
+}
+
+// remove removes the entry from the recency list and indexes.
+// The caller must hold the lock.
+func (c *QueuePropsCache) remove(e *list.Element) {
+	props := entryOf(e).props
+
 	c.props.Remove(e)
-	c.byID.Delete(id)
-	c.byName.Delete(name)
+	c.byID.Delete(props.ID)
+
+	// The name might be taken by another queue after the rename.
+	if n, ok := c.byName.Get(props.Name); ok && n == e {
+		c.byName.Delete(props.Name)
+	}
+}
+
+// until returns the expiration time of entries put now.
+func (c *QueuePropsCache) until() time.Time {
+	if c.ttl <= 0 {
+		return time.Time{}
+	}
+
+	return c.now().Add(c.ttl)
+}
+
+// expired reports whether the entry has outlived the TTL.
+func (c *QueuePropsCache) expired(entry propsEntry) bool {
+	return !entry.until.IsZero() && !c.now().Before(entry.until)
+}
+
+// complete reports whether the cache holds properties of all queues,
+// which is true unless any of them has been evicted, expired or invalidated.
+func (c *QueuePropsCache) complete() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for e := c.props.Back(); e != nil; {
+		prev := e.Prev()
+
+		if c.expired(entryOf(e)) {
+			c.remove(e)
+			c.evicted.Store(true)
+			c.observer.QueueCacheEvictions("ttl").Inc()
+		}
+
+		e = prev
+	}
+
+	return !c.evicted.Load()
+}
+
+// version returns the current version of the cached queue properties.
+func (c *QueuePropsCache) version() uint64 { return c.ver.Load() }
+
+func entryOf(e *list.Element) propsEntry {
+	entry, ok := e.Value.(propsEntry)
+	if !ok {
+		panic(fmt.Errorf("invalid type in queue props cache: %#v", e.Value))
+	}
+
+	return entry
 }
 
 func sortProps(props []QueueProps, listOptions QueuePropsListOptions) {
@@ -254,6 +442,21 @@ func propsToProto(p QueueProps) *v1.DescribeQueueResponse {
 		MaxReceiveAttempts:       p.MaxReceiveAttempts,
 		EvictionPolicy:           v1.EvictionPolicy(p.EvictionPolicy),
 		DeadLetterQueueId:        p.DeadLetterQueueID,
+		Version:                  p.Version,
+		Description:              p.Description,
+		Tags:                     p.Tags,
+		Fifo:                     p.FIFO,
+		DelaySeconds:             p.DelaySeconds,
+		MaxMessageSizeBytes:      p.MaxMessageSizeBytes,
+		Owner:                    p.Owner,
+		SearchIndex:              p.SearchIndex,
+		State:                    v1.QueueState(p.State),
+		RateLimit:                p.RateLimit,
+		RateLimitBurst:           p.RateLimitBurst,
+		MaxMessages:              p.MaxMessages,
+		MaxBytes:                 p.MaxBytes,
+		QuotaPolicy:              v1.QuotaPolicy(p.QuotaPolicy),
+		RetryPolicy:              retryPolicy(p.RetryInitialBackoff, p.RetryMultiplier, p.RetryMaxBackoff),
 	}
 
 	return &response
@@ -269,6 +472,23 @@ func propsFromProto(p *v1.DescribeQueueResponse) QueueProps {
 		MaxReceiveAttempts:       p.MaxReceiveAttempts,
 		EvictionPolicy:           uint32(p.EvictionPolicy),
 		DeadLetterQueueID:        p.DeadLetterQueueId,
+		Version:                  p.Version,
+		Description:              p.Description,
+		Tags:                     p.Tags,
+		FIFO:                     p.Fifo,
+		DelaySeconds:             p.DelaySeconds,
+		MaxMessageSizeBytes:      p.MaxMessageSizeBytes,
+		Owner:                    p.Owner,
+		SearchIndex:              p.SearchIndex,
+		State:                    uint32(p.State),
+		RateLimit:                p.RateLimit,
+		RateLimitBurst:           p.RateLimitBurst,
+		MaxMessages:              p.MaxMessages,
+		MaxBytes:                 p.MaxBytes,
+		QuotaPolicy:              uint32(p.QuotaPolicy),
+		RetryInitialBackoff:      p.GetRetryPolicy().GetInitialBackoffSeconds(),
+		RetryMultiplier:          p.GetRetryPolicy().GetMultiplier(),
+		RetryMaxBackoff:          p.GetRetryPolicy().GetMaxBackoffSeconds(),
 	}
 
 	return props
@@ -2,14 +2,17 @@ package litestore
 
 import (
 	"testing"
+	"time"
 
 	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/telemetry"
 )
 
 func Test_queuePropsCache_list(t *testing.T) {
 	tests := map[string]struct {
-		setup func(c *QueuePropsCache) *QueuePropsCache
-		want  []QueueProps
+		setup   func(c *QueuePropsCache) *QueuePropsCache
+		options []QueuePropsListOption
+		want    []QueueProps
 	}{
 		"Empty": {
 			setup: func(c *QueuePropsCache) *QueuePropsCache { return c },
@@ -37,12 +40,141 @@ func Test_queuePropsCache_list(t *testing.T) {
 				{ID: "1"}, {ID: "2"}, {ID: "3"},
 			},
 		},
+
+		"Prefix": {
+			setup: func(c *QueuePropsCache) *QueuePropsCache {
+				c.put(QueueProps{ID: "1", Name: "orders"})
+				c.put(QueueProps{ID: "2", Name: "payments"})
+				c.put(QueueProps{ID: "3", Name: "orders-dlq"})
+				return c
+			},
+			options: []QueuePropsListOption{listCachePrefix("orders")},
+			want: []QueueProps{
+				{ID: "1", Name: "orders"}, {ID: "3", Name: "orders-dlq"},
+			},
+		},
+
+		"CreatedAt": {
+			setup: func(c *QueuePropsCache) *QueuePropsCache {
+				c.put(QueueProps{ID: "1", CreatedAt: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)})
+				return c
+			},
+			want: []QueueProps{
+				{ID: "1", CreatedAt: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)},
+			},
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			cache := tc.setup(NewQueuePropsCache(0))
-			td.Cmp(t, cache.list(), tc.want)
+			cache := tc.setup(NewQueuePropsCache(0, 0, telemetry.NewObserver()))
+			td.Cmp(t, cache.list(tc.options...), tc.want)
 		})
 	}
 }
+
+func Test_queuePropsCache_lru(t *testing.T) {
+	cache := NewQueuePropsCache(2, 0, telemetry.NewObserver())
+
+	cache.put(QueueProps{ID: "1", Name: "one"})
+	cache.put(QueueProps{ID: "2", Name: "two"})
+
+	// The first queue becomes the most recently used one,
+	// so the second one is evicted to fit the third one.
+	_, ok := cache.getByID("1")
+	td.CmpTrue(t, ok)
+
+	cache.put(QueueProps{ID: "3", Name: "three"})
+
+	_, ok = cache.getByID("1")
+	td.CmpTrue(t, ok)
+
+	_, ok = cache.getByName("two")
+	td.CmpFalse(t, ok)
+
+	_, ok = cache.getByID("3")
+	td.CmpTrue(t, ok)
+
+	td.CmpFalse(t, cache.complete())
+}
+
+func Test_queuePropsCache_ttl(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+
+	cache := NewQueuePropsCache(0, time.Minute, telemetry.NewObserver())
+	cache.now = func() time.Time { return now }
+
+	cache.put(QueueProps{ID: "1", Name: "one"})
+	cache.put(QueueProps{ID: "2", Name: "two"})
+
+	now = now.Add(30 * time.Second)
+
+	// Putting the same properties again extends their TTL.
+	cache.put(QueueProps{ID: "2", Name: "two"})
+	td.CmpTrue(t, cache.complete())
+
+	now = now.Add(30 * time.Second)
+
+	_, ok := cache.getByName("one")
+	td.CmpFalse(t, ok)
+
+	props, ok := cache.getByID("2")
+	td.CmpTrue(t, ok)
+	td.Cmp(t, props.Name, "two")
+
+	td.CmpFalse(t, cache.complete())
+}
+
+func Test_queuePropsCache_rename(t *testing.T) {
+	cache := NewQueuePropsCache(0, 0, telemetry.NewObserver())
+
+	cache.put(QueueProps{ID: "1", Name: "one"})
+	cache.put(QueueProps{ID: "1", Name: "first", Version: 2})
+
+	_, ok := cache.getByName("one")
+	td.CmpFalse(t, ok)
+
+	props, ok := cache.getByName("first")
+	td.CmpTrue(t, ok)
+	td.Cmp(t, props.Version, uint64(2))
+}
+
+func Test_queuePropsCache_invalidate(t *testing.T) {
+	cache := NewQueuePropsCache(0, 0, telemetry.NewObserver())
+
+	cache.put(QueueProps{ID: "1", Name: "one"})
+	cache.put(QueueProps{ID: "2", Name: "two"})
+
+	version := cache.version()
+
+	cache.delete("1")
+	td.CmpTrue(t, cache.complete())
+	td.Cmp(t, cache.version(), version+1)
+
+	cache.invalidate("2")
+	td.CmpFalse(t, cache.complete())
+	td.Cmp(t, cache.version(), version+2)
+
+	_, ok := cache.getByName("two")
+	td.CmpFalse(t, ok)
+}
+
+func Test_queuePropsCache_purge(t *testing.T) {
+	cache := NewQueuePropsCache(0, 0, telemetry.NewObserver())
+
+	cache.put(QueueProps{ID: "1", Name: "one"})
+	cache.invalidate("1")
+	cache.put(QueueProps{ID: "2", Name: "two"})
+
+	version := cache.version()
+
+	cache.purge()
+	td.Cmp(t, cache.list(), td.Empty())
+	td.Cmp(t, cache.version(), version+1)
+
+	cache.put(QueueProps{ID: "3", Name: "three"})
+	td.CmpTrue(t, cache.complete())
+
+	_, ok := cache.getByID("2")
+	td.CmpFalse(t, ok)
+}
@@ -0,0 +1,56 @@
+package litestore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+// listCursor represents the position of the queue listing: the value of the
+// order column and the identifier of the last listed queue, which breaks ties
+// between queues with equal names or creation times.
+type listCursor struct {
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// encodeListCursor returns the opaque cursor pointing after the queue in the listing ordered by the column.
+func encodeListCursor(q *v1.DescribeQueueResponse, orderBy v1.ListQueuesRequest_OrderBy) string {
+	c := listCursor{ID: q.GetQueueId()}
+
+	switch orderBy {
+	case v1.ListQueuesRequest_ORDER_BY_NAME:
+		c.Value = q.GetQueueName()
+
+	case v1.ListQueuesRequest_ORDER_BY_CREATED_AT:
+		// Creation times are stored in the format of the current_timestamp.
+		c.Value = q.GetCreatedAt().AsTime().UTC().Format(time.DateTime)
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		panic(fmt.Errorf("encode list cursor: %w", err))
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeListCursor returns the position of the listing encoded by the encodeListCursor.
+func decodeListCursor(cursor string) (listCursor, error) {
+	var c listCursor
+
+	b, decodeErr := base64.RawURLEncoding.DecodeString(cursor)
+	if decodeErr != nil {
+		return c, fmt.Errorf("%w: invalid cursor", errkit.ErrInvalidArgument)
+	}
+
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return c, fmt.Errorf("%w: invalid cursor", errkit.ErrInvalidArgument)
+	}
+
+	return c, nil
+}
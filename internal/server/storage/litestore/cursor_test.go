@@ -0,0 +1,52 @@
+package litestore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_listCursor(t *testing.T) {
+	type tcase struct {
+		orderBy v1.ListQueuesRequest_OrderBy
+		want    listCursor
+	}
+
+	queue := v1.DescribeQueueResponse{
+		QueueId:   "cq1",
+		QueueName: "orders",
+		CreatedAt: timestamppb.New(time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)),
+	}
+
+	tests := map[string]tcase{
+		"ByID":        {orderBy: v1.ListQueuesRequest_ORDER_BY_ID, want: listCursor{ID: "cq1"}},
+		"ByName":      {orderBy: v1.ListQueuesRequest_ORDER_BY_NAME, want: listCursor{Value: "orders", ID: "cq1"}},
+		"ByCreatedAt": {orderBy: v1.ListQueuesRequest_ORDER_BY_CREATED_AT, want: listCursor{Value: "2024-05-01 10:30:00", ID: "cq1"}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := decodeListCursor(encodeListCursor(&queue, tc.orderBy))
+			td.CmpNoError(t, err)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
+
+func Test_decodeListCursor_invalid(t *testing.T) {
+	for name, cursor := range map[string]string{
+		"NotBase64":  "cq1!",
+		"NotJSON":    "Y3Ex",
+		"WithoutID":  "eyJ2IjoieCJ9",
+		"QueueIDRaw": "cq1",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := decodeListCursor(cursor)
+			td.CmpErrorIs(t, err, errkit.ErrInvalidArgument)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (s *Storage) DenyAccessToken(ctx context.Context, hash string, expiresAt time.Time) error {
+	if _, err := s.db.ExecContext(ctx, queryInsertDeniedAccessToken, hash, expiresAt.UTC()); err != nil {
+		return fmt.Errorf("insert denied access token: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) AccessTokenDenied(ctx context.Context, hash string) (bool, error) {
+	var denied bool
+
+	if err := s.db.QueryRowContext(ctx, querySelectAccessTokenDenied, hash).Scan(&denied); err != nil {
+		return false, fmt.Errorf("select denied access token: %w", err)
+	}
+
+	return denied, nil
+}
+
+func (s *Storage) DeleteExpiredAccessTokens(ctx context.Context, before time.Time) (int64, error) {
+	res, execErr := s.db.ExecContext(ctx, queryDeleteExpiredAccessTokens, before.UTC())
+	if execErr != nil {
+		return 0, fmt.Errorf("delete expired access tokens: %w", execErr)
+	}
+
+	deleted, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return 0, fmt.Errorf("delete expired access tokens: %w", rowsErr)
+	}
+
+	if _, err := s.db.ExecContext(ctx, queryDeleteExpiredSubjectTokenRevocations, before.UTC()); err != nil {
+		return 0, fmt.Errorf("delete expired token revocations: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, queryDeleteExpiredSessions, before.UTC()); err != nil {
+		return 0, fmt.Errorf("delete expired sessions: %w", err)
+	}
+
+	return deleted, nil
+}
+
+func (s *Storage) RevokeSubjectTokens(ctx context.Context, subject string, issuedBefore, expiresAt time.Time) error {
+	if _, err := s.db.ExecContext(ctx, queryInsertSubjectTokenRevocation, subject, issuedBefore.UTC(), expiresAt.UTC()); err != nil {
+		return fmt.Errorf("insert token revocation: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) SubjectTokensRevokedAt(ctx context.Context, subject string) (time.Time, error) {
+	var revokedAt time.Time
+
+	if err := s.db.QueryRowContext(ctx, querySelectSubjectTokenRevocation, subject).Scan(&revokedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil
+		}
+
+		return time.Time{}, fmt.Errorf("select token revocation: %w", err)
+	}
+
+	return revokedAt, nil
+}
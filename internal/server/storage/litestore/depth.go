@@ -0,0 +1,149 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/telemetry"
+)
+
+// depthInterval represents the default interval between reconciliations of depth gauges.
+const depthInterval = time.Minute
+
+// queueDepth holds the number of visible and in-flight messages of the queue.
+type queueDepth struct {
+	Visible  uint64
+	InFlight uint64
+}
+
+// reconcileDepth periodically sets depth gauges of all queues to the counted numbers of
+// messages. Operations keep gauges up to date in between, but messages also become visible
+// again when their visibility timeout expires and are dropped by the garbage collection.
+func (s *Storage) reconcileDepth(ctx context.Context) {
+	ticker := time.NewTicker(s.depthInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.countDepth(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("Failed to reconcile queue depth gauges",
+				slog.String("error", err.Error()),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+	}
+}
+
+// countDepth counts visible and in-flight messages of all queues and sets their depth gauges.
+func (s *Storage) countDepth(ctx context.Context) error {
+	queues, selectErr := s.queuesMaxReceiveAttempts(ctx)
+	if selectErr != nil {
+		return selectErr
+	}
+
+	for queueID, maxAttempts := range queues {
+		depth, countErr := s.queueDepth(ctx, queueID, maxAttempts)
+		if countErr != nil {
+			// The queue might have been deleted after it has been selected.
+			if !s.queueExists(ctx, queueID) {
+				continue
+			}
+
+			return fmt.Errorf("count queue %q depth: %w", queueID, countErr)
+		}
+
+		s.observer.MessagesVisible(queueID).Set(depth.Visible)
+		s.observer.MessagesInFlight(queueID).Set(depth.InFlight)
+	}
+
+	return nil
+}
+
+// queuesMaxReceiveAttempts returns the maximum receive attempts of all queues by their identifiers.
+func (s *Storage) queuesMaxReceiveAttempts(ctx context.Context) (_ map[string]uint32, sErr error) {
+	rows, queryErr := s.db.QueryContext(ctx, querySelectQueuesMaxReceiveAttempts)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select queues: %w", queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	queues := make(map[string]uint32)
+
+	for rows.Next() {
+		var (
+			queueID     string
+			maxAttempts uint32
+		)
+
+		if err := rows.Scan(&queueID, &maxAttempts); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		queues[queueID] = maxAttempts
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return queues, nil
+}
+
+// queueDepth counts visible and in-flight messages of the queue in a single transaction.
+func (s *Storage) queueDepth(ctx context.Context, queueID string, maxAttempts uint32) (_ queueDepth, sErr error) {
+	tx, txErr := s.beginTx(ctx, opQueueDepth, true)
+	if txErr != nil {
+		return queueDepth{}, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	var depth queueDepth
+
+	if err := tx.QueryRowContext(ctx, queryCountVisibleMessages(queueID), maxAttempts).Scan(&depth.Visible); err != nil {
+		return queueDepth{}, fmt.Errorf("count visible messages: %w", err)
+	}
+
+	if err := tx.QueryRowContext(ctx, queryCountInFlightMessages(queueID)).Scan(&depth.InFlight); err != nil {
+		return queueDepth{}, fmt.Errorf("count in-flight messages: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return queueDepth{}, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return depth, nil
+}
+
+// queueExists reports whether the queue exists, errors are reported as existence.
+func (s *Storage) queueExists(ctx context.Context, queueID string) bool {
+	var exists bool
+
+	if err := s.db.QueryRowContext(ctx, queryQueueExists, queueID).Scan(&exists); err != nil {
+		return true
+	}
+
+	return exists
+}
+
+// subGauge decrements the gauge by n, but not below zero,
+// since gauges may lag behind until they are reconciled.
+func subGauge(g telemetry.Gauge, n uint64) { g.Sub(min(n, g.Get())) }
@@ -0,0 +1,62 @@
+package litestore
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+)
+
+func Test_subGauge(t *testing.T) {
+	type tcase struct {
+		set  uint64
+		sub  uint64
+		want uint64
+	}
+
+	tests := map[string]tcase{
+		"Less": {
+			set:  5,
+			sub:  3,
+			want: 2,
+		},
+
+		"Equal": {
+			set:  3,
+			sub:  3,
+			want: 0,
+		},
+
+		"Lagging": {
+			set:  2,
+			sub:  5,
+			want: 0,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := telemetry.NewObserver().MessagesInFlight("depth-test-" + name)
+			g.Set(tc.set)
+
+			subGauge(g, tc.sub)
+			td.Cmp(t, g.Get(), tc.want)
+		})
+	}
+}
+
+func TestStorage_fillQueueStats(t *testing.T) {
+	s := Storage{observer: telemetry.NewObserver()}
+
+	s.observer.MessagesVisible("stats-test").Set(7)
+	s.observer.MessagesInFlight("stats-test").Set(3)
+
+	queues := []*v1.DescribeQueueResponse{{QueueId: "stats-test"}, {QueueId: "stats-test-empty"}}
+
+	s.fillQueueStats(queues)
+
+	td.Cmp(t, queues[0].GetStats(), &v1.ApproximateQueueStats{Depth: 10, InFlight: 3})
+	td.Cmp(t, queues[0].GetDepth(), uint64(10))
+	td.Cmp(t, queues[1].GetStats(), &v1.ApproximateQueueStats{})
+}
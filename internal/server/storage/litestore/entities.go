@@ -0,0 +1,245 @@
+package litestore
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqmatch"
+)
+
+const (
+	// defaultEntitiesLimit represents the default number of entities returned by the search.
+	defaultEntitiesLimit = 10
+
+	// maxEntityCandidates limits the number of records of each kind which are
+	// read from the database and scored, so broad queries stay fast.
+	maxEntityCandidates = 500
+)
+
+func (s *Storage) SearchEntities(ctx context.Context, input *v1.SearchRequest) (*v1.SearchResponse, error) {
+	query := input.GetQuery()
+
+	kinds := input.GetKinds()
+	if len(kinds) == 0 {
+		kinds = []v1.EntityKind{
+			v1.EntityKind_ENTITY_KIND_QUEUE,
+			v1.EntityKind_ENTITY_KIND_USER,
+			v1.EntityKind_ENTITY_KIND_ROLE,
+			v1.EntityKind_ENTITY_KIND_AUDIT_EVENT,
+		}
+	}
+
+	var results []*v1.SearchResult
+
+	if slices.Contains(kinds, v1.EntityKind_ENTITY_KIND_QUEUE) {
+		queues, err := s.searchQueues(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("search queues: %w", err)
+		}
+
+		results = append(results, queues...)
+	}
+
+	if slices.Contains(kinds, v1.EntityKind_ENTITY_KIND_USER) {
+		users, err := s.searchNamed(ctx, v1.EntityKind_ENTITY_KIND_USER, querySearchUsers, query)
+		if err != nil {
+			return nil, fmt.Errorf("search users: %w", err)
+		}
+
+		results = append(results, users...)
+	}
+
+	if slices.Contains(kinds, v1.EntityKind_ENTITY_KIND_ROLE) {
+		roles, err := s.searchNamed(ctx, v1.EntityKind_ENTITY_KIND_ROLE, querySearchRoles, query)
+		if err != nil {
+			return nil, fmt.Errorf("search roles: %w", err)
+		}
+
+		results = append(results, roles...)
+	}
+
+	if slices.Contains(kinds, v1.EntityKind_ENTITY_KIND_AUDIT_EVENT) {
+		events, err := s.searchAuditEvents(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("search audit events: %w", err)
+		}
+
+		results = append(results, events...)
+	}
+
+	pqmatch.Sort(results)
+
+	limit := cmp.Or(int(input.GetLimit()), defaultEntitiesLimit)
+
+	return &v1.SearchResponse{Results: results[:min(len(results), limit)]}, nil
+}
+
+// searchQueues returns queues which name, identifier or tags match the query.
+// Queues are matched in memory while the cache holds all of them.
+func (s *Storage) searchQueues(ctx context.Context, query string) (_ []*v1.SearchResult, sErr error) {
+	var results []*v1.SearchResult
+
+	if s.cache.complete() {
+		for _, p := range s.cache.list() {
+			if r := queueSearchResult(query, p.ID, p.Name, p.Tags); r != nil {
+				results = append(results, r)
+			}
+		}
+
+		return results, nil
+	}
+
+	rows, queryErr := s.db.QueryContext(ctx, querySearchQueues, pqmatch.LikePattern(query), maxEntityCandidates)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			id, name, tagsJSON string
+			tags               map[string]string
+		)
+
+		if err := rows.Scan(&id, &name, &tagsJSON); err != nil {
+			return nil, fmt.Errorf("scan queue record: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(tagsJSON), &tags); err != nil {
+			return nil, fmt.Errorf("unmarshal queue tags: %w", err)
+		}
+
+		if r := queueSearchResult(query, id, name, tags); r != nil {
+			results = append(results, r)
+		}
+	}
+
+	return results, rows.Err()
+}
+
+// queueSearchResult returns the result for the queue, or nil if it doesn't match
+// the query. Matched tags are reported in the result detail as "key=value".
+func queueSearchResult(query, id, name string, tags map[string]string) *v1.SearchResult {
+	r := v1.SearchResult{
+		Kind:  v1.EntityKind_ENTITY_KIND_QUEUE,
+		Id:    id,
+		Title: name,
+		Score: max(pqmatch.Score(query, name), pqmatch.Score(query, id)),
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(tags)) {
+		tag := k + "=" + tags[k]
+
+		if score := pqmatch.Score(query, tag); score > r.Score {
+			r.Score = score
+			r.Detail = tag
+		}
+	}
+
+	if r.Score == 0 {
+		return nil
+	}
+
+	return &r
+}
+
+// searchNamed returns entities of the kind which name or identifier match the query.
+// The SQL query should select identifiers and names of entities.
+func (s *Storage) searchNamed(ctx context.Context, kind v1.EntityKind, sqlQuery, query string) (_ []*v1.SearchResult, sErr error) {
+	rows, queryErr := s.db.QueryContext(ctx, sqlQuery, pqmatch.LikePattern(query), maxEntityCandidates)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	var results []*v1.SearchResult
+
+	for rows.Next() {
+		r := v1.SearchResult{Kind: kind}
+
+		if err := rows.Scan(&r.Id, &r.Title); err != nil {
+			return nil, fmt.Errorf("scan record: %w", err)
+		}
+
+		if r.Score = max(pqmatch.Score(query, r.Title), pqmatch.Score(query, r.Id)); r.Score > 0 {
+			results = append(results, &r)
+		}
+	}
+
+	return results, rows.Err()
+}
+
+// searchAuditEvents returns audit events which action, actor, target or detail match the query.
+// The newest events are read from the database, so the matches are recent ones.
+func (s *Storage) searchAuditEvents(ctx context.Context, query string) (_ []*v1.SearchResult, sErr error) {
+	rows, queryErr := s.db.QueryContext(ctx, querySearchAuditEvents, pqmatch.LikePattern(query), maxEntityCandidates)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	var results []*v1.SearchResult
+
+	for rows.Next() {
+		var id, action, actor, target, detail, createdAt string
+
+		if err := rows.Scan(&id, &action, &actor, &target, &detail, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan audit event: %w", err)
+		}
+
+		if r := auditEventSearchResult(query, id, action, actor, target, detail); r != nil {
+			r.Time = parseTimestamp(createdAt)
+			results = append(results, r)
+		}
+	}
+
+	return results, rows.Err()
+}
+
+// auditEventSearchResult returns the result for the audit event, or nil if it doesn't
+// match the query. The actor and the target of the event are reported in the result detail.
+func auditEventSearchResult(query, id, action, actor, target, detail string) *v1.SearchResult {
+	r := v1.SearchResult{
+		Kind:   v1.EntityKind_ENTITY_KIND_AUDIT_EVENT,
+		Id:     id,
+		Title:  action,
+		Detail: "actor=" + actor,
+		Score: max(
+			pqmatch.Score(query, action),
+			pqmatch.Score(query, actor),
+			pqmatch.Score(query, target),
+			pqmatch.Score(query, detail),
+		),
+	}
+
+	if target != "" {
+		r.Detail += " target=" + target
+	}
+
+	if r.Score == 0 {
+		return nil
+	}
+
+	return &r
+}
@@ -0,0 +1,129 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/mutations"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqmatch"
+	"github.com/plainq/servekit/dbkit/litekit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_queueSearchResult(t *testing.T) {
+	tags := map[string]string{"team": "billing", "env": "prod"}
+
+	tests := map[string]struct {
+		query string
+		want  *v1.SearchResult
+	}{
+		"Name": {
+			query: "orders",
+			want:  &v1.SearchResult{Kind: v1.EntityKind_ENTITY_KIND_QUEUE, Id: "Q1", Title: "orders", Score: pqmatch.ScoreExact},
+		},
+		"ID": {
+			query: "q1",
+			want:  &v1.SearchResult{Kind: v1.EntityKind_ENTITY_KIND_QUEUE, Id: "Q1", Title: "orders", Score: pqmatch.ScoreExact},
+		},
+		"Tag": {
+			query: "billing",
+			want: &v1.SearchResult{
+				Kind: v1.EntityKind_ENTITY_KIND_QUEUE, Id: "Q1", Title: "orders", Detail: "team=billing",
+				Score: pqmatch.ScoreWordPrefix,
+			},
+		},
+		"NoMatch": {query: "events", want: nil},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, queueSearchResult(tc.query, "Q1", "orders", tags), tc.want)
+		})
+	}
+}
+
+func Test_auditEventSearchResult(t *testing.T) {
+	tests := map[string]struct {
+		query string
+		want  *v1.SearchResult
+	}{
+		"Action": {
+			query: "queue.purge",
+			want: &v1.SearchResult{
+				Kind: v1.EntityKind_ENTITY_KIND_AUDIT_EVENT, Id: "E1", Title: "queue.purge",
+				Detail: "actor=alice@example.com target=Q1", Score: pqmatch.ScoreExact,
+			},
+		},
+		"Actor": {
+			query: "alice",
+			want: &v1.SearchResult{
+				Kind: v1.EntityKind_ENTITY_KIND_AUDIT_EVENT, Id: "E1", Title: "queue.purge",
+				Detail: "actor=alice@example.com target=Q1", Score: pqmatch.ScorePrefix,
+			},
+		},
+		"Detail": {
+			query: "orders",
+			want: &v1.SearchResult{
+				Kind: v1.EntityKind_ENTITY_KIND_AUDIT_EVENT, Id: "E1", Title: "queue.purge",
+				Detail: "actor=alice@example.com target=Q1", Score: pqmatch.ScoreWordPrefix,
+			},
+		},
+		"NoMatch": {query: "zzz", want: nil},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, auditEventSearchResult(tc.query, "E1", "queue.purge", "alice@example.com", "Q1", "name=orders"), tc.want)
+		})
+	}
+}
+
+func TestStorage_SearchEntities_auditEvents(t *testing.T) {
+	ctx := context.Background()
+
+	db, openErr := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "plainq.db")+"?_journal=WAL")
+	td.Require(t).CmpNoError(openErr)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	auditLog, readErr := fs.ReadFile(mutations.StorageMutations(), "12_audit_log.sql")
+	td.Require(t).CmpNoError(readErr)
+
+	_, execErr := db.Exec(string(auditLog))
+	td.Require(t).CmpNoError(execErr)
+
+	s := Storage{db: &litekit.Conn{DB: db}}
+
+	createdAt := time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC)
+
+	for _, e := range []*v1.AuditEvent{
+		{EventId: "01HQ5RJNXS6TPXK89PQWY4N8J1", Actor: "alice@example.com", Action: "queue.purge", Target: "Q1"},
+		{EventId: "01HQ5RJNXS6TPXK89PQWY4N8J2", Actor: "bob@example.com", Action: "role.create", Detail: "name=auditors"},
+	} {
+		e.CreatedAt = timestamppb.New(createdAt)
+
+		td.Require(t).CmpNoError(s.AppendAuditEvent(ctx, e))
+	}
+
+	output, searchErr := s.SearchEntities(ctx, &v1.SearchRequest{
+		Query: "auditors",
+		Kinds: []v1.EntityKind{v1.EntityKind_ENTITY_KIND_AUDIT_EVENT},
+	})
+	td.Require(t).CmpNoError(searchErr)
+
+	td.Cmp(t, output.GetResults(), []*v1.SearchResult{{
+		Kind:   v1.EntityKind_ENTITY_KIND_AUDIT_EVENT,
+		Id:     "01HQ5RJNXS6TPXK89PQWY4N8J2",
+		Title:  "role.create",
+		Detail: "actor=bob@example.com",
+		Score:  pqmatch.ScoreWordPrefix,
+		Time:   timestamppb.New(createdAt),
+	}})
+}
@@ -0,0 +1,167 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+const (
+	// messageEventsSize represents the default number of slots of the message events ring buffer.
+	messageEventsSize = 100_000
+
+	// messageEventsRetention represents the default time message events are kept for.
+	messageEventsRetention = 24 * time.Hour
+
+	// maxMessageEvents represents the maximum number of events of a message returned by the listing.
+	maxMessageEvents = 100
+)
+
+// messageEvent represents the event of the message lifecycle to record.
+type messageEvent struct {
+	MessageID string
+	Kind      v1.MessageEventKind
+	Attempts  uint32
+	VisibleAt time.Time
+	Detail    string
+}
+
+// messageEvents records events of messages to the ring buffer table.
+type messageEvents struct {
+	// size is the number of slots of the ring buffer, zero disables recording.
+	size uint64
+
+	// retention is how long events are kept for, zero keeps them until their slots are reused.
+	retention time.Duration
+}
+
+// record inserts events of messages of the queue, each replacing the oldest event in its slot.
+func (e messageEvents) record(ctx context.Context, db interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}, queueID string, events ...messageEvent,
+) (sErr error) {
+	if e.size == 0 || len(events) == 0 {
+		return nil
+	}
+
+	stmt, prepareErr := db.PrepareContext(ctx, queryInsertMessageEvent)
+	if prepareErr != nil {
+		return fmt.Errorf("prepare statement: %w", prepareErr)
+	}
+
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close prepared statement: %w", err))
+		}
+	}()
+
+	now := time.Now().UTC()
+
+	for _, event := range events {
+		var visibleAt sql.NullTime
+		if !event.VisibleAt.IsZero() {
+			visibleAt = sql.NullTime{Time: event.VisibleAt, Valid: true}
+		}
+
+		if _, err := stmt.ExecContext(ctx,
+			e.size,
+			queueID,
+			event.MessageID,
+			event.Kind,
+			event.Attempts,
+			visibleAt,
+			event.Detail,
+			now,
+		); err != nil {
+			return fmt.Errorf("record message %q event: %w", event.MessageID, err)
+		}
+	}
+
+	return nil
+}
+
+// since returns the time events recorded before are expired.
+func (e messageEvents) since() time.Time {
+	if e.retention <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().UTC().Add(-e.retention)
+}
+
+func (s *Storage) ListMessageEvents(ctx context.Context, input *v1.ListMessageEventsRequest) (_ *v1.ListMessageEventsResponse, sErr error) {
+	queueID := input.GetQueueId()
+
+	if _, ok := s.cache.getByID(queueID); !ok {
+		if _, err := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID}); err != nil {
+			return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, err)
+		}
+	}
+
+	rows, queryErr := s.db.QueryContext(ctx, querySelectMessageEvents,
+		queueID,
+		input.GetMessageId(),
+		s.events.since(),
+		maxMessageEvents,
+	)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select message %q events: %w", input.GetMessageId(), queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	output := v1.ListMessageEventsResponse{
+		Events: make([]*v1.MessageEvent, 0),
+	}
+
+	for rows.Next() {
+		var (
+			event     v1.MessageEvent
+			visibleAt sql.NullString
+			createdAt string
+		)
+
+		if err := rows.Scan(&event.Kind, &event.Attempts, &visibleAt, &event.Detail, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan message event record: %w", err)
+		}
+
+		if visibleAt.Valid {
+			event.VisibleAt = parseTimestamp(visibleAt.String)
+		}
+
+		event.CreatedAt = parseTimestamp(createdAt)
+
+		output.Events = append(output.Events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate message event records: %w", err)
+	}
+
+	// The most recent events are selected first.
+	slices.Reverse(output.Events)
+
+	return &output, nil
+}
+
+// dropExpiredMessageEvents deletes events which have outlived the retention.
+func (s *Storage) dropExpiredMessageEvents(ctx context.Context) error {
+	if s.events.retention <= 0 {
+		return nil
+	}
+
+	if _, err := s.db.ExecContext(ctx, queryDeleteMessageEventsBefore, s.events.since()); err != nil {
+		return fmt.Errorf("delete expired message events: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package litestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+func Test_messageEvents_since(t *testing.T) {
+	td.Cmp(t, messageEvents{size: 10}.since(), time.Time{})
+
+	since := messageEvents{size: 10, retention: time.Hour}.since()
+	td.Cmp(t, since, td.Between(time.Now().Add(-time.Hour-time.Minute), time.Now().Add(-time.Hour)))
+}
+
+func Test_messageEvents_recordDisabled(t *testing.T) {
+	event := messageEvent{MessageID: "01HQ5RJNXS6TPXK89PQWY4N8JD", Kind: v1.MessageEventKind_MESSAGE_EVENT_KIND_SENT}
+
+	// Disabled recording and empty events don't touch the database.
+	td.CmpNoError(t, messageEvents{}.record(context.Background(), nil, "queue", event))
+	td.CmpNoError(t, messageEvents{size: 10}.record(context.Background(), nil, "queue"))
+}
@@ -0,0 +1,283 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// evolutionBatchSize represents the default number of queue tables
+	// evolved between pauses.
+	evolutionBatchSize = 100
+
+	// evolutionPause represents the default pause between batches,
+	// which lets regular operations use the database.
+	evolutionPause = time.Second
+)
+
+// queueMutation represents a schema change of queue tables. Since every queue has
+// its own table, mutations are applied to existing tables in batches in background,
+// so mutations should be additive, e.g. add a column with a default value or an index,
+// and operations should keep working with tables which haven't been evolved yet.
+type queueMutation struct {
+	// name describes the mutation in logs.
+	name string
+
+	// query returns statements which apply the mutation to the queue table.
+	query func(queueID string) string
+}
+
+// queueMutations holds mutations of queue tables in order of application.
+// The version of the queue table is the number of mutations applied to it,
+// so mutations are only appended and never changed or removed.
+var queueMutations = []queueMutation{
+	{name: "add updated_at column", query: queryAddUpdatedAtColumn},
+	{name: "add first_received_at column", query: queryAddFirstReceivedAtColumn},
+	{name: "add msg_attributes column", query: queryAddAttributesColumn},
+}
+
+const (
+	// firstReceivedAtVersion represents the version of queue tables
+	// which have the first_received_at column.
+	firstReceivedAtVersion = 2
+
+	// attributesVersion represents the version of queue tables
+	// which have the msg_attributes column.
+	attributesVersion = 3
+)
+
+// queueTablesVersion returns the version of queue tables with all mutations applied.
+func queueTablesVersion() uint { return uint(len(queueMutations)) }
+
+// evolveQueues applies pending mutations to tables of existing queues in batches,
+// each queue in its own transaction along with its new version, so the evolution
+// resumes from the first pending queue after restart. Queues which failed
+// to evolve are logged and retried on the next start.
+func (s *Storage) evolveQueues(ctx context.Context) {
+	pending, countErr := s.countQueuesToEvolve(ctx)
+	if countErr != nil {
+		s.logger.Error("Failed to count queue tables to evolve",
+			slog.String("error", countErr.Error()),
+		)
+
+		return
+	}
+
+	s.observer.QueueTablesPending().Set(pending)
+
+	if pending == 0 {
+		return
+	}
+
+	s.logger.Info("Evolving queue tables",
+		slog.Uint64("queues_pending", pending),
+		slog.Uint64("version", uint64(queueTablesVersion())),
+	)
+
+	var (
+		start   = time.Now()
+		cursor  string
+		evolved uint64
+		failed  uint64
+	)
+
+	for {
+		last, n, f, batchErr := s.evolveBatch(ctx, cursor)
+		if batchErr != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("Queue tables evolution stopped",
+					slog.String("error", batchErr.Error()),
+				)
+			}
+
+			return
+		}
+
+		evolved += n
+		failed += f
+
+		if last == "" {
+			break
+		}
+
+		cursor = last
+
+		s.logger.Info("Queue tables evolution progress",
+			slog.Uint64("queues_evolved", evolved),
+			slog.Uint64("queues_failed", failed),
+			slog.Uint64("queues_total", pending),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-time.After(s.evolutionPause):
+		}
+	}
+
+	s.logger.Info("Queue tables evolution completed",
+		slog.Uint64("queues_evolved", evolved),
+		slog.Uint64("queues_failed", failed),
+		slog.String("duration", time.Since(start).String()),
+	)
+}
+
+// evolveBatch evolves the batch of queues following the cursor. It returns
+// the last queue of the batch, which is empty when there are no queues left,
+// and the number of evolved and failed queues.
+func (s *Storage) evolveBatch(ctx context.Context, cursor string) (string, uint64, uint64, error) {
+	queues, listErr := s.queuesToEvolve(ctx, cursor)
+	if listErr != nil {
+		return "", 0, 0, fmt.Errorf("list queues to evolve: %w", listErr)
+	}
+
+	if len(queues) == 0 {
+		return "", 0, 0, nil
+	}
+
+	// Evolution is a routine maintenance, so it doesn't run along with
+	// the garbage collection and waits while a snapshot is taken.
+	if err := s.maintenance.acquire(ctx, false); err != nil {
+		return "", 0, 0, err
+	}
+
+	defer s.maintenance.release()
+
+	var evolved, failed uint64
+
+	for _, q := range queues {
+		if err := ctx.Err(); err != nil {
+			return "", 0, 0, err
+		}
+
+		if err := s.evolveQueue(ctx, q.id, q.version); err != nil {
+			failed++
+
+			s.logger.Error("Failed to evolve queue table",
+				slog.String("queue_id", q.id),
+				slog.String("error", err.Error()),
+			)
+
+			continue
+		}
+
+		evolved++
+
+		s.observer.QueueTablesPending().Dec()
+	}
+
+	return queues[len(queues)-1].id, evolved, failed, nil
+}
+
+// evolveQueue applies mutations following the version to the queue table.
+func (s *Storage) evolveQueue(ctx context.Context, queueID string, version uint) (sErr error) {
+	tx, txErr := s.beginTx(ctx, opEvolveQueue, false)
+	if txErr != nil {
+		return fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	// The queue may have been deleted since the batch was listed.
+	var exists bool
+	if err := tx.QueryRowContext(ctx, queryQueueExists, queueID).Scan(&exists); err != nil {
+		return fmt.Errorf("check queue exists: %w", err)
+	}
+
+	if !exists {
+		return nil
+	}
+
+	if err := applyQueueMutations(ctx, tx.Tx, queueID, version); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	return nil
+}
+
+// queueTableVersion returns the version of the queue table, so operations
+// can tell whether columns added by mutations are there yet.
+func queueTableVersion(ctx context.Context, tx *sql.Tx, queueID string) (uint, error) {
+	var version uint
+	if err := tx.QueryRowContext(ctx, querySelectQueueTableVersion, queueID).Scan(&version); err != nil {
+		return 0, fmt.Errorf("select queue %q table version: %w", queueID, err)
+	}
+
+	return version, nil
+}
+
+// applyQueueMutations applies mutations following the version
+// to the queue table and records the new version of the table.
+func applyQueueMutations(ctx context.Context, tx *sql.Tx, queueID string, version uint) error {
+	for _, m := range queueMutations[min(version, queueTablesVersion()):] {
+		if _, err := tx.ExecContext(ctx, m.query(queueID)); err != nil {
+			return fmt.Errorf("apply mutation %q: %w", m.name, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, queryUpsertQueueTableVersion, queueID, queueTablesVersion()); err != nil {
+		return fmt.Errorf("update queue table version: %w", err)
+	}
+
+	return nil
+}
+
+// queueToEvolve holds the queue and the current version of its table.
+type queueToEvolve struct {
+	id      string
+	version uint
+}
+
+func (s *Storage) queuesToEvolve(ctx context.Context, cursor string) (_ []queueToEvolve, sErr error) {
+	rows, queryErr := s.db.QueryContext(ctx, querySelectQueuesToEvolve, queueTablesVersion(), cursor, s.evolutionBatchSize)
+	if queryErr != nil {
+		return nil, fmt.Errorf("execute query: %w", queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	queues := make([]queueToEvolve, 0, s.evolutionBatchSize)
+
+	for rows.Next() {
+		var q queueToEvolve
+
+		if err := rows.Scan(&q.id, &q.version); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		queues = append(queues, q)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return queues, nil
+}
+
+func (s *Storage) countQueuesToEvolve(ctx context.Context) (uint64, error) {
+	var count uint64
+
+	if err := s.db.QueryRowContext(ctx, queryCountQueuesToEvolve, queueTablesVersion()).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,313 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/mutations"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/servekit/dbkit/litekit"
+	"github.com/plainq/servekit/idkit"
+	"github.com/plainq/servekit/logkit"
+)
+
+func Test_queueMutations(t *testing.T) {
+	td.Cmp(t, queueTablesVersion(), uint(len(queueMutations)))
+
+	names := make(map[string]struct{}, len(queueMutations))
+
+	for _, m := range queueMutations {
+		t.Run(m.name, func(t *testing.T) {
+			td.CmpNotEmpty(t, m.name)
+			td.Cmp(t, m.query("CSGE6N05SHOB6TB8V5FG"), td.Contains("CSGE6N05SHOB6TB8V5FG"))
+
+			_, seen := names[m.name]
+			td.CmpFalse(t, seen, "mutation names should be unique")
+
+			names[m.name] = struct{}{}
+		})
+	}
+}
+
+func TestStorage_evolveBatch(t *testing.T) {
+	ctx := context.Background()
+	s := newEvolutionStorage(t, 2)
+
+	// Identifiers grow with the time of creation and queues are listed in their order.
+	versions := []uint{0, 1, 0, queueTablesVersion(), 0}
+	queues := make([]string, 0, len(versions))
+
+	for _, version := range versions {
+		queues = append(queues, createQueueAtVersion(t, s, version))
+	}
+
+	pending, countErr := s.countQueuesToEvolve(ctx)
+	td.Require(t).CmpNoError(countErr)
+	td.Cmp(t, pending, uint64(4))
+
+	var (
+		cursor  string
+		batches [][]string
+	)
+
+	for {
+		listed, listErr := s.queuesToEvolve(ctx, cursor)
+		td.Require(t).CmpNoError(listErr)
+
+		last, evolved, failed, batchErr := s.evolveBatch(ctx, cursor)
+		td.Require(t).CmpNoError(batchErr)
+
+		if last == "" {
+			td.CmpEmpty(t, listed)
+			break
+		}
+
+		ids := make([]string, 0, len(listed))
+		for _, q := range listed {
+			ids = append(ids, q.id)
+		}
+
+		td.Cmp(t, last, ids[len(ids)-1])
+		td.Cmp(t, evolved, uint64(len(ids)))
+		td.Cmp(t, failed, uint64(0))
+
+		batches = append(batches, ids)
+		cursor = last
+	}
+
+	// The queue which is evolved already isn't listed.
+	pendingQueues := slices.Delete(slices.Clone(queues), 3, 4)
+	td.Cmp(t, batches, [][]string{pendingQueues[:2], pendingQueues[2:]})
+
+	pending, countErr = s.countQueuesToEvolve(ctx)
+	td.Require(t).CmpNoError(countErr)
+	td.Cmp(t, pending, uint64(0))
+
+	for _, queueID := range queues {
+		td.Cmp(t, queueTableColumns(t, s, queueID), td.SuperBagOf("updated_at", "first_received_at"), queueID)
+		td.Cmp(t, queueTableVersionOf(t, s, queueID), queueTablesVersion(), queueID)
+	}
+}
+
+func TestStorage_evolveQueues(t *testing.T) {
+	ctx := context.Background()
+	s := newEvolutionStorage(t, 2)
+
+	queues := []string{
+		createQueueAtVersion(t, s, 0),
+		createQueueAtVersion(t, s, 1),
+		createQueueAtVersion(t, s, 0),
+	}
+
+	s.evolveQueues(ctx)
+
+	pending, countErr := s.countQueuesToEvolve(ctx)
+	td.Require(t).CmpNoError(countErr)
+	td.Cmp(t, pending, uint64(0))
+
+	for _, queueID := range queues {
+		td.Cmp(t, queueTableColumns(t, s, queueID), td.SuperBagOf("updated_at", "first_received_at"), queueID)
+		td.Cmp(t, queueTableVersionOf(t, s, queueID), queueTablesVersion(), queueID)
+	}
+}
+
+func TestStorage_evolveQueue(t *testing.T) {
+	ctx := context.Background()
+	s := newEvolutionStorage(t, 2)
+
+	t.Run("Resume", func(t *testing.T) {
+		queueID := createQueueAtVersion(t, s, 1)
+
+		// Mutations applied already would fail with the duplicate column, so only pending ones are applied.
+		td.CmpNoError(t, s.evolveQueue(ctx, queueID, 1))
+		td.Cmp(t, queueTableColumns(t, s, queueID), td.SuperBagOf("updated_at", "first_received_at"))
+		td.Cmp(t, queueTableVersionOf(t, s, queueID), queueTablesVersion())
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		queueID := createQueueAtVersion(t, s, 0)
+
+		_, deleteErr := s.db.ExecContext(ctx, `delete from queue_properties where queue_id = ?;`, queueID)
+		td.Require(t).CmpNoError(deleteErr)
+
+		// The queue deleted since the batch was listed is skipped without recording its version.
+		td.CmpNoError(t, s.evolveQueue(ctx, queueID, 0))
+		td.Cmp(t, queueTableVersionOf(t, s, queueID), uint(0))
+	})
+}
+
+func Test_firstReceivedAtVersion(t *testing.T) {
+	ctx := context.Background()
+	s := newEvolutionStorage(t, 2)
+
+	queueID := createQueueAtVersion(t, s, firstReceivedAtVersion-1)
+
+	_, insertErr := s.db.ExecContext(ctx, `insert into `+queueID+` (msg_id, msg_body) values ('m1', 'message');`)
+	td.Require(t).CmpNoError(insertErr)
+
+	// receive selects and updates the oldest message the way Receive does for the table of its version.
+	receive := func(now string) {
+		t.Helper()
+
+		tx, txErr := s.db.BeginTx(ctx, nil)
+		td.Require(t).CmpNoError(txErr)
+
+		defer func() { _ = tx.Rollback() }()
+
+		version, versionErr := queueTableVersion(ctx, tx, queueID)
+		td.Require(t).CmpNoError(versionErr)
+
+		firstReceivedAt := version >= firstReceivedAtVersion
+
+		var (
+			id, body, sentAt string
+			retries          uint32
+			firstRecvAt      sql.NullString
+			attributes       sql.NullString
+		)
+
+		selectErr := tx.QueryRowContext(ctx, querySelectMessages(queueID, firstReceivedAt, false, ""), maxReceiveAttempts, 1).
+			Scan(&id, &body, &retries, &sentAt, &firstRecvAt, &attributes)
+		td.Require(t).CmpNoError(selectErr)
+
+		// The message stays visible, so it's received again by the next call.
+		_, updateErr := tx.ExecContext(ctx, queryUpdateMessages(queueID, firstReceivedAt), "1970-01-01 00:00:00", now, id)
+		td.Require(t).CmpNoError(updateErr)
+
+		td.Require(t).CmpNoError(tx.Commit())
+	}
+
+	firstReceivedAtOf := func(msgID string) sql.NullString {
+		t.Helper()
+
+		var firstRecvAt sql.NullString
+
+		err := s.db.QueryRowContext(ctx, `select first_received_at from `+queueID+` where msg_id = ?;`, msgID).Scan(&firstRecvAt)
+		td.Require(t).CmpNoError(err)
+
+		return firstRecvAt
+	}
+
+	// The column which the table of the old version doesn't have isn't queried.
+	_, queryErr := s.db.ExecContext(ctx, querySelectMessages(queueID, true, false, ""), maxReceiveAttempts, 1)
+	td.CmpError(t, queryErr)
+
+	receive("2026-10-17 01:00:00")
+
+	td.Require(t).CmpNoError(s.evolveQueue(ctx, queueID, firstReceivedAtVersion-1))
+
+	receive("2026-10-17 02:00:00")
+
+	// The newer message is created earlier, so it's received first from now on.
+	_, insertErr = s.db.ExecContext(ctx, `insert into `+queueID+` (msg_id, msg_body, created_at) values ('m2', 'message', 0);`)
+	td.Require(t).CmpNoError(insertErr)
+
+	receive("2026-10-17 03:00:00")
+	receive("2026-10-17 04:00:00")
+
+	// The message received before the evolution isn't recorded as received for the first time.
+	td.Cmp(t, firstReceivedAtOf("m1"), sql.NullString{})
+	td.Cmp(t, firstReceivedAtOf("m2"), sql.NullString{String: "2026-10-17 03:00:00", Valid: true})
+}
+
+// newEvolutionStorage returns the Storage which evolves queue tables in batches of the given size.
+// The database holds queue properties and versions of queue tables only, which are enough for the evolution.
+func newEvolutionStorage(t *testing.T, batchSize uint) *Storage {
+	t.Helper()
+
+	db, openErr := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "plainq.db")+"?_journal=WAL")
+	td.Require(t).CmpNoError(openErr)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, createErr := db.Exec(`create table queue_properties (queue_id varchar(26) not null primary key);`)
+	td.Require(t).CmpNoError(createErr)
+
+	queueTables, readErr := fs.ReadFile(mutations.StorageMutations(), "11_queue_tables.sql")
+	td.Require(t).CmpNoError(readErr)
+
+	_, execErr := db.Exec(string(queueTables))
+	td.Require(t).CmpNoError(execErr)
+
+	s := Storage{
+		db:                 &litekit.Conn{DB: db},
+		logger:             logkit.NewNop(),
+		observer:           telemetry.NewObserver(),
+		maintenance:        newMaintenance(),
+		evolutionBatchSize: batchSize,
+	}
+
+	return &s
+}
+
+// createQueueAtVersion creates the queue with the table of the given version,
+// as if it had been created by the older server and evolved up to the version.
+func createQueueAtVersion(t *testing.T, s *Storage, version uint) string {
+	t.Helper()
+
+	ctx := context.Background()
+	queueID := idkit.XID()
+
+	_, insertErr := s.db.ExecContext(ctx, `insert into queue_properties (queue_id) values (?);`, queueID)
+	td.Require(t).CmpNoError(insertErr)
+
+	_, createErr := s.db.ExecContext(ctx, queryCreateQueueTable(queueID))
+	td.Require(t).CmpNoError(createErr)
+
+	for _, m := range queueMutations[:version] {
+		_, err := s.db.ExecContext(ctx, m.query(queueID))
+		td.Require(t).CmpNoError(err, m.name)
+	}
+
+	// Tables of the initial version have no record.
+	if version > 0 {
+		_, err := s.db.ExecContext(ctx, queryUpsertQueueTableVersion, queueID, version)
+		td.Require(t).CmpNoError(err)
+	}
+
+	return queueID
+}
+func queueTableColumns(t *testing.T, s *Storage, queueID string) []string {
+	t.Helper()
+
+	rows, queryErr := s.db.QueryContext(context.Background(), `select name from pragma_table_info(?);`, queueID)
+	td.Require(t).CmpNoError(queryErr)
+
+	defer func() { _ = rows.Close() }()
+
+	var columns []string
+
+	for rows.Next() {
+		var column string
+
+		td.Require(t).CmpNoError(rows.Scan(&column))
+
+		columns = append(columns, column)
+	}
+
+	td.Require(t).CmpNoError(rows.Err())
+
+	return columns
+}
+
+func queueTableVersionOf(t *testing.T, s *Storage, queueID string) uint {
+	t.Helper()
+
+	ctx := context.Background()
+
+	tx, txErr := s.db.BeginTx(ctx, nil)
+	td.Require(t).CmpNoError(txErr)
+
+	defer func() { _ = tx.Rollback() }()
+
+	version, versionErr := queueTableVersion(ctx, tx, queueID)
+	td.Require(t).CmpNoError(versionErr)
+
+	return version
+}
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type sweepResult struct {
@@ -19,15 +21,15 @@ type sweepResult struct {
 func (s *Storage) gc(ctx context.Context) {
 	defer func() {
 		if r := recover(); r != nil {
-			s.logger.Error("GC routine recovered from panic",
+			s.gcLogger.Error("GC routine recovered from panic",
 				slog.Any("panic", r),
 			)
 		}
 	}()
 
-	s.logger.Debug("Starting garbage collection routine...")
+	s.gcLogger.Debug("Starting garbage collection routine...")
 
-	timer := time.NewTicker(s.gcTimeout)
+	timer := time.NewTicker(s.GCTimeout())
 	defer timer.Stop()
 
 	for {
@@ -35,50 +37,128 @@ func (s *Storage) gc(ctx context.Context) {
 		case <-ctx.Done():
 			return
 
-		case <-timer.C:
-			start := time.Now()
+		case <-s.gcReset:
+			timer.Reset(s.GCTimeout())
 
-			// If there are no queues, there is no need for GC, obviously.
-			if s.observer.QueuesExist().Get() == 0 {
-				continue
+		case <-timer.C:
+			// Messages of servers which follow or stand by are collected by the leader,
+			// so the run is skipped as done.
+			if (s.isLeader == nil || s.isLeader()) && !s.collect(ctx) {
+				return
 			}
 
-			s.observer.GCSchedules().Inc()
+			s.gcLastRun.Store(time.Now().UnixNano())
+		}
+	}
+}
 
-			queues, queuesErr := s.queuesForGC(ctx)
-			if queuesErr != nil {
-				panic(fmt.Sprintf("get queue IDs for GC: %v", queuesErr))
-			}
+// collect runs a single garbage collection cycle.
+// Returns false when the garbage collection should be stopped.
+func (s *Storage) collect(ctx context.Context) bool {
+	// If there are no queues, there is no need for GC, obviously.
+	if s.observer.QueuesExist().Get() == 0 {
+		return true
+	}
 
-			for _, queueID := range queues {
-				s.logger.Debug("Running garbage collection for queue",
-					slog.String("queue_id", queueID),
-				)
+	// The span of the run holds spans of sweeps, and its start
+	// shows how long the run waited for other maintenance.
+	ctx, span := tracer.Start(ctx, "litestore.collect")
+	defer span.End()
+
+	if err := s.maintenance.acquire(ctx, false); err != nil {
+		return false
+	}
+
+	held := true
+	start := time.Now()
+
+	defer func() {
+		if held {
+			s.maintenance.release()
+		}
+	}()
+
+	s.observer.GCSchedules().Inc()
+
+	queues, queuesErr := s.queuesForGC(ctx)
+	if queuesErr != nil {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		panic(fmt.Sprintf("get queue IDs for GC: %v", queuesErr))
+	}
+
+	span.SetAttributes(attribute.Int("queues_total", len(queues)))
+
+	for i, queueID := range queues {
+		// Each queue is swept in its own transaction, so queues which
+		// have been swept already stay swept when GC is stopped.
+		if ctx.Err() != nil {
+			s.gcLogger.Debug("Garbage collection interrupted",
+				slog.Int("queues_swept", i),
+				slog.Int("queues_total", len(queues)),
+			)
 
-				result, sweepErr := s.sweep(ctx, queueID)
-				if sweepErr != nil {
-					panic(fmt.Errorf("sweep queue (id: %q): %s", queueID, sweepErr.Error()))
-				}
+			return false
+		}
+
+		// Let a waiting snapshot run between queues, so it never
+		// captures a partially swept queue and isn't blocked by long GC runs.
+		if err := s.maintenance.yield(ctx); err != nil {
+			held = false
+
+			s.gcLogger.Debug("Garbage collection interrupted",
+				slog.Int("queues_swept", i),
+				slog.Int("queues_total", len(queues)),
+			)
 
-				s.logger.Debug("Garbage collection",
+			return false
+		}
+
+		s.gcLogger.Debug("Running garbage collection for queue",
+			slog.String("queue_id", queueID),
+		)
+
+		result, sweepErr := s.sweep(ctx, queueID)
+		if sweepErr != nil {
+			if errors.Is(sweepErr, pqerr.ErrInterrupted) || ctx.Err() != nil {
+				s.gcLogger.Debug("Garbage collection interrupted",
 					slog.String("queue_id", queueID),
-					slog.String("duration", result.Duration.String()),
-					slog.Uint64("messages_dropped", result.MessagesDropped),
+					slog.String("error", sweepErr.Error()),
 				)
+
+				return false
 			}
 
-			s.observer.GCDuration().Dur(start)
+			panic(fmt.Errorf("sweep queue (id: %q): %s", queueID, sweepErr.Error()))
 		}
+
+		s.gcLogger.Debug("Garbage collection",
+			slog.String("queue_id", queueID),
+			slog.String("duration", result.Duration.String()),
+			slog.Uint64("messages_dropped", result.MessagesDropped),
+		)
 	}
+
+	if err := s.dropExpiredMessageEvents(ctx); err != nil && ctx.Err() == nil {
+		s.gcLogger.Error("Failed to drop expired message events",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	s.observer.GCDuration().Dur(start)
+
+	return true
 }
 
 func (s *Storage) queuesForGC(ctx context.Context) (_ []string, sErr error) {
 	limit := s.observer.QueuesExist().Get()
 	offset := uint64(0)
-	query := s.querier.selectQueuesForGC(s.gcTimeout, limit, offset)
+	query := s.querier.selectQueuesForGC(s.GCTimeout(), limit, offset)
 	queues := make([]string, 0, limit)
 
-	tx, txErr := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	tx, txErr := s.beginTx(ctx, opGC, false)
 	if txErr != nil {
 		return nil, fmt.Errorf(fmtBeginTxError, txErr)
 	}
@@ -115,7 +195,7 @@ func (s *Storage) queuesForGC(ctx context.Context) (_ []string, sErr error) {
 	}
 
 	for {
-		if err := getQueues(ctx, tx); err != nil {
+		if err := getQueues(ctx, tx.Tx); err != nil {
 			return nil, fmt.Errorf("query queues: %w", err)
 		}
 
@@ -124,7 +204,7 @@ func (s *Storage) queuesForGC(ctx context.Context) (_ []string, sErr error) {
 		}
 
 		offset += limit
-		query = s.querier.selectQueuesForGC(s.gcTimeout, limit, offset)
+		query = s.querier.selectQueuesForGC(s.GCTimeout(), limit, offset)
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -137,16 +217,18 @@ func (s *Storage) queuesForGC(ctx context.Context) (_ []string, sErr error) {
 func (s *Storage) sweep(ctx context.Context, queueID string) (_ *sweepResult, sErr error) {
 	start := time.Now()
 
-	props, ok := s.cache.getByID(queueID)
-	if !ok {
-		return nil, fmt.Errorf("queue props (id: %q) not cached", queueID)
+	props, propsErr := s.queueProps(ctx, queueID)
+	if propsErr != nil {
+		return nil, propsErr
 	}
 
-	tx, txErr := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	tx, txErr := s.beginTx(ctx, opGC, false)
 	if txErr != nil {
-		panic(fmt.Errorf("begin transaction: %w", txErr))
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
 	}
 
+	tx.span.SetAttributes(attribute.String("queue_id", queueID))
+
 	defer func() {
 		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
 			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
@@ -157,7 +239,7 @@ func (s *Storage) sweep(ctx context.Context, queueID string) (_ *sweepResult, sE
 
 	switch props.EvictionPolicy {
 	case uint32(v1.EvictionPolicy_EVICTION_POLICY_DROP):
-		dropped, dropErr := dropMessages(ctx, tx, props)
+		dropped, dropErr := dropMessages(ctx, tx.Tx, props)
 		if dropErr != nil {
 			return nil, fmt.Errorf("apply drop (drop) policy to a queue (id: %q): %w", queueID, dropErr)
 		}
@@ -165,18 +247,26 @@ func (s *Storage) sweep(ctx context.Context, queueID string) (_ *sweepResult, sE
 		messagesDropped = dropped
 
 	case uint32(v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER):
-		moved, moveErr := moveMessagesToDLQ(ctx, tx, props)
+		moved, moveErr := moveMessagesToDLQ(ctx, tx.Tx, props, s.events)
 		if moveErr != nil {
 			return nil, fmt.Errorf("apply drop (dead letter) policy to a queue (id: %q): %w", queueID, moveErr)
 		}
 
 		messagesDropped = moved
 
+	case uint32(v1.EvictionPolicy_EVICTION_POLICY_ARCHIVE):
+		archived, archiveErr := archiveMessages(ctx, tx.Tx, props)
+		if archiveErr != nil {
+			return nil, fmt.Errorf("apply drop (archive) policy to a queue (id: %q): %w", queueID, archiveErr)
+		}
+
+		messagesDropped = archived
+
 	default:
 		return nil, fmt.Errorf("queue props (id: %q) contains unsuppoted drop policy: %d", queueID, props.EvictionPolicy)
 	}
 
-	if err := updateQueuePropsAfterGC(ctx, queueID, tx); err != nil {
+	if err := updateQueuePropsAfterGC(ctx, queueID, tx.Tx); err != nil {
 		return nil, fmt.Errorf("update queue (id: %q) props record: %w", queueID, err)
 	}
 
@@ -215,7 +305,7 @@ func dropMessages(ctx context.Context, tx *sql.Tx, props QueueProps) (uint64, er
 	return uint64(rows), nil
 }
 
-func moveMessagesToDLQ(ctx context.Context, tx *sql.Tx, props QueueProps) (_ uint64, sErr error) {
+func moveMessagesToDLQ(ctx context.Context, tx *sql.Tx, props QueueProps, events messageEvents) (_ uint64, sErr error) {
 	rows, execErr := tx.QueryContext(ctx, querySelectMoveToDLQ(props.ID),
 		props.MaxReceiveAttempts,
 		props.RetentionPeriodSeconds,
@@ -238,6 +328,10 @@ func moveMessagesToDLQ(ctx context.Context, tx *sql.Tx, props QueueProps) (_ uin
 	var moved uint64
 
 	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return 0, &pqerr.InterruptedError{Operation: "move to dead letter queue", Processed: int(moved), Err: err}
+		}
+
 		var (
 			msgID   string
 			msgBody []byte
@@ -247,10 +341,18 @@ func moveMessagesToDLQ(ctx context.Context, tx *sql.Tx, props QueueProps) (_ uin
 			return 0, fmt.Errorf("scan message record: %w", err)
 		}
 
-		if _, err := stmt.ExecContext(ctx, msgID, msgBody); err != nil {
+		if _, err := stmt.ExecContext(ctx, msgID, msgBody, time.Now().UTC()); err != nil {
 			return 0, fmt.Errorf("update message record: %w", err)
 		}
 
+		if err := events.record(ctx, tx, props.ID, messageEvent{
+			MessageID: msgID,
+			Kind:      v1.MessageEventKind_MESSAGE_EVENT_KIND_MOVED_TO_DEAD_LETTER,
+			Detail:    props.DeadLetterQueueID,
+		}); err != nil {
+			return 0, err
+		}
+
 		moved++
 	}
 
@@ -0,0 +1,182 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/plainq/plainq/internal/server/rbac"
+	"github.com/plainq/servekit/errkit"
+)
+
+func (s *Storage) QueueGrants(ctx context.Context, subject, queueID string) (*rbac.Grants, error) {
+	cached, generation, ok := s.grants.get(subject, queueID)
+	if ok {
+		s.observer.GrantsCacheHits().Inc()
+		return cached, nil
+	}
+
+	s.observer.GrantsCacheMisses().Inc()
+
+	grants, err := s.queueGrants(ctx, subject, queueID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.grants.put(subject, queueID, generation, grants)
+
+	return grants, nil
+}
+
+// queueGrants resolves grants of the subject on the queue from the database.
+func (s *Storage) queueGrants(ctx context.Context, subject, queueID string) (*rbac.Grants, error) {
+	var grants rbac.Grants
+
+	roles, rolesErr := s.subjectRoles(ctx, subject)
+	if rolesErr != nil {
+		return nil, rolesErr
+	}
+
+	for _, role := range roles {
+		if role == rbac.AdminRole {
+			grants.Admin = true
+		}
+	}
+
+	if queueID != "" {
+		name, nameErr := s.queueName(ctx, queueID)
+		if nameErr != nil {
+			return nil, nameErr
+		}
+
+		grants.QueueName = name
+
+		var send, receive, purge, del, update bool
+
+		if err := s.db.QueryRowContext(ctx, querySelectSubjectQueuePermissions, subject, queueID).Scan(
+			&send,
+			&receive,
+			&purge,
+			&del,
+			&update,
+		); err != nil {
+			return nil, fmt.Errorf("select queue permissions (id: %q): %w", queueID, err)
+		}
+
+		for _, p := range []struct {
+			op      rbac.Operation
+			granted bool
+		}{
+			{op: rbac.OpSend, granted: send},
+			{op: rbac.OpReceive, granted: receive},
+			{op: rbac.OpPurge, granted: purge},
+			{op: rbac.OpDelete, granted: del},
+			{op: rbac.OpUpdate, granted: update},
+		} {
+			if p.granted {
+				grants.Operations = append(grants.Operations, p.op)
+			}
+		}
+	}
+
+	policies, policiesErr := s.subjectPolicies(ctx, subject)
+	if policiesErr != nil {
+		return nil, policiesErr
+	}
+
+	grants.Policies = policies
+
+	return &grants, nil
+}
+
+// subjectRoles returns names of roles of the subject.
+func (s *Storage) subjectRoles(ctx context.Context, subject string) (_ []string, sErr error) {
+	rows, queryErr := s.db.QueryContext(ctx, querySelectSubjectRoles, subject)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select roles of %q: %w", subject, queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	var roles []string
+
+	for rows.Next() {
+		var role string
+
+		if err := rows.Scan(&role); err != nil {
+			return nil, fmt.Errorf("scan role: %w", err)
+		}
+
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// subjectPolicies returns policies attached to roles of the subject.
+func (s *Storage) subjectPolicies(ctx context.Context, subject string) (_ rbac.PolicySet, sErr error) {
+	rows, queryErr := s.db.QueryContext(ctx, querySelectSubjectPolicies, subject)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select policies of %q: %w", subject, queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	var policies rbac.PolicySet
+
+	for rows.Next() {
+		var (
+			name     string
+			document []byte
+		)
+
+		if err := rows.Scan(&name, &document); err != nil {
+			return nil, fmt.Errorf("scan policy: %w", err)
+		}
+
+		policy, parseErr := rbac.ParsePolicy(name, document)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+
+		policies = append(policies, policy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate policies: %w", err)
+	}
+
+	return policies, nil
+}
+
+// queueName returns the name of the queue from the cache or from the database.
+func (s *Storage) queueName(ctx context.Context, queueID string) (string, error) {
+	if props, ok := s.cache.getByID(queueID); ok {
+		return props.Name, nil
+	}
+
+	var name string
+
+	if err := s.db.QueryRowContext(ctx, querySelectQueueName, queueID).Scan(&name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: queue (id: %q) not found", errkit.ErrNotFound, queueID)
+		}
+
+		return "", fmt.Errorf("select queue name (id: %q): %w", queueID, err)
+	}
+
+	return name, nil
+}
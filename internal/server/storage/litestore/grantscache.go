@@ -0,0 +1,114 @@
+package litestore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/rbac"
+)
+
+// grantsCache holds grants of subjects on queues resolved by the QueueGrants,
+// so that authorization of hot send and receive paths doesn't query roles,
+// permissions and policies of the subject on each request.
+//
+// Entries are dropped by the invalidate on any change of roles, permissions,
+// policies, users, service accounts and queues. The TTL bounds how long
+// changes made bypassing the Storage, e.g. by another process, are not seen.
+type grantsCache struct {
+	ttl  time.Duration
+	size int
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[grantsKey]grantsEntry
+
+	// generation is incremented by the invalidate, so grants resolved
+	// before the change are not put to the cache after it.
+	generation uint64
+}
+
+// grantsKey identifies cached grants of the subject on the queue.
+type grantsKey struct {
+	subject string
+	queueID string
+}
+
+// grantsEntry represents cached grants.
+type grantsEntry struct {
+	grants rbac.Grants
+
+	// until is the time the entry is dropped from the cache.
+	until time.Time
+}
+
+// newGrantsCache returns a pointer to a new instance of grantsCache,
+// which holds up to size entries. Zero TTL disables the cache.
+func newGrantsCache(size int, ttl time.Duration) *grantsCache {
+	c := grantsCache{
+		ttl:     ttl,
+		size:    size,
+		now:     time.Now,
+		entries: make(map[grantsKey]grantsEntry),
+	}
+
+	return &c
+}
+
+// get returns cached grants of the subject on the queue and the generation
+// of the cache, which should be passed to the put along with resolved grants.
+func (c *grantsCache) get(subject, queueID string) (*rbac.Grants, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := grantsKey{subject: subject, queueID: queueID}
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, c.generation, false
+	}
+
+	if !c.now().Before(entry.until) {
+		delete(c.entries, key)
+		return nil, c.generation, false
+	}
+
+	// Callers get their own copy, so they can't change cached grants.
+	grants := entry.grants
+
+	return &grants, c.generation, true
+}
+
+// put caches grants of the subject on the queue unless
+// the cache has been invalidated since the generation.
+func (c *grantsCache) put(subject, queueID string, generation uint64, grants *rbac.Grants) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	// Grants are cheap to resolve again, so the full cache
+	// is emptied rather than searched for the oldest entries.
+	if len(c.entries) >= c.size {
+		clear(c.entries)
+	}
+
+	c.entries[grantsKey{subject: subject, queueID: queueID}] = grantsEntry{
+		grants: *grants,
+		until:  c.now().Add(c.ttl),
+	}
+}
+
+// invalidate drops all cached grants.
+func (c *grantsCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	clear(c.entries)
+}
@@ -0,0 +1,97 @@
+package litestore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/rbac"
+)
+
+func Test_grantsCache(t *testing.T) {
+	type tcase struct {
+		ttl    time.Duration
+		size   int
+		action func(c *grantsCache, now *time.Time)
+
+		// queueID is the queue the cache is looked up for after the action.
+		queueID string
+		wantOK  bool
+	}
+
+	grants := rbac.Grants{QueueName: "orders", Operations: []rbac.Operation{rbac.OpSend}}
+
+	tests := map[string]tcase{
+		"Hit": {
+			ttl:    time.Minute,
+			size:   10,
+			action: func(*grantsCache, *time.Time) {},
+			wantOK: true,
+		},
+
+		"Expired": {
+			ttl:    time.Minute,
+			size:   10,
+			action: func(_ *grantsCache, now *time.Time) { *now = now.Add(time.Minute) },
+			wantOK: false,
+		},
+
+		"Invalidated": {
+			ttl:    time.Minute,
+			size:   10,
+			action: func(c *grantsCache, _ *time.Time) { c.invalidate() },
+			wantOK: false,
+		},
+
+		"ResolvedBeforeInvalidation": {
+			ttl:  time.Minute,
+			size: 10,
+			action: func(c *grantsCache, _ *time.Time) {
+				c.invalidate()
+				c.put("bob@example.com", "queue-b", 0, &grants)
+			},
+			queueID: "queue-b",
+			wantOK:  false,
+		},
+
+		"Full": {
+			ttl:    time.Minute,
+			size:   1,
+			action: func(c *grantsCache, _ *time.Time) { c.put("alice@example.com", "queue-a", 0, &grants) },
+			wantOK: false,
+		},
+
+		"Disabled": {
+			size:   10,
+			action: func(*grantsCache, *time.Time) {},
+			wantOK: false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+
+			cache := newGrantsCache(tc.size, tc.ttl)
+			cache.now = func() time.Time { return now }
+
+			_, generation, ok := cache.get("bob@example.com", "queue-a")
+			td.CmpFalse(t, ok)
+
+			cache.put("bob@example.com", "queue-a", generation, &grants)
+			tc.action(cache, &now)
+
+			queueID := tc.queueID
+			if queueID == "" {
+				queueID = "queue-a"
+			}
+
+			cached, _, ok := cache.get("bob@example.com", queueID)
+			td.Cmp(t, ok, tc.wantOK)
+
+			if tc.wantOK {
+				td.Cmp(t, cached, &grants)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// jobKindPurge represents the kind of asynchronous purge jobs.
+	jobKindPurge = "purge"
+
+	// purgeBatchSize represents the number of messages
+	// deleted by a single transaction of the purge job.
+	purgeBatchSize = 1000
+
+	// purgeBatchPause represents the pause between batches of the purge job,
+	// which lets regular operations use the database.
+	purgeBatchPause = 10 * time.Millisecond
+
+	// defaultJobsLimit represents the default number of jobs returned by the listing.
+	defaultJobsLimit = 20
+
+	// maxJobsLimit represents the maximum number of jobs returned by the listing.
+	maxJobsLimit = 100
+
+	// errJobInterrupted is the error of jobs which have been running when the server stopped.
+	errJobInterrupted = "interrupted by the server shutdown"
+)
+
+// errJobCanceled is the cause of the cancellation of jobs stopped by the CancelJob.
+var errJobCanceled = errors.New("job has been canceled")
+
+// jobFunc performs the work of the background job. It should stop once the context is canceled.
+type jobFunc func(ctx context.Context, jobID string) error
+
+// jobRunner keeps track of background jobs running in the process,
+// so they can be canceled and awaited on shutdown.
+type jobRunner struct {
+	mu      sync.Mutex
+	running map[string]*runningJob
+	wg      sync.WaitGroup
+}
+
+// runningJob represents the background job running in the process.
+type runningJob struct {
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+}
+
+func newJobRunner() *jobRunner {
+	return &jobRunner{running: make(map[string]*runningJob)}
+}
+
+// cancel cancels the running job and returns the channel which is closed once the job has stopped.
+// It reports false when the job is not running in the process.
+func (r *jobRunner) cancel(jobID string) (<-chan struct{}, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.running[jobID]
+	if !ok {
+		return nil, false
+	}
+
+	job.cancel(errJobCanceled)
+
+	return job.done, true
+}
+
+// wait blocks until all running jobs have stopped.
+func (r *jobRunner) wait() { r.wg.Wait() }
+
+func (s *Storage) GetJob(ctx context.Context, input *v1.GetJobRequest) (*v1.GetJobResponse, error) {
+	job, scanErr := scanJob(s.db.QueryRowContext(ctx, querySelectJob, input.GetJobId()))
+	if scanErr != nil {
+		if errors.Is(scanErr, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: job %q", errkit.ErrNotFound, input.GetJobId())
+		}
+
+		return nil, fmt.Errorf("select job %q: %w", input.GetJobId(), scanErr)
+	}
+
+	return &v1.GetJobResponse{Job: job}, nil
+}
+
+func (s *Storage) ListJobs(ctx context.Context, input *v1.ListJobsRequest) (_ *v1.ListJobsResponse, sErr error) {
+	limit := input.GetLimit()
+
+	switch {
+	case limit == 0:
+		limit = defaultJobsLimit
+
+	case limit > maxJobsLimit:
+		return nil, fmt.Errorf("%w: jobs limit should not exceed %d", errkit.ErrInvalidArgument, maxJobsLimit)
+	}
+
+	rows, queryErr := s.db.QueryContext(ctx, queryListJobs, input.GetQueueId(), input.GetState(), limit)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select jobs: %w", queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	output := v1.ListJobsResponse{Jobs: make([]*v1.Job, 0, limit)}
+
+	for rows.Next() {
+		job, scanErr := scanJob(rows)
+		if scanErr != nil {
+			return nil, fmt.Errorf("scan job: %w", scanErr)
+		}
+
+		output.Jobs = append(output.Jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate jobs: %w", err)
+	}
+
+	return &output, nil
+}
+
+func (s *Storage) CancelJob(ctx context.Context, input *v1.CancelJobRequest) (*v1.CancelJobResponse, error) {
+	jobID := input.GetJobId()
+
+	done, running := s.jobs.cancel(jobID)
+	if !running {
+		job, getErr := s.GetJob(ctx, &v1.GetJobRequest{JobId: jobID})
+		if getErr != nil {
+			return nil, getErr
+		}
+
+		return nil, fmt.Errorf("%w: job %q is not running", pqerr.ErrConflict, job.GetJob().GetJobId())
+	}
+
+	// The job stops between its steps, and its final state is recorded
+	// by the runner, so the response holds the job once it has stopped.
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: wait for job %q to stop: %w", pqerr.ErrInterrupted, jobID, ctx.Err())
+
+	case <-done:
+	}
+
+	job, getErr := s.GetJob(ctx, &v1.GetJobRequest{JobId: jobID})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	return &v1.CancelJobResponse{Job: job.GetJob()}, nil
+}
+
+// startJob records the running job of the kind on the queue and runs it in background.
+// The job is finished with the state depending on the error it has stopped with.
+func (s *Storage) startJob(ctx context.Context, kind, queueID string, total uint64, run jobFunc) (string, error) {
+	jobID, createErr := s.createJob(ctx, kind, queueID, total)
+	if createErr != nil {
+		return "", createErr
+	}
+
+	jobCtx, cancel := context.WithCancelCause(s.jobsCtx)
+	job := runningJob{cancel: cancel, done: make(chan struct{})}
+
+	s.jobs.mu.Lock()
+	s.jobs.running[jobID] = &job
+	s.jobs.wg.Add(1)
+	s.jobs.mu.Unlock()
+
+	go func() {
+		defer s.jobs.wg.Done()
+
+		runErr := run(jobCtx, jobID)
+		if runErr != nil && errors.Is(context.Cause(jobCtx), errJobCanceled) {
+			runErr = errJobCanceled
+		}
+
+		s.finishJob(jobID, runErr)
+
+		s.jobs.mu.Lock()
+		delete(s.jobs.running, jobID)
+		s.jobs.mu.Unlock()
+
+		cancel(nil)
+		close(job.done)
+
+		if runErr != nil && !errors.Is(runErr, errJobCanceled) {
+			s.logger.Error("Background job has failed",
+				slog.String("kind", kind),
+				slog.String("queue_id", queueID),
+				slog.String("job_id", jobID),
+				slog.String("error", runErr.Error()),
+			)
+		}
+	}()
+
+	return jobID, nil
+}
+
+// createJob records the running job of the kind on the queue.
+func (s *Storage) createJob(ctx context.Context, kind, queueID string, total uint64) (string, error) {
+	jobID := idkit.XID()
+	now := time.Now().UTC()
+
+	if _, err := s.db.ExecContext(ctx, queryInsertJob, jobID, kind, queueID, v1.JobState_JOB_STATE_RUNNING, total, now, now); err != nil {
+		return "", fmt.Errorf("insert %s job: %w", kind, err)
+	}
+
+	return jobID, nil
+}
+
+// finishJob sets the final state of the job depending on the error it has stopped with.
+// Jobs are finished with the background context, since they are also finished
+// when the context they have been running with is canceled.
+func (s *Storage) finishJob(jobID string, jobErr error) {
+	state, errText := v1.JobState_JOB_STATE_SUCCEEDED, ""
+
+	switch {
+	case errors.Is(jobErr, errJobCanceled):
+		state = v1.JobState_JOB_STATE_CANCELED
+
+	case errors.Is(jobErr, context.Canceled):
+		state, errText = v1.JobState_JOB_STATE_FAILED, errJobInterrupted
+
+	case jobErr != nil:
+		state, errText = v1.JobState_JOB_STATE_FAILED, jobErr.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, queryFinishJob, state, errText, time.Now().UTC(), jobID); err != nil {
+		s.logger.Error("Failed to finish the job",
+			slog.String("job_id", jobID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// failInterruptedJobs fails jobs which have been running when the server stopped,
+// so they don't stay running forever.
+func (s *Storage) failInterruptedJobs(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, queryFailRunningJobs,
+		v1.JobState_JOB_STATE_FAILED,
+		errJobInterrupted,
+		time.Now().UTC(),
+		v1.JobState_JOB_STATE_RUNNING,
+	); err != nil {
+		return fmt.Errorf("fail interrupted jobs: %w", err)
+	}
+
+	return nil
+}
+
+// purgeQueueAsync starts the job which purges messages of the queue matching filters of the request
+// in batches, so purging huge queues doesn't hold the database locked by a single transaction.
+func (s *Storage) purgeQueueAsync(ctx context.Context, input *v1.PurgeQueueRequest) (*v1.PurgeQueueResponse, error) {
+	queueID := input.GetQueueId()
+
+	info, describeErr := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID})
+	if describeErr != nil {
+		return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, describeErr)
+	}
+
+	where, args, filterErr := purgeFilterFromRequest(input)
+	if filterErr != nil {
+		return nil, filterErr
+	}
+
+	var total uint64
+	if err := s.db.QueryRowContext(ctx, queryCountPurgedMessages(queueID, where), args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("count queue %q messages to purge: %w", queueID, err)
+	}
+
+	jobID, startErr := s.startJob(ctx, jobKindPurge, queueID, total, func(ctx context.Context, jobID string) error {
+		// Purged messages might have been both visible and in flight, so depth gauges
+		// are counted again rather than decremented, as the filtered purge does.
+		defer s.observeDepth(queueID, info.GetMaxReceiveAttempts())
+
+		return s.purgeInBatches(ctx, jobID, queueID, where, args)
+	})
+	if startErr != nil {
+		return nil, startErr
+	}
+
+	return &v1.PurgeQueueResponse{JobId: jobID}, nil
+}
+
+// observeDepth counts depth gauges of the queue again.
+func (s *Storage) observeDepth(queueID string, maxReceiveAttempts uint32) {
+	depth, depthErr := s.queueDepth(s.jobsCtx, queueID, maxReceiveAttempts)
+	if depthErr != nil {
+		return
+	}
+
+	s.observer.MessagesVisible(queueID).Set(depth.Visible)
+	s.observer.MessagesInFlight(queueID).Set(depth.InFlight)
+}
+
+// purgeInBatches deletes batches of messages along with progress
+// of the job until no messages match the conditions.
+func (s *Storage) purgeInBatches(ctx context.Context, jobID, queueID string, where []string, args []any) error {
+	for {
+		deleted, batchErr := s.purgeBatch(ctx, jobID, queueID, where, args)
+		if batchErr != nil {
+			return batchErr
+		}
+
+		if deleted < purgeBatchSize {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-time.After(purgeBatchPause):
+		}
+	}
+}
+
+// purgeBatch deletes a batch of messages and adds them to progress of the job in a single transaction.
+func (s *Storage) purgeBatch(ctx context.Context, jobID, queueID string, where []string, args []any) (_ int64, sErr error) {
+	tx, txErr := s.beginTx(ctx, opPurgeQueue, false)
+	if txErr != nil {
+		return 0, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	res, execErr := tx.ExecContext(ctx, queryPurgeMessagesBatch(queueID, where), append(args, purgeBatchSize)...)
+	if execErr != nil {
+		return 0, fmt.Errorf("purge queue %q messages: %w", queueID, execErr)
+	}
+
+	deleted, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return 0, fmt.Errorf("purge queue %q messages: %w", queueID, rowsErr)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryUpdateJobProgress, deleted, time.Now().UTC(), jobID); err != nil {
+		return 0, fmt.Errorf("update job %q progress: %w", jobID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	return deleted, nil
+}
+
+// scanJob scans the job from the row of jobs columns.
+func scanJob(row interface{ Scan(dest ...any) error }) (*v1.Job, error) {
+	var (
+		job       v1.Job
+		createdAt time.Time
+		updatedAt time.Time
+	)
+
+	if err := row.Scan(
+		&job.JobId,
+		&job.Kind,
+		&job.QueueId,
+		&job.State,
+		&job.Processed,
+		&job.Total,
+		&job.Error,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	job.CreatedAt = timestamppb.New(createdAt)
+	job.UpdatedAt = timestamppb.New(updatedAt)
+
+	return &job, nil
+}
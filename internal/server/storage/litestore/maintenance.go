@@ -0,0 +1,105 @@
+package litestore
+
+import (
+	"context"
+	"sync"
+)
+
+// maintenance coordinates maintenance operations, such as the garbage collection
+// and snapshots, so they never run concurrently. Snapshots are urgent operations:
+// while a snapshot waits, routine operations don't acquire the coordinator,
+// and long-running ones yield it at consistent points, e.g. between queues.
+type maintenance struct {
+	mu sync.Mutex
+	// busy tells whether some operation holds the coordinator.
+	busy bool
+	// urgent holds the number of urgent operations waiting for the coordinator.
+	urgent int
+	// wake is closed and replaced each time the state changes.
+	wake chan struct{}
+}
+
+func newMaintenance() *maintenance {
+	m := maintenance{
+		wake: make(chan struct{}),
+	}
+
+	return &m
+}
+
+// acquire blocks until the coordinator is acquired or the ctx is done.
+// Routine operations wait until all urgent ones are completed.
+func (m *maintenance) acquire(ctx context.Context, urgent bool) error {
+	m.mu.Lock()
+
+	if urgent {
+		m.urgent++
+	}
+
+	for {
+		if !m.busy && (urgent || m.urgent == 0) {
+			m.busy = true
+
+			if urgent {
+				m.urgent--
+			}
+
+			m.mu.Unlock()
+
+			return nil
+		}
+
+		wake := m.wake
+		m.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			m.mu.Lock()
+
+			if urgent {
+				m.urgent--
+				m.notify()
+			}
+
+			m.mu.Unlock()
+
+			return ctx.Err()
+
+		case <-wake:
+		}
+
+		m.mu.Lock()
+	}
+}
+
+// release releases the coordinator.
+func (m *maintenance) release() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.busy = false
+	m.notify()
+}
+
+// yield lets waiting urgent operations run, if there are any, and
+// acquires the coordinator back after they are completed.
+// Should be called only while the coordinator is held.
+func (m *maintenance) yield(ctx context.Context) error {
+	m.mu.Lock()
+	waiting := m.urgent > 0
+	m.mu.Unlock()
+
+	if !waiting {
+		return nil
+	}
+
+	m.release()
+
+	return m.acquire(ctx, false)
+}
+
+// notify wakes up waiting operations. Should be called with the lock held.
+func (m *maintenance) notify() {
+	close(m.wake)
+	m.wake = make(chan struct{})
+}
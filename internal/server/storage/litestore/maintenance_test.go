@@ -0,0 +1,104 @@
+package litestore
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func Test_maintenance_yield(t *testing.T) {
+	m := newMaintenance()
+	ctx := context.Background()
+
+	// Routine operation, e.g. GC, holds the coordinator.
+	td.CmpNoError(t, m.acquire(ctx, false))
+
+	// Nobody waits, so yield returns immediately holding the coordinator.
+	td.CmpNoError(t, m.yield(ctx))
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+
+	record := func(s string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		order = append(order, s)
+	}
+
+	urgentDone := make(chan struct{})
+
+	go func() {
+		defer close(urgentDone)
+
+		td.CmpNoError(t, m.acquire(ctx, true))
+		record("snapshot")
+		m.release()
+	}()
+
+	// Wait for the urgent operation to start waiting.
+	td.CmpTrue(t, waitFor(func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		return m.urgent == 1
+	}))
+
+	// A routine operation, which comes after, waits for the urgent one.
+	routineDone := make(chan struct{})
+
+	go func() {
+		defer close(routineDone)
+
+		td.CmpNoError(t, m.acquire(ctx, false))
+		record("routine")
+		m.release()
+	}()
+
+	td.CmpNoError(t, m.yield(ctx))
+	record("gc")
+	m.release()
+
+	<-urgentDone
+	<-routineDone
+
+	td.Cmp(t, order[0], "snapshot")
+	td.Cmp(t, order[1:], td.Bag("gc", "routine"))
+}
+
+func Test_maintenance_acquireCanceled(t *testing.T) {
+	m := newMaintenance()
+
+	td.CmpNoError(t, m.acquire(context.Background(), false))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	td.CmpErrorIs(t, m.acquire(ctx, true), context.DeadlineExceeded)
+
+	// The canceled urgent operation doesn't make the holder yield.
+	m.mu.Lock()
+	td.Cmp(t, m.urgent, 0)
+	m.mu.Unlock()
+
+	m.release()
+
+	td.CmpNoError(t, m.acquire(context.Background(), false))
+}
+
+func waitFor(cond func() bool) bool {
+	for range 1000 {
+		if cond() {
+			return true
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	return false
+}
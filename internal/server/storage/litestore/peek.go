@@ -0,0 +1,175 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// defaultPeekLimit represents the default number of messages returned by the peek.
+	defaultPeekLimit = 100
+
+	// maxPeekLimit represents the maximum number of messages returned by the peek.
+	maxPeekLimit = 1000
+)
+
+func (s *Storage) PeekMessages(ctx context.Context, input *v1.PeekMessagesRequest) (_ *v1.PeekMessagesResponse, sErr error) {
+	queueID := input.GetQueueId()
+
+	if _, ok := s.cache.getByID(queueID); !ok {
+		if _, err := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID}); err != nil {
+			return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, err)
+		}
+	}
+
+	limit := input.GetLimit()
+
+	switch {
+	case limit == 0:
+		limit = defaultPeekLimit
+
+	case limit > maxPeekLimit:
+		return nil, fmt.Errorf("%w: peek limit should not exceed %d", errkit.ErrInvalidArgument, maxPeekLimit)
+	}
+
+	// The extra message tells whether there is the next page.
+	args := []any{limit + 1}
+
+	if input.GetCursor() != "" {
+		createdAt, msgID, decodeErr := decodePeekCursor(input.GetCursor())
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+
+		args = []any{createdAt, msgID, limit + 1}
+	}
+
+	rows, queryErr := s.db.QueryContext(ctx, queryPeekMessages(queueID, input.GetCursor() != "", input.GetIncludeBody()), args...)
+	if queryErr != nil {
+		return nil, fmt.Errorf("peek queue %q messages: %w", queueID, queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	var (
+		output = v1.PeekMessagesResponse{Messages: make([]*v1.PeekMessage, 0, limit)}
+		last   string
+	)
+
+	for rows.Next() {
+		var (
+			m         v1.PeekMessage
+			createdAt string
+			visibleAt string
+		)
+
+		if err := rows.Scan(&m.Id, &m.Body, &m.SizeBytes, &m.Attempts, &createdAt, &visibleAt); err != nil {
+			return nil, fmt.Errorf("scan message record: %w", err)
+		}
+
+		if len(output.Messages) == int(limit) {
+			output.NextCursor = encodePeekCursor(last, output.Messages[len(output.Messages)-1].GetId())
+			break
+		}
+
+		m.CreatedAt = parseTimestamp(createdAt)
+		m.VisibleAt = parseTimestamp(visibleAt)
+
+		output.Messages = append(output.Messages, &m)
+		last = createdAt
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate message records: %w", err)
+	}
+
+	return &output, nil
+}
+
+func (s *Storage) GetMessage(ctx context.Context, input *v1.GetMessageRequest) (*v1.GetMessageResponse, error) {
+	queueID := input.GetQueueId()
+
+	info, describeErr := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID})
+	if describeErr != nil {
+		return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, describeErr)
+	}
+
+	var (
+		m         v1.PeekMessage
+		output    = v1.GetMessageResponse{Message: &m}
+		createdAt string
+		visibleAt string
+	)
+
+	if err := s.db.QueryRowContext(ctx, querySelectMessage(queueID), input.GetMessageId()).Scan(
+		&m.Id,
+		&m.Body,
+		&m.SizeBytes,
+		&m.Attempts,
+		&createdAt,
+		&visibleAt,
+		&output.InFlight,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: message %q of queue (id: %q)", errkit.ErrNotFound, input.GetMessageId(), queueID)
+		}
+
+		return nil, fmt.Errorf("select message %q of queue %q: %w", input.GetMessageId(), queueID, err)
+	}
+
+	m.CreatedAt = parseTimestamp(createdAt)
+	m.VisibleAt = parseTimestamp(visibleAt)
+
+	if m.GetAttempts() < info.GetMaxReceiveAttempts() {
+		output.AttemptsLeft = info.GetMaxReceiveAttempts() - m.GetAttempts()
+	}
+
+	return &output, nil
+}
+
+// encodePeekCursor returns the opaque cursor which points to the message position.
+func encodePeekCursor(createdAt, msgID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt + "|" + msgID))
+}
+
+// decodePeekCursor returns the message position the cursor points to.
+func decodePeekCursor(cursor string) (createdAt, msgID string, err error) {
+	data, decodeErr := base64.RawURLEncoding.DecodeString(cursor)
+	if decodeErr != nil {
+		return "", "", fmt.Errorf("%w: invalid cursor", errkit.ErrInvalidArgument)
+	}
+
+	createdAt, msgID, ok := strings.Cut(string(data), "|")
+	if !ok || createdAt == "" || msgID == "" {
+		return "", "", fmt.Errorf("%w: invalid cursor", errkit.ErrInvalidArgument)
+	}
+
+	return createdAt, msgID, nil
+}
+
+// parseTimestamp parses the timestamp stored by SQLite or by the driver, which
+// converts values of timestamp columns to RFC 3339 when they are scanned to strings.
+// Unknown formats result in nil, since the timestamp is informational.
+func parseTimestamp(v string) *timestamppb.Timestamp {
+	for _, layout := range append([]string{time.RFC3339Nano}, sqlite3.SQLiteTimestampFormats...) {
+		if t, err := time.Parse(layout, v); err == nil {
+			return timestamppb.New(t)
+		}
+	}
+
+	return nil
+}
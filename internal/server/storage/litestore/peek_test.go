@@ -0,0 +1,36 @@
+package litestore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/errkit"
+)
+
+func Test_peekCursor(t *testing.T) {
+	cursor := encodePeekCursor("2024-01-02 03:04:05", "01HQ5RJNXS6TPXK89PQWY4N8JD")
+
+	createdAt, msgID, err := decodePeekCursor(cursor)
+	td.CmpNoError(t, err)
+	td.Cmp(t, createdAt, "2024-01-02 03:04:05")
+	td.Cmp(t, msgID, "01HQ5RJNXS6TPXK89PQWY4N8JD")
+
+	for name, invalid := range map[string]string{
+		"NotBase64":    "!!!",
+		"NoSeparator":  "YWJj",
+		"EmptyMessage": encodePeekCursor("2024-01-02 03:04:05", ""),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := decodePeekCursor(invalid)
+			td.CmpErrorIs(t, err, errkit.ErrInvalidArgument)
+		})
+	}
+}
+
+func Test_parseTimestamp(t *testing.T) {
+	td.Cmp(t, parseTimestamp("2024-01-02 03:04:05").AsTime(), time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	td.Cmp(t, parseTimestamp("2024-01-02 03:04:05.5+00:00").AsTime(), time.Date(2024, 1, 2, 3, 4, 5, 5e8, time.UTC))
+	td.Cmp(t, parseTimestamp("2024-01-02T03:04:05.5Z").AsTime(), time.Date(2024, 1, 2, 3, 4, 5, 5e8, time.UTC))
+	td.Cmp(t, parseTimestamp("yesterday"), td.Nil())
+}
@@ -0,0 +1,304 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/rbac"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Storage) QueuePermissions(ctx context.Context, queueID string) (_ []*v1.QueuePermission, sErr error) {
+	if _, err := s.queueName(ctx, queueID); err != nil {
+		return nil, err
+	}
+
+	rows, queryErr := s.db.QueryContext(ctx, querySelectQueuePermissions, queueID)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select queue permissions (id: %q): %w", queueID, queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	var permissions []*v1.QueuePermission
+
+	for rows.Next() {
+		permission, scanErr := scanQueuePermission(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate queue permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+func (s *Storage) SetQueuePermission(ctx context.Context, permission *v1.QueuePermission) (*v1.QueuePermission, error) {
+	var updatedAt time.Time
+
+	if err := s.db.QueryRowContext(ctx, queryUpsertQueuePermission,
+		permission.GetQueueId(),
+		permission.GetRole(),
+		permission.GetSend(),
+		permission.GetReceive(),
+		permission.GetPurge(),
+		permission.GetDelete(),
+		permission.GetUpdate(),
+		time.Now().UTC(),
+	).Scan(&updatedAt); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("grant queue permissions (id: %q) to role %q: %w", permission.GetQueueId(), permission.GetRole(), err)
+		}
+
+		if _, nameErr := s.queueName(ctx, permission.GetQueueId()); nameErr != nil {
+			return nil, nameErr
+		}
+
+		return nil, fmt.Errorf("%w: role %q not found", errkit.ErrNotFound, permission.GetRole())
+	}
+
+	s.grants.invalidate()
+
+	output := v1.QueuePermission{
+		QueueId:   permission.GetQueueId(),
+		Role:      permission.GetRole(),
+		Send:      permission.GetSend(),
+		Receive:   permission.GetReceive(),
+		Purge:     permission.GetPurge(),
+		Delete:    permission.GetDelete(),
+		Update:    permission.GetUpdate(),
+		UpdatedAt: timestamppb.New(updatedAt),
+	}
+
+	return &output, nil
+}
+
+func (s *Storage) DeleteQueuePermission(ctx context.Context, queueID, role string) error {
+	res, execErr := s.db.ExecContext(ctx, queryDeleteQueuePermission, queueID, role)
+	if execErr != nil {
+		return fmt.Errorf("revoke queue permissions (id: %q) from role %q: %w", queueID, role, execErr)
+	}
+
+	deleted, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return fmt.Errorf("revoke queue permissions (id: %q) from role %q: %w", queueID, role, rowsErr)
+	}
+
+	if deleted < 1 {
+		return fmt.Errorf("%w: permissions of role %q on queue (id: %q)", errkit.ErrNotFound, role, queueID)
+	}
+
+	s.grants.invalidate()
+
+	return nil
+}
+
+func (s *Storage) EffectivePermissions(ctx context.Context, subject, queueID string) (*v1.EffectivePermissions, error) {
+	if queueID != "" {
+		if _, err := s.queueName(ctx, queueID); err != nil {
+			return nil, err
+		}
+	}
+
+	roles, rolesErr := s.subjectRoles(ctx, subject)
+	if rolesErr != nil {
+		return nil, rolesErr
+	}
+
+	policies, policiesErr := s.subjectPolicies(ctx, subject)
+	if policiesErr != nil {
+		return nil, policiesErr
+	}
+
+	granted, grantedErr := s.subjectQueuePermissions(ctx, subject, queueID)
+	if grantedErr != nil {
+		return nil, grantedErr
+	}
+
+	queues, queuesErr := s.queueNames(ctx, queueID)
+	if queuesErr != nil {
+		return nil, queuesErr
+	}
+
+	output := v1.EffectivePermissions{
+		Subject: subject,
+		Roles:   roles,
+	}
+
+	for _, role := range roles {
+		if role == rbac.AdminRole {
+			output.Admin = true
+		}
+	}
+
+	for _, queue := range queues {
+		grants := rbac.Grants{
+			Admin:     output.Admin,
+			QueueName: queue.GetQueueName(),
+			Policies:  policies,
+		}
+
+		var grantedBy []string
+
+		for _, p := range granted[queue.GetQueueId()] {
+			ops := permissionOperations(p)
+			if len(ops) == 0 {
+				continue
+			}
+
+			grants.Operations = append(grants.Operations, ops...)
+			grantedBy = append(grantedBy, p.GetRole())
+		}
+
+		allowed := grants.Allowed()
+
+		// Queues which the subject is not allowed to use are listed only when asked for.
+		if len(allowed) == 0 && queueID == "" {
+			continue
+		}
+
+		queue.Roles = grantedBy
+
+		for _, op := range allowed {
+			queue.Operations = append(queue.Operations, string(op))
+		}
+
+		for _, policy := range policies {
+			for _, op := range allowed {
+				if policy.Allows(queue.GetQueueName(), op) {
+					queue.Policies = append(queue.Policies, policy.Name)
+					break
+				}
+			}
+		}
+
+		output.Queues = append(output.Queues, queue)
+	}
+
+	return &output, nil
+}
+
+// subjectQueuePermissions returns queue permissions granted to roles of the subject
+// on the queue, or on all queues when the queue id is empty, by queue ids.
+func (s *Storage) subjectQueuePermissions(ctx context.Context, subject, queueID string) (_ map[string][]*v1.QueuePermission, sErr error) {
+	rows, queryErr := s.db.QueryContext(ctx, querySelectSubjectQueuePermissionRecords, subject, queueID)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select queue permissions of %q: %w", subject, queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	permissions := make(map[string][]*v1.QueuePermission)
+
+	for rows.Next() {
+		permission, scanErr := scanQueuePermission(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		permissions[permission.GetQueueId()] = append(permissions[permission.GetQueueId()], permission)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate queue permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// queueNames returns ids and names of the queue, or of all queues when the queue id is empty.
+func (s *Storage) queueNames(ctx context.Context, queueID string) (_ []*v1.EffectiveQueuePermission, sErr error) {
+	rows, queryErr := s.db.QueryContext(ctx, querySelectQueueNames, queueID)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select queue names: %w", queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	var queues []*v1.EffectiveQueuePermission
+
+	for rows.Next() {
+		var queue v1.EffectiveQueuePermission
+
+		if err := rows.Scan(&queue.QueueId, &queue.QueueName); err != nil {
+			return nil, fmt.Errorf("scan queue name: %w", err)
+		}
+
+		queues = append(queues, &queue)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate queue names: %w", err)
+	}
+
+	return queues, nil
+}
+
+// permissionOperations returns operations granted by the queue permission.
+func permissionOperations(p *v1.QueuePermission) []rbac.Operation {
+	var ops []rbac.Operation
+
+	for _, g := range []struct {
+		op      rbac.Operation
+		granted bool
+	}{
+		{op: rbac.OpUpdate, granted: p.GetUpdate()},
+		{op: rbac.OpSend, granted: p.GetSend()},
+		{op: rbac.OpReceive, granted: p.GetReceive()},
+		{op: rbac.OpPurge, granted: p.GetPurge()},
+		{op: rbac.OpDelete, granted: p.GetDelete()},
+	} {
+		if g.granted {
+			ops = append(ops, g.op)
+		}
+	}
+
+	return ops
+}
+
+// scanQueuePermission scans the queue permission from the row of queue permissions columns.
+func scanQueuePermission(row interface{ Scan(dest ...any) error }) (*v1.QueuePermission, error) {
+	var (
+		permission v1.QueuePermission
+		updatedAt  time.Time
+	)
+
+	if err := row.Scan(
+		&permission.QueueId,
+		&permission.Role,
+		&permission.Send,
+		&permission.Receive,
+		&permission.Purge,
+		&permission.Delete,
+		&permission.Update,
+		&updatedAt,
+	); err != nil {
+		return nil, fmt.Errorf("scan queue permission: %w", err)
+	}
+
+	permission.UpdatedAt = timestamppb.New(updatedAt)
+
+	return &permission, nil
+}
@@ -3,6 +3,7 @@ package litestore
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	v1 "github.com/plainq/plainq/internal/server/schema/v1"
@@ -22,6 +23,24 @@ const (
 	// queryUpdateQueueAfterGC updates the gc_at in the queuePropsTable for given queue_id.
 	queryUpdateQueueAfterGC = `update queue_properties set gc_at = current_timestamp where queue_id = ?;`
 
+	// querySearchQueues returns identifiers, names and tags of queues which name, identifier
+	// or tags match the LIKE pattern, which is used when queues don't fit the cache.
+	querySearchQueues = `select queue_id, queue_name, tags from queue_properties
+	where queue_name like ?1 escape '\' or queue_id like ?1 escape '\' or tags like ?1 escape '\' limit ?2;`
+
+	// querySearchUsers returns identifiers and emails of users which match the LIKE pattern.
+	querySearchUsers = `select user_id, email from users
+	where email like ?1 escape '\' or user_id like ?1 escape '\' limit ?2;`
+
+	// querySearchRoles returns identifiers and names of roles which match the LIKE pattern.
+	querySearchRoles = `select role_id, role_name from roles
+	where role_name like ?1 escape '\' or role_id like ?1 escape '\' limit ?2;`
+
+	// querySearchAuditEvents returns the newest audit events which action, actor, target or detail match the LIKE pattern.
+	querySearchAuditEvents = `select event_id, action, actor, target, detail, created_at from audit_log
+	where action like ?1 escape '\' or actor like ?1 escape '\' or target like ?1 escape '\' or detail like ?1 escape '\'
+	order by event_id desc limit ?2;`
+
 	// queryInsertQueuePropRecord creates a record in the queuePropsTable.
 	queryInsertQueuePropRecord = `insert into queue_properties 
     (
@@ -31,13 +50,603 @@ const (
         visibility_timeout_seconds, 
         max_receive_attempts, 
         drop_policy, 
-        dead_letter_queue_id
+        dead_letter_queue_id,
+        description,
+        tags,
+        fifo,
+        delay_seconds,
+        max_message_size_bytes,
+        owner,
+        search_index,
+        state,
+        rate_limit,
+        rate_limit_burst,
+        max_messages,
+        max_bytes,
+        quota_policy,
+        retry_initial_backoff_seconds,
+        retry_multiplier,
+        retry_max_backoff_seconds
     ) 
-	values (?, ?, ?, ?, ?, ?, ?);
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 	`
 
+	// queryUpdateQueuePropRecord updates a record in the queuePropsTable
+	// only when its version matches the given one and increments the version.
+	queryUpdateQueuePropRecord = `update queue_properties set
+		queue_name = ?,
+		retention_period_seconds = ?,
+		visibility_timeout_seconds = ?,
+		max_receive_attempts = ?,
+		drop_policy = ?,
+		dead_letter_queue_id = ?,
+		rate_limit = ?,
+		rate_limit_burst = ?,
+		max_messages = ?,
+		max_bytes = ?,
+		quota_policy = ?,
+		retry_initial_backoff_seconds = ?,
+		retry_multiplier = ?,
+		retry_max_backoff_seconds = ?,
+		version = version + 1
+	where queue_id = ? and version = ?;
+	`
+
+	// querySelectQueueNameTaken reports whether the name is taken by another queue.
+	querySelectQueueNameTaken = `select exists(select 1 from queue_properties where queue_name = ? and queue_id != ?);`
+
+	// querySelectQueuePropVersion returns the version of queue properties for given queue_id.
+	querySelectQueuePropVersion = `select version from queue_properties where queue_id = ?;`
+
+	// queryInsertQueueUsage creates the usage record of the queue with given queue_id.
+	queryInsertQueueUsage = `insert into queue_usage (queue_id, messages, bytes) values (?, 0, 0);`
+
+	// querySelectQueueUsage returns the number of messages and bytes stored in the queue.
+	querySelectQueueUsage = `select messages, bytes from queue_usage where queue_id = ?;`
+
+	// queryDeleteQueueUsage deletes the usage record of the queue with given queue_id.
+	queryDeleteQueueUsage = `delete from queue_usage where queue_id = ?;`
+
+	// querySelectQueuesWithoutUsage returns identifiers of queues which have no usage record,
+	// e.g. the ones created before the usage has been tracked.
+	querySelectQueuesWithoutUsage = `select queue_id from queue_properties
+	where queue_id not in (select queue_id from queue_usage);`
+
+	// queryQueueExists reports whether the queue with given queue_id exists.
+	queryQueueExists = `select exists (select 1 from queue_properties where queue_id = ?);`
+
+	// querySelectQueuesMaxReceiveAttempts returns identifiers of all queues and their max_receive_attempts.
+	querySelectQueuesMaxReceiveAttempts = `select queue_id, max_receive_attempts from queue_properties;`
+
+	// querySelectQueuesToEvolve returns identifiers of queues which tables are behind the given
+	// version, and versions of their tables, ordered by queue_id and following the cursor.
+	querySelectQueuesToEvolve = `select p.queue_id, coalesce(t.version, 0) from queue_properties p
+	left join queue_tables t on t.queue_id = p.queue_id
+	where coalesce(t.version, 0) < ?1 and p.queue_id > ?2 order by p.queue_id limit ?3;`
+
+	// queryCountQueuesToEvolve returns the number of queues which tables are behind the given version.
+	queryCountQueuesToEvolve = `select count(*) from queue_properties p
+	left join queue_tables t on t.queue_id = p.queue_id
+	where coalesce(t.version, 0) < ?;`
+
+	// queryUpsertQueueTableVersion sets the version of the queue table.
+	queryUpsertQueueTableVersion = `insert into queue_tables (queue_id, version) values (?, ?)
+	on conflict (queue_id) do update set version = excluded.version, updated_at = current_timestamp;`
+
+	// querySelectQueueTableVersion selects the version of the queue table, which is zero for tables
+	// created before versions were recorded.
+	querySelectQueueTableVersion = `select coalesce((select version from queue_tables where queue_id = ?), 0);`
+
+	// queryDeleteQueueTableVersion deletes the version record of the queue table.
+	queryDeleteQueueTableVersion = `delete from queue_tables where queue_id = ?;`
+
+	// queryUpdateQueueState changes the state in the queuePropsTable
+	// only when the current state matches the given one and increments the version.
+	queryUpdateQueueState = `update queue_properties set state = ?, version = version + 1 where queue_id = ? and state = ?;`
+
 	// queryDeleteQueuePropRecord deletes records from the queuePropsTable for given queue_id.
 	queryDeleteQueuePropRecord = `delete from queue_properties where queue_id = ?;`
+
+	// queryInsertQueueTransfer creates a pending transfer of the queue ownership.
+	queryInsertQueueTransfer = `insert into queue_transfers
+		(transfer_id, queue_id, from_owner, to_owner, requested_by, created_at, expires_at)
+	values (?, ?, ?, ?, ?, ?, ?);
+	`
+
+	// querySelectQueueTransfer returns the pending transfer for given transfer_id.
+	querySelectQueueTransfer = `select transfer_id, queue_id, from_owner, to_owner, requested_by, created_at, expires_at
+	from queue_transfers where transfer_id = ?;
+	`
+
+	// queryCountQueueTransfers returns the number of pending transfers of given queue_id.
+	queryCountQueueTransfers = `select count(*) from queue_transfers where queue_id = ?;`
+
+	// queryDeleteQueueTransfer deletes the transfer for given transfer_id.
+	queryDeleteQueueTransfer = `delete from queue_transfers where transfer_id = ?;`
+
+	// queryDeleteExpiredQueueTransfer deletes the expired transfer of given queue_id,
+	// so a new transfer of the queue can be requested.
+	queryDeleteExpiredQueueTransfer = `delete from queue_transfers where queue_id = ? and expires_at <= ?;`
+
+	// queryUpdateQueueOwner changes the owner of the queue only when
+	// it matches the given one and increments the version.
+	queryUpdateQueueOwner = `update queue_properties set owner = ?, version = version + 1 where queue_id = ? and owner = ?;`
+
+	// queryEnsureRole creates the role with the name, e.g. the one named after the owner
+	// of queues, unless the role with the name exists.
+	queryEnsureRole = `insert into roles (role_id, role_name, created_at) values (?, ?, ?)
+	on conflict (role_name) do nothing;`
+
+	// queryGrantOwnerPermissions grants all queue permissions of the queue
+	// to the role named after its owner.
+	queryGrantOwnerPermissions = `insert or replace into queue_permissions
+	(queue_id, role_id, can_send, can_receive, can_purge, can_delete, can_update)
+	select ?, role_id, true, true, true, true, true from roles where role_name = ?;`
+
+	// queryMoveQueuePermissions moves queue permissions of the role named after the previous
+	// owner to the role named after the new owner, replacing permissions the latter had.
+	queryMoveQueuePermissions = `update or replace queue_permissions
+	set role_id = (select role_id from roles where role_name = ?), updated_at = current_timestamp
+	where queue_id = ?
+	  and role_id = (select role_id from roles where role_name = ?)
+	  and exists (select 1 from roles where role_name = ?);
+	`
+
+	// queryVacuumInto writes a consistent copy of the database to the given file.
+	queryVacuumInto = `vacuum into ?;`
+
+	// queryVacuum rebuilds the database, reclaiming free pages.
+	queryVacuum = `vacuum;`
+
+	// queryAnalyze gathers statistics of tables and indexes for the query planner.
+	queryAnalyze = `analyze;`
+
+	// queryEnableIncrementalVacuum makes the next vacuum enable the incremental auto-vacuum.
+	queryEnableIncrementalVacuum = `pragma auto_vacuum = incremental;`
+
+	// querySelectAutoVacuum selects the auto-vacuum mode of the database, 2 is incremental.
+	querySelectAutoVacuum = `pragma auto_vacuum;`
+
+	// queryIncrementalVacuum reclaims up to the given number of free pages, all of them when it's not positive.
+	// Pages are reclaimed one by one while the statement is stepped, so it should be queried to the end.
+	queryIncrementalVacuum = `pragma incremental_vacuum(%d);`
+
+	// queryCheckpointTruncate moves pages of the write-ahead log to the database and
+	// truncates both files, so the space reclaimed by vacuums is returned to the file system.
+	queryCheckpointTruncate = `pragma wal_checkpoint(truncate);`
+
+	// querySelectDatabaseSize selects the size of the database and the size of its free pages in bytes.
+	querySelectDatabaseSize = `select page_count * page_size, freelist_count * page_size
+	from pragma_page_count(), pragma_page_size(), pragma_freelist_count();`
+
+	// queryInsertAuditEvent appends the event to the audit log.
+	queryInsertAuditEvent = `insert into audit_log (event_id, actor, action, target, detail, created_at) values (?, ?, ?, ?, ?, ?);`
+
+	// querySelectAuditEvents returns audit events which match the filter, newest first.
+	// Empty filter values and null time bounds match any event.
+	querySelectAuditEvents = `select event_id, actor, action, target, detail, created_at from audit_log
+	where (?1 = '' or actor = ?1)
+	  and (?2 = '' or action = ?2)
+	  and (?3 = '' or target = ?3)
+	  and (?4 is null or created_at >= ?4)
+	  and (?5 is null or created_at < ?5)
+	  and (?6 = '' or event_id < ?6)
+	order by event_id desc
+	limit ?7;`
+
+	// queryInsertAlertRule creates the alert rule.
+	queryInsertAlertRule = `insert into alert_rules
+	(rule_id, name, metric, queue_id, operator, threshold, for_seconds, webhook_url, email, created_at, updated_at)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`
+
+	// querySelectAlertRules returns all alert rules ordered by name.
+	querySelectAlertRules = `select rule_id, name, metric, queue_id, operator, threshold, for_seconds,
+	webhook_url, email, created_at, updated_at from alert_rules order by name, rule_id;`
+
+	// queryUpdateAlertRule replaces the alert rule and returns the time it has been created.
+	queryUpdateAlertRule = `update alert_rules
+	set name = ?, metric = ?, queue_id = ?, operator = ?, threshold = ?, for_seconds = ?,
+	    webhook_url = ?, email = ?, updated_at = ?
+	where rule_id = ?
+	returning created_at;`
+
+	// queryDeleteAlertRule deletes the alert rule.
+	queryDeleteAlertRule = `delete from alert_rules where rule_id = ?;`
+
+	// subjectRoles selects ids and names of roles of the subject ?1, which are roles named
+	// after the subject, roles of the active user with the subject email or id, and roles
+	// of the service account with the subject name.
+	subjectRoles = `with subject_roles as (
+		select role_id, role_name from roles where role_name = ?1
+		union
+		select r.role_id, r.role_name from roles r
+		join user_roles ur on ur.role_id = r.role_id
+		join users u on u.user_id = ur.user_id
+		where (u.email = ?1 or u.user_id = ?1) and u.active
+		union
+		select r.role_id, r.role_name from roles r
+		join service_account_roles sr on sr.role_id = r.role_id
+		join service_accounts sa on sa.account_id = sr.account_id
+		where sa.name = ?1
+	)`
+
+	// querySelectSubjectRoles selects names of roles of the subject ?1.
+	querySelectSubjectRoles = subjectRoles + ` select role_name from subject_roles;`
+
+	// querySelectSubjectQueuePermissions selects queue permissions
+	// of the queue ?2 granted to any role of the subject ?1.
+	querySelectSubjectQueuePermissions = subjectRoles + `
+	select coalesce(max(can_send), 0), coalesce(max(can_receive), 0), coalesce(max(can_purge), 0), coalesce(max(can_delete), 0),
+	       coalesce(max(can_update), 0)
+	from queue_permissions
+	where queue_id = ?2 and role_id in (select role_id from subject_roles);`
+
+	// querySelectSubjectPolicies selects policies attached to roles of the subject ?1.
+	querySelectSubjectPolicies = subjectRoles + `
+	select distinct p.policy_name, p.document
+	from policies p
+	join role_policies rp on rp.policy_id = p.policy_id
+	where rp.role_id in (select role_id from subject_roles);`
+
+	// querySelectSubjectQueuePermissionRecords selects queue permissions granted to roles
+	// of the subject ?1 on the queue ?2, or on all queues when ?2 is empty.
+	querySelectSubjectQueuePermissionRecords = subjectRoles + `
+	select p.queue_id, r.role_name, p.can_send, p.can_receive, p.can_purge, p.can_delete, p.can_update, p.updated_at
+	from queue_permissions p
+	join subject_roles r on r.role_id = p.role_id
+	where ?2 = '' or p.queue_id = ?2
+	order by r.role_name;`
+
+	// querySelectQueueNames selects ids and names of the queue ?1, or of all queues when ?1 is empty.
+	querySelectQueueNames = `select queue_id, queue_name from queue_properties
+	where ?1 = '' or queue_id = ?1
+	order by queue_name;`
+
+	// querySelectQueuePermissions selects permissions granted to roles on the queue.
+	querySelectQueuePermissions = `select p.queue_id, r.role_name, p.can_send, p.can_receive, p.can_purge, p.can_delete, p.can_update, p.updated_at
+	from queue_permissions p
+	join roles r on r.role_id = p.role_id
+	where p.queue_id = ?
+	order by r.role_name;`
+
+	// queryUpsertQueuePermission grants permissions on the queue ?1 to the role named ?2,
+	// replacing permissions the role had. Nothing is granted when the queue or the role is unknown.
+	queryUpsertQueuePermission = `insert into queue_permissions
+	(queue_id, role_id, can_send, can_receive, can_purge, can_delete, can_update, updated_at)
+	select ?1, role_id, ?3, ?4, ?5, ?6, ?7, ?8 from roles
+	where role_name = ?2 and exists (select 1 from queue_properties where queue_id = ?1)
+	on conflict (queue_id, role_id) do update set
+		can_send = excluded.can_send,
+		can_receive = excluded.can_receive,
+		can_purge = excluded.can_purge,
+		can_delete = excluded.can_delete,
+		can_update = excluded.can_update,
+		updated_at = excluded.updated_at
+	returning updated_at;`
+
+	// queryUpsertPolicy creates the policy or replaces the document of the policy with the name.
+	queryUpsertPolicy = `insert into policies (policy_id, policy_name, document, updated_at) values (?, ?, ?, ?)
+	on conflict (policy_name) do update set document = excluded.document, updated_at = excluded.updated_at;`
+
+	// queryAttachRolePolicy attaches the policy with the name ?2 to the role with the name ?1,
+	// unless it is attached already.
+	queryAttachRolePolicy = `insert into role_policies (role_id, policy_id)
+	select r.role_id, p.policy_id from roles r, policies p
+	where r.role_name = ?1 and p.policy_name = ?2
+	on conflict (role_id, policy_id) do nothing;`
+
+	// queryRoleExists checks whether the role with the name exists.
+	queryRoleExists = `select exists (select 1 from roles where role_name = ?);`
+
+	// queryDeleteQueuePermission revokes permissions on the queue from the role with the name.
+	queryDeleteQueuePermission = `delete from queue_permissions
+	where queue_id = ? and role_id = (select role_id from roles where role_name = ?);`
+
+	// querySelectQueueName selects the name of the queue.
+	querySelectQueueName = `select queue_name from queue_properties where queue_id = ?;`
+
+	// querySelectServiceAccountExists reports whether the service account with the name exists.
+	querySelectServiceAccountExists = `select exists(select 1 from service_accounts where name = ?);`
+
+	// queryInsertServiceAccount creates the service account.
+	queryInsertServiceAccount = `insert into service_accounts (account_id, name, description, created_at) values (?, ?, ?, ?);`
+
+	// queryInsertServiceAccountRole grants the role with the name to the service account.
+	queryInsertServiceAccountRole = `insert into service_account_roles (account_id, role_id)
+	select ?, role_id from roles where role_name = ?;`
+
+	// querySelectServiceAccounts returns all service accounts along with
+	// JSON arrays of names of their roles ordered by name.
+	querySelectServiceAccounts = `select a.account_id, a.name, a.description, a.created_at,
+	coalesce(json_group_array(r.role_name) filter (where r.role_name is not null), '[]')
+	from service_accounts a
+	left join service_account_roles sr on sr.account_id = a.account_id
+	left join roles r on r.role_id = sr.role_id
+	group by a.account_id
+	order by a.name;`
+
+	// queryDeleteServiceAccountKeys deletes API keys of the service account.
+	queryDeleteServiceAccountKeys = `delete from api_keys where account_id = ?;`
+
+	// queryDeleteServiceAccountRoles deletes roles of the service account.
+	queryDeleteServiceAccountRoles = `delete from service_account_roles where account_id = ?;`
+
+	// queryDeleteServiceAccount deletes the service account.
+	queryDeleteServiceAccount = `delete from service_accounts where account_id = ?;`
+
+	// queryInsertAPIKey creates the API key of the existing service account.
+	queryInsertAPIKey = `insert into api_keys (key_id, account_id, name, prefix, hash, created_at, expires_at)
+	select ?1, account_id, ?3, ?4, ?5, ?6, ?7 from service_accounts where account_id = ?2;`
+
+	// querySelectAPIKeys returns API keys of the service account, newest first.
+	querySelectAPIKeys = `select key_id, account_id, name, prefix, created_at, expires_at, revoked_at
+	from api_keys where account_id = ? order by created_at desc, key_id desc;`
+
+	// queryRevokeAPIKey revokes the API key, keeping the time of the first revocation.
+	queryRevokeAPIKey = `update api_keys set revoked_at = coalesce(revoked_at, ?) where key_id = ?
+	returning account_id, name, prefix, created_at, expires_at, revoked_at;`
+
+	// querySelectAPIKeyByPrefix returns the API key with the prefix along with the name of its service account.
+	querySelectAPIKeyByPrefix = `select k.key_id, a.name, k.hash, k.expires_at, k.revoked_at
+	from api_keys k join service_accounts a on a.account_id = k.account_id
+	where k.prefix = ?;`
+
+	// querySelectUserPassword selects the password hash of the active user with the email.
+	querySelectUserPassword = `select password from users where email = ? and active;`
+
+	// queryInsertExternalUser creates the user managed by the directory, which has no password.
+	queryInsertExternalUser = `insert into users (user_id, email, password) values (?, ?, '')
+	on conflict (email) do nothing;`
+
+	// querySelectUserID selects the identifier of the user with the email.
+	querySelectUserID = `select user_id from users where email = ?;`
+
+	// queryDeleteUserRoles revokes all roles of the user.
+	queryDeleteUserRoles = `delete from user_roles where user_id = ?;`
+
+	// queryInsertUserRole grants the role with the name to the user, unknown roles are skipped.
+	queryInsertUserRole = `insert into user_roles (user_id, role_id)
+	select ?, role_id from roles where role_name = ?;`
+
+	// scimUsers selects users along with JSON arrays of ids and names of their roles.
+	scimUsers = `select u.user_id, u.email, u.external_id, u.active, u.created_at, u.updated_at,
+	coalesce(json_group_array(json_object('id', r.role_id, 'name', r.role_name)) filter (where r.role_id is not null), '[]')
+	from users u
+	left join user_roles ur on ur.user_id = u.user_id
+	left join roles r on r.role_id = ur.role_id`
+
+	// querySelectSCIMUsers selects all users ordered by the time they were created.
+	querySelectSCIMUsers = scimUsers + ` group by u.user_id order by u.created_at, u.user_id;`
+
+	// querySelectSCIMUser selects the user.
+	querySelectSCIMUser = scimUsers + ` where u.user_id = ? group by u.user_id;`
+
+	// querySelectUserEmailTaken reports whether the email is taken by any user other than the given one.
+	querySelectUserEmailTaken = `select exists(select 1 from users where email = ? and user_id != ?);`
+
+	// queryInsertSCIMUser creates the provisioned user, which has no password.
+	queryInsertSCIMUser = `insert into users (user_id, email, password, external_id, active, created_at, updated_at)
+	values (?, ?, '', ?, ?, ?, ?);`
+
+	// queryUpdateSCIMUser replaces the email, the external id and the state of the user.
+	queryUpdateSCIMUser = `update users set email = ?, external_id = ?, active = ?, updated_at = ? where user_id = ?;`
+
+	// queryDeleteUser deletes the user.
+	queryDeleteUser = `delete from users where user_id = ?;`
+
+	// scimGroups selects roles along with JSON arrays of ids and emails of their users.
+	scimGroups = `select r.role_id, r.role_name, r.created_at,
+	coalesce(json_group_array(json_object('id', u.user_id, 'name', u.email)) filter (where u.user_id is not null), '[]')
+	from roles r
+	left join user_roles ur on ur.role_id = r.role_id
+	left join users u on u.user_id = ur.user_id`
+
+	// querySelectSCIMGroups selects all roles ordered by name.
+	querySelectSCIMGroups = scimGroups + ` group by r.role_id order by r.role_name;`
+
+	// querySelectSCIMGroup selects the role.
+	querySelectSCIMGroup = scimGroups + ` where r.role_id = ? group by r.role_id;`
+
+	// querySelectRoleNameTaken reports whether the name is taken by any role other than the given one.
+	querySelectRoleNameTaken = `select exists(select 1 from roles where role_name = ? and role_id != ?);`
+
+	// queryInsertRole creates the role.
+	queryInsertRole = `insert into roles (role_id, role_name, created_at) values (?, ?, ?);`
+
+	// queryUpdateRoleName renames the role.
+	queryUpdateRoleName = `update roles set role_name = ? where role_id = ?;`
+
+	// queryDeleteRoleUsers revokes the role from all users.
+	queryDeleteRoleUsers = `delete from user_roles where role_id = ?;`
+
+	// queryInsertRoleUser grants the role to the existing user.
+	queryInsertRoleUser = `insert into user_roles (user_id, role_id) select user_id, ? from users where user_id = ?;`
+
+	// queryDeleteRoleServiceAccounts revokes the role from all service accounts.
+	queryDeleteRoleServiceAccounts = `delete from service_account_roles where role_id = ?;`
+
+	// queryDeleteRolePermissions deletes queue permissions granted to the role.
+	queryDeleteRolePermissions = `delete from queue_permissions where role_id = ?;`
+
+	// queryDeleteRolePolicies detaches policies from the role.
+	queryDeleteRolePolicies = `delete from role_policies where role_id = ?;`
+
+	// queryDeleteRole deletes the role.
+	queryDeleteRole = `delete from roles where role_id = ?;`
+
+	// querySelectRoleID selects the identifier of the role with the name.
+	querySelectRoleID = `select role_id from roles where role_name = ?;`
+
+	// queryAssignRole assigns the role with the name ?2 to the user with the email ?1,
+	// unless it is assigned already.
+	queryAssignRole = `insert into user_roles (user_id, role_id)
+	select u.user_id, r.role_id from users u, roles r
+	where u.email = ?1 and r.role_name = ?2
+	on conflict (user_id, role_id) do nothing;`
+
+	// queryUnassignRole takes the role with the name ?2 away from the user with the email ?1.
+	queryUnassignRole = `delete from user_roles
+	where user_id = (select user_id from users where email = ?1)
+	and role_id = (select role_id from roles where role_name = ?2);`
+
+	// queryInsertDeniedAccessToken adds the access token to the denylist.
+	queryInsertDeniedAccessToken = `insert into access_token_denylist (token_hash, expires_at) values (?, ?)
+	on conflict (token_hash) do nothing;`
+
+	// querySelectAccessTokenDenied reports whether the access token is on the denylist.
+	querySelectAccessTokenDenied = `select exists(select 1 from access_token_denylist where token_hash = ?);`
+
+	// queryDeleteExpiredAccessTokens removes access tokens expired before the time from the denylist.
+	queryDeleteExpiredAccessTokens = `delete from access_token_denylist where expires_at < ?;`
+
+	// queryInsertSubjectTokenRevocation revokes tokens of the subject issued at or before the time.
+	queryInsertSubjectTokenRevocation = `insert into subject_token_revocations (subject, revoked_at, expires_at) values (?, ?, ?)
+	on conflict (subject) do update set revoked_at = excluded.revoked_at, expires_at = excluded.expires_at;`
+
+	// querySelectSubjectTokenRevocation selects the time tokens of the subject issued at or before are revoked.
+	querySelectSubjectTokenRevocation = `select revoked_at from subject_token_revocations where subject = ?;`
+
+	// queryDeleteExpiredSubjectTokenRevocations removes revocations of tokens expired before the time.
+	queryDeleteExpiredSubjectTokenRevocations = `delete from subject_token_revocations where expires_at < ?;`
+
+	// querySelectLocalUserID selects the identifier of the active user with the email, which has the password.
+	querySelectLocalUserID = `select user_id from users where email = ? and active and password != '';`
+
+	// queryDeletePasswordResetCodes deletes unused password reset codes of the user.
+	queryDeletePasswordResetCodes = `delete from password_reset_codes where user_id = ? and used_at is null;`
+
+	// queryInsertPasswordResetCode stores the password reset code of the user.
+	queryInsertPasswordResetCode = `insert into password_reset_codes (code_hash, user_id, expires_at, created_at) values (?, ?, ?, ?);`
+
+	// querySelectPasswordResetCode selects the expiration of the unused and unexpired
+	// password reset code ?1 of the active user with the email ?2 at the time ?3.
+	querySelectPasswordResetCode = `select c.expires_at from password_reset_codes c
+	join users u on u.user_id = c.user_id
+	where c.code_hash = ?1 and u.email = ?2 and u.active and c.used_at is null and c.expires_at > ?3;`
+
+	// queryUsePasswordResetCode marks the unused and unexpired password reset code ?1
+	// of the active user with the email ?2 used at the time ?3.
+	queryUsePasswordResetCode = `update password_reset_codes set used_at = ?3
+	where code_hash = ?1 and used_at is null and expires_at > ?3
+	and user_id = (select user_id from users where email = ?2 and active)
+	returning user_id;`
+
+	// queryUpdateUserPassword replaces the password hash of the user.
+	queryUpdateUserPassword = `update users set password = ?, updated_at = ? where user_id = ?;`
+
+	// querySelectUnverifiedUserID selects the identifier of the active user with the email, which is not verified.
+	querySelectUnverifiedUserID = `select user_id from users where email = ? and active and not verified;`
+
+	// querySelectEmailVerificationCodeSentAt selects the time the last email verification code of the user has been created.
+	querySelectEmailVerificationCodeSentAt = `select c.created_at from email_verification_codes c
+	join users u on u.user_id = c.user_id
+	where u.email = ? order by c.created_at desc limit 1;`
+
+	// queryDeleteEmailVerificationCodes deletes unused email verification codes of the user.
+	queryDeleteEmailVerificationCodes = `delete from email_verification_codes where user_id = ? and used_at is null;`
+
+	// queryInsertEmailVerificationCode stores the email verification code of the user.
+	queryInsertEmailVerificationCode = `insert into email_verification_codes (code_hash, user_id, expires_at, created_at) values (?, ?, ?, ?);`
+
+	// queryUseEmailVerificationCode marks the unused and unexpired email verification code ?1
+	// of the active user with the email ?2 used at the time ?3.
+	queryUseEmailVerificationCode = `update email_verification_codes set used_at = ?3
+	where code_hash = ?1 and used_at is null and expires_at > ?3
+	and user_id = (select user_id from users where email = ?2 and active)
+	returning user_id;`
+
+	// queryUpdateUserVerified marks the email of the user verified.
+	queryUpdateUserVerified = `update users set verified = true, updated_at = ? where user_id = ?;`
+
+	// querySelectUserVerified selects whether the email of the user is verified.
+	querySelectUserVerified = `select verified from users where email = ?;`
+
+	// queryInsertSession records the session of the token issued on sign-in.
+	queryInsertSession = `insert into sessions (session_id, subject, token_hash, user_agent, remote_addr, created_at, expires_at)
+	values (?, ?, ?, ?, ?, ?, ?);`
+
+	// querySelectSessions selects sessions of the subject ?1 which are neither expired nor revoked at the time ?2.
+	querySelectSessions = `select session_id, subject, token_hash, user_agent, remote_addr, created_at, expires_at
+	from sessions where subject = ?1 and revoked_at is null and expires_at > ?2
+	order by created_at desc, session_id;`
+
+	// queryRevokeSession revokes the session ?2 of the subject ?1, which is neither expired nor revoked at the time ?3.
+	queryRevokeSession = `update sessions set revoked_at = ?3
+	where subject = ?1 and session_id = ?2 and revoked_at is null and expires_at > ?3
+	returning session_id, subject, token_hash, user_agent, remote_addr, created_at, expires_at;`
+
+	// queryRevokeSessions revokes sessions of the subject ?1, which are neither expired nor revoked at the time ?2.
+	queryRevokeSessions = `update sessions set revoked_at = ?2
+	where subject = ?1 and revoked_at is null and expires_at > ?2;`
+
+	// queryDeleteExpiredSessions removes sessions expired before the time.
+	queryDeleteExpiredSessions = `delete from sessions where expires_at < ?;`
+
+	// querySelectAccount selects the profile of the active user with the email.
+	querySelectAccount = `select user_id, email, name, verified, created_at, updated_at from users where email = ? and active;`
+
+	// querySelectAccountLocal selects the identifier of the active user with the email, and whether
+	// the user is the local one, which is neither managed by the directory nor provisioned.
+	querySelectAccountLocal = `select user_id, password != '' and external_id = '' from users where email = ? and active;`
+
+	// queryUpdateUserEmail replaces the email of the user, which should be verified again.
+	queryUpdateUserEmail = `update users set email = ?, verified = false, updated_at = ? where user_id = ?;`
+
+	// queryUpdateUserName replaces the display name of the user.
+	queryUpdateUserName = `update users set name = ?, updated_at = ? where user_id = ?;`
+
+	// queryUpdateUserPasswordByEmail replaces the password hash of the active local user with the email.
+	queryUpdateUserPasswordByEmail = `update users set password = ?, updated_at = ? where email = ? and active and password != '';`
+
+	// querySelectDatabaseFile selects the path of the main database file.
+	querySelectDatabaseFile = `select file from pragma_database_list where name = 'main';`
+
+	// queryInsertJob creates the background job.
+	queryInsertJob = `insert into jobs (job_id, kind, queue_id, state, total, created_at, updated_at) values (?, ?, ?, ?, ?, ?, ?);`
+
+	// querySelectJob selects the background job.
+	querySelectJob = `select job_id, kind, queue_id, state, processed, total, error, created_at, updated_at from jobs where job_id = ?;`
+
+	// querySelectArchivedMessageBody selects the body of the archived message of the queue.
+	querySelectArchivedMessageBody = `select msg_body from archive where queue_id = ? and msg_id = ?;`
+
+	// queryDeleteArchivedMessage deletes the archived message of the queue.
+	queryDeleteArchivedMessage = `delete from archive where queue_id = ? and msg_id = ?;`
+
+	// queryDeleteQueueArchive deletes archived messages of the queue.
+	queryDeleteQueueArchive = `delete from archive where queue_id = ?;`
+
+	// queryInsertMessageEvent records the message event with the next sequence number
+	// to the slot of the ring buffer of the given size, replacing the event in the slot.
+	queryInsertMessageEvent = `insert or replace into message_events
+	(slot, seq, queue_id, msg_id, kind, attempts, visible_at, detail, created_at)
+	select (last.seq + 1) % ?1, last.seq + 1, ?2, ?3, ?4, ?5, ?6, ?7, ?8
+	from (select coalesce(max(seq), 0) as seq from message_events) as last;`
+
+	// querySelectMessageEvents selects the most recent events of the message recorded after the given time.
+	querySelectMessageEvents = `select kind, attempts, visible_at, detail, created_at from message_events
+	where queue_id = ? and msg_id = ? and created_at >= ? order by seq desc limit ?;`
+
+	// queryDeleteMessageEventsBefore deletes message events recorded before the given time.
+	queryDeleteMessageEventsBefore = `delete from message_events where created_at < ?;`
+
+	// queryDeleteQueueMessageEvents deletes events of messages of the queue.
+	queryDeleteQueueMessageEvents = `delete from message_events where queue_id = ?;`
+
+	// queryListJobs selects the most recent background jobs, optionally limited
+	// to the ones of the queue (?1) and the ones in the state (?2).
+	queryListJobs = `select job_id, kind, queue_id, state, processed, total, error, created_at, updated_at from jobs
+		where (?1 = '' or queue_id = ?1) and (?2 = 0 or state = ?2)
+		order by created_at desc, job_id desc limit ?3;`
+
+	// queryUpdateJobProgress adds processed items to the running job.
+	queryUpdateJobProgress = `update jobs set processed = processed + ?, updated_at = ? where job_id = ?;`
+
+	// queryFinishJob sets the final state of the job along with the error it has failed with.
+	queryFinishJob = `update jobs set state = ?, error = ?, updated_at = ? where job_id = ?;`
+
+	// queryFailRunningJobs fails jobs which have been running when the server stopped.
+	queryFailRunningJobs = `update jobs set state = ?, error = ?, updated_at = ? where state = ?;`
 )
 
 type querier struct {
@@ -86,6 +695,9 @@ func queryCreateQueueTable(queueID string) string {
 		create index if not exists ` + queueID + `_created_at_index
 			on ` + queueID + ` (created_at);
 		
+		create index if not exists ` + queueID + `_created_at_msg_id_index
+			on ` + queueID + ` (created_at, msg_id);
+
 		create index if not exists ` + queueID + `_visible_at_index
 			on ` + queueID + `(visible_at);
 		
@@ -100,27 +712,252 @@ func queryCreateQueueTable(queueID string) string {
 	return q
 }
 
+// queryAddUpdatedAtColumn adds the column which
+// is set by the update trigger of the queue table.
+func queryAddUpdatedAtColumn(queueID string) string {
+	q := `alter table ` + queueID + ` add column updated_at int;`
+
+	return q
+}
+
+// queryAddFirstReceivedAtColumn adds the column which holds
+// the time the message has been received for the first time.
+func queryAddFirstReceivedAtColumn(queueID string) string {
+	q := `alter table ` + queueID + ` add column first_received_at int;`
+
+	return q
+}
+
+// queryAddAttributesColumn adds the column which holds attributes of the message as the JSON object.
+func queryAddAttributesColumn(queueID string) string {
+	q := `alter table ` + queueID + ` add column msg_attributes text;`
+
+	return q
+}
+
+// attributesColumn returns the column of message attributes,
+// or null for tables without the msg_attributes column.
+func attributesColumn(attributes bool) string {
+	if attributes {
+		return `msg_attributes`
+	}
+
+	return `null`
+}
+
+// queryCreateUsageTriggers creates triggers which keep the usage record
+// of the queue in sync with messages stored in the queue table.
+func queryCreateUsageTriggers(queueID string) string {
+	q := `create trigger if not exists ` + queueID + `_usage_insert
+			after insert on ` + queueID + `
+			for each row
+		begin
+			update queue_usage set messages = messages + 1, bytes = bytes + length(cast(new.msg_body as blob))
+			where queue_id = '` + queueID + `';
+		end;
+
+		create trigger if not exists ` + queueID + `_usage_delete
+			after delete on ` + queueID + `
+			for each row
+		begin
+			update queue_usage set messages = messages - 1, bytes = bytes - length(cast(old.msg_body as blob))
+			where queue_id = '` + queueID + `';
+		end;
+	`
+
+	return q
+}
+
+// queryInsertQueueUsageFromTable creates the usage record
+// of the queue from messages stored in the queue table.
+func queryInsertQueueUsageFromTable(queueID string) string {
+	q := `insert into queue_usage (queue_id, messages, bytes)
+	select ?, count(*), coalesce(sum(length(cast(msg_body as blob))), 0) from ` + queueID + `;`
+
+	return q
+}
+
+// querySelectOldestMessages returns messages ordered from the oldest,
+// which are evicted first when the queue exceeds its quota.
+func querySelectOldestMessages(queueID string) string {
+	q := `select msg_id, msg_body from ` + queueID + ` order by created_at, msg_id;`
+
+	return q
+}
+
+// querySelectReceiptKey selects the key of receipt handle signatures.
+const querySelectReceiptKey = `select key from receipt_key where id = 0;`
+
+// queryFullTextSearchSupported reports whether the SQLite library has the FTS5 module,
+// which is compiled in with the sqlite_fts5 build tag.
+const queryFullTextSearchSupported = `select sqlite_compileoption_used('ENABLE_FTS5');`
+
+// searchIndexTable returns the name of the full-text search index table of the queue.
+func searchIndexTable(queueID string) string { return queueID + `_fts` }
+
+// queryCreateSearchIndex creates the full-text search index of the queue
+// and triggers which keep it in sync with the queue table. Only the first
+// maxBytes of each message body are indexed to bound the indexing cost.
+func queryCreateSearchIndex(queueID string, maxBytes uint) string {
+	fts := searchIndexTable(queueID)
+	limit := strconv.FormatUint(uint64(maxBytes), 10)
+
+	q := `create virtual table ` + fts + ` using fts5(msg_id unindexed, msg_body);
+
+		create trigger if not exists ` + queueID + `_search_index_insert
+			after insert on ` + queueID + `
+			for each row
+		begin
+			insert into ` + fts + ` (msg_id, msg_body) values (new.msg_id, cast(substr(new.msg_body, 1, ` + limit + `) as text));
+		end;
+
+		create trigger if not exists ` + queueID + `_search_index_delete
+			after delete on ` + queueID + `
+			for each row
+		begin
+			delete from ` + fts + ` where msg_id = old.msg_id;
+		end;
+	`
+
+	return q
+}
+
+// querySearchMessages returns messages of the queue table aliased as "m" which match all conditions.
+// Messages are ordered by relevance when the full-text search query is matched, otherwise
+// by the time they were sent.
+func querySearchMessages(queueID string, fullText bool, where []string) string {
+	from := queueID + ` m`
+	order := `m.created_at, m.msg_id`
+
+	if fullText {
+		from = searchIndexTable(queueID) + ` f join ` + queueID + ` m on m.msg_id = f.msg_id`
+		order = `f.rank`
+	}
+
+	q := `select m.msg_id, m.msg_body, m.retries from ` + from +
+		` where ` + strings.Join(where, ` and `) + ` order by ` + order + ` limit ?;`
+
+	return q
+}
+
+// queryPeekMessages returns messages ordered by created_at and msg_id. When the cursor
+// is given, only messages after the (created_at, msg_id) position are returned, so
+// pages stay stable while messages are sent and deleted, and no rows are skipped by OFFSET.
+// Bodies are selected only when asked for, otherwise they're null.
+func queryPeekMessages(queueID string, cursor, body bool) string {
+	where := ``
+	if cursor {
+		where = ` where (created_at, msg_id) > (?, ?)`
+	}
+
+	bodyColumn := `null`
+	if body {
+		bodyColumn = `msg_body`
+	}
+
+	q := `select msg_id, ` + bodyColumn + `, coalesce(length(msg_body), 0), retries, created_at, visible_at from ` + queueID + where +
+		` order by created_at, msg_id limit ?;`
+
+	return q
+}
+
+// querySelectMessage returns the message by its identifier along with
+// whether it has been received and is not visible yet.
+func querySelectMessage(queueID string) string {
+	q := `select msg_id, msg_body, coalesce(length(msg_body), 0), retries, created_at, visible_at,
+		visible_at > current_timestamp from ` + queueID + ` where msg_id = ?;`
+
+	return q
+}
+
 func queryInsertMessages(queueID string) string {
-	q := `insert into ` + queueID + ` (msg_id, msg_body) values (?, ?);`
+	q := `insert into ` + queueID + ` (msg_id, msg_body, visible_at) values (?, ?, ?);`
+
+	return q
+}
+
+// queryInsertMessagesWithAttributes inserts messages along with their attributes.
+func queryInsertMessagesWithAttributes(queueID string) string {
+	q := `insert into ` + queueID + ` (msg_id, msg_body, visible_at, msg_attributes) values (?, ?, ?, ?);`
 
 	return q
 }
 
 func queryDeleteQueueTable(queueID string) string {
-	q := `drop table ` + queueID + `;`
+	q := `drop table ` + queueID + `; drop table if exists ` + searchIndexTable(queueID) + `;`
 
 	return q
 }
 
-func querySelectMessages(queueID string) string {
-	q := `select msg_id, msg_body from ` + queueID +
-		` where visible_at <= current_timestamp and retries <= ? order by created_at limit ?;`
+// querySelectMessages returns visible messages along with the time they were sent,
+// received for the first time and their attributes, which are null in tables without
+// the first_received_at and msg_attributes columns. Unless the filter condition is
+// empty, only messages which match it are returned, and its arguments go between
+// the maximum number of receives and the limit.
+func querySelectMessages(queueID string, firstReceivedAt, attributes bool, filter string) string {
+	firstReceivedAtColumn := `null`
+	if firstReceivedAt {
+		firstReceivedAtColumn = `first_received_at`
+	}
+
+	where := `visible_at <= current_timestamp and retries <= ?`
+	if filter != "" {
+		where += ` and (` + filter + `)`
+	}
+
+	q := `select msg_id, msg_body, retries, created_at, ` + firstReceivedAtColumn + `, ` + attributesColumn(attributes) +
+		` from ` + queueID + ` where ` + where + ` order by created_at limit ?;`
 
 	return q
 }
 
-func queryUpdateMessages(queueID string) string {
-	q := `update ` + queueID + ` set visible_at = ?, retries = retries + 1 where msg_id = ?;`
+func queryCountVisibleMessages(queueID string) string {
+	q := `select count(*) from ` + queueID +
+		` where visible_at <= current_timestamp and retries <= ?;`
+
+	return q
+}
+
+// queryCountVisibleMessagesUpTo counts visible messages up to the limit,
+// so that the count doesn't scan the whole backlog of the queue.
+func queryCountVisibleMessagesUpTo(queueID string) string {
+	q := `select count(*) from (select 1 from ` + queueID +
+		` where visible_at <= current_timestamp and retries <= ? limit ?);`
+
+	return q
+}
+
+func queryCountInFlightMessages(queueID string) string {
+	q := `select count(*) from ` + queueID + ` where visible_at > current_timestamp and retries > 0;`
+
+	return q
+}
+
+// queryUpdateMessages makes the received message invisible and counts the receive.
+// The time of the first receive is recorded in tables with the first_received_at column.
+func queryUpdateMessages(queueID string, firstReceivedAt bool) string {
+	if firstReceivedAt {
+		q := `update ` + queueID + ` set visible_at = ?1, retries = retries + 1,
+			first_received_at = case when retries = 0 then ?2 else first_received_at end where msg_id = ?3;`
+
+		return q
+	}
+
+	q := `update ` + queueID + ` set visible_at = ?1, retries = retries + 1 where msg_id = ?3;`
+
+	return q
+}
+
+func queryChangeVisibility(queueID string) string {
+	q := `update ` + queueID + ` set visible_at = ? where msg_id = ?;`
+
+	return q
+}
+
+// queryChangeReceivedVisibility changes visibility of the message only while
+// it stays invisible after the receive the retries counter is expected for.
+func queryChangeReceivedVisibility(queueID string) string {
+	q := `update ` + queueID + ` set visible_at = ? where msg_id = ? and retries = ? and visible_at > current_timestamp;`
 
 	return q
 }
@@ -131,12 +968,64 @@ func queryDeleteMessage(queueID string) string {
 	return q
 }
 
+// queryDeleteReceivedMessage deletes the message only while it stays
+// invisible after the receive the retries counter is expected for.
+func queryDeleteReceivedMessage(queueID string) string {
+	q := `delete from ` + queueID + ` where msg_id = ? and retries = ? and visible_at > current_timestamp;`
+
+	return q
+}
+
+func queryRetriesDistribution(queueID string) string {
+	q := `select retries, count(*) from ` + queueID + ` group by retries;`
+
+	return q
+}
+
+func queryOldestMessageAge(queueID string) string {
+	q := `select coalesce(strftime('%s', 'now') - strftime('%s', min(created_at)), 0) from ` + queueID + `;`
+
+	return q
+}
+
 func queryPurgeQueue(queueID string) string {
 	q := `delete from ` + queueID + `;`
 
 	return q
 }
 
+// queryPurgeMessagesBatch deletes a limited batch of messages of the queue which
+// match all conditions, so huge queues are purged by short transactions.
+func queryPurgeMessagesBatch(queueID string, where []string) string {
+	cond := ``
+	if len(where) > 0 {
+		cond = ` where ` + strings.Join(where, ` and `)
+	}
+
+	q := `delete from ` + queueID + ` where rowid in (select rowid from ` + queueID + cond + ` limit ?);`
+
+	return q
+}
+
+// queryCountPurgedMessages counts messages of the queue which match all conditions.
+func queryCountPurgedMessages(queueID string, where []string) string {
+	cond := ``
+	if len(where) > 0 {
+		cond = ` where ` + strings.Join(where, ` and `)
+	}
+
+	q := `select count(*) from ` + queueID + cond + `;`
+
+	return q
+}
+
+// queryPurgeMessages deletes messages of the queue which match all conditions.
+func queryPurgeMessages(queueID string, where []string) string {
+	q := `delete from ` + queueID + ` where ` + strings.Join(where, ` and `) + `;`
+
+	return q
+}
+
 func queryCountMessages(queueID string) string {
 	q := `select count(*) from ` + queueID + `;`
 
@@ -149,6 +1038,48 @@ func queryDropMessages(queueID string) string {
 	return q
 }
 
+// queryArchiveEvictedMessages copies messages of the queue which have been received the maximum
+// number of times (?2) or sent before the retention bound (?3) to the archive of the queue (?1).
+func queryArchiveEvictedMessages(queueID string) string {
+	q := `insert or replace into archive (queue_id, msg_id, msg_body, attempts, reason, created_at, archived_at)
+		select ?1, msg_id, msg_body, retries,
+			case when retries >= ?2 then '` + archiveReasonAttempts + `' else '` + archiveReasonRetention + `' end,
+			created_at, ?4
+		from ` + queueID + ` where retries >= ?2 or created_at <= ?3;`
+
+	return q
+}
+
+// queryDeleteEvictedMessages deletes messages of the queue which have been
+// received the maximum number of times or sent before the retention bound.
+func queryDeleteEvictedMessages(queueID string) string {
+	q := `delete from ` + queueID + ` where retries >= ? or created_at <= ?;`
+
+	return q
+}
+
+// queryArchiveMessage copies the message of the queue to the archive of the queue.
+func queryArchiveMessage(queueID string) string {
+	q := `insert or replace into archive (queue_id, msg_id, msg_body, attempts, reason, created_at, archived_at)
+		select ?, msg_id, msg_body, retries, ?, created_at, ? from ` + queueID + ` where msg_id = ?;`
+
+	return q
+}
+
+// querySelectArchivedMessages returns archived messages of the queue after the cursor
+// in order they have been sent. Bodies are selected only when asked for, otherwise they're null.
+func querySelectArchivedMessages(body bool) string {
+	bodyColumn := `null`
+	if body {
+		bodyColumn = `msg_body`
+	}
+
+	q := `select msg_id, ` + bodyColumn + `, attempts, reason, created_at, archived_at from archive
+		where queue_id = ? and msg_id > ? order by msg_id limit ?;`
+
+	return q
+}
+
 func querySelectMoveToDLQ(queueID string) string {
 	q := `select * from ` + queueID + ` where retries >= ? or datetime(created_at, '+? seconds') <= current_timestamp;`
 
@@ -161,17 +1092,20 @@ func queueDescribeQueueProps(where string) string {
 	return q
 }
 
-func queryListQueues(pageSize int32, cursor string, orderBy v1.ListQueuesRequest_OrderBy, sortBy v1.ListQueuesRequest_SortBy) string {
+// queryListQueues returns the query listing queue properties along with its arguments.
+// Queues are filtered by the name prefix unless it is empty, and listed after the cursor
+// unless it is nil. Queues are ordered by the identifier after the order column, so the
+// order is total and pages are stable whatever column they are ordered by.
+func queryListQueues(pageSize int32, cursor *listCursor, prefix string, orderBy v1.ListQueuesRequest_OrderBy, sortBy v1.ListQueuesRequest_SortBy) (string, []any) {
 	var (
 		orderByStr = "queue_id"
 		sortByStr  = "desc"
-		where      = ""
+		compare    = "<"
+		where      []string
+		args       []any
 	)
 
 	switch orderBy {
-	case v1.ListQueuesRequest_ORDER_BY_ID:
-		orderByStr = "queue_id"
-
 	case v1.ListQueuesRequest_ORDER_BY_NAME:
 		orderByStr = "queue_name"
 
@@ -179,23 +1113,44 @@ func queryListQueues(pageSize int32, cursor string, orderBy v1.ListQueuesRequest
 		orderByStr = "created_at"
 	}
 
-	switch sortBy {
-	case v1.ListQueuesRequest_SORT_BY_ASC:
+	// SORT_BY_ASC is the zero value, so queues are listed in ascending order unless
+	// the descending one is asked for, while unknown directions keep the descending order.
+	if sortBy == v1.ListQueuesRequest_SORT_BY_ASC {
 		sortByStr = "asc"
+		compare = ">"
+	}
 
-		if cursor != "" {
-			where = fmt.Sprintf("where %s > '%s'", orderByStr, cursor)
-		}
+	if prefix != "" {
+		// Names are compared by substring rather than with like,
+		// which is case-insensitive and treats '%' and '_' as wildcards.
+		where = append(where, "substr(queue_name, 1, length(?)) = ?")
+		args = append(args, prefix, prefix)
+	}
 
-	case v1.ListQueuesRequest_SORT_BY_DESC:
-		sortByStr = "desc"
+	order := orderByStr + " " + sortByStr
+
+	switch {
+	case orderByStr == "queue_id":
+		if cursor != nil {
+			where = append(where, "queue_id "+compare+" ?")
+			args = append(args, cursor.ID)
+		}
 
-		if cursor != "" {
-			where = fmt.Sprintf("where %s < '%s'", orderByStr, cursor)
+	default:
+		if cursor != nil {
+			where = append(where, "("+orderByStr+", queue_id) "+compare+" (?, ?)")
+			args = append(args, cursor.Value, cursor.ID)
 		}
+
+		order += ", queue_id " + sortByStr
+	}
+
+	var whereStr string
+	if len(where) > 0 {
+		whereStr = "where " + strings.Join(where, " and ") + " "
 	}
 
-	q := fmt.Sprintf(`select * from queue_properties %s order by %s %s limit %d;`, where, orderByStr, sortByStr, pageSize)
+	q := fmt.Sprintf(`select * from queue_properties %sorder by %s limit %d;`, whereStr, order, pageSize)
 
-	return q
+	return q, args
 }
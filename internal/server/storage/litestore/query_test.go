@@ -1,10 +1,13 @@
 package litestore
 
 import (
+	"database/sql"
 	"strings"
 	"testing"
 
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
 )
 
 func Test_queryCreateQueueTable(t *testing.T) {
@@ -26,3 +29,171 @@ func Test_queryCreateQueueTable(t *testing.T) {
 		})
 	}
 }
+
+func Test_queryUpdateMessages(t *testing.T) {
+	var tests = map[string]struct {
+		firstReceivedAt bool
+		expected        bool
+	}{
+		"evolved table":   {firstReceivedAt: true, expected: true},
+		"unevolved table": {firstReceivedAt: false, expected: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			// tables which haven't been evolved yet don't have the first_received_at column.
+			td.Cmp(t, strings.Contains(queryUpdateMessages("q1", tt.firstReceivedAt), "first_received_at"), tt.expected)
+			td.Cmp(t, strings.Contains(querySelectMessages("q1", tt.firstReceivedAt, false, ""), "first_received_at"), tt.expected)
+		})
+	}
+}
+
+func Test_querySelectMessages(t *testing.T) {
+	var tests = map[string]struct {
+		attributes bool
+		filter     string
+		expected   string
+	}{
+		"unevolved table": {
+			expected: "select msg_id, msg_body, retries, created_at, first_received_at, null from q1 " +
+				"where visible_at <= current_timestamp and retries <= ? order by created_at limit ?;",
+		},
+		"attributes": {
+			attributes: true,
+			expected: "select msg_id, msg_body, retries, created_at, first_received_at, msg_attributes from q1 " +
+				"where visible_at <= current_timestamp and retries <= ? order by created_at limit ?;",
+		},
+		"filter": {
+			attributes: true,
+			filter:     "json_extract(msg_attributes, ?) = ? or json_extract(msg_attributes, ?) = ?",
+			expected: "select msg_id, msg_body, retries, created_at, first_received_at, msg_attributes from q1 " +
+				"where visible_at <= current_timestamp and retries <= ? " +
+				"and (json_extract(msg_attributes, ?) = ? or json_extract(msg_attributes, ?) = ?) order by created_at limit ?;",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, querySelectMessages("q1", true, tt.attributes, tt.filter), tt.expected)
+		})
+	}
+}
+
+func Test_queryPurgeMessages(t *testing.T) {
+	var tests = map[string]struct {
+		where    []string
+		expected string
+	}{
+		"single condition":   {where: []string{"retries >= ?"}, expected: "delete from q1 where retries >= ?;"},
+		"several conditions": {where: []string{"created_at < ?", "retries >= ?"}, expected: "delete from q1 where created_at < ? and retries >= ?;"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, queryPurgeMessages("q1", tt.where), tt.expected)
+		})
+	}
+}
+
+func Test_queryListQueues(t *testing.T) {
+	var tests = map[string]struct {
+		cursor       *listCursor
+		prefix       string
+		orderBy      v1.ListQueuesRequest_OrderBy
+		sortBy       v1.ListQueuesRequest_SortBy
+		expected     string
+		expectedArgs []any
+	}{
+		"no filters": {
+			orderBy:  v1.ListQueuesRequest_ORDER_BY_NAME,
+			sortBy:   v1.ListQueuesRequest_SORT_BY_ASC,
+			expected: "select * from queue_properties order by queue_name asc, queue_id asc limit 11;",
+		},
+		"prefix": {
+			prefix:       "orders",
+			orderBy:      v1.ListQueuesRequest_ORDER_BY_NAME,
+			sortBy:       v1.ListQueuesRequest_SORT_BY_ASC,
+			expected:     "select * from queue_properties where substr(queue_name, 1, length(?)) = ? order by queue_name asc, queue_id asc limit 11;",
+			expectedArgs: []any{"orders", "orders"},
+		},
+		"prefix and cursor": {
+			cursor:       &listCursor{Value: "orders-a", ID: "q1"},
+			prefix:       "orders",
+			orderBy:      v1.ListQueuesRequest_ORDER_BY_NAME,
+			sortBy:       v1.ListQueuesRequest_SORT_BY_ASC,
+			expected:     "select * from queue_properties where substr(queue_name, 1, length(?)) = ? and (queue_name, queue_id) > (?, ?) order by queue_name asc, queue_id asc limit 11;",
+			expectedArgs: []any{"orders", "orders", "orders-a", "q1"},
+		},
+		"cursor by id": {
+			cursor:       &listCursor{ID: "q1"},
+			orderBy:      v1.ListQueuesRequest_ORDER_BY_ID,
+			sortBy:       v1.ListQueuesRequest_SORT_BY_DESC,
+			expected:     "select * from queue_properties where queue_id < ? order by queue_id desc limit 11;",
+			expectedArgs: []any{"q1"},
+		},
+		"unspecified sort": {
+			cursor:       &listCursor{Value: "orders-a", ID: "q1"},
+			orderBy:      v1.ListQueuesRequest_ORDER_BY_NAME,
+			expected:     "select * from queue_properties where (queue_name, queue_id) > (?, ?) order by queue_name asc, queue_id asc limit 11;",
+			expectedArgs: []any{"orders-a", "q1"},
+		},
+		"unknown sort": {
+			cursor:       &listCursor{Value: "orders-a", ID: "q1"},
+			orderBy:      v1.ListQueuesRequest_ORDER_BY_NAME,
+			sortBy:       v1.ListQueuesRequest_SortBy(42),
+			expected:     "select * from queue_properties where (queue_name, queue_id) < (?, ?) order by queue_name desc, queue_id desc limit 11;",
+			expectedArgs: []any{"orders-a", "q1"},
+		},
+		"cursor by creation time": {
+			cursor:       &listCursor{Value: "2024-05-01 10:30:00", ID: "q1"},
+			orderBy:      v1.ListQueuesRequest_ORDER_BY_CREATED_AT,
+			sortBy:       v1.ListQueuesRequest_SORT_BY_DESC,
+			expected:     "select * from queue_properties where (created_at, queue_id) < (?, ?) order by created_at desc, queue_id desc limit 11;",
+			expectedArgs: []any{"2024-05-01 10:30:00", "q1"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, args := queryListQueues(11, tt.cursor, tt.prefix, tt.orderBy, tt.sortBy)
+			td.Cmp(t, query, tt.expected)
+			td.Cmp(t, args, tt.expectedArgs)
+		})
+	}
+}
+
+func Test_queryCountVisibleMessagesUpTo(t *testing.T) {
+	db, openErr := sql.Open("sqlite3", ":memory:")
+	td.Require(t).CmpNoError(openErr)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, createErr := db.Exec(queryCreateQueueTable("count_test"))
+	td.Require(t).CmpNoError(createErr)
+
+	// 25 visible messages, one of them has exhausted receive attempts, and one in-flight message.
+	_, insertErr := db.Exec(`insert into count_test (msg_id, msg_body)
+		with recursive n(i) as (select 2 union all select i + 1 from n where i < 25)
+		select 'msg' || i, 'body' from n;
+		insert into count_test (msg_id, msg_body, retries) values ('msg1', 'body', 5);
+		insert into count_test (msg_id, msg_body, visible_at, retries)
+		values ('in-flight', 'body', datetime('now', '+1 hour'), 1);`)
+	td.Require(t).CmpNoError(insertErr)
+
+	tests := map[string]struct {
+		limit int
+		want  uint64
+	}{
+		"Bounded":   {limit: maxBatchSize, want: maxBatchSize},
+		"Unbounded": {limit: 100, want: 24},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var visible uint64
+
+			td.CmpNoError(t, db.QueryRow(queryCountVisibleMessagesUpTo("count_test"), 3, tt.limit).Scan(&visible))
+			td.Cmp(t, visible, tt.want)
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+	"github.com/plainq/servekit/errkit"
+)
+
+// Actions taken when the queue reaches its quota, used as metric labels.
+const (
+	quotaActionRejected = "rejected"
+	quotaActionEvicted  = "evicted"
+)
+
+// queueUsage holds the number of messages and bytes of message bodies.
+type queueUsage struct {
+	Messages uint64
+	Bytes    uint64
+}
+
+// hasQuota reports whether the queue has a quota on messages or bytes.
+func hasQuota(info *v1.DescribeQueueResponse) bool {
+	return info.GetMaxMessages() > 0 || info.GetMaxBytes() > 0
+}
+
+// checkQuota returns how much of the stored usage should be evicted to fit
+// the batch into the queue quota. It returns the *pqerr.QuotaExceededError
+// when the batch alone exceeds the quota, or when the queue doesn't fit
+// the batch and its quota policy is not to evict the oldest messages.
+func checkQuota(info *v1.DescribeQueueResponse, usage, batch queueUsage) (queueUsage, error) {
+	quotas := []struct {
+		name         string
+		limit        uint64
+		used, needed uint64
+	}{
+		{name: "messages", limit: info.GetMaxMessages(), used: usage.Messages, needed: batch.Messages},
+		{name: "bytes", limit: info.GetMaxBytes(), used: usage.Bytes, needed: batch.Bytes},
+	}
+
+	var excess queueUsage
+
+	for i, q := range quotas {
+		if q.limit == 0 || q.used+q.needed <= q.limit {
+			continue
+		}
+
+		quotaErr := pqerr.QuotaExceededError{Quota: q.name, Usage: q.used, Requested: q.needed, Limit: q.limit}
+
+		// Evicting the whole queue won't help.
+		if q.needed > q.limit || info.GetQuotaPolicy() != v1.QuotaPolicy_QUOTA_POLICY_EVICT {
+			return queueUsage{}, &quotaErr
+		}
+
+		if i == 0 {
+			excess.Messages = q.used + q.needed - q.limit
+		} else {
+			excess.Bytes = q.used + q.needed - q.limit
+		}
+	}
+
+	return excess, nil
+}
+
+// readUsage returns the number of messages and bytes stored in the queue.
+func readUsage(ctx context.Context, tx *sql.Tx, queueID string) (queueUsage, error) {
+	var usage queueUsage
+
+	if err := tx.QueryRowContext(ctx, querySelectQueueUsage, queueID).Scan(&usage.Messages, &usage.Bytes); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return queueUsage{}, fmt.Errorf("%w: usage of queue %q", errkit.ErrNotFound, queueID)
+		}
+
+		return queueUsage{}, err
+	}
+
+	return usage, nil
+}
+
+// evictForQuota removes the oldest messages of the queue until at least
+// the excess is freed. Evicted messages are moved to the dead letter queue
+// when the queue has one and its eviction policy is DEAD_LETTER, to the archive
+// when its eviction policy is ARCHIVE, otherwise they are dropped.
+// Returns the number of evicted messages.
+func evictForQuota(ctx context.Context, tx *sql.Tx, info *v1.DescribeQueueResponse, excess queueUsage) (_ uint64, sErr error) {
+	queueID := info.GetQueueId()
+
+	rows, queryErr := tx.QueryContext(ctx, querySelectOldestMessages(queueID))
+	if queryErr != nil {
+		return 0, fmt.Errorf("select oldest messages: %w", queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	var (
+		freed   queueUsage
+		ids     []string
+		bodies  [][]byte
+		toDLQ   = info.GetEvictionPolicy() == v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER && info.GetDeadLetterQueueId() != ""
+		archive = info.GetEvictionPolicy() == v1.EvictionPolicy_EVICTION_POLICY_ARCHIVE
+		evicted = func() bool { return freed.Messages >= excess.Messages && freed.Bytes >= excess.Bytes }
+	)
+
+	for !evicted() && rows.Next() {
+		var (
+			msgID   string
+			msgBody []byte
+		)
+
+		if err := rows.Scan(&msgID, &msgBody); err != nil {
+			return 0, fmt.Errorf("scan message record: %w", err)
+		}
+
+		ids = append(ids, msgID)
+		if toDLQ {
+			bodies = append(bodies, msgBody)
+		}
+
+		freed.Messages++
+		freed.Bytes += uint64(len(msgBody))
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate message records: %w", err)
+	}
+
+	if err := rows.Close(); err != nil {
+		return 0, fmt.Errorf("close rows: %w", err)
+	}
+
+	if toDLQ {
+		for i, id := range ids {
+			if _, err := tx.ExecContext(ctx, queryInsertMessages(info.GetDeadLetterQueueId()), id, bodies[i], time.Now().UTC()); err != nil {
+				return 0, fmt.Errorf("move message %q to dead letter queue: %w", id, err)
+			}
+		}
+	}
+
+	for _, id := range ids {
+		if archive {
+			if err := archiveMessage(ctx, tx, queueID, id, archiveReasonQuota); err != nil {
+				return 0, err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, queryDeleteMessage(queueID), id); err != nil {
+			return 0, fmt.Errorf("delete message %q: %w", id, err)
+		}
+	}
+
+	return uint64(len(ids)), nil
+}
+
+// ensureUsage creates usage records and triggers for queues which
+// have been created before the usage of queues has been tracked.
+func (s *Storage) ensureUsage(ctx context.Context) (sErr error) {
+	tx, txErr := s.beginTx(ctx, opEnsureUsage, false)
+	if txErr != nil {
+		return fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	rows, queryErr := tx.QueryContext(ctx, querySelectQueuesWithoutUsage)
+	if queryErr != nil {
+		return fmt.Errorf("select queues without usage: %w", queryErr)
+	}
+
+	var queues []string
+
+	for rows.Next() {
+		var queueID string
+
+		if err := rows.Scan(&queueID); err != nil {
+			return errors.Join(fmt.Errorf("scan row: %w", err), rows.Close())
+		}
+
+		queues = append(queues, queueID)
+	}
+
+	if err := errors.Join(rows.Err(), rows.Close()); err != nil {
+		return fmt.Errorf("iterate queues without usage: %w", err)
+	}
+
+	for _, queueID := range queues {
+		if _, err := tx.ExecContext(ctx, queryInsertQueueUsageFromTable(queueID), queueID); err != nil {
+			return fmt.Errorf("create queue %q usage record: %w", queueID, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, queryCreateUsageTriggers(queueID)); err != nil {
+			return fmt.Errorf("create queue %q usage triggers: %w", queueID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	return nil
+}
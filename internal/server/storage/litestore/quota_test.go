@@ -0,0 +1,81 @@
+package litestore
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+)
+
+func Test_checkQuota(t *testing.T) {
+	type tcase struct {
+		maxMessages uint64
+		maxBytes    uint64
+		policy      v1.QuotaPolicy
+		usage       queueUsage
+		batch       queueUsage
+		want        queueUsage
+		wantErr     *pqerr.QuotaExceededError
+	}
+
+	tests := map[string]tcase{
+		"NoQuota": {
+			usage: queueUsage{Messages: 100500, Bytes: 1 << 30},
+			batch: queueUsage{Messages: 1, Bytes: 10},
+		},
+		"WithinQuota": {
+			maxMessages: 10, maxBytes: 100,
+			usage: queueUsage{Messages: 8, Bytes: 80},
+			batch: queueUsage{Messages: 2, Bytes: 20},
+		},
+		"RejectMessages": {
+			maxMessages: 10,
+			usage:       queueUsage{Messages: 10, Bytes: 80},
+			batch:       queueUsage{Messages: 1, Bytes: 10},
+			wantErr:     &pqerr.QuotaExceededError{Quota: "messages", Usage: 10, Requested: 1, Limit: 10},
+		},
+		"RejectBytes": {
+			maxBytes: 100,
+			policy:   v1.QuotaPolicy_QUOTA_POLICY_REJECT,
+			usage:    queueUsage{Messages: 8, Bytes: 95},
+			batch:    queueUsage{Messages: 1, Bytes: 10},
+			wantErr:  &pqerr.QuotaExceededError{Quota: "bytes", Usage: 95, Requested: 10, Limit: 100},
+		},
+		"EvictMessages": {
+			maxMessages: 10,
+			policy:      v1.QuotaPolicy_QUOTA_POLICY_EVICT,
+			usage:       queueUsage{Messages: 10, Bytes: 100},
+			batch:       queueUsage{Messages: 3, Bytes: 30},
+			want:        queueUsage{Messages: 3},
+		},
+		"EvictBoth": {
+			maxMessages: 10, maxBytes: 100,
+			policy: v1.QuotaPolicy_QUOTA_POLICY_EVICT,
+			usage:  queueUsage{Messages: 10, Bytes: 100},
+			batch:  queueUsage{Messages: 1, Bytes: 25},
+			want:   queueUsage{Messages: 1, Bytes: 25},
+		},
+		"BatchOverQuota": {
+			maxBytes: 100,
+			policy:   v1.QuotaPolicy_QUOTA_POLICY_EVICT,
+			batch:    queueUsage{Messages: 2, Bytes: 101},
+			wantErr:  &pqerr.QuotaExceededError{Quota: "bytes", Requested: 101, Limit: 100},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			info := v1.DescribeQueueResponse{MaxMessages: tc.maxMessages, MaxBytes: tc.maxBytes, QuotaPolicy: tc.policy}
+
+			excess, err := checkQuota(&info, tc.usage, tc.batch)
+			if tc.wantErr != nil {
+				td.Cmp(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, excess, tc.want)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package litestore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/plainq/servekit/errkit"
+)
+
+// receiptHandleSeparator separates the message identifier
+// and the receive number in receipt handles.
+const receiptHandleSeparator = ":"
+
+// newReceiptHandle returns the receipt handle of the receive of the message.
+//
+// The handle holds the number of the receive, which is the retries counter of
+// the message after the receive, so the handle stops matching the message once
+// it is received again after its visibility timeout has expired. The handle is
+// signed with the receipt key, so it can't be made up from the message identifier.
+func (s *Storage) newReceiptHandle(queueID, messageID string, receive uint32) string {
+	payload := messageID + receiptHandleSeparator + strconv.FormatUint(uint64(receive), 10)
+
+	return base64.RawURLEncoding.EncodeToString(append([]byte(payload), s.receiptSignature(queueID, payload)...))
+}
+
+// parseReceiptHandle returns the message identifier and the receive number
+// held by the receipt handle issued for the receive from the queue.
+func (s *Storage) parseReceiptHandle(queueID, handle string) (string, uint32, error) {
+	raw, decodeErr := base64.RawURLEncoding.DecodeString(handle)
+	if decodeErr != nil || len(raw) <= sha256.Size {
+		return "", 0, fmt.Errorf("%w: receipt handle %q is malformed", errkit.ErrInvalidArgument, handle)
+	}
+
+	payload, signature := string(raw[:len(raw)-sha256.Size]), raw[len(raw)-sha256.Size:]
+
+	if !hmac.Equal(signature, s.receiptSignature(queueID, payload)) {
+		return "", 0, fmt.Errorf("%w: receipt handle %q is not issued by the queue", errkit.ErrInvalidArgument, handle)
+	}
+
+	messageID, receive, ok := strings.Cut(payload, receiptHandleSeparator)
+	if !ok || messageID == "" {
+		return "", 0, fmt.Errorf("%w: receipt handle %q is malformed", errkit.ErrInvalidArgument, handle)
+	}
+
+	n, parseErr := strconv.ParseUint(receive, 10, 32)
+	if parseErr != nil || n == 0 {
+		return "", 0, fmt.Errorf("%w: receipt handle %q is malformed", errkit.ErrInvalidArgument, handle)
+	}
+
+	return messageID, uint32(n), nil
+}
+
+// receiptSignature returns the HMAC-SHA256 of the receipt handle payload
+// and the queue, so handles of one queue aren't accepted by another.
+func (s *Storage) receiptSignature(queueID, payload string) []byte {
+	mac := hmac.New(sha256.New, s.receiptKey)
+	mac.Write([]byte(queueID + receiptHandleSeparator + payload))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,61 @@
+package litestore
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/errkit"
+)
+
+func TestStorage_parseReceiptHandle(t *testing.T) {
+	type tcase struct {
+		handle      string
+		wantID      string
+		wantReceive uint32
+		wantErr     error
+	}
+
+	const queueID = "CSGE6N05SHOB6TB8V5FG"
+
+	s := Storage{receiptKey: []byte("key")}
+	other := Storage{receiptKey: []byte("other key")}
+
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	// sign returns the handle with the given payload signed by the key of the storage.
+	sign := func(payload string) string {
+		return base64.RawURLEncoding.EncodeToString(append([]byte(payload), s.receiptSignature(queueID, payload)...))
+	}
+
+	tests := map[string]tcase{
+		"OK": {
+			handle:      s.newReceiptHandle(queueID, "01HQ5RJNXS6TPXK89PQWY4N8JD", 3),
+			wantID:      "01HQ5RJNXS6TPXK89PQWY4N8JD",
+			wantReceive: 3,
+		},
+
+		"MessageID":    {handle: "01HQ5RJNXS6TPXK89PQWY4N8JD", wantErr: errkit.ErrInvalidArgument},
+		"NotBase64":    {handle: "not a handle", wantErr: errkit.ErrInvalidArgument},
+		"Unsigned":     {handle: encode("01HQ5RJNXS6TPXK89PQWY4N8JD:3"), wantErr: errkit.ErrInvalidArgument},
+		"OtherKey":     {handle: other.newReceiptHandle(queueID, "01HQ5RJNXS6TPXK89PQWY4N8JD", 3), wantErr: errkit.ErrInvalidArgument},
+		"OtherQueue":   {handle: s.newReceiptHandle("CSGE6N05SHOB6TB8V5G0", "01HQ5RJNXS6TPXK89PQWY4N8JD", 3), wantErr: errkit.ErrInvalidArgument},
+		"NoReceive":    {handle: sign("01HQ5RJNXS6TPXK89PQWY4N8JD"), wantErr: errkit.ErrInvalidArgument},
+		"ZeroReceive":  {handle: sign("01HQ5RJNXS6TPXK89PQWY4N8JD:0"), wantErr: errkit.ErrInvalidArgument},
+		"EmptyMessage": {handle: sign(":1"), wantErr: errkit.ErrInvalidArgument},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			id, receive, err := s.parseReceiptHandle(queueID, tc.handle)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, id, tc.wantID)
+			td.Cmp(t, receive, tc.wantReceive)
+		})
+	}
+}
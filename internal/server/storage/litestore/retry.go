@@ -0,0 +1,61 @@
+package litestore
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+// validateRetryPolicy returns the errkit.ErrInvalidArgument when the retry policy is invalid.
+func validateRetryPolicy(p *v1.RetryPolicy) error {
+	multiplier := p.GetMultiplier()
+
+	if math.IsNaN(multiplier) || math.IsInf(multiplier, 0) || (multiplier != 0 && multiplier < 1) {
+		return fmt.Errorf("%w: retry multiplier should be zero or at least 1: %v", errkit.ErrInvalidArgument, multiplier)
+	}
+
+	if p.GetMaxBackoffSeconds() > 0 && p.GetMaxBackoffSeconds() < p.GetInitialBackoffSeconds() {
+		return fmt.Errorf("%w: max retry backoff %ds is less than the initial one %ds",
+			errkit.ErrInvalidArgument, p.GetMaxBackoffSeconds(), p.GetInitialBackoffSeconds(),
+		)
+	}
+
+	return nil
+}
+
+// retryPolicy returns the retry policy stored in the queue properties,
+// or nil when the queue has no backoff of redeliveries.
+func retryPolicy(initial uint64, multiplier float64, maxBackoff uint64) *v1.RetryPolicy {
+	if initial == 0 && multiplier == 0 && maxBackoff == 0 {
+		return nil
+	}
+
+	return &v1.RetryPolicy{
+		InitialBackoffSeconds: initial,
+		Multiplier:            multiplier,
+		MaxBackoffSeconds:     maxBackoff,
+	}
+}
+
+// retryBackoff returns how much longer than the visibility timeout the message
+// stays invisible after the receive with the given attempt number, starting at 1.
+func retryBackoff(p *v1.RetryPolicy, attempt uint32) time.Duration {
+	if p.GetInitialBackoffSeconds() == 0 || attempt == 0 {
+		return 0
+	}
+
+	multiplier := max(p.GetMultiplier(), 1)
+
+	backoff := float64(p.GetInitialBackoffSeconds()) * math.Pow(multiplier, float64(attempt-1))
+
+	// The bound also keeps the backoff from overflowing the time.Duration.
+	bound := float64(math.MaxInt64 / int64(time.Second))
+	if p.GetMaxBackoffSeconds() > 0 {
+		bound = min(bound, float64(p.GetMaxBackoffSeconds()))
+	}
+
+	return time.Duration(min(backoff, bound) * float64(time.Second))
+}
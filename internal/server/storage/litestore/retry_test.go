@@ -0,0 +1,98 @@
+package litestore
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+func Test_retryBackoff(t *testing.T) {
+	type tcase struct {
+		policy  *v1.RetryPolicy
+		attempt uint32
+		want    time.Duration
+	}
+
+	tests := map[string]tcase{
+		"NoPolicy": {
+			attempt: 3,
+		},
+		"NoBackoff": {
+			policy:  &v1.RetryPolicy{Multiplier: 2, MaxBackoffSeconds: 60},
+			attempt: 3,
+		},
+		"FirstAttempt": {
+			policy:  &v1.RetryPolicy{InitialBackoffSeconds: 5, Multiplier: 2},
+			attempt: 1,
+			want:    5 * time.Second,
+		},
+		"Grows": {
+			policy:  &v1.RetryPolicy{InitialBackoffSeconds: 5, Multiplier: 2},
+			attempt: 4,
+			want:    40 * time.Second,
+		},
+		"Constant": {
+			policy:  &v1.RetryPolicy{InitialBackoffSeconds: 5},
+			attempt: 4,
+			want:    5 * time.Second,
+		},
+		"Bounded": {
+			policy:  &v1.RetryPolicy{InitialBackoffSeconds: 5, Multiplier: 2, MaxBackoffSeconds: 30},
+			attempt: 4,
+			want:    30 * time.Second,
+		},
+		"Overflow": {
+			policy:  &v1.RetryPolicy{InitialBackoffSeconds: 5, Multiplier: 10},
+			attempt: 100,
+			want:    time.Duration(math.MaxInt64/int64(time.Second)) * time.Second,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, retryBackoff(tc.policy, tc.attempt), tc.want)
+		})
+	}
+}
+
+func Test_validateRetryPolicy(t *testing.T) {
+	type tcase struct {
+		policy  *v1.RetryPolicy
+		wantErr bool
+	}
+
+	tests := map[string]tcase{
+		"NoPolicy": {},
+		"Valid": {
+			policy: &v1.RetryPolicy{InitialBackoffSeconds: 5, Multiplier: 1.5, MaxBackoffSeconds: 60},
+		},
+		"Shrinking": {
+			policy:  &v1.RetryPolicy{InitialBackoffSeconds: 5, Multiplier: 0.5},
+			wantErr: true,
+		},
+		"NaN": {
+			policy:  &v1.RetryPolicy{InitialBackoffSeconds: 5, Multiplier: math.NaN()},
+			wantErr: true,
+		},
+		"BoundBelowInitial": {
+			policy:  &v1.RetryPolicy{InitialBackoffSeconds: 60, MaxBackoffSeconds: 5},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validateRetryPolicy(tc.policy)
+			if tc.wantErr {
+				td.Cmp(t, err, td.ErrorIs(errkit.ErrInvalidArgument))
+				return
+			}
+
+			td.CmpNoError(t, err)
+		})
+	}
+}
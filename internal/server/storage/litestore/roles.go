@@ -0,0 +1,134 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/scim"
+	"github.com/plainq/servekit/errkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Storage) Roles(ctx context.Context) ([]*v1.Role, error) {
+	groups, err := s.SCIMGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]*v1.Role, 0, len(groups))
+
+	for _, group := range groups {
+		roles = append(roles, groupRole(&group))
+	}
+
+	return roles, nil
+}
+
+func (s *Storage) CreateRole(ctx context.Context, name string) (*v1.Role, error) {
+	group, err := s.CreateSCIMGroup(ctx, scim.Group{DisplayName: name})
+	if err != nil {
+		return nil, err
+	}
+
+	return groupRole(group), nil
+}
+
+func (s *Storage) DeleteRole(ctx context.Context, name string) error {
+	var roleID string
+
+	if err := s.db.QueryRowContext(ctx, querySelectRoleID, name).Scan(&roleID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: role %q not found", errkit.ErrNotFound, name)
+		}
+
+		return fmt.Errorf("select role %q: %w", name, err)
+	}
+
+	return s.DeleteSCIMGroup(ctx, roleID)
+}
+
+func (s *Storage) AssignRole(ctx context.Context, email, role string) (sErr error) {
+	tx, txErr := s.beginTx(ctx, opAssignRole, false)
+	if txErr != nil {
+		return fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	var userID string
+
+	if err := tx.QueryRowContext(ctx, querySelectUserID, email).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: user %q not found", errkit.ErrNotFound, email)
+		}
+
+		return fmt.Errorf("select user %q: %w", email, err)
+	}
+
+	var exists bool
+
+	if err := tx.QueryRowContext(ctx, queryRoleExists, role).Scan(&exists); err != nil {
+		return fmt.Errorf("select role %q: %w", role, err)
+	}
+
+	if !exists {
+		return fmt.Errorf("%w: role %q not found", errkit.ErrNotFound, role)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryAssignRole, email, role); err != nil {
+		return fmt.Errorf("assign role %q to user %q: %w", role, email, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	s.grants.invalidate()
+
+	return nil
+}
+
+func (s *Storage) UnassignRole(ctx context.Context, email, role string) error {
+	res, execErr := s.db.ExecContext(ctx, queryUnassignRole, email, role)
+	if execErr != nil {
+		return fmt.Errorf("unassign role %q from user %q: %w", role, email, execErr)
+	}
+
+	deleted, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return fmt.Errorf("unassign role %q from user %q: %w", role, email, rowsErr)
+	}
+
+	if deleted < 1 {
+		return fmt.Errorf("%w: user %q has no role %q", errkit.ErrNotFound, email, role)
+	}
+
+	s.grants.invalidate()
+
+	return nil
+}
+
+// groupRole returns the role of the group, members of which are users it's assigned to.
+// Roles are stored the same way whether they are managed by administrators
+// or provisioned as groups by the identity provider.
+func groupRole(group *scim.Group) *v1.Role {
+	role := v1.Role{
+		RoleId:    group.ID,
+		Name:      group.DisplayName,
+		Users:     make([]string, 0, len(group.Members)),
+		CreatedAt: timestamppb.New(group.CreatedAt),
+	}
+
+	for _, m := range group.Members {
+		role.Users = append(role.Users, m.Display)
+	}
+
+	return &role
+}
@@ -0,0 +1,498 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/scim"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+)
+
+// scimMember is the JSON object of the user or the role
+// in arrays selected along with roles and users.
+type scimMember struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (s *Storage) SCIMUsers(ctx context.Context) (_ []scim.User, sErr error) {
+	rows, queryErr := s.db.QueryContext(ctx, querySelectSCIMUsers)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select users: %w", queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	var users []scim.User
+
+	for rows.Next() {
+		user, scanErr := scanSCIMUser(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		users = append(users, *user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate users: %w", err)
+	}
+
+	return users, nil
+}
+
+func (s *Storage) SCIMUser(ctx context.Context, id string) (*scim.User, error) {
+	user, err := scanSCIMUser(s.db.QueryRowContext(ctx, querySelectSCIMUser, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: user %q", errkit.ErrNotFound, id)
+		}
+
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *Storage) CreateSCIMUser(ctx context.Context, user scim.User) (_ *scim.User, sErr error) {
+	now := time.Now().UTC()
+
+	user.ID = idkit.XID()
+	user.Groups = nil
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	tx, txErr := s.beginTx(ctx, opCreateSCIMUser, false)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	var taken bool
+
+	if err := tx.QueryRowContext(ctx, querySelectUserEmailTaken, user.UserName, user.ID).Scan(&taken); err != nil {
+		return nil, fmt.Errorf("check user %q: %w", user.UserName, err)
+	}
+
+	if taken {
+		return nil, fmt.Errorf("%w: user %q", errkit.ErrAlreadyExists, user.UserName)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryInsertSCIMUser,
+		user.ID,
+		user.UserName,
+		user.ExternalID,
+		user.Active,
+		now,
+		now,
+	); err != nil {
+		return nil, fmt.Errorf("insert user %q: %w", user.UserName, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	s.grants.invalidate()
+
+	return &user, nil
+}
+
+func (s *Storage) UpdateSCIMUser(ctx context.Context, user scim.User) (_ *scim.User, sErr error) {
+	tx, txErr := s.beginTx(ctx, opUpdateSCIMUser, false)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	var taken bool
+
+	if err := tx.QueryRowContext(ctx, querySelectUserEmailTaken, user.UserName, user.ID).Scan(&taken); err != nil {
+		return nil, fmt.Errorf("check user %q: %w", user.UserName, err)
+	}
+
+	if taken {
+		return nil, fmt.Errorf("%w: user %q", errkit.ErrAlreadyExists, user.UserName)
+	}
+
+	res, execErr := tx.ExecContext(ctx, queryUpdateSCIMUser,
+		user.UserName,
+		user.ExternalID,
+		user.Active,
+		time.Now().UTC(),
+		user.ID,
+	)
+	if execErr != nil {
+		return nil, fmt.Errorf("update user %q: %w", user.ID, execErr)
+	}
+
+	rows, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return nil, fmt.Errorf("update user %q: %w", user.ID, rowsErr)
+	}
+
+	if rows == 0 {
+		return nil, fmt.Errorf("%w: user %q", errkit.ErrNotFound, user.ID)
+	}
+
+	updated, selectErr := scanSCIMUser(tx.QueryRowContext(ctx, querySelectSCIMUser, user.ID))
+	if selectErr != nil {
+		return nil, selectErr
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	s.grants.invalidate()
+
+	return updated, nil
+}
+
+func (s *Storage) DeleteSCIMUser(ctx context.Context, id string) (sErr error) {
+	tx, txErr := s.beginTx(ctx, opDeleteSCIMUser, false)
+	if txErr != nil {
+		return fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, queryDeleteUserRoles, id); err != nil {
+		return fmt.Errorf("delete roles of user %q: %w", id, err)
+	}
+
+	res, execErr := tx.ExecContext(ctx, queryDeleteUser, id)
+	if execErr != nil {
+		return fmt.Errorf("delete user %q: %w", id, execErr)
+	}
+
+	rows, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return fmt.Errorf("delete user %q: %w", id, rowsErr)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%w: user %q", errkit.ErrNotFound, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	s.grants.invalidate()
+
+	return nil
+}
+
+func (s *Storage) SCIMGroups(ctx context.Context) (_ []scim.Group, sErr error) {
+	rows, queryErr := s.db.QueryContext(ctx, querySelectSCIMGroups)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select roles: %w", queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	var groups []scim.Group
+
+	for rows.Next() {
+		group, scanErr := scanSCIMGroup(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		groups = append(groups, *group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate roles: %w", err)
+	}
+
+	return groups, nil
+}
+
+func (s *Storage) SCIMGroup(ctx context.Context, id string) (*scim.Group, error) {
+	group, err := scanSCIMGroup(s.db.QueryRowContext(ctx, querySelectSCIMGroup, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: role %q", errkit.ErrNotFound, id)
+		}
+
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (s *Storage) CreateSCIMGroup(ctx context.Context, group scim.Group) (_ *scim.Group, sErr error) {
+	group.ID = idkit.XID()
+
+	tx, txErr := s.beginTx(ctx, opCreateSCIMGroup, false)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	if err := checkRoleName(ctx, tx, group); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, queryInsertRole, group.ID, group.DisplayName, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("insert role %q: %w", group.DisplayName, err)
+	}
+
+	stored, commitErr := commitSCIMGroup(ctx, tx, group)
+	if commitErr != nil {
+		return nil, commitErr
+	}
+
+	s.grants.invalidate()
+
+	return stored, nil
+}
+
+func (s *Storage) UpdateSCIMGroup(ctx context.Context, group scim.Group) (_ *scim.Group, sErr error) {
+	tx, txErr := s.beginTx(ctx, opUpdateSCIMGroup, false)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	if err := checkRoleName(ctx, tx, group); err != nil {
+		return nil, err
+	}
+
+	res, execErr := tx.ExecContext(ctx, queryUpdateRoleName, group.DisplayName, group.ID)
+	if execErr != nil {
+		return nil, fmt.Errorf("rename role %q: %w", group.ID, execErr)
+	}
+
+	rows, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return nil, fmt.Errorf("rename role %q: %w", group.ID, rowsErr)
+	}
+
+	if rows == 0 {
+		return nil, fmt.Errorf("%w: role %q", errkit.ErrNotFound, group.ID)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeleteRoleUsers, group.ID); err != nil {
+		return nil, fmt.Errorf("revoke role %q: %w", group.ID, err)
+	}
+
+	stored, commitErr := commitSCIMGroup(ctx, tx, group)
+	if commitErr != nil {
+		return nil, commitErr
+	}
+
+	s.grants.invalidate()
+
+	return stored, nil
+}
+
+func (s *Storage) DeleteSCIMGroup(ctx context.Context, id string) (sErr error) {
+	tx, txErr := s.beginTx(ctx, opDeleteSCIMGroup, false)
+	if txErr != nil {
+		return fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	for _, q := range []string{
+		queryDeleteRoleUsers,
+		queryDeleteRoleServiceAccounts,
+		queryDeleteRolePermissions,
+		queryDeleteRolePolicies,
+	} {
+		if _, err := tx.ExecContext(ctx, q, id); err != nil {
+			return fmt.Errorf("revoke role %q: %w", id, err)
+		}
+	}
+
+	res, execErr := tx.ExecContext(ctx, queryDeleteRole, id)
+	if execErr != nil {
+		return fmt.Errorf("delete role %q: %w", id, execErr)
+	}
+
+	rows, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return fmt.Errorf("delete role %q: %w", id, rowsErr)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%w: role %q", errkit.ErrNotFound, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	s.grants.invalidate()
+
+	return nil
+}
+
+// checkRoleName rejects names of the group taken by other roles.
+func checkRoleName(ctx context.Context, tx *observedTx, group scim.Group) error {
+	var taken bool
+
+	if err := tx.QueryRowContext(ctx, querySelectRoleNameTaken, group.DisplayName, group.ID).Scan(&taken); err != nil {
+		return fmt.Errorf("check role %q: %w", group.DisplayName, err)
+	}
+
+	if taken {
+		return fmt.Errorf("%w: role %q", errkit.ErrAlreadyExists, group.DisplayName)
+	}
+
+	return nil
+}
+
+// commitSCIMGroup grants the role to members of the group, commits
+// the transaction and returns the group as it's stored.
+func commitSCIMGroup(ctx context.Context, tx *observedTx, group scim.Group) (*scim.Group, error) {
+	granted := make(map[string]struct{}, len(group.Members))
+
+	for _, m := range group.Members {
+		if _, ok := granted[m.ID]; ok {
+			continue
+		}
+
+		res, execErr := tx.ExecContext(ctx, queryInsertRoleUser, group.ID, m.ID)
+		if execErr != nil {
+			return nil, fmt.Errorf("grant role %q to user %q: %w", group.DisplayName, m.ID, execErr)
+		}
+
+		rows, rowsErr := res.RowsAffected()
+		if rowsErr != nil {
+			return nil, fmt.Errorf("grant role %q to user %q: %w", group.DisplayName, m.ID, rowsErr)
+		}
+
+		if rows == 0 {
+			return nil, fmt.Errorf("%w: unknown user %q", errkit.ErrInvalidArgument, m.ID)
+		}
+
+		granted[m.ID] = struct{}{}
+	}
+
+	stored, selectErr := scanSCIMGroup(tx.QueryRowContext(ctx, querySelectSCIMGroup, group.ID))
+	if selectErr != nil {
+		return nil, selectErr
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	return stored, nil
+}
+
+// scanSCIMUser scans the user selected by scimUsers.
+func scanSCIMUser(row interface{ Scan(dest ...any) error }) (*scim.User, error) {
+	var (
+		user  scim.User
+		roles string
+	)
+
+	if err := row.Scan(
+		&user.ID,
+		&user.UserName,
+		&user.ExternalID,
+		&user.Active,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&roles,
+	); err != nil {
+		return nil, fmt.Errorf("scan user: %w", err)
+	}
+
+	groups, err := unmarshalSCIMMembers(roles)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal roles of user %q: %w", user.ID, err)
+	}
+
+	user.Groups = groups
+
+	return &user, nil
+}
+
+// scanSCIMGroup scans the role selected by scimGroups.
+func scanSCIMGroup(row interface{ Scan(dest ...any) error }) (*scim.Group, error) {
+	var (
+		group scim.Group
+		users string
+	)
+
+	if err := row.Scan(
+		&group.ID,
+		&group.DisplayName,
+		&group.CreatedAt,
+		&users,
+	); err != nil {
+		return nil, fmt.Errorf("scan role: %w", err)
+	}
+
+	members, err := unmarshalSCIMMembers(users)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal users of role %q: %w", group.ID, err)
+	}
+
+	group.Members = members
+
+	return &group, nil
+}
+
+// unmarshalSCIMMembers unmarshals the JSON array of users or roles.
+func unmarshalSCIMMembers(data string) ([]scim.Member, error) {
+	var raw []scimMember
+
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, err
+	}
+
+	members := make([]scim.Member, 0, len(raw))
+	for _, m := range raw {
+		members = append(members, scim.Member{ID: m.ID, Display: m.Name})
+	}
+
+	return members, nil
+}
@@ -0,0 +1,180 @@
+package litestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+const (
+	// defaultSearchLimit represents the default number of messages returned by the search.
+	defaultSearchLimit = 20
+
+	// maxSearchLimit represents the maximum number of messages returned by the search.
+	maxSearchLimit = 100
+)
+
+func (s *Storage) SearchMessages(ctx context.Context, input *v1.SearchMessagesRequest) (_ *v1.SearchMessagesResponse, sErr error) {
+	queueID := input.GetQueueId()
+
+	filter, filterErr := searchFilterFromRequest(input)
+	if filterErr != nil {
+		return nil, filterErr
+	}
+
+	props, ok := s.cache.getByID(queueID)
+	if !ok {
+		info, describeErr := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID})
+		if describeErr != nil {
+			return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, describeErr)
+		}
+
+		props = propsFromProto(info)
+	}
+
+	// Filters other than the full-text query don't need the search index.
+	if input.GetQuery() != "" && !props.SearchIndex {
+		return nil, fmt.Errorf("%w: queue %q has no search index", errkit.ErrInvalidArgument, queueID)
+	}
+
+	limit := input.GetLimit()
+
+	switch {
+	case limit == 0:
+		limit = defaultSearchLimit
+
+	case limit > maxSearchLimit:
+		return nil, fmt.Errorf("%w: search limit should not exceed %d", errkit.ErrInvalidArgument, maxSearchLimit)
+	}
+
+	// The extra message tells whether the result is truncated.
+	args := append(filter.args, limit+1)
+
+	rows, queryErr := s.db.QueryContext(ctx, querySearchMessages(queueID, input.GetQuery() != "", filter.where), args...)
+	if queryErr != nil {
+		return nil, searchError(queueID, queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	output := v1.SearchMessagesResponse{
+		Messages: make([]*v1.ReceiveMessage, 0, limit),
+	}
+
+	for rows.Next() {
+		if len(output.Messages) == int(limit) {
+			output.Truncated = true
+			break
+		}
+
+		var m v1.ReceiveMessage
+
+		if err := rows.Scan(&m.Id, &m.Body, &m.Attempts); err != nil {
+			return nil, fmt.Errorf("scan message record: %w", err)
+		}
+
+		output.Messages = append(output.Messages, &m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, searchError(queueID, err)
+	}
+
+	return &output, nil
+}
+
+// searchFilter holds conditions of the search on columns of the queue table
+// aliased as "m", along with their arguments in order.
+type searchFilter struct {
+	where []string
+	args  []any
+}
+
+// searchFilterFromRequest returns conditions of the search request. The full-text query
+// is matched by the search index, the time range is served by the index of send times,
+// while other conditions are checked for each message in the range.
+func searchFilterFromRequest(input *v1.SearchMessagesRequest) (*searchFilter, error) {
+	var f searchFilter
+
+	if input.GetQuery() != "" {
+		f.where = append(f.where, searchIndexTable(input.GetQueueId())+` match ?`)
+		f.args = append(f.args, input.GetQuery())
+	}
+
+	if input.GetContains() != "" {
+		// Bodies are blobs, so the substring is matched as bytes.
+		f.where = append(f.where, `instr(m.msg_body, ?) > 0`)
+		f.args = append(f.args, []byte(input.GetContains()))
+	}
+
+	switch {
+	case input.GetJsonPath() != "" && input.GetJsonValue() != "":
+		f.where = append(f.where, `case when json_valid(cast(m.msg_body as text))
+			then cast(json_extract(cast(m.msg_body as text), ?) as text) end = ?`)
+		f.args = append(f.args, input.GetJsonPath(), input.GetJsonValue())
+
+	case input.GetJsonPath() != "":
+		f.where = append(f.where, `case when json_valid(cast(m.msg_body as text))
+			then json_type(cast(m.msg_body as text), ?) end is not null`)
+		f.args = append(f.args, input.GetJsonPath())
+
+	case input.GetJsonValue() != "":
+		return nil, fmt.Errorf("%w: json value requires the json path", errkit.ErrInvalidArgument)
+	}
+
+	from, to := input.GetFrom(), input.GetTo()
+
+	if from != nil && to != nil && !from.AsTime().Before(to.AsTime()) {
+		return nil, fmt.Errorf("%w: search time range is empty", errkit.ErrInvalidArgument)
+	}
+
+	if from != nil {
+		f.where = append(f.where, `m.created_at >= ?`)
+		f.args = append(f.args, from.AsTime().UTC().Format(time.DateTime))
+	}
+
+	if to != nil {
+		f.where = append(f.where, `m.created_at < ?`)
+		f.args = append(f.args, to.AsTime().UTC().Format(time.DateTime))
+	}
+
+	if input.GetMinAttempts() > 0 {
+		f.where = append(f.where, `m.retries >= ?`)
+		f.args = append(f.args, input.GetMinAttempts())
+	}
+
+	if input.MaxAttempts != nil {
+		if input.GetMaxAttempts() < input.GetMinAttempts() {
+			return nil, fmt.Errorf("%w: max attempts should not be less than min attempts", errkit.ErrInvalidArgument)
+		}
+
+		f.where = append(f.where, `m.retries <= ?`)
+		f.args = append(f.args, input.GetMaxAttempts())
+	}
+
+	if len(f.where) == 0 {
+		return nil, fmt.Errorf("%w: search query or filter is empty", errkit.ErrInvalidArgument)
+	}
+
+	return &f, nil
+}
+
+// searchError wraps the search error. Malformed queries, e.g. with unbalanced
+// quotes, are reported by SQLite as generic errors, while transient failures
+// have their own codes, e.g. sqlite3.ErrBusy.
+func searchError(queueID string, err error) error {
+	if sqliteErr := (sqlite3.Error{}); errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrError {
+		return fmt.Errorf("%w: search queue %q messages: %w", errkit.ErrInvalidArgument, queueID, err)
+	}
+
+	return fmt.Errorf("search queue %q messages: %w", queueID, err)
+}
@@ -0,0 +1,86 @@
+//go:build sqlite_fts5
+
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/servekit/dbkit/litekit"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+	"github.com/plainq/servekit/logkit"
+)
+
+func TestStorage_SearchMessages_fullText(t *testing.T) {
+	ctx := context.Background()
+
+	db, openErr := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "plainq.db")+"?_journal=WAL")
+	td.Require(t).CmpNoError(openErr)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	s := Storage{
+		db:       &litekit.Conn{DB: db},
+		logger:   logkit.NewNop(),
+		observer: telemetry.NewObserver(),
+		cache:    NewQueuePropsCache(0, 0, telemetry.NewObserver()),
+	}
+
+	td.Require(t).CmpNoError(s.db.QueryRowContext(ctx, queryFullTextSearchSupported).Scan(&s.fullTextSearch))
+	td.Require(t).True(s.fullTextSearch, "the sqlite_fts5 tag should compile the FTS5 module in")
+
+	queueID := idkit.XID()
+	s.cache.put(QueueProps{ID: queueID, Name: "orders", SearchIndex: true})
+
+	_, createErr := db.Exec(queryCreateQueueTable(queueID))
+	td.Require(t).CmpNoError(createErr)
+
+	for _, m := range queueMutations {
+		_, err := db.Exec(m.query(queueID))
+		td.Require(t).CmpNoError(err, m.name)
+	}
+
+	_, indexErr := db.Exec(queryCreateSearchIndex(queueID, 16))
+	td.Require(t).CmpNoError(indexErr)
+
+	messages := map[string]string{
+		"m1": "invoice paid",
+		"m2": "order shipped",
+		"m3": "the order is too long to have its invoice indexed",
+	}
+
+	for id, body := range messages {
+		_, err := db.Exec(`insert into `+queueID+` (msg_id, msg_body) values (?, ?);`, id, []byte(body))
+		td.Require(t).CmpNoError(err)
+	}
+
+	t.Run("Match", func(t *testing.T) {
+		output, err := s.SearchMessages(ctx, &v1.SearchMessagesRequest{QueueId: queueID, Query: "invoice"})
+		td.Require(t).CmpNoError(err)
+
+		// Words past the indexed prefix of the body aren't matched.
+		td.Cmp(t, output.GetMessages(), td.Bag(td.Struct(&v1.ReceiveMessage{Id: "m1"}, td.StructFields{"=*": td.Ignore()})))
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		_, deleteErr := db.Exec(`delete from ` + queueID + ` where msg_id = 'm2';`)
+		td.Require(t).CmpNoError(deleteErr)
+
+		output, err := s.SearchMessages(ctx, &v1.SearchMessagesRequest{QueueId: queueID, Query: "order"})
+		td.Require(t).CmpNoError(err)
+
+		td.Cmp(t, output.GetMessages(), td.Bag(td.Struct(&v1.ReceiveMessage{Id: "m3"}, td.StructFields{"=*": td.Ignore()})))
+	})
+
+	t.Run("InvalidQuery", func(t *testing.T) {
+		_, err := s.SearchMessages(ctx, &v1.SearchMessagesRequest{QueueId: queueID, Query: `"invoice`})
+		td.CmpErrorIs(t, err, errkit.ErrInvalidArgument)
+	})
+}
@@ -0,0 +1,70 @@
+package litestore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_searchError(t *testing.T) {
+	tests := map[string]struct {
+		err     error
+		invalid bool
+	}{
+		"MalformedQuery": {err: sqlite3.Error{Code: sqlite3.ErrError}, invalid: true},
+		"Busy":           {err: sqlite3.Error{Code: sqlite3.ErrBusy}, invalid: false},
+		"Other":          {err: errors.New("connection closed"), invalid: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := searchError("queue", tc.err)
+			td.CmpErrorIs(t, err, tc.err)
+			td.Cmp(t, errors.Is(err, errkit.ErrInvalidArgument), tc.invalid)
+		})
+	}
+}
+
+func Test_searchFilterFromRequest(t *testing.T) {
+	type tcase struct {
+		input     *v1.SearchMessagesRequest
+		wantWhere int
+		wantErr   error
+	}
+
+	now := time.Now()
+
+	tests := map[string]tcase{
+		"Query":          {input: &v1.SearchMessagesRequest{Query: "order"}, wantWhere: 1},
+		"Contains":       {input: &v1.SearchMessagesRequest{Contains: "order"}, wantWhere: 1},
+		"JSONPath":       {input: &v1.SearchMessagesRequest{JsonPath: "$.id"}, wantWhere: 1},
+		"JSONValue":      {input: &v1.SearchMessagesRequest{JsonPath: "$.id", JsonValue: "42"}, wantWhere: 1},
+		"NeverReceived":  {input: &v1.SearchMessagesRequest{MaxAttempts: proto.Uint32(0)}, wantWhere: 1},
+		"Combined":       {input: &v1.SearchMessagesRequest{Contains: "order", From: timestamppb.New(now.Add(-time.Hour)), To: timestamppb.New(now), MinAttempts: 1}, wantWhere: 4},
+		"Empty":          {input: &v1.SearchMessagesRequest{}, wantErr: errkit.ErrInvalidArgument},
+		"ValueNoPath":    {input: &v1.SearchMessagesRequest{JsonValue: "42"}, wantErr: errkit.ErrInvalidArgument},
+		"EmptyTimeRange": {input: &v1.SearchMessagesRequest{From: timestamppb.New(now), To: timestamppb.New(now)}, wantErr: errkit.ErrInvalidArgument},
+		"AttemptsRange":  {input: &v1.SearchMessagesRequest{MinAttempts: 2, MaxAttempts: proto.Uint32(1)}, wantErr: errkit.ErrInvalidArgument},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			filter, err := searchFilterFromRequest(tc.input)
+			if tc.wantErr != nil {
+				td.CmpErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, filter.where, td.Len(tc.wantWhere))
+			td.Cmp(t, filter.args, td.Len(td.Gte(tc.wantWhere)))
+		})
+	}
+}
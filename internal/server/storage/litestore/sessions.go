@@ -0,0 +1,104 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/auth"
+	"github.com/plainq/servekit/errkit"
+)
+
+func (s *Storage) CreateSession(ctx context.Context, session auth.Session) error {
+	if _, err := s.db.ExecContext(ctx, queryInsertSession,
+		session.ID,
+		session.Subject,
+		session.TokenHash,
+		session.Device.UserAgent,
+		session.Device.RemoteAddr,
+		session.CreatedAt.UTC(),
+		session.ExpiresAt.UTC(),
+	); err != nil {
+		return fmt.Errorf("insert session: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) Sessions(ctx context.Context, subject string, now time.Time) (_ []auth.Session, sErr error) {
+	rows, queryErr := s.db.QueryContext(ctx, querySelectSessions, subject, now.UTC())
+	if queryErr != nil {
+		return nil, fmt.Errorf("select sessions: %w", queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	var sessions []auth.Session
+
+	for rows.Next() {
+		session, scanErr := scanSession(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		sessions = append(sessions, *session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (s *Storage) RevokeSession(ctx context.Context, subject, id string, now time.Time) (*auth.Session, error) {
+	session, err := scanSession(s.db.QueryRowContext(ctx, queryRevokeSession, subject, id, now.UTC()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: session %q", errkit.ErrNotFound, id)
+		}
+
+		return nil, fmt.Errorf("revoke session %q: %w", id, err)
+	}
+
+	return session, nil
+}
+
+func (s *Storage) RevokeSessions(ctx context.Context, subject string, now time.Time) (int64, error) {
+	res, execErr := s.db.ExecContext(ctx, queryRevokeSessions, subject, now.UTC())
+	if execErr != nil {
+		return 0, fmt.Errorf("revoke sessions: %w", execErr)
+	}
+
+	revoked, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return 0, fmt.Errorf("revoke sessions: %w", rowsErr)
+	}
+
+	return revoked, nil
+}
+
+// scanSession scans the session from the row of sessions columns.
+func scanSession(row interface{ Scan(dest ...any) error }) (*auth.Session, error) {
+	var session auth.Session
+
+	if err := row.Scan(
+		&session.ID,
+		&session.Subject,
+		&session.TokenHash,
+		&session.Device.UserAgent,
+		&session.Device.RemoteAddr,
+		&session.CreatedAt,
+		&session.ExpiresAt,
+	); err != nil {
+		return nil, fmt.Errorf("scan session: %w", err)
+	}
+
+	return &session, nil
+}
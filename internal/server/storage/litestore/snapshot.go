@@ -0,0 +1,33 @@
+package litestore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Snapshot writes a consistent copy of the database to a new file at the path.
+// The snapshot waits for the running garbage collection to finish sweeping the
+// current queue, so it never captures a partially swept queue, and the garbage
+// collection waits for the snapshot to complete.
+func (s *Storage) Snapshot(ctx context.Context, path string) error {
+	if err := s.maintenance.acquire(ctx, true); err != nil {
+		return fmt.Errorf("wait for maintenance: %w", err)
+	}
+
+	defer s.maintenance.release()
+
+	start := time.Now()
+
+	if _, err := s.db.ExecContext(ctx, queryVacuumInto, path); err != nil {
+		return fmt.Errorf("write snapshot to %q: %w", path, err)
+	}
+
+	s.logger.Info("Snapshot created",
+		slog.String("path", path),
+		slog.String("duration", time.Since(start).String()),
+	)
+
+	return nil
+}
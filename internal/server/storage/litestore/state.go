@@ -0,0 +1,173 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+	"github.com/plainq/servekit/errkit"
+)
+
+// queueTransitions holds the states each queue state can be moved to.
+// The deleting state is terminal, the queue is gone once its deletion completes.
+var queueTransitions = map[v1.QueueState][]v1.QueueState{
+	v1.QueueState_QUEUE_STATE_CREATING: {
+		v1.QueueState_QUEUE_STATE_ACTIVE,
+		v1.QueueState_QUEUE_STATE_DELETING,
+	},
+	v1.QueueState_QUEUE_STATE_ACTIVE: {
+		v1.QueueState_QUEUE_STATE_PAUSED,
+		v1.QueueState_QUEUE_STATE_DRAINING,
+		v1.QueueState_QUEUE_STATE_ARCHIVED,
+		v1.QueueState_QUEUE_STATE_DELETING,
+	},
+	v1.QueueState_QUEUE_STATE_PAUSED: {
+		v1.QueueState_QUEUE_STATE_ACTIVE,
+		v1.QueueState_QUEUE_STATE_DRAINING,
+		v1.QueueState_QUEUE_STATE_ARCHIVED,
+		v1.QueueState_QUEUE_STATE_DELETING,
+	},
+	v1.QueueState_QUEUE_STATE_DRAINING: {
+		v1.QueueState_QUEUE_STATE_ACTIVE,
+		v1.QueueState_QUEUE_STATE_PAUSED,
+		v1.QueueState_QUEUE_STATE_ARCHIVED,
+		v1.QueueState_QUEUE_STATE_DELETING,
+	},
+	v1.QueueState_QUEUE_STATE_ARCHIVED: {
+		v1.QueueState_QUEUE_STATE_ACTIVE,
+		v1.QueueState_QUEUE_STATE_DELETING,
+	},
+}
+
+// canTransition reports whether the queue can be moved from one state to another.
+func canTransition(from, to v1.QueueState) bool {
+	return slices.Contains(queueTransitions[from], to)
+}
+
+// queueOperation represents an operation on messages of the queue gated by the queue state.
+type queueOperation string
+
+const (
+	operationSend    queueOperation = "send"
+	operationReceive queueOperation = "receive"
+)
+
+// checkQueueState returns errkit.ErrUnavailable if the queue
+// in the given state doesn't accept the operation.
+func checkQueueState(queueID string, state v1.QueueState, op queueOperation) error {
+	var allowed bool
+
+	switch op {
+	case operationSend:
+		allowed = state == v1.QueueState_QUEUE_STATE_ACTIVE || state == v1.QueueState_QUEUE_STATE_PAUSED
+
+	case operationReceive:
+		allowed = state == v1.QueueState_QUEUE_STATE_ACTIVE || state == v1.QueueState_QUEUE_STATE_DRAINING
+	}
+
+	if !allowed {
+		return fmt.Errorf("%w: queue %q doesn't accept %s in the %s state",
+			errkit.ErrUnavailable, queueID, op, stateName(state),
+		)
+	}
+
+	return nil
+}
+
+// stateName returns the short human-readable name of the state.
+func stateName(state v1.QueueState) string {
+	switch state {
+	case v1.QueueState_QUEUE_STATE_CREATING:
+		return "creating"
+	case v1.QueueState_QUEUE_STATE_ACTIVE:
+		return "active"
+	case v1.QueueState_QUEUE_STATE_PAUSED:
+		return "paused"
+	case v1.QueueState_QUEUE_STATE_DRAINING:
+		return "draining"
+	case v1.QueueState_QUEUE_STATE_DELETING:
+		return "deleting"
+	case v1.QueueState_QUEUE_STATE_ARCHIVED:
+		return "archived"
+	default:
+		return "unspecified"
+	}
+}
+
+func (s *Storage) SetQueueState(ctx context.Context, input *v1.SetQueueStateRequest) (_ *v1.SetQueueStateResponse, sErr error) {
+	queueID := input.GetQueueId()
+	state := input.GetState()
+
+	if _, ok := queueTransitions[state]; !ok && state != v1.QueueState_QUEUE_STATE_DELETING {
+		return nil, fmt.Errorf("%w: invalid queue state: %s", errkit.ErrInvalidArgument, state)
+	}
+
+	current, describeErr := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID})
+	if describeErr != nil {
+		return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, describeErr)
+	}
+
+	// Moving the queue to the state it's already in is a no-op.
+	if current.GetState() == state {
+		return &v1.SetQueueStateResponse{State: state, Version: current.GetVersion()}, nil
+	}
+
+	if !canTransition(current.GetState(), state) {
+		return nil, fmt.Errorf("%w: queue %q can't be moved from the %s to the %s state",
+			pqerr.ErrConflict, queueID, stateName(current.GetState()), stateName(state),
+		)
+	}
+
+	tx, txErr := s.beginTx(ctx, opSetQueueState, false)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	res, execErr := tx.ExecContext(ctx, queryUpdateQueueState, state, queueID, current.GetState())
+	if execErr != nil {
+		return nil, fmt.Errorf("update queue %q state: %w", queueID, execErr)
+	}
+
+	rows, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return nil, fmt.Errorf("update queue %q state: %w", queueID, rowsErr)
+	}
+
+	// The state has been changed by another
+	// transition after the properties were read.
+	if rows < 1 {
+		return nil, fmt.Errorf("%w: queue %q state has been changed concurrently", pqerr.ErrConflict, queueID)
+	}
+
+	var version uint64
+	if err := tx.QueryRowContext(ctx, querySelectQueuePropVersion, queueID).Scan(&version); err != nil {
+		return nil, fmt.Errorf("select queue %q properties version: %w", queueID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	props := propsFromProto(current)
+	props.State = uint32(state)
+	props.Version = version
+
+	s.cache.put(props)
+
+	output := v1.SetQueueStateResponse{
+		State:   state,
+		Version: version,
+	}
+
+	return &output, nil
+}
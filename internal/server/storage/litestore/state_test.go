@@ -0,0 +1,65 @@
+package litestore
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+)
+
+func Test_canTransition(t *testing.T) {
+	tests := map[string]struct {
+		from v1.QueueState
+		to   v1.QueueState
+		want bool
+	}{
+		"CreatingToActive":   {from: v1.QueueState_QUEUE_STATE_CREATING, to: v1.QueueState_QUEUE_STATE_ACTIVE, want: true},
+		"CreatingToPaused":   {from: v1.QueueState_QUEUE_STATE_CREATING, to: v1.QueueState_QUEUE_STATE_PAUSED, want: false},
+		"ActiveToPaused":     {from: v1.QueueState_QUEUE_STATE_ACTIVE, to: v1.QueueState_QUEUE_STATE_PAUSED, want: true},
+		"ActiveToCreating":   {from: v1.QueueState_QUEUE_STATE_ACTIVE, to: v1.QueueState_QUEUE_STATE_CREATING, want: false},
+		"PausedToDraining":   {from: v1.QueueState_QUEUE_STATE_PAUSED, to: v1.QueueState_QUEUE_STATE_DRAINING, want: true},
+		"DrainingToActive":   {from: v1.QueueState_QUEUE_STATE_DRAINING, to: v1.QueueState_QUEUE_STATE_ACTIVE, want: true},
+		"ArchivedToPaused":   {from: v1.QueueState_QUEUE_STATE_ARCHIVED, to: v1.QueueState_QUEUE_STATE_PAUSED, want: false},
+		"ArchivedToDeleting": {from: v1.QueueState_QUEUE_STATE_ARCHIVED, to: v1.QueueState_QUEUE_STATE_DELETING, want: true},
+		"DeletingToActive":   {from: v1.QueueState_QUEUE_STATE_DELETING, to: v1.QueueState_QUEUE_STATE_ACTIVE, want: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, canTransition(tc.from, tc.to), tc.want)
+		})
+	}
+}
+
+func Test_checkQueueState(t *testing.T) {
+	tests := map[string]struct {
+		state   v1.QueueState
+		op      queueOperation
+		allowed bool
+	}{
+		"ActiveSend":      {state: v1.QueueState_QUEUE_STATE_ACTIVE, op: operationSend, allowed: true},
+		"ActiveReceive":   {state: v1.QueueState_QUEUE_STATE_ACTIVE, op: operationReceive, allowed: true},
+		"PausedSend":      {state: v1.QueueState_QUEUE_STATE_PAUSED, op: operationSend, allowed: true},
+		"PausedReceive":   {state: v1.QueueState_QUEUE_STATE_PAUSED, op: operationReceive, allowed: false},
+		"DrainingSend":    {state: v1.QueueState_QUEUE_STATE_DRAINING, op: operationSend, allowed: false},
+		"DrainingReceive": {state: v1.QueueState_QUEUE_STATE_DRAINING, op: operationReceive, allowed: true},
+		"ArchivedSend":    {state: v1.QueueState_QUEUE_STATE_ARCHIVED, op: operationSend, allowed: false},
+		"ArchivedReceive": {state: v1.QueueState_QUEUE_STATE_ARCHIVED, op: operationReceive, allowed: false},
+		"DeletingReceive": {state: v1.QueueState_QUEUE_STATE_DELETING, op: operationReceive, allowed: false},
+		"CreatingSend":    {state: v1.QueueState_QUEUE_STATE_CREATING, op: operationSend, allowed: false},
+		"UnspecifiedSend": {state: v1.QueueState_QUEUE_STATE_UNSPECIFIED, op: operationSend, allowed: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := checkQueueState("queue", tc.state, tc.op)
+			if tc.allowed {
+				td.CmpNoError(t, err)
+				return
+			}
+
+			td.CmpErrorIs(t, err, errkit.ErrUnavailable)
+		})
+	}
+}
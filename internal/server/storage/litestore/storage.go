@@ -3,13 +3,18 @@ package litestore
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"math"
+	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/heartwilltell/hc"
+	"github.com/oklog/ulid/v2"
 	v1 "github.com/plainq/plainq/internal/server/schema/v1"
 	"github.com/plainq/plainq/internal/server/telemetry"
 	"github.com/plainq/plainq/internal/shared/pqerr"
@@ -17,6 +22,8 @@ import (
 	"github.com/plainq/servekit/errkit"
 	"github.com/plainq/servekit/idkit"
 	"github.com/plainq/servekit/logkit"
+	"github.com/plainq/servekit/tern"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -41,10 +48,26 @@ const (
 	// queuePropsCacheSize represents the size of the queue properties cache.
 	queuePropsCacheSize = 1000
 
+	// queuePropsCacheTTL represents the default duration queue properties are cached.
+	queuePropsCacheTTL = 5 * time.Minute
+
 	// queuePropsCacheFillingTimeout represents the default timeout duration
 	// for filling the queue properties cache.
 	queuePropsCacheFillingTimeout = 30 * time.Second
 
+	// grantsCacheSize represents the maximum number of cached grants of subjects on queues.
+	grantsCacheSize = 10000
+
+	// grantsCacheTTL represents the default duration grants of subjects on queues are cached.
+	grantsCacheTTL = 30 * time.Second
+
+	// maxBatchSize represents the maximum amount of messages returned by a single receive.
+	maxBatchSize = 10
+
+	// searchMaxIndexedBytes represents the default number of leading
+	// bytes of the message body which are indexed for the search.
+	searchMaxIndexedBytes = 4096
+
 	// defaultPageSize represents the default page size used for listing queues.
 	defaultPageSize uint32 = 10
 )
@@ -54,7 +77,12 @@ type Option func(o *Storage)
 
 // WithGCTimeout sets the timeout for garbage collection.
 func WithGCTimeout(to time.Duration) Option {
-	return func(s *Storage) { s.gcTimeout = to }
+	return func(s *Storage) { s.gcTimeout.Store(int64(to)) }
+}
+
+// WithObserver sets the Observer which measures storage events.
+func WithObserver(observer telemetry.Observer) Option {
+	return func(o *Storage) { o.observer = observer }
 }
 
 // WithLogger sets the Storage logger.
@@ -62,27 +90,157 @@ func WithLogger(logger *slog.Logger) Option {
 	return func(o *Storage) { o.logger = logger }
 }
 
+// WithSearchMaxIndexedBytes sets the number of leading bytes
+// of the message body which are indexed for the search.
+func WithSearchMaxIndexedBytes(n uint) Option {
+	return func(o *Storage) { o.searchMaxIndexedBytes = n }
+}
+
+// WithMaxMessageSize sets the maximum size of the message body in bytes
+// for all queues, queues may have lower own limits. Zero means no limit.
+func WithMaxMessageSize(n uint64) Option {
+	return func(o *Storage) { o.maxMessageSize.Store(n) }
+}
+
+// WithQueueEvolution sets the number of queue tables evolved in a batch
+// and the pause between batches, which lets regular operations use the database.
+func WithQueueEvolution(batchSize uint, pause time.Duration) Option {
+	return func(o *Storage) {
+		o.evolutionBatchSize = batchSize
+		o.evolutionPause = pause
+	}
+}
+
+// WithDepthInterval sets the interval between reconciliations of the gauges
+// of visible and in-flight messages with the counted numbers of messages.
+func WithDepthInterval(interval time.Duration) Option {
+	return func(o *Storage) { o.depthInterval = interval }
+}
+
+// WithGrantsCacheTTL sets how long grants of subjects on queues are cached.
+// Zero TTL disables the cache.
+func WithGrantsCacheTTL(ttl time.Duration) Option {
+	return func(o *Storage) { o.grants = newGrantsCache(grantsCacheSize, ttl) }
+}
+
+// WithQueuePropsCache sets how many queues properties are cached
+// for and how long. Zero TTL means properties don't expire.
+func WithQueuePropsCache(size uint64, ttl time.Duration) Option {
+	return func(o *Storage) {
+		o.cacheSize = size
+		o.cacheTTL = ttl
+	}
+}
+
+// WithMessageEvents sets the number of slots of the message events ring buffer, zero disables
+// recording of events, and how long events are kept for, zero keeps them until their slots are reused.
+func WithMessageEvents(size uint64, retention time.Duration) Option {
+	return func(o *Storage) {
+		o.events = messageEvents{size: size, retention: retention}
+	}
+}
+
+// WithReadOnly makes the Storage serve the database which is written by another
+// server, e.g. the primary one: it doesn't repair the database on start and doesn't
+// run the garbage collection and the evolution of queue tables.
+func WithReadOnly() Option {
+	return func(o *Storage) { o.readOnly = true }
+}
+
+// WithLeader makes the Storage serve the database which is changed only while
+// isLeader reports true, e.g. by the leader of the cluster. The database is
+// repaired and the evolution of queue tables runs once the Storage is told by
+// the Lead, and the garbage collection is skipped while isLeader reports false.
+func WithLeader(isLeader func() bool) Option {
+	return func(o *Storage) { o.isLeader = isLeader }
+}
+
+// WithGCLogger sets the logger of the garbage collection.
+// By default, the Storage logger is used.
+func WithGCLogger(logger *slog.Logger) Option {
+	return func(o *Storage) { o.gcLogger = logger }
+}
+
 // Storage represents a storage system.
 // This struct holds the necessary configurations and dependencies for the storage.
 type Storage struct {
-	db     *litekit.Conn
-	logger *slog.Logger
+	db       *litekit.Conn
+	logger   *slog.Logger
+	gcLogger *slog.Logger
 
 	querier querier
 
 	// cache holds information about queues properties.
 	cache *QueuePropsCache
 
+	// cacheSize and cacheTTL limit how many queues properties are cached for and how long.
+	cacheSize uint64
+	cacheTTL  time.Duration
+
 	// cacheFillingTimeout represents duration after which
 	// the cache filling procedure will be considered as failed.
 	cacheFillingTimeout time.Duration
 
-	// gcTimeout represents timeout duration between the garbage collection schedules.
-	gcTimeout time.Duration
+	// grants holds grants of subjects on queues resolved by the QueueGrants.
+	grants *grantsCache
+
+	// gcTimeout holds the time.Duration between the garbage collection
+	// schedules, which can be changed at runtime by the SetGCTimeout.
+	gcTimeout atomic.Int64
+
+	// gcReset notifies the garbage collection routine about changed gcTimeout.
+	gcReset chan struct{}
+
+	// gcLastRun holds the time of the last garbage collection run in Unix nanoseconds.
+	gcLastRun atomic.Int64
 
 	// observer is responsible for observing certain events and transform them to metrics.
 	observer telemetry.Observer
 
+	// maintenance serializes the garbage collection and snapshots.
+	maintenance *maintenance
+
+	// maxMessageSize holds the maximum size of the message body for all
+	// queues, which can be changed at runtime by the SetMaxMessageSize.
+	maxMessageSize atomic.Uint64
+
+	// searchMaxIndexedBytes limits the number of leading bytes
+	// of the message body which are indexed for the search.
+	searchMaxIndexedBytes uint
+
+	// receiptKey signs receipt handles, it's shared by servers of the database.
+	receiptKey []byte
+
+	// fullTextSearch reports whether search indexes can be created,
+	// which requires the FTS5 module of the SQLite library.
+	fullTextSearch bool
+
+	// evolutionBatchSize and evolutionPause control how fast
+	// schema mutations are applied to existing queue tables.
+	evolutionBatchSize uint
+	evolutionPause     time.Duration
+
+	// depthInterval is the interval between reconciliations of queue depth gauges.
+	depthInterval time.Duration
+
+	// events records lifecycle events of messages.
+	events messageEvents
+
+	// readOnly tells that the database is written by another server.
+	readOnly bool
+
+	// isLeader reports whether the database can be changed, nil when it always can.
+	isLeader func() bool
+
+	// window is the daily window of scheduled maintenance, nil when maintenance is not scheduled.
+	window *MaintenanceWindow
+
+	// jobsCtx is the context background jobs run with, which is canceled by the Close.
+	jobsCtx context.Context
+
+	// jobs keeps track of background jobs running in the process.
+	jobs *jobRunner
+
 	// stop is a function that can be called to stop the telemetry and garbage collection processes.
 	stop func()
 }
@@ -95,20 +253,52 @@ func New(db *litekit.Conn, options ...Option) (*Storage, error) {
 
 		querier: newQuerier(),
 
-		cache:               NewQueuePropsCache(queuePropsCacheSize),
+		cacheSize:           queuePropsCacheSize,
+		cacheTTL:            queuePropsCacheTTL,
 		cacheFillingTimeout: queuePropsCacheFillingTimeout,
 
-		gcTimeout: gcTimeout,
+		grants: newGrantsCache(grantsCacheSize, grantsCacheTTL),
+
+		gcReset: make(chan struct{}, 1),
 
 		observer: telemetry.NewObserver(),
 
+		maintenance: newMaintenance(),
+
+		searchMaxIndexedBytes: searchMaxIndexedBytes,
+
+		evolutionBatchSize: evolutionBatchSize,
+		evolutionPause:     evolutionPause,
+
+		depthInterval: depthInterval,
+
+		events: messageEvents{size: messageEventsSize, retention: messageEventsRetention},
+
+		jobs: newJobRunner(),
+
 		stop: nil,
 	}
 
+	s.gcTimeout.Store(int64(gcTimeout))
+
 	for _, option := range options {
 		option(&s)
 	}
 
+	if s.gcLogger == nil {
+		s.gcLogger = s.logger
+	}
+
+	if s.evolutionBatchSize == 0 {
+		s.evolutionBatchSize = evolutionBatchSize
+	}
+
+	if s.depthInterval <= 0 {
+		s.depthInterval = depthInterval
+	}
+
+	s.cache = NewQueuePropsCache(s.cacheSize, s.cacheTTL, s.observer)
+
 	prepareCtx, prepareCancel := context.WithTimeout(context.Background(), s.cacheFillingTimeout)
 	defer prepareCancel()
 
@@ -125,14 +315,104 @@ func New(db *litekit.Conn, options ...Option) (*Storage, error) {
 		return nil, fmt.Errorf("filling cache: %w", err)
 	}
 
+	if err := s.db.QueryRowContext(prepareCtx, queryFullTextSearchSupported).Scan(&s.fullTextSearch); err != nil {
+		return nil, fmt.Errorf("check full-text search support: %w", err)
+	}
+
+	if err := s.db.QueryRowContext(prepareCtx, querySelectReceiptKey).Scan(&s.receiptKey); err != nil {
+		return nil, fmt.Errorf("select receipt key: %w", err)
+	}
+
+	// The database written by another server is repaired and collected by that server,
+	// and the database of the cluster is repaired by the leader once it's elected.
+	if !s.readOnly && s.isLeader == nil {
+		if err := s.ensureUsage(prepareCtx); err != nil {
+			return nil, fmt.Errorf("ensure queues usage: %w", err)
+		}
+
+		if err := s.failInterruptedJobs(prepareCtx); err != nil {
+			return nil, err
+		}
+	}
+
 	ctx, stop := context.WithCancel(context.Background())
 	s.stop = stop
+	s.jobsCtx = ctx
+
+	if !s.readOnly {
+		go s.gc(ctx)
+	}
+
+	if !s.readOnly && s.isLeader == nil {
+		go s.evolveQueues(ctx)
+	}
+
+	if !s.readOnly && s.window != nil {
+		go s.maintainInWindow(ctx)
+	}
 
-	go s.gc(ctx)
+	go s.reconcileDepth(ctx)
 
 	return &s, nil
 }
 
+// Lead prepares the Storage created with the WithLeader to change the database once
+// the server has become the leader: it fills caches again, since the database has been
+// changed by the previous leader, repairs the database and starts the evolution of
+// queue tables.
+func (s *Storage) Lead(ctx context.Context) error {
+	s.grants.invalidate()
+	s.cache.purge()
+
+	if err := s.fillCache(ctx, ""); err != nil {
+		return fmt.Errorf("filling cache: %w", err)
+	}
+
+	if err := s.ensureUsage(ctx); err != nil {
+		return fmt.Errorf("ensure queues usage: %w", err)
+	}
+
+	if err := s.failInterruptedJobs(ctx); err != nil {
+		return err
+	}
+
+	go s.evolveQueues(ctx)
+
+	return nil
+}
+
+// MaxMessageSize returns the maximum size of the message body for all queues.
+func (s *Storage) MaxMessageSize() uint64 { return s.maxMessageSize.Load() }
+
+// SetMaxMessageSize changes the maximum size of the message body
+// for all queues, zero means no limit.
+func (s *Storage) SetMaxMessageSize(n uint64) { s.maxMessageSize.Store(n) }
+
+// GCTimeout returns the timeout between the garbage collection runs.
+func (s *Storage) GCTimeout() time.Duration { return time.Duration(s.gcTimeout.Load()) }
+
+// SetGCTimeout changes the timeout between the garbage collection runs,
+// zero timeout restores the default. The next run is rescheduled
+// according to the new timeout.
+func (s *Storage) SetGCTimeout(to time.Duration) error {
+	if to < 0 {
+		return fmt.Errorf("%w: GC timeout should not be negative: %s", errkit.ErrInvalidArgument, to)
+	}
+
+	if to == 0 {
+		to = gcTimeout
+	}
+
+	s.gcTimeout.Store(int64(to))
+
+	select {
+	case s.gcReset <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
 func (s *Storage) CreateQueue(ctx context.Context, input *v1.CreateQueueRequest) (_ *v1.CreateQueueResponse, sErr error) {
 	queueID := idkit.XID()
 
@@ -140,6 +420,11 @@ func (s *Storage) CreateQueue(ctx context.Context, input *v1.CreateQueueRequest)
 		return nil, fmt.Errorf("%w: queue name is empty", errkit.ErrInvalidArgument)
 	}
 
+	// Binaries built without the sqlite_fts5 tag can't create search indexes.
+	if input.SearchIndex && !s.fullTextSearch {
+		return nil, fmt.Errorf("%w: search index is not supported by the server, which should be built with the sqlite_fts5 tag", errkit.ErrInvalidArgument)
+	}
+
 	if input.MaxReceiveAttempts == 0 {
 		input.MaxReceiveAttempts = maxReceiveAttempts
 	}
@@ -152,7 +437,15 @@ func (s *Storage) CreateQueue(ctx context.Context, input *v1.CreateQueueRequest)
 		input.VisibilityTimeoutSeconds = uint64(msgVisibilityTimeout.Seconds())
 	}
 
-	tx, txErr := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if _, ok := v1.QuotaPolicy_name[int32(input.QuotaPolicy)]; !ok {
+		return nil, fmt.Errorf("%w: unknown quota policy: %d", errkit.ErrInvalidArgument, input.QuotaPolicy)
+	}
+
+	if err := validateRetryPolicy(input.RetryPolicy); err != nil {
+		return nil, err
+	}
+
+	tx, txErr := s.beginTx(ctx, opCreateQueue, false)
 	if txErr != nil {
 		return nil, fmt.Errorf(fmtBeginTxError, txErr)
 	}
@@ -163,6 +456,11 @@ func (s *Storage) CreateQueue(ctx context.Context, input *v1.CreateQueueRequest)
 		}
 	}()
 
+	tags, marshalErr := json.Marshal(tern.OP[map[string]string](input.Tags != nil, input.Tags, map[string]string{}))
+	if marshalErr != nil {
+		return nil, fmt.Errorf("marshal queue tags: %w", marshalErr)
+	}
+
 	if _, err := tx.ExecContext(ctx, queryInsertQueuePropRecord,
 		queueID,
 		input.QueueName,
@@ -171,6 +469,22 @@ func (s *Storage) CreateQueue(ctx context.Context, input *v1.CreateQueueRequest)
 		input.MaxReceiveAttempts,
 		input.EvictionPolicy,
 		input.DeadLetterQueueId,
+		input.Description,
+		string(tags),
+		input.Fifo,
+		input.DelaySeconds,
+		input.MaxMessageSizeBytes,
+		input.Owner,
+		input.SearchIndex,
+		v1.QueueState_QUEUE_STATE_ACTIVE,
+		input.RateLimit,
+		input.RateLimitBurst,
+		input.MaxMessages,
+		input.MaxBytes,
+		input.QuotaPolicy,
+		input.GetRetryPolicy().GetInitialBackoffSeconds(),
+		input.GetRetryPolicy().GetMultiplier(),
+		input.GetRetryPolicy().GetMaxBackoffSeconds(),
 	); err != nil {
 		return nil, fmt.Errorf("create queue properties record: execute query: %w", err)
 	}
@@ -179,10 +493,36 @@ func (s *Storage) CreateQueue(ctx context.Context, input *v1.CreateQueueRequest)
 		return nil, fmt.Errorf("create queue table: execute query: %w", err)
 	}
 
+	if err := applyQueueMutations(ctx, tx.Tx, queueID, 0); err != nil {
+		return nil, fmt.Errorf("create queue table: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryInsertQueueUsage, queueID); err != nil {
+		return nil, fmt.Errorf("create queue usage record: execute query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryCreateUsageTriggers(queueID)); err != nil {
+		return nil, fmt.Errorf("create queue usage triggers: execute query: %w", err)
+	}
+
+	if input.SearchIndex {
+		if _, err := tx.ExecContext(ctx, queryCreateSearchIndex(queueID, s.searchMaxIndexedBytes)); err != nil {
+			return nil, fmt.Errorf("create queue search index: execute query: %w", err)
+		}
+	}
+
+	if input.Owner != "" {
+		if err := grantOwnerPermissions(ctx, tx.Tx, queueID, input.Owner); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("commit transaction: %w", err)
 	}
 
+	s.grants.invalidate()
+
 	props := QueueProps{
 		ID:                       queueID,
 		Name:                     input.QueueName,
@@ -191,9 +531,27 @@ func (s *Storage) CreateQueue(ctx context.Context, input *v1.CreateQueueRequest)
 		MaxReceiveAttempts:       input.MaxReceiveAttempts,
 		EvictionPolicy:           uint32(input.EvictionPolicy),
 		DeadLetterQueueID:        input.DeadLetterQueueId,
+		Version:                  1,
+		Description:              input.Description,
+		Tags:                     input.Tags,
+		FIFO:                     input.Fifo,
+		DelaySeconds:             input.DelaySeconds,
+		MaxMessageSizeBytes:      input.MaxMessageSizeBytes,
+		Owner:                    input.Owner,
+		SearchIndex:              input.SearchIndex,
+		State:                    uint32(v1.QueueState_QUEUE_STATE_ACTIVE),
+		RateLimit:                input.RateLimit,
+		RateLimitBurst:           input.RateLimitBurst,
+		MaxMessages:              input.MaxMessages,
+		MaxBytes:                 input.MaxBytes,
+		QuotaPolicy:              uint32(input.QuotaPolicy),
+		RetryInitialBackoff:      input.GetRetryPolicy().GetInitialBackoffSeconds(),
+		RetryMultiplier:          input.GetRetryPolicy().GetMultiplier(),
+		RetryMaxBackoff:          input.GetRetryPolicy().GetMaxBackoffSeconds(),
 	}
 
 	s.cache.put(props)
+	s.observer.QueueTags(queueID, input.Tags)
 
 	output := v1.CreateQueueResponse{
 		QueueId: queueID,
@@ -214,9 +572,20 @@ func (s *Storage) ListQueues(ctx context.Context, input *v1.ListQueuesRequest) (
 	// The +1 is used to fetch one extra item to determine if there are more results.
 	limit := pageSize + 1
 
-	query := queryListQueues(limit, input.Cursor, input.OrderBy, input.SortBy)
+	var cursor *listCursor
+
+	if input.GetCursor() != "" {
+		c, decodeErr := decodeListCursor(input.GetCursor())
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+
+		cursor = &c
+	}
+
+	query, args := queryListQueues(limit, cursor, input.GetQueuePrefix(), input.GetOrderBy(), input.GetSortBy())
 
-	queues, listErr := s.listQueues(ctx, query, uint32(limit))
+	queues, listErr := s.listQueues(ctx, query, args, uint32(limit))
 	if listErr != nil {
 		return nil, fmt.Errorf("list queues: %w", listErr)
 	}
@@ -231,11 +600,16 @@ func (s *Storage) ListQueues(ctx context.Context, input *v1.ListQueuesRequest) (
 	if len(queues) > int(pageSize) {
 		// Remove the extra item before returning.
 		lastItem := queues[len(queues)-2]
-		nextCursor = lastItem.QueueId
+		nextCursor = encodeListCursor(lastItem, input.GetOrderBy())
 		queues = queues[:len(queues)-1]
 		hasMore = true
 	}
 
+	// The deprecated include_depth is served by the stats, which hold the depth as well.
+	if input.GetIncludeStats() || input.GetIncludeDepth() { //nolint:staticcheck // Deprecated include_depth is still accepted.
+		s.fillQueueStats(queues)
+	}
+
 	output := v1.ListQueuesResponse{
 		Queues:     queues,
 		NextCursor: nextCursor,
@@ -264,7 +638,7 @@ func (s *Storage) DescribeQueue(ctx context.Context, input *v1.DescribeQueueRequ
 		return propsToProto(p), nil
 	}
 
-	tx, txErr := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	tx, txErr := s.beginTx(ctx, opDescribeQueue, false)
 	if txErr != nil {
 		return nil, fmt.Errorf("begin transaction: %w", txErr)
 	}
@@ -288,43 +662,64 @@ func (s *Storage) DescribeQueue(ctx context.Context, input *v1.DescribeQueueRequ
 		return nil, fmt.Errorf("%w: queue_id or queue_name should be specified", pqerr.ErrInvalidInput)
 	}
 
-	var (
-		output    v1.DescribeQueueResponse
-		createdAt time.Time
-		gcAt      time.Time
-	)
-
 	query := queueDescribeQueueProps(where)
 
-	if err := s.db.QueryRowContext(ctx, query).Scan(
-		&output.QueueId,
-		&output.QueueName,
-		&createdAt,
-		&gcAt,
-		&output.RetentionPeriodSeconds,
-		&output.VisibilityTimeoutSeconds,
-		&output.MaxReceiveAttempts,
-		&output.EvictionPolicy,
-		&output.DeadLetterQueueId,
-	); err != nil {
-		return nil, fmt.Errorf("execute query (SQL: %s): %w", query, err)
+	output, scanErr := scanQueueProps(s.db.QueryRowContext(ctx, query))
+	if scanErr != nil {
+		return nil, fmt.Errorf("execute query (SQL: %s): %w", query, scanErr)
 	}
 
-	output.CreatedAt = timestamppb.New(createdAt)
-
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("commit transaction: %w", err)
 	}
 
-	s.cache.put(propsFromProto(&output))
+	s.cache.put(propsFromProto(output))
 
-	return &output, nil
+	return output, nil
 }
 
-func (s *Storage) PurgeQueue(ctx context.Context, input *v1.PurgeQueueRequest) (_ *v1.PurgeQueueResponse, sErr error) {
-	tx, txErr := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+// queueProps returns properties of the queue from the cache or from the database.
+func (s *Storage) queueProps(ctx context.Context, queueID string) (QueueProps, error) {
+	if props, ok := s.cache.getByID(queueID); ok {
+		return props, nil
+	}
+
+	info, describeErr := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID})
+	if describeErr != nil {
+		return QueueProps{}, fmt.Errorf("describe queue (id: %q): %w", queueID, describeErr)
+	}
+
+	return propsFromProto(info), nil
+}
+
+func (s *Storage) UpdateQueue(ctx context.Context, input *v1.UpdateQueueRequest) (_ *v1.UpdateQueueResponse, sErr error) {
+	queueID := input.GetQueueId()
+
+	current, describeErr := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID})
+	if describeErr != nil {
+		return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, describeErr)
+	}
+
+	if input.Version != current.Version {
+		// The cached properties might have been outdated by another instance.
+		s.cache.invalidate(queueID)
+
+		return nil, &pqerr.ConflictError{Resource: "queue " + queueID, Expected: input.Version, Actual: current.Version}
+	}
+
+	defaultQueueUpdate(input, current)
+
+	if _, ok := v1.QuotaPolicy_name[int32(input.QuotaPolicy)]; !ok {
+		return nil, fmt.Errorf("%w: unknown quota policy: %d", errkit.ErrInvalidArgument, input.QuotaPolicy)
+	}
+
+	if err := validateRetryPolicy(input.RetryPolicy); err != nil {
+		return nil, err
+	}
+
+	tx, txErr := s.beginTx(ctx, opUpdateQueue, false)
 	if txErr != nil {
-		return nil, fmt.Errorf("begin transaction: %w", txErr)
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
 	}
 
 	defer func() {
@@ -333,92 +728,157 @@ func (s *Storage) PurgeQueue(ctx context.Context, input *v1.PurgeQueueRequest) (
 		}
 	}()
 
-	queueID := input.GetQueueId()
+	renamed := input.QueueName != current.QueueName
 
-	var count uint64
-	if err := tx.QueryRowContext(ctx, queryCountMessages(queueID)).Scan(&count); err != nil {
-		return nil, fmt.Errorf("purge queue %q count messages: %w", queueID, err)
+	if renamed {
+		var taken bool
+
+		if err := tx.QueryRowContext(ctx, querySelectQueueNameTaken, input.QueueName, queueID).Scan(&taken); err != nil {
+			return nil, fmt.Errorf("check queue name %q: %w", input.QueueName, err)
+		}
+
+		if taken {
+			return nil, fmt.Errorf("%w: queue %q", errkit.ErrAlreadyExists, input.QueueName)
+		}
 	}
 
-	purgeQueueRes, purgeQueueErr := tx.ExecContext(ctx, queryPurgeQueue(queueID))
-	if purgeQueueErr != nil {
-		return nil, fmt.Errorf("purge queue %q table: %w", queueID, purgeQueueErr)
+	res, execErr := tx.ExecContext(ctx, queryUpdateQueuePropRecord,
+		input.QueueName,
+		input.RetentionPeriodSeconds,
+		input.VisibilityTimeoutSeconds,
+		input.MaxReceiveAttempts,
+		input.EvictionPolicy,
+		input.DeadLetterQueueId,
+		input.GetRateLimit(),
+		input.GetRateLimitBurst(),
+		input.GetMaxMessages(),
+		input.GetMaxBytes(),
+		input.QuotaPolicy,
+		input.GetRetryPolicy().GetInitialBackoffSeconds(),
+		input.GetRetryPolicy().GetMultiplier(),
+		input.GetRetryPolicy().GetMaxBackoffSeconds(),
+		queueID,
+		input.Version,
+	)
+	if execErr != nil {
+		return nil, fmt.Errorf("update queue %q properties record: %w", queueID, execErr)
 	}
 
-	rows, rowsErr := purgeQueueRes.RowsAffected()
+	rows, rowsErr := res.RowsAffected()
 	if rowsErr != nil {
-		return nil, fmt.Errorf("purge queue %q info record: %w", queueID, rowsErr)
+		return nil, fmt.Errorf("update queue %q properties record: %w", queueID, rowsErr)
 	}
 
-	if count > math.MaxInt64 || rows != int64(count) {
-		return nil, fmt.Errorf("purge queue %q count (%d) != rows affected (%d) by purge", queueID, count, rows)
+	// The version has been changed by another update
+	// after the properties were read.
+	if rows < 1 {
+		var actual uint64
+		if err := tx.QueryRowContext(ctx, querySelectQueuePropVersion, queueID).Scan(&actual); err != nil {
+			return nil, fmt.Errorf("select queue %q properties version: %w", queueID, err)
+		}
+
+		s.cache.invalidate(queueID)
+
+		return nil, &pqerr.ConflictError{Resource: "queue " + queueID, Expected: input.Version, Actual: actual}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("commit transaction: %w", err)
+		return nil, fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	props := propsFromProto(current)
+	props.Name = input.QueueName
+	props.RetentionPeriodSeconds = input.RetentionPeriodSeconds
+	props.VisibilityTimeoutSeconds = input.VisibilityTimeoutSeconds
+	props.MaxReceiveAttempts = input.MaxReceiveAttempts
+	props.EvictionPolicy = uint32(input.EvictionPolicy)
+	props.DeadLetterQueueID = input.DeadLetterQueueId
+	props.RateLimit = input.GetRateLimit()
+	props.RateLimitBurst = input.GetRateLimitBurst()
+	props.MaxMessages = input.GetMaxMessages()
+	props.MaxBytes = input.GetMaxBytes()
+	props.QuotaPolicy = uint32(input.QuotaPolicy)
+	props.RetryInitialBackoff = input.GetRetryPolicy().GetInitialBackoffSeconds()
+	props.RetryMultiplier = input.GetRetryPolicy().GetMultiplier()
+	props.RetryMaxBackoff = input.GetRetryPolicy().GetMaxBackoffSeconds()
+	props.Version = input.Version + 1
+
+	s.cache.put(props)
+
+	// Policies might grant operations on queues by their names.
+	if renamed {
+		s.grants.invalidate()
 	}
 
-	output := v1.PurgeQueueResponse{}
+	output := v1.UpdateQueueResponse{
+		Version: props.Version,
+	}
 
 	return &output, nil
 }
 
-func (s *Storage) DeleteQueue(ctx context.Context, input *v1.DeleteQueueRequest) (_ *v1.DeleteQueueResponse, sErr error) {
-	queueID := input.GetQueueId()
-
-	props, ok := s.cache.getByID(queueID)
-	if !ok {
-		return nil, fmt.Errorf("queue props (id: %q) not cached", queueID)
+// defaultQueueUpdate fills properties which aren't given by the update
+// with current ones, so the update changes only the given properties.
+func defaultQueueUpdate(input *v1.UpdateQueueRequest, current *v1.DescribeQueueResponse) {
+	if input.MaxReceiveAttempts == 0 {
+		input.MaxReceiveAttempts = current.MaxReceiveAttempts
 	}
 
-	tx, txErr := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
-	if txErr != nil {
-		return nil, fmt.Errorf("begin transaction: %w", txErr)
+	if input.RetentionPeriodSeconds == 0 {
+		input.RetentionPeriodSeconds = current.RetentionPeriodSeconds
 	}
 
-	defer func() {
-		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
-			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
-		}
-	}()
+	if input.VisibilityTimeoutSeconds == 0 {
+		input.VisibilityTimeoutSeconds = current.VisibilityTimeoutSeconds
+	}
 
-	queueInfoRes, queueHeaderErr := tx.ExecContext(ctx, queryDeleteQueuePropRecord, queueID)
-	if queueHeaderErr != nil {
-		return nil, fmt.Errorf("delete queue %q info record: %w", queueID, queueHeaderErr)
+	if input.EvictionPolicy == v1.EvictionPolicy_EVICTION_POLICY_UNSPECIFIED {
+		input.EvictionPolicy = current.EvictionPolicy
 	}
 
-	rows, rowsErr := queueInfoRes.RowsAffected()
-	if rowsErr != nil {
-		return nil, fmt.Errorf("delete queue %q info record: %w", queueID, rowsErr)
+	if input.DeadLetterQueueId == "" {
+		input.DeadLetterQueueId = current.DeadLetterQueueId
 	}
 
-	if rows < 1 {
-		return nil, fmt.Errorf("delete queue %q info record: %w", queueID, pqerr.ErrNotFound)
+	if input.RateLimit == nil {
+		input.RateLimit = &current.RateLimit
 	}
 
-	if _, err := tx.ExecContext(ctx, queryDeleteQueueTable(queueID)); err != nil {
-		return nil, fmt.Errorf("drop queue %q table: %w", queueID, err)
+	if input.RateLimitBurst == nil {
+		input.RateLimitBurst = &current.RateLimitBurst
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("commit transaction: %w", err)
+	if input.MaxMessages == nil {
+		input.MaxMessages = &current.MaxMessages
 	}
 
-	s.cache.delete(props.ID, props.Name)
+	if input.MaxBytes == nil {
+		input.MaxBytes = &current.MaxBytes
+	}
 
-	output := v1.DeleteQueueResponse{}
+	if input.QuotaPolicy == v1.QuotaPolicy_QUOTA_POLICY_UNSPECIFIED {
+		input.QuotaPolicy = current.QuotaPolicy
+	}
 
-	s.observer.QueuesExist().Dec()
+	if input.QueueName == "" {
+		input.QueueName = current.QueueName
+	}
 
-	return &output, nil
+	if input.RetryPolicy == nil {
+		input.RetryPolicy = current.GetRetryPolicy()
+	}
 }
 
-func (s *Storage) Send(ctx context.Context, input *v1.SendRequest) (_ *v1.SendResponse, sErr error) {
-	queueID := input.GetQueueId()
+func (s *Storage) PurgeQueue(ctx context.Context, input *v1.PurgeQueueRequest) (_ *v1.PurgeQueueResponse, sErr error) {
+	if input.GetAsync() {
+		return s.purgeQueueAsync(ctx, input)
+	}
 
-	s.cache.getByID(queueID)
+	if input.GetOlderThan() != nil || input.GetMinAttempts() > 0 {
+		return s.purgeMessages(ctx, input)
+	}
 
-	tx, txErr := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	tx, txErr := s.beginTx(ctx, opPurgeQueue, false)
 	if txErr != nil {
 		return nil, fmt.Errorf("begin transaction: %w", txErr)
 	}
@@ -429,43 +889,207 @@ func (s *Storage) Send(ctx context.Context, input *v1.SendRequest) (_ *v1.SendRe
 		}
 	}()
 
-	stmt, prepareErr := tx.PrepareContext(ctx, queryInsertMessages(queueID))
-	if prepareErr != nil {
-		return nil, fmt.Errorf("prepare statement: %w", prepareErr)
-	}
-
-	defer func() {
-		if err := stmt.Close(); err != nil {
-			sErr = errors.Join(sErr, fmt.Errorf("close prepared statement: %w", err))
-		}
-	}()
+	queueID := input.GetQueueId()
 
-	output := v1.SendResponse{
-		MessageIds: make([]string, 0, len(input.Messages)),
+	var count uint64
+	if err := tx.QueryRowContext(ctx, queryCountMessages(queueID)).Scan(&count); err != nil {
+		return nil, fmt.Errorf("purge queue %q count messages: %w", queueID, err)
 	}
 
-	for _, m := range input.GetMessages() {
-		msgID := idkit.ULID()
+	purgeQueueRes, purgeQueueErr := tx.ExecContext(ctx, queryPurgeQueue(queueID))
+	if purgeQueueErr != nil {
+		return nil, fmt.Errorf("purge queue %q table: %w", queueID, purgeQueueErr)
+	}
 
-		if _, err := stmt.ExecContext(ctx, msgID, m.Body); err != nil {
-			return nil, fmt.Errorf("insert message: %w", err)
+	rows, rowsErr := purgeQueueRes.RowsAffected()
+	if rowsErr != nil {
+		return nil, fmt.Errorf("purge queue %q info record: %w", queueID, rowsErr)
+	}
+
+	if count > math.MaxInt64 || rows != int64(count) {
+		return nil, fmt.Errorf("purge queue %q count (%d) != rows affected (%d) by purge", queueID, count, rows)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	s.observer.MessagesVisible(queueID).Set(0)
+	s.observer.MessagesInFlight(queueID).Set(0)
+
+	output := v1.PurgeQueueResponse{
+		MessagesCount: count,
+	}
+
+	return &output, nil
+}
+
+// purgeFilterFromRequest returns conditions and their arguments which
+// limit the purge to messages matching filters of the request.
+func purgeFilterFromRequest(input *v1.PurgeQueueRequest) ([]string, []any, error) {
+	var (
+		where []string
+		args  []any
+	)
+
+	if olderThan := input.GetOlderThan(); olderThan != nil {
+		if err := olderThan.CheckValid(); err != nil {
+			return nil, nil, fmt.Errorf("%w: older than: %w", errkit.ErrInvalidArgument, err)
+		}
+
+		where = append(where, `created_at < ?`)
+		args = append(args, olderThan.AsTime().UTC().Format(time.DateTime))
+	}
+
+	if minAttempts := input.GetMinAttempts(); minAttempts > 0 {
+		where = append(where, `retries >= ?`)
+		args = append(args, minAttempts)
+	}
+
+	return where, args, nil
+}
+
+// purgeMessages purges messages of the queue which match filters of the request,
+// so operators can clear a problematic subset of messages instead of the whole queue.
+func (s *Storage) purgeMessages(ctx context.Context, input *v1.PurgeQueueRequest) (_ *v1.PurgeQueueResponse, sErr error) {
+	queueID := input.GetQueueId()
+
+	info, describeErr := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID})
+	if describeErr != nil {
+		return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, describeErr)
+	}
+
+	where, args, filterErr := purgeFilterFromRequest(input)
+	if filterErr != nil {
+		return nil, filterErr
+	}
+
+	tx, txErr := s.beginTx(ctx, opPurgeQueue, false)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	res, execErr := tx.ExecContext(ctx, queryPurgeMessages(queueID, where), args...)
+	if execErr != nil {
+		return nil, fmt.Errorf("purge queue %q messages: %w", queueID, execErr)
+	}
+
+	rows, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return nil, fmt.Errorf("purge queue %q messages: %w", queueID, rowsErr)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	// Purged messages might have been both visible and in flight, so depth
+	// gauges are counted again rather than decremented. Failing to count them
+	// doesn't fail the purge, since they are reconciled periodically anyway.
+	depth, depthErr := s.queueDepth(ctx, queueID, info.GetMaxReceiveAttempts())
+	if depthErr != nil {
+		s.logger.Error("Failed to count queue depth after purge",
+			slog.String("queue_id", queueID),
+			slog.String("error", depthErr.Error()),
+		)
+	} else {
+		s.observer.MessagesVisible(queueID).Set(depth.Visible)
+		s.observer.MessagesInFlight(queueID).Set(depth.InFlight)
+	}
+
+	output := v1.PurgeQueueResponse{
+		MessagesCount: uint64(rows),
+	}
+
+	return &output, nil
+}
+
+func (s *Storage) DeleteQueue(ctx context.Context, input *v1.DeleteQueueRequest) (_ *v1.DeleteQueueResponse, sErr error) {
+	queueID := input.GetQueueId()
+
+	props, propsErr := s.queueProps(ctx, queueID)
+	if propsErr != nil {
+		return nil, propsErr
+	}
+
+	if state := v1.QueueState(props.State); state != v1.QueueState_QUEUE_STATE_DELETING &&
+		!canTransition(state, v1.QueueState_QUEUE_STATE_DELETING) {
+		return nil, fmt.Errorf("%w: queue %q can't be deleted in the %s state", pqerr.ErrConflict, queueID, stateName(state))
+	}
+
+	tx, txErr := s.beginTx(ctx, opDeleteQueue, false)
+	if txErr != nil {
+		return nil, fmt.Errorf("begin transaction: %w", txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
 		}
+	}()
+
+	queueInfoRes, queueHeaderErr := tx.ExecContext(ctx, queryDeleteQueuePropRecord, queueID)
+	if queueHeaderErr != nil {
+		return nil, fmt.Errorf("delete queue %q info record: %w", queueID, queueHeaderErr)
+	}
+
+	rows, rowsErr := queueInfoRes.RowsAffected()
+	if rowsErr != nil {
+		return nil, fmt.Errorf("delete queue %q info record: %w", queueID, rowsErr)
+	}
 
-		output.MessageIds = append(output.MessageIds, msgID)
+	if rows < 1 {
+		return nil, fmt.Errorf("delete queue %q info record: %w", queueID, pqerr.ErrNotFound)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeleteQueueTable(queueID)); err != nil {
+		return nil, fmt.Errorf("drop queue %q table: %w", queueID, err)
+	}
 
-		s.observer.MessagesSentBytes(queueID).Add(uint64(len(m.Body)))
+	if _, err := tx.ExecContext(ctx, queryDeleteQueueUsage, queueID); err != nil {
+		return nil, fmt.Errorf("delete queue %q usage record: %w", queueID, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeleteQueueTableVersion, queueID); err != nil {
+		return nil, fmt.Errorf("delete queue %q table version: %w", queueID, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeleteQueueArchive, queueID); err != nil {
+		return nil, fmt.Errorf("delete queue %q archive: %w", queueID, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeleteQueueMessageEvents, queueID); err != nil {
+		return nil, fmt.Errorf("delete queue %q message events: %w", queueID, err)
 	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("commit transaction: %w", err)
 	}
 
-	s.observer.MessagesSent(queueID).Add(uint64(len(output.MessageIds)))
+	s.cache.delete(props.ID)
+	s.grants.invalidate()
+	s.observer.MessagesVisible(props.ID).Set(0)
+	s.observer.MessagesInFlight(props.ID).Set(0)
+	s.observer.QueueTags(props.ID, nil)
+
+	output := v1.DeleteQueueResponse{}
+
+	s.observer.QueuesExist().Dec()
 
 	return &output, nil
 }
 
-func (s *Storage) Receive(ctx context.Context, input *v1.ReceiveRequest) (_ *v1.ReceiveResponse, sErr error) {
+func (s *Storage) Send(ctx context.Context, input *v1.SendRequest) (*v1.SendResponse, error) {
+	return retryBusy(ctx, s.observer, opSend, func() (*v1.SendResponse, error) { return s.send(ctx, input) })
+}
+
+func (s *Storage) send(ctx context.Context, input *v1.SendRequest) (_ *v1.SendResponse, sErr error) {
 	queueID := input.GetQueueId()
 
 	info, describeErr := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{
@@ -475,11 +1099,250 @@ func (s *Storage) Receive(ctx context.Context, input *v1.ReceiveRequest) (_ *v1.
 		return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, describeErr)
 	}
 
-	tx, txErr := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err := checkQueueState(queueID, info.GetState(), operationSend); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkMessageSize(info, input.GetMessages()); err != nil {
+		return nil, err
+	}
+
+	tx, txErr := s.beginTx(ctx, opSend, false)
 	if txErr != nil {
 		return nil, fmt.Errorf("begin transaction: %w", txErr)
 	}
 
+	tx.span.SetAttributes(attribute.String("queue_id", queueID), attribute.Int("messages", len(input.GetMessages())))
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	sent, insertErr := s.insertMessages(ctx, tx.Tx, info, input.GetMessages())
+	if insertErr != nil {
+		return nil, insertErr
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	s.observeSent(info, sent)
+
+	return &v1.SendResponse{MessageIds: sent.ids}, nil
+}
+
+// sentMessages represents messages inserted to the queue, which are
+// counted after the commit, since sends are retried when the database is busy.
+type sentMessages struct {
+	ids     []string
+	bytes   uint64
+	evicted uint64
+}
+
+// insertMessages inserts messages to the queue in the transaction,
+// making room for them according to the quota of the queue.
+func (s *Storage) insertMessages(ctx context.Context, tx *sql.Tx, info *v1.DescribeQueueResponse, messages []*v1.SendMessage) (_ *sentMessages, sErr error) {
+	queueID := info.GetQueueId()
+	visibleAt := time.Now().UTC().Add(time.Duration(info.GetDelaySeconds()) * time.Second)
+
+	sent := sentMessages{
+		ids: make([]string, 0, len(messages)),
+	}
+
+	events := make([]messageEvent, 0, len(messages))
+
+	if err := validateAttributes(messages); err != nil {
+		return nil, err
+	}
+
+	query := queryInsertMessages(queueID)
+	withAttributes := hasAttributes(messages)
+
+	if withAttributes {
+		version, versionErr := queueTableVersion(ctx, tx, queueID)
+		if versionErr != nil {
+			return nil, versionErr
+		}
+
+		if version < attributesVersion {
+			return nil, fmt.Errorf("%w: queue %q can't store message attributes until its table evolves",
+				errkit.ErrUnavailable, queueID,
+			)
+		}
+
+		query = queryInsertMessagesWithAttributes(queueID)
+	}
+
+	if hasQuota(info) {
+		n, quotaErr := s.applyQuota(ctx, tx, info, messages)
+		if quotaErr != nil {
+			return nil, quotaErr
+		}
+
+		sent.evicted = n
+	}
+
+	stmt, prepareErr := tx.PrepareContext(ctx, query)
+	if prepareErr != nil {
+		return nil, fmt.Errorf("prepare statement: %w", prepareErr)
+	}
+
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close prepared statement: %w", err))
+		}
+	}()
+
+	for _, m := range messages {
+		// Stop early when the caller has gone away, there is
+		// no point in committing messages nobody knows about.
+		if err := ctx.Err(); err != nil {
+			return nil, &pqerr.InterruptedError{
+				Operation: "send",
+				Processed: len(sent.ids),
+				Total:     len(messages),
+				Err:       err,
+			}
+		}
+
+		msgID := idkit.ULID()
+		args := []any{msgID, m.Body, visibleAt}
+
+		if withAttributes {
+			attrs, marshalErr := marshalAttributes(m.GetAttributes())
+			if marshalErr != nil {
+				return nil, marshalErr
+			}
+
+			args = append(args, attrs)
+		}
+
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return nil, fmt.Errorf("insert message: %w", err)
+		}
+
+		sent.ids = append(sent.ids, msgID)
+		sent.bytes += uint64(len(m.Body))
+
+		events = append(events, messageEvent{MessageID: msgID, Kind: v1.MessageEventKind_MESSAGE_EVENT_KIND_SENT, VisibleAt: visibleAt})
+	}
+
+	if err := s.events.record(ctx, tx, queueID, events...); err != nil {
+		return nil, err
+	}
+
+	return &sent, nil
+}
+
+// observeSent updates metrics of the queue with committed messages.
+func (s *Storage) observeSent(info *v1.DescribeQueueResponse, sent *sentMessages) {
+	queueID := info.GetQueueId()
+
+	s.observer.MessagesSent(queueID).Add(uint64(len(sent.ids)))
+	s.observer.MessagesSentBytes(queueID).Add(sent.bytes)
+
+	// Delayed messages become visible later, the periodic
+	// reconciliation of depth gauges takes them into account.
+	if info.GetDelaySeconds() == 0 {
+		s.observer.MessagesVisible(queueID).Add(uint64(len(sent.ids)))
+	}
+
+	if sent.evicted > 0 {
+		s.observer.MessagesOverQuota(queueID, quotaActionEvicted).Add(sent.evicted)
+	}
+}
+
+// checkMessageSize returns the *pqerr.MessageTooLargeError if any of messages exceeds
+// the lower of the queue limit and the limit for all queues.
+func (s *Storage) checkMessageSize(info *v1.DescribeQueueResponse, messages []*v1.SendMessage) error {
+	limit, scope := info.GetMaxMessageSizeBytes(), "queue"
+
+	if server := s.maxMessageSize.Load(); server > 0 && (limit == 0 || server < limit) {
+		limit, scope = server, "server"
+	}
+
+	if limit == 0 {
+		return nil
+	}
+
+	for i, m := range messages {
+		if size := uint64(len(m.GetBody())); size > limit {
+			s.observer.MessagesOversized(info.GetQueueId(), scope).Inc()
+
+			return fmt.Errorf("%w: %w", errkit.ErrInvalidArgument, &pqerr.MessageTooLargeError{
+				Index: i,
+				Size:  size,
+				Limit: limit,
+				Scope: scope,
+			})
+		}
+	}
+
+	return nil
+}
+
+// applyQuota makes room for messages in the queue according to its quota policy.
+// It returns the number of evicted messages, or the *pqerr.QuotaExceededError
+// when messages don't fit the queue quota.
+func (s *Storage) applyQuota(ctx context.Context, tx *sql.Tx, info *v1.DescribeQueueResponse, messages []*v1.SendMessage) (uint64, error) {
+	batch := queueUsage{Messages: uint64(len(messages))}
+
+	for _, m := range messages {
+		batch.Bytes += uint64(len(m.GetBody()))
+	}
+
+	usage, usageErr := readUsage(ctx, tx, info.GetQueueId())
+	if usageErr != nil {
+		return 0, fmt.Errorf("read queue %q usage: %w", info.GetQueueId(), usageErr)
+	}
+
+	excess, quotaErr := checkQuota(info, usage, batch)
+	if quotaErr != nil {
+		s.observer.MessagesOverQuota(info.GetQueueId(), quotaActionRejected).Add(batch.Messages)
+
+		return 0, quotaErr
+	}
+
+	if excess == (queueUsage{}) {
+		return 0, nil
+	}
+
+	evicted, evictErr := evictForQuota(ctx, tx, info, excess)
+	if evictErr != nil {
+		return 0, fmt.Errorf("evict messages over queue %q quota: %w", info.GetQueueId(), evictErr)
+	}
+
+	return evicted, nil
+}
+
+func (s *Storage) Receive(ctx context.Context, input *v1.ReceiveRequest) (*v1.ReceiveResponse, error) {
+	return retryBusy(ctx, s.observer, opReceive, func() (*v1.ReceiveResponse, error) { return s.receive(ctx, input) })
+}
+
+func (s *Storage) receive(ctx context.Context, input *v1.ReceiveRequest) (_ *v1.ReceiveResponse, sErr error) {
+	queueID := input.GetQueueId()
+
+	info, describeErr := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{
+		QueueId: queueID,
+	})
+	if describeErr != nil {
+		return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, describeErr)
+	}
+
+	if err := checkQueueState(queueID, info.GetState(), operationReceive); err != nil {
+		return nil, err
+	}
+
+	tx, txErr := s.beginTx(ctx, opReceive, false)
+	if txErr != nil {
+		return nil, fmt.Errorf("begin transaction: %w", txErr)
+	}
+
+	tx.span.SetAttributes(attribute.String("queue_id", queueID))
+
 	defer func() {
 		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
 			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
@@ -491,7 +1354,44 @@ func (s *Storage) Receive(ctx context.Context, input *v1.ReceiveRequest) (_ *v1.
 		limit = 1
 	}
 
-	stmt, prepareErr := tx.PrepareContext(ctx, queryUpdateMessages(queueID))
+	// FIFO queue doesn't deliver messages while older ones are being processed,
+	// otherwise a redelivered message would be processed out of order.
+	if info.GetFifo() {
+		var inFlight uint64
+		if err := tx.QueryRowContext(ctx, queryCountInFlightMessages(queueID)).Scan(&inFlight); err != nil {
+			return nil, fmt.Errorf("count in-flight messages: %w", err)
+		}
+
+		if inFlight > 0 {
+			s.observer.ReceiveRequests(queueID).Inc()
+			s.observer.EmptyReceives(queueID).Inc()
+
+			return &v1.ReceiveResponse{SuggestedBatchSize: 1}, nil
+		}
+	}
+
+	version, versionErr := queueTableVersion(ctx, tx.Tx, queueID)
+	if versionErr != nil {
+		return nil, versionErr
+	}
+
+	// Tables which haven't been evolved yet don't record the first receive,
+	// nor do they hold attributes, so filters see messages without attributes.
+	firstReceivedAt := version >= firstReceivedAtVersion
+	attributes := version >= attributesVersion
+
+	var filter attributeFilter
+
+	if input.GetFilter() != "" {
+		f, filterErr := parseAttributeFilter(input.GetFilter(), attributesColumn(attributes))
+		if filterErr != nil {
+			return nil, filterErr
+		}
+
+		filter = *f
+	}
+
+	stmt, prepareErr := tx.PrepareContext(ctx, queryUpdateMessages(queueID, firstReceivedAt))
 	if prepareErr != nil {
 		return nil, fmt.Errorf("prepare statement: %w", prepareErr)
 	}
@@ -502,10 +1402,12 @@ func (s *Storage) Receive(ctx context.Context, input *v1.ReceiveRequest) (_ *v1.
 		}
 	}()
 
-	rows, queryErr := tx.QueryContext(ctx, querySelectMessages(queueID),
-		info.MaxReceiveAttempts,
-		limit,
-	)
+	args := make([]any, 0, len(filter.args)+2)
+	args = append(args, info.MaxReceiveAttempts)
+	args = append(args, filter.args...)
+	args = append(args, limit)
+
+	rows, queryErr := tx.QueryContext(ctx, querySelectMessages(queueID, firstReceivedAt, attributes, filter.where), args...)
 	if queryErr != nil {
 		return nil, fmt.Errorf("select query: %w", queryErr)
 	}
@@ -520,26 +1422,84 @@ func (s *Storage) Receive(ctx context.Context, input *v1.ReceiveRequest) (_ *v1.
 		Messages: make([]*v1.ReceiveMessage, 0, input.BatchSize),
 	}
 
-	visibleAt := time.Now().UTC().Add(time.Duration(info.VisibilityTimeoutSeconds) * time.Second)
+	now := time.Now().UTC()
+	visibleAt := now.Add(time.Duration(info.VisibilityTimeoutSeconds) * time.Second)
+	retry := info.GetRetryPolicy()
+	events := make([]messageEvent, 0, limit)
 
 	for rows.Next() {
-		var m v1.ReceiveMessage
+		var (
+			m           v1.ReceiveMessage
+			sentAt      string
+			firstRecvAt sql.NullString
+			attrs       sql.NullString
+		)
 
-		if err := rows.Scan(&m.Id, &m.Body); err != nil {
+		if err := rows.Scan(&m.Id, &m.Body, &m.Attempts, &sentAt, &firstRecvAt, &attrs); err != nil {
 			return nil, fmt.Errorf("scan message record: %w", err)
 		}
 
-		if _, err := stmt.ExecContext(ctx, visibleAt, m.Id); err != nil {
+		if attrs.Valid {
+			if err := json.Unmarshal([]byte(attrs.String), &m.Attributes); err != nil {
+				return nil, fmt.Errorf("unmarshal message %q attributes: %w", m.GetId(), err)
+			}
+		}
+
+		m.SentAt = parseTimestamp(sentAt)
+
+		switch {
+		case firstRecvAt.Valid:
+			m.FirstReceivedAt = parseTimestamp(firstRecvAt.String)
+
+		case m.GetAttempts() == 0 && firstReceivedAt:
+			m.FirstReceivedAt = timestamppb.New(now)
+		}
+
+		// The retries counter is incremented by the update below,
+		// so the current receive counts as an attempt too.
+		m.Attempts++
+		m.ReceiptHandle = s.newReceiptHandle(queueID, m.GetId(), m.GetAttempts())
+
+		// Messages which haven't been deleted after the receive
+		// are redelivered later with every attempt.
+		msgVisibleAt := visibleAt.Add(retryBackoff(retry, m.GetAttempts()))
+
+		if _, err := stmt.ExecContext(ctx, msgVisibleAt, now, m.Id); err != nil {
 			return nil, fmt.Errorf("update message record: %w", err)
 		}
 
 		output.Messages = append(output.Messages, &m)
+
+		events = append(events, messageEvent{
+			MessageID: m.GetId(),
+			Kind:      v1.MessageEventKind_MESSAGE_EVENT_KIND_RECEIVED,
+			Attempts:  m.GetAttempts(),
+			VisibleAt: msgVisibleAt,
+		})
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate message records: %w", err)
+	}
+
+	if err := s.events.record(ctx, tx, queueID, events...); err != nil {
+		return nil, err
+	}
+
+	// The suggestion never exceeds the maximum batch size, so visible messages are counted up to it.
+	var visible uint64
+	if err := tx.QueryRowContext(ctx, queryCountVisibleMessagesUpTo(queueID), info.MaxReceiveAttempts, maxBatchSize).Scan(&visible); err != nil {
+		return nil, fmt.Errorf("count visible messages: %w", err)
+	}
+
+	output.SuggestedBatchSize = suggestBatchSize(visible)
+
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("commit transaction: %w", err)
 	}
 
+	s.observer.ReceiveRequests(queueID).Inc()
+
 	if len(output.Messages) == 0 {
 		s.observer.EmptyReceives(queueID).Inc()
 	}
@@ -547,18 +1507,28 @@ func (s *Storage) Receive(ctx context.Context, input *v1.ReceiveRequest) (_ *v1.
 	messagesCount := uint64(len(output.Messages))
 
 	s.observer.MessagesReceived(queueID).Add(messagesCount)
+	s.observer.MessagesVisible(queueID).Set(visible)
+	s.observer.MessagesInFlight(queueID).Add(messagesCount)
 
 	return &output, nil
 }
 
-func (s *Storage) Delete(ctx context.Context, input *v1.DeleteRequest) (_ *v1.DeleteResponse, sErr error) {
+func (s *Storage) Delete(ctx context.Context, input *v1.DeleteRequest) (*v1.DeleteResponse, error) {
+	return retryBusy(ctx, s.observer, opDelete, func() (*v1.DeleteResponse, error) { return s.deleteMessages(ctx, input) })
+}
+
+func (s *Storage) deleteMessages(ctx context.Context, input *v1.DeleteRequest) (_ *v1.DeleteResponse, sErr error) {
 	queueID := input.GetQueueId()
 
-	tx, txErr := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	tx, txErr := s.beginTx(ctx, opDelete, false)
 	if txErr != nil {
 		return nil, fmt.Errorf("begin transaction: %w", txErr)
 	}
 
+	total := len(input.GetMessageIds()) + len(input.GetReceiptHandles())
+
+	tx.span.SetAttributes(attribute.String("queue_id", queueID), attribute.Int("messages", total))
+
 	defer func() {
 		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
 			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
@@ -576,33 +1546,106 @@ func (s *Storage) Delete(ctx context.Context, input *v1.DeleteRequest) (_ *v1.De
 		}
 	}()
 
+	receivedStmt, prepareReceivedErr := tx.PrepareContext(ctx, queryDeleteReceivedMessage(queueID))
+	if prepareReceivedErr != nil {
+		return nil, fmt.Errorf("prepare statement: %w", prepareReceivedErr)
+	}
+
+	defer func() {
+		if err := receivedStmt.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close prepared statement: %w", err))
+		}
+	}()
+
 	output := v1.DeleteResponse{
-		Successful: make([]string, 0, len(input.MessageIds)),
+		Successful: make([]string, 0, total),
 		Failed:     make([]*v1.DeleteFailure, 0, 1),
 	}
 
-	for _, id := range input.GetMessageIds() {
-		if _, err := stmt.ExecContext(ctx, id); err != nil {
+	events := make([]messageEvent, 0, total)
+
+	for i, id := range input.GetMessageIds() {
+		if err := ctx.Err(); err != nil {
+			return nil, &pqerr.InterruptedError{
+				Operation: "delete",
+				Processed: i,
+				Total:     total,
+				Err:       err,
+			}
+		}
+
+		res, execErr := stmt.ExecContext(ctx, id)
+		if execErr != nil {
 			output.Failed = append(output.Failed, &v1.DeleteFailure{
 				MessageId: id,
+				Error:     fmt.Sprintf("delete message: %s", execErr),
 			})
 
 			continue
 		}
 
-		if xID, err := idkit.ParseXID(id); err == nil {
-			s.observer.TimeInQueue(queueID).Dur(xID.Time())
-		} else {
-			// The fact that queue contains messages with invalid ID format
-			// means that something is really wrong with the queue. Looks like
-			// someone has modified the storage manually.
-			panic(fmt.Errorf(
-				"queue (id: %q) contains messages with invalid id (id: %q): %s",
-				queueID, id, err.Error(),
-			))
+		s.observeDeleted(queueID, id)
+
+		output.Successful = append(output.Successful, id)
+
+		if rows, err := res.RowsAffected(); err == nil && rows > 0 {
+			events = append(events, messageEvent{MessageID: id, Kind: v1.MessageEventKind_MESSAGE_EVENT_KIND_DELETED})
 		}
+	}
+
+	for i, handle := range input.GetReceiptHandles() {
+		if err := ctx.Err(); err != nil {
+			return nil, &pqerr.InterruptedError{
+				Operation: "delete",
+				Processed: len(input.GetMessageIds()) + i,
+				Total:     total,
+				Err:       err,
+			}
+		}
+
+		id, receive, parseErr := s.parseReceiptHandle(queueID, handle)
+		if parseErr != nil {
+			output.Failed = append(output.Failed, &v1.DeleteFailure{
+				ReceiptHandle: handle,
+				Error:         parseErr.Error(),
+			})
+
+			continue
+		}
+
+		res, execErr := receivedStmt.ExecContext(ctx, id, receive)
+		if execErr != nil {
+			output.Failed = append(output.Failed, &v1.DeleteFailure{
+				MessageId:     id,
+				ReceiptHandle: handle,
+				Error:         fmt.Sprintf("delete message: %s", execErr),
+			})
+
+			continue
+		}
+
+		// The message has been deleted already, received again or has never been received
+		// with this handle, or its visibility timeout has expired, so another consumer
+		// may be processing it now.
+		if rows, err := res.RowsAffected(); err != nil || rows < 1 {
+			output.Failed = append(output.Failed, &v1.DeleteFailure{
+				MessageId:     id,
+				ReceiptHandle: handle,
+				Error:         "receipt handle has expired",
+			})
+
+			continue
+		}
+
+		s.observeDeleted(queueID, id)
 
 		output.Successful = append(output.Successful, id)
+
+		events = append(events, messageEvent{MessageID: id, Kind: v1.MessageEventKind_MESSAGE_EVENT_KIND_DELETED, Attempts: receive})
+	}
+
+	if err := s.events.record(ctx, tx, queueID, events...); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -612,10 +1655,252 @@ func (s *Storage) Delete(ctx context.Context, input *v1.DeleteRequest) (_ *v1.De
 	messagesCount := uint64(len(output.Successful))
 
 	s.observer.MessagesDeleted(queueID).Add(messagesCount)
+	subGauge(s.observer.MessagesInFlight(queueID), messagesCount)
+
+	return &output, nil
+}
+
+// observeDeleted records the time the deleted message has spent in the queue.
+func (s *Storage) observeDeleted(queueID, messageID string) {
+	// Message identifiers are ULIDs, which hold the time the message has been sent.
+	if msgID, err := ulid.Parse(messageID); err == nil {
+		s.observer.TimeInQueue(queueID).Dur(ulid.Time(msgID.Time()))
+	}
+}
+
+func (s *Storage) ChangeVisibility(ctx context.Context, input *v1.ChangeVisibilityRequest) (*v1.ChangeVisibilityResponse, error) {
+	queueID := input.GetQueueId()
+
+	if input.GetMessageId() == "" && input.GetReceiptHandle() == "" {
+		return nil, fmt.Errorf("%w: message id and receipt handle are empty", errkit.ErrInvalidArgument)
+	}
+
+	visibleAt := time.Now().UTC().Add(time.Duration(input.GetVisibilityTimeoutSeconds()) * time.Second)
+
+	var (
+		res      sql.Result
+		execErr  error
+		attempts uint32
+	)
+
+	messageID := input.GetMessageId()
+
+	if input.GetReceiptHandle() != "" {
+		id, receive, parseErr := s.parseReceiptHandle(queueID, input.GetReceiptHandle())
+		if parseErr != nil {
+			return nil, parseErr
+		}
+
+		if messageID != "" && messageID != id {
+			return nil, fmt.Errorf("%w: receipt handle doesn't belong to message %q", errkit.ErrInvalidArgument, messageID)
+		}
+
+		messageID = id
+		attempts = receive
+		res, execErr = s.db.ExecContext(ctx, queryChangeReceivedVisibility(queueID), visibleAt, messageID, receive)
+	} else {
+		res, execErr = s.db.ExecContext(ctx, queryChangeVisibility(queueID), visibleAt, messageID)
+	}
+
+	if execErr != nil {
+		return nil, fmt.Errorf("change message %q visibility: %w", messageID, execErr)
+	}
+
+	rows, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return nil, fmt.Errorf("change message %q visibility: %w", messageID, rowsErr)
+	}
+
+	if rows < 1 {
+		if input.GetReceiptHandle() != "" {
+			return nil, fmt.Errorf("%w: receipt handle of message %q has expired", errkit.ErrNotFound, messageID)
+		}
+
+		return nil, fmt.Errorf("%w: message %q", errkit.ErrNotFound, messageID)
+	}
+
+	// The visibility has been changed already, so the event which
+	// failed to be recorded doesn't fail the change.
+	if err := s.events.record(ctx, s.db, queueID, messageEvent{
+		MessageID: messageID,
+		Kind:      v1.MessageEventKind_MESSAGE_EVENT_KIND_VISIBILITY_CHANGED,
+		Attempts:  attempts,
+		VisibleAt: visibleAt,
+	}); err != nil {
+		s.logger.Warn("Failed to record message event",
+			slog.String("queue_id", queueID),
+			slog.String("message_id", messageID),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return &v1.ChangeVisibilityResponse{}, nil
+}
+
+func (s *Storage) RetriesDistribution(ctx context.Context, queueID string) (_ map[uint32]uint64, sErr error) {
+	if _, ok := s.cache.getByID(queueID); !ok {
+		if _, err := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID}); err != nil {
+			return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, err)
+		}
+	}
+
+	rows, queryErr := s.db.QueryContext(ctx, queryRetriesDistribution(queueID))
+	if queryErr != nil {
+		return nil, fmt.Errorf("select retries distribution: %w", queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	distribution := make(map[uint32]uint64)
+
+	for rows.Next() {
+		var (
+			retries uint32
+			count   uint64
+		)
+
+		if err := rows.Scan(&retries, &count); err != nil {
+			return nil, fmt.Errorf("scan retries distribution: %w", err)
+		}
+
+		distribution[retries] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate retries distribution: %w", err)
+	}
+
+	return distribution, nil
+}
+
+func (s *Storage) QueueStats(ctx context.Context, queueID string) (_ *v1.QueueStatsResponse, sErr error) {
+	if _, ok := s.cache.getByID(queueID); !ok {
+		if _, err := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID}); err != nil {
+			return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, err)
+		}
+	}
+
+	tx, txErr := s.beginTx(ctx, opQueueStats, true)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	output := v1.QueueStatsResponse{QueueId: queueID}
+
+	if err := tx.QueryRowContext(ctx, queryCountMessages(queueID)).Scan(&output.Depth); err != nil {
+		return nil, fmt.Errorf("count queue %q messages: %w", queueID, err)
+	}
+
+	if err := tx.QueryRowContext(ctx, queryCountInFlightMessages(queueID)).Scan(&output.InFlight); err != nil {
+		return nil, fmt.Errorf("count queue %q in-flight messages: %w", queueID, err)
+	}
+
+	if err := tx.QueryRowContext(ctx, queryOldestMessageAge(queueID)).Scan(&output.OldestMessageAgeSeconds); err != nil {
+		return nil, fmt.Errorf("get queue %q oldest message age: %w", queueID, err)
+	}
+
+	usage, usageErr := readUsage(ctx, tx.Tx, queueID)
+	if usageErr != nil {
+		return nil, fmt.Errorf("read queue %q usage: %w", queueID, usageErr)
+	}
+
+	output.Bytes = usage.Bytes
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
 
 	return &output, nil
 }
 
+// grantOwnerPermissions grants all permissions on the queue to the role named after
+// the owner, so the owner can use the queue without changes of roles by administrators.
+// The role is created when it doesn't exist.
+func grantOwnerPermissions(ctx context.Context, tx *sql.Tx, queueID, owner string) error {
+	if _, err := ensureRole(ctx, tx, owner); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, queryGrantOwnerPermissions, queueID, owner); err != nil {
+		return fmt.Errorf("grant queue %q permissions to owner %q: %w", queueID, owner, err)
+	}
+
+	return nil
+}
+
+// scanQueueProps scans a queue properties record into the v1.DescribeQueueResponse.
+func scanQueueProps(row interface{ Scan(dest ...any) error }) (*v1.DescribeQueueResponse, error) {
+	var (
+		output    v1.DescribeQueueResponse
+		createdAt time.Time
+		gcAt      time.Time
+		tags      string
+		retry     v1.RetryPolicy
+	)
+
+	if err := row.Scan(
+		&output.QueueId,
+		&output.QueueName,
+		&createdAt,
+		&gcAt,
+		&output.RetentionPeriodSeconds,
+		&output.VisibilityTimeoutSeconds,
+		&output.MaxReceiveAttempts,
+		&output.EvictionPolicy,
+		&output.DeadLetterQueueId,
+		&output.Version,
+		&output.Description,
+		&tags,
+		&output.Fifo,
+		&output.DelaySeconds,
+		&output.MaxMessageSizeBytes,
+		&output.Owner,
+		&output.SearchIndex,
+		&output.State,
+		&output.RateLimit,
+		&output.RateLimitBurst,
+		&output.MaxMessages,
+		&output.MaxBytes,
+		&output.QuotaPolicy,
+		&retry.InitialBackoffSeconds,
+		&retry.Multiplier,
+		&retry.MaxBackoffSeconds,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(tags), &output.Tags); err != nil {
+		return nil, fmt.Errorf("unmarshal queue tags: %w", err)
+	}
+
+	output.CreatedAt = timestamppb.New(createdAt)
+	output.RetryPolicy = retryPolicy(retry.InitialBackoffSeconds, retry.Multiplier, retry.MaxBackoffSeconds)
+
+	return &output, nil
+}
+
+// suggestBatchSize returns the batch size which allows consumers
+// to drain the visible messages with the fewest receives.
+// The lag of consumers isn't taken into account: messages consumers lag
+// behind on stay visible, so they are already counted by the depth.
+func suggestBatchSize(visible uint64) uint32 {
+	return uint32(max(min(visible, maxBatchSize), 1))
+}
+
+// QueuePropsVersion returns the version of queue properties. The version is
+// changed each time any queue is created, deleted or its properties are modified.
+func (s *Storage) QueuePropsVersion() uint64 { return s.cache.version() }
+
 // Health implements hc.HealthChecker interface.
 func (s *Storage) Health(ctx context.Context) error {
 	if err := s.db.PingContext(ctx); err != nil {
@@ -625,13 +1910,60 @@ func (s *Storage) Health(ctx context.Context) error {
 	return nil
 }
 
+// GCLastRun returns the time of the last garbage collection run,
+// which is zero when the garbage collection hasn't run yet.
+func (s *Storage) GCLastRun() time.Time {
+	if n := s.gcLastRun.Load(); n != 0 {
+		return time.Unix(0, n)
+	}
+
+	return time.Time{}
+}
+
+// WALSize returns the size of the write-ahead log file of the database in bytes,
+// which is zero when the database doesn't use the write-ahead log.
+func (s *Storage) WALSize(ctx context.Context) (int64, error) {
+	var path string
+
+	if err := s.db.QueryRowContext(ctx, querySelectDatabaseFile).Scan(&path); err != nil {
+		return 0, fmt.Errorf("select database file: %w", err)
+	}
+
+	return walSize(path)
+}
+
+// walSize returns the size of the write-ahead log file of the database file at the path,
+// which is zero when the database doesn't use the write-ahead log.
+func walSize(path string) (int64, error) {
+	// In-memory databases have no files.
+	if path == "" {
+		return 0, nil
+	}
+
+	info, statErr := os.Stat(path + "-wal")
+	if statErr != nil {
+		if errors.Is(statErr, fs.ErrNotExist) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("stat write-ahead log: %w", statErr)
+	}
+
+	return info.Size(), nil
+}
+
 func (s *Storage) Close() error {
 	s.stop()
+
+	// Jobs stop once the context they run with is canceled,
+	// so they are recorded as interrupted before the database is closed.
+	s.jobs.wait()
+
 	return nil
 }
 
-func (s *Storage) listQueues(ctx context.Context, query string, pageSize uint32) (_ []*v1.DescribeQueueResponse, sErr error) {
-	tx, txErr := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+func (s *Storage) listQueues(ctx context.Context, query string, args []any, pageSize uint32) (_ []*v1.DescribeQueueResponse, sErr error) {
+	tx, txErr := s.beginTx(ctx, opListQueues, false)
 	if txErr != nil {
 		return nil, fmt.Errorf("begin transaction: %w", txErr)
 	}
@@ -642,7 +1974,7 @@ func (s *Storage) listQueues(ctx context.Context, query string, pageSize uint32)
 		}
 	}()
 
-	rows, txQueryErr := s.db.QueryContext(ctx, query)
+	rows, txQueryErr := s.db.QueryContext(ctx, query, args...)
 	if txQueryErr != nil {
 		return nil, fmt.Errorf("execute query (query: %q): %w", query, txQueryErr)
 	}
@@ -656,35 +1988,18 @@ func (s *Storage) listQueues(ctx context.Context, query string, pageSize uint32)
 	queues := make([]*v1.DescribeQueueResponse, 0, pageSize)
 
 	for rows.Next() {
-		var (
-			info      v1.DescribeQueueResponse
-			createdAt time.Time
-			gcAt      time.Time
-		)
-
-		if err := rows.Scan(
-			&info.QueueId,
-			&info.QueueName,
-			&createdAt,
-			&gcAt,
-			&info.RetentionPeriodSeconds,
-			&info.VisibilityTimeoutSeconds,
-			&info.MaxReceiveAttempts,
-			&info.EvictionPolicy,
-			&info.DeadLetterQueueId,
-		); err != nil {
-			return nil, fmt.Errorf("row scan: %w", err)
+		info, scanErr := scanQueueProps(rows)
+		if scanErr != nil {
+			return nil, fmt.Errorf("row scan: %w", scanErr)
 		}
 
-		info.CreatedAt = timestamppb.New(createdAt)
-
 		// Default eviction policy is DROP.
 		// It should never happen, but we have to handle it anyway.
 		if info.EvictionPolicy == v1.EvictionPolicy_EVICTION_POLICY_UNSPECIFIED {
 			info.EvictionPolicy = v1.EvictionPolicy_EVICTION_POLICY_DROP
 		}
 
-		queues = append(queues, &info)
+		queues = append(queues, info)
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -694,6 +2009,22 @@ func (s *Storage) listQueues(ctx context.Context, query string, pageSize uint32)
 	return queues, nil
 }
 
+// fillQueueStats sets approximate statistics and the depth of queues
+// from depth gauges, so listing doesn't count messages of each queue.
+func (s *Storage) fillQueueStats(queues []*v1.DescribeQueueResponse) {
+	for _, q := range queues {
+		visible := s.observer.MessagesVisible(q.GetQueueId()).Get()
+		inFlight := s.observer.MessagesInFlight(q.GetQueueId()).Get()
+
+		q.Stats = &v1.ApproximateQueueStats{
+			Depth:    visible + inFlight,
+			InFlight: inFlight,
+		}
+
+		q.Depth = q.GetStats().GetDepth()
+	}
+}
+
 func (s *Storage) fillCache(ctx context.Context, cursor string) error {
 	s.logger.Debug("Listing queue to fill the cache")
 
@@ -705,18 +2036,10 @@ func (s *Storage) fillCache(ctx context.Context, cursor string) error {
 	}
 
 	for _, q := range queues.GetQueues() {
-		props := QueueProps{
-			ID:                       q.QueueId,
-			Name:                     q.QueueName,
-			CreatedAt:                q.CreatedAt.AsTime().UTC(),
-			RetentionPeriodSeconds:   q.RetentionPeriodSeconds,
-			VisibilityTimeoutSeconds: q.VisibilityTimeoutSeconds,
-			MaxReceiveAttempts:       q.MaxReceiveAttempts,
-			EvictionPolicy:           uint32(q.EvictionPolicy),
-			DeadLetterQueueID:        q.DeadLetterQueueId,
-		}
+		props := propsFromProto(q)
 
 		s.cache.put(props)
+		s.observer.QueueTags(props.ID, props.Tags)
 	}
 
 	if queues.HasMore {
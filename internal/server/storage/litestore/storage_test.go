@@ -1 +1,148 @@
 package litestore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+)
+
+func Test_suggestBatchSize(t *testing.T) {
+	tests := map[string]struct {
+		visible uint64
+		want    uint32
+	}{
+		"Empty":   {visible: 0, want: 1},
+		"Few":     {visible: 3, want: 3},
+		"Backlog": {visible: 100500, want: maxBatchSize},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, suggestBatchSize(tc.visible), tc.want)
+		})
+	}
+}
+
+func TestStorage_checkMessageSize(t *testing.T) {
+	type tcase struct {
+		server  uint64
+		queue   uint64
+		sizes   []int
+		wantErr *pqerr.MessageTooLargeError
+	}
+
+	tests := map[string]tcase{
+		"NoLimits":      {sizes: []int{1 << 20}},
+		"WithinLimits":  {server: 100, queue: 50, sizes: []int{50, 10}},
+		"ServerLimit":   {server: 100, sizes: []int{10, 101}, wantErr: &pqerr.MessageTooLargeError{Index: 1, Size: 101, Limit: 100, Scope: "server"}},
+		"QueueLimit":    {server: 100, queue: 50, sizes: []int{51}, wantErr: &pqerr.MessageTooLargeError{Size: 51, Limit: 50, Scope: "queue"}},
+		"LowerServer":   {server: 50, queue: 100, sizes: []int{51}, wantErr: &pqerr.MessageTooLargeError{Size: 51, Limit: 50, Scope: "server"}},
+		"OnlyQueue":     {queue: 50, sizes: []int{51}, wantErr: &pqerr.MessageTooLargeError{Size: 51, Limit: 50, Scope: "queue"}},
+		"EmptyMessages": {server: 1},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Counters are global, so each case counts its own queue.
+			queueID := idkit.XID()
+
+			s := Storage{observer: telemetry.NewObserver()}
+			s.SetMaxMessageSize(tc.server)
+
+			messages := make([]*v1.SendMessage, 0, len(tc.sizes))
+
+			for _, size := range tc.sizes {
+				messages = append(messages, &v1.SendMessage{Body: []byte(strings.Repeat("a", size))})
+			}
+
+			info := v1.DescribeQueueResponse{QueueId: queueID, MaxMessageSizeBytes: tc.queue}
+
+			err := s.checkMessageSize(&info, messages)
+			if tc.wantErr == nil {
+				td.CmpNoError(t, err)
+				return
+			}
+
+			td.CmpErrorIs(t, err, errkit.ErrInvalidArgument)
+
+			var tooLarge *pqerr.MessageTooLargeError
+
+			td.CmpTrue(t, errors.As(err, &tooLarge))
+			td.Cmp(t, tooLarge, tc.wantErr)
+			td.Cmp(t, s.observer.MessagesOversized(queueID, tc.wantErr.Scope).Get(), uint64(1))
+		})
+	}
+}
+
+func TestStorage_CreateQueue_searchIndex(t *testing.T) {
+	// The storage without FTS5 module rejects search indexes before touching the database.
+	s := Storage{observer: telemetry.NewObserver()}
+
+	_, err := s.CreateQueue(context.Background(), &v1.CreateQueueRequest{QueueName: "orders", SearchIndex: true})
+	td.CmpErrorIs(t, err, errkit.ErrInvalidArgument)
+}
+
+func Test_defaultQueueUpdate(t *testing.T) {
+	current := v1.DescribeQueueResponse{
+		QueueId:                  "CSGE6N05SHOB6TB8V5FG",
+		QueueName:                "orders",
+		RetentionPeriodSeconds:   3600,
+		VisibilityTimeoutSeconds: 30,
+		MaxReceiveAttempts:       5,
+		EvictionPolicy:           v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER,
+		DeadLetterQueueId:        "CSGE6N05SHOB6TB8V5G0",
+		RateLimit:                10,
+		RateLimitBurst:           20,
+		MaxMessages:              1000,
+		MaxBytes:                 1 << 20,
+		QuotaPolicy:              v1.QuotaPolicy_QUOTA_POLICY_REJECT,
+		Version:                  1,
+	}
+
+	tests := map[string]struct {
+		input *v1.UpdateQueueRequest
+		want  td.StructFields
+	}{
+		"Rename": {
+			input: &v1.UpdateQueueRequest{QueueName: "payments", Version: 1},
+			want: td.StructFields{
+				"QueueName":                "payments",
+				"RetentionPeriodSeconds":   uint64(3600),
+				"VisibilityTimeoutSeconds": uint64(30),
+				"MaxReceiveAttempts":       uint32(5),
+				"EvictionPolicy":           v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER,
+				"DeadLetterQueueId":        "CSGE6N05SHOB6TB8V5G0",
+				"RateLimit":                td.Ptr(uint32(10)),
+				"RateLimitBurst":           td.Ptr(uint32(20)),
+				"MaxMessages":              td.Ptr(uint64(1000)),
+				"MaxBytes":                 td.Ptr(uint64(1 << 20)),
+				"QuotaPolicy":              v1.QuotaPolicy_QUOTA_POLICY_REJECT,
+			},
+		},
+		"DeadLetterQueue": {
+			input: &v1.UpdateQueueRequest{DeadLetterQueueId: "CSGE6N05SHOB6TB8V5H0", Version: 1},
+			want: td.StructFields{
+				"QueueName":         "orders",
+				"DeadLetterQueueId": "CSGE6N05SHOB6TB8V5H0",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			defaultQueueUpdate(tc.input, &current)
+
+			tc.want["=*"] = td.Ignore()
+
+			td.Cmp(t, tc.input, td.Struct(&v1.UpdateQueueRequest{}, tc.want))
+		})
+	}
+}
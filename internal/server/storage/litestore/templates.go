@@ -0,0 +1,132 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/plainq/plainq/internal/server/rbac"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+)
+
+// SeedRoles creates the rbac.AdminRole and roles named after the templates unless they
+// exist, and keeps policies of the templates up to date. Policies are attached only to roles
+// which are created, so permissions of existing roles of the same names don't change.
+func (s *Storage) SeedRoles(ctx context.Context, templates []rbac.Template) (sErr error) {
+	tx, txErr := s.beginTx(ctx, opSeedRoles, false)
+	if txErr != nil {
+		return fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	if _, err := ensureRole(ctx, tx.Tx, rbac.AdminRole); err != nil {
+		return err
+	}
+
+	for _, t := range templates {
+		if err := upsertTemplatePolicy(ctx, tx.Tx, &t); err != nil {
+			return err
+		}
+
+		created, createErr := ensureRole(ctx, tx.Tx, t.Name)
+		if createErr != nil {
+			return createErr
+		}
+
+		if !created {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, queryAttachRolePolicy, t.Name, t.Policy().Name); err != nil {
+			return fmt.Errorf("attach policy of template %q: %w", t.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	s.grants.invalidate()
+
+	return nil
+}
+
+func (s *Storage) ApplyTemplate(ctx context.Context, role string, template *rbac.Template) (sErr error) {
+	tx, txErr := s.beginTx(ctx, opApplyTemplate, false)
+	if txErr != nil {
+		return fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	var exists bool
+
+	if err := tx.QueryRowContext(ctx, queryRoleExists, role).Scan(&exists); err != nil {
+		return fmt.Errorf("select role %q: %w", role, err)
+	}
+
+	if !exists {
+		return fmt.Errorf("%w: role %q not found", errkit.ErrNotFound, role)
+	}
+
+	if err := upsertTemplatePolicy(ctx, tx.Tx, template); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, queryAttachRolePolicy, role, template.Policy().Name); err != nil {
+		return fmt.Errorf("attach policy of template %q to role %q: %w", template.Name, role, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	s.grants.invalidate()
+
+	return nil
+}
+
+// ensureRole creates the role with the name unless it exists,
+// and reports whether it has been created.
+func ensureRole(ctx context.Context, tx *sql.Tx, name string) (bool, error) {
+	res, execErr := tx.ExecContext(ctx, queryEnsureRole, idkit.XID(), name, time.Now().UTC())
+	if execErr != nil {
+		return false, fmt.Errorf("create role %q: %w", name, execErr)
+	}
+
+	created, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return false, fmt.Errorf("create role %q: %w", name, rowsErr)
+	}
+
+	return created > 0, nil
+}
+
+// upsertTemplatePolicy creates the policy of the template or updates it to the template.
+func upsertTemplatePolicy(ctx context.Context, tx *sql.Tx, template *rbac.Template) error {
+	policy := template.Policy()
+
+	document, marshalErr := json.Marshal(policy.Rules)
+	if marshalErr != nil {
+		return fmt.Errorf("marshal policy of template %q: %w", template.Name, marshalErr)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryUpsertPolicy, idkit.XID(), policy.Name, string(document), time.Now().UTC()); err != nil {
+		return fmt.Errorf("upsert policy of template %q: %w", template.Name, err)
+	}
+
+	return nil
+}
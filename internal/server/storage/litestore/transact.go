@@ -0,0 +1,155 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func (s *Storage) Transact(ctx context.Context, input *v1.TransactRequest) (*v1.TransactResponse, error) {
+	return retryBusy(ctx, s.observer, opTransact, func() (*v1.TransactResponse, error) { return s.transact(ctx, input) })
+}
+
+func (s *Storage) transact(ctx context.Context, input *v1.TransactRequest) (_ *v1.TransactResponse, sErr error) {
+	infos := make([]*v1.DescribeQueueResponse, 0, len(input.GetSends()))
+
+	for _, send := range input.GetSends() {
+		info, describeErr := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: send.GetQueueId()})
+		if describeErr != nil {
+			return nil, fmt.Errorf("describe queue (id: %q): %w", send.GetQueueId(), describeErr)
+		}
+
+		if err := checkQueueState(send.GetQueueId(), info.GetState(), operationSend); err != nil {
+			return nil, err
+		}
+
+		if err := s.checkMessageSize(info, send.GetMessages()); err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, info)
+	}
+
+	for _, del := range input.GetDeletes() {
+		if _, err := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: del.GetQueueId()}); err != nil {
+			return nil, fmt.Errorf("describe queue (id: %q): %w", del.GetQueueId(), err)
+		}
+	}
+
+	tx, txErr := s.beginTx(ctx, opTransact, false)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	tx.span.SetAttributes(attribute.Int("sends", len(input.GetSends())), attribute.Int("deletes", len(input.GetDeletes())))
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	output := v1.TransactResponse{
+		Sends: make([]*v1.SendResponse, 0, len(input.GetSends())),
+	}
+
+	// Deletes go first, so messages deleted by expired receipt handles
+	// fail the transaction before anything is inserted.
+	deleted := make([][]string, 0, len(input.GetDeletes()))
+
+	for _, del := range input.GetDeletes() {
+		ids, deleteErr := s.deleteInTx(ctx, tx.Tx, del)
+		if deleteErr != nil {
+			return nil, deleteErr
+		}
+
+		events := make([]messageEvent, 0, len(ids))
+		for _, id := range ids {
+			events = append(events, messageEvent{MessageID: id, Kind: v1.MessageEventKind_MESSAGE_EVENT_KIND_DELETED})
+		}
+
+		if err := s.events.record(ctx, tx, del.GetQueueId(), events...); err != nil {
+			return nil, err
+		}
+
+		deleted = append(deleted, ids)
+		output.Deleted = append(output.Deleted, ids...)
+	}
+
+	sent := make([]*sentMessages, 0, len(input.GetSends()))
+
+	for i, send := range input.GetSends() {
+		result, insertErr := s.insertMessages(ctx, tx.Tx, infos[i], send.GetMessages())
+		if insertErr != nil {
+			return nil, fmt.Errorf("send to queue %q: %w", send.GetQueueId(), insertErr)
+		}
+
+		sent = append(sent, result)
+		output.Sends = append(output.Sends, &v1.SendResponse{MessageIds: result.ids})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	for i, del := range input.GetDeletes() {
+		for _, id := range deleted[i] {
+			s.observeDeleted(del.GetQueueId(), id)
+		}
+
+		s.observer.MessagesDeleted(del.GetQueueId()).Add(uint64(len(deleted[i])))
+		subGauge(s.observer.MessagesInFlight(del.GetQueueId()), uint64(len(deleted[i])))
+	}
+
+	for i, info := range infos {
+		s.observeSent(info, sent[i])
+	}
+
+	return &output, nil
+}
+
+// deleteInTx deletes messages from the queue in the transaction. Unlike the Delete,
+// which reports messages it failed to delete, it fails on the first message which
+// hasn't been deleted, so the transaction doesn't take effect partially.
+func (s *Storage) deleteInTx(ctx context.Context, tx *sql.Tx, input *v1.DeleteRequest) ([]string, error) {
+	queueID := input.GetQueueId()
+	deleted := make([]string, 0, len(input.GetMessageIds())+len(input.GetReceiptHandles()))
+
+	for _, id := range input.GetMessageIds() {
+		res, execErr := tx.ExecContext(ctx, queryDeleteMessage(queueID), id)
+		if execErr != nil {
+			return nil, fmt.Errorf("delete message %q from queue %q: %w", id, queueID, execErr)
+		}
+
+		if rows, err := res.RowsAffected(); err != nil || rows < 1 {
+			return nil, fmt.Errorf("%w: message %q of queue %q", errkit.ErrNotFound, id, queueID)
+		}
+
+		deleted = append(deleted, id)
+	}
+
+	for _, handle := range input.GetReceiptHandles() {
+		id, receive, parseErr := s.parseReceiptHandle(queueID, handle)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+
+		res, execErr := tx.ExecContext(ctx, queryDeleteReceivedMessage(queueID), id, receive)
+		if execErr != nil {
+			return nil, fmt.Errorf("delete message %q from queue %q: %w", id, queueID, execErr)
+		}
+
+		if rows, err := res.RowsAffected(); err != nil || rows < 1 {
+			return nil, fmt.Errorf("%w: receipt handle of message %q of queue %q has expired", errkit.ErrNotFound, id, queueID)
+		}
+
+		deleted = append(deleted, id)
+	}
+
+	return deleted, nil
+}
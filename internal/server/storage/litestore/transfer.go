@@ -0,0 +1,240 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// queueTransferTTL represents the time the receiving team has to accept the transfer.
+const queueTransferTTL = 7 * 24 * time.Hour
+
+func (s *Storage) TransferQueue(ctx context.Context, input *v1.TransferQueueRequest) (_ *v1.TransferQueueResponse, sErr error) {
+	queueID := input.GetQueueId()
+
+	switch {
+	case input.GetToOwner() == "":
+		return nil, fmt.Errorf("%w: receiving owner is empty", errkit.ErrInvalidArgument)
+
+	case input.GetRequestedBy() == "":
+		return nil, fmt.Errorf("%w: requester is empty", errkit.ErrInvalidArgument)
+
+	case input.GetToOwner() == input.GetFromOwner():
+		return nil, fmt.Errorf("%w: queue is already owned by %q", errkit.ErrInvalidArgument, input.GetToOwner())
+	}
+
+	current, describeErr := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID})
+	if describeErr != nil {
+		return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, describeErr)
+	}
+
+	if current.GetOwner() != input.GetFromOwner() {
+		return nil, fmt.Errorf("%w: queue %q is owned by %q, not by %q",
+			errkit.ErrInvalidArgument, queueID, current.GetOwner(), input.GetFromOwner(),
+		)
+	}
+
+	tx, txErr := s.beginTx(ctx, opTransfer, false)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	now := time.Now().UTC()
+
+	transfer := v1.QueueTransfer{
+		TransferId:  idkit.XID(),
+		QueueId:     queueID,
+		FromOwner:   input.GetFromOwner(),
+		ToOwner:     input.GetToOwner(),
+		RequestedBy: input.GetRequestedBy(),
+		CreatedAt:   timestamppb.New(now),
+		ExpiresAt:   timestamppb.New(now.Add(queueTransferTTL)),
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeleteExpiredQueueTransfer, queueID, now); err != nil {
+		return nil, fmt.Errorf("delete expired transfer of queue %q: %w", queueID, err)
+	}
+
+	var pending uint64
+	if err := tx.QueryRowContext(ctx, queryCountQueueTransfers, queueID).Scan(&pending); err != nil {
+		return nil, fmt.Errorf("count transfers of queue %q: %w", queueID, err)
+	}
+
+	if pending > 0 {
+		return nil, fmt.Errorf("%w: queue %q has a pending transfer", errkit.ErrAlreadyExists, queueID)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryInsertQueueTransfer,
+		transfer.TransferId,
+		transfer.QueueId,
+		transfer.FromOwner,
+		transfer.ToOwner,
+		transfer.RequestedBy,
+		now,
+		now.Add(queueTransferTTL),
+	); err != nil {
+		return nil, fmt.Errorf("create transfer of queue %q: %w", queueID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	return &v1.TransferQueueResponse{Transfer: &transfer}, nil
+}
+
+func (s *Storage) AcceptQueueTransfer(ctx context.Context, input *v1.AcceptQueueTransferRequest) (_ *v1.AcceptQueueTransferResponse, sErr error) {
+	tx, txErr := s.beginTx(ctx, opTransfer, false)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	transfer, selectErr := selectQueueTransfer(ctx, tx, input.GetTransferId())
+	if selectErr != nil {
+		return nil, selectErr
+	}
+
+	queueID := transfer.GetQueueId()
+
+	switch {
+	case transfer.GetExpiresAt().AsTime().Before(time.Now()):
+		return nil, fmt.Errorf("%w: transfer %q has expired", errkit.ErrNotFound, transfer.GetTransferId())
+
+	case input.GetToOwner() != transfer.GetToOwner():
+		return nil, fmt.Errorf("%w: transfer %q is addressed to %q",
+			errkit.ErrUnauthorized, transfer.GetTransferId(), transfer.GetToOwner(),
+		)
+
+	case input.GetAcceptedBy() == "" || input.GetAcceptedBy() == transfer.GetRequestedBy():
+		return nil, fmt.Errorf("%w: transfer %q should be accepted by someone other than the requester",
+			errkit.ErrUnauthorized, transfer.GetTransferId(),
+		)
+	}
+
+	res, execErr := tx.ExecContext(ctx, queryUpdateQueueOwner, transfer.GetToOwner(), queueID, transfer.GetFromOwner())
+	if execErr != nil {
+		return nil, fmt.Errorf("update queue %q owner: %w", queueID, execErr)
+	}
+
+	rows, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return nil, fmt.Errorf("update queue %q owner: %w", queueID, rowsErr)
+	}
+
+	// The owner has been changed by another operation since the transfer was requested.
+	if rows < 1 {
+		return nil, fmt.Errorf("%w: queue %q is not owned by %q anymore",
+			pqerr.ErrConflict, queueID, transfer.GetFromOwner(),
+		)
+	}
+
+	// Permissions of the previous owner move to the role of the new one, which
+	// is created when the queue is transferred to the owner the first time.
+	if _, err := ensureRole(ctx, tx.Tx, transfer.GetToOwner()); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, queryMoveQueuePermissions,
+		transfer.GetToOwner(),
+		queueID,
+		transfer.GetFromOwner(),
+		transfer.GetToOwner(),
+	); err != nil {
+		return nil, fmt.Errorf("move queue %q permissions: %w", queueID, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeleteQueueTransfer, transfer.GetTransferId()); err != nil {
+		return nil, fmt.Errorf("delete transfer %q: %w", transfer.GetTransferId(), err)
+	}
+
+	var version uint64
+	if err := tx.QueryRowContext(ctx, querySelectQueuePropVersion, queueID).Scan(&version); err != nil {
+		return nil, fmt.Errorf("select queue %q properties version: %w", queueID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	s.grants.invalidate()
+
+	if props, ok := s.cache.getByID(queueID); ok {
+		props.Owner = transfer.GetToOwner()
+		props.Version = version
+
+		s.cache.put(props)
+	}
+
+	output := v1.AcceptQueueTransferResponse{
+		QueueId: queueID,
+		Version: version,
+	}
+
+	return &output, nil
+}
+
+func (s *Storage) CancelQueueTransfer(ctx context.Context, input *v1.CancelQueueTransferRequest) (*v1.QueueTransfer, error) {
+	transfer, selectErr := selectQueueTransfer(ctx, s.db, input.GetTransferId())
+	if selectErr != nil {
+		return nil, selectErr
+	}
+
+	if _, err := s.db.ExecContext(ctx, queryDeleteQueueTransfer, transfer.GetTransferId()); err != nil {
+		return nil, fmt.Errorf("delete transfer %q: %w", transfer.GetTransferId(), err)
+	}
+
+	return transfer, nil
+}
+
+// selectQueueTransfer returns the transfer with given identifier.
+func selectQueueTransfer(ctx context.Context, db interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}, transferID string,
+) (*v1.QueueTransfer, error) {
+	var (
+		transfer  v1.QueueTransfer
+		createdAt time.Time
+		expiresAt time.Time
+	)
+
+	if err := db.QueryRowContext(ctx, querySelectQueueTransfer, transferID).Scan(
+		&transfer.TransferId,
+		&transfer.QueueId,
+		&transfer.FromOwner,
+		&transfer.ToOwner,
+		&transfer.RequestedBy,
+		&createdAt,
+		&expiresAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: transfer %q", errkit.ErrNotFound, transferID)
+		}
+
+		return nil, fmt.Errorf("select transfer %q: %w", transferID, err)
+	}
+
+	transfer.CreatedAt = timestamppb.New(createdAt)
+	transfer.ExpiresAt = timestamppb.New(expiresAt)
+
+	return &transfer, nil
+}
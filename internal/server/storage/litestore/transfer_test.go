@@ -0,0 +1,106 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/servekit/idkit"
+)
+
+func Test_ownerPermissions(t *testing.T) {
+	ctx := context.Background()
+
+	db, openErr := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "plainq.db")+"?_journal=WAL")
+	td.Require(t).CmpNoError(openErr)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	// Roles and permissions of queues, as they're created by mutations.
+	_, createErr := db.Exec(`
+		create table roles (
+			role_id    varchar(26)                         not null primary key,
+			role_name  text                                not null unique,
+			created_at timestamp default current_timestamp not null
+		);
+
+		create table queue_permissions (
+			queue_id    varchar(26)                         not null,
+			role_id     varchar(26)                         not null,
+			can_send    boolean   default false             not null,
+			can_receive boolean   default false             not null,
+			can_purge   boolean   default false             not null,
+			can_delete  boolean   default false             not null,
+			can_update  boolean   default false             not null,
+			created_at  timestamp default current_timestamp not null,
+			updated_at  timestamp default current_timestamp not null,
+
+			primary key (queue_id, role_id)
+		);
+	`)
+	td.Require(t).CmpNoError(createErr)
+
+	queueID := idkit.XID()
+
+	// grantsOf returns names of roles which have permissions on the queue.
+	grantsOf := func() []string {
+		t.Helper()
+
+		rows, queryErr := db.QueryContext(ctx, `select r.role_name from queue_permissions p
+			join roles r on r.role_id = p.role_id
+			where p.queue_id = ? and p.can_send and p.can_receive and p.can_purge and p.can_delete and p.can_update;`, queueID)
+		td.Require(t).CmpNoError(queryErr)
+
+		defer func() { _ = rows.Close() }()
+
+		var roles []string
+
+		for rows.Next() {
+			var role string
+
+			td.Require(t).CmpNoError(rows.Scan(&role))
+
+			roles = append(roles, role)
+		}
+
+		td.Require(t).CmpNoError(rows.Err())
+
+		return roles
+	}
+
+	// inTx runs f in the transaction which is committed.
+	inTx := func(f func(tx *sql.Tx) error) {
+		t.Helper()
+
+		tx, txErr := db.BeginTx(ctx, nil)
+		td.Require(t).CmpNoError(txErr)
+
+		td.Require(t).CmpNoError(f(tx))
+		td.Require(t).CmpNoError(tx.Commit())
+	}
+
+	// The creator owns the queue.
+	inTx(func(tx *sql.Tx) error { return grantOwnerPermissions(ctx, tx, queueID, "alice@example.com") })
+	td.Cmp(t, grantsOf(), []string{"alice@example.com"})
+
+	// Permissions of the creator move to the new owner along with the ownership,
+	// the way AcceptQueueTransfer moves them.
+	inTx(func(tx *sql.Tx) error {
+		if _, err := ensureRole(ctx, tx, "payments"); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, queryMoveQueuePermissions, "payments", queueID, "alice@example.com", "payments")
+
+		return err
+	})
+	td.Cmp(t, grantsOf(), []string{"payments"})
+
+	var left int
+
+	td.Require(t).CmpNoError(db.QueryRowContext(ctx, `select count(*) from queue_permissions where queue_id = ?;`, queueID).Scan(&left))
+	td.Cmp(t, left, 1, "the creator keeps no permissions on the transferred queue")
+}
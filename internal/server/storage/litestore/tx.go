@@ -0,0 +1,194 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records spans of storage operations. Spans are dropped until tracing is started.
+var tracer = otel.Tracer("github.com/plainq/plainq/internal/server/storage/litestore")
+
+// Operations of storage transactions. Operations are used as metric labels.
+const (
+	opCreateQueue   = "create_queue"
+	opDescribeQueue = "describe_queue"
+	opUpdateQueue   = "update_queue"
+	opPurgeQueue    = "purge_queue"
+	opDeleteQueue   = "delete_queue"
+	opSetQueueState = "set_queue_state"
+	opSend          = "send"
+	opReceive       = "receive"
+	opDelete        = "delete"
+	opQueueStats    = "queue_stats"
+	opListQueues    = "list_queues"
+	opTransfer      = "transfer"
+	opGC            = "gc"
+	opEnsureUsage   = "ensure_usage"
+	opEvolveQueue   = "evolve_queue"
+	opQueueDepth    = "queue_depth"
+	opTransact      = "transact"
+
+	opRestoreArchived  = "restore_archived"
+	opExtendVisibility = "extend_visibility"
+
+	opCreateServiceAccount = "create_service_account"
+	opDeleteServiceAccount = "delete_service_account"
+	opSyncUserRoles        = "sync_user_roles"
+	opCreateSCIMUser       = "create_scim_user"
+	opUpdateSCIMUser       = "update_scim_user"
+	opDeleteSCIMUser       = "delete_scim_user"
+	opCreateSCIMGroup      = "create_scim_group"
+	opUpdateSCIMGroup      = "update_scim_group"
+	opDeleteSCIMGroup      = "delete_scim_group"
+	opCreateResetCode      = "create_reset_code"
+	opResetPassword        = "reset_password"
+	opCreateVerifyCode     = "create_verify_code"
+	opVerifyEmail          = "verify_email"
+	opUpdateAccount        = "update_account"
+	opSeedRoles            = "seed_roles"
+	opApplyTemplate        = "apply_template"
+	opAssignRole           = "assign_role"
+)
+
+const (
+	// maxBusyAttempts limits the number of attempts of operations which fail because the database is busy.
+	maxBusyAttempts = 3
+
+	// busyRetryBackoff represents the delay before the first retry, which doubles on each next one.
+	busyRetryBackoff = 10 * time.Millisecond
+)
+
+// observedTx is the transaction which measures its duration and traces it as a span.
+type observedTx struct {
+	*sql.Tx
+
+	start    time.Time
+	duration telemetry.Histogram
+	span     trace.Span
+}
+
+// beginTx starts the serializable transaction of the operation.
+func (s *Storage) beginTx(ctx context.Context, op string, readOnly bool) (*observedTx, error) {
+	ctx, span := tracer.Start(ctx, "litestore."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemSqlite,
+			semconv.DBOperationName(op),
+			attribute.Bool("db.read_only", readOnly),
+		),
+	)
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: readOnly})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "begin transaction")
+		span.End()
+
+		return nil, err
+	}
+
+	otx := observedTx{
+		Tx:       tx,
+		start:    time.Now(),
+		duration: s.observer.StorageTxDuration(op),
+		span:     span,
+	}
+
+	return &otx, nil
+}
+
+// Commit commits the transaction, measures its duration and ends its span.
+func (t *observedTx) Commit() error {
+	err := t.Tx.Commit()
+	if !errors.Is(err, sql.ErrTxDone) {
+		t.duration.Dur(t.start)
+
+		if err != nil {
+			t.span.RecordError(err)
+			t.span.SetStatus(codes.Error, "commit transaction")
+		}
+
+		t.span.End()
+	}
+
+	return err
+}
+
+// Rollback aborts the transaction, measures its duration and ends its span,
+// unless the transaction has been already committed or aborted.
+func (t *observedTx) Rollback() error {
+	err := t.Tx.Rollback()
+	if !errors.Is(err, sql.ErrTxDone) {
+		t.duration.Dur(t.start)
+
+		if err != nil {
+			t.span.RecordError(err)
+		}
+
+		// Transactions are only aborted when operations fail.
+		t.span.SetStatus(codes.Error, "transaction rolled back")
+		t.span.End()
+	}
+
+	return err
+}
+
+// retryBusy calls fn again when it fails because the database is busy, since failed transactions
+// are rolled back and have no effects. The busy timeout of the connection has already expired by
+// then, unless another transaction changed the data the failed one read, which is a conflict.
+func retryBusy[T any](ctx context.Context, observer telemetry.Observer, op string, fn func() (T, error)) (T, error) {
+	backoff := busyRetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		out, err := fn()
+
+		busy, conflict := busyError(err)
+		if conflict {
+			observer.StorageConflicts(op).Inc()
+		}
+
+		if !busy || attempt == maxBusyAttempts {
+			return out, err
+		}
+
+		observer.StorageBusyRetries(op).Inc()
+
+		trace.SpanFromContext(ctx).AddEvent("storage busy, retrying", trace.WithAttributes(
+			semconv.DBOperationName(op),
+			attribute.Int("attempt", attempt),
+			attribute.Bool("conflict", conflict),
+		))
+
+		select {
+		case <-ctx.Done():
+			return out, err
+
+		case <-time.After(backoff):
+			backoff *= 2
+		}
+	}
+}
+
+// busyError reports whether the err is caused by the busy or locked database,
+// and whether it's the conflict with another transaction in particular.
+func busyError(err error) (busy, conflict bool) {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false, false
+	}
+
+	busy = sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	conflict = sqliteErr.ExtendedCode == sqlite3.ErrBusySnapshot
+
+	return busy, conflict
+}
@@ -0,0 +1,100 @@
+package litestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/server/telemetry"
+	"github.com/plainq/servekit/idkit"
+)
+
+var (
+	errBusy     = sqlite3.Error{Code: sqlite3.ErrBusy, ExtendedCode: sqlite3.ErrBusy.Extend(0)}
+	errSnapshot = sqlite3.Error{Code: sqlite3.ErrBusy, ExtendedCode: sqlite3.ErrBusySnapshot}
+	errLocked   = sqlite3.Error{Code: sqlite3.ErrLocked}
+)
+
+func Test_busyError(t *testing.T) {
+	tests := map[string]struct {
+		err          error
+		wantBusy     bool
+		wantConflict bool
+	}{
+		"Nil":      {err: nil},
+		"Other":    {err: errors.New("disk I/O error")},
+		"Busy":     {err: errBusy, wantBusy: true},
+		"Locked":   {err: errLocked, wantBusy: true},
+		"Snapshot": {err: errSnapshot, wantBusy: true, wantConflict: true},
+		"Wrapped":  {err: fmt.Errorf("commit transaction: %w", errSnapshot), wantBusy: true, wantConflict: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			busy, conflict := busyError(tc.err)
+			td.Cmp(t, busy, tc.wantBusy)
+			td.Cmp(t, conflict, tc.wantConflict)
+		})
+	}
+}
+
+func Test_retryBusy(t *testing.T) {
+	type tcase struct {
+		errs          []error
+		wantCalls     int
+		wantErr       bool
+		wantRetries   uint64
+		wantConflicts uint64
+	}
+
+	tests := map[string]tcase{
+		"OK":           {errs: []error{nil}, wantCalls: 1},
+		"NotBusy":      {errs: []error{errors.New("fail")}, wantCalls: 1, wantErr: true},
+		"BusyOnce":     {errs: []error{errBusy, nil}, wantCalls: 2, wantRetries: 1},
+		"ConflictOnce": {errs: []error{errSnapshot, nil}, wantCalls: 2, wantRetries: 1, wantConflicts: 1},
+		"AlwaysBusy": {
+			errs:        []error{errBusy, errBusy, errBusy, errBusy},
+			wantCalls:   maxBusyAttempts,
+			wantErr:     true,
+			wantRetries: maxBusyAttempts - 1,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Counters are global, so each case counts its own operation.
+			op := "test_" + idkit.XID()
+			observer := telemetry.NewObserver()
+
+			var calls int
+
+			_, err := retryBusy(context.Background(), observer, op, func() (struct{}, error) {
+				calls++
+				return struct{}{}, tc.errs[calls-1]
+			})
+
+			td.Cmp(t, err != nil, tc.wantErr)
+			td.Cmp(t, calls, tc.wantCalls)
+			td.Cmp(t, observer.StorageBusyRetries(op).Get(), tc.wantRetries)
+			td.Cmp(t, observer.StorageConflicts(op).Get(), tc.wantConflicts)
+		})
+	}
+}
+
+func Test_retryBusy_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var calls int
+
+	_, err := retryBusy(ctx, telemetry.NewObserver(), "test_"+idkit.XID(), func() (struct{}, error) {
+		calls++
+		return struct{}{}, errBusy
+	})
+
+	td.CmpErrorIs(t, err, errBusy)
+	td.Cmp(t, calls, 1)
+}
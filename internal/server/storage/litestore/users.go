@@ -0,0 +1,257 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+)
+
+func (s *Storage) UserPasswordHash(ctx context.Context, email string) (string, error) {
+	var hash string
+
+	if err := s.db.QueryRowContext(ctx, querySelectUserPassword, email).Scan(&hash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: user %q", errkit.ErrNotFound, email)
+		}
+
+		return "", fmt.Errorf("select password of user %q: %w", email, err)
+	}
+
+	return hash, nil
+}
+
+func (s *Storage) SyncUserRoles(ctx context.Context, email string, roles []string) (sErr error) {
+	tx, txErr := s.beginTx(ctx, opSyncUserRoles, false)
+	if txErr != nil {
+		return fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, queryInsertExternalUser, idkit.XID(), email); err != nil {
+		return fmt.Errorf("insert user %q: %w", email, err)
+	}
+
+	var userID string
+
+	if err := tx.QueryRowContext(ctx, querySelectUserID, email).Scan(&userID); err != nil {
+		return fmt.Errorf("select user %q: %w", email, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeleteUserRoles, userID); err != nil {
+		return fmt.Errorf("delete roles of user %q: %w", email, err)
+	}
+
+	for _, role := range roles {
+		if _, err := tx.ExecContext(ctx, queryInsertUserRole, userID, role); err != nil {
+			return fmt.Errorf("grant role %q to user %q: %w", role, email, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	s.grants.invalidate()
+
+	return nil
+}
+
+func (s *Storage) CreatePasswordResetCode(ctx context.Context, email, hash string, expiresAt time.Time) (sErr error) {
+	tx, txErr := s.beginTx(ctx, opCreateResetCode, false)
+	if txErr != nil {
+		return fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	var userID string
+
+	if err := tx.QueryRowContext(ctx, querySelectLocalUserID, email).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: user %q", errkit.ErrNotFound, email)
+		}
+
+		return fmt.Errorf("select user %q: %w", email, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeletePasswordResetCodes, userID); err != nil {
+		return fmt.Errorf("delete password reset codes of user %q: %w", email, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryInsertPasswordResetCode, hash, userID, expiresAt.UTC(), time.Now().UTC()); err != nil {
+		return fmt.Errorf("insert password reset code of user %q: %w", email, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) PasswordResetCodeExpiresAt(ctx context.Context, email, hash string, now time.Time) (time.Time, error) {
+	var expiresAt time.Time
+
+	if err := s.db.QueryRowContext(ctx, querySelectPasswordResetCode, hash, email, now.UTC()).Scan(&expiresAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, fmt.Errorf("%w: password reset code of user %q", errkit.ErrNotFound, email)
+		}
+
+		return time.Time{}, fmt.Errorf("select password reset code of user %q: %w", email, err)
+	}
+
+	return expiresAt, nil
+}
+
+func (s *Storage) ResetPassword(ctx context.Context, email, hash, passwordHash string, now time.Time) (sErr error) {
+	tx, txErr := s.beginTx(ctx, opResetPassword, false)
+	if txErr != nil {
+		return fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	var userID string
+
+	if err := tx.QueryRowContext(ctx, queryUsePasswordResetCode, hash, email, now.UTC()).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: password reset code of user %q", errkit.ErrNotFound, email)
+		}
+
+		return fmt.Errorf("use password reset code of user %q: %w", email, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryUpdateUserPassword, passwordHash, now.UTC(), userID); err != nil {
+		return fmt.Errorf("update password of user %q: %w", email, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeletePasswordResetCodes, userID); err != nil {
+		return fmt.Errorf("delete password reset codes of user %q: %w", email, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) EmailVerificationCodeSentAt(ctx context.Context, email string) (time.Time, error) {
+	var sentAt time.Time
+
+	if err := s.db.QueryRowContext(ctx, querySelectEmailVerificationCodeSentAt, email).Scan(&sentAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil
+		}
+
+		return time.Time{}, fmt.Errorf("select email verification code of user %q: %w", email, err)
+	}
+
+	return sentAt, nil
+}
+
+func (s *Storage) CreateEmailVerificationCode(ctx context.Context, email, hash string, now, expiresAt time.Time) (sErr error) {
+	tx, txErr := s.beginTx(ctx, opCreateVerifyCode, false)
+	if txErr != nil {
+		return fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	var userID string
+
+	if err := tx.QueryRowContext(ctx, querySelectUnverifiedUserID, email).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: unverified user %q", errkit.ErrNotFound, email)
+		}
+
+		return fmt.Errorf("select user %q: %w", email, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeleteEmailVerificationCodes, userID); err != nil {
+		return fmt.Errorf("delete email verification codes of user %q: %w", email, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryInsertEmailVerificationCode, hash, userID, expiresAt.UTC(), now.UTC()); err != nil {
+		return fmt.Errorf("insert email verification code of user %q: %w", email, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) VerifyEmail(ctx context.Context, email, hash string, now time.Time) (sErr error) {
+	tx, txErr := s.beginTx(ctx, opVerifyEmail, false)
+	if txErr != nil {
+		return fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	var userID string
+
+	if err := tx.QueryRowContext(ctx, queryUseEmailVerificationCode, hash, email, now.UTC()).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: email verification code of user %q", errkit.ErrNotFound, email)
+		}
+
+		return fmt.Errorf("use email verification code of user %q: %w", email, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryUpdateUserVerified, now.UTC(), userID); err != nil {
+		return fmt.Errorf("update verification of user %q: %w", email, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeleteEmailVerificationCodes, userID); err != nil {
+		return fmt.Errorf("delete email verification codes of user %q: %w", email, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(fmtCommitTxError, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) UserEmailVerified(ctx context.Context, email string) (bool, error) {
+	var verified bool
+
+	if err := s.db.QueryRowContext(ctx, querySelectUserVerified, email).Scan(&verified); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("select verification of user %q: %w", email, err)
+	}
+
+	return verified, nil
+}
@@ -0,0 +1,318 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// autoVacuumIncremental is the auto-vacuum mode of databases which reclaim free pages by the incremental vacuum.
+const autoVacuumIncremental = 2
+
+// maintenanceOperations holds maintenance operations by names the window is configured with.
+var maintenanceOperations = map[string]v1.MaintenanceOperation{
+	"vacuum":             v1.MaintenanceOperation_MAINTENANCE_OPERATION_VACUUM,
+	"analyze":            v1.MaintenanceOperation_MAINTENANCE_OPERATION_ANALYZE,
+	"incremental-vacuum": v1.MaintenanceOperation_MAINTENANCE_OPERATION_INCREMENTAL_VACUUM,
+}
+
+// MaintenanceWindow is the daily window scheduled maintenance operations run in.
+type MaintenanceWindow struct {
+	// Start and End are offsets of the window from the midnight in UTC.
+	// The window which ends before it starts ends on the next day.
+	Start time.Duration
+	End   time.Duration
+
+	// Operations run in the window one after another.
+	Operations []v1.MaintenanceOperation
+}
+
+// ParseMaintenanceWindow parses the window like "02:00-04:00" in UTC, in which
+// comma-separated operations, e.g. "analyze,incremental-vacuum", run every day.
+func ParseMaintenanceWindow(window, operations string) (MaintenanceWindow, error) {
+	from, to, ok := strings.Cut(window, "-")
+	if !ok {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance window %q should be like 02:00-04:00", window)
+	}
+
+	start, startErr := parseTimeOfDay(from)
+	if startErr != nil {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance window %q: %w", window, startErr)
+	}
+
+	end, endErr := parseTimeOfDay(to)
+	if endErr != nil {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance window %q: %w", window, endErr)
+	}
+
+	w := MaintenanceWindow{Start: start, End: end}
+
+	for _, name := range strings.Split(operations, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		op, known := maintenanceOperations[name]
+		if !known {
+			return MaintenanceWindow{}, fmt.Errorf("unknown maintenance operation %q, should be vacuum, analyze or incremental-vacuum", name)
+		}
+
+		w.Operations = append(w.Operations, op)
+	}
+
+	if len(w.Operations) == 0 {
+		return MaintenanceWindow{}, errors.New("no maintenance operations to run in the window")
+	}
+
+	return w, nil
+}
+
+// parseTimeOfDay parses the time like "02:00" as the offset from the midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", strings.TrimSpace(s))
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// next returns the start and the end of the first window which starts after now.
+func (w MaintenanceWindow) next(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(w.Start)
+	if !start.After(now) {
+		start = start.AddDate(0, 0, 1)
+	}
+
+	length := w.End - w.Start
+	if length <= 0 {
+		length += 24 * time.Hour
+	}
+
+	return start, start.Add(length)
+}
+
+// WithMaintenanceWindow makes the Storage run maintenance operations
+// of the window every day, while the database can be changed.
+func WithMaintenanceWindow(window MaintenanceWindow) Option {
+	return func(o *Storage) { o.window = &window }
+}
+
+// RunMaintenance runs the maintenance operation of the database and reports the size of the
+// database before and after it. Operations wait for other maintenance, e.g. the garbage
+// collection, to yield, and the vacuum enables the incremental auto-vacuum of the database,
+// which the incremental vacuum requires.
+func (s *Storage) RunMaintenance(ctx context.Context, input *v1.RunMaintenanceRequest) (*v1.RunMaintenanceResponse, error) {
+	op := input.GetOperation()
+
+	if op == v1.MaintenanceOperation_MAINTENANCE_OPERATION_UNSPECIFIED || v1.MaintenanceOperation_name[int32(op)] == "" {
+		return nil, fmt.Errorf("%w: maintenance operation should be specified", errkit.ErrInvalidArgument)
+	}
+
+	if err := s.maintenance.acquire(ctx, true); err != nil {
+		return nil, fmt.Errorf("wait for maintenance: %w", err)
+	}
+
+	defer s.maintenance.release()
+
+	// The vacuum uses the auto-vacuum mode set by the connection it runs on.
+	conn, connErr := s.db.Conn(ctx)
+	if connErr != nil {
+		return nil, fmt.Errorf("get connection: %w", connErr)
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	before, beforeErr := databaseSize(ctx, conn)
+	if beforeErr != nil {
+		return nil, beforeErr
+	}
+
+	startedAt := time.Now()
+
+	if err := maintain(ctx, conn, op, input.GetPages()); err != nil {
+		return nil, err
+	}
+
+	after, afterErr := databaseSize(ctx, conn)
+	if afterErr != nil {
+		return nil, afterErr
+	}
+
+	finishedAt := time.Now()
+
+	s.logger.Info("Maintenance operation has completed",
+		slog.String("operation", op.String()),
+		slog.Uint64("size_before", before.GetSizeBytes()),
+		slog.Uint64("size_after", after.GetSizeBytes()),
+		slog.Uint64("free_before", before.GetFreeBytes()),
+		slog.Uint64("free_after", after.GetFreeBytes()),
+		slog.String("duration", finishedAt.Sub(startedAt).String()),
+	)
+
+	output := v1.RunMaintenanceResponse{
+		Operation:  op,
+		Before:     before,
+		After:      after,
+		StartedAt:  timestamppb.New(startedAt),
+		FinishedAt: timestamppb.New(finishedAt),
+	}
+
+	return &output, nil
+}
+
+// maintain runs the maintenance operation on the connection.
+func maintain(ctx context.Context, conn *sql.Conn, op v1.MaintenanceOperation, pages uint64) error {
+	switch op {
+	case v1.MaintenanceOperation_MAINTENANCE_OPERATION_ANALYZE:
+		if _, err := conn.ExecContext(ctx, queryAnalyze); err != nil {
+			return fmt.Errorf("analyze: %w", err)
+		}
+
+		return nil
+
+	case v1.MaintenanceOperation_MAINTENANCE_OPERATION_VACUUM:
+		if _, err := conn.ExecContext(ctx, queryEnableIncrementalVacuum); err != nil {
+			return fmt.Errorf("enable incremental auto-vacuum: %w", err)
+		}
+
+		if _, err := conn.ExecContext(ctx, queryVacuum); err != nil {
+			return fmt.Errorf("vacuum: %w", err)
+		}
+
+	case v1.MaintenanceOperation_MAINTENANCE_OPERATION_INCREMENTAL_VACUUM:
+		if err := incrementalVacuum(ctx, conn, pages); err != nil {
+			return err
+		}
+	}
+
+	if _, err := conn.ExecContext(ctx, queryCheckpointTruncate); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// incrementalVacuum reclaims up to the number of free pages, all of them when it's zero.
+func incrementalVacuum(ctx context.Context, conn *sql.Conn, pages uint64) (iErr error) {
+	var mode int
+
+	if err := conn.QueryRowContext(ctx, querySelectAutoVacuum).Scan(&mode); err != nil {
+		return fmt.Errorf("select auto-vacuum mode: %w", err)
+	}
+
+	if mode != autoVacuumIncremental {
+		return fmt.Errorf("%w: incremental vacuum requires the incremental auto-vacuum, which is enabled by the vacuum",
+			errkit.ErrInvalidArgument,
+		)
+	}
+
+	rows, queryErr := conn.QueryContext(ctx, fmt.Sprintf(queryIncrementalVacuum, min(pages, math.MaxInt32)))
+	if queryErr != nil {
+		return fmt.Errorf("incremental vacuum: %w", queryErr)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			iErr = errors.Join(iErr, fmt.Errorf("close rows: %w", err))
+		}
+	}()
+
+	// Each step of the statement reclaims a page.
+	for rows.Next() {
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("incremental vacuum: %w", err)
+	}
+
+	return nil
+}
+
+// databaseSize returns the size of the database, its free pages and the write-ahead log.
+func databaseSize(ctx context.Context, conn *sql.Conn) (*v1.StorageSize, error) {
+	var size, free int64
+
+	if err := conn.QueryRowContext(ctx, querySelectDatabaseSize).Scan(&size, &free); err != nil {
+		return nil, fmt.Errorf("select database size: %w", err)
+	}
+
+	var path string
+
+	if err := conn.QueryRowContext(ctx, querySelectDatabaseFile).Scan(&path); err != nil {
+		return nil, fmt.Errorf("select database file: %w", err)
+	}
+
+	wal, walErr := walSize(path)
+	if walErr != nil {
+		return nil, walErr
+	}
+
+	output := v1.StorageSize{
+		SizeBytes: uint64(max(size, 0)),
+		FreeBytes: uint64(max(free, 0)),
+		WalBytes:  uint64(max(wal, 0)),
+	}
+
+	return &output, nil
+}
+
+// maintainInWindow runs maintenance operations in the window every day until the context is canceled.
+func (s *Storage) maintainInWindow(ctx context.Context) {
+	for {
+		start, end := s.window.next(time.Now())
+
+		timer := time.NewTimer(time.Until(start))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+
+		case <-timer.C:
+		}
+
+		// The database of servers which follow or stand by is maintained by the leader.
+		if s.isLeader != nil && !s.isLeader() {
+			continue
+		}
+
+		s.maintainUntil(ctx, end)
+	}
+}
+
+// maintainUntil runs maintenance operations of the window one after another,
+// interrupting the operation which is still running at the end of the window.
+func (s *Storage) maintainUntil(ctx context.Context, end time.Time) {
+	ctx, cancel := context.WithDeadline(ctx, end)
+	defer cancel()
+
+	for _, op := range s.window.Operations {
+		if _, err := s.RunMaintenance(ctx, &v1.RunMaintenanceRequest{Operation: op}); err != nil {
+			if ctx.Err() != nil {
+				s.logger.Warn("Maintenance window has ended before operations have completed",
+					slog.String("operation", op.String()),
+				)
+
+				return
+			}
+
+			s.logger.Error("Failed to run maintenance operation",
+				slog.String("operation", op.String()),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+func TestParseMaintenanceWindow(t *testing.T) {
+	tests := map[string]struct {
+		window     string
+		operations string
+		want       MaintenanceWindow
+		wantErr    string
+	}{
+		"Valid": {
+			window:     "02:00-04:30",
+			operations: "analyze, incremental-vacuum,",
+			want: MaintenanceWindow{
+				Start: 2 * time.Hour,
+				End:   4*time.Hour + 30*time.Minute,
+				Operations: []v1.MaintenanceOperation{
+					v1.MaintenanceOperation_MAINTENANCE_OPERATION_ANALYZE,
+					v1.MaintenanceOperation_MAINTENANCE_OPERATION_INCREMENTAL_VACUUM,
+				},
+			},
+		},
+		"NoEnd": {
+			window:     "02:00",
+			operations: "vacuum",
+			wantErr:    `maintenance window "02:00" should be like 02:00-04:00`,
+		},
+		"InvalidTime": {
+			window:     "02:00-25:00",
+			operations: "vacuum",
+			wantErr:    `maintenance window "02:00-25:00": invalid time of day "25:00"`,
+		},
+		"UnknownOperation": {
+			window:     "02:00-04:00",
+			operations: "vacuum,reindex",
+			wantErr:    `unknown maintenance operation "reindex", should be vacuum, analyze or incremental-vacuum`,
+		},
+		"NoOperations": {
+			window:  "02:00-04:00",
+			wantErr: "no maintenance operations to run in the window",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			w, err := ParseMaintenanceWindow(tt.window, tt.operations)
+			if tt.wantErr != "" {
+				td.CmpString(t, err, tt.wantErr)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, w, tt.want)
+		})
+	}
+}
+
+func TestMaintenanceWindow_next(t *testing.T) {
+	day := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		window    MaintenanceWindow
+		now       time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		"Today": {
+			window:    MaintenanceWindow{Start: 2 * time.Hour, End: 4 * time.Hour},
+			now:       day.Add(time.Hour),
+			wantStart: day.Add(2 * time.Hour),
+			wantEnd:   day.Add(4 * time.Hour),
+		},
+		"Tomorrow": {
+			window:    MaintenanceWindow{Start: 2 * time.Hour, End: 4 * time.Hour},
+			now:       day.Add(2 * time.Hour),
+			wantStart: day.Add(26 * time.Hour),
+			wantEnd:   day.Add(28 * time.Hour),
+		},
+		"OverMidnight": {
+			window:    MaintenanceWindow{Start: 23 * time.Hour, End: time.Hour},
+			now:       day.Add(12 * time.Hour),
+			wantStart: day.Add(23 * time.Hour),
+			wantEnd:   day.Add(25 * time.Hour),
+		},
+		"OtherTimeZone": {
+			window:    MaintenanceWindow{Start: 2 * time.Hour, End: 4 * time.Hour},
+			now:       time.Date(2026, 10, 17, 3, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60)),
+			wantStart: day.Add(2 * time.Hour),
+			wantEnd:   day.Add(4 * time.Hour),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			start, end := tt.window.next(tt.now)
+
+			td.Cmp(t, start, tt.wantStart)
+			td.Cmp(t, end, tt.wantEnd)
+		})
+	}
+}
+
+func Test_maintain(t *testing.T) {
+	ctx := context.Background()
+
+	db, openErr := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "plainq.db")+"?_journal=WAL")
+	td.Require(t).CmpNoError(openErr)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	conn, connErr := db.Conn(ctx)
+	td.Require(t).CmpNoError(connErr)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	// fill inserts and deletes rows, which leaves free pages in the database.
+	fill := func() {
+		t.Helper()
+
+		_, err := conn.ExecContext(ctx, `insert into items (body)
+			with recursive n(i) as (select 1 union all select i + 1 from n where i < 100)
+			select randomblob(4000) from n;
+			delete from items;`)
+		td.Require(t).CmpNoError(err)
+	}
+
+	_, createErr := conn.ExecContext(ctx, `create table items (body blob);`)
+	td.Require(t).CmpNoError(createErr)
+
+	fill()
+
+	before, beforeErr := databaseSize(ctx, conn)
+	td.Require(t).CmpNoError(beforeErr)
+	td.Cmp(t, before.GetFreeBytes(), td.Gt(uint64(0)))
+	td.Cmp(t, before.GetWalBytes(), td.Gt(uint64(0)))
+
+	// The incremental vacuum requires the incremental auto-vacuum, which is enabled by the vacuum.
+	td.CmpString(t, maintain(ctx, conn, v1.MaintenanceOperation_MAINTENANCE_OPERATION_INCREMENTAL_VACUUM, 0),
+		"invalid argument: incremental vacuum requires the incremental auto-vacuum, which is enabled by the vacuum")
+
+	td.Require(t).CmpNoError(maintain(ctx, conn, v1.MaintenanceOperation_MAINTENANCE_OPERATION_VACUUM, 0))
+
+	vacuumed, vacuumedErr := databaseSize(ctx, conn)
+	td.Require(t).CmpNoError(vacuumedErr)
+	td.Cmp(t, vacuumed.GetFreeBytes(), uint64(0))
+	td.Cmp(t, vacuumed.GetWalBytes(), uint64(0))
+	td.Cmp(t, vacuumed.GetSizeBytes(), td.Lt(before.GetSizeBytes()))
+
+	fill()
+
+	filled, filledErr := databaseSize(ctx, conn)
+	td.Require(t).CmpNoError(filledErr)
+
+	// The incremental vacuum reclaims the given number of pages.
+	td.Require(t).CmpNoError(maintain(ctx, conn, v1.MaintenanceOperation_MAINTENANCE_OPERATION_INCREMENTAL_VACUUM, 10))
+
+	partial, partialErr := databaseSize(ctx, conn)
+	td.Require(t).CmpNoError(partialErr)
+	td.Cmp(t, filled.GetFreeBytes()-partial.GetFreeBytes(), uint64(10*4096))
+
+	td.Require(t).CmpNoError(maintain(ctx, conn, v1.MaintenanceOperation_MAINTENANCE_OPERATION_INCREMENTAL_VACUUM, 0))
+
+	reclaimed, reclaimedErr := databaseSize(ctx, conn)
+	td.Require(t).CmpNoError(reclaimedErr)
+	td.Cmp(t, reclaimed.GetFreeBytes(), uint64(0))
+
+	td.Require(t).CmpNoError(maintain(ctx, conn, v1.MaintenanceOperation_MAINTENANCE_OPERATION_ANALYZE, 0))
+
+	var stats int
+
+	td.CmpNoError(t, conn.QueryRowContext(ctx, `select count(*) from sqlite_master where name = 'sqlite_stat1';`).Scan(&stats))
+	td.Cmp(t, stats, 1)
+}
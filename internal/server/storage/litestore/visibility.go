@@ -0,0 +1,110 @@
+package litestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Storage) ExtendVisibilityBatch(ctx context.Context, input *v1.ExtendVisibilityBatchRequest) (*v1.ExtendVisibilityBatchResponse, error) {
+	return retryBusy(ctx, s.observer, opExtendVisibility, func() (*v1.ExtendVisibilityBatchResponse, error) {
+		return s.extendVisibilityBatch(ctx, input)
+	})
+}
+
+func (s *Storage) extendVisibilityBatch(ctx context.Context, input *v1.ExtendVisibilityBatchRequest) (_ *v1.ExtendVisibilityBatchResponse, sErr error) {
+	queueID := input.GetQueueId()
+
+	if _, ok := s.cache.getByID(queueID); !ok {
+		if _, err := s.DescribeQueue(ctx, &v1.DescribeQueueRequest{QueueId: queueID}); err != nil {
+			return nil, fmt.Errorf("describe queue (id: %q): %w", queueID, err)
+		}
+	}
+
+	tx, txErr := s.beginTx(ctx, opExtendVisibility, false)
+	if txErr != nil {
+		return nil, fmt.Errorf(fmtBeginTxError, txErr)
+	}
+
+	tx.span.SetAttributes(attribute.String("queue_id", queueID), attribute.Int("messages", len(input.GetEntries())))
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			sErr = errors.Join(sErr, fmt.Errorf("rollback transaction: %w", err))
+		}
+	}()
+
+	stmt, prepareErr := tx.PrepareContext(ctx, queryChangeReceivedVisibility(queueID))
+	if prepareErr != nil {
+		return nil, fmt.Errorf("prepare statement: %w", prepareErr)
+	}
+
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			sErr = errors.Join(sErr, fmt.Errorf("close prepared statement: %w", err))
+		}
+	}()
+
+	output := v1.ExtendVisibilityBatchResponse{
+		Results: make([]*v1.ExtendVisibilityResult, 0, len(input.GetEntries())),
+	}
+
+	events := make([]messageEvent, 0, len(input.GetEntries()))
+	now := time.Now().UTC()
+
+	for _, entry := range input.GetEntries() {
+		result := v1.ExtendVisibilityResult{
+			ReceiptHandle: entry.GetReceiptHandle(),
+		}
+
+		output.Results = append(output.Results, &result)
+
+		id, receive, parseErr := s.parseReceiptHandle(queueID, entry.GetReceiptHandle())
+		if parseErr != nil {
+			result.Error = parseErr.Error()
+			continue
+		}
+
+		result.MessageId = id
+
+		visibleAt := now.Add(time.Duration(entry.GetVisibilityTimeoutSeconds()) * time.Second)
+
+		res, execErr := stmt.ExecContext(ctx, visibleAt, id, receive)
+		if execErr != nil {
+			return nil, fmt.Errorf("change message %q visibility: %w", id, execErr)
+		}
+
+		// As with the ChangeVisibility, the message has been deleted, received again
+		// or its visibility timeout has expired, so it can't be extended anymore.
+		if rows, err := res.RowsAffected(); err != nil || rows < 1 {
+			result.Error = "receipt handle has expired"
+			continue
+		}
+
+		result.Success = true
+		result.VisibleAt = timestamppb.New(visibleAt)
+
+		events = append(events, messageEvent{
+			MessageID: id,
+			Kind:      v1.MessageEventKind_MESSAGE_EVENT_KIND_VISIBILITY_CHANGED,
+			Attempts:  receive,
+			VisibleAt: visibleAt,
+		})
+	}
+
+	if err := s.events.record(ctx, tx, queueID, events...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return &output, nil
+}
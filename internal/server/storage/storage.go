@@ -2,13 +2,18 @@ package storage
 
 import (
 	"context"
+	"time"
 
+	"github.com/plainq/plainq/internal/server/auth"
+	"github.com/plainq/plainq/internal/server/rbac"
 	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/plainq/internal/server/scim"
 )
 
 // Storage encapsulates interaction with queue storage.
 type Storage interface {
-	// CreateQueue creates new queue.
+	// CreateQueue creates new queue. The role named after the owner of the queue,
+	// which transports default to its creator, is granted all permissions on the queue.
 	CreateQueue(ctx context.Context, input *v1.CreateQueueRequest) (*v1.CreateQueueResponse, error)
 
 	// DescribeQueue returns information about specified queue.
@@ -17,10 +22,15 @@ type Storage interface {
 		input *v1.DescribeQueueRequest,
 	) (*v1.DescribeQueueResponse, error)
 
+	// UpdateQueue updates properties of the queue. The update is rejected with
+	// pqerr.ConflictError if the given version doesn't match the current one.
+	UpdateQueue(ctx context.Context, input *v1.UpdateQueueRequest) (*v1.UpdateQueueResponse, error)
+
 	// ListQueues returns a list of existing queues.
 	ListQueues(ctx context.Context, input *v1.ListQueuesRequest) (*v1.ListQueuesResponse, error)
 
-	// PurgeQueue purges all messages from the queue.
+	// PurgeQueue purges all messages from the queue, or the ones matching filters of the request.
+	// Asynchronous purges delete messages in batches by the background job, which is returned.
 	PurgeQueue(ctx context.Context, input *v1.PurgeQueueRequest) (*v1.PurgeQueueResponse, error)
 
 	// DeleteQueue deletes a queue if it's not empty. Also supports DeleteQueueInput.Force
@@ -35,4 +45,298 @@ type Storage interface {
 
 	// Delete delete messages from the queue.
 	Delete(ctx context.Context, input *v1.DeleteRequest) (*v1.DeleteResponse, error)
+
+	// ChangeVisibility changes visibility timeout of the received message.
+	ChangeVisibility(ctx context.Context, input *v1.ChangeVisibilityRequest) (*v1.ChangeVisibilityResponse, error)
+
+	// ExtendVisibilityBatch changes visibility timeouts of received messages by their receipt
+	// handles in a single transaction. Entries which can't be changed, e.g. the ones with expired
+	// handles, are reported as failed without failing the others.
+	ExtendVisibilityBatch(ctx context.Context, input *v1.ExtendVisibilityBatchRequest) (*v1.ExtendVisibilityBatchResponse, error)
+
+	// ListArchivedMessages returns messages evicted to the archive of the queue
+	// by the archive eviction policy.
+	ListArchivedMessages(ctx context.Context, input *v1.ListArchivedMessagesRequest) (*v1.ListArchivedMessagesResponse, error)
+
+	// RestoreArchivedMessages moves archived messages back to the queue,
+	// skipping messages which are not in the archive.
+	RestoreArchivedMessages(ctx context.Context, input *v1.RestoreArchivedMessagesRequest) (*v1.RestoreArchivedMessagesResponse, error)
+
+	// GetJob returns the background job along with its progress.
+	// Unknown jobs are rejected with errkit.ErrNotFound.
+	GetJob(ctx context.Context, input *v1.GetJobRequest) (*v1.GetJobResponse, error)
+
+	// ListJobs returns background jobs matching filters of the request, the most recent first.
+	ListJobs(ctx context.Context, input *v1.ListJobsRequest) (*v1.ListJobsResponse, error)
+
+	// CancelJob stops the running background job and returns it once it has stopped.
+	// Unknown jobs are rejected with errkit.ErrNotFound
+	// and finished jobs are rejected with pqerr.ErrConflict.
+	CancelJob(ctx context.Context, input *v1.CancelJobRequest) (*v1.CancelJobResponse, error)
+
+	// RunMaintenance runs the maintenance operation of the database, e.g. the vacuum,
+	// and reports the size of the database before and after it.
+	RunMaintenance(ctx context.Context, input *v1.RunMaintenanceRequest) (*v1.RunMaintenanceResponse, error)
+
+	// Transact performs sends and deletes across queues in a single transaction. Unlike
+	// the Delete, it fails if any of messages hasn't been deleted, e.g. its receipt handle
+	// has expired, so either all sends and deletes take effect or none.
+	Transact(ctx context.Context, input *v1.TransactRequest) (*v1.TransactResponse, error)
+
+	// RetriesDistribution returns the number of messages which
+	// are stored in the queue grouped by the number of receive attempts.
+	RetriesDistribution(ctx context.Context, queueID string) (map[uint32]uint64, error)
+
+	// QueueStats returns the number of messages stored in the queue,
+	// the number of in-flight messages and the age of the oldest message.
+	QueueStats(ctx context.Context, queueID string) (*v1.QueueStatsResponse, error)
+
+	// TransferQueue creates a pending transfer of the queue ownership,
+	// which takes effect only after it's accepted by the receiving team.
+	TransferQueue(ctx context.Context, input *v1.TransferQueueRequest) (*v1.TransferQueueResponse, error)
+
+	// AcceptQueueTransfer changes the owner of the queue and moves permissions
+	// of the previous owner to the new one.
+	AcceptQueueTransfer(ctx context.Context, input *v1.AcceptQueueTransferRequest) (*v1.AcceptQueueTransferResponse, error)
+
+	// CancelQueueTransfer deletes the pending transfer and returns it.
+	CancelQueueTransfer(ctx context.Context, input *v1.CancelQueueTransferRequest) (*v1.QueueTransfer, error)
+
+	// SearchMessages returns messages of the queue with the search index
+	// which match the full-text search query.
+	SearchMessages(ctx context.Context, input *v1.SearchMessagesRequest) (*v1.SearchMessagesResponse, error)
+
+	// PeekMessages returns messages of the queue ordered by the time they were
+	// sent without changing their visibility. Pages are iterated with a cursor.
+	// Bodies of messages are returned only when the request includes them.
+	PeekMessages(ctx context.Context, input *v1.PeekMessagesRequest) (*v1.PeekMessagesResponse, error)
+
+	// GetMessage returns the message of the queue by its identifier, along with its delivery
+	// state, without changing its visibility. Unknown messages are rejected with errkit.ErrNotFound.
+	GetMessage(ctx context.Context, input *v1.GetMessageRequest) (*v1.GetMessageResponse, error)
+
+	// ListMessageEvents returns retained lifecycle events of the message, oldest first.
+	// Messages without events, e.g. the ones whose events have expired, have an empty list.
+	ListMessageEvents(ctx context.Context, input *v1.ListMessageEventsRequest) (*v1.ListMessageEventsResponse, error)
+
+	// SetQueueState moves the queue to another lifecycle state. Transitions which
+	// are not allowed from the current state are rejected with pqerr.ErrConflict.
+	SetQueueState(ctx context.Context, input *v1.SetQueueStateRequest) (*v1.SetQueueStateResponse, error)
+
+	// SearchEntities returns queues, users and roles which match the query
+	// of the command palette, ordered by relevance.
+	SearchEntities(ctx context.Context, input *v1.SearchRequest) (*v1.SearchResponse, error)
+
+	// AppendAuditEvent appends the event to the audit log. Recorded events can't be changed.
+	AppendAuditEvent(ctx context.Context, event *v1.AuditEvent) error
+
+	// ListAuditEvents returns audit events which match the filter, newest first.
+	ListAuditEvents(ctx context.Context, input *v1.ListAuditEventsRequest) (*v1.ListAuditEventsResponse, error)
+
+	// CreateAlertRule creates the threshold rule over an observed metric.
+	CreateAlertRule(ctx context.Context, input *v1.CreateAlertRuleRequest) (*v1.CreateAlertRuleResponse, error)
+
+	// ListAlertRules returns all alert rules ordered by name.
+	ListAlertRules(ctx context.Context, input *v1.ListAlertRulesRequest) (*v1.ListAlertRulesResponse, error)
+
+	// UpdateAlertRule replaces the alert rule. Unknown rules are rejected with errkit.ErrNotFound.
+	UpdateAlertRule(ctx context.Context, input *v1.UpdateAlertRuleRequest) (*v1.UpdateAlertRuleResponse, error)
+
+	// DeleteAlertRule deletes the alert rule. Unknown rules are rejected with errkit.ErrNotFound.
+	DeleteAlertRule(ctx context.Context, input *v1.DeleteAlertRuleRequest) (*v1.DeleteAlertRuleResponse, error)
+
+	// CreateServiceAccount creates the service account with the roles. Names are unique,
+	// taken ones are rejected with errkit.ErrAlreadyExists, unknown roles are
+	// rejected with errkit.ErrInvalidArgument.
+	CreateServiceAccount(ctx context.Context, input *v1.CreateServiceAccountRequest) (*v1.CreateServiceAccountResponse, error)
+
+	// ListServiceAccounts returns all service accounts ordered by name.
+	ListServiceAccounts(ctx context.Context, input *v1.ListServiceAccountsRequest) (*v1.ListServiceAccountsResponse, error)
+
+	// DeleteServiceAccount deletes the service account along with its API keys.
+	// Unknown accounts are rejected with errkit.ErrNotFound.
+	DeleteServiceAccount(ctx context.Context, input *v1.DeleteServiceAccountRequest) (*v1.DeleteServiceAccountResponse, error)
+
+	// CreateAPIKey stores the API key of the service account by its prefix and hash.
+	// Unknown accounts are rejected with errkit.ErrNotFound.
+	CreateAPIKey(ctx context.Context, input *v1.CreateAPIKeyRequest, prefix, hash string) (*v1.APIKey, error)
+
+	// ListAPIKeys returns API keys of the service account, newest first.
+	ListAPIKeys(ctx context.Context, input *v1.ListAPIKeysRequest) (*v1.ListAPIKeysResponse, error)
+
+	// RevokeAPIKey revokes the API key. Unknown keys are rejected with errkit.ErrNotFound.
+	RevokeAPIKey(ctx context.Context, input *v1.RevokeAPIKeyRequest) (*v1.RevokeAPIKeyResponse, error)
+
+	// APIKey returns the stored API key with the prefix, which authenticates
+	// the service account. Unknown keys are rejected with errkit.ErrNotFound.
+	APIKey(ctx context.Context, prefix string) (*auth.StoredAPIKey, error)
+
+	// UserPasswordHash returns the bcrypt hash of the password of the user with the email, which is
+	// empty for users managed by the directory. Unknown and deactivated users are rejected with errkit.ErrNotFound.
+	UserPasswordHash(ctx context.Context, email string) (string, error)
+
+	// SyncUserRoles creates the user managed by the directory with the email,
+	// unless it exists, and replaces its roles with the roles with the names.
+	SyncUserRoles(ctx context.Context, email string, roles []string) error
+
+	// SCIMUsers returns all users ordered by the time they were created.
+	SCIMUsers(ctx context.Context) ([]scim.User, error)
+
+	// SCIMUser returns the user. Unknown users are rejected with errkit.ErrNotFound.
+	SCIMUser(ctx context.Context, id string) (*scim.User, error)
+
+	// CreateSCIMUser creates the user provisioned by the identity provider, which has
+	// no password. Taken user names are rejected with errkit.ErrAlreadyExists.
+	CreateSCIMUser(ctx context.Context, user scim.User) (*scim.User, error)
+
+	// UpdateSCIMUser replaces the user name, the external id and the state of the user.
+	// Unknown users are rejected with errkit.ErrNotFound, user names taken by
+	// other users are rejected with errkit.ErrAlreadyExists.
+	UpdateSCIMUser(ctx context.Context, user scim.User) (*scim.User, error)
+
+	// DeleteSCIMUser deletes the user along with its roles.
+	// Unknown users are rejected with errkit.ErrNotFound.
+	DeleteSCIMUser(ctx context.Context, id string) error
+
+	// SCIMGroups returns all roles as groups ordered by name.
+	SCIMGroups(ctx context.Context) ([]scim.Group, error)
+
+	// SCIMGroup returns the role as the group. Unknown roles are rejected with errkit.ErrNotFound.
+	SCIMGroup(ctx context.Context, id string) (*scim.Group, error)
+
+	// CreateSCIMGroup creates the role granted to members of the group. Taken names are
+	// rejected with errkit.ErrAlreadyExists, unknown members with errkit.ErrInvalidArgument.
+	CreateSCIMGroup(ctx context.Context, group scim.Group) (*scim.Group, error)
+
+	// UpdateSCIMGroup renames the role and replaces users it's granted to by members of the group.
+	// Unknown roles are rejected with errkit.ErrNotFound, names taken by other roles are rejected
+	// with errkit.ErrAlreadyExists, unknown members with errkit.ErrInvalidArgument.
+	UpdateSCIMGroup(ctx context.Context, group scim.Group) (*scim.Group, error)
+
+	// DeleteSCIMGroup deletes the role along with its grants and queue permissions.
+	// Unknown roles are rejected with errkit.ErrNotFound.
+	DeleteSCIMGroup(ctx context.Context, id string) error
+
+	// DenyAccessToken adds the access token with the hash to the denylist until it expires.
+	DenyAccessToken(ctx context.Context, hash string, expiresAt time.Time) error
+
+	// AccessTokenDenied reports whether the access token with the hash is on the denylist.
+	AccessTokenDenied(ctx context.Context, hash string) (bool, error)
+
+	// DeleteExpiredAccessTokens removes access tokens, revocations of tokens of subjects and sessions
+	// expired before the time from the denylist and returns the number of removed tokens.
+	DeleteExpiredAccessTokens(ctx context.Context, before time.Time) (int64, error)
+
+	// RevokeSubjectTokens revokes tokens of the subject issued at or before the time,
+	// until the time those tokens expire. Later revocations replace earlier ones.
+	RevokeSubjectTokens(ctx context.Context, subject string, issuedBefore, expiresAt time.Time) error
+
+	// SubjectTokensRevokedAt returns the time tokens of the subject issued at or before
+	// are revoked, which is zero when tokens of the subject are not revoked.
+	SubjectTokensRevokedAt(ctx context.Context, subject string) (time.Time, error)
+
+	// CreatePasswordResetCode stores the password reset code with the hash of the user with the email
+	// until it expires, replacing unused codes of the user. Unknown and deactivated users,
+	// and users without the password are rejected with errkit.ErrNotFound.
+	CreatePasswordResetCode(ctx context.Context, email, hash string, expiresAt time.Time) error
+
+	// PasswordResetCodeExpiresAt returns the time the unused password reset code with the hash of the
+	// user with the email expires. Unknown, used and expired codes are rejected with errkit.ErrNotFound.
+	PasswordResetCodeExpiresAt(ctx context.Context, email, hash string, now time.Time) (time.Time, error)
+
+	// ResetPassword uses the password reset code with the hash of the user with the email and replaces
+	// the password hash of the user. Unknown, used and expired codes are rejected with errkit.ErrNotFound.
+	ResetPassword(ctx context.Context, email, hash, passwordHash string, now time.Time) error
+
+	// EmailVerificationCodeSentAt returns the time the last email verification code of the user
+	// with the email has been created, which is zero when there is no such code or user.
+	EmailVerificationCodeSentAt(ctx context.Context, email string) (time.Time, error)
+
+	// CreateEmailVerificationCode stores the email verification code with the hash of the user with
+	// the email until it expires, replacing unused codes of the user. Unknown, deactivated
+	// and verified users are rejected with errkit.ErrNotFound.
+	CreateEmailVerificationCode(ctx context.Context, email, hash string, now, expiresAt time.Time) error
+
+	// VerifyEmail uses the email verification code with the hash of the user with the email and marks
+	// the email of the user verified. Unknown, used and expired codes are rejected with errkit.ErrNotFound.
+	VerifyEmail(ctx context.Context, email, hash string, now time.Time) error
+
+	// CreateSession records the session of the token issued on sign-in.
+	CreateSession(ctx context.Context, session auth.Session) error
+
+	// Sessions returns sessions of the subject which are neither expired nor revoked at the time, newest first.
+	Sessions(ctx context.Context, subject string, now time.Time) ([]auth.Session, error)
+
+	// RevokeSession revokes the active session of the subject and returns it.
+	// Unknown, expired and revoked sessions are rejected with errkit.ErrNotFound.
+	RevokeSession(ctx context.Context, subject, id string, now time.Time) (*auth.Session, error)
+
+	// RevokeSessions revokes active sessions of the subject and returns the number of revoked sessions.
+	RevokeSessions(ctx context.Context, subject string, now time.Time) (int64, error)
+
+	// Account returns the profile of the active user with the email along with names of its roles.
+	// Unknown users are rejected with errkit.ErrNotFound.
+	Account(ctx context.Context, email string) (*v1.AccountProfile, error)
+
+	// UpdateAccount updates the profile of the active user with the email by fields set in the input.
+	// The changed email should be verified again, and only emails of local users can be changed.
+	UpdateAccount(ctx context.Context, email string, input *v1.UpdateAccountRequest) (*v1.AccountProfile, error)
+
+	// UpdateUserPassword replaces the password hash of the active local user with the email.
+	// Unknown users are rejected with errkit.ErrNotFound.
+	UpdateUserPassword(ctx context.Context, email, passwordHash string, now time.Time) error
+
+	// UserEmailVerified reports whether the email of the user has been verified.
+	// Unknown users are not verified.
+	UserEmailVerified(ctx context.Context, email string) (bool, error)
+
+	// QueuePermissions returns permissions granted to roles on the queue.
+	// Unknown queues are rejected with errkit.ErrNotFound.
+	QueuePermissions(ctx context.Context, queueID string) ([]*v1.QueuePermission, error)
+
+	// SetQueuePermission grants permissions on the queue to the role, replacing permissions
+	// the role had on the queue. Unknown queues and roles are rejected with errkit.ErrNotFound.
+	SetQueuePermission(ctx context.Context, permission *v1.QueuePermission) (*v1.QueuePermission, error)
+
+	// DeleteQueuePermission revokes permissions on the queue from the role.
+	// Roles without permissions on the queue are rejected with errkit.ErrNotFound.
+	DeleteQueuePermission(ctx context.Context, queueID, role string) error
+
+	// EffectivePermissions returns permissions of the subject combined from all its roles as
+	// QueueGrants resolves them, on the queue when the queue id is given, or on all queues
+	// which the subject is allowed to use. Unknown queues are rejected with errkit.ErrNotFound.
+	EffectivePermissions(ctx context.Context, subject, queueID string) (*v1.EffectivePermissions, error)
+
+	// Roles returns all roles ordered by name along with emails of users they are assigned to.
+	Roles(ctx context.Context) ([]*v1.Role, error)
+
+	// CreateRole creates the role with the name. Taken names are rejected with errkit.ErrAlreadyExists.
+	CreateRole(ctx context.Context, name string) (*v1.Role, error)
+
+	// DeleteRole deletes the role with the name along with its assignments, queue permissions
+	// and policies. Unknown roles are rejected with errkit.ErrNotFound.
+	DeleteRole(ctx context.Context, name string) error
+
+	// AssignRole assigns the role with the name to the user with the email, roles assigned
+	// already are kept as they are. Unknown users and roles are rejected with errkit.ErrNotFound.
+	AssignRole(ctx context.Context, email, role string) error
+
+	// UnassignRole takes the role with the name away from the user with the email.
+	// Users without the role are rejected with errkit.ErrNotFound.
+	UnassignRole(ctx context.Context, email, role string) error
+
+	// ApplyTemplate attaches the policy of the template to the role, which grants operations
+	// of the template on all queues. Unknown roles are rejected with errkit.ErrNotFound.
+	ApplyTemplate(ctx context.Context, role string, template *rbac.Template) error
+
+	// QueueGrants returns permissions granted to roles of the subject, which are roles named
+	// after the subject, roles of the active user with the subject email or id, and roles of the
+	// service account with the subject name. Permissions on the queue are resolved when
+	// the queue id is given, unknown queues are rejected with errkit.ErrNotFound.
+	// Grants may be cached, changes of roles and permissions made by the Storage drop them.
+	QueueGrants(ctx context.Context, subject, queueID string) (*rbac.Grants, error)
+
+	// QueuePropsVersion returns the version of queue properties which
+	// changes each time any queue is created, deleted or modified.
+	QueuePropsVersion() uint64
 }
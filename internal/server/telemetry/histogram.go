@@ -0,0 +1,325 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+const (
+	// MetricTimeInQueue is the name of the metric which measures
+	// the amount of time each message stay in a queue.
+	MetricTimeInQueue = "message_in_queue_duration"
+
+	// MetricGCDuration is the name of the metric which
+	// measures the duration of the garbage collection.
+	MetricGCDuration = "gc_duration"
+
+	// MetricStorageTxDuration is the name of the metric which
+	// measures the duration of storage transactions.
+	MetricStorageTxDuration = "storage_tx_duration"
+
+	// MaxHistogramBuckets represents the maximum number of configured histogram buckets.
+	MaxHistogramBuckets = 30
+)
+
+var (
+	// bucketHistograms holds histograms with configured buckets by their full name.
+	bucketHistograms = histogramRegistry{m: make(map[string]*bucketHistogram)}
+
+	// shadowSet holds unregistered histograms which are used to
+	// estimate quantiles of summaries, since summaries don't expose them.
+	shadowSet = metrics.NewSet()
+)
+
+// ParseDurationBuckets parses a comma separated list of durations, e.g. "1s,1m,1h",
+// which are used as upper bounds of histogram buckets. The bounds should be positive
+// and increasing. Empty string means that the default buckets are used.
+func ParseDurationBuckets(s string) ([]time.Duration, error) {
+	var bounds []time.Duration
+
+	for v := range strings.SplitSeq(s, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+
+		d, parseErr := time.ParseDuration(v)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse bucket %q: %w", v, parseErr)
+		}
+
+		if d <= 0 {
+			return nil, fmt.Errorf("bucket %q should be positive", v)
+		}
+
+		if len(bounds) > 0 && d <= bounds[len(bounds)-1] {
+			return nil, fmt.Errorf("bucket %q should be greater than the previous one", v)
+		}
+
+		bounds = append(bounds, d)
+	}
+
+	if len(bounds) > MaxHistogramBuckets {
+		return nil, fmt.Errorf("too many buckets: %d, maximum is %d", len(bounds), MaxHistogramBuckets)
+	}
+
+	return bounds, nil
+}
+
+// WithDurationBuckets sets upper bounds of histogram buckets of the duration metric,
+// e.g. MetricTimeInQueue. Such a histogram is exposed in the Prometheus format with
+// "le" labels instead of VictoriaMetrics log-scale buckets.
+func WithDurationBuckets(metric string, bounds []time.Duration) ObserverOption {
+	return func(o *MetricsObserver) {
+		if len(bounds) == 0 {
+			delete(o.buckets, metric)
+			return
+		}
+
+		seconds := make([]float64, 0, len(bounds))
+
+		for _, b := range bounds {
+			seconds = append(seconds, b.Seconds())
+		}
+
+		slices.Sort(seconds)
+
+		if o.buckets == nil {
+			o.buckets = make(map[string][]float64)
+		}
+
+		o.buckets[metric] = slices.Compact(seconds)
+	}
+}
+
+// WithDurationSummary exposes duration metrics as summaries instead of histograms.
+func WithDurationSummary() ObserverOption {
+	return func(o *MetricsObserver) { o.summary = true }
+}
+
+// duration returns a Histogram of the duration metric with given family and labels.
+func (o *MetricsObserver) duration(family, labels string) Histogram {
+	name := family
+	if labels != "" {
+		name += `{` + labels + `}`
+	}
+
+	obs := o.observers.get()
+	// Updates are mirrored by wrapping functions which are set below.
+	defer o.mirrorDuration(obs, name)
+
+	if o.summary {
+		summary := metrics.GetOrCreateSummary(name)
+		shadow := shadowSet.GetOrCreateHistogram(name)
+
+		obs.dur = func(t time.Time) {
+			d := time.Since(t).Seconds()
+			summary.Update(d)
+			shadow.Update(d)
+		}
+		obs.upd = func(n float64) { summary.Update(n); shadow.Update(n) }
+		obs.qnt = func(phi float64) float64 { return histogramQuantile(shadow, phi) }
+
+		return obs
+	}
+
+	if bounds, ok := o.buckets[family]; ok {
+		h := bucketHistograms.getOrCreate(name, bounds)
+
+		obs.dur = func(t time.Time) { h.Update(time.Since(t).Seconds()) }
+		obs.upd = func(n float64) { h.Update(n) }
+		obs.qnt = func(phi float64) float64 { return h.quantile(phi) }
+
+		return obs
+	}
+
+	vmHis := metrics.GetOrCreateHistogram(name)
+
+	obs.dur = func(t time.Time) { vmHis.UpdateDuration(t) }
+	obs.upd = func(n float64) { vmHis.Update(n) }
+	obs.qnt = func(phi float64) float64 { return histogramQuantile(vmHis, phi) }
+
+	return obs
+}
+
+// mirrorDuration mirrors updates of the duration metric with given name, when metrics are mirrored.
+func (o *MetricsObserver) mirrorDuration(obs *observe, name string) {
+	if o.mirror == nil {
+		return
+	}
+
+	m := o.mirror.histogram(name)
+	if m == nil {
+		return
+	}
+
+	dur, upd := obs.dur, obs.upd
+
+	obs.dur = func(t time.Time) {
+		d := time.Since(t)
+		dur(t)
+		m.instrument.Record(context.Background(), d.Seconds(), m.attrs)
+	}
+	obs.upd = func(n float64) { upd(n); m.instrument.Record(context.Background(), n, m.attrs) }
+}
+
+// histogramRegistry holds histograms with configured buckets and
+// writes them to the default VictoriaMetrics set on scrape.
+type histogramRegistry struct {
+	once sync.Once
+	mu   sync.Mutex
+	m    map[string]*bucketHistogram
+}
+
+// getOrCreate returns the histogram with given name, creating it with given bounds
+// if it doesn't exist yet. The bounds of the existing histogram are not changed.
+func (r *histogramRegistry) getOrCreate(name string, bounds []float64) *bucketHistogram {
+	r.once.Do(func() { metrics.RegisterMetricsWriter(r.writePrometheus) })
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.m[name]
+	if !ok {
+		h = newBucketHistogram(name, bounds)
+		r.m[name] = h
+	}
+
+	return h
+}
+
+func (r *histogramRegistry) writePrometheus(w io.Writer) {
+	r.mu.Lock()
+	list := make([]*bucketHistogram, 0, len(r.m))
+
+	for _, h := range r.m {
+		list = append(list, h)
+	}
+	r.mu.Unlock()
+
+	slices.SortFunc(list, func(a, b *bucketHistogram) int { return strings.Compare(a.name, b.name) })
+
+	var family string
+
+	for _, h := range list {
+		if h.family != family {
+			family = h.family
+			metrics.WriteMetadataIfNeeded(w, family, "histogram")
+		}
+
+		h.writePrometheus(w)
+	}
+}
+
+// bucketHistogram is a cumulative histogram with fixed upper bounds of buckets.
+type bucketHistogram struct {
+	name   string
+	family string
+	labels string
+	bounds []float64
+
+	mu sync.Mutex
+	// counts holds the number of values per bucket, the last one is +Inf.
+	counts []uint64
+	sum    float64
+}
+
+func newBucketHistogram(name string, bounds []float64) *bucketHistogram {
+	family, labels, _ := strings.Cut(name, "{")
+
+	h := bucketHistogram{
+		name:   name,
+		family: family,
+		labels: strings.TrimSuffix(labels, "}"),
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1),
+	}
+
+	return &h
+}
+
+// Update adds the value to the histogram.
+func (h *bucketHistogram) Update(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+
+	i, _ := slices.BinarySearch(h.bounds, v)
+
+	h.mu.Lock()
+	h.counts[i]++
+	h.sum += v
+	h.mu.Unlock()
+}
+
+// quantile estimates the phi-quantile by the upper bounds of buckets.
+// Values above the last bound are estimated by the last bound.
+func (h *bucketHistogram) quantile(phi float64) float64 {
+	h.mu.Lock()
+	counts := slices.Clone(h.counts)
+	h.mu.Unlock()
+
+	var total uint64
+
+	for _, c := range counts {
+		total += c
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(phi * float64(total)))
+
+	var seen uint64
+
+	for i, c := range counts[:len(h.bounds)] {
+		seen += c
+		if seen >= rank {
+			return h.bounds[i]
+		}
+	}
+
+	return h.bounds[len(h.bounds)-1]
+}
+
+func (h *bucketHistogram) writePrometheus(w io.Writer) {
+	h.mu.Lock()
+	counts := slices.Clone(h.counts)
+	sum := h.sum
+	h.mu.Unlock()
+
+	prefix := ""
+	if h.labels != "" {
+		prefix = h.labels + `,`
+	}
+
+	var cumulative uint64
+
+	for i, c := range counts {
+		cumulative += c
+
+		le := "+Inf"
+		if i < len(h.bounds) {
+			le = strconv.FormatFloat(h.bounds[i], 'g', -1, 64)
+		}
+
+		fmt.Fprintf(w, "%s_bucket{%sle=%q} %d\n", h.family, prefix, le, cumulative)
+	}
+
+	suffix := ""
+	if h.labels != "" {
+		suffix = `{` + h.labels + `}`
+	}
+
+	fmt.Fprintf(w, "%s_sum%s %s\n", h.family, suffix, strconv.FormatFloat(sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count%s %d\n", h.family, suffix, cumulative)
+}
@@ -0,0 +1,118 @@
+package telemetry
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestParseDurationBuckets(t *testing.T) {
+	type tcase struct {
+		spec    string
+		want    []time.Duration
+		wantErr bool
+	}
+
+	tests := map[string]tcase{
+		"Empty":      {spec: "", want: nil},
+		"Single":     {spec: "1s", want: []time.Duration{time.Second}},
+		"Multiple":   {spec: "1s, 1m,1h,,6h", want: []time.Duration{time.Second, time.Minute, time.Hour, 6 * time.Hour}},
+		"Invalid":    {spec: "1s,forever", wantErr: true},
+		"Zero":       {spec: "0s,1s", wantErr: true},
+		"Negative":   {spec: "-1s", wantErr: true},
+		"Unordered":  {spec: "1m,1s", wantErr: true},
+		"Duplicated": {spec: "1s,1s", wantErr: true},
+		"TooMany":    {spec: strings.Repeat("1s,", MaxHistogramBuckets) + "2s", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseDurationBuckets(tc.spec)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
+
+func Test_bucketHistogram(t *testing.T) {
+	h := newBucketHistogram(`test_bucket_histogram{queue="q1"}`, []float64{1, 60, 3600})
+
+	for _, v := range []float64{0.5, 1, 30, 30, 7200} {
+		h.Update(v)
+	}
+
+	var buf bytes.Buffer
+
+	h.writePrometheus(&buf)
+
+	td.Cmp(t, buf.String(), `test_bucket_histogram_bucket{queue="q1",le="1"} 2
+test_bucket_histogram_bucket{queue="q1",le="60"} 4
+test_bucket_histogram_bucket{queue="q1",le="3600"} 4
+test_bucket_histogram_bucket{queue="q1",le="+Inf"} 5
+test_bucket_histogram_sum{queue="q1"} 7261.5
+test_bucket_histogram_count{queue="q1"} 5
+`)
+
+	td.Cmp(t, h.quantile(0.4), 1.0)
+	td.Cmp(t, h.quantile(0.8), 60.0)
+	td.Cmp(t, h.quantile(1), 3600.0)
+}
+
+func Test_bucketHistogram_noLabels(t *testing.T) {
+	h := newBucketHistogram(`test_bucket_histogram_plain`, []float64{0.1})
+
+	td.Cmp(t, h.quantile(0.5), 0.0)
+
+	h.Update(0.05)
+
+	var buf bytes.Buffer
+
+	h.writePrometheus(&buf)
+
+	td.Cmp(t, buf.String(), `test_bucket_histogram_plain_bucket{le="0.1"} 1
+test_bucket_histogram_plain_bucket{le="+Inf"} 1
+test_bucket_histogram_plain_sum 0.05
+test_bucket_histogram_plain_count 1
+`)
+}
+
+func TestMetricsObserver_duration(t *testing.T) {
+	t.Run("Buckets", func(t *testing.T) {
+		o := NewObserver(WithDurationBuckets(MetricTimeInQueue, []time.Duration{time.Minute, time.Hour}))
+
+		o.TimeInQueue("observer_buckets").Dur(time.Now().Add(-30 * time.Minute))
+
+		var buf bytes.Buffer
+
+		metrics.WritePrometheus(&buf, false)
+
+		td.Cmp(t, buf.String(), td.Contains(
+			`message_in_queue_duration_bucket{queue="observer_buckets",le="3600"} 1`,
+		))
+		td.Cmp(t, o.TimeInQueue("observer_buckets").Quantile(0.5), 3600.0)
+	})
+
+	t.Run("Summary", func(t *testing.T) {
+		o := NewObserver(WithDurationSummary())
+
+		o.TimeInQueue("observer_summary").Dur(time.Now().Add(-time.Second))
+
+		var buf bytes.Buffer
+
+		metrics.WritePrometheus(&buf, false)
+
+		td.Cmp(t, buf.String(), td.Contains(
+			`message_in_queue_duration_count{queue="observer_summary"} 1`,
+		))
+		td.Cmp(t, o.TimeInQueue("observer_summary").Quantile(0.5), td.Between(1.0, 1.2))
+	})
+}
@@ -0,0 +1,89 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultHistoryRange is the time range of a query which doesn't set its start.
+	DefaultHistoryRange = time.Hour
+
+	// maxHistoryPoints limits the number of datapoints of each series,
+	// the step of queries which don't set it is chosen to fit this limit.
+	maxHistoryPoints = 500
+)
+
+// ErrHistoryUnavailable is returned when historical metrics are not stored.
+var ErrHistoryUnavailable = errors.New("historical metrics are not available")
+
+// Query describes the range of datapoints of the metric.
+type Query struct {
+	// Metric is the name of an observed metric, e.g. "messages_sent_total".
+	Metric string
+
+	// QueueID limits series to the ones of the queue.
+	// Empty QueueID selects series which don't belong to queues.
+	QueueID string
+
+	// From and To bound the time range, both inclusive.
+	From time.Time
+	To   time.Time
+
+	// Step is the resolution of datapoints, each step holds the last datapoint in it.
+	Step time.Duration
+}
+
+// Validate checks the query and sets the step when it's not set.
+func (q *Query) Validate() error {
+	if ok, _ := Observable(context.Background(), q.Metric); !ok {
+		return fmt.Errorf("metric %q is not observed", q.Metric)
+	}
+
+	if q.From.After(q.To) {
+		return errors.New("start of the range is after its end")
+	}
+
+	if q.Step < 0 {
+		return errors.New("step should not be negative")
+	}
+
+	if q.Step == 0 {
+		q.Step = max(q.To.Sub(q.From)/maxHistoryPoints, time.Second)
+	}
+
+	if q.To.Sub(q.From)/q.Step > maxHistoryPoints {
+		return fmt.Errorf("range holds more than %d steps", maxHistoryPoints)
+	}
+
+	return nil
+}
+
+// Querier returns historical datapoints of metrics.
+type Querier interface {
+	// Query returns series of the metric which match the query,
+	// one Metric for each distinct set of labels.
+	Query(ctx context.Context, q Query) ([]Metric, error)
+}
+
+// downsample keeps the last datapoint of each step, datapoints should be sorted by time.
+// Counters grow and gauges hold the current value, so the last datapoint represents the step.
+func downsample(values []Datapoint, from time.Time, step time.Duration) []Datapoint {
+	if step <= 0 || len(values) == 0 {
+		return values
+	}
+
+	result := make([]Datapoint, 0, len(values))
+
+	for i, v := range values {
+		if i+1 < len(values) && values[i+1].Timestamp.Sub(from)/step == v.Timestamp.Sub(from)/step {
+			continue
+		}
+
+		result = append(result, v)
+	}
+
+	return result
+}
@@ -0,0 +1,67 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestQuery_Validate(t *testing.T) {
+	now := time.Now()
+
+	type tcase struct {
+		query    Query
+		wantStep time.Duration
+		wantErr  bool
+	}
+
+	tests := map[string]tcase{
+		"DefaultStep": {
+			query:    Query{Metric: "messages_sent_total", From: now.Add(-time.Hour), To: now},
+			wantStep: time.Hour / maxHistoryPoints,
+		},
+		"MinimalStep": {
+			query:    Query{Metric: "messages_sent_total", From: now.Add(-time.Minute), To: now},
+			wantStep: time.Second,
+		},
+		"Step": {
+			query:    Query{Metric: "queues_exist", From: now.Add(-time.Hour), To: now, Step: time.Minute},
+			wantStep: time.Minute,
+		},
+		"UnknownMetric": {query: Query{Metric: "go_goroutines", From: now, To: now}, wantErr: true},
+		"Reversed":      {query: Query{Metric: "queues_exist", From: now, To: now.Add(-time.Hour)}, wantErr: true},
+		"NegativeStep":  {query: Query{Metric: "queues_exist", From: now, To: now, Step: -time.Second}, wantErr: true},
+		"TooManySteps": {
+			query:   Query{Metric: "queues_exist", From: now.Add(-time.Hour), To: now, Step: time.Second},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tc.query.Validate()
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, tc.query.Step, tc.wantStep)
+		})
+	}
+}
+
+func Test_downsample(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	at := func(sec int, v float64) Datapoint {
+		return Datapoint{Timestamp: from.Add(time.Duration(sec) * time.Second), Value: v}
+	}
+
+	values := []Datapoint{at(0, 1), at(10, 2), at(59, 3), at(60, 4), at(130, 5), at(170, 6)}
+
+	td.Cmp(t, downsample(values, from, 0), values)
+	td.Cmp(t, downsample(nil, from, time.Minute), []Datapoint(nil))
+	td.Cmp(t, downsample(values, from, time.Minute), []Datapoint{at(59, 3), at(60, 4), at(170, 6)})
+}
@@ -0,0 +1,161 @@
+package telemetry
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// MaxTagLabels represents the maximum number of queue tags
+	// which can be attached to the queue metrics as labels.
+	MaxTagLabels = 5
+
+	// DefaultTagLabelValues represents the default maximum number
+	// of distinct values of a single tag label.
+	DefaultTagLabelValues = 20
+
+	// tagLabelOverflow is used as the label value when the
+	// limit of distinct values of the tag label is reached.
+	tagLabelOverflow = "other"
+
+	// maxTagLabelValueLen represents the maximum length of the tag label value.
+	maxTagLabelValueLen = 64
+)
+
+var (
+	// labelNameRegexp matches valid Prometheus label names.
+	labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	// reservedLabels holds labels which are set by the Observer itself.
+	reservedLabels = []string{"queue", "policy"}
+
+	// labelValueEscaper escapes label values according to the Prometheus text format.
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// QueueLabels holds the queue tags which are attached to the queue metrics as labels.
+// To keep the cardinality of metrics under control only selected tag keys are used,
+// and the number of distinct values of each tag is limited. Values which exceed
+// the limit are replaced with "other".
+type QueueLabels struct {
+	keys      []string
+	maxValues int
+
+	mu sync.RWMutex
+	// values holds seen values per tag key.
+	values map[string]map[string]struct{}
+	// labels holds rendered labels per queue.
+	labels map[string]string
+}
+
+// NewQueueLabels returns a pointer to a new instance of QueueLabels which uses given tag
+// keys as labels. The maxValues limits the distinct values of each tag label.
+func NewQueueLabels(keys []string, maxValues int) (*QueueLabels, error) {
+	if len(keys) > MaxTagLabels {
+		return nil, fmt.Errorf("too many tag labels: %d, maximum is %d", len(keys), MaxTagLabels)
+	}
+
+	for _, k := range keys {
+		if !labelNameRegexp.MatchString(k) {
+			return nil, fmt.Errorf("invalid tag label name: %q", k)
+		}
+
+		if slices.Contains(reservedLabels, k) {
+			return nil, fmt.Errorf("tag label name %q is reserved", k)
+		}
+	}
+
+	if maxValues <= 0 {
+		maxValues = DefaultTagLabelValues
+	}
+
+	l := QueueLabels{
+		keys:      slices.Compact(slices.Sorted(slices.Values(keys))),
+		maxValues: maxValues,
+		values:    make(map[string]map[string]struct{}, len(keys)),
+		labels:    make(map[string]string),
+	}
+
+	return &l, nil
+}
+
+// Set renders labels of the queue from its tags.
+func (l *QueueLabels) Set(queueID string, tags map[string]string) {
+	if l == nil || len(l.keys) == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var b strings.Builder
+
+	for _, k := range l.keys {
+		v, ok := tags[k]
+		if !ok || v == "" {
+			continue
+		}
+
+		b.WriteString(`, ` + k + `="` + labelValueEscaper.Replace(l.value(k, v)) + `"`)
+	}
+
+	if b.Len() == 0 {
+		delete(l.labels, queueID)
+		return
+	}
+
+	l.labels[queueID] = b.String()
+}
+
+// Delete removes labels of the queue.
+func (l *QueueLabels) Delete(queueID string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.labels, queueID)
+}
+
+// get returns rendered labels of the queue, which start with a comma, or an empty string.
+func (l *QueueLabels) get(queueID string) string {
+	if l == nil {
+		return ""
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.labels[queueID]
+}
+
+// value returns the label value for the tag value, applying the values limit.
+// Should be called with the lock held.
+func (l *QueueLabels) value(key, value string) string {
+	if len(value) > maxTagLabelValueLen {
+		value = strings.ToValidUTF8(value[:maxTagLabelValueLen], "")
+	}
+
+	seen, ok := l.values[key]
+	if !ok {
+		seen = make(map[string]struct{}, l.maxValues)
+		l.values[key] = seen
+	}
+
+	if _, ok := seen[value]; ok {
+		return value
+	}
+
+	if len(seen) >= l.maxValues {
+		return tagLabelOverflow
+	}
+
+	seen[value] = struct{}{}
+
+	return value
+}
@@ -0,0 +1,78 @@
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestNewQueueLabels(t *testing.T) {
+	type tcase struct {
+		keys    []string
+		wantErr bool
+	}
+
+	tests := map[string]tcase{
+		"Empty":    {keys: nil},
+		"Valid":    {keys: []string{"team", "service"}},
+		"Invalid":  {keys: []string{"team-name"}, wantErr: true},
+		"Reserved": {keys: []string{"queue"}, wantErr: true},
+		"TooMany":  {keys: []string{"a", "b", "c", "d", "e", "f"}, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewQueueLabels(tc.keys, 0)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+		})
+	}
+}
+
+func TestQueueLabels(t *testing.T) {
+	labels, err := NewQueueLabels([]string{"team", "service"}, 2)
+	td.Require(t).CmpNoError(err)
+
+	labels.Set("q1", map[string]string{"team": "core", "service": `bill"ing`, "env": "prod"})
+	td.Cmp(t, labels.get("q1"), `, service="bill\"ing", team="core"`)
+
+	labels.Set("q2", map[string]string{"team": "edge"})
+	td.Cmp(t, labels.get("q2"), `, team="edge"`)
+
+	// The third distinct team exceeds the limit.
+	labels.Set("q3", map[string]string{"team": "infra"})
+	td.Cmp(t, labels.get("q3"), `, team="other"`)
+
+	labels.Set("q4", map[string]string{"team": "core"})
+	td.Cmp(t, labels.get("q4"), `, team="core"`)
+
+	labels.Set("q5", nil)
+	td.Cmp(t, labels.get("q5"), "")
+
+	labels.Delete("q1")
+	td.Cmp(t, labels.get("q1"), "")
+
+	var nilLabels *QueueLabels
+
+	nilLabels.Set("q1", map[string]string{"team": "core"})
+	td.Cmp(t, nilLabels.get("q1"), "")
+}
+
+func TestMetricsObserver_QueueTags(t *testing.T) {
+	labels, err := NewQueueLabels([]string{"team"}, 0)
+	td.Require(t).CmpNoError(err)
+
+	// Observers share labels, so both report the same series.
+	producer := NewObserver(WithQueueLabels(labels))
+	reader := NewObserver(WithQueueLabels(labels))
+
+	producer.QueueTags("queue-tags-test", map[string]string{"team": "core"})
+	producer.MessagesSent("queue-tags-test").Add(3)
+
+	td.Cmp(t, reader.MessagesSent("queue-tags-test").Get(), uint64(3))
+	td.Cmp(t, reader.queueLabels("queue-tags-test"), `queue="queue-tags-test", team="core"`)
+}
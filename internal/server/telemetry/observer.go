@@ -1,9 +1,13 @@
 package telemetry
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"math"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,18 +17,47 @@ import (
 
 // observedMetrics represents a set of observed metrics.
 var observedMetrics = map[string]struct{}{
-	"queues_exist":              {}, // gauge.
-	"message_in_queue_duration": {}, // histogram.
-	"messages_sent_total":       {}, // counter.
-	"messages_sent_bytes_total": {}, // counter.
-	"messages_received_total":   {}, // counter.
-	"messages_deleted_total":    {}, // counter.
-	"messages_dropped_total":    {}, // counter.
-	"empty_receives_total":      {}, // counter.
-	"gc_schedules_total":        {}, // counter.
-	"gc_duration":               {}, // histogram.
+	"queues_exist":               {}, // gauge.
+	"message_in_queue_duration":  {}, // histogram.
+	"messages_sent_total":        {}, // counter.
+	"messages_sent_bytes_total":  {}, // counter.
+	"messages_received_total":    {}, // counter.
+	"messages_deleted_total":     {}, // counter.
+	"messages_dropped_total":     {}, // counter.
+	"empty_receives_total":       {}, // counter.
+	"receive_requests_total":     {}, // counter.
+	"gc_schedules_total":         {}, // counter.
+	"gc_duration":                {}, // histogram.
+	"auth_failures_total":        {}, // counter.
+	"auth_denials_total":         {}, // counter.
+	"token_errors_total":         {}, // counter.
+	"oauth_sync_failures_total":  {}, // counter.
+	"breaker_trips_total":        {}, // counter.
+	"rate_limited_total":         {}, // counter.
+	"storage_tx_duration":        {}, // histogram.
+	"storage_busy_retries_total": {}, // counter.
+	"storage_conflicts_total":    {}, // counter.
+	"messages_oversized_total":   {}, // counter.
+	"messages_over_quota_total":  {}, // counter.
+	"queue_tables_pending":       {}, // gauge.
+	"messages_visible":           {}, // gauge.
+	"messages_in_flight":         {}, // gauge.
+	"backups_total":              {}, // counter.
+	"backup_failures_total":      {}, // counter.
+	"backups_failing":            {}, // gauge.
 }
 
+// Reasons of authentication failures and token validation errors.
+// Reasons are used as metric labels, so the set of them is bounded.
+const (
+	ReasonMissingCredentials = "missing_credentials"
+	ReasonInvalidCredentials = "invalid_credentials"
+	ReasonMalformedToken     = "malformed"
+	ReasonExpiredToken       = "expired"
+	ReasonInvalidSignature   = "invalid_signature"
+	ReasonRevokedToken       = "revoked"
+)
+
 // Observable checks if a given metric is being observed.
 func Observable(_ context.Context, metric string) (bool, error) {
 	_, ok := observedMetrics[metric]
@@ -70,6 +103,10 @@ type Observer interface {
 	// the amount of empty receives.
 	EmptyReceives(queueID string) Counter
 
+	// ReceiveRequests returns a Counter to measure
+	// the amount of receive requests including empty ones.
+	ReceiveRequests(queueID string) Counter
+
 	// TimeInQueue returns a Histogram to measure the amount
 	// of time each message stay in a queue.
 	TimeInQueue(queueID string) Histogram
@@ -83,12 +120,108 @@ type Observer interface {
 	// QueuesExist returns a Gauge to measure the amount of
 	// queues that exist now.
 	QueuesExist() Gauge
+
+	// QueueTablesPending returns a Gauge to measure the amount of
+	// queue tables which are waiting for schema mutations.
+	QueueTablesPending() Gauge
+
+	// MessagesVisible returns a Gauge to measure the amount
+	// of messages which are ready to be received from the queue.
+	MessagesVisible(queueID string) Gauge
+
+	// MessagesInFlight returns a Gauge to measure the amount of messages
+	// which have been received but not yet deleted or made visible again.
+	MessagesInFlight(queueID string) Gauge
+
+	// AuthFailures returns a Counter to measure the amount
+	// of requests which failed authentication by the reason.
+	AuthFailures(reason string) Counter
+
+	// AuthDenials returns a Counter to measure the amount of requests
+	// of authenticated subjects which were denied the operation on the queue.
+	AuthDenials(queueID, operation string) Counter
+
+	// GrantsCacheHits returns a Counter to measure the amount of
+	// permission checks which were served by the cache of grants.
+	GrantsCacheHits() Counter
+
+	// GrantsCacheMisses returns a Counter to measure the amount of
+	// permission checks which resolved grants from the database.
+	GrantsCacheMisses() Counter
+
+	// QueueCacheHits returns a Counter to measure the amount of
+	// lookups of queue properties which were served by the cache.
+	QueueCacheHits() Counter
+
+	// QueueCacheMisses returns a Counter to measure the amount of
+	// lookups of queue properties which were not served by the cache.
+	QueueCacheMisses() Counter
+
+	// QueueCacheEvictions returns a Counter to measure the amount of queue
+	// properties removed from the cache by the reason: "size" or "ttl".
+	QueueCacheEvictions(reason string) Counter
+
+	// TokenErrors returns a Counter to measure the amount
+	// of tokens which failed validation by the reason.
+	TokenErrors(reason string) Counter
+
+	// OAuthSyncFailures returns a Counter to measure the amount
+	// of failed synchronizations with the OAuth provider.
+	OAuthSyncFailures(provider string) Counter
+
+	// BreakerTrips returns a Counter to measure the amount
+	// of times the circuit breaker has frozen the queue.
+	BreakerTrips(queueID string) Counter
+
+	// RateLimited returns a Counter to measure the amount of requests
+	// which were rejected by the rate limit of the scope.
+	RateLimited(scope string) Counter
+
+	// StorageTxDuration returns a Histogram to measure
+	// the duration of storage transactions of the operation.
+	StorageTxDuration(operation string) Histogram
+
+	// StorageBusyRetries returns a Counter to measure the amount of storage
+	// operations which were retried because the database was busy.
+	StorageBusyRetries(operation string) Counter
+
+	// StorageConflicts returns a Counter to measure the amount of storage
+	// transactions which failed because another transaction changed the data they read.
+	StorageConflicts(operation string) Counter
+
+	// MessagesOversized returns a Counter to measure the amount of messages
+	// which were rejected because their size exceeds the limit of the scope.
+	MessagesOversized(queueID, scope string) Counter
+
+	// MessagesOverQuota returns a Counter to measure the amount of messages which
+	// were rejected or evicted, according to the action, to keep the queue within its quota.
+	MessagesOverQuota(queueID, action string) Counter
+
+	// Backups returns a Counter to measure the amount of
+	// backups of the database which were uploaded.
+	Backups(database string) Counter
+
+	// BackupFailures returns a Counter to measure the amount
+	// of backups of the database which failed.
+	BackupFailures(database string) Counter
+
+	// BackupsFailing returns a Gauge which is 1 while
+	// the last backup of the database has failed.
+	BackupsFailing(database string) Gauge
+
+	// QueueTags sets the tags of the queue which are attached to its metrics
+	// as labels. Nil tags mean that the queue doesn't exist anymore.
+	QueueTags(queueID string, tags map[string]string)
 }
 
 // Histogram interface represents a type that can be used to collect and analyze duration data.
 type Histogram interface {
 	// Dur track the duration since given time.
 	Dur(since time.Time)
+
+	// Quantile returns the estimated phi-quantile (0 <= phi <= 1) of observed values.
+	// The estimation is based on the upper bounds of histogram buckets.
+	Quantile(phi float64) float64
 }
 
 // Counter represents a simple counter.
@@ -112,152 +245,199 @@ type Gauge interface {
 
 	// Sub decrements n from the underlying value.
 	Sub(n uint64)
+
+	// Set sets the underlying value to n.
+	Set(n uint64)
+}
+
+// ObserverOption configures the MetricsObserver.
+type ObserverOption func(o *MetricsObserver)
+
+// WithQueueLabels sets the QueueLabels which are attached to queue metrics.
+// Observers which report the same metrics should share the QueueLabels.
+func WithQueueLabels(labels *QueueLabels) ObserverOption {
+	return func(o *MetricsObserver) { o.labels = labels }
 }
 
 // MetricsObserver implements the Observer interface.
-type MetricsObserver struct{ observers obsPool[observe] }
+type MetricsObserver struct {
+	observers obsPool[observe]
+	labels    *QueueLabels
+
+	// buckets holds upper bounds of histogram buckets in seconds by metric name.
+	buckets map[string][]float64
+	// summary tells whether duration metrics are exposed as summaries.
+	summary bool
+	// mirror mirrors metrics to instruments of the meter, nil when metrics aren't mirrored.
+	mirror *meterMirror
+}
 
 func (*MetricsObserver) Observable(ctx context.Context, metric string) (bool, error) {
 	return Observable(ctx, metric)
 }
 
 // NewObserver returns a pointer to a new instance of MetricsObserver.
-func NewObserver() *MetricsObserver {
+func NewObserver(options ...ObserverOption) *MetricsObserver {
 	o := MetricsObserver{observers: obsPool[observe]{
 		pool: sync.Pool{New: func() any { return &observe{} }},
 	}}
 
+	for _, option := range options {
+		option(&o)
+	}
+
+	if o.mirror != nil {
+		o.mirror.buckets = o.buckets
+	}
+
 	return &o
 }
 
-func (o *MetricsObserver) MessagesReceived(queueID string) Counter {
-	vmCounter := metrics.GetOrCreateCounter(
-		`messages_received_total{queue="` + queueID + `"}`,
-	)
-
-	obs := o.observers.get()
-	obs.inc = func() { vmCounter.Inc() }
-	obs.get = func() uint64 { return vmCounter.Get() }
-	obs.add = func(n uint64) {
-		if n > math.MaxInt {
-			vmCounter.Add(math.MaxInt)
-		} else {
-			vmCounter.Add(int(n))
-		}
+func (o *MetricsObserver) QueueTags(queueID string, tags map[string]string) {
+	if tags == nil {
+		o.labels.Delete(queueID)
+		return
 	}
 
-	return obs
+	o.labels.Set(queueID, tags)
 }
 
-func (o *MetricsObserver) MessagesDeleted(queueID string) Counter {
-	vmCounter := metrics.GetOrCreateCounter(
-		`messages_deleted_total{queue="` + queueID + `"}`,
-	)
+// queueLabels returns the labels of queue metrics.
+func (o *MetricsObserver) queueLabels(queueID string) string {
+	return `queue="` + queueID + `"` + o.labels.get(queueID)
+}
 
-	obs := o.observers.get()
-	obs.inc = func() { vmCounter.Inc() }
-	obs.get = func() uint64 { return vmCounter.Get() }
-	obs.add = func(n uint64) {
-		if n > math.MaxInt {
-			vmCounter.Add(math.MaxInt)
-		} else {
-			vmCounter.Add(int(n))
-		}
-	}
+func (o *MetricsObserver) MessagesReceived(queueID string) Counter {
+	return o.counter(`messages_received_total{` + o.queueLabels(queueID) + `}`)
+}
 
-	return obs
+func (o *MetricsObserver) MessagesDeleted(queueID string) Counter {
+	return o.counter(`messages_deleted_total{` + o.queueLabels(queueID) + `}`)
 }
 
 func (o *MetricsObserver) MessageDropped(queueID string, policy v1.EvictionPolicy) Counter {
-	vmCounter := metrics.GetOrCreateCounter(
-		`messages_dropped_total{queue="` + queueID + `", policy="` + policy.String() + `"}`,
-	)
+	return o.counter(`messages_dropped_total{` + o.queueLabels(queueID) + `, policy="` + policy.String() + `"}`)
+}
 
-	obs := o.observers.get()
-	obs.inc = func() { vmCounter.Inc() }
-	obs.get = func() uint64 { return vmCounter.Get() }
-	obs.add = func(n uint64) {
-		if n > math.MaxInt {
-			vmCounter.Add(math.MaxInt)
-		} else {
-			vmCounter.Add(int(n))
-		}
-	}
+func (o *MetricsObserver) EmptyReceives(queueID string) Counter {
+	return o.counter(`empty_receives_total{` + o.queueLabels(queueID) + `}`)
+}
 
-	return obs
+func (o *MetricsObserver) ReceiveRequests(queueID string) Counter {
+	return o.counter(`receive_requests_total{` + o.queueLabels(queueID) + `}`)
 }
 
-func (o *MetricsObserver) EmptyReceives(queueID string) Counter {
-	vmCounter := metrics.GetOrCreateCounter(
-		`messages_sent_total{queue="` + queueID + `"}`,
-	)
+func (o *MetricsObserver) MessagesSent(queueID string) Counter {
+	return o.counter(`messages_sent_total{` + o.queueLabels(queueID) + `}`)
+}
 
-	obs := o.observers.get()
-	obs.inc = func() { vmCounter.Inc() }
-	obs.get = func() uint64 { return vmCounter.Get() }
-	obs.add = func(n uint64) {
-		if n > math.MaxInt {
-			vmCounter.Add(math.MaxInt)
-		} else {
-			vmCounter.Add(int(n))
-		}
-	}
+func (o *MetricsObserver) MessagesSentBytes(queueID string) Counter {
+	return o.counter(`messages_sent_bytes_total{` + o.queueLabels(queueID) + `}`)
+}
 
-	return obs
+func (o *MetricsObserver) TimeInQueue(queueID string) Histogram {
+	return o.duration(MetricTimeInQueue, o.queueLabels(queueID))
 }
 
-func (o *MetricsObserver) MessagesSent(queueID string) Counter {
-	vmCounter := metrics.GetOrCreateCounter(
-		`messages_sent_total{queue="` + queueID + `"}`,
-	)
+func (o *MetricsObserver) QueuesExist() Gauge { return o.gauge(`queues_exist`) }
 
-	obs := o.observers.get()
-	obs.inc = func() { vmCounter.Inc() }
-	obs.get = func() uint64 { return vmCounter.Get() }
-	obs.add = func(n uint64) {
-		if n > math.MaxInt {
-			vmCounter.Add(math.MaxInt)
-		} else {
-			vmCounter.Add(int(n))
-		}
-	}
+func (o *MetricsObserver) QueueTablesPending() Gauge { return o.gauge(`queue_tables_pending`) }
 
-	return obs
+func (o *MetricsObserver) MessagesVisible(queueID string) Gauge {
+	return o.gauge(`messages_visible{` + o.queueLabels(queueID) + `}`)
 }
 
-func (o *MetricsObserver) MessagesSentBytes(queueID string) Counter {
-	vmCounter := metrics.GetOrCreateCounter(
-		`messages_sent_bytes_total{queue="` + queueID + `"}`,
-	)
+func (o *MetricsObserver) MessagesInFlight(queueID string) Gauge {
+	return o.gauge(`messages_in_flight{` + o.queueLabels(queueID) + `}`)
+}
 
-	obs := o.observers.get()
-	obs.inc = func() { vmCounter.Inc() }
-	obs.get = func() uint64 { return vmCounter.Get() }
-	obs.add = func(n uint64) {
-		if n > math.MaxInt {
-			vmCounter.Add(math.MaxInt)
-		} else {
-			vmCounter.Add(int(n))
-		}
-	}
+func (o *MetricsObserver) GCSchedules() Counter {
+	return o.counter(`gc_schedules_total`)
+}
 
-	return obs
+func (o *MetricsObserver) GCDuration() Histogram {
+	return o.duration(MetricGCDuration, "")
 }
 
-func (o *MetricsObserver) TimeInQueue(queueID string) Histogram {
-	vmHis := metrics.GetOrCreateHistogram(
-		`message_in_queue_duration{queue="` + queueID + `"}`,
-	)
+func (o *MetricsObserver) AuthFailures(reason string) Counter {
+	return o.counter(`auth_failures_total{reason="` + reason + `"}`)
+}
 
-	obs := o.observers.get()
-	obs.dur = func(t time.Time) { vmHis.UpdateDuration(t) }
-	obs.upd = func(n float64) { vmHis.Update(n) }
+func (o *MetricsObserver) AuthDenials(queueID, operation string) Counter {
+	return o.counter(`auth_denials_total{` + o.queueLabels(queueID) + `, operation="` + operation + `"}`)
+}
 
-	return obs
+func (o *MetricsObserver) GrantsCacheHits() Counter {
+	return o.counter(`grants_cache_hits_total`)
 }
 
-func (o *MetricsObserver) QueuesExist() Gauge {
-	vmGauge := metrics.GetOrCreateCounter(`queues_exist`)
+func (o *MetricsObserver) GrantsCacheMisses() Counter {
+	return o.counter(`grants_cache_misses_total`)
+}
+
+func (o *MetricsObserver) QueueCacheHits() Counter {
+	return o.counter(`queue_cache_hits_total`)
+}
+
+func (o *MetricsObserver) QueueCacheMisses() Counter {
+	return o.counter(`queue_cache_misses_total`)
+}
+
+func (o *MetricsObserver) QueueCacheEvictions(reason string) Counter {
+	return o.counter(`queue_cache_evictions_total{reason="` + reason + `"}`)
+}
+
+func (o *MetricsObserver) TokenErrors(reason string) Counter {
+	return o.counter(`token_errors_total{reason="` + reason + `"}`)
+}
+
+func (o *MetricsObserver) OAuthSyncFailures(provider string) Counter {
+	return o.counter(`oauth_sync_failures_total{provider="` + provider + `"}`)
+}
+
+func (o *MetricsObserver) BreakerTrips(queueID string) Counter {
+	return o.counter(`breaker_trips_total{` + o.queueLabels(queueID) + `}`)
+}
+
+func (o *MetricsObserver) RateLimited(scope string) Counter {
+	return o.counter(`rate_limited_total{scope="` + scope + `"}`)
+}
+
+func (o *MetricsObserver) StorageTxDuration(operation string) Histogram {
+	return o.duration(MetricStorageTxDuration, `operation="`+operation+`"`)
+}
+
+func (o *MetricsObserver) StorageBusyRetries(operation string) Counter {
+	return o.counter(`storage_busy_retries_total{operation="` + operation + `"}`)
+}
+
+func (o *MetricsObserver) StorageConflicts(operation string) Counter {
+	return o.counter(`storage_conflicts_total{operation="` + operation + `"}`)
+}
+
+func (o *MetricsObserver) MessagesOversized(queueID, scope string) Counter {
+	return o.counter(`messages_oversized_total{` + o.queueLabels(queueID) + `,limit="` + scope + `"}`)
+}
+
+func (o *MetricsObserver) MessagesOverQuota(queueID, action string) Counter {
+	return o.counter(`messages_over_quota_total{` + o.queueLabels(queueID) + `,action="` + action + `"}`)
+}
+
+func (o *MetricsObserver) Backups(database string) Counter {
+	return o.counter(`backups_total{database="` + database + `"}`)
+}
+
+func (o *MetricsObserver) BackupFailures(database string) Counter {
+	return o.counter(`backup_failures_total{database="` + database + `"}`)
+}
+
+func (o *MetricsObserver) BackupsFailing(database string) Gauge {
+	return o.gauge(`backups_failing{database="` + database + `"}`)
+}
+
+// gauge returns a Gauge backed by the VictoriaMetrics counter with given name.
+func (o *MetricsObserver) gauge(name string) Gauge {
+	vmGauge := metrics.GetOrCreateCounter(name)
 
 	obs := o.observers.get()
 	obs.inc = func() { vmGauge.Inc() }
@@ -277,12 +457,30 @@ func (o *MetricsObserver) QueuesExist() Gauge {
 			vmGauge.Add(-int(n))
 		}
 	}
+	obs.set = func(n uint64) { vmGauge.Set(n) }
+
+	if o.mirror != nil {
+		if m := o.mirror.gauge(name); m != nil {
+			inc, add, dec, sub, set := obs.inc, obs.add, obs.dec, obs.sub, obs.set
+
+			obs.inc = func() { inc(); m.instrument.Add(context.Background(), 1, m.attrs) }
+			obs.dec = func() { dec(); m.instrument.Add(context.Background(), -1, m.attrs) }
+			obs.add = func(n uint64) { add(n); m.instrument.Add(context.Background(), clampInt64(n), m.attrs) }
+			obs.sub = func(n uint64) { sub(n); m.instrument.Add(context.Background(), -clampInt64(n), m.attrs) }
+			obs.set = func(n uint64) {
+				prev := vmGauge.Get()
+				set(n)
+				m.instrument.Add(context.Background(), clampInt64(n)-clampInt64(prev), m.attrs)
+			}
+		}
+	}
 
 	return obs
 }
 
-func (o *MetricsObserver) GCSchedules() Counter {
-	vmCounter := metrics.GetOrCreateCounter(`gc_schedules_total`)
+// counter returns a Counter backed by the VictoriaMetrics counter with given name.
+func (o *MetricsObserver) counter(name string) Counter {
+	vmCounter := metrics.GetOrCreateCounter(name)
 
 	obs := o.observers.get()
 	obs.inc = func() { vmCounter.Inc() }
@@ -295,17 +493,25 @@ func (o *MetricsObserver) GCSchedules() Counter {
 		}
 	}
 
+	if o.mirror != nil {
+		if m := o.mirror.counter(name); m != nil {
+			inc, add := obs.inc, obs.add
+
+			obs.inc = func() { inc(); m.instrument.Add(context.Background(), 1, m.attrs) }
+			obs.add = func(n uint64) { add(n); m.instrument.Add(context.Background(), clampInt64(n), m.attrs) }
+		}
+	}
+
 	return obs
 }
 
-func (o *MetricsObserver) GCDuration() Histogram {
-	vmHis := metrics.GetOrCreateHistogram(`gc_duration`)
-
-	obs := o.observers.get()
-	obs.dur = func(t time.Time) { vmHis.UpdateDuration(t) }
-	obs.upd = func(n float64) { vmHis.Update(n) }
+// clampInt64 converts n to int64, clamping values which don't fit.
+func clampInt64(n uint64) int64 {
+	if n > math.MaxInt64 {
+		return math.MaxInt64
+	}
 
-	return obs
+	return int64(n)
 }
 
 // observe implements Counter and Gauge interfaces
@@ -316,18 +522,71 @@ type observe struct {
 	get func() uint64
 	add func(n uint64)
 	sub func(n uint64)
+	set func(n uint64)
 	dur func(t time.Time)
 	upd func(n float64)
+	qnt func(phi float64) float64
 }
 
 func (c *observe) Dec()                { c.dec() }
 func (c *observe) Inc()                { c.inc() }
 func (c *observe) Add(n uint64)        { c.add(n) }
 func (c *observe) Sub(n uint64)        { c.sub(n) }
+func (c *observe) Set(n uint64)        { c.set(n) }
 func (c *observe) Get() uint64         { return c.get() }
 func (c *observe) Dur(since time.Time) { c.dur(since) }
 func (c *observe) Upd(n float64)       { c.upd(n) }
 
+func (c *observe) Quantile(phi float64) float64 { return c.qnt(phi) }
+
+// histogramQuantile estimates the phi-quantile of the VictoriaMetrics histogram
+// by walking its buckets. The bucket ranges look like "1.000e-03...1.136e-03".
+func histogramQuantile(h *metrics.Histogram, phi float64) float64 {
+	type bucket struct {
+		upper float64
+		count uint64
+	}
+
+	var (
+		buckets []bucket
+		total   uint64
+	)
+
+	h.VisitNonZeroBuckets(func(vmrange string, count uint64) {
+		_, upperStr, ok := strings.Cut(vmrange, "...")
+		if !ok {
+			return
+		}
+
+		upper, err := strconv.ParseFloat(upperStr, 64)
+		if err != nil {
+			return
+		}
+
+		buckets = append(buckets, bucket{upper: upper, count: count})
+		total += count
+	})
+
+	if total == 0 {
+		return 0
+	}
+
+	slices.SortFunc(buckets, func(a, b bucket) int { return cmp.Compare(a.upper, b.upper) })
+
+	rank := uint64(math.Ceil(phi * float64(total)))
+
+	var seen uint64
+
+	for _, b := range buckets {
+		seen += b.count
+		if seen >= rank {
+			return b.upper
+		}
+	}
+
+	return buckets[len(buckets)-1].upper
+}
+
 type obsPool[T observe] struct{ pool sync.Pool }
 
 func (p *obsPool[T]) put(v *T) { p.pool.Put(v) }
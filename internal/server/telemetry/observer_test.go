@@ -1 +1,68 @@
 package telemetry
+
+import (
+	"testing"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func Test_histogramQuantile(t *testing.T) {
+	type tcase struct {
+		values []float64
+		phi    float64
+		want   any
+	}
+
+	tests := map[string]tcase{
+		"Empty":  {values: nil, phi: 0.9, want: 0.0},
+		"Single": {values: []float64{1}, phi: 0.5, want: td.Between(1.0, 1.2)},
+		"P90": {
+			values: []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 100},
+			phi:    0.9,
+			want:   td.Between(1.0, 1.2),
+		},
+		"P100": {
+			values: []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 100},
+			phi:    1,
+			want:   td.Between(100.0, 120.0),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := metrics.NewHistogram(`test_histogram_quantile{case="` + name + `"}`)
+
+			for _, v := range tc.values {
+				h.Update(v)
+			}
+
+			td.Cmp(t, histogramQuantile(h, tc.phi), tc.want)
+		})
+	}
+}
+
+func TestMetricsObserver_SecurityCounters(t *testing.T) {
+	o := NewObserver()
+
+	o.AuthFailures(ReasonInvalidCredentials).Inc()
+	o.AuthFailures(ReasonInvalidCredentials).Add(2)
+	o.AuthDenials("queue-a", "send").Inc()
+	o.TokenErrors(ReasonExpiredToken).Inc()
+	o.OAuthSyncFailures("github").Inc()
+
+	td.Cmp(t, o.AuthFailures(ReasonInvalidCredentials).Get(), uint64(3))
+	td.Cmp(t, o.AuthFailures(ReasonMissingCredentials).Get(), uint64(0))
+	td.Cmp(t, o.AuthDenials("queue-a", "send").Get(), uint64(1))
+	td.Cmp(t, o.AuthDenials("queue-a", "receive").Get(), uint64(0))
+	td.Cmp(t, o.TokenErrors(ReasonExpiredToken).Get(), uint64(1))
+	td.Cmp(t, o.OAuthSyncFailures("github").Get(), uint64(1))
+
+	for _, metric := range []string{
+		"auth_failures_total", "auth_denials_total", "token_errors_total", "oauth_sync_failures_total",
+	} {
+		ok, err := o.Observable(t.Context(), metric)
+		td.CmpNoError(t, err)
+		td.CmpTrue(t, ok, metric)
+	}
+}
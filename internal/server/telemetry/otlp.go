@@ -0,0 +1,239 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// otlpDurationBuckets holds upper bounds of buckets in seconds of
+// mirrored duration metrics which have no configured buckets.
+var otlpDurationBuckets = []float64{
+	0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600,
+}
+
+// OTLPConfig holds the configuration of the metrics export over OTLP.
+type OTLPConfig struct {
+	// Endpoint is the address of the OTLP gRPC collector, e.g. "localhost:4317".
+	Endpoint string
+
+	// Insecure disables TLS of the connection to the collector.
+	Insecure bool
+
+	// Headers are sent to the collector with each export, e.g. authentication.
+	Headers map[string]string
+
+	// Interval is the interval between exports.
+	Interval time.Duration
+
+	// ServiceName is the name of the service in exported metrics.
+	ServiceName string
+}
+
+// StartOTLP returns the meter provider which periodically exports metrics to the collector.
+// The returned function exports pending metrics and stops the export.
+func StartOTLP(ctx context.Context, cfg OTLPConfig) (metric.MeterProvider, func(context.Context) error, error) {
+	options := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(cfg.Endpoint),
+		otlpmetricgrpc.WithHeaders(cfg.Headers),
+	}
+
+	if cfg.Insecure {
+		options = append(options, otlpmetricgrpc.WithInsecure())
+	}
+
+	exporter, exporterErr := otlpmetricgrpc.New(ctx, options...)
+	if exporterErr != nil {
+		return nil, nil, fmt.Errorf("create exporter: %w", exporterErr)
+	}
+
+	res, resErr := resource.Merge(resource.Default(),
+		resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName)),
+	)
+	if resErr != nil {
+		return nil, nil, fmt.Errorf("create resource: %w", resErr)
+	}
+
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(cfg.Interval))),
+		sdkmetric.WithResource(res),
+	)
+
+	return provider, provider.Shutdown, nil
+}
+
+// WithMeter mirrors observed metrics to instruments of the meter, e.g. to export
+// them over OTLP along with the /metrics endpoint. Counters are mirrored as counters,
+// gauges as up-down counters and durations as histograms in seconds, with labels
+// as attributes. Histograms have buckets set by WithDurationBuckets.
+func WithMeter(meter metric.Meter) ObserverOption {
+	return func(o *MetricsObserver) {
+		o.mirror = &meterMirror{meter: meter, instruments: make(map[string]any)}
+	}
+}
+
+// meterMirror holds instruments which mirror observed metrics.
+type meterMirror struct {
+	meter metric.Meter
+	// buckets holds upper bounds of histogram buckets in seconds by metric name.
+	buckets map[string][]float64
+
+	mu sync.Mutex
+	// instruments holds instruments by metric family.
+	instruments map[string]any
+	// bindings holds instruments with attributes by the full metric name.
+	bindings sync.Map
+}
+
+// mirrored binds the instrument to attributes of the metric.
+type mirrored[T any] struct {
+	instrument T
+	attrs      metric.MeasurementOption
+}
+
+// counter returns the counter which mirrors the metric with given full name.
+func (m *meterMirror) counter(name string) *mirrored[metric.Int64Counter] {
+	return bind(m, name, func(family string) (metric.Int64Counter, error) {
+		return m.meter.Int64Counter(family)
+	})
+}
+
+// gauge returns the up-down counter which mirrors the metric with given full name.
+func (m *meterMirror) gauge(name string) *mirrored[metric.Int64UpDownCounter] {
+	return bind(m, name, func(family string) (metric.Int64UpDownCounter, error) {
+		return m.meter.Int64UpDownCounter(family)
+	})
+}
+
+// histogram returns the histogram which mirrors the duration metric with given full name.
+func (m *meterMirror) histogram(name string) *mirrored[metric.Float64Histogram] {
+	return bind(m, name, func(family string) (metric.Float64Histogram, error) {
+		bounds, ok := m.buckets[family]
+		if !ok {
+			bounds = otlpDurationBuckets
+		}
+
+		return m.meter.Float64Histogram(family,
+			metric.WithUnit("s"),
+			metric.WithExplicitBucketBoundaries(bounds...),
+		)
+	})
+}
+
+// bind returns the instrument of the metric family bound to labels of the metric,
+// creating the instrument with newFn when the family is mirrored for the first time.
+// It returns nil when the instrument can't be created.
+func bind[T any](m *meterMirror, name string, newFn func(family string) (T, error)) *mirrored[T] {
+	if v, ok := m.bindings.Load(name); ok {
+		b, _ := v.(*mirrored[T])
+		return b
+	}
+
+	family, attrs, parseErr := parseMetricName(name)
+	if parseErr != nil {
+		otel.Handle(fmt.Errorf("mirror metric %q: %w", name, parseErr))
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	instrument, ok := m.instruments[family].(T)
+	if !ok {
+		i, newErr := newFn(family)
+		if newErr != nil {
+			otel.Handle(fmt.Errorf("mirror metric %q: %w", family, newErr))
+			return nil
+		}
+
+		m.instruments[family] = i
+		instrument = i
+	}
+
+	b := mirrored[T]{
+		instrument: instrument,
+		attrs:      metric.WithAttributeSet(attribute.NewSet(attrs...)),
+	}
+
+	v, _ := m.bindings.LoadOrStore(name, &b)
+	bound, _ := v.(*mirrored[T])
+
+	return bound
+}
+
+// parseMetricName splits the metric name in form: family{key="value", ...}
+// to the family and labels. Label values are unescaped according
+// to the Prometheus text format.
+func parseMetricName(name string) (string, []attribute.KeyValue, error) {
+	family, labels, ok := strings.Cut(name, "{")
+	if !ok {
+		return name, nil, nil
+	}
+
+	labels, ok = strings.CutSuffix(labels, "}")
+	if !ok {
+		return "", nil, fmt.Errorf("labels should end with '}'")
+	}
+
+	var attrs []attribute.KeyValue
+
+	for {
+		labels = strings.TrimLeft(labels, ", ")
+		if labels == "" {
+			return family, attrs, nil
+		}
+
+		key, rest, ok := strings.Cut(labels, `="`)
+		if !ok || key == "" {
+			return "", nil, fmt.Errorf("invalid label %q", labels)
+		}
+
+		var (
+			value   strings.Builder
+			escaped bool
+			end     = -1
+		)
+
+		for i, r := range rest {
+			if escaped {
+				if r == 'n' {
+					r = '\n'
+				}
+
+				value.WriteRune(r)
+				escaped = false
+
+				continue
+			}
+
+			if r == '\\' {
+				escaped = true
+				continue
+			}
+
+			if r == '"' {
+				end = i
+				break
+			}
+
+			value.WriteRune(r)
+		}
+
+		if end < 0 {
+			return "", nil, fmt.Errorf("unterminated value of label %q", key)
+		}
+
+		attrs = append(attrs, attribute.String(strings.TrimSpace(key), value.String()))
+		labels = rest[end+1:]
+	}
+}
@@ -0,0 +1,101 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func Test_parseMetricName(t *testing.T) {
+	type tcase struct {
+		name       string
+		wantFamily string
+		wantAttrs  []attribute.KeyValue
+		wantErr    bool
+	}
+
+	tests := map[string]tcase{
+		"NoLabels": {name: "gc_schedules_total", wantFamily: "gc_schedules_total"},
+		"Labels": {
+			name:       `messages_dropped_total{queue="q1", team="billing",policy="DROP"}`,
+			wantFamily: "messages_dropped_total",
+			wantAttrs: []attribute.KeyValue{
+				attribute.String("queue", "q1"), attribute.String("team", "billing"), attribute.String("policy", "DROP"),
+			},
+		},
+		"Escaped": {
+			name:       `messages_sent_total{queue="q1", note="say \"hi\"\nand, bye\\"}`,
+			wantFamily: "messages_sent_total",
+			wantAttrs:  []attribute.KeyValue{attribute.String("queue", "q1"), attribute.String("note", "say \"hi\"\nand, bye\\")},
+		},
+		"Unclosed":     {name: `messages_sent_total{queue="q1"`, wantErr: true},
+		"Unterminated": {name: `messages_sent_total{queue="q1}`, wantErr: true},
+		"NoValue":      {name: `messages_sent_total{queue}`, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			family, attrs, err := parseMetricName(tc.name)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, family, tc.wantFamily)
+			td.Cmp(t, attrs, tc.wantAttrs)
+		})
+	}
+}
+
+func TestWithMeter(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	o := NewObserver(
+		WithMeter(provider.Meter("test")),
+		WithDurationBuckets(MetricGCDuration, []time.Duration{time.Second, time.Minute}),
+	)
+
+	o.RateLimited("otlp-test").Inc()
+	o.RateLimited("otlp-test").Add(2)
+	o.QueueTablesPending().Add(5)
+	o.QueueTablesPending().Dec()
+	o.MessagesVisible("otlp-test").Add(2)
+	o.MessagesVisible("otlp-test").Set(7)
+	o.GCDuration().Dur(time.Now().Add(-2 * time.Second))
+
+	var rm metricdata.ResourceMetrics
+	td.CmpNoError(t, reader.Collect(t.Context(), &rm))
+	td.Cmp(t, rm.ScopeMetrics, td.Len(1))
+
+	got := make(map[string]metricdata.Aggregation)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		got[m.Name] = m.Data
+	}
+
+	td.Cmp(t, got["rate_limited_total"], td.Struct(metricdata.Sum[int64]{IsMonotonic: true}, td.StructFields{
+		"DataPoints": td.Smuggle("[0]", td.Struct(metricdata.DataPoint[int64]{Value: 3}, td.StructFields{
+			"Attributes": attribute.NewSet(attribute.String("scope", "otlp-test")),
+		})),
+	}))
+
+	td.Cmp(t, got["queue_tables_pending"], td.Struct(metricdata.Sum[int64]{IsMonotonic: false}, td.StructFields{
+		"DataPoints": td.Smuggle("[0].Value", int64(4)),
+	}))
+
+	td.Cmp(t, got["messages_visible"], td.Struct(metricdata.Sum[int64]{IsMonotonic: false}, td.StructFields{
+		"DataPoints": td.Smuggle("[0].Value", int64(7)),
+	}))
+
+	td.Cmp(t, got["gc_duration"], td.Struct(metricdata.Histogram[float64]{}, td.StructFields{
+		"DataPoints": td.Smuggle("[0]", td.Struct(metricdata.HistogramDataPoint[float64]{Count: 1}, td.StructFields{
+			"Bounds":       []float64{1, 60},
+			"BucketCounts": []uint64{0, 1, 0},
+		})),
+	}))
+}
@@ -0,0 +1,138 @@
+package telemetry
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/plainq/plainq/internal/shared/pqtime"
+)
+
+// prometheusErrorBodySize limits the part of the response body which is reported in errors.
+const prometheusErrorBodySize = 512
+
+// PrometheusQuerier serves historical metrics from the Prometheus HTTP API,
+// which scrapes the /metrics endpoint or receives metrics by remote-write.
+type PrometheusQuerier struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewPrometheusQuerier returns a pointer to a new instance of PrometheusQuerier.
+// The baseURL is the address of the Prometheus server, e.g. "http://prometheus:9090".
+func NewPrometheusQuerier(baseURL string, client *http.Client) *PrometheusQuerier {
+	q := PrometheusQuerier{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+	}
+
+	return &q
+}
+
+func (p *PrometheusQuerier) Query(ctx context.Context, q Query) ([]Metric, error) {
+	params := url.Values{
+		"query": {promSelector(q.Metric, q.QueueID)},
+		"start": {strconv.FormatFloat(pqtime.TimeToFloat64(q.From), 'f', -1, 64)},
+		"end":   {strconv.FormatFloat(pqtime.TimeToFloat64(q.To), 'f', -1, 64)},
+		"step":  {strconv.FormatFloat(q.Step.Seconds(), 'f', -1, 64)},
+	}
+
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet,
+		p.baseURL+"/api/v1/query_range?"+params.Encode(), nil,
+	)
+	if reqErr != nil {
+		return nil, fmt.Errorf("create request: %w", reqErr)
+	}
+
+	resp, doErr := p.client.Do(req)
+	if doErr != nil {
+		return nil, fmt.Errorf("send request: %w", doErr)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, prometheusErrorBodySize))
+		return nil, fmt.Errorf("unexpected response status %q: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var body promResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	return body.metrics(q.Metric)
+}
+
+// promSelector returns the PromQL selector of the metric series of the queue,
+// or of the series which don't belong to queues when the queue is empty.
+func promSelector(metric, queueID string) string {
+	return metric + `{queue="` + labelValueEscaper.Replace(queueID) + `"}`
+}
+
+// promResponse represents the response of the Prometheus range query.
+type promResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][2]any          `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// metrics converts the matrix of the response to metrics with the name.
+func (r *promResponse) metrics(name string) ([]Metric, error) {
+	if r.Status != "success" {
+		return nil, fmt.Errorf("query failed: %s", r.Error)
+	}
+
+	if r.Data.ResultType != "matrix" {
+		return nil, fmt.Errorf("unexpected result type %q", r.Data.ResultType)
+	}
+
+	result := make([]Metric, 0, len(r.Data.Result))
+
+	for _, series := range r.Data.Result {
+		m := Metric{Name: name, Labels: make(Labels, 0, len(series.Metric))}
+
+		for k, v := range series.Metric {
+			if k != "__name__" {
+				m.Labels = append(m.Labels, Label{Key: k, Value: v})
+			}
+		}
+
+		slices.SortFunc(m.Labels, func(a, b Label) int { return cmp.Compare(a.Key, b.Key) })
+
+		for _, pair := range series.Values {
+			ts, tsOK := pair[0].(float64)
+			raw, rawOK := pair[1].(string)
+
+			if !tsOK || !rawOK {
+				return nil, fmt.Errorf("invalid datapoint %v", pair)
+			}
+
+			value, parseErr := strconv.ParseFloat(raw, 64)
+			if parseErr != nil {
+				return nil, fmt.Errorf("invalid datapoint value %q: %w", raw, parseErr)
+			}
+
+			m.Values = append(m.Values, Datapoint{Timestamp: pqtime.Float64ToTime(ts).UTC(), Value: value})
+		}
+
+		result = append(result, m)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package telemetry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestPrometheusQuerier_Query(t *testing.T) {
+	var (
+		params url.Values
+		status = http.StatusOK
+		body   = `{"status":"success","data":{"resultType":"matrix","result":[` +
+			`{"metric":{"__name__":"messages_sent_total","queue":"q1","team":"a"},"values":[[1704067200,"1"],[1704067260.5,"3"]]}]}}`
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		td.Cmp(t, r.URL.Path, "/api/v1/query_range")
+		params = r.URL.Query()
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	querier := NewPrometheusQuerier(server.URL+"/", server.Client())
+	query := Query{Metric: "messages_sent_total", QueueID: "q1", From: from, To: from.Add(time.Hour), Step: time.Minute}
+
+	got, err := querier.Query(t.Context(), query)
+	td.CmpNoError(t, err)
+	td.Cmp(t, params.Get("query"), `messages_sent_total{queue="q1"}`)
+	td.Cmp(t, params.Get("start"), "1704067200")
+	td.Cmp(t, params.Get("end"), "1704070800")
+	td.Cmp(t, params.Get("step"), "60")
+	td.Cmp(t, got, []Metric{{
+		Name:   "messages_sent_total",
+		Labels: Labels{{Key: "queue", Value: "q1"}, {Key: "team", Value: "a"}},
+		Values: []Datapoint{
+			{Timestamp: from, Value: 1},
+			{Timestamp: from.Add(time.Minute + 500*time.Millisecond), Value: 3},
+		},
+	}})
+
+	body = `{"status":"error","error":"bad query"}`
+	_, err = querier.Query(t.Context(), query)
+	td.CmpContains(t, err, "bad query")
+
+	status, body = http.StatusServiceUnavailable, "overloaded"
+	_, err = querier.Query(t.Context(), query)
+	td.CmpContains(t, err, "overloaded")
+}
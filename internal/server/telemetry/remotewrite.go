@@ -0,0 +1,238 @@
+package telemetry
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/klauspost/compress/s2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// remoteWriteErrorBodySize limits the part of the response body which is reported in errors.
+const remoteWriteErrorBodySize = 512
+
+// RemoteWriteConfig holds the configuration of the Prometheus remote-write.
+type RemoteWriteConfig struct {
+	// URL is the remote-write endpoint, e.g. "https://prometheus:9090/api/v1/write".
+	URL string
+
+	// Interval is the interval between pushes.
+	Interval time.Duration
+
+	// Timeout limits the duration of each push.
+	Timeout time.Duration
+
+	// Headers are sent with each push, e.g. authentication.
+	Headers map[string]string
+
+	// Labels are attached to each series, e.g. the instance, since pushed
+	// series don't get labels of the scrape target. Labels of metrics take precedence.
+	Labels map[string]string
+}
+
+// RemoteWriter pushes metrics of the /metrics endpoint to the Prometheus remote-write endpoint.
+type RemoteWriter struct {
+	cfg    RemoteWriteConfig
+	client *http.Client
+	logger *slog.Logger
+
+	// gather writes metrics in the Prometheus text format.
+	gather func(w io.Writer)
+}
+
+// NewRemoteWriter returns a pointer to a new instance of RemoteWriter.
+func NewRemoteWriter(cfg RemoteWriteConfig, logger *slog.Logger) *RemoteWriter {
+	w := RemoteWriter{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		logger: logger,
+		gather: func(w io.Writer) { metrics.WritePrometheus(w, true) },
+	}
+
+	return &w
+}
+
+// Run pushes metrics on the interval until the context is canceled,
+// then pushes them for the last time, so the final values aren't lost.
+// Failed pushes are logged and the metrics are pushed again on the next tick.
+func (w *RemoteWriter) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			pushCtx, cancel := context.WithTimeout(context.Background(), w.cfg.Timeout)
+			defer cancel()
+
+			if err := w.Push(pushCtx); err != nil {
+				w.logger.Error("Failed to push metrics on shutdown",
+					slog.String("error", err.Error()),
+				)
+			}
+
+			return
+
+		case <-ticker.C:
+			if err := w.Push(ctx); err != nil {
+				w.logger.Error("Failed to push metrics",
+					slog.String("url", w.cfg.URL),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
+
+// Push sends the current values of metrics to the remote-write endpoint.
+func (w *RemoteWriter) Push(ctx context.Context) error {
+	var text bytes.Buffer
+
+	w.gather(&text)
+
+	series, parseErr := parseExposition(text.String(), time.Now(), w.cfg.Labels)
+	if parseErr != nil {
+		return fmt.Errorf("parse metrics: %w", parseErr)
+	}
+
+	body := s2.EncodeSnappy(nil, encodeWriteRequest(series))
+
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if reqErr != nil {
+		return fmt.Errorf("create request: %w", reqErr)
+	}
+
+	for k, v := range w.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, doErr := w.client.Do(req)
+	if doErr != nil {
+		return fmt.Errorf("send request: %w", doErr)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, remoteWriteErrorBodySize))
+		return fmt.Errorf("unexpected response status %q: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+// timeSeries represents the sample of the series with sorted labels, including the metric name.
+type timeSeries struct {
+	labels    Labels
+	value     float64
+	timestamp time.Time
+}
+
+// parseExposition parses metrics in the Prometheus text format to series
+// with the timestamp and the extra labels. Comments are skipped.
+func parseExposition(text string, timestamp time.Time, extra map[string]string) ([]timeSeries, error) {
+	var series []timeSeries
+
+	for line := range strings.Lines(text) {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Label values may hold spaces, while values never hold braces.
+		var name, rest string
+
+		if end := strings.LastIndexByte(line, '}'); end >= 0 && strings.IndexByte(line, '{') >= 0 {
+			name, rest = line[:end+1], line[end+1:]
+		} else {
+			name, rest, _ = strings.Cut(line, " ")
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("no value of metric %q", name)
+		}
+
+		value, valueErr := strconv.ParseFloat(fields[0], 64)
+		if valueErr != nil {
+			return nil, fmt.Errorf("invalid value of metric %q: %w", name, valueErr)
+		}
+
+		family, attrs, nameErr := parseMetricName(name)
+		if nameErr != nil {
+			return nil, fmt.Errorf("invalid metric %q: %w", name, nameErr)
+		}
+
+		labels := make(Labels, 0, len(attrs)+len(extra)+1)
+		labels = append(labels, Label{Key: "__name__", Value: family})
+
+		for _, a := range attrs {
+			labels = append(labels, Label{Key: string(a.Key), Value: a.Value.AsString()})
+		}
+
+		for k, v := range extra {
+			if !slices.ContainsFunc(labels, func(l Label) bool { return l.Key == k }) {
+				labels = append(labels, Label{Key: k, Value: v})
+			}
+		}
+
+		slices.SortFunc(labels, func(a, b Label) int { return cmp.Compare(a.Key, b.Key) })
+
+		series = append(series, timeSeries{labels: labels, value: value, timestamp: timestamp})
+	}
+
+	return series, nil
+}
+
+// encodeWriteRequest encodes series to the remote-write WriteRequest protobuf message:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(series []timeSeries) []byte {
+	var req, ts, msg []byte
+
+	for _, s := range series {
+		ts = ts[:0]
+
+		for _, l := range s.labels {
+			msg = msg[:0]
+			msg = protowire.AppendTag(msg, 1, protowire.BytesType)
+			msg = protowire.AppendString(msg, l.Key)
+			msg = protowire.AppendTag(msg, 2, protowire.BytesType)
+			msg = protowire.AppendString(msg, l.Value)
+
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, msg)
+		}
+
+		msg = msg[:0]
+		msg = protowire.AppendTag(msg, 1, protowire.Fixed64Type)
+		msg = protowire.AppendFixed64(msg, math.Float64bits(s.value))
+		msg = protowire.AppendTag(msg, 2, protowire.VarintType)
+		msg = protowire.AppendVarint(msg, uint64(s.timestamp.UnixMilli()))
+
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, msg)
+
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, ts)
+	}
+
+	return req
+}
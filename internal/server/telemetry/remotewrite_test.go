@@ -0,0 +1,138 @@
+package telemetry
+
+import (
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/maxatome/go-testdeep/td"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func Test_parseExposition(t *testing.T) {
+	now := time.Now()
+
+	type tcase struct {
+		text    string
+		extra   map[string]string
+		want    []timeSeries
+		wantErr bool
+	}
+
+	tests := map[string]tcase{
+		"Empty": {text: "# HELP nothing\n\n"},
+		"Series": {
+			text:  "gc_schedules_total 3\n" + `messages_sent_total{queue="q1", team="a b"} 10` + "\n",
+			extra: map[string]string{"instance": "plainq-1", "team": "ignored"},
+			want: []timeSeries{
+				{
+					labels:    Labels{{Key: "__name__", Value: "gc_schedules_total"}, {Key: "instance", Value: "plainq-1"}, {Key: "team", Value: "ignored"}},
+					value:     3,
+					timestamp: now,
+				},
+				{
+					labels: Labels{
+						{Key: "__name__", Value: "messages_sent_total"}, {Key: "instance", Value: "plainq-1"},
+						{Key: "queue", Value: "q1"}, {Key: "team", Value: "a b"},
+					},
+					value:     10,
+					timestamp: now,
+				},
+			},
+		},
+		"NoValue":      {text: "gc_schedules_total\n", wantErr: true},
+		"InvalidValue": {text: "gc_schedules_total many\n", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseExposition(tc.text, now, tc.extra)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
+
+func TestRemoteWriter_Push(t *testing.T) {
+	var (
+		header http.Header
+		body   []byte
+		status = http.StatusNoContent
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	writer := NewRemoteWriter(RemoteWriteConfig{
+		URL:     server.URL,
+		Timeout: time.Second,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}, slog.New(slog.DiscardHandler))
+	writer.gather = func(w io.Writer) { _, _ = io.WriteString(w, `rate_limited_total{scope="client"} 7`+"\n") }
+
+	td.CmpNoError(t, writer.Push(t.Context()))
+	td.Cmp(t, header.Get("Authorization"), "Bearer token")
+	td.Cmp(t, header.Get("Content-Encoding"), "snappy")
+	td.Cmp(t, header.Get("X-Prometheus-Remote-Write-Version"), "0.1.0")
+
+	decoded, decodeErr := s2.Decode(nil, body)
+	td.CmpNoError(t, decodeErr)
+
+	// WriteRequest > TimeSeries > Label and Sample fields.
+	var (
+		labels []string
+		value  float64
+	)
+
+	consume := func(b []byte, fn func(num protowire.Number, v []byte)) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			b = b[n:]
+
+			switch typ {
+			case protowire.BytesType:
+				v, m := protowire.ConsumeBytes(b)
+				fn(num, v)
+				b = b[m:]
+			case protowire.Fixed64Type:
+				v, m := protowire.ConsumeFixed64(b)
+				value = math.Float64frombits(v)
+				b = b[m:]
+			default:
+				_, m := protowire.ConsumeVarint(b)
+				b = b[m:]
+			}
+		}
+	}
+
+	consume(decoded, func(_ protowire.Number, ts []byte) {
+		consume(ts, func(num protowire.Number, msg []byte) {
+			if num == 1 {
+				consume(msg, func(_ protowire.Number, s []byte) { labels = append(labels, string(s)) })
+				return
+			}
+
+			consume(msg, func(protowire.Number, []byte) {})
+		})
+	})
+
+	td.Cmp(t, labels, []string{"__name__", "rate_limited_total", "scope", "client"})
+	td.Cmp(t, value, 7.0)
+
+	status = http.StatusBadRequest
+	td.CmpContains(t, writer.Push(t.Context()), "400")
+}
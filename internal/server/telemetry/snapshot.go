@@ -0,0 +1,58 @@
+package telemetry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// Sample represents the current value of a series of an observed metric.
+type Sample struct {
+	// Name is the metric name, e.g. "messages_visible".
+	Name string
+
+	// QueueID is the queue of the series, empty for series which don't belong to queues.
+	QueueID string
+
+	// Labels are the rest of the series labels.
+	Labels Labels
+
+	// Value is the current value of the series.
+	Value float64
+}
+
+// Snapshot returns current values of series of observed metrics. Histogram buckets,
+// sums and counts are not included, since their families are not observed metrics,
+// as well as process metrics.
+func Snapshot() ([]Sample, error) {
+	return snapshot(func(w io.Writer) { metrics.WritePrometheus(w, false) })
+}
+
+// snapshot returns samples of observed metrics written by the gather in the Prometheus text format.
+func snapshot(gather func(w io.Writer)) ([]Sample, error) {
+	var text bytes.Buffer
+
+	gather(&text)
+
+	series, parseErr := parseExposition(text.String(), time.Now(), nil)
+	if parseErr != nil {
+		return nil, fmt.Errorf("parse metrics: %w", parseErr)
+	}
+
+	samples := make([]Sample, 0, len(series))
+
+	for _, ts := range series {
+		name, queueID, labels := splitSeriesLabels(ts.labels)
+
+		if _, ok := observedMetrics[name]; !ok {
+			continue
+		}
+
+		samples = append(samples, Sample{Name: name, QueueID: queueID, Labels: labels, Value: ts.value})
+	}
+
+	return samples, nil
+}
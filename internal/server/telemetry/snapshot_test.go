@@ -0,0 +1,24 @@
+package telemetry
+
+import (
+	"io"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func Test_snapshot(t *testing.T) {
+	samples, err := snapshot(func(w io.Writer) {
+		_, _ = io.WriteString(w, `messages_visible{queue="q1"} 12
+rate_limited_total{scope="client"} 3
+gc_duration_bucket{vmrange="1.000e-01...1.136e-01"} 2
+go_goroutines 9
+`)
+	})
+
+	td.CmpNoError(t, err)
+	td.Cmp(t, samples, []Sample{
+		{Name: "messages_visible", QueueID: "q1", Labels: Labels{}, Value: 12},
+		{Name: "rate_limited_total", Labels: Labels{{Key: "scope", Value: "client"}}, Value: 3},
+	})
+}
@@ -0,0 +1,236 @@
+package telemetry
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/plainq/plainq/internal/shared/pqtime"
+)
+
+const (
+	sqliteInsertMetric = `insert into metrics (queue_id, metric_name, metric_value, timestamp, labels) values (?, ?, ?, ?, ?);`
+	sqliteDeleteBefore = `delete from metrics where timestamp < ?;`
+	sqliteVacuumInto   = `vacuum into ?;`
+	sqliteSelectRange  = `select metric_value, timestamp, labels from metrics
+		where queue_id = ? and metric_name = ? and timestamp >= ? and timestamp <= ?
+		order by timestamp;`
+)
+
+// SQLiteConfig holds the configuration of the SQLite telemetry store.
+type SQLiteConfig struct {
+	// Interval is the interval between collections of metrics.
+	Interval time.Duration
+
+	// GCInterval is the interval between removals of expired datapoints.
+	GCInterval time.Duration
+
+	// Retention is the period datapoints are kept for.
+	Retention time.Duration
+}
+
+// SQLiteStore periodically stores values of observed metrics
+// in the SQLite database and serves them as historical metrics.
+type SQLiteStore struct {
+	db     *sql.DB
+	cfg    SQLiteConfig
+	logger *slog.Logger
+
+	// gather writes metrics in the Prometheus text format.
+	gather func(w io.Writer)
+}
+
+// NewSQLiteStore returns a pointer to a new instance of SQLiteStore.
+// The database should have the schema of telemetry mutations.
+func NewSQLiteStore(db *sql.DB, cfg SQLiteConfig, logger *slog.Logger) *SQLiteStore {
+	s := SQLiteStore{
+		db:     db,
+		cfg:    cfg,
+		logger: logger,
+		gather: func(w io.Writer) { metrics.WritePrometheus(w, false) },
+	}
+
+	return &s
+}
+
+// Health implements the hc.HealthChecker interface.
+func (s *SQLiteStore) Health(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping telemetry database: %w", err)
+	}
+
+	return nil
+}
+
+// Run collects metrics and removes expired datapoints
+// on their intervals until the context is canceled.
+func (s *SQLiteStore) Run(ctx context.Context) {
+	collect := time.NewTicker(s.cfg.Interval)
+	defer collect.Stop()
+
+	gc := time.NewTicker(s.cfg.GCInterval)
+	defer gc.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-collect.C:
+			if err := s.Collect(ctx); err != nil {
+				s.logger.Error("Failed to collect metrics",
+					slog.String("error", err.Error()),
+				)
+			}
+
+		case <-gc.C:
+			if err := s.GC(ctx); err != nil {
+				s.logger.Error("Failed to remove expired metrics",
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
+
+// Collect stores the current values of observed metrics.
+func (s *SQLiteStore) Collect(ctx context.Context) error {
+	samples, snapshotErr := snapshot(s.gather)
+	if snapshotErr != nil {
+		return snapshotErr
+	}
+
+	timestamp := pqtime.TimeToFloat64(time.Now())
+
+	tx, txErr := s.db.BeginTx(ctx, nil)
+	if txErr != nil {
+		return fmt.Errorf("begin transaction: %w", txErr)
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, stmtErr := tx.PrepareContext(ctx, sqliteInsertMetric)
+	if stmtErr != nil {
+		return fmt.Errorf("prepare statement: %w", stmtErr)
+	}
+
+	defer func() { _ = stmt.Close() }()
+
+	for _, sample := range samples {
+		// Label values may hold any characters, so labels are stored as JSON.
+		encoded, encodeErr := sample.Labels.MarshalJSON()
+		if encodeErr != nil {
+			return fmt.Errorf("encode labels of metric %q: %w", sample.Name, encodeErr)
+		}
+
+		if _, err := stmt.ExecContext(ctx, sample.QueueID, sample.Name, sample.Value, timestamp, string(encoded)); err != nil {
+			return fmt.Errorf("insert metric %q: %w", sample.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GC removes datapoints older than the retention period.
+func (s *SQLiteStore) GC(ctx context.Context) error {
+	before := time.Now().Add(-s.cfg.Retention)
+
+	if _, err := s.db.ExecContext(ctx, sqliteDeleteBefore, pqtime.TimeToFloat64(before)); err != nil {
+		return fmt.Errorf("delete metrics: %w", err)
+	}
+
+	return nil
+}
+
+// Snapshot writes a consistent copy of the database to a new file at the path.
+func (s *SQLiteStore) Snapshot(ctx context.Context, path string) error {
+	if _, err := s.db.ExecContext(ctx, sqliteVacuumInto, path); err != nil {
+		return fmt.Errorf("write snapshot to %q: %w", path, err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStore) Query(ctx context.Context, q Query) ([]Metric, error) {
+	rows, queryErr := s.db.QueryContext(ctx, sqliteSelectRange,
+		q.QueueID, q.Metric, pqtime.TimeToFloat64(q.From), pqtime.TimeToFloat64(q.To),
+	)
+	if queryErr != nil {
+		return nil, fmt.Errorf("select metrics: %w", queryErr)
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	var (
+		result []Metric
+		index  = make(map[string]int)
+	)
+
+	for rows.Next() {
+		var (
+			value     float64
+			timestamp float64
+			labels    string
+		)
+
+		if err := rows.Scan(&value, &timestamp, &labels); err != nil {
+			return nil, fmt.Errorf("scan metric: %w", err)
+		}
+
+		i, ok := index[labels]
+		if !ok {
+			var parsed Labels
+
+			if err := parsed.UnmarshalJSON([]byte(labels)); err != nil {
+				return nil, fmt.Errorf("parse labels %q: %w", labels, err)
+			}
+
+			if q.QueueID != "" {
+				parsed = append(Labels{{Key: "queue", Value: q.QueueID}}, parsed...)
+			}
+
+			i = len(result)
+			index[labels] = i
+			result = append(result, Metric{Name: q.Metric, Labels: parsed})
+		}
+
+		result[i].Values = append(result[i].Values, Datapoint{Timestamp: pqtime.Float64ToTime(timestamp).UTC(), Value: value})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate metrics: %w", err)
+	}
+
+	for i := range result {
+		result[i].Values = downsample(result[i].Values, q.From, q.Step)
+	}
+
+	return result, nil
+}
+
+// splitSeriesLabels splits sorted labels of the series to the metric name, the queue and the rest.
+func splitSeriesLabels(labels Labels) (name, queueID string, rest Labels) {
+	rest = slices.DeleteFunc(slices.Clone(labels), func(l Label) bool {
+		switch l.Key {
+		case "__name__":
+			name = l.Value
+		case "queue":
+			queueID = l.Value
+		default:
+			return false
+		}
+
+		return true
+	})
+
+	return name, queueID, rest
+}
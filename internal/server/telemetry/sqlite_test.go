@@ -0,0 +1,26 @@
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func Test_splitSeriesLabels(t *testing.T) {
+	name, queueID, rest := splitSeriesLabels(Labels{
+		{Key: "__name__", Value: "messages_dropped_total"},
+		{Key: "policy", Value: "EVICTION_POLICY_DROP"},
+		{Key: "queue", Value: "q1"},
+		{Key: "team", Value: "a,b"},
+	})
+
+	td.Cmp(t, name, "messages_dropped_total")
+	td.Cmp(t, queueID, "q1")
+	td.Cmp(t, rest, Labels{{Key: "policy", Value: "EVICTION_POLICY_DROP"}, {Key: "team", Value: "a,b"}})
+
+	name, queueID, rest = splitSeriesLabels(Labels{{Key: "__name__", Value: "queues_exist"}})
+
+	td.Cmp(t, name, "queues_exist")
+	td.Cmp(t, queueID, "")
+	td.Cmp(t, rest, Labels{})
+}
@@ -1,7 +1,9 @@
 package telemetry
 
 import (
+	"encoding/json"
 	"errors"
+	"maps"
 	"slices"
 	"strings"
 	"time"
@@ -39,6 +41,26 @@ func (l Labels) Map() map[string]string {
 	return m
 }
 
+// MarshalJSON encodes labels as an object of keys and values.
+func (l Labels) MarshalJSON() ([]byte, error) { return json.Marshal(l.Map()) }
+
+// UnmarshalJSON decodes labels from an object of keys and values, sorted by keys.
+func (l *Labels) UnmarshalJSON(data []byte) error {
+	var m map[string]string
+
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+
+	*l = make(Labels, 0, len(m))
+
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		*l = append(*l, Label{Key: k, Value: m[k]})
+	}
+
+	return nil
+}
+
 func (l Labels) String() string {
 	var concatString string
 
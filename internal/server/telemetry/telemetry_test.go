@@ -1 +1,22 @@
 package telemetry
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestLabels_JSON(t *testing.T) {
+	labels := Labels{{Key: "queue", Value: "q1"}, {Key: "team", Value: `a,b="c"`}}
+
+	data, err := json.Marshal(labels)
+	td.CmpNoError(t, err)
+	td.Cmp(t, string(data), `{"queue":"q1","team":"a,b=\"c\""}`)
+
+	var decoded Labels
+
+	td.CmpNoError(t, json.Unmarshal(data, &decoded))
+	td.Cmp(t, decoded, labels)
+	td.CmpError(t, json.Unmarshal([]byte(`["queue"]`), &decoded))
+}
@@ -0,0 +1,62 @@
+// Package timeout provides per-route timeouts of HTTP routes and gRPC methods,
+// which override global listener timeouts, e.g. to give long-running
+// administrative operations more time than regular ones.
+package timeout
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Any is the key of the timeout applied to routes which have no own timeout.
+const Any = "*"
+
+// Timeouts holds timeouts by route.
+type Timeouts map[string]time.Duration
+
+// Parse parses comma separated route timeouts in form: route=duration,
+// e.g. "Send=2s,Receive=30s,*=10s" or "GET /api/v1/queue/{id}/search=1m".
+func Parse(spec string) (Timeouts, error) {
+	timeouts := make(Timeouts)
+
+	for pair := range strings.SplitSeq(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		i := strings.LastIndex(pair, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid route timeout %q, should be in form: route=duration", pair)
+		}
+
+		route := strings.TrimSpace(pair[:i])
+
+		d, parseErr := time.ParseDuration(strings.TrimSpace(pair[i+1:]))
+		if parseErr != nil {
+			return nil, fmt.Errorf("route %q timeout: %w", route, parseErr)
+		}
+
+		if d <= 0 {
+			return nil, fmt.Errorf("route %q timeout should be positive: %s", route, d)
+		}
+
+		timeouts[route] = d
+	}
+
+	return timeouts, nil
+}
+
+// Lookup returns the timeout of the first of given routes which has one,
+// or the Any timeout when none of them has.
+func (t Timeouts) Lookup(routes ...string) (time.Duration, bool) {
+	for _, r := range routes {
+		if d, ok := t[r]; ok {
+			return d, true
+		}
+	}
+
+	d, ok := t[Any]
+
+	return d, ok
+}
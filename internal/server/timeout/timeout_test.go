@@ -0,0 +1,81 @@
+package timeout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestParse(t *testing.T) {
+	type tcase struct {
+		spec    string
+		want    Timeouts
+		wantErr bool
+	}
+
+	tests := map[string]tcase{
+		"Empty": {
+			spec: "",
+			want: Timeouts{},
+		},
+		"Methods": {
+			spec: "Send=2s, Receive=30s,*=10s",
+			want: Timeouts{"Send": 2 * time.Second, "Receive": 30 * time.Second, "*": 10 * time.Second},
+		},
+		"Routes": {
+			spec: "GET /api/v1/queue/{id}/search=1m",
+			want: Timeouts{"GET /api/v1/queue/{id}/search": time.Minute},
+		},
+		"NoRoute": {
+			spec:    "=1s",
+			wantErr: true,
+		},
+		"NoDuration": {
+			spec:    "Send",
+			wantErr: true,
+		},
+		"InvalidDuration": {
+			spec:    "Send=soon",
+			wantErr: true,
+		},
+		"NotPositive": {
+			spec:    "Send=0s",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(tc.spec)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
+
+func TestTimeouts_Lookup(t *testing.T) {
+	timeouts := Timeouts{"/plainq.v1.PlainQService/Send": time.Second, "Receive": time.Minute}
+
+	d, ok := timeouts.Lookup("/plainq.v1.PlainQService/Send", "Send")
+	td.Cmp(t, ok, true)
+	td.Cmp(t, d, time.Second)
+
+	d, ok = timeouts.Lookup("/plainq.v1.PlainQService/Receive", "Receive")
+	td.Cmp(t, ok, true)
+	td.Cmp(t, d, time.Minute)
+
+	_, ok = timeouts.Lookup("/plainq.v1.PlainQService/Delete", "Delete")
+	td.Cmp(t, ok, false)
+
+	timeouts[Any] = time.Hour
+
+	d, ok = timeouts.Lookup("/plainq.v1.PlainQService/Delete", "Delete")
+	td.Cmp(t, ok, true)
+	td.Cmp(t, d, time.Hour)
+}
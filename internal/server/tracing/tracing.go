@@ -0,0 +1,100 @@
+// Package tracing exports OpenTelemetry spans of the server over OTLP, which show
+// where requests spend time between transports, the storage and the garbage collection.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// ServiceName is the name of the service in exported spans.
+const ServiceName = "plainq"
+
+// Config holds the configuration of the span export.
+type Config struct {
+	// Endpoint is the address of the OTLP gRPC collector, e.g. "localhost:4317".
+	Endpoint string
+
+	// Insecure disables TLS of the connection to the collector.
+	Insecure bool
+
+	// Headers are sent to the collector with each export, e.g. authentication.
+	Headers map[string]string
+
+	// SampleRatio is the fraction of traces started by the server which are
+	// sampled. Traces started by callers follow the decision of the caller.
+	SampleRatio float64
+}
+
+// Start sets the global tracer provider which exports spans to the collector,
+// and the propagation of the W3C trace context and baggage. The returned
+// function flushes pending spans and stops the export.
+func Start(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("invalid sample ratio %v, should be between 0 and 1", cfg.SampleRatio)
+	}
+
+	options := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(cfg.Endpoint),
+		otlptracegrpc.WithHeaders(cfg.Headers),
+	}
+
+	if cfg.Insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, exporterErr := otlptracegrpc.New(ctx, options...)
+	if exporterErr != nil {
+		return nil, fmt.Errorf("create exporter: %w", exporterErr)
+	}
+
+	res, resErr := resource.Merge(resource.Default(),
+		resource.NewSchemaless(semconv.ServiceName(ServiceName)),
+	)
+	if resErr != nil {
+		return nil, fmt.Errorf("create resource: %w", resErr)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// ParseHeaders parses comma separated headers in form: key=value,
+// e.g. "authorization=Bearer token,x-tenant=plainq".
+func ParseHeaders(spec string) (map[string]string, error) {
+	headers := make(map[string]string)
+
+	for pair := range strings.SplitSeq(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return nil, fmt.Errorf("invalid header %q, should be in form: key=value", pair)
+		}
+
+		headers[key] = strings.TrimSpace(value)
+	}
+
+	return headers, nil
+}
@@ -0,0 +1,39 @@
+package tracing
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestParseHeaders(t *testing.T) {
+	type tcase struct {
+		spec    string
+		want    map[string]string
+		wantErr bool
+	}
+
+	tests := map[string]tcase{
+		"Empty": {spec: "", want: map[string]string{}},
+		"Many": {
+			spec: "authorization=Bearer token, x-tenant=plainq",
+			want: map[string]string{"authorization": "Bearer token", "x-tenant": "plainq"},
+		},
+		"ValueWithEquals": {spec: "x-key=a=b", want: map[string]string{"x-key": "a=b"}},
+		"NoValue":         {spec: "authorization", wantErr: true},
+		"NoKey":           {spec: "=token", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseHeaders(tc.spec)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.Cmp(t, got, tc.want)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"github.com/plainq/servekit/errkit"
+	"github.com/plainq/servekit/idkit"
+)
+
+// transferQueue requests the transfer of the queue ownership and records it to the audit log.
+func (s *PlainQ) transferQueue(ctx context.Context, input *v1.TransferQueueRequest) (*v1.TransferQueueResponse, error) {
+	if err := validateQueueIDFromRequest(input); err != nil {
+		return nil, err
+	}
+
+	output, transferErr := s.storage.TransferQueue(ctx, input)
+	if transferErr != nil {
+		return nil, fmt.Errorf("transfer queue (id: %q): %w", input.GetQueueId(), transferErr)
+	}
+
+	t := output.GetTransfer()
+
+	s.audit.Info("Queue ownership transfer has been requested",
+		slog.String("transfer_id", t.GetTransferId()),
+		slog.String("queue_id", t.GetQueueId()),
+		slog.String("from_owner", t.GetFromOwner()),
+		slog.String("to_owner", t.GetToOwner()),
+		slog.String("requested_by", t.GetRequestedBy()),
+		slog.Time("expires_at", t.GetExpiresAt().AsTime()),
+	)
+
+	return output, nil
+}
+
+// acceptQueueTransfer confirms the transfer of the queue ownership and records it to the audit log.
+func (s *PlainQ) acceptQueueTransfer(ctx context.Context, input *v1.AcceptQueueTransferRequest) (*v1.AcceptQueueTransferResponse, error) {
+	if err := validateTransferID(input.GetTransferId()); err != nil {
+		return nil, err
+	}
+
+	output, acceptErr := s.storage.AcceptQueueTransfer(ctx, input)
+	if acceptErr != nil {
+		s.audit.Warn("Queue ownership transfer has not been accepted",
+			slog.String("transfer_id", input.GetTransferId()),
+			slog.String("to_owner", input.GetToOwner()),
+			slog.String("accepted_by", input.GetAcceptedBy()),
+			slog.String("error", acceptErr.Error()),
+		)
+
+		return nil, fmt.Errorf("accept queue transfer (id: %q): %w", input.GetTransferId(), acceptErr)
+	}
+
+	s.audit.Info("Queue ownership transfer has been accepted",
+		slog.String("transfer_id", input.GetTransferId()),
+		slog.String("queue_id", output.GetQueueId()),
+		slog.String("to_owner", input.GetToOwner()),
+		slog.String("accepted_by", input.GetAcceptedBy()),
+	)
+
+	return output, nil
+}
+
+// cancelQueueTransfer cancels the pending transfer of the queue ownership and records it to the audit log.
+func (s *PlainQ) cancelQueueTransfer(ctx context.Context, input *v1.CancelQueueTransferRequest) (*v1.CancelQueueTransferResponse, error) {
+	if err := validateTransferID(input.GetTransferId()); err != nil {
+		return nil, err
+	}
+
+	if input.GetCanceledBy() == "" {
+		return nil, fmt.Errorf("%w: canceled_by is empty", errkit.ErrInvalidArgument)
+	}
+
+	t, cancelErr := s.storage.CancelQueueTransfer(ctx, input)
+	if cancelErr != nil {
+		return nil, fmt.Errorf("cancel queue transfer (id: %q): %w", input.GetTransferId(), cancelErr)
+	}
+
+	s.audit.Info("Queue ownership transfer has been canceled",
+		slog.String("transfer_id", t.GetTransferId()),
+		slog.String("queue_id", t.GetQueueId()),
+		slog.String("from_owner", t.GetFromOwner()),
+		slog.String("to_owner", t.GetToOwner()),
+		slog.String("canceled_by", input.GetCanceledBy()),
+	)
+
+	return &v1.CancelQueueTransferResponse{}, nil
+}
+
+// validateTransferID validates given transfer identifier.
+func validateTransferID(transferID string) error {
+	if err := idkit.ValidateXID(strings.ToLower(transferID)); err != nil {
+		return fmt.Errorf("%w: invalid transfer id %q", errkit.ErrInvalidArgument, transferID)
+	}
+
+	return nil
+}
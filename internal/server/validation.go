@@ -1,9 +1,12 @@
 package server
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/plainq/plainq/internal/shared/pqerr"
+	"github.com/plainq/plainq/internal/shared/pqname"
+	"github.com/plainq/servekit/errkit"
 	"github.com/plainq/servekit/idkit"
 )
 
@@ -28,3 +31,12 @@ func validateQueueID(queueID string) error {
 
 	return nil
 }
+
+// validateQueueName validates given queue name.
+func validateQueueName(name string) error {
+	if err := pqname.ValidateQueueName(name); err != nil {
+		return fmt.Errorf("%w: %w", errkit.ErrInvalidArgument, err)
+	}
+
+	return nil
+}
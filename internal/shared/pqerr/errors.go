@@ -1,5 +1,7 @@
 package pqerr
 
+import "fmt"
+
 // Compilation time check for interface implementation.
 var _ error = Error("") //nolint: errcheck
 
@@ -26,6 +28,21 @@ const (
 	// cannot be identified (use ErrUnauthenticated instead for those errors).
 	ErrUnauthorized Error = "permission denied"
 
+	// ErrConflict indicates that the operation has been rejected because
+	// the resource has been modified concurrently by another operation.
+	ErrConflict Error = "conflict"
+
+	// ErrInterrupted indicates that the operation has been stopped before completion
+	// because the context has been canceled or its deadline has been exceeded.
+	ErrInterrupted Error = "operation interrupted"
+
+	// ErrMessageTooLarge indicates that the message body exceeds the size limit.
+	ErrMessageTooLarge Error = "message too large"
+
+	// ErrQuotaExceeded indicates that the queue has reached its quota
+	// on the number of messages or the total size of message bodies.
+	ErrQuotaExceeded Error = "quota exceeded"
+
 	// ErrUnavailable indicates that the service is currently unavailable.
 	// This kind of error is retryable. Caller should retry with a backoff.
 	ErrUnavailable Error = "temporarily unavailable"
@@ -46,3 +63,99 @@ const (
 type Error string
 
 func (e Error) Error() string { return string(e) }
+
+// ConflictError represents a typed ErrConflict which holds
+// the information about the version mismatch.
+type ConflictError struct {
+	// Resource describes the resource which has been modified.
+	Resource string
+
+	// Expected is the version specified by the caller.
+	Expected uint64
+
+	// Actual is the current version of the resource.
+	Actual uint64
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: %s version mismatch: expected %d, actual %d", ErrConflict, e.Resource, e.Expected, e.Actual)
+}
+
+func (*ConflictError) Is(target error) bool { return target == ErrConflict }
+
+// InterruptedError represents a typed ErrInterrupted which holds
+// the progress of the operation at the moment of interruption.
+type InterruptedError struct {
+	// Operation describes the interrupted operation.
+	Operation string
+
+	// Processed is the number of items processed before the interruption.
+	Processed int
+
+	// Total is the number of items the operation has been asked to process.
+	// Zero when the number of items is not known in advance.
+	Total int
+
+	// Err holds the context error which caused the interruption.
+	Err error
+}
+
+func (e *InterruptedError) Error() string {
+	if e.Total == 0 {
+		return fmt.Sprintf("%s: %s stopped after %d items: %s", ErrInterrupted, e.Operation, e.Processed, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %s stopped after %d of %d items: %s", ErrInterrupted, e.Operation, e.Processed, e.Total, e.Err)
+}
+
+func (*InterruptedError) Is(target error) bool { return target == ErrInterrupted }
+
+func (e *InterruptedError) Unwrap() error { return e.Err }
+
+// MessageTooLargeError represents a typed ErrMessageTooLarge which
+// holds the size of the message and the limit it exceeds.
+type MessageTooLargeError struct {
+	// Index is the position of the message in the batch.
+	Index int
+
+	// Size is the size of the message body in bytes.
+	Size uint64
+
+	// Limit is the maximum size of the message body in bytes.
+	Limit uint64
+
+	// Scope is the scope of the exceeded limit, e.g. "server" or "queue".
+	Scope string
+}
+
+func (e *MessageTooLargeError) Error() string {
+	return fmt.Sprintf("%s: message %d body is %d bytes, which exceeds the %s limit of %d bytes",
+		ErrMessageTooLarge, e.Index, e.Size, e.Scope, e.Limit,
+	)
+}
+
+func (*MessageTooLargeError) Is(target error) bool { return target == ErrMessageTooLarge }
+
+// QuotaExceededError represents a typed ErrQuotaExceeded which
+// holds the usage of the queue and the quota it exceeds.
+type QuotaExceededError struct {
+	// Quota is the name of the exceeded quota, e.g. "messages" or "bytes".
+	Quota string
+
+	// Usage is the current usage of the queue.
+	Usage uint64
+
+	// Requested is the amount the operation has been asked to add.
+	Requested uint64
+
+	// Limit is the quota of the queue.
+	Limit uint64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s: queue holds %d of %d %s, which leaves no room for %d more",
+		ErrQuotaExceeded, e.Usage, e.Limit, e.Quota, e.Requested,
+	)
+}
+
+func (*QuotaExceededError) Is(target error) bool { return target == ErrQuotaExceeded }
@@ -1,6 +1,10 @@
 package pqerr
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"testing"
+)
 
 func TestError_Error(t *testing.T) {
 	type tcase struct {
@@ -13,6 +17,7 @@ func TestError_Error(t *testing.T) {
 		"ErrAlreadyExists":    {err: ErrAlreadyExists, want: "already exist"},
 		"ErrInvalidBatchSize": {err: ErrInvalidBatchSize, want: "invalid batch size"},
 		"ErrNotFound":         {err: ErrNotFound, want: "not found"},
+		"ErrConflict":         {err: ErrConflict, want: "conflict"},
 		"Custom":              {err: Error("test error"), want: "test error"},
 	}
 
@@ -24,3 +29,55 @@ func TestError_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestConflictError(t *testing.T) {
+	var err error = &ConflictError{Resource: "queue 1", Expected: 1, Actual: 2}
+
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("errors.Is(%v, ErrConflict) = false, want true", err)
+	}
+
+	if want := "conflict: queue 1 version mismatch: expected 1, actual 2"; err.Error() != want {
+		t.Errorf("Error() = %v, want %v", err.Error(), want)
+	}
+}
+
+func TestInterruptedError(t *testing.T) {
+	var err error = &InterruptedError{Operation: "send", Processed: 3, Total: 10, Err: context.Canceled}
+
+	if !errors.Is(err, ErrInterrupted) {
+		t.Errorf("errors.Is(%v, ErrInterrupted) = false, want true", err)
+	}
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("errors.Is(%v, context.Canceled) = false, want true", err)
+	}
+
+	if want := "operation interrupted: send stopped after 3 of 10 items: context canceled"; err.Error() != want {
+		t.Errorf("Error() = %v, want %v", err.Error(), want)
+	}
+}
+
+func TestMessageTooLargeError(t *testing.T) {
+	var err error = &MessageTooLargeError{Index: 2, Size: 2048, Limit: 1024, Scope: "queue"}
+
+	if !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("errors.Is(%v, ErrMessageTooLarge) = false, want true", err)
+	}
+
+	if want := "message too large: message 2 body is 2048 bytes, which exceeds the queue limit of 1024 bytes"; err.Error() != want {
+		t.Errorf("Error() = %v, want %v", err.Error(), want)
+	}
+}
+
+func TestQuotaExceededError(t *testing.T) {
+	var err error = &QuotaExceededError{Quota: "messages", Usage: 99, Requested: 2, Limit: 100}
+
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("errors.Is(%v, ErrQuotaExceeded) = false, want true", err)
+	}
+
+	if want := "quota exceeded: queue holds 99 of 100 messages, which leaves no room for 2 more"; err.Error() != want {
+		t.Errorf("Error() = %v, want %v", err.Error(), want)
+	}
+}
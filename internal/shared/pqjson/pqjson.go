@@ -0,0 +1,69 @@
+// Package pqjson encodes and decodes protobuf messages to and from JSON
+// the same way across the HTTP API and the command line client.
+//
+// Enums are encoded as their names, timestamps as RFC 3339 strings and
+// field names in lowerCamelCase, as defined by the protobuf JSON mapping.
+package pqjson
+
+import (
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	marshalOptions = protojson.MarshalOptions{
+		UseEnumNumbers:  false,
+		EmitUnpopulated: false,
+		UseProtoNames:   false,
+	}
+
+	unmarshalOptions = protojson.UnmarshalOptions{
+		DiscardUnknown: false,
+	}
+)
+
+// Marshal returns JSON encoding of the message.
+func Marshal(msg proto.Message) ([]byte, error) {
+	return marshalOptions.Marshal(msg)
+}
+
+// Unmarshal parses JSON encoded data into the message.
+func Unmarshal(data []byte, msg proto.Message) error {
+	return unmarshalOptions.Unmarshal(data, msg)
+}
+
+// Options returns options of the encoding, e.g. for marshalers which encode messages the same way.
+func Options() (protojson.MarshalOptions, protojson.UnmarshalOptions) {
+	return marshalOptions, unmarshalOptions
+}
+
+// Encode writes JSON encoding of the message followed by a newline to w.
+func Encode(w io.Writer, msg proto.Message) error {
+	data, marshalErr := Marshal(msg)
+	if marshalErr != nil {
+		return fmt.Errorf("marshal message: %w", marshalErr)
+	}
+
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+
+	return nil
+}
+
+// Decode reads all data from r and parses it into the message.
+func Decode(r io.Reader, msg proto.Message) error {
+	data, readErr := io.ReadAll(r)
+	if readErr != nil {
+		return fmt.Errorf("read message: %w", readErr)
+	}
+
+	if err := Unmarshal(data, msg); err != nil {
+		return fmt.Errorf("unmarshal message: %w", err)
+	}
+
+	return nil
+}
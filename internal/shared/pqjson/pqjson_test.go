@@ -0,0 +1,80 @@
+package pqjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/td"
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestEncode(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Encode(&buf, &v1.DescribeQueueResponse{
+		QueueId:        "cn1k9ttd4tktcpbtfvv0",
+		CreatedAt:      timestamppb.New(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)),
+		EvictionPolicy: v1.EvictionPolicy_EVICTION_POLICY_DEAD_LETTER,
+	})
+	td.CmpNoError(t, err)
+	td.Cmp(t, buf.String(), td.HasSuffix("\n"))
+
+	var got map[string]any
+
+	td.CmpNoError(t, json.Unmarshal(buf.Bytes(), &got))
+	td.Cmp(t, got, td.JSON(`{
+		"queueId": "cn1k9ttd4tktcpbtfvv0",
+		"createdAt": "2024-03-01T12:00:00Z",
+		"evictionPolicy": "EVICTION_POLICY_DEAD_LETTER"
+	}`))
+}
+
+func TestDecode(t *testing.T) {
+	type tcase struct {
+		input   string
+		want    *v1.CreateQueueRequest
+		wantErr bool
+	}
+
+	tests := map[string]tcase{
+		"CamelCase": {
+			input: `{"queueName":"orders","evictionPolicy":"EVICTION_POLICY_DROP"}`,
+			want:  &v1.CreateQueueRequest{QueueName: "orders", EvictionPolicy: v1.EvictionPolicy_EVICTION_POLICY_DROP},
+		},
+
+		"ProtoNames": {
+			input: `{"queue_name":"orders","max_receive_attempts":3}`,
+			want:  &v1.CreateQueueRequest{QueueName: "orders", MaxReceiveAttempts: 3},
+		},
+
+		"UnknownField": {
+			input:   `{"queueName":"orders","unknown":true}`,
+			wantErr: true,
+		},
+
+		"Malformed": {
+			input:   `{"queueName":`,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got v1.CreateQueueRequest
+
+			err := Decode(strings.NewReader(tc.input), &got)
+			if tc.wantErr {
+				td.CmpError(t, err)
+				return
+			}
+
+			td.CmpNoError(t, err)
+			td.CmpTrue(t, proto.Equal(&got, tc.want))
+		})
+	}
+}
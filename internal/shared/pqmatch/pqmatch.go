@@ -0,0 +1,151 @@
+// Package pqmatch scores how well names of PlainQ entities match the search
+// query typed by a user, e.g. in the command palette.
+package pqmatch
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	v1 "github.com/plainq/plainq/internal/server/schema/v1"
+)
+
+// Scores of the match kinds, the better kind has the higher score.
+const (
+	ScoreExact       uint32 = 100
+	ScorePrefix      uint32 = 80
+	ScoreWordPrefix  uint32 = 60
+	ScoreContains    uint32 = 40
+	ScoreSubsequence uint32 = 20
+)
+
+// Score returns how well the candidate matches the query ignoring case, or 0
+// if it doesn't match. The query matches when it equals the candidate, is its
+// prefix, a prefix of one of its words, its substring, or when characters
+// of the query appear in the candidate in the same order. Subsequence matches
+// score lower the more gaps they have, e.g. "ordq" matches "orders-dlq".
+func Score(query, candidate string) uint32 {
+	if query == "" || candidate == "" {
+		return 0
+	}
+
+	query, candidate = strings.ToLower(query), strings.ToLower(candidate)
+
+	switch {
+	case candidate == query:
+		return ScoreExact
+
+	case strings.HasPrefix(candidate, query):
+		return ScorePrefix
+	}
+
+	if i := strings.Index(candidate, query); i >= 0 {
+		for ; i >= 0; i = nextIndex(candidate, query, i) {
+			if r, _ := utf8.DecodeLastRuneInString(candidate[:i]); isSeparator(r) {
+				return ScoreWordPrefix
+			}
+		}
+
+		return ScoreContains
+	}
+
+	gaps, ok := subsequenceGaps(query, candidate)
+	if !ok {
+		return 0
+	}
+
+	return max(ScoreSubsequence-gaps, 1)
+}
+
+// nextIndex returns the index of the query in the candidate after the one at i, or -1.
+func nextIndex(candidate, query string, i int) int {
+	_, size := utf8.DecodeRuneInString(candidate[i:])
+
+	j := strings.Index(candidate[i+size:], query)
+	if j < 0 {
+		return -1
+	}
+
+	return i + size + j
+}
+
+// subsequenceGaps reports whether runes of the query appear in the candidate
+// in the same order, and the number of gaps between them.
+func subsequenceGaps(query, candidate string) (uint32, bool) {
+	var (
+		gaps    uint32
+		matched = -1
+		pos     = 0
+	)
+
+	for _, q := range query {
+		i := strings.IndexRune(candidate[pos:], q)
+		if i < 0 {
+			return 0, false
+		}
+
+		if matched >= 0 && pos+i != matched {
+			gaps++
+		}
+
+		_, size := utf8.DecodeRuneInString(candidate[pos+i:])
+		pos += i + size
+		matched = pos
+	}
+
+	return gaps, true
+}
+
+// isSeparator reports whether r separates words of entity names,
+// e.g. "orders-dlq", "billing.events" or "admin@plainq.local".
+func isSeparator(r rune) bool {
+	switch r {
+	case '-', '_', '.', ' ', '@', ':', '/', '=':
+		return true
+
+	default:
+		return false
+	}
+}
+
+// LikePattern returns the SQL LIKE pattern which matches values the query may
+// match with Score, to narrow down candidates before scoring them. The pattern
+// should be used with the escape '\' clause.
+func LikePattern(query string) string {
+	var b strings.Builder
+
+	b.Grow(len(query)*2 + 1)
+	b.WriteByte('%')
+
+	for _, r := range query {
+		if r == '%' || r == '_' || r == '\\' {
+			b.WriteByte('\\')
+		}
+
+		b.WriteRune(r)
+		b.WriteByte('%')
+	}
+
+	return b.String()
+}
+
+// Sort sorts search results by score, the best first. Results with
+// the same score keep their order, so equally good audit events stay
+// ordered from the newest, and otherwise are ordered by kind and title.
+func Sort(results []*v1.SearchResult) {
+	slices.SortStableFunc(results, func(a, b *v1.SearchResult) int {
+		if c := cmp.Compare(b.GetScore(), a.GetScore()); c != 0 {
+			return c
+		}
+
+		if a.GetKind() == v1.EntityKind_ENTITY_KIND_AUDIT_EVENT && b.GetKind() == v1.EntityKind_ENTITY_KIND_AUDIT_EVENT {
+			return 0
+		}
+
+		return cmp.Or(
+			cmp.Compare(a.GetKind(), b.GetKind()),
+			cmp.Compare(a.GetTitle(), b.GetTitle()),
+		)
+	})
+}
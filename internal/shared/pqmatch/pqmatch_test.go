@@ -0,0 +1,51 @@
+package pqmatch
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestScore(t *testing.T) {
+	type tcase struct {
+		query     string
+		candidate string
+		want      uint32
+	}
+
+	tests := map[string]tcase{
+		"Empty":          {query: "", candidate: "orders", want: 0},
+		"Exact":          {query: "Orders", candidate: "orders", want: ScoreExact},
+		"Prefix":         {query: "ord", candidate: "orders-dlq", want: ScorePrefix},
+		"WordPrefix":     {query: "dlq", candidate: "orders-dlq", want: ScoreWordPrefix},
+		"WordPrefixLast": {query: "ev", candidate: "prevents.events", want: ScoreWordPrefix},
+		"Email":          {query: "plainq", candidate: "admin@plainq.local", want: ScoreWordPrefix},
+		"Contains":       {query: "der", candidate: "orders", want: ScoreContains},
+		"Subsequence":    {query: "odlq", candidate: "orders-dlq", want: ScoreSubsequence - 2},
+		"ScatteredMatch": {query: "osdq", candidate: "orders-dlq", want: ScoreSubsequence - 3},
+		"NoMatch":        {query: "qdl", candidate: "orders-dlq", want: 0},
+		"Unicode":        {query: "очер", candidate: "очередь", want: ScorePrefix},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, Score(tc.query, tc.candidate), tc.want)
+		})
+	}
+}
+
+func TestLikePattern(t *testing.T) {
+	tests := map[string]struct {
+		query string
+		want  string
+	}{
+		"Plain":   {query: "ord", want: "%o%r%d%"},
+		"Escaped": {query: "a_%", want: `%a%\_%\%%`},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.Cmp(t, LikePattern(tc.query), tc.want)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Package pqname holds validation rules for names of PlainQ entities.
+package pqname
+
+import (
+	"fmt"
+
+	"github.com/plainq/plainq/internal/shared/pqerr"
+)
+
+const (
+	// MaxQueueNameLen represents the maximum length of the queue name.
+	MaxQueueNameLen = 80
+
+	// MaxServiceAccountNameLen represents the maximum length of the service account name.
+	MaxServiceAccountNameLen = 64
+)
+
+// ValidateQueueName validates given queue name. The name should be from 1 to
+// MaxQueueNameLen characters long and may contain only ASCII letters, digits,
+// hyphens, underscores and dots.
+func ValidateQueueName(name string) error {
+	return validateName("queue", name, MaxQueueNameLen)
+}
+
+// ValidateServiceAccountName validates given service account name. The name should
+// be from 1 to MaxServiceAccountNameLen characters long and may contain only ASCII
+// letters, digits, hyphens, underscores and dots, so it never looks like an email.
+func ValidateServiceAccountName(name string) error {
+	return validateName("service account", name, MaxServiceAccountNameLen)
+}
+
+// validateName validates the name of the entity of the kind.
+func validateName(kind, name string, maxLen int) error {
+	if name == "" {
+		return fmt.Errorf("%w: %s name is empty", pqerr.ErrInvalidInput, kind)
+	}
+
+	if len(name) > maxLen {
+		return fmt.Errorf("%w: %s name is longer than %d characters", pqerr.ErrInvalidInput, kind, maxLen)
+	}
+
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			continue
+
+		default:
+			return fmt.Errorf("%w: %s name contains invalid character %q at position %d", pqerr.ErrInvalidInput, kind, r, i)
+		}
+	}
+
+	return nil
+}
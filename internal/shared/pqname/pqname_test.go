@@ -0,0 +1,52 @@
+package pqname
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/plainq/plainq/internal/shared/pqerr"
+)
+
+func TestValidateQueueName(t *testing.T) {
+	type tcase struct {
+		name    string
+		wantErr error
+	}
+
+	tests := map[string]tcase{
+		"OK":           {name: "orders.eu-west_1"},
+		"Empty":        {name: "", wantErr: pqerr.ErrInvalidInput},
+		"TooLong":      {name: strings.Repeat("a", MaxQueueNameLen+1), wantErr: pqerr.ErrInvalidInput},
+		"MaxLen":       {name: strings.Repeat("a", MaxQueueNameLen)},
+		"Space":        {name: "my queue", wantErr: pqerr.ErrInvalidInput},
+		"NonASCII":     {name: "очередь", wantErr: pqerr.ErrInvalidInput},
+		"SQLInjection": {name: "q'; drop table users;--", wantErr: pqerr.ErrInvalidInput},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.CmpErrorIs(t, ValidateQueueName(tc.name), tc.wantErr)
+		})
+	}
+}
+
+func TestValidateServiceAccountName(t *testing.T) {
+	type tcase struct {
+		name    string
+		wantErr error
+	}
+
+	tests := map[string]tcase{
+		"OK":      {name: "orders-producer"},
+		"Empty":   {name: "", wantErr: pqerr.ErrInvalidInput},
+		"TooLong": {name: strings.Repeat("a", MaxServiceAccountNameLen+1), wantErr: pqerr.ErrInvalidInput},
+		"Email":   {name: "admin@plainq.local", wantErr: pqerr.ErrInvalidInput},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td.CmpErrorIs(t, ValidateServiceAccountName(tc.name), tc.wantErr)
+		})
+	}
+}